@@ -73,12 +73,46 @@ const (
 	// workspaceCpuBurstLimit denotes the cpu burst limit of a workspace
 	WorkspaceCpuBurstLimitAnnotation = "gitpod.io/cpuBurstLimit"
 
+	// WorkspaceCpuBurstClassAnnotation denotes the class of workspaces whose cpu limits a workspace shares
+	WorkspaceCpuBurstClassAnnotation = "gitpod.io/cpuBurstClass"
+
+	// WorkspaceCpuBurstBudgetAnnotation denotes how long a workspace may run at its cpu burst limit before it is throttled
+	WorkspaceCpuBurstBudgetAnnotation = "gitpod.io/cpuBurstBudget"
+
+	// WorkspaceIOReadBandwidthLimitAnnotation denotes the read bandwidth per second ws-daemon limits a workspace to
+	WorkspaceIOReadBandwidthLimitAnnotation = "gitpod.io/ioReadBandwidthLimit"
+
+	// WorkspaceIOWriteBandwidthLimitAnnotation denotes the write bandwidth per second ws-daemon limits a workspace to
+	WorkspaceIOWriteBandwidthLimitAnnotation = "gitpod.io/ioWriteBandwidthLimit"
+
+	// WorkspaceIOReadIOPSLimitAnnotation denotes the read operations per second ws-daemon limits a workspace to
+	WorkspaceIOReadIOPSLimitAnnotation = "gitpod.io/ioReadIOPSLimit"
+
+	// WorkspaceIOWriteIOPSLimitAnnotation denotes the write operations per second ws-daemon limits a workspace to
+	WorkspaceIOWriteIOPSLimitAnnotation = "gitpod.io/ioWriteIOPSLimit"
+
 	// workspaceNetConnLimit denotes the maximum number of connections a workspace can make per minute
 	WorkspaceNetConnLimitAnnotation = "gitpod.io/netConnLimitPerMinute"
 
+	// WorkspaceEgressBandwidthLimitAnnotation denotes the egress bandwidth per second ws-daemon shapes a workspace's traffic to
+	WorkspaceEgressBandwidthLimitAnnotation = "gitpod.io/egressBandwidthLimit"
+
+	// WorkspaceMemoryHighAnnotation denotes the memory.high throttle limit ws-daemon sets on a workspace
+	WorkspaceMemoryHighAnnotation = "gitpod.io/memoryHigh"
+
+	// WorkspaceSwapMaxAnnotation denotes the memory.swap.max limit ws-daemon sets on a workspace
+	WorkspaceSwapMaxAnnotation = "gitpod.io/swapMax"
+
 	// workspacePressureStallInfo indicates if pressure stall information should be retrieved for the workspace
 	WorkspacePressureStallInfoAnnotation = "gitpod.io/psi"
 
+	// DebugWorkspaceAnnotation marks a debug workspace. On start requests it asks for a debug workspace, on workspaces
+	// and their pods it tells ws-proxy to mark the session as such.
+	DebugWorkspaceAnnotation = "gitpod.io/debugWorkspace"
+
+	// RestoreSnapshotAnnotation on start requests names the Snapshot the content of the new workspace is restored from
+	RestoreSnapshotAnnotation = "gitpod.io/restoreSnapshot"
+
 	// ImageNameAnnotation indicates the original format of the main image of the pod
 	ImageNameAnnotation = "gitpod.io/image_name"
 )
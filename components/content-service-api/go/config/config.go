@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
+	"github.com/gitpod-io/gitpod/common-go/util"
 )
 
 // StorageConfig configures the remote storage we use
@@ -27,6 +28,18 @@ type StorageConfig struct {
 	// S3Config configures the S3 remote storage
 	S3Config *S3Config `json:"s3,omitempty"`
 
+	// AzureConfig configures the Azure Blob Storage remote storage
+	AzureConfig AzureConfig `json:"azure,omitempty"`
+
+	// Transfer configures the multipart up- and download of backups and prebuild archives
+	Transfer TransferConfig `json:"transfer,omitempty"`
+
+	// SignedURLs configures the lifetime of presigned URLs
+	SignedURLs SignedURLConfig `json:"signedURLs,omitempty"`
+
+	// SlowOperationThreshold is the duration after which up- and downloads are logged as slow. Defaults to one minute.
+	SlowOperationThreshold util.Duration `json:"slowOperationThreshold,omitempty"`
+
 	BlobQuota int64 `json:"blobQuota"`
 }
 
@@ -58,6 +71,9 @@ const (
 	// exist in the environment. See https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/config#LoadDefaultConfig for more details.
 	S3Storage RemoteStorageType = "s3"
 
+	// AzureStorage stores workspaces in Azure Blob Storage containers
+	AzureStorage RemoteStorageType = "azure"
+
 	// NullStorage does not synchronize workspaces at all
 	NullStorage RemoteStorageType = ""
 )
@@ -87,6 +103,32 @@ func (c *StorageConfig) GetStage() Stage {
 	return c.Stage
 }
 
+// TransferConfig configures multipart transfers. Zero values fall back to the defaults.
+type TransferConfig struct {
+	// PartSizeMiB is the size of the parts objects are transferred in
+	PartSizeMiB int64 `json:"partSizeMiB,omitempty"`
+	// Concurrency is the number of parts transferred in parallel
+	Concurrency int `json:"concurrency,omitempty"`
+	// Retries is how often the transfer of a part is resumed before the whole transfer fails
+	Retries int `json:"retries,omitempty"`
+	// BufferDownloads downloads large objects in parallel parts, which are buffered in TmpDir before they're read.
+	// Otherwise objects are streamed.
+	BufferDownloads bool `json:"bufferDownloads,omitempty"`
+	// TmpDir is the directory downloads are buffered in. Defaults to the temp directory of the system.
+	TmpDir string `json:"tmpDir,omitempty"`
+}
+
+// SignedURLConfig configures the lifetime of presigned URLs. Zero values fall back to the defaults.
+type SignedURLConfig struct {
+	// DownloadTTL is how long presigned download URLs remain valid. Defaults to one hour.
+	DownloadTTL util.Duration `json:"downloadTTL,omitempty"`
+	// UploadTTL is how long presigned upload URLs remain valid. Defaults to 30 minutes.
+	UploadTTL util.Duration `json:"uploadTTL,omitempty"`
+	// MaxTTL bounds the lifetime clients can ask for when refreshing a URL. Defaults to seven days,
+	// the longest lifetime S3 and GCloud V4 signatures support.
+	MaxTTL util.Duration `json:"maxTTL,omitempty"`
+}
+
 // GCPConfig controls the access to GCloud resources/buckets
 type GCPConfig struct {
 	CredentialsFile string `json:"credentialsFile"`
@@ -116,6 +158,20 @@ type S3Config struct {
 	CredentialsFile string `json:"credentialsFile"`
 }
 
+// AzureConfig configures the Azure Blob Storage remote storage backend
+type AzureConfig struct {
+	AccountName    string `json:"accountName"`
+	AccountKey     string `json:"accountKey"`
+	AccountKeyFile string `json:"accountKeyFile"`
+
+	// Endpoint is the blob service endpoint of the account. Defaults to https://<accountName>.blob.core.windows.net/,
+	// which needs to be changed for national clouds.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// ContainerName is the container all content is stored in. If empty, every user gets their own container.
+	ContainerName string `json:"container,omitempty"`
+}
+
 type PProf struct {
 	Addr string `json:"address"`
 }
@@ -125,9 +181,90 @@ type UsageReportConfig struct {
 	BucketName string `json:"bucketName"`
 }
 
+// RetentionConfig configures the retention policies the content service enforces on the remote storage.
+// Zero values disable the respective policy. Chunks of deduplicated backups which their backup no longer
+// references are always deleted.
+type RetentionConfig struct {
+	// Enabled starts the reconciler which enforces the policies
+	Enabled bool `json:"enabled"`
+
+	// DryRun makes the reconciler only log and count what it would do, without changing any object
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Interval is the time between two reconciliations. Defaults to 24 hours.
+	Interval util.Duration `json:"interval,omitempty"`
+
+	// KeepSnapshots is the number of most recent snapshots kept per workspace. Snapshots are the backups
+	// taken on request and of prebuilds, the regular backup of a workspace is replaced by every backup.
+	// Older snapshots which the database still references, e.g. of prebuilds or shared snapshots, are kept as well.
+	// Finding these references requires access to the database, configured like for other components using
+	// the DB_HOST, DB_PORT, DB_USERNAME, DB_PASSWORD and DB_CA_CERT environment variables.
+	KeepSnapshots int `json:"keepSnapshots,omitempty"`
+
+	// HeadlessLogsMaxAge is how long the logs of prebuilds and other headless workspaces are kept
+	HeadlessLogsMaxAge util.Duration `json:"headlessLogsMaxAge,omitempty"`
+
+	// BuildLogsMaxAge is how long the logs of image builds are kept
+	BuildLogsMaxAge util.Duration `json:"buildLogsMaxAge,omitempty"`
+
+	// ColdStorageAfter is how long backups, the chunks of deduplicated backups and snapshots remain unmodified before they
+	// are moved to ColdStorageClass
+	ColdStorageAfter util.Duration `json:"coldStorageAfter,omitempty"`
+
+	// ColdStorageClass is the storage class backups and snapshots are archived in, e.g. COLDLINE on GCloud, GLACIER_IR on S3 or Cold on Azure.
+	// Restoring a workspace reads its backup directly, hence the class must not require objects to be restored first.
+	ColdStorageClass string `json:"coldStorageClass,omitempty"`
+}
+
+// EncryptionProvider manages the keys workspace content is encrypted with
+type EncryptionProvider string
+
+const (
+	// EncryptionProviderNone leaves workspace content unencrypted
+	EncryptionProviderNone EncryptionProvider = ""
+
+	// EncryptionProviderLocal reads the keys of organizations from disk
+	EncryptionProviderLocal EncryptionProvider = "local"
+
+	// EncryptionProviderGCPKMS uses keys held in Google Cloud KMS
+	EncryptionProviderGCPKMS EncryptionProvider = "gcpKms"
+)
+
+// EncryptionConfig configures the envelope encryption of workspace backups and snapshots with per-organization keys.
+// Every archive is encrypted with its own data key, which is stored in the archive wrapped with the key of the organization.
+type EncryptionConfig struct {
+	// Provider manages the keys of organizations. Content of organizations without a key remains unencrypted.
+	Provider EncryptionProvider `json:"provider,omitempty"`
+
+	// Local configures the local key provider
+	Local *LocalKeysConfig `json:"local,omitempty"`
+
+	// GCPKMS configures the Google Cloud KMS key provider
+	GCPKMS *GCPKMSConfig `json:"gcpKms,omitempty"`
+}
+
+// LocalKeysConfig configures keys which are read from disk
+type LocalKeysConfig struct {
+	// KeysDir contains a directory per organization ID which holds the keys of the organization, one base64-encoded
+	// 256 bit key per <keyID>.key file. New content is encrypted with the key whose ID sorts last, hence keys are
+	// rotated by adding a key. Keys must be kept for as long as content encrypted with them exists.
+	KeysDir string `json:"keysDir"`
+}
+
+// GCPKMSConfig configures the Google Cloud KMS key provider
+type GCPKMSConfig struct {
+	CredentialsFile string `json:"credentialsFile,omitempty"`
+
+	// Keys maps organization IDs to the resource name of their key, i.e.
+	// projects/<project>/locations/<location>/keyRings/<keyRing>/cryptoKeys/<key>.
+	// Keys are rotated by adding key versions in KMS.
+	Keys map[string]string `json:"keys"`
+}
+
 type ServiceConfig struct {
-	Service baseserver.ServerConfiguration `json:"service"`
-	Storage StorageConfig                  `json:"storage"`
+	Service   baseserver.ServerConfiguration `json:"service"`
+	Storage   StorageConfig                  `json:"storage"`
+	Retention RetentionConfig                `json:"retention,omitempty"`
 	// Deprecated
 	_ UsageReportConfig `json:"usageReport"`
 }
@@ -252,6 +252,194 @@ func (x *ListLogsResponse) GetTaskId() []string {
 	return nil
 }
 
+type BuildLogUploadURLRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BuildId string `protobuf:"bytes,1,opt,name=build_id,json=buildId,proto3" json:"build_id,omitempty"`
+}
+
+func (x *BuildLogUploadURLRequest) Reset() {
+	*x = BuildLogUploadURLRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_headless_log_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuildLogUploadURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildLogUploadURLRequest) ProtoMessage() {}
+
+func (x *BuildLogUploadURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headless_log_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildLogUploadURLRequest.ProtoReflect.Descriptor instead.
+func (*BuildLogUploadURLRequest) Descriptor() ([]byte, []int) {
+	return file_headless_log_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BuildLogUploadURLRequest) GetBuildId() string {
+	if x != nil {
+		return x.BuildId
+	}
+	return ""
+}
+
+type BuildLogUploadURLResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+func (x *BuildLogUploadURLResponse) Reset() {
+	*x = BuildLogUploadURLResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_headless_log_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuildLogUploadURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildLogUploadURLResponse) ProtoMessage() {}
+
+func (x *BuildLogUploadURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headless_log_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildLogUploadURLResponse.ProtoReflect.Descriptor instead.
+func (*BuildLogUploadURLResponse) Descriptor() ([]byte, []int) {
+	return file_headless_log_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BuildLogUploadURLResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type BuildLogDownloadURLRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BuildId string `protobuf:"bytes,1,opt,name=build_id,json=buildId,proto3" json:"build_id,omitempty"`
+}
+
+func (x *BuildLogDownloadURLRequest) Reset() {
+	*x = BuildLogDownloadURLRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_headless_log_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuildLogDownloadURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildLogDownloadURLRequest) ProtoMessage() {}
+
+func (x *BuildLogDownloadURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headless_log_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildLogDownloadURLRequest.ProtoReflect.Descriptor instead.
+func (*BuildLogDownloadURLRequest) Descriptor() ([]byte, []int) {
+	return file_headless_log_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BuildLogDownloadURLRequest) GetBuildId() string {
+	if x != nil {
+		return x.BuildId
+	}
+	return ""
+}
+
+type BuildLogDownloadURLResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+func (x *BuildLogDownloadURLResponse) Reset() {
+	*x = BuildLogDownloadURLResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_headless_log_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuildLogDownloadURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildLogDownloadURLResponse) ProtoMessage() {}
+
+func (x *BuildLogDownloadURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headless_log_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildLogDownloadURLResponse.ProtoReflect.Descriptor instead.
+func (*BuildLogDownloadURLResponse) Descriptor() ([]byte, []int) {
+	return file_headless_log_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BuildLogDownloadURLResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
 var File_headless_log_proto protoreflect.FileDescriptor
 
 var file_headless_log_proto_rawDesc = []byte{
@@ -279,7 +467,20 @@ var file_headless_log_proto_rawDesc = []byte{
 	0x63, 0x65, 0x49, 0x64, 0x22, 0x2b, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x6f, 0x67, 0x73,
 	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b,
 	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x73, 0x6b, 0x49,
-	0x64, 0x32, 0xc8, 0x01, 0x0a, 0x12, 0x48, 0x65, 0x61, 0x64, 0x6c, 0x65, 0x73, 0x73, 0x4c, 0x6f,
+	0x64, 0x22, 0x35, 0x0a, 0x18, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x55, 0x70, 0x6c,
+	0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a,
+	0x08, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x49, 0x64, 0x22, 0x2d, 0x0a, 0x19, 0x42, 0x75, 0x69, 0x6c,
+	0x64, 0x4c, 0x6f, 0x67, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x22, 0x37, 0x0a, 0x1a, 0x42, 0x75, 0x69, 0x6c, 0x64,
+	0x4c, 0x6f, 0x67, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x49, 0x64,
+	0x22, 0x2f, 0x0a, 0x1b, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x44, 0x6f, 0x77, 0x6e,
+	0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72,
+	0x6c, 0x32, 0xa6, 0x03, 0x0a, 0x12, 0x48, 0x65, 0x61, 0x64, 0x6c, 0x65, 0x73, 0x73, 0x4c, 0x6f,
 	0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x61, 0x0a, 0x0e, 0x4c, 0x6f, 0x67, 0x44,
 	0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x12, 0x25, 0x2e, 0x63, 0x6f, 0x6e,
 	0x74, 0x65, 0x6e, 0x74, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x4c, 0x6f, 0x67, 0x44,
@@ -291,11 +492,25 @@ var file_headless_log_proto_rawDesc = []byte{
 	0x74, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x6f, 0x67,
 	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x65,
 	0x6e, 0x74, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x6f,
-	0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x31, 0x5a, 0x2f,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x69, 0x74, 0x70, 0x6f,
-	0x64, 0x2d, 0x69, 0x6f, 0x2f, 0x67, 0x69, 0x74, 0x70, 0x6f, 0x64, 0x2f, 0x63, 0x6f, 0x6e, 0x74,
-	0x65, 0x6e, 0x74, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x6a, 0x0a, 0x11,
+	0x42, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52,
+	0x4c, 0x12, 0x28, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x55, 0x70, 0x6c, 0x6f, 0x61,
+	0x64, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x75, 0x69,
+	0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x70, 0x0a, 0x13, 0x42, 0x75, 0x69, 0x6c,
+	0x64, 0x4c, 0x6f, 0x67, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c, 0x12,
+	0x2a, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2b, 0x2e, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x75, 0x69,
+	0x6c, 0x64, 0x4c, 0x6f, 0x67, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x55, 0x52, 0x4c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x69, 0x74, 0x70, 0x6f, 0x64, 0x2d,
+	0x69, 0x6f, 0x2f, 0x67, 0x69, 0x74, 0x70, 0x6f, 0x64, 0x2f, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -310,20 +525,28 @@ func file_headless_log_proto_rawDescGZIP() []byte {
 	return file_headless_log_proto_rawDescData
 }
 
-var file_headless_log_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_headless_log_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_headless_log_proto_goTypes = []interface{}{
-	(*LogDownloadURLRequest)(nil),  // 0: contentservice.LogDownloadURLRequest
-	(*LogDownloadURLResponse)(nil), // 1: contentservice.LogDownloadURLResponse
-	(*ListLogsRequest)(nil),        // 2: contentservice.ListLogsRequest
-	(*ListLogsResponse)(nil),       // 3: contentservice.ListLogsResponse
+	(*LogDownloadURLRequest)(nil),       // 0: contentservice.LogDownloadURLRequest
+	(*LogDownloadURLResponse)(nil),      // 1: contentservice.LogDownloadURLResponse
+	(*ListLogsRequest)(nil),             // 2: contentservice.ListLogsRequest
+	(*ListLogsResponse)(nil),            // 3: contentservice.ListLogsResponse
+	(*BuildLogUploadURLRequest)(nil),    // 4: contentservice.BuildLogUploadURLRequest
+	(*BuildLogUploadURLResponse)(nil),   // 5: contentservice.BuildLogUploadURLResponse
+	(*BuildLogDownloadURLRequest)(nil),  // 6: contentservice.BuildLogDownloadURLRequest
+	(*BuildLogDownloadURLResponse)(nil), // 7: contentservice.BuildLogDownloadURLResponse
 }
 var file_headless_log_proto_depIdxs = []int32{
 	0, // 0: contentservice.HeadlessLogService.LogDownloadURL:input_type -> contentservice.LogDownloadURLRequest
 	2, // 1: contentservice.HeadlessLogService.ListLogs:input_type -> contentservice.ListLogsRequest
-	1, // 2: contentservice.HeadlessLogService.LogDownloadURL:output_type -> contentservice.LogDownloadURLResponse
-	3, // 3: contentservice.HeadlessLogService.ListLogs:output_type -> contentservice.ListLogsResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
+	4, // 2: contentservice.HeadlessLogService.BuildLogUploadURL:input_type -> contentservice.BuildLogUploadURLRequest
+	6, // 3: contentservice.HeadlessLogService.BuildLogDownloadURL:input_type -> contentservice.BuildLogDownloadURLRequest
+	1, // 4: contentservice.HeadlessLogService.LogDownloadURL:output_type -> contentservice.LogDownloadURLResponse
+	3, // 5: contentservice.HeadlessLogService.ListLogs:output_type -> contentservice.ListLogsResponse
+	5, // 6: contentservice.HeadlessLogService.BuildLogUploadURL:output_type -> contentservice.BuildLogUploadURLResponse
+	7, // 7: contentservice.HeadlessLogService.BuildLogDownloadURL:output_type -> contentservice.BuildLogDownloadURLResponse
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -383,6 +606,54 @@ func file_headless_log_proto_init() {
 				return nil
 			}
 		}
+		file_headless_log_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuildLogUploadURLRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_headless_log_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuildLogUploadURLResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_headless_log_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuildLogDownloadURLRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_headless_log_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuildLogDownloadURLResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -390,7 +661,7 @@ func file_headless_log_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_headless_log_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
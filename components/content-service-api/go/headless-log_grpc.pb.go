@@ -30,6 +30,10 @@ type HeadlessLogServiceClient interface {
 	LogDownloadURL(ctx context.Context, in *LogDownloadURLRequest, opts ...grpc.CallOption) (*LogDownloadURLResponse, error)
 	// ListLogs returns a list of taskIds for the specified workspace instance
 	ListLogs(ctx context.Context, in *ListLogsRequest, opts ...grpc.CallOption) (*ListLogsResponse, error)
+	// BuildLogUploadURL provides a URL to which the log of an image build can be uploaded via HTTP PUT
+	BuildLogUploadURL(ctx context.Context, in *BuildLogUploadURLRequest, opts ...grpc.CallOption) (*BuildLogUploadURLResponse, error)
+	// BuildLogDownloadURL provides a URL from where the log of an image build can be downloaded from
+	BuildLogDownloadURL(ctx context.Context, in *BuildLogDownloadURLRequest, opts ...grpc.CallOption) (*BuildLogDownloadURLResponse, error)
 }
 
 type headlessLogServiceClient struct {
@@ -58,6 +62,24 @@ func (c *headlessLogServiceClient) ListLogs(ctx context.Context, in *ListLogsReq
 	return out, nil
 }
 
+func (c *headlessLogServiceClient) BuildLogUploadURL(ctx context.Context, in *BuildLogUploadURLRequest, opts ...grpc.CallOption) (*BuildLogUploadURLResponse, error) {
+	out := new(BuildLogUploadURLResponse)
+	err := c.cc.Invoke(ctx, "/contentservice.HeadlessLogService/BuildLogUploadURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *headlessLogServiceClient) BuildLogDownloadURL(ctx context.Context, in *BuildLogDownloadURLRequest, opts ...grpc.CallOption) (*BuildLogDownloadURLResponse, error) {
+	out := new(BuildLogDownloadURLResponse)
+	err := c.cc.Invoke(ctx, "/contentservice.HeadlessLogService/BuildLogDownloadURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HeadlessLogServiceServer is the server API for HeadlessLogService service.
 // All implementations must embed UnimplementedHeadlessLogServiceServer
 // for forward compatibility
@@ -66,6 +88,10 @@ type HeadlessLogServiceServer interface {
 	LogDownloadURL(context.Context, *LogDownloadURLRequest) (*LogDownloadURLResponse, error)
 	// ListLogs returns a list of taskIds for the specified workspace instance
 	ListLogs(context.Context, *ListLogsRequest) (*ListLogsResponse, error)
+	// BuildLogUploadURL provides a URL to which the log of an image build can be uploaded via HTTP PUT
+	BuildLogUploadURL(context.Context, *BuildLogUploadURLRequest) (*BuildLogUploadURLResponse, error)
+	// BuildLogDownloadURL provides a URL from where the log of an image build can be downloaded from
+	BuildLogDownloadURL(context.Context, *BuildLogDownloadURLRequest) (*BuildLogDownloadURLResponse, error)
 	mustEmbedUnimplementedHeadlessLogServiceServer()
 }
 
@@ -79,6 +105,12 @@ func (UnimplementedHeadlessLogServiceServer) LogDownloadURL(context.Context, *Lo
 func (UnimplementedHeadlessLogServiceServer) ListLogs(context.Context, *ListLogsRequest) (*ListLogsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListLogs not implemented")
 }
+func (UnimplementedHeadlessLogServiceServer) BuildLogUploadURL(context.Context, *BuildLogUploadURLRequest) (*BuildLogUploadURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuildLogUploadURL not implemented")
+}
+func (UnimplementedHeadlessLogServiceServer) BuildLogDownloadURL(context.Context, *BuildLogDownloadURLRequest) (*BuildLogDownloadURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuildLogDownloadURL not implemented")
+}
 func (UnimplementedHeadlessLogServiceServer) mustEmbedUnimplementedHeadlessLogServiceServer() {}
 
 // UnsafeHeadlessLogServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -128,6 +160,42 @@ func _HeadlessLogService_ListLogs_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HeadlessLogService_BuildLogUploadURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildLogUploadURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeadlessLogServiceServer).BuildLogUploadURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/contentservice.HeadlessLogService/BuildLogUploadURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeadlessLogServiceServer).BuildLogUploadURL(ctx, req.(*BuildLogUploadURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HeadlessLogService_BuildLogDownloadURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildLogDownloadURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeadlessLogServiceServer).BuildLogDownloadURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/contentservice.HeadlessLogService/BuildLogDownloadURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeadlessLogServiceServer).BuildLogDownloadURL(ctx, req.(*BuildLogDownloadURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // HeadlessLogService_ServiceDesc is the grpc.ServiceDesc for HeadlessLogService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -143,6 +211,14 @@ var HeadlessLogService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListLogs",
 			Handler:    _HeadlessLogService_ListLogs_Handler,
 		},
+		{
+			MethodName: "BuildLogUploadURL",
+			Handler:    _HeadlessLogService_BuildLogUploadURL_Handler,
+		},
+		{
+			MethodName: "BuildLogDownloadURL",
+			Handler:    _HeadlessLogService_BuildLogDownloadURL_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "headless-log.proto",
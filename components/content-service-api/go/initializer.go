@@ -141,6 +141,13 @@ func WalkInitializer(path []string, init *WorkspaceInitializer, visitor func(pat
 		return visitor(append(path, "download"), init)
 	case *WorkspaceInitializer_Backup:
 		return visitor(append(path, "backup"), init)
+	case *WorkspaceInitializer_VolumeSnapshot:
+		path = append(path, "volumeSnapshot")
+		err := visitor(path, init)
+		if err != nil {
+			return err
+		}
+		return WalkInitializer(append(path, "fallback"), spec.VolumeSnapshot.Fallback, visitor)
 
 	default:
 		return fmt.Errorf("unsupported workspace initializer in walkInitializer - this is a bug in Gitpod")
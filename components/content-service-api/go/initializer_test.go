@@ -102,6 +102,24 @@ func TestGetCheckoutLocationsFromInitializer(t *testing.T) {
 			},
 			Expectation: "/foo,/bar",
 		},
+		{
+			Name: "volume snapshot initializer",
+			Initializer: &api.WorkspaceInitializer{
+				Spec: &api.WorkspaceInitializer_VolumeSnapshot{
+					VolumeSnapshot: &api.VolumeSnapshotInitializer{
+						VolumeSnapshotName: "foo",
+						Fallback: &api.WorkspaceInitializer{
+							Spec: &api.WorkspaceInitializer_Backup{
+								Backup: &api.FromBackupInitializer{
+									CheckoutLocation: "/foobar",
+								},
+							},
+						},
+					},
+				},
+			},
+			Expectation: "/foobar",
+		},
 		{
 			Name: "nil initializer",
 		},
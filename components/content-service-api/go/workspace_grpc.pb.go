@@ -32,6 +32,13 @@ type WorkspaceServiceClient interface {
 	DeleteWorkspace(ctx context.Context, in *DeleteWorkspaceRequest, opts ...grpc.CallOption) (*DeleteWorkspaceResponse, error)
 	// WorkspaceSnapshotExists checks whether the snapshot exists or not
 	WorkspaceSnapshotExists(ctx context.Context, in *WorkspaceSnapshotExistsRequest, opts ...grpc.CallOption) (*WorkspaceSnapshotExistsResponse, error)
+	// DeleteSnapshot deletes a single snapshot of a workspace
+	DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*DeleteSnapshotResponse, error)
+	// ListWorkspaceContent lists the backups and snapshots of a workspace
+	ListWorkspaceContent(ctx context.Context, in *ListWorkspaceContentRequest, opts ...grpc.CallOption) (*ListWorkspaceContentResponse, error)
+	// RefreshWorkspaceContentURL provides a new download or upload URL for a backup or snapshot, so that
+	// long-running transfers can resume once their previous URL expired
+	RefreshWorkspaceContentURL(ctx context.Context, in *RefreshWorkspaceContentURLRequest, opts ...grpc.CallOption) (*RefreshWorkspaceContentURLResponse, error)
 }
 
 type workspaceServiceClient struct {
@@ -69,6 +76,33 @@ func (c *workspaceServiceClient) WorkspaceSnapshotExists(ctx context.Context, in
 	return out, nil
 }
 
+func (c *workspaceServiceClient) DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*DeleteSnapshotResponse, error) {
+	out := new(DeleteSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/contentservice.WorkspaceService/DeleteSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workspaceServiceClient) ListWorkspaceContent(ctx context.Context, in *ListWorkspaceContentRequest, opts ...grpc.CallOption) (*ListWorkspaceContentResponse, error) {
+	out := new(ListWorkspaceContentResponse)
+	err := c.cc.Invoke(ctx, "/contentservice.WorkspaceService/ListWorkspaceContent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workspaceServiceClient) RefreshWorkspaceContentURL(ctx context.Context, in *RefreshWorkspaceContentURLRequest, opts ...grpc.CallOption) (*RefreshWorkspaceContentURLResponse, error) {
+	out := new(RefreshWorkspaceContentURLResponse)
+	err := c.cc.Invoke(ctx, "/contentservice.WorkspaceService/RefreshWorkspaceContentURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // WorkspaceServiceServer is the server API for WorkspaceService service.
 // All implementations must embed UnimplementedWorkspaceServiceServer
 // for forward compatibility
@@ -79,6 +113,13 @@ type WorkspaceServiceServer interface {
 	DeleteWorkspace(context.Context, *DeleteWorkspaceRequest) (*DeleteWorkspaceResponse, error)
 	// WorkspaceSnapshotExists checks whether the snapshot exists or not
 	WorkspaceSnapshotExists(context.Context, *WorkspaceSnapshotExistsRequest) (*WorkspaceSnapshotExistsResponse, error)
+	// DeleteSnapshot deletes a single snapshot of a workspace
+	DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*DeleteSnapshotResponse, error)
+	// ListWorkspaceContent lists the backups and snapshots of a workspace
+	ListWorkspaceContent(context.Context, *ListWorkspaceContentRequest) (*ListWorkspaceContentResponse, error)
+	// RefreshWorkspaceContentURL provides a new download or upload URL for a backup or snapshot, so that
+	// long-running transfers can resume once their previous URL expired
+	RefreshWorkspaceContentURL(context.Context, *RefreshWorkspaceContentURLRequest) (*RefreshWorkspaceContentURLResponse, error)
 	mustEmbedUnimplementedWorkspaceServiceServer()
 }
 
@@ -95,6 +136,15 @@ func (UnimplementedWorkspaceServiceServer) DeleteWorkspace(context.Context, *Del
 func (UnimplementedWorkspaceServiceServer) WorkspaceSnapshotExists(context.Context, *WorkspaceSnapshotExistsRequest) (*WorkspaceSnapshotExistsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WorkspaceSnapshotExists not implemented")
 }
+func (UnimplementedWorkspaceServiceServer) DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*DeleteSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSnapshot not implemented")
+}
+func (UnimplementedWorkspaceServiceServer) ListWorkspaceContent(context.Context, *ListWorkspaceContentRequest) (*ListWorkspaceContentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkspaceContent not implemented")
+}
+func (UnimplementedWorkspaceServiceServer) RefreshWorkspaceContentURL(context.Context, *RefreshWorkspaceContentURLRequest) (*RefreshWorkspaceContentURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshWorkspaceContentURL not implemented")
+}
 func (UnimplementedWorkspaceServiceServer) mustEmbedUnimplementedWorkspaceServiceServer() {}
 
 // UnsafeWorkspaceServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -162,6 +212,60 @@ func _WorkspaceService_WorkspaceSnapshotExists_Handler(srv interface{}, ctx cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WorkspaceService_DeleteSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkspaceServiceServer).DeleteSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/contentservice.WorkspaceService/DeleteSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkspaceServiceServer).DeleteSnapshot(ctx, req.(*DeleteSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkspaceService_ListWorkspaceContent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkspaceContentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkspaceServiceServer).ListWorkspaceContent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/contentservice.WorkspaceService/ListWorkspaceContent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkspaceServiceServer).ListWorkspaceContent(ctx, req.(*ListWorkspaceContentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkspaceService_RefreshWorkspaceContentURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshWorkspaceContentURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkspaceServiceServer).RefreshWorkspaceContentURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/contentservice.WorkspaceService/RefreshWorkspaceContentURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkspaceServiceServer).RefreshWorkspaceContentURL(ctx, req.(*RefreshWorkspaceContentURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // WorkspaceService_ServiceDesc is the grpc.ServiceDesc for WorkspaceService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -181,6 +285,18 @@ var WorkspaceService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "WorkspaceSnapshotExists",
 			Handler:    _WorkspaceService_WorkspaceSnapshotExists_Handler,
 		},
+		{
+			MethodName: "DeleteSnapshot",
+			Handler:    _WorkspaceService_DeleteSnapshot_Handler,
+		},
+		{
+			MethodName: "ListWorkspaceContent",
+			Handler:    _WorkspaceService_ListWorkspaceContent_Handler,
+		},
+		{
+			MethodName: "RefreshWorkspaceContentURL",
+			Handler:    _WorkspaceService_RefreshWorkspaceContentURL_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "workspace.proto",
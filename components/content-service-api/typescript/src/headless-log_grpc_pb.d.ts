@@ -16,6 +16,8 @@ import * as headless_log_pb from "./headless-log_pb";
 interface IHeadlessLogServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
     logDownloadURL: IHeadlessLogServiceService_ILogDownloadURL;
     listLogs: IHeadlessLogServiceService_IListLogs;
+    buildLogUploadURL: IHeadlessLogServiceService_IBuildLogUploadURL;
+    buildLogDownloadURL: IHeadlessLogServiceService_IBuildLogDownloadURL;
 }
 
 interface IHeadlessLogServiceService_ILogDownloadURL extends grpc.MethodDefinition<headless_log_pb.LogDownloadURLRequest, headless_log_pb.LogDownloadURLResponse> {
@@ -36,12 +38,32 @@ interface IHeadlessLogServiceService_IListLogs extends grpc.MethodDefinition<hea
     responseSerialize: grpc.serialize<headless_log_pb.ListLogsResponse>;
     responseDeserialize: grpc.deserialize<headless_log_pb.ListLogsResponse>;
 }
+interface IHeadlessLogServiceService_IBuildLogUploadURL extends grpc.MethodDefinition<headless_log_pb.BuildLogUploadURLRequest, headless_log_pb.BuildLogUploadURLResponse> {
+    path: "/contentservice.HeadlessLogService/BuildLogUploadURL";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<headless_log_pb.BuildLogUploadURLRequest>;
+    requestDeserialize: grpc.deserialize<headless_log_pb.BuildLogUploadURLRequest>;
+    responseSerialize: grpc.serialize<headless_log_pb.BuildLogUploadURLResponse>;
+    responseDeserialize: grpc.deserialize<headless_log_pb.BuildLogUploadURLResponse>;
+}
+interface IHeadlessLogServiceService_IBuildLogDownloadURL extends grpc.MethodDefinition<headless_log_pb.BuildLogDownloadURLRequest, headless_log_pb.BuildLogDownloadURLResponse> {
+    path: "/contentservice.HeadlessLogService/BuildLogDownloadURL";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<headless_log_pb.BuildLogDownloadURLRequest>;
+    requestDeserialize: grpc.deserialize<headless_log_pb.BuildLogDownloadURLRequest>;
+    responseSerialize: grpc.serialize<headless_log_pb.BuildLogDownloadURLResponse>;
+    responseDeserialize: grpc.deserialize<headless_log_pb.BuildLogDownloadURLResponse>;
+}
 
 export const HeadlessLogServiceService: IHeadlessLogServiceService;
 
 export interface IHeadlessLogServiceServer extends grpc.UntypedServiceImplementation {
     logDownloadURL: grpc.handleUnaryCall<headless_log_pb.LogDownloadURLRequest, headless_log_pb.LogDownloadURLResponse>;
     listLogs: grpc.handleUnaryCall<headless_log_pb.ListLogsRequest, headless_log_pb.ListLogsResponse>;
+    buildLogUploadURL: grpc.handleUnaryCall<headless_log_pb.BuildLogUploadURLRequest, headless_log_pb.BuildLogUploadURLResponse>;
+    buildLogDownloadURL: grpc.handleUnaryCall<headless_log_pb.BuildLogDownloadURLRequest, headless_log_pb.BuildLogDownloadURLResponse>;
 }
 
 export interface IHeadlessLogServiceClient {
@@ -51,6 +73,12 @@ export interface IHeadlessLogServiceClient {
     listLogs(request: headless_log_pb.ListLogsRequest, callback: (error: grpc.ServiceError | null, response: headless_log_pb.ListLogsResponse) => void): grpc.ClientUnaryCall;
     listLogs(request: headless_log_pb.ListLogsRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: headless_log_pb.ListLogsResponse) => void): grpc.ClientUnaryCall;
     listLogs(request: headless_log_pb.ListLogsRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: headless_log_pb.ListLogsResponse) => void): grpc.ClientUnaryCall;
+    buildLogUploadURL(request: headless_log_pb.BuildLogUploadURLRequest, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogUploadURLResponse) => void): grpc.ClientUnaryCall;
+    buildLogUploadURL(request: headless_log_pb.BuildLogUploadURLRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogUploadURLResponse) => void): grpc.ClientUnaryCall;
+    buildLogUploadURL(request: headless_log_pb.BuildLogUploadURLRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogUploadURLResponse) => void): grpc.ClientUnaryCall;
+    buildLogDownloadURL(request: headless_log_pb.BuildLogDownloadURLRequest, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogDownloadURLResponse) => void): grpc.ClientUnaryCall;
+    buildLogDownloadURL(request: headless_log_pb.BuildLogDownloadURLRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogDownloadURLResponse) => void): grpc.ClientUnaryCall;
+    buildLogDownloadURL(request: headless_log_pb.BuildLogDownloadURLRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogDownloadURLResponse) => void): grpc.ClientUnaryCall;
 }
 
 export class HeadlessLogServiceClient extends grpc.Client implements IHeadlessLogServiceClient {
@@ -61,4 +89,10 @@ export class HeadlessLogServiceClient extends grpc.Client implements IHeadlessLo
     public listLogs(request: headless_log_pb.ListLogsRequest, callback: (error: grpc.ServiceError | null, response: headless_log_pb.ListLogsResponse) => void): grpc.ClientUnaryCall;
     public listLogs(request: headless_log_pb.ListLogsRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: headless_log_pb.ListLogsResponse) => void): grpc.ClientUnaryCall;
     public listLogs(request: headless_log_pb.ListLogsRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: headless_log_pb.ListLogsResponse) => void): grpc.ClientUnaryCall;
+    public buildLogUploadURL(request: headless_log_pb.BuildLogUploadURLRequest, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogUploadURLResponse) => void): grpc.ClientUnaryCall;
+    public buildLogUploadURL(request: headless_log_pb.BuildLogUploadURLRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogUploadURLResponse) => void): grpc.ClientUnaryCall;
+    public buildLogUploadURL(request: headless_log_pb.BuildLogUploadURLRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogUploadURLResponse) => void): grpc.ClientUnaryCall;
+    public buildLogDownloadURL(request: headless_log_pb.BuildLogDownloadURLRequest, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogDownloadURLResponse) => void): grpc.ClientUnaryCall;
+    public buildLogDownloadURL(request: headless_log_pb.BuildLogDownloadURLRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogDownloadURLResponse) => void): grpc.ClientUnaryCall;
+    public buildLogDownloadURL(request: headless_log_pb.BuildLogDownloadURLRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: headless_log_pb.BuildLogDownloadURLResponse) => void): grpc.ClientUnaryCall;
 }
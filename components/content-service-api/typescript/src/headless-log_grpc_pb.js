@@ -9,6 +9,50 @@
 var grpc = require('@grpc/grpc-js');
 var headless$log_pb = require('./headless-log_pb.js');
 
+function serialize_contentservice_BuildLogDownloadURLRequest(arg) {
+  if (!(arg instanceof headless$log_pb.BuildLogDownloadURLRequest)) {
+    throw new Error('Expected argument of type contentservice.BuildLogDownloadURLRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_BuildLogDownloadURLRequest(buffer_arg) {
+  return headless$log_pb.BuildLogDownloadURLRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_BuildLogDownloadURLResponse(arg) {
+  if (!(arg instanceof headless$log_pb.BuildLogDownloadURLResponse)) {
+    throw new Error('Expected argument of type contentservice.BuildLogDownloadURLResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_BuildLogDownloadURLResponse(buffer_arg) {
+  return headless$log_pb.BuildLogDownloadURLResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_BuildLogUploadURLRequest(arg) {
+  if (!(arg instanceof headless$log_pb.BuildLogUploadURLRequest)) {
+    throw new Error('Expected argument of type contentservice.BuildLogUploadURLRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_BuildLogUploadURLRequest(buffer_arg) {
+  return headless$log_pb.BuildLogUploadURLRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_BuildLogUploadURLResponse(arg) {
+  if (!(arg instanceof headless$log_pb.BuildLogUploadURLResponse)) {
+    throw new Error('Expected argument of type contentservice.BuildLogUploadURLResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_BuildLogUploadURLResponse(buffer_arg) {
+  return headless$log_pb.BuildLogUploadURLResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_contentservice_ListLogsRequest(arg) {
   if (!(arg instanceof headless$log_pb.ListLogsRequest)) {
     throw new Error('Expected argument of type contentservice.ListLogsRequest');
@@ -79,6 +123,30 @@ listLogs: {
     responseSerialize: serialize_contentservice_ListLogsResponse,
     responseDeserialize: deserialize_contentservice_ListLogsResponse,
   },
+  // BuildLogUploadURL provides a URL to which the log of an image build can be uploaded via HTTP PUT
+buildLogUploadURL: {
+    path: '/contentservice.HeadlessLogService/BuildLogUploadURL',
+    requestStream: false,
+    responseStream: false,
+    requestType: headless$log_pb.BuildLogUploadURLRequest,
+    responseType: headless$log_pb.BuildLogUploadURLResponse,
+    requestSerialize: serialize_contentservice_BuildLogUploadURLRequest,
+    requestDeserialize: deserialize_contentservice_BuildLogUploadURLRequest,
+    responseSerialize: serialize_contentservice_BuildLogUploadURLResponse,
+    responseDeserialize: deserialize_contentservice_BuildLogUploadURLResponse,
+  },
+  // BuildLogDownloadURL provides a URL from where the log of an image build can be downloaded from
+buildLogDownloadURL: {
+    path: '/contentservice.HeadlessLogService/BuildLogDownloadURL',
+    requestStream: false,
+    responseStream: false,
+    requestType: headless$log_pb.BuildLogDownloadURLRequest,
+    responseType: headless$log_pb.BuildLogDownloadURLResponse,
+    requestSerialize: serialize_contentservice_BuildLogDownloadURLRequest,
+    requestDeserialize: deserialize_contentservice_BuildLogDownloadURLRequest,
+    responseSerialize: serialize_contentservice_BuildLogDownloadURLResponse,
+    responseDeserialize: deserialize_contentservice_BuildLogDownloadURLResponse,
+  },
 };
 
 exports.HeadlessLogServiceClient = grpc.makeGenericClientConstructor(HeadlessLogServiceService);
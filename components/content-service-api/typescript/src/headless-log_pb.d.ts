@@ -108,3 +108,83 @@ export namespace ListLogsResponse {
         taskIdList: Array<string>,
     }
 }
+
+export class BuildLogUploadURLRequest extends jspb.Message {
+    getBuildId(): string;
+    setBuildId(value: string): BuildLogUploadURLRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildLogUploadURLRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildLogUploadURLRequest): BuildLogUploadURLRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildLogUploadURLRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildLogUploadURLRequest;
+    static deserializeBinaryFromReader(message: BuildLogUploadURLRequest, reader: jspb.BinaryReader): BuildLogUploadURLRequest;
+}
+
+export namespace BuildLogUploadURLRequest {
+    export type AsObject = {
+        buildId: string,
+    }
+}
+
+export class BuildLogUploadURLResponse extends jspb.Message {
+    getUrl(): string;
+    setUrl(value: string): BuildLogUploadURLResponse;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildLogUploadURLResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildLogUploadURLResponse): BuildLogUploadURLResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildLogUploadURLResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildLogUploadURLResponse;
+    static deserializeBinaryFromReader(message: BuildLogUploadURLResponse, reader: jspb.BinaryReader): BuildLogUploadURLResponse;
+}
+
+export namespace BuildLogUploadURLResponse {
+    export type AsObject = {
+        url: string,
+    }
+}
+
+export class BuildLogDownloadURLRequest extends jspb.Message {
+    getBuildId(): string;
+    setBuildId(value: string): BuildLogDownloadURLRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildLogDownloadURLRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildLogDownloadURLRequest): BuildLogDownloadURLRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildLogDownloadURLRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildLogDownloadURLRequest;
+    static deserializeBinaryFromReader(message: BuildLogDownloadURLRequest, reader: jspb.BinaryReader): BuildLogDownloadURLRequest;
+}
+
+export namespace BuildLogDownloadURLRequest {
+    export type AsObject = {
+        buildId: string,
+    }
+}
+
+export class BuildLogDownloadURLResponse extends jspb.Message {
+    getUrl(): string;
+    setUrl(value: string): BuildLogDownloadURLResponse;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildLogDownloadURLResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildLogDownloadURLResponse): BuildLogDownloadURLResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildLogDownloadURLResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildLogDownloadURLResponse;
+    static deserializeBinaryFromReader(message: BuildLogDownloadURLResponse, reader: jspb.BinaryReader): BuildLogDownloadURLResponse;
+}
+
+export namespace BuildLogDownloadURLResponse {
+    export type AsObject = {
+        url: string,
+    }
+}
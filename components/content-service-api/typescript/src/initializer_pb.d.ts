@@ -49,6 +49,11 @@ export class WorkspaceInitializer extends jspb.Message {
     getBackup(): FromBackupInitializer | undefined;
     setBackup(value?: FromBackupInitializer): WorkspaceInitializer;
 
+    hasVolumeSnapshot(): boolean;
+    clearVolumeSnapshot(): void;
+    getVolumeSnapshot(): VolumeSnapshotInitializer | undefined;
+    setVolumeSnapshot(value?: VolumeSnapshotInitializer): WorkspaceInitializer;
+
     getSpecCase(): WorkspaceInitializer.SpecCase;
 
     serializeBinary(): Uint8Array;
@@ -70,6 +75,7 @@ export namespace WorkspaceInitializer {
         composite?: CompositeInitializer.AsObject,
         download?: FileDownloadInitializer.AsObject,
         backup?: FromBackupInitializer.AsObject,
+        volumeSnapshot?: VolumeSnapshotInitializer.AsObject,
     }
 
     export enum SpecCase {
@@ -81,6 +87,7 @@ export namespace WorkspaceInitializer {
         COMPOSITE = 5,
         DOWNLOAD = 6,
         BACKUP = 7,
+        VOLUME_SNAPSHOT = 8,
     }
 
 }
@@ -370,6 +377,32 @@ export namespace GitStatus {
     }
 }
 
+export class VolumeSnapshotInitializer extends jspb.Message {
+    getVolumeSnapshotName(): string;
+    setVolumeSnapshotName(value: string): VolumeSnapshotInitializer;
+
+    hasFallback(): boolean;
+    clearFallback(): void;
+    getFallback(): WorkspaceInitializer | undefined;
+    setFallback(value?: WorkspaceInitializer): VolumeSnapshotInitializer;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): VolumeSnapshotInitializer.AsObject;
+    static toObject(includeInstance: boolean, msg: VolumeSnapshotInitializer): VolumeSnapshotInitializer.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: VolumeSnapshotInitializer, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): VolumeSnapshotInitializer;
+    static deserializeBinaryFromReader(message: VolumeSnapshotInitializer, reader: jspb.BinaryReader): VolumeSnapshotInitializer;
+}
+
+export namespace VolumeSnapshotInitializer {
+    export type AsObject = {
+        volumeSnapshotName: string,
+        fallback?: WorkspaceInitializer.AsObject,
+    }
+}
+
 export enum CloneTargetMode {
     REMOTE_HEAD = 0,
     REMOTE_COMMIT = 1,
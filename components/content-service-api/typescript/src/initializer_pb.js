@@ -33,6 +33,7 @@ goog.exportSymbol('proto.contentservice.GitInitializer', null, global);
 goog.exportSymbol('proto.contentservice.GitStatus', null, global);
 goog.exportSymbol('proto.contentservice.PrebuildInitializer', null, global);
 goog.exportSymbol('proto.contentservice.SnapshotInitializer', null, global);
+goog.exportSymbol('proto.contentservice.VolumeSnapshotInitializer', null, global);
 goog.exportSymbol('proto.contentservice.WorkspaceInitializer', null, global);
 goog.exportSymbol('proto.contentservice.WorkspaceInitializer.SpecCase', null, global);
 /**
@@ -266,6 +267,27 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.contentservice.GitStatus.displayName = 'proto.contentservice.GitStatus';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.contentservice.VolumeSnapshotInitializer = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.contentservice.VolumeSnapshotInitializer, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.contentservice.VolumeSnapshotInitializer.displayName = 'proto.contentservice.VolumeSnapshotInitializer';
+}
 
 /**
  * Oneof group definitions for this message. Each group defines the field
@@ -275,7 +297,7 @@ if (goog.DEBUG && !COMPILED) {
  * @private {!Array<!Array<number>>}
  * @const
  */
-proto.contentservice.WorkspaceInitializer.oneofGroups_ = [[1,2,3,4,5,6,7]];
+proto.contentservice.WorkspaceInitializer.oneofGroups_ = [[1,2,3,4,5,6,7,8]];
 
 /**
  * @enum {number}
@@ -288,7 +310,8 @@ proto.contentservice.WorkspaceInitializer.SpecCase = {
   PREBUILD: 4,
   COMPOSITE: 5,
   DOWNLOAD: 6,
-  BACKUP: 7
+  BACKUP: 7,
+  VOLUME_SNAPSHOT: 8
 };
 
 /**
@@ -335,7 +358,8 @@ proto.contentservice.WorkspaceInitializer.toObject = function(includeInstance, m
     prebuild: (f = msg.getPrebuild()) && proto.contentservice.PrebuildInitializer.toObject(includeInstance, f),
     composite: (f = msg.getComposite()) && proto.contentservice.CompositeInitializer.toObject(includeInstance, f),
     download: (f = msg.getDownload()) && proto.contentservice.FileDownloadInitializer.toObject(includeInstance, f),
-    backup: (f = msg.getBackup()) && proto.contentservice.FromBackupInitializer.toObject(includeInstance, f)
+    backup: (f = msg.getBackup()) && proto.contentservice.FromBackupInitializer.toObject(includeInstance, f),
+    volumeSnapshot: (f = msg.getVolumeSnapshot()) && proto.contentservice.VolumeSnapshotInitializer.toObject(includeInstance, f)
   };
 
   if (includeInstance) {
@@ -407,6 +431,11 @@ proto.contentservice.WorkspaceInitializer.deserializeBinaryFromReader = function
       reader.readMessage(value,proto.contentservice.FromBackupInitializer.deserializeBinaryFromReader);
       msg.setBackup(value);
       break;
+    case 8:
+      var value = new proto.contentservice.VolumeSnapshotInitializer;
+      reader.readMessage(value,proto.contentservice.VolumeSnapshotInitializer.deserializeBinaryFromReader);
+      msg.setVolumeSnapshot(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -492,6 +521,14 @@ proto.contentservice.WorkspaceInitializer.serializeBinaryToWriter = function(mes
       proto.contentservice.FromBackupInitializer.serializeBinaryToWriter
     );
   }
+  f = message.getVolumeSnapshot();
+  if (f != null) {
+    writer.writeMessage(
+      8,
+      f,
+      proto.contentservice.VolumeSnapshotInitializer.serializeBinaryToWriter
+    );
+  }
 };
 
 
@@ -754,6 +791,43 @@ proto.contentservice.WorkspaceInitializer.prototype.hasBackup = function() {
 };
 
 
+/**
+ * optional VolumeSnapshotInitializer volume_snapshot = 8;
+ * @return {?proto.contentservice.VolumeSnapshotInitializer}
+ */
+proto.contentservice.WorkspaceInitializer.prototype.getVolumeSnapshot = function() {
+  return /** @type{?proto.contentservice.VolumeSnapshotInitializer} */ (
+    jspb.Message.getWrapperField(this, proto.contentservice.VolumeSnapshotInitializer, 8));
+};
+
+
+/**
+ * @param {?proto.contentservice.VolumeSnapshotInitializer|undefined} value
+ * @return {!proto.contentservice.WorkspaceInitializer} returns this
+*/
+proto.contentservice.WorkspaceInitializer.prototype.setVolumeSnapshot = function(value) {
+  return jspb.Message.setOneofWrapperField(this, 8, proto.contentservice.WorkspaceInitializer.oneofGroups_[0], value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.contentservice.WorkspaceInitializer} returns this
+ */
+proto.contentservice.WorkspaceInitializer.prototype.clearVolumeSnapshot = function() {
+  return this.setVolumeSnapshot(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.contentservice.WorkspaceInitializer.prototype.hasVolumeSnapshot = function() {
+  return jspb.Message.getField(this, 8) != null;
+};
+
+
 
 /**
  * List of repeated fields within this message type.
@@ -2884,6 +2958,185 @@ proto.contentservice.GitStatus.prototype.setTotalUnpushedCommits = function(valu
 };
 
 
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.toObject = function(opt_includeInstance) {
+  return proto.contentservice.VolumeSnapshotInitializer.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.contentservice.VolumeSnapshotInitializer} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.contentservice.VolumeSnapshotInitializer.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    volumeSnapshotName: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    fallback: (f = msg.getFallback()) && proto.contentservice.WorkspaceInitializer.toObject(includeInstance, f)
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.contentservice.VolumeSnapshotInitializer}
+ */
+proto.contentservice.VolumeSnapshotInitializer.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.contentservice.VolumeSnapshotInitializer;
+  return proto.contentservice.VolumeSnapshotInitializer.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.contentservice.VolumeSnapshotInitializer} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.contentservice.VolumeSnapshotInitializer}
+ */
+proto.contentservice.VolumeSnapshotInitializer.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setVolumeSnapshotName(value);
+      break;
+    case 2:
+      var value = new proto.contentservice.WorkspaceInitializer;
+      reader.readMessage(value,proto.contentservice.WorkspaceInitializer.deserializeBinaryFromReader);
+      msg.setFallback(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.contentservice.VolumeSnapshotInitializer.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.contentservice.VolumeSnapshotInitializer} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.contentservice.VolumeSnapshotInitializer.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getVolumeSnapshotName();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getFallback();
+  if (f != null) {
+    writer.writeMessage(
+      2,
+      f,
+      proto.contentservice.WorkspaceInitializer.serializeBinaryToWriter
+    );
+  }
+};
+
+
+/**
+ * optional string volume_snapshot_name = 1;
+ * @return {string}
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.getVolumeSnapshotName = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.contentservice.VolumeSnapshotInitializer} returns this
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.setVolumeSnapshotName = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional WorkspaceInitializer fallback = 2;
+ * @return {?proto.contentservice.WorkspaceInitializer}
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.getFallback = function() {
+  return /** @type{?proto.contentservice.WorkspaceInitializer} */ (
+    jspb.Message.getWrapperField(this, proto.contentservice.WorkspaceInitializer, 2));
+};
+
+
+/**
+ * @param {?proto.contentservice.WorkspaceInitializer|undefined} value
+ * @return {!proto.contentservice.VolumeSnapshotInitializer} returns this
+*/
+proto.contentservice.VolumeSnapshotInitializer.prototype.setFallback = function(value) {
+  return jspb.Message.setWrapperField(this, 2, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.contentservice.VolumeSnapshotInitializer} returns this
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.clearFallback = function() {
+  return this.setFallback(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.contentservice.VolumeSnapshotInitializer.prototype.hasFallback = function() {
+  return jspb.Message.getField(this, 2) != null;
+};
+
+
 /**
  * @enum {number}
  */
@@ -17,6 +17,9 @@ interface IWorkspaceServiceService extends grpc.ServiceDefinition<grpc.UntypedSe
     workspaceDownloadURL: IWorkspaceServiceService_IWorkspaceDownloadURL;
     deleteWorkspace: IWorkspaceServiceService_IDeleteWorkspace;
     workspaceSnapshotExists: IWorkspaceServiceService_IWorkspaceSnapshotExists;
+    deleteSnapshot: IWorkspaceServiceService_IDeleteSnapshot;
+    listWorkspaceContent: IWorkspaceServiceService_IListWorkspaceContent;
+    refreshWorkspaceContentURL: IWorkspaceServiceService_IRefreshWorkspaceContentURL;
 }
 
 interface IWorkspaceServiceService_IWorkspaceDownloadURL extends grpc.MethodDefinition<workspace_pb.WorkspaceDownloadURLRequest, workspace_pb.WorkspaceDownloadURLResponse> {
@@ -46,6 +49,33 @@ interface IWorkspaceServiceService_IWorkspaceSnapshotExists extends grpc.MethodD
     responseSerialize: grpc.serialize<workspace_pb.WorkspaceSnapshotExistsResponse>;
     responseDeserialize: grpc.deserialize<workspace_pb.WorkspaceSnapshotExistsResponse>;
 }
+interface IWorkspaceServiceService_IDeleteSnapshot extends grpc.MethodDefinition<workspace_pb.DeleteSnapshotRequest, workspace_pb.DeleteSnapshotResponse> {
+    path: "/contentservice.WorkspaceService/DeleteSnapshot";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<workspace_pb.DeleteSnapshotRequest>;
+    requestDeserialize: grpc.deserialize<workspace_pb.DeleteSnapshotRequest>;
+    responseSerialize: grpc.serialize<workspace_pb.DeleteSnapshotResponse>;
+    responseDeserialize: grpc.deserialize<workspace_pb.DeleteSnapshotResponse>;
+}
+interface IWorkspaceServiceService_IListWorkspaceContent extends grpc.MethodDefinition<workspace_pb.ListWorkspaceContentRequest, workspace_pb.ListWorkspaceContentResponse> {
+    path: "/contentservice.WorkspaceService/ListWorkspaceContent";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<workspace_pb.ListWorkspaceContentRequest>;
+    requestDeserialize: grpc.deserialize<workspace_pb.ListWorkspaceContentRequest>;
+    responseSerialize: grpc.serialize<workspace_pb.ListWorkspaceContentResponse>;
+    responseDeserialize: grpc.deserialize<workspace_pb.ListWorkspaceContentResponse>;
+}
+interface IWorkspaceServiceService_IRefreshWorkspaceContentURL extends grpc.MethodDefinition<workspace_pb.RefreshWorkspaceContentURLRequest, workspace_pb.RefreshWorkspaceContentURLResponse> {
+    path: "/contentservice.WorkspaceService/RefreshWorkspaceContentURL";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<workspace_pb.RefreshWorkspaceContentURLRequest>;
+    requestDeserialize: grpc.deserialize<workspace_pb.RefreshWorkspaceContentURLRequest>;
+    responseSerialize: grpc.serialize<workspace_pb.RefreshWorkspaceContentURLResponse>;
+    responseDeserialize: grpc.deserialize<workspace_pb.RefreshWorkspaceContentURLResponse>;
+}
 
 export const WorkspaceServiceService: IWorkspaceServiceService;
 
@@ -53,6 +83,9 @@ export interface IWorkspaceServiceServer extends grpc.UntypedServiceImplementati
     workspaceDownloadURL: grpc.handleUnaryCall<workspace_pb.WorkspaceDownloadURLRequest, workspace_pb.WorkspaceDownloadURLResponse>;
     deleteWorkspace: grpc.handleUnaryCall<workspace_pb.DeleteWorkspaceRequest, workspace_pb.DeleteWorkspaceResponse>;
     workspaceSnapshotExists: grpc.handleUnaryCall<workspace_pb.WorkspaceSnapshotExistsRequest, workspace_pb.WorkspaceSnapshotExistsResponse>;
+    deleteSnapshot: grpc.handleUnaryCall<workspace_pb.DeleteSnapshotRequest, workspace_pb.DeleteSnapshotResponse>;
+    listWorkspaceContent: grpc.handleUnaryCall<workspace_pb.ListWorkspaceContentRequest, workspace_pb.ListWorkspaceContentResponse>;
+    refreshWorkspaceContentURL: grpc.handleUnaryCall<workspace_pb.RefreshWorkspaceContentURLRequest, workspace_pb.RefreshWorkspaceContentURLResponse>;
 }
 
 export interface IWorkspaceServiceClient {
@@ -65,6 +98,15 @@ export interface IWorkspaceServiceClient {
     workspaceSnapshotExists(request: workspace_pb.WorkspaceSnapshotExistsRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.WorkspaceSnapshotExistsResponse) => void): grpc.ClientUnaryCall;
     workspaceSnapshotExists(request: workspace_pb.WorkspaceSnapshotExistsRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.WorkspaceSnapshotExistsResponse) => void): grpc.ClientUnaryCall;
     workspaceSnapshotExists(request: workspace_pb.WorkspaceSnapshotExistsRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.WorkspaceSnapshotExistsResponse) => void): grpc.ClientUnaryCall;
+    deleteSnapshot(request: workspace_pb.DeleteSnapshotRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.DeleteSnapshotResponse) => void): grpc.ClientUnaryCall;
+    deleteSnapshot(request: workspace_pb.DeleteSnapshotRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.DeleteSnapshotResponse) => void): grpc.ClientUnaryCall;
+    deleteSnapshot(request: workspace_pb.DeleteSnapshotRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.DeleteSnapshotResponse) => void): grpc.ClientUnaryCall;
+    listWorkspaceContent(request: workspace_pb.ListWorkspaceContentRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.ListWorkspaceContentResponse) => void): grpc.ClientUnaryCall;
+    listWorkspaceContent(request: workspace_pb.ListWorkspaceContentRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.ListWorkspaceContentResponse) => void): grpc.ClientUnaryCall;
+    listWorkspaceContent(request: workspace_pb.ListWorkspaceContentRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.ListWorkspaceContentResponse) => void): grpc.ClientUnaryCall;
+    refreshWorkspaceContentURL(request: workspace_pb.RefreshWorkspaceContentURLRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.RefreshWorkspaceContentURLResponse) => void): grpc.ClientUnaryCall;
+    refreshWorkspaceContentURL(request: workspace_pb.RefreshWorkspaceContentURLRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.RefreshWorkspaceContentURLResponse) => void): grpc.ClientUnaryCall;
+    refreshWorkspaceContentURL(request: workspace_pb.RefreshWorkspaceContentURLRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.RefreshWorkspaceContentURLResponse) => void): grpc.ClientUnaryCall;
 }
 
 export class WorkspaceServiceClient extends grpc.Client implements IWorkspaceServiceClient {
@@ -78,4 +120,13 @@ export class WorkspaceServiceClient extends grpc.Client implements IWorkspaceSer
     public workspaceSnapshotExists(request: workspace_pb.WorkspaceSnapshotExistsRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.WorkspaceSnapshotExistsResponse) => void): grpc.ClientUnaryCall;
     public workspaceSnapshotExists(request: workspace_pb.WorkspaceSnapshotExistsRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.WorkspaceSnapshotExistsResponse) => void): grpc.ClientUnaryCall;
     public workspaceSnapshotExists(request: workspace_pb.WorkspaceSnapshotExistsRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.WorkspaceSnapshotExistsResponse) => void): grpc.ClientUnaryCall;
+    public deleteSnapshot(request: workspace_pb.DeleteSnapshotRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.DeleteSnapshotResponse) => void): grpc.ClientUnaryCall;
+    public deleteSnapshot(request: workspace_pb.DeleteSnapshotRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.DeleteSnapshotResponse) => void): grpc.ClientUnaryCall;
+    public deleteSnapshot(request: workspace_pb.DeleteSnapshotRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.DeleteSnapshotResponse) => void): grpc.ClientUnaryCall;
+    public listWorkspaceContent(request: workspace_pb.ListWorkspaceContentRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.ListWorkspaceContentResponse) => void): grpc.ClientUnaryCall;
+    public listWorkspaceContent(request: workspace_pb.ListWorkspaceContentRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.ListWorkspaceContentResponse) => void): grpc.ClientUnaryCall;
+    public listWorkspaceContent(request: workspace_pb.ListWorkspaceContentRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.ListWorkspaceContentResponse) => void): grpc.ClientUnaryCall;
+    public refreshWorkspaceContentURL(request: workspace_pb.RefreshWorkspaceContentURLRequest, callback: (error: grpc.ServiceError | null, response: workspace_pb.RefreshWorkspaceContentURLResponse) => void): grpc.ClientUnaryCall;
+    public refreshWorkspaceContentURL(request: workspace_pb.RefreshWorkspaceContentURLRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_pb.RefreshWorkspaceContentURLResponse) => void): grpc.ClientUnaryCall;
+    public refreshWorkspaceContentURL(request: workspace_pb.RefreshWorkspaceContentURLRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_pb.RefreshWorkspaceContentURLResponse) => void): grpc.ClientUnaryCall;
 }
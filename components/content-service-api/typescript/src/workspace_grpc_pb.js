@@ -9,6 +9,28 @@
 var grpc = require('@grpc/grpc-js');
 var workspace_pb = require('./workspace_pb.js');
 
+function serialize_contentservice_DeleteSnapshotRequest(arg) {
+  if (!(arg instanceof workspace_pb.DeleteSnapshotRequest)) {
+    throw new Error('Expected argument of type contentservice.DeleteSnapshotRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_DeleteSnapshotRequest(buffer_arg) {
+  return workspace_pb.DeleteSnapshotRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_DeleteSnapshotResponse(arg) {
+  if (!(arg instanceof workspace_pb.DeleteSnapshotResponse)) {
+    throw new Error('Expected argument of type contentservice.DeleteSnapshotResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_DeleteSnapshotResponse(buffer_arg) {
+  return workspace_pb.DeleteSnapshotResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_contentservice_DeleteWorkspaceRequest(arg) {
   if (!(arg instanceof workspace_pb.DeleteWorkspaceRequest)) {
     throw new Error('Expected argument of type contentservice.DeleteWorkspaceRequest');
@@ -31,6 +53,50 @@ function deserialize_contentservice_DeleteWorkspaceResponse(buffer_arg) {
   return workspace_pb.DeleteWorkspaceResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_contentservice_ListWorkspaceContentRequest(arg) {
+  if (!(arg instanceof workspace_pb.ListWorkspaceContentRequest)) {
+    throw new Error('Expected argument of type contentservice.ListWorkspaceContentRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_ListWorkspaceContentRequest(buffer_arg) {
+  return workspace_pb.ListWorkspaceContentRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_ListWorkspaceContentResponse(arg) {
+  if (!(arg instanceof workspace_pb.ListWorkspaceContentResponse)) {
+    throw new Error('Expected argument of type contentservice.ListWorkspaceContentResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_ListWorkspaceContentResponse(buffer_arg) {
+  return workspace_pb.ListWorkspaceContentResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_RefreshWorkspaceContentURLRequest(arg) {
+  if (!(arg instanceof workspace_pb.RefreshWorkspaceContentURLRequest)) {
+    throw new Error('Expected argument of type contentservice.RefreshWorkspaceContentURLRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_RefreshWorkspaceContentURLRequest(buffer_arg) {
+  return workspace_pb.RefreshWorkspaceContentURLRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_contentservice_RefreshWorkspaceContentURLResponse(arg) {
+  if (!(arg instanceof workspace_pb.RefreshWorkspaceContentURLResponse)) {
+    throw new Error('Expected argument of type contentservice.RefreshWorkspaceContentURLResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_contentservice_RefreshWorkspaceContentURLResponse(buffer_arg) {
+  return workspace_pb.RefreshWorkspaceContentURLResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_contentservice_WorkspaceDownloadURLRequest(arg) {
   if (!(arg instanceof workspace_pb.WorkspaceDownloadURLRequest)) {
     throw new Error('Expected argument of type contentservice.WorkspaceDownloadURLRequest');
@@ -113,6 +179,43 @@ workspaceSnapshotExists: {
     responseSerialize: serialize_contentservice_WorkspaceSnapshotExistsResponse,
     responseDeserialize: deserialize_contentservice_WorkspaceSnapshotExistsResponse,
   },
+  // DeleteSnapshot deletes a single snapshot of a workspace
+deleteSnapshot: {
+    path: '/contentservice.WorkspaceService/DeleteSnapshot',
+    requestStream: false,
+    responseStream: false,
+    requestType: workspace_pb.DeleteSnapshotRequest,
+    responseType: workspace_pb.DeleteSnapshotResponse,
+    requestSerialize: serialize_contentservice_DeleteSnapshotRequest,
+    requestDeserialize: deserialize_contentservice_DeleteSnapshotRequest,
+    responseSerialize: serialize_contentservice_DeleteSnapshotResponse,
+    responseDeserialize: deserialize_contentservice_DeleteSnapshotResponse,
+  },
+  // ListWorkspaceContent lists the backups and snapshots of a workspace
+listWorkspaceContent: {
+    path: '/contentservice.WorkspaceService/ListWorkspaceContent',
+    requestStream: false,
+    responseStream: false,
+    requestType: workspace_pb.ListWorkspaceContentRequest,
+    responseType: workspace_pb.ListWorkspaceContentResponse,
+    requestSerialize: serialize_contentservice_ListWorkspaceContentRequest,
+    requestDeserialize: deserialize_contentservice_ListWorkspaceContentRequest,
+    responseSerialize: serialize_contentservice_ListWorkspaceContentResponse,
+    responseDeserialize: deserialize_contentservice_ListWorkspaceContentResponse,
+  },
+  // RefreshWorkspaceContentURL provides a new download or upload URL for a backup or snapshot, so that
+// long-running transfers can resume once their previous URL expired
+refreshWorkspaceContentURL: {
+    path: '/contentservice.WorkspaceService/RefreshWorkspaceContentURL',
+    requestStream: false,
+    responseStream: false,
+    requestType: workspace_pb.RefreshWorkspaceContentURLRequest,
+    responseType: workspace_pb.RefreshWorkspaceContentURLResponse,
+    requestSerialize: serialize_contentservice_RefreshWorkspaceContentURLRequest,
+    requestDeserialize: deserialize_contentservice_RefreshWorkspaceContentURLRequest,
+    responseSerialize: serialize_contentservice_RefreshWorkspaceContentURLResponse,
+    responseDeserialize: deserialize_contentservice_RefreshWorkspaceContentURLResponse,
+  },
 };
 
 exports.WorkspaceServiceClient = grpc.makeGenericClientConstructor(WorkspaceServiceService);
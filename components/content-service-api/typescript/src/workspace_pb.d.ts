@@ -143,3 +143,193 @@ export namespace WorkspaceSnapshotExistsResponse {
         exists: boolean,
     }
 }
+
+export class DeleteSnapshotRequest extends jspb.Message {
+    getOwnerId(): string;
+    setOwnerId(value: string): DeleteSnapshotRequest;
+    getWorkspaceId(): string;
+    setWorkspaceId(value: string): DeleteSnapshotRequest;
+    getFilename(): string;
+    setFilename(value: string): DeleteSnapshotRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): DeleteSnapshotRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: DeleteSnapshotRequest): DeleteSnapshotRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: DeleteSnapshotRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): DeleteSnapshotRequest;
+    static deserializeBinaryFromReader(message: DeleteSnapshotRequest, reader: jspb.BinaryReader): DeleteSnapshotRequest;
+}
+
+export namespace DeleteSnapshotRequest {
+    export type AsObject = {
+        ownerId: string,
+        workspaceId: string,
+        filename: string,
+    }
+}
+
+export class DeleteSnapshotResponse extends jspb.Message {
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): DeleteSnapshotResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: DeleteSnapshotResponse): DeleteSnapshotResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: DeleteSnapshotResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): DeleteSnapshotResponse;
+    static deserializeBinaryFromReader(message: DeleteSnapshotResponse, reader: jspb.BinaryReader): DeleteSnapshotResponse;
+}
+
+export namespace DeleteSnapshotResponse {
+    export type AsObject = {
+    }
+}
+
+export class ListWorkspaceContentRequest extends jspb.Message {
+    getOwnerId(): string;
+    setOwnerId(value: string): ListWorkspaceContentRequest;
+    getWorkspaceId(): string;
+    setWorkspaceId(value: string): ListWorkspaceContentRequest;
+    getPageSize(): number;
+    setPageSize(value: number): ListWorkspaceContentRequest;
+    getPageToken(): string;
+    setPageToken(value: string): ListWorkspaceContentRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ListWorkspaceContentRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: ListWorkspaceContentRequest): ListWorkspaceContentRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ListWorkspaceContentRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ListWorkspaceContentRequest;
+    static deserializeBinaryFromReader(message: ListWorkspaceContentRequest, reader: jspb.BinaryReader): ListWorkspaceContentRequest;
+}
+
+export namespace ListWorkspaceContentRequest {
+    export type AsObject = {
+        ownerId: string,
+        workspaceId: string,
+        pageSize: number,
+        pageToken: string,
+    }
+}
+
+export class ListWorkspaceContentResponse extends jspb.Message {
+    clearContentList(): void;
+    getContentList(): Array<WorkspaceContent>;
+    setContentList(value: Array<WorkspaceContent>): ListWorkspaceContentResponse;
+    addContent(value?: WorkspaceContent, index?: number): WorkspaceContent;
+    getNextPageToken(): string;
+    setNextPageToken(value: string): ListWorkspaceContentResponse;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ListWorkspaceContentResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: ListWorkspaceContentResponse): ListWorkspaceContentResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ListWorkspaceContentResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ListWorkspaceContentResponse;
+    static deserializeBinaryFromReader(message: ListWorkspaceContentResponse, reader: jspb.BinaryReader): ListWorkspaceContentResponse;
+}
+
+export namespace ListWorkspaceContentResponse {
+    export type AsObject = {
+        contentList: Array<WorkspaceContent.AsObject>,
+        nextPageToken: string,
+    }
+}
+
+export class WorkspaceContent extends jspb.Message {
+    getName(): string;
+    setName(value: string): WorkspaceContent;
+    getKind(): WorkspaceContentKind;
+    setKind(value: WorkspaceContentKind): WorkspaceContent;
+    getSize(): number;
+    setSize(value: number): WorkspaceContent;
+    getLastModified(): number;
+    setLastModified(value: number): WorkspaceContent;
+    getInstanceId(): string;
+    setInstanceId(value: string): WorkspaceContent;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): WorkspaceContent.AsObject;
+    static toObject(includeInstance: boolean, msg: WorkspaceContent): WorkspaceContent.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: WorkspaceContent, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): WorkspaceContent;
+    static deserializeBinaryFromReader(message: WorkspaceContent, reader: jspb.BinaryReader): WorkspaceContent;
+}
+
+export namespace WorkspaceContent {
+    export type AsObject = {
+        name: string,
+        kind: WorkspaceContentKind,
+        size: number,
+        lastModified: number,
+        instanceId: string,
+    }
+}
+
+export class RefreshWorkspaceContentURLRequest extends jspb.Message {
+    getOwnerId(): string;
+    setOwnerId(value: string): RefreshWorkspaceContentURLRequest;
+    getWorkspaceId(): string;
+    setWorkspaceId(value: string): RefreshWorkspaceContentURLRequest;
+    getName(): string;
+    setName(value: string): RefreshWorkspaceContentURLRequest;
+    getTtlSeconds(): number;
+    setTtlSeconds(value: number): RefreshWorkspaceContentURLRequest;
+    getUpload(): boolean;
+    setUpload(value: boolean): RefreshWorkspaceContentURLRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): RefreshWorkspaceContentURLRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: RefreshWorkspaceContentURLRequest): RefreshWorkspaceContentURLRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: RefreshWorkspaceContentURLRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): RefreshWorkspaceContentURLRequest;
+    static deserializeBinaryFromReader(message: RefreshWorkspaceContentURLRequest, reader: jspb.BinaryReader): RefreshWorkspaceContentURLRequest;
+}
+
+export namespace RefreshWorkspaceContentURLRequest {
+    export type AsObject = {
+        ownerId: string,
+        workspaceId: string,
+        name: string,
+        ttlSeconds: number,
+        upload: boolean,
+    }
+}
+
+export class RefreshWorkspaceContentURLResponse extends jspb.Message {
+    getUrl(): string;
+    setUrl(value: string): RefreshWorkspaceContentURLResponse;
+    getExpiresAt(): number;
+    setExpiresAt(value: number): RefreshWorkspaceContentURLResponse;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): RefreshWorkspaceContentURLResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: RefreshWorkspaceContentURLResponse): RefreshWorkspaceContentURLResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: RefreshWorkspaceContentURLResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): RefreshWorkspaceContentURLResponse;
+    static deserializeBinaryFromReader(message: RefreshWorkspaceContentURLResponse, reader: jspb.BinaryReader): RefreshWorkspaceContentURLResponse;
+}
+
+export namespace RefreshWorkspaceContentURLResponse {
+    export type AsObject = {
+        url: string,
+        expiresAt: number,
+    }
+}
+
+export enum WorkspaceContentKind {
+    BACKUP = 0,
+    SNAPSHOT = 1,
+    INSTANCE_BACKUP = 2,
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
+	"github.com/gitpod-io/gitpod/content-service/pkg/migration"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+var encryptOpts struct {
+	storage        string
+	encryption     string
+	workspaces     string
+	state          string
+	bandwidthLimit int64
+	dryRun         bool
+	tempDir        string
+}
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypts the backups and snapshots which were taken before the organization of their workspace had a key",
+	Long: `Encrypts the backups and snapshots which were taken before the organization of their workspace had a key.
+Run it once the key of an organization is available to ws-daemon, which encrypts all backups from then on.
+The storage is configured like the storage of the content service, the keys like the encryption of ws-daemon.
+The workspaces file lists one workspace ID and organization ID per line, e.g. as exported from the database using
+  SELECT id, organizationId FROM d_b_workspace WHERE organizationId != ''
+Deduplicated backups are encrypted as a whole and their chunks deleted.
+Objects which were encrypted are recorded in the state file, such that an interrupted migration resumes where it stopped.`,
+	Example: "encrypt --storage gcloud.json --encryption keys.json --workspaces workspaces.txt --state encryption.state",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		storageCfg, err := getTestConfig(encryptOpts.storage)
+		if err != nil {
+			return xerrors.Errorf("cannot read storage config: %w", err)
+		}
+		encryptionCfg, err := getEncryptionConfig(encryptOpts.encryption)
+		if err != nil {
+			return xerrors.Errorf("cannot read encryption config: %w", err)
+		}
+		organizations, err := migration.ReadOrganizations(encryptOpts.workspaces)
+		if err != nil {
+			return xerrors.Errorf("cannot read workspaces: %w", err)
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		keys, err := encryption.NewKeyProvider(ctx, *encryptionCfg)
+		if err != nil {
+			return xerrors.Errorf("cannot use keys: %w", err)
+		}
+		if keys == nil {
+			return xerrors.Errorf("no key provider is configured")
+		}
+		lifecycle, err := storage.NewLifecycleAccess(storageCfg)
+		if err != nil {
+			return xerrors.Errorf("cannot use storage: %w", err)
+		}
+
+		var state *migration.State
+		if encryptOpts.state != "" {
+			state, err = migration.OpenState(encryptOpts.state)
+			if err != nil {
+				return err
+			}
+			defer state.Close()
+		}
+
+		m := &migration.EncryptionMigrator{
+			Storage: lifecycle,
+			NewDirectAccess: func() (storage.DirectAccess, error) {
+				return storage.NewDirectAccess(storageCfg)
+			},
+			Keys:           keys,
+			Organizations:  organizations,
+			State:          state,
+			BandwidthLimit: encryptOpts.bandwidthLimit,
+			DryRun:         encryptOpts.dryRun,
+			TempDir:        encryptOpts.tempDir,
+		}
+		stats, err := m.Run(ctx)
+		log.WithField("encrypted", stats.Encrypted).
+			WithField("resumed", stats.Resumed).
+			WithField("skipped", stats.Skipped).
+			WithField("failed", stats.Failed).
+			Info("encryption finished")
+		return err
+	},
+}
+
+func getEncryptionConfig(path string) (*config.EncryptionConfig, error) {
+	ctnt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg config.EncryptionConfig
+	err = json.Unmarshal(ctnt, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func init() {
+	encryptCmd.Flags().StringVar(&encryptOpts.storage, "storage", "", "storage config of the content to encrypt")
+	encryptCmd.Flags().StringVar(&encryptOpts.encryption, "encryption", "", "encryption config with the keys of organizations")
+	encryptCmd.Flags().StringVar(&encryptOpts.workspaces, "workspaces", "", "file mapping workspace IDs to the ID of their organization")
+	encryptCmd.Flags().StringVar(&encryptOpts.state, "state", "", "file recording the objects which were encrypted, to resume an interrupted migration")
+	encryptCmd.Flags().Int64Var(&encryptOpts.bandwidthLimit, "bandwidth-limit", 0, "maximum rate in bytes per second content is transferred with, zero means unlimited")
+	encryptCmd.Flags().BoolVar(&encryptOpts.dryRun, "dry-run", false, "only log which objects would be encrypted")
+	encryptCmd.Flags().StringVar(&encryptOpts.tempDir, "temp-dir", "", "directory objects are held in while they are encrypted")
+	_ = encryptCmd.MarkFlagRequired("storage")
+	_ = encryptCmd.MarkFlagRequired("encryption")
+	_ = encryptCmd.MarkFlagRequired("workspaces")
+
+	rootCmd.AddCommand(encryptCmd)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/content-service/pkg/migration"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+var migrateOpts struct {
+	from           string
+	to             string
+	state          string
+	bandwidthLimit int64
+	dryRun         bool
+	tempDir        string
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copies the backups, snapshots, logs and blobs of all users from one remote storage to another",
+	Long: `Copies the backups, snapshots, logs and blobs of all users from one remote storage to another, e.g. when moving an installation from MinIO to S3.
+Both storages are configured like the storage of the content service. Objects are verified against their digest and size.
+Objects which were copied are recorded in the state file, such that an interrupted migration resumes where it stopped.`,
+	Example: "migrate --from minio.json --to s3.json --state migration.state --bandwidth-limit 52428800",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := getTestConfig(migrateOpts.from)
+		if err != nil {
+			return xerrors.Errorf("cannot read source config: %w", err)
+		}
+		to, err := getTestConfig(migrateOpts.to)
+		if err != nil {
+			return xerrors.Errorf("cannot read destination config: %w", err)
+		}
+
+		src, err := storage.NewLifecycleAccess(from)
+		if err != nil {
+			return xerrors.Errorf("cannot use source storage: %w", err)
+		}
+		dst, err := storage.NewPresignedAccess(to)
+		if err != nil {
+			return xerrors.Errorf("cannot use destination storage: %w", err)
+		}
+
+		var state *migration.State
+		if migrateOpts.state != "" {
+			state, err = migration.OpenState(migrateOpts.state)
+			if err != nil {
+				return err
+			}
+			defer state.Close()
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		m := &migration.Migrator{
+			Source:      src,
+			Destination: dst,
+			NewDirectDestination: func() (storage.DirectAccess, error) {
+				return storage.NewDirectAccess(to)
+			},
+			State:          state,
+			BandwidthLimit: migrateOpts.bandwidthLimit,
+			DryRun:         migrateOpts.dryRun,
+			TempDir:        migrateOpts.tempDir,
+		}
+		stats, err := m.Run(ctx)
+		log.WithField("copied", stats.Copied).
+			WithField("bytes", stats.Bytes).
+			WithField("resumed", stats.Resumed).
+			WithField("skipped", stats.Skipped).
+			WithField("failed", stats.Failed).
+			Info("migration finished")
+		return err
+	},
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&migrateOpts.from, "from", "", "storage config to copy content from")
+	migrateCmd.Flags().StringVar(&migrateOpts.to, "to", "", "storage config to copy content to")
+	migrateCmd.Flags().StringVar(&migrateOpts.state, "state", "", "file recording the objects which were copied, to resume an interrupted migration")
+	migrateCmd.Flags().Int64Var(&migrateOpts.bandwidthLimit, "bandwidth-limit", 0, "maximum rate in bytes per second content is copied with, zero means unlimited")
+	migrateCmd.Flags().BoolVar(&migrateOpts.dryRun, "dry-run", false, "only log which objects would be copied")
+	migrateCmd.Flags().StringVar(&migrateOpts.tempDir, "temp-dir", "", "directory objects are held in while they are copied")
+	_ = migrateCmd.MarkFlagRequired("from")
+	_ = migrateCmd.MarkFlagRequired("to")
+
+	rootCmd.AddCommand(migrateCmd)
+}
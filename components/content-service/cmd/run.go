@@ -5,10 +5,14 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/retention"
 	"github.com/gitpod-io/gitpod/content-service/pkg/service"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -58,6 +62,24 @@ var runCmd = &cobra.Command{
 		}
 		api.RegisterIDEPluginServiceServer(srv.GRPC(), idePluginService)
 
+		if cfg.Retention.Enabled {
+			lifecycleAccess, err := storage.NewLifecycleAccess(&cfg.Storage)
+			if err != nil {
+				log.WithError(err).Fatal("Cannot create retention reconciler")
+			}
+			var refs retention.SnapshotReferences
+			if cfg.Retention.KeepSnapshots > 0 {
+				// snapshots which the database still references must never be deleted
+				db, err := retention.OpenDatabase()
+				if err != nil {
+					log.WithError(err).Fatal("Cannot connect to the database to find referenced snapshots")
+				}
+				refs = &retention.DBSnapshotReferences{DB: db}
+			}
+			reconciler := retention.NewReconciler(cfg.Retention, lifecycleAccess, refs, srv.MetricsRegistry())
+			go reconciler.Start(context.Background())
+		}
+
 		err = srv.ListenAndServe()
 		if err != nil {
 			log.WithError(err).Fatal("Cannot start server")
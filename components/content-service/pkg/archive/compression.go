@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/xerrors"
+)
+
+// Compression is the algorithm an archive is compressed with
+type Compression string
+
+const (
+	// CompressionNone leaves archives uncompressed
+	CompressionNone Compression = ""
+
+	// CompressionGzip compresses archives using gzip, with levels from 1 to 9
+	CompressionGzip Compression = "gzip"
+
+	// CompressionZstd compresses archives using zstd, with levels from 1 to 22
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Validate checks if the algorithm is supported and the level is valid for it. Level 0 selects the default level.
+func (c Compression) Validate(level int) error {
+	var maxLevel int
+	switch c {
+	case CompressionNone:
+		if level != 0 {
+			return xerrors.Errorf("uncompressed archives have no compression level")
+		}
+		return nil
+	case CompressionGzip:
+		maxLevel = gzip.BestCompression
+	case CompressionZstd:
+		maxLevel = 22
+	default:
+		return xerrors.Errorf("unsupported compression: %s", c)
+	}
+	if level < 0 || level > maxLevel {
+		return xerrors.Errorf("%s compression level must be between 1 and %d", c, maxLevel)
+	}
+	return nil
+}
+
+// NewCompressedWriter compresses everything written to w. Closing the writer flushes the compressed data, but does not close w.
+func NewCompressedWriter(w io.Writer, c Compression, level int) (io.WriteCloser, error) {
+	err := c.Validate(level)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c {
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CompressionZstd:
+		var opts []zstd.EOption
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Decompress detects the compression of an archive by its magic bytes and returns a reader of the uncompressed archive.
+// Archives which are not compressed are read as they are.
+func Decompress(r io.Reader) (io.ReadCloser, Compression, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, CompressionNone, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, CompressionGzip, err
+		}
+		return gr, CompressionGzip, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, CompressionZstd, err
+		}
+		return zr.IOReadCloser(), CompressionZstd, nil
+	default:
+		return io.NopCloser(br), CompressionNone, nil
+	}
+}
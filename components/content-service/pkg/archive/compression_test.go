@@ -0,0 +1,101 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	content := bytes.Repeat([]byte("workspace content "), 1000)
+
+	tests := []struct {
+		Compression Compression
+		Level       int
+	}{
+		{Compression: CompressionNone},
+		{Compression: CompressionGzip},
+		{Compression: CompressionGzip, Level: 9},
+		{Compression: CompressionZstd},
+		{Compression: CompressionZstd, Level: 1},
+		{Compression: CompressionZstd, Level: 19},
+	}
+	for _, test := range tests {
+		name := string(test.Compression)
+		if name == "" {
+			name = "none"
+		}
+		t.Run(fmt.Sprintf("%s level %d", name, test.Level), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewCompressedWriter(&buf, test.Compression, test.Level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = w.Write(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.Compression != CompressionNone && buf.Len() >= len(content) {
+				t.Errorf("archive was not compressed: %d bytes", buf.Len())
+			}
+
+			r, compression, err := Decompress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if compression != test.Compression {
+				t.Errorf("detected %q compression, expected %q", compression, test.Compression)
+			}
+			act, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, act) {
+				t.Errorf("unexpected content after decompression")
+			}
+		})
+	}
+}
+
+func TestCompressionValidate(t *testing.T) {
+	tests := []struct {
+		Compression Compression
+		Level       int
+		Valid       bool
+	}{
+		{Compression: CompressionNone, Valid: true},
+		{Compression: CompressionNone, Level: 3},
+		{Compression: CompressionGzip, Level: 9, Valid: true},
+		{Compression: CompressionGzip, Level: 10},
+		{Compression: CompressionZstd, Level: 22, Valid: true},
+		{Compression: CompressionZstd, Level: -1},
+		{Compression: "lz4"},
+	}
+	for _, test := range tests {
+		err := test.Compression.Validate(test.Level)
+		if (err == nil) != test.Valid {
+			t.Errorf("%q at level %d: expected valid=%v, got %v", test.Compression, test.Level, test.Valid, err)
+		}
+	}
+}
+
+func TestDecompressEmpty(t *testing.T) {
+	r, compression, err := Decompress(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compression != CompressionNone {
+		t.Errorf("detected %q compression in empty archive", compression)
+	}
+	r.Close()
+}
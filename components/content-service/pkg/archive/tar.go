@@ -28,6 +28,12 @@ import (
 type TarConfig struct {
 	UIDMaps []IDMapping
 	GIDMaps []IDMapping
+
+	Compression      Compression
+	CompressionLevel int
+
+	// Encrypt wraps the writer of the archive file, such that the archive is encrypted after it was compressed
+	Encrypt func(w io.Writer) (io.WriteCloser, error)
 }
 
 // BuildTarbalOption configures the tarbal creation
@@ -54,6 +60,21 @@ func WithGIDMapping(mappings []IDMapping) TarOption {
 	}
 }
 
+// WithCompression compresses the archive during creation. Level 0 selects the default level of the algorithm.
+func WithCompression(c Compression, level int) TarOption {
+	return func(o *TarConfig) {
+		o.Compression = c
+		o.CompressionLevel = level
+	}
+}
+
+// WithEncryption encrypts the archive during creation, after it was compressed
+func WithEncryption(encrypt func(w io.Writer) (io.WriteCloser, error)) TarOption {
+	return func(o *TarConfig) {
+		o.Encrypt = encrypt
+	}
+}
+
 // ExtractTarbal extracts an OCI compatible tar file src to the folder dst, expecting the overlay whiteout format
 func ExtractTarbal(ctx context.Context, src io.Reader, dst string, opts ...TarOption) (err error) {
 	type Info struct {
@@ -73,8 +94,16 @@ func ExtractTarbal(ctx context.Context, src io.Reader, dst string, opts ...TarOp
 		opt(&cfg)
 	}
 
+	// archives may be compressed, which we detect regardless of the compression configured when they were created
+	decompressed, compression, err := Decompress(src)
+	if err != nil {
+		return xerrors.Errorf("cannot decompress archive: %w", err)
+	}
+	defer decompressed.Close()
+	span.LogKV("compression", string(compression))
+
 	pipeReader, pipeWriter := io.Pipe()
-	teeReader := io.TeeReader(src, pipeWriter)
+	teeReader := io.TeeReader(decompressed, pipeWriter)
 
 	tarReader := tar.NewReader(pipeReader)
 
@@ -23,8 +23,9 @@ func TestExtractTarbal(t *testing.T) {
 		Mode        int
 	}
 	tests := []struct {
-		Name  string
-		Files []file
+		Name        string
+		Files       []file
+		Compression Compression
 	}{
 		{
 			Name: "simple-test",
@@ -37,14 +38,30 @@ func TestExtractTarbal(t *testing.T) {
 			Name:  "empty-tar",
 			Files: []file{},
 		},
+		{
+			Name: "gzip-compressed",
+			Files: []file{
+				{"file.txt", 1024, 33333, 0644},
+			},
+			Compression: CompressionGzip,
+		},
+		{
+			Name: "zstd-compressed",
+			Files: []file{
+				{"file.txt", 1024, 33333, 0644},
+			},
+			Compression: CompressionZstd,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			var (
-				buf = bytes.NewBuffer(nil)
-				tw  = tar.NewWriter(buf)
-			)
+			buf := bytes.NewBuffer(nil)
+			cw, err := NewCompressedWriter(buf, test.Compression, 0)
+			if err != nil {
+				t.Fatalf("cannot prepare archive: %q", err)
+			}
+			tw := tar.NewWriter(cw)
 
 			for _, file := range test.Files {
 				err := tw.WriteHeader(&tar.Header{
@@ -65,6 +82,7 @@ func TestExtractTarbal(t *testing.T) {
 			}
 			tw.Flush()
 			tw.Close()
+			cw.Close()
 
 			wd, err := os.MkdirTemp("", "")
 			defer os.RemoveAll(wd)
@@ -0,0 +1,363 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// magic precedes the header of encrypted archives, so that they can be told apart from unencrypted ones
+	magic = "gitpod-encrypted/v1\n"
+
+	// maxHeaderSize bounds the header we're willing to read
+	maxHeaderSize = 64 * 1024
+
+	// segmentSize is the size of the plaintext segments which are encrypted individually, such that archives
+	// can be decrypted while they're streamed without holding them in memory
+	segmentSize = 64 * 1024
+
+	// dataKeySize is the size of the AES-256 data keys archives are encrypted with
+	dataKeySize = 32
+
+	// noncePrefixSize is the size of the random part of the segment nonces. The remaining five bytes hold
+	// the segment counter and whether the segment is the final one.
+	noncePrefixSize = 7
+)
+
+var (
+	// ErrNoKey is returned when an organization has no key, in which case its content remains unencrypted
+	ErrNoKey = errors.New("organization has no encryption key")
+
+	// ErrCorrupted is returned when encrypted content cannot be decrypted because it was truncated or modified
+	ErrCorrupted = errors.New("encrypted content is corrupted")
+)
+
+// Header describes how an archive is encrypted. It precedes the encrypted segments of the archive.
+type Header struct {
+	// Provider is the name of the key provider which wrapped the data key
+	Provider string `json:"provider"`
+	// Organization owns the key the data key is wrapped with
+	Organization string `json:"organization"`
+	// KeyID identifies the key of the organization the data key is wrapped with
+	KeyID string `json:"keyId"`
+	// WrappedKey is the data key of the archive, encrypted with the key of the organization
+	WrappedKey []byte `json:"wrappedKey"`
+	// NoncePrefix is the random part of the segment nonces
+	NoncePrefix []byte `json:"noncePrefix"`
+}
+
+// UnwrapKey decrypts the data key of an archive using the key provider it was wrapped by
+func UnwrapKey(ctx context.Context, kp KeyProvider, hdr *Header) ([]byte, error) {
+	if kp == nil {
+		return nil, xerrors.Errorf("content is encrypted using %s, but no key provider is configured", hdr.Provider)
+	}
+	if hdr.Provider != kp.Name() {
+		return nil, xerrors.Errorf("content is encrypted using %s, but %s is configured", hdr.Provider, kp.Name())
+	}
+
+	key, err := kp.UnwrapKey(ctx, hdr.Organization, hdr.KeyID, hdr.WrappedKey)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unwrap key of organization %s using key %s: %w", hdr.Organization, hdr.KeyID, err)
+	}
+	if len(key) != dataKeySize {
+		return nil, xerrors.Errorf("unwrapped key has invalid size %d", len(key))
+	}
+	return key, nil
+}
+
+// Encrypter encrypts archives with a data key wrapped by the current key of an organization
+type Encrypter struct {
+	header Header
+	key    []byte
+}
+
+// NewEncrypter generates a data key and wraps it with the current key of the organization.
+// Returns ErrNoKey if the organization has no key.
+func NewEncrypter(ctx context.Context, kp KeyProvider, organization string) (*Encrypter, error) {
+	key := make([]byte, dataKeySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, wrapped, err := kp.WrapKey(ctx, organization, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encrypter{
+		header: Header{
+			Provider:     kp.Name(),
+			Organization: organization,
+			KeyID:        keyID,
+			WrappedKey:   wrapped,
+		},
+		key: key,
+	}, nil
+}
+
+// KeyID identifies the key of the organization the data key is wrapped with
+func (e *Encrypter) KeyID() string {
+	return e.header.KeyID
+}
+
+// Writer encrypts everything written to w. Closing the writer writes the final segment, but does not close w.
+func (e *Encrypter) Writer(w io.Writer) (io.WriteCloser, error) {
+	aead, err := newAEAD(e.key)
+	if err != nil {
+		return nil, err
+	}
+
+	// every writer gets its own nonces, such that writing the same archive again never reuses a nonce
+	hdr := e.header
+	hdr.NoncePrefix = make([]byte, noncePrefixSize)
+	_, err = io.ReadFull(rand.Reader, hdr.NoncePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeHeader(w, &hdr)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot write encryption header: %w", err)
+	}
+
+	return &writer{
+		w:           w,
+		aead:        aead,
+		noncePrefix: hdr.NoncePrefix,
+		buf:         make([]byte, 0, segmentSize+aead.Overhead()),
+	}, nil
+}
+
+// Decrypt returns a reader of the plaintext of r. keyFor is called with the header of encrypted content to
+// obtain its data key. Unencrypted content, e.g. backups taken before encryption was configured, is read as it is.
+func Decrypt(r io.Reader, keyFor func(hdr *Header) ([]byte, error)) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, segmentSize)
+	if !IsEncrypted(br) {
+		return br, nil
+	}
+
+	hdr, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keyFor(hdr)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{
+		r:           br,
+		aead:        aead,
+		noncePrefix: hdr.NoncePrefix,
+		buf:         make([]byte, segmentSize+aead.Overhead()),
+	}, nil
+}
+
+// ReadHeader reads the encryption header of an archive. If r is not encrypted, nil is returned without an error.
+func ReadHeader(r io.Reader) (*Header, error) {
+	br := bufio.NewReader(r)
+	if !IsEncrypted(br) {
+		return nil, nil
+	}
+	return readHeader(br)
+}
+
+// IsEncrypted checks if a stream starts with an encryption header without consuming it
+func IsEncrypted(br *bufio.Reader) bool {
+	header, _ := br.Peek(len(magic))
+	return bytes.Equal(header, []byte(magic))
+}
+
+func writeHeader(w io.Writer, hdr *Header) error {
+	ctnt, err := json.Marshal(hdr)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, len(magic)+4+len(ctnt))
+	buf = append(buf, magic...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(ctnt)))
+	buf = append(buf, ctnt...)
+	_, err = w.Write(buf)
+	return err
+}
+
+func readHeader(br *bufio.Reader) (*Header, error) {
+	_, err := br.Discard(len(magic))
+	if err != nil {
+		return nil, err
+	}
+
+	var size uint32
+	err = binary.Read(br, binary.BigEndian, &size)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read encryption header: %w", err)
+	}
+	if size > maxHeaderSize {
+		return nil, xerrors.Errorf("encryption header exceeds %d bytes", maxHeaderSize)
+	}
+	ctnt := make([]byte, size)
+	_, err = io.ReadFull(br, ctnt)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read encryption header: %w", err)
+	}
+
+	var hdr Header
+	err = json.Unmarshal(ctnt, &hdr)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse encryption header: %w", err)
+	}
+	if len(hdr.NoncePrefix) != noncePrefixSize {
+		return nil, xerrors.Errorf("invalid encryption header: nonce prefix has size %d", len(hdr.NoncePrefix))
+	}
+	return &hdr, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce derives the nonce of a segment. Marking the final segment lets readers detect truncated content.
+func segmentNonce(prefix []byte, seq uint32, final bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, seq)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+type writer struct {
+	w           io.Writer
+	aead        cipher.AEAD
+	noncePrefix []byte
+	seq         uint32
+	buf         []byte
+	closed      bool
+}
+
+func (w *writer) Write(p []byte) (n int, err error) {
+	if w.closed {
+		return 0, xerrors.Errorf("write to closed writer")
+	}
+	for len(p) > 0 {
+		// a full segment is only written once we know it's not the final one
+		if len(w.buf) == segmentSize {
+			err = w.flush(false)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		m := segmentSize - len(w.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(true)
+}
+
+func (w *writer) flush(final bool) error {
+	if w.seq == math.MaxUint32 {
+		return xerrors.Errorf("content exceeds the maximum size")
+	}
+
+	sealed := w.aead.Seal(w.buf[:0], segmentNonce(w.noncePrefix, w.seq, final), w.buf, nil)
+	_, err := w.w.Write(sealed)
+	if err != nil {
+		return err
+	}
+	w.seq++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+type reader struct {
+	r           *bufio.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	seq         uint32
+	buf         []byte
+	plain       []byte
+	done        bool
+}
+
+func (r *reader) Read(p []byte) (n int, err error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		err = r.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *reader) next() error {
+	n, err := io.ReadFull(r.r, r.buf)
+	var final bool
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		final = true
+	case err != nil:
+		return err
+	default:
+		// a full segment is the final one if nothing follows it
+		_, err = r.r.Peek(1)
+		if err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	plain, err := r.aead.Open(r.buf[:0], segmentNonce(r.noncePrefix, r.seq, final), r.buf[:n], nil)
+	if err != nil {
+		return xerrors.Errorf("%w: cannot decrypt segment %d", ErrCorrupted, r.seq)
+	}
+	r.seq++
+	r.plain = plain
+	r.done = final
+	return nil
+}
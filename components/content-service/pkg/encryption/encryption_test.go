@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func addTestKey(t *testing.T, dir, organization, keyID string) {
+	key := make([]byte, dataKeySize)
+	_, err := rand.Read(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(dir, organization), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, organization, keyID+".key"), []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func encrypt(t *testing.T, kp KeyProvider, organization string, content []byte) []byte {
+	enc, err := NewEncrypter(context.Background(), kp, organization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w, err := enc.Writer(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// write in odd pieces to exercise the segmentation
+	for len(content) > 0 {
+		n := 1000
+		if n > len(content) {
+			n = len(content)
+		}
+		_, err = w.Write(content[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = content[n:]
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(kp KeyProvider, ciphertext []byte) ([]byte, error) {
+	r, err := Decrypt(bytes.NewReader(ciphertext), func(hdr *Header) ([]byte, error) {
+		return UnwrapKey(context.Background(), kp, hdr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryption(t *testing.T) {
+	kp := &LocalKeys{Dir: t.TempDir()}
+	addTestKey(t, kp.Dir, "org1", "1")
+
+	tests := []struct {
+		Name string
+		Size int
+	}{
+		{Name: "empty", Size: 0},
+		{Name: "single segment", Size: 1234},
+		{Name: "exactly one segment", Size: segmentSize},
+		{Name: "exactly two segments", Size: 2 * segmentSize},
+		{Name: "several segments", Size: 3*segmentSize + 17},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			content := make([]byte, test.Size)
+			_, _ = rand.Read(content)
+
+			ciphertext := encrypt(t, kp, "org1", content)
+			if test.Size > 0 && bytes.Contains(ciphertext, content) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+
+			act, err := decrypt(kp, ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, act) {
+				t.Fatal("decrypted content does not match the plaintext")
+			}
+		})
+	}
+}
+
+func TestDecryptCorrupted(t *testing.T) {
+	kp := &LocalKeys{Dir: t.TempDir()}
+	addTestKey(t, kp.Dir, "org1", "1")
+
+	content := make([]byte, 2*segmentSize+100)
+	_, _ = rand.Read(content)
+	ciphertext := encrypt(t, kp, "org1", content)
+	lastSegment := len(ciphertext) - (100 + 16)
+
+	tests := []struct {
+		Name    string
+		Corrupt func(c []byte) []byte
+	}{
+		{Name: "truncated segment", Corrupt: func(c []byte) []byte { return c[:len(c)-1] }},
+		{Name: "missing final segment", Corrupt: func(c []byte) []byte { return c[:lastSegment] }},
+		{Name: "modified segment", Corrupt: func(c []byte) []byte {
+			c = append([]byte(nil), c...)
+			c[lastSegment-10]++
+			return c
+		}},
+		{Name: "appended content", Corrupt: func(c []byte) []byte { return append(append([]byte(nil), c...), 42) }},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := decrypt(kp, test.Corrupt(ciphertext))
+			if !errors.Is(err, ErrCorrupted) {
+				t.Fatalf("expected corrupted content, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecryptUnencrypted(t *testing.T) {
+	content := []byte("an archive taken before encryption was configured")
+	r, err := Decrypt(bytes.NewReader(content), func(hdr *Header) ([]byte, error) {
+		t.Fatal("unencrypted content must not ask for a key")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, act) {
+		t.Fatal("unencrypted content was modified")
+	}
+
+	hdr, err := ReadHeader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr != nil {
+		t.Error("expected unencrypted content to have no header")
+	}
+}
+
+func TestLocalKeyRotation(t *testing.T) {
+	kp := &LocalKeys{Dir: t.TempDir()}
+	addTestKey(t, kp.Dir, "org1", "2023-01")
+	addTestKey(t, kp.Dir, "org2", "2023-01")
+
+	_, err := NewEncrypter(context.Background(), kp, "org3")
+	if !errors.Is(err, ErrNoKey) {
+		t.Fatalf("expected organization without key to have no key, got %v", err)
+	}
+
+	content := []byte("workspace content")
+	old := encrypt(t, kp, "org1", content)
+
+	addTestKey(t, kp.Dir, "org1", "2023-06")
+	hdr, err := ReadHeader(bytes.NewReader(encrypt(t, kp, "org1", content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.KeyID != "2023-06" {
+		t.Errorf("expected content to be encrypted with the newest key, got %s", hdr.KeyID)
+	}
+
+	act, err := decrypt(kp, old)
+	if err != nil {
+		t.Fatalf("cannot decrypt content encrypted with a previous key: %v", err)
+	}
+	if !bytes.Equal(content, act) {
+		t.Fatal("decrypted content does not match the plaintext")
+	}
+
+	// the key of one organization must not unwrap the data keys of another one
+	hdr, err = ReadHeader(bytes.NewReader(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = kp.UnwrapKey(context.Background(), "org2", hdr.KeyID, hdr.WrappedKey)
+	if err == nil {
+		t.Fatal("expected key of another organization to be rejected")
+	}
+	_, err = kp.UnwrapKey(context.Background(), "org1", "../org2/2023-01", hdr.WrappedKey)
+	if err == nil {
+		t.Fatal("expected key outside of the organization's directory to be rejected")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+)
+
+// gcpKMS wraps data keys using the keys of organizations held in Google Cloud KMS
+type gcpKMS struct {
+	keys *cloudkms.ProjectsLocationsKeyRingsCryptoKeysService
+	cfg  *config.GCPKMSConfig
+}
+
+var _ KeyProvider = &gcpKMS{}
+
+func newGCPKMS(ctx context.Context, cfg *config.GCPKMSConfig) (*gcpKMS, error) {
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	svc, err := cloudkms.NewService(ctx, opts...)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create GCP KMS client: %w", err)
+	}
+
+	return &gcpKMS{
+		keys: svc.Projects.Locations.KeyRings.CryptoKeys,
+		cfg:  cfg,
+	}, nil
+}
+
+// Name identifies the provider in the header of encrypted archives
+func (k *gcpKMS) Name() string {
+	return string(config.EncryptionProviderGCPKMS)
+}
+
+// WrapKey encrypts a data key with the primary version of the organization's key. The ID of the key is the
+// resource name of that version.
+func (k *gcpKMS) WrapKey(ctx context.Context, organization string, key []byte) (keyID string, wrapped []byte, err error) {
+	name, ok := k.cfg.Keys[organization]
+	if !ok {
+		return "", nil, ErrNoKey
+	}
+
+	resp, err := k.keys.Encrypt(name, &cloudkms.EncryptRequest{
+		Plaintext:                   base64.StdEncoding.EncodeToString(key),
+		AdditionalAuthenticatedData: base64.StdEncoding.EncodeToString([]byte(organization)),
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", nil, xerrors.Errorf("cannot encrypt using %s: %w", name, err)
+	}
+	wrapped, err = base64.StdEncoding.DecodeString(resp.Ciphertext)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.Name, wrapped, nil
+}
+
+// UnwrapKey decrypts a data key which was wrapped with a version of the organization's key
+func (k *gcpKMS) UnwrapKey(ctx context.Context, organization, keyID string, wrapped []byte) (key []byte, err error) {
+	name, ok := k.cfg.Keys[organization]
+	if !ok {
+		return nil, ErrNoKey
+	}
+	// KMS finds the version from the ciphertext, but we make sure the key belongs to the organization
+	if !strings.HasPrefix(keyID, name+"/cryptoKeyVersions/") {
+		return nil, xerrors.Errorf("key %s does not belong to organization %s", keyID, organization)
+	}
+
+	resp, err := k.keys.Decrypt(name, &cloudkms.DecryptRequest{
+		Ciphertext:                  base64.StdEncoding.EncodeToString(wrapped),
+		AdditionalAuthenticatedData: base64.StdEncoding.EncodeToString([]byte(organization)),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot decrypt using %s: %w", name, err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
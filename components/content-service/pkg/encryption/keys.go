@@ -0,0 +1,162 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+)
+
+// KeyProvider wraps the data keys of archives with the keys of organizations
+type KeyProvider interface {
+	// Name identifies the provider in the header of encrypted archives
+	Name() string
+
+	// WrapKey encrypts a data key with the current key of the organization and returns the ID of that key.
+	// Returns ErrNoKey if the organization has no key.
+	WrapKey(ctx context.Context, organization string, key []byte) (keyID string, wrapped []byte, err error)
+
+	// UnwrapKey decrypts a data key which was wrapped with the given key of the organization
+	UnwrapKey(ctx context.Context, organization, keyID string, wrapped []byte) (key []byte, err error)
+}
+
+// NewKeyProvider produces the configured key provider. Returns nil if encryption is not configured.
+func NewKeyProvider(ctx context.Context, cfg config.EncryptionConfig) (KeyProvider, error) {
+	switch cfg.Provider {
+	case config.EncryptionProviderNone:
+		return nil, nil
+	case config.EncryptionProviderLocal:
+		if cfg.Local == nil || cfg.Local.KeysDir == "" {
+			return nil, xerrors.Errorf("local key provider requires a keys directory")
+		}
+		return &LocalKeys{Dir: cfg.Local.KeysDir}, nil
+	case config.EncryptionProviderGCPKMS:
+		if cfg.GCPKMS == nil {
+			return nil, xerrors.Errorf("missing GCP KMS config")
+		}
+		return newGCPKMS(ctx, cfg.GCPKMS)
+	default:
+		return nil, xerrors.Errorf("unsupported key provider: %s", cfg.Provider)
+	}
+}
+
+// LocalKeys reads the keys of organizations from disk, see config.LocalKeysConfig for the layout.
+// Keys are read whenever they're used, such that keys can be added without a restart.
+type LocalKeys struct {
+	Dir string
+}
+
+var _ KeyProvider = &LocalKeys{}
+
+// Name identifies the provider in the header of encrypted archives
+func (l *LocalKeys) Name() string {
+	return string(config.EncryptionProviderLocal)
+}
+
+// WrapKey encrypts a data key with the key of the organization whose ID sorts last
+func (l *LocalKeys) WrapKey(ctx context.Context, organization string, key []byte) (keyID string, wrapped []byte, err error) {
+	ids, err := l.keyIDs(organization)
+	if err != nil {
+		return "", nil, err
+	}
+	keyID = ids[len(ids)-1]
+
+	aead, err := l.keyEncryption(organization, keyID)
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// binding the wrapped key to the organization prevents it from being passed off as the key of another one
+	return keyID, aead.Seal(nonce, nonce, key, []byte(organization)), nil
+}
+
+// UnwrapKey decrypts a data key which was wrapped with the given key of the organization
+func (l *LocalKeys) UnwrapKey(ctx context.Context, organization, keyID string, wrapped []byte) (key []byte, err error) {
+	aead, err := l.keyEncryption(organization, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrCorrupted
+	}
+
+	nonce, ciphertext := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	key, err = aead.Open(nil, nonce, ciphertext, []byte(organization))
+	if err != nil {
+		return nil, xerrors.Errorf("%w: cannot unwrap key", ErrCorrupted)
+	}
+	return key, nil
+}
+
+// keyIDs lists the IDs of the keys of an organization in ascending order
+func (l *LocalKeys) keyIDs(organization string) ([]string, error) {
+	if !isPathSegment(organization) {
+		return nil, ErrNoKey
+	}
+
+	entries, err := os.ReadDir(filepath.Join(l.Dir, organization))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), ".key")
+		if e.IsDir() || id == e.Name() || !isPathSegment(id) {
+			continue
+		}
+		res = append(res, id)
+	}
+	if len(res) == 0 {
+		return nil, ErrNoKey
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (l *LocalKeys) keyEncryption(organization, keyID string) (cipher.AEAD, error) {
+	if !isPathSegment(organization) || !isPathSegment(keyID) {
+		return nil, xerrors.Errorf("invalid key %s of organization %s", keyID, organization)
+	}
+
+	ctnt, err := os.ReadFile(filepath.Join(l.Dir, organization, keyID+".key"))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read key %s of organization %s: %w", keyID, organization, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(ctnt)))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot decode key %s of organization %s: %w", keyID, organization, err)
+	}
+	if len(key) != dataKeySize {
+		return nil, xerrors.Errorf("key %s of organization %s must be %d bytes long", keyID, organization, dataKeySize)
+	}
+	return newAEAD(key)
+}
+
+// isPathSegment checks if s can safely be used as a single segment of a path
+func isPathSegment(s string) bool {
+	return s != "" && !strings.HasPrefix(s, ".") && !strings.ContainsAny(s, `/\`)
+}
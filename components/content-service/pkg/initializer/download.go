@@ -16,6 +16,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/tracing"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	"github.com/opencontainers/go-digest"
 	"github.com/opentracing/opentracing-go"
 	"golang.org/x/sync/errgroup"
@@ -127,7 +128,7 @@ func (ws *fileDownloadInitializer) downloadFile(ctx context.Context, info fileIn
 				return err
 			}
 			if dgst != info.Digest {
-				return xerrors.Errorf("digest mismatch")
+				return &storage.DigestMismatchError{Expected: info.Digest, Actual: dgst}
 			}
 			return nil
 		})
@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	"github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	"github.com/opencontainers/go-digest"
 )
 
@@ -74,7 +75,7 @@ func TestFileDownloadInitializer(t *testing.T) {
 			ServerSide: []serverSideFile{
 				{Path: "/file1", Content: defaultContent},
 			},
-			ExpectedError: "digest mismatch",
+			ExpectedError: (&storage.DigestMismatchError{Expected: digest.FromString(defaultContent + "foobar"), Actual: digest.FromString(defaultContent)}).Error(),
 		},
 		{
 			Name: "file not found",
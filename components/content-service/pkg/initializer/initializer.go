@@ -162,6 +162,14 @@ func NewFromRequest(ctx context.Context, loc string, rs storage.DirectDownloader
 		initializer, err = newFileDownloadInitializer(loc, ir.Download)
 	} else if ir, ok := spec.(*csapi.WorkspaceInitializer_Backup); ok {
 		initializer, err = newFromBackupInitializer(loc, rs, ir.Backup)
+	} else if ir, ok := spec.(*csapi.WorkspaceInitializer_VolumeSnapshot); ok {
+		// Content restored from a volume snapshot never passes through here, because the workspace volume
+		// already holds it and ws-daemon skips the initializer. We only end up here if the volume snapshot
+		// is missing, in which case we use the fallback.
+		if ir.VolumeSnapshot == nil || ir.VolumeSnapshot.Fallback == nil {
+			return &EmptyInitializer{}, nil
+		}
+		return NewFromRequest(ctx, loc, rs, ir.VolumeSnapshot.Fallback, opts)
 	} else {
 		initializer = &EmptyInitializer{}
 	}
@@ -241,6 +241,10 @@ func (*testStorage) ObjectExists(ctx context.Context, bucket string, path string
 	return false, nil
 }
 
+func (*testStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]storage.ObjectInfo, error) {
+	return nil, nil
+}
+
 type roundTripFunc func(req *http.Request) *http.Response
 
 // RoundTrip .
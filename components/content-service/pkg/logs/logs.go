@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"golang.org/x/xerrors"
 )
@@ -23,13 +24,45 @@ const (
 
 	prebuildLogFilePrefix = "prebuild-log-"
 
+	prebuildSummaryFileName = "prebuild-summary.json"
+
 	legacyTerminalStoreLocation = "/workspace"
 	legacyPrebuildLogFilePrefix = ".prebuild-log-"
 
 	// UploadedHeadlessLogPathPrefix is the prefix under which headless logs are stored inside an instance
 	UploadedHeadlessLogPathPrefix = "logs"
+
+	// UploadedPrebuildSummaryPath is the path relative to the workspace instance under which the prebuild summary is stored.
+	// It lives outside of UploadedHeadlessLogPathPrefix, since everything there is considered a task log.
+	UploadedPrebuildSummaryPath = "prebuild-summary.json"
+
+	// BuildLogBlobPrefix is the prefix of the blobs the logs of image builds are stored in
+	BuildLogBlobPrefix = "image-builds/"
 )
 
+// PrebuildSummary summarizes how the tasks of a prebuild went.
+type PrebuildSummary struct {
+	Tasks []PrebuildTaskSummary `json:"tasks"`
+}
+
+// PrebuildTaskSummary summarizes how a single prebuild task went. The output of the task
+// is found in the log stream of the same ID.
+type PrebuildTaskSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// ExitCode is the exit code of the task's shell, nil if the task did not finish
+	ExitCode *int `json:"exitCode,omitempty"`
+	// Failure describes why the task failed, empty if it succeeded
+	Failure         string     `json:"failure,omitempty"`
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	DurationSeconds float64    `json:"durationSeconds"`
+}
+
+// BuildLogBlobName returns the name of the blob the log of an image build is stored in
+func BuildLogBlobName(buildID string) string {
+	return fmt.Sprintf("%s%s.log", BuildLogBlobPrefix, buildID)
+}
+
 // UploadedHeadlessLogPath returns the path relative to the workspace instance
 func UploadedHeadlessLogPath(taskID string) string {
 	return fmt.Sprintf("%s/%s", UploadedHeadlessLogPathPrefix, taskID)
@@ -40,6 +73,20 @@ func PrebuildLogFileName(storeLocation string, taskId string) string {
 	return storeLocation + "/" + prebuildLogFilePrefix + taskId
 }
 
+// PrebuildSummaryFileName is the absolute path to the file containing the summary of the prebuild tasks
+func PrebuildSummaryFileName(storeLocation string) string {
+	return storeLocation + "/" + prebuildSummaryFileName
+}
+
+// FindPrebuildSummaryFile returns the path of the prebuild summary in the workspace, if there is one. Location is assumed to be the base dir of the workspace session
+func FindPrebuildSummaryFile(location string) (filePath string, exists bool) {
+	filePath = filepath.Join(location, strings.TrimPrefix(TerminalStoreLocation, "/workspace"), prebuildSummaryFileName)
+	if _, err := os.Stat(filePath); err != nil {
+		return "", false
+	}
+	return filePath, true
+}
+
 // LegacyPrebuildLogFileName is the absolute path to the file containing the output of the prebuild log for the given
 // task in older workspaces
 func LegacyPrebuildLogFileName(taskId string) string {
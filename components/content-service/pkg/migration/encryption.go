@@ -0,0 +1,355 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package migration
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+// EncryptionMigrator encrypts the backups and snapshots which were taken before the organization of their
+// workspace had a key. ws-daemon encrypts all content it uploads from then on, hence this is needed only once
+// per organization, after its key was added.
+type EncryptionMigrator struct {
+	Storage storage.LifecycleAccess
+	// NewDirectAccess provides direct access to the storage, which uploads workspace content with its annotations
+	NewDirectAccess func() (storage.DirectAccess, error)
+	Keys            encryption.KeyProvider
+	// Organizations maps workspace IDs to the ID of their organization. The content of other workspaces is skipped.
+	Organizations map[string]string
+
+	// State records the objects which were encrypted already. Optional.
+	State *State
+	// BandwidthLimit is the maximum rate in bytes per second objects are transferred with. If zero, transfers are not throttled.
+	BandwidthLimit int64
+	// DryRun only logs which objects would be encrypted
+	DryRun bool
+	// TempDir holds objects while they are encrypted. Defaults to the system's temporary directory.
+	TempDir string
+}
+
+// EncryptionStats summarise an encryption migration
+type EncryptionStats struct {
+	Encrypted int
+	Resumed   int
+	Skipped   int
+	Failed    int
+}
+
+// Run encrypts all workspace archives which are not encrypted yet. Objects which fail to encrypt do not stop the migration,
+// but make Run return an error once all other objects were encrypted. Running it again retries the failed objects.
+func (m *EncryptionMigrator) Run(ctx context.Context) (stats EncryptionStats, err error) {
+	err = m.Storage.WalkObjects(ctx, func(obj storage.ObjectInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		key := obj.Bucket + "/" + obj.Name
+		if m.State.Done(key) {
+			stats.Resumed++
+			return nil
+		}
+
+		// backups and snapshots are kept at the top level of a workspace, unlike logs and the chunks of deduplicated backups
+		dst, ok := destinationOf(obj)
+		if !ok || dst.Workspace == "" || strings.Contains(dst.Name, "/") {
+			return nil
+		}
+		organization, ok := m.Organizations[dst.Workspace]
+		if !ok {
+			stats.Skipped++
+			return nil
+		}
+		if m.DryRun {
+			log.WithField("bucket", obj.Bucket).WithField("object", obj.Name).WithField("organization", organization).Info("would encrypt object")
+			return nil
+		}
+
+		res, err := m.encrypt(ctx, obj, dst, organization)
+		if err != nil {
+			log.WithError(err).WithField("bucket", obj.Bucket).WithField("object", obj.Name).Error("cannot encrypt object")
+			stats.Failed++
+			return nil
+		}
+		if res == encryptionSkipped {
+			stats.Skipped++
+			return nil
+		}
+		err = m.State.MarkDone(key)
+		if err != nil {
+			return xerrors.Errorf("cannot record encryption of %s: %w", key, err)
+		}
+
+		if res == encryptionNotNeeded {
+			stats.Skipped++
+			return nil
+		}
+		stats.Encrypted++
+		log.WithField("bucket", obj.Bucket).WithField("object", obj.Name).WithField("organization", organization).Debug("encrypted object")
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	if stats.Failed > 0 {
+		return stats, xerrors.Errorf("%d objects failed to encrypt, run the migration again to retry them", stats.Failed)
+	}
+	return stats, nil
+}
+
+type encryptionResult int
+
+const (
+	// encryptionSkipped means the archive was left as it is for now, because the organization has no key
+	// or the archive was replaced in the meantime
+	encryptionSkipped encryptionResult = iota
+	// encryptionNotNeeded means the archive is encrypted already
+	encryptionNotNeeded
+	// encryptionDone means the archive was replaced with its encrypted version
+	encryptionDone
+)
+
+// encrypt replaces an archive with its encrypted version
+func (m *EncryptionMigrator) encrypt(ctx context.Context, obj storage.ObjectInfo, dst destination, organization string) (res encryptionResult, err error) {
+	info, ok, err := m.objectInfo(ctx, obj.Bucket, obj.Name)
+	if err != nil || !ok {
+		return encryptionSkipped, err
+	}
+
+	dl, err := m.Storage.SignDownload(ctx, obj.Bucket, obj.Name, &storage.SignedURLOptions{})
+	if err != nil {
+		return encryptionSkipped, xerrors.Errorf("cannot sign download: %w", err)
+	}
+	fn, err := download(ctx, dl.URL, m.TempDir, m.BandwidthLimit)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	defer os.Remove(fn)
+	err = storage.VerifyFileDigest(fn, info.Annotation(storage.ObjectAnnotationDigest))
+	if err != nil {
+		return encryptionSkipped, err
+	}
+
+	// the chunks of deduplicated backups are stored next to the backup of a workspace
+	wsPrefix := strings.TrimSuffix(obj.Name, dst.Name)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	if encryption.IsEncrypted(br) {
+		// deleting the chunks may have failed after the backup was encrypted before
+		if dst.Name == storage.DefaultBackup {
+			err = m.deleteChunks(ctx, obj.Bucket, wsPrefix)
+		}
+		if err != nil {
+			return encryptionSkipped, err
+		}
+		return encryptionNotNeeded, nil
+	}
+	manifest, err := storage.ReadDedupManifest(br)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+
+	encrypter, err := encryption.NewEncrypter(ctx, m.Keys, organization)
+	if errors.Is(err, encryption.ErrNoKey) {
+		log.WithField("organization", organization).WithField("object", obj.Name).Debug("organization has no key")
+		return encryptionSkipped, nil
+	}
+	if err != nil {
+		return encryptionSkipped, xerrors.Errorf("cannot create data key: %w", err)
+	}
+
+	var plain io.Reader = br
+	if manifest != nil {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go func() {
+			pw.CloseWithError(storage.RestoreDedupChunks(ctx, pw, manifest, func(ctx context.Context, chunk storage.DedupChunk) (io.ReadCloser, error) {
+				return m.open(ctx, obj.Bucket, wsPrefix+storage.DedupChunkObject(chunk.Digest))
+			}))
+		}()
+		plain = pr
+	}
+	encfn, err := m.encryptTo(encrypter, plain)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	defer os.Remove(encfn)
+	dgst, err := storage.FileDigest(encfn)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	stat, err := os.Stat(encfn)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+
+	// ws-daemon encrypts the backups it uploads itself, hence we must not overwrite a backup taken in the meantime
+	current, ok, err := m.objectInfo(ctx, obj.Bucket, obj.Name)
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	if !ok || current.Size != info.Size || !current.LastModified.Equal(info.LastModified) {
+		log.WithField("bucket", obj.Bucket).WithField("object", obj.Name).Info("object changed while it was encrypted")
+		return encryptionSkipped, nil
+	}
+
+	anns := map[string]string{
+		storage.ObjectAnnotationDigest: dgst.String(),
+	}
+	for _, name := range []string{storage.ObjectAnnotationCompression, storage.ObjectAnnotationInstanceID} {
+		if v := info.Annotation(name); v != "" {
+			anns[name] = v
+		}
+	}
+	opts := []storage.UploadOption{storage.WithAnnotations(anns), storage.WithBandwidthLimit(m.BandwidthLimit)}
+	if ct := dl.Meta.ContentType; ct != "" && ct != storage.DedupManifestContentType {
+		opts = append(opts, storage.WithContentType(ct))
+	}
+
+	rs, err := m.NewDirectAccess()
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	err = rs.Init(ctx, dst.Owner, dst.Workspace, "")
+	if err != nil {
+		return encryptionSkipped, err
+	}
+	bucket, name, err := rs.Upload(ctx, encfn, dst.Name, opts...)
+	if err != nil {
+		return encryptionSkipped, xerrors.Errorf("cannot upload object: %w", err)
+	}
+	uploaded, ok, err := m.objectInfo(ctx, bucket, name)
+	if err != nil {
+		return encryptionSkipped, xerrors.Errorf("cannot verify upload: %w", err)
+	}
+	if !ok || uploaded.Size != stat.Size() {
+		return encryptionSkipped, xerrors.Errorf("encrypted object %s/%s is incomplete", bucket, name)
+	}
+
+	// the chunks of a deduplicated backup hold its plaintext
+	if manifest != nil {
+		err = m.deleteChunks(ctx, obj.Bucket, wsPrefix)
+		if err != nil {
+			return encryptionSkipped, err
+		}
+	}
+	return encryptionDone, nil
+}
+
+// encryptTo writes the encrypted content of r into a temporary file
+func (m *EncryptionMigrator) encryptTo(encrypter *encryption.Encrypter, r io.Reader) (fn string, err error) {
+	f, err := os.CreateTemp(m.TempDir, "encryption-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	w, err := encrypter.Writer(f)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return "", xerrors.Errorf("cannot encrypt object: %w", err)
+	}
+	err = w.Close()
+	if err != nil {
+		return "", xerrors.Errorf("cannot encrypt object: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// open streams an object from the storage
+func (m *EncryptionMigrator) open(ctx context.Context, bucket, obj string) (io.ReadCloser, error) {
+	dl, err := m.Storage.SignDownload(ctx, bucket, obj, &storage.SignedURLOptions{})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot sign download: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download %s: %w", obj, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, xerrors.Errorf("cannot download %s: %s", obj, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (m *EncryptionMigrator) deleteChunks(ctx context.Context, bucket, wsPrefix string) error {
+	err := m.Storage.DeleteObject(ctx, bucket, &storage.DeleteObjectQuery{Prefix: wsPrefix + storage.DedupChunkPrefix})
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return xerrors.Errorf("cannot delete chunks of deduplicated backup: %w", err)
+	}
+	return nil
+}
+
+// objectInfo describes a single object including its annotations
+func (m *EncryptionMigrator) objectInfo(ctx context.Context, bucket, obj string) (info storage.ObjectInfo, ok bool, err error) {
+	infos, err := m.Storage.ListObjectInfos(ctx, bucket, obj)
+	if err != nil {
+		return storage.ObjectInfo{}, false, xerrors.Errorf("cannot describe %s/%s: %w", bucket, obj, err)
+	}
+	for _, info := range infos {
+		if info.Name == obj {
+			return info, true, nil
+		}
+	}
+	return storage.ObjectInfo{}, false, nil
+}
+
+// ReadOrganizations reads which organization workspaces belong to from a file listing one workspace ID
+// and organization ID per line, separated by whitespace, e.g. as exported from the database using
+//
+//	SELECT id, organizationId FROM d_b_workspace WHERE organizationId != ''
+func ReadOrganizations(fn string) (map[string]string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, xerrors.Errorf("%s:%d: expected a workspace ID and an organization ID", fn, line)
+		}
+		res[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+// fakeEncryptionStorage keeps the objects of a single owner, whose bucket is gitpod-user-1234
+type fakeEncryptionStorage struct {
+	fakeSource
+
+	mu      sync.Mutex
+	Content map[string]string
+	Deleted []string
+}
+
+func (s *fakeEncryptionStorage) put(name, content string, anns map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Content[name] = content
+	info := storage.ObjectInfo{Bucket: "gitpod-user-1234", Name: name, Size: int64(len(content)), LastModified: time.Now(), Annotations: anns}
+	for i, obj := range s.Objects {
+		if obj.Name == name {
+			s.Objects[i] = info
+			return
+		}
+	}
+	s.Objects = append(s.Objects, info)
+}
+
+func (s *fakeEncryptionStorage) get(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Content[name]
+}
+
+func (s *fakeEncryptionStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]storage.ObjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fakeSource.ListObjectInfos(ctx, bucket, prefix)
+}
+
+func (s *fakeEncryptionStorage) DeleteObject(ctx context.Context, bucket string, query *storage.DeleteObjectQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var objs []storage.ObjectInfo
+	for _, obj := range s.Objects {
+		if query.Prefix != "" && strings.HasPrefix(obj.Name, query.Prefix) {
+			delete(s.Content, obj.Name)
+			s.Deleted = append(s.Deleted, obj.Name)
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	s.Objects = objs
+	return nil
+}
+
+type fakeEncryptionDirectAccess struct {
+	storage.DirectNoopStorage
+
+	Storage          *fakeEncryptionStorage
+	Owner, Workspace string
+}
+
+func (d *fakeEncryptionDirectAccess) Init(ctx context.Context, owner, workspace, instance string) error {
+	d.Owner, d.Workspace = owner, workspace
+	return nil
+}
+
+func (d *fakeEncryptionDirectAccess) Upload(ctx context.Context, source string, name string, opts ...storage.UploadOption) (string, string, error) {
+	options, err := storage.GetUploadOptions(opts)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return "", "", err
+	}
+	obj := "workspaces/" + d.Workspace + "/" + name
+	d.Storage.put(obj, string(content), options.Annotations)
+	return "gitpod-user-" + d.Owner, obj, nil
+}
+
+func TestEncryptionMigration(t *testing.T) {
+	keysDir := t.TempDir()
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	err := os.MkdirAll(filepath.Join(keysDir, "org-a"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(keysDir, "org-a", "1.key"), []byte(base64.StdEncoding.EncodeToString(key)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &encryption.LocalKeys{Dir: keysDir}
+
+	fs := &fakeEncryptionStorage{Content: make(map[string]string)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, fs.get(strings.TrimPrefix(r.URL.Path, "/")))
+	}))
+	defer srv.Close()
+	fs.URL = srv.URL
+
+	// ws1 has a deduplicated backup and a snapshot, ws2 belongs to an organization without a key
+	// and ws3 to no organization at all
+	backup := strings.Repeat("backup content ", 1000)
+	manifest := storage.DedupManifest{Digest: digest.FromString(backup).String(), Size: int64(len(backup))}
+	err = storage.SplitChunks(strings.NewReader(backup), func(chunk storage.DedupChunk, data []byte) error {
+		manifest.Chunks = append(manifest.Chunks, chunk)
+		fs.put("workspaces/ws1/"+storage.DedupChunkObject(chunk.Digest), string(data), nil)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = storage.WriteDedupManifest(&buf, &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.put("workspaces/ws1/full.tar", buf.String(), map[string]string{
+		storage.ObjectAnnotationDigest:     digest.FromString(buf.String()).String(),
+		storage.ObjectAnnotationInstanceID: "i1",
+	})
+	fs.put("workspaces/ws1/snapshot-1.tar", "snapshot", map[string]string{
+		storage.ObjectAnnotationCompression: "zstd",
+	})
+	fs.put("workspaces/ws1/instances/i1/logs/task-1", "logs", nil)
+	fs.put("workspaces/ws2/full.tar", "other backup", nil)
+	fs.put("workspaces/ws3/full.tar", "unknown backup", nil)
+
+	state, err := OpenState(filepath.Join(t.TempDir(), "encryption.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+
+	m := &EncryptionMigrator{
+		Storage: fs,
+		NewDirectAccess: func() (storage.DirectAccess, error) {
+			return &fakeEncryptionDirectAccess{Storage: fs}, nil
+		},
+		Keys:          keys,
+		Organizations: map[string]string{"ws1": "org-a", "ws2": "org-b"},
+		State:         state,
+		TempDir:       t.TempDir(),
+	}
+	stats, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(EncryptionStats{Encrypted: 2, Skipped: 2}, stats); diff != "" {
+		t.Errorf("unexpected stats (-want +got):\n%s", diff)
+	}
+
+	expectation := map[string]string{
+		"workspaces/ws1/full.tar":       backup,
+		"workspaces/ws1/snapshot-1.tar": "snapshot",
+	}
+	for name, plain := range expectation {
+		ciphertext := fs.get(name)
+		if !encryption.IsEncrypted(bufio.NewReader(strings.NewReader(ciphertext))) {
+			t.Errorf("%s is not encrypted", name)
+			continue
+		}
+		r, err := encryption.Decrypt(strings.NewReader(ciphertext), func(hdr *encryption.Header) ([]byte, error) {
+			if hdr.Organization != "org-a" {
+				t.Errorf("%s is encrypted for organization %s", name, hdr.Organization)
+			}
+			return encryption.UnwrapKey(context.Background(), keys, hdr)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		act, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("cannot decrypt %s: %v", name, err)
+		}
+		if string(act) != plain {
+			t.Errorf("unexpected content of %s", name)
+		}
+	}
+
+	infos, _ := fs.ListObjectInfos(context.Background(), "gitpod-user-1234", "workspaces/ws1/full.tar")
+	expectedAnns := map[string]string{
+		storage.ObjectAnnotationDigest:     digest.FromString(fs.get("workspaces/ws1/full.tar")).String(),
+		storage.ObjectAnnotationInstanceID: "i1",
+	}
+	if diff := cmp.Diff(expectedAnns, infos[0].Annotations); diff != "" {
+		t.Errorf("unexpected annotations (-want +got):\n%s", diff)
+	}
+	if len(fs.Deleted) != len(manifest.Chunks) {
+		t.Errorf("expected the %d plaintext chunks to be deleted, deleted %v", len(manifest.Chunks), fs.Deleted)
+	}
+	for _, name := range []string{"workspaces/ws2/full.tar", "workspaces/ws3/full.tar", "workspaces/ws1/instances/i1/logs/task-1"} {
+		if encryption.IsEncrypted(bufio.NewReader(strings.NewReader(fs.get(name)))) {
+			t.Errorf("%s must not be encrypted", name)
+		}
+	}
+
+	// running the migration again without its state leaves encrypted content as it is
+	m.State = nil
+	encrypted := fs.get("workspaces/ws1/snapshot-1.tar")
+	stats, err = m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(EncryptionStats{Skipped: 4}, stats); diff != "" {
+		t.Errorf("unexpected stats when running again (-want +got):\n%s", diff)
+	}
+	if fs.get("workspaces/ws1/snapshot-1.tar") != encrypted {
+		t.Errorf("encrypted content was encrypted again")
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package migration
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+// annotations are carried over to the destination, storage systems differ in how they case their names
+var annotations = []string{
+	storage.ObjectAnnotationDigest,
+	storage.ObjectAnnotationUncompressedDigest,
+	storage.ObjectAnnotationOCIContentType,
+	storage.ObjectAnnotationCompression,
+	storage.ObjectAnnotationInstanceID,
+}
+
+// Migrator copies the content of all users from one remote storage to another
+type Migrator struct {
+	Source      storage.LifecycleAccess
+	Destination storage.PresignedAccess
+	// NewDirectDestination provides direct access to the destination, which uploads workspace content with its annotations
+	NewDirectDestination func() (storage.DirectAccess, error)
+
+	// State records the objects which were migrated already. Optional.
+	State *State
+	// BandwidthLimit is the maximum rate in bytes per second objects are copied with. If zero, copies are not throttled.
+	BandwidthLimit int64
+	// DryRun only logs which objects would be copied
+	DryRun bool
+	// TempDir holds objects while they are copied. Defaults to the system's temporary directory.
+	TempDir string
+}
+
+// Stats summarise a migration
+type Stats struct {
+	Copied  int
+	Bytes   int64
+	Resumed int
+	Skipped int
+	Failed  int
+}
+
+// Run copies all objects which have not been migrated yet. Objects which fail to copy do not stop the migration,
+// but make Run return an error once all other objects were copied. Running it again retries the failed objects.
+func (m *Migrator) Run(ctx context.Context) (stats Stats, err error) {
+	err = m.Source.WalkObjects(ctx, func(obj storage.ObjectInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		key := obj.Bucket + "/" + obj.Name
+		if m.State.Done(key) {
+			stats.Resumed++
+			return nil
+		}
+
+		dst, ok := destinationOf(obj)
+		if !ok {
+			log.WithField("bucket", obj.Bucket).WithField("object", obj.Name).Warn("skipping object which belongs to neither a workspace nor a blob")
+			stats.Skipped++
+			return nil
+		}
+		if m.DryRun {
+			log.WithField("bucket", obj.Bucket).WithField("object", obj.Name).WithField("size", obj.Size).Info("would copy object")
+			return nil
+		}
+
+		err := m.copy(ctx, obj, dst)
+		if err != nil {
+			log.WithError(err).WithField("bucket", obj.Bucket).WithField("object", obj.Name).Error("cannot copy object")
+			stats.Failed++
+			return nil
+		}
+		err = m.State.MarkDone(key)
+		if err != nil {
+			return xerrors.Errorf("cannot record migration of %s: %w", key, err)
+		}
+
+		stats.Copied++
+		stats.Bytes += obj.Size
+		log.WithField("bucket", obj.Bucket).WithField("object", obj.Name).WithField("size", obj.Size).Debug("copied object")
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	if stats.Failed > 0 {
+		return stats, xerrors.Errorf("%d objects failed to copy, run the migration again to retry them", stats.Failed)
+	}
+	return stats, nil
+}
+
+// destination identifies where an object is copied to
+type destination struct {
+	Owner string
+	// Workspace is empty for blobs
+	Workspace string
+	// Name is relative to the workspace or the blobs of the owner
+	Name string
+}
+
+// destinationOf finds the owner of an object and its name relative to the workspace or blobs of the owner.
+// Depending on the storage, objects are either kept in a bucket per owner, e.g. gitpod-user-<ownerID>,
+// or their names are prefixed with the owner ID.
+func destinationOf(obj storage.ObjectInfo) (res destination, ok bool) {
+	segs := strings.Split(obj.Name, "/")
+	switch {
+	case len(segs) > 0 && (segs[0] == "workspaces" || segs[0] == "blobs"):
+		idx := strings.LastIndex(obj.Bucket, "-user-")
+		if idx < 0 {
+			return destination{}, false
+		}
+		res.Owner = obj.Bucket[idx+len("-user-"):]
+	case len(segs) > 1 && (segs[1] == "workspaces" || segs[1] == "blobs"):
+		res.Owner = segs[0]
+		segs = segs[1:]
+	default:
+		return destination{}, false
+	}
+	if res.Owner == "" {
+		return destination{}, false
+	}
+
+	switch {
+	case segs[0] == "workspaces" && len(segs) >= 3 && segs[1] != "":
+		res.Workspace = segs[1]
+		res.Name = strings.Join(segs[2:], "/")
+	case segs[0] == "blobs" && len(segs) >= 2:
+		res.Name = strings.Join(segs[1:], "/")
+	default:
+		return destination{}, false
+	}
+	if res.Name == "" {
+		return destination{}, false
+	}
+	return res, true
+}
+
+func (m *Migrator) copy(ctx context.Context, obj storage.ObjectInfo, dst destination) error {
+	infos, err := m.Source.ListObjectInfos(ctx, obj.Bucket, obj.Name)
+	if err != nil {
+		return xerrors.Errorf("cannot read annotations: %w", err)
+	}
+	anns := make(map[string]string)
+	for _, info := range infos {
+		if info.Name != obj.Name {
+			continue
+		}
+		for _, name := range annotations {
+			if v := info.Annotation(name); v != "" {
+				anns[name] = v
+			}
+		}
+	}
+
+	dl, err := m.Source.SignDownload(ctx, obj.Bucket, obj.Name, &storage.SignedURLOptions{})
+	if err != nil {
+		return xerrors.Errorf("cannot sign download: %w", err)
+	}
+	fn, err := m.download(ctx, dl.URL)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fn)
+
+	stat, err := os.Stat(fn)
+	if err != nil {
+		return err
+	}
+	if stat.Size() != obj.Size {
+		return xerrors.Errorf("downloaded %d bytes, but the object has %d bytes", stat.Size(), obj.Size)
+	}
+	err = storage.VerifyFileDigest(fn, anns[storage.ObjectAnnotationDigest])
+	if err != nil {
+		return err
+	}
+
+	var bucket, name string
+	if dst.Workspace != "" {
+		bucket, name, err = m.uploadWorkspaceContent(ctx, fn, dst, dl.Meta.ContentType, anns)
+	} else {
+		bucket, name, err = m.uploadBlob(ctx, fn, dst, dl.Meta.ContentType)
+	}
+	if err != nil {
+		return err
+	}
+
+	return m.verify(ctx, bucket, name, obj.Size)
+}
+
+// download fetches an object into a temporary file
+func (m *Migrator) download(ctx context.Context, url string) (fn string, err error) {
+	return download(ctx, url, m.TempDir, m.BandwidthLimit)
+}
+
+func download(ctx context.Context, url, tempDir string, bandwidthLimit int64) (fn string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", xerrors.Errorf("cannot download object: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", xerrors.Errorf("cannot download object: %s", resp.Status)
+	}
+
+	f, err := os.CreateTemp(tempDir, "migration-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = io.Copy(f, storage.NewThrottledReader(resp.Body, bandwidthLimit))
+	if err != nil {
+		return "", xerrors.Errorf("cannot download object: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func (m *Migrator) uploadWorkspaceContent(ctx context.Context, fn string, dst destination, contentType string, anns map[string]string) (bucket, obj string, err error) {
+	rs, err := m.NewDirectDestination()
+	if err != nil {
+		return "", "", err
+	}
+	err = rs.Init(ctx, dst.Owner, dst.Workspace, "")
+	if err != nil {
+		return "", "", err
+	}
+	err = rs.EnsureExists(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	opts := []storage.UploadOption{storage.WithAnnotations(anns), storage.WithBandwidthLimit(m.BandwidthLimit)}
+	if contentType != "" {
+		opts = append(opts, storage.WithContentType(contentType))
+	}
+	bucket, obj, err = rs.Upload(ctx, fn, dst.Name, opts...)
+	if err != nil {
+		return "", "", xerrors.Errorf("cannot upload object: %w", err)
+	}
+	return bucket, obj, nil
+}
+
+func (m *Migrator) uploadBlob(ctx context.Context, fn string, dst destination, contentType string) (bucket, obj string, err error) {
+	bucket = m.Destination.Bucket(dst.Owner)
+	obj, err = m.Destination.BlobObject(dst.Owner, dst.Name)
+	if err != nil {
+		return "", "", err
+	}
+	err = m.Destination.EnsureExists(ctx, bucket)
+	if err != nil {
+		return "", "", err
+	}
+	info, err := m.Destination.SignUpload(ctx, bucket, obj, &storage.SignedURLOptions{ContentType: contentType})
+	if err != nil {
+		return "", "", xerrors.Errorf("cannot sign upload: %w", err)
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, info.URL, storage.NewThrottledReader(f, m.BandwidthLimit))
+	if err != nil {
+		return "", "", err
+	}
+	req.ContentLength = stat.Size()
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// required by Azure, other storage systems ignore it
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", xerrors.Errorf("cannot upload object: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", "", xerrors.Errorf("cannot upload object: %s", resp.Status)
+	}
+	return bucket, obj, nil
+}
+
+// verify checks that an object arrived at the destination in full
+func (m *Migrator) verify(ctx context.Context, bucket, obj string, size int64) error {
+	infos, err := m.Destination.ListObjectInfos(ctx, bucket, obj)
+	if err != nil {
+		return xerrors.Errorf("cannot verify copy: %w", err)
+	}
+	for _, info := range infos {
+		if info.Name != obj {
+			continue
+		}
+		if info.Size != size {
+			return xerrors.Errorf("copy of %s/%s has %d bytes, but the original has %d bytes", bucket, obj, info.Size, size)
+		}
+		return nil
+	}
+	return xerrors.Errorf("copy of %s/%s does not exist", bucket, obj)
+}
+
+// State records the objects which were migrated already, such that an interrupted migration can be resumed.
+// A nil State records nothing.
+type State struct {
+	mu   sync.Mutex
+	done map[string]struct{}
+	f    *os.File
+}
+
+// OpenState reads the state of a previous migration from a file, which the state is recorded in from there on
+func OpenState(fn string) (*State, error) {
+	res := &State{done: make(map[string]struct{})}
+
+	f, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot open migration state: %w", err)
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key := scanner.Text(); key != "" {
+			res.done[key] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, xerrors.Errorf("cannot read migration state: %w", err)
+	}
+	res.f = f
+
+	return res, nil
+}
+
+// Done returns true if the object was migrated already
+func (s *State) Done(key string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.done[key]
+	return ok
+}
+
+// MarkDone records that an object was migrated
+func (s *State) MarkDone(key string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.Contains(key, "\n") {
+		return errors.New("object names must not contain newlines")
+	}
+	_, err := fmt.Fprintln(s.f, key)
+	if err != nil {
+		return err
+	}
+	s.done[key] = struct{}{}
+	return nil
+}
+
+// Close closes the file the state is recorded in
+func (s *State) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.f.Close()
+}
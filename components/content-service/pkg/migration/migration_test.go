@@ -0,0 +1,279 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package migration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+func TestDestinationOf(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Object      storage.ObjectInfo
+		Expectation *destination
+	}{
+		{
+			Name:        "bucket per owner",
+			Object:      storage.ObjectInfo{Bucket: "gitpod-prod-user-1234", Name: "workspaces/ws1/full.tar"},
+			Expectation: &destination{Owner: "1234", Workspace: "ws1", Name: "full.tar"},
+		},
+		{
+			Name:        "owner prefix",
+			Object:      storage.ObjectInfo{Bucket: "gitpod-s3", Name: "1234/workspaces/ws1/instances/i1/logs/task-1"},
+			Expectation: &destination{Owner: "1234", Workspace: "ws1", Name: "instances/i1/logs/task-1"},
+		},
+		{
+			Name:        "blob",
+			Object:      storage.ObjectInfo{Bucket: "gitpod-user-1234", Name: "blobs/plugins/some-plugin.vsix"},
+			Expectation: &destination{Owner: "1234", Name: "plugins/some-plugin.vsix"},
+		},
+		{
+			Name:        "prefixed blob",
+			Object:      storage.ObjectInfo{Bucket: "gitpod-s3", Name: "1234/blobs/some-blob"},
+			Expectation: &destination{Owner: "1234", Name: "some-blob"},
+		},
+		{
+			Name:   "unknown bucket",
+			Object: storage.ObjectInfo{Bucket: "some-bucket", Name: "workspaces/ws1/full.tar"},
+		},
+		{
+			Name:   "workspace without content",
+			Object: storage.ObjectInfo{Bucket: "gitpod-s3", Name: "1234/workspaces/ws1"},
+		},
+		{
+			Name:   "other object",
+			Object: storage.ObjectInfo{Bucket: "gitpod-s3", Name: "1234/something-else"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, ok := destinationOf(test.Object)
+			if test.Expectation == nil {
+				if ok {
+					t.Fatalf("expected no destination, got %+v", act)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected a destination")
+			}
+			if diff := cmp.Diff(*test.Expectation, act); diff != "" {
+				t.Errorf("unexpected destination (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeSource struct {
+	storage.PresignedNoopStorage
+
+	URL     string
+	Objects []storage.ObjectInfo
+}
+
+func (s *fakeSource) WalkObjects(ctx context.Context, fn func(obj storage.ObjectInfo) error) error {
+	for _, obj := range s.Objects {
+		err := fn(storage.ObjectInfo{Bucket: obj.Bucket, Name: obj.Name, Size: obj.Size})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeSource) SetStorageClass(ctx context.Context, bucket, obj, class string) error {
+	return nil
+}
+
+func (s *fakeSource) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]storage.ObjectInfo, error) {
+	var res []storage.ObjectInfo
+	for _, obj := range s.Objects {
+		if obj.Bucket == bucket && strings.HasPrefix(obj.Name, prefix) {
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeSource) SignDownload(ctx context.Context, bucket, obj string, options *storage.SignedURLOptions) (*storage.DownloadInfo, error) {
+	return &storage.DownloadInfo{URL: s.URL + "/" + obj}, nil
+}
+
+// fakeDestination keeps objects by their bucket and name
+type fakeDestination struct {
+	storage.PresignedNoopStorage
+
+	mu      sync.Mutex
+	URL     string
+	Objects map[string]string
+	Anns    map[string]map[string]string
+}
+
+func (d *fakeDestination) put(bucket, obj, content string, anns map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Objects[bucket+"/"+obj] = content
+	d.Anns[bucket+"/"+obj] = anns
+}
+
+func (d *fakeDestination) Bucket(owner string) string {
+	return "gitpod-s3"
+}
+
+func (d *fakeDestination) BlobObject(owner, name string) (string, error) {
+	return owner + "/blobs/" + name, nil
+}
+
+func (d *fakeDestination) SignUpload(ctx context.Context, bucket, obj string, options *storage.SignedURLOptions) (*storage.UploadInfo, error) {
+	return &storage.UploadInfo{URL: d.URL + "/" + bucket + "/" + obj}, nil
+}
+
+func (d *fakeDestination) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]storage.ObjectInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	content, ok := d.Objects[bucket+"/"+prefix]
+	if !ok {
+		return nil, nil
+	}
+	return []storage.ObjectInfo{{Bucket: bucket, Name: prefix, Size: int64(len(content))}}, nil
+}
+
+type fakeDirectDestination struct {
+	storage.DirectNoopStorage
+
+	Dest             *fakeDestination
+	Owner, Workspace string
+}
+
+func (d *fakeDirectDestination) Init(ctx context.Context, owner, workspace, instance string) error {
+	d.Owner, d.Workspace = owner, workspace
+	return nil
+}
+
+func (d *fakeDirectDestination) Upload(ctx context.Context, source string, name string, opts ...storage.UploadOption) (string, string, error) {
+	options, err := storage.GetUploadOptions(opts)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return "", "", err
+	}
+	obj := d.Owner + "/workspaces/" + d.Workspace + "/" + name
+	d.Dest.put("gitpod-s3", obj, string(content), options.Annotations)
+	return "gitpod-s3", obj, nil
+}
+
+func TestMigrate(t *testing.T) {
+	content := map[string]string{
+		"workspaces/ws1/full.tar":       "backup",
+		"workspaces/ws1/snapshot-1.tar": "snapshot",
+		"blobs/some-blob":               "blob",
+		"workspaces/ws1/corrupted.tar":  "corrupted",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, content[strings.TrimPrefix(r.URL.Path, "/")])
+	}))
+	defer srv.Close()
+
+	dst := &fakeDestination{Objects: make(map[string]string), Anns: make(map[string]map[string]string)}
+	upload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bucket, obj, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		dst.put(bucket, obj, string(body), nil)
+	}))
+	defer upload.Close()
+	dst.URL = upload.URL
+
+	object := func(name string, anns map[string]string) storage.ObjectInfo {
+		return storage.ObjectInfo{Bucket: "gitpod-user-1234", Name: name, Size: int64(len(content[name])), Annotations: anns}
+	}
+	src := &fakeSource{
+		URL: srv.URL,
+		Objects: []storage.ObjectInfo{
+			object("workspaces/ws1/full.tar", map[string]string{
+				"Gitpod-Digest":     digest.FromString("backup").String(),
+				"Gitpod-Instanceid": "i1",
+			}),
+			object("workspaces/ws1/snapshot-1.tar", nil),
+			object("blobs/some-blob", nil),
+			object("workspaces/ws1/corrupted.tar", map[string]string{
+				"Gitpod-Digest": digest.FromString("something else").String(),
+			}),
+			{Bucket: "gitpod-user-1234", Name: "unrelated"},
+		},
+	}
+
+	state, err := OpenState(filepath.Join(t.TempDir(), "migration.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+
+	m := &Migrator{
+		Source:      src,
+		Destination: dst,
+		NewDirectDestination: func() (storage.DirectAccess, error) {
+			return &fakeDirectDestination{Dest: dst}, nil
+		},
+		State:          state,
+		BandwidthLimit: 1024 * 1024,
+		TempDir:        t.TempDir(),
+	}
+	stats, err := m.Run(context.Background())
+	if err == nil {
+		t.Fatal("expected the corrupted object to fail the migration")
+	}
+	if diff := cmp.Diff(Stats{Copied: 3, Bytes: 18, Skipped: 1, Failed: 1}, stats); diff != "" {
+		t.Errorf("unexpected stats (-want +got):\n%s", diff)
+	}
+
+	expectation := map[string]string{
+		"gitpod-s3/1234/workspaces/ws1/full.tar":       "backup",
+		"gitpod-s3/1234/workspaces/ws1/snapshot-1.tar": "snapshot",
+		"gitpod-s3/1234/blobs/some-blob":               "blob",
+	}
+	if diff := cmp.Diff(expectation, dst.Objects); diff != "" {
+		t.Errorf("unexpected destination content (-want +got):\n%s", diff)
+	}
+	expectedAnns := map[string]string{
+		storage.ObjectAnnotationDigest:     digest.FromString("backup").String(),
+		storage.ObjectAnnotationInstanceID: "i1",
+	}
+	if diff := cmp.Diff(expectedAnns, dst.Anns["gitpod-s3/1234/workspaces/ws1/full.tar"]); diff != "" {
+		t.Errorf("unexpected annotations (-want +got):\n%s", diff)
+	}
+
+	// resuming the migration only retries the object which failed
+	state.Close()
+	state, err = OpenState(state.f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+	m.State = state
+	content["workspaces/ws1/corrupted.tar"] = "something else"
+	src.Objects[3].Size = int64(len("something else"))
+	stats, err = m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Stats{Copied: 1, Bytes: 14, Resumed: 3, Skipped: 1}, stats); diff != "" {
+		t.Errorf("unexpected stats after resuming (-want +got):\n%s", diff)
+	}
+}
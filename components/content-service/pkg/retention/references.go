@@ -0,0 +1,105 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package retention
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/xerrors"
+)
+
+// referencesBatchSize is the number of snapshots we look up in a single query
+const referencesBatchSize = 500
+
+// DBSnapshotReferences finds the snapshots which the database of the server still references, i.e. the
+// snapshots users took and the snapshots of prebuilds which were not deleted.
+type DBSnapshotReferences struct {
+	DB *sql.DB
+}
+
+// Referenced returns those of the snapshots which are still referenced
+func (d *DBSnapshotReferences) Referenced(ctx context.Context, snapshots []string) (map[string]struct{}, error) {
+	res := make(map[string]struct{})
+	for start := 0; start < len(snapshots); start += referencesBatchSize {
+		batch := snapshots[start:min(start+referencesBatchSize, len(snapshots))]
+
+		args := make([]interface{}, 0, 2*len(batch))
+		for _, snap := range batch {
+			args = append(args, snap)
+		}
+		args = append(args, args...)
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		query := fmt.Sprintf("SELECT bucketId FROM d_b_snapshot WHERE bucketId IN (%[1]s) "+
+			"UNION SELECT snapshot FROM d_b_prebuilt_workspace WHERE deleted = 0 AND snapshot IN (%[1]s)", placeholders)
+
+		rows, err := d.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot query snapshot references: %w", err)
+		}
+		for rows.Next() {
+			var snap string
+			err = rows.Scan(&snap)
+			if err != nil {
+				rows.Close()
+				return nil, xerrors.Errorf("cannot read snapshot references: %w", err)
+			}
+			res[snap] = struct{}{}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("cannot read snapshot references: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// OpenDatabase connects to the database of the server, configured using the DB_HOST, DB_PORT, DB_USERNAME,
+// DB_PASSWORD and DB_CA_CERT environment variables like for other components.
+func OpenDatabase() (*sql.DB, error) {
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		return nil, xerrors.Errorf("DB_HOST is not set")
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.User = os.Getenv("DB_USERNAME")
+	cfg.Passwd = os.Getenv("DB_PASSWORD")
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, os.Getenv("DB_PORT"))
+	cfg.DBName = "gitpod"
+	cfg.AllowNativePasswords = true
+
+	if caCert := os.Getenv("DB_CA_CERT"); caCert != "" {
+		rootCertPool := x509.NewCertPool()
+		if ok := rootCertPool.AppendCertsFromPEM([]byte(caCert)); !ok {
+			return nil, xerrors.Errorf("cannot append custom certificate for database connection")
+		}
+
+		tlsConfigName := "custom"
+		err := mysql.RegisterTLSConfig(tlsConfigName, &tls.Config{
+			RootCAs:    rootCertPool,
+			MinVersion: tls.VersionTLS12,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("cannot register custom DB CA cert: %w", err)
+		}
+		cfg.TLSConfig = tlsConfigName
+	}
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, xerrors.Errorf("cannot open database connection: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,508 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package retention
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/logs"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+const (
+	defaultInterval = 24 * time.Hour
+
+	// dedupChunkGracePeriod is how long chunks of deduplicated backups are kept after they were uploaded
+	// although no backup references them. Backups upload their chunks before their manifest.
+	dedupChunkGracePeriod = 24 * time.Hour
+)
+
+// Action is what the reconciler does with an object
+type Action string
+
+const (
+	// ActionDeleteSnapshot deletes a snapshot which exceeds the number of snapshots kept per workspace
+	ActionDeleteSnapshot Action = "delete_snapshot"
+	// ActionDeleteHeadlessLog deletes the log of a headless workspace which exceeds the maximum age
+	ActionDeleteHeadlessLog Action = "delete_headless_log"
+	// ActionDeleteBuildLog deletes the log of an image build which exceeds the maximum age
+	ActionDeleteBuildLog Action = "delete_build_log"
+	// ActionDeleteDedupChunk deletes a chunk of a deduplicated backup which the backup no longer references
+	ActionDeleteDedupChunk Action = "delete_dedup_chunk"
+	// ActionArchive moves a backup or snapshot to the cold storage class
+	ActionArchive Action = "archive"
+)
+
+// objectKind classifies the objects of a workspace
+type objectKind int
+
+const (
+	kindOther objectKind = iota
+	kindBackup
+	kindSnapshot
+	kindHeadlessLog
+	kindDedupChunk
+	kindDedupUploadMarker
+)
+
+// workspaceObject is an object which belongs to a workspace
+type workspaceObject struct {
+	storage.ObjectInfo
+
+	// Workspace identifies the workspace across buckets
+	Workspace string
+	Kind      objectKind
+}
+
+// classify finds the workspace an object belongs to and what kind of object it is. Depending on the storage,
+// object names are either relative to the bucket of the owner or prefixed with the owner ID, but always contain
+// a workspaces/<workspaceID>/ segment.
+func classify(obj storage.ObjectInfo) (res workspaceObject, ok bool) {
+	segs := strings.Split(obj.Name, "/")
+	for i := 0; i+2 < len(segs); i++ {
+		if segs[i] != "workspaces" {
+			continue
+		}
+
+		res = workspaceObject{
+			ObjectInfo: obj,
+			Workspace:  obj.Bucket + "/" + strings.Join(segs[:i+2], "/"),
+		}
+		rel := segs[i+2:]
+		switch {
+		case len(rel) == 1 && rel[0] == storage.DefaultBackup:
+			res.Kind = kindBackup
+		case len(rel) == 1 && strings.HasPrefix(rel[0], "snapshot-") && strings.HasSuffix(rel[0], ".tar"):
+			res.Kind = kindSnapshot
+		case len(rel) >= 4 && rel[0] == "instances" && rel[2] == logs.UploadedHeadlessLogPathPrefix,
+			len(rel) == 3 && rel[0] == "instances" && rel[2] == logs.UploadedPrebuildSummaryPath:
+			res.Kind = kindHeadlessLog
+		case len(rel) == 2 && rel[0]+"/" == storage.DedupChunkPrefix:
+			res.Kind = kindDedupChunk
+		case len(rel) == 1 && rel[0] == storage.DedupUploadMarker:
+			res.Kind = kindDedupUploadMarker
+		}
+		return res, true
+	}
+	return workspaceObject{}, false
+}
+
+// isBuildLog returns true if the object is the log of an image build. Build logs are blobs of a pseudo owner,
+// whose names are prefixed with the owner ID on some storages.
+func isBuildLog(obj storage.ObjectInfo) bool {
+	return strings.Contains("/"+obj.Name, "/blobs/"+logs.BuildLogBlobPrefix) && strings.HasSuffix(obj.Name, ".log")
+}
+
+// plannedAction is an action the reconciler is going to take on an object
+type plannedAction struct {
+	Action Action
+	Object storage.ObjectInfo
+	// Basis is when the latest backup of the workspace of a dedup chunk was uploaded or started to upload,
+	// when we decided to delete the chunk. The chunk is kept if another upload started since.
+	Basis time.Time
+}
+
+// SnapshotReferences finds the snapshots which are still in use
+type SnapshotReferences interface {
+	// Referenced returns those of the snapshots which are still referenced. Snapshots are qualified
+	// as <object>@<bucket>, which is how they are referred to by the server.
+	Referenced(ctx context.Context, snapshots []string) (map[string]struct{}, error)
+}
+
+// Reconciler enforces the retention policies on the remote storage
+type Reconciler struct {
+	Config  config.RetentionConfig
+	Storage storage.LifecycleAccess
+	// References tells which snapshots must be kept although they exceed KeepSnapshots. Without references
+	// no snapshot is deleted.
+	References SnapshotReferences
+
+	objects         *prometheus.CounterVec
+	bytes           *prometheus.CounterVec
+	errors          *prometheus.CounterVec
+	duration        prometheus.Histogram
+	lastReconcileAt prometheus.Gauge
+}
+
+// NewReconciler creates a new retention reconciler
+func NewReconciler(cfg config.RetentionConfig, s storage.LifecycleAccess, refs SnapshotReferences, prom prometheus.Registerer) *Reconciler {
+	if cfg.Interval == 0 {
+		cfg.Interval = util.Duration(defaultInterval)
+	}
+
+	r := &Reconciler{
+		Config:     cfg,
+		Storage:    s,
+		References: refs,
+
+		objects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "content_service_retention_objects_total",
+			Help: "Number of objects the retention policies were applied to",
+		}, []string{"action", "dry_run"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "content_service_retention_bytes_total",
+			Help: "Size of the objects the retention policies were applied to",
+		}, []string{"action", "dry_run"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "content_service_retention_errors_total",
+			Help: "Number of objects the retention policies failed to be applied to",
+		}, []string{"action"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "content_service_retention_reconcile_duration_seconds",
+			Help:    "Time it took to walk the remote storage and apply the retention policies",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+		}),
+		lastReconcileAt: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "content_service_retention_last_reconcile_timestamp_seconds",
+			Help: "Time the retention policies were last applied without error",
+		}),
+	}
+
+	if cfg.Enabled {
+		prom.MustRegister(
+			r.objects,
+			r.bytes,
+			r.errors,
+			r.duration,
+			r.lastReconcileAt,
+		)
+	}
+
+	return r
+}
+
+// Start applies the retention policies in regular intervals until the context is canceled
+func (r *Reconciler) Start(ctx context.Context) {
+	log.WithField("interval", r.Config.Interval.String()).WithField("dryRun", r.Config.DryRun).Info("started retention reconciler")
+
+	ticker := time.NewTicker(time.Duration(r.Config.Interval))
+	defer ticker.Stop()
+
+	for {
+		err := r.Reconcile(ctx, time.Now())
+		if err != nil {
+			log.WithError(err).Error("error during retention reconciliation")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Debug("stopping retention reconciler")
+			return
+		}
+	}
+}
+
+// Reconcile walks the remote storage once and applies the retention policies to its objects
+func (r *Reconciler) Reconcile(ctx context.Context, now time.Time) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Reconciler.Reconcile")
+	defer tracing.FinishSpan(span, &err)
+
+	start := time.Now()
+	plan, err := r.plan(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, p := range plan {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := r.apply(ctx, p)
+		if err != nil {
+			failed++
+			r.errors.WithLabelValues(string(p.Action)).Inc()
+			log.WithError(err).WithFields(objectFields(p)).Warn("cannot apply retention policy")
+		}
+	}
+	r.duration.Observe(time.Since(start).Seconds())
+
+	if failed > 0 {
+		return xerrors.Errorf("cannot apply retention policies to %d of %d objects", failed, len(plan))
+	}
+	r.lastReconcileAt.SetToCurrentTime()
+	return nil
+}
+
+// plan decides which actions to take on the objects in the remote storage
+func (r *Reconciler) plan(ctx context.Context, now time.Time) ([]plannedAction, error) {
+	var (
+		cfg       = r.Config
+		res       []plannedAction
+		snapshots = make(map[string][]storage.ObjectInfo)
+		backups   = make(map[string]storage.ObjectInfo)
+		chunks    = make(map[string][]storage.ObjectInfo)
+		markers   = make(map[string]storage.ObjectInfo)
+	)
+	archive := func(obj storage.ObjectInfo) {
+		if cfg.ColdStorageAfter == 0 || cfg.ColdStorageClass == "" || strings.EqualFold(obj.StorageClass, cfg.ColdStorageClass) {
+			return
+		}
+		if now.Sub(obj.LastModified) < time.Duration(cfg.ColdStorageAfter) {
+			return
+		}
+		res = append(res, plannedAction{Action: ActionArchive, Object: obj})
+	}
+
+	err := r.Storage.WalkObjects(ctx, func(obj storage.ObjectInfo) error {
+		if isBuildLog(obj) {
+			if cfg.BuildLogsMaxAge != 0 && now.Sub(obj.LastModified) >= time.Duration(cfg.BuildLogsMaxAge) {
+				res = append(res, plannedAction{Action: ActionDeleteBuildLog, Object: obj})
+			}
+			return nil
+		}
+
+		wsObj, ok := classify(obj)
+		if !ok {
+			return nil
+		}
+
+		switch wsObj.Kind {
+		case kindBackup:
+			backups[wsObj.Workspace] = obj
+			archive(obj)
+		case kindDedupChunk:
+			// which chunks are kept is decided once we've seen the backup of the workspace
+			chunks[wsObj.Workspace] = append(chunks[wsObj.Workspace], obj)
+		case kindDedupUploadMarker:
+			markers[wsObj.Workspace] = obj
+		case kindSnapshot:
+			// which snapshots are kept is decided once we've seen all snapshots of the workspace
+			snapshots[wsObj.Workspace] = append(snapshots[wsObj.Workspace], obj)
+		case kindHeadlessLog:
+			if cfg.HeadlessLogsMaxAge != 0 && now.Sub(obj.LastModified) >= time.Duration(cfg.HeadlessLogsMaxAge) {
+				res = append(res, plannedAction{Action: ActionDeleteHeadlessLog, Object: obj})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot walk remote storage: %w", err)
+	}
+
+	var expired []storage.ObjectInfo
+	for _, snaps := range snapshots {
+		// Snapshots are named after the time they were taken. Unlike their modification time,
+		// the name doesn't change when a snapshot is moved to another storage class.
+		sort.Slice(snaps, func(i, j int) bool { return snaps[i].Name > snaps[j].Name })
+		for i, snap := range snaps {
+			if cfg.KeepSnapshots > 0 && i >= cfg.KeepSnapshots {
+				expired = append(expired, snap)
+				continue
+			}
+			archive(snap)
+		}
+	}
+	referenced := r.referencedSnapshots(ctx, expired)
+	for _, snap := range expired {
+		if _, ok := referenced[qualifiedName(snap)]; ok {
+			archive(snap)
+			continue
+		}
+		res = append(res, plannedAction{Action: ActionDeleteSnapshot, Object: snap})
+	}
+
+	for ws, objs := range chunks {
+		var (
+			backup, hasBackup = backups[ws]
+			marker, hasMarker = markers[ws]
+			basis             time.Time
+			collect           = true
+		)
+		if hasBackup {
+			basis = backup.LastModified
+		}
+		switch {
+		case hasBackup && now.Sub(backup.LastModified) < dedupChunkGracePeriod:
+			// the backup was replaced just now, we leave the chunks of the previous one for a while longer
+			collect = false
+		case hasMarker && marker.LastModified.After(basis) && now.Sub(marker.LastModified) < dedupChunkGracePeriod:
+			// an upload is in progress, which may reuse any of the chunks. The marker of an upload which
+			// failed keeps the chunks for the grace period only.
+			collect = false
+		}
+		if hasMarker && marker.LastModified.After(basis) {
+			basis = marker.LastModified
+		}
+
+		var used map[string]struct{}
+		if collect && hasBackup {
+			used, err = r.referencedChunks(ctx, backup)
+			if err != nil {
+				log.WithError(err).WithField("workspace", ws).Warn("cannot find the chunks the backup references, keeping all chunks")
+				collect = false
+			}
+		}
+		for _, chunk := range objs {
+			_, inUse := used[chunk.Name]
+			if collect && !inUse && now.Sub(chunk.LastModified) >= dedupChunkGracePeriod {
+				res = append(res, plannedAction{Action: ActionDeleteDedupChunk, Object: chunk, Basis: basis})
+				continue
+			}
+			archive(chunk)
+		}
+	}
+
+	return res, nil
+}
+
+// referencedSnapshots returns the qualified names of the snapshots which must be kept. If we cannot tell,
+// all of them are kept.
+func (r *Reconciler) referencedSnapshots(ctx context.Context, snapshots []storage.ObjectInfo) map[string]struct{} {
+	names := make([]string, 0, len(snapshots))
+	for _, snap := range snapshots {
+		names = append(names, qualifiedName(snap))
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	all := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		all[name] = struct{}{}
+	}
+	if r.References == nil {
+		log.WithField("snapshots", len(names)).Warn("cannot tell which snapshots are still referenced, keeping all snapshots")
+		return all
+	}
+	res, err := r.References.Referenced(ctx, names)
+	if err != nil {
+		log.WithError(err).WithField("snapshots", len(names)).Warn("cannot find the snapshots which are still referenced, keeping all snapshots")
+		return all
+	}
+	return res
+}
+
+// referencedChunks returns the names of the chunk objects a backup references, which is none if the backup
+// is a regular archive rather than the manifest of a deduplicated backup.
+func (r *Reconciler) referencedChunks(ctx context.Context, backup storage.ObjectInfo) (map[string]struct{}, error) {
+	info, err := r.Storage.SignDownload(ctx, backup.Bucket, backup.Name, &storage.SignedURLOptions{})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot sign backup download: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, info.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download backup: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("cannot download backup: non-OK status code: %v", resp.StatusCode)
+	}
+
+	// regular archives are not read beyond their first bytes
+	manifest, err := storage.ReadDedupManifest(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, nil
+	}
+
+	// chunk objects live next to the backup, i.e. share the prefix of its name
+	prefix := strings.TrimSuffix(backup.Name, storage.DefaultBackup)
+	res := make(map[string]struct{}, len(manifest.Chunks))
+	for _, chunk := range manifest.Chunks {
+		res[prefix+storage.DedupChunkObject(chunk.Digest)] = struct{}{}
+	}
+	return res, nil
+}
+
+// dedupUploadStartedSince returns true if an upload of a deduplicated backup started in the workspace of the chunk
+// after basis
+func (r *Reconciler) dedupUploadStartedSince(ctx context.Context, chunk storage.ObjectInfo, basis time.Time) (bool, error) {
+	// chunk objects live in the chunk directory next to the marker
+	marker := strings.TrimSuffix(path.Dir(chunk.Name)+"/", storage.DedupChunkPrefix) + storage.DedupUploadMarker
+	objs, err := r.Storage.ListObjectInfos(ctx, chunk.Bucket, marker)
+	if err != nil {
+		return false, xerrors.Errorf("cannot check for dedup uploads: %w", err)
+	}
+	for _, obj := range objs {
+		if obj.Name == marker && obj.LastModified.After(basis) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// qualifiedName returns the name the server refers to an object by
+func qualifiedName(obj storage.ObjectInfo) string {
+	return obj.Name + "@" + obj.Bucket
+}
+
+// apply takes a planned action, unless we're in dry-run mode
+func (r *Reconciler) apply(ctx context.Context, p plannedAction) error {
+	dryRun := strconv.FormatBool(r.Config.DryRun)
+	if r.Config.DryRun {
+		log.WithFields(objectFields(p)).Info("would apply retention policy (dry-run)")
+		r.objects.WithLabelValues(string(p.Action), dryRun).Inc()
+		r.bytes.WithLabelValues(string(p.Action), dryRun).Add(float64(p.Object.Size))
+		return nil
+	}
+
+	if p.Action == ActionDeleteDedupChunk {
+		// uploads which started after we walked the storage may reuse the chunk
+		started, err := r.dedupUploadStartedSince(ctx, p.Object, p.Basis)
+		if err != nil {
+			return err
+		}
+		if started {
+			log.WithFields(objectFields(p)).Debug("not deleting dedup chunk because an upload started since")
+			return nil
+		}
+	}
+
+	var err error
+	switch p.Action {
+	case ActionDeleteSnapshot, ActionDeleteHeadlessLog, ActionDeleteBuildLog, ActionDeleteDedupChunk:
+		err = r.Storage.DeleteObject(ctx, p.Object.Bucket, &storage.DeleteObjectQuery{Name: p.Object.Name})
+	case ActionArchive:
+		err = r.Storage.SetStorageClass(ctx, p.Object.Bucket, p.Object.Name, r.Config.ColdStorageClass)
+	default:
+		err = xerrors.Errorf("unknown action %s", p.Action)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		// the object is gone already, e.g. because its workspace was deleted in the meantime
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(objectFields(p)).Debug("applied retention policy")
+	r.objects.WithLabelValues(string(p.Action), dryRun).Inc()
+	r.bytes.WithLabelValues(string(p.Action), dryRun).Add(float64(p.Object.Size))
+	return nil
+}
+
+func objectFields(p plannedAction) map[string]interface{} {
+	return map[string]interface{}{
+		"action":       p.Action,
+		"bucket":       p.Object.Bucket,
+		"object":       p.Object.Name,
+		"size":         p.Object.Size,
+		"lastModified": p.Object.LastModified,
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package retention
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+type fakeStorage struct {
+	storage.PresignedNoopStorage
+
+	Objects  []storage.ObjectInfo
+	Content  map[string]string
+	URL      string
+	Deleted  []string
+	Archived []string
+
+	// AfterWalk is called once the objects were walked, e.g. to change them before the reconciler acts on them
+	AfterWalk func()
+}
+
+func (f *fakeStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]storage.ObjectInfo, error) {
+	var res []storage.ObjectInfo
+	for _, obj := range f.Objects {
+		if obj.Bucket == bucket && strings.HasPrefix(obj.Name, prefix) {
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeStorage) SignDownload(ctx context.Context, bucket, obj string, options *storage.SignedURLOptions) (*storage.DownloadInfo, error) {
+	return &storage.DownloadInfo{URL: f.URL + "/" + bucket + "/" + obj}, nil
+}
+
+// serve serves the content of the objects, which SignDownload links to
+func (f *fakeStorage) serve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := f.Content[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+	f.URL = srv.URL
+}
+
+type fakeReferences map[string]struct{}
+
+func (f fakeReferences) Referenced(ctx context.Context, snapshots []string) (map[string]struct{}, error) {
+	res := make(map[string]struct{})
+	for _, snap := range snapshots {
+		if _, ok := f[snap]; ok {
+			res[snap] = struct{}{}
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeStorage) WalkObjects(ctx context.Context, fn func(obj storage.ObjectInfo) error) error {
+	for _, obj := range f.Objects {
+		err := fn(obj)
+		if err != nil {
+			return err
+		}
+	}
+	if f.AfterWalk != nil {
+		f.AfterWalk()
+	}
+	return nil
+}
+
+func (f *fakeStorage) DeleteObject(ctx context.Context, bucket string, query *storage.DeleteObjectQuery) error {
+	f.Deleted = append(f.Deleted, bucket+"/"+query.Name)
+	return nil
+}
+
+func (f *fakeStorage) SetStorageClass(ctx context.Context, bucket, obj, class string) error {
+	f.Archived = append(f.Archived, bucket+"/"+obj+"@"+class)
+	return nil
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Object      string
+		Expectation workspaceObject
+		OK          bool
+	}{
+		{Name: "gcloud backup", Object: "workspaces/ws1/full.tar", OK: true, Expectation: workspaceObject{Workspace: "bkt/workspaces/ws1", Kind: kindBackup}},
+		{Name: "s3 snapshot", Object: "owner/workspaces/ws1/snapshot-1700000000000000000.tar", OK: true, Expectation: workspaceObject{Workspace: "bkt/owner/workspaces/ws1", Kind: kindSnapshot}},
+		{Name: "headless log", Object: "workspaces/ws1/instances/i1/logs/task1", OK: true, Expectation: workspaceObject{Workspace: "bkt/workspaces/ws1", Kind: kindHeadlessLog}},
+		{Name: "prebuild summary", Object: "workspaces/ws1/instances/i1/prebuild-summary.json", OK: true, Expectation: workspaceObject{Workspace: "bkt/workspaces/ws1", Kind: kindHeadlessLog}},
+		{Name: "backup chunk", Object: "workspaces/ws1/chunks/abc", OK: true, Expectation: workspaceObject{Workspace: "bkt/workspaces/ws1", Kind: kindDedupChunk}},
+		{Name: "dedup upload marker", Object: "workspaces/ws1/dedup-upload", OK: true, Expectation: workspaceObject{Workspace: "bkt/workspaces/ws1", Kind: kindDedupUploadMarker}},
+		{Name: "other", Object: "workspaces/ws1/instances/i1/other", OK: true, Expectation: workspaceObject{Workspace: "bkt/workspaces/ws1", Kind: kindOther}},
+		{Name: "blob", Object: "blobs/some-blob"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			obj := storage.ObjectInfo{Bucket: "bkt", Name: test.Object}
+			act, ok := classify(obj)
+			if ok != test.OK {
+				t.Fatalf("unexpected ok: expected %v, got %v", test.OK, ok)
+			}
+			if !ok {
+				return
+			}
+			test.Expectation.ObjectInfo = obj
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected classification (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// ws3 has a deduplicated backup which references chunk c1, ws4 has chunks but no backup anymore,
+	// ws5 uploaded a deduplicated backup just now, and ws6 is uploading one right now
+	var (
+		c1       = "workspaces/ws3/" + storage.DedupChunkObject(digest.FromString("c1").String())
+		c2       = "workspaces/ws3/" + storage.DedupChunkObject(digest.FromString("c2").String())
+		manifest bytes.Buffer
+	)
+	err := storage.WriteDedupManifest(&manifest, &storage.DedupManifest{Chunks: []storage.DedupChunk{{Digest: digest.FromString("c1").String()}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := map[string]string{
+		"user-b/workspaces/ws3/full.tar": manifest.String(),
+		"user-b/workspaces/ws5/full.tar": manifest.String(),
+		"user-b/workspaces/ws6/full.tar": manifest.String(),
+	}
+
+	objects := []storage.ObjectInfo{
+		{Bucket: "user-a", Name: "workspaces/ws1/full.tar", LastModified: now.Add(-40 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws1/snapshot-1.tar", LastModified: now.Add(-1 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws1/snapshot-3.tar", LastModified: now.Add(-50 * day), StorageClass: "COLDLINE"},
+		{Bucket: "user-a", Name: "workspaces/ws1/snapshot-2.tar", LastModified: now.Add(-40 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws1/snapshot-0.tar", LastModified: now.Add(-70 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws2/full.tar", LastModified: now.Add(-1 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws2/snapshot-1.tar", LastModified: now.Add(-60 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws2/instances/i1/logs/task1", LastModified: now.Add(-10 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws2/instances/i1/prebuild-summary.json", LastModified: now.Add(-10 * day)},
+		{Bucket: "user-a", Name: "workspaces/ws2/instances/i2/logs/task1", LastModified: now.Add(-1 * day)},
+		{Bucket: "user-a", Name: "blobs/some-blob", LastModified: now.Add(-100 * day)},
+		{Bucket: "user-b", Name: "workspaces/ws3/full.tar", LastModified: now.Add(-2 * day)},
+		{Bucket: "user-b", Name: c1, LastModified: now.Add(-40 * day)},
+		{Bucket: "user-b", Name: c2, LastModified: now.Add(-40 * day)},
+		{Bucket: "user-b", Name: "workspaces/ws3/chunks/" + digest.FromString("c3").Encoded(), LastModified: now.Add(-1 * time.Hour)},
+		{Bucket: "user-b", Name: "workspaces/ws4/chunks/" + digest.FromString("c1").Encoded(), LastModified: now.Add(-2 * day)},
+		{Bucket: "user-b", Name: "workspaces/ws5/full.tar", LastModified: now.Add(-1 * time.Hour)},
+		{Bucket: "user-b", Name: "workspaces/ws5/chunks/" + digest.FromString("c2").Encoded(), LastModified: now.Add(-40 * day)},
+		{Bucket: "user-b", Name: "workspaces/ws6/full.tar", LastModified: now.Add(-2 * day)},
+		{Bucket: "user-b", Name: "workspaces/ws6/dedup-upload", LastModified: now.Add(-1 * time.Hour)},
+		{Bucket: "user-b", Name: "workspaces/ws6/chunks/" + digest.FromString("c2").Encoded(), LastModified: now.Add(-2 * day)},
+		{Bucket: "user-image-builds", Name: "blobs/image-builds/build-1.log", LastModified: now.Add(-20 * day)},
+		{Bucket: "user-image-builds", Name: "blobs/image-builds/build-2.log", LastModified: now.Add(-1 * day)},
+	}
+	// the database still references snapshot-0 of ws1, e.g. because a prebuild uses it
+	refs := fakeReferences{"workspaces/ws1/snapshot-0.tar@user-a": {}}
+	cfg := config.RetentionConfig{
+		Enabled:            true,
+		KeepSnapshots:      2,
+		HeadlessLogsMaxAge: util.Duration(7 * day),
+		BuildLogsMaxAge:    util.Duration(14 * day),
+		ColdStorageAfter:   util.Duration(30 * day),
+		ColdStorageClass:   "COLDLINE",
+	}
+
+	type Expectation struct {
+		Deleted  []string
+		Archived []string
+	}
+	tests := []struct {
+		Name        string
+		DryRun      bool
+		Expectation Expectation
+	}{
+		{
+			Name: "enforces policies",
+			Expectation: Expectation{
+				Deleted: []string{
+					"user-a/workspaces/ws1/snapshot-1.tar",
+					"user-a/workspaces/ws2/instances/i1/logs/task1",
+					"user-a/workspaces/ws2/instances/i1/prebuild-summary.json",
+					"user-b/" + c2,
+					"user-b/workspaces/ws4/chunks/" + digest.FromString("c1").Encoded(),
+					"user-image-builds/blobs/image-builds/build-1.log",
+				},
+				Archived: []string{
+					"user-a/workspaces/ws1/full.tar@COLDLINE",
+					"user-a/workspaces/ws1/snapshot-0.tar@COLDLINE",
+					"user-a/workspaces/ws1/snapshot-2.tar@COLDLINE",
+					"user-a/workspaces/ws2/snapshot-1.tar@COLDLINE",
+					"user-b/" + c1 + "@COLDLINE",
+					"user-b/workspaces/ws5/chunks/" + digest.FromString("c2").Encoded() + "@COLDLINE",
+				},
+			},
+		},
+		{Name: "dry run", DryRun: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			s := &fakeStorage{Objects: objects, Content: content}
+			s.serve(t)
+			cfg := cfg
+			cfg.DryRun = test.DryRun
+			r := NewReconciler(cfg, s, refs, prometheus.NewRegistry())
+
+			err := r.Reconcile(context.Background(), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Strings(s.Deleted)
+			sort.Strings(s.Archived)
+			act := Expectation{Deleted: s.Deleted, Archived: s.Archived}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected actions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileWithoutReferences(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	s := &fakeStorage{Objects: []storage.ObjectInfo{
+		{Bucket: "user-a", Name: "workspaces/ws1/snapshot-1.tar", LastModified: now},
+		{Bucket: "user-a", Name: "workspaces/ws1/snapshot-2.tar", LastModified: now},
+	}}
+	r := NewReconciler(config.RetentionConfig{Enabled: true, KeepSnapshots: 1}, s, nil, prometheus.NewRegistry())
+
+	err := r.Reconcile(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Deleted) != 0 {
+		t.Errorf("snapshots were deleted without knowing whether they are referenced: %v", s.Deleted)
+	}
+}
+
+func TestReconcileDedupUploadRace(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	var manifest bytes.Buffer
+	err := storage.WriteDedupManifest(&manifest, &storage.DedupManifest{Chunks: []storage.DedupChunk{{Digest: digest.FromString("c1").String()}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreferenced := "workspaces/ws1/" + storage.DedupChunkObject(digest.FromString("c2").String())
+	s := &fakeStorage{
+		Objects: []storage.ObjectInfo{
+			{Bucket: "user-a", Name: "workspaces/ws1/full.tar", LastModified: now.Add(-2 * day)},
+			{Bucket: "user-a", Name: "workspaces/ws1/dedup-upload", LastModified: now.Add(-3 * day)},
+			{Bucket: "user-a", Name: unreferenced, LastModified: now.Add(-2 * day)},
+		},
+		Content: map[string]string{"user-a/workspaces/ws1/full.tar": manifest.String()},
+	}
+	s.serve(t)
+	// an upload starts after we walked the storage, and may reuse the unreferenced chunk
+	s.AfterWalk = func() {
+		s.Objects[1].LastModified = now
+	}
+
+	r := NewReconciler(config.RetentionConfig{Enabled: true}, s, nil, prometheus.NewRegistry())
+	err = r.Reconcile(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Deleted) != 0 {
+		t.Errorf("chunks were deleted while an upload was in progress: %v", s.Deleted)
+	}
+
+	// once the upload finished, the unreferenced chunk is collected
+	s.AfterWalk = nil
+	s.Objects[0].LastModified = now.Add(-1 * day)
+	s.Objects[1].LastModified = now.Add(-1*day - time.Hour)
+	err = r.Reconcile(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"user-a/" + unreferenced}, s.Deleted); diff != "" {
+		t.Errorf("unexpected deleted chunks (-want +got):\n%s", diff)
+	}
+}
@@ -6,6 +6,7 @@ package service
 
 import (
 	"context"
+	"regexp"
 	"strings"
 
 	"github.com/opentracing/opentracing-go"
@@ -21,6 +22,14 @@ import (
 	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 )
 
+const (
+	// buildLogOwner is the owner under which the logs of image builds are stored.
+	// Image builds are not owned by a single user, hence they share a pseudo owner.
+	buildLogOwner = "image-builds"
+)
+
+var buildIDRegex = regexp.MustCompile(`^[a-zA-Z0-9\-]+$`)
+
 // HeadlessLogService implements LogServiceServer
 type HeadlessLogService struct {
 	cfg       config.StorageConfig
@@ -104,3 +113,84 @@ func (ls *HeadlessLogService) ListLogs(ctx context.Context, req *api.ListLogsReq
 		TaskId: taskIds,
 	}, nil
 }
+
+// BuildLogUploadURL provides a URL to which the log of an image build can be uploaded via HTTP PUT
+func (ls *HeadlessLogService) BuildLogUploadURL(ctx context.Context, req *api.BuildLogUploadURLRequest) (resp *api.BuildLogUploadURLResponse, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BuildLogUploadURL")
+	span.SetTag("buildID", req.BuildId)
+	defer tracing.FinishSpan(span, &err)
+
+	blobName, err := ls.buildLogObject(req.BuildId)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := ls.s.Bucket(buildLogOwner)
+	err = ls.s.EnsureExists(ctx, bucket)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	info, err := ls.s.SignUpload(ctx, bucket, blobName, &storage.SignedURLOptions{
+		ContentType: "text/plain",
+	})
+	if err != nil {
+		log.WithField("buildID", req.BuildId).
+			WithField("bucket", bucket).
+			WithField("blobName", blobName).
+			WithError(err).
+			Error("error getting SignUpload URL")
+		if err == storage.ErrNotFound {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+
+	return &api.BuildLogUploadURLResponse{
+		Url: info.URL,
+	}, nil
+}
+
+// BuildLogDownloadURL provides a URL from where the log of an image build can be downloaded from
+func (ls *HeadlessLogService) BuildLogDownloadURL(ctx context.Context, req *api.BuildLogDownloadURLRequest) (resp *api.BuildLogDownloadURLResponse, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BuildLogDownloadURL")
+	span.SetTag("buildID", req.BuildId)
+	defer tracing.FinishSpan(span, &err)
+
+	blobName, err := ls.buildLogObject(req.BuildId)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := ls.s.Bucket(buildLogOwner)
+	info, err := ls.s.SignDownload(ctx, bucket, blobName, &storage.SignedURLOptions{})
+	if err != nil {
+		log.WithField("buildID", req.BuildId).
+			WithField("bucket", bucket).
+			WithField("blobName", blobName).
+			WithError(err).
+			Error("error getting SignDownload URL")
+		if err == storage.ErrNotFound {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+
+	return &api.BuildLogDownloadURLResponse{
+		Url: info.URL,
+	}, nil
+}
+
+func (ls *HeadlessLogService) buildLogObject(buildID string) (string, error) {
+	if !buildIDRegex.MatchString(buildID) {
+		return "", status.Errorf(codes.InvalidArgument, "invalid build ID %q", buildID)
+	}
+
+	blobName, err := ls.s.BlobObject(buildLogOwner, logs.BuildLogBlobName(buildID))
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return blobName, nil
+}
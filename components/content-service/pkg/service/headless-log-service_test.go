@@ -11,6 +11,8 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/content-service/api/config"
@@ -87,3 +89,51 @@ func TestListLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildLogDownloadURL(t *testing.T) {
+	tests := []struct {
+		Name             string
+		BuildID          string
+		ExpectedBlobName string
+		ExpectedCode     codes.Code
+	}{
+		{
+			Name:             "valid build ID",
+			BuildID:          "c5b3b9d6-1c3b-4c1e-8a47-4c4cd8f1f0a3",
+			ExpectedBlobName: "blobs/image-builds/c5b3b9d6-1c3b-4c1e-8a47-4c4cd8f1f0a3.log",
+			ExpectedCode:     codes.OK,
+		},
+		{
+			Name:         "path traversal",
+			BuildID:      "../../secret",
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "empty build ID",
+			ExpectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storagemock.NewMockPresignedAccess(ctrl)
+			svc := HeadlessLogService{s: s}
+
+			if test.ExpectedBlobName != "" {
+				s.EXPECT().BlobObject(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(userID, name string) (string, error) { return "blobs/" + name, nil })
+				s.EXPECT().Bucket(gomock.Eq(buildLogOwner)).Return("image-builds-bucket")
+				s.EXPECT().SignDownload(gomock.Any(), gomock.Eq("image-builds-bucket"), gomock.Eq(test.ExpectedBlobName), gomock.Any()).
+					Return(&storage.DownloadInfo{URL: "https://storage/" + test.ExpectedBlobName}, nil)
+			}
+
+			_, err := svc.BuildLogDownloadURL(context.Background(), &api.BuildLogDownloadURLRequest{BuildId: test.BuildID})
+			if code := status.Code(err); code != test.ExpectedCode {
+				t.Errorf("unexpected status code: want %v, got %v (%v)", test.ExpectedCode, code, err)
+			}
+		})
+	}
+}
@@ -7,7 +7,10 @@ package service
 import (
 	"context"
 	"errors"
+	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc/codes"
@@ -20,6 +23,13 @@ import (
 	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 )
 
+const (
+	// defaultContentPageSize is the number of entries ListWorkspaceContent returns if the request does not specify a page size
+	defaultContentPageSize = 50
+	// maxContentPageSize is the maximum number of entries ListWorkspaceContent returns
+	maxContentPageSize = 500
+)
+
 // WorkspaceService implements WorkspaceServiceServer
 type WorkspaceService struct {
 	cfg config.StorageConfig
@@ -129,3 +139,175 @@ func (cs *WorkspaceService) WorkspaceSnapshotExists(ctx context.Context, req *ap
 		Exists: exists,
 	}, nil
 }
+
+// DeleteSnapshot deletes a single snapshot of a workspace
+func (cs *WorkspaceService) DeleteSnapshot(ctx context.Context, req *api.DeleteSnapshotRequest) (resp *api.DeleteSnapshotResponse, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "DeleteSnapshot")
+	span.SetTag("user", req.OwnerId)
+	span.SetTag("workspaceId", req.WorkspaceId)
+	span.SetTag("filename", req.Filename)
+	defer tracing.FinishSpan(span, &err)
+
+	if req.OwnerId == "" || req.WorkspaceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner and workspace ID are required")
+	}
+	if req.Filename == "" {
+		return nil, status.Error(codes.InvalidArgument, "filename is missing")
+	}
+	// The filename becomes part of the object name, hence it must not point outside of the snapshots of the workspace
+	if strings.ContainsAny(req.Filename, `/\`) || strings.Contains(req.Filename, "..") {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not a valid snapshot filename", req.Filename)
+	}
+	if c := workspaceContent(req.Filename, storage.ObjectInfo{}); c == nil || c.Kind != api.WorkspaceContentKind_SNAPSHOT {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not a snapshot", req.Filename)
+	}
+
+	blobName := cs.s.BackupObject(req.OwnerId, req.WorkspaceId, req.Filename)
+	err = cs.s.DeleteObject(ctx, cs.s.Bucket(req.OwnerId), &storage.DeleteObjectQuery{Name: blobName})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.WithError(err).Debug("deleting workspace snapshot: NotFound, ", blobName)
+			return &api.DeleteSnapshotResponse{}, nil
+		}
+		log.WithError(err).Error("error deleting workspace snapshot: ", blobName)
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+
+	return &api.DeleteSnapshotResponse{}, nil
+}
+
+// ListWorkspaceContent lists the backups and snapshots of a workspace
+func (cs *WorkspaceService) ListWorkspaceContent(ctx context.Context, req *api.ListWorkspaceContentRequest) (resp *api.ListWorkspaceContentResponse, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ListWorkspaceContent")
+	span.SetTag("user", req.OwnerId)
+	span.SetTag("workspaceId", req.WorkspaceId)
+	defer tracing.FinishSpan(span, &err)
+
+	if req.OwnerId == "" || req.WorkspaceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner and workspace ID are required")
+	}
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultContentPageSize
+	case pageSize > maxContentPageSize:
+		pageSize = maxContentPageSize
+	}
+
+	prefix := cs.s.BackupObject(req.OwnerId, req.WorkspaceId, "")
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+	objs, err := cs.s.ListObjectInfos(ctx, cs.s.Bucket(req.OwnerId), prefix)
+	if err != nil {
+		log.WithFields(log.OWI(req.OwnerId, req.WorkspaceId, "")).WithError(err).Error("error listing workspace content")
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+
+	var content []*api.WorkspaceContent
+	for _, obj := range objs {
+		c := workspaceContent(strings.TrimPrefix(obj.Name, prefix), obj)
+		if c == nil {
+			continue
+		}
+		content = append(content, c)
+	}
+	// the page token is the name of the last entry of the previous page, which keeps pages stable
+	// while content is added or removed
+	sort.Slice(content, func(i, j int) bool { return content[i].Name < content[j].Name })
+	start := sort.Search(len(content), func(i int) bool { return content[i].Name > req.PageToken })
+	content = content[start:]
+
+	resp = &api.ListWorkspaceContentResponse{}
+	if len(content) > pageSize {
+		content = content[:pageSize]
+		resp.NextPageToken = content[pageSize-1].Name
+	}
+	resp.Content = content
+	return resp, nil
+}
+
+// RefreshWorkspaceContentURL provides a new download or upload URL for a backup or snapshot of a workspace
+func (cs *WorkspaceService) RefreshWorkspaceContentURL(ctx context.Context, req *api.RefreshWorkspaceContentURLRequest) (resp *api.RefreshWorkspaceContentURLResponse, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RefreshWorkspaceContentURL")
+	span.SetTag("user", req.OwnerId)
+	span.SetTag("workspaceId", req.WorkspaceId)
+	span.SetTag("name", req.Name)
+	span.SetTag("upload", req.Upload)
+	defer tracing.FinishSpan(span, &err)
+
+	if req.OwnerId == "" || req.WorkspaceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner and workspace ID are required")
+	}
+	if path.Clean(req.Name) != req.Name || workspaceContent(req.Name, storage.ObjectInfo{}) == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not a backup or snapshot", req.Name)
+	}
+	if req.TtlSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "TTL must not be negative")
+	}
+
+	var (
+		bucket   = cs.s.Bucket(req.OwnerId)
+		blobName = cs.s.BackupObject(req.OwnerId, req.WorkspaceId, req.Name)
+		opts     = &storage.SignedURLOptions{TTL: time.Duration(req.TtlSeconds) * time.Second}
+		url      string
+		expires  time.Time
+	)
+	if req.Upload {
+		var info *storage.UploadInfo
+		info, err = cs.s.SignUpload(ctx, bucket, blobName, opts)
+		if info != nil {
+			url, expires = info.URL, info.Expires
+		}
+	} else {
+		var info *storage.DownloadInfo
+		info, err = cs.s.SignDownload(ctx, bucket, blobName, opts)
+		if info != nil {
+			url, expires = info.URL, info.Expires
+		}
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		log.WithFields(log.OWI(req.OwnerId, req.WorkspaceId, "")).
+			WithField("bucket", bucket).
+			WithField("blobName", blobName).
+			WithField("upload", req.Upload).
+			WithError(err).
+			Error("error refreshing signed URL")
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+
+	return &api.RefreshWorkspaceContentURLResponse{
+		Url:       url,
+		ExpiresAt: expires.Unix(),
+	}, nil
+}
+
+// workspaceContent describes an object of a workspace if it is a backup or snapshot, e.g. full.tar,
+// snapshot-<timestamp>.tar or instances/<instanceID>/full.tar. Returns nil for any other object.
+func workspaceContent(name string, obj storage.ObjectInfo) *api.WorkspaceContent {
+	res := &api.WorkspaceContent{
+		Name:         name,
+		Size:         obj.Size,
+		LastModified: obj.LastModified.Unix(),
+		InstanceId:   obj.Annotation(storage.ObjectAnnotationInstanceID),
+	}
+
+	segs := strings.Split(name, "/")
+	switch {
+	case len(segs) == 1 && segs[0] == storage.DefaultBackup:
+		res.Kind = api.WorkspaceContentKind_BACKUP
+	case len(segs) == 1 && strings.HasPrefix(segs[0], "snapshot-") && strings.HasSuffix(segs[0], ".tar"):
+		res.Kind = api.WorkspaceContentKind_SNAPSHOT
+	case len(segs) == 3 && segs[0] == "instances" && strings.HasSuffix(segs[2], ".tar"):
+		res.Kind = api.WorkspaceContentKind_INSTANCE_BACKUP
+		res.InstanceId = segs[1]
+	default:
+		return nil
+	}
+	return res
+}
@@ -0,0 +1,290 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+	storagemock "github.com/gitpod-io/gitpod/content-service/pkg/storage/mock"
+)
+
+func TestListWorkspaceContent(t *testing.T) {
+	const (
+		ownerID     = "1234"
+		workspaceID = "amber-baboon-cij4wozf"
+		bucket      = "gitpod-user-1234"
+		prefix      = "workspaces/amber-baboon-cij4wozf/"
+	)
+	lastModified := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	object := func(name string, size int64, instanceID string) storage.ObjectInfo {
+		res := storage.ObjectInfo{Bucket: bucket, Name: prefix + name, Size: size, LastModified: lastModified}
+		if instanceID != "" {
+			// storage systems differ in how they case annotations
+			res.Annotations = map[string]string{"Gitpod-Instanceid": instanceID}
+		}
+		return res
+	}
+	objects := []storage.ObjectInfo{
+		object("snapshot-2.tar", 20, "i2"),
+		object("full.tar", 10, "i3"),
+		object("trail-1.tar", 1, ""),
+		object("instances/i1/full.tar", 30, ""),
+		object("instances/i1/logs/task-1", 2, ""),
+		object("snapshot-1.tar", 40, ""),
+	}
+	content := func(name string, kind api.WorkspaceContentKind, size int64, instanceID string) *api.WorkspaceContent {
+		return &api.WorkspaceContent{Name: name, Kind: kind, Size: size, LastModified: lastModified.Unix(), InstanceId: instanceID}
+	}
+
+	tests := []struct {
+		Name        string
+		PageSize    int32
+		PageToken   string
+		Expectation *api.ListWorkspaceContentResponse
+	}{
+		{
+			Name: "all content",
+			Expectation: &api.ListWorkspaceContentResponse{
+				Content: []*api.WorkspaceContent{
+					content("full.tar", api.WorkspaceContentKind_BACKUP, 10, "i3"),
+					content("instances/i1/full.tar", api.WorkspaceContentKind_INSTANCE_BACKUP, 30, "i1"),
+					content("snapshot-1.tar", api.WorkspaceContentKind_SNAPSHOT, 40, ""),
+					content("snapshot-2.tar", api.WorkspaceContentKind_SNAPSHOT, 20, "i2"),
+				},
+			},
+		},
+		{
+			Name:     "first page",
+			PageSize: 2,
+			Expectation: &api.ListWorkspaceContentResponse{
+				Content: []*api.WorkspaceContent{
+					content("full.tar", api.WorkspaceContentKind_BACKUP, 10, "i3"),
+					content("instances/i1/full.tar", api.WorkspaceContentKind_INSTANCE_BACKUP, 30, "i1"),
+				},
+				NextPageToken: "instances/i1/full.tar",
+			},
+		},
+		{
+			Name:      "last page",
+			PageSize:  2,
+			PageToken: "instances/i1/full.tar",
+			Expectation: &api.ListWorkspaceContentResponse{
+				Content: []*api.WorkspaceContent{
+					content("snapshot-1.tar", api.WorkspaceContentKind_SNAPSHOT, 40, ""),
+					content("snapshot-2.tar", api.WorkspaceContentKind_SNAPSHOT, 20, "i2"),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storagemock.NewMockPresignedAccess(ctrl)
+			s.EXPECT().Bucket(ownerID).Return(bucket).AnyTimes()
+			s.EXPECT().BackupObject(ownerID, workspaceID, "").Return(prefix)
+			s.EXPECT().ListObjectInfos(gomock.Any(), bucket, prefix).Return(objects, nil)
+
+			svc := WorkspaceService{
+				cfg: config.StorageConfig{Kind: config.GCloudStorage}, // dummy, mocked away
+				s:   s,
+			}
+			resp, err := svc.ListWorkspaceContent(context.Background(), &api.ListWorkspaceContentRequest{
+				OwnerId:     ownerID,
+				WorkspaceId: workspaceID,
+				PageSize:    test.PageSize,
+				PageToken:   test.PageToken,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(test.Expectation, resp, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRefreshWorkspaceContentURL(t *testing.T) {
+	const (
+		ownerID     = "1234"
+		workspaceID = "amber-baboon-cij4wozf"
+		bucket      = "gitpod-user-1234"
+	)
+	expires := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name         string
+		Request      *api.RefreshWorkspaceContentURLRequest
+		ExpectedTTL  time.Duration
+		SignErr      error
+		ExpectedCode codes.Code
+	}{
+		{
+			Name:    "backup",
+			Request: &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "full.tar"},
+		},
+		{
+			Name:        "snapshot with TTL",
+			Request:     &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "snapshot-1.tar", TtlSeconds: 7200},
+			ExpectedTTL: 2 * time.Hour,
+		},
+		{
+			Name:        "upload",
+			Request:     &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "full.tar", TtlSeconds: 3600, Upload: true},
+			ExpectedTTL: time.Hour,
+		},
+		{
+			Name:         "not found",
+			Request:      &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "instances/i1/full.tar"},
+			SignErr:      storage.ErrNotFound,
+			ExpectedCode: codes.NotFound,
+		},
+		{
+			Name:         "not workspace content",
+			Request:      &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "trail-1.tar"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "path traversal",
+			Request:      &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "instances/../../other-workspace/full.tar"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "negative TTL",
+			Request:      &api.RefreshWorkspaceContentURLRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Name: "full.tar", TtlSeconds: -1},
+			ExpectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storagemock.NewMockPresignedAccess(ctrl)
+			s.EXPECT().Bucket(ownerID).Return(bucket).AnyTimes()
+			if test.ExpectedCode == codes.OK || test.SignErr != nil {
+				obj := "workspaces/" + workspaceID + "/" + test.Request.Name
+				s.EXPECT().BackupObject(ownerID, workspaceID, test.Request.Name).Return(obj)
+				if test.Request.Upload {
+					s.EXPECT().SignUpload(gomock.Any(), bucket, obj, &storage.SignedURLOptions{TTL: test.ExpectedTTL}).
+						Return(&storage.UploadInfo{URL: "https://storage/" + obj, Expires: expires}, test.SignErr)
+				} else {
+					s.EXPECT().SignDownload(gomock.Any(), bucket, obj, &storage.SignedURLOptions{TTL: test.ExpectedTTL}).
+						Return(&storage.DownloadInfo{URL: "https://storage/" + obj, Expires: expires}, test.SignErr)
+				}
+			}
+
+			svc := WorkspaceService{
+				cfg: config.StorageConfig{Kind: config.GCloudStorage}, // dummy, mocked away
+				s:   s,
+			}
+			resp, err := svc.RefreshWorkspaceContentURL(context.Background(), test.Request)
+			if code := status.Code(err); code != test.ExpectedCode {
+				t.Fatalf("unexpected status code: is %v but expected %v (%v)", code, test.ExpectedCode, err)
+			}
+			if err != nil {
+				return
+			}
+
+			expectation := &api.RefreshWorkspaceContentURLResponse{
+				Url:       "https://storage/workspaces/" + workspaceID + "/" + test.Request.Name,
+				ExpiresAt: expires.Unix(),
+			}
+			if diff := cmp.Diff(expectation, resp, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	const (
+		ownerID     = "1234"
+		workspaceID = "amber-baboon-cij4wozf"
+		bucket      = "gitpod-user-1234"
+	)
+
+	tests := []struct {
+		Name         string
+		Request      *api.DeleteSnapshotRequest
+		DeleteErr    error
+		ExpectedCode codes.Code
+	}{
+		{
+			Name:    "snapshot",
+			Request: &api.DeleteSnapshotRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Filename: "snapshot-1.tar"},
+		},
+		{
+			Name:      "not found",
+			Request:   &api.DeleteSnapshotRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Filename: "snapshot-1.tar"},
+			DeleteErr: storage.ErrNotFound,
+		},
+		{
+			Name:         "missing workspace ID",
+			Request:      &api.DeleteSnapshotRequest{OwnerId: ownerID, Filename: "snapshot-1.tar"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "backup",
+			Request:      &api.DeleteSnapshotRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Filename: "full.tar"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "path traversal",
+			Request:      &api.DeleteSnapshotRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Filename: "../other-workspace/snapshot-1.tar"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "parent directory",
+			Request:      &api.DeleteSnapshotRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Filename: "snapshot-..tar"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "backslash",
+			Request:      &api.DeleteSnapshotRequest{OwnerId: ownerID, WorkspaceId: workspaceID, Filename: `snapshot-..\..\full.tar`},
+			ExpectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storagemock.NewMockPresignedAccess(ctrl)
+			s.EXPECT().Bucket(ownerID).Return(bucket).AnyTimes()
+			if test.ExpectedCode == codes.OK {
+				obj := "workspaces/" + workspaceID + "/" + test.Request.Filename
+				s.EXPECT().BackupObject(ownerID, workspaceID, test.Request.Filename).Return(obj)
+				s.EXPECT().DeleteObject(gomock.Any(), bucket, &storage.DeleteObjectQuery{Name: obj}).Return(test.DeleteErr)
+			}
+
+			svc := WorkspaceService{
+				cfg: config.StorageConfig{Kind: config.GCloudStorage}, // dummy, mocked away
+				s:   s,
+			}
+			_, err := svc.DeleteSnapshot(context.Background(), test.Request)
+			if code := status.Code(err); code != test.ExpectedCode {
+				t.Fatalf("unexpected status code: is %v but expected %v (%v)", code, test.ExpectedCode, err)
+			}
+		})
+	}
+}
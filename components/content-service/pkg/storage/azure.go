@@ -0,0 +1,750 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+)
+
+var _ DirectAccess = &DirectAzureStorage{}
+var _ LifecycleAccess = &presignedAzureStorage{}
+
+// ValidateAzureConfig checks if the Azure storage config is valid
+func ValidateAzureConfig(c *config.AzureConfig) error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.AccountName, validation.Required),
+		validation.Field(&c.AccountKey, validation.Required),
+	)
+}
+
+// addAzureParamsFromMounts allows for the account key to be read from a file
+func addAzureParamsFromMounts(c *config.AzureConfig) error {
+	if c.AccountKeyFile == "" {
+		return nil
+	}
+	value, err := os.ReadFile(c.AccountKeyFile)
+	if err != nil {
+		return err
+	}
+	c.AccountKey = strings.TrimSpace(string(value))
+	return nil
+}
+
+// NewAzureClient produces a new blob service client based on this configuration
+func NewAzureClient(c *config.AzureConfig) (*service.Client, error) {
+	err := addAzureParamsFromMounts(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateAzureConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// presigning URLs requires the account key, hence we don't support other means of authentication
+	cred, err := azblob.NewSharedKeyCredential(c.AccountName, c.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", c.AccountName)
+	}
+	return service.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+}
+
+// newDirectAzureAccess provides direct access to the remote storage system
+func newDirectAzureAccess(cfg config.AzureConfig, transfer config.TransferConfig) (*DirectAzureStorage, error) {
+	err := addAzureParamsFromMounts(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ValidateAzureConfig(&cfg); err != nil {
+		return nil, err
+	}
+	return &DirectAzureStorage{AzureConfig: cfg, Transfer: transfer}, nil
+}
+
+// DirectAzureStorage implements Azure Blob Storage as remote storage backend
+type DirectAzureStorage struct {
+	Username      string
+	WorkspaceName string
+	InstanceID    string
+	AzureConfig   config.AzureConfig
+	Transfer      config.TransferConfig
+
+	client *service.Client
+}
+
+// Validate checks if the Azure storage is configured properly
+func (rs *DirectAzureStorage) Validate() error {
+	err := ValidateAzureConfig(&rs.AzureConfig)
+	if err != nil {
+		return err
+	}
+
+	return validation.ValidateStruct(rs,
+		validation.Field(&rs.Username, validation.Required),
+		validation.Field(&rs.WorkspaceName, validation.Required),
+	)
+}
+
+// Init initializes the remote storage - call this before calling anything else on the interface
+func (rs *DirectAzureStorage) Init(ctx context.Context, owner, workspace, instance string) (err error) {
+	rs.Username = owner
+	rs.WorkspaceName = workspace
+	rs.InstanceID = instance
+
+	err = rs.Validate()
+	if err != nil {
+		return err
+	}
+
+	cl, err := NewAzureClient(&rs.AzureConfig)
+	if err != nil {
+		return err
+	}
+	rs.client = cl
+
+	return nil
+}
+
+// EnsureExists makes sure that the remote storage location exists and can be up- or downloaded from
+func (rs *DirectAzureStorage) EnsureExists(ctx context.Context) (err error) {
+	return azureEnsureExists(ctx, rs.client, rs.bucketName())
+}
+
+func azureEnsureExists(ctx context.Context, client *service.Client, containerName string) (err error) {
+	//nolint:staticcheck,ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.EnsureExists")
+	defer tracing.FinishSpan(span, &err)
+
+	if client == nil {
+		return xerrors.Errorf("no Azure client available - did you call Init()?")
+	}
+
+	_, err = client.NewContainerClient(containerName).Create(ctx, nil)
+	if bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		// container exists already - we're fine
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot create container: %w", err)
+	}
+
+	log.WithField("containerName", containerName).Debug("Created container")
+	return nil
+}
+
+func (rs *DirectAzureStorage) download(ctx context.Context, destination string, ctn string, obj string, mappings []archive.IDMapping) (found bool, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.download")
+	span.SetTag("container", ctn)
+	span.SetTag("object", obj)
+	defer tracing.FinishSpan(span, &err)
+
+	if rs.client == nil {
+		return false, xerrors.Errorf("no Azure client available - did you call Init()?")
+	}
+
+	blb := rs.client.NewContainerClient(ctn).NewBlobClient(obj)
+	props, err := blb.GetProperties(ctx, nil)
+	if err != nil {
+		err = translateAzureError(err)
+		if err == ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	rc, err := rs.objectReader(ctx, blb, props)
+	if err != nil {
+		return true, err
+	}
+	defer rc.Close()
+
+	vr, verify, err := verifyingReader(rc, annotation(azureAnnotations(props.Metadata), ObjectAnnotationDigest))
+	if err != nil {
+		return true, err
+	}
+
+	err = extractTarbal(ctx, destination, vr, mappings, func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+		resp, err := rs.client.NewContainerClient(ctn).NewBlobClient(rs.BackupObject(DedupChunkObject(chunk.Digest))).DownloadStream(ctx, nil)
+		if err != nil {
+			return nil, translateAzureError(err)
+		}
+		return resp.Body, nil
+	})
+	// corrupted content can fail extraction, in which case the digest mismatch is the more useful error
+	if verr := verify(); verr != nil {
+		return true, xerrors.Errorf("cannot verify %s: %w", obj, verr)
+	}
+	if err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// objectReader reads a blob. Large blobs are downloaded in parts using ranged reads, all of which are pinned to the
+// version of the blob described by props.
+func (rs *DirectAzureStorage) objectReader(ctx context.Context, blb *blob.Client, props blob.GetPropertiesResponse) (io.ReadCloser, error) {
+	pinned := &blob.AccessConditions{
+		ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfMatch: props.ETag},
+	}
+
+	var size int64
+	if props.ContentLength != nil {
+		size = *props.ContentLength
+	}
+	transfer := getTransferOptions(rs.Transfer)
+	if !transfer.multipartDownload(size) {
+		resp, err := blb.DownloadStream(ctx, &blob.DownloadStreamOptions{AccessConditions: pinned})
+		if err != nil {
+			return nil, translateAzureError(err)
+		}
+		return resp.Body, nil
+	}
+
+	return downloadToTempFile(ctx, size, transfer, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		resp, err := blb.DownloadStream(ctx, &blob.DownloadStreamOptions{
+			Range:            blob.HTTPRange{Offset: offset, Count: length},
+			AccessConditions: pinned,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	})
+}
+
+// Download takes the latest state from the remote storage and downloads it to a local path
+func (rs *DirectAzureStorage) Download(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	return rs.download(ctx, destination, rs.bucketName(), rs.objectName(name), mappings)
+}
+
+// DownloadSnapshot downloads a snapshot. The snapshot name is expected to be one produced by Qualify
+func (rs *DirectAzureStorage) DownloadSnapshot(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	ctn, obj, err := ParseSnapshotName(name)
+	if err != nil {
+		return false, err
+	}
+
+	return rs.download(ctx, destination, ctn, obj, mappings)
+}
+
+// ListObjects returns all objects found with the given prefix. Returns an empty list if the container does not exist (yet).
+func (rs *DirectAzureStorage) ListObjects(ctx context.Context, prefix string) (objects []string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	err = azureListBlobs(ctx, rs.client.NewContainerClient(rs.bucketName()), prefix, false, func(item *container.BlobItem) error {
+		objects = append(objects, *item.Name)
+		return nil
+	})
+	if translateAzureError(err) == ErrNotFound {
+		// container does not exist: nothing to list
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("cannot list objects: %w", err)
+	}
+	return objects, nil
+}
+
+// Qualify fully qualifies a snapshot name so that it can be downloaded using DownloadSnapshot
+func (rs *DirectAzureStorage) Qualify(name string) string {
+	return fmt.Sprintf("%s@%s", rs.objectName(name), rs.bucketName())
+}
+
+// UploadInstance takes all files from a local location and uploads it to the per-instance remote storage
+func (rs *DirectAzureStorage) UploadInstance(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, object string, err error) {
+	if rs.InstanceID == "" {
+		return "", "", xerrors.Errorf("instanceID is required to comput object name")
+	}
+	return rs.Upload(ctx, source, InstanceObjectName(rs.InstanceID, name), opts...)
+}
+
+// Upload takes all files from a local location and uploads it to the remote storage
+func (rs *DirectAzureStorage) Upload(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, obj string, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.Upload")
+	defer tracing.FinishSpan(span, &err)
+
+	options, err := GetUploadOptions(opts)
+	if err != nil {
+		err = xerrors.Errorf("cannot get options: %w", err)
+		return
+	}
+
+	if rs.client == nil {
+		err = xerrors.Errorf("no Azure client available - did you call Init()?")
+		return
+	}
+
+	bucket = rs.bucketName()
+	obj = rs.objectName(name)
+	span.LogKV("container", bucket)
+	span.LogKV("obj", obj)
+	span.LogKV("account", rs.AzureConfig.AccountName)
+
+	f, err := os.Open(source)
+	if err != nil {
+		err = xerrors.Errorf("cannot open file for uploading: %w", err)
+		return
+	}
+	defer f.Close()
+
+	var headers *blob.HTTPHeaders
+	if options.ContentType != "" {
+		headers = &blob.HTTPHeaders{BlobContentType: &options.ContentType}
+	}
+	transfer := getTransferOptions(rs.Transfer)
+	blb := rs.client.NewContainerClient(bucket).NewBlockBlobClient(obj)
+	if options.streamed() {
+		_, err = blb.UploadStream(ctx, options.uploadReader(f), &blockblob.UploadStreamOptions{
+			BlockSize:   transfer.PartSize,
+			Concurrency: transfer.Concurrency,
+			Metadata:    azureMetadata(options.Annotations),
+			HTTPHeaders: headers,
+		})
+	} else {
+		_, err = blb.UploadFile(ctx, f, &blockblob.UploadFileOptions{
+			BlockSize:   transfer.PartSize,
+			Concurrency: uint16(transfer.Concurrency),
+			Metadata:    azureMetadata(options.Annotations),
+			HTTPHeaders: headers,
+		})
+	}
+	if err != nil {
+		err = translateAzureError(err)
+		return
+	}
+
+	return
+}
+
+// azureContainerName returns the container of a user. Unlike buckets, containers are scoped to the storage account,
+// hence there's no need to make their names globally unique.
+func azureContainerName(ownerID, containerName string) string {
+	if containerName != "" {
+		return containerName
+	}
+
+	return fmt.Sprintf("gitpod-user-%s", ownerID)
+}
+
+func azureWorkspaceBackupObjectName(ownerID, workspaceID, name string) string {
+	return filepath.Join(ownerID, "workspaces", workspaceID, name)
+}
+
+// Bucket provides the container name for a particular user
+func (rs *DirectAzureStorage) Bucket(ownerID string) string {
+	return azureContainerName(ownerID, rs.AzureConfig.ContainerName)
+}
+
+// BackupObject returns a backup's object name that a direct downloader would download
+func (rs *DirectAzureStorage) BackupObject(name string) string {
+	return rs.objectName(name)
+}
+
+func (rs *DirectAzureStorage) bucketName() string {
+	return azureContainerName(rs.Username, rs.AzureConfig.ContainerName)
+}
+
+func (rs *DirectAzureStorage) objectName(name string) string {
+	var username string
+	if rs.AzureConfig.ContainerName != "" {
+		username = rs.Username
+	}
+	return azureWorkspaceBackupObjectName(username, rs.WorkspaceName, name)
+}
+
+func newPresignedAzureAccess(cfg config.AzureConfig, signedURLs config.SignedURLConfig) (*presignedAzureStorage, error) {
+	cl, err := NewAzureClient(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &presignedAzureStorage{client: cl, AzureConfig: cfg, signedURLs: getSignedURLTTLs(signedURLs)}, nil
+}
+
+type presignedAzureStorage struct {
+	client      *service.Client
+	AzureConfig config.AzureConfig
+	signedURLs  signedURLTTLs
+}
+
+// EnsureExists makes sure that the remote storage location exists and can be up- or downloaded from
+func (s *presignedAzureStorage) EnsureExists(ctx context.Context, bucket string) (err error) {
+	return azureEnsureExists(ctx, s.client, bucket)
+}
+
+// DiskUsage gives the total objects size of objects that have the given prefix
+func (s *presignedAzureStorage) DiskUsage(ctx context.Context, bucket string, prefix string) (size int64, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.DiskUsage")
+	defer tracing.FinishSpan(span, &err)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	err = azureListBlobs(ctx, s.client.NewContainerClient(bucket), prefix, false, func(item *container.BlobItem) error {
+		size += azureBlobSize(item)
+		return nil
+	})
+	if err != nil {
+		return 0, translateAzureError(err)
+	}
+	return size, nil
+}
+
+// SignDownload describes an object for download - if the object is not found, ErrNotFound is returned
+func (s *presignedAzureStorage) SignDownload(ctx context.Context, bucket, object string, options *SignedURLOptions) (info *DownloadInfo, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.SignDownload")
+	defer func() {
+		if err == ErrNotFound {
+			span.LogKV("found", false)
+			tracing.FinishSpan(span, nil)
+			return
+		}
+
+		tracing.FinishSpan(span, &err)
+	}()
+
+	blb := s.client.NewContainerClient(bucket).NewBlobClient(object)
+	props, err := blb.GetProperties(ctx, nil)
+	if err != nil {
+		return nil, translateAzureError(err)
+	}
+	expires := time.Now().Add(s.signedURLs.download(options))
+	url, err := blb.GetSASURL(sas.BlobPermissions{Read: true}, expires, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := azureAnnotations(props.Metadata)
+	res := &DownloadInfo{
+		Meta: ObjectMeta{
+			OCIMediaType:       annotation(annotations, ObjectAnnotationOCIContentType),
+			Digest:             annotation(annotations, ObjectAnnotationDigest),
+			UncompressedDigest: annotation(annotations, ObjectAnnotationUncompressedDigest),
+		},
+		URL:     url,
+		Expires: expires,
+	}
+	if props.ContentType != nil {
+		res.Meta.ContentType = *props.ContentType
+	}
+	if props.ContentLength != nil {
+		res.Size = *props.ContentLength
+	}
+	return res, nil
+}
+
+// SignUpload describes an object for upload. Clients need to send the x-ms-blob-type: BlockBlob header when using the URL.
+func (s *presignedAzureStorage) SignUpload(ctx context.Context, bucket, obj string, options *SignedURLOptions) (info *UploadInfo, err error) {
+	//nolint:ineffassign,staticcheck
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.SignUpload")
+	defer tracing.FinishSpan(span, &err)
+
+	expires := time.Now().Add(s.signedURLs.upload(options))
+	url, err := s.client.NewContainerClient(bucket).NewBlobClient(obj).GetSASURL(sas.BlobPermissions{Create: true, Write: true}, expires, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadInfo{URL: url, Expires: expires}, nil
+}
+
+// DeleteObject deletes objects in the given container specified by the given query
+func (s *presignedAzureStorage) DeleteObject(ctx context.Context, bucket string, query *DeleteObjectQuery) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.DeleteObject")
+	defer tracing.FinishSpan(span, &err)
+
+	ctn := s.client.NewContainerClient(bucket)
+	if query.Name != "" {
+		_, err = ctn.NewBlobClient(query.Name).Delete(ctx, nil)
+		if err != nil {
+			log.WithField("bucket", bucket).WithField("object", query.Name).Error(err)
+			return translateAzureError(err)
+		}
+		return nil
+	}
+
+	// blobs are deleted one by one, as batch deletion is not supported with shared keys everywhere
+	err = azureListBlobs(ctx, ctn, query.Prefix, false, func(item *container.BlobItem) error {
+		_, err := ctn.NewBlobClient(*item.Name).Delete(ctx, nil)
+		if translateAzureError(err) == ErrNotFound {
+			// deleted since we listed it
+			return nil
+		}
+		if err != nil {
+			log.WithField("bucket", bucket).WithField("object", *item.Name).Error(err)
+		}
+		return err
+	})
+	return translateAzureError(err)
+}
+
+// DeleteBucket deletes the container of a user, or their content if all users share a container
+func (s *presignedAzureStorage) DeleteBucket(ctx context.Context, userID, bucket string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.DeleteBucket")
+	defer tracing.FinishSpan(span, &err)
+
+	if s.AzureConfig.ContainerName != "" {
+		if bucket != s.AzureConfig.ContainerName {
+			log.WithField("requestedBucket", bucket).WithField("configuredContainer", s.AzureConfig.ContainerName).Error("can only delete from configured container")
+			return xerrors.Errorf("can only delete from configured container; this looks like a bug in Gitpod")
+		}
+		return s.DeleteObject(ctx, bucket, &DeleteObjectQuery{Prefix: userID + "/"})
+	}
+
+	// deleting a container deletes all of its blobs
+	_, err = s.client.NewContainerClient(bucket).Delete(ctx, nil)
+	return translateAzureError(err)
+}
+
+// ObjectHash gets a hash value of an object
+func (s *presignedAzureStorage) ObjectHash(ctx context.Context, bucket string, obj string) (hash string, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.ObjectHash")
+	defer tracing.FinishSpan(span, &err)
+
+	props, err := s.client.NewContainerClient(bucket).NewBlobClient(obj).GetProperties(ctx, nil)
+	if err != nil {
+		return "", translateAzureError(err)
+	}
+	if props.ETag == nil {
+		return "", nil
+	}
+	return string(*props.ETag), nil
+}
+
+// ObjectExists tells whether the given object exists or not
+func (s *presignedAzureStorage) ObjectExists(ctx context.Context, bucket, obj string) (exists bool, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.ObjectExists")
+	defer tracing.FinishSpan(span, &err)
+
+	_, err = s.client.NewContainerClient(bucket).NewBlobClient(obj).GetProperties(ctx, nil)
+	if err != nil {
+		e := translateAzureError(err)
+		if e == ErrNotFound {
+			return false, nil
+		}
+		return false, e
+	}
+	return true, nil
+}
+
+// ListObjectInfos describes the objects whose name starts with prefix, including their annotations
+func (s *presignedAzureStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) (res []ObjectInfo, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.ListObjectInfos")
+	defer tracing.FinishSpan(span, &err)
+
+	// unlike other storage systems, Azure lists the metadata of blobs
+	err = azureListBlobs(ctx, s.client.NewContainerClient(bucket), prefix, true, func(item *container.BlobItem) error {
+		obj := azureObjectInfo(bucket, item)
+		obj.Annotations = azureAnnotations(item.Metadata)
+		res = append(res, obj)
+		return nil
+	})
+	if translateAzureError(err) == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("cannot list objects of %s: %w", bucket, err)
+	}
+	return res, nil
+}
+
+// Bucket provides the container name for a particular user
+func (s *presignedAzureStorage) Bucket(ownerID string) string {
+	return azureContainerName(ownerID, s.AzureConfig.ContainerName)
+}
+
+// BlobObject returns a blob's object name
+func (s *presignedAzureStorage) BlobObject(userID, name string) (string, error) {
+	return blobObjectName(name)
+}
+
+// BackupObject returns a backup's object name that a direct downloader would download
+func (s *presignedAzureStorage) BackupObject(ownerID string, workspaceID, name string) string {
+	var username string
+	if s.AzureConfig.ContainerName != "" {
+		username = ownerID
+	}
+	return azureWorkspaceBackupObjectName(username, workspaceID, name)
+}
+
+// InstanceObject returns a instance's object name that a direct downloader would download
+func (s *presignedAzureStorage) InstanceObject(ownerID string, workspaceID string, instanceID string, name string) string {
+	return s.BackupObject(ownerID, workspaceID, InstanceObjectName(instanceID, name))
+}
+
+// WalkObjects calls fn for every object in the containers of all users
+func (s *presignedAzureStorage) WalkObjects(ctx context.Context, fn func(obj ObjectInfo) error) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.WalkObjects")
+	defer tracing.FinishSpan(span, &err)
+
+	var containers []string
+	if s.AzureConfig.ContainerName != "" {
+		containers = []string{s.AzureConfig.ContainerName}
+	} else {
+		prefix := azureContainerName("", "")
+		pager := s.client.NewListContainersPager(&service.ListContainersOptions{Prefix: &prefix})
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return xerrors.Errorf("cannot list containers: %w", err)
+			}
+			for _, ctn := range page.ContainerItems {
+				containers = append(containers, *ctn.Name)
+			}
+		}
+	}
+
+	for _, ctn := range containers {
+		err := azureListBlobs(ctx, s.client.NewContainerClient(ctn), "", false, func(item *container.BlobItem) error {
+			return fn(azureObjectInfo(ctn, item))
+		})
+		if err != nil {
+			return xerrors.Errorf("cannot walk objects of %s: %w", ctn, err)
+		}
+	}
+	return nil
+}
+
+// SetStorageClass moves an object to another access tier. Unlike other storage systems, Azure changes the tier of
+// blobs in place, which keeps their content and metadata.
+func (s *presignedAzureStorage) SetStorageClass(ctx context.Context, bucket, obj, class string) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure.SetStorageClass")
+	defer tracing.FinishSpan(span, &err)
+
+	_, err = s.client.NewContainerClient(bucket).NewBlobClient(obj).SetTier(ctx, blob.AccessTier(class), nil)
+	if err != nil {
+		return xerrors.Errorf("cannot move %s to access tier %s: %w", obj, class, translateAzureError(err))
+	}
+	return nil
+}
+
+// azureListBlobs calls fn for every blob whose name starts with prefix. If withMetadata is set, the blobs are
+// listed with their metadata.
+func azureListBlobs(ctx context.Context, ctn *container.Client, prefix string, withMetadata bool, fn func(item *container.BlobItem) error) error {
+	opts := &container.ListBlobsFlatOptions{
+		Include: container.ListBlobsInclude{Metadata: withMetadata},
+	}
+	if prefix != "" {
+		opts.Prefix = &prefix
+	}
+
+	pager := ctn.NewListBlobsFlatPager(opts)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Segment.BlobItems {
+			err = fn(item)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func azureObjectInfo(ctn string, item *container.BlobItem) ObjectInfo {
+	res := ObjectInfo{
+		Bucket: ctn,
+		Name:   *item.Name,
+		Size:   azureBlobSize(item),
+	}
+	if item.Properties != nil {
+		if item.Properties.LastModified != nil {
+			res.LastModified = *item.Properties.LastModified
+		}
+		if item.Properties.AccessTier != nil {
+			res.StorageClass = string(*item.Properties.AccessTier)
+		}
+	}
+	return res
+}
+
+func azureBlobSize(item *container.BlobItem) int64 {
+	if item.Properties == nil || item.Properties.ContentLength == nil {
+		return 0
+	}
+	return *item.Properties.ContentLength
+}
+
+// azureMetadata turns annotations into blob metadata. The names of metadata must be valid C# identifiers,
+// hence the dashes of annotation names are replaced.
+func azureMetadata(annotations map[string]string) map[string]*string {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	res := make(map[string]*string, len(annotations))
+	for k, v := range annotations {
+		res[strings.ReplaceAll(k, "-", "_")] = &v
+	}
+	return res
+}
+
+// azureAnnotations turns blob metadata back into annotations. Azure does not preserve the case of metadata names
+// everywhere, hence annotations need to be looked up using annotation.
+func azureAnnotations(metadata map[string]*string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if v == nil {
+			continue
+		}
+		res[strings.ReplaceAll(k, "_", "-")] = *v
+	}
+	return res
+}
+
+func translateAzureError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound, bloberror.ResourceNotFound) {
+		return ErrNotFound
+	}
+
+	return err
+}
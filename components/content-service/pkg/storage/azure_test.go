@@ -0,0 +1,101 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+)
+
+func TestAzureBackupObject(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		ContainerNameConfig  string
+		Username             string
+		Workspace            string
+		ObjectName           string
+		ExpectedContainer    string
+		ExpectedBackupObject string
+	}{
+		{
+			Name:                 "no dedicated container",
+			Username:             "test-user",
+			Workspace:            "gitpodio-gitpod-2cx8z8e643x",
+			ObjectName:           "backup.tar",
+			ExpectedContainer:    "gitpod-user-test-user",
+			ExpectedBackupObject: "workspaces/gitpodio-gitpod-2cx8z8e643x/backup.tar",
+		},
+		{
+			Name:                 "with dedicated container",
+			ContainerNameConfig:  "root-container",
+			Username:             "test-user",
+			Workspace:            "gitpodio-gitpod-2cx8z8e643x",
+			ObjectName:           "backup.tar",
+			ExpectedContainer:    "root-container",
+			ExpectedBackupObject: "test-user/workspaces/gitpodio-gitpod-2cx8z8e643x/backup.tar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := config.AzureConfig{
+				AccountName:   "fake",
+				AccountKey:    "ZmFrZV9rZXk=",
+				ContainerName: test.ContainerNameConfig,
+			}
+			azure, err := newDirectAzureAccess(cfg, config.TransferConfig{})
+			if err != nil {
+				t.Fatalf("failed to create azure access: '%v'", err)
+			}
+			err = azure.Init(context.Background(), test.Username, test.Workspace, "fa9aa2af-b6de-45fc-8b48-534bb440429f")
+			if err != nil {
+				t.Fatalf("failed to init azure access: '%v'", err)
+			}
+			presignedAzure, err := newPresignedAzureAccess(cfg, config.SignedURLConfig{})
+			if err != nil {
+				t.Fatalf("failed to create presigned azure access: '%v'", err)
+			}
+
+			if act := azure.Bucket(test.Username); act != test.ExpectedContainer {
+				t.Errorf("[azure] unexpected container name: is '%s' but expected '%s'", act, test.ExpectedContainer)
+			}
+			if act := presignedAzure.Bucket(test.Username); act != test.ExpectedContainer {
+				t.Errorf("[presigned azure] unexpected container name: is '%s' but expected '%s'", act, test.ExpectedContainer)
+			}
+			if act := azure.BackupObject(test.ObjectName); act != test.ExpectedBackupObject {
+				t.Errorf("[azure] unexpected backup object name: is '%s' but expected '%s'", act, test.ExpectedBackupObject)
+			}
+			if act := presignedAzure.BackupObject(test.Username, test.Workspace, test.ObjectName); act != test.ExpectedBackupObject {
+				t.Errorf("[presigned azure] unexpected backup object name: is '%s' but expected '%s'", act, test.ExpectedBackupObject)
+			}
+		})
+	}
+}
+
+func TestAzureAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		ObjectAnnotationDigest:             "sha256:abc",
+		ObjectAnnotationUncompressedDigest: "sha256:def",
+	}
+
+	metadata := azureMetadata(annotations)
+	if _, ok := metadata["gitpod_digest"]; !ok {
+		t.Fatalf("expected metadata names to be valid C# identifiers, got %v", metadata)
+	}
+
+	// Azure returns metadata names in the canonical form of HTTP headers
+	returned := make(map[string]*string, len(metadata))
+	returned["Gitpod_digest"] = metadata["gitpod_digest"]
+	returned["Gitpod_uncompresseddigest"] = metadata["gitpod_uncompressedDigest"]
+
+	act := azureAnnotations(returned)
+	for name, expected := range annotations {
+		if v := annotation(act, name); v != expected {
+			t.Errorf("unexpected annotation %s: expected '%s', got '%s'", name, expected, v)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DedupChunkPrefix is the name prefix of the chunks deduplicated backups are stored in
+	DedupChunkPrefix = "chunks/"
+
+	// DedupUploadMarker is the object an upload of a deduplicated backup writes before it looks for chunks to reuse.
+	// Chunks are not garbage collected while an upload is in progress, because it may reuse any of them.
+	DedupUploadMarker = "dedup-upload"
+
+	// DedupManifestContentType is the content type of the manifest which replaces the archive of a deduplicated backup
+	DedupManifestContentType = "application/vnd.gitpod.dedup-manifest.v1+json"
+
+	// dedupManifestHeader precedes the manifest of a deduplicated backup, so that it can be told apart from an archive
+	dedupManifestHeader = "gitpod-dedup-manifest/v1\n"
+)
+
+// dedupChunkSizes bound the size of content-defined chunks. Mask decides how often the rolling hash
+// cuts a chunk and hence the average chunk size.
+type dedupChunkSizes struct {
+	Min  int
+	Max  int
+	Mask uint64
+}
+
+// defaultDedupChunkSizes produces chunks of 4 MiB on average, large enough to keep the number of objects
+// per backup manageable and small enough that a changed file doesn't invalidate much of the backup.
+var defaultDedupChunkSizes = dedupChunkSizes{
+	Min:  1 * megabytes,
+	Max:  16 * megabytes,
+	Mask: 1<<22 - 1,
+}
+
+// gearTable maps every byte to a pseudo-random value for the rolling hash. The values must never change,
+// otherwise chunks uploaded before could not be reused.
+var gearTable = func() (res [256]uint64) {
+	// splitmix64 with a fixed seed
+	seed := uint64(0x6769747061640001)
+	for i := range res {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		res[i] = z ^ (z >> 31)
+	}
+	return
+}()
+
+// DedupManifest lists the chunks of a deduplicated backup in the order they make up the archive
+type DedupManifest struct {
+	// Digest is the digest of the archive the chunks make up
+	Digest string `json:"digest"`
+	// Size is the size of the archive
+	Size   int64        `json:"size"`
+	Chunks []DedupChunk `json:"chunks"`
+}
+
+// DedupChunk is a content-addressed part of a deduplicated backup
+type DedupChunk struct {
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+// DedupChunkObject returns the object name a chunk is stored under, relative to the workspace
+func DedupChunkObject(chunkDigest string) string {
+	return DedupChunkPrefix + digest.Digest(chunkDigest).Encoded()
+}
+
+// SplitChunks splits the content of r into content-defined chunks, such that content which is unchanged
+// between two backups results in the same chunks even if content before it has changed.
+// The chunk data passed to fn is only valid until fn returns.
+func SplitChunks(r io.Reader, fn func(chunk DedupChunk, data []byte) error) error {
+	return splitChunks(r, defaultDedupChunkSizes, fn)
+}
+
+func splitChunks(r io.Reader, sizes dedupChunkSizes, fn func(chunk DedupChunk, data []byte) error) error {
+	var (
+		buf = make([]byte, sizes.Max)
+		n   int
+		eof bool
+	)
+	for {
+		if !eof {
+			m, err := io.ReadFull(r, buf[n:])
+			n += m
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if n == 0 {
+			return nil
+		}
+
+		cut := chunkBoundary(buf[:n], sizes)
+		data := buf[:cut]
+		err := fn(DedupChunk{Digest: digest.FromBytes(data).String(), Size: int64(cut)}, data)
+		if err != nil {
+			return err
+		}
+		n = copy(buf, buf[cut:n])
+	}
+}
+
+// chunkBoundary finds the end of the chunk at the start of data using a gear rolling hash
+func chunkBoundary(data []byte, sizes dedupChunkSizes) int {
+	if len(data) <= sizes.Min {
+		return len(data)
+	}
+
+	var hash uint64
+	for i := sizes.Min; i < len(data); i++ {
+		hash = hash<<1 + gearTable[data[i]]
+		if hash&sizes.Mask == 0 {
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// WriteDedupManifest writes the manifest of a deduplicated backup
+func WriteDedupManifest(w io.Writer, manifest *DedupManifest) error {
+	_, err := io.WriteString(w, dedupManifestHeader)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(manifest)
+}
+
+// ReadDedupManifest reads the manifest of a deduplicated backup. If r does not start with a manifest,
+// e.g. because it is a regular archive, nil is returned without an error.
+func ReadDedupManifest(r io.Reader) (*DedupManifest, error) {
+	br := bufio.NewReader(r)
+	if !isDedupManifest(br) {
+		return nil, nil
+	}
+	_, _ = br.Discard(len(dedupManifestHeader))
+
+	var res DedupManifest
+	err := json.NewDecoder(br).Decode(&res)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse dedup manifest: %w", err)
+	}
+	for _, chunk := range res.Chunks {
+		_, err = digest.Parse(chunk.Digest)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid chunk in dedup manifest: %w", err)
+		}
+	}
+	return &res, nil
+}
+
+// isDedupManifest checks if a stream starts with a dedup manifest without consuming it
+func isDedupManifest(br *bufio.Reader) bool {
+	header, _ := br.Peek(len(dedupManifestHeader))
+	return bytes.Equal(header, []byte(dedupManifestHeader))
+}
+
+// RestoreDedupChunks writes the archive of a deduplicated backup to w, verifying every chunk and the archive as a whole
+func RestoreDedupChunks(ctx context.Context, w io.Writer, manifest *DedupManifest, open func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error)) error {
+	expected, err := digest.Parse(manifest.Digest)
+	if err != nil {
+		return xerrors.Errorf("cannot parse digest %s: %w", manifest.Digest, err)
+	}
+	archive := expected.Algorithm().Digester()
+	w = io.MultiWriter(w, archive.Hash())
+
+	var size int64
+	for _, chunk := range manifest.Chunks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := restoreDedupChunk(ctx, w, chunk, open)
+		if err != nil {
+			return xerrors.Errorf("cannot restore chunk %s: %w", chunk.Digest, err)
+		}
+		size += n
+	}
+
+	if size != manifest.Size {
+		return xerrors.Errorf("%w: expected %d bytes, got %d", ErrDigestMismatch, manifest.Size, size)
+	}
+	if actual := archive.Digest(); actual != expected {
+		return &DigestMismatchError{Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+func restoreDedupChunk(ctx context.Context, w io.Writer, chunk DedupChunk, open func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error)) (int64, error) {
+	expected, err := digest.Parse(chunk.Digest)
+	if err != nil {
+		return 0, err
+	}
+
+	rc, err := open(ctx, chunk)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	// a chunk is verified before any of it is written, so that a corrupted chunk never ends up in the archive
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, io.LimitReader(rc, chunk.Size+1))
+	if err != nil {
+		return 0, err
+	}
+	if int64(buf.Len()) != chunk.Size {
+		return 0, xerrors.Errorf("%w: expected %d bytes, got %d", ErrDigestMismatch, chunk.Size, buf.Len())
+	}
+	if actual := expected.Algorithm().FromBytes(buf.Bytes()); actual != expected {
+		return 0, &DigestMismatchError{Expected: expected, Actual: actual}
+	}
+
+	return buf.WriteTo(w)
+}
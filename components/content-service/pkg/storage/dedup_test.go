@@ -0,0 +1,175 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+)
+
+var testDedupChunkSizes = dedupChunkSizes{Min: 256, Max: 4096, Mask: 1<<10 - 1}
+
+func splitTestChunks(t *testing.T, content []byte) (chunks []DedupChunk, data map[string][]byte) {
+	data = make(map[string][]byte)
+	err := splitChunks(bytes.NewReader(content), testDedupChunkSizes, func(chunk DedupChunk, d []byte) error {
+		chunks = append(chunks, chunk)
+		data[chunk.Digest] = append([]byte(nil), d...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestSplitChunks(t *testing.T) {
+	content := make([]byte, 64*1024)
+	rand.New(rand.NewSource(42)).Read(content)
+
+	chunks, data := splitTestChunks(t, content)
+
+	var joined []byte
+	for _, chunk := range chunks {
+		if chunk.Size > int64(testDedupChunkSizes.Max) {
+			t.Errorf("chunk %s exceeds the maximum size: %d", chunk.Digest, chunk.Size)
+		}
+		joined = append(joined, data[chunk.Digest]...)
+	}
+	if !bytes.Equal(content, joined) {
+		t.Fatal("chunks do not make up the content")
+	}
+
+	// inserting content at the start must only change the chunks around it
+	changed := append([]byte("some new file"), content...)
+	changedChunks, _ := splitTestChunks(t, changed)
+	var reused int
+	for _, chunk := range changedChunks {
+		if _, ok := data[chunk.Digest]; ok {
+			reused++
+		}
+	}
+	if reused < len(chunks)-2 {
+		t.Errorf("expected most chunks to be reused, got %d of %d", reused, len(chunks))
+	}
+}
+
+func TestDedupManifest(t *testing.T) {
+	content := make([]byte, 16*1024)
+	rand.New(rand.NewSource(42)).Read(content)
+	chunks, data := splitTestChunks(t, content)
+	manifest := &DedupManifest{Digest: digest.FromBytes(content).String(), Size: int64(len(content)), Chunks: chunks}
+
+	var buf bytes.Buffer
+	err := WriteDedupManifest(&buf, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err := ReadDedupManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(manifest, act); diff != "" {
+		t.Fatalf("unexpected manifest (-want +got):\n%s", diff)
+	}
+
+	notManifest, err := ReadDedupManifest(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notManifest != nil {
+		t.Error("expected archive not to be read as a manifest")
+	}
+
+	tests := []struct {
+		Name    string
+		Corrupt func(d []byte) []byte
+	}{
+		{Name: "valid chunks"},
+		{Name: "truncated chunk", Corrupt: func(d []byte) []byte { return d[:len(d)-1] }},
+		{Name: "modified chunk", Corrupt: func(d []byte) []byte {
+			d = append([]byte(nil), d...)
+			d[0]++
+			return d
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			open := func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+				d := data[chunk.Digest]
+				if test.Corrupt != nil && chunk.Digest == chunks[1].Digest {
+					d = test.Corrupt(d)
+				}
+				return io.NopCloser(bytes.NewReader(d)), nil
+			}
+
+			var res bytes.Buffer
+			err := RestoreDedupChunks(context.Background(), &res, manifest, open)
+			if test.Corrupt != nil {
+				if !IsDigestMismatch(err) {
+					t.Fatalf("expected digest mismatch, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, res.Bytes()) {
+				t.Error("restored archive does not match the content")
+			}
+		})
+	}
+}
+
+func TestExtractDedupManifest(t *testing.T) {
+	fc := make([]byte, 64*1024)
+	rand.New(rand.NewSource(42)).Read(fc)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	err := tw.WriteHeader(&tar.Header{Name: "content", Mode: 0644, Size: int64(len(fc)), Uid: os.Getuid(), Gid: os.Getgid()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = tw.Write(fc)
+	tw.Close()
+
+	chunks, data := splitTestChunks(t, archive.Bytes())
+	manifest := &DedupManifest{Digest: digest.FromBytes(archive.Bytes()).String(), Size: int64(archive.Len()), Chunks: chunks}
+	var mf bytes.Buffer
+	err = WriteDedupManifest(&mf, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data[chunk.Digest])), nil
+	}
+
+	err = extractTarbal(context.Background(), t.TempDir(), bytes.NewReader(mf.Bytes()), nil, nil)
+	if err == nil {
+		t.Error("expected manifest without chunks to fail")
+	}
+
+	dest := t.TempDir()
+	err = extractTarbal(context.Background(), dest, bytes.NewReader(mf.Bytes()), nil, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err := os.ReadFile(filepath.Join(dest, "content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fc, act) {
+		t.Error("extracted content does not match the archive")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrDigestMismatch is returned when downloaded content does not match the digest it was uploaded with,
+// e.g. because remote storage returned a truncated object
+var ErrDigestMismatch = errors.New("content does not match its digest")
+
+// DigestMismatchError describes content which does not match the digest it was uploaded with. It is an
+// ErrDigestMismatch, and gRPC services which return it fail with DataLoss.
+type DigestMismatchError struct {
+	Expected digest.Digest
+	Actual   digest.Digest
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", ErrDigestMismatch, e.Expected, e.Actual)
+}
+
+// Is makes errors.Is(err, ErrDigestMismatch) hold
+func (e *DigestMismatchError) Is(target error) bool {
+	return target == ErrDigestMismatch
+}
+
+// GRPCStatus lets gRPC report the error as DataLoss
+func (e *DigestMismatchError) GRPCStatus() *status.Status {
+	return status.New(codes.DataLoss, e.Error())
+}
+
+// IsDigestMismatch returns true if err was caused by content not matching its digest. Content initializers
+// run in a separate process and only report their error message, hence we look for the message as well.
+func IsDigestMismatch(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrDigestMismatch) {
+		return true
+	}
+	return strings.Contains(err.Error(), ErrDigestMismatch.Error())
+}
+
+// FileDigest computes the digest of a file, as we annotate uploaded objects with it
+func FileDigest(fn string) (digest.Digest, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return digest.FromReader(f)
+}
+
+// VerifyFileDigest checks that a downloaded file matches the digest its object was annotated with.
+// Objects without digest, e.g. those uploaded before we annotated them, are not verified.
+func VerifyFileDigest(fn string, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	dgst, err := digest.Parse(expected)
+	if err != nil {
+		return xerrors.Errorf("cannot parse digest %s: %w", expected, err)
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	actual, err := dgst.Algorithm().FromReader(f)
+	if err != nil {
+		return err
+	}
+	if actual != dgst {
+		return &DigestMismatchError{Expected: dgst, Actual: actual}
+	}
+	return nil
+}
+
+// verifyingReader computes the digest of r while it's read, such that streamed downloads can be verified.
+// Once the content was consumed, verify reads the remainder of r and checks that the content matches
+// the digest it was annotated with. Like VerifyFileDigest, content without digest is not verified.
+func verifyingReader(r io.Reader, expected string) (vr io.Reader, verify func() error, err error) {
+	if expected == "" {
+		return r, func() error { return nil }, nil
+	}
+	dgst, err := digest.Parse(expected)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("cannot parse digest %s: %w", expected, err)
+	}
+
+	digester := dgst.Algorithm().Digester()
+	vr = io.TeeReader(r, digester.Hash())
+	verify = func() error {
+		// archives may be followed by padding which extracting them does not read
+		_, err := io.Copy(io.Discard, vr)
+		if err != nil {
+			return err
+		}
+		if actual := digester.Digest(); actual != dgst {
+			return &DigestMismatchError{Expected: dgst, Actual: actual}
+		}
+		return nil
+	}
+	return vr, verify, nil
+}
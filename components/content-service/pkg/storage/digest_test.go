@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyFileDigest(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "full.tar")
+	err := os.WriteFile(fn, []byte("hello world"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst, err := FileDigest(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := filepath.Join(t.TempDir(), "truncated.tar")
+	err = os.WriteFile(truncated, []byte("hello"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name             string
+		File             string
+		Digest           string
+		ExpectedMismatch bool
+		ExpectedError    bool
+	}{
+		{Name: "matching digest", File: fn, Digest: dgst.String()},
+		{Name: "no digest", File: truncated},
+		{Name: "truncated content", File: truncated, Digest: dgst.String(), ExpectedMismatch: true, ExpectedError: true},
+		{Name: "invalid digest", File: fn, Digest: "sha256:nope", ExpectedError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := VerifyFileDigest(test.File, test.Digest)
+			if (err != nil) != test.ExpectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act := IsDigestMismatch(err); act != test.ExpectedMismatch {
+				t.Errorf("unexpected IsDigestMismatch: expected %v, got %v", test.ExpectedMismatch, act)
+			}
+		})
+	}
+
+	// content initializers only report the message of their errors
+	err = VerifyFileDigest(truncated, dgst.String())
+	if !IsDigestMismatch(errors.New(err.Error())) {
+		t.Errorf("expected the error message to be recognised as digest mismatch: %v", err)
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+	dgst := digest.FromString("hello world").String()
+
+	tests := []struct {
+		Name             string
+		Content          string
+		Digest           string
+		Read             int64
+		ExpectedMismatch bool
+	}{
+		{Name: "matching digest", Content: "hello world", Digest: dgst, Read: -1},
+		{Name: "partially read", Content: "hello world", Digest: dgst, Read: 5},
+		{Name: "no digest", Content: "hello", Read: -1},
+		{Name: "truncated content", Content: "hello", Digest: dgst, Read: -1, ExpectedMismatch: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r, verify, err := verifyingReader(strings.NewReader(test.Content), test.Digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.Read >= 0 {
+				r = io.LimitReader(r, test.Read)
+			}
+			_, err = io.Copy(io.Discard, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = verify()
+			if act := IsDigestMismatch(err); act != test.ExpectedMismatch {
+				t.Fatalf("unexpected IsDigestMismatch: expected %v, got %v", test.ExpectedMismatch, act)
+			}
+			if test.ExpectedMismatch && status.Code(err) != codes.DataLoss {
+				t.Errorf("expected digest mismatch to have code %v, got %v", codes.DataLoss, status.Code(err))
+			}
+		})
+	}
+}
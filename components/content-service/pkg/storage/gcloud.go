@@ -35,6 +35,7 @@ import (
 )
 
 var _ DirectAccess = &DirectGCPStorage{}
+var _ LifecycleAccess = &PresignedGCPStorage{}
 
 var validateExistsInFilesystem = validation.By(func(o interface{}) error {
 	s, ok := o.(string)
@@ -61,7 +62,7 @@ func ValidateGCPConfig(c *config.GCPConfig) error {
 }
 
 // newDirectGCPAccess provides direct access to the remote storage system
-func newDirectGCPAccess(cfg config.GCPConfig, stage config.Stage) (*DirectGCPStorage, error) {
+func newDirectGCPAccess(cfg config.GCPConfig, stage config.Stage, transfer config.TransferConfig) (*DirectGCPStorage, error) {
 	if err := ValidateGCPConfig(&cfg); err != nil {
 		return nil, err
 	}
@@ -69,6 +70,7 @@ func newDirectGCPAccess(cfg config.GCPConfig, stage config.Stage) (*DirectGCPSto
 	return &DirectGCPStorage{
 		Stage:     stage,
 		GCPConfig: cfg,
+		Transfer:  transfer,
 	}, nil
 }
 
@@ -79,6 +81,7 @@ type DirectGCPStorage struct {
 	InstanceID    string
 	GCPConfig     config.GCPConfig
 	Stage         config.Stage
+	Transfer      config.TransferConfig
 
 	client *gcpstorage.Client
 
@@ -207,10 +210,15 @@ func (rs *DirectGCPStorage) download(ctx context.Context, destination string, bk
 			sa = fmt.Sprintf(`-o "Credentials:gs_service_key_file=%v"`, rs.GCPConfig.CredentialsFile)
 		}
 
-		args := fmt.Sprintf(`gsutil -q -m %v\
-		  -o "GSUtil:sliced_object_download_max_components=8" \
+		components := 8
+		if rs.Transfer.Concurrency > 0 {
+			components = rs.Transfer.Concurrency
+		}
+
+		args := fmt.Sprintf(`gsutil -q -m %v%v\
+		  -o "GSUtil:sliced_object_download_max_components=%d" \
 		  -o "GSUtil:parallel_thread_count=1" \
-		  cp gs://%s %s`, sa, filepath.Join(bkt, obj), backupDir)
+		  cp gs://%s %s`, sa, gsutilTransferFlags(rs.Transfer, "sliced_object_download_component_size"), components, filepath.Join(bkt, obj), backupDir)
 
 		log.WithField("flags", args).Debug("gsutil flags")
 
@@ -233,7 +241,18 @@ func (rs *DirectGCPStorage) download(ctx context.Context, destination string, bk
 	}
 	defer rc.Close()
 
-	err = extractTarbal(ctx, destination, rc, mappings)
+	attrs, err := rs.client.Bucket(bkt).Object(obj).Attrs(ctx)
+	if err != nil {
+		return true, xerrors.Errorf("cannot get metadata of %s: %w", obj, err)
+	}
+	err = VerifyFileDigest(rc.Name(), attrs.Metadata[ObjectAnnotationDigest])
+	if err != nil {
+		return true, xerrors.Errorf("cannot verify %s: %w", obj, err)
+	}
+
+	err = extractTarbal(ctx, destination, rc, mappings, func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+		return rs.client.Bucket(bkt).Object(rs.BackupObject(DedupChunkObject(chunk.Digest))).NewReader(ctx)
+	})
 	if err != nil {
 		return true, err
 	}
@@ -358,6 +377,12 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 	defer tracing.FinishSpan(span, &err)
 	log := log.WithFields(log.OWI(rs.Username, rs.WorkspaceName, ""))
 
+	options, err := GetUploadOptions(opts)
+	if err != nil {
+		err = xerrors.Errorf("cannot get options: %w", err)
+		return
+	}
+
 	if rs.client == nil {
 		err = xerrors.Errorf("no gcloud client available - did you call Init()?")
 		return
@@ -391,6 +416,13 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 		return
 	}
 
+	if options.streamed() {
+		// gsutil can neither throttle uploads nor report their progress, hence we upload through the client library instead
+		err = rs.streamedUpload(ctx, options.uploadReader(sfn), bucket, object, options)
+		uploadSpan.Finish()
+		return
+	}
+
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -403,11 +435,16 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 			sa = fmt.Sprintf(`-o "Credentials:gs_service_key_file=%v"`, rs.GCPConfig.CredentialsFile)
 		}
 
-		args := fmt.Sprintf(`gsutil -q -m %v\
+		threads := 6
+		if rs.Transfer.Concurrency > 0 {
+			threads = rs.Transfer.Concurrency
+		}
+
+		args := fmt.Sprintf(`gsutil -q -m %v%v\
 		  -o "GSUtil:parallel_composite_upload_threshold=150M" \
 		  -o "GSUtil:parallel_process_count=3" \
-		  -o "GSUtil:parallel_thread_count=6" \
-		  cp %s gs://%s`, sa, source, filepath.Join(bucket, object))
+		  -o "GSUtil:parallel_thread_count=%d" \
+		  cp %s gs://%s`, sa, gsutilTransferFlags(rs.Transfer, "parallel_composite_upload_component_size"), threads, source, filepath.Join(bucket, object))
 
 		log.WithField("flags", args).Debug("gsutil flags")
 
@@ -429,6 +466,40 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 	return
 }
 
+func (rs *DirectGCPStorage) streamedUpload(ctx context.Context, src io.Reader, bucket, object string, options *UploadOptions) error {
+	wc := rs.client.Bucket(bucket).Object(object).NewWriter(ctx)
+	wc.Metadata = options.Annotations
+	wc.ContentType = options.ContentType
+	if rs.Transfer.PartSizeMiB > 0 {
+		// the upload is resumable, failed chunks are retried on their own
+		wc.ChunkSize = int(getTransferOptions(rs.Transfer).PartSize)
+	}
+
+	_, err := io.Copy(wc, src)
+	if err != nil {
+		wc.Close()
+		return xerrors.Errorf("cannot upload backup: %w", err)
+	}
+	err = wc.Close()
+	if err != nil {
+		return xerrors.Errorf("cannot upload backup: %w", err)
+	}
+	return nil
+}
+
+// gsutilTransferFlags returns the gsutil options for the configured part size and retries.
+// gsutil keeps track of the transferred parts and resumes failed transfers by itself.
+func gsutilTransferFlags(c config.TransferConfig, partSizeOption string) string {
+	var res string
+	if c.PartSizeMiB > 0 {
+		res += fmt.Sprintf(` -o "GSUtil:%s=%dM"`, partSizeOption, getTransferOptions(c).PartSize/megabytes)
+	}
+	if c.Retries > 0 {
+		res += fmt.Sprintf(` -o "Boto:num_retries=%d"`, c.Retries)
+	}
+	return res
+}
+
 func (rs *DirectGCPStorage) bucketName() string {
 	return gcpBucketName(rs.Stage, rs.Username)
 }
@@ -472,7 +543,7 @@ func newGCPClient(ctx context.Context, cfg config.GCPConfig) (*gcpstorage.Client
 	return client, nil
 }
 
-func newPresignedGCPAccess(config config.GCPConfig, stage config.Stage) (*PresignedGCPStorage, error) {
+func newPresignedGCPAccess(config config.GCPConfig, stage config.Stage, signedURLs config.SignedURLConfig) (*PresignedGCPStorage, error) {
 	err := ValidateGCPConfig(&config)
 	if err != nil {
 		return nil, xerrors.Errorf("invalid config: %w", err)
@@ -511,6 +582,7 @@ func newPresignedGCPAccess(config config.GCPConfig, stage config.Stage) (*Presig
 		stage:      stage,
 		privateKey: privateKey.PrivateKey,
 		accessID:   privateKey.Email,
+		signedURLs: getSignedURLTTLs(signedURLs),
 	}, nil
 }
 
@@ -520,6 +592,7 @@ type PresignedGCPStorage struct {
 	stage      config.Stage
 	privateKey []byte
 	accessID   string
+	signedURLs signedURLTTLs
 }
 
 // Bucket provides the bucket name for a particular user
@@ -619,11 +692,12 @@ func (p *PresignedGCPStorage) downloadInfo(ctx context.Context, client *gcpstora
 		Digest:             obj.Metadata[ObjectAnnotationDigest],
 		UncompressedDigest: obj.Metadata[ObjectAnnotationUncompressedDigest],
 	}
+	expires := time.Now().Add(p.signedURLs.download(options))
 	url, err := gcpstorage.SignedURL(obj.Bucket, obj.Name, &gcpstorage.SignedURLOptions{
 		Method:         "GET",
 		GoogleAccessID: p.accessID,
 		PrivateKey:     p.privateKey,
-		Expires:        time.Now().Add(1 * time.Hour),
+		Expires:        expires,
 		ContentType:    options.ContentType,
 	})
 	if err != nil {
@@ -631,9 +705,10 @@ func (p *PresignedGCPStorage) downloadInfo(ctx context.Context, client *gcpstora
 	}
 
 	return &DownloadInfo{
-		Meta: *meta,
-		URL:  url,
-		Size: obj.Size,
+		Meta:    *meta,
+		URL:     url,
+		Size:    obj.Size,
+		Expires: expires,
 	}, nil
 }
 
@@ -655,11 +730,12 @@ func (p *PresignedGCPStorage) SignUpload(ctx context.Context, bucket, object str
 		return nil, err
 	}
 
+	expires := time.Now().Add(p.signedURLs.upload(options))
 	url, err := gcpstorage.SignedURL(bucket, object, &gcpstorage.SignedURLOptions{
 		Method:         "PUT",
 		GoogleAccessID: p.accessID,
 		PrivateKey:     p.privateKey,
-		Expires:        time.Now().Add(30 * time.Minute),
+		Expires:        expires,
 		ContentType:    options.ContentType,
 	})
 	if err != nil {
@@ -667,7 +743,8 @@ func (p *PresignedGCPStorage) SignUpload(ctx context.Context, bucket, object str
 	}
 
 	return &UploadInfo{
-		URL: url,
+		URL:     url,
+		Expires: expires,
 	}, nil
 }
 
@@ -796,6 +873,43 @@ func (p *PresignedGCPStorage) ObjectExists(ctx context.Context, bucket, obj stri
 	return true, nil
 }
 
+// ListObjectInfos describes the objects whose name starts with prefix, including their annotations
+func (p *PresignedGCPStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) (res []ObjectInfo, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GCloudBucketRemotegcpStorage.ListObjectInfos")
+	defer tracing.FinishSpan(span, &err)
+
+	client, err := newGCPClient(ctx, p.config)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:staticcheck
+	defer client.Close()
+
+	objects := client.Bucket(bucket).Objects(ctx, &gcpstorage.Query{Prefix: prefix})
+	for {
+		obj, err := objects.Next()
+		if err == iterator.Done {
+			return res, nil
+		}
+		if errors.Is(err, gcpstorage.ErrBucketNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("cannot list objects of %s: %w", bucket, err)
+		}
+
+		res = append(res, ObjectInfo{
+			Bucket:       bucket,
+			Name:         obj.Name,
+			Size:         obj.Size,
+			LastModified: obj.Updated,
+			StorageClass: obj.StorageClass,
+			Annotations:  obj.Metadata,
+		})
+	}
+}
+
 // BackupObject returns a backup's object name that a direct downloader would download
 func (p *PresignedGCPStorage) BackupObject(ownerID string, workspaceID string, name string) string {
 	return fmt.Sprintf("workspaces/%s", gcpWorkspaceBackupObjectName(workspaceID, name))
@@ -805,3 +919,86 @@ func (p *PresignedGCPStorage) BackupObject(ownerID string, workspaceID string, n
 func (p *PresignedGCPStorage) InstanceObject(ownerID string, workspaceID string, instanceID string, name string) string {
 	return p.BackupObject(ownerID, workspaceID, InstanceObjectName(instanceID, name))
 }
+
+// WalkObjects calls fn for every object in the buckets of all users
+func (p *PresignedGCPStorage) WalkObjects(ctx context.Context, fn func(obj ObjectInfo) error) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GCloudBucketRemotegcpStorage.WalkObjects")
+	defer tracing.FinishSpan(span, &err)
+
+	client, err := newGCPClient(ctx, p.config)
+	if err != nil {
+		return err
+	}
+	//nolint:staticcheck
+	defer client.Close()
+
+	buckets := client.Buckets(ctx, p.config.Project)
+	buckets.Prefix = gcpBucketName(p.stage, "")
+	for {
+		bkt, err := buckets.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("cannot list buckets: %w", err)
+		}
+
+		objects := client.Bucket(bkt.Name).Objects(ctx, nil)
+		for {
+			obj, err := objects.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return xerrors.Errorf("cannot list objects of %s: %w", bkt.Name, err)
+			}
+
+			err = fn(ObjectInfo{
+				Bucket:       bkt.Name,
+				Name:         obj.Name,
+				Size:         obj.Size,
+				LastModified: obj.Updated,
+				StorageClass: obj.StorageClass,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SetStorageClass rewrites an object in another storage class
+func (p *PresignedGCPStorage) SetStorageClass(ctx context.Context, bucket, object, class string) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GCloudBucketRemotegcpStorage.SetStorageClass")
+	defer tracing.FinishSpan(span, &err)
+
+	client, err := newGCPClient(ctx, p.config)
+	if err != nil {
+		return err
+	}
+	//nolint:staticcheck
+	defer client.Close()
+
+	obj := client.Bucket(bucket).Object(object)
+	attrs, err := obj.Attrs(ctx)
+	if errors.Is(err, gcpstorage.ErrObjectNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// a rewrite replaces the metadata of the object, hence we carry it over. The generation condition
+	// ensures we don't overwrite a backup which was uploaded in the meantime.
+	copier := obj.If(gcpstorage.Conditions{GenerationMatch: attrs.Generation}).CopierFrom(obj)
+	copier.ContentType = attrs.ContentType
+	copier.Metadata = attrs.Metadata
+	copier.StorageClass = class
+	_, err = copier.Run(ctx)
+	if err != nil {
+		return xerrors.Errorf("cannot rewrite %s in storage class %s: %w", object, class, err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+)
+
+const (
+	// defaultSlowOperationThreshold is the duration after which up- and downloads are logged as slow
+	defaultSlowOperationThreshold = 1 * time.Minute
+
+	operationDownload         = "download"
+	operationDownloadSnapshot = "download_snapshot"
+	operationUpload           = "upload"
+	operationUploadInstance   = "upload_instance"
+)
+
+// Metrics instruments the up- and downloads of remote storage. Metrics are labeled by operation only,
+// owners and buckets - which contain the owner on some storage systems - are only logged.
+type Metrics struct {
+	slowOperationThreshold time.Duration
+
+	duration   *prometheus.HistogramVec
+	bytes      *prometheus.CounterVec
+	throughput *prometheus.HistogramVec
+	errors     *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics of remote storage operations. The metrics need to be registered before they are reported.
+func NewMetrics(cfg config.StorageConfig) *Metrics {
+	threshold := time.Duration(cfg.SlowOperationThreshold)
+	if threshold <= 0 {
+		threshold = defaultSlowOperationThreshold
+	}
+
+	labels := []string{"operation"}
+	return &Metrics{
+		slowOperationThreshold: threshold,
+
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "gitpod",
+			Subsystem: "content_storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Time it took to up- or download content from the remote storage",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+		}, labels),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gitpod",
+			Subsystem: "content_storage",
+			Name:      "operation_bytes_total",
+			Help:      "Number of bytes up- or downloaded from the remote storage",
+		}, labels),
+		throughput: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "gitpod",
+			Subsystem: "content_storage",
+			Name:      "operation_throughput_bytes_per_second",
+			Help:      "Throughput of up- and downloads from the remote storage",
+			Buckets:   prometheus.ExponentialBuckets(256*1024, 2, 12),
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gitpod",
+			Subsystem: "content_storage",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed up- and downloads from the remote storage",
+		}, labels),
+	}
+}
+
+// Describe implements Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.bytes.Describe(ch)
+	m.throughput.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+// Collect implements Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.bytes.Collect(ch)
+	m.throughput.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+// Instrument records the metrics of all up- and downloads of the remote storage. Returns da as is if there are no metrics.
+func (m *Metrics) Instrument(da DirectAccess) DirectAccess {
+	if m == nil {
+		return da
+	}
+	return &instrumentedStorage{DirectAccess: da, metrics: m}
+}
+
+// ObserveDownload records a download which did not go through an instrumented storage, e.g. because the
+// content initializer downloaded it from a presigned URL
+func (m *Metrics) ObserveDownload(owner, workspace, instance, name string, bytes int64, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.observe(operation{
+		Name:      operationDownload,
+		Owner:     owner,
+		Workspace: workspace,
+		Instance:  instance,
+		Object:    name,
+		Bytes:     bytes,
+		Duration:  duration,
+		Err:       err,
+	})
+}
+
+// operation is a completed up- or download
+type operation struct {
+	Name      string
+	Owner     string
+	Workspace string
+	Instance  string
+	Bucket    string
+	Object    string
+	Bytes     int64
+	Duration  time.Duration
+	Err       error
+}
+
+func (m *Metrics) observe(op operation) {
+	labels := prometheus.Labels{"operation": op.Name}
+
+	if op.Err != nil {
+		m.errors.With(labels).Inc()
+		return
+	}
+
+	m.duration.With(labels).Observe(op.Duration.Seconds())
+	m.bytes.With(labels).Add(float64(op.Bytes))
+	var throughput float64
+	if op.Duration > 0 {
+		throughput = float64(op.Bytes) / op.Duration.Seconds()
+		m.throughput.With(labels).Observe(throughput)
+	}
+
+	if op.Duration >= m.slowOperationThreshold {
+		log.WithFields(log.OWI(op.Owner, op.Workspace, op.Instance)).
+			WithField("operation", op.Name).
+			WithField("bucket", op.Bucket).
+			WithField("object", op.Object).
+			WithField("bytes", op.Bytes).
+			WithField("duration", op.Duration.String()).
+			WithField("bytesPerSecond", int64(throughput)).
+			Warn("slow remote storage operation")
+	}
+}
+
+var _ DirectAccess = &instrumentedStorage{}
+
+type instrumentedStorage struct {
+	DirectAccess
+
+	metrics                    *Metrics
+	owner, workspace, instance string
+}
+
+// Init initializes the remote storage and remembers the owner for logging its operations
+func (rs *instrumentedStorage) Init(ctx context.Context, owner, workspace, instance string) error {
+	rs.owner, rs.workspace, rs.instance = owner, workspace, instance
+	return rs.DirectAccess.Init(ctx, owner, workspace, instance)
+}
+
+// Download implements DirectDownloader
+func (rs *instrumentedStorage) Download(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (found bool, err error) {
+	return rs.download(ctx, operationDownload, name, func(ctx context.Context) (bool, error) {
+		return rs.DirectAccess.Download(ctx, destination, name, mappings)
+	})
+}
+
+// DownloadSnapshot implements DirectDownloader
+func (rs *instrumentedStorage) DownloadSnapshot(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (found bool, err error) {
+	return rs.download(ctx, operationDownloadSnapshot, name, func(ctx context.Context) (bool, error) {
+		return rs.DirectAccess.DownloadSnapshot(ctx, destination, name, mappings)
+	})
+}
+
+func (rs *instrumentedStorage) download(ctx context.Context, op, name string, download func(ctx context.Context) (bool, error)) (found bool, err error) {
+	ctx, read := withReadCounter(ctx)
+	start := time.Now()
+	found, err = download(ctx)
+	if !found && err == nil {
+		return found, err
+	}
+
+	rs.metrics.observe(operation{
+		Name:      op,
+		Owner:     rs.owner,
+		Workspace: rs.workspace,
+		Instance:  rs.instance,
+		Bucket:    rs.Bucket(rs.owner),
+		Object:    name,
+		Bytes:     read.Load(),
+		Duration:  time.Since(start),
+		Err:       err,
+	})
+	return found, err
+}
+
+// Upload implements DirectAccess
+func (rs *instrumentedStorage) Upload(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, obj string, err error) {
+	return rs.upload(operationUpload, source, name, func() (string, string, error) {
+		return rs.DirectAccess.Upload(ctx, source, name, opts...)
+	})
+}
+
+// UploadInstance implements DirectAccess
+func (rs *instrumentedStorage) UploadInstance(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, obj string, err error) {
+	return rs.upload(operationUploadInstance, source, name, func() (string, string, error) {
+		return rs.DirectAccess.UploadInstance(ctx, source, name, opts...)
+	})
+}
+
+func (rs *instrumentedStorage) upload(op, source, name string, upload func() (string, string, error)) (bucket, obj string, err error) {
+	var size int64
+	if stat, serr := os.Stat(source); serr == nil {
+		size = stat.Size()
+	}
+
+	start := time.Now()
+	bucket, obj, err = upload()
+
+	rs.metrics.observe(operation{
+		Name:      op,
+		Owner:     rs.owner,
+		Workspace: rs.workspace,
+		Instance:  rs.instance,
+		Bucket:    rs.Bucket(rs.owner),
+		Object:    name,
+		Bytes:     size,
+		Duration:  time.Since(start),
+		Err:       err,
+	})
+	return bucket, obj, err
+}
+
+type readCounterKey struct{}
+
+// withReadCounter returns a context which makes extractTarbal count the bytes it reads
+func withReadCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	var cnt atomic.Int64
+	return context.WithValue(ctx, readCounterKey{}, &cnt), &cnt
+}
+
+// countReads counts the bytes read from r if the context carries a read counter
+func countReads(ctx context.Context, r io.Reader) io.Reader {
+	cnt, ok := ctx.Value(readCounterKey{}).(*atomic.Int64)
+	if !ok {
+		return r
+	}
+	return &countingReader{r: r, cnt: cnt}
+}
+
+// countingReadCloser closes the reader whose reads are counted
+type countingReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type countingReader struct {
+	r   io.Reader
+	cnt *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.cnt.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+)
+
+type fakeTransferStorage struct {
+	DirectNoopStorage
+	Content   string
+	UploadErr error
+}
+
+func (rs *fakeTransferStorage) Bucket(owner string) string {
+	return "gitpod-user-" + owner
+}
+
+func (rs *fakeTransferStorage) Download(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	if rs.Content == "" {
+		return false, nil
+	}
+	_, err := io.Copy(io.Discard, countReads(ctx, strings.NewReader(rs.Content)))
+	return true, err
+}
+
+func (rs *fakeTransferStorage) Upload(ctx context.Context, source string, name string, opts ...UploadOption) (string, string, error) {
+	return rs.Bucket(""), name, rs.UploadErr
+}
+
+func TestInstrumentedStorage(t *testing.T) {
+	const owner = "fa9aa2af-b6de-45fc-8b48-534bb440429f"
+	labels := func(op string) prometheus.Labels {
+		return prometheus.Labels{"operation": op}
+	}
+
+	source := filepath.Join(t.TempDir(), "backup.tar")
+	err := os.WriteFile(source, []byte("hello world"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeTransferStorage{Content: "some content"}
+	m := NewMetrics(config.StorageConfig{})
+	rs := m.Instrument(fake)
+	err = rs.Init(context.Background(), owner, "workspace", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = rs.Download(context.Background(), t.TempDir(), DefaultBackup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := testutil.ToFloat64(m.bytes.With(labels(operationDownload))); act != float64(len(fake.Content)) {
+		t.Errorf("unexpected downloaded bytes: is %v but expected %v", act, len(fake.Content))
+	}
+
+	_, _, err = rs.Upload(context.Background(), source, DefaultBackup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := testutil.ToFloat64(m.bytes.With(labels(operationUpload))); act != float64(len("hello world")) {
+		t.Errorf("unexpected uploaded bytes: is %v but expected %v", act, len("hello world"))
+	}
+
+	fake.UploadErr = errors.New("failed")
+	_, _, err = rs.Upload(context.Background(), source, DefaultBackup)
+	if err == nil {
+		t.Fatal("expected upload to fail")
+	}
+	if act := testutil.ToFloat64(m.errors.With(labels(operationUpload))); act != 1 {
+		t.Errorf("unexpected upload errors: is %v but expected 1", act)
+	}
+
+	// content which does not exist is neither an error nor a transfer
+	fake.Content = ""
+	_, err = rs.Download(context.Background(), t.TempDir(), DefaultBackup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := testutil.CollectAndCount(m.duration); act != 2 {
+		t.Errorf("unexpected number of observed operations: is %v but expected 2", act)
+	}
+}
+
+func TestObserveDownload(t *testing.T) {
+	m := NewMetrics(config.StorageConfig{})
+	m.ObserveDownload("owner", "workspace", "instance", DefaultBackup, 42, time.Second, nil)
+	m.ObserveDownload("owner", "workspace", "instance", DefaultBackup, 0, time.Second, errors.New("failed"))
+
+	labels := prometheus.Labels{"operation": operationDownload}
+	if act := testutil.ToFloat64(m.bytes.With(labels)); act != 42 {
+		t.Errorf("unexpected downloaded bytes: is %v but expected 42", act)
+	}
+	if act := testutil.ToFloat64(m.errors.With(labels)); act != 1 {
+		t.Errorf("unexpected download errors: is %v but expected 1", act)
+	}
+
+	var nilMetrics *Metrics
+	nilMetrics.ObserveDownload("owner", "workspace", "instance", DefaultBackup, 42, time.Second, nil)
+}
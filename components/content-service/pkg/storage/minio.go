@@ -27,6 +27,7 @@ import (
 )
 
 var _ DirectAccess = &DirectMinIOStorage{}
+var _ LifecycleAccess = &presignedMinIOStorage{}
 
 // Validate checks if the GCloud storage MinIOconfig is valid
 func ValidateMinIOConfig(c *config.MinIOConfig) error {
@@ -87,7 +88,7 @@ func NewMinIOClient(c *config.MinIOConfig) (*minio.Client, error) {
 }
 
 // newDirectMinIOAccess provides direct access to the remote storage system
-func newDirectMinIOAccess(cfg config.MinIOConfig) (*DirectMinIOStorage, error) {
+func newDirectMinIOAccess(cfg config.MinIOConfig, transfer config.TransferConfig) (*DirectMinIOStorage, error) {
 	err := addMinioParamsFromMounts(&cfg)
 	if err != nil {
 		return nil, err
@@ -96,7 +97,7 @@ func newDirectMinIOAccess(cfg config.MinIOConfig) (*DirectMinIOStorage, error) {
 	if err = ValidateMinIOConfig(&cfg); err != nil {
 		return nil, err
 	}
-	return &DirectMinIOStorage{MinIOConfig: cfg}, nil
+	return &DirectMinIOStorage{MinIOConfig: cfg, Transfer: transfer}, nil
 }
 
 // DirectMinIOStorage implements MinIO as remote storage backend
@@ -105,6 +106,7 @@ type DirectMinIOStorage struct {
 	WorkspaceName string
 	InstanceID    string
 	MinIOConfig   config.MinIOConfig
+	Transfer      config.TransferConfig
 
 	client *minio.Client
 
@@ -158,12 +160,30 @@ func (rs *DirectMinIOStorage) defaultObjectAccess(ctx context.Context, bkt, obj
 	if err != nil {
 		return nil, translateMinioError(err)
 	}
-	_, err = object.Stat()
+	info, err := object.Stat()
 	if err != nil {
 		return nil, translateMinioError(err)
 	}
 
-	return object, nil
+	transfer := getTransferOptions(rs.Transfer)
+	if !transfer.multipartDownload(info.Size) {
+		return object, nil
+	}
+	object.Close()
+
+	// large objects are downloaded in parts which are pinned to the version we just looked at
+	return downloadToTempFile(ctx, info.Size, transfer, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		var opts minio.GetObjectOptions
+		err := opts.SetMatchETag(info.ETag)
+		if err != nil {
+			return nil, err
+		}
+		err = opts.SetRange(offset, offset+length-1)
+		if err != nil {
+			return nil, err
+		}
+		return rs.client.GetObject(ctx, bkt, obj, opts)
+	})
 }
 
 // EnsureExists makes sure that the remote storage location exists and can be up- or downloaded from
@@ -211,7 +231,22 @@ func (rs *DirectMinIOStorage) download(ctx context.Context, destination string,
 	}
 	defer rc.Close()
 
-	err = extractTarbal(ctx, destination, rc, mappings)
+	stat, err := rs.client.StatObject(ctx, bkt, obj, minio.StatObjectOptions{})
+	if err != nil {
+		return true, xerrors.Errorf("cannot get metadata of %s: %w", obj, translateMinioError(err))
+	}
+	vr, verify, err := verifyingReader(rc, stat.Metadata.Get(annotationToAmzMetaHeader(ObjectAnnotationDigest)))
+	if err != nil {
+		return true, err
+	}
+
+	err = extractTarbal(ctx, destination, vr, mappings, func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+		return rs.ObjectAccess(ctx, bkt, rs.BackupObject(DedupChunkObject(chunk.Digest)))
+	})
+	// corrupted content can fail extraction, in which case the digest mismatch is the more useful error
+	if verr := verify(); verr != nil {
+		return true, xerrors.Errorf("cannot verify %s: %w", obj, verr)
+	}
 	if err != nil {
 		return true, err
 	}
@@ -300,11 +335,35 @@ func (rs *DirectMinIOStorage) Upload(ctx context.Context, source string, name st
 	span.LogKV("endpoint", rs.MinIOConfig.Endpoint)
 	span.LogKV("region", rs.MinIOConfig.Region)
 	span.LogKV("key", rs.MinIOConfig.AccessKeyID)
-	_, err = rs.client.FPutObject(ctx, bucket, obj, source, minio.PutObjectOptions{
+	putOpts := minio.PutObjectOptions{
 		NumThreads:   rs.MinIOConfig.ParallelUpload,
 		UserMetadata: options.Annotations,
 		ContentType:  options.ContentType,
-	})
+	}
+	if rs.Transfer.Concurrency > 0 {
+		putOpts.NumThreads = uint(rs.Transfer.Concurrency)
+	}
+	if rs.Transfer.PartSizeMiB > 0 {
+		putOpts.PartSize = uint64(getTransferOptions(rs.Transfer).PartSize)
+	}
+	if options.streamed() {
+		var f *os.File
+		f, err = os.Open(source)
+		if err != nil {
+			err = xerrors.Errorf("cannot open file for uploading: %w", err)
+			return
+		}
+		defer f.Close()
+
+		var stat os.FileInfo
+		stat, err = f.Stat()
+		if err != nil {
+			return
+		}
+		_, err = rs.client.PutObject(ctx, bucket, obj, options.uploadReader(f), stat.Size(), putOpts)
+	} else {
+		_, err = rs.client.FPutObject(ctx, bucket, obj, source, putOpts)
+	}
 	if err != nil {
 		return
 	}
@@ -346,17 +405,18 @@ func (rs *DirectMinIOStorage) objectName(name string) string {
 	return minioWorkspaceBackupObjectName(username, rs.WorkspaceName, name)
 }
 
-func newPresignedMinIOAccess(cfg config.MinIOConfig) (*presignedMinIOStorage, error) {
+func newPresignedMinIOAccess(cfg config.MinIOConfig, signedURLs config.SignedURLConfig) (*presignedMinIOStorage, error) {
 	cl, err := NewMinIOClient(&cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &presignedMinIOStorage{client: cl, MinIOConfig: cfg}, nil
+	return &presignedMinIOStorage{client: cl, MinIOConfig: cfg, signedURLs: getSignedURLTTLs(signedURLs)}, nil
 }
 
 type presignedMinIOStorage struct {
 	client      *minio.Client
 	MinIOConfig config.MinIOConfig
+	signedURLs  signedURLTTLs
 }
 
 // EnsureExists makes sure that the remote storage location exists and can be up- or downloaded from
@@ -407,7 +467,8 @@ func (s *presignedMinIOStorage) SignDownload(ctx context.Context, bucket, object
 	if err != nil {
 		return nil, translateMinioError(err)
 	}
-	url, err := s.client.PresignedGetObject(ctx, bucket, object, 30*time.Minute, nil)
+	ttl := s.signedURLs.download(options)
+	url, err := s.client.PresignedGetObject(ctx, bucket, object, ttl, nil)
 	if err != nil {
 		return nil, translateMinioError(err)
 	}
@@ -419,8 +480,9 @@ func (s *presignedMinIOStorage) SignDownload(ctx context.Context, bucket, object
 			Digest:             stat.Metadata.Get(annotationToAmzMetaHeader(ObjectAnnotationDigest)),
 			UncompressedDigest: stat.Metadata.Get(annotationToAmzMetaHeader(ObjectAnnotationUncompressedDigest)),
 		},
-		Size: stat.Size,
-		URL:  url.String(),
+		Size:    stat.Size,
+		URL:     url.String(),
+		Expires: time.Now().Add(ttl),
 	}, nil
 }
 
@@ -438,11 +500,12 @@ func (s *presignedMinIOStorage) SignUpload(ctx context.Context, bucket, obj stri
 		tracing.FinishSpan(span, &err)
 	}()
 
-	url, err := s.client.PresignedPutObject(ctx, bucket, obj, 30*time.Minute)
+	ttl := s.signedURLs.upload(options)
+	url, err := s.client.PresignedPutObject(ctx, bucket, obj, ttl)
 	if err != nil {
 		return nil, translateMinioError(err)
 	}
-	return &UploadInfo{URL: url.String()}, nil
+	return &UploadInfo{URL: url.String(), Expires: time.Now().Add(ttl)}, nil
 }
 
 func (s *presignedMinIOStorage) DeleteObject(ctx context.Context, bucket string, query *DeleteObjectQuery) (err error) {
@@ -521,6 +584,40 @@ func (s *presignedMinIOStorage) ObjectExists(ctx context.Context, bucket, obj st
 	return true, nil
 }
 
+// ListObjectInfos describes the objects whose name starts with prefix, including their annotations
+func (s *presignedMinIOStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) (res []ObjectInfo, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "minio.ListObjectInfos")
+	defer tracing.FinishSpan(span, &err)
+
+	for obj := range s.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			if translateMinioError(obj.Err) == ErrNotFound {
+				return nil, nil
+			}
+			return nil, xerrors.Errorf("cannot list objects of %s: %w", bucket, obj.Err)
+		}
+
+		// listings carry no user metadata unless the server is MinIO, hence we stat every object
+		stat, err := s.client.StatObject(ctx, bucket, obj.Key, minio.StatObjectOptions{})
+		if translateMinioError(err) == ErrNotFound {
+			// deleted since we listed it
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("cannot stat %s: %w", obj.Key, err)
+		}
+		res = append(res, ObjectInfo{
+			Bucket:       bucket,
+			Name:         obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+			StorageClass: obj.StorageClass,
+			Annotations:  stat.UserMetadata,
+		})
+	}
+	return res, nil
+}
+
 func annotationToAmzMetaHeader(annotation string) string {
 	return http.CanonicalHeaderKey(fmt.Sprintf("X-Amz-Meta-%s", annotation))
 }
@@ -549,6 +646,93 @@ func (s *presignedMinIOStorage) InstanceObject(ownerID string, workspaceID strin
 	return s.BackupObject(ownerID, workspaceID, InstanceObjectName(instanceID, name))
 }
 
+// WalkObjects calls fn for every object in the buckets of all users
+func (s *presignedMinIOStorage) WalkObjects(ctx context.Context, fn func(obj ObjectInfo) error) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "minio.WalkObjects")
+	defer tracing.FinishSpan(span, &err)
+
+	var buckets []string
+	if s.MinIOConfig.BucketName != "" {
+		buckets = []string{s.MinIOConfig.BucketName}
+	} else {
+		bkts, err := s.client.ListBuckets(ctx)
+		if err != nil {
+			return xerrors.Errorf("cannot list buckets: %w", err)
+		}
+		prefix := minioBucketName("", "")
+		for _, bkt := range bkts {
+			if strings.HasPrefix(bkt.Name, prefix) {
+				buckets = append(buckets, bkt.Name)
+			}
+		}
+	}
+
+	for _, bkt := range buckets {
+		ctx, cancel := context.WithCancel(ctx)
+		err := func() error {
+			// cancelling stops the listing in case fn fails
+			defer cancel()
+			for obj := range s.client.ListObjects(ctx, bkt, minio.ListObjectsOptions{Recursive: true}) {
+				if obj.Err != nil {
+					return xerrors.Errorf("cannot list objects of %s: %w", bkt, obj.Err)
+				}
+				err := fn(ObjectInfo{
+					Bucket:       bkt,
+					Name:         obj.Key,
+					Size:         obj.Size,
+					LastModified: obj.LastModified,
+					StorageClass: obj.StorageClass,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetStorageClass copies an object onto itself in another storage class
+func (s *presignedMinIOStorage) SetStorageClass(ctx context.Context, bucket, obj, class string) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "minio.SetStorageClass")
+	defer tracing.FinishSpan(span, &err)
+
+	stat, err := s.client.StatObject(ctx, bucket, obj, minio.StatObjectOptions{})
+	if err != nil {
+		return translateMinioError(err)
+	}
+
+	// changing the storage class replaces the metadata of the object, hence we carry it over
+	metadata := make(map[string]string, len(stat.UserMetadata)+2)
+	for k, v := range stat.UserMetadata {
+		metadata[k] = v
+	}
+	metadata["Content-Type"] = stat.ContentType
+	metadata["X-Amz-Storage-Class"] = class
+
+	_, err = s.client.CopyObject(ctx, minio.CopyDestOptions{
+		Bucket:          bucket,
+		Object:          obj,
+		ReplaceMetadata: true,
+		UserMetadata:    metadata,
+	}, minio.CopySrcOptions{
+		Bucket: bucket,
+		Object: obj,
+		// don't overwrite a backup which was uploaded in the meantime
+		MatchETag: stat.ETag,
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot copy %s to storage class %s: %w", obj, class, translateMinioError(err))
+	}
+	return nil
+}
+
 func translateMinioError(err error) error {
 	if err == nil {
 		return nil
@@ -41,7 +41,7 @@ func TestMinioBucketName(t *testing.T) {
 				Region:          "none",
 				BucketName:      test.BucketNameConfig,
 			}
-			minio, err := newDirectMinIOAccess(cfg)
+			minio, err := newDirectMinIOAccess(cfg, config.TransferConfig{})
 			if err != nil {
 				t.Fatalf("failed to create minio access: '%v'", err)
 			}
@@ -51,7 +51,7 @@ func TestMinioBucketName(t *testing.T) {
 				t.Fatalf("[minio] unexpected bucket name: is '%s' but expected '%s'", actualBucketName, test.ExpectedBucket)
 			}
 
-			presignedMinio, err := newPresignedMinIOAccess(cfg)
+			presignedMinio, err := newPresignedMinIOAccess(cfg, config.SignedURLConfig{})
 			if err != nil {
 				t.Fatalf("failed to create presigned minio access: '%v'", err)
 			}
@@ -103,7 +103,7 @@ func TestMinioBackupObject(t *testing.T) {
 				Region:          "none",
 				BucketName:      test.BucketNameConfig,
 			}
-			minio, err := newDirectMinIOAccess(cfg)
+			minio, err := newDirectMinIOAccess(cfg, config.TransferConfig{})
 			if err != nil {
 				t.Fatalf("failed to create minio access: '%v'", err)
 			}
@@ -117,7 +117,7 @@ func TestMinioBackupObject(t *testing.T) {
 				t.Fatalf("[minio] unexpected backup object name: is '%s' but expected '%s'", actualBackupObject, test.ExpectedBackupObject)
 			}
 
-			presignedMinio, err := newPresignedMinIOAccess(cfg)
+			presignedMinio, err := newPresignedMinIOAccess(cfg, config.SignedURLConfig{})
 			if err != nil {
 				t.Fatalf("failed to create presigned minio access: '%v'", err)
 			}
@@ -6,6 +6,7 @@ package storage
 
 import (
 	"context"
+	"io"
 	"net/http"
 
 	"golang.org/x/xerrors"
@@ -42,7 +43,7 @@ func (d *NamedURLDownloader) Download(ctx context.Context, destination string, n
 	}
 	defer resp.Body.Close()
 
-	err = extractTarbal(ctx, destination, resp.Body, mappings)
+	err = extractTarbal(ctx, destination, resp.Body, mappings, d.openChunk)
 	if err != nil {
 		return true, err
 	}
@@ -50,6 +51,29 @@ func (d *NamedURLDownloader) Download(ctx context.Context, destination string, n
 	return true, nil
 }
 
+// openChunk downloads a chunk of a deduplicated backup from its URL, which is named by DedupChunkObject
+func (d *NamedURLDownloader) openChunk(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+	obj := DedupChunkObject(chunk.Digest)
+	url, found := d.URLs[obj]
+	if !found {
+		return nil, xerrors.Errorf("no download URL for chunk %s", chunk.Digest)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, xerrors.Errorf("cannot download chunk %s: non-OK status code: %v", chunk.Digest, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
 // DownloadSnapshot downloads a snapshot.
 func (d *NamedURLDownloader) DownloadSnapshot(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (found bool, err error) {
 	return d.Download(ctx, destination, name, mappings)
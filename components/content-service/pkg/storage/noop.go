@@ -116,6 +116,11 @@ func (*PresignedNoopStorage) ObjectHash(ctx context.Context, bucket string, obj
 	return "", nil
 }
 
+// ListObjectInfos returns no objects
+func (p *PresignedNoopStorage) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]ObjectInfo, error) {
+	return nil, nil
+}
+
 func (p *PresignedNoopStorage) ObjectExists(ctx context.Context, bucket, obj string) (bool, error) {
 	return false, nil
 }
@@ -129,3 +134,13 @@ func (*PresignedNoopStorage) BackupObject(ownerID string, workspaceID string, na
 func (*PresignedNoopStorage) InstanceObject(ownerID string, workspaceID string, instanceID string, name string) string {
 	return ""
 }
+
+// WalkObjects does nothing, as there are no objects
+func (*PresignedNoopStorage) WalkObjects(ctx context.Context, fn func(obj ObjectInfo) error) error {
+	return nil
+}
+
+// SetStorageClass does nothing
+func (*PresignedNoopStorage) SetStorageClass(ctx context.Context, bucket, obj, class string) error {
+	return nil
+}
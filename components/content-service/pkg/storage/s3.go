@@ -8,11 +8,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
 	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
 	"golang.org/x/xerrors"
 
@@ -30,17 +33,21 @@ const (
 )
 
 var _ DirectAccess = &s3Storage{}
-var _ PresignedAccess = &PresignedS3Storage{}
+var _ LifecycleAccess = &PresignedS3Storage{}
 
 type S3Config struct {
-	Bucket string
+	Bucket     string
+	Transfer   config.TransferConfig
+	SignedURLs config.SignedURLConfig
 }
 
 type S3Client interface {
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
 	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
 	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
 	GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error)
 	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
 }
 
 type PresignedS3Client interface {
@@ -164,6 +171,56 @@ func (rs *PresignedS3Storage) DiskUsage(ctx context.Context, bucket string, pref
 	return
 }
 
+// WalkObjects implements LifecycleAccess
+func (rs *PresignedS3Storage) WalkObjects(ctx context.Context, fn func(obj ObjectInfo) error) error {
+	listParams := &s3.ListObjectsV2Input{
+		Bucket: aws.String(rs.Config.Bucket),
+	}
+	for {
+		objs, err := rs.client.ListObjectsV2(ctx, listParams)
+		if err != nil {
+			return xerrors.Errorf("cannot list objects: %w", err)
+		}
+
+		for _, o := range objs.Contents {
+			err = fn(ObjectInfo{
+				Bucket:       rs.Config.Bucket,
+				Name:         aws.ToString(o.Key),
+				Size:         aws.ToInt64(o.Size),
+				LastModified: aws.ToTime(o.LastModified),
+				StorageClass: string(o.StorageClass),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !aws.ToBool(objs.IsTruncated) {
+			return nil
+		}
+		listParams.ContinuationToken = objs.NextContinuationToken
+	}
+}
+
+// SetStorageClass implements LifecycleAccess
+func (rs *PresignedS3Storage) SetStorageClass(ctx context.Context, bucket, obj, class string) error {
+	_, err := rs.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(rs.Config.Bucket),
+		Key:               aws.String(obj),
+		CopySource:        aws.String(fmt.Sprintf("%s/%s", rs.Config.Bucket, obj)),
+		MetadataDirective: types.MetadataDirectiveCopy,
+		StorageClass:      types.StorageClass(class),
+	})
+	var nsk *types.NoSuchKey
+	if errors.As(err, &nsk) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot copy %s to storage class %s: %w", obj, class, err)
+	}
+	return nil
+}
+
 // EnsureExists implements PresignedAccess
 func (rs *PresignedS3Storage) EnsureExists(ctx context.Context, bucket string) error {
 	return nil
@@ -174,6 +231,51 @@ func (rs *PresignedS3Storage) InstanceObject(ownerID string, workspaceID string,
 	return rs.BackupObject(ownerID, workspaceID, InstanceObjectName(instanceID, name))
 }
 
+// ListObjectInfos implements PresignedAccess
+func (rs *PresignedS3Storage) ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]ObjectInfo, error) {
+	var res []ObjectInfo
+	listParams := &s3.ListObjectsV2Input{
+		Bucket: aws.String(rs.Config.Bucket),
+		Prefix: aws.String(prefix),
+	}
+	for {
+		objs, err := rs.client.ListObjectsV2(ctx, listParams)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot list objects: %w", err)
+		}
+
+		for _, o := range objs.Contents {
+			// listings carry no user metadata, hence we have to ask for every object
+			head, err := rs.client.HeadObject(ctx, &s3.HeadObjectInput{
+				Bucket: aws.String(rs.Config.Bucket),
+				Key:    o.Key,
+			})
+			var nf *types.NotFound
+			if errors.As(err, &nf) {
+				// deleted since we listed it
+				continue
+			}
+			if err != nil {
+				return nil, xerrors.Errorf("cannot get metadata of %s: %w", aws.ToString(o.Key), err)
+			}
+
+			res = append(res, ObjectInfo{
+				Bucket:       rs.Config.Bucket,
+				Name:         aws.ToString(o.Key),
+				Size:         aws.ToInt64(o.Size),
+				LastModified: aws.ToTime(o.LastModified),
+				StorageClass: string(o.StorageClass),
+				Annotations:  head.Metadata,
+			})
+		}
+
+		if !aws.ToBool(objs.IsTruncated) {
+			return res, nil
+		}
+		listParams.ContinuationToken = objs.NextContinuationToken
+	}
+}
+
 // ObjectExists implements PresignedAccess
 func (rs *PresignedS3Storage) ObjectExists(ctx context.Context, bucket string, path string) (bool, error) {
 	_, err := rs.client.GetObjectAttributes(ctx, &s3.GetObjectAttributesInput{
@@ -214,14 +316,14 @@ func (rs *PresignedS3Storage) ObjectHash(ctx context.Context, bucket string, obj
 
 // SignDownload implements PresignedAccess
 func (rs *PresignedS3Storage) SignDownload(ctx context.Context, bucket string, obj string, options *SignedURLOptions) (info *DownloadInfo, err error) {
-	resp, err := rs.client.GetObjectAttributes(ctx, &s3.GetObjectAttributesInput{
-		Bucket:           &rs.Config.Bucket,
-		Key:              aws.String(obj),
-		ObjectAttributes: []types.ObjectAttributes{types.ObjectAttributesObjectSize},
+	// unlike the object attributes, the head of an object carries its metadata
+	head, err := rs.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &rs.Config.Bucket,
+		Key:    aws.String(obj),
 	})
 
-	var nsk *types.NoSuchKey
-	if errors.As(err, &nsk) {
+	var nf *types.NotFound
+	if errors.As(err, &nf) {
 		return nil, ErrNotFound
 	}
 
@@ -229,35 +331,42 @@ func (rs *PresignedS3Storage) SignDownload(ctx context.Context, bucket string, o
 		return nil, err
 	}
 
+	ttl := getSignedURLTTLs(rs.Config.SignedURLs).download(options)
 	req, err := rs.PresignedFactory().PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(rs.Config.Bucket),
 		Key:    aws.String(obj),
-	})
+	}, s3.WithPresignExpires(ttl))
 	if err != nil {
 		return nil, err
 	}
 
 	return &DownloadInfo{
 		Meta: ObjectMeta{
-			// TODO(cw): implement this if we need to support FWB with S3
+			ContentType:        aws.ToString(head.ContentType),
+			OCIMediaType:       annotation(head.Metadata, ObjectAnnotationOCIContentType),
+			Digest:             annotation(head.Metadata, ObjectAnnotationDigest),
+			UncompressedDigest: annotation(head.Metadata, ObjectAnnotationUncompressedDigest),
 		},
-		Size: *resp.ObjectSize,
-		URL:  req.URL,
+		Size:    aws.ToInt64(head.ContentLength),
+		URL:     req.URL,
+		Expires: time.Now().Add(ttl),
 	}, nil
 }
 
 // SignUpload implements PresignedAccess
 func (rs *PresignedS3Storage) SignUpload(ctx context.Context, bucket string, obj string, options *SignedURLOptions) (info *UploadInfo, err error) {
+	ttl := getSignedURLTTLs(rs.Config.SignedURLs).upload(options)
 	resp, err := rs.PresignedFactory().PresignPutObject(ctx, &s3.PutObjectInput{
 		Bucket: &rs.Config.Bucket,
 		Key:    aws.String(obj),
-	})
+	}, s3.WithPresignExpires(ttl))
 	if err != nil {
 		return nil, err
 	}
 
 	return &UploadInfo{
-		URL: resp.URL,
+		URL:     resp.URL,
+		Expires: time.Now().Add(ttl),
 	}, nil
 }
 
@@ -297,9 +406,11 @@ func (s3st *s3Storage) DownloadSnapshot(ctx context.Context, destination string,
 }
 
 func (s3st *s3Storage) download(ctx context.Context, destination string, obj string, mappings []archive.IDMapping) (found bool, err error) {
+	transfer := getTransferOptions(s3st.Config.Transfer)
 	downloader := s3manager.NewDownloader(s3st.client, func(d *s3manager.Downloader) {
-		d.Concurrency = defaultCopyConcurrency
-		d.PartSize = defaultPartSize * megabytes
+		d.Concurrency = transfer.Concurrency
+		d.PartSize = transfer.PartSize
+		d.PartBodyMaxRetries = transfer.Retries
 		d.BufferProvider = s3manager.NewPooledBufferedWriterReadFromProvider(25 * megabytes)
 	})
 
@@ -317,14 +428,35 @@ func (s3st *s3Storage) download(ctx context.Context, destination string, obj str
 		return false, err
 	}
 
+	head, err := s3st.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s3st.Config.Bucket),
+		Key:    aws.String(obj),
+	})
+	if err != nil {
+		return true, xerrors.Errorf("cannot get metadata of %s: %w", obj, err)
+	}
+	err = VerifyFileDigest(s3File.Name(), annotation(head.Metadata, ObjectAnnotationDigest))
+	if err != nil {
+		return true, xerrors.Errorf("cannot verify %s: %w", obj, err)
+	}
+
 	_, err = s3File.Seek(0, 0)
 	if err != nil {
 		return false, err
 	}
 
-	err = archive.ExtractTarbal(ctx, s3File, destination, archive.WithUIDMapping(mappings), archive.WithGIDMapping(mappings))
+	err = extractTarbal(ctx, destination, s3File, mappings, func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+		resp, err := s3st.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s3st.Config.Bucket),
+			Key:    aws.String(s3st.BackupObject(DedupChunkObject(chunk.Digest))),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	})
 	if err != nil {
-		return true, xerrors.Errorf("tar %s: %s", destination, err.Error())
+		return true, err
 	}
 
 	return true, nil
@@ -418,18 +550,24 @@ func (s3st *s3Storage) Upload(ctx context.Context, source string, name string, o
 		err = xerrors.Errorf("Can only upload with actual S3 client")
 	}
 
+	// f implements io.ReadSeeker and hence is uploaded in parallel, unless we throttle the upload.
+	// cf. https://aws.github.io/aws-sdk-go-v2/docs/sdk-utilities/s3/#putobjectinput-body-field-ioreadseeker-vs-ioreader
+	body := options.uploadReader(f)
+
+	transfer := getTransferOptions(s3st.Config.Transfer)
 	uploader := s3manager.NewUploader(s3c, func(u *s3manager.Uploader) {
-		u.Concurrency = defaultCopyConcurrency
-		u.PartSize = defaultPartSize * megabytes
+		u.Concurrency = transfer.Concurrency
+		u.PartSize = transfer.PartSize
 		u.BufferProvider = s3manager.NewBufferedReadSeekerWriteToPool(25 * megabytes)
+		// failed parts are retried on their own, rather than restarting the whole upload
+		u.ClientOptions = append(u.ClientOptions, func(o *s3.Options) {
+			o.RetryMaxAttempts = transfer.Retries + 1
+		})
 	})
 	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(obj),
-
-		// f implements io.ReadSeeker and hence is uploaded in parallel.
-		// cf. https://aws.github.io/aws-sdk-go-v2/docs/sdk-utilities/s3/#putobjectinput-body-field-ioreadseeker-vs-ioreader
-		Body: f,
+		Body:   body,
 
 		Metadata:    options.Annotations,
 		ContentType: contentType,
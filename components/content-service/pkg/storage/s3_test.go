@@ -38,6 +38,9 @@ func TestS3PresignedHappyPath(t *testing.T) {
 		ETag:       aws.String("foobar"),
 		ObjectSize: aws.Int64(100),
 	}, nil).AnyTimes()
+	s3c.EXPECT().HeadObject(gomock.Any(), gomock.Any()).Return(&s3.HeadObjectOutput{
+		ContentLength: aws.Int64(100),
+	}, nil).AnyTimes()
 	s3c.EXPECT().ListObjectsV2(gomock.Any(), gomock.Any()).Return(&s3.ListObjectsV2Output{
 		Contents: []types.Object{
 			{Size: aws.Int64(100)},
@@ -45,10 +48,10 @@ func TestS3PresignedHappyPath(t *testing.T) {
 	}, nil)
 
 	ps3c := mock.NewMockPresignedS3Client(ctrl)
-	ps3c.EXPECT().PresignGetObject(gomock.Any(), gomock.Any()).Return(&v4.PresignedHTTPRequest{
+	ps3c.EXPECT().PresignGetObject(gomock.Any(), gomock.Any(), gomock.Any()).Return(&v4.PresignedHTTPRequest{
 		URL: "some value",
 	}, nil).AnyTimes()
-	ps3c.EXPECT().PresignPutObject(gomock.Any(), gomock.Any()).Return(&v4.PresignedHTTPRequest{
+	ps3c.EXPECT().PresignPutObject(gomock.Any(), gomock.Any(), gomock.Any()).Return(&v4.PresignedHTTPRequest{
 		URL: "some value",
 	}, nil).AnyTimes()
 
@@ -7,10 +7,15 @@
 package storage
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -20,6 +25,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/log"
 	config "github.com/gitpod-io/gitpod/content-service/api/config"
 	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
 )
 
 const (
@@ -88,6 +94,10 @@ type PresignedAccess interface {
 	// ObjectExists tells whether the given object exists or not
 	ObjectExists(ctx context.Context, bucket string, path string) (bool, error)
 
+	// ListObjectInfos describes the objects whose name starts with prefix, including their annotations.
+	// Returns an empty list if the bucket does not exist (yet).
+	ListObjectInfos(ctx context.Context, bucket string, prefix string) ([]ObjectInfo, error)
+
 	// BackupObject returns a backup's object name that a direct downloader would download
 	BackupObject(ownerID string, workspaceID string, name string) string
 
@@ -95,6 +105,47 @@ type PresignedAccess interface {
 	InstanceObject(ownerID string, workspaceID string, instanceID string, name string) string
 }
 
+// LifecycleAccess lists and transitions the objects of all users, such that retention policies can be enforced
+type LifecycleAccess interface {
+	PresignedAccess
+
+	// WalkObjects calls fn for every object in the buckets of all users. If fn returns an error, the walk stops.
+	WalkObjects(ctx context.Context, fn func(obj ObjectInfo) error) error
+
+	// SetStorageClass moves an object to another storage class, keeping its content and metadata
+	SetStorageClass(ctx context.Context, bucket, obj, class string) error
+}
+
+// ObjectInfo describes an object found while walking or listing the remote storage
+type ObjectInfo struct {
+	Bucket       string
+	Name         string
+	Size         int64
+	LastModified time.Time
+	StorageClass string
+
+	// Annotations are only set when listing objects, not when walking them
+	Annotations map[string]string
+}
+
+// Annotation returns the value of an annotation of the object. Storage systems differ in how
+// they case the names of annotations, hence they're compared case-insensitively.
+func (o ObjectInfo) Annotation(name string) string {
+	return annotation(o.Annotations, name)
+}
+
+func annotation(annotations map[string]string, name string) string {
+	if v, ok := annotations[name]; ok {
+		return v
+	}
+	for k, v := range annotations {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
 // ObjectMeta describtes the metadata of a remote object
 type ObjectMeta struct {
 	ContentType        string
@@ -108,11 +159,15 @@ type DownloadInfo struct {
 	Meta ObjectMeta
 	URL  string
 	Size int64
+	// Expires is when URL stops being valid
+	Expires time.Time
 }
 
 // UploadInfo describes an object for upload
 type UploadInfo struct {
 	URL string
+	// Expires is when URL stops being valid
+	Expires time.Time
 }
 
 // DeleteObjectQuery specifies objects to delete, either by an exact name or prefix
@@ -127,6 +182,61 @@ type SignedURLOptions struct {
 	// to use the generated signed URL.
 	// Optional.
 	ContentType string
+
+	// TTL is how long the signed URL remains valid. It is capped at the configured maximum lifetime.
+	// Optional, defaults to the configured lifetime of download or upload URLs.
+	TTL time.Duration
+}
+
+const (
+	defaultDownloadURLTTL = 1 * time.Hour
+	defaultUploadURLTTL   = 30 * time.Minute
+	// defaultMaxURLTTL is the longest lifetime S3 and GCloud V4 signatures support
+	defaultMaxURLTTL = 7 * 24 * time.Hour
+)
+
+// signedURLTTLs are the lifetimes of presigned URLs
+type signedURLTTLs struct {
+	Download time.Duration
+	Upload   time.Duration
+	Max      time.Duration
+}
+
+// getSignedURLTTLs applies the defaults to a signed URL config
+func getSignedURLTTLs(c config.SignedURLConfig) signedURLTTLs {
+	res := signedURLTTLs{
+		Download: defaultDownloadURLTTL,
+		Upload:   defaultUploadURLTTL,
+		Max:      defaultMaxURLTTL,
+	}
+	if c.DownloadTTL > 0 {
+		res.Download = time.Duration(c.DownloadTTL)
+	}
+	if c.UploadTTL > 0 {
+		res.Upload = time.Duration(c.UploadTTL)
+	}
+	if c.MaxTTL > 0 {
+		res.Max = time.Duration(c.MaxTTL)
+	}
+	return res
+}
+
+// download returns how long a download URL signed with the given options remains valid
+func (t signedURLTTLs) download(options *SignedURLOptions) time.Duration {
+	return t.ttl(options, t.Download)
+}
+
+// upload returns how long an upload URL signed with the given options remains valid
+func (t signedURLTTLs) upload(options *SignedURLOptions) time.Duration {
+	return t.ttl(options, t.Upload)
+}
+
+func (t signedURLTTLs) ttl(options *SignedURLOptions, def time.Duration) time.Duration {
+	res := def
+	if options != nil && options.TTL > 0 {
+		res = options.TTL
+	}
+	return min(res, t.Max)
 }
 
 // DirectDownloader downloads a snapshot
@@ -169,6 +279,32 @@ type UploadOptions struct {
 	Annotations map[string]string
 
 	ContentType string
+
+	// BandwidthLimit is the maximum upload rate in bytes per second. If zero, uploads are not throttled.
+	BandwidthLimit int64
+
+	// Progress is called with the number of bytes uploaded so far whenever the upload advanced. If nil, the
+	// progress is not reported.
+	Progress func(bytesDone int64)
+}
+
+// streamed returns true if the upload has to read the source itself, rather than leave the file to a tool or
+// client library function, because we throttle the upload or report its progress.
+func (o *UploadOptions) streamed() bool {
+	return o.BandwidthLimit > 0 || o.Progress != nil
+}
+
+// uploadReader wraps the source file of an upload such that it is throttled and reports the progress
+// of the upload, as configured
+func (o *UploadOptions) uploadReader(f *os.File) io.Reader {
+	if o.Progress == nil {
+		return NewThrottledReader(f, o.BandwidthLimit)
+	}
+	if o.BandwidthLimit > 0 {
+		return NewThrottledReader(&progressReader{r: f, progress: o.Progress}, o.BandwidthLimit)
+	}
+	// Unthrottled uploads may read the file in parallel parts, hence we keep it seekable and readable at an offset.
+	return &progressFile{progressReader: progressReader{r: f, progress: o.Progress}, f: f}
 }
 
 // UploadOption configures a particular aspect of remote storage upload
@@ -190,6 +326,96 @@ func WithContentType(ct string) UploadOption {
 	}
 }
 
+// WithBandwidthLimit throttles the upload to the given amount of bytes per second
+func WithBandwidthLimit(bytesPerSecond int64) UploadOption {
+	return func(opts *UploadOptions) error {
+		if bytesPerSecond < 0 {
+			return xerrors.Errorf("bandwidth limit must not be negative")
+		}
+		opts.BandwidthLimit = bytesPerSecond
+		return nil
+	}
+}
+
+// WithProgress reports the number of bytes uploaded so far to the given function as the upload advances
+func WithProgress(progress func(bytesDone int64)) UploadOption {
+	return func(opts *UploadOptions) error {
+		opts.Progress = progress
+		return nil
+	}
+}
+
+// progressReader reports the number of bytes read from an underlying reader
+type progressReader struct {
+	r        io.Reader
+	progress func(bytesDone int64)
+
+	read atomic.Int64
+}
+
+func (p *progressReader) Read(b []byte) (n int, err error) {
+	n, err = p.r.Read(b)
+	if n > 0 {
+		p.progress(p.read.Add(int64(n)))
+	}
+	return n, err
+}
+
+// progressFile reports the number of bytes read from a file, no matter whether they're read sequentially or at an offset.
+// Parts which are read more than once, e.g. because their upload is retried, count more than once.
+type progressFile struct {
+	progressReader
+	f *os.File
+}
+
+func (p *progressFile) ReadAt(b []byte, off int64) (n int, err error) {
+	n, err = p.f.ReadAt(b, off)
+	if n > 0 {
+		p.progress(p.read.Add(int64(n)))
+	}
+	return n, err
+}
+
+func (p *progressFile) Seek(offset int64, whence int) (int64, error) {
+	return p.f.Seek(offset, whence)
+}
+
+// throttledReader reads from an underlying reader no faster than a given amount of bytes per second
+type throttledReader struct {
+	r              io.Reader
+	bytesPerSecond int64
+
+	start time.Time
+	read  int64
+}
+
+// NewThrottledReader limits reading from r to the given amount of bytes per second. If bytesPerSecond is zero, r is not throttled.
+func NewThrottledReader(r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+	return &throttledReader{r: r, bytesPerSecond: bytesPerSecond}
+}
+
+func (t *throttledReader) Read(p []byte) (n int, err error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	// never read more than a second's worth of bytes at once, such that we don't sleep too long
+	if int64(len(p)) > t.bytesPerSecond {
+		p = p[:t.bytesPerSecond]
+	}
+
+	n, err = t.r.Read(p)
+	t.read += int64(n)
+
+	due := time.Duration(float64(t.read) / float64(t.bytesPerSecond) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
+
 // GetUploadOptions turns functional opts into a struct
 func GetUploadOptions(opts []UploadOption) (*UploadOptions, error) {
 	res := &UploadOptions{}
@@ -211,6 +437,12 @@ const (
 
 	// ObjectAnnotationOCIContentType is the OCI media type of the object
 	ObjectAnnotationOCIContentType = "gitpod-oci-contentType"
+
+	// ObjectAnnotationCompression is the algorithm an archive is compressed with, if it is compressed
+	ObjectAnnotationCompression = "gitpod-compression"
+
+	// ObjectAnnotationInstanceID is the ID of the workspace instance whose content an archive holds
+	ObjectAnnotationInstanceID = "gitpod-instanceId"
 )
 
 // NewDirectAccess provides direct access to a storage system
@@ -222,9 +454,11 @@ func NewDirectAccess(c *config.StorageConfig) (DirectAccess, error) {
 
 	switch c.Kind {
 	case config.GCloudStorage:
-		return newDirectGCPAccess(c.GCloudConfig, stage)
+		return newDirectGCPAccess(c.GCloudConfig, stage, c.Transfer)
 	case config.MinIOStorage:
-		return newDirectMinIOAccess(c.MinIOConfig)
+		return newDirectMinIOAccess(c.MinIOConfig, c.Transfer)
+	case config.AzureStorage:
+		return newDirectAzureAccess(c.AzureConfig, c.Transfer)
 	case config.S3Storage:
 		cfg, err := loadAwsConfig(c.S3Config)
 		if err != nil {
@@ -232,7 +466,8 @@ func NewDirectAccess(c *config.StorageConfig) (DirectAccess, error) {
 		}
 
 		return newDirectS3Access(s3.NewFromConfig(*cfg), S3Config{
-			Bucket: c.S3Config.Bucket,
+			Bucket:   c.S3Config.Bucket,
+			Transfer: c.Transfer,
 		}), nil
 	default:
 		return &DirectNoopStorage{}, nil
@@ -248,9 +483,11 @@ func NewPresignedAccess(c *config.StorageConfig) (PresignedAccess, error) {
 
 	switch c.Kind {
 	case config.GCloudStorage:
-		return newPresignedGCPAccess(c.GCloudConfig, stage)
+		return newPresignedGCPAccess(c.GCloudConfig, stage, c.SignedURLs)
 	case config.MinIOStorage:
-		return newPresignedMinIOAccess(c.MinIOConfig)
+		return newPresignedMinIOAccess(c.MinIOConfig, c.SignedURLs)
+	case config.AzureStorage:
+		return newPresignedAzureAccess(c.AzureConfig, c.SignedURLs)
 	case config.S3Storage:
 		cfg, err := loadAwsConfig(c.S3Config)
 		if err != nil {
@@ -258,7 +495,8 @@ func NewPresignedAccess(c *config.StorageConfig) (PresignedAccess, error) {
 		}
 
 		return NewPresignedS3Access(s3.NewFromConfig(*cfg), S3Config{
-			Bucket: c.S3Config.Bucket,
+			Bucket:     c.S3Config.Bucket,
+			SignedURLs: c.SignedURLs,
 		}), nil
 	default:
 		log.Warnf("falling back to noop presigned storage access. Is this intentional? (storage kind: %s)", c.Kind)
@@ -266,6 +504,19 @@ func NewPresignedAccess(c *config.StorageConfig) (PresignedAccess, error) {
 	}
 }
 
+// NewLifecycleAccess provides access to the objects of all users for enforcing retention policies
+func NewLifecycleAccess(c *config.StorageConfig) (LifecycleAccess, error) {
+	ps, err := NewPresignedAccess(c)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := ps.(LifecycleAccess)
+	if !ok {
+		return nil, xerrors.Errorf("storage kind %s does not support lifecycle management", c.Kind)
+	}
+	return res, nil
+}
+
 func loadAwsConfig(s3config *config.S3Config) (*aws.Config, error) {
 	var opts []func(*awsconfig.LoadOptions) error
 	if s3config.CredentialsFile != "" {
@@ -284,8 +535,42 @@ func loadAwsConfig(s3config *config.S3Config) (*aws.Config, error) {
 	return &cfg, nil
 }
 
-func extractTarbal(ctx context.Context, dest string, src io.Reader, mappings []archive.IDMapping) error {
-	err := archive.ExtractTarbal(ctx, src, dest, archive.WithUIDMapping(mappings), archive.WithGIDMapping(mappings))
+// chunkOpener opens a chunk of a deduplicated backup
+type chunkOpener func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error)
+
+// extractTarbal extracts an archive to dest. If src is the manifest of a deduplicated backup, the archive is
+// restored from the chunks opened by chunks.
+func extractTarbal(ctx context.Context, dest string, src io.Reader, mappings []archive.IDMapping, chunks chunkOpener) error {
+	br := bufio.NewReader(countReads(ctx, src))
+	if isDedupManifest(br) {
+		if chunks == nil {
+			return xerrors.Errorf("cannot extract deduplicated backup to %s: its chunks are not available", dest)
+		}
+		manifest, err := ReadDedupManifest(br)
+		if err != nil {
+			return err
+		}
+
+		pr, pw := io.Pipe()
+		// closing the reader stops the restore should the extraction fail
+		defer pr.Close()
+		go func() {
+			pw.CloseWithError(RestoreDedupChunks(ctx, pw, manifest, func(ctx context.Context, chunk DedupChunk) (io.ReadCloser, error) {
+				rc, err := chunks(ctx, chunk)
+				if err != nil {
+					return nil, err
+				}
+				return &countingReadCloser{Reader: countReads(ctx, rc), Closer: rc}, nil
+			}))
+		}()
+		br = bufio.NewReader(pr)
+	}
+	if encryption.IsEncrypted(br) {
+		// the data key is unwrapped by ws-daemon, which holds the keys of organizations
+		return xerrors.Errorf("cannot extract encrypted archive to %s: it must be restored by ws-daemon", dest)
+	}
+
+	err := archive.ExtractTarbal(ctx, br, dest, archive.WithUIDMapping(mappings), archive.WithGIDMapping(mappings))
 	if err != nil {
 		return xerrors.Errorf("tar %s: %s", dest, err.Error())
 	}
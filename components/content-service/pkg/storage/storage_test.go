@@ -5,10 +5,19 @@
 package storage
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
 )
 
 func TestBlobObjectName(t *testing.T) {
@@ -53,6 +62,111 @@ func TestBlobObjectName(t *testing.T) {
 	}
 }
 
+func TestThrottledReader(t *testing.T) {
+	const bytesPerSecond = 1000
+	data := bytes.Repeat([]byte("x"), 1500)
+
+	start := time.Now()
+	read, err := io.ReadAll(NewThrottledReader(bytes.NewReader(data), bytesPerSecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatalf("unexpected content: read %d bytes, expected %d", len(read), len(data))
+	}
+	if elapsed := time.Since(start); elapsed < 1400*time.Millisecond {
+		t.Errorf("read %d bytes at %d bytes per second in %v, which is too fast", len(data), bytesPerSecond, elapsed)
+	}
+}
+
+func TestUploadReaderProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 1500)
+	fn := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(fn, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []int64{0, 10000} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			f, err := os.Open(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var reported int64
+			options, err := GetUploadOptions([]UploadOption{WithBandwidthLimit(limit), WithProgress(func(bytesDone int64) { reported = bytesDone })})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !options.streamed() {
+				t.Fatal("uploads which report their progress must be streamed")
+			}
+
+			r := options.uploadReader(f)
+			if _, seekable := r.(io.ReadSeeker); seekable != (limit == 0) {
+				t.Errorf("expected the reader to be seekable only if the upload isn't throttled")
+			}
+			read, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(read, data) {
+				t.Fatalf("unexpected content: read %d bytes, expected %d", len(read), len(data))
+			}
+			if reported != int64(len(data)) {
+				t.Errorf("expected %d bytes to be reported, got %d", len(data), reported)
+			}
+		})
+	}
+}
+
+func TestSignedURLTTLs(t *testing.T) {
+	tests := []struct {
+		Name             string
+		Config           config.SignedURLConfig
+		Options          SignedURLOptions
+		ExpectedDownload time.Duration
+		ExpectedUpload   time.Duration
+	}{
+		{
+			Name:             "defaults",
+			ExpectedDownload: 1 * time.Hour,
+			ExpectedUpload:   30 * time.Minute,
+		},
+		{
+			Name:             "configured",
+			Config:           config.SignedURLConfig{DownloadTTL: util.Duration(4 * time.Hour), UploadTTL: util.Duration(2 * time.Hour)},
+			ExpectedDownload: 4 * time.Hour,
+			ExpectedUpload:   2 * time.Hour,
+		},
+		{
+			Name:             "requested TTL",
+			Options:          SignedURLOptions{TTL: 12 * time.Hour},
+			ExpectedDownload: 12 * time.Hour,
+			ExpectedUpload:   12 * time.Hour,
+		},
+		{
+			Name:             "requested TTL exceeds maximum",
+			Config:           config.SignedURLConfig{MaxTTL: util.Duration(6 * time.Hour)},
+			Options:          SignedURLOptions{TTL: 12 * time.Hour},
+			ExpectedDownload: 6 * time.Hour,
+			ExpectedUpload:   6 * time.Hour,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ttls := getSignedURLTTLs(test.Config)
+			if act := ttls.download(&test.Options); act != test.ExpectedDownload {
+				t.Errorf("unexpected download TTL: is %v but expected %v", act, test.ExpectedDownload)
+			}
+			if act := ttls.upload(&test.Options); act != test.ExpectedUpload {
+				t.Errorf("unexpected upload TTL: is %v but expected %v", act, test.ExpectedUpload)
+			}
+		})
+	}
+}
+
 func invalidNameError(name string) error {
 	return xerrors.Errorf(`blob name '%s' needs to match regex '^[a-zA-Z0-9._\-\/]+$'`, name)
 }
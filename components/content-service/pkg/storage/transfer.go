@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+)
+
+const (
+	// minTransferPartSize is the smallest part size S3 compatible storage accepts for multipart uploads
+	minTransferPartSize = 5 * megabytes
+
+	defaultTransferRetries = 3
+)
+
+// partRetryDelay is how long the download of a part waits before it is resumed, it grows with every attempt
+var partRetryDelay = 1 * time.Second
+
+// transferOptions configure multipart up- and downloads
+type transferOptions struct {
+	PartSize    int64
+	Concurrency int
+	Retries     int
+
+	// BufferDownloads enables multipart downloads, which are buffered in TmpDir
+	BufferDownloads bool
+	TmpDir          string
+}
+
+// multipartDownload returns true if an object of the given size is downloaded in parts rather than streamed
+func (o transferOptions) multipartDownload(size int64) bool {
+	return o.BufferDownloads && size > o.PartSize && o.Concurrency > 1
+}
+
+// getTransferOptions applies the defaults to a transfer config
+func getTransferOptions(c config.TransferConfig) transferOptions {
+	res := transferOptions{
+		PartSize:        defaultPartSize * megabytes,
+		Concurrency:     defaultCopyConcurrency,
+		Retries:         defaultTransferRetries,
+		BufferDownloads: c.BufferDownloads,
+		TmpDir:          c.TmpDir,
+	}
+	if c.PartSizeMiB > 0 {
+		res.PartSize = max(c.PartSizeMiB*megabytes, minTransferPartSize)
+	}
+	if c.Concurrency > 0 {
+		res.Concurrency = c.Concurrency
+	}
+	if c.Retries > 0 {
+		res.Retries = c.Retries
+	}
+	return res
+}
+
+// rangeReader opens a reader for length bytes of an object, starting at offset
+type rangeReader func(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+
+// downloadParts downloads an object of the given size into dst, transferring opts.Concurrency parts in parallel.
+func downloadParts(ctx context.Context, dst io.WriterAt, size int64, opts transferOptions, open rangeReader) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(opts.Concurrency)
+	for offset := int64(0); offset < size; offset += opts.PartSize {
+		offset, length := offset, min(opts.PartSize, size-offset)
+		eg.Go(func() error {
+			return downloadPart(ctx, dst, offset, length, opts.Retries, open)
+		})
+	}
+	return eg.Wait()
+}
+
+// downloadPart downloads a single part. If the download fails it is resumed from the last byte written, up to retries times.
+func downloadPart(ctx context.Context, dst io.WriterAt, offset, length int64, retries int, open rangeReader) error {
+	var written int64
+	for attempt := 0; ; attempt++ {
+		err := func() error {
+			rc, err := open(ctx, offset+written, length-written)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			n, err := io.Copy(io.NewOffsetWriter(dst, offset+written), io.LimitReader(rc, length-written))
+			written += n
+			if err == nil && written < length {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}()
+		if err == nil {
+			return nil
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			return xerrors.Errorf("cannot download part at offset %d: %w", offset, err)
+		}
+
+		log.WithError(err).WithField("offset", offset).WithField("written", written).Debug("resuming download of part")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * partRetryDelay):
+		}
+	}
+}
+
+// downloadToTempFile downloads an object in parts into a temporary file, which is removed once the returned reader is closed.
+func downloadToTempFile(ctx context.Context, size int64, opts transferOptions, open rangeReader) (io.ReadCloser, error) {
+	f, err := os.CreateTemp(opts.TmpDir, "download-*")
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create temporary file: %w", err)
+	}
+	res := &tempFileReader{f}
+
+	err = downloadParts(ctx, f, size, opts, open)
+	if err != nil {
+		res.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// tempFileReader reads a temporary file from the start and removes it when closed
+type tempFileReader struct {
+	f *os.File
+}
+
+func (t *tempFileReader) Read(p []byte) (int, error) {
+	return t.f.Read(p)
+}
+
+func (t *tempFileReader) Close() error {
+	err := t.f.Close()
+	os.Remove(t.f.Name())
+	return err
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	config "github.com/gitpod-io/gitpod/content-service/api/config"
+)
+
+func TestGetTransferOptions(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      config.TransferConfig
+		Expectation transferOptions
+	}{
+		{
+			Name:        "defaults",
+			Expectation: transferOptions{PartSize: 50 * megabytes, Concurrency: 10, Retries: 3},
+		},
+		{
+			Name:        "configured",
+			Config:      config.TransferConfig{PartSizeMiB: 16, Concurrency: 4, Retries: 5},
+			Expectation: transferOptions{PartSize: 16 * megabytes, Concurrency: 4, Retries: 5},
+		},
+		{
+			Name:        "buffered downloads",
+			Config:      config.TransferConfig{BufferDownloads: true, TmpDir: "/mnt/tmp"},
+			Expectation: transferOptions{PartSize: 50 * megabytes, Concurrency: 10, Retries: 3, BufferDownloads: true, TmpDir: "/mnt/tmp"},
+		},
+		{
+			Name:        "part size too small",
+			Config:      config.TransferConfig{PartSizeMiB: 1},
+			Expectation: transferOptions{PartSize: 5 * megabytes, Concurrency: 10, Retries: 3},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if diff := cmp.Diff(test.Expectation, getTransferOptions(test.Config)); diff != "" {
+				t.Errorf("unexpected options (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// failingReader fails after returning a number of bytes
+type failingReader struct {
+	r     io.Reader
+	limit int
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.limit <= 0 {
+		return 0, errors.New("connection reset")
+	}
+	if len(p) > f.limit {
+		p = p[:f.limit]
+	}
+	n, err := f.r.Read(p)
+	f.limit -= n
+	return n, err
+}
+
+func TestDownloadParts(t *testing.T) {
+	defer func(delay time.Duration) { partRetryDelay = delay }(partRetryDelay)
+	partRetryDelay = time.Millisecond
+
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	tests := []struct {
+		Name     string
+		Failures int
+		Retries  int
+		Error    bool
+	}{
+		{Name: "no failures"},
+		{Name: "resumes failed parts", Failures: 2, Retries: 3},
+		{Name: "too many failures", Failures: 4, Retries: 3, Error: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				failures = make(map[int64]int)
+			)
+			open := func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+				var r io.Reader = bytes.NewReader(content[offset : offset+length])
+
+				mu.Lock()
+				// the first part fails after a few bytes every time it is opened
+				part := offset / 300
+				if part == 0 && failures[part] < test.Failures {
+					failures[part]++
+					r = &failingReader{r: r, limit: 10}
+				}
+				mu.Unlock()
+				return io.NopCloser(r), nil
+			}
+
+			dst, err := os.CreateTemp(t.TempDir(), "download")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dst.Close()
+
+			opts := transferOptions{PartSize: 300, Concurrency: 2, Retries: test.Retries}
+			err = downloadParts(context.Background(), dst, int64(len(content)), opts, open)
+			if test.Error {
+				if err == nil {
+					t.Fatal("expected download to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, err := io.ReadAll(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, act) {
+				t.Errorf("unexpected content: %v", act)
+			}
+		})
+	}
+}
+
+func TestGsutilTransferFlags(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      config.TransferConfig
+		Expectation string
+	}{
+		{Name: "defaults"},
+		{
+			Name:        "configured",
+			Config:      config.TransferConfig{PartSizeMiB: 64, Concurrency: 4, Retries: 5},
+			Expectation: ` -o "GSUtil:parallel_composite_upload_component_size=64M" -o "Boto:num_retries=5"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := gsutilTransferFlags(test.Config, "parallel_composite_upload_component_size")
+			if act != test.Expectation {
+				t.Errorf("unexpected flags: expected %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
@@ -12,13 +12,17 @@ import ConfirmationModal from "../components/ConfirmationModal";
 import { SSHPublicKeyValue } from "@gitpod/gitpod-protocol";
 import dayjs from "dayjs";
 import { PageWithSettingsSubMenu } from "./PageWithSettingsSubMenu";
-import { Heading2, Subheading } from "../components/typography/headings";
+import { Heading2, Heading3, Subheading } from "../components/typography/headings";
 import { EmptyMessage } from "../components/EmptyMessage";
 import { Button } from "@podkit/buttons/Button";
 import { sshClient } from "../service/public-api";
 import { SSHPublicKey } from "@gitpod/public-api/lib/gitpod/v1/ssh_pb";
 import { InputField } from "../components/forms/InputField";
 import { TextInputField } from "../components/forms/TextInputField";
+import { CheckboxInputField } from "../components/forms/CheckboxInputField";
+import { getGitpodService } from "../service/service";
+import { useUpdateCurrentUserMutation } from "../data/current-user/update-mutation";
+import { useToast } from "../components/toasts/Toasts";
 
 interface AddModalProps {
     value: SSHPublicKeyValue;
@@ -145,6 +149,9 @@ export default function SSHKeys() {
     const [currentDelData, setCurrentDelData] = useState<SSHPublicKey>();
     const [showAddModal, setShowAddModal] = useState(false);
     const [showDelModal, setShowDelModal] = useState(false);
+    const [agentForwarding, setAgentForwarding] = useState<boolean>();
+    const updateUser = useUpdateCurrentUserMutation();
+    const { toast } = useToast();
 
     const loadData = () => {
         sshClient.listSSHPublicKeys({}).then((r) => setDataList(r.sshKeys));
@@ -152,8 +159,24 @@ export default function SSHKeys() {
 
     useEffect(() => {
         loadData();
+        getGitpodService()
+            .server.getLoggedInUser()
+            .then((u) => setAgentForwarding(!!u.additionalData?.sshAgentForwarding));
     }, []);
 
+    const saveAgentForwarding = useCallback(
+        async (checked: boolean) => {
+            await updateUser.mutateAsync({
+                additionalData: {
+                    sshAgentForwarding: checked,
+                },
+            });
+            setAgentForwarding(checked);
+            toast("SSH agent forwarding was updated. It applies to newly started workspaces.");
+        },
+        [updateUser, toast],
+    );
+
     const addOne = () => {
         setCurrentData({ name: "", key: "" });
         setShowAddModal(true);
@@ -235,6 +258,15 @@ export default function SSHKeys() {
                     })}
                 </div>
             )}
+            <Heading3 className="mt-12">Agent Forwarding</Heading3>
+            <Subheading>Use the keys of your local SSH agent inside workspaces without uploading them.</Subheading>
+            <CheckboxInputField
+                label="Forward SSH agent"
+                hint="Lets SSH sessions into your workspaces use your local SSH agent, e.g. for Git operations. Make sure to connect with agent forwarding enabled (ssh -A)."
+                checked={!!agentForwarding}
+                onChange={saveAgentForwarding}
+                disabled={agentForwarding === undefined || updateUser.isLoading}
+            />
         </PageWithSettingsSubMenu>
     );
 }
@@ -27,6 +27,7 @@ import (
 
 var exportEnvs = false
 var unsetEnvs = false
+var fileEnvs = false
 
 // envCmd represents the env command
 var envCmd = &cobra.Command{
@@ -45,6 +46,10 @@ you can do so using -e:
 To update the current terminal session with the latest set of persistent environment variables, use:
     eval $(gp env -e)
 
+To pass a secret to workspace processes as a file readable only by you, rather than as an environment variable, use --file.
+The variable FOO_FILE then points to the file holding the value of FOO:
+	gp env --file FOO=secret
+
 To delete a persistent environment variable use:
 	gp env -u foo
 
@@ -280,7 +285,7 @@ func parseArgs(args []string, pattern string) ([]*serverapi.UserEnvVarValue, err
 			return nil, GpError{Err: xerrors.Errorf("variable must have a value; use -u to unset a variable"), OutCome: utils.Outcome_UserErr, ErrorCode: utils.UserErrorCode_InvalidArguments}
 		}
 
-		vars[i] = &serverapi.UserEnvVarValue{Name: key, Value: val, RepositoryPattern: pattern}
+		vars[i] = &serverapi.UserEnvVarValue{Name: key, Value: val, RepositoryPattern: pattern, AsFile: fileEnvs}
 	}
 
 	return vars, nil
@@ -291,4 +296,5 @@ func init() {
 
 	envCmd.Flags().BoolVarP(&exportEnvs, "export", "e", false, "produce a script that can be eval'ed in Bash")
 	envCmd.Flags().BoolVarP(&unsetEnvs, "unset", "u", false, "deletes/unsets persisted environment variables")
+	envCmd.Flags().BoolVar(&fileEnvs, "file", false, "passes the variables to workspace processes as files rather than environment variables")
 }
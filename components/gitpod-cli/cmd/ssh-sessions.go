@@ -0,0 +1,109 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gitpod-io/gitpod/gitpod-cli/pkg/supervisor"
+	"github.com/gitpod-io/gitpod/gitpod-cli/pkg/utils"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sshSessionsCmd represents the ssh sessions command
+var sshSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Lists the active SSH sessions and port tunnels of the workspace",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+		defer cancel()
+
+		client, err := supervisor.New(ctx)
+		if err != nil {
+			return xerrors.Errorf("cannot list sessions: %w", err)
+		}
+		defer client.Close()
+
+		resp, err := client.Control.ListSessions(ctx, &api.ListSessionsRequest{})
+		if err != nil {
+			return xerrors.Errorf("cannot list sessions: %w", err)
+		}
+
+		if len(resp.Sessions) == 0 {
+			fmt.Println("No sessions detected")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Kind", "Client", "Connections", "Started"})
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+
+		for _, session := range resp.Sessions {
+			var (
+				kind        string
+				from        string
+				connections = "1"
+			)
+			switch session.Kind {
+			case api.SessionKind_tunnel_session:
+				kind = "tunnel"
+				from = fmt.Sprintf("%s (%d:%d)", session.ClientId, session.LocalPort, session.TargetPort)
+				connections = fmt.Sprint(session.Connections)
+			default:
+				kind = "ssh"
+				from = session.RemoteAddr
+			}
+			started := time.Since(time.Unix(session.StartedAt, 0)).Round(time.Second)
+			table.Append([]string{session.Id, kind, from, connections, started.String() + " ago"})
+		}
+
+		table.Render()
+		return nil
+	},
+}
+
+// sshSessionsRevokeCmd represents the ssh sessions revoke command
+var sshSessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Terminates an SSH session or closes the connections of a port tunnel",
+	Long: `Terminates an SSH session or closes all connections of a port tunnel client.
+The IDs of the active sessions are listed by "gp ssh sessions".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+		defer cancel()
+
+		client, err := supervisor.New(ctx)
+		if err != nil {
+			return xerrors.Errorf("cannot revoke session: %w", err)
+		}
+		defer client.Close()
+
+		_, err = client.Control.RevokeSession(ctx, &api.RevokeSessionRequest{Id: args[0]})
+		if status.Code(err) == codes.NotFound {
+			return GpError{Err: xerrors.Errorf("session %s not found", args[0]), OutCome: utils.Outcome_UserErr, ErrorCode: utils.UserErrorCode_InvalidArguments}
+		}
+		if err != nil {
+			return xerrors.Errorf("cannot revoke session: %w", err)
+		}
+		fmt.Printf("Session %s revoked\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	sshSessionsCmd.AddCommand(sshSessionsRevokeCmd)
+	sshCmd.AddCommand(sshSessionsCmd)
+}
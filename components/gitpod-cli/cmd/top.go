@@ -33,7 +33,7 @@ type topData struct {
 
 var topCmd = &cobra.Command{
 	Use:   "top",
-	Short: "Display usage of workspace resources (CPU and memory)",
+	Short: "Display usage of workspace resources (CPU, memory and disk)",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
 		defer cancel()
@@ -108,6 +108,17 @@ func outputTable(workspaceResources *api.ResourcesStatusResponse, workspaceClass
 	table.Rich([]string{"CPU (millicores)", cpu}, cpuColors)
 	table.Rich([]string{"Memory (bytes)", memory}, memoryColors)
 
+	if workspaceResources.Disk != nil && workspaceResources.Disk.Limit > 0 {
+		diskFraction := int64((float64(workspaceResources.Disk.Used) / float64(workspaceResources.Disk.Limit)) * 100)
+		disk := fmt.Sprintf("%dGi/%dGi (%d%%)", workspaceResources.Disk.Used/(1024*1024*1024), workspaceResources.Disk.Limit/(1024*1024*1024), diskFraction)
+
+		var diskColors []tablewriter.Colors
+		if !noColor && utils.ColorsEnabled() {
+			diskColors = []tablewriter.Colors{nil, {getColor(workspaceResources.Disk.Severity)}}
+		}
+		table.Rich([]string{"Disk (bytes)", disk}, diskColors)
+	}
+
 	table.Render()
 }
 
@@ -83,6 +83,21 @@ func runRebuild(ctx context.Context, supervisorClient *supervisor.SupervisorClie
 		return GpError{Err: err, OutCome: utils.Outcome_UserErr, ErrorCode: utils.RebuildErrorCode_MissingGitpodYaml, Silence: true}
 	}
 
+	gitpodConfigData, err := os.ReadFile(filepath.Join(checkoutLocation, ".gitpod.yml"))
+	if err != nil {
+		return err
+	}
+	if problems := utils.LintGitpodConfig(gitpodConfigData, gitpodConfig); len(problems) > 0 {
+		fmt.Println("The .gitpod.yml file is invalid, please fix the following problems and try again:")
+		for _, problem := range problems {
+			fmt.Println("  - " + problem.String())
+		}
+		fmt.Println("")
+		fmt.Println("For help check out the reference page:")
+		fmt.Println("https://www.gitpod.io/docs/references/gitpod-yml#gitpodyml")
+		return GpError{Err: xerrors.Errorf("invalid .gitpod.yml: %d problems", len(problems)), OutCome: utils.Outcome_UserErr, ErrorCode: utils.RebuildErrorCode_InvalidGitpodYaml, Silence: true}
+	}
+
 	var image string
 	var dockerfilePath string
 	var dockerContext string
@@ -174,6 +189,12 @@ func runRebuild(ctx context.Context, supervisorClient *supervisor.SupervisorClie
 		utils.TrackCommandUsageEvent.ImageBuildDuration = time.Since(imageBuildStartTime).Milliseconds()
 	}
 
+	if validateOpts.DryRun {
+		fmt.Println("")
+		fmt.Println("The .gitpod.yml file is valid and the workspace image builds, skipping the workspace start (dry run)")
+		return nil
+	}
+
 	// 3. start debug
 	fmt.Println("")
 	runLog := log.New()
@@ -622,6 +643,7 @@ var validateOpts struct {
 	From            string
 	Prebuild        bool
 	Headless        bool
+	DryRun          bool
 
 	// internal
 	GitpodEnvs []string
@@ -666,6 +688,7 @@ func init() {
 	setFlags := func(cmd *cobra.Command) {
 		cmd.PersistentFlags().BoolVarP(&validateOpts.Prebuild, "prebuild", "", false, "starts as a prebuild workspace.")
 		cmd.PersistentFlags().StringVarP(&validateOpts.LogLevel, "log", "", "error", "Log level to use. Allowed values are 'error', 'warn', 'info', 'debug', 'trace'.")
+		cmd.PersistentFlags().BoolVarP(&validateOpts.DryRun, "dry-run", "", false, "only validates the .gitpod.yml and builds the workspace image without starting the workspace.")
 
 		// internal
 		cmd.PersistentFlags().StringArrayVarP(&validateOpts.GitpodEnvs, "gitpod-env", "", nil, "")
@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const maxOnStopTimeout = 5 * time.Minute
+
+var (
+	portRangeRegexp = regexp.MustCompile(`^\d+[:-]\d+$`)
+
+	taskOpenInValues     = []string{"bottom", "main", "left", "right"}
+	taskOpenModeValues   = []string{"split-left", "split-right", "tab-before", "tab-after"}
+	portOnOpenValues     = []string{"open-browser", "open-preview", "notify", "ignore"}
+	portVisibilityValues = []string{"private", "public"}
+	portProtocolValues   = []string{"http", "https"}
+)
+
+// GitpodConfigProblem is a mistake in a .gitpod.yml file
+type GitpodConfigProblem struct {
+	// Path points to the offending property, e.g. tasks[0].openMode
+	Path    string
+	Message string
+}
+
+func (p GitpodConfigProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// LintGitpodConfig checks a .gitpod.yml file for mistakes which parsing it does not catch,
+// i.e. unknown properties, invalid values and tasks which would not do anything.
+func LintGitpodConfig(data []byte, config *gitpod.GitpodConfig) []GitpodConfigProblem {
+	var problems []GitpodConfigProblem
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, GitpodConfigProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err == nil {
+		for _, key := range unknownProperties(raw, reflect.TypeOf(gitpod.GitpodConfig{})) {
+			report(key, "unknown property")
+		}
+		if tasks, ok := raw["tasks"].([]interface{}); ok {
+			for i, task := range tasks {
+				for _, key := range unknownProperties(task, reflect.TypeOf(gitpod.TasksItems{})) {
+					report(fmt.Sprintf("tasks[%d].%s", i, key), "unknown property")
+				}
+			}
+		}
+		if ports, ok := raw["ports"].([]interface{}); ok {
+			for i, port := range ports {
+				for _, key := range unknownProperties(port, reflect.TypeOf(gitpod.PortsItems{})) {
+					report(fmt.Sprintf("ports[%d].%s", i, key), "unknown property")
+				}
+			}
+		}
+	}
+	if config == nil {
+		return problems
+	}
+
+	if img, ok := config.Image.(map[interface{}]interface{}); ok {
+		if file, _ := img["file"].(string); file == "" {
+			report("image.file", "a Dockerfile is required when the image is configured as an object")
+		}
+	}
+	for _, loc := range []struct{ Path, Value string }{
+		{"checkoutLocation", config.CheckoutLocation},
+		{"workspaceLocation", config.WorkspaceLocation},
+	} {
+		if loc.Value == "" {
+			continue
+		}
+		p := loc.Value
+		if !filepath.IsAbs(p) {
+			p = filepath.Join("/workspace", p)
+		}
+		if p = filepath.Clean(p); p != "/workspace" && !strings.HasPrefix(p, "/workspace/") {
+			report(loc.Path, "%s must be within /workspace", loc.Value)
+		}
+	}
+
+	for i, task := range config.Tasks {
+		path := fmt.Sprintf("tasks[%d]", i)
+		if task == nil {
+			report(path, "task is empty")
+			continue
+		}
+		if strings.TrimSpace(task.Before+task.Init+task.Prebuild+task.Command+task.OnStop) == "" {
+			report(path, "task has no before, init, prebuild, command or onStop, hence it does not do anything")
+		}
+		if task.OpenIn != "" && !slices.Contains(taskOpenInValues, task.OpenIn) {
+			report(path+".openIn", "%q is not one of %s", task.OpenIn, strings.Join(taskOpenInValues, ", "))
+		}
+		if task.OpenMode != "" && !slices.Contains(taskOpenModeValues, task.OpenMode) {
+			report(path+".openMode", "%q is not one of %s", task.OpenMode, strings.Join(taskOpenModeValues, ", "))
+		}
+		if task.OnStopTimeout != "" {
+			timeout, err := time.ParseDuration(task.OnStopTimeout)
+			if err != nil || timeout <= 0 {
+				report(path+".onStopTimeout", "%q is not a duration, use e.g. 30s", task.OnStopTimeout)
+			} else if timeout > maxOnStopTimeout {
+				report(path+".onStopTimeout", "%s exceeds the maximum of %s", task.OnStopTimeout, maxOnStopTimeout)
+			}
+			if task.OnStop == "" {
+				report(path+".onStopTimeout", "has no effect without onStop")
+			}
+		}
+	}
+
+	seenPorts := make(map[string]int)
+	for i, port := range config.Ports {
+		path := fmt.Sprintf("ports[%d]", i)
+		if port == nil {
+			report(path, "port is empty")
+			continue
+		}
+		portSpec, ok := lintPort(port.Port)
+		if !ok {
+			report(path+".port", "%v is neither a port number nor a range like 3000-3999", port.Port)
+		} else if prev, exists := seenPorts[portSpec]; exists {
+			report(path+".port", "%s is already configured in ports[%d]", portSpec, prev)
+		} else {
+			seenPorts[portSpec] = i
+		}
+		if port.OnOpen != "" && !slices.Contains(portOnOpenValues, port.OnOpen) {
+			report(path+".onOpen", "%q is not one of %s", port.OnOpen, strings.Join(portOnOpenValues, ", "))
+		}
+		if port.Visibility != "" && !slices.Contains(portVisibilityValues, port.Visibility) {
+			report(path+".visibility", "%q is not one of %s", port.Visibility, strings.Join(portVisibilityValues, ", "))
+		}
+		if port.Protocol != "" && !slices.Contains(portProtocolValues, port.Protocol) {
+			report(path+".protocol", "%q is not one of %s", port.Protocol, strings.Join(portProtocolValues, ", "))
+		}
+	}
+
+	return problems
+}
+
+// lintPort returns the normalized port spec if port is a valid port number or range.
+func lintPort(port interface{}) (spec string, ok bool) {
+	switch p := port.(type) {
+	case int:
+		return strconv.Itoa(p), p > 0 && p <= 65535
+	case string:
+		// a quoted port is as good as a port number
+		if n, err := strconv.Atoi(p); err == nil {
+			return strconv.Itoa(n), n > 0 && n <= 65535
+		}
+		if !portRangeRegexp.MatchString(p) {
+			return "", false
+		}
+		bounds := strings.FieldsFunc(p, func(r rune) bool { return r == ':' || r == '-' })
+		start, _ := strconv.Atoi(bounds[0])
+		end, _ := strconv.Atoi(bounds[1])
+		return fmt.Sprintf("%d-%d", start, end), start > 0 && start <= end && end <= 65535
+	default:
+		return "", false
+	}
+}
+
+// unknownProperties returns the keys of obj which are not a yaml property of typ, sorted.
+func unknownProperties(obj interface{}, typ reflect.Type) []string {
+	var keys []string
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k := range o {
+			keys = append(keys, k)
+		}
+	case map[interface{}]interface{}:
+		for k := range o {
+			keys = append(keys, fmt.Sprint(k))
+		}
+	default:
+		return nil
+	}
+
+	known := make(map[string]struct{}, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+		known[name] = struct{}{}
+	}
+
+	var res []string
+	for _, k := range keys {
+		if _, ok := known[k]; !ok {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
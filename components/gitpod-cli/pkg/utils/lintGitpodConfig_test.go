@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	yaml "gopkg.in/yaml.v2"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+)
+
+func TestLintGitpodConfig(t *testing.T) {
+	tests := []struct {
+		Desc        string
+		Config      string
+		Expectation []GitpodConfigProblem
+	}{
+		{
+			Desc: "valid",
+			Config: `
+image: gitpod/workspace-full
+tasks:
+  - name: build
+    init: yarn
+    command: yarn start
+    openMode: split-right
+    onStop: yarn stop
+    onStopTimeout: 30s
+  - name: cleanup
+    onStop: docker compose down
+ports:
+  - port: 3000
+    onOpen: open-preview
+  - port: 4000-4999
+    visibility: public
+  - port: "5000"
+`,
+		},
+		{
+			Desc: "unknown properties",
+			Config: `
+imgae: gitpod/workspace-full
+tasks:
+  - comand: yarn start
+ports:
+  - port: 3000
+    onopen: notify
+`,
+			Expectation: []GitpodConfigProblem{
+				{Path: "imgae", Message: "unknown property"},
+				{Path: "tasks[0].comand", Message: "unknown property"},
+				{Path: "ports[0].onopen", Message: "unknown property"},
+				{Path: "tasks[0]", Message: "task has no before, init, prebuild, command or onStop, hence it does not do anything"},
+			},
+		},
+		{
+			Desc: "invalid task values",
+			Config: `
+tasks:
+  - command: yarn start
+    openIn: top
+    openMode: split
+    onStopTimeout: 10m
+`,
+			Expectation: []GitpodConfigProblem{
+				{Path: "tasks[0].openIn", Message: `"top" is not one of bottom, main, left, right`},
+				{Path: "tasks[0].openMode", Message: `"split" is not one of split-left, split-right, tab-before, tab-after`},
+				{Path: "tasks[0].onStopTimeout", Message: "10m exceeds the maximum of 5m0s"},
+				{Path: "tasks[0].onStopTimeout", Message: "has no effect without onStop"},
+			},
+		},
+		{
+			Desc: "invalid ports",
+			Config: `
+ports:
+  - port: 3000
+  - port: 3000
+    visibility: secret
+  - port: 3000-2000
+  - port: abc
+    protocol: tcp
+  - port: "3000"
+  - port: "70000"
+`,
+			Expectation: []GitpodConfigProblem{
+				{Path: "ports[1].port", Message: "3000 is already configured in ports[0]"},
+				{Path: "ports[1].visibility", Message: `"secret" is not one of private, public`},
+				{Path: "ports[2].port", Message: "3000-2000 is neither a port number nor a range like 3000-3999"},
+				{Path: "ports[3].port", Message: "abc is neither a port number nor a range like 3000-3999"},
+				{Path: "ports[3].protocol", Message: `"tcp" is not one of http, https`},
+				{Path: "ports[4].port", Message: "3000 is already configured in ports[0]"},
+				{Path: "ports[5].port", Message: "70000 is neither a port number nor a range like 3000-3999"},
+			},
+		},
+		{
+			Desc: "image and locations",
+			Config: `
+image:
+  context: .
+checkoutLocation: ../etc
+workspaceLocation: project/project.code-workspace
+`,
+			Expectation: []GitpodConfigProblem{
+				{Path: "image.file", Message: "a Dockerfile is required when the image is configured as an object"},
+				{Path: "checkoutLocation", Message: "../etc must be within /workspace"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			var config *gitpod.GitpodConfig
+			err := yaml.Unmarshal([]byte(test.Config), &config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			problems := LintGitpodConfig([]byte(test.Config), config)
+			if diff := cmp.Diff(test.Expectation, problems); diff != "" {
+				t.Errorf("unexpected problems (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
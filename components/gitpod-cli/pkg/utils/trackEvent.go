@@ -30,6 +30,7 @@ const (
 	RebuildErrorCode_DockerRunFailed     = "rebuild_docker_run_failed"
 	RebuildErrorCode_MalformedGitpodYaml = "rebuild_malformed_gitpod_yaml"
 	RebuildErrorCode_MissingGitpodYaml   = "rebuild_missing_gitpod_yaml"
+	RebuildErrorCode_InvalidGitpodYaml   = "rebuild_invalid_gitpod_yaml"
 	RebuildErrorCode_NoCustomImage       = "rebuild_no_custom_image"
 	RebuildErrorCode_AlreadyInDebug      = "rebuild_already_in_debug"
 	RebuildErrorCode_InvaligLogLevel     = "rebuild_invalid_log_level"
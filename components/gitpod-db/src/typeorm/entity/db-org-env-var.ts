@@ -0,0 +1,42 @@
+/**
+ * Copyright (c) 2024 Gitpod GmbH. All rights reserved.
+ * Licensed under the GNU Affero General Public License (AGPL).
+ * See License.AGPL.txt in the project root for license information.
+ */
+
+import { PrimaryColumn, Entity, Column } from "typeorm";
+import { TypeORM } from "../typeorm";
+import { OrgEnvVarWithValue } from "@gitpod/gitpod-protocol";
+import { Transformer } from "../transformer";
+import { getGlobalEncryptionService } from "@gitpod/gitpod-protocol/lib/encryption/encryption-service";
+
+@Entity()
+// on DB but not Typeorm: @Index("ind_lastModified", ["_lastModified"])   // DBSync
+export class DBOrgEnvVar implements OrgEnvVarWithValue {
+    @PrimaryColumn(TypeORM.UUID_COLUMN_TYPE)
+    id: string;
+
+    // `orgId` is part of the primary key for the same reason `projectId` is part of the key of project env vars:
+    // it's impossible to address the variable of another organization by its `id`.
+    @PrimaryColumn(TypeORM.UUID_COLUMN_TYPE)
+    orgId: string;
+
+    @Column()
+    name: string;
+
+    @Column({
+        type: "simple-json",
+        transformer: Transformer.compose(
+            Transformer.SIMPLE_JSON([]),
+            Transformer.encrypted(getGlobalEncryptionService),
+        ),
+    })
+    value: string;
+
+    @Column("varchar")
+    creationTime: string;
+
+    // This column triggers the periodic deleter deletion mechanism. It's not intended for public consumption.
+    @Column()
+    deleted: boolean;
+}
@@ -42,6 +42,9 @@ export class DBUserEnvVar implements UserEnvVar {
     @Column()
     repositoryPattern: string;
 
+    @Column({ default: false })
+    asFile?: boolean;
+
     @Column()
     deleted?: boolean;
 }
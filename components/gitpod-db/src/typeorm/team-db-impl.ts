@@ -6,6 +6,8 @@
 
 import {
     OrganizationSettings,
+    OrgEnvVar,
+    OrgEnvVarWithValue,
     Team,
     TeamMemberInfo,
     TeamMemberRole,
@@ -22,9 +24,17 @@ import { DBTeam } from "./entity/db-team";
 import { DBTeamMembership } from "./entity/db-team-membership";
 import { DBTeamMembershipInvite } from "./entity/db-team-membership-invite";
 import { DBOrgSettings } from "./entity/db-team-settings";
+import { DBOrgEnvVar } from "./entity/db-org-env-var";
 import { DBUser } from "./entity/db-user";
 import { TransactionalDBImpl } from "./transactional-db-impl";
 import { TypeORM } from "./typeorm";
+import { filter } from "../utils";
+
+function toOrgEnvVar(envVarWithValue: DBOrgEnvVar): OrgEnvVar {
+    const envVar = { ...envVarWithValue };
+    delete (envVar as any)["value"];
+    return envVar;
+}
 
 @injectable()
 export class TeamDBImpl extends TransactionalDBImpl<TeamDB> implements TeamDB {
@@ -52,6 +62,10 @@ export class TeamDBImpl extends TransactionalDBImpl<TeamDB> implements TeamDB {
         return (await this.getEntityManager()).getRepository<DBOrgSettings>(DBOrgSettings);
     }
 
+    private async getOrgEnvVarRepo(): Promise<Repository<DBOrgEnvVar>> {
+        return (await this.getEntityManager()).getRepository<DBOrgEnvVar>(DBOrgEnvVar);
+    }
+
     private async getUserRepo(): Promise<Repository<DBUser>> {
         return (await this.getEntityManager()).getRepository<DBUser>(DBUser);
     }
@@ -244,9 +258,15 @@ export class TeamDBImpl extends TransactionalDBImpl<TeamDB> implements TeamDB {
             team.markedDeleted = true;
             await teamRepo.save(team);
             await this.deleteOrgSettings(teamId);
+            await this.deleteOrgEnvVars(teamId);
         }
     }
 
+    private async deleteOrgEnvVars(orgId: string): Promise<void> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        await envVarRepo.delete({ orgId });
+    }
+
     private async deleteOrgSettings(orgId: string): Promise<void> {
         const orgSettingsRepo = await this.getOrgSettingsRepo();
         const orgSettings = await orgSettingsRepo.findOne({ where: { orgId } });
@@ -400,4 +420,69 @@ export class TeamDBImpl extends TransactionalDBImpl<TeamDB> implements TeamDB {
         );
         return result.length === 1;
     }
+
+    public async findOrgEnvironmentVariableByName(orgId: string, name: string): Promise<OrgEnvVar | undefined> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        const envVar = await envVarRepo.findOne({ orgId, name, deleted: false });
+        return envVar && toOrgEnvVar(envVar);
+    }
+
+    public async addOrgEnvironmentVariable(orgId: string, envVar: OrgEnvVarWithValue): Promise<OrgEnvVar> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        const insertedEnvVar = await envVarRepo.save({
+            id: uuidv4(),
+            orgId,
+            name: envVar.name,
+            value: envVar.value,
+            creationTime: new Date().toISOString(),
+            deleted: false,
+        });
+        return toOrgEnvVar(insertedEnvVar);
+    }
+
+    public async updateOrgEnvironmentVariable(
+        orgId: string,
+        envVar: Partial<OrgEnvVarWithValue>,
+    ): Promise<OrgEnvVar | undefined> {
+        if (!envVar.id) {
+            throw new ApplicationError(ErrorCodes.NOT_FOUND, "An environment variable with this ID could not be found");
+        }
+
+        return await this.transaction(async (_, ctx) => {
+            const envVarRepo = ctx.entityManager.getRepository<DBOrgEnvVar>(DBOrgEnvVar);
+
+            await envVarRepo.update(
+                { id: envVar.id, orgId },
+                filter(envVar, (_, v) => v !== null && v !== undefined),
+            );
+
+            const found = await envVarRepo.findOne({ id: envVar.id, orgId, deleted: false });
+            if (!found) {
+                return;
+            }
+            return toOrgEnvVar(found);
+        });
+    }
+
+    public async getOrgEnvironmentVariables(orgId: string): Promise<OrgEnvVar[]> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        const envVarsWithValue = await envVarRepo.find({ orgId, deleted: false });
+        return envVarsWithValue.map(toOrgEnvVar);
+    }
+
+    public async getOrgEnvironmentVariableById(variableId: string): Promise<OrgEnvVar | undefined> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        const envVarWithValue = await envVarRepo.findOne({ id: variableId, deleted: false });
+        return envVarWithValue && toOrgEnvVar(envVarWithValue);
+    }
+
+    public async deleteOrgEnvironmentVariable(variableId: string): Promise<void> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        await envVarRepo.delete({ id: variableId });
+    }
+
+    public async getOrgEnvironmentVariableValues(envVars: OrgEnvVar[]): Promise<OrgEnvVarWithValue[]> {
+        const envVarRepo = await this.getOrgEnvVarRepo();
+        return envVarRepo.findByIds(envVars);
+    }
 }
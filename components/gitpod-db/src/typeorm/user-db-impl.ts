@@ -412,6 +412,7 @@ export class TypeORMUserDBImpl extends TransactionalDBImpl<UserDB> implements Us
             name: envVar.name,
             repositoryPattern: envVar.repositoryPattern,
             value: envVar.value,
+            asFile: !!envVar.asFile,
         });
     }
 
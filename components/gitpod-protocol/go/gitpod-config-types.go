@@ -207,6 +207,12 @@ type TasksItems struct {
 	// Name of the task. Shown on the tab of the opened terminal.
 	Name string `yaml:"name,omitempty" json:"name,omitempty"`
 
+	// A shell command to run when the workspace is stopping, before its content is backed up. Use it to e.g. push work in progress or deregister from services. This command is expected to terminate and is killed once `onStopTimeout` is exceeded.
+	OnStop string `yaml:"onStop,omitempty" json:"onStop,omitempty"`
+
+	// The maximum duration the `onStop` command may run, e.g. '30s'. Defaults to 10s, can be at most 5m.
+	OnStopTimeout string `yaml:"onStopTimeout,omitempty" json:"onStopTimeout,omitempty"`
+
 	// The panel/area where to open the terminal. Default is 'bottom' panel.
 	OpenIn string `yaml:"openIn,omitempty" json:"openIn,omitempty"`
 
@@ -1882,14 +1882,16 @@ type PortConfig struct {
 
 // TaskConfig is the TaskConfig message type
 type TaskConfig struct {
-	Before   string                 `json:"before,omitempty"`
-	Command  string                 `json:"command,omitempty"`
-	Env      map[string]interface{} `json:"env,omitempty"`
-	Init     string                 `json:"init,omitempty"`
-	Name     string                 `json:"name,omitempty"`
-	OpenIn   string                 `json:"openIn,omitempty"`
-	OpenMode string                 `json:"openMode,omitempty"`
-	Prebuild string                 `json:"prebuild,omitempty"`
+	Before        string                 `json:"before,omitempty"`
+	Command       string                 `json:"command,omitempty"`
+	Env           map[string]interface{} `json:"env,omitempty"`
+	Init          string                 `json:"init,omitempty"`
+	Name          string                 `json:"name,omitempty"`
+	OnStop        string                 `json:"onStop,omitempty"`
+	OnStopTimeout string                 `json:"onStopTimeout,omitempty"`
+	OpenIn        string                 `json:"openIn,omitempty"`
+	OpenMode      string                 `json:"openMode,omitempty"`
+	Prebuild      string                 `json:"prebuild,omitempty"`
 }
 
 // VSCodeConfig is the VSCodeConfig message type
@@ -1916,6 +1918,7 @@ type UserEnvVarValue struct {
 	Name              string `json:"name,omitempty"`
 	RepositoryPattern string `json:"repositoryPattern,omitempty"`
 	Value             string `json:"value,omitempty"`
+	AsFile            bool   `json:"asFile"`
 }
 
 type SSHPublicKeyValue struct {
@@ -21,6 +21,7 @@ import {
     GuessGitTokenScopesParams,
     GuessedGitTokenScopes,
     ProjectEnvVar,
+    OrgEnvVar,
     PrebuiltWorkspace,
     UserSSHPublicKeyValue,
     SSHPublicKeyValue,
@@ -178,6 +179,9 @@ export interface GitpodServer extends JsonRpcServer<GitpodClient>, AdminServer,
     deleteTeam(teamId: string): Promise<void>;
     getOrgSettings(orgId: string): Promise<OrganizationSettings>;
     updateOrgSettings(teamId: string, settings: Partial<OrganizationSettings>): Promise<OrganizationSettings>;
+    setOrgEnvironmentVariable(orgId: string, name: string, value: string): Promise<void>;
+    getOrgEnvironmentVariables(orgId: string): Promise<OrgEnvVar[]>;
+    deleteOrgEnvironmentVariable(variableId: string): Promise<void>;
     getOrgWorkspaceClasses(orgId: string): Promise<SupportedWorkspaceClass[]>;
 
     getDefaultWorkspaceImage(params: GetDefaultWorkspaceImageParams): Promise<GetDefaultWorkspaceImageResult>;
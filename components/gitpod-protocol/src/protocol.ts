@@ -129,6 +129,8 @@ export interface AdditionalUserData extends Partial<WorkspaceTimeoutSetting> {
     knownGitHubOrgs?: string[];
     // Git clone URL pointing to the user's dotfile repo
     dotfileRepo?: string;
+    // whether the user's local SSH agent is forwarded into SSH sessions of their workspaces
+    sshAgentForwarding?: boolean;
     // preferred workspace classes
     workspaceClasses?: WorkspaceClasses;
     // additional user profile data
@@ -279,9 +281,24 @@ export interface ProjectEnvVar extends Omit<ProjectEnvVarWithValue, "value"> {
     projectId: string;
 }
 
+/**
+ * Environment variables of an organization are never exposed to workspaces. The server uses them on behalf of
+ * all projects of the organization, e.g. GITPOD_IMAGE_AUTH provides the credentials for private base images.
+ */
+export interface OrgEnvVarWithValue extends EnvVarWithValue {
+    id?: string;
+}
+
+export interface OrgEnvVar extends Omit<OrgEnvVarWithValue, "value"> {
+    id: string;
+    orgId: string;
+}
+
 export interface UserEnvVarValue extends EnvVarWithValue {
     id?: string;
     repositoryPattern: string; // DEPRECATED: Use ProjectEnvVar instead of repositoryPattern - https://github.com/gitpod-com/gitpod/issues/5322
+    // asFile passes the variable to workspace processes as a file readable only by the gitpod user, see SUPERVISOR_FILE_SECRETS
+    asFile?: boolean;
 }
 export interface UserEnvVar extends UserEnvVarValue {
     id: string;
@@ -978,6 +995,8 @@ export interface TaskConfig {
     env?: { [env: string]: any };
     openIn?: "bottom" | "main" | "left" | "right";
     openMode?: "split-top" | "split-left" | "split-right" | "split-bottom" | "tab-before" | "tab-after";
+    onStop?: string;
+    onStopTimeout?: string;
 }
 
 export namespace TaskConfig {
@@ -27,6 +27,11 @@ export interface ProjectSettings {
     restrictedWorkspaceClasses?: string[];
 
     restrictedEditorNames?: string[];
+
+    /**
+     * Disables reusing the layers of previous image builds of this project, e.g. when a build depends on content which changes without the Dockerfile changing.
+     */
+    disableImageBuildCache?: boolean;
 }
 export namespace PrebuildSettings {
     export type BranchStrategy = "default-branch" | "all-branches" | "matched-branches";
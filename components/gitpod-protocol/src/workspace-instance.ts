@@ -314,6 +314,8 @@ export interface WorkspaceInstanceConfiguration {
  */
 export interface ImageBuildInfo {
     log?: ImageBuildLogInfo;
+    /** the ID of the image build, which lets us cancel it */
+    buildId?: string;
 }
 
 /**
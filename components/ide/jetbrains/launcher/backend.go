@@ -0,0 +1,334 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	supervisor "github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+const (
+	// backendRestartInitialDelay is how long the launcher waits before restarting a backend which stopped,
+	// the delay doubles with every restart up to backendRestartMaxDelay.
+	backendRestartInitialDelay = 1 * time.Second
+	backendRestartMaxDelay     = 1 * time.Minute
+	// backendStableUptime is how long a backend has to run before its restart delay is reset.
+	backendStableUptime = 5 * time.Minute
+	// backendMonitorInterval is how often the launcher checks the readiness and memory of the backend.
+	backendMonitorInterval = 10 * time.Second
+)
+
+// restartBackoff computes the delay before the backend is restarted.
+type restartBackoff struct {
+	delay time.Duration
+}
+
+// Next returns the delay before restarting a backend which ran for uptime.
+func (b *restartBackoff) Next(uptime time.Duration) time.Duration {
+	if b.delay == 0 || uptime >= backendStableUptime {
+		b.delay = backendRestartInitialDelay
+		return b.delay
+	}
+	b.delay *= 2
+	if b.delay > backendRestartMaxDelay {
+		b.delay = backendRestartMaxDelay
+	}
+	return b.delay
+}
+
+// backendMonitor keeps the backend running, restarting it whenever it stops, and reports its state to supervisor.
+type backendMonitor struct {
+	backendPort string
+	newCmd      func() *exec.Cmd
+	report      func(*supervisor.IDEBackendStatus)
+
+	mu     sync.Mutex
+	status *supervisor.IDEBackendStatus
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newBackendMonitor(launchCtx *LaunchContext, newCmd func() *exec.Cmd) *backendMonitor {
+	var maxHeapBytes uint64
+	options, err := readVMOptions(launchCtx.vmOptionsFile)
+	if err != nil {
+		log.WithError(err).Warn("cannot resolve max heap size of the backend")
+	} else {
+		maxHeapBytes = resolveMaxHeapBytes(options)
+	}
+	return &backendMonitor{
+		backendPort: defaultBackendPort,
+		newCmd:      newCmd,
+		report:      reportBackendStatus,
+		status: &supervisor.IDEBackendStatus{
+			Kind:         launchCtx.alias,
+			State:        supervisor.IDEBackendState_backend_starting,
+			MaxHeapBytes: maxHeapBytes,
+		},
+		stop: make(chan struct{}),
+	}
+}
+
+// Stop prevents the backend from being restarted once it stops.
+func (m *backendMonitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+func (m *backendMonitor) isStopped() bool {
+	select {
+	case <-m.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run starts the backend and restarts it with backoff until Stop is called. It returns the exit code of the last backend.
+func (m *backendMonitor) Run() int {
+	var backoff restartBackoff
+	for {
+		cmd := m.newCmd()
+		started := time.Now()
+		m.update(func(status *supervisor.IDEBackendStatus) {
+			status.State = supervisor.IDEBackendState_backend_starting
+		})
+		err := cmd.Start()
+		if err != nil {
+			log.WithError(err).Error("failed to start")
+		} else {
+			ctx, cancel := context.WithCancel(context.Background())
+			go m.watch(ctx, cmd.Process.Pid)
+			err = cmd.Wait()
+			cancel()
+			if err != nil {
+				log.WithError(err).Error("failed to wait")
+			}
+		}
+
+		exitCode := 1
+		if cmd.ProcessState != nil {
+			exitCode = cmd.ProcessState.ExitCode()
+		}
+		if m.isStopped() {
+			log.Info("IDE stopped, exiting")
+			return exitCode
+		}
+
+		failure := describeBackendExit(cmd.ProcessState, err)
+		delay := backoff.Next(time.Since(started))
+		log.WithField("failure", failure).WithField("delay", delay.String()).Warn("IDE stopped, restarting")
+		m.update(func(status *supervisor.IDEBackendStatus) {
+			status.State = supervisor.IDEBackendState_backend_restarting
+			status.Restarts++
+			status.Failure = failure
+			status.MemoryBytes = 0
+		})
+
+		select {
+		case <-time.After(delay):
+		case <-m.stop:
+			log.Info("IDE stopped, exiting")
+			return exitCode
+		}
+	}
+}
+
+// watch periodically checks whether the backend accepts connections and how much memory it uses.
+func (m *backendMonitor) watch(ctx context.Context, pid int) {
+	ticker := time.NewTicker(backendMonitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := resolveJsonLink2(m.backendPort)
+		running := err == nil
+		memory, err := readProcessTreeRSS("/proc", pid)
+		if err != nil {
+			log.WithError(err).Debug("cannot read memory of the backend")
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		m.update(func(status *supervisor.IDEBackendStatus) {
+			if running {
+				status.State = supervisor.IDEBackendState_backend_running
+			}
+			status.MemoryBytes = memory
+		})
+	}
+}
+
+func (m *backendMonitor) update(change func(status *supervisor.IDEBackendStatus)) {
+	m.mu.Lock()
+	change(m.status)
+	status := proto.Clone(m.status).(*supervisor.IDEBackendStatus)
+	m.mu.Unlock()
+
+	m.report(status)
+}
+
+// reportBackendStatus lets supervisor know about the state of the backend.
+func reportBackendStatus(status *supervisor.IDEBackendStatus) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	supervisorConn, err := grpc.DialContext(ctx, util.GetSupervisorAddress(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.WithError(err).Debug("dial supervisor failed")
+		return
+	}
+	defer supervisorConn.Close()
+	_, err = supervisor.NewStatusServiceClient(supervisorConn).UpdateIDEBackendStatus(ctx, &supervisor.UpdateIDEBackendStatusRequest{Backend: status})
+	if err != nil {
+		log.WithError(err).Debug("cannot report backend status")
+	}
+}
+
+// describeBackendExit explains why the backend stopped.
+func describeBackendExit(state *os.ProcessState, err error) string {
+	if state == nil {
+		return fmt.Sprintf("backend failed to start: %v", err)
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		if ws.Signal() == syscall.SIGKILL {
+			return "backend was killed, e.g. because it ran out of memory"
+		}
+		return fmt.Sprintf("backend was terminated by signal %s", ws.Signal())
+	}
+	return fmt.Sprintf("backend exited with code %d", state.ExitCode())
+}
+
+// resolveMaxHeapBytes returns the max heap size configured by the last -Xmx option, 0 if there is none.
+func resolveMaxHeapBytes(vmoptions []string) uint64 {
+	var res uint64
+	for _, option := range vmoptions {
+		size, ok := strings.CutPrefix(option, "-Xmx")
+		if !ok {
+			continue
+		}
+		bytes, err := parseJavaMemorySize(size)
+		if err != nil {
+			log.WithError(err).WithField("option", option).Warn("cannot parse max heap size")
+			continue
+		}
+		res = bytes
+	}
+	return res
+}
+
+// parseJavaMemorySize parses sizes as accepted by the JVM, e.g. 2048m or 3g.
+func parseJavaMemorySize(size string) (uint64, error) {
+	if size == "" {
+		return 0, errors.New("empty size")
+	}
+	var unit uint64 = 1
+	switch size[len(size)-1] {
+	case 'k', 'K':
+		unit = 1 << 10
+	case 'm', 'M':
+		unit = 1 << 20
+	case 'g', 'G':
+		unit = 1 << 30
+	case 't', 'T':
+		unit = 1 << 40
+	}
+	if unit != 1 {
+		size = size[:len(size)-1]
+	}
+	value, err := strconv.ParseUint(size, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value * unit, nil
+}
+
+// readProcessTreeRSS sums up the resident memory of a process and all its descendants.
+func readProcessTreeRSS(procDir string, pid int) (uint64, error) {
+	stats, err := filepath.Glob(filepath.Join(procDir, "[0-9]*", "stat"))
+	if err != nil {
+		return 0, err
+	}
+	var (
+		children = make(map[int][]int)
+		rss      = make(map[int]uint64)
+	)
+	for _, stat := range stats {
+		content, err := os.ReadFile(stat)
+		if err != nil {
+			// the process terminated in the meantime
+			continue
+		}
+		p, ppid, pages, err := parseProcStat(string(content))
+		if err != nil {
+			log.WithError(err).WithField("stat", stat).Debug("cannot parse process stat")
+			continue
+		}
+		children[ppid] = append(children[ppid], p)
+		rss[p] = pages
+	}
+	if _, ok := rss[pid]; !ok {
+		return 0, fmt.Errorf("process %d not found", pid)
+	}
+
+	pageSize := uint64(os.Getpagesize())
+	var res uint64
+	queue := []int{pid}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = append(queue[1:], children[p]...)
+		res += rss[p] * pageSize
+	}
+	return res, nil
+}
+
+// parseProcStat returns the pid, parent pid and resident pages of a /proc/<pid>/stat file.
+func parseProcStat(stat string) (pid, ppid int, rss uint64, err error) {
+	// the command name can contain spaces and parentheses, hence the fields are split around its last closing parenthesis
+	start := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		err = errors.New("invalid stat format")
+		return
+	}
+	pid, err = strconv.Atoi(strings.TrimSpace(stat[:start]))
+	if err != nil {
+		return
+	}
+	// fields after the command name start with the state (3rd field), rss is the 24th field
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		err = errors.New("invalid stat format")
+		return
+	}
+	ppid, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return
+	}
+	rss, err = strconv.ParseUint(fields[21], 10, 64)
+	return
+}
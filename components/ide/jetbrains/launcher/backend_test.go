@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	var backoff restartBackoff
+	var actual []time.Duration
+	for i := 0; i < 8; i++ {
+		actual = append(actual, backoff.Next(time.Second))
+	}
+	actual = append(actual, backoff.Next(backendStableUptime))
+
+	expectation := []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		32 * time.Second,
+		1 * time.Minute,
+		1 * time.Minute,
+		1 * time.Second,
+	}
+	if diff := cmp.Diff(expectation, actual); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveMaxHeapBytes(t *testing.T) {
+	tests := []struct {
+		Desc        string
+		VMOptions   []string
+		Expectation uint64
+	}{
+		{"no -Xmx", []string{"-Xms128m", "-Dsun.tools.attach.tmp.only=true"}, 0},
+		{"megabytes", []string{"-Xms128m", "-Xmx2048m"}, 2048 << 20},
+		{"gigabytes", []string{"-Xmx3G"}, 3 << 30},
+		{"bytes", []string{"-Xmx1073741824"}, 1 << 30},
+		{"last one wins", []string{"-Xmx750m", "-Xmx4g"}, 4 << 30},
+		{"invalid size is ignored", []string{"-Xmx2g", "-Xmxlots"}, 2 << 30},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			assert.Equal(t, test.Expectation, resolveMaxHeapBytes(test.VMOptions))
+		})
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	pid, ppid, rss, err := parseProcStat("4242 (java) (x) S 4200 4200 4200 0 -1 4194560 1 0 0 0 5 3 0 0 20 0 42 0 100 3000000000 51200 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4242, pid)
+	assert.Equal(t, 4200, ppid)
+	assert.Equal(t, uint64(51200), rss)
+
+	_, _, _, err = parseProcStat("4242 java S 4200")
+	assert.Error(t, err)
+}
+
+func TestReadProcessTreeRSS(t *testing.T) {
+	rss, err := readProcessTreeRSS("/proc", os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotZero(t, rss)
+
+	_, err = readProcessTreeRSS(t.TempDir(), os.Getpid())
+	assert.Error(t, err)
+}
@@ -560,27 +560,41 @@ func run(launchCtx *LaunchContext) {
 	}
 	args = append(args, launchCtx.projectContextDir)
 
-	cmd := remoteDevServerCmd(args, launchCtx)
-	cmd.Env = append(cmd.Env, "JETBRAINS_GITPOD_BACKEND_KIND="+launchCtx.alias)
-	workspaceUrl, err := url.Parse(launchCtx.wsInfo.WorkspaceUrl)
-	if err == nil {
-		cmd.Env = append(cmd.Env, "JETBRAINS_GITPOD_WORKSPACE_HOST="+workspaceUrl.Hostname())
+	newCmd := func() *exec.Cmd {
+		cmd := remoteDevServerCmd(args, launchCtx)
+		cmd.Env = append(cmd.Env, "JETBRAINS_GITPOD_BACKEND_KIND="+launchCtx.alias)
+		workspaceUrl, err := url.Parse(launchCtx.wsInfo.WorkspaceUrl)
+		if err == nil {
+			cmd.Env = append(cmd.Env, "JETBRAINS_GITPOD_WORKSPACE_HOST="+workspaceUrl.Hostname())
+		}
+		// Enable host status endpoint
+		cmd.Env = append(cmd.Env, "CWM_HOST_STATUS_OVER_HTTP_TOKEN=gitpod")
+		return cmd
 	}
-	// Enable host status endpoint
-	cmd.Env = append(cmd.Env, "CWM_HOST_STATUS_OVER_HTTP_TOKEN=gitpod")
 
-	if err := cmd.Start(); err != nil {
-		log.WithError(err).Error("failed to start")
+	if launchCtx.warmup {
+		cmd := newCmd()
+		if err := cmd.Start(); err != nil {
+			log.WithError(err).Error("failed to start")
+		}
+
+		// Nicely handle SIGTERM sinal
+		go handleSignal(nil)
+
+		if err := cmd.Wait(); err != nil {
+			log.WithError(err).Error("failed to wait")
+		}
+		log.Info("IDE stopped, exiting")
+		os.Exit(cmd.ProcessState.ExitCode())
 	}
 
+	// keep the backend warm: restart it whenever it stops instead of relaunching everything
+	monitor := newBackendMonitor(launchCtx, newCmd)
+
 	// Nicely handle SIGTERM sinal
-	go handleSignal()
+	go handleSignal(monitor)
 
-	if err := cmd.Wait(); err != nil {
-		log.WithError(err).Error("failed to wait")
-	}
-	log.Info("IDE stopped, exiting")
-	os.Exit(cmd.ProcessState.ExitCode())
+	os.Exit(monitor.Run())
 }
 
 // resolveUserEnvs emulats the interactive login shell to ensure that all user defined shell scripts are loaded
@@ -667,11 +681,14 @@ func remoteDevServerCmd(args []string, launchCtx *LaunchContext) *exec.Cmd {
 	return cmd
 }
 
-func handleSignal() {
+func handleSignal(monitor *backendMonitor) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	<-sigChan
+	if monitor != nil {
+		monitor.Stop()
+	}
 	log.WithField("port", defaultBackendPort).Info("receive SIGTERM signal, terminating IDE")
 	if err := terminateIDE(defaultBackendPort); err != nil {
 		log.WithError(err).Error("failed to terminate IDE")
@@ -6,6 +6,7 @@ package config
 
 import (
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
+	"github.com/gitpod-io/gitpod/common-go/util"
 )
 
 type ServiceConfig struct {
@@ -47,10 +48,68 @@ type Configuration struct {
 	// trying to access.
 	EnableAdditionalECRAuth bool `json:"enableAdditionalECRAuth"`
 
+	// CredentialHelpers lists the credential helpers which provide short-lived credentials for the registries of
+	// cloud providers using the cloud identity of image-builder, i.e. ecr, gcr or acr. Their credentials are refreshed
+	// before they expire, and are used to pull base images as well as to push the images we build.
+	// EnableAdditionalECRAuth is the same as listing ecr.
+	CredentialHelpers []string `json:"credentialHelpers,omitempty"`
+
+	// CredentialHelperRepositories are the registries, e.g. eu.gcr.io, or repositories, e.g. europe-docker.pkg.dev/project/images,
+	// of the images the credential helpers provide credentials for, in addition to the base image, workspace image and build cache
+	// repositories. As the credentials grant access to everything the cloud identity of image-builder has access to, users can
+	// use images of these repositories in their builds.
+	CredentialHelperRepositories []string `json:"credentialHelperRepositories,omitempty"`
+
 	// SubassemblyBucketName configures the subassembly bucket
 	SubassemblyBucketName string `json:"subassemblyBucketName,omitempty"`
 	// SubassemblyBucketPrefix configures an optional key prefix used for locating subassemblies in the bucket
 	SubassemblyBucketPrefix string `json:"subassemblyBucketPrefix,omitempty"`
+
+	// BuildCache configures the registry cache image builds import layers from and export their layers to
+	BuildCache BuildCacheConfig `json:"buildCache,omitempty"`
+
+	// Queue limits the number of builds which run at the same time. Builds beyond the limits wait until they can start.
+	Queue QueueConfig `json:"queue,omitempty"`
+
+	// BuildLogs configures where the logs of builds are stored once the builds are done
+	BuildLogs BuildLogsConfig `json:"buildLogs,omitempty"`
+
+	// Platforms lists the platforms workspace images are built for, e.g. linux/amd64 and linux/arm64.
+	// Defaults to linux/amd64. With more than one platform builds publish multi-arch images, and BuildKit
+	// emulates the foreign architectures. This requires QEMU to be registered with binfmt_misc on the workspace nodes.
+	Platforms []string `json:"platforms,omitempty"`
+
+	// BuildTimeout limits how long a build may run once it started. Requests can ask for a shorter timeout,
+	// but never for a longer one. Defaults to one hour.
+	BuildTimeout util.Duration `json:"buildTimeout,omitempty"`
+}
+
+// BuildLogsConfig configures the storage of build logs. While a build runs its log is kept in a temporary file,
+// and uploaded to content-service once the build is done.
+type BuildLogsConfig struct {
+	// ContentServiceAddress is the address of the content-service which stores build logs.
+	// Build logs are not stored if no address is configured.
+	ContentServiceAddress string `json:"contentServiceAddress,omitempty"`
+}
+
+// QueueConfig limits concurrent builds. Waiting builds start in turns across organizations, such that one
+// organization which triggers many builds does not hold up the builds of others. Zero means no limit.
+type QueueConfig struct {
+	// MaxConcurrentBuilds is the number of builds which run at the same time
+	MaxConcurrentBuilds int `json:"maxConcurrentBuilds,omitempty"`
+
+	// MaxConcurrentBuildsPerOrganization is the number of builds of one organization which run at the same time
+	MaxConcurrentBuildsPerOrganization int `json:"maxConcurrentBuildsPerOrganization,omitempty"`
+}
+
+// BuildCacheConfig configures the registry cache of image builds. Builds share their cache by the scope of
+// their request, e.g. their project, and builds without scope never use the cache.
+type BuildCacheConfig struct {
+	// Enabled makes builds import and export the layers of previous builds of their scope
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Repository is where the cache is pushed to. Defaults to the BaseImageRepository.
+	Repository string `json:"repository,omitempty"`
 }
 
 type TLS struct {
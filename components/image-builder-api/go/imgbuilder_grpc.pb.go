@@ -37,6 +37,12 @@ type ImageBuilderClient interface {
 	Logs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (ImageBuilder_LogsClient, error)
 	// ListBuilds returns a list of currently running builds
 	ListBuilds(ctx context.Context, in *ListBuildsRequest, opts ...grpc.CallOption) (*ListBuildsResponse, error)
+	// BuildLogs streams the log output of a build identified by its build ID. Ongoing builds stream their
+	// output as it is produced, finished builds stream the log which was stored once they were done.
+	BuildLogs(ctx context.Context, in *BuildLogsRequest, opts ...grpc.CallOption) (ImageBuilder_BuildLogsClient, error)
+	// CancelBuild stops a queued or running build identified by its build ID or the ref of the image it builds.
+	// Clients waiting for the build receive a failed build status.
+	CancelBuild(ctx context.Context, in *CancelBuildRequest, opts ...grpc.CallOption) (*CancelBuildResponse, error)
 }
 
 type imageBuilderClient struct {
@@ -138,6 +144,47 @@ func (c *imageBuilderClient) ListBuilds(ctx context.Context, in *ListBuildsReque
 	return out, nil
 }
 
+func (c *imageBuilderClient) BuildLogs(ctx context.Context, in *BuildLogsRequest, opts ...grpc.CallOption) (ImageBuilder_BuildLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ImageBuilder_ServiceDesc.Streams[2], "/builder.ImageBuilder/BuildLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &imageBuilderBuildLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ImageBuilder_BuildLogsClient interface {
+	Recv() (*LogsResponse, error)
+	grpc.ClientStream
+}
+
+type imageBuilderBuildLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *imageBuilderBuildLogsClient) Recv() (*LogsResponse, error) {
+	m := new(LogsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *imageBuilderClient) CancelBuild(ctx context.Context, in *CancelBuildRequest, opts ...grpc.CallOption) (*CancelBuildResponse, error) {
+	out := new(CancelBuildResponse)
+	err := c.cc.Invoke(ctx, "/builder.ImageBuilder/CancelBuild", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ImageBuilderServer is the server API for ImageBuilder service.
 // All implementations must embed UnimplementedImageBuilderServer
 // for forward compatibility
@@ -153,6 +200,12 @@ type ImageBuilderServer interface {
 	Logs(*LogsRequest, ImageBuilder_LogsServer) error
 	// ListBuilds returns a list of currently running builds
 	ListBuilds(context.Context, *ListBuildsRequest) (*ListBuildsResponse, error)
+	// BuildLogs streams the log output of a build identified by its build ID. Ongoing builds stream their
+	// output as it is produced, finished builds stream the log which was stored once they were done.
+	BuildLogs(*BuildLogsRequest, ImageBuilder_BuildLogsServer) error
+	// CancelBuild stops a queued or running build identified by its build ID or the ref of the image it builds.
+	// Clients waiting for the build receive a failed build status.
+	CancelBuild(context.Context, *CancelBuildRequest) (*CancelBuildResponse, error)
 	mustEmbedUnimplementedImageBuilderServer()
 }
 
@@ -175,6 +228,12 @@ func (UnimplementedImageBuilderServer) Logs(*LogsRequest, ImageBuilder_LogsServe
 func (UnimplementedImageBuilderServer) ListBuilds(context.Context, *ListBuildsRequest) (*ListBuildsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListBuilds not implemented")
 }
+func (UnimplementedImageBuilderServer) BuildLogs(*BuildLogsRequest, ImageBuilder_BuildLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method BuildLogs not implemented")
+}
+func (UnimplementedImageBuilderServer) CancelBuild(context.Context, *CancelBuildRequest) (*CancelBuildResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelBuild not implemented")
+}
 func (UnimplementedImageBuilderServer) mustEmbedUnimplementedImageBuilderServer() {}
 
 // UnsafeImageBuilderServer may be embedded to opt out of forward compatibility for this service.
@@ -284,6 +343,45 @@ func _ImageBuilder_ListBuilds_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImageBuilder_BuildLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BuildLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ImageBuilderServer).BuildLogs(m, &imageBuilderBuildLogsServer{stream})
+}
+
+type ImageBuilder_BuildLogsServer interface {
+	Send(*LogsResponse) error
+	grpc.ServerStream
+}
+
+type imageBuilderBuildLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *imageBuilderBuildLogsServer) Send(m *LogsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ImageBuilder_CancelBuild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelBuildRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageBuilderServer).CancelBuild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/builder.ImageBuilder/CancelBuild",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageBuilderServer).CancelBuild(ctx, req.(*CancelBuildRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ImageBuilder_ServiceDesc is the grpc.ServiceDesc for ImageBuilder service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -303,6 +401,10 @@ var ImageBuilder_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListBuilds",
 			Handler:    _ImageBuilder_ListBuilds_Handler,
 		},
+		{
+			MethodName: "CancelBuild",
+			Handler:    _ImageBuilder_CancelBuild_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -315,6 +417,11 @@ var ImageBuilder_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ImageBuilder_Logs_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "BuildLogs",
+			Handler:       _ImageBuilder_BuildLogs_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "imgbuilder.proto",
 }
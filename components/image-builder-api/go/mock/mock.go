@@ -3,7 +3,7 @@
 // See License.AGPL.txt in the project root for license information.
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/gitpod-io/gitpod/image-builder/api (interfaces: ImageBuilderClient,ImageBuilder_BuildClient,ImageBuilder_LogsClient,ImageBuilderServer,ImageBuilder_BuildServer,ImageBuilder_LogsServer)
+// Source: github.com/gitpod-io/gitpod/image-builder/api (interfaces: ImageBuilderClient,ImageBuilder_BuildClient,ImageBuilder_LogsClient,ImageBuilder_BuildLogsClient,ImageBuilderServer,ImageBuilder_BuildServer,ImageBuilder_LogsServer,ImageBuilder_BuildLogsServer)
 
 // Package mock is a generated GoMock package.
 package mock
@@ -61,6 +61,46 @@ func (mr *MockImageBuilderClientMockRecorder) Build(arg0, arg1 interface{}, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockImageBuilderClient)(nil).Build), varargs...)
 }
 
+// BuildLogs mocks base method.
+func (m *MockImageBuilderClient) BuildLogs(arg0 context.Context, arg1 *api.BuildLogsRequest, arg2 ...grpc.CallOption) (api.ImageBuilder_BuildLogsClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BuildLogs", varargs...)
+	ret0, _ := ret[0].(api.ImageBuilder_BuildLogsClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildLogs indicates an expected call of BuildLogs.
+func (mr *MockImageBuilderClientMockRecorder) BuildLogs(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildLogs", reflect.TypeOf((*MockImageBuilderClient)(nil).BuildLogs), varargs...)
+}
+
+// CancelBuild mocks base method.
+func (m *MockImageBuilderClient) CancelBuild(arg0 context.Context, arg1 *api.CancelBuildRequest, arg2 ...grpc.CallOption) (*api.CancelBuildResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CancelBuild", varargs...)
+	ret0, _ := ret[0].(*api.CancelBuildResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelBuild indicates an expected call of CancelBuild.
+func (mr *MockImageBuilderClientMockRecorder) CancelBuild(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelBuild", reflect.TypeOf((*MockImageBuilderClient)(nil).CancelBuild), varargs...)
+}
+
 // ListBuilds mocks base method.
 func (m *MockImageBuilderClient) ListBuilds(arg0 context.Context, arg1 *api.ListBuildsRequest, arg2 ...grpc.CallOption) (*api.ListBuildsResponse, error) {
 	m.ctrl.T.Helper()
@@ -387,6 +427,129 @@ func (mr *MockImageBuilder_LogsClientMockRecorder) Trailer() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockImageBuilder_LogsClient)(nil).Trailer))
 }
 
+// MockImageBuilder_BuildLogsClient is a mock of ImageBuilder_BuildLogsClient interface.
+type MockImageBuilder_BuildLogsClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageBuilder_BuildLogsClientMockRecorder
+}
+
+// MockImageBuilder_BuildLogsClientMockRecorder is the mock recorder for MockImageBuilder_BuildLogsClient.
+type MockImageBuilder_BuildLogsClientMockRecorder struct {
+	mock *MockImageBuilder_BuildLogsClient
+}
+
+// NewMockImageBuilder_BuildLogsClient creates a new mock instance.
+func NewMockImageBuilder_BuildLogsClient(ctrl *gomock.Controller) *MockImageBuilder_BuildLogsClient {
+	mock := &MockImageBuilder_BuildLogsClient{ctrl: ctrl}
+	mock.recorder = &MockImageBuilder_BuildLogsClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImageBuilder_BuildLogsClient) EXPECT() *MockImageBuilder_BuildLogsClientMockRecorder {
+	return m.recorder
+}
+
+// CloseSend mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).CloseSend))
+}
+
+// Context mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).Context))
+}
+
+// Header mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).Header))
+}
+
+// Recv mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) Recv() (*api.LogsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*api.LogsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).Recv))
+}
+
+// RecvMsg mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) RecvMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecvMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) RecvMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).RecvMsg), arg0)
+}
+
+// SendMsg mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) SendMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) SendMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).SendMsg), arg0)
+}
+
+// Trailer mocks base method.
+func (m *MockImageBuilder_BuildLogsClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer.
+func (mr *MockImageBuilder_BuildLogsClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockImageBuilder_BuildLogsClient)(nil).Trailer))
+}
+
 // MockImageBuilderServer is a mock of ImageBuilderServer interface.
 type MockImageBuilderServer struct {
 	ctrl     *gomock.Controller
@@ -424,6 +587,35 @@ func (mr *MockImageBuilderServerMockRecorder) Build(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockImageBuilderServer)(nil).Build), arg0, arg1)
 }
 
+// BuildLogs mocks base method.
+func (m *MockImageBuilderServer) BuildLogs(arg0 *api.BuildLogsRequest, arg1 api.ImageBuilder_BuildLogsServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildLogs", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BuildLogs indicates an expected call of BuildLogs.
+func (mr *MockImageBuilderServerMockRecorder) BuildLogs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildLogs", reflect.TypeOf((*MockImageBuilderServer)(nil).BuildLogs), arg0, arg1)
+}
+
+// CancelBuild mocks base method.
+func (m *MockImageBuilderServer) CancelBuild(arg0 context.Context, arg1 *api.CancelBuildRequest) (*api.CancelBuildResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelBuild", arg0, arg1)
+	ret0, _ := ret[0].(*api.CancelBuildResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelBuild indicates an expected call of CancelBuild.
+func (mr *MockImageBuilderServerMockRecorder) CancelBuild(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelBuild", reflect.TypeOf((*MockImageBuilderServer)(nil).CancelBuild), arg0, arg1)
+}
+
 // ListBuilds mocks base method.
 func (m *MockImageBuilderServer) ListBuilds(arg0 context.Context, arg1 *api.ListBuildsRequest) (*api.ListBuildsResponse, error) {
 	m.ctrl.T.Helper()
@@ -732,3 +924,122 @@ func (mr *MockImageBuilder_LogsServerMockRecorder) SetTrailer(arg0 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockImageBuilder_LogsServer)(nil).SetTrailer), arg0)
 }
+
+// MockImageBuilder_BuildLogsServer is a mock of ImageBuilder_BuildLogsServer interface.
+type MockImageBuilder_BuildLogsServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageBuilder_BuildLogsServerMockRecorder
+}
+
+// MockImageBuilder_BuildLogsServerMockRecorder is the mock recorder for MockImageBuilder_BuildLogsServer.
+type MockImageBuilder_BuildLogsServerMockRecorder struct {
+	mock *MockImageBuilder_BuildLogsServer
+}
+
+// NewMockImageBuilder_BuildLogsServer creates a new mock instance.
+func NewMockImageBuilder_BuildLogsServer(ctrl *gomock.Controller) *MockImageBuilder_BuildLogsServer {
+	mock := &MockImageBuilder_BuildLogsServer{ctrl: ctrl}
+	mock.recorder = &MockImageBuilder_BuildLogsServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImageBuilder_BuildLogsServer) EXPECT() *MockImageBuilder_BuildLogsServerMockRecorder {
+	return m.recorder
+}
+
+// Context mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).Context))
+}
+
+// RecvMsg mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) RecvMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecvMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) RecvMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).RecvMsg), arg0)
+}
+
+// Send mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) Send(arg0 *api.LogsResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).Send), arg0)
+}
+
+// SendHeader mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).SendHeader), arg0)
+}
+
+// SendMsg mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) SendMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) SendMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).SendMsg), arg0)
+}
+
+// SetHeader mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).SetHeader), arg0)
+}
+
+// SetTrailer mocks base method.
+func (m *MockImageBuilder_BuildLogsServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer.
+func (mr *MockImageBuilder_BuildLogsServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockImageBuilder_BuildLogsServer)(nil).SetTrailer), arg0)
+}
@@ -20,6 +20,8 @@ interface IImageBuilderService extends grpc.ServiceDefinition<grpc.UntypedServic
     build: IImageBuilderService_IBuild;
     logs: IImageBuilderService_ILogs;
     listBuilds: IImageBuilderService_IListBuilds;
+    buildLogs: IImageBuilderService_IBuildLogs;
+    cancelBuild: IImageBuilderService_ICancelBuild;
 }
 
 interface IImageBuilderService_IResolveBaseImage extends grpc.MethodDefinition<imgbuilder_pb.ResolveBaseImageRequest, imgbuilder_pb.ResolveBaseImageResponse> {
@@ -67,6 +69,24 @@ interface IImageBuilderService_IListBuilds extends grpc.MethodDefinition<imgbuil
     responseSerialize: grpc.serialize<imgbuilder_pb.ListBuildsResponse>;
     responseDeserialize: grpc.deserialize<imgbuilder_pb.ListBuildsResponse>;
 }
+interface IImageBuilderService_IBuildLogs extends grpc.MethodDefinition<imgbuilder_pb.BuildLogsRequest, imgbuilder_pb.LogsResponse> {
+    path: "/builder.ImageBuilder/BuildLogs";
+    requestStream: false;
+    responseStream: true;
+    requestSerialize: grpc.serialize<imgbuilder_pb.BuildLogsRequest>;
+    requestDeserialize: grpc.deserialize<imgbuilder_pb.BuildLogsRequest>;
+    responseSerialize: grpc.serialize<imgbuilder_pb.LogsResponse>;
+    responseDeserialize: grpc.deserialize<imgbuilder_pb.LogsResponse>;
+}
+interface IImageBuilderService_ICancelBuild extends grpc.MethodDefinition<imgbuilder_pb.CancelBuildRequest, imgbuilder_pb.CancelBuildResponse> {
+    path: "/builder.ImageBuilder/CancelBuild";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<imgbuilder_pb.CancelBuildRequest>;
+    requestDeserialize: grpc.deserialize<imgbuilder_pb.CancelBuildRequest>;
+    responseSerialize: grpc.serialize<imgbuilder_pb.CancelBuildResponse>;
+    responseDeserialize: grpc.deserialize<imgbuilder_pb.CancelBuildResponse>;
+}
 
 export const ImageBuilderService: IImageBuilderService;
 
@@ -76,6 +96,8 @@ export interface IImageBuilderServer extends grpc.UntypedServiceImplementation {
     build: grpc.handleServerStreamingCall<imgbuilder_pb.BuildRequest, imgbuilder_pb.BuildResponse>;
     logs: grpc.handleServerStreamingCall<imgbuilder_pb.LogsRequest, imgbuilder_pb.LogsResponse>;
     listBuilds: grpc.handleUnaryCall<imgbuilder_pb.ListBuildsRequest, imgbuilder_pb.ListBuildsResponse>;
+    buildLogs: grpc.handleServerStreamingCall<imgbuilder_pb.BuildLogsRequest, imgbuilder_pb.LogsResponse>;
+    cancelBuild: grpc.handleUnaryCall<imgbuilder_pb.CancelBuildRequest, imgbuilder_pb.CancelBuildResponse>;
 }
 
 export interface IImageBuilderClient {
@@ -92,6 +114,11 @@ export interface IImageBuilderClient {
     listBuilds(request: imgbuilder_pb.ListBuildsRequest, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.ListBuildsResponse) => void): grpc.ClientUnaryCall;
     listBuilds(request: imgbuilder_pb.ListBuildsRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.ListBuildsResponse) => void): grpc.ClientUnaryCall;
     listBuilds(request: imgbuilder_pb.ListBuildsRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.ListBuildsResponse) => void): grpc.ClientUnaryCall;
+    buildLogs(request: imgbuilder_pb.BuildLogsRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<imgbuilder_pb.LogsResponse>;
+    buildLogs(request: imgbuilder_pb.BuildLogsRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<imgbuilder_pb.LogsResponse>;
+    cancelBuild(request: imgbuilder_pb.CancelBuildRequest, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.CancelBuildResponse) => void): grpc.ClientUnaryCall;
+    cancelBuild(request: imgbuilder_pb.CancelBuildRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.CancelBuildResponse) => void): grpc.ClientUnaryCall;
+    cancelBuild(request: imgbuilder_pb.CancelBuildRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.CancelBuildResponse) => void): grpc.ClientUnaryCall;
 }
 
 export class ImageBuilderClient extends grpc.Client implements IImageBuilderClient {
@@ -109,4 +136,9 @@ export class ImageBuilderClient extends grpc.Client implements IImageBuilderClie
     public listBuilds(request: imgbuilder_pb.ListBuildsRequest, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.ListBuildsResponse) => void): grpc.ClientUnaryCall;
     public listBuilds(request: imgbuilder_pb.ListBuildsRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.ListBuildsResponse) => void): grpc.ClientUnaryCall;
     public listBuilds(request: imgbuilder_pb.ListBuildsRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.ListBuildsResponse) => void): grpc.ClientUnaryCall;
+    public buildLogs(request: imgbuilder_pb.BuildLogsRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<imgbuilder_pb.LogsResponse>;
+    public buildLogs(request: imgbuilder_pb.BuildLogsRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<imgbuilder_pb.LogsResponse>;
+    public cancelBuild(request: imgbuilder_pb.CancelBuildRequest, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.CancelBuildResponse) => void): grpc.ClientUnaryCall;
+    public cancelBuild(request: imgbuilder_pb.CancelBuildRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.CancelBuildResponse) => void): grpc.ClientUnaryCall;
+    public cancelBuild(request: imgbuilder_pb.CancelBuildRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: imgbuilder_pb.CancelBuildResponse) => void): grpc.ClientUnaryCall;
 }
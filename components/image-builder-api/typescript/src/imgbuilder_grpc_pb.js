@@ -11,6 +11,17 @@ var grpc = require('@grpc/grpc-js');
 var imgbuilder_pb = require('./imgbuilder_pb.js');
 var content$service$api_initializer_pb = require('@gitpod/content-service/lib');
 
+function serialize_builder_BuildLogsRequest(arg) {
+  if (!(arg instanceof imgbuilder_pb.BuildLogsRequest)) {
+    throw new Error('Expected argument of type builder.BuildLogsRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_builder_BuildLogsRequest(buffer_arg) {
+  return imgbuilder_pb.BuildLogsRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_builder_BuildRequest(arg) {
   if (!(arg instanceof imgbuilder_pb.BuildRequest)) {
     throw new Error('Expected argument of type builder.BuildRequest');
@@ -33,6 +44,28 @@ function deserialize_builder_BuildResponse(buffer_arg) {
   return imgbuilder_pb.BuildResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_builder_CancelBuildRequest(arg) {
+  if (!(arg instanceof imgbuilder_pb.CancelBuildRequest)) {
+    throw new Error('Expected argument of type builder.CancelBuildRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_builder_CancelBuildRequest(buffer_arg) {
+  return imgbuilder_pb.CancelBuildRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_builder_CancelBuildResponse(arg) {
+  if (!(arg instanceof imgbuilder_pb.CancelBuildResponse)) {
+    throw new Error('Expected argument of type builder.CancelBuildResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_builder_CancelBuildResponse(buffer_arg) {
+  return imgbuilder_pb.CancelBuildResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_builder_ListBuildsRequest(arg) {
   if (!(arg instanceof imgbuilder_pb.ListBuildsRequest)) {
     throw new Error('Expected argument of type builder.ListBuildsRequest');
@@ -184,6 +217,32 @@ listBuilds: {
     responseSerialize: serialize_builder_ListBuildsResponse,
     responseDeserialize: deserialize_builder_ListBuildsResponse,
   },
+  // BuildLogs streams the log output of a build identified by its build ID. Ongoing builds stream their
+// output as it is produced, finished builds stream the log which was stored once they were done.
+buildLogs: {
+    path: '/builder.ImageBuilder/BuildLogs',
+    requestStream: false,
+    responseStream: true,
+    requestType: imgbuilder_pb.BuildLogsRequest,
+    responseType: imgbuilder_pb.LogsResponse,
+    requestSerialize: serialize_builder_BuildLogsRequest,
+    requestDeserialize: deserialize_builder_BuildLogsRequest,
+    responseSerialize: serialize_builder_LogsResponse,
+    responseDeserialize: deserialize_builder_LogsResponse,
+  },
+  // CancelBuild stops a queued or running build identified by its build ID or the ref of the image it builds.
+// Clients waiting for the build receive a failed build status.
+cancelBuild: {
+    path: '/builder.ImageBuilder/CancelBuild',
+    requestStream: false,
+    responseStream: false,
+    requestType: imgbuilder_pb.CancelBuildRequest,
+    responseType: imgbuilder_pb.CancelBuildResponse,
+    requestSerialize: serialize_builder_CancelBuildRequest,
+    requestDeserialize: deserialize_builder_CancelBuildRequest,
+    responseSerialize: serialize_builder_CancelBuildResponse,
+    responseDeserialize: deserialize_builder_CancelBuildResponse,
+  },
 };
 
 exports.ImageBuilderClient = grpc.makeGenericClientConstructor(ImageBuilderService);
@@ -83,6 +83,8 @@ export class BuildSourceDockerfile extends jspb.Message {
     setDockerfilePath(value: string): BuildSourceDockerfile;
     getContextPath(): string;
     setContextPath(value: string): BuildSourceDockerfile;
+    getDockerfileContent(): string;
+    setDockerfileContent(value: string): BuildSourceDockerfile;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): BuildSourceDockerfile.AsObject;
@@ -100,6 +102,7 @@ export namespace BuildSourceDockerfile {
         dockerfileVersion: string,
         dockerfilePath: string,
         contextPath: string,
+        dockerfileContent: string,
     }
 }
 
@@ -224,6 +227,15 @@ export class BuildRequest extends jspb.Message {
     getBaseImageNameResolved(): string;
     setBaseImageNameResolved(value: string): BuildRequest;
 
+    hasCache(): boolean;
+    clearCache(): void;
+    getCache(): BuildCache | undefined;
+    setCache(value?: BuildCache): BuildRequest;
+    getOrganizationId(): string;
+    setOrganizationId(value: string): BuildRequest;
+    getTimeout(): string;
+    setTimeout(value: string): BuildRequest;
+
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): BuildRequest.AsObject;
     static toObject(includeInstance: boolean, msg: BuildRequest): BuildRequest.AsObject;
@@ -242,6 +254,9 @@ export namespace BuildRequest {
         triggeredBy: string,
         supervisorRef: string,
         baseImageNameResolved: string,
+        cache?: BuildCache.AsObject,
+        organizationId: string,
+        timeout: string,
     }
 }
 
@@ -350,6 +365,8 @@ export class BuildResponse extends jspb.Message {
     clearInfo(): void;
     getInfo(): BuildInfo | undefined;
     setInfo(value?: BuildInfo): BuildResponse;
+    getQueuePosition(): number;
+    setQueuePosition(value: number): BuildResponse;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): BuildResponse.AsObject;
@@ -368,6 +385,7 @@ export namespace BuildResponse {
         status: BuildStatus,
         message: string,
         info?: BuildInfo.AsObject,
+        queuePosition: number,
     }
 }
 
@@ -521,6 +539,92 @@ export namespace LogInfo {
     }
 }
 
+export class BuildCache extends jspb.Message {
+    getDisabled(): boolean;
+    setDisabled(value: boolean): BuildCache;
+    getScope(): string;
+    setScope(value: string): BuildCache;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildCache.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildCache): BuildCache.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildCache, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildCache;
+    static deserializeBinaryFromReader(message: BuildCache, reader: jspb.BinaryReader): BuildCache;
+}
+
+export namespace BuildCache {
+    export type AsObject = {
+        disabled: boolean,
+        scope: string,
+    }
+}
+
+export class BuildLogsRequest extends jspb.Message {
+    getBuildId(): string;
+    setBuildId(value: string): BuildLogsRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildLogsRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildLogsRequest): BuildLogsRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildLogsRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildLogsRequest;
+    static deserializeBinaryFromReader(message: BuildLogsRequest, reader: jspb.BinaryReader): BuildLogsRequest;
+}
+
+export namespace BuildLogsRequest {
+    export type AsObject = {
+        buildId: string,
+    }
+}
+
+export class CancelBuildRequest extends jspb.Message {
+    getBuildId(): string;
+    setBuildId(value: string): CancelBuildRequest;
+    getBuildRef(): string;
+    setBuildRef(value: string): CancelBuildRequest;
+    getReason(): string;
+    setReason(value: string): CancelBuildRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): CancelBuildRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: CancelBuildRequest): CancelBuildRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: CancelBuildRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): CancelBuildRequest;
+    static deserializeBinaryFromReader(message: CancelBuildRequest, reader: jspb.BinaryReader): CancelBuildRequest;
+}
+
+export namespace CancelBuildRequest {
+    export type AsObject = {
+        buildId: string,
+        buildRef: string,
+        reason: string,
+    }
+}
+
+export class CancelBuildResponse extends jspb.Message {
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): CancelBuildResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: CancelBuildResponse): CancelBuildResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: CancelBuildResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): CancelBuildResponse;
+    static deserializeBinaryFromReader(message: CancelBuildResponse, reader: jspb.BinaryReader): CancelBuildResponse;
+}
+
+export namespace CancelBuildResponse {
+    export type AsObject = {
+    }
+}
+
 export enum BuildStatus {
     UNKNOWN = 0,
     RUNNING = 1,
@@ -23,7 +23,9 @@ var global = (function() { return this || window || global || self || Function('
 
 var content$service$api_initializer_pb = require('@gitpod/content-service/lib');
 goog.object.extend(proto, content$service$api_initializer_pb);
+goog.exportSymbol('proto.builder.BuildCache', null, global);
 goog.exportSymbol('proto.builder.BuildInfo', null, global);
+goog.exportSymbol('proto.builder.BuildLogsRequest', null, global);
 goog.exportSymbol('proto.builder.BuildRegistryAuth', null, global);
 goog.exportSymbol('proto.builder.BuildRegistryAuth.ModeCase', null, global);
 goog.exportSymbol('proto.builder.BuildRegistryAuthSelective', null, global);
@@ -35,6 +37,8 @@ goog.exportSymbol('proto.builder.BuildSource.FromCase', null, global);
 goog.exportSymbol('proto.builder.BuildSourceDockerfile', null, global);
 goog.exportSymbol('proto.builder.BuildSourceReference', null, global);
 goog.exportSymbol('proto.builder.BuildStatus', null, global);
+goog.exportSymbol('proto.builder.CancelBuildRequest', null, global);
+goog.exportSymbol('proto.builder.CancelBuildResponse', null, global);
 goog.exportSymbol('proto.builder.ListBuildsRequest', null, global);
 goog.exportSymbol('proto.builder.ListBuildsResponse', null, global);
 goog.exportSymbol('proto.builder.LogInfo', null, global);
@@ -422,6 +426,90 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.builder.LogInfo.displayName = 'proto.builder.LogInfo';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.builder.BuildCache = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.builder.BuildCache, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.builder.BuildCache.displayName = 'proto.builder.BuildCache';
+}
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.builder.BuildLogsRequest = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.builder.BuildLogsRequest, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.builder.BuildLogsRequest.displayName = 'proto.builder.BuildLogsRequest';
+}
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.builder.CancelBuildRequest = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.builder.CancelBuildRequest, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.builder.CancelBuildRequest.displayName = 'proto.builder.CancelBuildRequest';
+}
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.builder.CancelBuildResponse = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.builder.CancelBuildResponse, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.builder.CancelBuildResponse.displayName = 'proto.builder.CancelBuildResponse';
+}
 
 /**
  * Oneof group definitions for this message. Each group defines the field
@@ -815,7 +903,8 @@ proto.builder.BuildSourceDockerfile.toObject = function(includeInstance, msg) {
     source: (f = msg.getSource()) && content$service$api_initializer_pb.WorkspaceInitializer.toObject(includeInstance, f),
     dockerfileVersion: jspb.Message.getFieldWithDefault(msg, 2, ""),
     dockerfilePath: jspb.Message.getFieldWithDefault(msg, 3, ""),
-    contextPath: jspb.Message.getFieldWithDefault(msg, 4, "")
+    contextPath: jspb.Message.getFieldWithDefault(msg, 4, ""),
+    dockerfileContent: jspb.Message.getFieldWithDefault(msg, 5, "")
   };
 
   if (includeInstance) {
@@ -869,6 +958,10 @@ proto.builder.BuildSourceDockerfile.deserializeBinaryFromReader = function(msg,
       var value = /** @type {string} */ (reader.readString());
       msg.setContextPath(value);
       break;
+    case 5:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setDockerfileContent(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -927,6 +1020,13 @@ proto.builder.BuildSourceDockerfile.serializeBinaryToWriter = function(message,
       f
     );
   }
+  f = message.getDockerfileContent();
+  if (f.length > 0) {
+    writer.writeString(
+      5,
+      f
+    );
+  }
 };
 
 
@@ -1021,6 +1121,24 @@ proto.builder.BuildSourceDockerfile.prototype.setContextPath = function(value) {
 };
 
 
+/**
+ * optional string dockerfile_content = 5;
+ * @return {string}
+ */
+proto.builder.BuildSourceDockerfile.prototype.getDockerfileContent = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 5, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildSourceDockerfile} returns this
+ */
+proto.builder.BuildSourceDockerfile.prototype.setDockerfileContent = function(value) {
+  return jspb.Message.setProto3StringField(this, 5, value);
+};
+
+
 
 
 
@@ -1761,7 +1879,10 @@ proto.builder.BuildRequest.toObject = function(includeInstance, msg) {
     forceRebuild: jspb.Message.getBooleanFieldWithDefault(msg, 3, false),
     triggeredBy: jspb.Message.getFieldWithDefault(msg, 4, ""),
     supervisorRef: jspb.Message.getFieldWithDefault(msg, 5, ""),
-    baseImageNameResolved: jspb.Message.getFieldWithDefault(msg, 6, "")
+    baseImageNameResolved: jspb.Message.getFieldWithDefault(msg, 6, ""),
+    cache: (f = msg.getCache()) && proto.builder.BuildCache.toObject(includeInstance, f),
+    organizationId: jspb.Message.getFieldWithDefault(msg, 8, ""),
+    timeout: jspb.Message.getFieldWithDefault(msg, 9, "")
   };
 
   if (includeInstance) {
@@ -1824,6 +1945,19 @@ proto.builder.BuildRequest.deserializeBinaryFromReader = function(msg, reader) {
       var value = /** @type {string} */ (reader.readString());
       msg.setBaseImageNameResolved(value);
       break;
+    case 7:
+      var value = new proto.builder.BuildCache;
+      reader.readMessage(value,proto.builder.BuildCache.deserializeBinaryFromReader);
+      msg.setCache(value);
+      break;
+    case 8:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setOrganizationId(value);
+      break;
+    case 9:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setTimeout(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -1897,6 +2031,28 @@ proto.builder.BuildRequest.serializeBinaryToWriter = function(message, writer) {
       f
     );
   }
+  f = message.getCache();
+  if (f != null) {
+    writer.writeMessage(
+      7,
+      f,
+      proto.builder.BuildCache.serializeBinaryToWriter
+    );
+  }
+  f = message.getOrganizationId();
+  if (f.length > 0) {
+    writer.writeString(
+      8,
+      f
+    );
+  }
+  f = message.getTimeout();
+  if (f.length > 0) {
+    writer.writeString(
+      9,
+      f
+    );
+  }
 };
 
 
@@ -2046,6 +2202,79 @@ proto.builder.BuildRequest.prototype.setBaseImageNameResolved = function(value)
 };
 
 
+/**
+ * optional BuildCache cache = 7;
+ * @return {?proto.builder.BuildCache}
+ */
+proto.builder.BuildRequest.prototype.getCache = function() {
+  return /** @type{?proto.builder.BuildCache} */ (
+    jspb.Message.getWrapperField(this, proto.builder.BuildCache, 7));
+};
+
+
+/**
+ * @param {?proto.builder.BuildCache|undefined} value
+ * @return {!proto.builder.BuildRequest} returns this
+*/
+proto.builder.BuildRequest.prototype.setCache = function(value) {
+  return jspb.Message.setWrapperField(this, 7, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.builder.BuildRequest} returns this
+ */
+proto.builder.BuildRequest.prototype.clearCache = function() {
+  return this.setCache(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.builder.BuildRequest.prototype.hasCache = function() {
+  return jspb.Message.getField(this, 7) != null;
+};
+
+
+/**
+ * optional string organization_id = 8;
+ * @return {string}
+ */
+proto.builder.BuildRequest.prototype.getOrganizationId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 8, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildRequest} returns this
+ */
+proto.builder.BuildRequest.prototype.setOrganizationId = function(value) {
+  return jspb.Message.setProto3StringField(this, 8, value);
+};
+
+
+/**
+ * optional string timeout = 9;
+ * @return {string}
+ */
+proto.builder.BuildRequest.prototype.getTimeout = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 9, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildRequest} returns this
+ */
+proto.builder.BuildRequest.prototype.setTimeout = function(value) {
+  return jspb.Message.setProto3StringField(this, 9, value);
+};
+
+
 
 /**
  * Oneof group definitions for this message. Each group defines the field
@@ -2689,7 +2918,8 @@ proto.builder.BuildResponse.toObject = function(includeInstance, msg) {
     baseRef: jspb.Message.getFieldWithDefault(msg, 4, ""),
     status: jspb.Message.getFieldWithDefault(msg, 2, 0),
     message: jspb.Message.getFieldWithDefault(msg, 3, ""),
-    info: (f = msg.getInfo()) && proto.builder.BuildInfo.toObject(includeInstance, f)
+    info: (f = msg.getInfo()) && proto.builder.BuildInfo.toObject(includeInstance, f),
+    queuePosition: jspb.Message.getFieldWithDefault(msg, 6, 0)
   };
 
   if (includeInstance) {
@@ -2747,6 +2977,10 @@ proto.builder.BuildResponse.deserializeBinaryFromReader = function(msg, reader)
       reader.readMessage(value,proto.builder.BuildInfo.deserializeBinaryFromReader);
       msg.setInfo(value);
       break;
+    case 6:
+      var value = /** @type {number} */ (reader.readInt32());
+      msg.setQueuePosition(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -2812,6 +3046,13 @@ proto.builder.BuildResponse.serializeBinaryToWriter = function(message, writer)
       proto.builder.BuildInfo.serializeBinaryToWriter
     );
   }
+  f = message.getQueuePosition();
+  if (f !== 0) {
+    writer.writeInt32(
+      6,
+      f
+    );
+  }
 };
 
 
@@ -2924,6 +3165,24 @@ proto.builder.BuildResponse.prototype.hasInfo = function() {
 };
 
 
+/**
+ * optional int32 queue_position = 6;
+ * @return {number}
+ */
+proto.builder.BuildResponse.prototype.getQueuePosition = function() {
+  return /** @type {number} */ (jspb.Message.getFieldWithDefault(this, 6, 0));
+};
+
+
+/**
+ * @param {number} value
+ * @return {!proto.builder.BuildResponse} returns this
+ */
+proto.builder.BuildResponse.prototype.setQueuePosition = function(value) {
+  return jspb.Message.setProto3IntField(this, 6, value);
+};
+
+
 
 
 
@@ -3993,6 +4252,586 @@ proto.builder.LogInfo.prototype.clearHeadersMap = function() {
   return this;};
 
 
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.builder.BuildCache.prototype.toObject = function(opt_includeInstance) {
+  return proto.builder.BuildCache.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.builder.BuildCache} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildCache.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    disabled: jspb.Message.getBooleanFieldWithDefault(msg, 1, false),
+    scope: jspb.Message.getFieldWithDefault(msg, 2, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.builder.BuildCache}
+ */
+proto.builder.BuildCache.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.builder.BuildCache;
+  return proto.builder.BuildCache.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.builder.BuildCache} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.builder.BuildCache}
+ */
+proto.builder.BuildCache.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {boolean} */ (reader.readBool());
+      msg.setDisabled(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setScope(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.builder.BuildCache.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.builder.BuildCache.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.builder.BuildCache} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildCache.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getDisabled();
+  if (f) {
+    writer.writeBool(
+      1,
+      f
+    );
+  }
+  f = message.getScope();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional bool disabled = 1;
+ * @return {boolean}
+ */
+proto.builder.BuildCache.prototype.getDisabled = function() {
+  return /** @type {boolean} */ (jspb.Message.getBooleanFieldWithDefault(this, 1, false));
+};
+
+
+/**
+ * @param {boolean} value
+ * @return {!proto.builder.BuildCache} returns this
+ */
+proto.builder.BuildCache.prototype.setDisabled = function(value) {
+  return jspb.Message.setProto3BooleanField(this, 1, value);
+};
+
+
+/**
+ * optional string scope = 2;
+ * @return {string}
+ */
+proto.builder.BuildCache.prototype.getScope = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildCache} returns this
+ */
+proto.builder.BuildCache.prototype.setScope = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.builder.BuildLogsRequest.prototype.toObject = function(opt_includeInstance) {
+  return proto.builder.BuildLogsRequest.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.builder.BuildLogsRequest} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildLogsRequest.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    buildId: jspb.Message.getFieldWithDefault(msg, 1, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.builder.BuildLogsRequest}
+ */
+proto.builder.BuildLogsRequest.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.builder.BuildLogsRequest;
+  return proto.builder.BuildLogsRequest.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.builder.BuildLogsRequest} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.builder.BuildLogsRequest}
+ */
+proto.builder.BuildLogsRequest.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setBuildId(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.builder.BuildLogsRequest.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.builder.BuildLogsRequest.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.builder.BuildLogsRequest} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildLogsRequest.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getBuildId();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string build_id = 1;
+ * @return {string}
+ */
+proto.builder.BuildLogsRequest.prototype.getBuildId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildLogsRequest} returns this
+ */
+proto.builder.BuildLogsRequest.prototype.setBuildId = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.builder.CancelBuildRequest.prototype.toObject = function(opt_includeInstance) {
+  return proto.builder.CancelBuildRequest.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.builder.CancelBuildRequest} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.CancelBuildRequest.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    buildId: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    buildRef: jspb.Message.getFieldWithDefault(msg, 2, ""),
+    reason: jspb.Message.getFieldWithDefault(msg, 3, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.builder.CancelBuildRequest}
+ */
+proto.builder.CancelBuildRequest.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.builder.CancelBuildRequest;
+  return proto.builder.CancelBuildRequest.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.builder.CancelBuildRequest} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.builder.CancelBuildRequest}
+ */
+proto.builder.CancelBuildRequest.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setBuildId(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setBuildRef(value);
+      break;
+    case 3:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setReason(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.builder.CancelBuildRequest.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.builder.CancelBuildRequest.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.builder.CancelBuildRequest} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.CancelBuildRequest.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getBuildId();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getBuildRef();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+  f = message.getReason();
+  if (f.length > 0) {
+    writer.writeString(
+      3,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string build_id = 1;
+ * @return {string}
+ */
+proto.builder.CancelBuildRequest.prototype.getBuildId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.CancelBuildRequest} returns this
+ */
+proto.builder.CancelBuildRequest.prototype.setBuildId = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string build_ref = 2;
+ * @return {string}
+ */
+proto.builder.CancelBuildRequest.prototype.getBuildRef = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.CancelBuildRequest} returns this
+ */
+proto.builder.CancelBuildRequest.prototype.setBuildRef = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+/**
+ * optional string reason = 3;
+ * @return {string}
+ */
+proto.builder.CancelBuildRequest.prototype.getReason = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 3, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.CancelBuildRequest} returns this
+ */
+proto.builder.CancelBuildRequest.prototype.setReason = function(value) {
+  return jspb.Message.setProto3StringField(this, 3, value);
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.builder.CancelBuildResponse.prototype.toObject = function(opt_includeInstance) {
+  return proto.builder.CancelBuildResponse.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.builder.CancelBuildResponse} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.CancelBuildResponse.toObject = function(includeInstance, msg) {
+  var f, obj = {
+
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.builder.CancelBuildResponse}
+ */
+proto.builder.CancelBuildResponse.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.builder.CancelBuildResponse;
+  return proto.builder.CancelBuildResponse.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.builder.CancelBuildResponse} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.builder.CancelBuildResponse}
+ */
+proto.builder.CancelBuildResponse.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.builder.CancelBuildResponse.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.builder.CancelBuildResponse.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.builder.CancelBuildResponse} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.CancelBuildResponse.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+};
+
+
 /**
  * @enum {number}
  */
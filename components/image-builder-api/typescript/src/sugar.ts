@@ -15,6 +15,9 @@ import {
     BuildRequest,
     BuildResponse,
     BuildStatus,
+    BuildLogsRequest,
+    CancelBuildRequest,
+    CancelBuildResponse,
     LogsRequest,
     LogsResponse,
     ResolveWorkspaceImageResponse,
@@ -121,6 +124,8 @@ export interface StagedBuildResponse {
     actuallyNeedsBuild: boolean;
     ref: string;
     baseRef: string;
+    // buildId identifies the build, e.g. to cancel it. Only set if the image actually needs to be built.
+    buildId?: string;
 }
 
 export class PromisifiedImageBuilderClient {
@@ -225,6 +230,9 @@ export class PromisifiedImageBuilderClient {
                 if (resp.hasInfo()) {
                     // assumes that log info stays stable for instance lifetime
                     const info = resp.getInfo();
+                    if (info && info.getBuildId() && !resultResp.buildId) {
+                        resultResp.buildId = info.getBuildId();
+                    }
                     if (info && info.hasLogInfo() && !logInfoDeferred.isResolved) {
                         const logInfo = info.getLogInfo()!;
                         const headers: { [key: string]: string } = {};
@@ -301,6 +309,43 @@ export class PromisifiedImageBuilderClient {
         const span = TraceContext.startSpan(`/image-builder/logs`, ctx);
 
         const stream = this.client.logs(request, withTracing({ span }));
+        return this.forwardLogs(span, stream, cb);
+    }
+
+    // buildLogs streams the logs of a build by its ID, also after the build is done.
+    // This function returns when there are no more logs to provide
+    public buildLogs(
+        ctx: TraceContext,
+        request: BuildLogsRequest,
+        cb: (data: string) => "continue" | "stop",
+    ): Promise<void> {
+        const span = TraceContext.startSpan(`/image-builder/buildLogs`, ctx);
+
+        const stream = this.client.buildLogs(request, withTracing({ span }));
+        return this.forwardLogs(span, stream, cb);
+    }
+
+    // cancelBuild stops a queued or running build. Clients waiting for the build see it fail.
+    public cancelBuild(ctx: TraceContext, request: CancelBuildRequest): Promise<CancelBuildResponse> {
+        return new Promise<CancelBuildResponse>((resolve, reject) => {
+            const span = TraceContext.startSpan(`/image-builder/cancelBuild`, ctx);
+            this.client.cancelBuild(request, withTracing({ span }), this.getDefaultUnaryOptions(), (err, resp) => {
+                if (err) {
+                    TraceContext.setError({ span }, err);
+                    reject(err);
+                } else {
+                    resolve(resp);
+                }
+                span.finish();
+            });
+        });
+    }
+
+    protected forwardLogs(
+        span: opentracing.Span,
+        stream: grpc.ClientReadableStream<LogsResponse>,
+        cb: (data: string) => "continue" | "stop",
+    ): Promise<void> {
         return new Promise<void>((resolve, reject) => {
             stream.on("end", () => {
                 span.finish();
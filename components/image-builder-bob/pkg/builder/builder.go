@@ -83,7 +83,7 @@ func (b *Builder) buildBaseLayer(ctx context.Context, cl *client.Client) error {
 	}
 
 	log.Info("building base image")
-	return buildImage(ctx, b.Config.ContextDir, b.Config.Dockerfile, b.Config.WorkspaceLayerAuth, b.Config.BaseRef)
+	return buildImage(ctx, b.Config.ContextDir, b.Config.Dockerfile, b.Config.WorkspaceLayerAuth, b.Config.BaseRef, b.Config.CacheRef, b.Config.Platforms)
 }
 
 func (b *Builder) buildWorkspaceImage(ctx context.Context) (err error) {
@@ -94,7 +94,7 @@ func (b *Builder) buildWorkspaceImage(ctx context.Context) (err error) {
 	return crane.Copy(b.Config.BaseRef, b.Config.TargetRef, crane.Insecure, crane.WithJobs(runtime.GOMAXPROCS(0)))
 }
 
-func buildImage(ctx context.Context, contextDir, dockerfile, authLayer, target string) (err error) {
+func buildImage(ctx context.Context, contextDir, dockerfile, authLayer, target, cacheRef, platforms string) (err error) {
 	log.Info("waiting for build context")
 	waitctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
 	defer cancel()
@@ -138,12 +138,22 @@ func buildImage(ctx context.Context, contextDir, dockerfile, authLayer, target s
 		"--output=type=image,name=" + target + ",push=true,oci-mediatypes=true",
 		//"--export-cache=type=inline",
 		"--local=context=" + contextdir,
-		//"--export-cache=type=registry,ref=" + target + "-cache",
-		//"--import-cache=type=registry,ref=" + target + "-cache",
 		"--frontend=dockerfile.v0",
 		"--local=dockerfile=" + filepath.Dir(dockerfile),
 		"--opt=filename=" + filepath.Base(dockerfile),
 	}
+	if platforms != "" {
+		// building for more than one platform pushes an image index which references the image of each platform
+		buildctlArgs = append(buildctlArgs, "--opt=platform="+platforms)
+	}
+	if cacheRef != "" {
+		// mode=max exports the layers of all build stages, not only those of the final image.
+		// A cache which cannot be exported must not fail the build.
+		buildctlArgs = append(buildctlArgs,
+			"--import-cache=type=registry,ref="+cacheRef,
+			"--export-cache=type=registry,ref="+cacheRef+",mode=max,oci-mediatypes=true,ignore-error=true",
+		)
+	}
 
 	buildctlCmd := exec.Command("buildctl", buildctlArgs...)
 
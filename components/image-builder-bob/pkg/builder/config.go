@@ -26,6 +26,8 @@ type Config struct {
 	Dockerfile         string
 	ContextDir         string
 	ExternalBuildkitd  string
+	CacheRef           string
+	Platforms          string
 	localCacheImport   string
 }
 
@@ -41,6 +43,8 @@ func GetConfigFromEnv() (*Config, error) {
 		Dockerfile:         os.Getenv("BOB_DOCKERFILE_PATH"),
 		ContextDir:         os.Getenv("BOB_CONTEXT_DIR"),
 		ExternalBuildkitd:  os.Getenv("BOB_EXTERNAL_BUILDKITD"),
+		CacheRef:           os.Getenv("BOB_CACHE_REF"),
+		Platforms:          os.Getenv("BOB_PLATFORMS"),
 		localCacheImport:   os.Getenv("BOB_LOCAL_CACHE_IMPORT"),
 	}
 
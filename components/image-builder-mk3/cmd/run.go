@@ -51,7 +51,7 @@ var runCmd = &cobra.Command{
 			}
 
 			resolver := &resolve.PrecachingRefResolver{
-				Resolver:   &resolve.StandaloneRefResolver{},
+				Resolver:   &resolve.StandaloneRefResolver{Platforms: cfg.Orchestrator.Platforms},
 				Candidates: cfg.RefCache.Refs,
 			}
 			go resolver.StartCaching(ctx, interval)
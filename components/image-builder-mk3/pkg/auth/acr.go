@@ -0,0 +1,189 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// azureIMDSTokenURL serves AAD tokens of the managed identity of the node
+	azureIMDSTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// azureDefaultAuthorityHost is where federated tokens of Azure Workload Identity are exchanged for AAD tokens
+	azureDefaultAuthorityHost = "https://login.microsoftonline.com/"
+	// azureManagementResource is the resource ACR expects AAD tokens for
+	azureManagementResource = "https://management.azure.com/"
+
+	// acrUsername is the username ACR expects alongside ACR refresh tokens
+	acrUsername = "00000000-0000-0000-0000-000000000000"
+	// ACR refresh tokens are valid for 3h, which is what we assume if the token does not tell
+	acrTokenLifetime = 3 * time.Hour
+)
+
+// NewACRHelper creates a credential helper for ACR registries. It uses Azure Workload Identity if it is configured
+// through the AZURE_* environment variables, and the managed identity of the node otherwise. AZURE_CLIENT_ID selects
+// the user-assigned managed identity if the node has more than one.
+func NewACRHelper() *ACRHelper {
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = azureDefaultAuthorityHost
+	}
+	return &ACRHelper{
+		ClientID:           os.Getenv("AZURE_CLIENT_ID"),
+		TenantID:           os.Getenv("AZURE_TENANT_ID"),
+		FederatedTokenFile: os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+
+		client:         &http.Client{Timeout: 10 * time.Second},
+		imdsURL:        azureIMDSTokenURL,
+		authorityHost:  authorityHost,
+		registryScheme: "https",
+	}
+}
+
+// ACRHelper provides credentials for ACR registries by exchanging an AAD token for an ACR refresh token.
+// The Azure role assignments of the identity dictate which registries they grant access to.
+type ACRHelper struct {
+	ClientID           string
+	TenantID           string
+	FederatedTokenFile string
+
+	client         *http.Client
+	imdsURL        string
+	authorityHost  string
+	registryScheme string
+}
+
+// Matches returns true for ACR registries
+func (h *ACRHelper) Matches(registry string) bool {
+	return strings.HasSuffix(registry, ".azurecr.io") || strings.HasSuffix(registry, ".azurecr.cn") || strings.HasSuffix(registry, ".azurecr.us")
+}
+
+// Credentials exchanges an AAD token for an ACR refresh token of the registry
+func (h *ACRHelper) Credentials(ctx context.Context, registry string) (auth *Authentication, expiresAt time.Time, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error with ACR authenticate: %w", err)
+		}
+	}()
+
+	aadToken, err := h.aadToken(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"access_token": {aadToken},
+	}
+	if h.TenantID != "" {
+		form.Set("tenant", h.TenantID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s://%s/oauth2/exchange", h.registryScheme, registry), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tkn struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = doJSON(h.client, req, &tkn)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if tkn.RefreshToken == "" {
+		return nil, time.Time{}, fmt.Errorf("no refresh token received")
+	}
+
+	expiresAt, ok := jwtExpiry(tkn.RefreshToken)
+	if !ok {
+		expiresAt = time.Now().Add(acrTokenLifetime)
+	}
+	return &Authentication{
+		Username: acrUsername,
+		Password: tkn.RefreshToken,
+	}, expiresAt, nil
+}
+
+// aadToken requests an AAD token for the Azure management API
+func (h *ACRHelper) aadToken(ctx context.Context) (string, error) {
+	var (
+		req *http.Request
+		err error
+	)
+	if h.FederatedTokenFile != "" {
+		assertion, err := os.ReadFile(h.FederatedTokenFile)
+		if err != nil {
+			return "", err
+		}
+		form := url.Values{
+			"client_id":             {h.ClientID},
+			"grant_type":            {"client_credentials"},
+			"scope":                 {azureManagementResource + ".default"},
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+		}
+		tokenURL := strings.TrimSuffix(h.authorityHost, "/") + "/" + h.TenantID + "/oauth2/v2.0/token"
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := url.Values{
+			"api-version": {"2018-02-01"},
+			"resource":    {azureManagementResource},
+		}
+		if h.ClientID != "" {
+			query.Set("client_id", h.ClientID)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, h.imdsURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+	}
+
+	var tkn struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = doJSON(h.client, req, &tkn)
+	if err != nil {
+		return "", err
+	}
+	if tkn.AccessToken == "" {
+		return "", fmt.Errorf("no AAD token received")
+	}
+	return tkn.AccessToken, nil
+}
+
+// jwtExpiry reads the expiry of a JWT without verifying it
+func jwtExpiry(token string) (time.Time, bool) {
+	segs := strings.Split(token, ".")
+	if len(segs) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(segs[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
@@ -11,13 +11,9 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 	"sync"
-	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/distribution/reference"
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/docker/api/types/registry"
@@ -138,75 +134,6 @@ func (ca CompositeAuth) Authenticate(ctx context.Context, registry string) (auth
 	return &Authentication{}, nil
 }
 
-func NewECRAuthenticator(ecrc *ecr.Client) *ECRAuthenticator {
-	return &ECRAuthenticator{
-		ecrc: ecrc,
-	}
-}
-
-type ECRAuthenticator struct {
-	ecrc *ecr.Client
-
-	ecrAuth                string
-	ecrAuthLastRefreshTime time.Time
-	ecrAuthLock            sync.Mutex
-}
-
-const (
-	// ECR tokens are valid for 12h [1], and we want to ensure we refresh at least twice a day before full expiry.
-	//
-	// [1] https://docs.aws.amazon.com/AmazonECR/latest/APIReference/API_GetAuthorizationToken.html
-	ecrTokenRefreshTime = 4 * time.Hour
-)
-
-func (ath *ECRAuthenticator) Authenticate(ctx context.Context, registry string) (auth *Authentication, err error) {
-	if !isECRRegistry(registry) {
-		return nil, nil
-	}
-
-	defer func() {
-		if err != nil {
-			err = fmt.Errorf("error with ECR authenticate: %w", err)
-			log.WithError(err).WithField("registry", registry).Error("failed ECR authenticate")
-		}
-	}()
-
-	ath.ecrAuthLock.Lock()
-	defer ath.ecrAuthLock.Unlock()
-	if time.Since(ath.ecrAuthLastRefreshTime) > ecrTokenRefreshTime {
-		tknout, err := ath.ecrc.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
-		if err != nil {
-			return nil, err
-		}
-		if len(tknout.AuthorizationData) == 0 {
-			err = fmt.Errorf("no ECR authorization data received")
-			return nil, err
-		}
-
-		pwd, err := base64.StdEncoding.DecodeString(aws.ToString(tknout.AuthorizationData[0].AuthorizationToken))
-		if err != nil {
-			return nil, err
-		}
-
-		ath.ecrAuth = string(pwd)
-		ath.ecrAuthLastRefreshTime = time.Now()
-		log.Info("refreshed ECR token")
-	} else {
-		log.Info("no ECR token refresh necessary")
-	}
-
-	segs := strings.Split(ath.ecrAuth, ":")
-	if len(segs) != 2 {
-		err = fmt.Errorf("cannot understand ECR token. Expected 2 segments, got %d", len(segs))
-		return nil, err
-	}
-	return &Authentication{
-		Username: segs[0],
-		Password: segs[1],
-		Auth:     base64.StdEncoding.EncodeToString([]byte(ath.ecrAuth)),
-	}, nil
-}
-
 // Authentication represents docker usable authentication
 type Authentication registry.AuthConfig
 
@@ -220,15 +147,6 @@ func (a *Authentication) Empty() bool {
 	return false
 }
 
-var ecrRegistryRegexp = regexp.MustCompile(`\d{12}.dkr.ecr.\w+-\w+-\w+.amazonaws.com`)
-
-const DummyECRRegistryDomain = "000000000000.dkr.ecr.dummy-host-zone.amazonaws.com"
-
-// isECRRegistry returns true if the registry domain is an ECR registry
-func isECRRegistry(domain string) bool {
-	return ecrRegistryRegexp.MatchString(domain)
-}
-
 // AllowedAuthFor describes for which repositories authentication may be provided for
 type AllowedAuthFor struct {
 	All        bool
@@ -355,6 +273,9 @@ func (a AllowedAuthFor) GetAuthFor(ctx context.Context, auth RegistryAuthenticat
 		// We allow ECR registries by default to support private ECR registries OOTB.
 		// The AWS IAM permissions dictate what users actually have access to.
 		regAllowed = true
+	case MatchesCredentialHelper(auth, ref):
+		// The same holds for the repositories we allow the credential helpers for.
+		regAllowed = true
 	default:
 		for _, a := range a.Explicit {
 			if a == reg {
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+)
+
+var ecrRegistryRegexp = regexp.MustCompile(`\d{12}.dkr.ecr.\w+-\w+-\w+.amazonaws.com`)
+
+const DummyECRRegistryDomain = "000000000000.dkr.ecr.dummy-host-zone.amazonaws.com"
+
+// isECRRegistry returns true if the registry domain is an ECR registry
+func isECRRegistry(domain string) bool {
+	return ecrRegistryRegexp.MatchString(domain)
+}
+
+// ECR tokens are valid for 12h [1], which is also what we assume if ECR does not tell.
+//
+// [1] https://docs.aws.amazon.com/AmazonECR/latest/APIReference/API_GetAuthorizationToken.html
+const ecrTokenLifetime = 12 * time.Hour
+
+type ecrClient interface {
+	GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error)
+}
+
+// NewECRHelper creates a credential helper for ECR registries using the default AWS config, e.g. IRSA
+func NewECRHelper(ctx context.Context) (*ECRHelper, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ECRHelper{ecrc: ecr.NewFromConfig(awsCfg)}, nil
+}
+
+// ECRHelper provides credentials for ECR registries. The AWS IAM permissions dictate which registries they grant access to.
+type ECRHelper struct {
+	ecrc ecrClient
+}
+
+// Matches returns true for ECR registries
+func (h *ECRHelper) Matches(registry string) bool {
+	return isECRRegistry(registry)
+}
+
+// Credentials requests an ECR authorization token
+func (h *ECRHelper) Credentials(ctx context.Context, registry string) (auth *Authentication, expiresAt time.Time, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error with ECR authenticate: %w", err)
+		}
+	}()
+
+	tknout, err := h.ecrc.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(tknout.AuthorizationData) == 0 {
+		return nil, time.Time{}, fmt.Errorf("no ECR authorization data received")
+	}
+	data := tknout.AuthorizationData[0]
+
+	tkn := aws.ToString(data.AuthorizationToken)
+	pwd, err := base64.StdEncoding.DecodeString(tkn)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	segs := strings.Split(string(pwd), ":")
+	if len(segs) != 2 {
+		return nil, time.Time{}, fmt.Errorf("cannot understand ECR token. Expected 2 segments, got %d", len(segs))
+	}
+
+	expiresAt = time.Now().Add(ecrTokenLifetime)
+	if data.ExpiresAt != nil {
+		expiresAt = *data.ExpiresAt
+	}
+	return &Authentication{
+		Username: segs[0],
+		Password: segs[1],
+		Auth:     tkn,
+	}, expiresAt, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// gcpMetadataTokenURL serves access tokens of the service account of the node, or of the Kubernetes service
+	// account when GKE Workload Identity is enabled
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// gcrUsername is the username registries of Google Cloud expect alongside OAuth access tokens
+	gcrUsername = "oauth2accesstoken"
+)
+
+// NewGCRHelper creates a credential helper for Google Container Registry and Artifact Registry
+func NewGCRHelper() *GCRHelper {
+	return &GCRHelper{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		tokenURL: gcpMetadataTokenURL,
+	}
+}
+
+// GCRHelper provides credentials for Google Container Registry and Artifact Registry using the GCP metadata server.
+// The IAM permissions of the service account dictate which registries they grant access to.
+type GCRHelper struct {
+	client   *http.Client
+	tokenURL string
+}
+
+// Matches returns true for gcr.io and Artifact Registry registries
+func (h *GCRHelper) Matches(registry string) bool {
+	return registry == "gcr.io" || strings.HasSuffix(registry, ".gcr.io") || strings.HasSuffix(registry, "-docker.pkg.dev")
+}
+
+// Credentials requests an access token from the metadata server
+func (h *GCRHelper) Credentials(ctx context.Context, registry string) (auth *Authentication, expiresAt time.Time, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error with GCR authenticate: %w", err)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.tokenURL, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var tkn struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	err = doJSON(h.client, req, &tkn)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if tkn.AccessToken == "" {
+		return nil, time.Time{}, fmt.Errorf("no access token received")
+	}
+
+	return &Authentication{
+		Username: gcrUsername,
+		Password: tkn.AccessToken,
+	}, time.Now().Add(time.Duration(tkn.ExpiresIn) * time.Second), nil
+}
+
+// doJSON sends the request and decodes the JSON body of a successful response into res
+func doJSON(client *http.Client, req *http.Request, res interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+// CredentialHelper provides short-lived credentials for the registries of a cloud provider,
+// using the cloud identity image-builder runs with
+type CredentialHelper interface {
+	// Matches returns true if the helper can provide credentials for the registry
+	Matches(registry string) bool
+	// Credentials requests new credentials for the registry and returns when they expire
+	Credentials(ctx context.Context, registry string) (auth *Authentication, expiresAt time.Time, err error)
+}
+
+const (
+	// CredentialHelperECR provides credentials for ECR registries
+	CredentialHelperECR = "ecr"
+	// CredentialHelperGCR provides credentials for GCR and Artifact Registry registries
+	CredentialHelperGCR = "gcr"
+	// CredentialHelperACR provides credentials for ACR registries
+	CredentialHelperACR = "acr"
+)
+
+// NewCredentialHelper creates the credential helper with the given name
+func NewCredentialHelper(ctx context.Context, name string) (CredentialHelper, error) {
+	switch name {
+	case CredentialHelperECR:
+		return NewECRHelper(ctx)
+	case CredentialHelperGCR:
+		return NewGCRHelper(), nil
+	case CredentialHelperACR:
+		return NewACRHelper(), nil
+	default:
+		return nil, fmt.Errorf("unknown credential helper %q, must be one of %s, %s or %s", name, CredentialHelperECR, CredentialHelperGCR, CredentialHelperACR)
+	}
+}
+
+// credentialRefreshMargin is how long credentials must remain valid at most to be handed out again. Builds get the
+// credentials once when they start, hence long-lived credentials which would expire during a build with the default
+// build timeout are refreshed. Short-lived credentials are refreshed once half of their lifetime has passed.
+const credentialRefreshMargin = 1 * time.Hour
+
+// NewCredentialHelperAuth provides the credentials of a credential helper for images in the given registries or
+// repositories. If repositories is nil, the credentials are provided for all registries the helper matches.
+func NewCredentialHelperAuth(helper CredentialHelper, repositories []string) *CredentialHelperAuth {
+	return &CredentialHelperAuth{
+		Helper:       helper,
+		Repositories: repositories,
+		cache:        make(map[string]cachedCredentials),
+	}
+}
+
+// CredentialHelperAuth provides the credentials of a credential helper, and refreshes them before they expire
+type CredentialHelperAuth struct {
+	Helper CredentialHelper
+	// Repositories are the registries, e.g. eu.gcr.io, or repositories, e.g. europe-docker.pkg.dev/project/images,
+	// we provide credentials for. The credentials grant access to everything the cloud identity of image-builder has
+	// access to, hence we must not hand them out for images of arbitrary users.
+	Repositories []string
+
+	cache map[string]cachedCredentials
+	mu    sync.Mutex
+}
+
+type cachedCredentials struct {
+	Auth      *Authentication
+	ExpiresAt time.Time
+	Lifetime  time.Duration
+}
+
+// refreshMargin is how long the credentials must remain valid to be handed out again
+func (c cachedCredentials) refreshMargin() time.Duration {
+	if margin := c.Lifetime / 2; margin < credentialRefreshMargin {
+		return margin
+	}
+	return credentialRefreshMargin
+}
+
+// MatchesRef returns true if the credential helper provides credentials for the image
+func (a *CredentialHelperAuth) MatchesRef(ref reference.Named) bool {
+	if !a.Helper.Matches(reference.Domain(ref)) {
+		return false
+	}
+	if a.Repositories == nil {
+		return true
+	}
+	name := ref.Name()
+	for _, repo := range a.Repositories {
+		if name == repo || strings.HasPrefix(name, repo+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRegistry returns true if the credential helper provides credentials for images in the registry
+func (a *CredentialHelperAuth) matchesRegistry(registry string) bool {
+	if !a.Helper.Matches(registry) {
+		return false
+	}
+	if a.Repositories == nil {
+		return true
+	}
+	for _, repo := range a.Repositories {
+		if repo == registry || strings.HasPrefix(repo, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate provides the credentials for the registry if the credential helper matches it and it's allowed
+func (a *CredentialHelperAuth) Authenticate(ctx context.Context, registry string) (auth *Authentication, err error) {
+	if !a.matchesRegistry(registry) {
+		return nil, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, ok := a.cache[registry]; ok && time.Until(c.ExpiresAt) > c.refreshMargin() {
+		return c.Auth, nil
+	}
+
+	auth, expiresAt, err := a.Helper.Credentials(ctx, registry)
+	if err != nil {
+		log.WithError(err).WithField("registry", registry).Error("cannot refresh registry credentials")
+		return nil, err
+	}
+	if auth.Auth == "" {
+		auth.Auth = base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+	}
+	a.cache[registry] = cachedCredentials{Auth: auth, ExpiresAt: expiresAt, Lifetime: time.Until(expiresAt)}
+	log.WithField("registry", registry).WithField("expiresAt", expiresAt).Info("refreshed registry credentials")
+
+	return auth, nil
+}
+
+// MatchesCredentialHelper returns true if a credential helper of auth provides credentials for the image
+func MatchesCredentialHelper(auth RegistryAuthenticator, ref reference.Named) bool {
+	switch a := auth.(type) {
+	case *CredentialHelperAuth:
+		return a.MatchesRef(ref)
+	case CompositeAuth:
+		for _, ath := range a {
+			if MatchesCredentialHelper(ath, ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsCredentialHelperRegistry returns true if a credential helper of auth matches the registry, regardless of
+// whether it provides credentials for it
+func IsCredentialHelperRegistry(auth RegistryAuthenticator, registry string) bool {
+	switch a := auth.(type) {
+	case *CredentialHelperAuth:
+		return a.Helper.Matches(registry)
+	case CompositeAuth:
+		for _, ath := range a {
+			if IsCredentialHelperRegistry(ath, registry) {
+				return true
+			}
+		}
+	}
+	return false
+}
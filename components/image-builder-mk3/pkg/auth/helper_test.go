@@ -0,0 +1,212 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeHelper struct {
+	Lifetime time.Duration
+	Calls    int
+}
+
+func (h *fakeHelper) Matches(registry string) bool {
+	return strings.HasSuffix(registry, ".example.com")
+}
+
+func (h *fakeHelper) Credentials(ctx context.Context, registry string) (*Authentication, time.Time, error) {
+	h.Calls++
+	return &Authentication{Username: "user", Password: fmt.Sprintf("token-%d", h.Calls)}, time.Now().Add(h.Lifetime), nil
+}
+
+func TestCredentialHelperAuth(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Lifetime    time.Duration
+		Elapsed     time.Duration
+		Registries  []string
+		Expectation []string
+	}{
+		{
+			Name:        "long-lived credentials are cached",
+			Lifetime:    12 * time.Hour,
+			Registries:  []string{"a.example.com", "a.example.com", "b.example.com"},
+			Expectation: []string{"token-1", "token-1", "token-2"},
+		},
+		{
+			Name:        "short-lived credentials are cached",
+			Lifetime:    time.Hour,
+			Registries:  []string{"a.example.com", "a.example.com"},
+			Expectation: []string{"token-1", "token-1"},
+		},
+		{
+			Name:        "long-lived credentials are refreshed an hour before they expire",
+			Lifetime:    12 * time.Hour,
+			Elapsed:     11*time.Hour + time.Minute,
+			Registries:  []string{"a.example.com", "a.example.com"},
+			Expectation: []string{"token-1", "token-2"},
+		},
+		{
+			Name:        "short-lived credentials are refreshed after half their lifetime",
+			Lifetime:    time.Hour,
+			Elapsed:     31 * time.Minute,
+			Registries:  []string{"a.example.com", "a.example.com"},
+			Expectation: []string{"token-1", "token-2"},
+		},
+		{
+			Name:        "other registries",
+			Lifetime:    12 * time.Hour,
+			Registries:  []string{"docker.io"},
+			Expectation: []string{""},
+		},
+		{
+			Name:        "registries which are not allowed",
+			Lifetime:    12 * time.Hour,
+			Registries:  []string{"c.example.com"},
+			Expectation: []string{""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ath := NewCredentialHelperAuth(&fakeHelper{Lifetime: test.Lifetime}, []string{"a.example.com", "b.example.com/project"})
+
+			var act []string
+			for _, reg := range test.Registries {
+				if c, ok := ath.cache[reg]; ok {
+					// pretend time passed since the credentials were refreshed
+					c.ExpiresAt = c.ExpiresAt.Add(-test.Elapsed)
+					ath.cache[reg] = c
+				}
+				res, err := ath.Authenticate(context.Background(), reg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Empty() {
+					act = append(act, "")
+					continue
+				}
+				if res.Auth != base64.StdEncoding.EncodeToString([]byte("user:"+res.Password)) {
+					t.Errorf("unexpected auth %q", res.Auth)
+				}
+				act = append(act, res.Password)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("Authenticate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchesCredentialHelper(t *testing.T) {
+	ath := CompositeAuth{
+		&DockerConfigFileAuth{},
+		NewCredentialHelperAuth(NewGCRHelper(), []string{"eu.gcr.io", "europe-west1-docker.pkg.dev/gitpod/images"}),
+		NewCredentialHelperAuth(NewACRHelper(), []string{"gitpod.azurecr.io"}),
+		NewCredentialHelperAuth(&fakeHelper{}, nil),
+	}
+	tests := []struct {
+		Ref         string
+		Expectation bool
+	}{
+		{Ref: "eu.gcr.io/gitpod/workspace:latest", Expectation: true},
+		{Ref: "gcr.io/gitpod/workspace:latest", Expectation: false},
+		{Ref: "europe-west1-docker.pkg.dev/gitpod/images/workspace:latest", Expectation: true},
+		{Ref: "europe-west1-docker.pkg.dev/gitpod/images", Expectation: true},
+		{Ref: "europe-west1-docker.pkg.dev/gitpod/images-other/workspace:latest", Expectation: false},
+		{Ref: "europe-west1-docker.pkg.dev/other/images/workspace:latest", Expectation: false},
+		{Ref: "gitpod.azurecr.io/workspace:latest", Expectation: true},
+		{Ref: "other.azurecr.io/workspace:latest", Expectation: false},
+		{Ref: "any.example.com/workspace:latest", Expectation: true},
+		{Ref: "422899872803.dkr.ecr.eu-central-1.amazonaws.com/workspace:latest", Expectation: false},
+		{Ref: "ubuntu:latest", Expectation: false},
+	}
+	for _, test := range tests {
+		t.Run(test.Ref, func(t *testing.T) {
+			ref, err := reference.ParseNormalizedNamed(test.Ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			act := MatchesCredentialHelper(ath, ref)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("MatchesCredentialHelper() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGCRHelper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor header", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"gcp-token","expires_in":3599,"token_type":"Bearer"}`)
+	}))
+	defer srv.Close()
+
+	h := NewGCRHelper()
+	h.tokenURL = srv.URL
+	res, expiresAt, err := h.Credentials(context.Background(), "gcr.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Authentication{Username: "oauth2accesstoken", Password: "gcp-token"}, res); diff != "" {
+		t.Errorf("Credentials() mismatch (-want +got):\n%s", diff)
+	}
+	if d := time.Until(expiresAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("unexpected expiry in %s", d)
+	}
+}
+
+func TestACRHelper(t *testing.T) {
+	exp := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	refreshToken := "header." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix()))) + ".signature"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metadata/identity/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("client_id") != "client" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"aad-token","expires_in":"3599"}`)
+	})
+	mux.HandleFunc("/oauth2/exchange", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("access_token") != "aad-token" || r.PostFormValue("grant_type") != "access_token" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"refresh_token":%q}`, refreshToken)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	h := &ACRHelper{
+		ClientID:       "client",
+		client:         srv.Client(),
+		imdsURL:        srv.URL + "/metadata/identity/oauth2/token",
+		registryScheme: "http",
+	}
+	res, expiresAt, err := h.Credentials(context.Background(), strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Authentication{Username: "00000000-0000-0000-0000-000000000000", Password: refreshToken}, res); diff != "" {
+		t.Errorf("Credentials() mismatch (-want +got):\n%s", diff)
+	}
+	if !expiresAt.Equal(exp) {
+		t.Errorf("expected expiry %s, got %s", exp, expiresAt)
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+// Package dockerfile finds mistakes in Dockerfiles before they are built. It is no replacement
+// for the parser of BuildKit, but catches the typos which would otherwise only fail the build
+// once the build workspace started.
+package dockerfile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// instructions lists all instructions a Dockerfile may contain
+var instructions = map[string]struct{}{
+	"ADD":         {},
+	"ARG":         {},
+	"CMD":         {},
+	"COPY":        {},
+	"ENTRYPOINT":  {},
+	"ENV":         {},
+	"EXPOSE":      {},
+	"FROM":        {},
+	"HEALTHCHECK": {},
+	"LABEL":       {},
+	"MAINTAINER":  {},
+	"ONBUILD":     {},
+	"RUN":         {},
+	"SHELL":       {},
+	"STOPSIGNAL":  {},
+	"USER":        {},
+	"VOLUME":      {},
+	"WORKDIR":     {},
+}
+
+var (
+	directiveExpr = regexp.MustCompile(`^#\s*([a-zA-Z][a-zA-Z0-9]*)\s*=\s*(.+?)\s*$`)
+	heredocExpr   = regexp.MustCompile(`^[0-9]*<<-?["']?([^<"']+)["']?$`)
+	argExpr       = regexp.MustCompile(`\$(?:\{([a-zA-Z_][a-zA-Z0-9_]*)\}|([a-zA-Z_][a-zA-Z0-9_]*))`)
+)
+
+// Problem is a mistake in a Dockerfile
+type Problem struct {
+	// Line is the line the problem was found on, starting at 1. Problems which concern the whole file have no line.
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Stage is a build stage of a Dockerfile
+type Stage struct {
+	// Line is the line of the FROM instruction which starts the stage
+	Line int
+	// Name is the name given to the stage using "AS", if any
+	Name string
+	// Image is the image the stage is based on, with the defaults of build args substituted
+	Image string
+	// Platform is the value of the --platform flag, if any
+	Platform string
+	// External is true if Image is pulled from a registry, i.e. it is no previous stage and not scratch,
+	// and all build args could be substituted
+	External bool
+}
+
+// instruction is a logical line of a Dockerfile, i.e. with line continuations joined
+type instruction struct {
+	Line    int
+	Keyword string
+	Args    string
+}
+
+// Parse reads the stages of a Dockerfile and reports mistakes which would fail its build
+func Parse(content string) (stages []Stage, problems []Problem) {
+	insts, problems := split(content)
+
+	var (
+		globalArgs = make(map[string]string)
+		names      = make(map[string]int)
+		seenFrom   bool
+	)
+	for _, inst := range insts {
+		if _, ok := instructions[inst.Keyword]; !ok {
+			msg := fmt.Sprintf("unknown instruction %q", inst.Keyword)
+			if s := suggest(inst.Keyword); s != "" {
+				msg += fmt.Sprintf(", did you mean %q?", s)
+			}
+			problems = append(problems, Problem{Line: inst.Line, Message: msg})
+			continue
+		}
+		if inst.Args == "" {
+			problems = append(problems, Problem{Line: inst.Line, Message: fmt.Sprintf("%s requires at least one argument", inst.Keyword)})
+			continue
+		}
+
+		switch inst.Keyword {
+		case "ARG":
+			if !seenFrom {
+				name, value, _ := strings.Cut(inst.Args, "=")
+				globalArgs[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+			continue
+		case "FROM":
+			seenFrom = true
+		default:
+			if !seenFrom {
+				problems = append(problems, Problem{Line: inst.Line, Message: fmt.Sprintf("%s comes before the first FROM instruction, which must start the Dockerfile", inst.Keyword)})
+			}
+			continue
+		}
+
+		stage, problem := parseFrom(inst, globalArgs)
+		if problem != nil {
+			problems = append(problems, *problem)
+			continue
+		}
+		if stage.Name != "" {
+			if line, exists := names[stage.Name]; exists {
+				problems = append(problems, Problem{Line: inst.Line, Message: fmt.Sprintf("stage name %q is already used on line %d", stage.Name, line)})
+				continue
+			}
+		}
+		if _, previous := names[strings.ToLower(stage.Image)]; previous || strings.EqualFold(stage.Image, "scratch") {
+			stage.External = false
+		}
+		if stage.Name != "" {
+			names[stage.Name] = inst.Line
+		}
+		stages = append(stages, stage)
+	}
+	if !seenFrom {
+		problems = append(problems, Problem{Message: "the Dockerfile has no FROM instruction"})
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return stages, problems
+}
+
+// parseFrom parses "FROM [--platform=<platform>] <image> [AS <name>]"
+func parseFrom(inst instruction, args map[string]string) (stage Stage, problem *Problem) {
+	const usage = "FROM expects [--platform=<platform>] <image> [AS <name>]"
+
+	stage.Line = inst.Line
+	fields := strings.Fields(inst.Args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		flag, value, _ := strings.Cut(strings.TrimPrefix(fields[0], "--"), "=")
+		if flag != "platform" {
+			return stage, &Problem{Line: inst.Line, Message: fmt.Sprintf("FROM does not support the flag --%s", flag)}
+		}
+		stage.Platform = value
+		fields = fields[1:]
+	}
+	switch {
+	case len(fields) == 1:
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		stage.Name = strings.ToLower(fields[2])
+	default:
+		return stage, &Problem{Line: inst.Line, Message: usage}
+	}
+
+	stage.Image, stage.External = expand(fields[0], args)
+	if stage.Image == "" {
+		return stage, &Problem{Line: inst.Line, Message: fmt.Sprintf("the base image %q is empty, please set a default for its build args", fields[0])}
+	}
+	return stage, nil
+}
+
+// expand substitutes the build args in s. It returns false if not all build args have a value.
+func expand(s string, args map[string]string) (res string, complete bool) {
+	complete = true
+	res = argExpr.ReplaceAllStringFunc(s, func(m string) string {
+		sub := argExpr.FindStringSubmatch(m)
+		name := sub[1]
+		if name == "" {
+			name = sub[2]
+		}
+		v, ok := args[name]
+		if !ok || v == "" {
+			complete = false
+		}
+		return v
+	})
+	if strings.Contains(res, "$") {
+		// e.g. ${VERSION:-latest}, which we do not attempt to evaluate
+		complete = false
+	}
+	return res, complete
+}
+
+// split turns the content of a Dockerfile into instructions. It drops comments, parser directives and the
+// bodies of heredocs.
+func split(content string) (insts []instruction, problems []Problem) {
+	var (
+		escape     = `\`
+		lines      = strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+		directives = true
+		current    *instruction
+		heredocs   []string
+	)
+	for i, line := range lines {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		if len(heredocs) > 0 {
+			if strings.TrimLeft(line, "\t") == heredocs[0] {
+				heredocs = heredocs[1:]
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			if directives {
+				if m := directiveExpr.FindStringSubmatch(trimmed); m != nil {
+					if strings.EqualFold(m[1], "escape") {
+						if m[2] != `\` && m[2] != "`" {
+							problems = append(problems, Problem{Line: lineNo, Message: fmt.Sprintf("invalid escape character %q, must be \\ or `", m[2])})
+						} else {
+							escape = m[2]
+						}
+					}
+					continue
+				}
+			}
+			// parser directives must precede all comments, and comments within continued instructions do not end them
+			directives = false
+			continue
+		}
+		directives = false
+
+		continued := strings.HasSuffix(trimmed, escape)
+		if continued {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, escape))
+		}
+		if trimmed == "" {
+			continue
+		}
+		if current == nil {
+			keyword := strings.Fields(trimmed)[0]
+			current = &instruction{Line: lineNo, Keyword: strings.ToUpper(keyword), Args: strings.TrimSpace(trimmed[len(keyword):])}
+		} else {
+			current.Args = strings.TrimSpace(current.Args + " " + trimmed)
+		}
+		if continued {
+			continue
+		}
+
+		if current.Keyword == "RUN" || current.Keyword == "COPY" || current.Keyword == "ADD" {
+			for _, word := range words(current.Args, escape) {
+				if m := heredocExpr.FindStringSubmatch(word); m != nil {
+					heredocs = append(heredocs, m[1])
+				}
+			}
+		}
+		insts = append(insts, *current)
+		current = nil
+	}
+	if current != nil {
+		insts = append(insts, *current)
+	}
+	if len(heredocs) > 0 {
+		problems = append(problems, Problem{Message: fmt.Sprintf("the heredoc %s is never closed", heredocs[0])})
+	}
+	return insts, problems
+}
+
+// words splits the arguments of an instruction at whitespace outside of quotes, like BuildKit does when it looks
+// for heredocs. Quotes and escapes are kept, such that e.g. a quoted "<<EOF" is no heredoc.
+func words(args string, escape string) (res []string) {
+	var (
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range args {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if quote == '"' && string(c) == escape {
+				escaped = true
+			}
+		case string(c) == escape:
+			escaped = true
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ' ' || c == '\t':
+			if inWord {
+				res = append(res, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		}
+		word.WriteRune(c)
+		inWord = true
+	}
+	if inWord {
+		res = append(res, word.String())
+	}
+	return res
+}
+
+// suggest returns the instruction which is closest to keyword, or an empty string if none is close
+func suggest(keyword string) string {
+	var (
+		res  string
+		best = 3
+	)
+	for inst := range instructions {
+		if d := distance(keyword, inst); d < best || d == best && inst < res {
+			res, best = inst, d
+		}
+	}
+	return res
+}
+
+// distance returns the Damerau-Levenshtein distance of a and b (optimal string alignment)
+func distance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package dockerfile
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	type Expectation struct {
+		Stages   []Stage
+		Problems []Problem
+	}
+	tests := []struct {
+		Name        string
+		Content     string
+		Expectation Expectation
+	}{
+		{
+			Name: "valid",
+			Content: `# syntax=docker/dockerfile:1
+# escape=\
+ARG VERSION=22.04
+ARG REGISTRY
+
+FROM --platform=linux/amd64 ubuntu:${VERSION} AS build
+RUN apt-get update \
+    # comments do not end continued instructions
+    && apt-get install -y make
+COPY <<EOF /etc/motd
+FORM is not an instruction in here
+EOF
+
+FROM $REGISTRY/base:latest
+FROM scratch
+from build
+COPY --from=build /out /out
+`,
+			Expectation: Expectation{
+				Stages: []Stage{
+					{Line: 6, Name: "build", Image: "ubuntu:22.04", Platform: "linux/amd64", External: true},
+					{Line: 14, Image: "/base:latest"},
+					{Line: 15, Image: "scratch"},
+					{Line: 16, Image: "build"},
+				},
+			},
+		},
+		{
+			Name: "typos",
+			Content: `FORM ubuntu:22.04
+RUN echo hello
+WROKDIR /workspace
+CMDD ["bash"]
+FOOBAR
+`,
+			Expectation: Expectation{
+				Problems: []Problem{
+					{Message: "the Dockerfile has no FROM instruction"},
+					{Line: 1, Message: `unknown instruction "FORM", did you mean "FROM"?`},
+					{Line: 2, Message: "RUN comes before the first FROM instruction, which must start the Dockerfile"},
+					{Line: 3, Message: `unknown instruction "WROKDIR", did you mean "WORKDIR"?`},
+					{Line: 4, Message: `unknown instruction "CMDD", did you mean "CMD"?`},
+					{Line: 5, Message: `unknown instruction "FOOBAR"`},
+				},
+			},
+		},
+		{
+			Name: "invalid FROM",
+			Content: `FROM
+FROM ubuntu:22.04 build
+FROM --chown=gitpod ubuntu:22.04
+FROM ubuntu:22.04 AS build
+FROM ubuntu:20.04 AS BUILD
+FROM ${BASE}
+`,
+			Expectation: Expectation{
+				Stages: []Stage{
+					{Line: 4, Name: "build", Image: "ubuntu:22.04", External: true},
+				},
+				Problems: []Problem{
+					{Line: 1, Message: "FROM requires at least one argument"},
+					{Line: 2, Message: "FROM expects [--platform=<platform>] <image> [AS <name>]"},
+					{Line: 3, Message: "FROM does not support the flag --chown"},
+					{Line: 5, Message: `stage name "build" is already used on line 4`},
+					{Line: 6, Message: `the base image "${BASE}" is empty, please set a default for its build args`},
+				},
+			},
+		},
+		{
+			Name: "no heredocs",
+			Content: `FROM ubuntu:22.04
+RUN cat <<< "here-string"
+RUN echo "<<EOF" && echo '<<EOF' && echo \<<EOF
+RUN bash -c "cat <<EOF"
+COPY <<-"EOF" <<'END' /etc/
+	motd
+	EOF
+issue
+END
+RUN 3<<EOF cat /dev/fd/3
+EOF
+`,
+			Expectation: Expectation{
+				Stages: []Stage{
+					{Line: 1, Image: "ubuntu:22.04", External: true},
+				},
+			},
+		},
+		{
+			Name: "unclosed heredoc",
+			Content: `FROM ubuntu:22.04
+RUN <<EOF
+echo hello
+`,
+			Expectation: Expectation{
+				Stages: []Stage{
+					{Line: 1, Image: "ubuntu:22.04", External: true},
+				},
+				Problems: []Problem{
+					{Message: "the heredoc EOF is never closed"},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			stages, problems := Parse(test.Content)
+			act := Expectation{Stages: stages, Problems: problems}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
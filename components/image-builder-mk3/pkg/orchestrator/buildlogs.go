@@ -0,0 +1,209 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+)
+
+const (
+	// buildLogUploadTimeout is the time we give the upload of a build log
+	buildLogUploadTimeout = 5 * time.Minute
+
+	// finishedBuildRetention is how long we remember that a build is done, such that log output
+	// which arrives late does not start a new log.
+	finishedBuildRetention = 5 * time.Minute
+)
+
+// buildLogStore keeps the log output of builds in temporary files while they run, and uploads the logs to
+// content-service once the builds are done. This way the logs of a build are available after its build
+// workspace is gone. A nil store keeps no logs.
+type buildLogStore struct {
+	client csapi.HeadlessLogServiceClient
+	dir    string
+
+	mu       sync.Mutex
+	logs     map[string]*os.File
+	finished map[string]time.Time
+	uploads  sync.WaitGroup
+}
+
+func newBuildLogStore(client csapi.HeadlessLogServiceClient, dir string) *buildLogStore {
+	return &buildLogStore{
+		client:   client,
+		dir:      dir,
+		logs:     make(map[string]*os.File),
+		finished: make(map[string]time.Time),
+	}
+}
+
+// Append adds log output to the log of a build. Output of builds which are done is dropped.
+func (s *buildLogStore) Append(buildID string, content string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, done := s.finished[buildID]; done {
+		return
+	}
+	f, ok := s.logs[buildID]
+	if !ok {
+		var err error
+		f, err = os.CreateTemp(s.dir, "build-*.log")
+		if err != nil {
+			log.WithError(err).WithField("buildID", buildID).Warn("cannot create build log")
+			return
+		}
+		s.logs[buildID] = f
+	}
+
+	_, err := f.WriteString(content)
+	if err != nil {
+		log.WithError(err).WithField("buildID", buildID).Warn("cannot write build log")
+	}
+}
+
+// Finish marks a build as done and uploads its log in the background
+func (s *buildLogStore) Finish(buildID string) {
+	if s == nil {
+		return
+	}
+
+	f := s.finish(buildID)
+	if f == nil {
+		return
+	}
+
+	s.uploads.Add(1)
+	go func() {
+		defer s.uploads.Done()
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), buildLogUploadTimeout)
+		defer cancel()
+
+		err := s.upload(ctx, buildID, f)
+		if err != nil {
+			log.WithError(err).WithField("buildID", buildID).Warn("cannot store build log")
+			return
+		}
+		log.WithField("buildID", buildID).Debug("stored build log")
+	}()
+}
+
+// Discard drops the log of a build without storing it
+func (s *buildLogStore) Discard(buildID string) {
+	if s == nil {
+		return
+	}
+
+	f := s.finish(buildID)
+	if f == nil {
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// finish removes a build's log from the store and remembers that the build is done
+func (s *buildLogStore) finish(buildID string) *os.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range s.finished {
+		if now.Sub(t) > finishedBuildRetention {
+			delete(s.finished, id)
+		}
+	}
+	s.finished[buildID] = now
+
+	f := s.logs[buildID]
+	delete(s.logs, buildID)
+	return f
+}
+
+func (s *buildLogStore) upload(ctx context.Context, buildID string, f *os.File) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.BuildLogUploadURL(ctx, &csapi.BuildLogUploadURLRequest{BuildId: buildID})
+	if err != nil {
+		return xerrors.Errorf("cannot get upload URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, resp.Url, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stat.Size()
+	req.Header.Set("Content-Type", "text/plain")
+	// Azure Blob Storage refuses uploads which do not name the blob type
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("cannot upload build log: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return xerrors.Errorf("cannot upload build log: %s", res.Status)
+	}
+	return nil
+}
+
+// Open returns the stored log of a build which is done. Returns a NotFound error if there's no log of the build.
+func (s *buildLogStore) Open(ctx context.Context, buildID string) (io.ReadCloser, error) {
+	if s == nil {
+		return nil, status.Error(codes.FailedPrecondition, "build logs are not stored")
+	}
+
+	resp, err := s.client.BuildLogDownloadURL(ctx, &csapi.BuildLogDownloadURLRequest{BuildId: buildID})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Error(codes.NotFound, "build log not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "cannot get download URL: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resp.Url, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot download build log: %v", err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "cannot download build log: %v", err)
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, status.Error(codes.NotFound, "build log not found")
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, status.Errorf(codes.Unavailable, "cannot download build log: %s", res.Status)
+	}
+	return res.Body, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+)
+
+// fakeBuildLogStorage mimics content-service and the remote storage behind it
+type fakeBuildLogStorage struct {
+	csapi.HeadlessLogServiceClient
+
+	srv *httptest.Server
+
+	mu   sync.Mutex
+	logs map[string]string
+}
+
+func newFakeBuildLogStorage(t *testing.T) *fakeBuildLogStorage {
+	f := &fakeBuildLogStorage{logs: make(map[string]string)}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			if ct := r.Header.Get("Content-Type"); ct != "text/plain" {
+				http.Error(w, "unexpected content type "+ct, http.StatusBadRequest)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			f.logs[r.URL.Path] = string(b)
+		case http.MethodGet:
+			l, ok := f.logs[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = io.WriteString(w, l)
+		}
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeBuildLogStorage) BuildLogUploadURL(ctx context.Context, in *csapi.BuildLogUploadURLRequest, opts ...grpc.CallOption) (*csapi.BuildLogUploadURLResponse, error) {
+	return &csapi.BuildLogUploadURLResponse{Url: f.srv.URL + "/" + in.BuildId}, nil
+}
+
+func (f *fakeBuildLogStorage) BuildLogDownloadURL(ctx context.Context, in *csapi.BuildLogDownloadURLRequest, opts ...grpc.CallOption) (*csapi.BuildLogDownloadURLResponse, error) {
+	return &csapi.BuildLogDownloadURLResponse{Url: f.srv.URL + "/" + in.BuildId}, nil
+}
+
+func TestBuildLogStore(t *testing.T) {
+	storage := newFakeBuildLogStorage(t)
+	s := newBuildLogStore(storage, t.TempDir())
+
+	s.Append("b1", "starting image build\n")
+	s.Append("b2", "starting image build\n")
+	s.Append("b1", "Step 1/2 : FROM ubuntu\n")
+	s.Finish("b1")
+	s.Discard("b2")
+
+	// output which arrives once the build is done does not start another log
+	s.Append("b1", "late output\n")
+	s.Finish("b1")
+	s.uploads.Wait()
+
+	s.mu.Lock()
+	if len(s.logs) != 0 {
+		t.Errorf("expected no open logs, got %d", len(s.logs))
+	}
+	s.mu.Unlock()
+
+	rd, err := s.Open(context.Background(), "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+	content, err := io.ReadAll(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("starting image build\nStep 1/2 : FROM ubuntu\n", string(content)); diff != "" {
+		t.Errorf("unexpected build log (-want +got):\n%s", diff)
+	}
+
+	_, err = s.Open(context.Background(), "b2")
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a discarded build log, got %v", err)
+	}
+}
+
+func TestNilBuildLogStore(t *testing.T) {
+	var s *buildLogStore
+	s.Append("b1", "starting image build\n")
+	s.Finish("b1")
+
+	_, err := s.Open(context.Background(), "b1")
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+}
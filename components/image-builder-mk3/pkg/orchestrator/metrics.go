@@ -20,6 +20,18 @@ func (o *Orchestrator) RegisterMetrics(reg prometheus.Registerer) error {
 	if err != nil {
 		return err
 	}
+	err = reg.Register(o.metrics.queuedBuilds)
+	if err != nil {
+		return err
+	}
+	err = reg.Register(o.metrics.runningBuilds)
+	if err != nil {
+		return err
+	}
+	err = reg.Register(o.metrics.queueDuration)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -31,6 +43,9 @@ const (
 type metrics struct {
 	imageBuildsDoneTotal    *prometheus.CounterVec
 	imageBuildsStartedTotal prometheus.Counter
+	queuedBuilds            prometheus.Gauge
+	runningBuilds           prometheus.Gauge
+	queueDuration           prometheus.Histogram
 }
 
 func newMetrics() *metrics {
@@ -45,6 +60,25 @@ func newMetrics() *metrics {
 			Subsystem: metricsSubsystem,
 			Name:      "builds_started_total",
 		}),
+		queuedBuilds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "builds_queued",
+			Help:      "Number of builds waiting to start",
+		}),
+		runningBuilds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "builds_running",
+			Help:      "Number of builds counted against the concurrency limits",
+		}),
+		queueDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "build_queue_duration_seconds",
+			Help:      "Time builds waited before they started",
+			Buckets:   []float64{0.1, 1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		}),
 	}
 }
 
@@ -13,12 +13,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/distribution/reference"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
@@ -30,7 +30,6 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	common_grpc "github.com/gitpod-io/gitpod/common-go/grpc"
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
@@ -39,6 +38,7 @@ import (
 	protocol "github.com/gitpod-io/gitpod/image-builder/api"
 	"github.com/gitpod-io/gitpod/image-builder/api/config"
 	"github.com/gitpod-io/gitpod/image-builder/pkg/auth"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/dockerfile"
 	"github.com/gitpod-io/gitpod/image-builder/pkg/resolve"
 	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
 )
@@ -47,8 +47,11 @@ const (
 	// buildWorkspaceManagerID identifies the manager for the workspace
 	buildWorkspaceManagerID = "image-builder"
 
-	// maxBuildRuntime is the maximum time a build is allowed to take
-	maxBuildRuntime = 60 * time.Minute
+	// defaultBuildTimeout is the maximum time a build is allowed to take unless configured otherwise
+	defaultBuildTimeout = 60 * time.Minute
+
+	// defaultCancelReason is reported to clients of builds which were canceled without a reason
+	defaultCancelReason = "image build was canceled"
 
 	// workspaceBuildProcessVersion controls how we build workspace images.
 	// Incrementing this value will trigger a rebuild of all workspace images.
@@ -70,13 +73,28 @@ func NewOrchestratingBuilder(cfg config.Configuration) (res *Orchestrator, err e
 		}
 		authentication = append(authentication, ath)
 	}
-	if cfg.EnableAdditionalECRAuth {
-		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	credentialHelpers := cfg.CredentialHelpers
+	if cfg.EnableAdditionalECRAuth && !slices.Contains(credentialHelpers, auth.CredentialHelperECR) {
+		credentialHelpers = append(credentialHelpers, auth.CredentialHelperECR)
+	}
+	// a nil list would allow the credential helpers for all registries
+	credentialHelperRepositories := []string{}
+	for _, repo := range append([]string{cfg.BaseImageRepository, cfg.WorkspaceImageRepository, cfg.BuildCache.Repository}, cfg.CredentialHelperRepositories...) {
+		if repo != "" {
+			credentialHelperRepositories = append(credentialHelperRepositories, repo)
+		}
+	}
+	for _, name := range credentialHelpers {
+		helper, err := auth.NewCredentialHelper(context.Background(), name)
 		if err != nil {
 			return nil, err
 		}
-		ecrc := ecr.NewFromConfig(awsCfg)
-		authentication = append(authentication, auth.NewECRAuthenticator(ecrc))
+		repositories := credentialHelperRepositories
+		if name == auth.CredentialHelperECR && cfg.EnableAdditionalECRAuth {
+			// additional ECR auth has always been provided for all ECR registries
+			repositories = nil
+		}
+		authentication = append(authentication, auth.NewCredentialHelperAuth(helper, repositories))
 	}
 
 	var wsman wsmanapi.WorkspaceManagerClient
@@ -106,6 +124,16 @@ func NewOrchestratingBuilder(cfg config.Configuration) (res *Orchestrator, err e
 		wsman = wsmanapi.NewWorkspaceManagerClient(conn)
 	}
 
+	var buildLogs *buildLogStore
+	if cfg.BuildLogs.ContentServiceAddress != "" {
+		grpcOpts := append(common_grpc.DefaultClientOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		conn, err := grpc.Dial(cfg.BuildLogs.ContentServiceAddress, grpcOpts...)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot connect to content-service: %w", err)
+		}
+		buildLogs = newBuildLogStore(csapi.NewHeadlessLogServiceClient(conn), "")
+	}
+
 	o := &Orchestrator{
 		Config: cfg,
 		Auth:   authentication,
@@ -113,14 +141,18 @@ func NewOrchestratingBuilder(cfg config.Configuration) (res *Orchestrator, err e
 			BaseImageRepository:      cfg.BaseImageRepository,
 			WorkspaceImageRepository: cfg.WorkspaceImageRepository,
 		},
-		RefResolver: &resolve.StandaloneRefResolver{},
+		RefResolver: &resolve.StandaloneRefResolver{Platforms: cfg.Platforms},
 
 		wsman:         wsman,
 		buildListener: make(map[string]map[buildListener]struct{}),
 		logListener:   make(map[string]map[logListener]struct{}),
 		censorship:    make(map[string][]string),
+		queuedBuilds:  make(map[string]queuedBuildHandle),
+		cancellations: make(map[string]string),
+		buildLogs:     buildLogs,
 		metrics:       newMetrics(),
 	}
+	o.scheduler = newBuildScheduler(cfg.Queue, o.metrics)
 	o.monitor = newBuildMonitor(o, o.wsman)
 
 	return o, nil
@@ -138,9 +170,13 @@ type Orchestrator struct {
 	buildListener map[string]map[buildListener]struct{}
 	logListener   map[string]map[logListener]struct{}
 	censorship    map[string][]string
+	queuedBuilds  map[string]queuedBuildHandle
+	cancellations map[string]string
 	mu            sync.RWMutex
 
-	monitor *buildMonitor
+	monitor   *buildMonitor
+	scheduler *buildScheduler
+	buildLogs *buildLogStore
 
 	metrics *metrics
 
@@ -225,6 +261,10 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 	if req.Source == nil {
 		return status.Errorf(codes.InvalidArgument, "build source is missing")
 	}
+	timeout, err := o.buildTimeout(req.Timeout)
+	if err != nil {
+		return err
+	}
 
 	// resolve build request authentication
 	reqauth := o.AuthResolver.ResolveRequestAuth(ctx, req.Auth)
@@ -312,19 +352,74 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 		return nil
 	}
 
+	if fsrc := req.Source.GetFile(); fsrc != nil {
+		err = o.preflight(ctx, fsrc, baseref, reqauth)
+		if err != nil {
+			return err
+		}
+	}
+
+	randomUUID, err := uuid.NewRandom()
+	if err != nil {
+		return
+	}
+
+	// Builds wait for their turn for as long as the client is connected or until they are canceled. Once started,
+	// a build holds its slot until its build workspace stopped.
+	queueCtx, cancelQueued := context.WithCancel(ctx)
+	defer cancelQueued()
+	o.registerQueuedBuild(randomUUID.String(), wsrefstr, cancelQueued)
+	err = o.scheduler.Acquire(queueCtx, randomUUID.String(), req.GetOrganizationId(), func(position int) {
+		err := resp.Send(&protocol.BuildResponse{
+			Status:        protocol.BuildStatus_running,
+			Ref:           wsrefstr,
+			BaseRef:       baseref,
+			Message:       fmt.Sprintf("waiting for %d other image builds to start first", position-1),
+			QueuePosition: int32(position),
+			// clients need the build ID to cancel the build while it's queued
+			Info: &protocol.BuildInfo{
+				BuildId: randomUUID.String(),
+				Ref:     wsrefstr,
+				BaseRef: baseref,
+				Status:  protocol.BuildStatus_running,
+			},
+		})
+		if err != nil {
+			log.WithError(err).Debug("cannot send queue position")
+		}
+	})
+	o.unregisterQueuedBuild(randomUUID.String())
+	if reason, canceled := o.takeCancellation(randomUUID.String()); canceled {
+		if err == nil {
+			o.scheduler.Release(randomUUID.String())
+		}
+		o.buildLogs.Discard(randomUUID.String())
+		return resp.Send(&protocol.BuildResponse{
+			Status:  protocol.BuildStatus_done_failure,
+			Ref:     wsrefstr,
+			BaseRef: baseref,
+			Message: reason,
+		})
+	}
+	if err != nil {
+		return status.Error(codes.Canceled, "build was canceled while it was queued")
+	}
+	var workspaceStarted bool
+	defer func() {
+		if !workspaceStarted {
+			o.scheduler.Release(randomUUID.String())
+			o.buildLogs.Discard(randomUUID.String())
+		}
+	}()
+
 	o.metrics.BuildStarted()
 
 	// Once a build is running we don't want it cancelled becuase the server disconnected i.e. during deployment.
 	// Instead we want to impose our own timeout/lifecycle on the build. Using context.WithTimeout does not shadow its parent's
 	// cancelation (see https://play.golang.org/p/N3QBIGlp8Iw for an example/experiment).
-	ctx, cancel := context.WithTimeout(&parentCantCancelContext{Delegate: ctx}, maxBuildRuntime)
+	ctx, cancel := context.WithTimeout(&parentCantCancelContext{Delegate: ctx}, timeout)
 	defer cancel()
 
-	randomUUID, err := uuid.NewRandom()
-	if err != nil {
-		return
-	}
-
 	var (
 		buildID        = randomUUID.String()
 		buildBase      = "false"
@@ -349,12 +444,17 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 	}
 	contextPath = filepath.Join("/workspace", strings.TrimPrefix(contextPath, "/workspace"))
 
-	o.censor(buildID, []string{
+	cacheref := o.getBuildCacheRef(req.GetCache())
+	censored := []string{
 		wsrefstr,
 		baseref,
 		strings.Split(wsrefstr, ":")[0],
 		strings.Split(baseref, ":")[0],
-	})
+	}
+	if cacheref != "" {
+		censored = append(censored, cacheref, strings.Split(cacheref, ":")[0])
+	}
+	o.censor(buildID, censored)
 
 	// push some log to the client before starting the job, just in case the build workspace takes a while to start up
 	o.PublishLog(buildID, "starting image build")
@@ -379,7 +479,15 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 	wsref, err := reference.ParseNamed(wsrefstr)
 	var additionalAuth []byte
 	if err == nil {
-		ath := reqauth.GetImageBuildAuthFor(ctx, o.Auth, []string{reference.Domain(pbaseref), auth.DummyECRRegistryDomain}, []string{
+		registries := []string{auth.DummyECRRegistryDomain}
+		// Credential helpers provide credentials for whole registries, hence we must not ask for the credentials of the
+		// base image's registry if a credential helper matches it but isn't allowed for the base image.
+		if baseReg := reference.Domain(pbaseref); !auth.IsCredentialHelperRegistry(o.Auth, baseReg) || auth.MatchesCredentialHelper(o.Auth, pbaseref) {
+			registries = append(registries, baseReg)
+		}
+		helperRefs := append([]string{wsrefstr, cacheref}, dockerfileImages(req.Source.GetFile())...)
+		registries = append(registries, o.credentialHelperRegistries(helperRefs)...)
+		ath := reqauth.GetImageBuildAuthFor(ctx, o.Auth, registries, []string{
 			reference.Domain(wsref),
 		})
 		additionalAuth, err = json.Marshal(ath)
@@ -404,14 +512,14 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 			},
 			Spec: &wsmanapi.StartWorkspaceSpec{
 				Initializer:    initializer,
-				Timeout:        maxBuildRuntime.String(),
+				Timeout:        timeout.String(),
 				WorkspaceImage: o.Config.BuilderImage,
 				IdeImage: &wsmanapi.IDEImage{
 					WebRef:        o.Config.BuilderImage,
 					SupervisorRef: req.SupervisorRef,
 				},
 				WorkspaceLocation: contextPath,
-				Envvars: []*wsmanapi.EnvironmentVariable{
+				Envvars: append([]*wsmanapi.EnvironmentVariable{
 					{Name: "BOB_TARGET_REF", Value: "localhost:8080/target:latest"},
 					{Name: "BOB_BASE_REF", Value: bobBaseref},
 					{Name: "BOB_BUILD_BASE", Value: buildBase},
@@ -433,7 +541,7 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 						Value: string(additionalAuth),
 					},
 					{Name: "SUPERVISOR_DEBUG_ENABLE", Value: fmt.Sprintf("%v", log.Log.Logger.IsLevelEnabled(logrus.DebugLevel))},
-				},
+				}, append(buildCacheEnvvars(cacheref), buildPlatformEnvvars(o.buildPlatforms())...)...),
 			},
 			Type: wsmanapi.WorkspaceType_IMAGEBUILD,
 		})
@@ -446,14 +554,30 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 	} else if err != nil {
 		return status.Errorf(codes.Internal, "cannot start build: %q", err)
 	} else {
+		workspaceStarted = true
 		o.monitor.RegisterNewBuild(buildID, wsrefstr, baseref, swr.Url, swr.OwnerToken)
 		o.PublishLog(buildID, "starting image build ...\n")
 	}
 
 	updates, cancel := o.registerBuildListener(buildID)
 	defer cancel()
+	// Headless workspaces are subject to the timeout of the installation rather than their own, hence we stop
+	// the build workspace ourselves once the build timed out. The build fails when its workspace stopped.
+	timedOut := ctx.Done()
 	for {
-		update := <-updates
+		var update *protocol.BuildResponse
+		select {
+		case update = <-updates:
+		case <-timedOut:
+			timedOut = nil
+			stopCtx, cancelStop := context.WithTimeout(context.Background(), 30*time.Second)
+			err := o.stopBuild(stopCtx, buildID, fmt.Sprintf("image build timed out after %s", timeout))
+			cancelStop()
+			if err != nil {
+				log.WithError(err).WithField("buildID", buildID).Error("cannot stop timed out build")
+			}
+			continue
+		}
 		if update == nil {
 			// channel was closed unexpectatly
 			return status.Error(codes.Aborted, "subscription canceled - please try again")
@@ -496,6 +620,19 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 
 // publishStatus broadcasts a build status update to all listeners
 func (o *Orchestrator) PublishStatus(buildID string, resp *api.BuildResponse) {
+	if resp.Status == api.BuildStatus_done_success || resp.Status == api.BuildStatus_done_failure {
+		if reason, canceled := o.takeCancellation(buildID); canceled {
+			// the build workspace was stopped because the build was canceled or timed out
+			resp.Status = api.BuildStatus_done_failure
+			resp.Message = reason
+			if resp.Info != nil {
+				resp.Info.Status = api.BuildStatus_done_failure
+			}
+		}
+		o.scheduler.Release(buildID)
+		o.buildLogs.Finish(buildID)
+	}
+
 	o.mu.RLock()
 	listener, ok := o.buildListener[buildID]
 	o.mu.RUnlock()
@@ -545,6 +682,59 @@ func (o *Orchestrator) Logs(req *protocol.LogsRequest, resp protocol.ImageBuilde
 		return status.Error(codes.NotFound, "build not found")
 	}
 
+	return o.forwardLogs(buildID, resp.Send)
+}
+
+// BuildLogs streams the log output of a build identified by its build ID. Ongoing builds stream their
+// output as it is produced, finished builds stream the log which was stored once they were done.
+func (o *Orchestrator) BuildLogs(req *protocol.BuildLogsRequest, resp protocol.ImageBuilder_BuildLogsServer) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(resp.Context(), "BuildLogs")
+	defer tracing.FinishSpan(span, &err)
+	tracing.LogRequestSafe(span, req)
+
+	if req.BuildId == "" {
+		return status.Error(codes.InvalidArgument, "build ID is missing")
+	}
+
+	rb, err := o.monitor.GetAllRunningBuilds(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot list running builds: %v", err)
+	}
+	for _, bld := range rb {
+		if bld.Info.BuildId == req.BuildId {
+			return o.forwardLogs(req.BuildId, resp.Send)
+		}
+	}
+
+	rd, err := o.buildLogs.Open(ctx, req.BuildId)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := rd.Read(buf)
+		if n > 0 {
+			// the message must not change after it was sent, hence we cannot reuse the buffer
+			content := make([]byte, n)
+			copy(content, buf[:n])
+			serr := resp.Send(&protocol.LogsResponse{Content: content})
+			if serr != nil {
+				return status.Errorf(codes.Unknown, "cannot send log output: %v", serr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "cannot read build log: %v", err)
+		}
+	}
+}
+
+// forwardLogs sends the log output of a running build until the build is done
+func (o *Orchestrator) forwardLogs(buildID string, send func(*api.LogsResponse) error) error {
 	logs, cancel := o.registerLogListener(buildID)
 	defer cancel()
 	for {
@@ -553,14 +743,14 @@ func (o *Orchestrator) Logs(req *protocol.LogsRequest, resp protocol.ImageBuilde
 			break
 		}
 
-		err := resp.Send(update)
+		err := send(update)
 		if err != nil {
 			log.WithError(err).Error("cannot forward log output - dropping listener")
 			return status.Errorf(codes.Unknown, "cannot send log output: %v", err)
 		}
 	}
 
-	return
+	return nil
 }
 
 // ListBuilds returns a list of currently running builds
@@ -581,6 +771,98 @@ func (o *Orchestrator) ListBuilds(ctx context.Context, req *protocol.ListBuildsR
 	return &protocol.ListBuildsResponse{Builds: res}, nil
 }
 
+// CancelBuild stops a queued or running build identified by its build ID or the ref of the image it builds
+func (o *Orchestrator) CancelBuild(ctx context.Context, req *protocol.CancelBuildRequest) (resp *protocol.CancelBuildResponse, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "CancelBuild")
+	defer tracing.FinishSpan(span, &err)
+	tracing.LogRequestSafe(span, req)
+
+	if req.BuildId == "" && req.BuildRef == "" {
+		return nil, status.Error(codes.InvalidArgument, "either build ID or build ref is required")
+	}
+	matches := func(buildID, ref string) bool {
+		return (req.BuildId != "" && buildID == req.BuildId) || (req.BuildRef != "" && ref == req.BuildRef)
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = defaultCancelReason
+	}
+
+	var found bool
+	o.mu.Lock()
+	for buildID, q := range o.queuedBuilds {
+		if !matches(buildID, q.ref) {
+			continue
+		}
+		o.cancellations[buildID] = reason
+		q.cancel()
+		found = true
+	}
+	o.mu.Unlock()
+
+	builds, err := o.monitor.GetAllRunningBuilds(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot list running builds: %v", err)
+	}
+	for _, bld := range builds {
+		if !matches(bld.Info.BuildId, bld.Info.Ref) {
+			continue
+		}
+		err = o.stopBuild(ctx, bld.Info.BuildId, reason)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot stop build: %v", err)
+		}
+		found = true
+	}
+
+	if !found {
+		return nil, status.Error(codes.NotFound, "build not found")
+	}
+	return &protocol.CancelBuildResponse{}, nil
+}
+
+// stopBuild stops the workspace of a running build, which stops its BuildKit job and removes the builder pod.
+// Once the workspace stopped, the build fails with reason.
+func (o *Orchestrator) stopBuild(ctx context.Context, buildID string, reason string) error {
+	o.mu.Lock()
+	o.cancellations[buildID] = reason
+	o.mu.Unlock()
+
+	_, err := o.wsman.StopWorkspace(ctx, &wsmanapi.StopWorkspaceRequest{
+		Id:     buildID,
+		Policy: wsmanapi.StopWorkspacePolicy_IMMEDIATELY,
+	})
+	if status.Code(err) == codes.NotFound {
+		// the build workspace is gone already
+		err = nil
+	}
+	if err != nil {
+		o.takeCancellation(buildID)
+		return err
+	}
+	return nil
+}
+
+// buildTimeout returns how long a build may run. Requests can shorten the timeout of the installation, but not extend it.
+func (o *Orchestrator) buildTimeout(requested string) (time.Duration, error) {
+	timeout := time.Duration(o.Config.BuildTimeout)
+	if timeout <= 0 {
+		timeout = defaultBuildTimeout
+	}
+	if requested == "" {
+		return timeout, nil
+	}
+
+	t, err := time.ParseDuration(requested)
+	if err != nil || t <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid build timeout %q", requested)
+	}
+	if t < timeout {
+		timeout = t
+	}
+	return timeout, nil
+}
+
 func (o *Orchestrator) checkImageExists(ctx context.Context, ref string, authentication *auth.Authentication) (exists bool, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "checkImageExists")
 	defer tracing.FinishSpan(span, &err)
@@ -612,7 +894,7 @@ func (o *Orchestrator) getAbsoluteImageRef(ctx context.Context, ref string, allo
 		return "", status.Errorf(codes.InvalidArgument, "cannt resolve base image ref: %v", err)
 	}
 
-	ref, err = o.RefResolver.Resolve(ctx, ref, resolve.WithAuthentication(auth))
+	res, err = o.RefResolver.Resolve(ctx, ref, resolve.WithAuthentication(auth))
 	if errors.Is(err, resolve.ErrNotFound) {
 		return "", status.Error(codes.NotFound, "cannot resolve image")
 	}
@@ -622,12 +904,31 @@ func (o *Orchestrator) getAbsoluteImageRef(ctx context.Context, ref string, allo
 		} else if auth.Auth == "" && auth.Password == "" {
 			log.WithField("ref", ref).Warn("auth was empty")
 		}
-		return "", status.Error(codes.Unauthenticated, "cannot resolve image")
+		return "", unauthenticatedError(ref, auth, allowedAuth)
 	}
 	if err != nil {
 		return "", status.Errorf(codes.Internal, "cannot resolve image: %v", err)
 	}
-	return ref, nil
+	return res, nil
+}
+
+// unauthenticatedError explains why we could not access an image, such that users know which credentials to fix
+func unauthenticatedError(ref string, authentication *auth.Authentication, allowedAuth auth.AllowedAuthFor) error {
+	pref, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "cannot resolve image")
+	}
+	reg := reference.Domain(pref)
+	additional, hasAdditional := allowedAuth.Additional[reg]
+
+	switch {
+	case authentication.Empty():
+		return status.Errorf(codes.Unauthenticated, "cannot resolve image %s: the registry %s requires authentication, please add credentials for %s to the GITPOD_IMAGE_AUTH environment variable of your project or organization", ref, reg, reg)
+	case hasAdditional && authentication.Auth == additional:
+		return status.Errorf(codes.Unauthenticated, "cannot resolve image %s: the registry %s rejected the credentials in GITPOD_IMAGE_AUTH of your project or organization, please check that they are valid and grant pull access", ref, reg)
+	default:
+		return status.Errorf(codes.Unauthenticated, "cannot resolve image %s: the registry %s rejected the credentials of this installation", ref, reg)
+	}
 }
 
 func (o *Orchestrator) getBaseImageRef(ctx context.Context, bs *protocol.BuildSource, allowedAuth auth.AllowedAuthFor) (res string, err error) {
@@ -654,6 +955,10 @@ func (o *Orchestrator) getBaseImageRef(ctx context.Context, bs *protocol.BuildSo
 		} else {
 			return "", xerrors.Errorf("unsupported context initializer")
 		}
+		// base images built for the default platform keep their previous ref
+		if platforms := o.buildPlatforms(); len(platforms) > 0 {
+			manifest["Platforms"] = strings.Join(platforms, ",")
+		}
 		// Go maps do NOT maintain their order - we must sort the keys to maintain a stable order
 		var keys []string
 		for k := range manifest {
@@ -706,6 +1011,83 @@ func (o *Orchestrator) getWorkspaceImageRef(ctx context.Context, baseref string)
 	return fmt.Sprintf("%s:%x", o.Config.WorkspaceImageRepository, dst), nil
 }
 
+// getBuildCacheRef returns the registry cache of the build, or an empty string if the build does not use a cache.
+// The scope of the request is hashed into the tag of the cache, such that builds of one scope never import the
+// layers of another.
+func (o *Orchestrator) getBuildCacheRef(cache *protocol.BuildCache) string {
+	if !o.Config.BuildCache.Enabled || cache.GetDisabled() || cache.GetScope() == "" {
+		return ""
+	}
+	repo := o.Config.BuildCache.Repository
+	if repo == "" {
+		repo = o.Config.BaseImageRepository
+	}
+	return fmt.Sprintf("%s:cache-%x", repo, sha256.Sum256([]byte(cache.GetScope())))
+}
+
+// buildPlatforms returns the sorted platforms images are built for, or nil if they're built for the default platform only
+func (o *Orchestrator) buildPlatforms() []string {
+	if len(o.Config.Platforms) == 0 || len(o.Config.Platforms) == 1 && o.Config.Platforms[0] == resolve.DefaultPlatform {
+		return nil
+	}
+	res := make([]string, len(o.Config.Platforms))
+	copy(res, o.Config.Platforms)
+	sort.Strings(res)
+	return res
+}
+
+// buildPlatformEnvvars makes BuildKit build for all platforms and publish a multi-arch image
+func buildPlatformEnvvars(platforms []string) []*wsmanapi.EnvironmentVariable {
+	if len(platforms) == 0 {
+		return nil
+	}
+	return []*wsmanapi.EnvironmentVariable{
+		{Name: "BOB_PLATFORMS", Value: strings.Join(platforms, ",")},
+	}
+}
+
+// buildCacheEnvvars makes the build import and export its layers through the proxy, which confines it to the cache ref
+// credentialHelperRegistries returns the registries of refs which a credential helper provides credentials for.
+// The credential helpers must be allowed for the repositories of the refs.
+// Unlike the pull secret, the build gets these credentials as additional auth, because they expire.
+func (o *Orchestrator) credentialHelperRegistries(refs []string) []string {
+	var res []string
+	for _, ref := range refs {
+		pref, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			continue
+		}
+		reg := reference.Domain(pref)
+		if slices.Contains(res, reg) || !auth.MatchesCredentialHelper(o.Auth, pref) {
+			continue
+		}
+		res = append(res, reg)
+	}
+	return res
+}
+
+// dockerfileImages returns the images the stages of a Dockerfile are based on
+func dockerfileImages(src *protocol.BuildSourceDockerfile) []string {
+	stages, _ := dockerfile.Parse(src.GetDockerfileContent())
+	var res []string
+	for _, stage := range stages {
+		if stage.External {
+			res = append(res, stage.Image)
+		}
+	}
+	return res
+}
+
+func buildCacheEnvvars(cacheref string) []*wsmanapi.EnvironmentVariable {
+	if cacheref == "" {
+		return nil
+	}
+	return []*wsmanapi.EnvironmentVariable{
+		{Name: "BOB_CACHE_REF", Value: "localhost:8080/cache:latest"},
+		{Name: "WORKSPACEKIT_BOBPROXY_CACHEREF", Value: cacheref},
+	}
+}
+
 // parentCantCancelContext is a bit of a hack. We have some operations which we want to keep alive even after clients
 // disconnect. gRPC cancels the context once a client disconnects, thus we intercept the cancelation and act as if
 // nothing had happened.
@@ -743,6 +1125,33 @@ type buildListener chan *api.BuildResponse
 
 type logListener chan *api.LogsResponse
 
+// queuedBuildHandle lets CancelBuild stop a build which waits in the queue
+type queuedBuildHandle struct {
+	ref    string
+	cancel context.CancelFunc
+}
+
+func (o *Orchestrator) registerQueuedBuild(buildID, ref string, cancel context.CancelFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.queuedBuilds[buildID] = queuedBuildHandle{ref: ref, cancel: cancel}
+}
+
+func (o *Orchestrator) unregisterQueuedBuild(buildID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.queuedBuilds, buildID)
+}
+
+// takeCancellation returns and forgets the reason why a build was canceled
+func (o *Orchestrator) takeCancellation(buildID string) (reason string, canceled bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	reason, canceled = o.cancellations[buildID]
+	delete(o.cancellations, buildID)
+	return
+}
+
 func (o *Orchestrator) registerBuildListener(buildID string) (c <-chan *api.BuildResponse, cancel func()) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
@@ -814,24 +1223,18 @@ func (o *Orchestrator) censor(buildID string, words []string) {
 	o.censorship[buildID] = words
 }
 
-// PublishLog broadcasts log output to all registered listener
+// PublishLog adds log output to the log of a build and broadcasts it to all registered listener
 func (o *Orchestrator) PublishLog(buildID string, message string) {
 	o.mu.RLock()
-	listener, ok := o.logListener[buildID]
-	o.mu.RUnlock()
-
-	// we don't have any log listener for this build
-	if !ok {
-		return
-	}
-
-	o.mu.RLock()
+	listener := o.logListener[buildID]
 	wds := o.censorship[buildID]
 	o.mu.RUnlock()
 	for _, w := range wds {
 		message = strings.ReplaceAll(message, w, "")
 	}
 
+	o.buildLogs.Append(buildID, message)
+
 	for l := range listener {
 		select {
 		case l <- &api.LogsResponse{
@@ -11,9 +11,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/image-builder/api"
 	"github.com/gitpod-io/gitpod/image-builder/api/config"
 	apimock "github.com/gitpod-io/gitpod/image-builder/api/mock"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/auth"
 	"github.com/gitpod-io/gitpod/image-builder/pkg/resolve"
 	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
 	wsmock "github.com/gitpod-io/gitpod/ws-manager/api/mock"
@@ -21,6 +23,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestBuild(t *testing.T) {
@@ -205,3 +208,283 @@ func TestResolveBaseImage(t *testing.T) {
 	}
 
 }
+
+func TestUnauthenticatedError(t *testing.T) {
+	ref := "registry.example.com:5000/private/image:latest"
+	projectAuth := auth.AllowedAuthFor{Additional: map[string]string{"registry.example.com:5000": "dXNlcjpwYXNz"}}
+	tests := []struct {
+		Name           string
+		Authentication *auth.Authentication
+		AllowedAuth    auth.AllowedAuthFor
+		Expectation    string
+	}{
+		{
+			Name:        "no credentials",
+			AllowedAuth: auth.AllowedAuthForNone(),
+			Expectation: "cannot resolve image registry.example.com:5000/private/image:latest: the registry registry.example.com:5000 requires authentication, please add credentials for registry.example.com:5000 to the GITPOD_IMAGE_AUTH environment variable of your project or organization",
+		},
+		{
+			Name:           "project credentials",
+			Authentication: &auth.Authentication{Auth: "dXNlcjpwYXNz"},
+			AllowedAuth:    projectAuth,
+			Expectation:    "cannot resolve image registry.example.com:5000/private/image:latest: the registry registry.example.com:5000 rejected the credentials in GITPOD_IMAGE_AUTH of your project or organization, please check that they are valid and grant pull access",
+		},
+		{
+			Name:           "installation credentials",
+			Authentication: &auth.Authentication{Username: "installation", Password: "secret"},
+			AllowedAuth:    projectAuth,
+			Expectation:    "cannot resolve image registry.example.com:5000/private/image:latest: the registry registry.example.com:5000 rejected the credentials of this installation",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := unauthenticatedError(ref, test.Authentication, test.AllowedAuth)
+			if code := status.Code(err); code != codes.Unauthenticated {
+				t.Errorf("expected Unauthenticated, got %v", code)
+			}
+			if diff := cmp.Diff(test.Expectation, status.Convert(err).Message()); diff != "" {
+				t.Errorf("unauthenticatedError() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetBuildCacheRef(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      config.BuildCacheConfig
+		Cache       *api.BuildCache
+		Expectation string
+	}{
+		{
+			Name:        "disabled installation",
+			Cache:       &api.BuildCache{Scope: "project"},
+			Expectation: "",
+		},
+		{
+			Name:        "no scope",
+			Config:      config.BuildCacheConfig{Enabled: true},
+			Expectation: "",
+		},
+		{
+			Name:        "disabled request",
+			Config:      config.BuildCacheConfig{Enabled: true},
+			Cache:       &api.BuildCache{Scope: "project", Disabled: true},
+			Expectation: "",
+		},
+		{
+			Name:        "base image repository",
+			Config:      config.BuildCacheConfig{Enabled: true},
+			Cache:       &api.BuildCache{Scope: "project"},
+			Expectation: "registry/base:cache-244210e48437b6556980a70249a99369934a352429034cef9d7bd253b3bf2c01",
+		},
+		{
+			Name:        "cache repository",
+			Config:      config.BuildCacheConfig{Enabled: true, Repository: "registry/cache"},
+			Cache:       &api.BuildCache{Scope: "project"},
+			Expectation: "registry/cache:cache-244210e48437b6556980a70249a99369934a352429034cef9d7bd253b3bf2c01",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			o := &Orchestrator{Config: config.Configuration{
+				BaseImageRepository: "registry/base",
+				BuildCache:          test.Config,
+			}}
+			act := o.getBuildCacheRef(test.Cache)
+			if act != test.Expectation {
+				t.Errorf("unexpected cache ref: is %q but expected %q", act, test.Expectation)
+			}
+		})
+	}
+}
+
+func TestBuildPlatforms(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Platforms   []string
+		Expectation []string
+	}{
+		{
+			Name: "not configured",
+		},
+		{
+			Name:      "default platform",
+			Platforms: []string{"linux/amd64"},
+		},
+		{
+			Name:        "arm64",
+			Platforms:   []string{"linux/arm64"},
+			Expectation: []string{"linux/arm64"},
+		},
+		{
+			Name:        "multi-arch",
+			Platforms:   []string{"linux/arm64", "linux/amd64"},
+			Expectation: []string{"linux/amd64", "linux/arm64"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			o := &Orchestrator{Config: config.Configuration{Platforms: test.Platforms}}
+			act := o.buildPlatforms()
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("buildPlatforms() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCancelBuild(t *testing.T) {
+	const (
+		buildID = "build-id"
+		ref     = "registry/workspace:some-image"
+		reason  = "workspace was stopped"
+	)
+	tests := []struct {
+		Name        string
+		Request     *api.CancelBuildRequest
+		Running     bool
+		Expectation codes.Code
+	}{
+		{
+			Name:        "no build ID or ref",
+			Request:     &api.CancelBuildRequest{},
+			Expectation: codes.InvalidArgument,
+		},
+		{
+			Name:        "unknown build",
+			Request:     &api.CancelBuildRequest{BuildId: buildID},
+			Expectation: codes.NotFound,
+		},
+		{
+			Name:        "running build by ID",
+			Request:     &api.CancelBuildRequest{BuildId: buildID, Reason: reason},
+			Running:     true,
+			Expectation: codes.OK,
+		},
+		{
+			Name:        "running build by ref",
+			Request:     &api.CancelBuildRequest{BuildRef: ref, Reason: reason},
+			Running:     true,
+			Expectation: codes.OK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			wsman := wsmock.NewMockWorkspaceManagerClient(ctrl)
+			o, err := NewOrchestratingBuilder(config.Configuration{
+				WorkspaceManager: config.WorkspaceManagerConfig{
+					Client: wsman,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.Running {
+				o.monitor.RegisterNewBuild(buildID, ref, "base", "", "")
+				wsman.EXPECT().StopWorkspace(gomock.Any(), &wsmanapi.StopWorkspaceRequest{
+					Id:     buildID,
+					Policy: wsmanapi.StopWorkspacePolicy_IMMEDIATELY,
+				}).Return(&wsmanapi.StopWorkspaceResponse{}, nil)
+			}
+
+			_, err = o.CancelBuild(context.Background(), test.Request)
+			if code := status.Code(err); code != test.Expectation {
+				t.Fatalf("expected %v, got %v", test.Expectation, err)
+			}
+			if !test.Running {
+				return
+			}
+
+			// the build fails once its workspace stopped, regardless of how far it got
+			updates, cancel := o.registerBuildListener(buildID)
+			defer cancel()
+			go o.PublishStatus(buildID, &api.BuildResponse{Ref: ref, Status: api.BuildStatus_done_success})
+			update := <-updates
+			if diff := cmp.Diff(&api.BuildResponse{Ref: ref, Status: api.BuildStatus_done_failure, Message: reason}, update, cmp.Comparer(proto.Equal)); diff != "" {
+				t.Errorf("unexpected build update (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCancelQueuedBuild(t *testing.T) {
+	o, err := NewOrchestratingBuilder(config.Configuration{
+		WorkspaceManager: config.WorkspaceManagerConfig{
+			Client: wsmock.NewMockWorkspaceManagerClient(gomock.NewController(t)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.registerQueuedBuild("build-id", "registry/workspace:some-image", cancel)
+
+	_, err = o.CancelBuild(context.Background(), &api.CancelBuildRequest{BuildRef: "registry/workspace:some-image"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() == nil {
+		t.Error("queued build was not canceled")
+	}
+	if reason, canceled := o.takeCancellation("build-id"); !canceled || reason != defaultCancelReason {
+		t.Errorf("unexpected cancellation: %q, %v", reason, canceled)
+	}
+}
+
+func TestBuildTimeout(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      time.Duration
+		Requested   string
+		Expectation time.Duration
+		Code        codes.Code
+	}{
+		{
+			Name:        "default",
+			Expectation: defaultBuildTimeout,
+		},
+		{
+			Name:        "configured",
+			Config:      2 * time.Hour,
+			Expectation: 2 * time.Hour,
+		},
+		{
+			Name:        "shorter request",
+			Requested:   "30m",
+			Expectation: 30 * time.Minute,
+		},
+		{
+			Name:        "longer request",
+			Config:      2 * time.Hour,
+			Requested:   "3h",
+			Expectation: 2 * time.Hour,
+		},
+		{
+			Name:      "invalid request",
+			Requested: "forever",
+			Code:      codes.InvalidArgument,
+		},
+		{
+			Name:      "negative request",
+			Requested: "-5m",
+			Code:      codes.InvalidArgument,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			o := &Orchestrator{Config: config.Configuration{BuildTimeout: util.Duration(test.Config)}}
+			act, err := o.buildTimeout(test.Requested)
+			if code := status.Code(err); code != test.Code {
+				t.Fatalf("expected %v, got %v", test.Code, err)
+			}
+			if act != test.Expectation {
+				t.Errorf("unexpected timeout: is %v but expected %v", act, test.Expectation)
+			}
+		})
+	}
+}
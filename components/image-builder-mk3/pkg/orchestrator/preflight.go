@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	protocol "github.com/gitpod-io/gitpod/image-builder/api"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/auth"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/dockerfile"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/resolve"
+)
+
+// preflight checks the Dockerfile of a build before the build starts, such that mistakes and base images which
+// cannot be pulled fail the build right away, rather than minutes later in BuildKit. Dockerfiles whose base image
+// exists already are not built again, hence they are not checked either.
+func (o *Orchestrator) preflight(ctx context.Context, src *protocol.BuildSourceDockerfile, baseref string, allowedAuth auth.AllowedAuthFor) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "preflight")
+	defer tracing.FinishSpan(span, &err)
+
+	if src.GetDockerfileContent() == "" {
+		return nil
+	}
+
+	baseAuth, err := auth.AllowedAuthForAll().GetAuthFor(ctx, o.Auth, baseref)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot get base image authentication: %q", err)
+	}
+	exists, err := o.checkImageExists(ctx, baseref, baseAuth)
+	if err != nil {
+		log.WithError(err).WithField("baseref", baseref).Warn("cannot check if base image exists - checking its Dockerfile anyways")
+	}
+	if exists {
+		return nil
+	}
+
+	stages, problems := dockerfile.Parse(src.DockerfileContent)
+	for i, stage := range stages {
+		if !stage.External {
+			continue
+		}
+		if _, err := reference.ParseNormalizedNamed(stage.Image); err != nil {
+			problems = append(problems, dockerfile.Problem{Line: stage.Line, Message: fmt.Sprintf("%q is not a valid image reference", stage.Image)})
+			stages[i].External = false
+		}
+	}
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+		return dockerfileError(src.DockerfilePath, problems)
+	}
+
+	// stages often share their image, e.g. a build and a runtime stage based on the same image, which is
+	// resolved only once
+	resolved := make(map[string]error)
+	for _, stage := range stages {
+		if !stage.External {
+			continue
+		}
+		err = o.checkBaseImage(ctx, src.DockerfilePath, stage, allowedAuth, resolved)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBaseImage makes sure the image of a build stage exists, can be pulled, and is available for the platforms we build for.
+// The outcome of resolving the image is kept in resolved.
+func (o *Orchestrator) checkBaseImage(ctx context.Context, dockerfilePath string, stage dockerfile.Stage, allowedAuth auth.AllowedAuthFor, resolved map[string]error) error {
+	authentication, err := allowedAuth.GetAuthFor(ctx, o.Auth, stage.Image)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "cannot resolve base image ref: %v", err)
+	}
+
+	err, ok := resolved[stage.Image]
+	if !ok {
+		_, err = o.RefResolver.Resolve(ctx, stage.Image, resolve.WithAuthentication(authentication))
+		resolved[stage.Image] = err
+	}
+	var platformErr *resolve.PlatformNotFoundError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, resolve.ErrNotFound):
+		return status.Errorf(codes.NotFound, "cannot resolve image %s in line %d of %s: the image does not exist", stage.Image, stage.Line, dockerfilePath)
+	case errors.Is(err, resolve.ErrUnauthorized):
+		msg := status.Convert(unauthenticatedError(stage.Image, authentication, allowedAuth)).Message()
+		return status.Errorf(codes.Unauthenticated, "%s (line %d of %s)", msg, stage.Line, dockerfilePath)
+	case errors.As(err, &platformErr):
+		if stage.Platform != "" {
+			// the stage chooses its platform itself
+			return nil
+		}
+		return status.Errorf(codes.FailedPrecondition, "cannot resolve image %s in line %d of %s: the image is not available for platform %s", stage.Image, stage.Line, dockerfilePath, platformErr.Platform)
+	default:
+		// the build will tell whether this was more than a hiccup
+		log.WithError(err).WithField("ref", stage.Image).Warn("cannot check base image before the build")
+		return nil
+	}
+}
+
+// dockerfileError lists the problems of a Dockerfile, one per line
+func dockerfileError(dockerfilePath string, problems []dockerfile.Problem) error {
+	lines := make([]string, 0, len(problems))
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	return status.Errorf(codes.InvalidArgument, "invalid Dockerfile %s:\n%s", dockerfilePath, strings.Join(lines, "\n"))
+}
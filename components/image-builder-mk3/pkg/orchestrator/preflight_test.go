@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/image-builder/api"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/auth"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/resolve"
+)
+
+// preflightResolver resolves refs to the error it has for them. Refs it has no error for resolve successfully.
+type preflightResolver map[string]error
+
+func (r preflightResolver) Resolve(ctx context.Context, ref string, opts ...resolve.DockerRefResolverOption) (string, error) {
+	return ref, r[ref]
+}
+
+// countingResolver counts how often each ref is resolved
+type countingResolver map[string]int
+
+func (r countingResolver) Resolve(ctx context.Context, ref string, opts ...resolve.DockerRefResolverOption) (string, error) {
+	r[ref]++
+	if ref == "registry/base:some-hash" {
+		return "", resolve.ErrNotFound
+	}
+	return ref, nil
+}
+
+func TestPreflightResolvesImagesOnce(t *testing.T) {
+	resolver := make(countingResolver)
+	o := &Orchestrator{RefResolver: resolver}
+	err := o.preflight(context.Background(), &api.BuildSourceDockerfile{
+		DockerfilePath:    ".gitpod.Dockerfile",
+		DockerfileContent: "FROM ubuntu:22.04 AS build\nFROM ubuntu:22.04\nFROM --platform=linux/amd64 ubuntu:22.04\nFROM alpine:3",
+	}, "registry/base:some-hash", auth.AllowedAuthForNone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(countingResolver{"registry/base:some-hash": 1, "ubuntu:22.04": 1, "alpine:3": 1}, resolver); diff != "" {
+		t.Errorf("unexpected resolutions (-want +got):\n%s", diff)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	const baseref = "registry/base:some-hash"
+	type Expectation struct {
+		Code    codes.Code
+		Message string
+	}
+	tests := []struct {
+		Name        string
+		Dockerfile  string
+		Resolver    preflightResolver
+		Expectation Expectation
+	}{
+		{
+			Name:       "no content",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound},
+			Dockerfile: "",
+		},
+		{
+			Name:       "base image exists",
+			Dockerfile: "FORM ubuntu:22.04",
+		},
+		{
+			Name:       "valid",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound},
+			Dockerfile: "FROM ubuntu:22.04 AS build\nFROM build\nFROM scratch",
+		},
+		{
+			Name:       "typos",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound},
+			Dockerfile: "FORM ubuntu:22.04\nRUN echo hello\nFROM Ubuntu:22.04",
+			Expectation: Expectation{
+				Code:    codes.InvalidArgument,
+				Message: "invalid Dockerfile .gitpod.Dockerfile:\nline 1: unknown instruction \"FORM\", did you mean \"FROM\"?\nline 2: RUN comes before the first FROM instruction, which must start the Dockerfile\nline 3: \"Ubuntu:22.04\" is not a valid image reference",
+			},
+		},
+		{
+			Name:       "missing image",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound, "ubuntu:2204": resolve.ErrNotFound},
+			Dockerfile: "FROM ubuntu:22.04\nFROM ubuntu:2204",
+			Expectation: Expectation{
+				Code:    codes.NotFound,
+				Message: "cannot resolve image ubuntu:2204 in line 2 of .gitpod.Dockerfile: the image does not exist",
+			},
+		},
+		{
+			Name:       "private image",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound, "registry.example.com/private:latest": resolve.ErrUnauthorized},
+			Dockerfile: "FROM registry.example.com/private:latest",
+			Expectation: Expectation{
+				Code:    codes.Unauthenticated,
+				Message: "cannot resolve image registry.example.com/private:latest: the registry registry.example.com requires authentication, please add credentials for registry.example.com to the GITPOD_IMAGE_AUTH environment variable of your project or organization (line 1 of .gitpod.Dockerfile)",
+			},
+		},
+		{
+			Name:       "missing platform",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound, "amd64-only:latest": &resolve.PlatformNotFoundError{Platform: "linux/arm64"}},
+			Dockerfile: "FROM amd64-only:latest",
+			Expectation: Expectation{
+				Code:    codes.FailedPrecondition,
+				Message: "cannot resolve image amd64-only:latest in line 1 of .gitpod.Dockerfile: the image is not available for platform linux/arm64",
+			},
+		},
+		{
+			Name:       "explicit platform",
+			Resolver:   preflightResolver{baseref: resolve.ErrNotFound, "amd64-only:latest": &resolve.PlatformNotFoundError{Platform: "linux/arm64"}},
+			Dockerfile: "FROM --platform=linux/amd64 amd64-only:latest",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			o := &Orchestrator{RefResolver: test.Resolver}
+			err := o.preflight(context.Background(), &api.BuildSourceDockerfile{
+				DockerfilePath:    ".gitpod.Dockerfile",
+				DockerfileContent: test.Dockerfile,
+			}, baseref, auth.AllowedAuthForNone())
+
+			act := Expectation{Code: status.Code(err)}
+			if err != nil {
+				act.Message = status.Convert(err).Message()
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("preflight() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/image-builder/api/config"
+)
+
+// buildScheduler limits the number of builds which run at the same time, globally and per organization.
+// Builds beyond the limits wait in a queue. Whenever a build can start, the queue favours the organizations
+// with the fewest running builds, and otherwise the builds which waited the longest.
+//
+// Builds hold their slot until they are released, which happens once their build workspace stopped.
+// Builds which were running before image-builder restarted are not counted against the limits.
+type buildScheduler struct {
+	cfg     config.QueueConfig
+	metrics *metrics
+
+	mu      sync.Mutex
+	running map[string]string
+	perOrg  map[string]int
+	queue   []*queuedBuild
+}
+
+type queuedBuild struct {
+	buildID  string
+	org      string
+	queuedAt time.Time
+
+	// granted is closed once the build may start
+	granted chan struct{}
+	// position holds the latest position of the build in the queue
+	position     chan int
+	lastPosition int
+}
+
+func newBuildScheduler(cfg config.QueueConfig, metrics *metrics) *buildScheduler {
+	return &buildScheduler{
+		cfg:     cfg,
+		metrics: metrics,
+		running: make(map[string]string),
+		perOrg:  make(map[string]int),
+	}
+}
+
+// Acquire waits until the build may start. While the build waits, onQueued is called with its position in the queue
+// whenever it changes. Once Acquire returns without error, the build holds its slot until Release is called.
+func (s *buildScheduler) Acquire(ctx context.Context, buildID, org string, onQueued func(position int)) error {
+	b := &queuedBuild{
+		buildID:  buildID,
+		org:      org,
+		queuedAt: time.Now(),
+		granted:  make(chan struct{}),
+		position: make(chan int, 1),
+	}
+	s.mu.Lock()
+	s.queue = append(s.queue, b)
+	s.schedule()
+	s.mu.Unlock()
+
+	for {
+		select {
+		case <-b.granted:
+			return nil
+		case pos := <-b.position:
+			onQueued(pos)
+		case <-ctx.Done():
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			select {
+			case <-b.granted:
+				// the build was granted its slot just now, but won't use it
+				s.release(buildID)
+			default:
+				s.dequeue(b)
+			}
+			s.schedule()
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees the slot of a build, such that the next build in the queue can start.
+// Releasing a build which holds no slot is a no-op.
+func (s *buildScheduler) Release(buildID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.release(buildID) {
+		s.schedule()
+	}
+}
+
+func (s *buildScheduler) release(buildID string) bool {
+	org, ok := s.running[buildID]
+	if !ok {
+		return false
+	}
+	delete(s.running, buildID)
+	s.perOrg[org]--
+	if s.perOrg[org] <= 0 {
+		delete(s.perOrg, org)
+	}
+	s.metrics.runningBuilds.Set(float64(len(s.running)))
+	return true
+}
+
+func (s *buildScheduler) dequeue(b *queuedBuild) {
+	for i, q := range s.queue {
+		if q == b {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			break
+		}
+	}
+	s.metrics.queuedBuilds.Set(float64(len(s.queue)))
+}
+
+// schedule starts the builds which fit the limits and tells the remaining builds their position.
+// Must be called with mu held.
+func (s *buildScheduler) schedule() {
+	order := s.order()
+	waiting := order[:0]
+	for _, b := range order {
+		if !s.fits(b.org) {
+			waiting = append(waiting, b)
+			continue
+		}
+
+		s.running[b.buildID] = b.org
+		s.perOrg[b.org]++
+		s.metrics.queueDuration.Observe(time.Since(b.queuedAt).Seconds())
+		close(b.granted)
+	}
+
+	queue := s.queue[:0]
+	for _, b := range s.queue {
+		select {
+		case <-b.granted:
+		default:
+			queue = append(queue, b)
+		}
+	}
+	s.queue = queue
+
+	for i, b := range waiting {
+		if b.lastPosition == i+1 {
+			continue
+		}
+		b.lastPosition = i + 1
+
+		// replace the position the build has not picked up yet
+		select {
+		case <-b.position:
+		default:
+		}
+		b.position <- i + 1
+	}
+	s.metrics.queuedBuilds.Set(float64(len(s.queue)))
+	s.metrics.runningBuilds.Set(float64(len(s.running)))
+}
+
+// fits returns true if another build of the organization can start. Must be called with mu held.
+func (s *buildScheduler) fits(org string) bool {
+	if s.cfg.MaxConcurrentBuilds > 0 && len(s.running) >= s.cfg.MaxConcurrentBuilds {
+		return false
+	}
+	if s.cfg.MaxConcurrentBuildsPerOrganization > 0 && s.perOrg[org] >= s.cfg.MaxConcurrentBuildsPerOrganization {
+		return false
+	}
+	return true
+}
+
+// order returns the queued builds in the order they start: the n-th queued build of an organization starts
+// as if the organization already ran n more builds, hence organizations take turns. Must be called with mu held.
+func (s *buildScheduler) order() []*queuedBuild {
+	var (
+		res  = make([]*queuedBuild, len(s.queue))
+		turn = make(map[*queuedBuild]int, len(s.queue))
+		nth  = make(map[string]int)
+	)
+	for i, b := range s.queue {
+		res[i] = b
+		turn[b] = s.perOrg[b.org] + nth[b.org]
+		nth[b.org]++
+	}
+	sort.SliceStable(res, func(i, j int) bool { return turn[res[i]] < turn[res[j]] })
+	return res
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitpod-io/gitpod/image-builder/api/config"
+)
+
+// enqueueBuilds queues builds one after another, named after their organization, e.g. a1 for organization a
+func enqueueBuilds(ctx context.Context, t *testing.T, s *buildScheduler, ids []string, onStart func(id string), onQueued func(id string, position int)) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		s.mu.Lock()
+		n := len(s.queue)
+		s.mu.Unlock()
+
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			err := s.Acquire(ctx, id, id[:1], func(position int) { onQueued(id, position) })
+			if err == nil {
+				onStart(id)
+			}
+		}(id)
+
+		// wait for the build to be queued, such that the builds queue in order
+		for start := time.Now(); ; time.Sleep(time.Millisecond) {
+			s.mu.Lock()
+			l := len(s.queue)
+			s.mu.Unlock()
+			if l > n {
+				break
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("build %s was not queued", id)
+			}
+		}
+	}
+	return &wg
+}
+
+func TestBuildSchedulerFairness(t *testing.T) {
+	s := newBuildScheduler(config.QueueConfig{MaxConcurrentBuilds: 2, MaxConcurrentBuildsPerOrganization: 2}, newMetrics())
+	for _, id := range []string{"a1", "a2"} {
+		err := s.Acquire(context.Background(), id, "a", func(position int) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// one organization triggers many builds before another one triggers its first
+	started := make(chan string, 5)
+	wg := enqueueBuilds(context.Background(), t, s, []string{"a3", "a4", "a5", "b1", "b2"},
+		func(id string) { started <- id },
+		func(id string, position int) {},
+	)
+
+	var (
+		running = []string{"a1", "a2"}
+		order   []string
+	)
+	for len(order) < 5 {
+		s.Release(running[0])
+		select {
+		case id := <-started:
+			running = append(running[1:], id)
+			order = append(order, id)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no build started after %v", order)
+		}
+	}
+	wg.Wait()
+
+	if diff := cmp.Diff([]string{"b1", "a3", "b2", "a4", "a5"}, order); diff != "" {
+		t.Errorf("unexpected build order (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildSchedulerQueue(t *testing.T) {
+	s := newBuildScheduler(config.QueueConfig{MaxConcurrentBuilds: 1}, newMetrics())
+	err := s.Acquire(context.Background(), "a0", "a", func(position int) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu        sync.Mutex
+		positions = make(map[string]int)
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := enqueueBuilds(ctx, t, s, []string{"a1", "a2", "a3", "b1", "c1", "b2"},
+		func(id string) { t.Errorf("build %s started while the limit is reached", id) },
+		func(id string, position int) {
+			mu.Lock()
+			positions[id] = position
+			mu.Unlock()
+		},
+	)
+
+	// organizations which run fewer builds go first, and organizations take turns
+	expectation := map[string]int{"b1": 1, "c1": 2, "a1": 3, "b2": 4, "a2": 5, "a3": 6}
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		mu.Lock()
+		diff := cmp.Diff(expectation, positions)
+		mu.Unlock()
+		if diff == "" {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("unexpected queue positions (-want +got):\n%s", diff)
+		}
+	}
+
+	// builds whose client is gone leave the queue
+	cancel()
+	wg.Wait()
+	s.Release("a0")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) != 0 || len(s.running) != 0 {
+		t.Errorf("expected no queued or running builds: %d queued, %d running", len(s.queue), len(s.running))
+	}
+}
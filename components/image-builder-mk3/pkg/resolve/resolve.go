@@ -30,13 +30,29 @@ var (
 	// ErrNotFound is returned when the reference was not found
 	ErrNotFound = xerrors.Errorf("not found")
 
-	// ErrNotFound is returned when we're not authorized to return the reference
+	// ErrUnauthorized is returned when we're not authorized to return the reference
 	ErrUnauthorized = xerrors.Errorf("not authorized")
 )
 
+// DefaultPlatform is the platform images are resolved for unless configured otherwise
+const DefaultPlatform = "linux/amd64"
+
+// PlatformNotFoundError is returned when an image index has no manifest for a platform images are resolved for
+type PlatformNotFoundError struct {
+	Platform string
+}
+
+func (e *PlatformNotFoundError) Error() string {
+	return fmt.Sprintf("no manifest for platform %s found", strings.ReplaceAll(e.Platform, "/", "-"))
+}
+
 // StandaloneRefResolver can resolve image references without a Docker daemon
 type StandaloneRefResolver struct {
 	ResolverFactory func() remotes.Resolver
+
+	// Platforms are the platforms images are resolved for. An image index resolves to the manifest of
+	// the only platform, or to the index itself if there's more than one platform. Defaults to DefaultPlatform.
+	Platforms []string
 }
 
 // Resolve resolves a mutable Docker tag to its absolute digest form by asking the corresponding Docker registry
@@ -82,7 +98,8 @@ func (sr *StandaloneRefResolver) Resolve(ctx context.Context, ref string, opts .
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			err = ErrNotFound
-		} else if strings.Contains(err.Error(), "Unauthorized") {
+		} else if strings.Contains(err.Error(), "Unauthorized") || strings.Contains(err.Error(), "Forbidden") {
+			// some registries answer with 403 Forbidden rather than 401 Unauthorized if the credentials lack pull access
 			err = ErrUnauthorized
 		}
 		return
@@ -122,18 +139,28 @@ func (sr *StandaloneRefResolver) Resolve(ctx context.Context, ref string, opts .
 		return
 	}
 
-	var dgst digest.Digest
-	for _, mf := range mfl.Manifests {
-		if mf.Platform == nil {
-			continue
+	if len(sr.Platforms) > 1 {
+		// multi-arch images keep all their platforms - the registry serves each node the manifest of its platform.
+		// Builds need the image for every platform though.
+		for _, platform := range sr.Platforms {
+			if findPlatformManifest(&mfl, platform) == "" {
+				return "", &PlatformNotFoundError{Platform: platform}
+			}
 		}
-		if fmt.Sprintf("%s-%s", mf.Platform.OS, mf.Platform.Architecture) == "linux-amd64" {
-			dgst = mf.Digest
-			break
+		pref, err = reference.WithDigest(pref, desc.Digest)
+		if err != nil {
+			return
 		}
+		return pref.String(), nil
+	}
+
+	platform := DefaultPlatform
+	if len(sr.Platforms) == 1 {
+		platform = sr.Platforms[0]
 	}
+	dgst := findPlatformManifest(&mfl, platform)
 	if dgst == "" {
-		return "", fmt.Errorf("no manifest for platform linux-amd64 found")
+		return "", &PlatformNotFoundError{Platform: platform}
 	}
 
 	pref, err = reference.WithDigest(pref, dgst)
@@ -143,6 +170,21 @@ func (sr *StandaloneRefResolver) Resolve(ctx context.Context, ref string, opts .
 	return pref.String(), nil
 }
 
+// findPlatformManifest returns the digest of the manifest for platform, e.g. linux/amd64, or an empty digest
+// if the index has no such manifest.
+func findPlatformManifest(mfl *ociv1.Index, platform string) digest.Digest {
+	platform = strings.ReplaceAll(platform, "/", "-")
+	for _, mf := range mfl.Manifests {
+		if mf.Platform == nil {
+			continue
+		}
+		if fmt.Sprintf("%s-%s", mf.Platform.OS, mf.Platform.Architecture) == platform {
+			return mf.Digest
+		}
+	}
+	return ""
+}
+
 type opts struct {
 	Auth *auth.Authentication
 }
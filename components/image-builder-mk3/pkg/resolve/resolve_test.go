@@ -25,6 +25,20 @@ import (
 )
 
 func TestStandaloneRefResolverResolve(t *testing.T) {
+	multiArchIndex := &ociv1.Index{
+		Manifests: []ociv1.Descriptor{
+			{
+				MediaType: ociv1.MediaTypeImageManifest,
+				Digest:    digest.FromString("amd64"),
+				Platform:  &ociv1.Platform{Architecture: "amd64", OS: "linux"},
+			},
+			{
+				MediaType: ociv1.MediaTypeImageManifest,
+				Digest:    digest.FromString("arm64"),
+				Platform:  &ociv1.Platform{Architecture: "arm64", OS: "linux"},
+			},
+		},
+	}
 	type Expectation struct {
 		Ref   string
 		Error string
@@ -41,6 +55,7 @@ func TestStandaloneRefResolverResolve(t *testing.T) {
 		ResolveResponse ResolveResponse
 		Expectation     Expectation
 		Ref             string
+		Platforms       []string
 	}{
 		{
 			Name: "basic resolve",
@@ -96,6 +111,42 @@ func TestStandaloneRefResolverResolve(t *testing.T) {
 			},
 			Expectation: Expectation{Ref: "docker.io/library/alpine@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
 		},
+		{
+			Name:      "arm64 index",
+			Ref:       "docker.io/library/alpine:latest",
+			Platforms: []string{"linux/arm64"},
+			ResolveResponse: ResolveResponse{
+				Index: multiArchIndex,
+			},
+			Expectation: Expectation{Ref: "docker.io/library/alpine@" + digest.FromString("arm64").String()},
+		},
+		{
+			Name:      "multi-arch index",
+			Ref:       "docker.io/library/alpine:latest",
+			Platforms: []string{"linux/amd64", "linux/arm64"},
+			ResolveResponse: ResolveResponse{
+				Index: multiArchIndex,
+			},
+			Expectation: Expectation{Ref: "docker.io/library/alpine@sha256:0a91a291e6839db805e54574950b3178a7148cf3636ea5a9441c5b5c1c8a2e4d"},
+		},
+		{
+			Name:      "missing platform",
+			Ref:       "docker.io/library/alpine:latest",
+			Platforms: []string{"linux/riscv64"},
+			ResolveResponse: ResolveResponse{
+				Index: multiArchIndex,
+			},
+			Expectation: Expectation{Error: "no manifest for platform linux-riscv64 found"},
+		},
+		{
+			Name:      "multi-arch index with missing platform",
+			Ref:       "docker.io/library/alpine:latest",
+			Platforms: []string{"linux/amd64", "linux/riscv64"},
+			ResolveResponse: ResolveResponse{
+				Index: multiArchIndex,
+			},
+			Expectation: Expectation{Error: "no manifest for platform linux-riscv64 found"},
+		},
 		{
 			Name: "not authorized",
 			Ref:  "registry-1.testing.gitpod-self-hosted.com:5000/gitpod/gitpod/workspace-full:latest",
@@ -106,6 +157,16 @@ func TestStandaloneRefResolverResolve(t *testing.T) {
 				Error: resolve.ErrUnauthorized.Error(),
 			},
 		},
+		{
+			Name: "forbidden",
+			Ref:  "registry-1.testing.gitpod-self-hosted.com:5000/gitpod/gitpod/workspace-full:latest",
+			ResolveResponse: ResolveResponse{
+				Error: errors.New("unexpected status from HEAD request: 403 Forbidden"),
+			},
+			Expectation: Expectation{
+				Error: resolve.ErrUnauthorized.Error(),
+			},
+		},
 		{
 			Name: "not found",
 			Ref:  "something.com/we/dont:find",
@@ -188,7 +249,7 @@ func TestStandaloneRefResolverResolve(t *testing.T) {
 				return resolver
 			}
 
-			sr := &resolve.StandaloneRefResolver{ResolverFactory: factory}
+			sr := &resolve.StandaloneRefResolver{ResolverFactory: factory, Platforms: test.Platforms}
 			ref, err := sr.Resolve(context.Background(), test.Ref)
 			act := Expectation{Ref: ref}
 			if err != nil {
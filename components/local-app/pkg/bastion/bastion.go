@@ -65,6 +65,7 @@ type TunnelListener struct {
 	LocalAddr  string
 	LocalPort  uint32
 	Visibility supervisor.TunnelVisiblity
+	Protocol   supervisor.TunnelProtocol
 	Ctx        context.Context
 	Cancel     func()
 }
@@ -603,30 +604,14 @@ func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix
 				defer logrus.WithField("workspace", ws.WorkspaceID).Debug(logprefix + ": connection closed")
 				defer conn.Close()
 
-				clientCh := make(chan *TunnelClient, 1)
-				select {
-				case <-listenerCtx.Done():
-					return
-				case ws.tunnelClient <- clientCh:
-				}
-				client := <-clientCh
-
-				payload, err := proto.Marshal(&supervisor.TunnelPortRequest{
-					ClientId:   client.ID,
+				sshChan, err := b.openTunnelChannel(listenerCtx, ws, logprefix, &supervisor.TunnelPortRequest{
 					Port:       uint32(remotePort),
 					TargetPort: uint32(localPort),
 				})
 				if err != nil {
-					logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("id", client.ID).Error(logprefix + ": failed to marshal tunnel payload")
-					return
-				}
-				sshChan, reqs, err := client.Conn.OpenChannel("tunnel", payload)
-				if err != nil {
-					logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("id", client.ID).Warn(logprefix + ": failed to establish tunnel")
 					return
 				}
 				defer sshChan.Close()
-				go ssh.DiscardRequests(reqs)
 
 				ctx, cancel := context.WithCancel(listenerCtx)
 				go func() {
@@ -646,11 +631,144 @@ func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix
 		LocalAddr:  netListener.Addr().String(),
 		LocalPort:  uint32(localPort),
 		Visibility: visibility,
+		Protocol:   supervisor.TunnelProtocol_tcp,
+		Ctx:        listenerCtx,
+		Cancel:     cancel,
+	}, nil
+}
+
+// establishUDPTunnel forwards datagrams received on a local UDP socket to a remote port.
+// Each local peer gets its own tunnel, which is closed after it has been idle for udpSessionTimeout.
+func (b *Bastion) establishUDPTunnel(ctx context.Context, ws *Workspace, logprefix string, remotePort int, targetPort int, visibility supervisor.TunnelVisiblity) (*TunnelListener, error) {
+	if !ws.tunnelClientConnected {
+		return nil, xerrors.Errorf("tunnel client is not connected")
+	}
+	if visibility == supervisor.TunnelVisiblity_none {
+		return nil, xerrors.Errorf("tunnel visibility is none")
+	}
+
+	targetHost := "127.0.0.1"
+	if visibility == supervisor.TunnelVisiblity_network {
+		targetHost = "0.0.0.0"
+	}
+
+	packetConn, err := net.ListenPacket("udp", targetHost+":"+strconv.Itoa(targetPort))
+	if err != nil {
+		packetConn, err = net.ListenPacket("udp", targetHost+":0")
+		if err != nil {
+			return nil, err
+		}
+	}
+	localPort := packetConn.LocalAddr().(*net.UDPAddr).Port
+	logrus.WithField("workspace", ws.WorkspaceID).Info(logprefix + ": listening on udp " + packetConn.LocalAddr().String() + "...")
+	listenerCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-listenerCtx.Done()
+		packetConn.Close()
+		logrus.WithField("workspace", ws.WorkspaceID).Info(logprefix + ": closed")
+	}()
+	go func() {
+		var (
+			mu       sync.Mutex
+			sessions = make(map[string]*udpSession)
+		)
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := packetConn.ReadFrom(buf)
+			if listenerCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Warn(logprefix + ": failed to read datagram")
+				continue
+			}
+
+			mu.Lock()
+			session, exists := sessions[addr.String()]
+			mu.Unlock()
+			if !exists {
+				sshChan, err := b.openTunnelChannel(listenerCtx, ws, logprefix, &supervisor.TunnelPortRequest{
+					Port:       uint32(remotePort),
+					TargetPort: uint32(localPort),
+					Protocol:   supervisor.TunnelProtocol_udp,
+				})
+				if err != nil {
+					continue
+				}
+				logrus.WithField("workspace", ws.WorkspaceID).WithField("peer", addr.String()).Debug(logprefix + ": opened udp session")
+				session = newUDPSession(sshChan)
+				mu.Lock()
+				sessions[addr.String()] = session
+				mu.Unlock()
+
+				go func(addr net.Addr) {
+					defer logrus.WithField("workspace", ws.WorkspaceID).WithField("peer", addr.String()).Debug(logprefix + ": udp session closed")
+					defer func() {
+						mu.Lock()
+						delete(sessions, addr.String())
+						mu.Unlock()
+						session.Close()
+					}()
+
+					rbuf := make([]byte, maxDatagramSize)
+					for {
+						n, err := readDatagram(session.ch, rbuf)
+						if err != nil {
+							return
+						}
+						session.touch()
+						_, err = packetConn.WriteTo(rbuf[:n], addr)
+						if err != nil {
+							return
+						}
+					}
+				}(addr)
+			}
+
+			session.touch()
+			err = writeDatagram(session.ch, buf[:n])
+			if err != nil {
+				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("peer", addr.String()).Warn(logprefix + ": failed to forward datagram")
+				session.Close()
+			}
+		}
+	}()
+	return &TunnelListener{
+		RemotePort: uint32(remotePort),
+		LocalAddr:  packetConn.LocalAddr().String(),
+		LocalPort:  uint32(localPort),
+		Visibility: visibility,
+		Protocol:   supervisor.TunnelProtocol_udp,
 		Ctx:        listenerCtx,
 		Cancel:     cancel,
 	}, nil
 }
 
+// openTunnelChannel opens an SSH channel to the supervisor which is connected to the remote port described by req.
+func (b *Bastion) openTunnelChannel(ctx context.Context, ws *Workspace, logprefix string, req *supervisor.TunnelPortRequest) (ssh.Channel, error) {
+	clientCh := make(chan *TunnelClient, 1)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case ws.tunnelClient <- clientCh:
+	}
+	client := <-clientCh
+
+	req.ClientId = client.ID
+	payload, err := proto.Marshal(req)
+	if err != nil {
+		logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("id", client.ID).Error(logprefix + ": failed to marshal tunnel payload")
+		return nil, err
+	}
+	sshChan, reqs, err := client.Conn.OpenChannel("tunnel", payload)
+	if err != nil {
+		logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("id", client.ID).Warn(logprefix + ": failed to establish tunnel")
+		return nil, err
+	}
+	go ssh.DiscardRequests(reqs)
+	return sshChan, nil
+}
+
 func (b *Bastion) establishSSHTunnel(ws *Workspace) (listener *TunnelListener, err error) {
 	if ws.SSHPublicKey == "" {
 		return nil, xerrors.Errorf("no public key generated")
@@ -785,11 +903,13 @@ func (b *Bastion) doTunnelPorts(ctx context.Context, ws *Workspace) error {
 		currentTunneled := make(map[uint32]struct{})
 		for _, port := range resp.Ports {
 			visibility := supervisor.TunnelVisiblity_none
+			protocol := supervisor.TunnelProtocol_tcp
 			if port.Tunneled != nil {
 				visibility = port.Tunneled.Visibility
+				protocol = port.Tunneled.Protocol
 			}
 			listener, alreadyTunneled := ws.tunnelListeners[port.LocalPort]
-			if alreadyTunneled && listener.Visibility != visibility {
+			if alreadyTunneled && (listener.Visibility != visibility || listener.Protocol != protocol) {
 				listener.Cancel()
 				delete(ws.tunnelListeners, port.LocalPort)
 			}
@@ -807,7 +927,11 @@ func (b *Bastion) doTunnelPorts(ctx context.Context, ws *Workspace) error {
 			}
 
 			logprefix := "tunnel[" + supervisor.TunnelVisiblity_name[int32(port.Tunneled.Visibility)] + ":" + strconv.Itoa(int(port.LocalPort)) + "]"
-			listener, err := b.establishTunnel(ws.ctx, ws, logprefix, int(port.LocalPort), int(port.Tunneled.TargetPort), port.Tunneled.Visibility)
+			if protocol == supervisor.TunnelProtocol_udp {
+				listener, err = b.establishUDPTunnel(ws.ctx, ws, logprefix, int(port.LocalPort), int(port.Tunneled.TargetPort), port.Tunneled.Visibility)
+			} else {
+				listener, err = b.establishTunnel(ws.ctx, ws, logprefix, int(port.LocalPort), int(port.Tunneled.TargetPort), port.Tunneled.Visibility)
+			}
 			if err != nil {
 				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("port", port.LocalPort).Error("cannot establish port tunnel")
 			} else {
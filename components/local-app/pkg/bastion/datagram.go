@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package bastion
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// maxDatagramSize is the largest UDP payload which can be forwarded through a tunnel.
+	maxDatagramSize = 0xFFFF
+
+	// udpSessionTimeout is the time after which a UDP tunnel without traffic is closed.
+	udpSessionTimeout = 2 * time.Minute
+)
+
+// writeDatagram writes a single datagram to an SSH channel. Channels are streams, hence every datagram
+// is prefixed by its length as two byte big endian integer. This must match the framing of the supervisor.
+func writeDatagram(w io.Writer, datagram []byte) error {
+	if len(datagram) > maxDatagramSize {
+		return xerrors.Errorf("datagram exceeds %d bytes", maxDatagramSize)
+	}
+	frame := make([]byte, 2+len(datagram))
+	binary.BigEndian.PutUint16(frame, uint16(len(datagram)))
+	copy(frame[2:], datagram)
+	_, err := w.Write(frame)
+	return err
+}
+
+// readDatagram reads a single datagram written by writeDatagram into buf.
+func readDatagram(r io.Reader, buf []byte) (int, error) {
+	var header [2]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return 0, err
+	}
+	size := int(binary.BigEndian.Uint16(header[:]))
+	if size > len(buf) {
+		return 0, xerrors.Errorf("datagram of %d bytes exceeds the buffer", size)
+	}
+	_, err = io.ReadFull(r, buf[:size])
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return size, err
+}
+
+// udpSession is the tunnel of a single local UDP peer.
+type udpSession struct {
+	ch    ssh.Channel
+	timer *time.Timer
+	once  sync.Once
+}
+
+func newUDPSession(ch ssh.Channel) *udpSession {
+	s := &udpSession{ch: ch}
+	s.timer = time.AfterFunc(udpSessionTimeout, func() { s.Close() })
+	return s
+}
+
+// touch postpones closing the session due to inactivity.
+func (s *udpSession) touch() {
+	s.timer.Reset(udpSessionTimeout)
+}
+
+func (s *udpSession) Close() {
+	s.once.Do(func() {
+		s.timer.Stop()
+		s.ch.Close()
+	})
+}
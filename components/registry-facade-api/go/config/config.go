@@ -69,6 +69,9 @@ type Config struct {
 	Store              string           `json:"store"`
 	RequireAuth        bool             `json:"requireAuth"`
 	TLS                *TLS             `json:"tls"`
+	// Platform is the platform of the workspaces we serve images for, e.g. linux/arm64.
+	// Defaults to the platform registry-facade runs on.
+	Platform string `json:"platform,omitempty"`
 
 	IPFSCache *IPFSCacheConfig `json:"ipfs,omitempty"`
 
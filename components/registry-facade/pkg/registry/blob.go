@@ -76,6 +76,7 @@ func (reg *Registry) handleBlob(ctx context.Context, r *http.Request) http.Handl
 			reg.LayerSource,
 		},
 		ConfigModifier: reg.ConfigModifier,
+		Platform:       reg.Platform,
 
 		Metrics: reg.metrics,
 	}
@@ -103,6 +104,7 @@ type blobHandler struct {
 	IPFS              *IPFSBlobCache
 	AdditionalSources []BlobSource
 	ConfigModifier    ConfigModifier
+	Platform          ociv1.Platform
 
 	Metrics *metrics
 }
@@ -273,7 +275,7 @@ func (bh *blobHandler) downloadManifest(ctx context.Context, ref string) (res *o
 		log.WithError(err).WithField("ref", ref).WithField("instanceId", bh.Name).Error("cannot get fetcher")
 		return nil, nil, err
 	}
-	res, _, err = DownloadManifest(ctx, AsFetcherFunc(fetcher), desc, WithStore(bh.Store))
+	res, _, err = DownloadManifest(ctx, AsFetcherFunc(fetcher), desc, WithStore(bh.Store), WithPlatform(bh.Platform))
 	return
 }
 
@@ -236,8 +236,9 @@ const (
 	envPrefixPrepend = "GITPOD_ENV_PREPEND_"
 )
 
-// NewStaticSourceFromImage downloads image layers into the store and uses them as static layer
-func NewStaticSourceFromImage(ctx context.Context, newResolver ResolverProvider, ref string) (*ImageLayerSource, error) {
+// NewStaticSourceFromImage downloads image layers into the store and uses them as static layer.
+// If ref points to an image index, we use the layers of the given platform.
+func NewStaticSourceFromImage(ctx context.Context, newResolver ResolverProvider, ref string, platform ociv1.Platform) (*ImageLayerSource, error) {
 	resolver := newResolver()
 	_, desc, err := resolver.Resolve(ctx, ref)
 	if err != nil {
@@ -248,7 +249,7 @@ func NewStaticSourceFromImage(ctx context.Context, newResolver ResolverProvider,
 		return nil, err
 	}
 
-	manifest, _, err := DownloadManifest(ctx, AsFetcherFunc(fetcher), desc)
+	manifest, _, err := DownloadManifest(ctx, AsFetcherFunc(fetcher), desc, WithPlatform(platform))
 	if err != nil {
 		return nil, err
 	}
@@ -390,8 +391,8 @@ func (cs CompositeLayerSource) GetBlob(ctx context.Context, spec *api.ImageSpec,
 // RefSource extracts an image reference from an image spec
 type RefSource func(*api.ImageSpec) (ref []string, err error)
 
-// NewSpecMappedImageSource creates a new spec mapped image source
-func NewSpecMappedImageSource(resolver ResolverProvider, refSource RefSource) (*SpecMappedImagedSource, error) {
+// NewSpecMappedImageSource creates a new spec mapped image source which provides the layers of the given platform
+func NewSpecMappedImageSource(resolver ResolverProvider, refSource RefSource, platform ociv1.Platform) (*SpecMappedImagedSource, error) {
 	cache, err := lru.New(128)
 	if err != nil {
 		return nil, err
@@ -399,6 +400,7 @@ func NewSpecMappedImageSource(resolver ResolverProvider, refSource RefSource) (*
 	return &SpecMappedImagedSource{
 		RefSource: refSource,
 		Resolver:  resolver,
+		Platform:  platform,
 		cache:     cache,
 	}, nil
 }
@@ -407,6 +409,7 @@ func NewSpecMappedImageSource(resolver ResolverProvider, refSource RefSource) (*
 type SpecMappedImagedSource struct {
 	RefSource RefSource
 	Resolver  ResolverProvider
+	Platform  ociv1.Platform
 
 	// TODO: add ttl
 	cache *lru.Cache
@@ -508,7 +511,7 @@ func (src *SpecMappedImagedSource) getDelegate(ctx context.Context, spec *api.Im
 			layers[i] = s.(LayerSource)
 			continue
 		}
-		lsrc, err := NewStaticSourceFromImage(ctx, src.Resolver, ref)
+		lsrc, err := NewStaticSourceFromImage(ctx, src.Resolver, ref, src.Platform)
 		if err != nil {
 			return nil, err
 		}
@@ -17,8 +17,11 @@ import (
 	ctesting "github.com/gitpod-io/gitpod/common-go/testing"
 	"golang.org/x/xerrors"
 
+	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
 	"github.com/containerd/containerd/remotes/docker"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -65,7 +68,7 @@ func TestStaticLayerSource(t *testing.T) {
 		Test: func(t *testing.T, input interface{}) interface{} {
 			fixture := input.(*testStaticLayerSourceFixture)
 
-			src, err := NewStaticSourceFromImage(context.Background(), func() remotes.Resolver { return &fakeFetcher{Content: fixture.Content} }, fixture.SourceRef)
+			src, err := NewStaticSourceFromImage(context.Background(), func() remotes.Resolver { return &fakeFetcher{Content: fixture.Content} }, fixture.SourceRef, platforms.DefaultSpec())
 			if err != nil {
 				return &gold{Error: err.Error()}
 			}
@@ -86,6 +89,63 @@ func TestStaticLayerSource(t *testing.T) {
 	test.Run()
 }
 
+func TestStaticSourceFromImageIndex(t *testing.T) {
+	content := make(map[string][]byte)
+	add := func(mediaType string, obj interface{}) ocispec.Descriptor {
+		b, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+		content[desc.Digest.Encoded()] = b
+		return desc
+	}
+	image := func(arch string) ocispec.Descriptor {
+		layer := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-" + arch), Size: 1}
+		cfg := add(ocispec.MediaTypeImageConfig, ocispec.Image{
+			Platform: ocispec.Platform{OS: "linux", Architecture: arch},
+			RootFS:   ocispec.RootFS{Type: "layers", DiffIDs: []digest.Digest{digest.FromString("diff-" + arch)}},
+		})
+		desc := add(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    cfg,
+			Layers:    []ocispec.Descriptor{layer},
+		})
+		desc.Platform = &ocispec.Platform{OS: "linux", Architecture: arch}
+		return desc
+	}
+	index := add(ocispec.MediaTypeImageIndex, ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{image("amd64"), image("arm64")},
+	})
+	const ref = "eu.gcr.io/gitpod-core-dev/build/supervisor:commit-test"
+	var err error
+	content[ref], err = json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		t.Run(arch, func(t *testing.T) {
+			src, err := NewStaticSourceFromImage(context.Background(), func() remotes.Resolver { return &fakeFetcher{Content: content} }, ref, ocispec.Platform{OS: "linux", Architecture: arch})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(src.layers) != 1 {
+				t.Fatalf("expected one layer, got %d", len(src.layers))
+			}
+			if act, exp := src.layers[0].Descriptor.Digest, digest.FromString("layer-"+arch); act != exp {
+				t.Errorf("expected layer %s, got %s", exp, act)
+			}
+			if act, exp := src.layers[0].DiffID, digest.FromString("diff-"+arch); act != exp {
+				t.Errorf("expected diff ID %s, got %s", exp, act)
+			}
+		})
+	}
+}
+
 func createFixtureFromImage(ctx context.Context, resolver remotes.Resolver, ref string) (*testStaticLayerSourceFixture, error) {
 	fetcher, err := resolver.Fetcher(ctx, ref)
 	if err != nil {
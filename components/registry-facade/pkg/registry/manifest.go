@@ -18,6 +18,7 @@ import (
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
 	distv2 "github.com/docker/distribution/registry/api/v2"
 	"github.com/gorilla/handlers"
@@ -70,6 +71,7 @@ func (reg *Registry) handleManifest(ctx context.Context, r *http.Request) http.H
 		Resolver:       reg.Resolver(),
 		Store:          reg.Store,
 		ConfigModifier: reg.ConfigModifier,
+		Platform:       reg.Platform,
 	}
 	reference := getReference(ctx)
 	dgst, err := digest.Parse(reference)
@@ -102,6 +104,7 @@ type manifestHandler struct {
 	Resolver       remotes.Resolver
 	Store          BlobStore
 	ConfigModifier ConfigModifier
+	Platform       ociv1.Platform
 
 	Name   string
 	Tag    string
@@ -168,7 +171,7 @@ func (mh *manifestHandler) getManifest(w http.ResponseWriter, r *http.Request) {
 			return fcache, nil
 		}
 
-		manifest, ndesc, err := DownloadManifest(ctx, fetch, desc, WithStore(mh.Store))
+		manifest, ndesc, err := DownloadManifest(ctx, fetch, desc, WithStore(mh.Store), WithPlatform(mh.Platform))
 		if err != nil {
 			log.WithError(err).WithField("desc", desc).WithFields(logFields).WithField("ref", ref).Error("cannot download manifest")
 			return distv2.ErrorCodeManifestUnknown.WithDetail(err)
@@ -337,12 +340,35 @@ func DownloadConfig(ctx context.Context, fetch FetcherFunc, ref string, desc oci
 	return &res, nil
 }
 
+// chooseManifest returns the manifest of a platform from the manifests of an image index. Indexes which do not
+// name the platforms of their manifests are assumed to be single-platform images.
+func chooseManifest(manifests []ociv1.Descriptor, platform ociv1.Platform) (ociv1.Descriptor, error) {
+	var (
+		matcher      = platforms.Only(platform)
+		hasPlatforms bool
+	)
+	for _, md := range manifests {
+		if md.Platform == nil {
+			continue
+		}
+		hasPlatforms = true
+		if matcher.Match(*md.Platform) {
+			return md, nil
+		}
+	}
+	if !hasPlatforms {
+		return manifests[0], nil
+	}
+	return ociv1.Descriptor{}, xerrors.Errorf("image has no manifest for platform %s", platforms.Format(platform))
+}
+
 func contentTypeLabel(mt string) map[string]string {
 	return map[string]string{"Content-Type": mt}
 }
 
 type manifestDownloadOptions struct {
-	Store BlobStore
+	Store    BlobStore
+	Platform ociv1.Platform
 }
 
 // ManifestDownloadOption alters the default manifest download behaviour
@@ -355,6 +381,14 @@ func WithStore(store BlobStore) ManifestDownloadOption {
 	}
 }
 
+// WithPlatform chooses the manifest of a platform from image indexes. Defaults to the platform
+// registry-facade runs on, which is the platform of the node the workspace runs on.
+func WithPlatform(platform ociv1.Platform) ManifestDownloadOption {
+	return func(o *manifestDownloadOptions) {
+		o.Platform = platform
+	}
+}
+
 type BlobStore interface {
 	ReaderAt(ctx context.Context, desc ociv1.Descriptor) (content.ReaderAt, error)
 
@@ -374,9 +408,11 @@ func AsFetcherFunc(f remotes.Fetcher) FetcherFunc {
 }
 
 // DownloadManifest downloads and unmarshals the manifest of the given desc. If the desc points to manifest list
-// we choose the first manifest in that list.
+// we choose the manifest of our platform in that list.
 func DownloadManifest(ctx context.Context, fetch FetcherFunc, desc ociv1.Descriptor, options ...ManifestDownloadOption) (cfg *ociv1.Manifest, rdesc *ociv1.Descriptor, err error) {
-	var opts manifestDownloadOptions
+	opts := manifestDownloadOptions{
+		Platform: platforms.DefaultSpec(),
+	}
 	for _, o := range options {
 		o(&opts)
 	}
@@ -448,7 +484,7 @@ func DownloadManifest(ctx context.Context, fetch FetcherFunc, desc ociv1.Descrip
 	case images.MediaTypeDockerSchema2ManifestList, ociv1.MediaTypeImageIndex:
 		log.WithField("desc", rdesc).Debug("resolving image index")
 
-		// we received a manifest list which means we'll pick the default platform
+		// we received a manifest list which means we'll pick the manifest of our platform
 		// and fetch that manifest
 		var list ociv1.Index
 		err = json.Unmarshal(inpt, &list)
@@ -461,14 +497,18 @@ func DownloadManifest(ctx context.Context, fetch FetcherFunc, desc ociv1.Descrip
 			return
 		}
 
+		var md ociv1.Descriptor
+		md, err = chooseManifest(list.Manifests, opts.Platform)
+		if err != nil {
+			return
+		}
+
 		var fetcher remotes.Fetcher
 		fetcher, err = fetch()
 		if err != nil {
 			return
 		}
 
-		// TODO(cw): choose by platform, not just the first manifest
-		md := list.Manifests[0]
 		rc, err = fetcher.Fetch(ctx, md)
 		if err != nil {
 			err = xerrors.Errorf("cannot download config: %w", err)
@@ -105,6 +105,65 @@ func TestDownloadManifest(t *testing.T) {
 	}
 }
 
+func TestChooseManifest(t *testing.T) {
+	var (
+		amd64 = ociv1.Descriptor{Digest: digest.FromString("amd64"), Platform: &ociv1.Platform{OS: "linux", Architecture: "amd64"}}
+		arm64 = ociv1.Descriptor{Digest: digest.FromString("arm64"), Platform: &ociv1.Platform{OS: "linux", Architecture: "arm64"}}
+		// BuildKit adds attestations to the index of multi-arch images
+		attestation = ociv1.Descriptor{Digest: digest.FromString("attestation"), Platform: &ociv1.Platform{OS: "unknown", Architecture: "unknown"}}
+		noPlatform  = ociv1.Descriptor{Digest: digest.FromString("no-platform")}
+	)
+	tests := []struct {
+		Name        string
+		Manifests   []ociv1.Descriptor
+		Platform    ociv1.Platform
+		Expectation digest.Digest
+		Error       bool
+	}{
+		{
+			Name:        "amd64",
+			Manifests:   []ociv1.Descriptor{amd64, arm64, attestation},
+			Platform:    ociv1.Platform{OS: "linux", Architecture: "amd64"},
+			Expectation: amd64.Digest,
+		},
+		{
+			Name:        "arm64",
+			Manifests:   []ociv1.Descriptor{amd64, arm64, attestation},
+			Platform:    ociv1.Platform{OS: "linux", Architecture: "arm64"},
+			Expectation: arm64.Digest,
+		},
+		{
+			Name:      "missing platform",
+			Manifests: []ociv1.Descriptor{amd64, attestation},
+			Platform:  ociv1.Platform{OS: "linux", Architecture: "arm64"},
+			Error:     true,
+		},
+		{
+			Name:        "no platforms",
+			Manifests:   []ociv1.Descriptor{noPlatform},
+			Platform:    ociv1.Platform{OS: "linux", Architecture: "arm64"},
+			Expectation: noPlatform.Digest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			md, err := chooseManifest(test.Manifests, test.Platform)
+			if test.Error {
+				if err == nil {
+					t.Errorf("expected an error, got %s", md.Digest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md.Digest != test.Expectation {
+				t.Errorf("expected %s, got %s", test.Expectation, md.Digest)
+			}
+		})
+	}
+}
+
 type alwaysNotFoundStore struct{}
 
 func (fbs *alwaysNotFoundStore) ReaderAt(ctx context.Context, desc ociv1.Descriptor) (content.ReaderAt, error) {
@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
@@ -35,6 +36,7 @@ import (
 	"github.com/gorilla/mux"
 	httpapi "github.com/ipfs/kubo/client/rpc"
 	ma "github.com/multiformats/go-multiaddr"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/xerrors"
@@ -44,7 +46,7 @@ import (
 )
 
 // BuildStaticLayer builds a layer set from a static layer configuration
-func buildStaticLayer(ctx context.Context, cfg []config.StaticLayerCfg, newResolver ResolverProvider) (CompositeLayerSource, error) {
+func buildStaticLayer(ctx context.Context, cfg []config.StaticLayerCfg, newResolver ResolverProvider, platform ociv1.Platform) (CompositeLayerSource, error) {
 	var l CompositeLayerSource
 	for _, sl := range cfg {
 		switch sl.Type {
@@ -55,7 +57,7 @@ func buildStaticLayer(ctx context.Context, cfg []config.StaticLayerCfg, newResol
 			}
 			l = append(l, src)
 		case "image":
-			src, err := NewStaticSourceFromImage(ctx, newResolver, sl.Ref)
+			src, err := NewStaticSourceFromImage(ctx, newResolver, sl.Ref, platform)
 			if err != nil {
 				return nil, xerrors.Errorf("cannot source layer from %s: %w", sl.Ref, err)
 			}
@@ -73,6 +75,7 @@ type ResolverProvider func() remotes.Resolver
 // Registry acts as registry facade
 type Registry struct {
 	Config         config.Config
+	Platform       ociv1.Platform
 	Resolver       ResolverProvider
 	Store          BlobStore
 	IPFS           *IPFSBlobCache
@@ -89,6 +92,16 @@ type Registry struct {
 func NewRegistry(cfg config.Config, newResolver ResolverProvider, reg prometheus.Registerer) (*Registry, error) {
 	var mfStore BlobStore
 
+	platform := platforms.DefaultSpec()
+	if cfg.Platform != "" {
+		p, err := platforms.Parse(cfg.Platform)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid platform %s: %w", cfg.Platform, err)
+		}
+		platform = platforms.Normalize(p)
+	}
+	log.WithField("platform", platforms.Format(platform)).Info("serving images for platform")
+
 	if cfg.IPFSCache != nil && cfg.IPFSCache.Enabled {
 		if cfg.RedisCache == nil || !cfg.RedisCache.Enabled {
 			return nil, xerrors.Errorf("IPFS cache requires Redis")
@@ -139,7 +152,7 @@ func NewRegistry(cfg config.Config, newResolver ResolverProvider, reg prometheus
 	staticLayer := NewRevisioningLayerSource(CompositeLayerSource{})
 	layerSources = append(layerSources, staticLayer)
 	if len(cfg.StaticLayer) > 0 {
-		l, err := buildStaticLayer(ctx, cfg.StaticLayer, newResolver)
+		l, err := buildStaticLayer(ctx, cfg.StaticLayer, newResolver, platform)
 		if err != nil {
 			return nil, err
 		}
@@ -152,7 +165,7 @@ func NewRegistry(cfg config.Config, newResolver ResolverProvider, reg prometheus
 		ref = append(ref, s.IdeLayerRef...)
 		return ref, nil
 	}
-	ideLayerSource, err := NewSpecMappedImageSource(newResolver, ideRefSource)
+	ideLayerSource, err := NewSpecMappedImageSource(newResolver, ideRefSource, platform)
 	if err != nil {
 		return nil, err
 	}
@@ -235,6 +248,7 @@ func NewRegistry(cfg config.Config, newResolver ResolverProvider, reg prometheus
 	layerSource := CompositeLayerSource(layerSources)
 	return &Registry{
 		Config:            cfg,
+		Platform:          platform,
 		Resolver:          newResolver,
 		Store:             mfStore,
 		IPFS:              ipfs,
@@ -329,7 +343,7 @@ func getRedisClient(cfg *config.RedisCacheConfig) (*redis.Client, error) {
 
 // UpdateStaticLayer updates the static layer a registry-facade adds
 func (reg *Registry) UpdateStaticLayer(ctx context.Context, cfg []config.StaticLayerCfg) error {
-	l, err := buildStaticLayer(ctx, cfg, reg.Resolver)
+	l, err := buildStaticLayer(ctx, cfg, reg.Resolver, reg.Platform)
 	if err != nil {
 		return err
 	}
@@ -97,6 +97,9 @@ const defaultFunctions: FunctionsConfig = {
     setProjectEnvironmentVariable: { group: "default", points: 1 },
     getProjectEnvironmentVariables: { group: "default", points: 1 },
     deleteProjectEnvironmentVariable: { group: "default", points: 1 },
+    setOrgEnvironmentVariable: { group: "default", points: 1 },
+    getOrgEnvironmentVariables: { group: "default", points: 1 },
+    deleteOrgEnvironmentVariable: { group: "default", points: 1 },
     getTeam: { group: "default", points: 1 },
     updateTeam: { group: "default", points: 1 },
     getTeams: { group: "default", points: 1 },
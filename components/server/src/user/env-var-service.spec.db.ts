@@ -197,6 +197,24 @@ describe("EnvVarService", async () => {
         await expectError(ErrorCodes.NOT_FOUND, es.listUserEnvVars(stranger.id, member.id));
     });
 
+    it("should flag env vars as files", async () => {
+        await es.addUserEnvVar(member.id, member.id, {
+            name: "NPM_TOKEN",
+            value: "secret",
+            repositoryPattern: "*/*",
+            asFile: true,
+        });
+        await es.addUserEnvVar(member.id, member.id, { name: "var1", value: "foo", repositoryPattern: "*/*" });
+
+        const envVars = await es.listUserEnvVars(member.id, member.id);
+        expect(envVars.find((e) => e.name === "NPM_TOKEN")?.asFile).to.be.true;
+        expect(envVars.find((e) => e.name === "var1")?.asFile).to.be.false;
+
+        const resolved = await es.resolveEnvVariables(member.id, undefined, "regular", commitContext);
+        const fileSecrets = resolved.workspace.filter((e) => "asFile" in e && e.asFile).map((e) => e.name);
+        expect(fileSecrets).to.deep.equal(["NPM_TOKEN"]);
+    });
+
     it("should delete env vars", async () => {
         await es.addUserEnvVar(member.id, member.id, { name: "var1", value: "foo", repositoryPattern: "*/*" });
         await es.addUserEnvVar(member.id, member.id, { name: "var2", value: "bar", repositoryPattern: "*/*" });
@@ -269,6 +287,30 @@ describe("EnvVarService", async () => {
         await expectError(ErrorCodes.NOT_FOUND, es.listProjectEnvVars(stranger.id, project.id));
     });
 
+    it("should let owners set, get and delete org image auth", async () => {
+        await expectError(ErrorCodes.BAD_REQUEST, es.setOrgEnvVar(owner.id, org.id, { name: "FOO", value: "BAR" }));
+
+        const added = await es.setOrgEnvVar(owner.id, org.id, {
+            name: "GITPOD_IMAGE_AUTH",
+            value: "registry.example.com:dXNlcjpwYXNz",
+        });
+        const updated = await es.setOrgEnvVar(owner.id, org.id, {
+            name: "GITPOD_IMAGE_AUTH",
+            value: "registry.example.com:dXNlcjpwYXNzMg==",
+        });
+        expect(updated.id).to.equal(added.id);
+
+        const envVars = await es.listOrgEnvVars(owner.id, org.id);
+        expect(envVars.length).to.equal(1);
+        expect(envVars[0].name).to.equal("GITPOD_IMAGE_AUTH");
+
+        await expectError(ErrorCodes.NOT_FOUND, es.listOrgEnvVars(stranger.id, org.id));
+        await expectError(ErrorCodes.NOT_FOUND, es.deleteOrgEnvVar(stranger.id, added.id));
+
+        await es.deleteOrgEnvVar(owner.id, added.id);
+        await expectError(ErrorCodes.NOT_FOUND, es.getOrgEnvVarById(owner.id, added.id));
+    });
+
     it("should resolve env variables 1 ", async () => {
         await es.addUserEnvVar(member.id, member.id, fooAnyUserEnvVar);
         await es.addUserEnvVar(member.id, member.id, barUserCommitEnvVar);
@@ -4,10 +4,12 @@
  * See License.AGPL.txt in the project root for license information.
  */
 
-import { ProjectDB, UserDB } from "@gitpod/gitpod-db/lib";
+import { ProjectDB, TeamDB, UserDB } from "@gitpod/gitpod-db/lib";
 import {
     CommitContext,
     EnvVar,
+    OrgEnvVar,
+    OrgEnvVarWithValue,
     ProjectEnvVar,
     ProjectEnvVarWithValue,
     UserEnvVar,
@@ -21,6 +23,7 @@ import { Authorizer } from "../authorization/authorizer";
 import { IAnalyticsWriter } from "@gitpod/gitpod-protocol/lib/analytics";
 import { ApplicationError, ErrorCodes } from "@gitpod/gitpod-protocol/lib/messaging/error";
 import { Config } from "../config";
+import { IMAGE_AUTH_ENV_VAR, parseImageAuth } from "../workspace/image-auth";
 
 export interface ResolvedEnvVars {
     // all project env vars, censored included always
@@ -35,6 +38,7 @@ export class EnvVarService {
         @inject(Config) private readonly config: Config,
         @inject(UserDB) private readonly userDB: UserDB,
         @inject(ProjectDB) private readonly projectDB: ProjectDB,
+        @inject(TeamDB) private readonly teamDB: TeamDB,
         @inject(Authorizer) private readonly auth: Authorizer,
         @inject(IAnalyticsWriter) private readonly analytics: IAnalyticsWriter,
     ) {}
@@ -55,6 +59,7 @@ export class EnvVarService {
                 name: value.name,
                 value: value.value,
                 repositoryPattern: value.repositoryPattern,
+                asFile: value.asFile,
             });
         }
         return result;
@@ -203,6 +208,15 @@ export class EnvVarService {
                 "Please choose a variable name containing only letters, numbers, or _, and which doesn't start with a number",
             );
         }
+        if (envVar.name === IMAGE_AUTH_ENV_VAR && envVar.value !== undefined) {
+            const { errors } = parseImageAuth(envVar.value);
+            if (errors.length > 0) {
+                throw new ApplicationError(
+                    ErrorCodes.BAD_REQUEST,
+                    `Invalid ${IMAGE_AUTH_ENV_VAR} value, ${errors.join("; ")}. Expected a comma-separated list of <registry>:<base64 encoded username:password>.`,
+                );
+            }
+        }
     }
 
     async updateProjectEnvVar(
@@ -226,6 +240,58 @@ export class EnvVarService {
         return this.projectDB.deleteProjectEnvironmentVariable(variableId);
     }
 
+    async listOrgEnvVars(requestorId: string, orgId: string): Promise<OrgEnvVar[]> {
+        await this.auth.checkPermissionOnOrganization(requestorId, "read_settings", orgId);
+        return this.teamDB.getOrgEnvironmentVariables(orgId);
+    }
+
+    async getOrgEnvVarById(requestorId: string, variableId: string): Promise<OrgEnvVar> {
+        const result = await this.teamDB.getOrgEnvironmentVariableById(variableId);
+        if (!result) {
+            throw new ApplicationError(ErrorCodes.NOT_FOUND, `Environment Variable ${variableId} not found.`);
+        }
+        await this.auth.checkPermissionOnOrganization(requestorId, "read_settings", result.orgId);
+        return result;
+    }
+
+    /**
+     * Adds or updates an environment variable of an organization. Organizations can only provide
+     * GITPOD_IMAGE_AUTH, which applies to all projects of the organization.
+     */
+    async setOrgEnvVar(requestorId: string, orgId: string, envVar: OrgEnvVarWithValue): Promise<OrgEnvVar> {
+        this.validateOrgEnvVar(envVar);
+        await this.auth.checkPermissionOnOrganization(requestorId, "write_settings", orgId);
+
+        const existingVar = await this.teamDB.findOrgEnvironmentVariableByName(orgId, envVar.name);
+        if (existingVar) {
+            const result = await this.teamDB.updateOrgEnvironmentVariable(orgId, {
+                id: existingVar.id,
+                value: envVar.value,
+            });
+            if (!result) {
+                throw new ApplicationError(ErrorCodes.NOT_FOUND, `Environment Variable ${envVar.name} not found.`);
+            }
+            return result;
+        }
+        return this.teamDB.addOrgEnvironmentVariable(orgId, envVar);
+    }
+
+    validateOrgEnvVar(envVar: OrgEnvVarWithValue) {
+        if (envVar.name !== IMAGE_AUTH_ENV_VAR) {
+            throw new ApplicationError(
+                ErrorCodes.BAD_REQUEST,
+                `Organizations can only set the ${IMAGE_AUTH_ENV_VAR} environment variable`,
+            );
+        }
+        this.validateProjectEnvVar({ ...envVar, censored: true });
+    }
+
+    async deleteOrgEnvVar(requestorId: string, variableId: string): Promise<void> {
+        const variable = await this.getOrgEnvVarById(requestorId, variableId);
+        await this.auth.checkPermissionOnOrganization(requestorId, "write_settings", variable.orgId);
+        return this.teamDB.deleteOrgEnvironmentVariable(variableId);
+    }
+
     async resolveEnvVariables(
         requestorId: string,
         projectId: string | undefined,
@@ -98,6 +98,7 @@ import {
     EnvVarWithValue,
     LinkedInProfile,
     ProjectEnvVar,
+    OrgEnvVar,
     UserEnvVar,
     UserFeatureSettings,
     WorkspaceTimeoutSetting,
@@ -1591,6 +1592,28 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
         return this.organizationService.updateSettings(user.id, orgId, settings);
     }
 
+    async setOrgEnvironmentVariable(ctx: TraceContextWithSpan, orgId: string, name: string, value: string): Promise<void> {
+        traceAPIParams(ctx, { orgId, name }); // value may contain secrets
+        const user = await this.checkAndBlockUser("setOrgEnvironmentVariable");
+        await this.guardTeamOperation(orgId, "update");
+        await this.envVarService.setOrgEnvVar(user.id, orgId, { name, value });
+    }
+
+    async getOrgEnvironmentVariables(ctx: TraceContextWithSpan, orgId: string): Promise<OrgEnvVar[]> {
+        traceAPIParams(ctx, { orgId });
+        const user = await this.checkAndBlockUser("getOrgEnvironmentVariables");
+        await this.guardTeamOperation(orgId, "get");
+        return this.envVarService.listOrgEnvVars(user.id, orgId);
+    }
+
+    async deleteOrgEnvironmentVariable(ctx: TraceContextWithSpan, variableId: string): Promise<void> {
+        traceAPIParams(ctx, { variableId });
+        const user = await this.checkAndBlockUser("deleteOrgEnvironmentVariable");
+        const envVar = await this.envVarService.getOrgEnvVarById(user.id, variableId);
+        await this.guardTeamOperation(envVar.orgId, "update");
+        return this.envVarService.deleteOrgEnvVar(user.id, envVar.id);
+    }
+
     async getOrgWorkspaceClasses(ctx: TraceContextWithSpan, orgId: string): Promise<SupportedWorkspaceClass[]> {
         const user = await this.checkAndBlockUser("getOrgWorkspaceClasses");
         traceAPIParams(ctx, { orgId, userId: user.id });
@@ -0,0 +1,51 @@
+/**
+ * Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+ * Licensed under the GNU Affero General Public License (AGPL).
+ * See License.AGPL.txt in the project root for license information.
+ */
+
+import { suite, test } from "@testdeck/mocha";
+import * as chai from "chai";
+import { parseImageAuth } from "./image-auth";
+const expect = chai.expect;
+
+const credentials = Buffer.from("user:pass:word").toString("base64");
+
+@suite
+class TestImageAuth {
+    @test
+    public testParse() {
+        const result = parseImageAuth(` my-registry.io:${credentials}, localhost:5000:${credentials} `);
+        expect(result.errors).to.be.empty;
+        expect([...result.auth.entries()]).to.deep.equal([
+            ["my-registry.io", credentials],
+            ["localhost:5000", credentials],
+        ]);
+    }
+
+    @test
+    public testEmpty() {
+        const result = parseImageAuth("");
+        expect(result.errors).to.be.empty;
+        expect(result.auth.size).to.equal(0);
+    }
+
+    @test
+    public testInvalidEntries() {
+        const result = parseImageAuth(
+            [
+                "my-registry.io",
+                `my-registry.io/org/image:${credentials}`,
+                "my-registry.io:user:pass",
+                `other-registry.io:${Buffer.from("token").toString("base64")}`,
+                `valid-registry.io:${credentials}`,
+                `valid-registry.io:${credentials}`,
+            ].join(","),
+        );
+        expect(result.errors).to.have.lengthOf(5);
+        expect(result.errors[0]).to.contain("entry 1");
+        expect(result.errors[3]).to.contain("other-registry.io");
+        expect([...result.auth.keys()]).to.deep.equal(["valid-registry.io"]);
+    }
+}
+module.exports = new TestImageAuth();
@@ -0,0 +1,62 @@
+/**
+ * Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+ * Licensed under the GNU Affero General Public License (AGPL).
+ * See License.AGPL.txt in the project root for license information.
+ */
+
+/**
+ * The name of the project environment variable which holds the credentials of private registries
+ * base images are pulled from.
+ */
+export const IMAGE_AUTH_ENV_VAR = "GITPOD_IMAGE_AUTH";
+
+export interface ParsedImageAuth {
+    // registry host to base64 encoded "<username>:<password>"
+    auth: Map<string, string>;
+    // human readable reasons why entries were skipped
+    errors: string[];
+}
+
+/**
+ * Parses the value of GITPOD_IMAGE_AUTH, which is a comma-separated list of `<registry>:<credentials>` entries.
+ * The credentials are the base64 encoded `<username>:<password>`. As the registry may contain a port,
+ * the credentials start after the last colon of an entry.
+ */
+export function parseImageAuth(value: string): ParsedImageAuth {
+    const res: ParsedImageAuth = { auth: new Map(), errors: [] };
+    const entries = value
+        .split(",")
+        .map((e) => e.trim())
+        .filter((e) => e.length > 0);
+    for (const [i, entry] of entries.entries()) {
+        const sep = entry.lastIndexOf(":");
+        const registry = sep > 0 ? entry.substring(0, sep).trim() : "";
+        const credentials = sep > 0 ? entry.substring(sep + 1).trim() : "";
+        if (!registry || !credentials) {
+            res.errors.push(`entry ${i + 1} is not of the form <registry>:<base64 encoded username:password>`);
+            continue;
+        }
+        if (registry.includes("/")) {
+            res.errors.push(`entry ${i + 1}: ${registry} is not a registry host, please remove the repository path`);
+            continue;
+        }
+        if (!isBase64Credentials(credentials)) {
+            res.errors.push(`entry ${i + 1}: the credentials for ${registry} are not a base64 encoded username:password`);
+            continue;
+        }
+        if (res.auth.has(registry)) {
+            res.errors.push(`entry ${i + 1}: ${registry} has credentials already`);
+            continue;
+        }
+        res.auth.set(registry, credentials);
+    }
+    return res;
+}
+
+function isBase64Credentials(credentials: string): boolean {
+    if (!/^[A-Za-z0-9+/]+={0,2}$/.test(credentials)) {
+        return false;
+    }
+    const decoded = Buffer.from(credentials, "base64").toString("utf8");
+    return decoded.indexOf(":") > 0;
+}
@@ -19,6 +19,7 @@ import {
     DBWithTracing,
     ProjectDB,
     RedisPublisher,
+    TeamDB,
     TracedUserDB,
     TracedWorkspaceDB,
     UserDB,
@@ -68,6 +69,7 @@ import { TraceContext } from "@gitpod/gitpod-protocol/lib/util/tracing";
 import { WorkspaceRegion } from "@gitpod/gitpod-protocol/lib/workspace-cluster";
 import * as IdeServiceApi from "@gitpod/ide-service-api/lib/ide.pb";
 import {
+    BuildCache,
     BuildRegistryAuth,
     BuildRegistryAuthSelective,
     BuildRegistryAuthTotal,
@@ -77,6 +79,7 @@ import {
     BuildSourceDockerfile,
     BuildSourceReference,
     BuildStatus,
+    CancelBuildRequest,
     ImageBuilderClientProvider,
     ResolveBaseImageRequest,
 } from "@gitpod/image-builder/lib";
@@ -125,6 +128,7 @@ import { AuthorizationService } from "../user/authorization-service";
 import { TokenProvider } from "../user/token-provider";
 import { UserAuthentication } from "../user/user-authentication";
 import { ImageSourceProvider } from "./image-source-provider";
+import { IMAGE_AUTH_ENV_VAR, parseImageAuth } from "./image-auth";
 import { WorkspaceClassesConfig } from "./workspace-classes";
 import { SYSTEM_USER, SYSTEM_USER_ID } from "../authorization/authorizer";
 import { EnvVarService, ResolvedEnvVars } from "../user/env-var-service";
@@ -224,6 +228,7 @@ export class WorkspaceStarter {
         @inject(IAnalyticsWriter) private readonly analytics: IAnalyticsWriter,
         @inject(OneTimeSecretServer) private readonly otsServer: OneTimeSecretServer,
         @inject(ProjectDB) private readonly projectDB: ProjectDB,
+        @inject(TeamDB) private readonly teamDB: TeamDB,
         @inject(BlockedRepositoryDB) private readonly blockedRepositoryDB: BlockedRepositoryDB,
         @inject(EntitlementService) private readonly entitlementService: EntitlementService,
         @inject(RedisMutex) private readonly redisMutex: RedisMutex,
@@ -461,6 +466,12 @@ export class WorkspaceStarter {
         span.setTag("stopWorkspaceReason", reason);
         log.info({ instanceId }, "Stopping workspace instance", { reason });
 
+        // Instances which build their image do not run in ws-manager yet, hence we stop their build instead.
+        // The build fails, and so does the instance start.
+        if (await this.cancelImageBuild({ span }, instanceId, reason)) {
+            return;
+        }
+
         const req = new StopWorkspaceRequest();
         req.setId(instanceId);
         req.setPolicy(policy || StopWorkspacePolicy.NORMALLY);
@@ -498,6 +509,39 @@ export class WorkspaceStarter {
         await client.stopWorkspace(ctx, req);
     }
 
+    /**
+     * Cancels the image build of an instance which is in phase "building".
+     * @returns true if the build was canceled, false if the instance does not build its image (anymore)
+     */
+    private async cancelImageBuild(ctx: TraceContext, instanceId: string, reason: string): Promise<boolean> {
+        const instance = await this.workspaceDb.trace(ctx).findInstanceById(instanceId);
+        const buildId = instance?.imageBuildInfo?.buildId;
+        if (instance?.status.phase !== "building" || !buildId) {
+            return false;
+        }
+        const workspace = await this.workspaceDb.trace(ctx).findByInstanceId(instanceId);
+        const user = workspace && (await this.userDB.trace(ctx).findUserById(workspace.ownerId));
+        if (!workspace || !user) {
+            return false;
+        }
+
+        const req = new CancelBuildRequest();
+        req.setBuildId(buildId);
+        req.setReason(`workspace was stopped: ${reason}`);
+        try {
+            const client = await this.getImageBuilderClient(user, workspace, instance);
+            await client.cancelBuild(ctx, req);
+            log.info({ instanceId, workspaceId: workspace.id }, "Canceled image build", { reason });
+            return true;
+        } catch (err) {
+            if (isGrpcError(err) && err.code === grpc.status.NOT_FOUND) {
+                // the build is done already
+                return false;
+            }
+            throw err;
+        }
+    }
+
     private async checkBlockedRepository(user: User, { contextURL, organizationId }: Workspace) {
         const blockedRepository = await this.blockedRepositoryDB.findBlockedRepositoryByURL(contextURL);
         if (!blockedRepository) return;
@@ -552,7 +596,7 @@ export class WorkspaceStarter {
             const checkPendingFirst = await isCheckPendingFirstEnabled(user);
             const doBuildWorkspaceImage = async (): Promise<StartWorkspaceRequest> => {
                 // build workspace image
-                const additionalAuth = await this.getAdditionalImageAuth(envVars);
+                const additionalAuth = await this.getAdditionalImageAuth(workspace.organizationId, envVars);
                 instance = await this.buildWorkspaceImage(
                     { span },
                     user,
@@ -788,20 +832,35 @@ export class WorkspaceStarter {
         return undefined;
     }
 
-    private async getAdditionalImageAuth(envVars: ResolvedEnvVars): Promise<Map<string, string>> {
+    /**
+     * Returns the credentials for private registries from GITPOD_IMAGE_AUTH of the organization and the project.
+     * The credentials of the project take precedence over the organization's for the same registry.
+     */
+    private async getAdditionalImageAuth(organizationId: string, envVars: ResolvedEnvVars): Promise<Map<string, string>> {
         const res = new Map<string, string>();
-        const imageAuth = envVars.project.find((e) => e.name === "GITPOD_IMAGE_AUTH");
-        if (!imageAuth) {
-            return res;
-        }
+        const merge = (value: string | undefined, logCtx: LogContext, payload: { projectId?: string } = {}) => {
+            const parsed = parseImageAuth(value || "");
+            if (parsed.errors.length > 0) {
+                // we still try the valid entries: the image build reports which registry it could not authenticate against
+                log.warn(logCtx, `ignoring invalid ${IMAGE_AUTH_ENV_VAR} entries`, {
+                    ...payload,
+                    errors: parsed.errors,
+                });
+            }
+            parsed.auth.forEach((credentials, registry) => res.set(registry, credentials));
+        };
 
-        const imageAuthValue = (await this.projectDB.getProjectEnvironmentVariableValues([imageAuth]))[0];
+        const orgImageAuth = await this.teamDB.findOrgEnvironmentVariableByName(organizationId, IMAGE_AUTH_ENV_VAR);
+        if (orgImageAuth) {
+            const orgImageAuthValue = (await this.teamDB.getOrgEnvironmentVariableValues([orgImageAuth]))[0];
+            merge(orgImageAuthValue?.value, { organizationId });
+        }
 
-        (imageAuthValue.value || "")
-            .split(",")
-            .map((e) => e.trim().split(":"))
-            .filter((e) => e.length == 2)
-            .forEach((e) => res.set(e[0], e[1]));
+        const imageAuth = envVars.project.find((e) => e.name === IMAGE_AUTH_ENV_VAR);
+        if (imageAuth) {
+            const imageAuthValue = (await this.projectDB.getProjectEnvironmentVariableValues([imageAuth]))[0];
+            merge(imageAuthValue?.value, { organizationId }, { projectId: imageAuth.projectId });
+        }
         return res;
     }
 
@@ -1131,6 +1190,10 @@ export class WorkspaceStarter {
                 file.setDockerfilePath(dockerFilePath);
                 file.setSource(source);
                 file.setDockerfileVersion(imgsrc.dockerFileHash);
+                const dockerfileContent = await this.getDockerfileContent(user, workspace, imgsrc);
+                if (dockerfileContent) {
+                    file.setDockerfileContent(dockerfileContent);
+                }
 
                 const src = new BuildSource();
                 src.setFile(file);
@@ -1154,6 +1217,46 @@ export class WorkspaceStarter {
         }
     }
 
+    /**
+     * Builds of a project share their cache. Builds outside of a project never use the cache, because their
+     * layers can hold content which other users have no access to.
+     */
+    private async getBuildCache(workspace: Workspace): Promise<BuildCache> {
+        const cache = new BuildCache();
+        if (!workspace.projectId) {
+            return cache;
+        }
+        const project = await this.projectDB.findProjectById(workspace.projectId);
+        cache.setScope(workspace.projectId);
+        cache.setDisabled(!!project?.settings?.disableImageBuildCache);
+        return cache;
+    }
+
+    /**
+     * Returns the content of the Dockerfile of an image build, which image-builder checks before the build starts.
+     * Builds go ahead without these checks if the content is not available.
+     */
+    private async getDockerfileContent(
+        user: User,
+        workspace: Workspace,
+        imgsrc: WorkspaceImageSourceDocker,
+    ): Promise<string | undefined> {
+        if (AdditionalContentContext.hasDockerConfig(workspace.context, workspace.config)) {
+            return (workspace.context as AdditionalContentContext).additionalFiles[imgsrc.dockerFilePath];
+        }
+        const commit = imgsrc.dockerFileSource;
+        const hostContext = commit && this.hostContextProvider.get(commit.repository.host);
+        if (!commit || !hostContext?.services) {
+            return undefined;
+        }
+        try {
+            return await hostContext.services.fileProvider.getFileContent(commit, user, imgsrc.dockerFilePath);
+        } catch (err) {
+            log.debug({ workspaceId: workspace.id }, "cannot fetch Dockerfile for image build checks", err);
+            return undefined;
+        }
+    }
+
     private async buildWorkspaceImage(
         ctx: TraceContext,
         user: User,
@@ -1182,6 +1285,7 @@ export class WorkspaceStarter {
             req.setAuth(auth);
             req.setForceRebuild(forceRebuild);
             req.setTriggeredBy(user.id);
+            req.setOrganizationId(workspace.organizationId);
             if (!ignoreBaseImageresolvedAndRebuildBase && !forceRebuild && workspace.baseImageNameResolved) {
                 req.setBaseImageNameResolved(workspace.baseImageNameResolved);
             }
@@ -1189,6 +1293,7 @@ export class WorkspaceStarter {
             if (supervisorImage) {
                 req.setSupervisorRef(supervisorImage);
             }
+            req.setCache(await this.getBuildCache(workspace));
 
             // Make sure we persist logInfo as soon as we retrieve it
             const imageBuildLogInfo = new Deferred<ImageBuildLogInfo>();
@@ -1237,9 +1342,12 @@ export class WorkspaceStarter {
             const status: WorkspaceInstanceStatus = result.actuallyNeedsBuild
                 ? { ...instance.status, phase: "building" }
                 : instance.status;
-            instance = await this.workspaceDb
-                .trace({ span })
-                .updateInstancePartial(instance.id, { workspaceImage, status });
+            const update: Partial<WorkspaceInstance> = { workspaceImage, status };
+            if (result.buildId) {
+                // Other workspaces may build the same image, hence we remember the ID of our build to cancel only that one
+                update.imageBuildInfo = { ...(instance.imageBuildInfo || {}), buildId: result.buildId };
+            }
+            instance = await this.workspaceDb.trace({ span }).updateInstancePartial(instance.id, update);
             await this.publisher.publishInstanceUpdate({
                 instanceID: instance.id,
                 ownerID: workspace.ownerId,
@@ -1312,7 +1420,8 @@ export class WorkspaceStarter {
                 return (
                     msg.startsWith("build failed:") ||
                     msg.includes("headless task failed:") ||
-                    msg.includes("cannot resolve image")
+                    msg.includes("cannot resolve image") ||
+                    msg.includes("invalid Dockerfile")
                 );
             };
             if (looksLikeUserError(message)) {
@@ -1361,6 +1470,15 @@ export class WorkspaceStarter {
             return ev;
         });
 
+        // supervisor writes the variables flagged as files to a tmpfs rather than passing them to every process
+        const fileSecrets = envVars.workspace.filter((e) => "asFile" in e && e.asFile).map((e) => e.name);
+        if (fileSecrets.length > 0) {
+            const fileSecretsEnv = new EnvironmentVariable();
+            fileSecretsEnv.setName("SUPERVISOR_FILE_SECRETS");
+            fileSecretsEnv.setValue(fileSecrets.join(","));
+            envvars.push(fileSecretsEnv);
+        }
+
         const contextUrlEnv = new EnvironmentVariable();
         contextUrlEnv.setName("GITPOD_WORKSPACE_CONTEXT_URL");
         // Beware that `workspace.contextURL` is not normalized so it might contain other modifiers
@@ -1404,6 +1522,26 @@ export class WorkspaceStarter {
         dotfileEnv.setValue(user.additionalData?.dotfileRepo || "");
         envvars.push(dotfileEnv);
 
+        const sshAgentForwardingEnv = new EnvironmentVariable();
+        sshAgentForwardingEnv.setName("SUPERVISOR_SSH_AGENT_FORWARDING");
+        sshAgentForwardingEnv.setValue(String(!!user.additionalData?.sshAgentForwarding));
+        envvars.push(sshAgentForwardingEnv);
+
+        // supervisor only asks for git tokens of the SCM hosts configured in this installation. A token is
+        // good for git operations if it has either the public or the private repository scopes of its host.
+        const gitHostsEnv = new EnvironmentVariable();
+        gitHostsEnv.setName("SUPERVISOR_GIT_HOSTS");
+        gitHostsEnv.setValue(
+            JSON.stringify(
+                this.hostContextProvider.getAll().map((hostContext) => ({
+                    host: hostContext.authProvider.info.host,
+                    scopes: hostContext.authProvider.info.requirements?.publicRepo,
+                    privateRepoScopes: hostContext.authProvider.info.requirements?.privateRepo,
+                })),
+            ),
+        );
+        envvars.push(gitHostsEnv);
+
         if (workspace.config.coreDump?.enabled) {
             // default core dump size is 262144 blocks (if blocksize is 4096)
             const defaultLimit: number = 1073741824;
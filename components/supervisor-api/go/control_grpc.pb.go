@@ -32,6 +32,13 @@ type ControlServiceClient interface {
 	CreateSSHKeyPair(ctx context.Context, in *CreateSSHKeyPairRequest, opts ...grpc.CallOption) (*CreateSSHKeyPairResponse, error)
 	// CreateDebugEnv creates a debug workspace envs
 	CreateDebugEnv(ctx context.Context, in *CreateDebugEnvRequest, opts ...grpc.CallOption) (*CreateDebugEnvResponse, error)
+	// Exec runs a command in the workspace and streams its output until it terminates.
+	// Requests must carry a Gitpod API token of the workspace as bearer token in the authorization metadata.
+	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (ControlService_ExecClient, error)
+	// ListSessions lists the active SSH sessions and port tunnel clients of the workspace.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// RevokeSession terminates an SSH session or closes all connections of a port tunnel client.
+	RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error)
 }
 
 type controlServiceClient struct {
@@ -69,6 +76,56 @@ func (c *controlServiceClient) CreateDebugEnv(ctx context.Context, in *CreateDeb
 	return out, nil
 }
 
+func (c *controlServiceClient) Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (ControlService_ExecClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ControlService_ServiceDesc.Streams[0], "/supervisor.ControlService/Exec", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlServiceExecClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ControlService_ExecClient interface {
+	Recv() (*ExecResponse, error)
+	grpc.ClientStream
+}
+
+type controlServiceExecClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlServiceExecClient) Recv() (*ExecResponse, error) {
+	m := new(ExecResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *controlServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, "/supervisor.ControlService/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error) {
+	out := new(RevokeSessionResponse)
+	err := c.cc.Invoke(ctx, "/supervisor.ControlService/RevokeSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ControlServiceServer is the server API for ControlService service.
 // All implementations must embed UnimplementedControlServiceServer
 // for forward compatibility
@@ -79,6 +136,13 @@ type ControlServiceServer interface {
 	CreateSSHKeyPair(context.Context, *CreateSSHKeyPairRequest) (*CreateSSHKeyPairResponse, error)
 	// CreateDebugEnv creates a debug workspace envs
 	CreateDebugEnv(context.Context, *CreateDebugEnvRequest) (*CreateDebugEnvResponse, error)
+	// Exec runs a command in the workspace and streams its output until it terminates.
+	// Requests must carry a Gitpod API token of the workspace as bearer token in the authorization metadata.
+	Exec(*ExecRequest, ControlService_ExecServer) error
+	// ListSessions lists the active SSH sessions and port tunnel clients of the workspace.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// RevokeSession terminates an SSH session or closes all connections of a port tunnel client.
+	RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error)
 	mustEmbedUnimplementedControlServiceServer()
 }
 
@@ -95,6 +159,15 @@ func (UnimplementedControlServiceServer) CreateSSHKeyPair(context.Context, *Crea
 func (UnimplementedControlServiceServer) CreateDebugEnv(context.Context, *CreateDebugEnvRequest) (*CreateDebugEnvResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateDebugEnv not implemented")
 }
+func (UnimplementedControlServiceServer) Exec(*ExecRequest, ControlService_ExecServer) error {
+	return status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (UnimplementedControlServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedControlServiceServer) RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
 func (UnimplementedControlServiceServer) mustEmbedUnimplementedControlServiceServer() {}
 
 // UnsafeControlServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -162,6 +235,63 @@ func _ControlService_CreateDebugEnv_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ControlService_Exec_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServiceServer).Exec(m, &controlServiceExecServer{stream})
+}
+
+type ControlService_ExecServer interface {
+	Send(*ExecResponse) error
+	grpc.ServerStream
+}
+
+type controlServiceExecServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlServiceExecServer) Send(m *ExecResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ControlService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supervisor.ControlService/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supervisor.ControlService/RevokeSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).RevokeSession(ctx, req.(*RevokeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ControlService_ServiceDesc is the grpc.ServiceDesc for ControlService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -181,7 +311,21 @@ var ControlService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateDebugEnv",
 			Handler:    _ControlService_CreateDebugEnv_Handler,
 		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _ControlService_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _ControlService_RevokeSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Exec",
+			Handler:       _ControlService_Exec_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "control.proto",
 }
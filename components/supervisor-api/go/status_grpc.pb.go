@@ -43,6 +43,17 @@ type StatusServiceClient interface {
 	TasksStatus(ctx context.Context, in *TasksStatusRequest, opts ...grpc.CallOption) (StatusService_TasksStatusClient, error)
 	// ResourcesStatus provides workspace resources status information.
 	ResourcesStatus(ctx context.Context, in *ResourcesStatuRequest, opts ...grpc.CallOption) (*ResourcesStatusResponse, error)
+	// DotfilesStatus provides feedback about the installation of the user's dotfiles. When used with `wait`,
+	// the call returns when the installation has finished.
+	DotfilesStatus(ctx context.Context, in *DotfilesStatusRequest, opts ...grpc.CallOption) (*DotfilesStatusResponse, error)
+	// StartupDiagnostics reports what supervisor waited on during the workspace startup and for how long,
+	// e.g. to debug slow or stuck IDE startups.
+	StartupDiagnostics(ctx context.Context, in *StartupDiagnosticsRequest, opts ...grpc.CallOption) (*StartupDiagnosticsResponse, error)
+	// IDEBackendStatus reports the state of the desktop IDE backend, e.g. the JetBrains backend,
+	// as last reported by the desktop IDE launcher.
+	IDEBackendStatus(ctx context.Context, in *IDEBackendStatusRequest, opts ...grpc.CallOption) (*IDEBackendStatusResponse, error)
+	// UpdateIDEBackendStatus is called by the desktop IDE launcher whenever the state of its backend changes.
+	UpdateIDEBackendStatus(ctx context.Context, in *UpdateIDEBackendStatusRequest, opts ...grpc.CallOption) (*UpdateIDEBackendStatusResponse, error)
 }
 
 type statusServiceClient struct {
@@ -162,6 +173,42 @@ func (c *statusServiceClient) ResourcesStatus(ctx context.Context, in *Resources
 	return out, nil
 }
 
+func (c *statusServiceClient) DotfilesStatus(ctx context.Context, in *DotfilesStatusRequest, opts ...grpc.CallOption) (*DotfilesStatusResponse, error) {
+	out := new(DotfilesStatusResponse)
+	err := c.cc.Invoke(ctx, "/supervisor.StatusService/DotfilesStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statusServiceClient) StartupDiagnostics(ctx context.Context, in *StartupDiagnosticsRequest, opts ...grpc.CallOption) (*StartupDiagnosticsResponse, error) {
+	out := new(StartupDiagnosticsResponse)
+	err := c.cc.Invoke(ctx, "/supervisor.StatusService/StartupDiagnostics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statusServiceClient) IDEBackendStatus(ctx context.Context, in *IDEBackendStatusRequest, opts ...grpc.CallOption) (*IDEBackendStatusResponse, error) {
+	out := new(IDEBackendStatusResponse)
+	err := c.cc.Invoke(ctx, "/supervisor.StatusService/IDEBackendStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statusServiceClient) UpdateIDEBackendStatus(ctx context.Context, in *UpdateIDEBackendStatusRequest, opts ...grpc.CallOption) (*UpdateIDEBackendStatusResponse, error) {
+	out := new(UpdateIDEBackendStatusResponse)
+	err := c.cc.Invoke(ctx, "/supervisor.StatusService/UpdateIDEBackendStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StatusServiceServer is the server API for StatusService service.
 // All implementations must embed UnimplementedStatusServiceServer
 // for forward compatibility
@@ -183,6 +230,17 @@ type StatusServiceServer interface {
 	TasksStatus(*TasksStatusRequest, StatusService_TasksStatusServer) error
 	// ResourcesStatus provides workspace resources status information.
 	ResourcesStatus(context.Context, *ResourcesStatuRequest) (*ResourcesStatusResponse, error)
+	// DotfilesStatus provides feedback about the installation of the user's dotfiles. When used with `wait`,
+	// the call returns when the installation has finished.
+	DotfilesStatus(context.Context, *DotfilesStatusRequest) (*DotfilesStatusResponse, error)
+	// StartupDiagnostics reports what supervisor waited on during the workspace startup and for how long,
+	// e.g. to debug slow or stuck IDE startups.
+	StartupDiagnostics(context.Context, *StartupDiagnosticsRequest) (*StartupDiagnosticsResponse, error)
+	// IDEBackendStatus reports the state of the desktop IDE backend, e.g. the JetBrains backend,
+	// as last reported by the desktop IDE launcher.
+	IDEBackendStatus(context.Context, *IDEBackendStatusRequest) (*IDEBackendStatusResponse, error)
+	// UpdateIDEBackendStatus is called by the desktop IDE launcher whenever the state of its backend changes.
+	UpdateIDEBackendStatus(context.Context, *UpdateIDEBackendStatusRequest) (*UpdateIDEBackendStatusResponse, error)
 	mustEmbedUnimplementedStatusServiceServer()
 }
 
@@ -211,6 +269,18 @@ func (UnimplementedStatusServiceServer) TasksStatus(*TasksStatusRequest, StatusS
 func (UnimplementedStatusServiceServer) ResourcesStatus(context.Context, *ResourcesStatuRequest) (*ResourcesStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ResourcesStatus not implemented")
 }
+func (UnimplementedStatusServiceServer) DotfilesStatus(context.Context, *DotfilesStatusRequest) (*DotfilesStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DotfilesStatus not implemented")
+}
+func (UnimplementedStatusServiceServer) StartupDiagnostics(context.Context, *StartupDiagnosticsRequest) (*StartupDiagnosticsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartupDiagnostics not implemented")
+}
+func (UnimplementedStatusServiceServer) IDEBackendStatus(context.Context, *IDEBackendStatusRequest) (*IDEBackendStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IDEBackendStatus not implemented")
+}
+func (UnimplementedStatusServiceServer) UpdateIDEBackendStatus(context.Context, *UpdateIDEBackendStatusRequest) (*UpdateIDEBackendStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateIDEBackendStatus not implemented")
+}
 func (UnimplementedStatusServiceServer) mustEmbedUnimplementedStatusServiceServer() {}
 
 // UnsafeStatusServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -356,6 +426,78 @@ func _StatusService_ResourcesStatus_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StatusService_DotfilesStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DotfilesStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).DotfilesStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supervisor.StatusService/DotfilesStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).DotfilesStatus(ctx, req.(*DotfilesStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_StartupDiagnostics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartupDiagnosticsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).StartupDiagnostics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supervisor.StatusService/StartupDiagnostics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).StartupDiagnostics(ctx, req.(*StartupDiagnosticsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_IDEBackendStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDEBackendStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).IDEBackendStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supervisor.StatusService/IDEBackendStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).IDEBackendStatus(ctx, req.(*IDEBackendStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_UpdateIDEBackendStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateIDEBackendStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).UpdateIDEBackendStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supervisor.StatusService/UpdateIDEBackendStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).UpdateIDEBackendStatus(ctx, req.(*UpdateIDEBackendStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // StatusService_ServiceDesc is the grpc.ServiceDesc for StatusService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -383,6 +525,22 @@ var StatusService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ResourcesStatus",
 			Handler:    _StatusService_ResourcesStatus_Handler,
 		},
+		{
+			MethodName: "DotfilesStatus",
+			Handler:    _StatusService_DotfilesStatus_Handler,
+		},
+		{
+			MethodName: "StartupDiagnostics",
+			Handler:    _StatusService_StartupDiagnostics_Handler,
+		},
+		{
+			MethodName: "IDEBackendStatus",
+			Handler:    _StatusService_IDEBackendStatus_Handler,
+		},
+		{
+			MethodName: "UpdateIDEBackendStatus",
+			Handler:    _StatusService_UpdateIDEBackendStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -26,7 +26,7 @@ var topOpts struct {
 
 var topCmd = &cobra.Command{
 	Use:   "top",
-	Short: "Display resource (CPU/memory) usage of the workspace",
+	Short: "Display resource (CPU/memory/disk) usage of the workspace",
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -52,12 +52,18 @@ var topCmd = &cobra.Command{
 			tw := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', 0)
 			defer tw.Flush()
 
-			fmt.Fprintf(tw, "CPU(millicores)\tMEMORY(bytes)\n")
+			fmt.Fprintf(tw, "CPU(millicores)\tMEMORY(bytes)\tDISK(bytes)\n")
 
 			cpuFraction := int64((float64(status.Cpu.Used) / float64(status.Cpu.Limit)) * 100)
 			memFraction := int64((float64(status.Memory.Used) / float64(status.Memory.Limit)) * 100)
 
-			fmt.Fprintf(tw, "%dm/%dm (%d%%)\t%dMi/%dMi (%d%%)\n", status.Cpu.Used, status.Cpu.Limit, cpuFraction, status.Memory.Used/(1024*1024), status.Memory.Limit/(1024*1024), memFraction)
+			disk := "-"
+			if status.Disk != nil && status.Disk.Limit > 0 {
+				diskFraction := int64((float64(status.Disk.Used) / float64(status.Disk.Limit)) * 100)
+				disk = fmt.Sprintf("%dGi/%dGi (%d%%)", status.Disk.Used/(1024*1024*1024), status.Disk.Limit/(1024*1024*1024), diskFraction)
+			}
+
+			fmt.Fprintf(tw, "%dm/%dm (%d%%)\t%dMi/%dMi (%d%%)\t%s\n", status.Cpu.Used, status.Cpu.Limit, cpuFraction, status.Memory.Used/(1024*1024), status.Memory.Limit/(1024*1024), memFraction, disk)
 		}
 	},
 }
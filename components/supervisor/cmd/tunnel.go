@@ -15,6 +15,10 @@ import (
 	"github.com/gitpod-io/gitpod/supervisor/api"
 )
 
+var tunnelCmdOpts struct {
+	Protocol string
+}
+
 var tunnelCmd = &cobra.Command{
 	Use:   "tunnel <localPort> [targetPort] [visibility]",
 	Short: "opens a new tunnel",
@@ -36,6 +40,10 @@ var tunnelCmd = &cobra.Command{
 		if len(args) > 2 {
 			visiblity = api.TunnelVisiblity(api.TunnelVisiblity_value[args[2]])
 		}
+		protocol, ok := api.TunnelProtocol_value[tunnelCmdOpts.Protocol]
+		if !ok {
+			log.WithField("protocol", tunnelCmdOpts.Protocol).Fatal("invalid protocol")
+		}
 
 		client := api.NewPortServiceClient(dialSupervisor())
 
@@ -45,6 +53,7 @@ var tunnelCmd = &cobra.Command{
 			Port:       uint32(localPort),
 			TargetPort: uint32(targetPort),
 			Visibility: visiblity,
+			Protocol:   api.TunnelProtocol(protocol),
 		})
 		if err != nil {
 			log.WithError(err).Fatal("cannot tunnel")
@@ -102,6 +111,7 @@ var autoTunnelCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(tunnelCmd)
+	tunnelCmd.Flags().StringVar(&tunnelCmdOpts.Protocol, "protocol", "tcp", "protocol of the tunneled port: tcp or udp")
 	tunnelCmd.AddCommand(closeTunnelCmd)
 	tunnelCmd.AddCommand(autoTunnelCmd)
 }
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package config
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// envReferenceRegexp matches ${NAME} references. Shell expansions like $NAME or ${NAME:-default} are not matched.
+var envReferenceRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// UndefinedVariablesError is returned if a template references variables which are not defined.
+type UndefinedVariablesError struct {
+	Names []string
+}
+
+func (e *UndefinedVariablesError) Error() string {
+	refs := make([]string, 0, len(e.Names))
+	for _, name := range e.Names {
+		refs = append(refs, "${"+name+"}")
+	}
+	if len(refs) == 1 {
+		return "undefined variable " + refs[0]
+	}
+	return "undefined variables " + strings.Join(refs, ", ")
+}
+
+// ExpandEnv replaces ${NAME} references in s with the values returned by lookup.
+// It fails with an UndefinedVariablesError listing all references for which lookup returns false.
+func ExpandEnv(s string, lookup func(name string) (value string, ok bool)) (string, error) {
+	var undefined []string
+	res := envReferenceRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		value, ok := lookup(name)
+		if !ok {
+			if !slices.Contains(undefined, name) {
+				undefined = append(undefined, name)
+			}
+			return ref
+		}
+		return value
+	})
+	if len(undefined) > 0 {
+		return s, &UndefinedVariablesError{Names: undefined}
+	}
+	return res, nil
+}
+
+// EnvLookup looks up variables in a list of NAME=value pairs, later pairs take precedence.
+func EnvLookup(env []string) func(name string) (string, bool) {
+	vars := make(map[string]string, len(env))
+	for _, e := range env {
+		name, value, ok := strings.Cut(e, "=")
+		if !ok {
+			continue
+		}
+		vars[name] = value
+	}
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandEnv(t *testing.T) {
+	lookup := EnvLookup([]string{"GITPOD_WORKSPACE_URL=https://ws.gitpod.io", "PORT=3000", "PORT=3001", "EMPTY=", "INVALID"})
+
+	type Expectation struct {
+		Result string
+		Error  string
+	}
+	tests := []struct {
+		Desc        string
+		Template    string
+		Expectation Expectation
+	}{
+		{
+			Desc:        "no references",
+			Template:    "plain $PORT ${PORT:-80}",
+			Expectation: Expectation{Result: "plain $PORT ${PORT:-80}"},
+		},
+		{
+			Desc:        "defined variables",
+			Template:    "${GITPOD_WORKSPACE_URL}:${PORT}${EMPTY}",
+			Expectation: Expectation{Result: "https://ws.gitpod.io:3001"},
+		},
+		{
+			Desc:        "undefined variable",
+			Template:    "${PORT} ${GITPOD_UNDEFINED}",
+			Expectation: Expectation{Result: "${PORT} ${GITPOD_UNDEFINED}", Error: "undefined variable ${GITPOD_UNDEFINED}"},
+		},
+		{
+			Desc:        "multiple undefined variables",
+			Template:    "${INVALID} ${UNDEFINED} ${INVALID}",
+			Expectation: Expectation{Result: "${INVALID} ${UNDEFINED} ${INVALID}", Error: "undefined variables ${INVALID}, ${UNDEFINED}"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			var act Expectation
+			res, err := ExpandEnv(test.Template, lookup)
+			act.Result = res
+			if err != nil {
+				act.Error = err.Error()
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,6 +16,9 @@ type SupervisorMetrics struct {
 	InitializerHistogram  *prometheus.HistogramVec
 	SSHTunnelOpenedTotal  *prometheus.CounterVec
 	SSHTunnelClosedTotal  *prometheus.CounterVec
+	SSHSessionsTotal      *prometheus.CounterVec
+	TaskStateTotal        *prometheus.CounterVec
+	PortExposureDuration  *prometheus.HistogramVec
 }
 
 func NewMetrics() *SupervisorMetrics {
@@ -38,6 +41,19 @@ func NewMetrics() *SupervisorMetrics {
 			Name: "supervisor_ssh_tunnel_closed_total",
 			Help: "Total number of SSH tunnels closed by the supervisor",
 		}, []string{"code"}),
+		SSHSessionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "supervisor_ssh_sessions_total",
+			Help: "Total number of SSH sessions started in the workspace",
+		}, []string{}),
+		TaskStateTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "supervisor_task_state_total",
+			Help: "Total number of tasks which reached a state",
+		}, []string{"state"}),
+		PortExposureDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "supervisor_port_exposure_duration_seconds",
+			Help:    "the time it takes to auto-expose a served port",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"result"}),
 	}
 }
 
@@ -47,6 +63,9 @@ func (m *SupervisorMetrics) Register(registry *prometheus.Registry) error {
 		m.InitializerHistogram,
 		m.SSHTunnelOpenedTotal,
 		m.SSHTunnelClosedTotal,
+		m.SSHSessionsTotal,
+		m.TaskStateTotal,
+		m.PortExposureDuration,
 	}
 
 	for _, metric := range metrics {
@@ -34,17 +34,20 @@ func NewGrpcMetricsReporter(gitpodHost string) *GrpcMetricsReporter {
 	return &GrpcMetricsReporter{
 		Registry: prometheus.NewRegistry(),
 		supportedMetrics: map[string]bool{
-			"grpc_server_handled_total":           true,
-			"grpc_server_msg_received_total":      true,
-			"grpc_server_msg_sent_total":          true,
-			"grpc_server_started_total":           true,
-			"grpc_server_handling_seconds":        true,
-			"supervisor_ide_ready_duration_total": true,
-			"supervisor_initializer_bytes_second": true,
-			"supervisor_client_handled_total":     true,
-			"supervisor_client_handling_seconds":  true,
-			"supervisor_ssh_tunnel_opened_total":  true,
-			"supervisor_ssh_tunnel_closed_total":  true,
+			"grpc_server_handled_total":                 true,
+			"grpc_server_msg_received_total":            true,
+			"grpc_server_msg_sent_total":                true,
+			"grpc_server_started_total":                 true,
+			"grpc_server_handling_seconds":              true,
+			"supervisor_ide_ready_duration_total":       true,
+			"supervisor_initializer_bytes_second":       true,
+			"supervisor_client_handled_total":           true,
+			"supervisor_client_handling_seconds":        true,
+			"supervisor_ssh_tunnel_opened_total":        true,
+			"supervisor_ssh_tunnel_closed_total":        true,
+			"supervisor_ssh_sessions_total":             true,
+			"supervisor_task_state_total":               true,
+			"supervisor_port_exposure_duration_seconds": true,
 		},
 		values: make(map[string]float64),
 		addCounter: func(name string, labels map[string]string, value uint64) {
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package ports
+
+import (
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// MaxDatagramSize is the largest UDP payload which can be forwarded through a tunnel.
+const MaxDatagramSize = 0xFFFF
+
+// WriteDatagram writes a single datagram to a stream, e.g. an SSH channel.
+// Stream transports don't preserve message boundaries, hence every datagram is prefixed by its length
+// as two byte big endian integer.
+func WriteDatagram(w io.Writer, datagram []byte) error {
+	if len(datagram) > MaxDatagramSize {
+		return xerrors.Errorf("datagram exceeds %d bytes", MaxDatagramSize)
+	}
+	frame := make([]byte, 2+len(datagram))
+	binary.BigEndian.PutUint16(frame, uint16(len(datagram)))
+	copy(frame[2:], datagram)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadDatagram reads a single datagram written by WriteDatagram from a stream into buf,
+// which must be at least MaxDatagramSize large.
+func ReadDatagram(r io.Reader, buf []byte) (int, error) {
+	var header [2]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return 0, err
+	}
+	size := int(binary.BigEndian.Uint16(header[:]))
+	if size > len(buf) {
+		return 0, xerrors.Errorf("datagram of %d bytes exceeds the buffer", size)
+	}
+	_, err = io.ReadFull(r, buf[:size])
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return size, err
+}
+
+// CopyDatagramsToStream forwards datagrams read from a packet connection to a stream until either fails.
+func CopyDatagramsToStream(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, MaxDatagramSize)
+	for {
+		n, err := src.Read(buf)
+		if err != nil {
+			return err
+		}
+		err = WriteDatagram(dst, buf[:n])
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CopyStreamToDatagrams forwards datagrams read from a stream to a packet connection until either fails.
+func CopyStreamToDatagrams(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, MaxDatagramSize)
+	for {
+		n, err := ReadDatagram(src, buf)
+		if err != nil {
+			return err
+		}
+		_, err = dst.Write(buf[:n])
+		if err != nil {
+			return err
+		}
+	}
+}
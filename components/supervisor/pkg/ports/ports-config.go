@@ -100,13 +100,15 @@ type ConfigInterace interface {
 type ConfigService struct {
 	workspaceID   string
 	configService config.ConfigInterface
+	lookupEnv     func(name string) (string, bool)
 }
 
-// NewConfigService creates a new instance of ConfigService.
-func NewConfigService(workspaceID string, configService config.ConfigInterface) *ConfigService {
+// NewConfigService creates a new instance of ConfigService. ${NAME} references in port configs are resolved against env.
+func NewConfigService(workspaceID string, configService config.ConfigInterface, env []string) *ConfigService {
 	return &ConfigService{
 		workspaceID:   workspaceID,
 		configService: configService,
+		lookupEnv:     config.EnvLookup(env),
 	}
 }
 
@@ -131,7 +133,14 @@ func (service *ConfigService) Observe(ctx context.Context) (<-chan *Configs, <-c
 				if !ok {
 					return
 				}
-				changed := service.update(config, current)
+				changed, errs := service.update(config, current)
+				for _, err := range errs {
+					select {
+					case errorsChan <- err:
+					case <-ctx.Done():
+						return
+					}
+				}
 				if !changed {
 					continue
 				}
@@ -145,16 +154,64 @@ func (service *ConfigService) Observe(ctx context.Context) (<-chan *Configs, <-c
 	return updatesChan, errorsChan
 }
 
-func (service *ConfigService) update(config *gitpod.GitpodConfig, current *Configs) bool {
+func (service *ConfigService) update(config *gitpod.GitpodConfig, current *Configs) (bool, []error) {
 	currentPortConfigs, currentRangeConfigs := current.instancePortConfigs, current.instanceRangeConfigs
 	var ports []*gitpod.PortsItems
 	if config != nil {
 		ports = config.Ports
 	}
+	ports, errs := expandPortsItems(ports, service.lookupEnv)
 	portConfigs, rangeConfigs := parseInstanceConfigs(ports)
 	current.instancePortConfigs = portConfigs
 	current.instanceRangeConfigs = rangeConfigs
-	return !reflect.DeepEqual(currentPortConfigs, portConfigs) || !reflect.DeepEqual(currentRangeConfigs, rangeConfigs)
+	return !reflect.DeepEqual(currentPortConfigs, portConfigs) || !reflect.DeepEqual(currentRangeConfigs, rangeConfigs), errs
+}
+
+// expandPortsItems resolves ${NAME} references in the port configs.
+// Port configs referencing undefined variables are left out, so that no port is configured with a half-resolved value.
+func expandPortsItems(ports []*gitpod.PortsItems, lookupEnv func(name string) (string, bool)) ([]*gitpod.PortsItems, []error) {
+	var (
+		res  = make([]*gitpod.PortsItems, len(ports))
+		errs []error
+	)
+	for index, item := range ports {
+		if item == nil {
+			continue
+		}
+		expanded := *item
+		for _, field := range []struct {
+			Name  string
+			Value *string
+		}{
+			{"name", &expanded.Name},
+			{"description", &expanded.Description},
+			{"onOpen", &expanded.OnOpen},
+			{"visibility", &expanded.Visibility},
+			{"protocol", &expanded.Protocol},
+		} {
+			value, err := config.ExpandEnv(*field.Value, lookupEnv)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("ports[%d].%s: %w", index, field.Name, err))
+				expanded.Port = nil
+				continue
+			}
+			*field.Value = value
+		}
+		if port, ok := expanded.Port.(string); ok {
+			value, err := config.ExpandEnv(port, lookupEnv)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("ports[%d].port: %w", index, err))
+				expanded.Port = nil
+			} else {
+				expanded.Port = value
+			}
+		}
+		if expanded.Port == nil {
+			continue
+		}
+		res[index] = &expanded
+	}
+	return res, errs
 }
 
 var portRangeRegexp = regexp.MustCompile(`^(\d+)[-:](\d+)$`)
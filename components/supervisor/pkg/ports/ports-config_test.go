@@ -18,6 +18,7 @@ func TestPortsConfig(t *testing.T) {
 	tests := []struct {
 		Desc         string
 		GitpodConfig *gitpod.GitpodConfig
+		Env          []string
 		Expectation  *PortConfigTestExpectations
 	}{
 		{
@@ -78,6 +79,50 @@ func TestPortsConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Desc: "interpolated port configs",
+			GitpodConfig: &gitpod.GitpodConfig{
+				Ports: []*gitpod.PortsItems{
+					{
+						Port:        "${APP_PORT}",
+						Name:        "App",
+						Description: "Serves ${GITPOD_WORKSPACE_URL}",
+						Visibility:  "${APP_VISIBILITY}",
+					},
+					{
+						Port:        "${DEBUG_PORTS}",
+						Description: "Debug ports",
+					},
+					{
+						Port: 8080,
+						Name: "${GITPOD_UNDEFINED}",
+					},
+				},
+			},
+			Env: []string{"APP_PORT=3000", "APP_VISIBILITY=public", "GITPOD_WORKSPACE_URL=https://ws.gitpod.io", "DEBUG_PORTS=9229-9239"},
+			Expectation: &PortConfigTestExpectations{
+				InstancePortConfigs: []*gitpod.PortConfig{
+					{
+						Port:        3000,
+						Visibility:  "public",
+						Name:        "App",
+						Description: "Serves https://ws.gitpod.io",
+					},
+				},
+				InstanceRangeConfigs: []*RangeConfig{
+					{
+						PortsItems: gitpod.PortsItems{
+							Port:        "9229-9239",
+							Description: "Debug ports",
+						},
+						Start: 9229,
+						End:   9239,
+						Sort:  1,
+					},
+				},
+				Errors: []string{"ports[2].name: undefined variable ${GITPOD_UNDEFINED}"},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.Desc, func(t *testing.T) {
@@ -96,7 +141,7 @@ func TestPortsConfig(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			service := NewConfigService(workspaceID, configService)
+			service := NewConfigService(workspaceID, configService, test.Env)
 			updates, errors := service.Observe(context)
 
 			actual := &PortConfigTestExpectations{}
@@ -105,13 +150,16 @@ func TestPortsConfig(t *testing.T) {
 				go func() {
 					configService.configs <- test.GitpodConfig
 				}()
-				select {
-				case err := <-errors:
-					t.Fatal(err)
-				case change := <-updates:
-					actual.InstanceRangeConfigs = change.instanceRangeConfigs
-					for _, config := range change.instancePortConfigs {
-						actual.InstancePortConfigs = append(actual.InstancePortConfigs, &config.PortConfig)
+				for updated := false; !updated; {
+					select {
+					case err := <-errors:
+						actual.Errors = append(actual.Errors, err.Error())
+					case change := <-updates:
+						actual.InstanceRangeConfigs = change.instanceRangeConfigs
+						for _, config := range change.instancePortConfigs {
+							actual.InstancePortConfigs = append(actual.InstancePortConfigs, &config.PortConfig)
+						}
+						updated = true
 					}
 				}
 			}
@@ -126,6 +174,7 @@ func TestPortsConfig(t *testing.T) {
 type PortConfigTestExpectations struct {
 	InstancePortConfigs  []*gitpod.PortConfig
 	InstanceRangeConfigs []*RangeConfig
+	Errors               []string
 }
 
 type testGitpodConfigService struct {
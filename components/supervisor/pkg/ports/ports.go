@@ -56,6 +56,10 @@ func NewManager(exposed ExposedPortsInterface, served ServedPortsObserver, confi
 		subscriptions: make(map[*Subscription]struct{}),
 		proxyStarter:  startLocalhostProxy,
 
+		detectedProtocols: make(map[uint32]string),
+		probingProtocols:  make(map[uint32]struct{}),
+		protocolDetector:  DetectProtocol,
+
 		autoTunnelEnabled: true,
 	}
 }
@@ -80,6 +84,9 @@ type Manager struct {
 	C ConfigInterace
 	T TunneledPortsInterface
 
+	// OnAutoExposed is called with the time it took to auto-expose a port, if set
+	OnAutoExposed func(duration time.Duration, err error)
+
 	forceUpdates chan struct{}
 
 	internal     map[uint32]struct{}
@@ -87,6 +94,10 @@ type Manager struct {
 	proxyStarter func(port uint32) (proxy io.Closer, err error)
 	autoExposed  map[uint32]*autoExposure
 
+	detectedProtocols map[uint32]string
+	probingProtocols  map[uint32]struct{}
+	protocolDetector  func(ctx context.Context, port ServedPort) string
+
 	autoTunneled      map[uint32]struct{}
 	autoTunnelEnabled bool
 
@@ -119,6 +130,7 @@ type managedPort struct {
 	Tunneled           bool
 	TunneledTargetPort uint32
 	TunneledVisibility api.TunnelVisiblity
+	TunneledProtocol   api.TunnelProtocol
 	TunneledClients    map[string]uint32
 }
 
@@ -291,6 +303,13 @@ func (pm *Manager) updateState(ctx context.Context, exposed []ExposedPort, serve
 			newServed = append(newServed, servedMap[key])
 		}
 
+		for port := range pm.detectedProtocols {
+			if _, stillServed := servedMap[port]; !stillServed {
+				// the next service on this port might speak a different protocol
+				delete(pm.detectedProtocols, port)
+			}
+		}
+
 		if !reflect.DeepEqual(pm.served, newServed) {
 			log.WithField("served", newServed).Debug("updating served ports")
 			pm.served = newServed
@@ -302,6 +321,7 @@ func (pm *Manager) updateState(ctx context.Context, exposed []ExposedPort, serve
 	if configured != nil {
 		pm.configs = configured
 	}
+	pm.probeProtocols(ctx)
 
 	newState := pm.nextState(ctx)
 	stateChanged := !reflect.DeepEqual(newState, pm.state)
@@ -378,6 +398,7 @@ func (pm *Manager) nextState(ctx context.Context) map[uint32]*managedPort {
 		mp.Tunneled = true
 		mp.TunneledTargetPort = tunneled.Desc.TargetPort
 		mp.TunneledVisibility = tunneled.Desc.Visibility
+		mp.TunneledProtocol = tunneled.Desc.Protocol
 		mp.TunneledClients = tunneled.Clients
 	}
 
@@ -423,7 +444,7 @@ func (pm *Manager) nextState(ctx context.Context) map[uint32]*managedPort {
 		}
 
 		var public bool
-		protocol := "http"
+		var protocol string
 		config, kind, exists := pm.configs.Get(mp.LocalhostPort)
 
 		getProtocol := func(p api.PortProtocol) string {
@@ -439,9 +460,14 @@ func (pm *Manager) nextState(ctx context.Context) map[uint32]*managedPort {
 		if mp.Exposed || configured {
 			public = mp.Visibility == api.PortVisibility_public
 			protocol = getProtocol(mp.Protocol)
-		} else if exists {
-			public = config.Visibility == "public"
-			protocol = config.Protocol
+		} else {
+			if exists {
+				public = config.Visibility == "public"
+				protocol = config.Protocol
+			}
+			if protocol == "" {
+				protocol = pm.exposureProtocol(port)
+			}
 		}
 
 		if mp.Exposed && ((mp.Visibility == api.PortVisibility_public && public) || (mp.Visibility == api.PortVisibility_private && !public)) && protocol != "https" {
@@ -470,6 +496,7 @@ func (pm *Manager) nextState(ctx context.Context) map[uint32]*managedPort {
 
 // clients should guard a call with check whether such port is already exposed or auto exposed
 func (pm *Manager) autoExpose(ctx context.Context, localPort uint32, public bool, protocol string) *autoExposure {
+	start := time.Now()
 	exposing := pm.E.Expose(ctx, localPort, public, protocol)
 	autoExpose := &autoExposure{
 		state:    api.PortAutoExposure_trying,
@@ -479,6 +506,9 @@ func (pm *Manager) autoExpose(ctx context.Context, localPort uint32, public bool
 	}
 	go func() {
 		err := <-exposing
+		if err != context.Canceled && pm.OnAutoExposed != nil {
+			pm.OnAutoExposed(time.Since(start), err)
+		}
 		if err != nil {
 			if err != context.Canceled {
 				autoExpose.state = api.PortAutoExposure_failed
@@ -666,6 +696,7 @@ func (pm *Manager) Expose(ctx context.Context, port uint32) error {
 		// will be auto-exposed
 		return nil
 	}
+	protocol := pm.exposureProtocol(port)
 
 	// we don't need the lock anymore. Let's unlock and make sure the defer doesn't try
 	// the same thing again.
@@ -673,11 +704,12 @@ func (pm *Manager) Expose(ctx context.Context, port uint32) error {
 	unlock = false
 
 	public := false
-	protocol := gitpod.PortProtocolHTTP
 
 	if exists {
 		public = config.Visibility != "private"
-		protocol = config.Protocol
+		if config.Protocol != "" {
+			protocol = config.Protocol
+		}
 	}
 
 	err := <-pm.E.Expose(ctx, port, public, protocol)
@@ -728,8 +760,8 @@ func (pm *Manager) CloseTunnel(ctx context.Context, port uint32) error {
 }
 
 // EstablishTunnel actually establishes the tunnel
-func (pm *Manager) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error) {
-	return pm.T.EstablishTunnel(ctx, clientID, localPort, targetPort)
+func (pm *Manager) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error) {
+	return pm.T.EstablishTunnel(ctx, clientID, localPort, targetPort, protocol)
 }
 
 // AutoTunnel controls enablement of auto tunneling
@@ -829,6 +861,7 @@ func (pm *Manager) getPortStatus(port uint32) *api.PortsStatus {
 			TargetPort: mp.TunneledTargetPort,
 			Visibility: mp.TunneledVisibility,
 			Clients:    mp.TunneledClients,
+			Protocol:   mp.TunneledProtocol,
 		}
 	}
 	return ps
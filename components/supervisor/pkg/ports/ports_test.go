@@ -672,6 +672,9 @@ func TestPortsUpdateState(t *testing.T) {
 			pm.proxyStarter = func(port uint32) (io.Closer, error) {
 				return io.NopCloser(nil), nil
 			}
+			pm.protocolDetector = func(ctx context.Context, port ServedPort) string {
+				return PortProtocolTCP
+			}
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
@@ -760,7 +763,7 @@ func (tep *testTunneledPorts) Tunnel(ctx context.Context, options *TunnelOptions
 func (tep *testTunneledPorts) CloseTunnel(ctx context.Context, localPorts ...uint32) ([]uint32, error) {
 	return nil, nil
 }
-func (tep *testTunneledPorts) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error) {
+func (tep *testTunneledPorts) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error) {
 	return nil, nil
 }
 
@@ -834,6 +837,9 @@ func TestPortsConcurrentSubscribe(t *testing.T) {
 	pm.proxyStarter = func(local uint32) (io.Closer, error) {
 		return io.NopCloser(nil), nil
 	}
+	pm.protocolDetector = func(ctx context.Context, port ServedPort) string {
+		return PortProtocolTCP
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package ports
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+)
+
+// PortProtocolTCP is reported for served ports which speak neither HTTP nor HTTPS.
+// Such ports can only be exposed through the HTTP proxy, hence they are exposed as HTTP.
+const PortProtocolTCP = "tcp"
+
+// protocolProbeTimeout bounds each probe so that services which wait for the client
+// to speak first do not stall the detection.
+const protocolProbeTimeout = 1 * time.Second
+
+// protocolDetectionTimeout bounds the detection of the protocol of a port, which probes it several times
+const protocolDetectionTimeout = 3 * protocolProbeTimeout
+
+// DetectProtocol probes the service listening on a served port and reports whether
+// it speaks HTTPS, HTTP or something else.
+func DetectProtocol(ctx context.Context, port ServedPort) string {
+	addr := probeAddress(port)
+	if probeTLS(ctx, addr) {
+		return gitpod.PortProtocolHTTPS
+	}
+	if probeHTTP(ctx, addr) {
+		return gitpod.PortProtocolHTTP
+	}
+	return PortProtocolTCP
+}
+
+func probeAddress(port ServedPort) string {
+	ip := port.Address
+	if ip == nil || ip.IsUnspecified() {
+		if ip != nil && ip.To4() == nil {
+			ip = net.IPv6loopback
+		} else {
+			ip = net.IPv4(127, 0, 0, 1)
+		}
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port.Port)))
+}
+
+func probeTLS(ctx context.Context, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, protocolProbeTimeout)
+	defer cancel()
+
+	// dev servers mostly use self-signed certificates, we only care whether they speak TLS at all
+	dialer := tls.Dialer{Config: &tls.Config{InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func probeHTTP(ctx context.Context, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, protocolProbeTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "http://"+addr+"/", nil)
+	if err != nil {
+		return false
+	}
+	err = req.Write(conn)
+	if err != nil {
+		return false
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// probeProtocols starts probing the served ports whose protocol is not known yet. The probes run in the background
+// and are bounded by protocolDetectionTimeout, such that they neither block the port manager nor status requests.
+// Until a probe is done the port is exposed as HTTP, and re-exposed if it turns out to serve HTTPS.
+// Must be called with pm.mu held.
+func (pm *Manager) probeProtocols(ctx context.Context) {
+	for _, port := range pm.served {
+		if _, detected := pm.detectedProtocols[port.Port]; detected || pm.boundInternally(port.Port) {
+			continue
+		}
+		if _, probing := pm.probingProtocols[port.Port]; probing {
+			continue
+		}
+		if config, _, exists := pm.configs.Get(port.Port); exists && config.Protocol != "" {
+			continue
+		}
+
+		pm.probingProtocols[port.Port] = struct{}{}
+		go func(port ServedPort) {
+			probeCtx, cancel := context.WithTimeout(ctx, protocolDetectionTimeout)
+			protocol := pm.protocolDetector(probeCtx, port)
+			if probeCtx.Err() != nil {
+				// the service did not answer in time, we expose it like any port whose protocol we don't know
+				protocol = PortProtocolTCP
+			}
+			cancel()
+			log.WithField("localPort", port.Port).WithField("protocol", protocol).Debug("detected port protocol")
+
+			pm.mu.Lock()
+			defer pm.mu.Unlock()
+			delete(pm.probingProtocols, port.Port)
+			var stillServed bool
+			for _, p := range pm.served {
+				stillServed = stillServed || p.Port == port.Port
+			}
+			if !stillServed {
+				return
+			}
+			pm.detectedProtocols[port.Port] = protocol
+
+			autoExpose, autoExposed := pm.autoExposed[port.Port]
+			if protocol != gitpod.PortProtocolHTTPS || !autoExposed || autoExpose.ctx.Err() != nil || autoExpose.protocol == gitpod.PortProtocolHTTPS {
+				return
+			}
+			pm.autoExpose(autoExpose.ctx, port.Port, autoExpose.public, gitpod.PortProtocolHTTPS)
+			pm.forceUpdate()
+		}(port)
+	}
+}
+
+// exposureProtocol returns the protocol a port should be exposed with if it's not configured explicitly
+func (pm *Manager) exposureProtocol(port uint32) string {
+	if pm.detectedProtocols[port] == gitpod.PortProtocolHTTPS {
+		return gitpod.PortProtocolHTTPS
+	}
+	return gitpod.PortProtocolHTTP
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package ports
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+)
+
+func TestDetectProtocol(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+	httpsServer := httptest.NewTLSServer(handler)
+	defer httpsServer.Close()
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+	go func() {
+		for {
+			conn, err := tcpListener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_8.9\r\n"))
+				_, _ = conn.Read(make([]byte, 1024))
+			}()
+		}
+	}()
+
+	tests := []struct {
+		Desc     string
+		Addr     net.Addr
+		Expected string
+	}{
+		{Desc: "http", Addr: httpServer.Listener.Addr(), Expected: gitpod.PortProtocolHTTP},
+		{Desc: "https", Addr: httpsServer.Listener.Addr(), Expected: gitpod.PortProtocolHTTPS},
+		{Desc: "raw tcp", Addr: tcpListener.Addr(), Expected: PortProtocolTCP},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			addr := test.Addr.(*net.TCPAddr)
+			act := DetectProtocol(context.Background(), ServedPort{Address: net.IPv4zero, Port: uint32(addr.Port)})
+			if act != test.Expected {
+				t.Errorf("unexpected protocol: expected %s, got %s", test.Expected, act)
+			}
+		})
+	}
+}
+
+type protocolExposedPorts struct {
+	testExposedPorts
+	protocols chan string
+}
+
+func (pep *protocolExposedPorts) Expose(ctx context.Context, local uint32, public bool, protocol string) <-chan error {
+	pep.protocols <- protocol
+	return nil
+}
+
+func TestProtocolDetectionDoesNotBlock(t *testing.T) {
+	var (
+		exposed = &protocolExposedPorts{protocols: make(chan string, 2)}
+		pm      = NewManager(exposed, &testServedPorts{}, &testConfigService{}, &testTunneledPorts{})
+		probed  = make(chan struct{})
+		once    sync.Once
+	)
+	pm.proxyStarter = func(port uint32) (io.Closer, error) {
+		return io.NopCloser(nil), nil
+	}
+	pm.protocolDetector = func(ctx context.Context, port ServedPort) string {
+		once.Do(func() { <-probed })
+		return gitpod.PortProtocolHTTPS
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := []ServedPort{{Address: net.IPv4zero, Port: 8080}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pm.updateState(ctx, nil, served, nil, nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(protocolProbeTimeout):
+		t.Fatal("updating the state waits for the protocol detection")
+	}
+	if act := <-exposed.protocols; act != gitpod.PortProtocolHTTP {
+		t.Fatalf("unexpected protocol before detection: expected %s, got %s", gitpod.PortProtocolHTTP, act)
+	}
+
+	close(probed)
+	select {
+	case act := <-exposed.protocols:
+		if act != gitpod.PortProtocolHTTPS {
+			t.Errorf("unexpected protocol after detection: expected %s, got %s", gitpod.PortProtocolHTTPS, act)
+		}
+	case <-time.After(protocolDetectionTimeout):
+		t.Fatal("port was not re-exposed after the protocol was detected")
+	}
+}
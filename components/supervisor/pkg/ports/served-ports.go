@@ -172,6 +172,53 @@ func readNetTCPFile(fc io.Reader, listeningOnly bool) (ports []ServedPort, err e
 	return
 }
 
+// ConnectedPorts returns the local ports which peers outside the workspace are connected to.
+func ConnectedPorts() (map[uint32]struct{}, error) {
+	res := make(map[uint32]struct{})
+	for _, fn := range []string{fnNetTCP, fnNetTCP6} {
+		fc, err := os.Open(fn)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = readConnectedPorts(fc, res)
+		fc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func readConnectedPorts(fc io.Reader, res map[uint32]struct{}) error {
+	scanner := bufio.NewScanner(fc)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// only established connections
+		if len(fields) < 4 || fields[3] != "01" {
+			continue
+		}
+
+		local := strings.Split(fields[1], ":")
+		remote := strings.Split(fields[2], ":")
+		if len(local) < 2 || len(remote) < 2 {
+			continue
+		}
+		if hexDecodeIP([]byte(remote[0])).IsLoopback() {
+			continue
+		}
+		port, err := strconv.ParseUint(local[1], 16, 32)
+		if err != nil {
+			log.WithError(err).WithField("port", local[1]).Warn("cannot parse port entry from /proc/net/tcp* file")
+			continue
+		}
+		res[uint32(port)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
 // Parses IPv4/IPv6 addresses. The address is a big endian 32 bit ints, hex encoded.
 // We just decode the hex and flip the bytes in every group of 4.
 func hexDecodeIP(src []byte) net.IP {
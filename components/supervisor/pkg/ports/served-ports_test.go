@@ -300,3 +300,21 @@ func TestReadNetTCPFile(t *testing.T) {
 		})
 	}
 }
+
+func TestReadConnectedPorts(t *testing.T) {
+	act := make(map[uint32]struct{})
+	for _, input := range []string{validTCPInput, validTCP6Input} {
+		err := readConnectedPorts(bytes.NewReader([]byte(input)), act)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := map[uint32]struct{}{
+		22999: {},
+		23000: {},
+	}
+	if diff := cmp.Diff(exp, act); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
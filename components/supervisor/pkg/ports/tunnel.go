@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -22,6 +23,7 @@ type PortTunnelDescription struct {
 	LocalPort  uint32
 	TargetPort uint32
 	Visibility api.TunnelVisiblity
+	Protocol   api.TunnelProtocol
 }
 
 type PortTunnelState struct {
@@ -32,6 +34,18 @@ type PortTunnelState struct {
 type PortTunnel struct {
 	State PortTunnelState
 	Conns map[string]map[net.Conn]struct{}
+
+	// since records when clients established their first connection
+	since map[string]time.Time
+}
+
+// TunnelClient describes a client which forwards connections through a tunnel.
+type TunnelClient struct {
+	LocalPort   uint32
+	TargetPort  uint32
+	ClientID    string
+	Connections int
+	Since       time.Time
 }
 
 type TunnelOptions struct {
@@ -55,7 +69,8 @@ type TunneledPortsInterface interface {
 	CloseTunnel(ctx context.Context, localPorts ...uint32) ([]uint32, error)
 
 	// EstablishTunnel actually establishes the tunnel for an incoming connection on a remote machine.
-	EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error)
+	// For UDP tunnels the returned connection reads and writes whole datagrams.
+	EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error)
 }
 
 // TunneledPortsService observes the tunneled ports.
@@ -129,6 +144,9 @@ func (desc *PortTunnelDescription) validate() (err error) {
 	if desc.TargetPort > 0xFFFF {
 		return xerrors.Errorf("bad target port: %d", desc.TargetPort)
 	}
+	if _, ok := api.TunnelProtocol_name[int32(desc.Protocol)]; !ok {
+		return xerrors.Errorf("bad protocol: %d", desc.Protocol)
+	}
 	return nil
 }
 
@@ -205,7 +223,7 @@ func (p *TunneledPortsService) CloseTunnel(ctx context.Context, localPorts ...ui
 }
 
 // EstablishTunnel actually establishes the tunnel.
-func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error) {
+func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
@@ -218,9 +236,16 @@ func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID str
 	} else {
 		return nil, xerrors.Errorf("client '%s': '%d' tunnel does not exist", clientID, localPort)
 	}
+	if tunnel.State.Desc.Protocol != protocol {
+		return nil, xerrors.Errorf("client '%s': '%d' tunnel expects %s, got %s", clientID, localPort, tunnel.State.Desc.Protocol, protocol)
+	}
 
+	network := "tcp"
+	if protocol == api.TunnelProtocol_udp {
+		network = "udp"
+	}
 	addr := net.JoinHostPort("localhost", strconv.FormatInt(int64(localPort), 10))
-	conn, err := net.Dial("tcp", addr)
+	conn, err := net.Dial(network, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -237,6 +262,7 @@ func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID str
 			delete(tunnel.Conns[clientID], result)
 			if len(tunnel.Conns[clientID]) == 0 {
 				delete(tunnel.State.Clients, clientID)
+				delete(tunnel.since, clientID)
 			}
 			p.cond.Broadcast()
 		},
@@ -244,12 +270,72 @@ func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID str
 	if tunnel.Conns[clientID] == nil {
 		tunnel.Conns[clientID] = make(map[net.Conn]struct{})
 	}
+	if tunnel.since == nil {
+		tunnel.since = make(map[string]time.Time)
+	}
+	if _, ok := tunnel.since[clientID]; !ok {
+		tunnel.since[clientID] = time.Now()
+	}
 	tunnel.Conns[clientID][result] = struct{}{}
 	tunnel.State.Clients[clientID] = targetPort
 	p.cond.Broadcast()
 	return result, nil
 }
 
+// Clients lists the clients with open connections, ordered by local port and client ID.
+func (p *TunneledPortsService) Clients() []TunnelClient {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var res []TunnelClient
+	for localPort, tunnel := range p.tunnels {
+		for clientID, conns := range tunnel.Conns {
+			if len(conns) == 0 {
+				continue
+			}
+			res = append(res, TunnelClient{
+				LocalPort:   localPort,
+				TargetPort:  tunnel.State.Clients[clientID],
+				ClientID:    clientID,
+				Connections: len(conns),
+				Since:       tunnel.since[clientID],
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].LocalPort != res[j].LocalPort {
+			return res[i].LocalPort < res[j].LocalPort
+		}
+		return res[i].ClientID < res[j].ClientID
+	})
+	return res
+}
+
+// CloseClient closes all connections of a client to a tunnel, the tunnel itself stays open.
+// It returns false if the client has no open connections.
+func (p *TunneledPortsService) CloseClient(localPort uint32, clientID string) (closed bool, err error) {
+	var conns []net.Conn
+	p.cond.L.Lock()
+	if tunnel, exists := p.tunnels[localPort]; exists {
+		for conn := range tunnel.Conns[clientID] {
+			conns = append(conns, conn)
+		}
+	}
+	p.cond.L.Unlock()
+	for _, conn := range conns {
+		closeErr := conn.Close()
+		if closeErr == nil {
+			continue
+		}
+		if err == nil {
+			err = closeErr
+		} else {
+			err = xerrors.Errorf("%s\n%s", err, closeErr)
+		}
+	}
+	return len(conns) > 0, err
+}
+
 // Snapshot writes a snapshot to w.
 func (p *TunneledPortsService) Snapshot(w io.Writer) {
 	p.mu.RLock()
@@ -267,6 +353,7 @@ func (p *TunneledPortsService) Snapshot(w io.Writer) {
 		fmt.Fprintf(w, "Target Port: %d\n", tunnel.State.Desc.TargetPort)
 		visibilty := api.TunnelVisiblity_name[int32(tunnel.State.Desc.Visibility)]
 		fmt.Fprintf(w, "Visibility: %s\n", visibilty)
+		fmt.Fprintf(w, "Protocol: %s\n", tunnel.State.Desc.Protocol)
 		for clientID, remotePort := range tunnel.State.Clients {
 			fmt.Fprintf(w, "Client: %s\n", clientID)
 			fmt.Fprintf(w, "  Remote Port: %d\n", remotePort)
@@ -119,7 +119,7 @@ func TestLocalPortTunneling(t *testing.T) {
 		}
 		defer src.Close()
 
-		dst, err := service.EstablishTunnel(ctx, "test", localPort, targetPort)
+		dst, err := service.EstablishTunnel(ctx, "test", localPort, targetPort, api.TunnelProtocol_tcp)
 		if err != nil {
 			return err
 		}
@@ -167,6 +167,148 @@ func TestLocalPortTunneling(t *testing.T) {
 	}
 }
 
+func TestUDPPortTunneling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	localConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localConn.Close()
+	go func() {
+		buf := make([]byte, MaxDatagramSize)
+		for {
+			n, addr, err := localConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = localConn.WriteTo(append(buf[:n], '!'), addr)
+		}
+	}()
+	localPort := uint32(localConn.LocalAddr().(*net.UDPAddr).Port)
+
+	service := NewTunneledPortsService(false)
+	_, err = service.Tunnel(ctx, &TunnelOptions{}, &PortTunnelDescription{
+		LocalPort:  localPort,
+		TargetPort: localPort,
+		Visibility: api.TunnelVisiblity_host,
+		Protocol:   api.TunnelProtocol_udp,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.EstablishTunnel(ctx, "test", localPort, localPort, api.TunnelProtocol_tcp)
+	if err == nil {
+		t.Fatal("expected protocol mismatch to fail")
+	}
+
+	tunnel, err := service.EstablishTunnel(ctx, "test", localPort, localPort, api.TunnelProtocol_udp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	// the pipe stands in for the SSH channel, which does not preserve message boundaries
+	stream, remote := net.Pipe()
+	defer stream.Close()
+	defer remote.Close()
+	go func() { _ = CopyDatagramsToStream(stream, tunnel) }()
+	go func() { _ = CopyStreamToDatagrams(tunnel, stream) }()
+
+	buf := make([]byte, MaxDatagramSize)
+	for _, msg := range []string{"Hello", "World"} {
+		err = WriteDatagram(remote, []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := ReadDatagram(remote, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(buf[:n]); act != msg+"!" {
+			t.Errorf("unexpected response: expected %q, got %q", msg+"!", act)
+		}
+	}
+}
+
+func TestTunnelClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	localListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localListener.Close()
+	go func() {
+		for {
+			conn, err := localListener.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, conn) }()
+		}
+	}()
+	localPort := uint32(localListener.Addr().(*net.TCPAddr).Port)
+
+	service := NewTunneledPortsService(false)
+	_, err = service.Tunnel(ctx, &TunnelOptions{}, &PortTunnelDescription{
+		LocalPort:  localPort,
+		TargetPort: 2000,
+		Visibility: api.TunnelVisiblity_host,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, clientID := range []string{"b", "a", "b"} {
+		conn, err := service.EstablishTunnel(ctx, clientID, localPort, 2000, api.TunnelProtocol_tcp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+
+	type Client struct {
+		ClientID    string
+		TargetPort  uint32
+		Connections int
+	}
+	clients := func() []Client {
+		var res []Client
+		for _, c := range service.Clients() {
+			if c.LocalPort != localPort || c.Since.IsZero() {
+				t.Errorf("unexpected client %+v", c)
+			}
+			res = append(res, Client{ClientID: c.ClientID, TargetPort: c.TargetPort, Connections: c.Connections})
+		}
+		return res
+	}
+	if diff := cmp.Diff([]Client{{"a", 2000, 1}, {"b", 2000, 2}}, clients()); diff != "" {
+		t.Errorf("unexpected clients (-want +got):\n%s", diff)
+	}
+
+	closed, err := service.CloseClient(localPort, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closed {
+		t.Error("expected client to be closed")
+	}
+	if diff := cmp.Diff([]Client{{"a", 2000, 1}}, clients()); diff != "" {
+		t.Errorf("unexpected clients (-want +got):\n%s", diff)
+	}
+
+	closed, err = service.CloseClient(localPort, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed {
+		t.Error("expected client without connections not to be closed")
+	}
+}
+
 func availablePort() (uint32, error) {
 	l, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
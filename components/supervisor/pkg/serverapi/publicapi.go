@@ -33,6 +33,7 @@ type APIInterface interface {
 	GetToken(ctx context.Context, query *gitpod.GetTokenSearchOptions) (res *gitpod.Token, err error)
 	OpenPort(ctx context.Context, port *gitpod.WorkspaceInstancePort) (res *gitpod.WorkspaceInstancePort, err error)
 	UpdateGitStatus(ctx context.Context, status *gitpod.WorkspaceInstanceRepoStatus) (err error)
+	SendHeartBeat(ctx context.Context) (err error)
 	WorkspaceUpdates(ctx context.Context) (<-chan *gitpod.WorkspaceInstance, error)
 
 	// Metrics
@@ -90,6 +91,7 @@ func NewServerApiService(ctx context.Context, cfg *ServiceConfig, tknsrv api.Tok
 			"function:openPort",
 			"function:trackEvent",
 			"function:getWorkspace",
+			"function:sendHeartBeat",
 		},
 	})
 	if err != nil {
@@ -261,6 +263,28 @@ func (s *Service) UpdateGitStatus(ctx context.Context, status *gitpod.WorkspaceI
 	return
 }
 
+// SendHeartBeat marks the workspace instance as active.
+func (s *Service) SendHeartBeat(ctx context.Context) (err error) {
+	if s == nil {
+		return errNotConnected
+	}
+	startTime := time.Now()
+	usePublicApi := s.usePublicAPI(ctx)
+	defer func() {
+		s.apiMetrics.ProcessMetrics(usePublicApi, "SendHeartBeat", err, startTime)
+	}()
+	if !usePublicApi {
+		return s.gitpodService.SendHeartBeat(ctx, &gitpod.SendHeartBeatOptions{
+			InstanceID: s.cfg.InstanceID,
+		})
+	}
+	service := v1.NewIDEClientServiceClient(s.publicAPIConn)
+	_, err = service.SendHeartbeat(ctx, &v1.SendHeartbeatRequest{
+		WorkspaceId: s.cfg.WorkspaceID,
+	})
+	return
+}
+
 func (s *Service) OpenPort(ctx context.Context, port *gitpod.WorkspaceInstancePort) (res *gitpod.WorkspaceInstancePort, err error) {
 	if s == nil {
 		return nil, errNotConnected
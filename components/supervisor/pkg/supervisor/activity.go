@@ -0,0 +1,132 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/ports"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/serverapi"
+)
+
+// activitySignal is a source of user activity in the workspace.
+type activitySignal string
+
+const (
+	// activityIDE are requests of IDE clients for the IDE status, e.g. a browser tab with the IDE open
+	activityIDE activitySignal = "ide"
+	// activityTerminal is input written to a terminal
+	activityTerminal activitySignal = "terminal"
+	// activityOutput is output of terminals and the processes of tasks, e.g. a long running build. Processes
+	// which print output forever, e.g. dev servers or watch loops, must not keep the workspace running on their
+	// own, hence output only counts together with another signal by default.
+	activityOutput activitySignal = "output"
+	// activityPorts are connections from outside the workspace to exposed ports
+	activityPorts activitySignal = "ports"
+)
+
+const (
+	// activityWindow is how long a signal counts towards the activity of the workspace.
+	activityWindow = 5 * time.Minute
+	// activityInterval is how often the workspace is checked for activity.
+	activityInterval = 1 * time.Minute
+)
+
+var defaultActivityWeights = map[activitySignal]float64{
+	activityIDE:      1,
+	activityTerminal: 1,
+	activityOutput:   0.5,
+	activityPorts:    1,
+}
+
+// activityTracker combines activity signals and keeps the workspace from timing out while it is active.
+type activityTracker struct {
+	weights map[activitySignal]float64
+
+	mu       sync.Mutex
+	lastSeen map[activitySignal]time.Time
+}
+
+func newActivityTracker(weights map[activitySignal]float64) *activityTracker {
+	return &activityTracker{
+		weights:  weights,
+		lastSeen: make(map[activitySignal]time.Time),
+	}
+}
+
+// Mark records activity of a signal.
+func (t *activityTracker) Mark(signal activitySignal) {
+	t.mu.Lock()
+	t.lastSeen[signal] = time.Now()
+	t.mu.Unlock()
+}
+
+// Score sums the weights of all signals seen within the activity window.
+func (t *activityTracker) Score(now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var score float64
+	for signal, lastSeen := range t.lastSeen {
+		if now.Sub(lastSeen) <= activityWindow {
+			score += t.weights[signal]
+		}
+	}
+	return score
+}
+
+// IsActive returns true if the score of the signals seen within the activity window is at least 1.
+func (t *activityTracker) IsActive(now time.Time) bool {
+	return t.Score(now) >= 1
+}
+
+// Run regularly samples the traffic on exposed ports and sends a heartbeat while the workspace is active.
+func (t *activityTracker) Run(ctx context.Context, gitpodService serverapi.APIInterface, portsManager *ports.Manager) {
+	ticker := time.NewTicker(activityInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if t.weights[activityPorts] > 0 && hasConnectedExposedPort(portsManager) {
+			t.Mark(activityPorts)
+		}
+
+		now := time.Now()
+		if !t.IsActive(now) {
+			continue
+		}
+		err := gitpodService.SendHeartBeat(ctx)
+		if err != nil {
+			log.WithError(err).Warn("cannot send heartbeat for active workspace")
+			continue
+		}
+		log.WithField("score", t.Score(now)).Debug("sent heartbeat for active workspace")
+	}
+}
+
+func hasConnectedExposedPort(portsManager *ports.Manager) bool {
+	connected, err := ports.ConnectedPorts()
+	if err != nil {
+		log.WithError(err).Debug("cannot read connected ports")
+		return false
+	}
+	for _, port := range portsManager.Status() {
+		if port.Exposed == nil {
+			continue
+		}
+		if _, ok := connected[port.LocalPort]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestActivityTracker(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		Desc        string
+		Weights     string
+		LastSeen    map[activitySignal]time.Time
+		Expectation bool
+	}{
+		{
+			Desc:        "no activity",
+			Expectation: false,
+		},
+		{
+			Desc:        "recent terminal input",
+			LastSeen:    map[activitySignal]time.Time{activityTerminal: now.Add(-time.Minute)},
+			Expectation: true,
+		},
+		{
+			Desc:        "output only",
+			LastSeen:    map[activitySignal]time.Time{activityOutput: now},
+			Expectation: false,
+		},
+		{
+			Desc: "output of a long running build the user watches",
+			LastSeen: map[activitySignal]time.Time{
+				activityOutput: now.Add(-2 * time.Minute),
+				activityIDE:    now.Add(-4 * time.Minute),
+			},
+			Expectation: true,
+		},
+		{
+			Desc:        "stale IDE traffic",
+			LastSeen:    map[activitySignal]time.Time{activityIDE: now.Add(-activityWindow - time.Second)},
+			Expectation: false,
+		},
+		{
+			Desc:        "port traffic below threshold",
+			Weights:     `{"ports":0.5}`,
+			LastSeen:    map[activitySignal]time.Time{activityPorts: now},
+			Expectation: false,
+		},
+		{
+			Desc:    "combined signals reach threshold",
+			Weights: `{"ports":0.5,"terminal":0.5}`,
+			LastSeen: map[activitySignal]time.Time{
+				activityPorts:    now,
+				activityTerminal: now.Add(-time.Minute),
+			},
+			Expectation: true,
+		},
+		{
+			Desc:        "disabled signal",
+			Weights:     `{"ide":0}`,
+			LastSeen:    map[activitySignal]time.Time{activityIDE: now},
+			Expectation: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			weights, err := WorkspaceConfig{ActivityWeights: test.Weights}.getActivityWeights()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tracker := newActivityTracker(weights)
+			for signal, lastSeen := range test.LastSeen {
+				tracker.lastSeen[signal] = lastSeen
+			}
+
+			if act := tracker.IsActive(now); act != test.Expectation {
+				t.Errorf("unexpected activity: want %v, got %v (score %v)", test.Expectation, act, tracker.Score(now))
+			}
+		})
+	}
+}
+
+func TestGetActivityWeights(t *testing.T) {
+	tests := []struct {
+		Desc        string
+		Weights     string
+		Expectation map[activitySignal]float64
+		Error       bool
+	}{
+		{
+			Desc:        "defaults",
+			Expectation: defaultActivityWeights,
+		},
+		{
+			Desc:        "override",
+			Weights:     `{"ports":0.25}`,
+			Expectation: map[activitySignal]float64{activityIDE: 1, activityTerminal: 1, activityOutput: 0.5, activityPorts: 0.25},
+		},
+		{
+			Desc:    "unknown signal",
+			Weights: `{"mouse":1}`,
+			Error:   true,
+		},
+		{
+			Desc:    "negative weight",
+			Weights: `{"ide":-1}`,
+			Error:   true,
+		},
+		{
+			Desc:    "invalid JSON",
+			Weights: `ide=1`,
+			Error:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			act, err := WorkspaceConfig{ActivityWeights: test.Weights}.getActivityWeights()
+			if test.Error {
+				if err == nil {
+					t.Errorf("expected an error, got %v", act)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected weights (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -11,9 +11,11 @@ import (
 	"io/ioutil"
 	"math"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	env "github.com/Netflix/go-env"
@@ -165,6 +167,12 @@ type IDEConfig struct {
 
 			// Path is the path to make requests to. Defaults to "/".
 			Path string `json:"path"`
+
+			// StatusCode is the status code the IDE responds with once it is ready. Defaults to 200.
+			StatusCode int `json:"statusCode"`
+
+			// TimeoutSeconds is how long a single request may take. Defaults to 1 second.
+			TimeoutSeconds int `json:"timeoutSeconds"`
 		} `json:"http"`
 	} `json:"readinessProbe"`
 
@@ -202,6 +210,13 @@ func (c IDEConfig) Validate() error {
 		return xerrors.Errorf("logRateLimit must be >= 0")
 	}
 
+	if code := c.ReadinessProbe.HTTPProbe.StatusCode; code != 0 && (code < 100 || code > 599) {
+		return xerrors.Errorf("readinessProbe.http.statusCode must be a valid HTTP status code")
+	}
+	if c.ReadinessProbe.HTTPProbe.TimeoutSeconds < 0 {
+		return xerrors.Errorf("readinessProbe.http.timeoutSeconds must be >= 0")
+	}
+
 	return nil
 }
 
@@ -309,12 +324,43 @@ type WorkspaceConfig struct {
 	// the in-workspace epxerience.
 	DotfileRepo string `env:"SUPERVISOR_DOTFILE_REPO"`
 
+	// DotfileInstallTimeoutSeconds is the max number of seconds cloning and installing the dotfiles may take.
+	DotfileInstallTimeoutSeconds *int `env:"SUPERVISOR_DOTFILE_INSTALL_TIMEOUT_SECONDS"`
+
+	// SSHAgentForwarding enables forwarding the SSH agent of the user's local machine into SSH sessions.
+	// It's a user setting, hence disabled unless the user opted in.
+	SSHAgentForwarding bool `env:"SUPERVISOR_SSH_AGENT_FORWARDING"`
+
 	// EnvvarOTS points to a URL from which environment variables for child processes can be downloaded from.
 	// This provides a safer means to transport environment variables compared to shipping them on the Kubernetes pod.
 	//
 	// The format of the content downloaded from this URL is expected to be JSON in the form of [{"name":"name", "value":"value"}]
 	EnvvarOTS string `env:"SUPERVISOR_ENVVAR_OTS"`
 
+	// FileSecrets is a comma-separated list of environment variables which are passed to child processes as files
+	// rather than values. Each of these variables is replaced by <NAME>_FILE which points to the file containing the value.
+	FileSecrets string `env:"SUPERVISOR_FILE_SECRETS"`
+
+	// GitHosts are the SCM hosts configured in the installation. If set, git tokens are only requested for these hosts.
+	//
+	// The format is expected to be JSON in the form of
+	// [{"host":"github.com", "scopes":["public_repo"], "privateRepoScopes":["repo"]}] where scopes are the scopes
+	// a token must have to be used for git operations on public repositories of that host, and privateRepoScopes
+	// the ones it must have for private repositories.
+	GitHosts string `env:"SUPERVISOR_GIT_HOSTS"`
+
+	// ActivityWeights configures how much user activity signals count before the workspace is considered idle.
+	// While the sum of the weights of all signals seen within the last minutes is at least 1, supervisor keeps
+	// the workspace from timing out.
+	//
+	// The format is expected to be JSON in the form of {"ide":1, "terminal":1, "output":0.5, "ports":0.5}. Signals which are
+	// not configured keep their default weight, which is 0.5 for output and 1 otherwise. A weight of 0 disables a signal.
+	ActivityWeights string `env:"SUPERVISOR_ACTIVITY_WEIGHTS"`
+
+	// TrustedProxies is a comma-separated list of the addresses or networks of the proxies in front of supervisor,
+	// e.g. ws-proxy. Only requests from these proxies may tell the address of the client in X-Forwarded-For.
+	TrustedProxies string `env:"SUPERVISOR_TRUSTED_PROXIES"`
+
 	// TerminationGracePeriodSeconds is the max number of seconds the workspace can take to shut down all its processes after SIGTERM was sent.
 	TerminationGracePeriodSeconds *int `env:"GITPOD_TERMINATION_GRACE_PERIOD_SECONDS"`
 
@@ -339,16 +385,28 @@ type WorkspaceGitpodToken struct {
 	TokenOTS string `json:"tokenOTS"`
 }
 
+// GitHost is an SCM host configured in the installation.
+type GitHost struct {
+	Host string `json:"host"`
+	// Scopes are the scopes a token needs for git operations on public repositories.
+	Scopes []string `json:"scopes,omitempty"`
+	// PrivateRepoScopes are the scopes a token needs for git operations on private repositories,
+	// which cover public repositories as well.
+	PrivateRepoScopes []string `json:"privateRepoScopes,omitempty"`
+}
+
 // TaskConfig defines gitpod task shape.
 type TaskConfig struct {
-	Name     *string                 `json:"name,omitempty"`
-	Before   *string                 `json:"before,omitempty"`
-	Init     *string                 `json:"init,omitempty"`
-	Prebuild *string                 `json:"prebuild,omitempty"`
-	Command  *string                 `json:"command,omitempty"`
-	Env      *map[string]interface{} `json:"env,omitempty"`
-	OpenIn   *string                 `json:"openIn,omitempty"`
-	OpenMode *string                 `json:"openMode,omitempty"`
+	Name          *string                 `json:"name,omitempty"`
+	Before        *string                 `json:"before,omitempty"`
+	Init          *string                 `json:"init,omitempty"`
+	Prebuild      *string                 `json:"prebuild,omitempty"`
+	Command       *string                 `json:"command,omitempty"`
+	Env           *map[string]interface{} `json:"env,omitempty"`
+	OpenIn        *string                 `json:"openIn,omitempty"`
+	OpenMode      *string                 `json:"openMode,omitempty"`
+	OnStop        *string                 `json:"onStop,omitempty"`
+	OnStopTimeout *string                 `json:"onStopTimeout,omitempty"`
 }
 
 // Validate validates this configuration.
@@ -369,6 +427,14 @@ func (c WorkspaceConfig) Validate() error {
 		return err
 	}
 
+	if _, err := c.getGitHosts(); err != nil {
+		return err
+	}
+
+	if _, err := c.getActivityWeights(); err != nil {
+		return err
+	}
+
 	if _, _, err := c.GitpodAPIEndpoint(); err != nil {
 		return err
 	}
@@ -539,6 +605,97 @@ func (c WorkspaceConfig) GetTerminationGracePeriod() time.Duration {
 	return time.Duration(*c.TerminationGracePeriodSeconds) * time.Second
 }
 
+// getFileSecrets returns the names of the environment variables which are passed to child processes as files.
+func (c WorkspaceConfig) getFileSecrets() []string {
+	var res []string
+	for _, name := range strings.Split(c.FileSecrets, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+// getGitHosts parses the SCM hosts configured in the installation. It returns nil if no hosts are configured.
+func (c WorkspaceConfig) getGitHosts() (map[string]GitHost, error) {
+	if c.GitHosts == "" {
+		return nil, nil
+	}
+
+	var hosts []GitHost
+	err := json.Unmarshal([]byte(c.GitHosts), &hosts)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse git hosts: %w", err)
+	}
+	res := make(map[string]GitHost, len(hosts))
+	for _, host := range hosts {
+		if host.Host == "" {
+			return nil, xerrors.Errorf("git host is required")
+		}
+		res[host.Host] = host
+	}
+	return res, nil
+}
+
+// getTrustedProxies parses the addresses and networks of the trusted proxies. Single addresses are returned as
+// prefixes which contain only that address.
+func (c WorkspaceConfig) getTrustedProxies() ([]netip.Prefix, error) {
+	var res []netip.Prefix
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			res = append(res, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid trusted proxy %s: %w", proxy, err)
+		}
+		res = append(res, prefix.Masked())
+	}
+	return res, nil
+}
+
+// getActivityWeights parses the weights of the activity signals.
+func (c WorkspaceConfig) getActivityWeights() (map[activitySignal]float64, error) {
+	res := make(map[activitySignal]float64, len(defaultActivityWeights))
+	for signal, weight := range defaultActivityWeights {
+		res[signal] = weight
+	}
+	if c.ActivityWeights == "" {
+		return res, nil
+	}
+
+	var weights map[activitySignal]float64
+	err := json.Unmarshal([]byte(c.ActivityWeights), &weights)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse activity weights: %w", err)
+	}
+	for signal, weight := range weights {
+		if _, ok := defaultActivityWeights[signal]; !ok {
+			return nil, xerrors.Errorf("unknown activity signal: %s", signal)
+		}
+		if weight < 0 {
+			return nil, xerrors.Errorf("activity weight of %s must not be negative", signal)
+		}
+		res[signal] = weight
+	}
+	return res, nil
+}
+
+func (c WorkspaceConfig) GetDotfileInstallTimeout() time.Duration {
+	defaultTimeout := 120 * time.Second
+	if c.DotfileInstallTimeoutSeconds == nil || *c.DotfileInstallTimeoutSeconds <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(*c.DotfileInstallTimeoutSeconds) * time.Second
+}
+
 // GetConfig loads the supervisor configuration.
 func GetConfig() (*Config, error) {
 	static, err := loadStaticConfigFromFile()
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+// startupDiagnostics records what supervisor waits on during the workspace startup and for how long.
+type startupDiagnostics struct {
+	started time.Time
+
+	mu     sync.RWMutex
+	phases []*startupPhase
+}
+
+// startupPhase is something supervisor waits on during the workspace startup.
+type startupPhase struct {
+	d *startupDiagnostics
+
+	name     string
+	started  time.Time
+	finished time.Time
+	failure  string
+}
+
+func newStartupDiagnostics() *startupDiagnostics {
+	return &startupDiagnostics{started: time.Now()}
+}
+
+// Begin records the start of a phase.
+func (d *startupDiagnostics) Begin(name string) *startupPhase {
+	phase := &startupPhase{d: d, name: name, started: time.Now()}
+
+	d.mu.Lock()
+	d.phases = append(d.phases, phase)
+	d.mu.Unlock()
+
+	return phase
+}
+
+// Watch records a phase which lasts until done is closed.
+func (d *startupDiagnostics) Watch(name string, done <-chan struct{}) {
+	phase := d.Begin(name)
+	go func() {
+		<-done
+		phase.Done()
+	}()
+}
+
+// SetFailure records why the phase does not finish as expected. An empty failure resets it.
+// It has no effect once the phase is done.
+func (p *startupPhase) SetFailure(failure string) {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	if !p.finished.IsZero() {
+		return
+	}
+	p.failure = failure
+}
+
+// Done marks the phase as finished. Subsequent calls have no effect.
+func (p *startupPhase) Done() {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	if !p.finished.IsZero() {
+		return
+	}
+	p.finished = time.Now()
+}
+
+// Report produces the API representation of all phases recorded so far.
+func (d *startupDiagnostics) Report(now time.Time) *api.StartupDiagnosticsResponse {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	res := &api.StartupDiagnosticsResponse{Phases: make([]*api.StartupPhase, 0, len(d.phases))}
+	for _, p := range d.phases {
+		finished := p.finished
+		if finished.IsZero() {
+			finished = now
+		}
+		res.Phases = append(res.Phases, &api.StartupPhase{
+			Name:               p.name,
+			Done:               !p.finished.IsZero(),
+			StartOffsetSeconds: p.started.Sub(d.started).Seconds(),
+			DurationSeconds:    finished.Sub(p.started).Seconds(),
+			Failure:            p.failure,
+		})
+	}
+	return res
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+func TestStartupDiagnostics(t *testing.T) {
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := &startupDiagnostics{started: started}
+
+	content := d.Begin("content")
+	content.started = started
+	content.Done()
+	content.finished = started.Add(2 * time.Second)
+
+	ide := d.Begin("web-ide")
+	ide.started = started.Add(2 * time.Second)
+	ide.SetFailure("connection refused")
+
+	dotfiles := d.Begin("dotfiles")
+	dotfiles.started = started.Add(time.Second)
+	dotfiles.SetFailure("install script failed")
+	dotfiles.Done()
+	dotfiles.finished = started.Add(3 * time.Second)
+	dotfiles.SetFailure("")
+	dotfiles.Done()
+
+	act := d.Report(started.Add(5 * time.Second))
+	exp := &api.StartupDiagnosticsResponse{
+		Phases: []*api.StartupPhase{
+			{Name: "content", Done: true, StartOffsetSeconds: 0, DurationSeconds: 2},
+			{Name: "web-ide", Done: false, StartOffsetSeconds: 2, DurationSeconds: 3, Failure: "connection refused"},
+			{Name: "dotfiles", Done: true, StartOffsetSeconds: 1, DurationSeconds: 2, Failure: "install script failed"},
+		},
+	}
+	if diff := cmp.Diff(exp, act, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	ide.SetFailure("")
+	ide.Done()
+	act = d.Report(started.Add(time.Hour))
+	if phase := act.Phases[1]; !phase.Done || phase.Failure != "" {
+		t.Errorf("unexpected web-ide phase after it is done: %v", phase)
+	}
+}
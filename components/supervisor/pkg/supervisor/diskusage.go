@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+	daemonapi "github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+// diskUsageRetryInterval is how long we wait before we watch the disk usage again after the stream broke
+const diskUsageRetryInterval = 30 * time.Second
+
+// watchDiskUsage notifies the user when their workspace content crosses one of the soft limits of its storage quota
+func watchDiskUsage(ctx context.Context, notifications *NotificationService) {
+	const socketFN = "/.supervisor/info.sock"
+
+	if _, err := os.Stat(socketFN); os.IsNotExist(err) {
+		log.Debug("workspace info service is not available - not watching disk usage")
+		return
+	}
+
+	var notified float64
+	for {
+		err := watchDiskUsageOnce(ctx, socketFN, func(usage *daemonapi.DiskUsage) {
+			if usage.SoftLimit <= notified {
+				notified = usage.SoftLimit
+				return
+			}
+			notified = usage.SoftLimit
+
+			_, err := notifications.Notify(ctx, &api.NotifyRequest{
+				Level:   api.NotifyRequest_WARNING,
+				Message: diskUsageMessage(usage),
+			})
+			if err != nil {
+				log.WithError(err).Warn("cannot notify about disk usage")
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if code := status.Code(err); code == codes.Unavailable || code == codes.Unimplemented {
+			// ws-daemon doesn't measure the disk usage of this workspace
+			log.WithError(err).Debug("disk usage is not measured - not watching disk usage")
+			return
+		}
+		if err != nil {
+			log.WithError(err).Warn("cannot watch disk usage")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(diskUsageRetryInterval):
+		}
+	}
+}
+
+func watchDiskUsageOnce(ctx context.Context, socketFN string, onUpdate func(usage *daemonapi.DiskUsage)) error {
+	conn, err := grpc.DialContext(ctx, "unix://"+socketFN, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := daemonapi.NewWorkspaceInfoServiceClient(conn)
+	usage, err := client.WatchDiskUsage(ctx, &daemonapi.WatchDiskUsageRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		update, err := usage.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		onUpdate(update)
+	}
+}
+
+func diskUsageMessage(usage *daemonapi.DiskUsage) string {
+	const gib = 1024 * 1024 * 1024
+	return fmt.Sprintf("Your workspace uses %.0f%% of its storage (%.1f GiB of %.1f GiB). Delete files you don't need to avoid running out of disk space.",
+		usage.SoftLimit*100, float64(usage.Used)/gib, float64(usage.Limit)/gib)
+}
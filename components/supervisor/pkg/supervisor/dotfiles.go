@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+const (
+	dotfilesPath    = "/home/gitpod/.dotfiles"
+	dotfilesLogPath = "/home/gitpod/.dotfiles.log"
+
+	// dotfilesLogTailSize limits how much of the installation log is returned by the status API
+	dotfilesLogTailSize = 64 * 1024
+)
+
+// dotfilesState tracks the installation of the user's dotfiles.
+type dotfilesState struct {
+	repo    string
+	logPath string
+
+	mu      sync.RWMutex
+	state   api.DotfilesState
+	failure string
+	done    chan struct{}
+}
+
+// newDotfilesState creates a new dotfiles state. If no repo is configured, the state is final right away.
+func newDotfilesState(repo string) *dotfilesState {
+	res := &dotfilesState{
+		repo:    repo,
+		logPath: dotfilesLogPath,
+		state:   api.DotfilesState_not_configured,
+		done:    make(chan struct{}),
+	}
+	if repo == "" {
+		close(res.done)
+	}
+	return res
+}
+
+// MarkInstalling marks the beginning of the installation.
+func (s *dotfilesState) MarkInstalling() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDone() {
+		return
+	}
+	s.state = api.DotfilesState_installing
+}
+
+// MarkInstalled marks the installation as finished successfully.
+func (s *dotfilesState) MarkInstalled() {
+	s.finish(api.DotfilesState_installed, "")
+}
+
+// MarkFailed marks the installation as failed.
+func (s *dotfilesState) MarkFailed(err error) {
+	s.finish(api.DotfilesState_installation_failed, err.Error())
+}
+
+func (s *dotfilesState) finish(state api.DotfilesState, failure string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDone() {
+		return
+	}
+	s.state = state
+	s.failure = failure
+	close(s.done)
+}
+
+func (s *dotfilesState) isDone() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done returns a channel that is closed once the installation has finished, successfully or not.
+func (s *dotfilesState) Done() <-chan struct{} {
+	return s.done
+}
+
+// Status returns the current installation status including the tail of the installation log.
+func (s *dotfilesState) Status() *api.DotfilesStatusResponse {
+	s.mu.RLock()
+	res := &api.DotfilesStatusResponse{
+		State:      s.state,
+		Repository: s.repo,
+		Failure:    s.failure,
+	}
+	s.mu.RUnlock()
+
+	if res.State != api.DotfilesState_not_configured {
+		res.Log = readLogTail(s.logPath, dotfilesLogTailSize)
+	}
+	return res
+}
+
+func readLogTail(fn string, size int64) string {
+	f, err := os.Open(fn)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return ""
+	}
+	if offset := stat.Size() - size; offset > 0 {
+		_, err = f.Seek(offset, io.SeekStart)
+		if err != nil {
+			return ""
+		}
+	}
+	content, err := io.ReadAll(io.LimitReader(f, size))
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+func TestDotfilesState(t *testing.T) {
+	const lastLine = "# executing installation script candidate install.sh\n"
+	logPath := filepath.Join(t.TempDir(), "dotfiles.log")
+	err := os.WriteFile(logPath, []byte(strings.Repeat("x", dotfilesLogTailSize)+lastLine), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logTail := strings.Repeat("x", dotfilesLogTailSize-len(lastLine)) + lastLine
+
+	tests := []struct {
+		Desc        string
+		Repo        string
+		Transitions func(s *dotfilesState)
+		Expectation *api.DotfilesStatusResponse
+		Done        bool
+	}{
+		{
+			Desc:        "not configured",
+			Expectation: &api.DotfilesStatusResponse{State: api.DotfilesState_not_configured},
+			Done:        true,
+		},
+		{
+			Desc: "installing",
+			Repo: "https://github.com/gitpod-io/dotfiles",
+			Transitions: func(s *dotfilesState) {
+				s.MarkInstalling()
+			},
+			Expectation: &api.DotfilesStatusResponse{
+				State:      api.DotfilesState_installing,
+				Repository: "https://github.com/gitpod-io/dotfiles",
+				Log:        logTail,
+			},
+		},
+		{
+			Desc: "installed",
+			Repo: "https://github.com/gitpod-io/dotfiles",
+			Transitions: func(s *dotfilesState) {
+				s.MarkInstalling()
+				s.MarkInstalled()
+			},
+			Expectation: &api.DotfilesStatusResponse{
+				State:      api.DotfilesState_installed,
+				Repository: "https://github.com/gitpod-io/dotfiles",
+				Log:        logTail,
+			},
+			Done: true,
+		},
+		{
+			Desc: "failed",
+			Repo: "https://github.com/gitpod-io/dotfiles",
+			Transitions: func(s *dotfilesState) {
+				s.MarkInstalling()
+				s.MarkFailed(errors.New("installation process install.sh took longer than 2m0s"))
+				s.MarkInstalled()
+			},
+			Expectation: &api.DotfilesStatusResponse{
+				State:      api.DotfilesState_installation_failed,
+				Repository: "https://github.com/gitpod-io/dotfiles",
+				Log:        logTail,
+				Failure:    "installation process install.sh took longer than 2m0s",
+			},
+			Done: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			s := newDotfilesState(test.Repo)
+			s.logPath = logPath
+			if test.Transitions != nil {
+				test.Transitions(s)
+			}
+
+			if diff := cmp.Diff(test.Expectation, s.Status(), protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected status (-want +got):\n%s", diff)
+			}
+
+			var done bool
+			select {
+			case <-s.Done():
+				done = true
+			default:
+			}
+			if done != test.Done {
+				t.Errorf("unexpected done state: expected %v, got %v", test.Done, done)
+			}
+		})
+	}
+}
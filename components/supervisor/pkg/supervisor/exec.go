@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/serverapi"
+)
+
+// execWaitDelay is how long Exec waits for the output of a command to be closed once it has terminated,
+// e.g. because it started background processes which inherited its stdout.
+const execWaitDelay = 5 * time.Second
+
+// Exec runs a command in the workspace and streams its output until it terminates.
+func (ss *ControlService) Exec(req *api.ExecRequest, srv api.ControlService_ExecServer) error {
+	err := ss.authenticateExec(srv.Context())
+	if err != nil {
+		return err
+	}
+
+	if req.Command == "" {
+		return status.Error(codes.InvalidArgument, "command is required")
+	}
+	for _, e := range req.Env {
+		if name, _, ok := strings.Cut(e, "="); !ok || name == "" {
+			return status.Errorf(codes.InvalidArgument, "invalid environment variable %q, expected NAME=value", e)
+		}
+	}
+	workdir := req.Workdir
+	if workdir == "" {
+		workdir = ss.execWorkdir
+	}
+	if workdir != "" {
+		if stat, err := os.Stat(workdir); err != nil || !stat.IsDir() {
+			return status.Errorf(codes.InvalidArgument, "workdir %s is not a directory", workdir)
+		}
+	}
+
+	ctx := srv.Context()
+	if req.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	var env []string
+	if !req.CleanEnv {
+		env = append(env, ss.execEnv...)
+	}
+	env = append(env, req.Env...)
+
+	cmd := exec.CommandContext(ctx, req.Command, req.Args...)
+	cmd.Dir = workdir
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		// the command runs in its own process group, so that it can be killed with all its children
+		Setpgid:    true,
+		Credential: ss.execCreds,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = execWaitDelay
+
+	var mu sync.Mutex
+	cmd.Stdout = &execOutputWriter{mu: &mu, srv: srv}
+	cmd.Stderr = &execOutputWriter{mu: &mu, srv: srv, stderr: true}
+
+	err = cmd.Run()
+	if cmd.ProcessState == nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return status.Errorf(codes.NotFound, "command %s not found", req.Command)
+		}
+		return status.Errorf(codes.FailedPrecondition, "cannot start command: %v", err)
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !errors.Is(err, exec.ErrWaitDelay) {
+		log.WithError(err).WithField("command", req.Command).Debug("cannot stream command output")
+		return status.Errorf(codes.Aborted, "cannot stream command output: %v", err)
+	}
+
+	exitStatus := getExecExitStatus(cmd.ProcessState)
+	exitStatus.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded) && srv.Context().Err() == nil
+
+	mu.Lock()
+	defer mu.Unlock()
+	return srv.Send(&api.ExecResponse{Output: &api.ExecResponse_ExitStatus{ExitStatus: exitStatus}})
+}
+
+// authenticateExec requires the request to carry one of the Gitpod API tokens of the workspace.
+func (ss *ControlService) authenticateExec(ctx context.Context) error {
+	if ss.tokenService != nil {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, auth := range md.Get("authorization") {
+			tkn, ok := strings.CutPrefix(auth, "Bearer ")
+			if ok && ss.tokenService.hasToken(serverapi.KindGitpod, tkn) {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "a Gitpod API token of the workspace is required")
+}
+
+func getExecExitStatus(state *os.ProcessState) *api.ExecExitStatus {
+	res := &api.ExecExitStatus{ExitCode: int32(state.ExitCode())}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		res.Signal = unix.SignalName(ws.Signal())
+		if res.Signal == "" {
+			res.Signal = ws.Signal().String()
+		}
+	}
+	return res
+}
+
+// execOutputWriter streams the output of a command to an Exec client.
+type execOutputWriter struct {
+	mu     *sync.Mutex
+	srv    api.ControlService_ExecServer
+	stderr bool
+}
+
+func (w *execOutputWriter) Write(p []byte) (n int, err error) {
+	resp := &api.ExecResponse{Output: &api.ExecResponse_Stdout{Stdout: p}}
+	if w.stderr {
+		resp = &api.ExecResponse{Output: &api.ExecResponse_Stderr{Stderr: p}}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err = w.srv.Send(resp)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
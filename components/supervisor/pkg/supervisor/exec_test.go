@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/serverapi"
+)
+
+type testExecServer struct {
+	grpc.ServerStream
+
+	ctx       context.Context
+	responses []*api.ExecResponse
+}
+
+func (s *testExecServer) Context() context.Context { return s.ctx }
+
+func (s *testExecServer) Send(resp *api.ExecResponse) error {
+	// the output buffers are reused once Send returns
+	s.responses = append(s.responses, proto.Clone(resp).(*api.ExecResponse))
+	return nil
+}
+
+func TestExec(t *testing.T) {
+	const token = "workspace-token"
+	tokenService := NewInMemoryTokenService()
+	_, err := tokenService.SetToken(context.Background(), &api.SetTokenRequest{
+		Kind:  serverapi.KindGitpod,
+		Host:  "gitpod.io",
+		Token: token,
+		Reuse: api.TokenReuse_REUSE_WHEN_POSSIBLE,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Expectation struct {
+		Code       codes.Code
+		Stdout     string
+		Stderr     string
+		ExitStatus *api.ExecExitStatus
+	}
+	tests := []struct {
+		Desc        string
+		Token       string
+		Request     *api.ExecRequest
+		Expectation Expectation
+	}{
+		{
+			Desc:        "unauthenticated",
+			Token:       "wrong",
+			Request:     &api.ExecRequest{Command: "true"},
+			Expectation: Expectation{Code: codes.Unauthenticated},
+		},
+		{
+			Desc:        "missing command",
+			Token:       token,
+			Request:     &api.ExecRequest{},
+			Expectation: Expectation{Code: codes.InvalidArgument},
+		},
+		{
+			Desc:        "invalid env",
+			Token:       token,
+			Request:     &api.ExecRequest{Command: "true", Env: []string{"FOO"}},
+			Expectation: Expectation{Code: codes.InvalidArgument},
+		},
+		{
+			Desc:        "command not found",
+			Token:       token,
+			Request:     &api.ExecRequest{Command: "does-not-exist"},
+			Expectation: Expectation{Code: codes.NotFound},
+		},
+		{
+			Desc:  "output and exit code",
+			Token: token,
+			Request: &api.ExecRequest{
+				Command: "sh",
+				Args:    []string{"-c", `echo "$FOO"; echo "$BAR" >&2; exit 3`},
+				Env:     []string{"FOO=foo", "BAR=bar"},
+			},
+			Expectation: Expectation{
+				Stdout:     "foo\n",
+				Stderr:     "bar\n",
+				ExitStatus: &api.ExecExitStatus{ExitCode: 3},
+			},
+		},
+		{
+			Desc:  "clean env",
+			Token: token,
+			Request: &api.ExecRequest{
+				Command:  "/bin/sh",
+				Args:     []string{"-c", `echo "$WORKSPACE_VAR"`},
+				CleanEnv: true,
+			},
+			Expectation: Expectation{
+				Stdout:     "\n",
+				ExitStatus: &api.ExecExitStatus{},
+			},
+		},
+		{
+			Desc:  "timeout",
+			Token: token,
+			Request: &api.ExecRequest{
+				Command:        "sleep",
+				Args:           []string{"10"},
+				TimeoutSeconds: 1,
+			},
+			Expectation: Expectation{
+				ExitStatus: &api.ExecExitStatus{ExitCode: -1, Signal: "SIGKILL", TimedOut: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			service := &ControlService{
+				tokenService: tokenService,
+				execEnv:      []string{"PATH=/usr/bin:/bin", "WORKSPACE_VAR=value"},
+			}
+			srv := &testExecServer{
+				ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+test.Token)),
+			}
+
+			err := service.Exec(test.Request, srv)
+
+			var (
+				act    = Expectation{Code: status.Code(err)}
+				stdout bytes.Buffer
+				stderr bytes.Buffer
+			)
+			for _, resp := range srv.responses {
+				switch output := resp.Output.(type) {
+				case *api.ExecResponse_Stdout:
+					stdout.Write(output.Stdout)
+				case *api.ExecResponse_Stderr:
+					stderr.Write(output.Stderr)
+				case *api.ExecResponse_ExitStatus:
+					act.ExitStatus = output.ExitStatus
+				}
+			}
+			act.Stdout = stdout.String()
+			act.Stderr = stderr.String()
+
+			if diff := cmp.Diff(test.Expectation, act, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
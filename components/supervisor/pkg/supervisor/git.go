@@ -7,6 +7,7 @@ package supervisor
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os/exec"
 	"reflect"
 	"strings"
@@ -23,20 +24,35 @@ import (
 	"golang.org/x/xerrors"
 )
 
+const (
+	// gitTokenReuseDuration limits how long a git token is reused, so that tokens which were
+	// replaced by the user, e.g. to grant more permissions, are picked up soon.
+	gitTokenReuseDuration = 1 * time.Minute
+
+	// gitTokenExpiryMargin makes sure that git tokens aren't reused right before they expire.
+	gitTokenExpiryMargin = 5 * time.Minute
+)
+
 // GitTokenProvider provides tokens for Git hosting services by asking
 // the Gitpod server.
 type GitTokenProvider struct {
 	notificationService *NotificationService
 	workspaceConfig     WorkspaceConfig
 	gitpodAPI           serverapi.APIInterface
+
+	// hosts are the SCM hosts configured in the installation, nil if any host is allowed.
+	hosts map[string]GitHost
 }
 
 // NewGitTokenProvider creates a new instance of gitTokenProvider.
 func NewGitTokenProvider(gitpodAPI serverapi.APIInterface, workspaceConfig WorkspaceConfig, notificationService *NotificationService) *GitTokenProvider {
+	// the workspace config is validated when loading it
+	hosts, _ := workspaceConfig.getGitHosts()
 	return &GitTokenProvider{
 		notificationService: notificationService,
 		workspaceConfig:     workspaceConfig,
 		gitpodAPI:           gitpodAPI,
+		hosts:               hosts,
 	}
 }
 
@@ -45,6 +61,19 @@ func (p *GitTokenProvider) GetToken(ctx context.Context, req *api.GetTokenReques
 	if p.gitpodAPI == nil {
 		return nil, nil
 	}
+	requiredScopes := req.Scope
+	var alternativeScopes []string
+	if p.hosts != nil {
+		host, configured := p.hosts[req.Host]
+		if !configured {
+			// the server has no tokens for hosts which aren't configured in the installation
+			return nil, nil
+		}
+		if len(requiredScopes) == 0 {
+			requiredScopes = host.Scopes
+			alternativeScopes = host.PrivateRepoScopes
+		}
+	}
 	token, err := p.gitpodAPI.GetToken(ctx, &gitpod.GetTokenSearchOptions{
 		Host: req.Host,
 	})
@@ -58,20 +87,24 @@ func (p *GitTokenProvider) GetToken(ctx context.Context, req *api.GetTokenReques
 	for _, scp := range token.Scopes {
 		scopes[scp] = struct{}{}
 	}
-	missing := getMissingScopes(req.Scope, scopes)
+	missing := getMissingScopes(requiredScopes, scopes)
+	if len(missing) > 0 && len(alternativeScopes) > 0 && len(getMissingScopes(alternativeScopes, scopes)) == 0 {
+		// a token for private repositories can be used for public ones as well
+		missing = nil
+	}
 	if len(missing) > 0 {
 		message := fmt.Sprintf("An operation requires additional permissions: %s. Please grant permissions and try again.", strings.Join(missing, ", "))
 		result, err := p.notificationService.Notify(ctx, &api.NotifyRequest{
 			Level:   api.NotifyRequest_INFO,
 			Message: message,
-			Actions: []string{"Open Access Control"},
+			Actions: []string{"Grant Permissions"},
 		})
 		if err != nil {
 			return nil, err
 		}
-		if result.Action == "Open Access Control" {
+		if result.Action == "Grant Permissions" {
 			go func() {
-				_ = p.openAccessControl()
+				_ = p.openAuthorize(req.Host, missing)
 			}()
 		}
 		return nil, xerrors.Errorf("miss required permissions")
@@ -83,23 +116,57 @@ func (p *GitTokenProvider) GetToken(ctx context.Context, req *api.GetTokenReques
 		Scope: scopes,
 		Reuse: api.TokenReuse_REUSE_NEVER,
 	}
+	if expiry := gitTokenReuseExpiry(token.ExpiryDate, time.Now()); expiry != nil {
+		tkn.ExpiryDate = expiry
+		tkn.Reuse = api.TokenReuse_REUSE_WHEN_POSSIBLE
+	}
 	return tkn, nil
 }
 
-func (p *GitTokenProvider) openAccessControl() error {
+// gitTokenReuseExpiry determines until when a git token can be reused. It returns nil if the token should not be reused.
+func gitTokenReuseExpiry(expiryDate string, now time.Time) *time.Time {
+	res := now.Add(gitTokenReuseDuration)
+	if expiryDate != "" {
+		expiry, err := time.Parse(time.RFC3339, expiryDate)
+		if err != nil {
+			log.WithError(err).WithField("expiryDate", expiryDate).Warn("cannot parse git token expiry date")
+			return nil
+		}
+		expiry = expiry.Add(-gitTokenExpiryMargin)
+		if !expiry.After(now) {
+			return nil
+		}
+		if expiry.Before(res) {
+			res = expiry
+		}
+	}
+	return &res
+}
+
+// openAuthorize lets the user grant exactly the missing scopes for host, which are merged into the scopes
+// the user granted already.
+func (p *GitTokenProvider) openAuthorize(host string, scopes []string) error {
 	gpPath, err := exec.LookPath("gp")
 	if err != nil {
 		return err
 	}
-	gpCmd := exec.Command(gpPath, "preview", "--external", p.workspaceConfig.GitpodHost+"/access-control")
+	gpCmd := exec.Command(gpPath, "preview", "--external", authorizeURL(p.workspaceConfig.GitpodHost, host, scopes))
 	runAsGitpodUser(gpCmd)
 	if b, err := gpCmd.CombinedOutput(); err != nil {
-		log.WithField("Stdout", string(b)).WithError(err).Error("failed to exec gp preview to open access control")
+		log.WithField("Stdout", string(b)).WithError(err).Error("failed to exec gp preview to open authorize")
 		return err
 	}
 	return nil
 }
 
+func authorizeURL(gitpodHost, host string, scopes []string) string {
+	query := url.Values{}
+	query.Set("host", host)
+	query.Set("scopes", strings.Join(scopes, ","))
+	query.Set("returnTo", gitpodHost+"/complete-auth")
+	return gitpodHost + "/api/authorize?" + query.Encode()
+}
+
 func getMissingScopes(required []string, provided map[string]struct{}) []string {
 	var missing []string
 	for _, r := range required {
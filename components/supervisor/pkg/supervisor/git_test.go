@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/serverapi"
+)
+
+type fakeGitpodAPI struct {
+	serverapi.APIInterface
+
+	tokens map[string]*gitpod.Token
+}
+
+func (f *fakeGitpodAPI) GetToken(ctx context.Context, query *gitpod.GetTokenSearchOptions) (*gitpod.Token, error) {
+	tkn, ok := f.tokens[query.Host]
+	if !ok {
+		return &gitpod.Token{}, nil
+	}
+	return tkn, nil
+}
+
+func TestGitTokenProvider(t *testing.T) {
+	gitpodAPI := &fakeGitpodAPI{
+		tokens: map[string]*gitpod.Token{
+			"github.com":        {Username: "gh-user", Value: "gh-token", Scopes: []string{"repo", "user:email"}},
+			"gitlab.example.io": {Username: "gl-user", Value: "gl-token", Scopes: []string{"read_repository"}, ExpiryDate: time.Now().Add(2 * time.Hour).Format(time.RFC3339)},
+			"bitbucket.org":     {Username: "bb-user", Value: "bb-token"},
+		},
+	}
+
+	tests := []struct {
+		Desc        string
+		GitHosts    string
+		Req         *api.GetTokenRequest
+		Expectation *Token
+	}{
+		{
+			Desc:        "any host",
+			Req:         &api.GetTokenRequest{Host: "bitbucket.org"},
+			Expectation: &Token{User: "bb-user", Token: "bb-token", Host: "bitbucket.org", Scope: map[string]struct{}{}, Reuse: api.TokenReuse_REUSE_WHEN_POSSIBLE},
+		},
+		{
+			Desc:     "host not configured",
+			GitHosts: `[{"host":"github.com"},{"host":"gitlab.example.io","scopes":["read_repository"]}]`,
+			Req:      &api.GetTokenRequest{Host: "bitbucket.org"},
+		},
+		{
+			Desc:        "configured host",
+			GitHosts:    `[{"host":"github.com"},{"host":"gitlab.example.io","scopes":["read_repository"]}]`,
+			Req:         &api.GetTokenRequest{Host: "gitlab.example.io"},
+			Expectation: &Token{User: "gl-user", Token: "gl-token", Host: "gitlab.example.io", Scope: map[string]struct{}{"read_repository": {}}, Reuse: api.TokenReuse_REUSE_WHEN_POSSIBLE},
+		},
+		{
+			Desc:        "multiple configured hosts",
+			GitHosts:    `[{"host":"github.com"},{"host":"gitlab.example.io","scopes":["read_repository"]}]`,
+			Req:         &api.GetTokenRequest{Host: "github.com"},
+			Expectation: &Token{User: "gh-user", Token: "gh-token", Host: "github.com", Scope: map[string]struct{}{"repo": {}, "user:email": {}}, Reuse: api.TokenReuse_REUSE_WHEN_POSSIBLE},
+		},
+		{
+			Desc:        "private repository scopes",
+			GitHosts:    `[{"host":"github.com","scopes":["public_repo"],"privateRepoScopes":["repo"]}]`,
+			Req:         &api.GetTokenRequest{Host: "github.com"},
+			Expectation: &Token{User: "gh-user", Token: "gh-token", Host: "github.com", Scope: map[string]struct{}{"repo": {}, "user:email": {}}, Reuse: api.TokenReuse_REUSE_WHEN_POSSIBLE},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			p := NewGitTokenProvider(gitpodAPI, WorkspaceConfig{GitHosts: test.GitHosts}, nil)
+			tkn, err := p.GetToken(context.Background(), test.Req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tkn != nil {
+				if tkn.ExpiryDate == nil || !tkn.ExpiryDate.After(time.Now()) {
+					t.Errorf("expected token to be reusable for some time, got expiry date %v", tkn.ExpiryDate)
+				}
+				tkn.ExpiryDate = nil
+			}
+			if diff := cmp.Diff(test.Expectation, tkn); diff != "" {
+				t.Errorf("unexpected token (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGitTokenReuseExpiry(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		res := now.Add(d)
+		return &res
+	}
+
+	tests := []struct {
+		Desc        string
+		ExpiryDate  string
+		Expectation *time.Time
+	}{
+		{Desc: "no expiry", Expectation: at(gitTokenReuseDuration)},
+		{Desc: "long lived", ExpiryDate: "2023-01-01T14:00:00Z", Expectation: at(gitTokenReuseDuration)},
+		{Desc: "expires soon", ExpiryDate: "2023-01-01T12:05:30.000Z", Expectation: at(30 * time.Second)},
+		{Desc: "expires within margin", ExpiryDate: "2023-01-01T12:04:00Z"},
+		{Desc: "invalid expiry", ExpiryDate: "tomorrow"},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			act := gitTokenReuseExpiry(test.ExpiryDate, now)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected expiry (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	act := authorizeURL("https://gitpod.io", "github.com", []string{"public_repo", "read:org"})
+	exp := "https://gitpod.io/api/authorize?host=github.com&returnTo=https%3A%2F%2Fgitpod.io%2Fcomplete-auth&scopes=public_repo%2Cread%3Aorg"
+	if act != exp {
+		t.Errorf("unexpected authorize URL: %s, expected %s", act, exp)
+	}
+}
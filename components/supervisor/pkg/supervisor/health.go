@@ -0,0 +1,224 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+// Reasons why a health check is not ready. They are part of the health endpoint's API, hence must not change.
+const (
+	healthReasonContentInitializing  = "content_initializing"
+	healthReasonIDEStarting          = "ide_starting"
+	healthReasonTasksInitializing    = "tasks_initializing"
+	healthReasonTasksStarting        = "tasks_starting"
+	healthReasonSSHStarting          = "ssh_starting"
+	healthReasonSSHFailed            = "ssh_failed"
+	healthReasonIDEBackendStarting   = "ide_backend_starting"
+	healthReasonIDEBackendRestarting = "ide_backend_restarting"
+)
+
+// healthCheck is the state of a single part of the workspace.
+type healthCheck struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	// Reason is a machine-readable explanation why the check is not ready
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// healthReport summarizes the state of the workspace. It is ready if all its checks are ready.
+type healthReport struct {
+	Ready  bool          `json:"ready"`
+	Checks []healthCheck `json:"checks"`
+}
+
+// sshState tracks whether the SSH server accepts connections.
+type sshState struct {
+	mu      sync.RWMutex
+	ready   bool
+	failure string
+}
+
+// Set updates the SSH server state. A failure means the SSH server is not going to become ready.
+func (s *sshState) Set(ready bool, failure string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ready = ready
+	s.failure = failure
+}
+
+// Get returns the SSH server state.
+func (s *sshState) Get() (ready bool, failure string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready, s.failure
+}
+
+// ideBackendState holds the state of the desktop IDE backend as last reported by the desktop IDE launcher.
+type ideBackendState struct {
+	mu     sync.RWMutex
+	status *api.IDEBackendStatus
+}
+
+// Set replaces the state of the backend.
+func (s *ideBackendState) Set(status *api.IDEBackendStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = proto.Clone(status).(*api.IDEBackendStatus)
+}
+
+// Get returns the state of the backend, nil if it was never reported.
+func (s *ideBackendState) Get() *api.IDEBackendStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.status == nil {
+		return nil
+	}
+	return proto.Clone(s.status).(*api.IDEBackendStatus)
+}
+
+// healthService aggregates the readiness of content, IDEs, tasks and SSH.
+type healthService struct {
+	contentState    ContentState
+	ideReady        *ideReadyState
+	desktopIdeReady *ideReadyState
+	ideBackend      *ideBackendState
+	tasks           *tasksManager
+	ssh             *sshState
+}
+
+// Report checks all parts of the workspace.
+func (s *healthService) Report() *healthReport {
+	var checks []healthCheck
+
+	content := healthCheck{Name: "content", Ready: true}
+	select {
+	case <-s.contentState.ContentReady():
+	default:
+		content.Ready = false
+		content.Reason = healthReasonContentInitializing
+	}
+	checks = append(checks, content)
+
+	for _, ide := range []struct {
+		Name  string
+		State *ideReadyState
+	}{
+		{"ide", s.ideReady},
+		{"desktop-ide", s.desktopIdeReady},
+	} {
+		if ide.State == nil {
+			continue
+		}
+		check := healthCheck{Name: ide.Name, Ready: true}
+		if ready, _ := ide.State.Get(); !ready {
+			check.Ready = false
+			check.Reason = healthReasonIDEStarting
+			if ide.State.ideConfig != nil {
+				check.Message = ide.State.ideConfig.DisplayName + " is not ready yet"
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if backend := s.ideBackendCheck(); backend != nil {
+		checks = append(checks, *backend)
+	}
+
+	if s.tasks != nil {
+		checks = append(checks, s.tasksCheck())
+	}
+
+	if s.ssh != nil {
+		check := healthCheck{Name: "ssh", Ready: true}
+		if ready, failure := s.ssh.Get(); failure != "" {
+			check.Ready = false
+			check.Reason = healthReasonSSHFailed
+			check.Message = failure
+		} else if !ready {
+			check.Ready = false
+			check.Reason = healthReasonSSHStarting
+		}
+		checks = append(checks, check)
+	}
+
+	res := &healthReport{Ready: true, Checks: checks}
+	for _, check := range checks {
+		if !check.Ready {
+			res.Ready = false
+			break
+		}
+	}
+	return res
+}
+
+// ideBackendCheck is ready while the desktop IDE backend is running. It is nil until the desktop IDE launcher reports the backend.
+func (s *healthService) ideBackendCheck() *healthCheck {
+	if s.ideBackend == nil {
+		return nil
+	}
+	backend := s.ideBackend.Get()
+	if backend == nil {
+		return nil
+	}
+	check := &healthCheck{Name: "ide-backend", Ready: true}
+	switch backend.State {
+	case api.IDEBackendState_backend_starting:
+		check.Ready = false
+		check.Reason = healthReasonIDEBackendStarting
+	case api.IDEBackendState_backend_restarting:
+		check.Ready = false
+		check.Reason = healthReasonIDEBackendRestarting
+		check.Message = backend.Failure
+	}
+	return check
+}
+
+// tasksCheck is ready once all tasks have started.
+func (s *healthService) tasksCheck() healthCheck {
+	check := healthCheck{Name: "tasks", Ready: true}
+	select {
+	case <-s.tasks.ready:
+	default:
+		check.Ready = false
+		check.Reason = healthReasonTasksInitializing
+		return check
+	}
+
+	var starting int
+	for _, t := range s.tasks.Status() {
+		if t.State == api.TaskState_opening {
+			starting++
+		}
+	}
+	if starting > 0 {
+		check.Ready = false
+		check.Reason = healthReasonTasksStarting
+	}
+	return check
+}
+
+// ServeHTTP responds with the health report as JSON. The status code is 503 if the workspace is not ready.
+func (s *healthService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := s.Report()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(report)
+	if err != nil {
+		log.WithError(err).Debug("cannot write health report")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+func TestHealthService(t *testing.T) {
+	cstate := NewInMemoryContentState("")
+	ideReady := newIDEReadyState(&IDEConfig{DisplayName: "VS Code"})
+	tasks := &tasksManager{ready: make(chan struct{})}
+	ideBackend := &ideBackendState{}
+	ssh := &sshState{}
+	health := &healthService{
+		contentState: cstate,
+		ideReady:     ideReady,
+		ideBackend:   ideBackend,
+		tasks:        tasks,
+		ssh:          ssh,
+	}
+
+	expectReport := func(exp *healthReport, expStatus int) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		health.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_supervisor/healthz", nil))
+		if rec.Code != expStatus {
+			t.Errorf("unexpected status code: want %d, got %d", expStatus, rec.Code)
+		}
+		var act healthReport
+		err := json.Unmarshal(rec.Body.Bytes(), &act)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(exp, &act); diff != "" {
+			t.Errorf("unexpected health report (-want +got):\n%s", diff)
+		}
+	}
+
+	expectReport(&healthReport{
+		Checks: []healthCheck{
+			{Name: "content", Reason: healthReasonContentInitializing},
+			{Name: "ide", Reason: healthReasonIDEStarting, Message: "VS Code is not ready yet"},
+			{Name: "tasks", Reason: healthReasonTasksInitializing},
+			{Name: "ssh", Reason: healthReasonSSHStarting},
+		},
+	}, http.StatusServiceUnavailable)
+
+	cstate.MarkContentReady(csapi.WorkspaceInitFromOther)
+	ideReady.Set(true, nil)
+	tasks.tasks = []*task{{TaskStatus: api.TaskStatus{State: api.TaskState_opening}}}
+	close(tasks.ready)
+	ssh.Set(false, "cannot find executable path")
+	ideBackend.Set(&api.IDEBackendStatus{Kind: "intellij", State: api.IDEBackendState_backend_restarting, Restarts: 1, Failure: "backend exited with code 137"})
+	expectReport(&healthReport{
+		Checks: []healthCheck{
+			{Name: "content", Ready: true},
+			{Name: "ide", Ready: true},
+			{Name: "ide-backend", Reason: healthReasonIDEBackendRestarting, Message: "backend exited with code 137"},
+			{Name: "tasks", Reason: healthReasonTasksStarting},
+			{Name: "ssh", Reason: healthReasonSSHFailed, Message: "cannot find executable path"},
+		},
+	}, http.StatusServiceUnavailable)
+
+	tasks.tasks[0].State = api.TaskState_running
+	ssh.Set(true, "")
+	ideBackend.Set(&api.IDEBackendStatus{Kind: "intellij", State: api.IDEBackendState_backend_running, Restarts: 1})
+	expectReport(&healthReport{
+		Ready: true,
+		Checks: []healthCheck{
+			{Name: "content", Ready: true},
+			{Name: "ide", Ready: true},
+			{Name: "ide-backend", Ready: true},
+			{Name: "tasks", Ready: true},
+			{Name: "ssh", Ready: true},
+		},
+	}, http.StatusOK)
+}
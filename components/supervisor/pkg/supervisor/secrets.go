@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+const (
+	// fileSecretsLocation is where secrets flagged as files are written to. /dev/shm is a tmpfs,
+	// hence the secrets never hit the disk and don't end up in a workspace backup.
+	fileSecretsLocation = "/dev/shm/gitpod-secrets"
+
+	// fileSecretSuffix is appended to the name of an environment variable flagged as file secret
+	// to produce the environment variable pointing to the file.
+	fileSecretSuffix = "_FILE"
+
+	tmpfsMagic = 0x01021994
+)
+
+// writeFileSecrets writes the environment variables listed in names to files readable by the gitpod user only,
+// and replaces them in envs by <NAME>_FILE pointing at those files.
+func writeFileSecrets(envs map[string]string, names []string, location string) error {
+	err := os.MkdirAll(location, 0700)
+	if err != nil {
+		return xerrors.Errorf("cannot create file secrets location: %w", err)
+	}
+	err = os.Chmod(location, 0700)
+	if err != nil {
+		return xerrors.Errorf("cannot restrict file secrets location: %w", err)
+	}
+	err = os.Chown(location, gitpodUID, gitpodGID)
+	if err != nil {
+		log.WithError(err).WithField("location", location).Warn("cannot hand file secrets location to the gitpod user")
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(location, &stat); err == nil && stat.Type != tmpfsMagic {
+		log.WithField("location", location).Warn("file secrets location is not a tmpfs - secrets are written to disk")
+	}
+
+	for _, name := range names {
+		value, ok := envs[name]
+		if !ok {
+			continue
+		}
+		if filepath.Base(name) != name || name == "." || name == ".." {
+			log.WithField("envvar", name).Warn("invalid file secret name - not passing it to child processes")
+			delete(envs, name)
+			continue
+		}
+
+		fn := filepath.Join(location, name)
+		err := writeFileSecret(fn, value)
+		if err != nil {
+			log.WithError(err).WithField("envvar", name).Error("cannot write file secret - not passing it to child processes")
+			delete(envs, name)
+			continue
+		}
+
+		delete(envs, name)
+		envs[name+fileSecretSuffix] = fn
+	}
+	return nil
+}
+
+func writeFileSecret(fn, value string) error {
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// the file might have existed before with different permissions
+	err = f.Chmod(0600)
+	if err != nil {
+		return err
+	}
+	err = f.Chown(gitpodUID, gitpodGID)
+	if err != nil {
+		log.WithError(err).WithField("file", fn).Warn("cannot hand file secret to the gitpod user")
+	}
+	_, err = f.WriteString(value)
+	return err
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteFileSecrets(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "secrets")
+	envs := map[string]string{
+		"NPM_TOKEN": "npm-secret",
+		"../escape": "escape-secret",
+		"EDITOR":    "vim",
+	}
+
+	err := writeFileSecrets(envs, []string{"NPM_TOKEN", "../escape", "NOT_SET"}, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectation := map[string]string{
+		"NPM_TOKEN_FILE": filepath.Join(location, "NPM_TOKEN"),
+		"EDITOR":         "vim",
+	}
+	if diff := cmp.Diff(expectation, envs); diff != "" {
+		t.Errorf("unexpected environment (-want +got):\n%s", diff)
+	}
+
+	content, err := os.ReadFile(filepath.Join(location, "NPM_TOKEN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "npm-secret" {
+		t.Errorf("unexpected file secret content: %q", string(content))
+	}
+
+	for fn, perm := range map[string]os.FileMode{
+		location:                             0700 | os.ModeDir,
+		filepath.Join(location, "NPM_TOKEN"): 0600,
+	} {
+		stat, err := os.Stat(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Mode() != perm {
+			t.Errorf("unexpected mode of %s: expected %v, got %v", fn, perm, stat.Mode())
+		}
+	}
+}
@@ -7,13 +7,16 @@ package supervisor
 import (
 	"bytes"
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -106,6 +109,10 @@ type statusService struct {
 	ideReady        *ideReadyState
 	desktopIdeReady *ideReadyState
 	topService      *TopService
+	dotfiles        *dotfilesState
+	diagnostics     *startupDiagnostics
+	ideBackend      *ideBackendState
+	activity        *activityTracker
 
 	api.UnimplementedStatusServiceServer
 }
@@ -137,6 +144,12 @@ func (s *statusService) SupervisorStatus(ctx context.Context, req *api.Superviso
 }
 
 func (s *statusService) IDEStatus(ctx context.Context, req *api.IDEStatusRequest) (*api.IDEStatusResponse, error) {
+	// IDE clients query the IDE status when they connect and while they are open, unlike their traffic
+	// which reaches the IDE without passing supervisor.
+	if s.activity != nil {
+		s.activity.Mark(activityIDE)
+	}
+
 	if req.Wait {
 		select {
 		case <-s.ideReady.Wait():
@@ -221,6 +234,47 @@ func (s *statusService) ContentStatus(ctx context.Context, req *api.ContentStatu
 	}, nil
 }
 
+// DotfilesStatus provides feedback about the installation of the user's dotfiles.
+func (s *statusService) DotfilesStatus(ctx context.Context, req *api.DotfilesStatusRequest) (*api.DotfilesStatusResponse, error) {
+	if s.dotfiles == nil {
+		return &api.DotfilesStatusResponse{State: api.DotfilesState_not_configured}, nil
+	}
+
+	if req.Wait {
+		select {
+		case <-s.dotfiles.Done():
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil, status.Error(codes.Canceled, "Context canceled")
+			}
+
+			return nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+		}
+	}
+
+	return s.dotfiles.Status(), nil
+}
+
+// StartupDiagnostics reports what supervisor waited on during the workspace startup and for how long.
+func (s *statusService) StartupDiagnostics(ctx context.Context, req *api.StartupDiagnosticsRequest) (*api.StartupDiagnosticsResponse, error) {
+	if s.diagnostics == nil {
+		return &api.StartupDiagnosticsResponse{}, nil
+	}
+	return s.diagnostics.Report(time.Now()), nil
+}
+
+func (s *statusService) IDEBackendStatus(ctx context.Context, req *api.IDEBackendStatusRequest) (*api.IDEBackendStatusResponse, error) {
+	return &api.IDEBackendStatusResponse{Backend: s.ideBackend.Get()}, nil
+}
+
+func (s *statusService) UpdateIDEBackendStatus(ctx context.Context, req *api.UpdateIDEBackendStatusRequest) (*api.UpdateIDEBackendStatusResponse, error) {
+	if req.Backend == nil {
+		return nil, status.Error(codes.InvalidArgument, "backend is required")
+	}
+	s.ideBackend.Set(req.Backend)
+	return &api.UpdateIDEBackendStatusResponse{}, nil
+}
+
 func (s *statusService) BackupStatus(ctx context.Context, req *api.BackupStatusRequest) (*api.BackupStatusResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "not implemented")
 }
@@ -440,6 +494,26 @@ func (s *InMemoryTokenService) getCachedTokenFor(kind string, host string, scope
 	return nil
 }
 
+// hasToken checks whether a token of the given kind was provided to the token service and has not expired.
+func (s *InMemoryTokenService) hasToken(kind string, value string) bool {
+	if value == "" {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tkn := range s.token[kind] {
+		if tkn.ExpiryDate != nil && time.Now().After(*tkn.ExpiryDate) {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(tkn.Token), []byte(value)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *InMemoryTokenService) cacheToken(kind string, tkn *Token) {
 	if tkn.Reuse == api.TokenReuse_REUSE_NEVER {
 		// we just don't cache non-reuse tokens
@@ -449,7 +523,15 @@ func (s *InMemoryTokenService) cacheToken(kind string, tkn *Token) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.token[kind] = append(s.token[kind], tkn)
+	// drop expired tokens, so that the cache doesn't grow with tokens which are reused for a limited time only
+	now := time.Now()
+	tkns := s.token[kind][:0]
+	for _, t := range s.token[kind] {
+		if t.ExpiryDate == nil || now.Before(*t.ExpiryDate) {
+			tkns = append(tkns, t)
+		}
+	}
+	s.token[kind] = append(tkns, tkn)
 	log.WithField("kind", kind).WithField("host", tkn.Host).WithField("scopes", tkn.Scope).WithField("reuse", tkn.Reuse.String()).Info("registered new token")
 }
 
@@ -731,10 +813,20 @@ func (is *InfoService) WorkspaceInfo(ctx context.Context, req *api.WorkspaceInfo
 type ControlService struct {
 	portsManager *ports.Manager
 
+	// tokenService authenticates Exec requests, execWorkdir, execEnv and execCreds are the defaults of the commands it runs
+	tokenService *InMemoryTokenService
+	execWorkdir  string
+	execEnv      []string
+	execCreds    *syscall.Credential
+
 	privateKey string
 	publicKey  string
 	hostKey    *api.SSHPublicKey
 
+	// sshSessions and tunneledPorts are listed and revoked by ListSessions and RevokeSession
+	sshSessions   *sshSessions
+	tunneledPorts *ports.TunneledPortsService
+
 	api.UnimplementedControlServiceServer
 }
 
@@ -801,6 +893,8 @@ func (ss *ControlService) CreateSSHKeyPair(ctx context.Context, req *api.CreateS
 		if err != nil {
 			return nil, xerrors.Errorf("cannot create dir ~/.ssh/: %w", err)
 		}
+		authorizedKeysMu.Lock()
+		defer authorizedKeysMu.Unlock()
 		f, err := os.OpenFile(filepath.Join(home, ".ssh/authorized_keys"), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
 		if err != nil {
 			return nil, xerrors.Errorf("cannot open file ~/.ssh/authorized_keys: %w", err)
@@ -871,6 +965,62 @@ func (c *ControlService) CreateDebugEnv(ctx context.Context, req *api.CreateDebu
 	}, nil
 }
 
+// tunnelSessionPrefix prefixes the IDs of tunnel sessions, which are followed by the local port and client ID.
+const tunnelSessionPrefix = "tunnel-"
+
+// ListSessions lists the active SSH sessions and port tunnel clients.
+func (c *ControlService) ListSessions(ctx context.Context, req *api.ListSessionsRequest) (*api.ListSessionsResponse, error) {
+	var res []*api.Session
+	if c.sshSessions != nil {
+		for _, session := range c.sshSessions.List() {
+			res = append(res, &api.Session{
+				Id:         session.ID,
+				Kind:       api.SessionKind_ssh_session,
+				RemoteAddr: session.RemoteAddr,
+				StartedAt:  session.Started.Unix(),
+			})
+		}
+	}
+	if c.tunneledPorts != nil {
+		for _, client := range c.tunneledPorts.Clients() {
+			res = append(res, &api.Session{
+				Id:          fmt.Sprintf("%s%d-%s", tunnelSessionPrefix, client.LocalPort, client.ClientID),
+				Kind:        api.SessionKind_tunnel_session,
+				ClientId:    client.ClientID,
+				LocalPort:   client.LocalPort,
+				TargetPort:  client.TargetPort,
+				Connections: uint32(client.Connections),
+				StartedAt:   client.Since.Unix(),
+			})
+		}
+	}
+	return &api.ListSessionsResponse{Sessions: res}, nil
+}
+
+// RevokeSession terminates an SSH session or closes all connections of a port tunnel client.
+func (c *ControlService) RevokeSession(ctx context.Context, req *api.RevokeSessionRequest) (*api.RevokeSessionResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if tunnel, ok := strings.CutPrefix(req.Id, tunnelSessionPrefix); ok && c.tunneledPorts != nil {
+		port, clientID, _ := strings.Cut(tunnel, "-")
+		localPort, err := strconv.ParseUint(port, 10, 16)
+		if err == nil && clientID != "" {
+			closed, err := c.tunneledPorts.CloseClient(uint32(localPort), clientID)
+			if err != nil {
+				log.WithError(err).WithField("session", req.Id).Debug("error closing tunnel connections")
+			}
+			if closed {
+				return &api.RevokeSessionResponse{}, nil
+			}
+		}
+	}
+	if c.sshSessions != nil && c.sshSessions.Revoke(req.Id) {
+		return &api.RevokeSessionResponse{}, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "session %s not found", req.Id)
+}
+
 // ContentState signals the workspace content state.
 type ContentState interface {
 	MarkContentReady(src csapi.WorkspaceInitSource)
@@ -937,6 +1087,7 @@ func (s *portService) Tunnel(ctx context.Context, req *api.TunnelPortRequest) (*
 		LocalPort:  req.Port,
 		TargetPort: req.TargetPort,
 		Visibility: req.Visibility,
+		Protocol:   req.Protocol,
 	})
 	if err != nil {
 		return nil, err
@@ -960,16 +1111,22 @@ func (s *portService) EstablishTunnel(stream api.PortService_EstablishTunnelServ
 		return status.Error(codes.Internal, err.Error())
 	}
 	desc := req.GetDesc()
-	if desc != nil {
+	if desc == nil {
 		return status.Error(codes.FailedPrecondition, "first request should be a desc")
 	}
 
-	tunnel, err := s.portsManager.EstablishTunnel(stream.Context(), desc.ClientId, desc.Port, desc.TargetPort)
+	tunnel, err := s.portsManager.EstablishTunnel(stream.Context(), desc.ClientId, desc.Port, desc.TargetPort, desc.Protocol)
 	if err != nil {
 		return status.Errorf(codes.Internal, "failed establish the tunnel: %v", err)
 	}
 	defer tunnel.Close()
 
+	// every message carries exactly one datagram for UDP tunnels
+	buffSize := 4096
+	if desc.Protocol == api.TunnelProtocol_udp {
+		buffSize = ports.MaxDatagramSize
+	}
+
 	errChan := make(chan error)
 
 	go func() {
@@ -995,7 +1152,7 @@ func (s *portService) EstablishTunnel(stream api.PortService_EstablishTunnelServ
 
 	go func() {
 		for {
-			buff := make([]byte, 4096)
+			buff := make([]byte, buffSize)
 			bytesRead, err := tunnel.Read(buff)
 			if err != nil {
 				errChan <- err
@@ -6,6 +6,8 @@ package supervisor
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"testing"
 	"time"
 
@@ -13,9 +15,11 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/ports"
 )
 
 func TestInMemoryTokenServiceGetToken(t *testing.T) {
@@ -384,3 +388,72 @@ type tokenProviderFunc func(ctx context.Context, req *api.GetTokenRequest) (tkn
 func (f tokenProviderFunc) GetToken(ctx context.Context, req *api.GetTokenRequest) (tkn *Token, err error) {
 	return f(ctx, req)
 }
+
+func TestControlServiceSessions(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	localPort := uint32(listener.Addr().(*net.TCPAddr).Port)
+
+	tunneled := ports.NewTunneledPortsService(false)
+	_, err = tunneled.Tunnel(context.Background(), &ports.TunnelOptions{}, &ports.PortTunnelDescription{LocalPort: localPort, TargetPort: 3000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := tunneled.EstablishTunnel(context.Background(), "local-app", localPort, 3000, api.TunnelProtocol_tcp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var revoked []string
+	sessions := newSSHSessions()
+	sessions.add("10.0.0.1:4242", func() { revoked = append(revoked, "ssh-1") })
+	sessions.add("10.0.0.2:4242", func() { revoked = append(revoked, "ssh-2") })
+
+	service := &ControlService{sshSessions: sessions, tunneledPorts: tunneled}
+	list := func() []*api.Session {
+		resp, err := service.ListSessions(context.Background(), &api.ListSessionsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, session := range resp.Sessions {
+			if session.StartedAt == 0 {
+				t.Errorf("session %s has no start time", session.Id)
+			}
+		}
+		return resp.Sessions
+	}
+	ignoreStartedAt := protocmp.IgnoreFields(&api.Session{}, "started_at")
+
+	expectation := []*api.Session{
+		{Id: "ssh-1", Kind: api.SessionKind_ssh_session, RemoteAddr: "10.0.0.1:4242"},
+		{Id: "ssh-2", Kind: api.SessionKind_ssh_session, RemoteAddr: "10.0.0.2:4242"},
+		{Id: fmt.Sprintf("tunnel-%d-local-app", localPort), Kind: api.SessionKind_tunnel_session, ClientId: "local-app", LocalPort: localPort, TargetPort: 3000, Connections: 1},
+	}
+	if diff := cmp.Diff(expectation, list(), protocmp.Transform(), ignoreStartedAt); diff != "" {
+		t.Errorf("unexpected sessions (-want +got):\n%s", diff)
+	}
+
+	for _, id := range []string{"ssh-1", fmt.Sprintf("tunnel-%d-local-app", localPort)} {
+		_, err = service.RevokeSession(context.Background(), &api.RevokeSessionRequest{Id: id})
+		if err != nil {
+			t.Fatalf("cannot revoke %s: %v", id, err)
+		}
+	}
+	if diff := cmp.Diff([]string{"ssh-1"}, revoked); diff != "" {
+		t.Errorf("unexpected revoked sessions (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(expectation[1:2], list(), protocmp.Transform(), ignoreStartedAt); diff != "" {
+		t.Errorf("unexpected sessions (-want +got):\n%s", diff)
+	}
+
+	for _, id := range []string{"", "ssh-1", "tunnel-abc-local-app"} {
+		_, err = service.RevokeSession(context.Background(), &api.RevokeSessionRequest{Id: id})
+		if status.Code(err) == codes.OK {
+			t.Errorf("expected revoking %q to fail", id)
+		}
+	}
+}
@@ -6,23 +6,30 @@ package supervisor
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
+	"net/netip"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/supervisor/pkg/dropwriter"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/metrics"
 	"github.com/sirupsen/logrus"
 )
 
-func newSSHServer(ctx context.Context, cfg *Config, envvars []string) (*sshServer, error) {
+func newSSHServer(ctx context.Context, cfg *Config, envvars []string, metrics *metrics.SupervisorMetrics, sessions *sshSessions) (*sshServer, error) {
 	bin, err := os.Executable()
 	if err != nil {
 		return nil, xerrors.Errorf("cannot find executable path: %w", err)
@@ -52,11 +59,13 @@ func newSSHServer(ctx context.Context, cfg *Config, envvars []string) (*sshServe
 	}
 
 	return &sshServer{
-		ctx:     ctx,
-		cfg:     cfg,
-		sshkey:  sshkey,
-		envvars: envvars,
-		caPath:  caPath,
+		ctx:      ctx,
+		cfg:      cfg,
+		sshkey:   sshkey,
+		envvars:  envvars,
+		caPath:   caPath,
+		metrics:  metrics,
+		sessions: sessions,
 	}, nil
 }
 
@@ -67,14 +76,162 @@ type sshServer struct {
 
 	sshkey string
 	caPath string
+
+	metrics  *metrics.SupervisorMetrics
+	sessions *sshSessions
+}
+
+// sshSession is an SSH connection served by the SSH server.
+type sshSession struct {
+	ID         string
+	RemoteAddr string
+	Started    time.Time
+
+	seq   int
+	close func()
+}
+
+// sshSessions keeps track of the active SSH sessions, so that they can be listed and revoked.
+type sshSessions struct {
+	mu       sync.Mutex
+	next     int
+	sessions map[string]*sshSession
+	// clients are the addresses of the clients of tunneled connections to the SSH server, by the local address
+	// the tunnel connects from
+	clients map[string]string
+}
+
+func newSSHSessions() *sshSessions {
+	return &sshSessions{
+		sessions: make(map[string]*sshSession),
+		clients:  make(map[string]string),
+	}
+}
+
+// tunnel records the address of the client of a connection which is tunneled to the SSH server from localAddr,
+// such that its session lists the client rather than the tunnel. The returned function forgets the client.
+func (s *sshSessions) tunnel(localAddr, clientAddr string) (done func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients[localAddr] = clientAddr
+	// sshd may have accepted the connection already
+	for _, session := range s.sessions {
+		if session.RemoteAddr == localAddr {
+			session.RemoteAddr = clientAddr
+		}
+	}
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.clients, localAddr)
+	}
+}
+
+// add registers a session and returns its ID. close is called when the session is revoked.
+func (s *sshSessions) add(remoteAddr string, close func()) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if clientAddr, ok := s.clients[remoteAddr]; ok {
+		remoteAddr = clientAddr
+	}
+
+	s.next++
+	id := fmt.Sprintf("ssh-%d", s.next)
+	s.sessions[id] = &sshSession{
+		ID:         id,
+		RemoteAddr: remoteAddr,
+		Started:    time.Now(),
+		seq:        s.next,
+		close:      close,
+	}
+	return id
+}
+
+func (s *sshSessions) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+}
+
+// List returns the active sessions in the order they were opened.
+func (s *sshSessions) List() []sshSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]sshSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		res = append(res, *session)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].seq < res[j].seq })
+	return res
+}
+
+// clientAddress returns the address of the client of a request. Requests from trusted proxies may tell the address
+// of the client in X-Forwarded-For, where each proxy appends the address it got the request from. Hence we take the
+// rightmost address which isn't a trusted proxy, because anything left of it may be forged by the client.
+func clientAddress(r *http.Request, trustedProxies []netip.Prefix) string {
+	isTrusted := func(addr string) bool {
+		ip, err := netip.ParseAddr(addr)
+		if err != nil {
+			return false
+		}
+		ip = ip.Unmap()
+		for _, proxy := range trustedProxies {
+			if proxy.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || !isTrusted(host) {
+		return r.RemoteAddr
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, addr := range strings.Split(header, ",") {
+			forwarded = append(forwarded, strings.TrimSpace(addr))
+		}
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if forwarded[i] == "" {
+			break
+		}
+		if !isTrusted(forwarded[i]) {
+			return forwarded[i]
+		}
+	}
+	return r.RemoteAddr
+}
+
+// Revoke terminates a session. It returns false if there is no such session.
+func (s *sshSessions) Revoke(id string) bool {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	session.close()
+	return true
 }
 
 // ListenAndServe listens on the TCP network address laddr and then handle packets on incoming connections.
-func (s *sshServer) listenAndServe() error {
+// onListening is called once the server accepts connections.
+func (s *sshServer) listenAndServe(onListening func()) error {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%v", s.cfg.SSHPort))
 	if err != nil {
 		return err
 	}
+	onListening()
 
 	for {
 		conn, err := listener.Accept()
@@ -118,6 +275,12 @@ func (s *sshServer) handleConn(ctx context.Context, conn net.Conn) {
 		"-oStrictModes no", // don't care for home directory and file permissions
 		"-oTrustedUserCAKeys "+s.caPath,
 	)
+	// lets git inside the workspace use the keys of the user's local agent, so they don't need to upload private keys
+	if s.cfg.SSHAgentForwarding {
+		args = append(args, "-oAllowAgentForwarding yes")
+	} else {
+		args = append(args, "-oAllowAgentForwarding no")
+	}
 	// can be configured with gp env LOG_LEVEL=DEBUG to see SSH sessions/channels
 	sshdLogLevel := "ERROR"
 	switch log.Log.Logger.GetLevel() {
@@ -183,6 +346,20 @@ func (s *sshServer) handleConn(ctx context.Context, conn net.Conn) {
 	}()
 
 	log.Debug("sshd started")
+	if s.metrics != nil {
+		s.metrics.SSHSessionsTotal.WithLabelValues().Inc()
+	}
+	if s.sessions != nil {
+		id := s.sessions.add(conn.RemoteAddr().String(), func() {
+			// sshd holds its own copy of the socket, hence the connection is shut down rather than closed
+			if tcpConn, ok := conn.(*net.TCPConn); ok {
+				_ = tcpConn.CloseRead()
+				_ = tcpConn.CloseWrite()
+			}
+			_ = cmd.Process.Kill()
+		})
+		defer s.sessions.remove(id)
+	}
 
 	select {
 	case <-ctx.Done():
@@ -310,3 +487,115 @@ For more information, see the Gitpod documentation: https://gitpod.io/docs
 		log.WithError(err).Error("write /etc/motd failed")
 	}
 }
+
+const (
+	// workspaceSSHPublicKeysFile contains the SSH public keys of the workspace spec. ws-manager-mk2 mounts it
+	// from an annotation of the workspace pod, which the kubelet keeps up to date while the workspace is running.
+	workspaceSSHPublicKeysFile = "/.workspace-ssh/authorized_keys"
+	authorizedKeysSyncInterval = 10 * time.Second
+
+	authorizedKeysBegin = "# BEGIN gitpod workspace keys - managed by supervisor, changes will be overwritten"
+	authorizedKeysEnd   = "# END gitpod workspace keys"
+)
+
+// authorizedKeysMu serializes all changes to ~/.ssh/authorized_keys
+var authorizedKeysMu sync.Mutex
+
+// syncAuthorizedKeys keeps the workspace keys in ~/.ssh/authorized_keys in sync with the keys mounted at src,
+// such that keys added to a running workspace can be used without restarting it.
+func syncAuthorizedKeys(ctx context.Context, src string) {
+	var (
+		last   []byte
+		synced bool
+	)
+	t := time.NewTicker(authorizedKeysSyncInterval)
+	defer t.Stop()
+	for {
+		keys, err := os.ReadFile(src)
+		switch {
+		case os.IsNotExist(err):
+			// the workspace manager doesn't provide keys this way
+			return
+		case err != nil:
+			log.WithError(err).Warn("cannot read workspace SSH public keys")
+		case !synced || !bytes.Equal(keys, last):
+			err = writeAuthorizedKeys(keys)
+			if err != nil {
+				log.WithError(err).Warn("cannot update authorized SSH keys")
+				break
+			}
+			last, synced = keys, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func writeAuthorizedKeys(keys []byte) error {
+	authorizedKeysMu.Lock()
+	defer authorizedKeysMu.Unlock()
+
+	home := "/home/gitpod"
+	fn := filepath.Join(home, ".ssh", "authorized_keys")
+	existing, err := os.ReadFile(fn)
+	if err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("cannot read %s: %w", fn, err)
+	}
+	content := replaceManagedKeys(existing, keys)
+	if bytes.Equal(content, existing) {
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(fn), 0o700)
+	if err != nil {
+		return xerrors.Errorf("cannot create $HOME/.ssh: %w", err)
+	}
+	tmp := fn + ".tmp"
+	err = os.WriteFile(tmp, content, 0o600)
+	if err != nil {
+		return xerrors.Errorf("cannot write %s: %w", tmp, err)
+	}
+	err = os.Chown(tmp, gitpodUID, gitpodGID)
+	if err != nil {
+		return xerrors.Errorf("cannot chown %s: %w", tmp, err)
+	}
+	err = os.Rename(tmp, fn)
+	if err != nil {
+		return xerrors.Errorf("cannot replace %s: %w", fn, err)
+	}
+	return nil
+}
+
+// replaceManagedKeys replaces the block of workspace keys in the content of an authorized_keys file,
+// leaving all other keys untouched.
+func replaceManagedKeys(existing, keys []byte) []byte {
+	var (
+		res     bytes.Buffer
+		managed bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(existing))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == authorizedKeysBegin:
+			managed = true
+		case line == authorizedKeysEnd:
+			managed = false
+		case !managed:
+			res.WriteString(line)
+			res.WriteString("\n")
+		}
+	}
+
+	keys = bytes.TrimSpace(keys)
+	if len(keys) > 0 {
+		res.WriteString(authorizedKeysBegin + "\n")
+		res.Write(keys)
+		res.WriteString("\n" + authorizedKeysEnd + "\n")
+	}
+	return res.Bytes()
+}
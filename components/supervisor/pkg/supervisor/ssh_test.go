@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReplaceManagedKeys(t *testing.T) {
+	block := func(keys string) string {
+		return authorizedKeysBegin + "\n" + keys + authorizedKeysEnd + "\n"
+	}
+
+	tests := []struct {
+		Name        string
+		Existing    string
+		Keys        string
+		Expectation string
+	}{
+		{
+			Name: "empty",
+		},
+		{
+			Name:        "new file",
+			Keys:        "ssh-ed25519 AAAA foo\n",
+			Expectation: block("ssh-ed25519 AAAA foo\n"),
+		},
+		{
+			Name:        "keeps other keys",
+			Existing:    "ssh-rsa BBBB own\n",
+			Keys:        "ssh-ed25519 AAAA foo\n",
+			Expectation: "ssh-rsa BBBB own\n" + block("ssh-ed25519 AAAA foo\n"),
+		},
+		{
+			Name:        "replaces block",
+			Existing:    "ssh-rsa BBBB own\n" + block("ssh-ed25519 AAAA foo\n") + "ssh-rsa CCCC generated\n",
+			Keys:        "ssh-ed25519 AAAA foo\nssh-ed25519 DDDD bar\n",
+			Expectation: "ssh-rsa BBBB own\nssh-rsa CCCC generated\n" + block("ssh-ed25519 AAAA foo\nssh-ed25519 DDDD bar\n"),
+		},
+		{
+			Name:        "removes block",
+			Existing:    "ssh-rsa BBBB own\n" + block("ssh-ed25519 AAAA foo\n"),
+			Expectation: "ssh-rsa BBBB own\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := replaceManagedKeys([]byte(test.Existing), []byte(test.Keys))
+			if diff := cmp.Diff(test.Expectation, string(act)); diff != "" {
+				t.Errorf("unexpected authorized keys (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClientAddress(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		Name          string
+		RemoteAddr    string
+		XForwardedFor []string
+		Expectation   string
+	}{
+		{
+			Name:        "no proxy",
+			RemoteAddr:  "1.2.3.4:1234",
+			Expectation: "1.2.3.4:1234",
+		},
+		{
+			Name:          "untrusted proxy",
+			RemoteAddr:    "1.2.3.4:1234",
+			XForwardedFor: []string{"5.6.7.8"},
+			Expectation:   "1.2.3.4:1234",
+		},
+		{
+			Name:          "trusted proxy",
+			RemoteAddr:    "10.0.0.1:1234",
+			XForwardedFor: []string{"5.6.7.8"},
+			Expectation:   "5.6.7.8",
+		},
+		{
+			Name:          "forged address",
+			RemoteAddr:    "10.0.0.1:1234",
+			XForwardedFor: []string{"9.9.9.9, 5.6.7.8", "10.0.0.2"},
+			Expectation:   "5.6.7.8",
+		},
+		{
+			Name:          "only trusted proxies",
+			RemoteAddr:    "10.0.0.1:1234",
+			XForwardedFor: []string{"10.0.0.2"},
+			Expectation:   "10.0.0.1:1234",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/_supervisor/tunnel/ssh", nil)
+			r.RemoteAddr = test.RemoteAddr
+			for _, v := range test.XForwardedFor {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if act := clientAddress(r, trusted); act != test.Expectation {
+				t.Errorf("expected client address %s, got %s", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestSSHSessionsTunnel(t *testing.T) {
+	sessions := newSSHSessions()
+
+	// sshd may accept the connection before or after the tunnel records its client
+	done := sessions.tunnel("127.0.0.1:1000", "5.6.7.8")
+	sessions.add("127.0.0.1:1000", func() {})
+	sessions.add("127.0.0.1:2000", func() {})
+	sessions.tunnel("127.0.0.1:2000", "9.9.9.9")
+	done()
+	sessions.add("127.0.0.1:1000", func() {})
+
+	var act []string
+	for _, session := range sessions.List() {
+		act = append(act, session.RemoteAddr)
+	}
+	if diff := cmp.Diff([]string{"5.6.7.8", "9.9.9.9", "127.0.0.1:1000"}, act); diff != "" {
+		t.Errorf("unexpected remote addresses (-want +got):\n%s", diff)
+	}
+}
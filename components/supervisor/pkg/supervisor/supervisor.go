@@ -79,6 +79,7 @@ const (
 	gitpodGroupName = "gitpod"
 	desktopIDEPort  = 24000
 	debugProxyPort  = 23003
+	metricsPort     = 23004
 )
 
 var (
@@ -254,7 +255,7 @@ func Run(options ...RunOption) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	internalPorts := []uint32{uint32(cfg.IDEPort), uint32(cfg.APIEndpointPort), uint32(cfg.SSHPort)}
+	internalPorts := []uint32{uint32(cfg.IDEPort), uint32(cfg.APIEndpointPort), uint32(cfg.SSHPort), metricsPort}
 	if cfg.GetDesktopIDE() != nil {
 		internalPorts = append(internalPorts, desktopIDEPort)
 	}
@@ -268,6 +269,10 @@ func Run(options ...RunOption) {
 
 		cstate        = NewInMemoryContentState(cfg.RepoRoot)
 		gitpodService serverapi.APIInterface
+		diagnostics   = newStartupDiagnostics()
+		ideBackend    = &ideBackendState{}
+		sshSessions   = newSSHSessions()
+		activity      *activityTracker
 
 		notificationService = NewNotificationService()
 	)
@@ -292,6 +297,8 @@ func Run(options ...RunOption) {
 	}
 	tokenService.provider[KindGit] = []tokenProvider{NewGitTokenProvider(gitpodService, cfg.WorkspaceConfig, notificationService)}
 
+	diagnostics.Watch("content", cstate.ContentReady())
+
 	gitpodConfigService := config.NewConfigService(cfg.RepoRoot+"/.gitpod.yml", cstate.ContentReady())
 	go gitpodConfigService.Watch(ctx)
 
@@ -301,15 +308,23 @@ func Run(options ...RunOption) {
 		exposedPorts = createExposedPortsImpl(cfg, gitpodService)
 	}
 
+	supervisorMetrics := metrics.NewMetrics()
 	portMgmt := ports.NewManager(
 		exposedPorts,
 		&ports.PollingServedPortsObserver{
 			RefreshInterval: 2 * time.Second,
 		},
-		ports.NewConfigService(cfg.WorkspaceID, gitpodConfigService),
+		ports.NewConfigService(cfg.WorkspaceID, gitpodConfigService, childProcEnvvars),
 		tunneledPortsService,
 		internalPorts...,
 	)
+	portMgmt.OnAutoExposed = func(duration time.Duration, err error) {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		supervisorMetrics.PortExposureDuration.WithLabelValues(result).Observe(duration.Seconds())
+	}
 
 	topService := NewTopService()
 	if !opts.RunGP {
@@ -321,22 +336,25 @@ func Run(options ...RunOption) {
 		go analysePerfChanges(ctx, cfg, telemetry, topService)
 	}
 
-	supervisorMetrics := metrics.NewMetrics()
-	var metricsReporter *metrics.GrpcMetricsReporter
+	var (
+		metricsReporter *metrics.GrpcMetricsReporter
+		metricsRegistry = prometheus.NewRegistry()
+	)
 	if !opts.RunGP && !cfg.isDebugWorkspace() && !strings.Contains("ephemeral", cfg.WorkspaceClusterHost) {
 		_, gitpodHost, err := cfg.GitpodAPIEndpoint()
 		if err != nil {
 			log.WithError(err).Error("grpc metrics: failed to parse gitpod host")
 		} else {
 			metricsReporter = metrics.NewGrpcMetricsReporter(gitpodHost)
-			if err := supervisorMetrics.Register(metricsReporter.Registry); err != nil {
-				log.WithError(err).Error("could not register supervisor metrics")
-			}
-			if err := gitpodService.RegisterMetrics(metricsReporter.Registry); err != nil {
+			metricsRegistry = metricsReporter.Registry
+			if err := gitpodService.RegisterMetrics(metricsRegistry); err != nil {
 				log.WithError(err).Error("could not register public api metrics")
 			}
 		}
 	}
+	if err := supervisorMetrics.Register(metricsRegistry); err != nil {
+		log.WithError(err).Error("could not register supervisor metrics")
+	}
 
 	termMux := terminal.NewMux()
 	termMuxSrv := terminal.NewMuxTerminalService(termMux)
@@ -358,8 +376,14 @@ func Run(options ...RunOption) {
 		Uid: gitpodUID,
 		Gid: gitpodGID,
 	}
+	if !cfg.isHeadless() && !opts.RunGP && !cfg.isDebugWorkspace() {
+		weights, _ := cfg.getActivityWeights()
+		activity = newActivityTracker(weights)
+		termMuxSrv.OnInput = func() { activity.Mark(activityTerminal) }
+		termMux.OnOutput = func() { activity.Mark(activityOutput) }
+	}
 
-	taskManager := newTasksManager(cfg, termMuxSrv, cstate, nil, ideReady, desktopIdeReady)
+	taskManager := newTasksManager(cfg, termMuxSrv, cstate, nil, ideReady, desktopIdeReady, supervisorMetrics)
 
 	gitStatusWg := &sync.WaitGroup{}
 	gitStatusCtx, stopGitStatus := context.WithCancel(ctx)
@@ -375,6 +399,12 @@ func Run(options ...RunOption) {
 		go gitStatusService.Run(gitStatusCtx, gitStatusWg)
 	}
 
+	dotfileRepo := cfg.DotfileRepo
+	if cfg.isPrebuild() {
+		dotfileRepo = ""
+	}
+	dotfiles := newDotfilesState(dotfileRepo)
+
 	willShutdownCtx, fireWillShutdown := context.WithCancel(ctx)
 	apiServices := []RegisterableService{
 		&statusService{
@@ -385,20 +415,59 @@ func Run(options ...RunOption) {
 			ideReady:        ideReady,
 			desktopIdeReady: desktopIdeReady,
 			topService:      topService,
+			dotfiles:        dotfiles,
+			diagnostics:     diagnostics,
+			ideBackend:      ideBackend,
+			activity:        activity,
 		},
 		termMuxSrv,
 		RegistrableTokenService{Service: tokenService},
 		notificationService,
 		NewInfoService(cfg, cstate, gitpodService),
-		&ControlService{portsManager: portMgmt},
+		&ControlService{
+			portsManager:  portMgmt,
+			tokenService:  tokenService,
+			execWorkdir:   cfg.RepoRoot,
+			execEnv:       childProcEnvvars,
+			execCreds:     &syscall.Credential{Uid: gitpodUID, Gid: gitpodGID},
+			sshSessions:   sshSessions,
+			tunneledPorts: tunneledPortsService,
+		},
 		&portService{portsManager: portMgmt},
 	}
 	apiServices = append(apiServices, additionalServices...)
 
-	if !cfg.isPrebuild() {
+	var (
+		wg       sync.WaitGroup
+		shutdown = make(chan ShutdownReason, 1)
+	)
+
+	health := &healthService{
+		contentState:    cstate,
+		ideReady:        ideReady,
+		desktopIdeReady: desktopIdeReady,
+		ideBackend:      ideBackend,
+		tasks:           taskManager,
+	}
+	if !cfg.isHeadless() {
+		health.ssh = &sshState{}
+	}
+
+	// The API endpoint is started before the dotfiles are installed, so that clients can observe the installation.
+	wg.Add(1)
+	go startAPIEndpoint(ctx, cfg, &wg, apiServices, tunneledPortsService, metricsRegistry, metricsReporter, supervisorMetrics, topService, health, sshSessions, apiEndpointOpts...)
+
+	if !opts.RunGP {
+		wg.Add(1)
+		go startMetricsEndpoint(ctx, &wg, metricsRegistry)
+	}
+
+	if !cfg.isPrebuild() && cfg.DotfileRepo != "" {
 		// We need to checkout dotfiles first, because they may be changing the path which affects the IDE.
-		// TODO(cw): provide better feedback if the IDE start fails because of the dotfiles (provide any feedback at all).
-		installDotfiles(ctx, cfg, tokenService)
+		phase := diagnostics.Begin("dotfiles")
+		installDotfiles(ctx, cfg, tokenService, dotfiles)
+		phase.SetFailure(dotfiles.Status().Failure)
+		phase.Done()
 	}
 
 	shouldShutdown, shutdownDuration := getIDENotReadyShutdownDuration(ctx, exps, host)
@@ -408,16 +477,12 @@ func Run(options ...RunOption) {
 	shouldWaitBackend := shouldShutdown
 	var ideWG sync.WaitGroup
 	ideWG.Add(1)
-	go startAndWatchIDE(ctx, cfg, &cfg.IDE, &ideWG, cstate, ideReady, WebIDE, supervisorMetrics, shouldWaitBackend)
+	go startAndWatchIDE(ctx, cfg, &cfg.IDE, &ideWG, cstate, ideReady, WebIDE, supervisorMetrics, shouldWaitBackend, diagnostics)
 	if cfg.GetDesktopIDE() != nil {
 		ideWG.Add(1)
-		go startAndWatchIDE(ctx, cfg, cfg.GetDesktopIDE(), &ideWG, cstate, desktopIdeReady, DesktopIDE, supervisorMetrics, shouldWaitBackend)
+		go startAndWatchIDE(ctx, cfg, cfg.GetDesktopIDE(), &ideWG, cstate, desktopIdeReady, DesktopIDE, supervisorMetrics, shouldWaitBackend, diagnostics)
 	}
 
-	var (
-		wg       sync.WaitGroup
-		shutdown = make(chan ShutdownReason, 1)
-	)
 	go func() {
 		<-cstate.ContentReady()
 		if !shouldShutdown {
@@ -445,10 +510,7 @@ func Run(options ...RunOption) {
 	}
 
 	wg.Add(1)
-	go startAPIEndpoint(ctx, cfg, &wg, apiServices, tunneledPortsService, metricsReporter, supervisorMetrics, topService, apiEndpointOpts...)
-
-	wg.Add(1)
-	go startSSHServer(ctx, cfg, &wg)
+	go startSSHServer(ctx, cfg, &wg, supervisorMetrics, health.ssh, sshSessions)
 
 	wg.Add(1)
 	tasksSuccessChan := make(chan taskSuccess, 1)
@@ -467,6 +529,18 @@ func Run(options ...RunOption) {
 		go portMgmt.Run(ctx, &wg)
 	}
 
+	if activity != nil && gitpodService != nil {
+		go activity.Run(ctx, gitpodService, portMgmt)
+	}
+
+	if !cfg.isHeadless() && !opts.RunGP && !cfg.isDebugWorkspace() {
+		go func() {
+			<-cstate.ContentReady()
+			waitForIde(ctx, ideReady, desktopIdeReady, 5*time.Minute)
+			watchDiskUsage(ctx, notificationService)
+		}()
+	}
+
 	if cfg.PreventMetadataAccess {
 		go func() {
 			if !hasMetadataAccess() {
@@ -516,6 +590,9 @@ func Run(options ...RunOption) {
 	log.Info("received SIGTERM (or shutdown) - tearing down")
 	fireWillShutdown()
 
+	// let tasks clean up, e.g. push WIP branches, while the workspace is still fully functional
+	taskManager.RunOnStop(ctx)
+
 	// wait for last git status to persist
 	stopGitStatus()
 	gitStatusWg.Wait()
@@ -572,18 +649,23 @@ func isShallowRepository(rootDir string) bool {
 	return isShallow
 }
 
-func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTokenService) {
+func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTokenService, state *dotfilesState) {
 	repo := cfg.DotfileRepo
 	if repo == "" {
 		return
 	}
 
-	const dotfilePath = "/home/gitpod/.dotfiles"
-	if _, err := os.Stat(dotfilePath); err == nil {
+	if _, err := os.Stat(dotfilesPath); err == nil {
 		// dotfile path exists already - nothing to do here
+		state.MarkInstalled()
 		return
 	}
 
+	state.MarkInstalling()
+	timeout := cfg.GetDotfileInstallTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	prep := func(cfg *Config, out io.Writer, name string, args ...string) *exec.Cmd {
 		cmd := exec.Command(name, args...)
 		cmd.Dir = "/home/gitpod"
@@ -594,7 +676,7 @@ func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTok
 	}
 
 	err := func() (err error) {
-		out, err := os.OpenFile("/home/gitpod/.dotfiles.log", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		out, err := os.OpenFile(dotfilesLogPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 		if err != nil {
 			return err
 		}
@@ -629,7 +711,7 @@ func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTok
 			client := &git.Client{
 				AuthProvider: authProvider,
 				AuthMethod:   git.BasicAuth,
-				Location:     dotfilePath,
+				Location:     dotfilesPath,
 				RemoteURI:    repo,
 			}
 			done <- client.Clone(ctx)
@@ -640,11 +722,11 @@ func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTok
 			if err != nil {
 				return err
 			}
-		case <-time.After(120 * time.Second):
-			return xerrors.Errorf("dotfiles repo clone did not finish within two minutes")
+		case <-ctx.Done():
+			return xerrors.Errorf("dotfiles repo clone did not finish within %s", timeout)
 		}
 
-		filepath.Walk(dotfilePath, func(name string, info os.FileInfo, err error) error {
+		filepath.Walk(dotfilesPath, func(name string, info os.FileInfo, err error) error {
 			if err == nil {
 				err = os.Chown(name, gitpodUID, gitpodGID)
 			}
@@ -663,7 +745,7 @@ func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTok
 			"script/setup",
 		}
 		for _, c := range candidates {
-			fn := filepath.Join(dotfilePath, c)
+			fn := filepath.Join(dotfilesPath, c)
 			stat, err := os.Stat(fn)
 			if err != nil {
 				_, _ = out.WriteString(fmt.Sprintf("# installation script candidate %s is not available\n", fn))
@@ -695,20 +777,20 @@ func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTok
 			select {
 			case err = <-done:
 				return err
-			case <-time.After(120 * time.Second):
-				cmd.Process.Kill()
-				return xerrors.Errorf("installation process %s tool longer than 120 seconds", fn)
+			case <-ctx.Done():
+				_ = cmd.Process.Kill()
+				return xerrors.Errorf("installation process %s took longer than %s", fn, timeout)
 			}
 		}
 
 		// no installation script candidate was found, let's try and symlink this stuff
-		err = filepath.Walk(dotfilePath, func(path string, info fs.FileInfo, err error) error {
+		err = filepath.Walk(dotfilesPath, func(path string, info fs.FileInfo, err error) error {
 			if strings.Contains(path, "/.git") {
 				// don't symlink the .git directory or any of its content
 				return nil
 			}
 
-			homeFN := filepath.Join("/home/gitpod", strings.TrimPrefix(path, dotfilePath))
+			homeFN := filepath.Join("/home/gitpod", strings.TrimPrefix(path, dotfilesPath))
 			if _, err := os.Stat(homeFN); err == nil {
 				// homeFN exists already - do nothing
 				return nil
@@ -731,10 +813,12 @@ func installDotfiles(ctx context.Context, cfg *Config, tokenService *InMemoryTok
 		return nil
 	}()
 	if err != nil {
-		// installing the dotfiles failed for some reason - we must tell the user
-		// TODO(cw): tell the user
+		// installing the dotfiles failed for some reason - we tell the user through the dotfiles status API
 		log.WithError(err).Warn("installing dotfiles failed")
+		state.MarkFailed(err)
+		return
 	}
+	state.MarkInstalled()
 }
 
 func createExposedPortsImpl(cfg *Config, gitpodService serverapi.APIInterface) ports.ExposedPortsInterface {
@@ -837,7 +921,7 @@ var (
 	errSignalTerminated = errors.New("signal: terminated")
 )
 
-func startAndWatchIDE(ctx context.Context, cfg *Config, ideConfig *IDEConfig, wg *sync.WaitGroup, cstate *InMemoryContentState, ideReady *ideReadyState, ide IDEKind, metrics *metrics.SupervisorMetrics, shouldWaitBackend bool) {
+func startAndWatchIDE(ctx context.Context, cfg *Config, ideConfig *IDEConfig, wg *sync.WaitGroup, cstate *InMemoryContentState, ideReady *ideReadyState, ide IDEKind, metrics *metrics.SupervisorMetrics, shouldWaitBackend bool, diagnostics *startupDiagnostics) {
 	defer wg.Done()
 	defer log.WithField("ide", ide.String()).Debug("startAndWatchIDE shutdown")
 
@@ -850,6 +934,7 @@ func startAndWatchIDE(ctx context.Context, cfg *Config, ideConfig *IDEConfig, wg
 	<-cstate.ContentReady()
 
 	ideStatus := statusNeverRan
+	phase := diagnostics.Begin(ide.String() + "-ide")
 
 	var (
 		cmd                  *exec.Cmd
@@ -867,7 +952,7 @@ supervisorLoop:
 		ideStopped = make(chan struct{}, 1)
 		startTime := time.Now()
 		cmd = prepareIDELaunch(cfg, ideConfig)
-		launchIDE(cfg, ideConfig, cmd, ideStopped, ideReady, &ideStatus, ide, shouldWaitBackend)
+		launchIDE(cfg, ideConfig, cmd, ideStopped, ideReady, &ideStatus, ide, shouldWaitBackend, phase)
 		timerAfterMaxBucket := time.NewTimer((shared.IDEReadyDurationTotalMaxBucketSecond + 1) * time.Second)
 
 		if firstStart {
@@ -943,7 +1028,7 @@ supervisorLoop:
 	}
 }
 
-func launchIDE(cfg *Config, ideConfig *IDEConfig, cmd *exec.Cmd, ideStopped chan struct{}, ideReady *ideReadyState, s *ideStatus, ide IDEKind, shouldWaitBackend bool) {
+func launchIDE(cfg *Config, ideConfig *IDEConfig, cmd *exec.Cmd, ideStopped chan struct{}, ideReady *ideReadyState, s *ideStatus, ide IDEKind, shouldWaitBackend bool, phase *startupPhase) {
 	go func() {
 		// prepareIDELaunch sets Pdeathsig, which on on Linux, will kill the
 		// child process when the thread dies, not when the process dies.
@@ -971,8 +1056,9 @@ func launchIDE(cfg *Config, ideConfig *IDEConfig, cmd *exec.Cmd, ideStopped chan
 		s = func() *ideStatus { i := statusShouldRun; return &i }()
 
 		go func() {
-			IDEStatus := runIDEReadinessProbe(cfg, ideConfig, ide)
+			IDEStatus := runIDEReadinessProbe(cfg, ideConfig, ide, phase)
 			ideReady.Set(true, IDEStatus)
+			phase.Done()
 		}()
 
 		err = cmd.Wait()
@@ -1088,6 +1174,17 @@ func buildChildProcEnv(cfg *Config, envvars []string, runGP bool, setJavaXmx boo
 		}
 	}
 
+	if names := cfg.getFileSecrets(); len(names) > 0 {
+		err := writeFileSecrets(envs, names, fileSecretsLocation)
+		if err != nil {
+			// we must not pass the secrets as environment variables when the user asked us not to
+			log.WithError(err).Error("cannot write file secrets - not passing them to child processes")
+			for _, name := range names {
+				delete(envs, name)
+			}
+		}
+	}
+
 	// We're forcing basic environment variables here, because supervisor acts like a login process at this point.
 	// The gitpod user might not have existed when supervisor was started, hence the HOME coming
 	// from the container runtime is probably wrong ("/" to be exact).
@@ -1157,7 +1254,7 @@ func downloadEnvvarOTS(url string) (res map[string]string, err error) {
 	return res, nil
 }
 
-func runIDEReadinessProbe(cfg *Config, ideConfig *IDEConfig, ide IDEKind) (desktopIDEStatus *DesktopIDEStatus) {
+func runIDEReadinessProbe(cfg *Config, ideConfig *IDEConfig, ide IDEKind, phase *startupPhase) (desktopIDEStatus *DesktopIDEStatus) {
 	defer log.WithField("ide", ide.String()).Info("IDE is ready")
 
 	defaultIfEmpty := func(value, defaultValue string) string {
@@ -1189,6 +1286,9 @@ func runIDEReadinessProbe(cfg *Config, ideConfig *IDEConfig, ide IDEKind) (deskt
 			host   = defaultIfEmpty(ideConfig.ReadinessProbe.HTTPProbe.Host, "localhost")
 			port   = defaultIfZero(ideConfig.ReadinessProbe.HTTPProbe.Port, defaultProbePort)
 			url    = fmt.Sprintf("%s://%s:%d/%s", schema, host, port, strings.TrimPrefix(ideConfig.ReadinessProbe.HTTPProbe.Path, "/"))
+
+			statusCode = defaultIfZero(ideConfig.ReadinessProbe.HTTPProbe.StatusCode, http.StatusOK)
+			timeout    = time.Duration(defaultIfZero(ideConfig.ReadinessProbe.HTTPProbe.TimeoutSeconds, 1)) * time.Second
 		)
 
 		t0 := time.Now()
@@ -1196,11 +1296,13 @@ func runIDEReadinessProbe(cfg *Config, ideConfig *IDEConfig, ide IDEKind) (deskt
 		var body []byte
 		for range time.Tick(250 * time.Millisecond) {
 			var err error
-			body, err = ideStatusRequest(url)
+			body, err = ideStatusRequest(url, statusCode, timeout)
 			if err != nil {
 				log.WithField("ide", ide.String()).WithError(err).Debug("Error running IDE readiness probe")
+				phase.SetFailure(fmt.Sprintf("readiness probe %s failed: %v", url, err))
 				continue
 			}
+			phase.SetFailure("")
 
 			break
 		}
@@ -1225,8 +1327,8 @@ func runIDEReadinessProbe(cfg *Config, ideConfig *IDEConfig, ide IDEKind) (deskt
 	return
 }
 
-func ideStatusRequest(url string) ([]byte, error) {
-	client := http.Client{Timeout: 1 * time.Second}
+func ideStatusRequest(url string, statusCode int, timeout time.Duration) ([]byte, error) {
+	client := http.Client{Timeout: timeout}
 
 	resp, err := client.Get(url)
 	if err != nil {
@@ -1234,8 +1336,8 @@ func ideStatusRequest(url string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, xerrors.Errorf("IDE readiness probe came back with non-200 status code (%v)", resp.StatusCode)
+	if resp.StatusCode != statusCode {
+		return nil, xerrors.Errorf("IDE readiness probe came back with status code %v instead of %v", resp.StatusCode, statusCode)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -1283,9 +1385,12 @@ func startAPIEndpoint(
 	wg *sync.WaitGroup,
 	services []RegisterableService,
 	tunneled *ports.TunneledPortsService,
+	metricsRegistry *prometheus.Registry,
 	metricsReporter *metrics.GrpcMetricsReporter,
 	supervisorMetrics *metrics.SupervisorMetrics,
 	topService *TopService,
+	health *healthService,
+	sshSessions *sshSessions,
 	opts ...grpc.ServerOption,
 ) {
 	defer wg.Done()
@@ -1296,6 +1401,11 @@ func startAPIEndpoint(
 		log.WithError(err).Fatal("cannot start health endpoint")
 	}
 
+	trustedProxies, err := cfg.getTrustedProxies()
+	if err != nil {
+		log.WithError(err).Error("cannot parse trusted proxies, not trusting any")
+	}
+
 	var unaryInterceptors []grpc.UnaryServerInterceptor
 	var streamInterceptors []grpc.StreamServerInterceptor
 
@@ -1304,21 +1414,19 @@ func startAPIEndpoint(
 		streamInterceptors = append(streamInterceptors, grpc_logrus.StreamServerInterceptor(log.Log))
 	}
 
-	if metricsReporter != nil {
-		grpcMetrics := grpc_prometheus.NewServerMetrics()
-		grpcMetrics.EnableHandlingTimeHistogram(
-			// it should be aligned with https://github.com/gitpod-io/gitpod/blob/196a109eee50bfb7da2c6b858a3e78f2a2d0b26f/install/installer/pkg/components/ide-metrics/configmap.go#L199
-			grpc_prometheus.WithHistogramBuckets([]float64{.005, .025, .05, .1, .5, 1, 2.5, 5, 30, 60, 120, 240, 600}),
-		)
-		unaryInterceptors = append(unaryInterceptors, grpcMetrics.UnaryServerInterceptor())
-		streamInterceptors = append(streamInterceptors, grpcMetrics.StreamServerInterceptor())
+	grpcMetrics := grpc_prometheus.NewServerMetrics()
+	grpcMetrics.EnableHandlingTimeHistogram(
+		// it should be aligned with https://github.com/gitpod-io/gitpod/blob/196a109eee50bfb7da2c6b858a3e78f2a2d0b26f/install/installer/pkg/components/ide-metrics/configmap.go#L199
+		grpc_prometheus.WithHistogramBuckets([]float64{.005, .025, .05, .1, .5, 1, 2.5, 5, 30, 60, 120, 240, 600}),
+	)
+	unaryInterceptors = append(unaryInterceptors, grpcMetrics.UnaryServerInterceptor())
+	streamInterceptors = append(streamInterceptors, grpcMetrics.StreamServerInterceptor())
 
-		err = metricsReporter.Registry.Register(grpcMetrics)
-		if err != nil {
-			log.WithError(err).Error("supervisor: failed to register grpc metrics")
-		} else {
-			go metricsReporter.Report(ctx)
-		}
+	err = metricsRegistry.Register(grpcMetrics)
+	if err != nil {
+		log.WithError(err).Error("supervisor: failed to register grpc metrics")
+	} else if metricsReporter != nil {
+		go metricsReporter.Report(ctx)
 	}
 
 	// add gprc recover, must be last, to be executed first after the rpc handler, we want upstream interceptors to have a meaningful response to work with)
@@ -1381,6 +1489,7 @@ func startAPIEndpoint(
 
 	ideURL, _ := url.Parse(fmt.Sprintf("http://localhost:%d", cfg.IDEPort))
 	routes.Handle("/", httputil.NewSingleHostReverseProxy(ideURL))
+	routes.Handle("/_supervisor/healthz", health)
 	routes.Handle("/_supervisor/frontend/", http.StripPrefix("/_supervisor/frontend", http.FileServer(http.Dir(cfg.StaticConfig.FrontendLocation))))
 
 	routes.Handle("/_supervisor/v1/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1418,7 +1527,7 @@ func startAPIEndpoint(
 			supervisorMetrics.SSHTunnelClosedTotal.WithLabelValues(code).Inc()
 		}()
 		startTime := time.Now()
-		log := log.WithField("userAgent", r.Header.Get("user-agent")).WithField("remoteAddr", r.RemoteAddr)
+		log := log.WithField("userAgent", r.Header.Get("user-agent")).WithField("remoteAddr", clientAddress(r, trustedProxies))
 		wsConn, err := upgrader.Upgrade(rw, r, nil)
 		if err != nil {
 			log.WithError(err).Error("tunnel ssh: upgrade to the WebSocket protocol failed")
@@ -1439,6 +1548,7 @@ func startAPIEndpoint(
 			log.WithError(err).Error("tunnel ssh: dial to ssh server failed")
 			return
 		}
+		defer sshSessions.tunnel(conn2.LocalAddr().String(), clientAddress(r, trustedProxies))()
 
 		go io.Copy(conn, conn2)
 		_, err = io.Copy(conn2, conn)
@@ -1524,7 +1634,7 @@ func tunnelOverSSH(ctx context.Context, tunneled *ports.TunneledPortsService, ne
 		return
 	}
 
-	tunnel, err := tunneled.EstablishTunnel(ctx, tunnelReq.ClientId, tunnelReq.Port, tunnelReq.TargetPort)
+	tunnel, err := tunneled.EstablishTunnel(ctx, tunnelReq.ClientId, tunnelReq.Port, tunnelReq.TargetPort, tunnelReq.Protocol)
 	if err != nil {
 		log.WithError(err).Error("tunnel: failed to establish")
 		_ = newCh.Reject(ssh.Prohibited, err.Error())
@@ -1542,14 +1652,26 @@ func tunnelOverSSH(ctx context.Context, tunneled *ports.TunneledPortsService, ne
 	defer sshChan.Close()
 	go ssh.DiscardRequests(reqs)
 	ctx, cancel := context.WithCancel(ctx)
-	go func() {
-		_, _ = io.Copy(sshChan, tunnel)
-		cancel()
-	}()
-	go func() {
-		_, _ = io.Copy(tunnel, sshChan)
-		cancel()
-	}()
+	if tunnelReq.Protocol == api.TunnelProtocol_udp {
+		// SSH channels are streams, hence datagrams are framed to preserve their boundaries
+		go func() {
+			_ = ports.CopyDatagramsToStream(sshChan, tunnel)
+			cancel()
+		}()
+		go func() {
+			_ = ports.CopyStreamToDatagrams(tunnel, sshChan)
+			cancel()
+		}()
+	} else {
+		go func() {
+			_, _ = io.Copy(sshChan, tunnel)
+			cancel()
+		}()
+		go func() {
+			_, _ = io.Copy(tunnel, sshChan)
+			cancel()
+		}()
+	}
 	<-ctx.Done()
 }
 
@@ -1569,7 +1691,7 @@ func stopWhenTasksAreDone(ctx context.Context, wg *sync.WaitGroup, shutdown chan
 	shutdown <- ShutdownReasonSuccess
 }
 
-func startSSHServer(ctx context.Context, cfg *Config, wg *sync.WaitGroup) {
+func startSSHServer(ctx context.Context, cfg *Config, wg *sync.WaitGroup, metrics *metrics.SupervisorMetrics, state *sshState, sessions *sshSessions) {
 	defer wg.Done()
 
 	if cfg.isHeadless() {
@@ -1577,20 +1699,47 @@ func startSSHServer(ctx context.Context, cfg *Config, wg *sync.WaitGroup) {
 	}
 
 	go func() {
-		ssh, err := newSSHServer(ctx, cfg, childProcEnvvars)
+		ssh, err := newSSHServer(ctx, cfg, childProcEnvvars, metrics, sessions)
 		if err != nil {
 			log.WithError(err).Error("err creating SSH server")
+			state.Set(false, err.Error())
 			return
 		}
 		configureSSHDefaultDir(cfg)
 		configureSSHMessageOfTheDay()
-		err = ssh.listenAndServe()
+		go syncAuthorizedKeys(ctx, workspaceSSHPublicKeysFile)
+		err = ssh.listenAndServe(func() {
+			state.Set(true, "")
+		})
 		if err != nil {
 			log.WithError(err).Error("err starting SSH server")
+			state.Set(false, err.Error())
 		}
 	}()
 }
 
+// startMetricsEndpoint serves the supervisor metrics on a local port, so that they can be scraped from within the workspace
+func startMetricsEndpoint(ctx context.Context, wg *sync.WaitGroup, registry *prometheus.Registry) {
+	defer wg.Done()
+	defer log.Debug("startMetricsEndpoint shutdown")
+
+	routes := http.NewServeMux()
+	routes.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:    fmt.Sprintf("localhost:%d", metricsPort),
+		Handler: routes,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("cannot serve metrics endpoint")
+		}
+	}()
+
+	<-ctx.Done()
+	_ = server.Close()
+}
+
 func startContentInit(ctx context.Context, cfg *Config, wg *sync.WaitGroup, cst ContentState, metrics *metrics.SupervisorMetrics) {
 	defer wg.Done()
 	defer log.Info("supervisor: workspace content available")
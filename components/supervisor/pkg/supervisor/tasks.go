@@ -12,16 +12,22 @@ import (
 	"io"
 	"math"
 	"os"
+	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/gitpod-io/gitpod/common-go/log"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/content-service/pkg/logs"
 	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/config"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/metrics"
 	"github.com/gitpod-io/gitpod/supervisor/pkg/terminal"
 )
 
@@ -70,6 +76,11 @@ type task struct {
 	successChan chan taskSuccess
 	title       string
 	lastOutput  string
+
+	// started, finished and exitCode are guarded by tasksManager.mu
+	started  time.Time
+	finished time.Time
+	exitCode *int
 }
 
 type headlessTaskProgressReporter interface {
@@ -109,9 +120,10 @@ type tasksManager struct {
 	reporter        headlessTaskProgressReporter
 	ideReady        *ideReadyState
 	desktopIdeReady *ideReadyState
+	metrics         *metrics.SupervisorMetrics
 }
 
-func newTasksManager(config *Config, terminalService *terminal.MuxTerminalService, contentState ContentState, reporter headlessTaskProgressReporter, ideReady *ideReadyState, desktopIdeReady *ideReadyState) *tasksManager {
+func newTasksManager(config *Config, terminalService *terminal.MuxTerminalService, contentState ContentState, reporter headlessTaskProgressReporter, ideReady *ideReadyState, desktopIdeReady *ideReadyState, metrics *metrics.SupervisorMetrics) *tasksManager {
 	return &tasksManager{
 		config:          config,
 		terminalService: terminalService,
@@ -122,6 +134,7 @@ func newTasksManager(config *Config, terminalService *terminal.MuxTerminalServic
 		storeLocation:   logs.TerminalStoreLocation,
 		ideReady:        ideReady,
 		desktopIdeReady: desktopIdeReady,
+		metrics:         metrics,
 	}
 }
 
@@ -169,10 +182,18 @@ func (tm *tasksManager) setTaskState(t *task, newState api.TaskState) {
 		}
 
 		t.State = newState
+		tm.reportTaskState(newState)
 		return true
 	})
 }
 
+func (tm *tasksManager) reportTaskState(state api.TaskState) {
+	if tm.metrics == nil {
+		return
+	}
+	tm.metrics.TaskStateTotal.WithLabelValues(state.String()).Inc()
+}
+
 func (tm *tasksManager) init(ctx context.Context) {
 	defer close(tm.ready)
 
@@ -222,6 +243,10 @@ func (tm *tasksManager) init(ctx context.Context) {
 			title:       presentation.Name,
 		}
 		task.command = getCommand(task, tm.config.isHeadless(), tm.config.isPrebuild(), tm.contentSource, tm.storeLocation)
+		if err := checkTaskVariables(config, tm.terminalService.Env); err != nil {
+			log.WithError(err).WithField("task", presentation.Name).Warn("task references undefined variables")
+			task.command = getTaskErrorCommand(presentation.Name, err, tm.config.isHeadless())
+		}
 		if tm.config.isHeadless() && task.command == "exit" {
 			task.State = api.TaskState_closed
 			task.successChan <- taskSuccessful
@@ -264,24 +289,8 @@ func (tm *tasksManager) Run(ctx context.Context, wg *sync.WaitGroup, successChan
 		}
 		taskLog := log.WithField("command", t.command)
 		taskLog.Info("starting a task terminal...")
-		openRequest := &api.OpenTerminalRequest{}
-		if t.config.Env != nil {
-			openRequest.Env = make(map[string]string, len(*t.config.Env))
-			for key, value := range *t.config.Env {
-				// Required check because a string is considered valid JSON (e.g. "hello")
-				// We don't want to marshall basic strings otherwise we get a double quoted environment variable
-				// See: https://github.com/gitpod-io/gitpod/issues/5887
-				if val, ok := value.(string); ok {
-					openRequest.Env[key] = val
-				} else {
-					v, err := json.Marshal(value)
-					if err != nil {
-						taskLog.WithError(err).WithField("key", key).Error("cannot marshal env var")
-					} else {
-						openRequest.Env[key] = string(v)
-					}
-				}
-			}
+		openRequest := &api.OpenTerminalRequest{
+			Env: getTaskEnv(t.config, taskLog),
 		}
 		resp, err := tm.terminalService.OpenWithOptions(ctx, openRequest, terminal.TermOptions{
 			ReadTimeout: 5 * time.Second,
@@ -308,11 +317,21 @@ func (tm *tasksManager) Run(ctx context.Context, wg *sync.WaitGroup, successChan
 		tm.updateState(func() bool {
 			t.Terminal = resp.Terminal.Alias
 			t.State = api.TaskState_running
+			t.started = time.Now()
+			tm.reportTaskState(api.TaskState_running)
 			return true
 		})
 
 		go func(t *task, term *terminal.Term) {
 			state, err := term.Wait()
+			tm.updateState(func() bool {
+				t.finished = time.Now()
+				if state != nil {
+					exitCode := state.ExitCode()
+					t.exitCode = &exitCode
+				}
+				return false
+			})
 			if state != nil {
 				if state.Success() {
 					t.successChan <- taskSuccessful
@@ -340,20 +359,28 @@ func (tm *tasksManager) Run(ctx context.Context, wg *sync.WaitGroup, successChan
 		}
 	}
 
-	var success taskSuccess
-	for _, task := range tm.tasks {
+	var (
+		success taskSuccess
+		results = make([]taskSuccess, len(tm.tasks))
+	)
+	for i, task := range tm.tasks {
 		select {
 		case <-ctx.Done():
 			success = taskFailed(ctx.Err().Error())
+			results[i] = success
 		case taskResult := <-task.successChan:
 			if taskResult.Failed() {
 				success = success.Fail(string(taskResult))
 			}
+			results[i] = taskResult
 		}
 	}
 
-	if tm.config.isPrebuild() && tm.reporter != nil {
-		tm.reporter.done(success)
+	if tm.config.isPrebuild() {
+		tm.writePrebuildSummary(results)
+		if tm.reporter != nil {
+			tm.reporter.done(success)
+		}
 	}
 	successChan <- success
 }
@@ -385,6 +412,69 @@ func getCommand(task *task, isHeadless bool, isPrebuild bool, contentSource csap
 	return histfileCommand + "; " + command
 }
 
+// shellAssignmentRegexp matches variables a shell command assigns or exports, e.g. NAME=value, export NAME or read NAME
+var shellAssignmentRegexp = regexp.MustCompile(`(?:^|[\s;&|(])(?:(?:export|declare|typeset|local|readonly|read)(?:\s+-\w+)*\s+([A-Za-z_][A-Za-z0-9_]*)|([A-Za-z_][A-Za-z0-9_]*)\+?=)`)
+
+// checkTaskVariables fails if a command of the task references an undefined ${GITPOD_*} variable.
+// The references are expanded by the shell rather than spliced into the commands by supervisor, so that the values
+// are not interpreted as shell syntax. Only variables which the workspace config injects are checked: variables
+// the commands of the task define themselves, or which don't start with GITPOD_, are left to the shell.
+func checkTaskVariables(task TaskConfig, env []string) error {
+	commands := []struct {
+		Name  string
+		Value *string
+	}{
+		{"before", task.Before},
+		{"init", task.Init},
+		{"prebuild", task.Prebuild},
+		{"command", task.Command},
+	}
+
+	assigned := make(map[string]struct{})
+	for _, command := range commands {
+		if command.Value == nil {
+			continue
+		}
+		for _, m := range shellAssignmentRegexp.FindAllStringSubmatch(*command.Value, -1) {
+			assigned[m[1]+m[2]] = struct{}{}
+		}
+	}
+
+	lookupEnv := config.EnvLookup(env)
+	taskEnv := getTaskEnv(task, log.Log)
+	lookup := func(name string) (string, bool) {
+		if _, ok := taskEnv[name]; ok {
+			return "", true
+		}
+		if _, ok := lookupEnv(name); ok {
+			return "", true
+		}
+		if _, ok := assigned[name]; ok {
+			return "", true
+		}
+		return "", !strings.HasPrefix(name, "GITPOD_")
+	}
+	for _, command := range commands {
+		if command.Value == nil {
+			continue
+		}
+		if _, err := config.ExpandEnv(*command.Value, lookup); err != nil {
+			return fmt.Errorf("%s: %w", command.Name, err)
+		}
+	}
+	return nil
+}
+
+// getTaskErrorCommand prints why a task cannot run in its terminal and fails.
+func getTaskErrorCommand(name string, err error, isHeadless bool) string {
+	msg := fmt.Sprintf("%s: %v", name, err)
+	command := "echo '" + strings.ReplaceAll(msg, "'", `'\''`) + "' >&2; false"
+	if isHeadless {
+		return command + "; exit"
+	}
+	return command
+}
+
 func getHistfileCommand(task *task, commands []*string, contentSource csapi.WorkspaceInitSource, storeLocation string) string {
 	histfileCommands := commands
 	if contentSource == csapi.WorkspaceInitFromPrebuild {
@@ -434,6 +524,56 @@ func prebuildLogFileName(task *task, storeLocation string) string {
 	return logs.PrebuildLogFileName(storeLocation, task.Id)
 }
 
+// writePrebuildSummary persists the exit codes and durations of all tasks next to their logs,
+// so that they are uploaded together with the logs once the prebuild is done.
+func (tm *tasksManager) writePrebuildSummary(results []taskSuccess) {
+	tm.mu.RLock()
+	summary := prebuildSummary(tm.tasks, results, time.Now())
+	tm.mu.RUnlock()
+
+	for _, t := range summary.Tasks {
+		log.WithField("task", t.ID).WithField("exitCode", t.ExitCode).WithField("failure", t.Failure).WithField("duration", t.DurationSeconds).Info("prebuild task summary")
+	}
+
+	fn := logs.PrebuildSummaryFileName(tm.storeLocation)
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		log.WithError(err).Error("cannot marshal prebuild summary")
+		return
+	}
+	err = os.WriteFile(fn, content, 0644)
+	if err != nil {
+		log.WithError(err).WithField("file", fn).Error("cannot write prebuild summary")
+	}
+}
+
+// prebuildSummary summarizes the tasks given their results. Callers are expected to hold mu.
+func prebuildSummary(tasks []*task, results []taskSuccess, now time.Time) *logs.PrebuildSummary {
+	res := &logs.PrebuildSummary{Tasks: make([]logs.PrebuildTaskSummary, 0, len(tasks))}
+	for i, t := range tasks {
+		s := logs.PrebuildTaskSummary{
+			ID:       t.Id,
+			Name:     t.title,
+			ExitCode: t.exitCode,
+		}
+		if i < len(results) {
+			s.Failure = string(results[i])
+		}
+		if !t.started.IsZero() {
+			started := t.started
+			s.StartedAt = &started
+
+			finished := t.finished
+			if finished.IsZero() {
+				finished = now
+			}
+			s.DurationSeconds = finished.Sub(started).Seconds()
+		}
+		res.Tasks = append(res.Tasks, s)
+	}
+	return res
+}
+
 func (tm *tasksManager) watch(task *task, term *terminal.Term) {
 	if !tm.config.isPrebuild() {
 		return
@@ -551,6 +691,111 @@ func importParentLogAndGetDuration(fn string, out io.Writer) time.Duration {
 	return time.Duration(elapsedInMinutes) * time.Minute
 }
 
+func getTaskEnv(config TaskConfig, taskLog *logrus.Entry) map[string]string {
+	if config.Env == nil {
+		return nil
+	}
+	env := make(map[string]string, len(*config.Env))
+	for key, value := range *config.Env {
+		// Required check because a string is considered valid JSON (e.g. "hello")
+		// We don't want to marshall basic strings otherwise we get a double quoted environment variable
+		// See: https://github.com/gitpod-io/gitpod/issues/5887
+		if val, ok := value.(string); ok {
+			env[key] = val
+		} else {
+			v, err := json.Marshal(value)
+			if err != nil {
+				taskLog.WithError(err).WithField("key", key).Error("cannot marshal env var")
+			} else {
+				env[key] = string(v)
+			}
+		}
+	}
+	return env
+}
+
+const (
+	defaultOnStopTimeout = 10 * time.Second
+	maxOnStopTimeout     = 5 * time.Minute
+)
+
+// getOnStopTimeout returns how long the onStop command of a task may run.
+func getOnStopTimeout(config TaskConfig) time.Duration {
+	if config.OnStopTimeout == nil || *config.OnStopTimeout == "" {
+		return defaultOnStopTimeout
+	}
+	timeout, err := time.ParseDuration(*config.OnStopTimeout)
+	if err != nil || timeout <= 0 {
+		log.WithField("onStopTimeout", *config.OnStopTimeout).Warn("invalid onStop timeout, using the default")
+		return defaultOnStopTimeout
+	}
+	if timeout > maxOnStopTimeout {
+		return maxOnStopTimeout
+	}
+	return timeout
+}
+
+// RunOnStop runs the onStop commands of all tasks in parallel and waits until they're done or exceeded their deadline.
+// It's called once a stop was initiated, before the IDE and the task terminals are shut down.
+func (tm *tasksManager) RunOnStop(ctx context.Context) {
+	if tm.config.isHeadless() {
+		return
+	}
+	select {
+	case <-tm.ready:
+	default:
+		// tasks were never started, hence there is nothing to clean up
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range tm.tasks {
+		if t.config.OnStop == nil || strings.TrimSpace(*t.config.OnStop) == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(t *task) {
+			defer wg.Done()
+			tm.runOnStop(ctx, t)
+		}(t)
+	}
+	wg.Wait()
+}
+
+func (tm *tasksManager) runOnStop(ctx context.Context, t *task) {
+	timeout := getOnStopTimeout(t.config)
+	taskLog := log.WithField("task", t.title).WithField("timeout", timeout.String())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := runAsGitpodUser(exec.CommandContext(ctx, "/bin/bash", "-c", *t.config.OnStop))
+	for key, value := range getTaskEnv(t.config, taskLog) {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Dir = tm.config.WorkspaceRoot
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// once the deadline is exceeded we kill everything the command started, not just the shell
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	taskLog.Info("running onStop command")
+	start := time.Now()
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		taskLog.Warn("onStop command did not finish in time and was killed")
+	case err != nil:
+		taskLog.WithError(err).Warn("onStop command failed")
+	default:
+		taskLog.WithField("duration", time.Since(start).String()).Info("onStop command finished")
+	}
+}
+
 type composeCommandOptions struct {
 	commands []*string
 	format   string
@@ -11,13 +11,16 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/logs"
 	"github.com/gitpod-io/gitpod/supervisor/api"
+	"github.com/gitpod-io/gitpod/supervisor/pkg/metrics"
 	"github.com/gitpod-io/gitpod/supervisor/pkg/terminal"
 )
 
@@ -43,6 +46,9 @@ var (
 	testBooleanEnvCommand = `test "$BOOLEAN_ENV_VAR" == false`
 	testNullEnvCommand    = `test "$NULL_ENV_VAR" == null`
 	testNumberEnvCommand  = `test "$NUMBER_ENV_VAR" == 10`
+
+	testDefinedVariableCommand   = `test "${GITPOD_TEST_VAR}" == defined`
+	testUndefinedVariableCommand = `echo "${GITPOD_UNDEFINED_TEST_VAR}"`
 )
 
 func TestTaskManager(t *testing.T) {
@@ -197,6 +203,28 @@ func TestTaskManager(t *testing.T) {
 				Success: true,
 			},
 		},
+		{
+			Desc:        "Defined variable is expanded",
+			Headless:    true,
+			Source:      csapi.WorkspaceInitFromOther,
+			GitpodTasks: &[]TaskConfig{{Init: &testDefinedVariableCommand, Env: &map[string]interface{}{"GITPOD_TEST_VAR": "defined"}}},
+
+			ExpectedReporter: testHeadlessTaskProgressReporter{
+				Done:    true,
+				Success: true,
+			},
+		},
+		{
+			Desc:        "Undefined variable fails the task",
+			Headless:    true,
+			Source:      csapi.WorkspaceInitFromOther,
+			GitpodTasks: &[]TaskConfig{{Init: &testUndefinedVariableCommand}},
+
+			ExpectedReporter: testHeadlessTaskProgressReporter{
+				Done:    true,
+				Success: false,
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.Desc, func(t *testing.T) {
@@ -224,7 +252,7 @@ func TestTaskManager(t *testing.T) {
 						GitpodTasks:    gitpodTasks,
 						GitpodHeadless: strconv.FormatBool(test.Headless),
 					},
-				}, terminalService, contentState, &reporter, nil, nil)
+				}, terminalService, contentState, &reporter, nil, nil, metrics.NewMetrics())
 			)
 			taskManager.storeLocation = storeLocation
 			contentState.MarkContentReady(test.Source)
@@ -306,6 +334,51 @@ func TestGetTask(t *testing.T) {
 	}
 }
 
+func TestCheckTaskVariables(t *testing.T) {
+	var (
+		env          = []string{"GITPOD_WORKSPACE_ID=ws-id", "USER_VAR=value"}
+		defined      = `echo "${GITPOD_WORKSPACE_ID} ${USER_VAR} ${GITPOD_TASK_VAR}"`
+		shellDefined = `LOCAL_VAR=1; echo "${LOCAL_VAR} $GITPOD_UNDEFINED ${GITPOD_UNDEFINED:-default}"`
+		undefined    = `echo "${GITPOD_UNDEFINED}"`
+		exported     = `export GITPOD_EXPORTED="$(date)"; GITPOD_ASSIGNED=1 ./build.sh; read -r GITPOD_READ < file`
+		exportedUse  = `echo "${GITPOD_EXPORTED} ${GITPOD_ASSIGNED} ${GITPOD_READ}"`
+	)
+	tests := []struct {
+		Desc        string
+		Task        TaskConfig
+		Expectation string
+	}{
+		{
+			Desc: "defined variables",
+			Task: TaskConfig{Init: &defined, Command: &defined, Env: &map[string]interface{}{"GITPOD_TASK_VAR": "value"}},
+		},
+		{
+			Desc: "variables left to the shell",
+			Task: TaskConfig{Command: &shellDefined},
+		},
+		{
+			Desc: "variables exported by the task's commands",
+			Task: TaskConfig{Before: &exported, Command: &exportedUse},
+		},
+		{
+			Desc:        "undefined variable",
+			Task:        TaskConfig{Init: &skipCommand, Command: &undefined},
+			Expectation: "command: undefined variable ${GITPOD_UNDEFINED}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			var act string
+			if err := checkTaskVariables(test.Task, env); err != nil {
+				act = err.Error()
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected error (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestTaskSuccess(t *testing.T) {
 	type Expectation struct {
 		Failed bool
@@ -351,3 +424,57 @@ func TestTaskSuccess(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOnStopTimeout(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		Name        string
+		Timeout     *string
+		Expectation time.Duration
+	}{
+		{Name: "not set", Expectation: defaultOnStopTimeout},
+		{Name: "empty", Timeout: strPtr(""), Expectation: defaultOnStopTimeout},
+		{Name: "valid", Timeout: strPtr("30s"), Expectation: 30 * time.Second},
+		{Name: "invalid", Timeout: strPtr("soon"), Expectation: defaultOnStopTimeout},
+		{Name: "negative", Timeout: strPtr("-1m"), Expectation: defaultOnStopTimeout},
+		{Name: "exceeds max", Timeout: strPtr("1h"), Expectation: maxOnStopTimeout},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := getOnStopTimeout(TaskConfig{OnStop: strPtr("git push"), OnStopTimeout: test.Timeout})
+			if act != test.Expectation {
+				t.Errorf("unexpected timeout: expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestPrebuildSummary(t *testing.T) {
+	var (
+		now      = time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+		started  = now.Add(-2 * time.Minute)
+		finished = now.Add(-1 * time.Minute)
+		zero     = 0
+		one      = 1
+	)
+	tasks := []*task{
+		{TaskStatus: api.TaskStatus{Id: "0"}, title: "build", started: started, finished: finished, exitCode: &zero},
+		{TaskStatus: api.TaskStatus{Id: "1"}, title: "test", started: started, finished: finished, exitCode: &one},
+		{TaskStatus: api.TaskStatus{Id: "2"}, title: "watch", started: started},
+		{TaskStatus: api.TaskStatus{Id: "3"}, title: "skipped"},
+	}
+	results := []taskSuccess{taskSuccessful, taskFailed("exit status 1"), taskFailed("context canceled"), taskSuccessful}
+
+	act := prebuildSummary(tasks, results, now)
+
+	expectation := &logs.PrebuildSummary{Tasks: []logs.PrebuildTaskSummary{
+		{ID: "0", Name: "build", ExitCode: &zero, StartedAt: &started, DurationSeconds: 60},
+		{ID: "1", Name: "test", ExitCode: &one, Failure: "exit status 1", StartedAt: &started, DurationSeconds: 60},
+		{ID: "2", Name: "watch", Failure: "context canceled", StartedAt: &started, DurationSeconds: 120},
+		{ID: "3", Name: "skipped"},
+	}}
+	if diff := cmp.Diff(expectation, act); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	linuxproc "github.com/c9s/goprocinfo/linux"
@@ -87,8 +88,27 @@ func calcSeverity(value int64) api.ResourceStatusSeverity {
 	}
 }
 
+// workspaceContentLocation is where the workspace content volume is mounted. Its quota is the workspace disk limit.
+const workspaceContentLocation = "/workspace"
+
 // Top provides workspace resources status information.
 func Top(ctx context.Context) (*api.ResourcesStatusResponse, error) {
+	res, err := topCPUAndMemory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// not all workspaces have a quota on their content, hence we don't fail if we can't tell the disk usage
+	disk, err := resolveDiskStatus(workspaceContentLocation)
+	if err != nil {
+		log.WithError(err).Debug("cannot resolve disk status")
+		return res, nil
+	}
+	res.Disk = disk
+	return res, nil
+}
+
+func topCPUAndMemory(ctx context.Context) (*api.ResourcesStatusResponse, error) {
 	const socketFN = "/.supervisor/info.sock"
 
 	if _, err := os.Stat(socketFN); os.IsNotExist(err) {
@@ -142,6 +162,27 @@ func Top(ctx context.Context) (*api.ResourcesStatusResponse, error) {
 	}
 }
 
+func resolveDiskStatus(location string) (*api.ResourceStatus, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(location, &stat)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to statfs %s: %w", location, err)
+	}
+	if stat.Blocks == 0 {
+		return nil, xerrors.Errorf("%s has no size", location)
+	}
+
+	limit := int64(stat.Blocks) * int64(stat.Bsize)
+	used := int64(stat.Blocks-stat.Bfree) * int64(stat.Bsize)
+	diskPercentage := int64((float64(used) / float64(limit)) * 100)
+
+	return &api.ResourceStatus{
+		Limit:    limit,
+		Used:     used,
+		Severity: calcSeverity(diskPercentage),
+	}, nil
+}
+
 func resolveMemoryStatus() (*api.ResourceStatus, error) {
 	memory := cgroups.NewMemoryController("/sys/fs/cgroup")
 
@@ -11,11 +11,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/creack/pty"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -52,6 +55,9 @@ type MuxTerminalService struct {
 	Env          []string
 	DefaultCreds *syscall.Credential
 
+	// OnInput is called whenever input is written to a terminal
+	OnInput func()
+
 	api.UnimplementedTerminalServiceServer
 }
 
@@ -87,6 +93,9 @@ func (srv *MuxTerminalService) OpenWithOptions(ctx context.Context, req *api.Ope
 		}
 	}
 	if req.Workdir != "" {
+		if stat, err := os.Stat(req.Workdir); err != nil || !stat.IsDir() {
+			return nil, status.Errorf(codes.InvalidArgument, "workdir %s is not a directory", req.Workdir)
+		}
 		cmd.Dir = req.Workdir
 	} else if srv.DefaultWorkdirProvider != nil {
 		cmd.Dir = srv.DefaultWorkdirProvider()
@@ -96,6 +105,9 @@ func (srv *MuxTerminalService) OpenWithOptions(ctx context.Context, req *api.Ope
 	}
 	cmd.Env = append(srv.Env, "TERM=xterm-256color")
 	for key, value := range req.Env {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid environment variable name %q", key)
+		}
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%v=%v", key, value))
 	}
 	for k, v := range req.Annotations {
@@ -240,13 +252,21 @@ func (srv *MuxTerminalService) Listen(req *api.ListenTerminalRequest, resp api.T
 			messages <- &api.ListenTerminalResponse{Output: &api.ListenTerminalResponse_Data{Data: buf[:n]}}
 		}
 
+		// a process exiting with a non-zero exit code yields an error as well
 		state, err := term.Wait()
-		if err != nil {
+		if state == nil {
+			if err == nil {
+				err = xerrors.Errorf("terminal process did not start")
+			}
 			errchan <- err
 			return
 		}
 
-		messages <- &api.ListenTerminalResponse{Output: &api.ListenTerminalResponse_ExitCode{ExitCode: int32(state.ExitCode())}}
+		exitStatus := getExitStatus(state)
+		messages <- &api.ListenTerminalResponse{
+			Output:     &api.ListenTerminalResponse_ExitCode{ExitCode: exitStatus.ExitCode},
+			ExitStatus: exitStatus,
+		}
 		errchan <- io.EOF
 	}()
 	go func() {
@@ -289,6 +309,17 @@ func (srv *MuxTerminalService) Listen(req *api.ListenTerminalRequest, resp api.T
 	}
 }
 
+func getExitStatus(state *os.ProcessState) *api.TerminalExitStatus {
+	res := &api.TerminalExitStatus{ExitCode: int32(state.ExitCode())}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		res.Signal = unix.SignalName(ws.Signal())
+		if res.Signal == "" {
+			res.Signal = ws.Signal().String()
+		}
+	}
+	return res
+}
+
 // Write writes to a terminal.
 func (srv *MuxTerminalService) Write(ctx context.Context, req *api.WriteTerminalRequest) (*api.WriteTerminalResponse, error) {
 	srv.Mux.mu.RLock()
@@ -302,6 +333,9 @@ func (srv *MuxTerminalService) Write(ctx context.Context, req *api.WriteTerminal
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	if n > 0 && srv.OnInput != nil {
+		srv.OnInput()
+	}
 	return &api.WriteTerminalResponse{BytesWritten: uint32(n)}, nil
 }
 
@@ -314,6 +348,10 @@ func (srv *MuxTerminalService) SetSize(ctx context.Context, req *api.SetTerminal
 		return nil, status.Error(codes.NotFound, "terminal not found")
 	}
 
+	if req.Size == nil || req.Size.Rows == 0 || req.Size.Cols == 0 {
+		return nil, status.Error(codes.InvalidArgument, "size must have rows and cols")
+	}
+
 	// Setting the size only works with the starter token or when forcing it.
 	// This protects us from multiple listener mangling the terminal.
 	if !(req.GetForce() || req.GetToken() == term.StarterToken) {
@@ -49,6 +49,10 @@ type Mux struct {
 	aliases []string
 	terms   map[string]*Term
 	mu      sync.RWMutex
+
+	// OnOutput is called when a terminal produces output, including the terminals of tasks. It is called at most
+	// every 10 seconds per terminal.
+	OnOutput func()
 }
 
 // Get returns a terminal for the given alias.
@@ -71,7 +75,7 @@ func (m *Mux) Start(cmd *exec.Cmd, options TermOptions) (alias string, err error
 	}
 	alias = uid.String()
 
-	term, err := newTerm(alias, cmd, options)
+	term, err := newTerm(alias, cmd, options, m.OnOutput)
 	if err != nil {
 		return "", err
 	}
@@ -160,7 +164,7 @@ func (m *Mux) doClose(ctx context.Context, alias string) error {
 // For now we assume an average of five terminals per workspace, which makes this consume 1MiB of RAM.
 const terminalBacklogSize = 256 << 10
 
-func newTerm(alias string, cmd *exec.Cmd, options TermOptions) (*Term, error) {
+func newTerm(alias string, cmd *exec.Cmd, options TermOptions, onOutput func()) (*Term, error) {
 	token, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -265,6 +269,7 @@ func newTerm(alias string, cmd *exec.Cmd, options TermOptions) (*Term, error) {
 			recorder:  recorder,
 			logStdout: options.LogToStdout,
 			logLabel:  alias,
+			onOutput:  onOutput,
 		},
 		annotations:  annotations,
 		defaultTitle: options.Title,
@@ -454,8 +459,14 @@ type multiWriter struct {
 
 	logStdout bool
 	logLabel  string
+
+	onOutput     func()
+	lastOnOutput time.Time
 }
 
+// onOutputInterval throttles the OnOutput hook, which would otherwise run on every write of a busy terminal
+const onOutputInterval = 10 * time.Second
+
 var (
 	// ErrNotFound means the terminal was not found.
 	ErrNotFound = errors.New("not found")
@@ -584,6 +595,12 @@ func (mw *multiWriter) Write(p []byte) (n int, err error) {
 	defer mw.mu.Unlock()
 
 	mw.recorder.Write(p)
+	if len(p) > 0 && mw.onOutput != nil {
+		if now := time.Now(); now.Sub(mw.lastOnOutput) >= onOutputInterval {
+			mw.lastOnOutput = now
+			mw.onOutput()
+		}
+	}
 	if mw.logStdout {
 		log.WithFields(logrus.Fields{
 			"terminalOutput": true,
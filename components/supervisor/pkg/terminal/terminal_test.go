@@ -14,9 +14,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/creack/pty"
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	"github.com/gitpod-io/gitpod/supervisor/api"
 )
@@ -322,6 +326,52 @@ func TestConcurrent(t *testing.T) {
 	}
 }
 
+func TestOnOutput(t *testing.T) {
+	outputs := make(chan struct{}, 1)
+	terminals := NewMux()
+	terminals.OnOutput = func() {
+		select {
+		case outputs <- struct{}{}:
+		default:
+		}
+	}
+	defer terminals.Close(context.Background())
+
+	_, err := terminals.Start(exec.Command("/bin/sh", "-c", "echo build output; sleep 5"), TermOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-outputs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("output of the terminal was not observed")
+	}
+}
+
+func TestOnOutputThrottled(t *testing.T) {
+	recorder, err := NewRingBuffer(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	mw := &multiWriter{
+		listener: make(map[*multiWriterListener]struct{}),
+		recorder: recorder,
+		onOutput: func() { calls++ },
+	}
+
+	for i := 0; i < 100; i++ {
+		_, err := mw.Write([]byte("watching for changes\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected OnOutput to be called once, got %d calls", calls)
+	}
+}
+
 func TestWorkDirProvider(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -391,3 +441,113 @@ func TestWorkDirProvider(t *testing.T) {
 		expectedWorkDir: providedWorkDir,
 	})
 }
+
+func TestOpenInvalidRequest(t *testing.T) {
+	tests := []struct {
+		Desc string
+		Req  *api.OpenTerminalRequest
+	}{
+		{
+			Desc: "missing workdir",
+			Req:  &api.OpenTerminalRequest{Workdir: "/does/not/exist"},
+		},
+		{
+			Desc: "invalid env name",
+			Req:  &api.OpenTerminalRequest{Env: map[string]string{"FOO=BAR": "baz"}},
+		},
+		{
+			Desc: "empty env name",
+			Req:  &api.OpenTerminalRequest{Env: map[string]string{"": "baz"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			mux := NewMux()
+			defer mux.Close(ctx)
+
+			terminalService := NewMuxTerminalService(mux)
+			_, err := terminalService.Open(ctx, test.Req)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSetSize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mux := NewMux()
+	defer mux.Close(ctx)
+
+	terminalService := NewMuxTerminalService(mux)
+	terminalService.DefaultWorkdir = t.TempDir()
+	resp, err := terminalService.Open(ctx, &api.OpenTerminalRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alias := resp.Terminal.Alias
+
+	_, err = terminalService.SetSize(ctx, &api.SetTerminalSizeRequest{Alias: alias, Priority: &api.SetTerminalSizeRequest_Force{Force: true}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without size, got %v", err)
+	}
+
+	size := &api.TerminalSize{Rows: 42, Cols: 120}
+	_, err = terminalService.SetSize(ctx, &api.SetTerminalSizeRequest{Alias: alias, Size: size})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition without token, got %v", err)
+	}
+
+	_, err = terminalService.SetSize(ctx, &api.SetTerminalSizeRequest{Alias: alias, Size: size, Priority: &api.SetTerminalSizeRequest_Token{Token: resp.StarterToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	term, _ := mux.Get(alias)
+	rows, cols, err := pty.Getsize(term.PTY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 42 || cols != 120 {
+		t.Errorf("unexpected size: expected 42x120, got %dx%d", rows, cols)
+	}
+}
+
+func TestGetExitStatus(t *testing.T) {
+	tests := []struct {
+		Desc        string
+		Command     string
+		Expectation *api.TerminalExitStatus
+	}{
+		{
+			Desc:        "success",
+			Command:     "exit 0",
+			Expectation: &api.TerminalExitStatus{},
+		},
+		{
+			Desc:        "failure",
+			Command:     "exit 3",
+			Expectation: &api.TerminalExitStatus{ExitCode: 3},
+		},
+		{
+			Desc:        "signaled",
+			Command:     "kill -9 $$",
+			Expectation: &api.TerminalExitStatus{ExitCode: -1, Signal: "SIGKILL"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			cmd := exec.Command("/bin/sh", "-c", test.Command)
+			_ = cmd.Run()
+
+			act := getExitStatus(cmd.ProcessState)
+			if diff := cmp.Diff(test.Expectation, act, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected exit status (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
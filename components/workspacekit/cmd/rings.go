@@ -214,10 +214,6 @@ var ring1Cmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		mapping := []*daemonapi.WriteIDMappingRequest_Mapping{
-			{ContainerId: 0, HostId: 33333, Size: 1},
-			{ContainerId: 1, HostId: 100000, Size: 65534},
-		}
 		if !ring1Opts.MappingEstablished {
 			client, err := connectToInWorkspaceDaemonService(ctx)
 			if err != nil {
@@ -226,12 +222,12 @@ var ring1Cmd = &cobra.Command{
 			}
 			defer client.Close()
 
-			_, err = client.WriteIDMapping(ctx, &daemonapi.WriteIDMappingRequest{Pid: int64(os.Getpid()), Gid: false, Mapping: mapping})
+			err = establishIDMapping(ctx, client, false)
 			if err != nil {
 				log.WithError(err).Error("cannot establish UID mapping")
 				return
 			}
-			_, err = client.WriteIDMapping(ctx, &daemonapi.WriteIDMappingRequest{Pid: int64(os.Getpid()), Gid: true, Mapping: mapping})
+			err = establishIDMapping(ctx, client, true)
 			if err != nil {
 				log.WithError(err).Error("cannot establish GID mapping")
 				return
@@ -952,6 +948,36 @@ type ringSyncMsg struct {
 	FSShift api.FSShiftMethod `json:"fsshift"`
 }
 
+// defaultIDMapping is the mapping ws-daemon uses unless it is configured otherwise
+var defaultIDMapping = []*daemonapi.WriteIDMappingRequest_Mapping{
+	{ContainerId: 0, HostId: 33333, Size: 1},
+	{ContainerId: 1, HostId: 100000, Size: 65534},
+}
+
+// establishIDMapping asks ws-daemon to write the mapping it is configured with, such that nodes can use non-standard
+// ID ranges. ws-daemons which predate configurable mappings don't fill in the mapping, hence we fall back to the
+// default mapping if ws-daemon did not write one.
+func establishIDMapping(ctx context.Context, client *inWorkspaceServiceClient, gid bool) error {
+	_, err := client.WriteIDMapping(ctx, &daemonapi.WriteIDMappingRequest{Pid: int64(os.Getpid()), Gid: gid})
+	if err == nil && idMappingWritten(gid) {
+		return nil
+	}
+	log.WithError(err).WithField("gid", gid).Warn("ws-daemon did not write its ID mapping, falling back to the default mapping")
+
+	_, err = client.WriteIDMapping(ctx, &daemonapi.WriteIDMappingRequest{Pid: int64(os.Getpid()), Gid: gid, Mapping: defaultIDMapping})
+	return err
+}
+
+// idMappingWritten returns true if the UID (or GID) mapping of this process is established
+func idMappingWritten(gid bool) bool {
+	fn := "/proc/self/uid_map"
+	if gid {
+		fn = "/proc/self/gid_map"
+	}
+	content, err := ioutil.ReadFile(fn)
+	return err == nil && len(bytes.TrimSpace(content)) > 0
+}
+
 type inWorkspaceServiceClient struct {
 	daemonapi.InWorkspaceServiceClient
 
@@ -1072,6 +1098,34 @@ func (svc *workspaceInfoService) WorkspaceInfo(ctx context.Context, req *api.Wor
 	return resp, nil
 }
 
+func (svc *workspaceInfoService) WatchDiskUsage(req *api.WatchDiskUsageRequest, srv api.WorkspaceInfoService_WatchDiskUsageServer) error {
+	ctx := srv.Context()
+	client, err := connectToInWorkspaceDaemonService(ctx)
+	if err != nil {
+		log.WithError(err).Error("could not connect to workspace daemon")
+		return status.Error(codes.Internal, "could not watch disk usage")
+	}
+	defer client.Close()
+
+	usage, err := client.WatchDiskUsage(ctx, &api.WatchDiskUsageRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := usage.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		err = srv.Send(resp)
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(ring0Cmd)
 	rootCmd.AddCommand(ring1Cmd)
@@ -304,3 +304,470 @@ var WorkspaceContentService_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "daemon.proto",
 }
+
+// ContentProgressServiceClient is the client API for ContentProgressService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ContentProgressServiceClient interface {
+	// WatchContentProgress streams the progress of content restores and backups of a workspace.
+	// The last known progress of each operation is sent first. The stream ends when the client cancels it.
+	WatchContentProgress(ctx context.Context, in *WatchContentProgressRequest, opts ...grpc.CallOption) (ContentProgressService_WatchContentProgressClient, error)
+}
+
+type contentProgressServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewContentProgressServiceClient(cc grpc.ClientConnInterface) ContentProgressServiceClient {
+	return &contentProgressServiceClient{cc}
+}
+
+func (c *contentProgressServiceClient) WatchContentProgress(ctx context.Context, in *WatchContentProgressRequest, opts ...grpc.CallOption) (ContentProgressService_WatchContentProgressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ContentProgressService_ServiceDesc.Streams[0], "/wsdaemon.ContentProgressService/WatchContentProgress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &contentProgressServiceWatchContentProgressClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ContentProgressService_WatchContentProgressClient interface {
+	Recv() (*ContentProgress, error)
+	grpc.ClientStream
+}
+
+type contentProgressServiceWatchContentProgressClient struct {
+	grpc.ClientStream
+}
+
+func (x *contentProgressServiceWatchContentProgressClient) Recv() (*ContentProgress, error) {
+	m := new(ContentProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ContentProgressServiceServer is the server API for ContentProgressService service.
+// All implementations must embed UnimplementedContentProgressServiceServer
+// for forward compatibility
+type ContentProgressServiceServer interface {
+	// WatchContentProgress streams the progress of content restores and backups of a workspace.
+	// The last known progress of each operation is sent first. The stream ends when the client cancels it.
+	WatchContentProgress(*WatchContentProgressRequest, ContentProgressService_WatchContentProgressServer) error
+	mustEmbedUnimplementedContentProgressServiceServer()
+}
+
+// UnimplementedContentProgressServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedContentProgressServiceServer struct {
+}
+
+func (UnimplementedContentProgressServiceServer) WatchContentProgress(*WatchContentProgressRequest, ContentProgressService_WatchContentProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchContentProgress not implemented")
+}
+func (UnimplementedContentProgressServiceServer) mustEmbedUnimplementedContentProgressServiceServer() {
+}
+
+// UnsafeContentProgressServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ContentProgressServiceServer will
+// result in compilation errors.
+type UnsafeContentProgressServiceServer interface {
+	mustEmbedUnimplementedContentProgressServiceServer()
+}
+
+func RegisterContentProgressServiceServer(s grpc.ServiceRegistrar, srv ContentProgressServiceServer) {
+	s.RegisterService(&ContentProgressService_ServiceDesc, srv)
+}
+
+func _ContentProgressService_WatchContentProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchContentProgressRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ContentProgressServiceServer).WatchContentProgress(m, &contentProgressServiceWatchContentProgressServer{stream})
+}
+
+type ContentProgressService_WatchContentProgressServer interface {
+	Send(*ContentProgress) error
+	grpc.ServerStream
+}
+
+type contentProgressServiceWatchContentProgressServer struct {
+	grpc.ServerStream
+}
+
+func (x *contentProgressServiceWatchContentProgressServer) Send(m *ContentProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ContentProgressService_ServiceDesc is the grpc.ServiceDesc for ContentProgressService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ContentProgressService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wsdaemon.ContentProgressService",
+	HandlerType: (*ContentProgressServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchContentProgress",
+			Handler:       _ContentProgressService_WatchContentProgress_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "daemon.proto",
+}
+
+// NetworkAccountingServiceClient is the client API for NetworkAccountingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NetworkAccountingServiceClient interface {
+	// GetNetworkAccounting returns the connections a workspace opened and the destinations it sent traffic to.
+	// Destinations are aggregated by autonomous system if ws-daemon knows it, and by network otherwise.
+	GetNetworkAccounting(ctx context.Context, in *GetNetworkAccountingRequest, opts ...grpc.CallOption) (*GetNetworkAccountingResponse, error)
+}
+
+type networkAccountingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNetworkAccountingServiceClient(cc grpc.ClientConnInterface) NetworkAccountingServiceClient {
+	return &networkAccountingServiceClient{cc}
+}
+
+func (c *networkAccountingServiceClient) GetNetworkAccounting(ctx context.Context, in *GetNetworkAccountingRequest, opts ...grpc.CallOption) (*GetNetworkAccountingResponse, error) {
+	out := new(GetNetworkAccountingResponse)
+	err := c.cc.Invoke(ctx, "/wsdaemon.NetworkAccountingService/GetNetworkAccounting", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NetworkAccountingServiceServer is the server API for NetworkAccountingService service.
+// All implementations must embed UnimplementedNetworkAccountingServiceServer
+// for forward compatibility
+type NetworkAccountingServiceServer interface {
+	// GetNetworkAccounting returns the connections a workspace opened and the destinations it sent traffic to.
+	// Destinations are aggregated by autonomous system if ws-daemon knows it, and by network otherwise.
+	GetNetworkAccounting(context.Context, *GetNetworkAccountingRequest) (*GetNetworkAccountingResponse, error)
+	mustEmbedUnimplementedNetworkAccountingServiceServer()
+}
+
+// UnimplementedNetworkAccountingServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedNetworkAccountingServiceServer struct {
+}
+
+func (UnimplementedNetworkAccountingServiceServer) GetNetworkAccounting(context.Context, *GetNetworkAccountingRequest) (*GetNetworkAccountingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkAccounting not implemented")
+}
+func (UnimplementedNetworkAccountingServiceServer) mustEmbedUnimplementedNetworkAccountingServiceServer() {
+}
+
+// UnsafeNetworkAccountingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NetworkAccountingServiceServer will
+// result in compilation errors.
+type UnsafeNetworkAccountingServiceServer interface {
+	mustEmbedUnimplementedNetworkAccountingServiceServer()
+}
+
+func RegisterNetworkAccountingServiceServer(s grpc.ServiceRegistrar, srv NetworkAccountingServiceServer) {
+	s.RegisterService(&NetworkAccountingService_ServiceDesc, srv)
+}
+
+func _NetworkAccountingService_GetNetworkAccounting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNetworkAccountingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkAccountingServiceServer).GetNetworkAccounting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.NetworkAccountingService/GetNetworkAccounting",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkAccountingServiceServer).GetNetworkAccounting(ctx, req.(*GetNetworkAccountingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NetworkAccountingService_ServiceDesc is the grpc.ServiceDesc for NetworkAccountingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NetworkAccountingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wsdaemon.NetworkAccountingService",
+	HandlerType: (*NetworkAccountingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetNetworkAccounting",
+			Handler:    _NetworkAccountingService_GetNetworkAccounting_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "daemon.proto",
+}
+
+// DrainServiceClient is the client API for DrainService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DrainServiceClient interface {
+	// StartDrain puts the daemon into drain mode, e.g. before its node is rotated. While draining, the daemon refuses
+	// to initialize new workspaces, such that it only has to back up the workspaces which are stopping.
+	StartDrain(ctx context.Context, in *StartDrainRequest, opts ...grpc.CallOption) (*DrainStatus, error)
+	// StopDrain ends the drain mode, e.g. when a node rotation is aborted
+	StopDrain(ctx context.Context, in *StopDrainRequest, opts ...grpc.CallOption) (*DrainStatus, error)
+	// GetDrainStatus reports whether the daemon is draining and which workspace content is left on its node
+	GetDrainStatus(ctx context.Context, in *GetDrainStatusRequest, opts ...grpc.CallOption) (*DrainStatus, error)
+}
+
+type drainServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDrainServiceClient(cc grpc.ClientConnInterface) DrainServiceClient {
+	return &drainServiceClient{cc}
+}
+
+func (c *drainServiceClient) StartDrain(ctx context.Context, in *StartDrainRequest, opts ...grpc.CallOption) (*DrainStatus, error) {
+	out := new(DrainStatus)
+	err := c.cc.Invoke(ctx, "/wsdaemon.DrainService/StartDrain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drainServiceClient) StopDrain(ctx context.Context, in *StopDrainRequest, opts ...grpc.CallOption) (*DrainStatus, error) {
+	out := new(DrainStatus)
+	err := c.cc.Invoke(ctx, "/wsdaemon.DrainService/StopDrain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drainServiceClient) GetDrainStatus(ctx context.Context, in *GetDrainStatusRequest, opts ...grpc.CallOption) (*DrainStatus, error) {
+	out := new(DrainStatus)
+	err := c.cc.Invoke(ctx, "/wsdaemon.DrainService/GetDrainStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DrainServiceServer is the server API for DrainService service.
+// All implementations must embed UnimplementedDrainServiceServer
+// for forward compatibility
+type DrainServiceServer interface {
+	// StartDrain puts the daemon into drain mode, e.g. before its node is rotated. While draining, the daemon refuses
+	// to initialize new workspaces, such that it only has to back up the workspaces which are stopping.
+	StartDrain(context.Context, *StartDrainRequest) (*DrainStatus, error)
+	// StopDrain ends the drain mode, e.g. when a node rotation is aborted
+	StopDrain(context.Context, *StopDrainRequest) (*DrainStatus, error)
+	// GetDrainStatus reports whether the daemon is draining and which workspace content is left on its node
+	GetDrainStatus(context.Context, *GetDrainStatusRequest) (*DrainStatus, error)
+	mustEmbedUnimplementedDrainServiceServer()
+}
+
+// UnimplementedDrainServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDrainServiceServer struct {
+}
+
+func (UnimplementedDrainServiceServer) StartDrain(context.Context, *StartDrainRequest) (*DrainStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartDrain not implemented")
+}
+func (UnimplementedDrainServiceServer) StopDrain(context.Context, *StopDrainRequest) (*DrainStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopDrain not implemented")
+}
+func (UnimplementedDrainServiceServer) GetDrainStatus(context.Context, *GetDrainStatusRequest) (*DrainStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDrainStatus not implemented")
+}
+func (UnimplementedDrainServiceServer) mustEmbedUnimplementedDrainServiceServer() {
+}
+
+// UnsafeDrainServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DrainServiceServer will
+// result in compilation errors.
+type UnsafeDrainServiceServer interface {
+	mustEmbedUnimplementedDrainServiceServer()
+}
+
+func RegisterDrainServiceServer(s grpc.ServiceRegistrar, srv DrainServiceServer) {
+	s.RegisterService(&DrainService_ServiceDesc, srv)
+}
+
+func _DrainService_StartDrain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartDrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DrainServiceServer).StartDrain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.DrainService/StartDrain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DrainServiceServer).StartDrain(ctx, req.(*StartDrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DrainService_StopDrain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopDrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DrainServiceServer).StopDrain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.DrainService/StopDrain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DrainServiceServer).StopDrain(ctx, req.(*StopDrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DrainService_GetDrainStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDrainStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DrainServiceServer).GetDrainStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.DrainService/GetDrainStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DrainServiceServer).GetDrainStatus(ctx, req.(*GetDrainStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DrainService_ServiceDesc is the grpc.ServiceDesc for DrainService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DrainService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wsdaemon.DrainService",
+	HandlerType: (*DrainServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartDrain",
+			Handler:    _DrainService_StartDrain_Handler,
+		},
+		{
+			MethodName: "StopDrain",
+			Handler:    _DrainService_StopDrain_Handler,
+		},
+		{
+			MethodName: "GetDrainStatus",
+			Handler:    _DrainService_GetDrainStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "daemon.proto",
+}
+
+// CPULimitServiceClient is the client API for CPULimitService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CPULimitServiceClient interface {
+	// SetCPULimit adjusts the CPU allocation of a running workspace from the next control period on.
+	// Unset fields keep the limits of the workspace class, an empty request restores them altogether.
+	SetCPULimit(ctx context.Context, in *SetCPULimitRequest, opts ...grpc.CallOption) (*SetCPULimitResponse, error)
+}
+
+type cPULimitServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCPULimitServiceClient(cc grpc.ClientConnInterface) CPULimitServiceClient {
+	return &cPULimitServiceClient{cc}
+}
+
+func (c *cPULimitServiceClient) SetCPULimit(ctx context.Context, in *SetCPULimitRequest, opts ...grpc.CallOption) (*SetCPULimitResponse, error) {
+	out := new(SetCPULimitResponse)
+	err := c.cc.Invoke(ctx, "/wsdaemon.CPULimitService/SetCPULimit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CPULimitServiceServer is the server API for CPULimitService service.
+// All implementations must embed UnimplementedCPULimitServiceServer
+// for forward compatibility
+type CPULimitServiceServer interface {
+	// SetCPULimit adjusts the CPU allocation of a running workspace from the next control period on.
+	// Unset fields keep the limits of the workspace class, an empty request restores them altogether.
+	SetCPULimit(context.Context, *SetCPULimitRequest) (*SetCPULimitResponse, error)
+	mustEmbedUnimplementedCPULimitServiceServer()
+}
+
+// UnimplementedCPULimitServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCPULimitServiceServer struct {
+}
+
+func (UnimplementedCPULimitServiceServer) SetCPULimit(context.Context, *SetCPULimitRequest) (*SetCPULimitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCPULimit not implemented")
+}
+func (UnimplementedCPULimitServiceServer) mustEmbedUnimplementedCPULimitServiceServer() {
+}
+
+// UnsafeCPULimitServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CPULimitServiceServer will
+// result in compilation errors.
+type UnsafeCPULimitServiceServer interface {
+	mustEmbedUnimplementedCPULimitServiceServer()
+}
+
+func RegisterCPULimitServiceServer(s grpc.ServiceRegistrar, srv CPULimitServiceServer) {
+	s.RegisterService(&CPULimitService_ServiceDesc, srv)
+}
+
+func _CPULimitService_SetCPULimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCPULimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CPULimitServiceServer).SetCPULimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.CPULimitService/SetCPULimit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CPULimitServiceServer).SetCPULimit(ctx, req.(*SetCPULimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CPULimitService_ServiceDesc is the grpc.ServiceDesc for CPULimitService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CPULimitService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wsdaemon.CPULimitService",
+	HandlerType: (*CPULimitServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetCPULimit",
+			Handler:    _CPULimitService_SetCPULimit_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "daemon.proto",
+}
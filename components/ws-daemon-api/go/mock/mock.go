@@ -469,6 +469,26 @@ func (mr *MockInWorkspaceServiceClientMockRecorder) UmountSysfs(arg0, arg1 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UmountSysfs", reflect.TypeOf((*MockInWorkspaceServiceClient)(nil).UmountSysfs), varargs...)
 }
 
+// WatchDiskUsage mocks base method.
+func (m *MockInWorkspaceServiceClient) WatchDiskUsage(arg0 context.Context, arg1 *api.WatchDiskUsageRequest, arg2 ...grpc.CallOption) (api.InWorkspaceService_WatchDiskUsageClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WatchDiskUsage", varargs...)
+	ret0, _ := ret[0].(api.InWorkspaceService_WatchDiskUsageClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchDiskUsage indicates an expected call of WatchDiskUsage.
+func (mr *MockInWorkspaceServiceClientMockRecorder) WatchDiskUsage(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDiskUsage", reflect.TypeOf((*MockInWorkspaceServiceClient)(nil).WatchDiskUsage), varargs...)
+}
+
 // WorkspaceInfo mocks base method.
 func (m *MockInWorkspaceServiceClient) WorkspaceInfo(arg0 context.Context, arg1 *api.WorkspaceInfoRequest, arg2 ...grpc.CallOption) (*api.WorkspaceInfoResponse, error) {
 	m.ctrl.T.Helper()
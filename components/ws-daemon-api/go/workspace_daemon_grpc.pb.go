@@ -63,6 +63,8 @@ type InWorkspaceServiceClient interface {
 	SetupPairVeths(ctx context.Context, in *SetupPairVethsRequest, opts ...grpc.CallOption) (*SetupPairVethsResponse, error)
 	// Get information about the workspace
 	WorkspaceInfo(ctx context.Context, in *WorkspaceInfoRequest, opts ...grpc.CallOption) (*WorkspaceInfoResponse, error)
+	// WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+	WatchDiskUsage(ctx context.Context, in *WatchDiskUsageRequest, opts ...grpc.CallOption) (InWorkspaceService_WatchDiskUsageClient, error)
 }
 
 type inWorkspaceServiceClient struct {
@@ -163,6 +165,38 @@ func (c *inWorkspaceServiceClient) WorkspaceInfo(ctx context.Context, in *Worksp
 	return out, nil
 }
 
+func (c *inWorkspaceServiceClient) WatchDiskUsage(ctx context.Context, in *WatchDiskUsageRequest, opts ...grpc.CallOption) (InWorkspaceService_WatchDiskUsageClient, error) {
+	stream, err := c.cc.NewStream(ctx, &InWorkspaceService_ServiceDesc.Streams[0], "/iws.InWorkspaceService/WatchDiskUsage", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &inWorkspaceServiceWatchDiskUsageClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type InWorkspaceService_WatchDiskUsageClient interface {
+	Recv() (*DiskUsage, error)
+	grpc.ClientStream
+}
+
+type inWorkspaceServiceWatchDiskUsageClient struct {
+	grpc.ClientStream
+}
+
+func (x *inWorkspaceServiceWatchDiskUsageClient) Recv() (*DiskUsage, error) {
+	m := new(DiskUsage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // InWorkspaceServiceServer is the server API for InWorkspaceService service.
 // All implementations must embed UnimplementedInWorkspaceServiceServer
 // for forward compatibility
@@ -204,6 +238,8 @@ type InWorkspaceServiceServer interface {
 	SetupPairVeths(context.Context, *SetupPairVethsRequest) (*SetupPairVethsResponse, error)
 	// Get information about the workspace
 	WorkspaceInfo(context.Context, *WorkspaceInfoRequest) (*WorkspaceInfoResponse, error)
+	// WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+	WatchDiskUsage(*WatchDiskUsageRequest, InWorkspaceService_WatchDiskUsageServer) error
 	mustEmbedUnimplementedInWorkspaceServiceServer()
 }
 
@@ -241,6 +277,9 @@ func (UnimplementedInWorkspaceServiceServer) SetupPairVeths(context.Context, *Se
 func (UnimplementedInWorkspaceServiceServer) WorkspaceInfo(context.Context, *WorkspaceInfoRequest) (*WorkspaceInfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WorkspaceInfo not implemented")
 }
+func (UnimplementedInWorkspaceServiceServer) WatchDiskUsage(*WatchDiskUsageRequest, InWorkspaceService_WatchDiskUsageServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDiskUsage not implemented")
+}
 func (UnimplementedInWorkspaceServiceServer) mustEmbedUnimplementedInWorkspaceServiceServer() {}
 
 // UnsafeInWorkspaceServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -434,6 +473,27 @@ func _InWorkspaceService_WorkspaceInfo_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _InWorkspaceService_WatchDiskUsage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDiskUsageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(InWorkspaceServiceServer).WatchDiskUsage(m, &inWorkspaceServiceWatchDiskUsageServer{stream})
+}
+
+type InWorkspaceService_WatchDiskUsageServer interface {
+	Send(*DiskUsage) error
+	grpc.ServerStream
+}
+
+type inWorkspaceServiceWatchDiskUsageServer struct {
+	grpc.ServerStream
+}
+
+func (x *inWorkspaceServiceWatchDiskUsageServer) Send(m *DiskUsage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // InWorkspaceService_ServiceDesc is the grpc.ServiceDesc for InWorkspaceService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -482,7 +542,13 @@ var InWorkspaceService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _InWorkspaceService_WorkspaceInfo_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDiskUsage",
+			Handler:       _InWorkspaceService_WatchDiskUsage_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "workspace_daemon.proto",
 }
 
@@ -492,6 +558,8 @@ var InWorkspaceService_ServiceDesc = grpc.ServiceDesc{
 type WorkspaceInfoServiceClient interface {
 	// Get information about the workspace
 	WorkspaceInfo(ctx context.Context, in *WorkspaceInfoRequest, opts ...grpc.CallOption) (*WorkspaceInfoResponse, error)
+	// WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+	WatchDiskUsage(ctx context.Context, in *WatchDiskUsageRequest, opts ...grpc.CallOption) (WorkspaceInfoService_WatchDiskUsageClient, error)
 }
 
 type workspaceInfoServiceClient struct {
@@ -511,12 +579,46 @@ func (c *workspaceInfoServiceClient) WorkspaceInfo(ctx context.Context, in *Work
 	return out, nil
 }
 
+func (c *workspaceInfoServiceClient) WatchDiskUsage(ctx context.Context, in *WatchDiskUsageRequest, opts ...grpc.CallOption) (WorkspaceInfoService_WatchDiskUsageClient, error) {
+	stream, err := c.cc.NewStream(ctx, &WorkspaceInfoService_ServiceDesc.Streams[0], "/iws.WorkspaceInfoService/WatchDiskUsage", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &workspaceInfoServiceWatchDiskUsageClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type WorkspaceInfoService_WatchDiskUsageClient interface {
+	Recv() (*DiskUsage, error)
+	grpc.ClientStream
+}
+
+type workspaceInfoServiceWatchDiskUsageClient struct {
+	grpc.ClientStream
+}
+
+func (x *workspaceInfoServiceWatchDiskUsageClient) Recv() (*DiskUsage, error) {
+	m := new(DiskUsage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // WorkspaceInfoServiceServer is the server API for WorkspaceInfoService service.
 // All implementations must embed UnimplementedWorkspaceInfoServiceServer
 // for forward compatibility
 type WorkspaceInfoServiceServer interface {
 	// Get information about the workspace
 	WorkspaceInfo(context.Context, *WorkspaceInfoRequest) (*WorkspaceInfoResponse, error)
+	// WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+	WatchDiskUsage(*WatchDiskUsageRequest, WorkspaceInfoService_WatchDiskUsageServer) error
 	mustEmbedUnimplementedWorkspaceInfoServiceServer()
 }
 
@@ -527,6 +629,9 @@ type UnimplementedWorkspaceInfoServiceServer struct {
 func (UnimplementedWorkspaceInfoServiceServer) WorkspaceInfo(context.Context, *WorkspaceInfoRequest) (*WorkspaceInfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WorkspaceInfo not implemented")
 }
+func (UnimplementedWorkspaceInfoServiceServer) WatchDiskUsage(*WatchDiskUsageRequest, WorkspaceInfoService_WatchDiskUsageServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDiskUsage not implemented")
+}
 func (UnimplementedWorkspaceInfoServiceServer) mustEmbedUnimplementedWorkspaceInfoServiceServer() {}
 
 // UnsafeWorkspaceInfoServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -558,6 +663,27 @@ func _WorkspaceInfoService_WorkspaceInfo_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WorkspaceInfoService_WatchDiskUsage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDiskUsageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkspaceInfoServiceServer).WatchDiskUsage(m, &workspaceInfoServiceWatchDiskUsageServer{stream})
+}
+
+type WorkspaceInfoService_WatchDiskUsageServer interface {
+	Send(*DiskUsage) error
+	grpc.ServerStream
+}
+
+type workspaceInfoServiceWatchDiskUsageServer struct {
+	grpc.ServerStream
+}
+
+func (x *workspaceInfoServiceWatchDiskUsageServer) Send(m *DiskUsage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // WorkspaceInfoService_ServiceDesc is the grpc.ServiceDesc for WorkspaceInfoService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -570,6 +696,12 @@ var WorkspaceInfoService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _WorkspaceInfoService_WorkspaceInfo_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDiskUsage",
+			Handler:       _WorkspaceInfoService_WatchDiskUsage_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "workspace_daemon.proto",
 }
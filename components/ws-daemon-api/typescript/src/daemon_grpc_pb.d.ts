@@ -131,3 +131,167 @@ export class WorkspaceContentServiceClient extends grpc.Client implements IWorks
     public backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
     public backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
 }
+
+interface IContentProgressServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
+    watchContentProgress: IContentProgressServiceService_IWatchContentProgress;
+}
+
+interface IContentProgressServiceService_IWatchContentProgress extends grpc.MethodDefinition<daemon_pb.WatchContentProgressRequest, daemon_pb.ContentProgress> {
+    path: "/wsdaemon.ContentProgressService/WatchContentProgress";
+    requestStream: false;
+    responseStream: true;
+    requestSerialize: grpc.serialize<daemon_pb.WatchContentProgressRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.WatchContentProgressRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.ContentProgress>;
+    responseDeserialize: grpc.deserialize<daemon_pb.ContentProgress>;
+}
+
+export const ContentProgressServiceService: IContentProgressServiceService;
+
+export interface IContentProgressServiceServer extends grpc.UntypedServiceImplementation {
+    watchContentProgress: grpc.handleServerStreamingCall<daemon_pb.WatchContentProgressRequest, daemon_pb.ContentProgress>;
+}
+
+export interface IContentProgressServiceClient {
+    watchContentProgress(request: daemon_pb.WatchContentProgressRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ContentProgress>;
+    watchContentProgress(request: daemon_pb.WatchContentProgressRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ContentProgress>;
+}
+
+export class ContentProgressServiceClient extends grpc.Client implements IContentProgressServiceClient {
+    constructor(address: string, credentials: grpc.ChannelCredentials, options?: Partial<grpc.ClientOptions>);
+    public watchContentProgress(request: daemon_pb.WatchContentProgressRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ContentProgress>;
+    public watchContentProgress(request: daemon_pb.WatchContentProgressRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ContentProgress>;
+}
+
+interface INetworkAccountingServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
+    getNetworkAccounting: INetworkAccountingServiceService_IGetNetworkAccounting;
+}
+
+interface INetworkAccountingServiceService_IGetNetworkAccounting extends grpc.MethodDefinition<daemon_pb.GetNetworkAccountingRequest, daemon_pb.GetNetworkAccountingResponse> {
+    path: "/wsdaemon.NetworkAccountingService/GetNetworkAccounting";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<daemon_pb.GetNetworkAccountingRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.GetNetworkAccountingRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.GetNetworkAccountingResponse>;
+    responseDeserialize: grpc.deserialize<daemon_pb.GetNetworkAccountingResponse>;
+}
+
+export const NetworkAccountingServiceService: INetworkAccountingServiceService;
+
+export interface INetworkAccountingServiceServer extends grpc.UntypedServiceImplementation {
+    getNetworkAccounting: grpc.handleUnaryCall<daemon_pb.GetNetworkAccountingRequest, daemon_pb.GetNetworkAccountingResponse>;
+}
+
+export interface INetworkAccountingServiceClient {
+    getNetworkAccounting(request: daemon_pb.GetNetworkAccountingRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.GetNetworkAccountingResponse) => void): grpc.ClientUnaryCall;
+    getNetworkAccounting(request: daemon_pb.GetNetworkAccountingRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.GetNetworkAccountingResponse) => void): grpc.ClientUnaryCall;
+    getNetworkAccounting(request: daemon_pb.GetNetworkAccountingRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.GetNetworkAccountingResponse) => void): grpc.ClientUnaryCall;
+}
+
+export class NetworkAccountingServiceClient extends grpc.Client implements INetworkAccountingServiceClient {
+    constructor(address: string, credentials: grpc.ChannelCredentials, options?: Partial<grpc.ClientOptions>);
+    public getNetworkAccounting(request: daemon_pb.GetNetworkAccountingRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.GetNetworkAccountingResponse) => void): grpc.ClientUnaryCall;
+    public getNetworkAccounting(request: daemon_pb.GetNetworkAccountingRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.GetNetworkAccountingResponse) => void): grpc.ClientUnaryCall;
+    public getNetworkAccounting(request: daemon_pb.GetNetworkAccountingRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.GetNetworkAccountingResponse) => void): grpc.ClientUnaryCall;
+}
+
+interface IDrainServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
+    startDrain: IDrainServiceService_IStartDrain;
+    stopDrain: IDrainServiceService_IStopDrain;
+    getDrainStatus: IDrainServiceService_IGetDrainStatus;
+}
+
+interface IDrainServiceService_IStartDrain extends grpc.MethodDefinition<daemon_pb.StartDrainRequest, daemon_pb.DrainStatus> {
+    path: "/wsdaemon.DrainService/StartDrain";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<daemon_pb.StartDrainRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.StartDrainRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.DrainStatus>;
+    responseDeserialize: grpc.deserialize<daemon_pb.DrainStatus>;
+}
+interface IDrainServiceService_IStopDrain extends grpc.MethodDefinition<daemon_pb.StopDrainRequest, daemon_pb.DrainStatus> {
+    path: "/wsdaemon.DrainService/StopDrain";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<daemon_pb.StopDrainRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.StopDrainRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.DrainStatus>;
+    responseDeserialize: grpc.deserialize<daemon_pb.DrainStatus>;
+}
+interface IDrainServiceService_IGetDrainStatus extends grpc.MethodDefinition<daemon_pb.GetDrainStatusRequest, daemon_pb.DrainStatus> {
+    path: "/wsdaemon.DrainService/GetDrainStatus";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<daemon_pb.GetDrainStatusRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.GetDrainStatusRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.DrainStatus>;
+    responseDeserialize: grpc.deserialize<daemon_pb.DrainStatus>;
+}
+
+export const DrainServiceService: IDrainServiceService;
+
+export interface IDrainServiceServer extends grpc.UntypedServiceImplementation {
+    startDrain: grpc.handleUnaryCall<daemon_pb.StartDrainRequest, daemon_pb.DrainStatus>;
+    stopDrain: grpc.handleUnaryCall<daemon_pb.StopDrainRequest, daemon_pb.DrainStatus>;
+    getDrainStatus: grpc.handleUnaryCall<daemon_pb.GetDrainStatusRequest, daemon_pb.DrainStatus>;
+}
+
+export interface IDrainServiceClient {
+    startDrain(request: daemon_pb.StartDrainRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    startDrain(request: daemon_pb.StartDrainRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    startDrain(request: daemon_pb.StartDrainRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    stopDrain(request: daemon_pb.StopDrainRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    stopDrain(request: daemon_pb.StopDrainRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    stopDrain(request: daemon_pb.StopDrainRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    getDrainStatus(request: daemon_pb.GetDrainStatusRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    getDrainStatus(request: daemon_pb.GetDrainStatusRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    getDrainStatus(request: daemon_pb.GetDrainStatusRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+}
+
+export class DrainServiceClient extends grpc.Client implements IDrainServiceClient {
+    constructor(address: string, credentials: grpc.ChannelCredentials, options?: Partial<grpc.ClientOptions>);
+    public startDrain(request: daemon_pb.StartDrainRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public startDrain(request: daemon_pb.StartDrainRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public startDrain(request: daemon_pb.StartDrainRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public stopDrain(request: daemon_pb.StopDrainRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public stopDrain(request: daemon_pb.StopDrainRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public stopDrain(request: daemon_pb.StopDrainRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public getDrainStatus(request: daemon_pb.GetDrainStatusRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public getDrainStatus(request: daemon_pb.GetDrainStatusRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+    public getDrainStatus(request: daemon_pb.GetDrainStatusRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.DrainStatus) => void): grpc.ClientUnaryCall;
+}
+
+interface ICPULimitServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
+    setCPULimit: ICPULimitServiceService_ISetCPULimit;
+}
+
+interface ICPULimitServiceService_ISetCPULimit extends grpc.MethodDefinition<daemon_pb.SetCPULimitRequest, daemon_pb.SetCPULimitResponse> {
+    path: "/wsdaemon.CPULimitService/SetCPULimit";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<daemon_pb.SetCPULimitRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.SetCPULimitRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.SetCPULimitResponse>;
+    responseDeserialize: grpc.deserialize<daemon_pb.SetCPULimitResponse>;
+}
+
+export const CPULimitServiceService: ICPULimitServiceService;
+
+export interface ICPULimitServiceServer extends grpc.UntypedServiceImplementation {
+    setCPULimit: grpc.handleUnaryCall<daemon_pb.SetCPULimitRequest, daemon_pb.SetCPULimitResponse>;
+}
+
+export interface ICPULimitServiceClient {
+    setCPULimit(request: daemon_pb.SetCPULimitRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.SetCPULimitResponse) => void): grpc.ClientUnaryCall;
+    setCPULimit(request: daemon_pb.SetCPULimitRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.SetCPULimitResponse) => void): grpc.ClientUnaryCall;
+    setCPULimit(request: daemon_pb.SetCPULimitRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.SetCPULimitResponse) => void): grpc.ClientUnaryCall;
+}
+
+export class CPULimitServiceClient extends grpc.Client implements ICPULimitServiceClient {
+    constructor(address: string, credentials: grpc.ChannelCredentials, options?: Partial<grpc.ClientOptions>);
+    public setCPULimit(request: daemon_pb.SetCPULimitRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.SetCPULimitResponse) => void): grpc.ClientUnaryCall;
+    public setCPULimit(request: daemon_pb.SetCPULimitRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.SetCPULimitResponse) => void): grpc.ClientUnaryCall;
+    public setCPULimit(request: daemon_pb.SetCPULimitRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.SetCPULimitResponse) => void): grpc.ClientUnaryCall;
+}
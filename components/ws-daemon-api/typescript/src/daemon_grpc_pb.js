@@ -33,6 +33,17 @@ function deserialize_wsdaemon_BackupWorkspaceResponse(buffer_arg) {
   return daemon_pb.BackupWorkspaceResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_wsdaemon_ContentProgress(arg) {
+  if (!(arg instanceof daemon_pb.ContentProgress)) {
+    throw new Error('Expected argument of type wsdaemon.ContentProgress');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_ContentProgress(buffer_arg) {
+  return daemon_pb.ContentProgress.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_wsdaemon_DisposeWorkspaceRequest(arg) {
   if (!(arg instanceof daemon_pb.DisposeWorkspaceRequest)) {
     throw new Error('Expected argument of type wsdaemon.DisposeWorkspaceRequest');
@@ -55,6 +66,50 @@ function deserialize_wsdaemon_DisposeWorkspaceResponse(buffer_arg) {
   return daemon_pb.DisposeWorkspaceResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_wsdaemon_DrainStatus(arg) {
+  if (!(arg instanceof daemon_pb.DrainStatus)) {
+    throw new Error('Expected argument of type wsdaemon.DrainStatus');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_DrainStatus(buffer_arg) {
+  return daemon_pb.DrainStatus.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_GetDrainStatusRequest(arg) {
+  if (!(arg instanceof daemon_pb.GetDrainStatusRequest)) {
+    throw new Error('Expected argument of type wsdaemon.GetDrainStatusRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_GetDrainStatusRequest(buffer_arg) {
+  return daemon_pb.GetDrainStatusRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_GetNetworkAccountingRequest(arg) {
+  if (!(arg instanceof daemon_pb.GetNetworkAccountingRequest)) {
+    throw new Error('Expected argument of type wsdaemon.GetNetworkAccountingRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_GetNetworkAccountingRequest(buffer_arg) {
+  return daemon_pb.GetNetworkAccountingRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_GetNetworkAccountingResponse(arg) {
+  if (!(arg instanceof daemon_pb.GetNetworkAccountingResponse)) {
+    throw new Error('Expected argument of type wsdaemon.GetNetworkAccountingResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_GetNetworkAccountingResponse(buffer_arg) {
+  return daemon_pb.GetNetworkAccountingResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_wsdaemon_InitWorkspaceRequest(arg) {
   if (!(arg instanceof daemon_pb.InitWorkspaceRequest)) {
     throw new Error('Expected argument of type wsdaemon.InitWorkspaceRequest');
@@ -99,6 +154,50 @@ function deserialize_wsdaemon_IsWorkspaceExistsResponse(buffer_arg) {
   return daemon_pb.IsWorkspaceExistsResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_wsdaemon_SetCPULimitRequest(arg) {
+  if (!(arg instanceof daemon_pb.SetCPULimitRequest)) {
+    throw new Error('Expected argument of type wsdaemon.SetCPULimitRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_SetCPULimitRequest(buffer_arg) {
+  return daemon_pb.SetCPULimitRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_SetCPULimitResponse(arg) {
+  if (!(arg instanceof daemon_pb.SetCPULimitResponse)) {
+    throw new Error('Expected argument of type wsdaemon.SetCPULimitResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_SetCPULimitResponse(buffer_arg) {
+  return daemon_pb.SetCPULimitResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_StartDrainRequest(arg) {
+  if (!(arg instanceof daemon_pb.StartDrainRequest)) {
+    throw new Error('Expected argument of type wsdaemon.StartDrainRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_StartDrainRequest(buffer_arg) {
+  return daemon_pb.StartDrainRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_StopDrainRequest(arg) {
+  if (!(arg instanceof daemon_pb.StopDrainRequest)) {
+    throw new Error('Expected argument of type wsdaemon.StopDrainRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_StopDrainRequest(buffer_arg) {
+  return daemon_pb.StopDrainRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_wsdaemon_TakeSnapshotRequest(arg) {
   if (!(arg instanceof daemon_pb.TakeSnapshotRequest)) {
     throw new Error('Expected argument of type wsdaemon.TakeSnapshotRequest');
@@ -143,6 +242,17 @@ function deserialize_wsdaemon_WaitForInitResponse(buffer_arg) {
   return daemon_pb.WaitForInitResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_wsdaemon_WatchContentProgressRequest(arg) {
+  if (!(arg instanceof daemon_pb.WatchContentProgressRequest)) {
+    throw new Error('Expected argument of type wsdaemon.WatchContentProgressRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_WatchContentProgressRequest(buffer_arg) {
+  return daemon_pb.WatchContentProgressRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 
 var WorkspaceContentServiceService = exports.WorkspaceContentServiceService = {
   // initWorkspace intialises a new workspace folder in the working area
@@ -222,3 +332,95 @@ backupWorkspace: {
 };
 
 exports.WorkspaceContentServiceClient = grpc.makeGenericClientConstructor(WorkspaceContentServiceService);
+var ContentProgressServiceService = exports.ContentProgressServiceService = {
+  // WatchContentProgress streams the progress of content restores and backups of a workspace.
+// The last known progress of each operation is sent first. The stream ends when the client cancels it.
+watchContentProgress: {
+    path: '/wsdaemon.ContentProgressService/WatchContentProgress',
+    requestStream: false,
+    responseStream: true,
+    requestType: daemon_pb.WatchContentProgressRequest,
+    responseType: daemon_pb.ContentProgress,
+    requestSerialize: serialize_wsdaemon_WatchContentProgressRequest,
+    requestDeserialize: deserialize_wsdaemon_WatchContentProgressRequest,
+    responseSerialize: serialize_wsdaemon_ContentProgress,
+    responseDeserialize: deserialize_wsdaemon_ContentProgress,
+  },
+};
+
+exports.ContentProgressServiceClient = grpc.makeGenericClientConstructor(ContentProgressServiceService);
+var NetworkAccountingServiceService = exports.NetworkAccountingServiceService = {
+  // GetNetworkAccounting returns the connections a workspace opened and the destinations it sent traffic to.
+// Destinations are aggregated by autonomous system if ws-daemon knows it, and by network otherwise.
+getNetworkAccounting: {
+    path: '/wsdaemon.NetworkAccountingService/GetNetworkAccounting',
+    requestStream: false,
+    responseStream: false,
+    requestType: daemon_pb.GetNetworkAccountingRequest,
+    responseType: daemon_pb.GetNetworkAccountingResponse,
+    requestSerialize: serialize_wsdaemon_GetNetworkAccountingRequest,
+    requestDeserialize: deserialize_wsdaemon_GetNetworkAccountingRequest,
+    responseSerialize: serialize_wsdaemon_GetNetworkAccountingResponse,
+    responseDeserialize: deserialize_wsdaemon_GetNetworkAccountingResponse,
+  },
+};
+
+exports.NetworkAccountingServiceClient = grpc.makeGenericClientConstructor(NetworkAccountingServiceService);
+var DrainServiceService = exports.DrainServiceService = {
+  // StartDrain puts the daemon into drain mode, e.g. before its node is rotated. While draining, the daemon refuses
+// to initialize new workspaces, such that it only has to back up the workspaces which are stopping.
+startDrain: {
+    path: '/wsdaemon.DrainService/StartDrain',
+    requestStream: false,
+    responseStream: false,
+    requestType: daemon_pb.StartDrainRequest,
+    responseType: daemon_pb.DrainStatus,
+    requestSerialize: serialize_wsdaemon_StartDrainRequest,
+    requestDeserialize: deserialize_wsdaemon_StartDrainRequest,
+    responseSerialize: serialize_wsdaemon_DrainStatus,
+    responseDeserialize: deserialize_wsdaemon_DrainStatus,
+  },
+  // StopDrain ends the drain mode, e.g. when a node rotation is aborted
+stopDrain: {
+    path: '/wsdaemon.DrainService/StopDrain',
+    requestStream: false,
+    responseStream: false,
+    requestType: daemon_pb.StopDrainRequest,
+    responseType: daemon_pb.DrainStatus,
+    requestSerialize: serialize_wsdaemon_StopDrainRequest,
+    requestDeserialize: deserialize_wsdaemon_StopDrainRequest,
+    responseSerialize: serialize_wsdaemon_DrainStatus,
+    responseDeserialize: deserialize_wsdaemon_DrainStatus,
+  },
+  // GetDrainStatus reports whether the daemon is draining and which workspace content is left on its node
+getDrainStatus: {
+    path: '/wsdaemon.DrainService/GetDrainStatus',
+    requestStream: false,
+    responseStream: false,
+    requestType: daemon_pb.GetDrainStatusRequest,
+    responseType: daemon_pb.DrainStatus,
+    requestSerialize: serialize_wsdaemon_GetDrainStatusRequest,
+    requestDeserialize: deserialize_wsdaemon_GetDrainStatusRequest,
+    responseSerialize: serialize_wsdaemon_DrainStatus,
+    responseDeserialize: deserialize_wsdaemon_DrainStatus,
+  },
+};
+
+exports.DrainServiceClient = grpc.makeGenericClientConstructor(DrainServiceService);
+var CPULimitServiceService = exports.CPULimitServiceService = {
+  // SetCPULimit adjusts the CPU allocation of a running workspace from the next control period on.
+// Unset fields keep the limits of the workspace class, an empty request restores them altogether.
+setCPULimit: {
+    path: '/wsdaemon.CPULimitService/SetCPULimit',
+    requestStream: false,
+    responseStream: false,
+    requestType: daemon_pb.SetCPULimitRequest,
+    responseType: daemon_pb.SetCPULimitResponse,
+    requestSerialize: serialize_wsdaemon_SetCPULimitRequest,
+    requestDeserialize: deserialize_wsdaemon_SetCPULimitRequest,
+    responseSerialize: serialize_wsdaemon_SetCPULimitResponse,
+    responseDeserialize: deserialize_wsdaemon_SetCPULimitResponse,
+  },
+};
+
+exports.CPULimitServiceClient = grpc.makeGenericClientConstructor(CPULimitServiceService);
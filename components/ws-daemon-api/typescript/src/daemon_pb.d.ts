@@ -300,9 +300,290 @@ export namespace BackupWorkspaceResponse {
     }
 }
 
+export class WatchContentProgressRequest extends jspb.Message {
+    getId(): string;
+    setId(value: string): WatchContentProgressRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): WatchContentProgressRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: WatchContentProgressRequest): WatchContentProgressRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: WatchContentProgressRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): WatchContentProgressRequest;
+    static deserializeBinaryFromReader(message: WatchContentProgressRequest, reader: jspb.BinaryReader): WatchContentProgressRequest;
+}
+
+export namespace WatchContentProgressRequest {
+    export type AsObject = {
+        id: string,
+    }
+}
+
+export class ContentProgress extends jspb.Message {
+    getId(): string;
+    setId(value: string): ContentProgress;
+    getOperation(): ContentOperation;
+    setOperation(value: ContentOperation): ContentProgress;
+    getPhase(): string;
+    setPhase(value: string): ContentProgress;
+    getBytesDone(): number;
+    setBytesDone(value: number): ContentProgress;
+    getBytesTotal(): number;
+    setBytesTotal(value: number): ContentProgress;
+    getFilesDone(): number;
+    setFilesDone(value: number): ContentProgress;
+    getFilesTotal(): number;
+    setFilesTotal(value: number): ContentProgress;
+    getEtaSeconds(): number;
+    setEtaSeconds(value: number): ContentProgress;
+    getDone(): boolean;
+    setDone(value: boolean): ContentProgress;
+    getError(): string;
+    setError(value: string): ContentProgress;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ContentProgress.AsObject;
+    static toObject(includeInstance: boolean, msg: ContentProgress): ContentProgress.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ContentProgress, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ContentProgress;
+    static deserializeBinaryFromReader(message: ContentProgress, reader: jspb.BinaryReader): ContentProgress;
+}
+
+export namespace ContentProgress {
+    export type AsObject = {
+        id: string,
+        operation: ContentOperation,
+        phase: string,
+        bytesDone: number,
+        bytesTotal: number,
+        filesDone: number,
+        filesTotal: number,
+        etaSeconds: number,
+        done: boolean,
+        error: string,
+    }
+}
+
+export class GetNetworkAccountingRequest extends jspb.Message {
+    getId(): string;
+    setId(value: string): GetNetworkAccountingRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): GetNetworkAccountingRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: GetNetworkAccountingRequest): GetNetworkAccountingRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: GetNetworkAccountingRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): GetNetworkAccountingRequest;
+    static deserializeBinaryFromReader(message: GetNetworkAccountingRequest, reader: jspb.BinaryReader): GetNetworkAccountingRequest;
+}
+
+export namespace GetNetworkAccountingRequest {
+    export type AsObject = {
+        id: string,
+    }
+}
+
+export class GetNetworkAccountingResponse extends jspb.Message {
+    getConnections(): number;
+    setConnections(value: number): GetNetworkAccountingResponse;
+    clearDestinationsList(): void;
+    getDestinationsList(): Array<EgressDestination>;
+    setDestinationsList(value: Array<EgressDestination>): GetNetworkAccountingResponse;
+    addDestinations(value?: EgressDestination, index?: number): EgressDestination;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): GetNetworkAccountingResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: GetNetworkAccountingResponse): GetNetworkAccountingResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: GetNetworkAccountingResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): GetNetworkAccountingResponse;
+    static deserializeBinaryFromReader(message: GetNetworkAccountingResponse, reader: jspb.BinaryReader): GetNetworkAccountingResponse;
+}
+
+export namespace GetNetworkAccountingResponse {
+    export type AsObject = {
+        connections: number,
+        destinationsList: Array<EgressDestination.AsObject>,
+    }
+}
+
+export class EgressDestination extends jspb.Message {
+    getDestination(): string;
+    setDestination(value: string): EgressDestination;
+    getConnections(): number;
+    setConnections(value: number): EgressDestination;
+    getBytes(): number;
+    setBytes(value: number): EgressDestination;
+    getPackets(): number;
+    setPackets(value: number): EgressDestination;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): EgressDestination.AsObject;
+    static toObject(includeInstance: boolean, msg: EgressDestination): EgressDestination.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: EgressDestination, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): EgressDestination;
+    static deserializeBinaryFromReader(message: EgressDestination, reader: jspb.BinaryReader): EgressDestination;
+}
+
+export namespace EgressDestination {
+    export type AsObject = {
+        destination: string,
+        connections: number,
+        bytes: number,
+        packets: number,
+    }
+}
+
+export class StartDrainRequest extends jspb.Message {
+    getReason(): string;
+    setReason(value: string): StartDrainRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): StartDrainRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: StartDrainRequest): StartDrainRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: StartDrainRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): StartDrainRequest;
+    static deserializeBinaryFromReader(message: StartDrainRequest, reader: jspb.BinaryReader): StartDrainRequest;
+}
+
+export namespace StartDrainRequest {
+    export type AsObject = {
+        reason: string,
+    }
+}
+
+export class StopDrainRequest extends jspb.Message {
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): StopDrainRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: StopDrainRequest): StopDrainRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: StopDrainRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): StopDrainRequest;
+    static deserializeBinaryFromReader(message: StopDrainRequest, reader: jspb.BinaryReader): StopDrainRequest;
+}
+
+export namespace StopDrainRequest {
+    export type AsObject = {
+    }
+}
+
+export class GetDrainStatusRequest extends jspb.Message {
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): GetDrainStatusRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: GetDrainStatusRequest): GetDrainStatusRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: GetDrainStatusRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): GetDrainStatusRequest;
+    static deserializeBinaryFromReader(message: GetDrainStatusRequest, reader: jspb.BinaryReader): GetDrainStatusRequest;
+}
+
+export namespace GetDrainStatusRequest {
+    export type AsObject = {
+    }
+}
+
+export class DrainStatus extends jspb.Message {
+    getDraining(): boolean;
+    setDraining(value: boolean): DrainStatus;
+    getReason(): string;
+    setReason(value: string): DrainStatus;
+    getStartedUnix(): number;
+    setStartedUnix(value: number): DrainStatus;
+    clearWorkspacesList(): void;
+    getWorkspacesList(): Array<string>;
+    setWorkspacesList(value: Array<string>): DrainStatus;
+    addWorkspaces(value: string, index?: number): string;
+    getContentFree(): boolean;
+    setContentFree(value: boolean): DrainStatus;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): DrainStatus.AsObject;
+    static toObject(includeInstance: boolean, msg: DrainStatus): DrainStatus.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: DrainStatus, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): DrainStatus;
+    static deserializeBinaryFromReader(message: DrainStatus, reader: jspb.BinaryReader): DrainStatus;
+}
+
+export namespace DrainStatus {
+    export type AsObject = {
+        draining: boolean,
+        reason: string,
+        startedUnix: number,
+        workspacesList: Array<string>,
+        contentFree: boolean,
+    }
+}
+
+export class SetCPULimitRequest extends jspb.Message {
+    getId(): string;
+    setId(value: string): SetCPULimitRequest;
+    getMinLimit(): string;
+    setMinLimit(value: string): SetCPULimitRequest;
+    getBurstLimit(): string;
+    setBurstLimit(value: string): SetCPULimitRequest;
+    getBurstBudget(): string;
+    setBurstBudget(value: string): SetCPULimitRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): SetCPULimitRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: SetCPULimitRequest): SetCPULimitRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: SetCPULimitRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): SetCPULimitRequest;
+    static deserializeBinaryFromReader(message: SetCPULimitRequest, reader: jspb.BinaryReader): SetCPULimitRequest;
+}
+
+export namespace SetCPULimitRequest {
+    export type AsObject = {
+        id: string,
+        minLimit: string,
+        burstLimit: string,
+        burstBudget: string,
+    }
+}
+
+export class SetCPULimitResponse extends jspb.Message {
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): SetCPULimitResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: SetCPULimitResponse): SetCPULimitResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: SetCPULimitResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): SetCPULimitResponse;
+    static deserializeBinaryFromReader(message: SetCPULimitResponse, reader: jspb.BinaryReader): SetCPULimitResponse;
+}
+
+export namespace SetCPULimitResponse {
+    export type AsObject = {
+    }
+}
+
 export enum WorkspaceContentState {
     NONE = 0,
     SETTING_UP = 1,
     AVAILABLE = 2,
     WRAPPING_UP = 3,
 }
+
+export enum ContentOperation {
+    RESTORE = 0,
+    BACKUP = 1,
+    SNAPSHOT = 2,
+}
@@ -24,6 +24,7 @@ interface IInWorkspaceServiceService extends grpc.ServiceDefinition<grpc.Untyped
     teardown: IInWorkspaceServiceService_ITeardown;
     setupPairVeths: IInWorkspaceServiceService_ISetupPairVeths;
     workspaceInfo: IInWorkspaceServiceService_IWorkspaceInfo;
+    watchDiskUsage: IInWorkspaceServiceService_IWatchDiskUsage;
 }
 
 interface IInWorkspaceServiceService_IPrepareForUserNS extends grpc.MethodDefinition<workspace_daemon_pb.PrepareForUserNSRequest, workspace_daemon_pb.PrepareForUserNSResponse> {
@@ -116,6 +117,15 @@ interface IInWorkspaceServiceService_IWorkspaceInfo extends grpc.MethodDefinitio
     responseSerialize: grpc.serialize<workspace_daemon_pb.WorkspaceInfoResponse>;
     responseDeserialize: grpc.deserialize<workspace_daemon_pb.WorkspaceInfoResponse>;
 }
+interface IInWorkspaceServiceService_IWatchDiskUsage extends grpc.MethodDefinition<workspace_daemon_pb.WatchDiskUsageRequest, workspace_daemon_pb.DiskUsage> {
+    path: "/iws.InWorkspaceService/WatchDiskUsage";
+    requestStream: false;
+    responseStream: true;
+    requestSerialize: grpc.serialize<workspace_daemon_pb.WatchDiskUsageRequest>;
+    requestDeserialize: grpc.deserialize<workspace_daemon_pb.WatchDiskUsageRequest>;
+    responseSerialize: grpc.serialize<workspace_daemon_pb.DiskUsage>;
+    responseDeserialize: grpc.deserialize<workspace_daemon_pb.DiskUsage>;
+}
 
 export const InWorkspaceServiceService: IInWorkspaceServiceService;
 
@@ -130,6 +140,7 @@ export interface IInWorkspaceServiceServer extends grpc.UntypedServiceImplementa
     teardown: grpc.handleUnaryCall<workspace_daemon_pb.TeardownRequest, workspace_daemon_pb.TeardownResponse>;
     setupPairVeths: grpc.handleUnaryCall<workspace_daemon_pb.SetupPairVethsRequest, workspace_daemon_pb.SetupPairVethsResponse>;
     workspaceInfo: grpc.handleUnaryCall<workspace_daemon_pb.WorkspaceInfoRequest, workspace_daemon_pb.WorkspaceInfoResponse>;
+    watchDiskUsage: grpc.handleServerStreamingCall<workspace_daemon_pb.WatchDiskUsageRequest, workspace_daemon_pb.DiskUsage>;
 }
 
 export interface IInWorkspaceServiceClient {
@@ -163,6 +174,8 @@ export interface IInWorkspaceServiceClient {
     workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
+    watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
+    watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
 }
 
 export class InWorkspaceServiceClient extends grpc.Client implements IInWorkspaceServiceClient {
@@ -197,10 +210,13 @@ export class InWorkspaceServiceClient extends grpc.Client implements IInWorkspac
     public workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     public workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     public workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
+    public watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
+    public watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
 }
 
 interface IWorkspaceInfoServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
     workspaceInfo: IWorkspaceInfoServiceService_IWorkspaceInfo;
+    watchDiskUsage: IWorkspaceInfoServiceService_IWatchDiskUsage;
 }
 
 interface IWorkspaceInfoServiceService_IWorkspaceInfo extends grpc.MethodDefinition<workspace_daemon_pb.WorkspaceInfoRequest, workspace_daemon_pb.WorkspaceInfoResponse> {
@@ -212,17 +228,29 @@ interface IWorkspaceInfoServiceService_IWorkspaceInfo extends grpc.MethodDefinit
     responseSerialize: grpc.serialize<workspace_daemon_pb.WorkspaceInfoResponse>;
     responseDeserialize: grpc.deserialize<workspace_daemon_pb.WorkspaceInfoResponse>;
 }
+interface IWorkspaceInfoServiceService_IWatchDiskUsage extends grpc.MethodDefinition<workspace_daemon_pb.WatchDiskUsageRequest, workspace_daemon_pb.DiskUsage> {
+    path: "/iws.WorkspaceInfoService/WatchDiskUsage";
+    requestStream: false;
+    responseStream: true;
+    requestSerialize: grpc.serialize<workspace_daemon_pb.WatchDiskUsageRequest>;
+    requestDeserialize: grpc.deserialize<workspace_daemon_pb.WatchDiskUsageRequest>;
+    responseSerialize: grpc.serialize<workspace_daemon_pb.DiskUsage>;
+    responseDeserialize: grpc.deserialize<workspace_daemon_pb.DiskUsage>;
+}
 
 export const WorkspaceInfoServiceService: IWorkspaceInfoServiceService;
 
 export interface IWorkspaceInfoServiceServer extends grpc.UntypedServiceImplementation {
     workspaceInfo: grpc.handleUnaryCall<workspace_daemon_pb.WorkspaceInfoRequest, workspace_daemon_pb.WorkspaceInfoResponse>;
+    watchDiskUsage: grpc.handleServerStreamingCall<workspace_daemon_pb.WatchDiskUsageRequest, workspace_daemon_pb.DiskUsage>;
 }
 
 export interface IWorkspaceInfoServiceClient {
     workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
+    watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
+    watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
 }
 
 export class WorkspaceInfoServiceClient extends grpc.Client implements IWorkspaceInfoServiceClient {
@@ -230,4 +258,6 @@ export class WorkspaceInfoServiceClient extends grpc.Client implements IWorkspac
     public workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     public workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
     public workspaceInfo(request: workspace_daemon_pb.WorkspaceInfoRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: workspace_daemon_pb.WorkspaceInfoResponse) => void): grpc.ClientUnaryCall;
+    public watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
+    public watchDiskUsage(request: workspace_daemon_pb.WatchDiskUsageRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<workspace_daemon_pb.DiskUsage>;
 }
@@ -10,6 +10,17 @@
 var grpc = require('@grpc/grpc-js');
 var workspace_daemon_pb = require('./workspace_daemon_pb.js');
 
+function serialize_iws_DiskUsage(arg) {
+  if (!(arg instanceof workspace_daemon_pb.DiskUsage)) {
+    throw new Error('Expected argument of type iws.DiskUsage');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_iws_DiskUsage(buffer_arg) {
+  return workspace_daemon_pb.DiskUsage.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_iws_EvacuateCGroupRequest(arg) {
   if (!(arg instanceof workspace_daemon_pb.EvacuateCGroupRequest)) {
     throw new Error('Expected argument of type iws.EvacuateCGroupRequest');
@@ -142,6 +153,17 @@ function deserialize_iws_UmountProcResponse(buffer_arg) {
   return workspace_daemon_pb.UmountProcResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_iws_WatchDiskUsageRequest(arg) {
+  if (!(arg instanceof workspace_daemon_pb.WatchDiskUsageRequest)) {
+    throw new Error('Expected argument of type iws.WatchDiskUsageRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_iws_WatchDiskUsageRequest(buffer_arg) {
+  return workspace_daemon_pb.WatchDiskUsageRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_iws_WorkspaceInfoRequest(arg) {
   if (!(arg instanceof workspace_daemon_pb.WorkspaceInfoRequest)) {
     throw new Error('Expected argument of type iws.WorkspaceInfoRequest');
@@ -325,6 +347,18 @@ workspaceInfo: {
     responseSerialize: serialize_iws_WorkspaceInfoResponse,
     responseDeserialize: deserialize_iws_WorkspaceInfoResponse,
   },
+  // WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+watchDiskUsage: {
+    path: '/iws.InWorkspaceService/WatchDiskUsage',
+    requestStream: false,
+    responseStream: true,
+    requestType: workspace_daemon_pb.WatchDiskUsageRequest,
+    responseType: workspace_daemon_pb.DiskUsage,
+    requestSerialize: serialize_iws_WatchDiskUsageRequest,
+    requestDeserialize: deserialize_iws_WatchDiskUsageRequest,
+    responseSerialize: serialize_iws_DiskUsage,
+    responseDeserialize: deserialize_iws_DiskUsage,
+  },
 };
 
 exports.InWorkspaceServiceClient = grpc.makeGenericClientConstructor(InWorkspaceServiceService);
@@ -341,6 +375,18 @@ workspaceInfo: {
     responseSerialize: serialize_iws_WorkspaceInfoResponse,
     responseDeserialize: deserialize_iws_WorkspaceInfoResponse,
   },
+  // WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+watchDiskUsage: {
+    path: '/iws.WorkspaceInfoService/WatchDiskUsage',
+    requestStream: false,
+    responseStream: true,
+    requestType: workspace_daemon_pb.WatchDiskUsageRequest,
+    responseType: workspace_daemon_pb.DiskUsage,
+    requestSerialize: serialize_iws_WatchDiskUsageRequest,
+    requestDeserialize: deserialize_iws_WatchDiskUsageRequest,
+    responseSerialize: serialize_iws_DiskUsage,
+    responseDeserialize: deserialize_iws_DiskUsage,
+  },
 };
 
 exports.WorkspaceInfoServiceClient = grpc.makeGenericClientConstructor(WorkspaceInfoServiceService);
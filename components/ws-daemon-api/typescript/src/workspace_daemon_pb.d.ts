@@ -434,6 +434,49 @@ export namespace Memory {
     }
 }
 
+export class WatchDiskUsageRequest extends jspb.Message {
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): WatchDiskUsageRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: WatchDiskUsageRequest): WatchDiskUsageRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: WatchDiskUsageRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): WatchDiskUsageRequest;
+    static deserializeBinaryFromReader(message: WatchDiskUsageRequest, reader: jspb.BinaryReader): WatchDiskUsageRequest;
+}
+
+export namespace WatchDiskUsageRequest {
+    export type AsObject = {
+    }
+}
+
+export class DiskUsage extends jspb.Message {
+    getUsed(): number;
+    setUsed(value: number): DiskUsage;
+    getLimit(): number;
+    setLimit(value: number): DiskUsage;
+    getSoftLimit(): number;
+    setSoftLimit(value: number): DiskUsage;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): DiskUsage.AsObject;
+    static toObject(includeInstance: boolean, msg: DiskUsage): DiskUsage.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: DiskUsage, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): DiskUsage;
+    static deserializeBinaryFromReader(message: DiskUsage, reader: jspb.BinaryReader): DiskUsage;
+}
+
+export namespace DiskUsage {
+    export type AsObject = {
+        used: number,
+        limit: number,
+        softLimit: number,
+    }
+}
+
 export enum FSShiftMethod {
     SHIFTFS = 0,
 }
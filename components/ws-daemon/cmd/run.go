@@ -8,6 +8,8 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -22,8 +24,12 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/watch"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/config"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/controller"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cpulimit"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/daemon"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/netaccounting"
 )
 
 const grpcServerName = "wsdaemon"
@@ -38,6 +44,10 @@ var runCmd = &cobra.Command{
 		if err != nil {
 			log.WithError(err).Fatal("Cannot read configuration. Maybe missing --config?")
 		}
+		err = cfg.ApplyLogLevel()
+		if err != nil {
+			log.WithError(err).Fatal("Cannot apply log level.")
+		}
 
 		createLVMDevices()
 
@@ -62,6 +72,11 @@ var runCmd = &cobra.Command{
 			log.WithError(err).Fatal("Cannot set up server.")
 		}
 
+		api.RegisterContentProgressServiceServer(srv.GRPC(), controller.NewContentProgressService(dmn.ContentProgress()))
+		api.RegisterNetworkAccountingServiceServer(srv.GRPC(), netaccounting.NewService(dmn.NetworkAccounting()))
+		api.RegisterDrainServiceServer(srv.GRPC(), controller.NewDrainService(dmn.Drain()))
+		api.RegisterCPULimitServiceServer(srv.GRPC(), cpulimit.NewService(dmn.CPULimiter()))
+
 		health.AddReadinessCheck("ws-daemon", dmn.ReadinessProbe())
 		health.AddReadinessCheck("disk-space", freeDiskSpace(cfg.Daemon))
 
@@ -73,7 +88,13 @@ var runCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		err = watch.File(ctx, configFile, func() {
+		// The configuration is reloaded when its ConfigMap changes, or on SIGHUP, such that tuning the
+		// limits does not require restarting ws-daemon on every node.
+		var reloadMu sync.Mutex
+		reload := func() {
+			reloadMu.Lock()
+			defer reloadMu.Unlock()
+
 			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 			defer cancel()
 
@@ -83,15 +104,37 @@ var runCmd = &cobra.Command{
 				return
 			}
 
+			err = cfg.ApplyLogLevel()
+			if err != nil {
+				log.WithError(err).Warn("Cannot reload log level.")
+			}
+
 			err = dmn.ReloadConfig(ctx, &cfg.Daemon)
 			if err != nil {
 				log.WithError(err).Warn("Cannot reload configuration.")
 			}
-		})
+		}
+
+		err = watch.File(ctx, configFile, reload)
 		if err != nil {
 			log.WithError(err).Fatal("Cannot start watch of configuration file.")
 		}
 
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		go func() {
+			for {
+				select {
+				case <-sighup:
+					log.WithField("path", configFile).Info("reloading configuration after SIGHUP")
+					reload()
+				case <-ctx.Done():
+					signal.Stop(sighup)
+					return
+				}
+			}
+		}()
+
 		err = syscall.Setpriority(syscall.PRIO_PROCESS, os.Getpid(), -19)
 		if err != nil {
 			log.WithError(err).Error("cannot change ws-daemon priority")
@@ -443,6 +443,62 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:  "setup-egress-limit",
+				Usage: "shape the egress bandwidth of the network namespace with a token bucket filter",
+				Flags: []cli.Flag{
+					&cli.Uint64Flag{
+						Name:     "rate",
+						Usage:    "egress bandwidth in bytes per second",
+						Required: true,
+					},
+					&cli.Uint64Flag{
+						Name:  "burst",
+						Usage: "bytes which can be sent at once, defaults to a tenth of the rate",
+					},
+					&cli.StringFlag{
+						Name:  "interface",
+						Value: "eth0",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					rate := c.Uint64("rate")
+					if rate == 0 {
+						return xerrors.Errorf("rate must be positive")
+					}
+					burst := c.Uint64("burst")
+					if burst == 0 {
+						burst = rate / 10
+					}
+					ifname := c.String("interface")
+
+					link, err := netlink.LinkByName(ifname)
+					if err != nil {
+						return xerrors.Errorf("cannot get network device %s: %w", ifname, err)
+					}
+					if mtu := uint64(link.Attrs().MTU); burst < mtu {
+						burst = mtu
+					}
+
+					// tc qdisc replace dev eth0 root tbf rate <rate>bps burst <burst> latency 50ms
+					const latency = 50 * time.Millisecond
+					qdisc := &netlink.Tbf{
+						QdiscAttrs: netlink.QdiscAttrs{
+							LinkIndex: link.Attrs().Index,
+							Handle:    netlink.MakeHandle(1, 0),
+							Parent:    netlink.HANDLE_ROOT,
+						},
+						Rate:   rate,
+						Limit:  uint32(float64(rate)*latency.Seconds() + float64(burst)),
+						Buffer: netlink.Xmittime(rate, uint32(burst)),
+					}
+					if err := netlink.QdiscReplace(qdisc); err != nil {
+						return xerrors.Errorf("failed to apply egress limit: %v", err)
+					}
+
+					return nil
+				},
+			},
 			{
 				Name:  "setup-connection-limit",
 				Usage: "set up network connection rate limiting",
@@ -11,33 +11,85 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	v2 "github.com/containerd/cgroups/v2"
+	cgroups "github.com/gitpod-io/gitpod/common-go/cgroups/v2"
+	"github.com/gitpod-io/gitpod/common-go/kubernetes"
 	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type IOLimiterV2 struct {
-	limits *v2.Resources
+	limits ioLimits
 
 	cond *sync.Cond
 
 	devices []string
+
+	workspacesLimitedCounterVec *prometheus.CounterVec
+	ioStalledSecondsCounterVec  *prometheus.CounterVec
+}
+
+// ioLimits are the io.max limits of a workspace. Zero values leave the respective limit unset.
+type ioLimits struct {
+	WriteBytesPerSecond int64
+	ReadBytesPerSecond  int64
+	WriteIOPS           int64
+	ReadIOPS            int64
 }
 
+// ioLimitSourceNode and ioLimitSourceClass label the metrics of workspaces which are limited by the
+// node-wide limits only, or by limits of their workspace class.
+const (
+	ioLimitSourceNode  = "node"
+	ioLimitSourceClass = "class"
+)
+
+// ioStallScrapeInterval is how often we observe the IO pressure of limited workspaces
+const ioStallScrapeInterval = 10 * time.Second
+
+var _ prometheus.Collector = &IOLimiterV2{}
+
 func NewIOLimiterV2(writeBytesPerSecond, readBytesPerSecond, writeIOPs, readIOPs int64) (*IOLimiterV2, error) {
 	devices := buildDevices()
 	log.WithField("devices", devices).Debug("io limiting devices")
 	return &IOLimiterV2{
-		limits: buildV2Limits(writeBytesPerSecond, readBytesPerSecond, writeIOPs, readIOPs, devices),
+		limits: ioLimits{
+			WriteBytesPerSecond: writeBytesPerSecond,
+			ReadBytesPerSecond:  readBytesPerSecond,
+			WriteIOPS:           writeIOPs,
+			ReadIOPS:            readIOPs,
+		},
 
 		cond:    sync.NewCond(&sync.Mutex{}),
 		devices: devices,
+
+		workspacesLimitedCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "iolimit_workspaces_limited_total",
+			Help: "Number of workspaces whose IO was limited",
+		}, []string{"source"}),
+		ioStalledSecondsCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "iolimit_workspaces_io_stalled_seconds_total",
+			Help: "Time IO limited workspaces were fully stalled on IO",
+		}, []string{"source"}),
 	}, nil
 }
 
 func (c *IOLimiterV2) Name() string  { return "iolimiter-v2" }
 func (c *IOLimiterV2) Type() Version { return Version2 }
 
+func (c *IOLimiterV2) Describe(ch chan<- *prometheus.Desc) {
+	c.workspacesLimitedCounterVec.Describe(ch)
+	c.ioStalledSecondsCounterVec.Describe(ch)
+}
+
+func (c *IOLimiterV2) Collect(ch chan<- prometheus.Metric) {
+	c.workspacesLimitedCounterVec.Collect(ch)
+	c.ioStalledSecondsCounterVec.Collect(ch)
+}
+
 func (c *IOLimiterV2) Apply(ctx context.Context, opts *PluginOptions) error {
 	update := make(chan struct{}, 1)
 	go func() {
@@ -60,18 +112,48 @@ func (c *IOLimiterV2) Apply(ctx context.Context, opts *PluginOptions) error {
 	go func() {
 		log.WithFields(log.OWI("", "", opts.InstanceId)).WithField("cgroupPath", opts.CgroupPath).Debug("starting io limiting")
 
-		_, err := v2.NewManager(opts.BasePath, filepath.Join("/", opts.CgroupPath), c.limits)
+		limits, source := c.workspaceLimits(opts.Annotations)
+		_, err := v2.NewManager(opts.BasePath, filepath.Join("/", opts.CgroupPath), limits)
 		if err != nil {
-			log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).WithField("basePath", opts.BasePath).WithField("cgroupPath", opts.CgroupPath).WithField("limits", c.limits).Warn("cannot write IO limits")
+			log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).WithField("basePath", opts.BasePath).WithField("cgroupPath", opts.CgroupPath).WithField("limits", limits).Warn("cannot write IO limits")
+		} else if len(limits.IO.Max) > 0 {
+			c.workspacesLimitedCounterVec.WithLabelValues(source).Inc()
+		}
+
+		io := cgroups.NewIOController(filepath.Join(opts.BasePath, opts.CgroupPath))
+		var lastStalled uint64
+		if psi, err := io.PSI(); err == nil {
+			lastStalled = psi.Full
 		}
 
+		ticker := time.NewTicker(ioStallScrapeInterval)
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-update:
-				_, err := v2.NewManager(opts.BasePath, filepath.Join("/", opts.CgroupPath), c.limits)
+				limits, source = c.workspaceLimits(opts.Annotations)
+				_, err := v2.NewManager(opts.BasePath, filepath.Join("/", opts.CgroupPath), limits)
+				if err != nil {
+					log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).WithField("basePath", opts.BasePath).WithField("cgroupPath", opts.CgroupPath).WithField("limits", limits).Error("cannot write IO limits")
+				}
+			case <-ticker.C:
+				if len(limits.IO.Max) == 0 {
+					continue
+				}
+
+				psi, err := io.PSI()
 				if err != nil {
-					log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).WithField("basePath", opts.BasePath).WithField("cgroupPath", opts.CgroupPath).WithField("limits", c.limits).Error("cannot write IO limits")
+					if !os.IsNotExist(err) {
+						log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).Warn("could not retrieve io psi")
+					}
+					continue
+				}
+				if psi.Full > lastStalled {
+					// PSI totals are reported in microseconds
+					c.ioStalledSecondsCounterVec.WithLabelValues(source).Add(float64(psi.Full-lastStalled) / 1e6)
 				}
+				lastStalled = psi.Full
 			case <-ctx.Done():
 				// Prior to shutting down though, we need to reset the IO limits to ensure we don't have
 				// processes stuck in the uninterruptable "D" (disk sleep) state. This would prevent the
@@ -93,12 +175,65 @@ func (c *IOLimiterV2) Update(writeBytesPerSecond, readBytesPerSecond, writeIOPs,
 	c.cond.L.Lock()
 	defer c.cond.L.Unlock()
 
-	c.limits = buildV2Limits(writeBytesPerSecond, readBytesPerSecond, writeIOPs, readIOPs, c.devices)
-	log.WithField("limits", c.limits.IO).Info("updating I/O cgroups v2 limits")
+	c.limits = ioLimits{
+		WriteBytesPerSecond: writeBytesPerSecond,
+		ReadBytesPerSecond:  readBytesPerSecond,
+		WriteIOPS:           writeIOPs,
+		ReadIOPS:            readIOPs,
+	}
+	log.WithField("limits", c.limits).Info("updating I/O cgroups v2 limits")
 
 	c.cond.Broadcast()
 }
 
+// workspaceLimits returns the IO limits of a workspace. The limits of its workspace class, passed on by ws-manager
+// through annotations, take precedence over the node-wide limits.
+func (c *IOLimiterV2) workspaceLimits(annotations map[string]string) (*v2.Resources, string) {
+	c.cond.L.Lock()
+	limits, source := classIOLimits(c.limits, annotations)
+	c.cond.L.Unlock()
+
+	return buildV2Limits(limits.WriteBytesPerSecond, limits.ReadBytesPerSecond, limits.WriteIOPS, limits.ReadIOPS, c.devices), source
+}
+
+func classIOLimits(node ioLimits, annotations map[string]string) (ioLimits, string) {
+	var (
+		limits = node
+		source = ioLimitSourceNode
+	)
+	override := func(annotation string, parse func(string) (int64, error), dst *int64) {
+		value, ok := annotations[annotation]
+		if !ok {
+			return
+		}
+		v, err := parse(value)
+		if err != nil || v <= 0 {
+			log.WithError(err).WithField("annotation", annotation).WithField("value", value).Warn("invalid IO limit annotation")
+			return
+		}
+		*dst = v
+		source = ioLimitSourceClass
+	}
+	override(kubernetes.WorkspaceIOWriteBandwidthLimitAnnotation, parseQuantity, &limits.WriteBytesPerSecond)
+	override(kubernetes.WorkspaceIOReadBandwidthLimitAnnotation, parseQuantity, &limits.ReadBytesPerSecond)
+	override(kubernetes.WorkspaceIOWriteIOPSLimitAnnotation, parseInt, &limits.WriteIOPS)
+	override(kubernetes.WorkspaceIOReadIOPSLimitAnnotation, parseInt, &limits.ReadIOPS)
+
+	return limits, source
+}
+
+func parseQuantity(value string) (int64, error) {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	return q.Value(), nil
+}
+
+func parseInt(value string) (int64, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
 func buildV2Limits(writeBytesPerSecond, readBytesPerSecond, writeIOPs, readIOPs int64, devices []string) *v2.Resources {
 	resources := &v2.Resources{
 		IO: &v2.IO{},
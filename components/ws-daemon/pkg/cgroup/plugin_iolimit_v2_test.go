@@ -0,0 +1,59 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cgroup
+
+import (
+	"testing"
+
+	"github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClassIOLimits(t *testing.T) {
+	node := ioLimits{WriteBytesPerSecond: 100, ReadBytesPerSecond: 200, WriteIOPS: 10, ReadIOPS: 20}
+
+	tests := []struct {
+		Name         string
+		Annotations  map[string]string
+		Expectation  ioLimits
+		ExpectSource string
+	}{
+		{
+			Name:         "no annotations",
+			Expectation:  node,
+			ExpectSource: ioLimitSourceNode,
+		},
+		{
+			Name: "class limits",
+			Annotations: map[string]string{
+				kubernetes.WorkspaceIOReadBandwidthLimitAnnotation: "1Mi",
+				kubernetes.WorkspaceIOWriteIOPSLimitAnnotation:     "500",
+			},
+			Expectation:  ioLimits{WriteBytesPerSecond: 100, ReadBytesPerSecond: 1024 * 1024, WriteIOPS: 500, ReadIOPS: 20},
+			ExpectSource: ioLimitSourceClass,
+		},
+		{
+			Name: "invalid annotations",
+			Annotations: map[string]string{
+				kubernetes.WorkspaceIOWriteBandwidthLimitAnnotation: "fast",
+				kubernetes.WorkspaceIOReadIOPSLimitAnnotation:       "-1",
+			},
+			Expectation:  node,
+			ExpectSource: ioLimitSourceNode,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			limits, source := classIOLimits(node, test.Annotations)
+			if diff := cmp.Diff(test.Expectation, limits); diff != "" {
+				t.Errorf("unexpected limits (-want +got):\n%s", diff)
+			}
+			if source != test.ExpectSource {
+				t.Errorf("unexpected source: expected %s, got %s", test.ExpectSource, source)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cgroup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	v2 "github.com/containerd/cgroups/v2"
+	"github.com/gitpod-io/gitpod/common-go/cgroups"
+	cgroupsv2 "github.com/gitpod-io/gitpod/common-go/cgroups/v2"
+	"github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MemoryLimiterV2 sets the memory.high and memory.swap.max limits of workspaces whose class configures them.
+// Above memory.high the kernel throttles and reclaims the workspace rather than OOM-killing it at its memory
+// limit, and swap gives it room to degrade further before that happens.
+type MemoryLimiterV2 struct {
+	workspacesLimitedCounterVec *prometheus.CounterVec
+	highEventsCounter           prometheus.Counter
+}
+
+// memoryLimits are the memory limits of a workspace class. Nil values leave the respective limit unset.
+type memoryLimits struct {
+	High    *int64
+	SwapMax *int64
+}
+
+// memoryEventsScrapeInterval is how often we observe the memory.high events of limited workspaces
+const memoryEventsScrapeInterval = 10 * time.Second
+
+var _ prometheus.Collector = &MemoryLimiterV2{}
+
+func NewMemoryLimiterV2() *MemoryLimiterV2 {
+	return &MemoryLimiterV2{
+		workspacesLimitedCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "memlimit_workspaces_limited_total",
+			Help: "Number of workspaces whose memory.high or memory.swap.max was set",
+		}, []string{"limit"}),
+		highEventsCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "memlimit_workspaces_high_events_total",
+			Help: "Number of times workspaces were throttled because they exceeded their memory.high limit",
+		}),
+	}
+}
+
+func (c *MemoryLimiterV2) Name() string  { return "memory-limiter-v2" }
+func (c *MemoryLimiterV2) Type() Version { return Version2 }
+
+func (c *MemoryLimiterV2) Describe(ch chan<- *prometheus.Desc) {
+	c.workspacesLimitedCounterVec.Describe(ch)
+	c.highEventsCounter.Describe(ch)
+}
+
+func (c *MemoryLimiterV2) Collect(ch chan<- prometheus.Metric) {
+	c.workspacesLimitedCounterVec.Collect(ch)
+	c.highEventsCounter.Collect(ch)
+}
+
+func (c *MemoryLimiterV2) Apply(ctx context.Context, opts *PluginOptions) error {
+	limits := classMemoryLimits(opts.Annotations)
+	if limits.High == nil && limits.SwapMax == nil {
+		return nil
+	}
+
+	fullCgroupPath := filepath.Join(opts.BasePath, opts.CgroupPath)
+	if limits.High != nil {
+		// memory.high at or above memory.max would never throttle the workspace before it is OOM-killed
+		max, err := cgroupsv2.NewMemoryController(fullCgroupPath).Max()
+		if err == nil && uint64(*limits.High) >= max {
+			log.WithFields(log.OWI("", "", opts.InstanceId)).WithField("high", *limits.High).WithField("max", max).Warn("memory high limit is not below the memory limit, ignoring it")
+			limits.High = nil
+		}
+	}
+
+	// We write the limits separately, because memory.swap.max does not exist if the node has no swap accounting.
+	if limits.High != nil {
+		_, err := v2.NewManager(opts.BasePath, filepath.Join("/", opts.CgroupPath), &v2.Resources{Memory: &v2.Memory{High: limits.High}})
+		if err != nil {
+			log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).WithField("cgroupPath", opts.CgroupPath).WithField("high", *limits.High).Warn("cannot write memory high limit")
+			limits.High = nil
+		} else {
+			c.workspacesLimitedCounterVec.WithLabelValues("high").Inc()
+		}
+	}
+	if limits.SwapMax != nil {
+		_, err := v2.NewManager(opts.BasePath, filepath.Join("/", opts.CgroupPath), &v2.Resources{Memory: &v2.Memory{Swap: limits.SwapMax}})
+		if err != nil {
+			log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).WithField("cgroupPath", opts.CgroupPath).WithField("swapMax", *limits.SwapMax).Warn("cannot write swap limit")
+		} else {
+			c.workspacesLimitedCounterVec.WithLabelValues("swap").Inc()
+		}
+	}
+	if limits.High == nil {
+		return nil
+	}
+
+	go func() {
+		eventsFile := filepath.Join(fullCgroupPath, "memory.events")
+		var lastHigh uint64
+		if events, err := cgroups.ReadFlatKeyedFile(eventsFile); err == nil {
+			lastHigh = events["high"]
+		}
+
+		ticker := time.NewTicker(memoryEventsScrapeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				events, err := cgroups.ReadFlatKeyedFile(eventsFile)
+				if err != nil {
+					if !os.IsNotExist(err) {
+						log.WithError(err).WithFields(log.OWI("", "", opts.InstanceId)).Warn("could not retrieve memory events")
+					}
+					continue
+				}
+				if high := events["high"]; high > lastHigh {
+					c.highEventsCounter.Add(float64(high - lastHigh))
+					lastHigh = high
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// classMemoryLimits returns the memory limits of a workspace class, passed on by ws-manager through annotations
+func classMemoryLimits(annotations map[string]string) memoryLimits {
+	var limits memoryLimits
+	parse := func(annotation string, min int64) *int64 {
+		value, ok := annotations[annotation]
+		if !ok {
+			return nil
+		}
+		v, err := parseQuantity(value)
+		if err != nil || v < min {
+			log.WithError(err).WithField("annotation", annotation).WithField("value", value).Warn("invalid memory limit annotation")
+			return nil
+		}
+		return &v
+	}
+	// a swap limit of zero disables swap, while a memory.high limit of zero would throttle the workspace entirely
+	limits.High = parse(kubernetes.WorkspaceMemoryHighAnnotation, 1)
+	limits.SwapMax = parse(kubernetes.WorkspaceSwapMaxAnnotation, 0)
+
+	return limits
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cgroup
+
+import (
+	"testing"
+
+	"github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClassMemoryLimits(t *testing.T) {
+	ptr := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		Name        string
+		Annotations map[string]string
+		Expectation memoryLimits
+	}{
+		{
+			Name: "no annotations",
+		},
+		{
+			Name: "class limits",
+			Annotations: map[string]string{
+				kubernetes.WorkspaceMemoryHighAnnotation: "7Gi",
+				kubernetes.WorkspaceSwapMaxAnnotation:    "4Gi",
+			},
+			Expectation: memoryLimits{High: ptr(7 * 1024 * 1024 * 1024), SwapMax: ptr(4 * 1024 * 1024 * 1024)},
+		},
+		{
+			Name: "swap disabled",
+			Annotations: map[string]string{
+				kubernetes.WorkspaceSwapMaxAnnotation: "0",
+			},
+			Expectation: memoryLimits{SwapMax: ptr(0)},
+		},
+		{
+			Name: "invalid annotations",
+			Annotations: map[string]string{
+				kubernetes.WorkspaceMemoryHighAnnotation: "0",
+				kubernetes.WorkspaceSwapMaxAnnotation:    "lots",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			limits := classMemoryLimits(test.Annotations)
+			if diff := cmp.Diff(test.Expectation, limits); diff != "" {
+				t.Errorf("unexpected limits (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"encoding/json"
 	"os"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
+	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/daemon"
 )
 
@@ -28,6 +30,10 @@ func Read(fn string) (*Config, error) {
 	if err != nil {
 		return nil, xerrors.Errorf("cannot parse config file: %w", err)
 	}
+	err = cfg.Daemon.Content.Backup.Compression.Validate()
+	if err != nil {
+		return nil, xerrors.Errorf("invalid backup compression: %w", err)
+	}
 
 	return &cfg, nil
 }
@@ -35,4 +41,25 @@ func Read(fn string) (*Config, error) {
 type Config struct {
 	Daemon  daemon.Config                  `json:"daemon"`
 	Service baseserver.ServerConfiguration `json:"service"`
+
+	// LogLevel overrides the log level set through the environment and flags. Unlike those, it can be changed
+	// without restarting ws-daemon.
+	LogLevel string `json:"logLevel,omitempty"`
+}
+
+// ApplyLogLevel sets the configured log level, if there is one
+func (c *Config) ApplyLogLevel() error {
+	if c.LogLevel == "" {
+		return nil
+	}
+
+	level, err := logrus.ParseLevel(c.LogLevel)
+	if err != nil {
+		return xerrors.Errorf("invalid log level: %w", err)
+	}
+	if log.Log.Logger.GetLevel() != level {
+		log.WithField("level", level.String()).Info("changing log level")
+		log.Log.Logger.SetLevel(level)
+	}
+	return nil
 }
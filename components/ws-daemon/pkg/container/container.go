@@ -34,6 +34,13 @@ type Runtime interface {
 	// If the container, or its rootfs, is not found ErrNotFound is returned.
 	ContainerRootfs(ctx context.Context, id ID, opts OptsContainerRootfs) (loc string, err error)
 
+	// ContainerUpperdir finds the upperdir of the workspace container's rootfs, i.e. the files the workspace wrote
+	// to its container. The location returned here is accessible from the calling process.
+	//
+	// If the container is not found ErrNotFound is returned.
+	// If the container has no upperdir ErrNoUpperdir is returned.
+	ContainerUpperdir(ctx context.Context, id ID) (loc string, err error)
+
 	// ContainerCGroupPath finds the container's cgroup path on the node. Note: this path is not the complete path to the container's cgroup,
 	// but merely the suffix. To make it a complete path you need to add the cgroup base path (e.g. /sys/fs/cgroup) and the type of cgroup
 	// you care for, e.g. cpu: filepath.Join("/sys/fs/cgroup", "cpu", cgroupPath).
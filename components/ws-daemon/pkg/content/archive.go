@@ -66,11 +66,38 @@ func BuildTarbal(ctx context.Context, src string, dst string, opts ...carchive.T
 	if err != nil {
 		return xerrors.Errorf("Unable to create tar file: %v", err.Error())
 	}
+	defer tarFile.Close()
 
-	_, err = io.Copy(tarFile, tarReader)
+	var out io.Writer = tarFile
+	if cfg.Encrypt != nil {
+		var ew io.WriteCloser
+		ew, err = cfg.Encrypt(tarFile)
+		if err != nil {
+			return xerrors.Errorf("Unable to encrypt tar file: %v", err.Error())
+		}
+		out = ew
+		defer func() {
+			cerr := ew.Close()
+			if err == nil && cerr != nil {
+				err = xerrors.Errorf("Unable to encrypt tar file: %v", cerr.Error())
+			}
+		}()
+	}
+
+	span.LogKV("compression", string(cfg.Compression), "compressionLevel", cfg.CompressionLevel, "encrypted", cfg.Encrypt != nil)
+	w, err := carchive.NewCompressedWriter(out, cfg.Compression, cfg.CompressionLevel)
+	if err != nil {
+		return xerrors.Errorf("Unable to compress tar file: %v", err.Error())
+	}
+
+	_, err = io.Copy(w, tarReader)
 	if err != nil {
 		return xerrors.Errorf("Unable create tar file: %v", err.Error())
 	}
+	err = w.Close()
+	if err != nil {
+		return xerrors.Errorf("Unable to compress tar file: %v", err.Error())
+	}
 
 	return
 }
@@ -10,8 +10,10 @@ import (
 
 	"github.com/gitpod-io/gitpod/common-go/util"
 	cntntcfg "github.com/gitpod-io/gitpod/content-service/api/config"
+	carchive "github.com/gitpod-io/gitpod/content-service/pkg/archive"
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"golang.org/x/xerrors"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Config configures the workspace content service
@@ -31,6 +33,11 @@ type Config struct {
 	// Storage is some form of permanent file store to which we back up workspaces
 	Storage cntntcfg.StorageConfig `json:"storage"`
 
+	// Encryption configures the encryption of backups and snapshots with the key of the workspace's organization.
+	// Restoring content detects whether it is encrypted, hence content uploaded before encryption was configured
+	// remains restorable. Such content is encrypted using content-service's encrypt command.
+	Encryption cntntcfg.EncryptionConfig `json:"encryption,omitempty"`
+
 	// Backup configures the behaviour of ws-daemon during backup
 	Backup BackupConfig `json:"backup,omitempty"`
 
@@ -39,6 +46,35 @@ type Config struct {
 
 	// Initializer configures the isolated content initializer runtime
 	Initializer InitializerConfig `json:"initializer"`
+
+	// Quota configures how the storage quota of workspaces is enforced
+	Quota QuotaConfig `json:"quota,omitempty"`
+
+	// PrebuildCache configures the node-local cache of prebuild archives
+	PrebuildCache PrebuildCacheConfig `json:"prebuildCache,omitempty"`
+}
+
+// PrebuildCacheConfig configures the node-local cache of prebuild archives. Workspaces of the same prebuild
+// which start on a node share its archive rather than downloading it from remote storage each.
+type PrebuildCacheConfig struct {
+	// Location is the directory the archives are cached in. It should be on the node's disk, e.g. a hostPath,
+	// such that the cache outlives ws-daemon. If empty, prebuilds are not cached.
+	Location string `json:"location,omitempty"`
+
+	// MaxSize is the disk space the cached archives use at most. The least recently used archives are evicted first.
+	// Defaults to 20Gi.
+	MaxSize resource.Quantity `json:"maxSize,omitempty"`
+}
+
+// QuotaConfig configures the enforcement of workspace storage quotas using XFS project quotas.
+// The storage quota of a workspace is the storage limit of its workspace class.
+type QuotaConfig struct {
+	// Enforce fails workspaces whose storage quota cannot be installed, instead of running them without one
+	Enforce bool `json:"enforce,omitempty"`
+
+	// InitializationHeadroom is the fraction by which workspace content may exceed its storage quota while
+	// it is initialized, e.g. 0.1. If zero, only a soft limit applies during initialization.
+	InitializationHeadroom float64 `json:"initializationHeadroom,omitempty"`
 }
 
 type BackupConfig struct {
@@ -52,6 +88,38 @@ type BackupConfig struct {
 
 	// Period is the time between regular workspace backups
 	Period util.Duration `json:"period"`
+
+	// MaxConcurrent is the maximum number of backups uploaded at the same time on a node.
+	// Defaults to 5.
+	MaxConcurrent int `json:"maxConcurrent,omitempty"`
+
+	// UploadBandwidthPerSecond limits the upload rate of each backup, such that backing up many workspaces
+	// at once doesn't saturate the node's network. If zero, uploads are not throttled.
+	UploadBandwidthPerSecond resource.Quantity `json:"uploadBandwidthPerSecond,omitempty"`
+
+	// Compression configures how backups are compressed. Restoring a backup detects its compression,
+	// hence backups taken before the compression changed remain restorable.
+	Compression BackupCompression `json:"compression,omitempty"`
+
+	// Deduplicate splits regular backups into content-defined chunks which are stored by their digest, such that
+	// subsequent backups of the same workspace only upload the chunks which changed. Snapshots are never deduplicated.
+	// Compressed archives change throughout when little of their content changes, hence this works best without compression.
+	// Encrypted backups are never deduplicated.
+	Deduplicate bool `json:"deduplicate,omitempty"`
+}
+
+// BackupCompression configures the compression of backup archives
+type BackupCompression struct {
+	// Algorithm is either gzip or zstd. If empty, backups are not compressed.
+	Algorithm carchive.Compression `json:"algorithm,omitempty"`
+
+	// Level is the compression level, 1-9 for gzip and 1-22 for zstd. If zero, the default level of the algorithm is used.
+	Level int `json:"level,omitempty"`
+}
+
+// Validate checks if the compression algorithm is supported and the level is valid for it
+func (c BackupCompression) Validate() error {
+	return c.Algorithm.Validate(c.Level)
 }
 
 type UserNamespacesConfig struct {
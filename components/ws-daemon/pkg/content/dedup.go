@@ -0,0 +1,334 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+// dedupSignConcurrency is the number of chunk download URLs we sign, or chunks we delete, at the same time
+const dedupSignConcurrency = 16
+
+// DedupUploadResult describes the chunks of a deduplicated backup upload
+type DedupUploadResult struct {
+	Chunks         int
+	UploadedChunks int
+	UploadedBytes  int64
+	// Unreferenced are the objects of chunks which the backup no longer uses, see DeleteDedupChunks
+	Unreferenced []string
+}
+
+// UploadDeduplicated splits an archive into content-defined chunks and uploads those which don't exist in the
+// remote storage yet. The backup object itself is replaced by a manifest listing the chunks.
+// The digest annotation of the manifest is set to the manifest's digest, all other annotations are kept.
+func UploadDeduplicated(ctx context.Context, rs storage.DirectAccess, source, archiveDigest, name, tmpDir string, annotations map[string]string, opts ...storage.UploadOption) (res DedupUploadResult, err error) {
+	// The retention garbage collects chunks no backup references, unless an upload started since the last backup.
+	// Hence we mark the upload before we look for chunks to reuse.
+	err = markDedupUpload(ctx, rs, tmpDir)
+	if err != nil {
+		return res, xerrors.Errorf("cannot mark dedup upload: %w", err)
+	}
+
+	existing, err := rs.ListObjects(ctx, rs.BackupObject(storage.DedupChunkPrefix))
+	if err != nil {
+		return res, xerrors.Errorf("cannot list existing chunks: %w", err)
+	}
+	known := make(map[string]struct{}, len(existing))
+	for _, obj := range existing {
+		known[path.Base(obj)] = struct{}{}
+	}
+	referenced := make(map[string]struct{}, len(existing))
+
+	f, err := os.Open(source)
+	if err != nil {
+		return res, err
+	}
+	defer f.Close()
+
+	manifest := &storage.DedupManifest{Digest: archiveDigest}
+	err = storage.SplitChunks(f, func(chunk storage.DedupChunk, data []byte) error {
+		manifest.Chunks = append(manifest.Chunks, chunk)
+		manifest.Size += chunk.Size
+
+		obj := storage.DedupChunkObject(chunk.Digest)
+		referenced[path.Base(obj)] = struct{}{}
+		if _, exists := known[path.Base(obj)]; exists {
+			return nil
+		}
+
+		err := uploadChunk(ctx, rs, data, obj, tmpDir, opts)
+		if err != nil {
+			return xerrors.Errorf("cannot upload chunk %s: %w", chunk.Digest, err)
+		}
+		known[path.Base(obj)] = struct{}{}
+		res.UploadedChunks++
+		res.UploadedBytes += chunk.Size
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Chunks = len(manifest.Chunks)
+
+	mf, err := os.CreateTemp(tmpDir, "dedup-manifest-*")
+	if err != nil {
+		return res, err
+	}
+	defer os.Remove(mf.Name())
+	err = storage.WriteDedupManifest(mf, manifest)
+	mf.Close()
+	if err != nil {
+		return res, xerrors.Errorf("cannot write dedup manifest: %w", err)
+	}
+
+	mdgst, err := storage.FileDigest(mf.Name())
+	if err != nil {
+		return res, xerrors.Errorf("cannot compute manifest digest: %w", err)
+	}
+	manifestAnnotations := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		manifestAnnotations[k] = v
+	}
+	manifestAnnotations[storage.ObjectAnnotationDigest] = mdgst.String()
+	opts = append(opts, storage.WithContentType(storage.DedupManifestContentType), storage.WithAnnotations(manifestAnnotations))
+
+	_, _, err = rs.Upload(ctx, mf.Name(), name, opts...)
+	if err != nil {
+		return res, xerrors.Errorf("cannot upload dedup manifest: %w", err)
+	}
+
+	// Snapshots are never deduplicated, hence the regular backup is the only one which references chunks
+	for _, obj := range existing {
+		if _, ok := referenced[path.Base(obj)]; !ok {
+			res.Unreferenced = append(res.Unreferenced, obj)
+		}
+	}
+	return res, nil
+}
+
+// DeleteDedupChunks deletes the objects of chunks which no backup references anymore
+func DeleteDedupChunks(ctx context.Context, ps storage.PresignedAccess, bucket string, objs []string) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(dedupSignConcurrency)
+	for _, obj := range objs {
+		obj := obj
+		eg.Go(func() error {
+			err := ps.DeleteObject(ctx, bucket, &storage.DeleteObjectQuery{Name: obj})
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return xerrors.Errorf("cannot delete chunk %s: %w", obj, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// markDedupUpload writes the marker of an upload which is in progress
+func markDedupUpload(ctx context.Context, rs storage.DirectAccess, tmpDir string) error {
+	f, err := os.CreateTemp(tmpDir, "dedup-upload-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	_, _, err = rs.Upload(ctx, f.Name(), storage.DedupUploadMarker)
+	return err
+}
+
+func uploadChunk(ctx context.Context, rs storage.DirectAccess, data []byte, obj, tmpDir string, opts []storage.UploadOption) error {
+	f, err := os.CreateTemp(tmpDir, "dedup-chunk-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	_, _, err = rs.Upload(ctx, f.Name(), obj, opts...)
+	return err
+}
+
+// collectDedupChunks adds the download URLs of the chunks of a deduplicated backup to the remote content.
+// Backups which are regular archives are left as they are.
+func collectDedupChunks(ctx context.Context, rs storage.DirectAccess, ps storage.PresignedAccess, bucket string, backup storage.DownloadInfo, rc map[string]storage.DownloadInfo) error {
+	manifest, err := fetchDedupManifest(ctx, backup.URL)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return nil
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]struct{}, len(manifest.Chunks))
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(dedupSignConcurrency)
+	for _, chunk := range manifest.Chunks {
+		obj := storage.DedupChunkObject(chunk.Digest)
+		if _, exists := seen[obj]; exists {
+			continue
+		}
+		seen[obj] = struct{}{}
+
+		eg.Go(func() error {
+			info, err := ps.SignDownload(ctx, bucket, rs.BackupObject(obj), &storage.SignedURLOptions{})
+			if err != nil {
+				return xerrors.Errorf("cannot sign chunk %s: %w", obj, err)
+			}
+			mu.Lock()
+			rc[obj] = *info
+			mu.Unlock()
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// fetchDedupManifest downloads the manifest of a deduplicated backup. If the backup is a regular archive
+// the download is aborted after its first bytes and nil is returned.
+func fetchDedupManifest(ctx context.Context, url string) (*storage.DedupManifest, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download backup manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("cannot download backup manifest: non-OK status code: %v", resp.StatusCode)
+	}
+
+	return storage.ReadDedupManifest(resp.Body)
+}
+
+// restoreDedupBackup downloads the chunks of a deduplicated backup and reassembles its archive in a temporary file.
+// chunkURL returns the download URL of a chunk, which is a fresh one if refresh is set.
+func restoreDedupBackup(ctx context.Context, manifest *storage.DedupManifest, chunkURL func(obj string, refresh bool) (string, error)) (res *os.File, err error) {
+	dir, err := os.MkdirTemp("", "dedup-chunks-*")
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create chunk directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		objs []string
+		seen = make(map[string]struct{}, len(manifest.Chunks))
+	)
+	for _, chunk := range manifest.Chunks {
+		obj := storage.DedupChunkObject(chunk.Digest)
+		if _, exists := seen[obj]; exists {
+			continue
+		}
+		seen[obj] = struct{}{}
+		objs = append(objs, obj)
+	}
+
+	downloadStart := time.Now()
+	err = downloadDedupChunks(dir, objs, chunkURL, false)
+	if err != nil {
+		// chunk URLs may have expired while aria2c was retrying, hence we try once more with fresh ones
+		log.WithError(err).Warn("cannot download chunks, retrying with fresh URLs")
+		err = downloadDedupChunks(dir, objs, chunkURL, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("downloadDuration", time.Since(downloadStart).String()).WithField("chunks", len(seen)).Info("aria2c chunk download duration")
+
+	res, err = os.CreateTemp("", "remote-content-*")
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create temporal file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			res.Close()
+			os.Remove(res.Name())
+		}
+	}()
+
+	err = storage.RestoreDedupChunks(ctx, res, manifest, func(ctx context.Context, chunk storage.DedupChunk) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, path.Base(storage.DedupChunkObject(chunk.Digest))))
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = res.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// downloadDedupChunks downloads the chunk objects into dir. Chunks which were downloaded in full before are skipped,
+// aria2c keeps a control file next to the ones it did not complete.
+func downloadDedupChunks(dir string, objs []string, chunkURL func(obj string, refresh bool) (string, error), refresh bool) error {
+	var input strings.Builder
+	for _, obj := range objs {
+		fn := filepath.Join(dir, path.Base(obj))
+		if _, err := os.Stat(fn); err == nil {
+			if _, err := os.Stat(fn + ".aria2"); os.IsNotExist(err) {
+				continue
+			}
+		}
+
+		url, err := chunkURL(obj, refresh)
+		if err != nil {
+			return xerrors.Errorf("no download URL for chunk %s: %w", obj, err)
+		}
+		fmt.Fprintf(&input, "%s\n  out=%s\n", url, path.Base(obj))
+	}
+	if input.Len() == 0 {
+		return nil
+	}
+
+	inputFile := filepath.Join(dir, "chunks.txt")
+	err := os.WriteFile(inputFile, []byte(input.String()), 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(inputFile)
+
+	args := []string{
+		"-j12", "-x4",
+		"--retry-wait=5",
+		"--log-level=error",
+		"--allow-overwrite=true",
+		"-d", dir,
+		"-i", inputFile,
+	}
+	cmd := exec.Command("aria2c", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.WithError(err).WithField("out", string(out)).Error("unexpected error downloading chunks")
+		return xerrors.Errorf("unexpected error downloading chunks")
+	}
+	return nil
+}
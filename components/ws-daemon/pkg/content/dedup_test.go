@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+// recordingStorage records the uploads and listings of the remote storage in the order they happen
+type recordingStorage struct {
+	storage.DirectNoopStorage
+
+	calls []string
+}
+
+func (rs *recordingStorage) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	rs.calls = append(rs.calls, "list "+prefix)
+	return nil, nil
+}
+
+func (rs *recordingStorage) Upload(ctx context.Context, source string, name string, opts ...storage.UploadOption) (string, string, error) {
+	rs.calls = append(rs.calls, "upload "+name)
+	return "", name, nil
+}
+
+func (rs *recordingStorage) BackupObject(name string) string {
+	return name
+}
+
+func TestUploadDeduplicatedMarksUploadFirst(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "backup.tar")
+	if err := os.WriteFile(source, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := &recordingStorage{}
+	_, err := UploadDeduplicated(context.Background(), rs, source, "", storage.DefaultBackup, tmpDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the retention must see the upload before it could reuse any chunk
+	chunk := storage.DedupChunkObject("sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+	expectation := []string{
+		"upload " + storage.DedupUploadMarker,
+		"list " + storage.DedupChunkPrefix,
+		"upload " + chunk,
+		"upload " + storage.DefaultBackup,
+	}
+	if diff := cmp.Diff(expectation, rs.calls); diff != "" {
+		t.Errorf("unexpected storage calls (-want +got):\n%s", diff)
+	}
+}
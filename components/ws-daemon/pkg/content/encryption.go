@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+// CollectArchiveKeys unwraps the data keys of the encrypted archives among the remote content, such that the content
+// initializer can decrypt them without access to the keys of organizations. Unencrypted archives have no key.
+func CollectArchiveKeys(ctx context.Context, kp encryption.KeyProvider, remoteContent map[string]storage.DownloadInfo) (map[string][]byte, error) {
+	res := make(map[string][]byte)
+	for name, info := range remoteContent {
+		if strings.HasPrefix(name, storage.DedupChunkPrefix) {
+			// chunks belong to a deduplicated backup, which is never encrypted
+			continue
+		}
+
+		hdr, err := fetchEncryptionHeader(ctx, info.URL)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot read encryption header of %s: %w", name, err)
+		}
+		if hdr == nil {
+			continue
+		}
+
+		key, err := encryption.UnwrapKey(ctx, kp, hdr)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot decrypt %s: %w", name, err)
+		}
+		res[name] = key
+	}
+	return res, nil
+}
+
+// fetchEncryptionHeader downloads the encryption header of an archive. The download is aborted after the header,
+// and nil is returned if the archive is not encrypted.
+func fetchEncryptionHeader(ctx context.Context, url string) (*encryption.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("non-OK status code: %v", resp.StatusCode)
+	}
+
+	return encryption.ReadHeader(resp.Body)
+}
@@ -12,7 +12,6 @@ import (
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
-	"github.com/gitpod-io/gitpod/content-service/pkg/initializer"
 	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
@@ -23,25 +22,26 @@ import (
 )
 
 // WorkspaceLifecycleHooks configures the lifecycle hooks for all workspaces
-func WorkspaceLifecycleHooks(cfg Config, workspaceCIDR string, uidmapper *iws.Uidmapper, xfs *quota.XFS, cgroupMountPoint string) map[session.WorkspaceState][]session.WorkspaceLivecycleHook {
+func WorkspaceLifecycleHooks(cfg Config, workspaceCIDR string, uidmapper *iws.Uidmapper, xfs *quota.XFS, cgroupMountPoint string, storageMetrics *storage.Metrics) map[session.WorkspaceState][]session.WorkspaceLivecycleHook {
 	// startIWS starts the in-workspace service for a workspace. This lifecycle hook is idempotent, hence can - and must -
 	// be called on initialization and ready. The on-ready hook exists only to support ws-daemon restarts.
 	startIWS := iws.ServeWorkspace(uidmapper, api.FSShiftMethod(cfg.UserNamespaces.FSShift), cgroupMountPoint, workspaceCIDR)
 
 	return map[session.WorkspaceState][]session.WorkspaceLivecycleHook{
 		session.WorkspaceInitializing: {
-			hookSetupWorkspaceLocation,
+			hookSetupWorkspaceLocation(uidmapper.Config.Mapping),
 			startIWS, // workspacekit is waiting for starting IWS, so it needs to start as soon as possible.
-			hookSetupRemoteStorage(cfg),
-			// When starting a workspace, use soft limit for the following reason to ensure content is restored
+			hookSetupRemoteStorage(cfg, storageMetrics),
+			// When starting a workspace, use soft limit (or a hard limit with some headroom) for the following reason
+			// to ensure content is restored
 			// - workspacekit needs to generate some temporary file when starting a workspace
 			// - when extracting tar file, tar command create some symlinks following a original content
-			hookInstallQuota(xfs, false),
+			hookInstallQuota(xfs, cfg.Quota, false),
 		},
 		session.WorkspaceReady: {
 			startIWS,
-			hookSetupRemoteStorage(cfg),
-			hookInstallQuota(xfs, true),
+			hookSetupRemoteStorage(cfg, storageMetrics),
+			hookInstallQuota(xfs, cfg.Quota, true),
 		},
 		session.WorkspaceDisposed: {
 			iws.StopServingWorkspace,
@@ -51,7 +51,7 @@ func WorkspaceLifecycleHooks(cfg Config, workspaceCIDR string, uidmapper *iws.Ui
 }
 
 // hookSetupRemoteStorage configures the remote storage for a workspace
-func hookSetupRemoteStorage(cfg Config) session.WorkspaceLivecycleHook {
+func hookSetupRemoteStorage(cfg Config, metrics *storage.Metrics) session.WorkspaceLivecycleHook {
 	return func(ctx context.Context, ws *session.Workspace) (err error) {
 		span, ctx := opentracing.StartSpanFromContext(ctx, "hook.SetupRemoteStorage")
 		defer tracing.FinishSpan(span, &err)
@@ -61,6 +61,7 @@ func hookSetupRemoteStorage(cfg Config) session.WorkspaceLivecycleHook {
 			if err != nil {
 				return xerrors.Errorf("cannot use configured storage: %w", err)
 			}
+			remoteStorage = metrics.Instrument(remoteStorage)
 
 			err = remoteStorage.Init(ctx, ws.Owner, ws.WorkspaceID, ws.InstanceID)
 			if err != nil {
@@ -80,48 +81,59 @@ func hookSetupRemoteStorage(cfg Config) session.WorkspaceLivecycleHook {
 }
 
 // hookSetupWorkspaceLocation recreates the workspace location
-func hookSetupWorkspaceLocation(ctx context.Context, ws *session.Workspace) (err error) {
-	//nolint:ineffassign
-	span, _ := opentracing.StartSpanFromContext(ctx, "hook.SetupWorkspaceLocation")
-	defer tracing.FinishSpan(span, &err)
-	location := ws.Location
-
-	// 1. Clean out the workspace directory
-	if _, err := os.Stat(location); errors.Is(err, fs.ErrNotExist) {
-		// in the very unlikely event that the workspace Pod did not mount (and thus create) the workspace directory, create it
-		err = os.Mkdir(location, 0755)
-		if os.IsExist(err) {
-			log.WithError(err).WithFields(ws.OWI()).WithField("location", location).Debug("ran into non-atomic workspace location existence check")
-		} else if err != nil {
-			return xerrors.Errorf("cannot create workspace: %w", err)
+func hookSetupWorkspaceLocation(mapping iws.IDMappingConfig) session.WorkspaceLivecycleHook {
+	return func(ctx context.Context, ws *session.Workspace) (err error) {
+		//nolint:ineffassign
+		span, _ := opentracing.StartSpanFromContext(ctx, "hook.SetupWorkspaceLocation")
+		defer tracing.FinishSpan(span, &err)
+		location := ws.Location
+
+		// 1. Clean out the workspace directory
+		if _, err := os.Stat(location); errors.Is(err, fs.ErrNotExist) {
+			// in the very unlikely event that the workspace Pod did not mount (and thus create) the workspace directory, create it
+			err = os.Mkdir(location, 0755)
+			if os.IsExist(err) {
+				log.WithError(err).WithFields(ws.OWI()).WithField("location", location).Debug("ran into non-atomic workspace location existence check")
+			} else if err != nil {
+				return xerrors.Errorf("cannot create workspace: %w", err)
+			}
 		}
-	}
 
-	// Chown the workspace directory
-	err = os.Chown(location, initializer.GitpodUID, initializer.GitpodGID)
-	if err != nil {
-		return xerrors.Errorf("cannot create workspace: %w", err)
+		// Chown the workspace directory to the user the workspace's root is mapped to
+		err = os.Chown(location, int(mapping.HostID(0)), int(mapping.HostID(0)))
+		if err != nil {
+			return xerrors.Errorf("cannot create workspace: %w", err)
+		}
+		return nil
 	}
-	return nil
 }
 
-// hookInstallQuota enforces filesystem quota on the workspace location (if the filesystem supports it)
-func hookInstallQuota(xfs *quota.XFS, isHard bool) session.WorkspaceLivecycleHook {
+// hookInstallQuota enforces filesystem quota on the workspace location (if the filesystem supports it).
+// Unless the quota is configured to be enforced, workspaces whose quota cannot be installed run without one.
+func hookInstallQuota(xfs *quota.XFS, cfg QuotaConfig, isHard bool) session.WorkspaceLivecycleHook {
 	return func(ctx context.Context, ws *session.Workspace) (err error) {
 		span, _ := opentracing.StartSpanFromContext(ctx, "hook.InstallQuota")
 		defer tracing.FinishSpan(span, &err)
 
-		if xfs == nil {
-			log.WithFields(ws.OWI()).Warn("no xfs definition")
+		if ws.StorageQuota == 0 {
+			log.WithFields(ws.OWI()).Warn("no storage quota defined")
 			return nil
 		}
 
-		if ws.StorageQuota == 0 {
-			log.WithFields(ws.OWI()).Warn("no storage quota defined")
+		if xfs == nil {
+			if cfg.Enforce {
+				return xerrors.Errorf("%w: working area does not support XFS project quota", quota.ErrNotEnforced)
+			}
+			log.WithFields(ws.OWI()).Warn("no xfs definition")
 			return nil
 		}
 
 		size := quota.Size(ws.StorageQuota)
+		if !isHard && cfg.InitializationHeadroom > 0 {
+			// content may exceed the quota while it's initialized, but must never fill up the working area
+			isHard = true
+			size += quota.Size(float64(size) * cfg.InitializationHeadroom)
+		}
 
 		log.WithFields(ws.OWI()).WithField("isHard", isHard).WithField("size", size).WithField("directory", ws.Location).Debug("setting disk quota")
 
@@ -136,6 +148,9 @@ func hookInstallQuota(xfs *quota.XFS, isHard bool) session.WorkspaceLivecycleHoo
 		}
 
 		if err != nil {
+			if cfg.Enforce {
+				return xerrors.Errorf("%w: %v", quota.ErrNotEnforced, err)
+			}
 			log.WithFields(ws.OWI()).WithError(err).Warn("cannot enforce workspace size limit")
 		}
 		ws.XFSProjectID = int(prj)
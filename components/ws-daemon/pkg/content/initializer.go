@@ -9,11 +9,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -29,6 +32,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/tracing"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
 	wsinit "github.com/gitpod-io/gitpod/content-service/pkg/initializer"
 	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 )
@@ -45,6 +49,20 @@ type RunInitializerOpts struct {
 	UID uint32
 	GID uint32
 
+	// ArchiveKeys are the data keys of the encrypted archives among the remote content
+	ArchiveKeys map[string][]byte
+
+	// CachedContent maps names of remote content to the archives in the node-local cache which hold it.
+	// Cached content is restored from the cache rather than downloaded.
+	CachedContent map[string]string
+
+	// StorageMetrics records the downloads of remote content the initializer made
+	StorageMetrics *storage.Metrics
+
+	// RefreshURL signs a new download URL for remote content whose URL expired during the initialization.
+	// URLs are not refreshed if nil.
+	RefreshURL func(ctx context.Context, name string) (*storage.DownloadInfo, error)
+
 	OWI OWI
 }
 
@@ -74,20 +92,18 @@ func CollectRemoteContent(ctx context.Context, rs storage.DirectAccess, ps stora
 		return nil, err
 	} else {
 		rc[storage.DefaultBackup] = *backup
-	}
 
-	si := initializer.GetSnapshot()
-	pi := initializer.GetPrebuild()
-	if ci := initializer.GetComposite(); ci != nil {
-		for _, c := range ci.Initializer {
-			if c.GetSnapshot() != nil {
-				si = c.GetSnapshot()
-			}
-			if c.GetPrebuild() != nil {
-				pi = c.GetPrebuild()
+		// Only deduplicated backups are uploaded as manifest, whose chunks we need to download in addition.
+		if backup.Meta.ContentType == storage.DedupManifestContentType {
+			err = collectDedupChunks(ctx, rs, ps, rs.Bucket(workspaceOwner), *backup, rc)
+			if err != nil {
+				// the restore fails on its own should the backup need the chunks after all
+				log.WithError(err).WithField("backup", storage.DefaultBackup).Warn("cannot collect chunks of deduplicated backup")
 			}
 		}
 	}
+
+	si, pi := snapshotInitializers(initializer)
 	if si != nil {
 		bkt, obj, err := storage.ParseSnapshotName(si.Snapshot)
 		if err != nil {
@@ -121,6 +137,36 @@ func CollectRemoteContent(ctx context.Context, rs storage.DirectAccess, ps stora
 	return rc, nil
 }
 
+// RefreshRemoteContent signs a new download URL for remote content collected by CollectRemoteContent
+func RefreshRemoteContent(ctx context.Context, rs storage.DirectAccess, ps storage.PresignedAccess, workspaceOwner string, name string) (*storage.DownloadInfo, error) {
+	if name == storage.DefaultBackup || strings.HasPrefix(name, storage.DedupChunkPrefix) {
+		return ps.SignDownload(ctx, rs.Bucket(workspaceOwner), rs.BackupObject(name), &storage.SignedURLOptions{})
+	}
+
+	bkt, obj, err := storage.ParseSnapshotName(name)
+	if err != nil {
+		return nil, err
+	}
+	return ps.SignDownload(ctx, bkt, obj, &storage.SignedURLOptions{})
+}
+
+// snapshotInitializers finds the snapshot and prebuild initializers among the initializer, which may be a composite
+func snapshotInitializers(initializer *csapi.WorkspaceInitializer) (si *csapi.SnapshotInitializer, pi *csapi.PrebuildInitializer) {
+	si = initializer.GetSnapshot()
+	pi = initializer.GetPrebuild()
+	if ci := initializer.GetComposite(); ci != nil {
+		for _, c := range ci.Initializer {
+			if c.GetSnapshot() != nil {
+				si = c.GetSnapshot()
+			}
+			if c.GetPrebuild() != nil {
+				pi = c.GetPrebuild()
+			}
+		}
+	}
+	return si, pi
+}
+
 // RunInitializer runs a content initializer in a user, PID and mount namespace to isolate it from ws-daemon
 func RunInitializer(ctx context.Context, destination string, initializer *csapi.WorkspaceInitializer, remoteContent map[string]storage.DownloadInfo, opts RunInitializerOpts) (err error) {
 	//nolint:ineffassign,staticcheck
@@ -157,10 +203,28 @@ func RunInitializer(ctx context.Context, destination string, initializer *csapi.
 		return err
 	}
 
+	// cached archives are mounted into the initializer, which only reads them
+	var (
+		cacheMounts   []specs.Mount
+		cachedContent = make(map[string]string, len(opts.CachedContent))
+	)
+	for name, fn := range opts.CachedContent {
+		dst := filepath.Join("/cached-content", filepath.Base(fn))
+		cacheMounts = append(cacheMounts, specs.Mount{
+			Destination: dst,
+			Source:      fn,
+			Type:        "bind",
+			Options:     []string{"bind", "ro", "rprivate"},
+		})
+		cachedContent[name] = dst
+	}
+
 	msg := msgInitContent{
 		Destination:   "/dst",
 		Initializer:   init,
 		RemoteContent: remoteContent,
+		CachedContent: cachedContent,
+		ArchiveKeys:   opts.ArchiveKeys,
 		TraceInfo:     tracing.GetTraceID(span),
 		IDMappings:    opts.IdMappings,
 		GID:           int(opts.GID),
@@ -193,6 +257,7 @@ func RunInitializer(ctx context.Context, destination string, initializer *csapi.
 		Type:        "bind",
 		Options:     []string{"bind", "rprivate"},
 	})
+	spec.Mounts = append(spec.Mounts, cacheMounts...)
 
 	spec.Hostname = "content-init"
 	spec.Process.Terminal = false
@@ -251,7 +316,7 @@ func RunInitializer(ctx context.Context, destination string, initializer *csapi.
 	}
 
 	args = append(args, "--log-format", "json", "run")
-	args = append(args, "--preserve-fds", "1")
+	args = append(args, "--preserve-fds", "4")
 	args = append(args, name)
 
 	errIn, errOut, err := os.Pipe()
@@ -264,16 +329,39 @@ func RunInitializer(ctx context.Context, destination string, initializer *csapi.
 		errch <- errmsg
 	}()
 
+	resultIn, resultOut, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	resultch := make(chan []byte, 1)
+	go func() {
+		result, _ := ioutil.ReadAll(resultIn)
+		resultch <- result
+	}()
+
+	refreshReqIn, refreshReqOut, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	refreshRespIn, refreshRespOut, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	go serveURLRefreshes(ctx, refreshReqIn, refreshRespOut, remoteContent, opts)
+
 	var cmdOut bytes.Buffer
 	cmd := exec.Command("runc", args...)
 	cmd.Dir = tmpdir
 	cmd.Stdout = &cmdOut
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	cmd.ExtraFiles = []*os.File{errOut}
+	cmd.ExtraFiles = []*os.File{errOut, resultOut, refreshReqOut, refreshRespIn}
 	err = cmd.Run()
 	log.FromBuffer(&cmdOut, log.WithFields(opts.OWI.Fields()))
 	errOut.Close()
+	resultOut.Close()
+	refreshReqOut.Close()
+	refreshRespIn.Close()
 
 	var errmsg []byte
 	select {
@@ -281,6 +369,13 @@ func RunInitializer(ctx context.Context, destination string, initializer *csapi.
 	case <-time.After(1 * time.Second):
 		errmsg = []byte("failed to read content initializer response")
 	}
+
+	select {
+	case result := <-resultch:
+		reportInitResult(result, opts)
+	case <-time.After(1 * time.Second):
+		log.WithFields(opts.OWI.Fields()).Warn("failed to read content initializer result")
+	}
 	if err != nil {
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			// The program has exited with an exit code != 0. If it's FAIL_CONTENT_INITIALIZER_EXIT_CODE, it was deliberate.
@@ -296,6 +391,63 @@ func RunInitializer(ctx context.Context, destination string, initializer *csapi.
 	return nil
 }
 
+// serveURLRefreshes answers the requests of the content initializer for new URLs of remote content until
+// the initializer exits
+func serveURLRefreshes(ctx context.Context, in io.ReadCloser, out io.WriteCloser, remoteContent map[string]storage.DownloadInfo, opts RunInitializerOpts) {
+	defer in.Close()
+	defer out.Close()
+
+	var (
+		dec = json.NewDecoder(in)
+		enc = json.NewEncoder(out)
+	)
+	for {
+		var req msgRefreshURLRequest
+		err := dec.Decode(&req)
+		if err != nil {
+			return
+		}
+
+		var resp msgRefreshURLResponse
+		if _, ok := remoteContent[req.Name]; !ok || opts.RefreshURL == nil {
+			resp.Error = fmt.Sprintf("cannot refresh the URL of %s", req.Name)
+		} else if info, err := opts.RefreshURL(ctx, req.Name); err != nil {
+			log.WithError(err).WithFields(opts.OWI.Fields()).WithField("name", req.Name).Warn("cannot refresh download URL")
+			resp.Error = err.Error()
+		} else {
+			resp.URL, resp.Expires = info.URL, info.Expires
+		}
+
+		err = enc.Encode(resp)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// reportInitResult records the downloads the content initializer made. The initializer runs in its own process
+// and downloads remote content from presigned URLs, hence the downloads never go through an instrumented storage.
+func reportInitResult(result []byte, opts RunInitializerOpts) {
+	if len(result) == 0 {
+		return
+	}
+
+	var msg msgInitResult
+	err := json.Unmarshal(result, &msg)
+	if err != nil {
+		log.WithError(err).WithFields(opts.OWI.Fields()).Warn("cannot parse content initializer result")
+		return
+	}
+
+	for _, d := range msg.Downloads {
+		var err error
+		if d.Failed {
+			err = xerrors.Errorf("cannot download %s", d.Name)
+		}
+		opts.StorageMetrics.ObserveDownload(opts.OWI.Owner, opts.OWI.WorkspaceID, opts.OWI.InstanceID, d.Name, d.Bytes, d.Duration, err)
+	}
+}
+
 // RunInitializerChild is the function that's expected to run when we call `/proc/self/exe content-initializer`
 func RunInitializerChild() (err error) {
 	fc, err := os.ReadFile("/content.json")
@@ -326,7 +478,23 @@ func RunInitializerChild() (err error) {
 		return err
 	}
 
-	rs := &remoteContentStorage{RemoteContent: initmsg.RemoteContent}
+	rs := &remoteContentStorage{
+		RemoteContent: initmsg.RemoteContent,
+		CachedContent: initmsg.CachedContent,
+		ArchiveKeys:   initmsg.ArchiveKeys,
+		// URLs are refreshed by RunInitializer through fd 5 and 6
+		refresher: newURLRefresher(os.NewFile(uintptr(5), "refresh-req"), os.NewFile(uintptr(6), "refresh-resp")),
+	}
+	defer func() {
+		// the downloads are reported on fd 4 (see RunInitializer), even if the initializer failed
+		fc, err := json.Marshal(msgInitResult{Downloads: rs.downloads})
+		if err != nil {
+			return
+		}
+		resultfd := os.NewFile(uintptr(4), "result")
+		_, _ = resultfd.Write(fc)
+		resultfd.Close()
+	}()
 
 	dst := initmsg.Destination
 	initializer, err := wsinit.NewFromRequest(ctx, dst, rs, &req, wsinit.NewFromRequestOpts{ForceGitpodUserForGit: false})
@@ -364,6 +532,99 @@ var _ storage.DirectAccess = &remoteContentStorage{}
 
 type remoteContentStorage struct {
 	RemoteContent map[string]storage.DownloadInfo
+	CachedContent map[string]string
+	ArchiveKeys   map[string][]byte
+
+	downloads []initDownload
+	refresher *urlRefresher
+}
+
+// urlRefreshMargin is how long before they expire URLs are refreshed prior to a download
+const urlRefreshMargin = 10 * time.Minute
+
+// urlRefresher requests new URLs for remote content from RunInitializer
+type urlRefresher struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+func newURLRefresher(req io.Writer, resp io.Reader) *urlRefresher {
+	return &urlRefresher{
+		enc: json.NewEncoder(req),
+		dec: json.NewDecoder(resp),
+	}
+}
+
+// Refresh returns a new URL for the remote content with the given name
+func (r *urlRefresher) Refresh(name string) (url string, expires time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.enc.Encode(msgRefreshURLRequest{Name: name})
+	if err != nil {
+		return "", time.Time{}, xerrors.Errorf("cannot request URL refresh: %w", err)
+	}
+	var resp msgRefreshURLResponse
+	err = r.dec.Decode(&resp)
+	if err != nil {
+		return "", time.Time{}, xerrors.Errorf("cannot read URL refresh: %w", err)
+	}
+	if resp.Error != "" {
+		return "", time.Time{}, xerrors.New(resp.Error)
+	}
+	return resp.URL, resp.Expires, nil
+}
+
+// url returns the download URL of remote content. URLs which are about to expire, or any if force is set,
+// are refreshed first.
+func (rs *remoteContentStorage) url(name string, force bool) (string, error) {
+	info, ok := rs.RemoteContent[name]
+	if !ok {
+		return "", xerrors.Errorf("no download URL for %s", name)
+	}
+	if !force && (info.Expires.IsZero() || time.Until(info.Expires) > urlRefreshMargin) {
+		return info.URL, nil
+	}
+	if rs.refresher == nil {
+		if force {
+			return "", xerrors.Errorf("cannot refresh the URL of %s", name)
+		}
+		return info.URL, nil
+	}
+
+	url, expires, err := rs.refresher.Refresh(name)
+	if err != nil {
+		if force {
+			return "", err
+		}
+		log.WithError(err).WithField("name", name).Warn("cannot refresh download URL, using the current one")
+		return info.URL, nil
+	}
+	info.URL, info.Expires = url, expires
+	rs.RemoteContent[name] = info
+	return url, nil
+}
+
+// download fetches remote content into the file fn. Should the download fail, e.g. because its URL expired
+// while aria2c was retrying, it is retried once with a fresh URL.
+func (rs *remoteContentStorage) download(ctx context.Context, name, fn string) error {
+	url, err := rs.url(name, false)
+	if err != nil {
+		return err
+	}
+	err = downloadWithAria2c(ctx, url, fn)
+	if err == nil {
+		return nil
+	}
+
+	url, rerr := rs.url(name, true)
+	if rerr != nil {
+		log.WithError(rerr).WithField("name", name).Warn("cannot refresh download URL to retry the download")
+		return err
+	}
+	log.WithField("name", name).Info("retrying download with a fresh URL")
+	return downloadWithAria2c(ctx, url, fn)
 }
 
 // Init does nothing
@@ -390,51 +651,118 @@ func (rs *remoteContentStorage) Download(ctx context.Context, destination string
 
 	span.SetTag("URL", info.URL)
 
-	// create a temporal file to download the content
-	tempFile, err := os.CreateTemp("", "remote-content-*")
-	if err != nil {
-		return true, xerrors.Errorf("cannot create temporal file: %w", err)
-	}
-	tempFile.Close()
+	var tempFile *os.File
+	if cached, ok := rs.CachedContent[name]; ok {
+		span.SetTag("cached", true)
+		log.WithField("name", name).Info("restoring content from the node-local cache")
 
-	args := []string{
-		"-s10", "-x16", "-j12",
-		"--retry-wait=5",
-		"--log-level=error",
-		"--allow-overwrite=true", // rewrite temporal empty file
-		info.URL,
-		"-o", tempFile.Name(),
+		// cached archives were verified against their digest when they were cached
+		tempFile, err = os.Open(cached)
+		if err != nil {
+			return true, xerrors.Errorf("cannot open cached %s: %w", name, err)
+		}
+		defer tempFile.Close()
+	} else {
+		// create a temporal file to download the content
+		tempFile, err = os.CreateTemp("", "remote-content-*")
+		if err != nil {
+			return true, xerrors.Errorf("cannot create temporal file: %w", err)
+		}
+		tempFile.Close()
+
+		downloadStart := time.Now()
+		err = rs.download(ctx, name, tempFile.Name())
+		downloadDuration := time.Since(downloadStart)
+		rs.downloads = append(rs.downloads, initDownload{Name: name, Bytes: info.Size, Duration: downloadDuration, Failed: err != nil})
+		if err != nil {
+			os.Remove(tempFile.Name())
+			return true, err
+		}
+		// the download is separate from the extraction to tell whether restoring content is network or disk bound
+		log.WithField("name", name).
+			WithField("downloadDuration", downloadDuration.String()).
+			WithField("bytes", info.Size).
+			WithField("bytesPerSecond", int64(float64(info.Size)/downloadDuration.Seconds())).
+			Info("aria2c download duration")
+
+		tempFile, err = os.Open(tempFile.Name())
+		if err != nil {
+			return true, xerrors.Errorf("unexpected error downloading file")
+		}
+
+		defer os.Remove(tempFile.Name())
+		defer tempFile.Close()
+
+		// Better fail than start the workspace with partial content if remote storage returned a truncated object.
+		err = storage.VerifyFileDigest(tempFile.Name(), info.Meta.Digest)
+		if err != nil {
+			return true, xerrors.Errorf("cannot verify %s: %w", name, err)
+		}
 	}
 
-	downloadStart := time.Now()
-	cmd := exec.Command("aria2c", args...)
-	out, err := cmd.CombinedOutput()
+	// Deduplicated backups are a manifest of the chunks which make up the archive
+	manifest, err := storage.ReadDedupManifest(tempFile)
 	if err != nil {
-		log.WithError(err).WithField("out", string(out)).Error("unexpected error downloading file")
-		return true, xerrors.Errorf("unexpected error downloading file")
+		return true, xerrors.Errorf("cannot read %s: %w", name, err)
+	}
+	src := tempFile
+	if manifest != nil {
+		src, err = restoreDedupBackup(ctx, manifest, rs.url)
+		if err != nil {
+			return true, xerrors.Errorf("cannot restore deduplicated %s: %w", name, err)
+		}
+		defer os.Remove(src.Name())
+		defer src.Close()
+	} else {
+		_, err = tempFile.Seek(0, io.SeekStart)
+		if err != nil {
+			return true, err
+		}
 	}
-	downloadDuration := time.Since(downloadStart)
-	log.WithField("downloadDuration", downloadDuration.String()).Info("aria2c download duration")
 
-	tempFile, err = os.Open(tempFile.Name())
+	// Archives of organizations with an encryption key are encrypted, unless they were uploaded before the key was added
+	plain, err := encryption.Decrypt(src, func(hdr *encryption.Header) ([]byte, error) {
+		key, ok := rs.ArchiveKeys[name]
+		if !ok {
+			return nil, xerrors.Errorf("%s is encrypted, but its key is unknown", name)
+		}
+		return key, nil
+	})
 	if err != nil {
-		return true, xerrors.Errorf("unexpected error downloading file")
+		return true, xerrors.Errorf("cannot decrypt %s: %w", name, err)
 	}
 
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
 	extractStart := time.Now()
-	err = archive.ExtractTarbal(ctx, tempFile, destination, archive.WithUIDMapping(mappings), archive.WithGIDMapping(mappings))
+	err = archive.ExtractTarbal(ctx, plain, destination, archive.WithUIDMapping(mappings), archive.WithGIDMapping(mappings))
 	if err != nil {
 		return true, xerrors.Errorf("tar %s: %s", destination, err.Error())
 	}
 	extractDuration := time.Since(extractStart)
-	log.WithField("extractDuration", extractDuration.String()).Info("extract tarbal duration")
+	log.WithField("name", name).WithField("extractDuration", extractDuration.String()).Info("extract tarbal duration")
 
 	return true, nil
 }
 
+// downloadWithAria2c downloads the content of url to the file fn, overwriting it
+func downloadWithAria2c(ctx context.Context, url, fn string) error {
+	args := []string{
+		"-s10", "-x16", "-j12",
+		"--retry-wait=5",
+		"--log-level=error",
+		"--allow-overwrite=true", // rewrite temporal empty file
+		url,
+		"-o", fn,
+	}
+
+	cmd := exec.CommandContext(ctx, "aria2c", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.WithError(err).WithField("out", string(out)).Error("unexpected error downloading file")
+		return xerrors.Errorf("unexpected error downloading file")
+	}
+	return nil
+}
+
 // DownloadSnapshot always returns false and does nothing
 func (rs *remoteContentStorage) DownloadSnapshot(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
 	return rs.Download(ctx, destination, name, mappings)
@@ -483,6 +811,8 @@ func (rs *remoteContentStorage) SnapshotObject(name string) string {
 type msgInitContent struct {
 	Destination   string
 	RemoteContent map[string]storage.DownloadInfo
+	CachedContent map[string]string
+	ArchiveKeys   map[string][]byte
 	Initializer   []byte
 	UID, GID      int
 	IDMappings    []archive.IDMapping
@@ -490,3 +820,26 @@ type msgInitContent struct {
 	TraceInfo string
 	OWI       map[string]interface{}
 }
+
+// msgInitResult is what the content initializer reports back to RunInitializer
+type msgInitResult struct {
+	Downloads []initDownload
+}
+
+type initDownload struct {
+	Name     string
+	Bytes    int64
+	Duration time.Duration
+	Failed   bool
+}
+
+// msgRefreshURLRequest asks RunInitializer for a new URL of remote content
+type msgRefreshURLRequest struct {
+	Name string
+}
+
+type msgRefreshURLResponse struct {
+	URL     string
+	Expires time.Time
+	Error   string
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+func TestRemoteContentURLRefresh(t *testing.T) {
+	var (
+		soon    = time.Now().Add(time.Minute)
+		later   = time.Now().Add(time.Hour)
+		fresh   = time.Now().Add(2 * time.Hour)
+		content = map[string]storage.DownloadInfo{
+			"expiring": {URL: "https://storage/expiring?v=1", Expires: soon},
+			"valid":    {URL: "https://storage/valid?v=1", Expires: later},
+		}
+	)
+
+	reqIn, reqOut, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	respIn, respOut, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reqOut.Close()
+	defer respIn.Close()
+	go serveURLRefreshes(context.Background(), reqIn, respOut, content, RunInitializerOpts{
+		RefreshURL: func(ctx context.Context, name string) (*storage.DownloadInfo, error) {
+			return &storage.DownloadInfo{URL: "https://storage/" + name + "?v=2", Expires: fresh}, nil
+		},
+	})
+
+	rc := make(map[string]storage.DownloadInfo, len(content))
+	for k, v := range content {
+		rc[k] = v
+	}
+	rs := &remoteContentStorage{RemoteContent: rc, refresher: newURLRefresher(reqOut, respIn)}
+
+	tests := []struct {
+		Name        string
+		Force       bool
+		Expectation string
+		ExpectErr   bool
+	}{
+		{Name: "valid", Expectation: "https://storage/valid?v=1"},
+		{Name: "expiring", Expectation: "https://storage/expiring?v=2"},
+		{Name: "valid", Force: true, Expectation: "https://storage/valid?v=2"},
+		{Name: "unknown", ExpectErr: true},
+	}
+	for _, test := range tests {
+		url, err := rs.url(test.Name, test.Force)
+		if (err != nil) != test.ExpectErr {
+			t.Fatalf("%s: unexpected error: %v", test.Name, err)
+		}
+		if url != test.Expectation {
+			t.Errorf("%s: unexpected URL: is %s but expected %s", test.Name, url, test.Expectation)
+		}
+	}
+	if !rs.RemoteContent["expiring"].Expires.Equal(fresh) {
+		t.Errorf("refreshed URL was not remembered")
+	}
+
+	// the initializer can only refresh content it was given
+	_, _, err = rs.refresher.Refresh("other")
+	if err == nil {
+		t.Errorf("expected refreshing unknown content to fail")
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+const (
+	// defaultPrebuildCacheMaxSize is the disk space the prebuild cache uses if none is configured
+	defaultPrebuildCacheMaxSize = 20 * 1024 * 1024 * 1024
+
+	// prebuildCacheTempPrefix prefixes the archives which are being downloaded into the cache
+	prebuildCacheTempPrefix = ".download-"
+)
+
+// PrebuildCache keeps the archives of prebuilds on the node, such that workspaces which start from the same
+// prebuild download its archive once rather than each. Archives are shared by name and digest, and the least
+// recently used archives are evicted once the cache exceeds its size. Archives in use are never evicted.
+type PrebuildCache struct {
+	location string
+	maxSize  int64
+
+	// download fetches url into the file fn
+	download func(ctx context.Context, url, fn string) error
+
+	mu        sync.Mutex
+	entries   map[string]*prebuildCacheEntry
+	downloads map[string]*prebuildCacheDownload
+	size      int64
+
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+}
+
+type prebuildCacheEntry struct {
+	size     int64
+	lastUsed time.Time
+	refs     int
+}
+
+type prebuildCacheDownload struct {
+	done chan struct{}
+	err  error
+}
+
+// NewPrebuildCache creates the prebuild cache, picking up the archives cached before ws-daemon restarted.
+// Returns nil if no cache location is configured.
+func NewPrebuildCache(cfg PrebuildCacheConfig, reg prometheus.Registerer) (*PrebuildCache, error) {
+	if cfg.Location == "" {
+		return nil, nil
+	}
+
+	maxSize := cfg.MaxSize.Value()
+	if maxSize <= 0 {
+		maxSize = defaultPrebuildCacheMaxSize
+	}
+	c := &PrebuildCache{
+		location:  cfg.Location,
+		maxSize:   maxSize,
+		download:  downloadWithAria2c,
+		entries:   make(map[string]*prebuildCacheEntry),
+		downloads: make(map[string]*prebuildCacheDownload),
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "prebuild_cache_hits_total",
+			Help: "Number of prebuild archives restored from the node-local cache",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "prebuild_cache_misses_total",
+			Help: "Number of prebuild archives downloaded into the node-local cache",
+		}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "prebuild_cache_evictions_total",
+			Help: "Number of prebuild archives evicted from the node-local cache",
+		}),
+	}
+
+	err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	sizeGauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "prebuild_cache_size_bytes",
+		Help: "Disk space used by the prebuild archives in the node-local cache",
+	}, func() float64 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return float64(c.size)
+	})
+	for _, m := range []prometheus.Collector{c.hits, c.misses, c.evictions, sizeGauge} {
+		err = reg.Register(m)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot register prebuild cache metrics: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// load picks up the archives in the cache location and removes incomplete downloads
+func (c *PrebuildCache) load() error {
+	err := os.MkdirAll(c.location, 0755)
+	if err != nil {
+		return xerrors.Errorf("cannot create prebuild cache: %w", err)
+	}
+	files, err := os.ReadDir(c.location)
+	if err != nil {
+		return xerrors.Errorf("cannot read prebuild cache: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range files {
+		fn := filepath.Join(c.location, f.Name())
+		if strings.HasPrefix(f.Name(), prebuildCacheTempPrefix) {
+			_ = os.Remove(fn)
+			continue
+		}
+		if !f.Type().IsRegular() {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			log.WithError(err).WithField("file", fn).Warn("cannot read cached prebuild archive")
+			continue
+		}
+		// the modification time is bumped whenever an archive is used, such that recency survives restarts
+		c.entries[f.Name()] = &prebuildCacheEntry{size: info.Size(), lastUsed: info.ModTime()}
+		c.size += info.Size()
+	}
+	c.evict()
+	return nil
+}
+
+// CacheRemoteContent makes sure the prebuild archive the initializer restores is in the cache and returns its location
+// by the name of the remote content, as used by RunInitializerOpts.CachedContent. The archive remains in the cache until
+// release is called. Failing to cache the archive is not an error, in that case the prebuild is downloaded as usual.
+func (c *PrebuildCache) CacheRemoteContent(ctx context.Context, initializer *csapi.WorkspaceInitializer, remoteContent map[string]storage.DownloadInfo) (cachedContent map[string]string, release func()) {
+	release = func() {}
+	if c == nil {
+		return nil, release
+	}
+	// workspaces with a backup are restored from the backup and never download the prebuild
+	if _, ok := remoteContent[storage.DefaultBackup]; ok {
+		return nil, release
+	}
+	_, pi := snapshotInitializers(initializer)
+	if pi == nil || pi.Prebuild == nil || pi.Prebuild.Snapshot == "" {
+		return nil, release
+	}
+	name := pi.Prebuild.Snapshot
+	info, ok := remoteContent[name]
+	if !ok {
+		return nil, release
+	}
+
+	fn, release, err := c.Get(ctx, name, info)
+	if err != nil {
+		log.WithError(err).WithField("name", name).Warn("cannot cache prebuild, downloading it instead")
+		return nil, func() {}
+	}
+	return map[string]string{name: fn}, release
+}
+
+// Get returns the cached archive of the remote content with the given name, downloading it if it isn't cached yet.
+// Concurrent calls for the same content share one download. The archive remains in the cache until release is called.
+func (c *PrebuildCache) Get(ctx context.Context, name string, info storage.DownloadInfo) (fn string, release func(), err error) {
+	if info.Size > c.maxSize {
+		return "", nil, xerrors.Errorf("archive of %d bytes exceeds the prebuild cache of %d bytes", info.Size, c.maxSize)
+	}
+
+	key := prebuildCacheKey(name, info.Meta.Digest)
+	fn = filepath.Join(c.location, key)
+	for {
+		c.mu.Lock()
+		if entry, ok := c.entries[key]; ok {
+			entry.refs++
+			entry.lastUsed = time.Now()
+			c.mu.Unlock()
+
+			_ = os.Chtimes(fn, entry.lastUsed, entry.lastUsed)
+			c.hits.Inc()
+			return fn, c.releaseFunc(entry), nil
+		}
+		dl, ok := c.downloads[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-dl.done:
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		}
+		if dl.err != nil {
+			return "", nil, dl.err
+		}
+	}
+	dl := &prebuildCacheDownload{done: make(chan struct{})}
+	c.downloads[key] = dl
+	c.mu.Unlock()
+
+	c.misses.Inc()
+	size, err := c.fetch(ctx, fn, info)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.downloads, key)
+	dl.err = err
+	close(dl.done)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entry := &prebuildCacheEntry{size: size, lastUsed: time.Now(), refs: 1}
+	c.entries[key] = entry
+	c.size += size
+	c.evict()
+
+	return fn, c.releaseFunc(entry), nil
+}
+
+// fetch downloads and verifies an archive, and moves it to fn once it is complete
+func (c *PrebuildCache) fetch(ctx context.Context, fn string, info storage.DownloadInfo) (size int64, err error) {
+	tmp, err := os.CreateTemp(c.location, prebuildCacheTempPrefix+"*")
+	if err != nil {
+		return 0, xerrors.Errorf("cannot create temporal file: %w", err)
+	}
+	tmp.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	downloadStart := time.Now()
+	err = c.download(ctx, info.URL, tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	err = storage.VerifyFileDigest(tmp.Name(), info.Meta.Digest)
+	if err != nil {
+		return 0, err
+	}
+	// the content initializer reads the archive as the workspace user
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return 0, err
+	}
+	stat, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	err = os.Rename(tmp.Name(), fn)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot move archive into prebuild cache: %w", err)
+	}
+
+	log.WithField("file", fn).
+		WithField("downloadDuration", time.Since(downloadStart).String()).
+		WithField("bytes", stat.Size()).
+		Info("cached prebuild archive")
+	return stat.Size(), nil
+}
+
+func (c *PrebuildCache) releaseFunc(entry *prebuildCacheEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			entry.refs--
+			c.evict()
+		})
+	}
+}
+
+// evict removes the least recently used archives which are not in use until the cache fits its size.
+// Must be called with mu held.
+func (c *PrebuildCache) evict() {
+	for c.size > c.maxSize {
+		var (
+			oldest string
+			entry  *prebuildCacheEntry
+		)
+		for key, e := range c.entries {
+			if e.refs > 0 {
+				continue
+			}
+			if entry == nil || e.lastUsed.Before(entry.lastUsed) {
+				oldest, entry = key, e
+			}
+		}
+		if entry == nil {
+			// all archives are in use
+			return
+		}
+
+		fn := filepath.Join(c.location, oldest)
+		err := os.Remove(fn)
+		if err != nil && !os.IsNotExist(err) {
+			log.WithError(err).WithField("file", fn).Warn("cannot evict prebuild archive from cache")
+		}
+		delete(c.entries, oldest)
+		c.size -= entry.size
+		c.evictions.Inc()
+	}
+}
+
+// prebuildCacheKey names the cached archive of remote content. Content which changes its digest is cached anew.
+func prebuildCacheKey(name, digest string) string {
+	h := sha256.Sum256([]byte(name + "\n" + digest))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+func newTestPrebuildCache(t *testing.T, location string, maxSize int64, downloads *atomic.Int64) *PrebuildCache {
+	c, err := NewPrebuildCache(PrebuildCacheConfig{
+		Location: location,
+		MaxSize:  *resource.NewQuantity(maxSize, resource.BinarySI),
+	}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.download = func(ctx context.Context, url, fn string) error {
+		downloads.Add(1)
+		// the URL is the content, which makes archives as large as their URL
+		return os.WriteFile(fn, []byte(url), 0600)
+	}
+	return c
+}
+
+func TestPrebuildCache(t *testing.T) {
+	var (
+		location  = t.TempDir()
+		downloads atomic.Int64
+		c         = newTestPrebuildCache(t, location, 20, &downloads)
+		ctx       = context.Background()
+	)
+
+	// concurrent workspaces of the same prebuild download it once
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, release, err := c.Get(ctx, "prebuild-a", storage.DownloadInfo{URL: "aaaaaaaa"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			release()
+		}()
+	}
+	wg.Wait()
+	if act := downloads.Load(); act != 1 {
+		t.Errorf("unexpected number of downloads: is %d but expected 1", act)
+	}
+	if act := testutil.ToFloat64(c.hits); act != 4 {
+		t.Errorf("unexpected cache hits: is %v but expected 4", act)
+	}
+
+	fnB, releaseB, err := c.Get(ctx, "prebuild-b", storage.DownloadInfo{URL: "bbbbbbbb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(fnB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "bbbbbbbb" {
+		t.Errorf("unexpected cached content: %q", content)
+	}
+
+	// caching a third archive exceeds the cache, which evicts the least recently used archive that is not in use
+	_, releaseC, err := c.Get(ctx, "prebuild-c", storage.DownloadInfo{URL: "cccccccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(location, prebuildCacheKey("prebuild-a", ""))); !os.IsNotExist(err) {
+		t.Errorf("expected prebuild-a to be evicted: %v", err)
+	}
+	if _, err := os.Stat(fnB); err != nil {
+		t.Errorf("expected prebuild-b to remain cached while in use: %v", err)
+	}
+	releaseB()
+	releaseC()
+
+	// archives remain cached when ws-daemon restarts
+	c = newTestPrebuildCache(t, location, 20, &downloads)
+	_, release, err := c.Get(ctx, "prebuild-b", storage.DownloadInfo{URL: "bbbbbbbb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if act := downloads.Load(); act != 3 {
+		t.Errorf("unexpected number of downloads after restart: is %d but expected 3", act)
+	}
+
+	// archives which do not match their digest are not cached
+	_, _, err = c.Get(ctx, "prebuild-d", storage.DownloadInfo{URL: "dddddddd", Meta: storage.ObjectMeta{Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000"}})
+	if err == nil {
+		t.Error("expected corrupted archive to fail")
+	}
+	files, err := os.ReadDir(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("unexpected number of cached files: is %d but expected 2", len(files))
+	}
+}
+
+func TestPrebuildCacheRemoteContent(t *testing.T) {
+	var downloads atomic.Int64
+	c := newTestPrebuildCache(t, t.TempDir(), 1024, &downloads)
+
+	prebuild := &csapi.WorkspaceInitializer{
+		Spec: &csapi.WorkspaceInitializer_Prebuild{
+			Prebuild: &csapi.PrebuildInitializer{
+				Prebuild: &csapi.SnapshotInitializer{Snapshot: "bucket@prebuild.tar"},
+			},
+		},
+	}
+	tests := []struct {
+		Name          string
+		Initializer   *csapi.WorkspaceInitializer
+		RemoteContent map[string]storage.DownloadInfo
+		Cached        bool
+	}{
+		{
+			Name:          "prebuild",
+			Initializer:   prebuild,
+			RemoteContent: map[string]storage.DownloadInfo{"bucket@prebuild.tar": {URL: "prebuild"}},
+			Cached:        true,
+		},
+		{
+			Name:        "prebuild with backup",
+			Initializer: prebuild,
+			RemoteContent: map[string]storage.DownloadInfo{
+				"bucket@prebuild.tar": {URL: "prebuild"},
+				storage.DefaultBackup: {URL: "backup"},
+			},
+		},
+		{
+			Name:          "prebuild not found",
+			Initializer:   prebuild,
+			RemoteContent: map[string]storage.DownloadInfo{},
+		},
+		{
+			Name: "snapshot",
+			Initializer: &csapi.WorkspaceInitializer{
+				Spec: &csapi.WorkspaceInitializer_Snapshot{
+					Snapshot: &csapi.SnapshotInitializer{Snapshot: "bucket@snapshot.tar"},
+				},
+			},
+			RemoteContent: map[string]storage.DownloadInfo{"bucket@snapshot.tar": {URL: "snapshot"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cached, release := c.CacheRemoteContent(context.Background(), test.Initializer, test.RemoteContent)
+			defer release()
+
+			if test.Cached != (len(cached) == 1) {
+				t.Errorf("unexpected cached content: %v", cached)
+			}
+		})
+	}
+
+	var nilCache *PrebuildCache
+	cached, release := nilCache.CacheRemoteContent(context.Background(), prebuild, map[string]storage.DownloadInfo{"bucket@prebuild.tar": {URL: "prebuild"}})
+	release()
+	if cached != nil {
+		t.Errorf("expected no cached content without a cache: %v", cached)
+	}
+}
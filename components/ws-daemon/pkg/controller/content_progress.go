@@ -0,0 +1,313 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+const (
+	// contentProgressInterval is how often we measure the progress of content operations
+	contentProgressInterval = 5 * time.Second
+
+	// contentProgressSubscriberBuffer is the number of updates we buffer per subscriber before dropping updates
+	contentProgressSubscriberBuffer = 16
+)
+
+// Phases of content operations reported in their progress
+const (
+	ContentPhaseDownloading = "downloading"
+	ContentPhaseArchiving   = "archiving"
+	ContentPhaseUploading   = "uploading"
+)
+
+// ContentProgress keeps the latest progress of the content restores and backups of all workspaces on this node
+// and distributes updates to subscribers.
+type ContentProgress struct {
+	mu     sync.Mutex
+	latest map[string]map[api.ContentOperation]*api.ContentProgress
+	subs   map[string]map[chan *api.ContentProgress]struct{}
+}
+
+// NewContentProgress creates a new, empty content progress registry
+func NewContentProgress() *ContentProgress {
+	return &ContentProgress{
+		latest: make(map[string]map[api.ContentOperation]*api.ContentProgress),
+		subs:   make(map[string]map[chan *api.ContentProgress]struct{}),
+	}
+}
+
+// Update records the progress of a content operation and sends it to all subscribers of the workspace.
+// Subscribers which don't keep up miss updates, but will receive later ones.
+func (p *ContentProgress) Update(update *api.ContentProgress) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ops, ok := p.latest[update.Id]
+	if !ok {
+		ops = make(map[api.ContentOperation]*api.ContentProgress)
+		p.latest[update.Id] = ops
+	}
+	ops[update.Operation] = update
+
+	for sub := range p.subs[update.Id] {
+		select {
+		case sub <- update:
+		default:
+		}
+	}
+}
+
+// Subscribe listens for the content progress of a workspace. The latest progress of each operation is sent first.
+// Callers must call the returned function once they're no longer interested in updates.
+func (p *ContentProgress) Subscribe(instanceID string) (updates <-chan *api.ContentProgress, cancel func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sub := make(chan *api.ContentProgress, contentProgressSubscriberBuffer)
+	for _, op := range []api.ContentOperation{api.ContentOperation_RESTORE, api.ContentOperation_BACKUP, api.ContentOperation_SNAPSHOT} {
+		if latest, ok := p.latest[instanceID][op]; ok {
+			sub <- latest
+		}
+	}
+
+	subs, ok := p.subs[instanceID]
+	if !ok {
+		subs = make(map[chan *api.ContentProgress]struct{})
+		p.subs[instanceID] = subs
+	}
+	subs[sub] = struct{}{}
+
+	return sub, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.subs[instanceID], sub)
+		if len(p.subs[instanceID]) == 0 {
+			delete(p.subs, instanceID)
+		}
+	}
+}
+
+// Forget drops the progress of a workspace, e.g. once its content was deleted
+func (p *ContentProgress) Forget(instanceID string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.latest, instanceID)
+}
+
+// contentProgressTracker reports the progress of a single content operation
+type contentProgressTracker struct {
+	progress *ContentProgress
+	id       string
+	op       api.ContentOperation
+
+	mu         sync.Mutex
+	phase      string
+	phaseStart time.Time
+	done       bool
+}
+
+func (p *ContentProgress) track(instanceID string, op api.ContentOperation) *contentProgressTracker {
+	return &contentProgressTracker{
+		progress: p,
+		id:       instanceID,
+		op:       op,
+	}
+}
+
+// Report publishes the progress of the current phase. Totals are zero if they're not known.
+func (t *contentProgressTracker) Report(phase string, bytesDone, bytesTotal, filesDone, filesTotal int64) {
+	t.report(phase, bytesDone, bytesTotal, filesDone, filesTotal, time.Now())
+}
+
+func (t *contentProgressTracker) report(phase string, bytesDone, bytesTotal, filesDone, filesTotal int64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return
+	}
+	if phase != t.phase {
+		t.phase = phase
+		t.phaseStart = now
+	}
+
+	t.progress.Update(&api.ContentProgress{
+		Id:         t.id,
+		Operation:  t.op,
+		Phase:      phase,
+		BytesDone:  bytesDone,
+		BytesTotal: bytesTotal,
+		FilesDone:  filesDone,
+		FilesTotal: filesTotal,
+		EtaSeconds: estimateRemaining(bytesDone, bytesTotal, now.Sub(t.phaseStart)),
+	})
+}
+
+// Bytes returns a function which reports the bytes processed in a phase, e.g. as storage.WithProgress reports them.
+// As the function is called for every read, the progress is published at most once per contentProgressInterval.
+func (t *contentProgressTracker) Bytes(phase string, bytesTotal int64) func(bytesDone int64) {
+	var (
+		mu         sync.Mutex
+		lastReport time.Time
+	)
+	return func(bytesDone int64) {
+		now := time.Now()
+
+		mu.Lock()
+		if now.Sub(lastReport) < contentProgressInterval && bytesDone < bytesTotal {
+			mu.Unlock()
+			return
+		}
+		lastReport = now
+		mu.Unlock()
+
+		t.report(phase, bytesDone, bytesTotal, 0, 0, now)
+	}
+}
+
+// Done publishes the end of the operation
+func (t *contentProgressTracker) Done(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return
+	}
+	t.done = true
+
+	update := &api.ContentProgress{
+		Id:        t.id,
+		Operation: t.op,
+		Phase:     t.phase,
+		Done:      true,
+	}
+	if err != nil {
+		update.Error = err.Error()
+	}
+	t.progress.Update(update)
+}
+
+// Poll reports the result of measure until the context is canceled
+func (t *contentProgressTracker) Poll(ctx context.Context, measure func() (phase string, bytesDone, bytesTotal, filesDone, filesTotal int64)) {
+	ticker := time.NewTicker(contentProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.Report(measure())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// estimateRemaining extrapolates the rate at which bytes were processed so far to the remaining bytes.
+// It returns zero if there's no basis for an estimate.
+func estimateRemaining(done, total int64, elapsed time.Duration) int64 {
+	if done <= 0 || total <= 0 || elapsed <= 0 {
+		return 0
+	}
+	if done >= total {
+		return 0
+	}
+
+	rate := float64(done) / elapsed.Seconds()
+	return int64(float64(total-done)/rate + 0.5)
+}
+
+// measureDir returns the size and number of the regular files in a directory.
+// Files which disappear while we walk the directory are ignored.
+func measureDir(dir string) (size, files int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		files++
+		return nil
+	})
+	return size, files, err
+}
+
+// fileSize returns the size of a file, or zero if it cannot be determined
+func fileSize(fn string) int64 {
+	stat, err := os.Stat(fn)
+	if err != nil {
+		return 0
+	}
+	return stat.Size()
+}
+
+// ContentProgressService streams the progress of content operations to ws-manager and supervisor
+type ContentProgressService struct {
+	Progress *ContentProgress
+
+	api.UnimplementedContentProgressServiceServer
+}
+
+// NewContentProgressService creates a new content progress service
+func NewContentProgressService(progress *ContentProgress) *ContentProgressService {
+	return &ContentProgressService{Progress: progress}
+}
+
+// WatchContentProgress streams the progress of content restores and backups of a workspace
+func (s *ContentProgressService) WatchContentProgress(req *api.WatchContentProgressRequest, srv api.ContentProgressService_WatchContentProgressServer) error {
+	if req.Id == "" {
+		return status.Error(codes.InvalidArgument, "ID is required")
+	}
+
+	updates, cancel := s.Progress.Subscribe(req.Id)
+	defer cancel()
+
+	for {
+		select {
+		case update := <-updates:
+			err := srv.Send(proto.Clone(update).(*api.ContentProgress))
+			if err != nil {
+				return err
+			}
+		case <-srv.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+var _ = Describe("ContentProgress", func() {
+	It("should replay the latest progress to new subscribers", func() {
+		progress := NewContentProgress()
+		progress.Update(&api.ContentProgress{Id: "ws1", Operation: api.ContentOperation_RESTORE, Phase: ContentPhaseDownloading, BytesDone: 10})
+		progress.Update(&api.ContentProgress{Id: "ws1", Operation: api.ContentOperation_RESTORE, Phase: ContentPhaseDownloading, BytesDone: 20})
+		progress.Update(&api.ContentProgress{Id: "ws2", Operation: api.ContentOperation_BACKUP, Phase: ContentPhaseArchiving})
+
+		updates, cancel := progress.Subscribe("ws1")
+		defer cancel()
+
+		var update *api.ContentProgress
+		Eventually(updates).Should(Receive(&update))
+		Expect(update.BytesDone).To(Equal(int64(20)))
+		Consistently(updates, 100*time.Millisecond).ShouldNot(Receive())
+
+		progress.Update(&api.ContentProgress{Id: "ws1", Operation: api.ContentOperation_SNAPSHOT, Phase: ContentPhaseUploading})
+		Eventually(updates).Should(Receive(&update))
+		Expect(update.Operation).To(Equal(api.ContentOperation_SNAPSHOT))
+	})
+
+	It("should forget the progress of deleted workspaces", func() {
+		progress := NewContentProgress()
+		progress.Update(&api.ContentProgress{Id: "ws1", Operation: api.ContentOperation_BACKUP, Done: true})
+		progress.Forget("ws1")
+
+		updates, cancel := progress.Subscribe("ws1")
+		defer cancel()
+		Consistently(updates, 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should not report progress after an operation is done", func() {
+		progress := NewContentProgress()
+		tracker := progress.track("ws1", api.ContentOperation_BACKUP)
+		now := time.Now()
+		tracker.report(ContentPhaseUploading, 50, 100, 0, 0, now)
+		tracker.report(ContentPhaseUploading, 75, 100, 0, 0, now.Add(10*time.Second))
+		tracker.Done(errors.New("upload failed"))
+		tracker.report(ContentPhaseUploading, 100, 100, 0, 0, now.Add(20*time.Second))
+
+		updates, cancel := progress.Subscribe("ws1")
+		defer cancel()
+
+		var update *api.ContentProgress
+		Eventually(updates).Should(Receive(&update))
+		Expect(update.Done).To(BeTrue())
+		Expect(update.Error).To(Equal("upload failed"))
+		Expect(update.Phase).To(Equal(ContentPhaseUploading))
+	})
+
+	It("should report the bytes uploaded at most once per interval", func() {
+		progress := NewContentProgress()
+		tracker := progress.track("ws1", api.ContentOperation_BACKUP)
+		report := tracker.Bytes(ContentPhaseUploading, 100)
+
+		updates, cancel := progress.Subscribe("ws1")
+		defer cancel()
+
+		report(10)
+		report(20)
+		var update *api.ContentProgress
+		Eventually(updates).Should(Receive(&update))
+		Expect(update.BytesDone).To(Equal(int64(10)))
+		Expect(update.BytesTotal).To(Equal(int64(100)))
+		Consistently(updates, 100*time.Millisecond).ShouldNot(Receive())
+
+		report(100)
+		Eventually(updates).Should(Receive(&update))
+		Expect(update.BytesDone).To(Equal(int64(100)))
+	})
+})
+
+var _ = DescribeTable("estimateRemaining",
+	func(done, total int64, elapsed time.Duration, expectation int64) {
+		Expect(estimateRemaining(done, total, elapsed)).To(Equal(expectation))
+	},
+	Entry("nothing done yet", int64(0), int64(100), 10*time.Second, int64(0)),
+	Entry("unknown total", int64(10), int64(0), 10*time.Second, int64(0)),
+	Entry("halfway through", int64(50), int64(100), 10*time.Second, int64(10)),
+	Entry("a quarter through", int64(25), int64(100), 10*time.Second, int64(30)),
+	Entry("complete", int64(100), int64(100), 10*time.Second, int64(0)),
+)
@@ -0,0 +1,269 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	glog "github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+// drainStateFile is the file in the working area the drain mode is persisted in
+const drainStateFile = ".drain.json"
+
+// DrainTaintKey is the key of the taint which keeps new workspaces off a node while it's drained
+const DrainTaintKey = "gitpod.io/ws-daemon-draining"
+
+// errDraining is the failure of workspace initializations while the node is drained
+var errDraining = errors.New("the node is being drained and does not accept new workspaces")
+
+type drainState struct {
+	Reason  string    `json:"reason"`
+	Started time.Time `json:"started"`
+}
+
+// Drain is the drain mode of the daemon, e.g. during node rotations. While draining, the daemon refuses to
+// initialize new workspaces, which leaves its reconcile workers and disk bandwidth to the backups of stopping
+// workspaces. The drain mode is persisted in the working area, such that it survives restarts of the daemon.
+// While draining, the node is tainted such that new workspaces are no longer scheduled onto it.
+type Drain struct {
+	Location  string
+	Clientset kubernetes.Interface
+	NodeName  string
+
+	state *drainState
+	mu    sync.RWMutex
+
+	draining prometheus.Gauge
+}
+
+// NewDrain creates a new drain mode and restores a previously persisted one
+func NewDrain(location string, clientset kubernetes.Interface, nodeName string, prom prometheus.Registerer) (*Drain, error) {
+	d := &Drain{
+		Location:  location,
+		Clientset: clientset,
+		NodeName:  nodeName,
+		draining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "drain_active",
+			Help: "1 if ws-daemon is draining its node and refuses new workspaces, 0 otherwise",
+		}),
+	}
+	prom.MustRegister(d.draining)
+
+	fc, err := os.ReadFile(filepath.Join(location, drainStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read drain state: %w", err)
+	}
+	var state drainState
+	err = json.Unmarshal(fc, &state)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse drain state: %w", err)
+	}
+	d.state = &state
+	d.draining.Set(1)
+	glog.WithField("reason", state.Reason).WithField("started", state.Started).Info("resuming drain of the node")
+
+	// the taint might have been removed while the daemon was down
+	err = d.setTaint(true)
+	if err != nil {
+		glog.WithError(err).Warn("cannot taint drained node")
+	}
+
+	return d, nil
+}
+
+// Start puts the daemon into drain mode. Starting an ongoing drain keeps its original reason and start time.
+func (d *Drain) Start(reason string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.state != nil {
+		return nil
+	}
+
+	state := &drainState{Reason: reason, Started: time.Now()}
+	fc, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	// taint before persisting the drain, such that a failed start leaves no drain behind which a restart would resume
+	err = d.setTaint(true)
+	if err != nil {
+		return xerrors.Errorf("cannot taint node: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(d.Location, drainStateFile), fc, 0644)
+	if err != nil {
+		if terr := d.setTaint(false); terr != nil {
+			glog.WithError(terr).Warn("cannot remove taint from node after failed drain start")
+		}
+		return xerrors.Errorf("cannot persist drain state: %w", err)
+	}
+
+	d.state = state
+	d.draining.Set(1)
+	glog.WithField("reason", reason).Info("started draining the node")
+	return nil
+}
+
+// Stop ends the drain mode
+func (d *Drain) Stop() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.state == nil {
+		return nil
+	}
+
+	err := d.setTaint(false)
+	if err != nil {
+		return xerrors.Errorf("cannot remove taint from node: %w", err)
+	}
+	err = os.Remove(filepath.Join(d.Location, drainStateFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return xerrors.Errorf("cannot remove drain state: %w", err)
+	}
+
+	d.state = nil
+	d.draining.Set(0)
+	glog.Info("stopped draining the node")
+	return nil
+}
+
+// setTaint adds or removes the drain taint from the node
+func (d *Drain) setTaint(add bool) error {
+	if d.Clientset == nil {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		node, err := d.Clientset.CoreV1().Nodes().Get(ctx, d.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		taints := make([]corev1.Taint, 0, len(node.Spec.Taints)+1)
+		for _, t := range node.Spec.Taints {
+			if t.Key != DrainTaintKey {
+				taints = append(taints, t)
+			}
+		}
+		hasTaint := len(taints) != len(node.Spec.Taints)
+		if add == hasTaint {
+			return nil
+		}
+
+		if add {
+			taints = append(taints, corev1.Taint{Key: DrainTaintKey, Value: "true", Effect: corev1.TaintEffectNoSchedule})
+			glog.WithField("node", d.NodeName).Info("adding drain taint to node")
+		} else {
+			glog.WithField("node", d.NodeName).Info("removing drain taint from node")
+		}
+		node.Spec.Taints = taints
+		_, err = d.Clientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Draining returns true if the daemon must not initialize new workspaces
+func (d *Drain) Draining() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.state != nil
+}
+
+// Status reports the drain mode and the workspaces whose content is still in the working area
+func (d *Drain) Status() (*api.DrainStatus, error) {
+	states, err := filepath.Glob(filepath.Join(d.Location, "*.workspace.json"))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot list workspace state files: %w", err)
+	}
+	workspaces := make([]string, 0, len(states))
+	for _, fn := range states {
+		workspaces = append(workspaces, strings.TrimSuffix(filepath.Base(fn), ".workspace.json"))
+	}
+	sort.Strings(workspaces)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	res := &api.DrainStatus{
+		Workspaces: workspaces,
+	}
+	if d.state != nil {
+		res.Draining = true
+		res.Reason = d.state.Reason
+		res.StartedUnix = d.state.Started.Unix()
+		res.ContentFree = len(workspaces) == 0
+	}
+	return res, nil
+}
+
+// DrainService lets ws-manager or operators drain the node of the daemon
+type DrainService struct {
+	Drain *Drain
+
+	api.UnimplementedDrainServiceServer
+}
+
+// NewDrainService creates a new drain service
+func NewDrainService(drain *Drain) *DrainService {
+	return &DrainService{Drain: drain}
+}
+
+// StartDrain puts the daemon into drain mode
+func (s *DrainService) StartDrain(ctx context.Context, req *api.StartDrainRequest) (*api.DrainStatus, error) {
+	err := s.Drain.Start(req.Reason)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return s.status()
+}
+
+// StopDrain ends the drain mode of the daemon
+func (s *DrainService) StopDrain(ctx context.Context, req *api.StopDrainRequest) (*api.DrainStatus, error) {
+	err := s.Drain.Stop()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return s.status()
+}
+
+// GetDrainStatus reports the drain mode and the workspace content left on the node
+func (s *DrainService) GetDrainStatus(ctx context.Context, req *api.GetDrainStatusRequest) (*api.DrainStatus, error) {
+	return s.status()
+}
+
+func (s *DrainService) status() (*api.DrainStatus, error) {
+	res, err := s.Drain.Status()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res, nil
+}
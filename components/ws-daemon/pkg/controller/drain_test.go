@@ -0,0 +1,145 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Drain", func() {
+	var (
+		location  string
+		clientset *fake.Clientset
+	)
+
+	BeforeEach(func() {
+		location = GinkgoT().TempDir()
+		clientset = fake.NewSimpleClientset(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: NodeName},
+			Spec: corev1.NodeSpec{
+				Taints: []corev1.Taint{{Key: "gitpod.io/gpu", Effect: corev1.TaintEffectNoSchedule}},
+			},
+		})
+	})
+
+	newDrain := func() *Drain {
+		GinkgoHelper()
+
+		drain, err := NewDrain(location, clientset, NodeName, prometheus.NewRegistry())
+		Expect(err).NotTo(HaveOccurred())
+		return drain
+	}
+
+	nodeTaints := func() []string {
+		GinkgoHelper()
+
+		node, err := clientset.CoreV1().Nodes().Get(context.Background(), NodeName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		var keys []string
+		for _, t := range node.Spec.Taints {
+			keys = append(keys, t.Key)
+		}
+		return keys
+	}
+
+	It("should not drain by default", func() {
+		drain := newDrain()
+		Expect(drain.Draining()).To(BeFalse())
+
+		status, err := drain.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Draining).To(BeFalse())
+		Expect(status.ContentFree).To(BeFalse())
+		Expect(nodeTaints()).To(Equal([]string{"gitpod.io/gpu"}))
+	})
+
+	It("should taint the node while draining", func() {
+		drain := newDrain()
+		Expect(drain.Start("node rotation")).To(Succeed())
+		Expect(nodeTaints()).To(Equal([]string{"gitpod.io/gpu", DrainTaintKey}))
+
+		Expect(drain.Start("node rotation")).To(Succeed())
+		Expect(nodeTaints()).To(Equal([]string{"gitpod.io/gpu", DrainTaintKey}))
+
+		Expect(drain.Stop()).To(Succeed())
+		Expect(nodeTaints()).To(Equal([]string{"gitpod.io/gpu"}))
+	})
+
+	It("should not persist the drain if the node cannot be tainted", func() {
+		clientset = fake.NewSimpleClientset()
+
+		drain := newDrain()
+		Expect(drain.Start("node rotation")).NotTo(Succeed())
+		Expect(drain.Draining()).To(BeFalse())
+		Expect(filepath.Join(location, drainStateFile)).NotTo(BeAnExistingFile())
+		Expect(newDrain().Draining()).To(BeFalse())
+	})
+
+	It("should remove the taint if the drain cannot be persisted", func() {
+		drain := newDrain()
+		Expect(os.Mkdir(filepath.Join(location, drainStateFile), 0755)).To(Succeed())
+
+		Expect(drain.Start("node rotation")).NotTo(Succeed())
+		Expect(drain.Draining()).To(BeFalse())
+		Expect(nodeTaints()).To(Equal([]string{"gitpod.io/gpu"}))
+	})
+
+	It("should restore the taint when resuming a drain", func() {
+		Expect(newDrain().Start("node rotation")).To(Succeed())
+
+		node, err := clientset.CoreV1().Nodes().Get(context.Background(), NodeName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		node.Spec.Taints = nil
+		_, err = clientset.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newDrain().Draining()).To(BeTrue())
+		Expect(nodeTaints()).To(Equal([]string{DrainTaintKey}))
+	})
+
+	It("should survive restarts", func() {
+		Expect(newDrain().Start("node rotation")).To(Succeed())
+
+		drain := newDrain()
+		Expect(drain.Draining()).To(BeTrue())
+		status, err := drain.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Reason).To(Equal("node rotation"))
+		Expect(status.StartedUnix).NotTo(BeZero())
+
+		Expect(drain.Stop()).To(Succeed())
+		Expect(newDrain().Draining()).To(BeFalse())
+	})
+
+	It("should report workspace content left on the node", func() {
+		Expect(os.WriteFile(filepath.Join(location, "b.workspace.json"), []byte("{}"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(location, "a.workspace.json"), []byte("{}"), 0644)).To(Succeed())
+
+		drain := newDrain()
+		Expect(drain.Start("node rotation")).To(Succeed())
+
+		status, err := drain.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Workspaces).To(Equal([]string{"a", "b"}))
+		Expect(status.ContentFree).To(BeFalse())
+
+		Expect(os.Remove(filepath.Join(location, "a.workspace.json"))).To(Succeed())
+		Expect(os.Remove(filepath.Join(location, "b.workspace.json"))).To(Succeed())
+
+		status, err = drain.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Workspaces).To(BeEmpty())
+		Expect(status.ContentFree).To(BeTrue())
+	})
+})
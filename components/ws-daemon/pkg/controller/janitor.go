@@ -0,0 +1,271 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	glog "github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// JanitorMode determines what the janitor does with stale working areas
+type JanitorMode string
+
+const (
+	// JanitorModeMetrics only measures stale working areas
+	JanitorModeMetrics JanitorMode = "metrics"
+	// JanitorModeDryRun measures stale working areas and logs how they would be reclaimed
+	JanitorModeDryRun JanitorMode = "dry-run"
+	// JanitorModeReclaim backs up stale working areas if they have no final backup and deletes them
+	JanitorModeReclaim JanitorMode = "reclaim"
+)
+
+// reclaimedBackupName is the name stale content is backed up as. The janitor cannot tell whether a newer instance
+// of the workspace has backed up its content already, hence it never overwrites the regular backup.
+func reclaimedBackupName(instanceID string) string {
+	return fmt.Sprintf("reclaimed-%s.tar", instanceID)
+}
+
+const (
+	defaultJanitorInterval = 30 * time.Minute
+	defaultJanitorMinAge   = 1 * time.Hour
+)
+
+// JanitorConfig configures the garbage collection of working areas whose workspace no longer exists
+type JanitorConfig struct {
+	Enabled bool `json:"enabled"`
+	// Mode is one of metrics, dry-run or reclaim. Defaults to metrics.
+	Mode JanitorMode `json:"mode,omitempty"`
+	// Interval is how often the working area is checked for stale workspace content. Defaults to 30 minutes.
+	Interval util.Duration `json:"interval,omitempty"`
+	// MinAge is how long a workspace must have been gone before its content is considered stale. Defaults to 1 hour.
+	MinAge util.Duration `json:"minAge,omitempty"`
+}
+
+// Janitor finds workspace content in the working area whose workspace no longer exists in the cluster,
+// e.g. because ws-daemon crashed while the workspace stopped, and reclaims the space it uses.
+// Unlike the housekeeping, the janitor looks at content ws-daemon still has a state file for.
+type Janitor struct {
+	Config    JanitorConfig
+	Location  string
+	Namespace string
+
+	reader     client.Reader
+	operations WorkspaceOperations
+
+	// missingSince is when we first noticed that the workspace of some content is gone
+	missingSince map[string]time.Time
+
+	staleWorkingAreas prometheus.Gauge
+	staleBytes        prometheus.Gauge
+	reclaimedBytes    prometheus.Counter
+	staleBackups      *prometheus.CounterVec
+}
+
+// NewJanitor creates a new janitor
+func NewJanitor(cfg JanitorConfig, location, namespace string, reader client.Reader, ops WorkspaceOperations, prom prometheus.Registerer) *Janitor {
+	if cfg.Mode == "" {
+		cfg.Mode = JanitorModeMetrics
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = util.Duration(defaultJanitorInterval)
+	}
+	if cfg.MinAge == 0 {
+		cfg.MinAge = util.Duration(defaultJanitorMinAge)
+	}
+
+	j := &Janitor{
+		Config:       cfg,
+		Location:     location,
+		Namespace:    namespace,
+		reader:       reader,
+		operations:   ops,
+		missingSince: make(map[string]time.Time),
+
+		staleWorkingAreas: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "janitor_stale_working_areas",
+			Help: "Number of workspace contents in the working area whose workspace no longer exists",
+		}),
+		staleBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "janitor_stale_working_areas_bytes",
+			Help: "Disk space used by workspace contents whose workspace no longer exists",
+		}),
+		reclaimedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "janitor_reclaimed_bytes_total",
+			Help: "Disk space reclaimed from workspace contents whose workspace no longer exists",
+		}),
+		staleBackups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "janitor_stale_backups_total",
+			Help: "Number of backups of stale workspace contents which had no final backup",
+		}, []string{"success"}),
+	}
+
+	if cfg.Enabled {
+		prom.MustRegister(
+			j.staleWorkingAreas,
+			j.staleBytes,
+			j.reclaimedBytes,
+			j.staleBackups,
+		)
+	}
+
+	return j
+}
+
+// Start checks the working area for stale workspace content until the context is canceled
+func (j *Janitor) Start(ctx context.Context) {
+	glog.WithField("interval", time.Duration(j.Config.Interval).String()).WithField("mode", j.Config.Mode).Debug("started working area janitor")
+
+	ticker := time.NewTicker(time.Duration(j.Config.Interval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, err := range j.collect(ctx, time.Now()) {
+				glog.WithError(err).Error("error during working area garbage collection")
+			}
+		case <-ctx.Done():
+			glog.Debug("stopping working area janitor")
+			return
+		}
+	}
+}
+
+func (j *Janitor) collect(ctx context.Context, now time.Time) (errs []error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Janitor.collect")
+	defer tracing.FinishSpan(span, nil)
+
+	states, err := filepath.Glob(filepath.Join(j.Location, "*.workspace.json"))
+	if err != nil {
+		return []error{xerrors.Errorf("cannot list workspace state files: %w", err)}
+	}
+
+	var (
+		seen       = make(map[string]struct{}, len(states))
+		stale      int
+		staleBytes int64
+	)
+	for _, fn := range states {
+		instanceID := strings.TrimSuffix(filepath.Base(fn), ".workspace.json")
+		seen[instanceID] = struct{}{}
+
+		isStale, err := j.isStale(ctx, instanceID, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !isStale {
+			continue
+		}
+
+		ws, err := session.LoadWorkspace(ctx, fn)
+		if err != nil {
+			errs = append(errs, xerrors.Errorf("cannot load stale workspace %s: %w", instanceID, err))
+			continue
+		}
+		size, _, _ := measureDir(ws.Location)
+		stale++
+		staleBytes += size
+
+		err = j.reclaim(ctx, ws, size)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// forget about content which is gone, e.g. because the workspace controller eventually deleted it
+	for instanceID := range j.missingSince {
+		if _, ok := seen[instanceID]; !ok {
+			delete(j.missingSince, instanceID)
+		}
+	}
+
+	j.staleWorkingAreas.Set(float64(stale))
+	j.staleBytes.Set(float64(staleBytes))
+
+	return errs
+}
+
+// isStale returns true if the workspace of some content has been gone for at least the minimum age.
+// We only consider content stale once we've noticed its workspace missing at least twice, such that
+// we never act on a single inconsistent read.
+func (j *Janitor) isStale(ctx context.Context, instanceID string, now time.Time) (bool, error) {
+	var ws workspacev1.Workspace
+	err := j.reader.Get(ctx, types.NamespacedName{Namespace: j.Namespace, Name: instanceID}, &ws)
+	if err == nil {
+		delete(j.missingSince, instanceID)
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, xerrors.Errorf("cannot get workspace %s: %w", instanceID, err)
+	}
+
+	since, ok := j.missingSince[instanceID]
+	if !ok {
+		j.missingSince[instanceID] = now
+		return false, nil
+	}
+	return now.Sub(since) >= time.Duration(j.Config.MinAge), nil
+}
+
+// reclaim backs up stale content if it has no final backup and deletes it
+func (j *Janitor) reclaim(ctx context.Context, ws *session.Workspace, size int64) error {
+	needsBackup := !ws.RemoteStorageDisabled && !ws.FinalBackup
+	log := glog.WithFields(ws.OWI()).WithField("location", ws.Location).WithField("size", size).WithField("needsBackup", needsBackup)
+
+	switch j.Config.Mode {
+	case JanitorModeReclaim:
+	case JanitorModeDryRun:
+		log.Info("found stale workspace content (dry-run, not reclaiming it)")
+		return nil
+	default:
+		log.Debug("found stale workspace content")
+		return nil
+	}
+
+	if needsBackup {
+		_, err := j.operations.BackupWorkspace(ctx, BackupOptions{
+			Meta: WorkspaceMeta{
+				Owner:        ws.Owner,
+				Organization: ws.Organization,
+				WorkspaceID:  ws.WorkspaceID,
+				InstanceID:   ws.InstanceID,
+			},
+			SnapshotName: reclaimedBackupName(ws.InstanceID),
+			Reclaim:      true,
+		})
+		j.staleBackups.WithLabelValues(strconv.FormatBool(err == nil)).Inc()
+		if err != nil {
+			return xerrors.Errorf("cannot back up stale workspace content of %s, keeping it: %w", ws.InstanceID, err)
+		}
+	}
+
+	err := j.operations.DeleteWorkspace(ctx, ws.InstanceID)
+	if err != nil {
+		return xerrors.Errorf("cannot delete stale workspace content of %s: %w", ws.InstanceID, err)
+	}
+	delete(j.missingSince, ws.InstanceID)
+	j.reclaimedBytes.Add(float64(size))
+
+	log.Info("reclaimed stale workspace content")
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+var _ = Describe("Janitor", func() {
+	var (
+		fakeClient client.Client
+		ops        *MockWorkspaceOperations
+		location   string
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		fakeClient = fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).Build()
+		ops = NewMockWorkspaceOperations(gomock.NewController(GinkgoT()))
+		location = GinkgoT().TempDir()
+		now = time.Now()
+	})
+
+	newJanitor := func(mode JanitorMode) *Janitor {
+		return NewJanitor(JanitorConfig{Mode: mode}, location, workspaceNamespace, fakeClient, ops, prometheus.NewRegistry())
+	}
+
+	persistContent := func(ws *session.Workspace) *session.Workspace {
+		GinkgoHelper()
+
+		ws.Owner = "owner"
+		ws.WorkspaceID = "workspace"
+		ws.Location = filepath.Join(location, ws.InstanceID)
+		Expect(os.Mkdir(ws.Location, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ws.Location, "content"), []byte("hello world"), 0644)).To(Succeed())
+		Expect(ws.Persist()).To(Succeed())
+		return ws
+	}
+
+	collectTwice := func(j *Janitor) {
+		GinkgoHelper()
+
+		Expect(j.collect(ctx, now)).To(BeEmpty())
+		Expect(j.collect(ctx, now.Add(defaultJanitorMinAge))).To(BeEmpty())
+	}
+
+	It("should keep content of existing workspaces", func() {
+		ws := persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+		Expect(fakeClient.Create(ctx, &workspacev1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: ws.InstanceID, Namespace: workspaceNamespace},
+		})).To(Succeed())
+
+		collectTwice(newJanitor(JanitorModeReclaim))
+	})
+
+	It("should not act before the minimum age", func() {
+		persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+
+		j := newJanitor(JanitorModeReclaim)
+		Expect(j.collect(ctx, now)).To(BeEmpty())
+		Expect(j.collect(ctx, now.Add(defaultJanitorMinAge-time.Minute))).To(BeEmpty())
+	})
+
+	It("should only measure stale content in metrics mode", func() {
+		persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+
+		collectTwice(newJanitor(JanitorModeMetrics))
+	})
+
+	It("should only log stale content in dry-run mode", func() {
+		persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+
+		collectTwice(newJanitor(JanitorModeDryRun))
+	})
+
+	It("should delete stale content with final backup", func() {
+		ws := persistContent(&session.Workspace{InstanceID: uuid.NewString(), FinalBackup: true})
+		ops.EXPECT().DeleteWorkspace(gomock.Any(), ws.InstanceID).Return(nil)
+
+		collectTwice(newJanitor(JanitorModeReclaim))
+	})
+
+	It("should back up stale content without final backup under its own name", func() {
+		ws := persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+		gomock.InOrder(
+			ops.EXPECT().BackupWorkspace(gomock.Any(), BackupOptions{
+				Meta:         WorkspaceMeta{Owner: "owner", WorkspaceID: "workspace", InstanceID: ws.InstanceID},
+				SnapshotName: "reclaimed-" + ws.InstanceID + ".tar",
+				Reclaim:      true,
+			}).Return(&BackupResult{}, nil),
+			ops.EXPECT().DeleteWorkspace(gomock.Any(), ws.InstanceID).Return(nil),
+		)
+
+		collectTwice(newJanitor(JanitorModeReclaim))
+	})
+
+	It("should not overwrite the regular backup of a newer instance which is gone already", func() {
+		// the newer instance stopped and wrote the regular backup, and its workspace no longer exists
+		ws := persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+		gomock.InOrder(
+			ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, opts BackupOptions) (*BackupResult, error) {
+				Expect(opts.SnapshotName).NotTo(Equal(storage.DefaultBackup))
+				return &BackupResult{}, nil
+			}),
+			ops.EXPECT().DeleteWorkspace(gomock.Any(), ws.InstanceID).Return(nil),
+		)
+
+		collectTwice(newJanitor(JanitorModeReclaim))
+	})
+
+	It("should keep stale content if the backup fails", func() {
+		persistContent(&session.Workspace{InstanceID: uuid.NewString()})
+		ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("BOOM!"))
+
+		j := newJanitor(JanitorModeReclaim)
+		Expect(j.collect(ctx, now)).To(BeEmpty())
+		Expect(j.collect(ctx, now.Add(defaultJanitorMinAge))).To(HaveLen(1))
+	})
+})
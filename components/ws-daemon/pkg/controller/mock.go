@@ -12,7 +12,6 @@ import (
 	context "context"
 	reflect "reflect"
 
-	api "github.com/gitpod-io/gitpod/content-service/api"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -40,10 +39,10 @@ func (m *MockWorkspaceOperations) EXPECT() *MockWorkspaceOperationsMockRecorder
 }
 
 // BackupWorkspace mocks base method.
-func (m *MockWorkspaceOperations) BackupWorkspace(arg0 context.Context, arg1 BackupOptions) (*api.GitStatus, error) {
+func (m *MockWorkspaceOperations) BackupWorkspace(arg0 context.Context, arg1 BackupOptions) (*BackupResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "BackupWorkspace", arg0, arg1)
-	ret0, _ := ret[0].(*api.GitStatus)
+	ret0, _ := ret[0].(*BackupResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -98,17 +97,17 @@ func (mr *MockWorkspaceOperationsMockRecorder) SetupWorkspace(arg0, arg1 interfa
 }
 
 // Snapshot mocks base method.
-func (m *MockWorkspaceOperations) Snapshot(arg0 context.Context, arg1, arg2 string) error {
+func (m *MockWorkspaceOperations) Snapshot(arg0 context.Context, arg1, arg2 string, arg3 SnapshotProgressFunc) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Snapshot", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Snapshot", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Snapshot indicates an expected call of Snapshot.
-func (mr *MockWorkspaceOperationsMockRecorder) Snapshot(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockWorkspaceOperationsMockRecorder) Snapshot(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockWorkspaceOperations)(nil).Snapshot), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockWorkspaceOperations)(nil).Snapshot), arg0, arg1, arg2, arg3)
 }
 
 // SnapshotIDs mocks base method.
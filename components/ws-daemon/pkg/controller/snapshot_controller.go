@@ -101,6 +101,9 @@ func (ssc *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 
 		snapshot.Status.URL = snapshotURL
+		snapshot.Status.Phase = workspacev1.SnapshotPhasePending
+		snapshot.Status.ObservedGeneration = snapshot.Generation
+		snapshot.Status.UpdateReadyCondition()
 		return ssc.Client.Status().Update(ctx, &snapshot)
 	})
 
@@ -109,7 +112,28 @@ func (ssc *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, fmt.Errorf("could not set snapshot url: %w", err)
 	}
 
-	snapshotErr = ssc.operations.Snapshot(ctx, snapshot.Spec.WorkspaceID, snapshotName)
+	lastPhase := workspacev1.SnapshotPhasePending
+	snapshotErr = ssc.operations.Snapshot(ctx, snapshot.Spec.WorkspaceID, snapshotName, func(phase workspacev1.SnapshotPhase, size int64) {
+		lastPhase = phase
+		err := retry.RetryOnConflict(retryParams, func() error {
+			err := ssc.Client.Get(ctx, req.NamespacedName, &snapshot)
+			if err != nil {
+				return err
+			}
+
+			snapshot.Status.Phase = phase
+			snapshot.Status.Progress = snapshotProgress(phase)
+			if size > 0 {
+				snapshot.Status.Size = size
+			}
+			snapshot.Status.UpdateReadyCondition()
+			return ssc.Client.Status().Update(ctx, &snapshot)
+		})
+		if err != nil {
+			// Progress is informational only, don't fail the snapshot because of it.
+			log.Error(err, "could not update snapshot progress", "workspace", snapshot.Spec.WorkspaceID, "phase", phase)
+		}
+	})
 	if snapshotErr != nil {
 		log.Error(snapshotErr, "could not take snapshot", "workspace", snapshot.Spec.WorkspaceID)
 	}
@@ -123,7 +147,16 @@ func (ssc *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		snapshot.Status.Completed = true
 		if snapshotErr != nil {
 			snapshot.Status.Error = fmt.Errorf("could not take snapshot: %w", snapshotErr).Error()
+			snapshot.Status.Phase = workspacev1.SnapshotPhaseFailed
+			snapshot.Status.ErrorDetail = &workspacev1.SnapshotError{
+				Reason:  snapshotErrorReason(lastPhase),
+				Message: snapshotErr.Error(),
+			}
+		} else {
+			snapshot.Status.Phase = workspacev1.SnapshotPhaseCompleted
+			snapshot.Status.Progress = snapshotProgress(workspacev1.SnapshotPhaseCompleted)
 		}
+		snapshot.Status.UpdateReadyCondition()
 
 		return ssc.Status().Update(ctx, &snapshot)
 	})
@@ -137,6 +170,31 @@ func (ssc *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{}, err
 }
 
+// snapshotProgress estimates the completion percentage of a snapshot in the given phase.
+// Archiving and uploading take roughly the same time, hence they account for half of the progress each.
+func snapshotProgress(phase workspacev1.SnapshotPhase) int32 {
+	switch phase {
+	case workspacev1.SnapshotPhaseUploading:
+		return 50
+	case workspacev1.SnapshotPhaseCompleted:
+		return 100
+	default:
+		return 0
+	}
+}
+
+// snapshotErrorReason determines the reason of a snapshot failure from the last phase it reached.
+func snapshotErrorReason(lastPhase workspacev1.SnapshotPhase) workspacev1.SnapshotErrorReason {
+	switch lastPhase {
+	case workspacev1.SnapshotPhaseArchiving:
+		return workspacev1.SnapshotErrorArchiveFailed
+	case workspacev1.SnapshotPhaseUploading:
+		return workspacev1.SnapshotErrorUploadFailed
+	default:
+		return workspacev1.SnapshotErrorWorkspaceUnavailable
+	}
+}
+
 func (ssc *SnapshotReconciler) emitEvent(s *workspacev1.Snapshot, failure error) {
 	eventType := corev1.EventTypeNormal
 	reason := "Succeeded"
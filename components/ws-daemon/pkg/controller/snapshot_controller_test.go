@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("SnapshotController", func() {
+	var (
+		fakeClient client.Client
+		ops        *MockWorkspaceOperations
+		r          *SnapshotReconciler
+	)
+
+	BeforeEach(func() {
+		// The snapshot CRD isn't installed in the test environment, use a fake client instead.
+		fakeClient = fake.NewClientBuilder().WithStatusSubresource(&workspacev1.Snapshot{}).WithScheme(k8sClient.Scheme()).Build()
+		ops = NewMockWorkspaceOperations(gomock.NewController(GinkgoT()))
+		r = NewSnapshotController(fakeClient, record.NewFakeRecorder(100), NodeName, 1, ops)
+	})
+
+	reconcileSnapshot := func() *workspacev1.Snapshot {
+		GinkgoHelper()
+
+		snapshot := &workspacev1.Snapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: uuid.NewString(), Namespace: workspaceNamespace},
+			Spec:       workspacev1.SnapshotSpec{NodeName: NodeName, WorkspaceID: uuid.NewString()},
+		}
+		Expect(fakeClient.Create(ctx, snapshot)).To(Succeed())
+
+		key := types.NamespacedName{Name: snapshot.Name, Namespace: snapshot.Namespace}
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeClient.Get(ctx, key, snapshot)).To(Succeed())
+		return snapshot
+	}
+
+	It("should report progress of a successful snapshot", func() {
+		ops.EXPECT().SnapshotIDs(gomock.Any(), gomock.Any()).Return("snapshotUrl", "snapshotName", nil)
+		ops.EXPECT().Snapshot(gomock.Any(), gomock.Any(), "snapshotName", gomock.Any()).DoAndReturn(
+			func(ctx context.Context, instanceID, snapshotName string, progress SnapshotProgressFunc) error {
+				progress(workspacev1.SnapshotPhaseArchiving, 0)
+				progress(workspacev1.SnapshotPhaseUploading, 1024)
+
+				var snapshots workspacev1.SnapshotList
+				Expect(fakeClient.List(ctx, &snapshots)).To(Succeed())
+				Expect(snapshots.Items).To(HaveLen(1))
+				Expect(snapshots.Items[0].Status.Phase).To(Equal(workspacev1.SnapshotPhaseUploading))
+				Expect(snapshots.Items[0].Status.Progress).To(Equal(int32(50)))
+				Expect(snapshots.Items[0].Status.Completed).To(BeFalse())
+				Expect(snapshots.Items[0].Status.Conditions).To(ContainElement(And(
+					HaveField("Type", workspacev1.SnapshotConditionReady),
+					HaveField("Status", metav1.ConditionFalse),
+					HaveField("Reason", string(workspacev1.SnapshotPhaseUploading)),
+				)))
+				return nil
+			})
+
+		snapshot := reconcileSnapshot()
+		Expect(snapshot.Status.Completed).To(BeTrue())
+		Expect(snapshot.Status.URL).To(Equal("snapshotUrl"))
+		Expect(snapshot.Status.Phase).To(Equal(workspacev1.SnapshotPhaseCompleted))
+		Expect(snapshot.Status.Progress).To(Equal(int32(100)))
+		Expect(snapshot.Status.Size).To(Equal(int64(1024)))
+		Expect(snapshot.Status.ErrorDetail).To(BeNil())
+		Expect(snapshot.Status.ObservedGeneration).To(Equal(snapshot.Generation))
+		Expect(snapshot.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", workspacev1.SnapshotConditionReady),
+			HaveField("Status", metav1.ConditionTrue),
+			HaveField("ObservedGeneration", snapshot.Generation),
+		)))
+	})
+
+	It("should report a structured error for a failed upload", func() {
+		ops.EXPECT().SnapshotIDs(gomock.Any(), gomock.Any()).Return("snapshotUrl", "snapshotName", nil)
+		ops.EXPECT().Snapshot(gomock.Any(), gomock.Any(), "snapshotName", gomock.Any()).DoAndReturn(
+			func(ctx context.Context, instanceID, snapshotName string, progress SnapshotProgressFunc) error {
+				progress(workspacev1.SnapshotPhaseArchiving, 0)
+				progress(workspacev1.SnapshotPhaseUploading, 1024)
+				return fmt.Errorf("bucket not found")
+			})
+
+		snapshot := reconcileSnapshot()
+		Expect(snapshot.Status.Completed).To(BeTrue())
+		Expect(snapshot.Status.Phase).To(Equal(workspacev1.SnapshotPhaseFailed))
+		Expect(snapshot.Status.Progress).To(Equal(int32(50)))
+		Expect(snapshot.Status.Error).ToNot(BeEmpty())
+		Expect(snapshot.Status.ErrorDetail).To(Equal(&workspacev1.SnapshotError{
+			Reason:  workspacev1.SnapshotErrorUploadFailed,
+			Message: "bucket not found",
+		}))
+		Expect(snapshot.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", workspacev1.SnapshotConditionReady),
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", string(workspacev1.SnapshotErrorUploadFailed)),
+			HaveField("Message", "bucket not found"),
+		)))
+	})
+})
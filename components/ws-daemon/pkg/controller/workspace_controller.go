@@ -6,7 +6,9 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"time"
 
 	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
@@ -23,7 +25,7 @@ import (
 
 	"google.golang.org/protobuf/proto"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -53,24 +55,43 @@ type WorkspaceControllerOpts struct {
 	MetricsRegistry  prometheus.Registerer
 }
 
+// defaultBackupRetryBackoff is the delay before the first backup retry if none is configured
+const defaultBackupRetryBackoff = 10 * time.Second
+
+// BackupRetry configures how failed backups are retried before the backup is considered failed
+type BackupRetry struct {
+	// Attempts is the number of backup attempts, values below one mean a single attempt
+	Attempts int
+	// Backoff is the delay before the first retry, it doubles with every further attempt
+	Backoff time.Duration
+}
+
 type WorkspaceController struct {
 	client.Client
 	NodeName                string
 	maxConcurrentReconciles int
+	backupRetry             BackupRetry
+	drain                   *Drain
 	operations              WorkspaceOperations
 	metrics                 *workspaceMetrics
 	secretNamespace         string
 	recorder                record.EventRecorder
 }
 
-func NewWorkspaceController(c client.Client, recorder record.EventRecorder, nodeName, secretNamespace string, maxConcurrentReconciles int, ops WorkspaceOperations, reg prometheus.Registerer) (*WorkspaceController, error) {
+func NewWorkspaceController(c client.Client, recorder record.EventRecorder, nodeName, secretNamespace string, maxConcurrentReconciles int, backupRetry BackupRetry, drain *Drain, ops WorkspaceOperations, reg prometheus.Registerer) (*WorkspaceController, error) {
 	metrics := newWorkspaceMetrics()
 	reg.Register(metrics)
 
+	if backupRetry.Backoff <= 0 {
+		backupRetry.Backoff = defaultBackupRetryBackoff
+	}
+
 	return &WorkspaceController{
 		Client:                  c,
 		NodeName:                nodeName,
 		maxConcurrentReconciles: maxConcurrentReconciles,
+		backupRetry:             backupRetry,
+		drain:                   drain,
 		operations:              ops,
 		metrics:                 metrics,
 		secretNamespace:         secretNamespace,
@@ -151,7 +172,7 @@ func (wsc *WorkspaceController) latestWorkspace(ctx context.Context, ws *workspa
 	ws.Status.SetCondition(workspacev1.NewWorkspaceConditionRefresh())
 
 	err := wsc.Client.Status().Update(ctx, ws)
-	if err != nil && !errors.IsConflict(err) {
+	if err != nil && !apierrors.IsConflict(err) {
 		glog.WithFields(ws.OWI()).Warnf("could not refresh workspace: %v", err)
 	}
 
@@ -170,6 +191,10 @@ func (wsc *WorkspaceController) handleWorkspaceInit(ctx context.Context, ws *wor
 
 		glog.WithFields(ws.OWI()).WithField("workspace", req.NamespacedName).WithField("phase", ws.Status.Phase).Info("handle workspace init")
 
+		if wsc.drain.Draining() {
+			return wsc.refuseWorkspaceInit(ctx, ws, req)
+		}
+
 		init, err := wsc.prepareInitializer(ctx, ws)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to prepare initializer: %w", err)
@@ -178,9 +203,10 @@ func (wsc *WorkspaceController) handleWorkspaceInit(ctx context.Context, ws *wor
 		initStart := time.Now()
 		failure, initErr := wsc.operations.InitWorkspace(ctx, InitOptions{
 			Meta: WorkspaceMeta{
-				Owner:       ws.Spec.Ownership.Owner,
-				WorkspaceID: ws.Spec.Ownership.WorkspaceID,
-				InstanceID:  ws.Name,
+				Owner:        ws.Spec.Ownership.Owner,
+				Organization: ws.Spec.Ownership.Team,
+				WorkspaceID:  ws.Spec.Ownership.WorkspaceID,
+				InstanceID:   ws.Name,
 			},
 			Initializer:  init,
 			Headless:     ws.IsHeadless(),
@@ -195,6 +221,9 @@ func (wsc *WorkspaceController) handleWorkspaceInit(ctx context.Context, ws *wor
 			if failure != "" {
 				log.Error(initErr, "could not initialize workspace", "name", ws.Name)
 				ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionFalse, workspacev1.ReasonInitializationFailure, failure))
+				if errors.Is(initErr, storage.ErrDigestMismatch) {
+					ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentCorrupted(failure))
+				}
 			} else {
 				ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionTrue, workspacev1.ReasonInitializationSuccess, ""))
 			}
@@ -202,6 +231,9 @@ func (wsc *WorkspaceController) handleWorkspaceInit(ctx context.Context, ws *wor
 			return wsc.Status().Update(ctx, ws)
 		})
 
+		if errors.Is(initErr, storage.ErrDigestMismatch) {
+			wsc.metrics.recordContentCorrupted(ws)
+		}
 		if err == nil {
 			wsc.metrics.recordInitializeTime(time.Since(initStart).Seconds(), ws)
 		} else {
@@ -215,6 +247,26 @@ func (wsc *WorkspaceController) handleWorkspaceInit(ctx context.Context, ws *wor
 	return ctrl.Result{}, nil
 }
 
+// refuseWorkspaceInit fails the content initialization of a workspace because the node is being drained
+func (wsc *WorkspaceController) refuseWorkspaceInit(ctx context.Context, ws *workspacev1.Workspace, req ctrl.Request) (ctrl.Result, error) {
+	glog.WithFields(ws.OWI()).Warn("refusing to initialize workspace on draining node")
+
+	err := retry.RetryOnConflict(retryParams, func() error {
+		if err := wsc.Get(ctx, req.NamespacedName, ws); err != nil {
+			return err
+		}
+
+		ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionFalse, workspacev1.ReasonInitializationFailure, errDraining.Error()))
+		return wsc.Status().Update(ctx, ws)
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to set content ready condition (failure: '%s'): %w", errDraining.Error(), err)
+	}
+
+	wsc.emitEvent(ws, "Content init", errDraining)
+	return ctrl.Result{}, nil
+}
+
 func (wsc *WorkspaceController) handleWorkspaceRunning(ctx context.Context, ws *workspacev1.Workspace, req ctrl.Request) (result ctrl.Result, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "handleWorkspaceRunning")
 	defer tracing.FinishSpan(span, &err)
@@ -231,6 +283,10 @@ func (wsc *WorkspaceController) handleWorkspaceStop(ctx context.Context, ws *wor
 		return ctrl.Result{}, fmt.Errorf("workspace content was never ready")
 	}
 
+	if ws.UsesPVC() {
+		return wsc.handlePVCWorkspaceStop(ctx, ws)
+	}
+
 	if ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupComplete) {
 		return ctrl.Result{}, nil
 	}
@@ -260,6 +316,11 @@ func (wsc *WorkspaceController) handleWorkspaceStop(ctx context.Context, ws *wor
 		return ctrl.Result{Requeue: true, RequeueAfter: 100 * time.Millisecond}, nil
 	}
 
+	if delay := wsc.backupRetryDelay(ws); delay > 0 {
+		// The previous backup attempt failed, wait before we try again.
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
 	glog.WithFields(ws.OWI()).WithField("workspace", req.NamespacedName).WithField("phase", ws.Status.Phase).Info("handle workspace stop")
 
 	disposeStart := time.Now()
@@ -292,26 +353,45 @@ func (wsc *WorkspaceController) handleWorkspaceStop(ctx context.Context, ws *wor
 		}
 	}
 
-	gitStatus, disposeErr := wsc.operations.BackupWorkspace(ctx, BackupOptions{
+	backup, disposeErr := wsc.operations.BackupWorkspace(ctx, BackupOptions{
 		Meta: WorkspaceMeta{
-			Owner:       ws.Spec.Ownership.Owner,
-			WorkspaceID: ws.Spec.Ownership.WorkspaceID,
-			InstanceID:  ws.Name,
+			Owner:        ws.Spec.Ownership.Owner,
+			Organization: ws.Spec.Ownership.Team,
+			WorkspaceID:  ws.Spec.Ownership.WorkspaceID,
+			InstanceID:   ws.Name,
 		},
 		SnapshotName:    snapshotName,
 		BackupLogs:      ws.Spec.Type == workspacev1.WorkspaceTypePrebuild,
 		UpdateGitStatus: ws.Spec.Type == workspacev1.WorkspaceTypeRegular,
 	})
 
+	var retrying bool
 	err = retry.RetryOnConflict(retryParams, func() error {
 		if err := wsc.Get(ctx, req.NamespacedName, ws); err != nil {
 			return err
 		}
 
-		ws.Status.GitStatus = toWorkspaceGitStatus(gitStatus)
-
+		attempt := workspacev1.BackupAttempt{Time: metav1.Now()}
 		if disposeErr != nil {
-			log.Error(disposeErr, "failed to backup workspace", "name", ws.Name)
+			attempt.Error = disposeErr.Error()
+		}
+		ws.Status.BackupAttempts = append(ws.Status.BackupAttempts, attempt)
+		retrying = disposeErr != nil && len(ws.Status.BackupAttempts) < wsc.backupRetry.Attempts
+
+		if backup != nil {
+			ws.Status.GitStatus = toWorkspaceGitStatus(backup.GitStatus)
+			if len(backup.Logs) > 0 {
+				if ws.Status.Headless == nil {
+					ws.Status.Headless = &workspacev1.HeadlessStatus{}
+				}
+				ws.Status.Headless.Logs = backup.Logs
+			}
+		}
+
+		if retrying {
+			log.Error(disposeErr, "failed to backup workspace, will retry", "name", ws.Name, "attempt", len(ws.Status.BackupAttempts))
+		} else if disposeErr != nil {
+			log.Error(disposeErr, "failed to backup workspace", "name", ws.Name, "attempts", len(ws.Status.BackupAttempts))
 			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupFailure(disposeErr.Error()))
 		} else {
 			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupComplete())
@@ -320,6 +400,15 @@ func (wsc *WorkspaceController) handleWorkspaceStop(ctx context.Context, ws *wor
 		return wsc.Status().Update(ctx, ws)
 	})
 
+	if retrying {
+		// Keep the workspace content on the node such that the next attempt can back it up.
+		wsc.emitEvent(ws, "Backup", fmt.Errorf("backup attempt %d of %d failed, will retry: %w", len(ws.Status.BackupAttempts), wsc.backupRetry.Attempts, disposeErr))
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to record backup attempt: %w", err)
+		}
+		return ctrl.Result{RequeueAfter: wsc.backupRetryDelay(ws)}, nil
+	}
+
 	if err == nil {
 		wsc.metrics.recordFinalizeTime(time.Since(disposeStart).Seconds(), ws)
 	} else {
@@ -339,13 +428,63 @@ func (wsc *WorkspaceController) handleWorkspaceStop(ctx context.Context, ws *wor
 	return ctrl.Result{}, nil
 }
 
+// backupRetryDelay returns how long we have to wait before the next backup attempt, if the previous one failed.
+// The delay doubles with every failed attempt.
+func (wsc *WorkspaceController) backupRetryDelay(ws *workspacev1.Workspace) time.Duration {
+	attempts := ws.Status.BackupAttempts
+	if len(attempts) == 0 || attempts[len(attempts)-1].Error == "" {
+		return 0
+	}
+
+	delay := wsc.backupRetry.Backoff
+	for i := 1; i < len(attempts); i++ {
+		delay *= 2
+	}
+	return time.Until(attempts[len(attempts)-1].Time.Add(delay))
+}
+
+// handlePVCWorkspaceStop cleans up the node of a workspace whose content lives on a persistent volume claim.
+// ws-manager backs up such workspaces by taking a volume snapshot, hence there's nothing for us to back up.
+func (wsc *WorkspaceController) handlePVCWorkspaceStop(ctx context.Context, ws *workspacev1.Workspace) (ctrl.Result, error) {
+	if ws.IsConditionTrue(workspacev1.WorkspaceConditionContainerRunning) {
+		return ctrl.Result{RequeueAfter: 500 * time.Millisecond}, nil
+	}
+
+	err := wsc.operations.DeleteWorkspace(ctx, ws.Name)
+	if errors.Is(err, fs.ErrNotExist) {
+		// we have cleaned up already
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		wsc.emitEvent(ws, "Cleanup", fmt.Errorf("failed to clean up workspace: %w", err))
+		return ctrl.Result{}, fmt.Errorf("failed to clean up workspace: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
 func (wsc *WorkspaceController) prepareInitializer(ctx context.Context, ws *workspacev1.Workspace) (*csapi.WorkspaceInitializer, error) {
+	if ws.UsesPVC() && ws.Status.PVC.RestoredFrom != "" {
+		// The workspace volume was restored from a volume snapshot and already holds the content.
+		return &csapi.WorkspaceInitializer{Spec: &csapi.WorkspaceInitializer_Empty{Empty: &csapi.EmptyInitializer{}}}, nil
+	}
+
 	var init csapi.WorkspaceInitializer
 	err := proto.Unmarshal(ws.Spec.Initializer, &init)
 	if err != nil {
 		err = fmt.Errorf("cannot unmarshal initializer config: %w", err)
 		return nil, err
 	}
+	initializer := &init
+	if vs := init.GetVolumeSnapshot(); vs != nil {
+		// The workspace volume was not restored from the volume snapshot, e.g. because it has been deleted
+		// in the meantime. Initialize the content from the fallback instead.
+		glog.WithFields(ws.OWI()).WithField("volumeSnapshot", vs.VolumeSnapshotName).Warn("volume snapshot is missing, falling back to object storage")
+		initializer = vs.Fallback
+		if initializer == nil {
+			initializer = &csapi.WorkspaceInitializer{Spec: &csapi.WorkspaceInitializer_Empty{Empty: &csapi.EmptyInitializer{}}}
+		}
+	}
 
 	var tokenSecret corev1.Secret
 	err = wsc.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-tokens", ws.Name), Namespace: wsc.secretNamespace}, &tokenSecret)
@@ -353,11 +492,11 @@ func (wsc *WorkspaceController) prepareInitializer(ctx context.Context, ws *work
 		return nil, fmt.Errorf("could not get token secret for workspace: %w", err)
 	}
 
-	if err = csapi.InjectSecretsToInitializer(&init, tokenSecret.Data); err != nil {
+	if err = csapi.InjectSecretsToInitializer(initializer, tokenSecret.Data); err != nil {
 		return nil, fmt.Errorf("failed to inject secrets into initializer: %w", err)
 	}
 
-	return &init, nil
+	return initializer, nil
 }
 
 func (wsc *WorkspaceController) emitEvent(ws *workspacev1.Workspace, operation string, failure error) {
@@ -386,6 +525,7 @@ func toWorkspaceGitStatus(status *csapi.GitStatus) *workspacev1.GitStatus {
 type workspaceMetrics struct {
 	initializeTimeHistVec *prometheus.HistogramVec
 	finalizeTimeHistVec   *prometheus.HistogramVec
+	contentCorruptedVec   *prometheus.CounterVec
 }
 
 func newWorkspaceMetrics() *workspaceMetrics {
@@ -404,6 +544,12 @@ func newWorkspaceMetrics() *workspaceMetrics {
 			Help:      "time it took to finalize workspace",
 			Buckets:   prometheus.ExponentialBuckets(2, 2, 10),
 		}, []string{"type", "class"}),
+		contentCorruptedVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gitpod",
+			Subsystem: "ws_daemon",
+			Name:      "workspace_content_corrupted_total",
+			Help:      "number of workspaces whose content did not match the digest of its backup",
+		}, []string{"type", "class"}),
 	}
 }
 
@@ -431,14 +577,29 @@ func (m *workspaceMetrics) recordFinalizeTime(duration float64, ws *workspacev1.
 	hist.Observe(duration)
 }
 
+func (m *workspaceMetrics) recordContentCorrupted(ws *workspacev1.Workspace) {
+	tpe := string(ws.Spec.Type)
+	class := ws.Spec.Class
+
+	counter, err := m.contentCorruptedVec.GetMetricWithLabelValues(tpe, class)
+	if err != nil {
+		glog.WithError(err).WithFields(ws.OWI()).WithField("type", tpe).WithField("class", class).Infof("could not retrieve content corrupted metric")
+		return
+	}
+
+	counter.Inc()
+}
+
 // Describe implements Collector. It will send exactly one Desc to the provided channel.
 func (m *workspaceMetrics) Describe(ch chan<- *prometheus.Desc) {
 	m.initializeTimeHistVec.Describe(ch)
 	m.finalizeTimeHistVec.Describe(ch)
+	m.contentCorruptedVec.Describe(ch)
 }
 
 // Collect implements Collector.
 func (m *workspaceMetrics) Collect(ch chan<- prometheus.Metric) {
 	m.initializeTimeHistVec.Collect(ch)
 	m.finalizeTimeHistVec.Collect(ch)
+	m.contentCorruptedVec.Collect(ch)
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/aws/smithy-go/ptr"
 	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -56,6 +57,58 @@ var _ = Describe("WorkspaceController", func() {
 			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionContentReady), metav1.ConditionTrue, "InitializationSuccess")
 		})
 
+		It("should refuse content init while draining", func() {
+			name := uuid.NewString()
+
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			ops := NewMockWorkspaceOperations(mockCtrl)
+			workspaceCtrl.operations = ops
+
+			Expect(workspaceCtrl.drain.Start("node rotation")).To(Succeed())
+			defer func() {
+				Expect(workspaceCtrl.drain.Stop()).To(Succeed())
+			}()
+
+			_ = createSecret(fmt.Sprintf("%s-tokens", name), secretsNamespace)
+			ws := newWorkspace(name, workspaceNamespace, workspacev1.WorkspacePhaseCreating)
+			createWorkspace(ws)
+			updateObjWithRetries(k8sClient, ws, true, func(ws *workspacev1.Workspace) {
+				ws.Status.Phase = workspacev1.WorkspacePhaseCreating
+				ws.Status.Conditions = []metav1.Condition{}
+				ws.Status.Runtime = &workspacev1.WorkspaceRuntimeStatus{
+					NodeName: NodeName,
+				}
+			})
+
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionContentReady), metav1.ConditionFalse, "InitializationFailure")
+		})
+
+		It("should mark corrupted content on restore", func() {
+			name := uuid.NewString()
+
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			ops := NewMockWorkspaceOperations(mockCtrl)
+
+			ops.EXPECT().InitWorkspace(gomock.Any(), gomock.Any()).Return("workspace content is corrupted", fmt.Errorf("%w: full.tar", storage.ErrDigestMismatch)).Times(1)
+			workspaceCtrl.operations = ops
+
+			_ = createSecret(fmt.Sprintf("%s-tokens", name), secretsNamespace)
+			ws := newWorkspace(name, workspaceNamespace, workspacev1.WorkspacePhaseCreating)
+			createWorkspace(ws)
+			updateObjWithRetries(k8sClient, ws, true, func(ws *workspacev1.Workspace) {
+				ws.Status.Phase = workspacev1.WorkspacePhaseCreating
+				ws.Status.Conditions = []metav1.Condition{}
+				ws.Status.Runtime = &workspacev1.WorkspaceRuntimeStatus{
+					NodeName: NodeName,
+				}
+			})
+
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionContentReady), metav1.ConditionFalse, "InitializationFailure")
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionContentCorrupted), metav1.ConditionTrue, "DigestMismatch")
+		})
+
 		It("should handle regular content backup", func() {
 			name := uuid.NewString()
 
@@ -74,7 +127,7 @@ var _ = Describe("WorkspaceController", func() {
 				TotalUnpushedCommits: 1,
 			}
 
-			ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(gitStatus, nil).Times(1)
+			ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(&BackupResult{GitStatus: gitStatus}, nil).Times(1)
 			ops.EXPECT().DeleteWorkspace(gomock.Any(), gomock.Any())
 			workspaceCtrl.operations = ops
 
@@ -104,16 +157,71 @@ var _ = Describe("WorkspaceController", func() {
 			markContentReady(ws)
 
 			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionBackupFailure), metav1.ConditionTrue, "BackupFailed")
+			Expect(ws.Status.BackupAttempts).To(HaveLen(1))
+		})
+
+		It("should report git status on backup failure", func() {
+			name := uuid.NewString()
+
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			ops := NewMockWorkspaceOperations(mockCtrl)
+
+			gitStatus := &csapi.GitStatus{
+				Branch:               "main",
+				UncommitedFiles:      []string{"foo.txt"},
+				TotalUncommitedFiles: 1,
+			}
+			ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(&BackupResult{GitStatus: gitStatus}, fmt.Errorf("BOOM!")).Times(1)
+			ops.EXPECT().DeleteWorkspace(gomock.Any(), gomock.Any())
+			workspaceCtrl.operations = ops
+
+			_ = createSecret(fmt.Sprintf("%s-tokens", name), secretsNamespace)
+			ws := newWorkspace(name, workspaceNamespace, workspacev1.WorkspacePhaseCreating)
+			createWorkspace(ws)
+			markContentReady(ws)
+
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionBackupFailure), metav1.ConditionTrue, "BackupFailed")
+			expectGitStatusEventually(ws, gitStatus)
+		})
+
+		It("should retry failed backups", func() {
+			name := uuid.NewString()
+
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			ops := NewMockWorkspaceOperations(mockCtrl)
+
+			gomock.InOrder(
+				ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("BOOM!")),
+				ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(&BackupResult{}, nil),
+			)
+			ops.EXPECT().DeleteWorkspace(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			workspaceCtrl.operations = ops
+			workspaceCtrl.backupRetry = BackupRetry{Attempts: 3, Backoff: 100 * time.Millisecond}
+			defer func() { workspaceCtrl.backupRetry = BackupRetry{Backoff: defaultBackupRetryBackoff} }()
+
+			_ = createSecret(fmt.Sprintf("%s-tokens", name), secretsNamespace)
+			ws := newWorkspace(name, workspaceNamespace, workspacev1.WorkspacePhaseCreating)
+			createWorkspace(ws)
+			markContentReady(ws)
+
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionBackupComplete), metav1.ConditionTrue, "BackupComplete")
+			Expect(ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupFailure)).To(BeFalse())
+			Expect(ws.Status.BackupAttempts).To(HaveLen(2))
+			Expect(ws.Status.BackupAttempts[0].Error).To(Equal("BOOM!"))
+			Expect(ws.Status.BackupAttempts[1].Error).To(BeEmpty())
 		})
 
-		It("should report snapshot url on snapshot", func() {
+		It("should report snapshot url and logs on snapshot", func() {
 			name := uuid.NewString()
 
 			mockCtrl := gomock.NewController(GinkgoT())
 			defer mockCtrl.Finish()
 			ops := NewMockWorkspaceOperations(mockCtrl)
 
-			ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+			logs := map[string]string{"0": "logs/headless-0.txt"}
+			ops.EXPECT().BackupWorkspace(gomock.Any(), gomock.Any()).Return(&BackupResult{Logs: logs}, nil).Times(1)
 			ops.EXPECT().SnapshotIDs(gomock.Any(), gomock.Any()).Return("snapshotUrl", "snapshotName", nil)
 			ops.EXPECT().DeleteWorkspace(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			workspaceCtrl.operations = ops
@@ -130,6 +238,9 @@ var _ = Describe("WorkspaceController", func() {
 			}, timeout, interval).Should(Succeed())
 
 			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionBackupComplete), metav1.ConditionTrue, "BackupComplete")
+
+			Expect(ws.Status.Headless).ToNot(BeNil())
+			Expect(ws.Status.Headless.Logs).To(Equal(logs))
 		})
 
 	})
@@ -11,23 +11,32 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	glog "github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+	"github.com/gitpod-io/gitpod/content-service/pkg/encryption"
 	wsinit "github.com/gitpod-io/gitpod/content-service/pkg/initializer"
 	"github.com/gitpod-io/gitpod/content-service/pkg/logs"
 	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/content"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/iws"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/quota"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 )
 
+// defaultMaxConcurrentBackups is the number of backups we upload at the same time if none is configured
+const defaultMaxConcurrentBackups = 5
+
 type Metrics struct {
 	BackupWaitingTimeHist       prometheus.Histogram
 	BackupWaitingTimeoutCounter prometheus.Counter
@@ -62,23 +71,29 @@ func registerConcurrentBackupMetrics(reg prometheus.Registerer, suffix string) (
 type WorkspaceOperations interface {
 	// InitWorkspace initializes the workspace content
 	InitWorkspace(ctx context.Context, options InitOptions) (string, error)
-	// BackupWorkspace backups the content of the workspace
-	BackupWorkspace(ctx context.Context, opts BackupOptions) (*csapi.GitStatus, error)
+	// BackupWorkspace backups the content of the workspace. The result is returned even if the backup failed,
+	// e.g. to report the git status of the workspace.
+	BackupWorkspace(ctx context.Context, opts BackupOptions) (*BackupResult, error)
 	// DeleteWorkspace deletes the content of the workspace from disk
 	DeleteWorkspace(ctx context.Context, instanceID string) error
 	// SnapshotIDs generates the name and url for a snapshot
 	SnapshotIDs(ctx context.Context, instanceID string) (snapshotUrl, snapshotName string, err error)
-	// Snapshot takes a snapshot of the workspace
-	Snapshot(ctx context.Context, instanceID, snapshotName string) (err error)
+	// Snapshot takes a snapshot of the workspace, reporting its progress to the optional progress func
+	Snapshot(ctx context.Context, instanceID, snapshotName string, progress SnapshotProgressFunc) (err error)
 	// Setup ensures that the workspace has been setup
 	SetupWorkspace(ctx context.Context, instanceID string) error
 }
 
 type DefaultWorkspaceOperations struct {
 	config                 content.Config
+	idMapping              iws.IDMappingConfig
 	provider               *WorkspaceProvider
 	backupWorkspaceLimiter chan struct{}
 	metrics                *Metrics
+	storageMetrics         *storage.Metrics
+	prebuildCache          *content.PrebuildCache
+	progress               *ContentProgress
+	keys                   encryption.KeyProvider
 }
 
 var _ WorkspaceOperations = (*DefaultWorkspaceOperations)(nil)
@@ -87,8 +102,15 @@ type WorkspaceMeta struct {
 	Owner       string
 	WorkspaceID string
 	InstanceID  string
+
+	// Organization owns the workspace, its content is encrypted with the key of the organization
+	Organization string
 }
 
+// SnapshotProgressFunc is called whenever a snapshot enters a new phase. size is the size of
+// the snapshot archive in bytes, or zero if it isn't known yet.
+type SnapshotProgressFunc func(phase workspacev1.SnapshotPhase, size int64)
+
 type InitOptions struct {
 	Meta         WorkspaceMeta
 	Initializer  *csapi.WorkspaceInitializer
@@ -101,30 +123,58 @@ type BackupOptions struct {
 	BackupLogs      bool
 	UpdateGitStatus bool
 	SnapshotName    string
+	// Reclaim backs up the content of a workspace which no longer exists. Its lifecycle hooks are not run,
+	// because there is no workspace left to get ready.
+	Reclaim bool
 }
 
-func NewWorkspaceOperations(config content.Config, provider *WorkspaceProvider, reg prometheus.Registerer) (WorkspaceOperations, error) {
+// BackupResult describes what a workspace backup produced besides the workspace content itself.
+type BackupResult struct {
+	// GitStatus is the status of the workspace's repository, if it was requested
+	GitStatus *csapi.GitStatus
+	// Logs maps the ID of each headless task whose log was uploaded to the storage object holding it
+	Logs map[string]string
+}
+
+func NewWorkspaceOperations(config content.Config, idMapping iws.IDMappingConfig, provider *WorkspaceProvider, progress *ContentProgress, storageMetrics *storage.Metrics, prebuildCache *content.PrebuildCache, reg prometheus.Registerer) (WorkspaceOperations, error) {
 	waitingTimeHist, waitingTimeoutCounter, err := registerConcurrentBackupMetrics(reg, "_mk2")
 	if err != nil {
 		return nil, err
 	}
 
+	keys, err := encryption.NewKeyProvider(context.Background(), config.Encryption)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create encryption key provider: %w", err)
+	}
+
+	maxConcurrentBackups := config.Backup.MaxConcurrent
+	if maxConcurrentBackups <= 0 {
+		maxConcurrentBackups = defaultMaxConcurrentBackups
+	}
+
 	return &DefaultWorkspaceOperations{
-		config:   config,
-		provider: provider,
+		config:    config,
+		idMapping: idMapping,
+		provider:  provider,
 		metrics: &Metrics{
 			BackupWaitingTimeHist:       waitingTimeHist,
 			BackupWaitingTimeoutCounter: waitingTimeoutCounter,
 		},
-		// we permit five concurrent backups at any given time, hence the five in the channel
-		backupWorkspaceLimiter: make(chan struct{}, 5),
+		storageMetrics:         storageMetrics,
+		prebuildCache:          prebuildCache,
+		backupWorkspaceLimiter: make(chan struct{}, maxConcurrentBackups),
+		progress:               progress,
+		keys:                   keys,
 	}, nil
 }
 
 func (wso *DefaultWorkspaceOperations) InitWorkspace(ctx context.Context, options InitOptions) (string, error) {
 	ws, err := wso.provider.NewWorkspace(ctx, options.Meta.InstanceID, filepath.Join(wso.provider.Location, options.Meta.InstanceID),
-		wso.creator(options.Meta.Owner, options.Meta.WorkspaceID, options.Meta.InstanceID, options.Initializer, false, options.StorageQuota))
+		wso.creator(options.Meta, options.Initializer, false, options.StorageQuota))
 
+	if errors.Is(err, quota.ErrNotEnforced) {
+		return "cannot enforce the storage quota of the workspace", xerrors.Errorf("cannot add workspace to store: %w", err)
+	}
 	if err != nil {
 		return "bug: cannot add workspace to store", xerrors.Errorf("cannot add workspace to store: %w", err)
 	}
@@ -143,21 +193,34 @@ func (wso *DefaultWorkspaceOperations) InitWorkspace(ctx context.Context, option
 		return "remote content error", xerrors.Errorf("remote content error: %w", err)
 	}
 
+	var archiveKeys map[string][]byte
+	if wso.keys != nil {
+		archiveKeys, err = content.CollectArchiveKeys(ctx, wso.keys, remoteContent)
+		if err != nil {
+			return "cannot decrypt workspace content", xerrors.Errorf("cannot collect archive keys: %w", err)
+		}
+	}
+
+	// Workspaces of the same prebuild share its archive on the node
+	cachedContent, releaseCachedContent := wso.prebuildCache.CacheRemoteContent(ctx, options.Initializer, remoteContent)
+	defer releaseCachedContent()
+
 	// Initialize workspace.
 	// FWB workspaces initialize without the help of ws-daemon, but using their supervisor or the registry-facade.
 	opts := content.RunInitializerOpts{
 		Command: wso.config.Initializer.Command,
 		Args:    wso.config.Initializer.Args,
-		// This is a bit of a hack as it makes hard assumptions about the nature of the UID mapping.
-		// Also, we cannot do this in wsinit because we're dropping all the privileges that would be
-		// required for this operation.
-		//
-		// With FWB this bit becomes unneccesary.
-		UID: (wsinit.GitpodUID + 100000 - 1),
-		GID: (wsinit.GitpodGID + 100000 - 1),
-		IdMappings: []archive.IDMapping{
-			{ContainerID: 0, HostID: wsinit.GitpodUID, Size: 1},
-			{ContainerID: 1, HostID: 100000, Size: 65534},
+		// The initializer runs as the gitpod user would appear on the node once the workspace's
+		// user namespace is established. We cannot do this in wsinit because we're dropping all
+		// the privileges that would be required for this operation.
+		UID:            wso.idMapping.HostID(wsinit.GitpodUID),
+		GID:            wso.idMapping.HostID(wsinit.GitpodGID),
+		IdMappings:     archiveIDMappings(wso.idMapping),
+		ArchiveKeys:    archiveKeys,
+		CachedContent:  cachedContent,
+		StorageMetrics: wso.storageMetrics,
+		RefreshURL: func(ctx context.Context, name string) (*storage.DownloadInfo, error) {
+			return content.RefreshRemoteContent(ctx, rs, ps, options.Meta.Owner, name)
 		},
 		OWI: content.OWI{
 			Owner:       options.Meta.Owner,
@@ -171,7 +234,29 @@ func (wso *DefaultWorkspaceOperations) InitWorkspace(ctx context.Context, option
 		glog.WithFields(ws.OWI()).Warnf("cannot ensure clean slate for workspace %s (this might break content init): %v", ws.InstanceID, err)
 	}
 
+	var remoteContentSize int64
+	for _, info := range remoteContent {
+		remoteContentSize += info.Size
+	}
+	tracker := wso.progress.track(options.Meta.InstanceID, api.ContentOperation_RESTORE)
+	tracker.Report(ContentPhaseDownloading, 0, remoteContentSize, 0, 0)
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	go tracker.Poll(pollCtx, func() (string, int64, int64, int64, int64) {
+		size, files, _ := measureDir(ws.Location)
+		return ContentPhaseDownloading, size, remoteContentSize, files, 0
+	})
+
 	err = content.RunInitializer(ctx, ws.Location, options.Initializer, remoteContent, opts)
+	stopPolling()
+	tracker.Done(err)
+	if quota.IsExceeded(err) {
+		glog.WithFields(ws.OWI()).WithError(err).Info("workspace content exceeds its storage quota")
+		return quotaExceededMessage(options.StorageQuota), xerrors.Errorf("%w: %v", quota.ErrExceeded, err)
+	}
+	if storage.IsDigestMismatch(err) {
+		glog.WithFields(ws.OWI()).WithError(err).Error("workspace content is corrupted")
+		return "workspace content is corrupted, it does not match the digest of its backup", xerrors.Errorf("%w: %v", storage.ErrDigestMismatch, err)
+	}
 	if err != nil {
 		glog.WithFields(ws.OWI()).Infof("error running initializer %v", err)
 		return err.Error(), err
@@ -185,22 +270,23 @@ func (wso *DefaultWorkspaceOperations) InitWorkspace(ctx context.Context, option
 	return "", nil
 }
 
-func (wso *DefaultWorkspaceOperations) creator(owner, workspaceID, instanceID string, init *csapi.WorkspaceInitializer, storageDisabled bool, storageQuota int) WorkspaceFactory {
+func (wso *DefaultWorkspaceOperations) creator(meta WorkspaceMeta, init *csapi.WorkspaceInitializer, storageDisabled bool, storageQuota int) WorkspaceFactory {
 	var checkoutLocation string
 	allLocations := csapi.GetCheckoutLocationsFromInitializer(init)
 	if len(allLocations) > 0 {
 		checkoutLocation = allLocations[0]
 	}
 
-	serviceDirName := instanceID + "-daemon"
+	serviceDirName := meta.InstanceID + "-daemon"
 	return func(ctx context.Context, location string) (res *session.Workspace, err error) {
 		return &session.Workspace{
 			Location:              location,
 			CheckoutLocation:      checkoutLocation,
 			CreatedAt:             time.Now(),
-			Owner:                 owner,
-			WorkspaceID:           workspaceID,
-			InstanceID:            instanceID,
+			Owner:                 meta.Owner,
+			Organization:          meta.Organization,
+			WorkspaceID:           meta.WorkspaceID,
+			InstanceID:            meta.InstanceID,
 			RemoteStorageDisabled: storageDisabled,
 			StorageQuota:          storageQuota,
 
@@ -219,8 +305,19 @@ func (wso *DefaultWorkspaceOperations) SetupWorkspace(ctx context.Context, insta
 	return nil
 }
 
-func (wso *DefaultWorkspaceOperations) BackupWorkspace(ctx context.Context, opts BackupOptions) (*csapi.GitStatus, error) {
-	ws, err := wso.provider.GetAndConnect(ctx, opts.Meta.InstanceID)
+func (wso *DefaultWorkspaceOperations) BackupWorkspace(ctx context.Context, opts BackupOptions) (*BackupResult, error) {
+	var (
+		ws  *session.Workspace
+		err error
+	)
+	if opts.Reclaim {
+		ws, err = wso.provider.Get(ctx, opts.Meta.InstanceID)
+		if err == nil && !ws.RemoteStorageDisabled {
+			err = wso.setupReclaimStorage(ctx, ws)
+		}
+	} else {
+		ws, err = wso.provider.GetAndConnect(ctx, opts.Meta.InstanceID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot find workspace %s during DisposeWorkspace: %w", opts.Meta.InstanceID, err)
 	}
@@ -229,24 +326,19 @@ func (wso *DefaultWorkspaceOperations) BackupWorkspace(ctx context.Context, opts
 		return nil, fmt.Errorf("workspace has no remote storage")
 	}
 
+	var res BackupResult
 	if opts.BackupLogs {
-		err := wso.uploadWorkspaceLogs(ctx, opts, ws.Location)
+		res.Logs, err = wso.uploadWorkspaceLogs(ctx, opts, ws.Location)
 		if err != nil {
 			// we do not fail the workspace yet because we still might succeed with its content!
 			glog.WithError(err).WithFields(ws.OWI()).Error("log backup failed")
 		}
 	}
 
-	err = wso.uploadWorkspaceContent(ctx, ws, opts.SnapshotName)
-	if err != nil {
-		glog.WithError(err).WithFields(ws.OWI()).Error("final backup failed for workspace")
-		return nil, fmt.Errorf("final backup failed for workspace %s", opts.Meta.InstanceID)
-	}
-
-	var repo *csapi.GitStatus
 	if opts.UpdateGitStatus {
-		// Update the git status prior to deleting the workspace
-		repo, err = ws.UpdateGitStatus(ctx)
+		// Update the git status prior to the backup, such that users learn about their unsaved
+		// changes even if the backup fails.
+		res.GitStatus, err = ws.UpdateGitStatus(ctx)
 		if err != nil {
 			// do not fail workspace because we were unable to get git status
 			// which can happen for various reasons, including user corrupting his .git folder somehow
@@ -256,11 +348,47 @@ func (wso *DefaultWorkspaceOperations) BackupWorkspace(ctx context.Context, opts
 		}
 	}
 
-	return repo, nil
+	err = wso.uploadWorkspaceContent(ctx, ws, opts.SnapshotName, api.ContentOperation_BACKUP, nil)
+	if err != nil {
+		glog.WithError(err).WithFields(ws.OWI()).Error("final backup failed for workspace")
+		return &res, fmt.Errorf("final backup failed for workspace %s", opts.Meta.InstanceID)
+	}
+
+	// remember the backup in case we don't get to delete the content, e.g. because ws-daemon restarts
+	ws.FinalBackup = true
+	err = ws.Persist()
+	if err != nil {
+		glog.WithError(err).WithFields(ws.OWI()).Warn("cannot persist final backup of workspace")
+	}
+
+	return &res, nil
+}
+
+// setupReclaimStorage configures the remote storage of a workspace which no longer exists, which is otherwise
+// done by its lifecycle hooks
+func (wso *DefaultWorkspaceOperations) setupReclaimStorage(ctx context.Context, ws *session.Workspace) error {
+	if _, ok := ws.NonPersistentAttrs[session.AttrRemoteStorage]; ok {
+		return nil
+	}
+
+	remoteStorage, err := storage.NewDirectAccess(&wso.config.Storage)
+	if err != nil {
+		return xerrors.Errorf("cannot use configured storage: %w", err)
+	}
+	remoteStorage = wso.storageMetrics.Instrument(remoteStorage)
+
+	err = remoteStorage.Init(ctx, ws.Owner, ws.WorkspaceID, ws.InstanceID)
+	if err != nil {
+		return xerrors.Errorf("cannot use configured storage: %w", err)
+	}
+
+	ws.NonPersistentAttrs[session.AttrRemoteStorage] = remoteStorage
+	return nil
 }
 
 func (wso *DefaultWorkspaceOperations) DeleteWorkspace(ctx context.Context, instanceID string) error {
-	ws, err := wso.provider.GetAndConnect(ctx, instanceID)
+	// disposing of the workspace needs no ready workspace, which might not even exist anymore
+	ws, err := wso.provider.Get(ctx, instanceID)
 	if err != nil {
 		return fmt.Errorf("cannot find workspace %s during DisposeWorkspace: %w", instanceID, err)
 	}
@@ -276,6 +404,7 @@ func (wso *DefaultWorkspaceOperations) DeleteWorkspace(ctx context.Context, inst
 		return err
 	}
 	wso.provider.Remove(ctx, instanceID)
+	wso.progress.Forget(instanceID)
 
 	return nil
 }
@@ -297,7 +426,7 @@ func (wso *DefaultWorkspaceOperations) SnapshotIDs(ctx context.Context, instance
 	return rs.Qualify(snapshotName), snapshotName, nil
 }
 
-func (wso *DefaultWorkspaceOperations) Snapshot(ctx context.Context, workspaceID, snapshotName string) (err error) {
+func (wso *DefaultWorkspaceOperations) Snapshot(ctx context.Context, workspaceID, snapshotName string, progress SnapshotProgressFunc) (err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "TakeSnapshot")
 	span.SetTag("workspace", workspaceID)
@@ -316,10 +445,10 @@ func (wso *DefaultWorkspaceOperations) Snapshot(ctx context.Context, workspaceID
 		return fmt.Errorf("workspace has no remote storage")
 	}
 
-	err = wso.uploadWorkspaceContent(ctx, ws, snapshotName)
+	err = wso.uploadWorkspaceContent(ctx, ws, snapshotName, api.ContentOperation_SNAPSHOT, progress)
 	if err != nil {
 		glog.WithError(err).WithFields(ws.OWI()).Error("snapshot failed for workspace")
-		return fmt.Errorf("snapshot failed for workspace %s", workspaceID)
+		return fmt.Errorf("snapshot failed for workspace %s: %w", workspaceID, err)
 	}
 
 	return nil
@@ -344,28 +473,48 @@ func ensureCleanSlate(location string) error {
 	return nil
 }
 
-func (wso *DefaultWorkspaceOperations) uploadWorkspaceLogs(ctx context.Context, opts BackupOptions, location string) (err error) {
+// archiveIDMappings translates the user namespace mapping of workspaces for archive operations
+func archiveIDMappings(mapping iws.IDMappingConfig) []archive.IDMapping {
+	var res []archive.IDMapping
+	for _, m := range mapping.Mapping() {
+		res = append(res, archive.IDMapping{ContainerID: int(m.ContainerId), HostID: int(m.HostId), Size: int(m.Size)})
+	}
+	return res
+}
+
+// quotaExceededMessage is the failure users see when their workspace content does not fit into its storage quota
+func quotaExceededMessage(storageQuota int) string {
+	const gib = 1024 * 1024 * 1024
+	return fmt.Sprintf("workspace content exceeds the storage quota of %.1f GiB of its workspace class", float64(storageQuota)/gib)
+}
+
+// uploadWorkspaceLogs uploads the logs of the workspace's headless tasks along with the prebuild summary and returns the storage
+// objects they were uploaded to, by task ID. Logs uploaded before an error are still returned.
+func (wso *DefaultWorkspaceOperations) uploadWorkspaceLogs(ctx context.Context, opts BackupOptions, location string) (uploaded map[string]string, err error) {
 	// currently we're only uploading prebuild log files
 	logFiles, err := logs.ListPrebuildLogFiles(ctx, location)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	rs, err := storage.NewDirectAccess(&wso.config.Storage)
 	if err != nil {
-		return xerrors.Errorf("cannot use configured storage: %w", err)
+		return nil, xerrors.Errorf("cannot use configured storage: %w", err)
 	}
+	rs = wso.storageMetrics.Instrument(rs)
 
 	err = rs.Init(ctx, opts.Meta.Owner, opts.Meta.WorkspaceID, opts.Meta.InstanceID)
 	if err != nil {
-		return xerrors.Errorf("cannot use configured storage: %w", err)
+		return nil, xerrors.Errorf("cannot use configured storage: %w", err)
 	}
 
 	err = rs.EnsureExists(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	uploaded = make(map[string]string, len(logFiles))
+
 	for _, absLogPath := range logFiles {
 		taskID, parseErr := logs.ParseTaskIDFromPrebuildLogFilePath(absLogPath)
 		owi := glog.OWI(opts.Meta.Owner, opts.Meta.WorkspaceID, opts.Meta.InstanceID)
@@ -374,8 +523,9 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceLogs(ctx context.Context,
 			continue
 		}
 
+		var obj string
 		err = retryIfErr(ctx, 5, glog.WithField("op", "upload log").WithFields(owi), func(ctx context.Context) (err error) {
-			_, _, err = rs.UploadInstance(ctx, absLogPath, logs.UploadedHeadlessLogPath(taskID))
+			_, obj, err = rs.UploadInstance(ctx, absLogPath, logs.UploadedHeadlessLogPath(taskID))
 			if err != nil {
 				return
 			}
@@ -383,13 +533,34 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceLogs(ctx context.Context,
 			return
 		})
 		if err != nil {
-			return xerrors.Errorf("cannot upload workspace logs: %w", err)
+			return uploaded, xerrors.Errorf("cannot upload workspace logs: %w", err)
+		}
+		uploaded[taskID] = obj
+	}
+
+	if summaryPath, exists := logs.FindPrebuildSummaryFile(location); exists {
+		owi := glog.OWI(opts.Meta.Owner, opts.Meta.WorkspaceID, opts.Meta.InstanceID)
+		err = retryIfErr(ctx, 5, glog.WithField("op", "upload prebuild summary").WithFields(owi), func(ctx context.Context) (err error) {
+			_, _, err = rs.UploadInstance(ctx, summaryPath, logs.UploadedPrebuildSummaryPath)
+			return
+		})
+		if err != nil {
+			return uploaded, xerrors.Errorf("cannot upload prebuild summary: %w", err)
 		}
 	}
-	return err
+	return uploaded, err
 }
 
-func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Context, sess *session.Workspace, backupName string) error {
+func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Context, sess *session.Workspace, backupName string, op api.ContentOperation, progress SnapshotProgressFunc) (err error) {
+	if progress == nil {
+		progress = func(workspacev1.SnapshotPhase, int64) {}
+	}
+
+	tracker := wso.progress.track(sess.InstanceID, op)
+	defer func() {
+		tracker.Done(err)
+	}()
+
 	// Avoid too many simultaneous backups in order to avoid excessive memory utilization.
 	var timedOut bool
 	waitStart := time.Now()
@@ -419,8 +590,11 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Contex
 		loc  = sess.Location
 		opts []storage.UploadOption
 	)
+	if limit := wso.config.Backup.UploadBandwidthPerSecond.Value(); limit > 0 {
+		opts = append(opts, storage.WithBandwidthLimit(limit))
+	}
 
-	err := os.Remove(filepath.Join(sess.Location, wsinit.WorkspaceReadyFile))
+	err = os.Remove(filepath.Join(sess.Location, wsinit.WorkspaceReadyFile))
 	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		// We'll still upload the backup, well aware that the UX during restart will be broken.
 		// But it's better to have a backup with all files (albeit one too many), than having no backup at all.
@@ -433,21 +607,39 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Contex
 	}
 
 	var (
-		tmpf     *os.File
-		tmpfSize int64
+		tmpf        *os.File
+		tmpfSize    int64
+		encrypted   bool
+		archiveName atomic.Value
 	)
 
+	progress(workspacev1.SnapshotPhaseArchiving, 0)
+
 	defer func() {
 		if tmpf != nil {
 			os.Remove(tmpf.Name())
 		}
 	}()
 
+	// The archive is roughly as large as the workspace content, hence we measure progress against its size.
+	locSize, locFiles, _ := measureDir(loc)
+	tracker.Report(ContentPhaseArchiving, 0, locSize, 0, locFiles)
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	defer stopPolling()
+	go tracker.Poll(pollCtx, func() (string, int64, int64, int64, int64) {
+		var size int64
+		if fn, ok := archiveName.Load().(string); ok {
+			size = fileSize(fn)
+		}
+		return ContentPhaseArchiving, size, locSize, 0, locFiles
+	})
+
 	err = retryIfErr(ctx, wso.config.Backup.Attempts, glog.WithFields(sess.OWI()).WithField("op", "create archive"), func(ctx context.Context) (err error) {
 		tmpf, err = os.CreateTemp(wso.config.TmpDir, fmt.Sprintf("wsbkp-%s-*.tar", sess.InstanceID))
 		if err != nil {
 			return
 		}
+		archiveName.Store(tmpf.Name())
 
 		defer func() {
 			tmpf.Close()
@@ -458,15 +650,23 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Contex
 
 		var opts []archive.TarOption
 		opts = append(opts)
-		mappings := []archive.IDMapping{
-			{ContainerID: 0, HostID: wsinit.GitpodUID, Size: 1},
-			{ContainerID: 1, HostID: 100000, Size: 65534},
-		}
+		mappings := archiveIDMappings(wso.idMapping)
+		compression := wso.config.Backup.Compression
 		opts = append(opts,
 			archive.WithUIDMapping(mappings),
 			archive.WithGIDMapping(mappings),
+			archive.WithCompression(compression.Algorithm, compression.Level),
 		)
 
+		encrypter, err := wso.newEncrypter(ctx, sess)
+		if err != nil {
+			return
+		}
+		encrypted = encrypter != nil
+		if encrypted {
+			opts = append(opts, archive.WithEncryption(encrypter.Writer))
+		}
+
 		err = content.BuildTarbal(ctx, loc, tmpf.Name(), opts...)
 		if err != nil {
 			return
@@ -493,8 +693,41 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Contex
 		return xerrors.Errorf("cannot create archive: %w", err)
 	}
 
+	// The digest lets content initializers detect truncated or otherwise corrupted downloads of the archive.
+	dgst, err := storage.FileDigest(tmpf.Name())
+	if err != nil {
+		return xerrors.Errorf("cannot compute archive digest: %w", err)
+	}
+	annotations := map[string]string{
+		storage.ObjectAnnotationDigest:     dgst.String(),
+		storage.ObjectAnnotationInstanceID: sess.InstanceID,
+	}
+	if compression := wso.config.Backup.Compression.Algorithm; compression != archive.CompressionNone {
+		annotations[storage.ObjectAnnotationCompression] = string(compression)
+	}
+	archiveOpts := append([]storage.UploadOption{
+		storage.WithAnnotations(annotations),
+		storage.WithProgress(tracker.Bytes(ContentPhaseUploading, tmpfSize)),
+	}, opts...)
+
+	stopPolling()
+	progress(workspacev1.SnapshotPhaseUploading, tmpfSize)
+	tracker.Report(ContentPhaseUploading, 0, tmpfSize, 0, 0)
+
+	// Snapshots can be shared with other workspaces, hence only regular backups are deduplicated.
+	// Encrypted archives have nothing in common, no matter how little their content changed.
+	dedup := wso.config.Backup.Deduplicate && op == api.ContentOperation_BACKUP && !encrypted
 	err = retryIfErr(ctx, wso.config.Backup.Attempts, glog.WithFields(sess.OWI()).WithField("op", "upload layer"), func(ctx context.Context) (err error) {
-		_, _, err = rs.Upload(ctx, tmpf.Name(), backupName, opts...)
+		if dedup {
+			res, err := content.UploadDeduplicated(ctx, rs, tmpf.Name(), dgst.String(), backupName, wso.config.TmpDir, annotations, opts...)
+			if err != nil {
+				return err
+			}
+			glog.WithFields(sess.OWI()).WithField("chunks", res.Chunks).WithField("uploadedChunks", res.UploadedChunks).WithField("uploadedBytes", res.UploadedBytes).Debug("uploaded deduplicated backup")
+			wso.deleteUnreferencedChunks(ctx, sess, res.Unreferenced)
+			return nil
+		}
+		_, _, err = rs.Upload(ctx, tmpf.Name(), backupName, archiveOpts...)
 		if err != nil {
 			return
 		}
@@ -508,6 +741,42 @@ func (wso *DefaultWorkspaceOperations) uploadWorkspaceContent(ctx context.Contex
 	return nil
 }
 
+// deleteUnreferencedChunks garbage collects the chunks the deduplicated backup of a workspace no longer uses.
+// Chunks we fail to delete are collected with the next backup.
+func (wso *DefaultWorkspaceOperations) deleteUnreferencedChunks(ctx context.Context, sess *session.Workspace, objs []string) {
+	if len(objs) == 0 {
+		return
+	}
+
+	ps, err := storage.NewPresignedAccess(&wso.config.Storage)
+	if err == nil {
+		err = content.DeleteDedupChunks(ctx, ps, ps.Bucket(sess.Owner), objs)
+	}
+	if err != nil {
+		glog.WithError(err).WithFields(sess.OWI()).Warn("cannot delete unreferenced chunks of deduplicated backup")
+		return
+	}
+	glog.WithFields(sess.OWI()).WithField("chunks", len(objs)).Debug("deleted unreferenced chunks of deduplicated backup")
+}
+
+// newEncrypter produces the encrypter for the content of a workspace, or nil if its organization has no encryption key
+func (wso *DefaultWorkspaceOperations) newEncrypter(ctx context.Context, sess *session.Workspace) (*encryption.Encrypter, error) {
+	if wso.keys == nil || sess.Organization == "" {
+		return nil, nil
+	}
+
+	res, err := encryption.NewEncrypter(ctx, wso.keys, sess.Organization)
+	if errors.Is(err, encryption.ErrNoKey) {
+		return nil, nil
+	}
+	if err != nil {
+		// we'd rather fail the backup than upload the content of an organization which has a key unencrypted
+		return nil, xerrors.Errorf("cannot create data key: %w", err)
+	}
+	glog.WithFields(sess.OWI()).WithField("keyID", res.KeyID()).Debug("encrypting workspace content")
+	return res, nil
+}
+
 func retryIfErr(ctx context.Context, attempts int, log *logrus.Entry, op func(ctx context.Context) error) (err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "retryIfErr")
@@ -65,26 +65,31 @@ func (wf *WorkspaceProvider) Remove(ctx context.Context, instanceID string) {
 }
 
 func (wf *WorkspaceProvider) GetAndConnect(ctx context.Context, instanceID string) (*session.Workspace, error) {
-	ws, ok := wf.workspaces.Load(instanceID)
-	if !ok {
-		// if the workspace is not in memory ws-daemon probabably has been restarted
-		// in that case we reload it from disk
-		path := filepath.Join(wf.Location, fmt.Sprintf("%s.workspace.json", instanceID))
-		loadWs, err := session.LoadWorkspace(ctx, path)
-		if err != nil {
-			return nil, err
-		}
-
-		ws = loadWs
+	ws, err := wf.Get(ctx, instanceID)
+	if err != nil {
+		return nil, err
 	}
 
-	err := wf.runLifecycleHooks(ctx, ws.(*session.Workspace), session.WorkspaceReady)
+	err = wf.runLifecycleHooks(ctx, ws, session.WorkspaceReady)
 	if err != nil {
 		return nil, err
 	}
 	wf.workspaces.Store(instanceID, ws)
 
-	return ws.(*session.Workspace), nil
+	return ws, nil
+}
+
+// Get returns the workspace without running its lifecycle hooks, e.g. to dispose of it
+func (wf *WorkspaceProvider) Get(ctx context.Context, instanceID string) (*session.Workspace, error) {
+	ws, ok := wf.workspaces.Load(instanceID)
+	if ok {
+		return ws.(*session.Workspace), nil
+	}
+
+	// if the workspace is not in memory ws-daemon probabably has been restarted
+	// in that case we reload it from disk
+	path := filepath.Join(wf.Location, fmt.Sprintf("%s.workspace.json", instanceID))
+	return session.LoadWorkspace(ctx, path)
 }
 
 func (s *WorkspaceProvider) runLifecycleHooks(ctx context.Context, ws *session.Workspace, state session.WorkspaceState) error {
@@ -32,6 +32,11 @@ func BandwidthFromQuantity(v resource.Quantity) Bandwidth {
 	return Bandwidth(v.MilliValue())
 }
 
+// Quantity converts the bandwidth to a quantity of CPUs.
+func (b Bandwidth) Quantity() resource.Quantity {
+	return *resource.NewMilliQuantity(int64(b), resource.DecimalSI)
+}
+
 // BandwithFromUsage computes the bandwidth neccesary to realise actual CPU time
 // consumption represented by two point samples.
 func BandwithFromUsage(t0, t1 CPUTime, dt time.Duration) (Bandwidth, error) {
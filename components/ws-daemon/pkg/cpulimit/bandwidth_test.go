@@ -71,6 +71,27 @@ func TestBandwidthFromQuantity(t *testing.T) {
 	}
 }
 
+func TestBandwidthQuantity(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Bandwidth   cpulimit.Bandwidth
+		Expectation string
+	}{
+		{Name: "0", Bandwidth: 0, Expectation: "0"},
+		{Name: "full CPUs", Bandwidth: 5000, Expectation: "5"},
+		{Name: "milli CPUs", Bandwidth: 500, Expectation: "500m"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := test.Bandwidth.Quantity()
+			if diff := cmp.Diff(test.Expectation, act.String()); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestQuota(t *testing.T) {
 	tests := []struct {
 		Name        string
@@ -123,6 +123,23 @@ func (basePath CgroupV1CFSController) readCpuUsage() (time.Duration, error) {
 
 // NrThrottled returns the number of CFS periods the cgroup was throttled in
 func (basePath CgroupV1CFSController) NrThrottled() (uint64, error) {
+	throttled, err := basePath.readCpuStat("nr_throttled")
+	if err != nil {
+		return 0, err
+	}
+	return uint64(throttled), nil
+}
+
+// ThrottledTime returns the total time the cgroup was throttled for
+func (basePath CgroupV1CFSController) ThrottledTime() (time.Duration, error) {
+	throttled, err := basePath.readCpuStat("throttled_time")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(throttled) * time.Nanosecond, nil
+}
+
+func (basePath CgroupV1CFSController) readCpuStat(key string) (int64, error) {
 	f, err := os.Open(filepath.Join(string(basePath), "cpu.stat"))
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -133,20 +150,19 @@ func (basePath CgroupV1CFSController) NrThrottled() (uint64, error) {
 	}
 	defer f.Close()
 
-	const prefixNrThrottled = "nr_throttled "
-
+	prefix := key + " "
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		l := scanner.Text()
-		if !strings.HasPrefix(l, prefixNrThrottled) {
+		if !strings.HasPrefix(l, prefix) {
 			continue
 		}
 
-		r, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(l, prefixNrThrottled)), 10, 64)
+		r, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(l, prefix)), 10, 64)
 		if err != nil {
 			return 0, xerrors.Errorf("cannot parse cpu.stat: %s: %w", l, err)
 		}
-		return uint64(r), nil
+		return r, nil
 	}
-	return 0, xerrors.Errorf("cpu.stat did not contain nr_throttled")
+	return 0, xerrors.Errorf("cpu.stat did not contain %s", key)
 }
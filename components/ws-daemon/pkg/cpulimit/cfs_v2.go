@@ -58,6 +58,15 @@ func (basePath CgroupV2CFSController) NrThrottled() (uint64, error) {
 	return uint64(throttled), nil
 }
 
+func (basePath CgroupV2CFSController) ThrottledTime() (time.Duration, error) {
+	throttled, err := basePath.getFlatKeyedValue("throttled_usec")
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(throttled) * time.Microsecond, nil
+}
+
 func (basePath CgroupV2CFSController) readCpuMax() (time.Duration, time.Duration, error) {
 	cpuMaxPath := filepath.Join(string(basePath), "cpu.max")
 	cpuMax, err := os.ReadFile(cpuMaxPath)
@@ -127,5 +136,5 @@ func (basePath CgroupV2CFSController) getFlatKeyedValue(key string) (int64, erro
 		}
 		return int64(r), nil
 	}
-	return 0, xerrors.Errorf("cpu.stat did not contain nr_throttled")
+	return 0, xerrors.Errorf("cpu.stat did not contain %s", key)
 }
@@ -8,6 +8,7 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
@@ -23,6 +24,10 @@ type Workspace struct {
 	Usage       CPUTime
 	QoS         int
 	Annotations map[string]string
+
+	// BurstBudget is how long the workspace may run at its burst limit before it is throttled to its
+	// regular limit. Zero means the workspace may burst whenever there's bandwidth left.
+	BurstBudget time.Duration
 }
 
 type WorkspaceHistory struct {
@@ -32,6 +37,12 @@ type WorkspaceHistory struct {
 	UsageT0     CPUTime
 	ThrottleLag uint64
 	Limit       Bandwidth
+
+	// TickUsage is the CPU time the workspace used since the previous update
+	TickUsage CPUTime
+	// BurstCredits is the CPU time the workspace may still use above its limit, if it has a burst budget
+	BurstCredits   CPUTime
+	creditsStarted bool
 }
 
 func (h *WorkspaceHistory) Usage() CPUTime {
@@ -46,6 +57,7 @@ func (h *WorkspaceHistory) Update(w Workspace) {
 		h.UsageT0 = w.Usage
 	} else {
 		h.ThrottleLag = h.LastUpdate.NrThrottled
+		h.TickUsage = w.Usage - h.LastUpdate.Usage
 	}
 	h.LastUpdate = &w
 }
@@ -58,6 +70,41 @@ func (h *WorkspaceHistory) Throttled() bool {
 	return h.ThrottleLag != h.LastUpdate.NrThrottled
 }
 
+// AccrueBurstCredits updates the burst credits of a workspace with a burst budget. Workspaces spend credits
+// while they use more than their limit, and earn them back while they use less. They hold at most enough
+// credits to run at their burst limit for their entire budget, and start out with that many.
+func (h *WorkspaceHistory) AccrueBurstCredits(limit, burstLimit Bandwidth, dt time.Duration) {
+	if h.LastUpdate == nil || h.LastUpdate.BurstBudget <= 0 || burstLimit <= limit {
+		h.BurstCredits = 0
+		h.creditsStarted = false
+		return
+	}
+
+	capacity := (burstLimit - limit).Integrate(h.LastUpdate.BurstBudget)
+	if !h.creditsStarted {
+		h.BurstCredits = capacity
+		h.creditsStarted = true
+		return
+	}
+
+	credits := h.BurstCredits + limit.Integrate(dt) - h.TickUsage
+	if credits < 0 {
+		credits = 0
+	}
+	if credits > capacity {
+		credits = capacity
+	}
+	h.BurstCredits = credits
+}
+
+// CanBurst returns false if the workspace has spent its burst budget
+func (h *WorkspaceHistory) CanBurst() bool {
+	if h.LastUpdate == nil || h.LastUpdate.BurstBudget <= 0 {
+		return true
+	}
+	return h.BurstCredits > 0
+}
+
 type DistributorSource func(context.Context) ([]Workspace, error)
 type DistributorSink func(id string, limit Bandwidth, burst bool)
 
@@ -86,6 +133,9 @@ type Distributor struct {
 
 	// Log is used (if not nil) to log out errors. If log is nil, no logging happens.
 	Log *logrus.Entry
+
+	// mu guards the limiters and total bandwidth, which can change while the distributor runs
+	mu sync.Mutex
 }
 
 type DistributorDebug struct {
@@ -112,6 +162,9 @@ func (d *Distributor) Run(ctx context.Context, dt time.Duration) {
 // Tick drives the distributor and pushes out new limits.
 // Callers are epxected to call this function repeatedly, with dt time inbetween calls.
 func (d *Distributor) Tick(dt time.Duration) (DistributorDebug, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	// update state
 	ws, err := d.Source(context.Background())
 	if err != nil {
@@ -183,17 +236,23 @@ func (d *Distributor) Tick(dt time.Duration) (DistributorDebug, error) {
 			continue
 		}
 
-		// if we didn't get the max bandwidth, but were throttled last time
-		// and there's still some bandwidth left to give, let's act as if had
-		// never spent any CPU time and assume the workspace will spend their
-		// entire bandwidth at once.
-		var burst bool
-		if totalBandwidth < d.TotalBandwidth && ws.Throttled() {
-			limit, err = d.BurstLimiter.Limit(ws)
+		burstLimit := limit
+		if ws.Throttled() || ws.LastUpdate.BurstBudget > 0 {
+			burstLimit, err = d.BurstLimiter.Limit(ws)
 			if err != nil {
 				log.WithError(err).Errorf("unable to apply burst limit")
 				continue
 			}
+		}
+		ws.AccrueBurstCredits(limit, burstLimit, dt)
+
+		// if we didn't get the max bandwidth, but were throttled last time
+		// and there's still some bandwidth left to give, let's act as if had
+		// never spent any CPU time and assume the workspace will spend their
+		// entire bandwidth at once - unless the workspace has spent its burst budget.
+		var burst bool
+		if totalBandwidth < d.TotalBandwidth && ws.Throttled() && ws.CanBurst() {
+			limit = burstLimit
 
 			// We assume the workspace is going to use as much as their limit allows.
 			// This might not be true, because their process which consumed so much CPU
@@ -201,6 +260,7 @@ func (d *Distributor) Tick(dt time.Duration) (DistributorDebug, error) {
 			totalBandwidth += limit
 
 			burstBandwidth += limit
+			burst = true
 		}
 
 		d.Sink(id, limit, burst)
@@ -213,6 +273,16 @@ func (d *Distributor) Tick(dt time.Duration) (DistributorDebug, error) {
 	}, nil
 }
 
+// Update replaces the limiters and total bandwidth of a running distributor. The new limits apply from the next tick on.
+func (d *Distributor) Update(limiter ResourceLimiter, burstLimiter ResourceLimiter, totalBandwidth Bandwidth) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.Limiter = limiter
+	d.BurstLimiter = burstLimiter
+	d.TotalBandwidth = totalBandwidth
+}
+
 func (d *Distributor) Reset() {
 	d.History = make(map[string]*WorkspaceHistory)
 }
@@ -380,4 +450,6 @@ type CFSController interface {
 	SetLimit(limit Bandwidth) (changed bool, err error)
 	// NrThrottled returns the number of CFS periods the cgroup was throttled
 	NrThrottled() (uint64, error)
+	// ThrottledTime returns the total time the cgroup was throttled for
+	ThrottledTime() (time.Duration, error)
 }
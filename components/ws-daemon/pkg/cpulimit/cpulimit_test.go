@@ -418,3 +418,101 @@ func generateOrRestoreConsumers(t *testing.T, fn string, cs []Consumer) []Consum
 
 	return res
 }
+
+func TestDistributorUpdate(t *testing.T) {
+	var (
+		usage  cpulimit.CPUTime
+		limits = make(map[string]cpulimit.Bandwidth)
+	)
+	source := func(context.Context) ([]cpulimit.Workspace, error) {
+		usage += cpulimit.Bandwidth(1000).Integrate(testDt)
+		return []cpulimit.Workspace{{ID: "ws", Usage: usage}}, nil
+	}
+	sink := func(id string, limit cpulimit.Bandwidth, burst bool) {
+		limits[id] = limit
+	}
+	dist := cpulimit.NewDistributor(source, sink, defaultLimit, defaultBreakoutLimit, totalCapacity)
+
+	for i := 0; i < 3; i++ {
+		_, err := dist.Tick(testDt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if limits["ws"] != 2000 {
+		t.Fatalf("unexpected limit before update: expected 2000, got %d", limits["ws"])
+	}
+
+	dist.Update(cpulimit.FixedLimiter(4000), defaultBreakoutLimit, totalCapacity)
+	_, err := dist.Tick(testDt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits["ws"] != 4000 {
+		t.Errorf("unexpected limit after update: expected 4000, got %d", limits["ws"])
+	}
+}
+
+func TestDistributorBurstBudget(t *testing.T) {
+	type consumer struct {
+		rate      cpulimit.Bandwidth
+		limit     cpulimit.Bandwidth
+		usage     cpulimit.CPUTime
+		throttled uint64
+		bursts    int
+	}
+
+	run := func(dist *cpulimit.Distributor, ticks int) {
+		for i := 0; i < ticks; i++ {
+			_, err := dist.Tick(testDt)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	newDistributor := func(c *consumer, budget time.Duration) *cpulimit.Distributor {
+		source := func(context.Context) ([]cpulimit.Workspace, error) {
+			rate := c.rate
+			if c.limit != 0 && rate > c.limit {
+				rate = c.limit
+				c.throttled++
+			}
+			c.usage += rate.Integrate(testDt)
+			return []cpulimit.Workspace{{ID: "ws", Usage: c.usage, NrThrottled: c.throttled, BurstBudget: budget}}, nil
+		}
+		sink := func(id string, limit cpulimit.Bandwidth, burst bool) {
+			c.limit = limit
+			if burst {
+				c.bursts++
+			}
+		}
+		return cpulimit.NewDistributor(source, sink, defaultLimit, defaultBreakoutLimit, totalCapacity)
+	}
+
+	unlimited := &consumer{rate: 6000}
+	run(newDistributor(unlimited, 0), 30)
+
+	// the budget lasts for 30 seconds at the burst limit, i.e. three ticks
+	budgeted := &consumer{rate: 6000}
+	dist := newDistributor(budgeted, 3*testDt)
+	run(dist, 30)
+	if budgeted.bursts != 3 {
+		t.Errorf("unexpected bursts within the budget: expected 3, got %d", budgeted.bursts)
+	}
+	if unlimited.bursts <= budgeted.bursts {
+		t.Errorf("expected workspaces without budget to burst more often: got %d, budgeted %d", unlimited.bursts, budgeted.bursts)
+	}
+	if budgeted.limit != 2000 {
+		t.Errorf("expected workspace to be throttled once its budget is spent: limit is %d", budgeted.limit)
+	}
+
+	// idling refills the budget
+	budgeted.rate = 0
+	run(dist, 10)
+	budgeted.bursts = 0
+	budgeted.rate = 6000
+	run(dist, 10)
+	if budgeted.bursts == 0 {
+		t.Error("expected workspace to burst again after idling")
+	}
+}
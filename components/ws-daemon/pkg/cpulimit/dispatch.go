@@ -16,14 +16,23 @@ import (
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/gitpod-io/gitpod/common-go/cgroups"
 	"github.com/gitpod-io/gitpod/common-go/kubernetes"
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
 )
 
+// ErrWorkspaceNotFound is returned when the CPU of a workspace is not under our control
+var ErrWorkspaceNotFound = errors.New("workspace is not under CPU control")
+
+// statusReportInterval is how often we report the CPU limits of workspaces in their status
+const statusReportInterval = 30 * time.Second
+
 // Config configures the containerd resource governer dispatch
 type Config struct {
 	Enabled        bool              `json:"enabled"`
@@ -58,6 +67,10 @@ func NewDispatchListener(cfg *Config, prom prometheus.Registerer) *DispatchListe
 			Name: "cpulimit_workspaces_burst_total",
 			Help: "Number of workspaces which received burst CPU limits",
 		}, []string{"qos"}),
+		workspacesThrottledTimeCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cpulimit_workspaces_throttled_seconds_total",
+			Help: "Time workspaces spent throttled by their CPU limit",
+		}, []string{"qos"}),
 		workspacesCPUTimeVec: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cpulimit_workspaces_cputime_seconds",
 			Help: "CPU time of all observed workspaces",
@@ -65,12 +78,9 @@ func NewDispatchListener(cfg *Config, prom prometheus.Registerer) *DispatchListe
 	}
 
 	if cfg.Enabled {
-		dist := NewDistributor(d.source, d.sink,
-			CompositeLimiter(AnnotationLimiter(kubernetes.WorkspaceCpuMinLimitAnnotation), FixedLimiter(BandwidthFromQuantity(d.Config.Limit))),
-			CompositeLimiter(AnnotationLimiter(kubernetes.WorkspaceCpuBurstLimitAnnotation), FixedLimiter(BandwidthFromQuantity(d.Config.BurstLimit))),
-			BandwidthFromQuantity(d.Config.TotalBandwidth),
-		)
-		go dist.Run(context.Background(), time.Duration(d.Config.ControlPeriod))
+		limiter, burstLimiter := newLimiters(cfg)
+		d.dist = NewDistributor(d.source, d.sink, limiter, burstLimiter, BandwidthFromQuantity(d.Config.TotalBandwidth))
+		go d.dist.Run(context.Background(), time.Duration(d.Config.ControlPeriod))
 	}
 
 	prom.MustRegister(
@@ -78,25 +88,37 @@ func NewDispatchListener(cfg *Config, prom prometheus.Registerer) *DispatchListe
 		d.workspacesRemovedCounterVec,
 		d.workspacesThrottledCounterVec,
 		d.workspacesBurstCounterVec,
+		d.workspacesThrottledTimeCounterVec,
 		d.workspacesCPUTimeVec,
 	)
 
 	return d
 }
 
+// newLimiters returns the regular and burst limiters of a config. The limits of a workspace class, passed on by
+// ws-manager through annotations, take precedence over the configured limits.
+func newLimiters(cfg *Config) (limiter, burstLimiter ResourceLimiter) {
+	limiter = CompositeLimiter(AnnotationLimiter(kubernetes.WorkspaceCpuMinLimitAnnotation), FixedLimiter(BandwidthFromQuantity(cfg.Limit)))
+	burstLimiter = CompositeLimiter(AnnotationLimiter(kubernetes.WorkspaceCpuBurstLimitAnnotation), FixedLimiter(BandwidthFromQuantity(cfg.BurstLimit)))
+	return
+}
+
 // DispatchListener starts new resource governer using the workspace dispatch
 type DispatchListener struct {
 	Prometheus prometheus.Registerer
 	Config     *Config
 
+	dist *Distributor
+
 	workspaces map[string]*workspace
 	mu         sync.RWMutex
 
-	workspacesAddedCounterVec     *prometheus.CounterVec
-	workspacesRemovedCounterVec   *prometheus.CounterVec
-	workspacesThrottledCounterVec *prometheus.CounterVec
-	workspacesBurstCounterVec     *prometheus.CounterVec
-	workspacesCPUTimeVec          *prometheus.GaugeVec
+	workspacesAddedCounterVec         *prometheus.CounterVec
+	workspacesRemovedCounterVec       *prometheus.CounterVec
+	workspacesThrottledCounterVec     *prometheus.CounterVec
+	workspacesBurstCounterVec         *prometheus.CounterVec
+	workspacesThrottledTimeCounterVec *prometheus.CounterVec
+	workspacesCPUTimeVec              *prometheus.GaugeVec
 }
 
 type workspace struct {
@@ -105,7 +127,78 @@ type workspace struct {
 	HardLimit   ResourceLimiter
 	Annotations map[string]string
 
-	lastThrottled uint64
+	// Allocation overrides the CPU limits of the workspace class, e.g. when ws-manager adjusts them
+	Allocation *Allocation
+
+	lastThrottled     uint64
+	lastThrottledTime time.Duration
+	throttled         bool
+	requested         Bandwidth
+	allowed           Bandwidth
+	reported          *workspacev1.CPUStatus
+}
+
+// Allocation overrides the CPU limits of a running workspace. Unset values keep the limits of its class.
+type Allocation struct {
+	MinLimit    *resource.Quantity
+	BurstLimit  *resource.Quantity
+	BurstBudget *time.Duration
+}
+
+// annotations returns the annotations of the workspace with its allocation applied, as the limiters expect them
+func (w *workspace) annotations() map[string]string {
+	if w.Allocation == nil {
+		return w.Annotations
+	}
+
+	res := make(map[string]string, len(w.Annotations)+3)
+	for k, v := range w.Annotations {
+		res[k] = v
+	}
+	if w.Allocation.MinLimit != nil {
+		res[kubernetes.WorkspaceCpuMinLimitAnnotation] = w.Allocation.MinLimit.String()
+	}
+	if w.Allocation.BurstLimit != nil {
+		res[kubernetes.WorkspaceCpuBurstLimitAnnotation] = w.Allocation.BurstLimit.String()
+	}
+	if w.Allocation.BurstBudget != nil {
+		res[kubernetes.WorkspaceCpuBurstBudgetAnnotation] = w.Allocation.BurstBudget.String()
+	}
+	return res
+}
+
+// burstBudget returns the burst budget of a workspace class, passed on by ws-manager through annotations.
+// ws-manager validates the budget, hence we treat invalid ones like no budget at all.
+func burstBudget(annotations map[string]string) time.Duration {
+	value, ok := annotations[kubernetes.WorkspaceCpuBurstBudgetAnnotation]
+	if !ok {
+		return 0
+	}
+	budget, err := time.ParseDuration(value)
+	if err != nil || budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// qos returns the burst class of the workspace, which the workspace's metrics are labelled with
+func (w *workspace) qos() string {
+	if class, ok := w.Annotations[kubernetes.WorkspaceCpuBurstClassAnnotation]; ok && class != "" {
+		return class
+	}
+	return "none"
+}
+
+// status returns the CPU status of the workspace, or nil if we haven't limited its CPU yet
+func (w *workspace) status() *workspacev1.CPUStatus {
+	if w.requested == 0 {
+		return nil
+	}
+	return &workspacev1.CPUStatus{
+		Requested: w.requested.Quantity(),
+		Allowed:   w.allowed.Quantity(),
+		Throttled: w.throttled,
+	}
 }
 
 func (d *DispatchListener) source(context.Context) ([]Workspace, error) {
@@ -131,18 +224,29 @@ func (d *DispatchListener) source(context.Context) ([]Workspace, error) {
 			// limit, but at least we'll keep maintaining the limit.
 		}
 
-		if w.lastThrottled > 0 && w.lastThrottled != throttled {
-			d.workspacesThrottledCounterVec.WithLabelValues("none").Inc()
+		w.throttled = w.lastThrottled > 0 && w.lastThrottled != throttled
+		if w.throttled {
+			d.workspacesThrottledCounterVec.WithLabelValues(w.qos()).Inc()
 		}
 		w.lastThrottled = throttled
 
-		d.workspacesCPUTimeVec.WithLabelValues("none").Add(time.Duration(usage).Seconds())
+		throttledTime, err := w.CFS.ThrottledTime()
+		if err != nil {
+			log.WithFields(w.OWI).WithError(err).Warn("cannot read time cgroup was throttled")
+		} else if throttledTime > w.lastThrottledTime {
+			d.workspacesThrottledTimeCounterVec.WithLabelValues(w.qos()).Add((throttledTime - w.lastThrottledTime).Seconds())
+			w.lastThrottledTime = throttledTime
+		}
+
+		d.workspacesCPUTimeVec.WithLabelValues(w.qos()).Add(time.Duration(usage).Seconds())
 
+		annotations := w.annotations()
 		res = append(res, Workspace{
 			ID:          id,
 			NrThrottled: throttled,
 			Usage:       usage,
-			Annotations: w.Annotations,
+			Annotations: annotations,
+			BurstBudget: burstBudget(annotations),
 		})
 	}
 	return res, nil
@@ -158,7 +262,12 @@ func (d *DispatchListener) sink(id string, limit Bandwidth, burst bool) {
 		return
 	}
 
-	d.workspacesBurstCounterVec.WithLabelValues("none").Inc()
+	if burst {
+		d.workspacesBurstCounterVec.WithLabelValues(ws.qos()).Inc()
+	} else {
+		ws.requested = limit
+	}
+	ws.allowed = limit
 
 	changed, err := ws.CFS.SetLimit(limit)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
@@ -189,21 +298,22 @@ func (d *DispatchListener) WorkspaceAdded(ctx context.Context, ws *dispatch.Work
 		return xerrors.Errorf("cannot start CFS controller: %w", err)
 	}
 
-	d.workspaces[ws.InstanceID] = &workspace{
+	w := &workspace{
 		CFS:         controller,
 		OWI:         ws.OWI(),
 		Annotations: ws.Pod.Annotations,
 	}
+	d.workspaces[ws.InstanceID] = w
 	go func() {
 		<-ctx.Done()
 
 		d.mu.Lock()
 		defer d.mu.Unlock()
 		delete(d.workspaces, ws.InstanceID)
-		d.workspacesRemovedCounterVec.WithLabelValues("none").Inc()
+		d.workspacesRemovedCounterVec.WithLabelValues(w.qos()).Inc()
 	}()
 
-	d.workspacesAddedCounterVec.WithLabelValues("none").Inc()
+	d.workspacesAddedCounterVec.WithLabelValues(w.qos()).Inc()
 
 	return nil
 }
@@ -222,6 +332,117 @@ func (d *DispatchListener) WorkspaceUpdated(ctx context.Context, ws *dispatch.Wo
 	return nil
 }
 
+// SetAllocation overrides the CPU limits of a running workspace from the next control period on.
+// Passing an empty allocation restores the limits of the workspace class.
+func (d *DispatchListener) SetAllocation(instanceID string, alloc Allocation) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w, ok := d.workspaces[instanceID]
+	if !ok {
+		return ErrWorkspaceNotFound
+	}
+
+	if alloc.MinLimit == nil && alloc.BurstLimit == nil && alloc.BurstBudget == nil {
+		w.Allocation = nil
+	} else {
+		w.Allocation = &alloc
+	}
+	annotations := w.annotations()
+	log.WithFields(w.OWI).
+		WithField("minLimit", annotations[kubernetes.WorkspaceCpuMinLimitAnnotation]).
+		WithField("burstLimit", annotations[kubernetes.WorkspaceCpuBurstLimitAnnotation]).
+		WithField("burstBudget", annotations[kubernetes.WorkspaceCpuBurstBudgetAnnotation]).
+		Info("updated CPU allocation of workspace")
+	return nil
+}
+
+// Update applies new CPU limits and total bandwidth to all workspaces from the next control period on.
+// Enabling or disabling CPU limiting, the control period and the cgroup base path only change on restart.
+func (d *DispatchListener) Update(cfg Config) {
+	if d.dist == nil {
+		if cfg.Enabled {
+			log.Warn("CPU limiting was disabled on startup, enabling it requires a restart")
+		}
+		return
+	}
+
+	limiter, burstLimiter := newLimiters(&cfg)
+	d.dist.Update(limiter, burstLimiter, BandwidthFromQuantity(cfg.TotalBandwidth))
+	log.WithField("limit", cfg.Limit.String()).WithField("burstLimit", cfg.BurstLimit.String()).WithField("totalBandwidth", cfg.TotalBandwidth.String()).Info("updating CPU limits")
+}
+
+// ReportStatus periodically reports the CPU limits of the workspaces in the CPU status of their Workspace
+// resource, such that ws-manager can tell which workspaces are throttled or burst.
+func (d *DispatchListener) ReportStatus(ctx context.Context, c client.Client, namespace string) {
+	if !d.Config.Enabled {
+		return
+	}
+
+	go func() {
+		t := time.NewTicker(statusReportInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				d.reportStatus(ctx, c, namespace)
+			}
+		}
+	}()
+}
+
+func (d *DispatchListener) reportStatus(ctx context.Context, c client.Client, namespace string) {
+	type report struct {
+		ID     string
+		OWI    logrus.Fields
+		Status *workspacev1.CPUStatus
+	}
+
+	d.mu.Lock()
+	var reports []report
+	for id, w := range d.workspaces {
+		status := w.status()
+		if status == nil || equalCPUStatus(w.reported, status) {
+			continue
+		}
+		reports = append(reports, report{ID: id, OWI: w.OWI, Status: status})
+	}
+	d.mu.Unlock()
+
+	for _, r := range reports {
+		var ws workspacev1.Workspace
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: r.ID}, &ws)
+		if err != nil {
+			log.WithFields(r.OWI).WithError(err).Warn("cannot get workspace to report CPU status")
+			continue
+		}
+
+		patch := client.MergeFrom(ws.DeepCopy())
+		ws.Status.CPU = r.Status
+		err = c.Status().Patch(ctx, &ws, patch)
+		if err != nil {
+			log.WithFields(r.OWI).WithError(err).Warn("cannot report CPU status")
+			continue
+		}
+
+		d.mu.Lock()
+		if w, ok := d.workspaces[r.ID]; ok {
+			w.reported = r.Status
+		}
+		d.mu.Unlock()
+	}
+}
+
+func equalCPUStatus(a, b *workspacev1.CPUStatus) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Throttled == b.Throttled && a.Requested.Cmp(b.Requested) == 0 && a.Allowed.Cmp(b.Allowed) == 0
+}
+
 func newCFSController(basePath, cgroupPath string) (CFSController, error) {
 	unified, err := cgroups.IsUnifiedCgroupSetup()
 	if err != nil {
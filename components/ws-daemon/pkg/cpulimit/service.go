@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package cpulimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+// Service lets ws-manager adjust the CPU allocation of running workspaces
+type Service struct {
+	Dispatch *DispatchListener
+
+	api.UnimplementedCPULimitServiceServer
+}
+
+// NewService creates a new CPU limit service
+func NewService(dispatch *DispatchListener) *Service {
+	return &Service{Dispatch: dispatch}
+}
+
+// SetCPULimit adjusts the CPU allocation of a running workspace
+func (s *Service) SetCPULimit(ctx context.Context, req *api.SetCPULimitRequest) (*api.SetCPULimitResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "ID is required")
+	}
+	if s.Dispatch == nil || !s.Dispatch.Config.Enabled {
+		return nil, status.Error(codes.FailedPrecondition, "CPU limiting is disabled")
+	}
+
+	alloc, err := allocationFromRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.Dispatch.SetAllocation(req.Id, *alloc)
+	if errors.Is(err, ErrWorkspaceNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &api.SetCPULimitResponse{}, nil
+}
+
+func allocationFromRequest(req *api.SetCPULimitRequest) (*Allocation, error) {
+	var res Allocation
+	if req.MinLimit != "" {
+		q, err := resource.ParseQuantity(req.MinLimit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse min limit: %w", err)
+		}
+		res.MinLimit = &q
+	}
+	if req.BurstLimit != "" {
+		q, err := resource.ParseQuantity(req.BurstLimit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse burst limit: %w", err)
+		}
+		res.BurstLimit = &q
+	}
+	if res.MinLimit != nil && res.BurstLimit != nil && res.BurstLimit.Cmp(*res.MinLimit) < 0 {
+		return nil, fmt.Errorf("burst limit must not be below the min limit")
+	}
+	if req.BurstBudget != "" {
+		budget, err := time.ParseDuration(req.BurstBudget)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse burst budget: %w", err)
+		}
+		if budget < 0 {
+			return nil, fmt.Errorf("burst budget must not be negative")
+		}
+		res.BurstBudget = &budget
+	}
+	return &res, nil
+}
@@ -7,12 +7,16 @@ package daemon
 import (
 	"context"
 
+	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cgroup"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/content"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/controller"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cpulimit"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/diskguard"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/diskusage"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/iws"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/netaccounting"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/netlimit"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
@@ -27,15 +31,37 @@ type Config struct {
 	IOLimit             IOLimitConfig             `json:"ioLimit"`
 	ProcLimit           int64                     `json:"procLimit"`
 	NetLimit            netlimit.Config           `json:"netlimit"`
+	EgressLimit         netlimit.EgressConfig     `json:"egressLimit"`
+	NetAccounting       netaccounting.Config      `json:"netAccounting"`
 	OOMScores           cgroup.OOMScoreAdjConfig  `json:"oomScores"`
 	DiskSpaceGuard      diskguard.Config          `json:"disk"`
+	DiskUsage           diskusage.Config          `json:"diskUsage"`
 	WorkspaceController WorkspaceControllerConfig `json:"workspaceController"`
+	Janitor             controller.JanitorConfig  `json:"janitor"`
 
 	RegistryFacadeHost string `json:"registryFacadeHost,omitempty"`
 }
 
 type WorkspaceControllerConfig struct {
 	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+	// SnapshotMaxConcurrentReconciles configures the max amount of concurrent snapshot reconciliations.
+	// If zero, MaxConcurrentReconciles applies. Snapshots waiting for a worker show up in the
+	// workqueue_depth{name="snapshot"} metric.
+	SnapshotMaxConcurrentReconciles int `json:"snapshotMaxConcurrentReconciles,omitempty"`
+	// BackupAttempts is the number of attempts to back up a stopped workspace before its backup is
+	// considered failed. If zero, failed backups are not retried. All attempts need to fit into
+	// ws-manager's content finalization timeout.
+	BackupAttempts int `json:"backupAttempts,omitempty"`
+	// BackupRetryBackoff is the delay before the first backup retry. It doubles with every further attempt.
+	BackupRetryBackoff util.Duration `json:"backupRetryBackoff,omitempty"`
+}
+
+// SnapshotConcurrency returns the max amount of concurrent snapshot reconciliations
+func (c WorkspaceControllerConfig) SnapshotConcurrency() int {
+	if c.SnapshotMaxConcurrentReconciles > 0 {
+		return c.SnapshotMaxConcurrentReconciles
+	}
+	return c.MaxConcurrentReconciles
 }
 
 type RuntimeConfig struct {
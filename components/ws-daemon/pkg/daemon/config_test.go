@@ -0,0 +1,26 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package daemon
+
+import "testing"
+
+func TestSnapshotConcurrency(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Cfg         WorkspaceControllerConfig
+		Expectation int
+	}{
+		{Name: "unset", Expectation: 0},
+		{Name: "workspace concurrency", Cfg: WorkspaceControllerConfig{MaxConcurrentReconciles: 15}, Expectation: 15},
+		{Name: "snapshot concurrency", Cfg: WorkspaceControllerConfig{MaxConcurrentReconciles: 15, SnapshotMaxConcurrentReconciles: 5}, Expectation: 5},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if act := test.Cfg.SnapshotConcurrency(); act != test.Expectation {
+				t.Errorf("unexpected snapshot concurrency: %d, expected %d", act, test.Expectation)
+			}
+		})
+	}
+}
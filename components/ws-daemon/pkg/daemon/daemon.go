@@ -27,14 +27,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cgroup"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/content"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/controller"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cpulimit"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/diskguard"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/diskusage"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/iws"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/netaccounting"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/netlimit"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/quota"
 	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
@@ -90,6 +93,11 @@ func NewDaemon(config Config) (*Daemon, error) {
 		return nil, xerrors.Errorf("NODENAME env var isn't set")
 	}
 
+	err = config.Uidmapper.Validate()
+	if err != nil {
+		return nil, xerrors.Errorf("invalid UID mapper config: %w", err)
+	}
+
 	markUnmountFallback, err := NewMarkUnmountFallback(wrappedReg)
 	if err != nil {
 		return nil, err
@@ -108,6 +116,7 @@ func NewDaemon(config Config) (*Daemon, error) {
 	cgroupPlugins, err := cgroup.NewPluginHost(config.CPULimit.CGroupBasePath,
 		&cgroup.FuseDeviceEnablerV2{},
 		cgroupV2IOLimiter,
+		cgroup.NewMemoryLimiterV2(),
 		&cgroup.ProcessPriorityV2{
 			ProcessPriorities: map[cgroup.ProcessType]int{
 				cgroup.ProcessWorkspaceKit: -10,
@@ -145,8 +154,9 @@ func NewDaemon(config Config) (*Daemon, error) {
 		return nil, xerrors.Errorf("cannot register cgroup plugin metrics: %w", err)
 	}
 
+	cpulimiter := cpulimit.NewDispatchListener(&config.CPULimit, wrappedReg)
 	listener := []dispatch.Listener{
-		cpulimit.NewDispatchListener(&config.CPULimit, wrappedReg),
+		cpulimiter,
 		markUnmountFallback,
 		cgroupPlugins,
 	}
@@ -156,13 +166,35 @@ func NewDaemon(config Config) (*Daemon, error) {
 		listener = append(listener, netlimiter)
 	}
 
+	egressLimiter := netlimit.NewEgressLimiter(config.EgressLimit, wrappedReg)
+	if config.EgressLimit.Enabled {
+		listener = append(listener, egressLimiter)
+	}
+
+	netAccountant, err := netaccounting.NewAccountant(config.NetAccounting, config.CPULimit.CGroupBasePath, wrappedReg)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create network accountant: %w", err)
+	}
+	if config.NetAccounting.Enabled {
+		listener = append(listener, netAccountant)
+	}
+
+	diskUsage := diskusage.NewReporter(config.DiskUsage, wrappedReg)
+	if config.DiskUsage.Enabled {
+		listener = append(listener, diskUsage)
+	}
+
 	var configReloader CompositeConfigReloader
 	configReloader = append(configReloader, ConfigReloaderFunc(func(ctx context.Context, config *Config) error {
 		cgroupV2IOLimiter.Update(config.IOLimit.WriteBWPerSecond.Value(), config.IOLimit.ReadBWPerSecond.Value(), config.IOLimit.WriteIOPS, config.IOLimit.ReadIOPS)
 		procV2Plugin.Update(config.ProcLimit)
+		cpulimiter.Update(config.CPULimit)
 		if config.NetLimit.Enabled {
 			netlimiter.Update(config.NetLimit)
 		}
+		if config.EgressLimit.Enabled {
+			egressLimiter.Update(config.EgressLimit)
+		}
 		return nil
 	}))
 
@@ -191,27 +223,61 @@ func NewDaemon(config Config) (*Daemon, error) {
 	}
 
 	contentCfg := config.Content
+	// buffered downloads go to the temp directory configured for workspace content
+	if contentCfg.Storage.Transfer.TmpDir == "" {
+		contentCfg.Storage.Transfer.TmpDir = contentCfg.TmpDir
+	}
 
 	xfs, err := quota.NewXFS(contentCfg.WorkingArea)
 	if err != nil {
 		return nil, err
 	}
 
+	storageMetrics := storage.NewMetrics(contentCfg.Storage)
+	err = registry.Register(storageMetrics)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot register remote storage metrics: %w", err)
+	}
+
 	hooks := content.WorkspaceLifecycleHooks(
 		contentCfg,
 		config.Runtime.WorkspaceCIDR,
 		&iws.Uidmapper{Config: config.Uidmapper, Runtime: containerRuntime},
 		xfs,
 		config.CPULimit.CGroupBasePath,
+		storageMetrics,
 	)
 
-	workspaceOps, err := controller.NewWorkspaceOperations(contentCfg, controller.NewWorkspaceProvider(contentCfg.WorkingArea, hooks), wrappedReg)
+	workingArea := diskusage.NewWorkingAreaMonitor(config.DiskUsage.WorkingArea, wrappedReg)
+	if config.DiskUsage.WorkingArea.Enabled {
+		for state, h := range workingArea.WorkspaceLifecycleHooks() {
+			hooks[state] = append(hooks[state], h...)
+		}
+	}
+
+	prebuildCache, err := content.NewPrebuildCache(contentCfg.PrebuildCache, wrappedReg)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create prebuild cache: %w", err)
+	}
+
+	contentProgress := controller.NewContentProgress()
+	workspaceOps, err := controller.NewWorkspaceOperations(contentCfg, config.Uidmapper.Mapping, controller.NewWorkspaceProvider(contentCfg.WorkingArea, hooks), contentProgress, storageMetrics, prebuildCache, wrappedReg)
+	if err != nil {
+		return nil, err
+	}
+
+	drain, err := controller.NewDrain(contentCfg.WorkingArea, clientset, nodename, wrappedReg)
 	if err != nil {
 		return nil, err
 	}
 
 	wsctrl, err := controller.NewWorkspaceController(
-		mgr.GetClient(), mgr.GetEventRecorderFor("workspace"), nodename, config.Runtime.SecretsNamespace, config.WorkspaceController.MaxConcurrentReconciles, workspaceOps, wrappedReg)
+		mgr.GetClient(), mgr.GetEventRecorderFor("workspace"), nodename, config.Runtime.SecretsNamespace, config.WorkspaceController.MaxConcurrentReconciles,
+		controller.BackupRetry{
+			Attempts: config.WorkspaceController.BackupAttempts,
+			Backoff:  time.Duration(config.WorkspaceController.BackupRetryBackoff),
+		},
+		drain, workspaceOps, wrappedReg)
 	if err != nil {
 		return nil, err
 	}
@@ -221,15 +287,23 @@ func NewDaemon(config Config) (*Daemon, error) {
 	}
 
 	ssctrl := controller.NewSnapshotController(
-		mgr.GetClient(), mgr.GetEventRecorderFor("snapshot"), nodename, config.WorkspaceController.MaxConcurrentReconciles, workspaceOps)
+		mgr.GetClient(), mgr.GetEventRecorderFor("snapshot"), nodename, config.WorkspaceController.SnapshotConcurrency(), workspaceOps)
 	err = ssctrl.SetupWithManager(mgr)
 	if err != nil {
 		return nil, err
 	}
 
+	cpulimiter.ReportStatus(context.Background(), mgr.GetClient(), config.Runtime.KubernetesNamespace)
+	diskUsage.Start(context.Background(), mgr.GetClient(), config.Runtime.KubernetesNamespace)
+
 	housekeeping := controller.NewHousekeeping(contentCfg.WorkingArea, 5*time.Minute)
 	go housekeeping.Start(context.Background())
 
+	janitor := controller.NewJanitor(config.Janitor, contentCfg.WorkingArea, config.Runtime.KubernetesNamespace, mgr.GetAPIReader(), workspaceOps, wrappedReg)
+	if config.Janitor.Enabled {
+		go janitor.Start(context.Background())
+	}
+
 	dsptch, err := dispatch.NewDispatch(containerRuntime, clientset, config.Runtime.KubernetesNamespace, nodename, listener...)
 	if err != nil {
 		return nil, err
@@ -244,6 +318,10 @@ func NewDaemon(config Config) (*Daemon, error) {
 		configReloader:  configReloader,
 		mgr:             mgr,
 		metricsRegistry: registry,
+		contentProgress: contentProgress,
+		netAccountant:   netAccountant,
+		drain:           drain,
+		cpuLimiter:      cpulimiter,
 	}, nil
 }
 
@@ -264,6 +342,10 @@ type Daemon struct {
 	configReloader  ConfigReloader
 	mgr             ctrl.Manager
 	metricsRegistry *prometheus.Registry
+	contentProgress *controller.ContentProgress
+	netAccountant   *netaccounting.Accountant
+	drain           *controller.Drain
+	cpuLimiter      *cpulimit.DispatchListener
 
 	cancel context.CancelFunc
 }
@@ -336,3 +418,23 @@ func (d *Daemon) ReadinessProbe() func() error {
 func (d *Daemon) MetricsRegistry() *prometheus.Registry {
 	return d.metricsRegistry
 }
+
+// ContentProgress returns the progress of content restores and backups on this node
+func (d *Daemon) ContentProgress() *controller.ContentProgress {
+	return d.contentProgress
+}
+
+// NetworkAccounting returns the connections and egress destinations of the workspaces on this node
+func (d *Daemon) NetworkAccounting() *netaccounting.Accountant {
+	return d.netAccountant
+}
+
+// Drain returns the drain mode of this node
+func (d *Daemon) Drain() *controller.Drain {
+	return d.drain
+}
+
+// CPULimiter returns the CPU limiter of this node's workspaces
+func (d *Daemon) CPULimiter() *cpulimit.DispatchListener {
+	return d.cpuLimiter
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diskusage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// defaultInterval is how often we measure the disk usage of workspaces if none is configured
+	defaultInterval = 30 * time.Second
+
+	// reportThreshold is the change in disk usage below which we don't report again, such that we
+	// don't update the workspace status for every file the user writes.
+	reportThreshold = 10 * 1024 * 1024
+
+	workspaceContainerName = "workspace"
+)
+
+// Config configures the disk usage reports
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Interval is how often the disk usage of workspaces is measured
+	Interval util.Duration `json:"interval,omitempty"`
+	// WorkingArea configures the measurement of the workspace content in the working area
+	WorkingArea WorkingAreaConfig `json:"workingArea"`
+}
+
+// Reporter measures how much ephemeral storage workspaces use, i.e. the size of the files they wrote to their
+// container, and reports it in the ephemeral storage status of their Workspace resource. ws-manager uses these
+// reports to warn users and stop workspaces before the kubelet evicts them.
+type Reporter struct {
+	Config Config
+
+	workspaces map[string]*workspace
+	mu         sync.Mutex
+
+	usageHist prometheus.Histogram
+}
+
+type workspace struct {
+	OWI      logrus.Fields
+	Upperdir string
+	Limit    resource.Quantity
+
+	reported *resource.Quantity
+}
+
+// NewReporter creates a new disk usage reporter
+func NewReporter(cfg Config, prom prometheus.Registerer) *Reporter {
+	r := &Reporter{
+		Config:     cfg,
+		workspaces: make(map[string]*workspace),
+		usageHist: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "diskusage_workspace_ratio",
+			Help:    "Ephemeral storage used by workspaces relative to their limit",
+			Buckets: []float64{.1, .25, .5, .75, .8, .9, .95, 1},
+		}),
+	}
+
+	if cfg.Enabled {
+		prom.MustRegister(r.usageHist)
+	}
+
+	return r
+}
+
+// WorkspaceAdded starts measuring the disk usage of the workspace
+func (r *Reporter) WorkspaceAdded(ctx context.Context, ws *dispatch.Workspace) error {
+	disp := dispatch.GetFromContext(ctx)
+	if disp == nil {
+		return xerrors.Errorf("no dispatch available")
+	}
+
+	upperdir, err := disp.Runtime.ContainerUpperdir(context.Background(), ws.ContainerID)
+	if err != nil {
+		return xerrors.Errorf("cannot find upperdir of workspace container: %w", err)
+	}
+
+	w := &workspace{
+		OWI:      ws.OWI(),
+		Upperdir: upperdir,
+		Limit:    ephemeralStorageLimit(ws.Pod),
+	}
+
+	r.mu.Lock()
+	r.workspaces[ws.InstanceID] = w
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.workspaces, ws.InstanceID)
+	}()
+
+	return nil
+}
+
+// ephemeralStorageLimit returns the ephemeral storage limit of the workspace container, or zero if it has none
+func ephemeralStorageLimit(pod *corev1.Pod) resource.Quantity {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != workspaceContainerName {
+			continue
+		}
+		if limit, ok := c.Resources.Limits[corev1.ResourceEphemeralStorage]; ok {
+			return limit
+		}
+	}
+	return resource.Quantity{}
+}
+
+// Start periodically measures the disk usage of workspaces and reports it in their status
+func (r *Reporter) Start(ctx context.Context, c client.Client, namespace string) {
+	if !r.Config.Enabled {
+		return
+	}
+
+	interval := time.Duration(r.Config.Interval)
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				r.report(ctx, c, namespace)
+			}
+		}
+	}()
+}
+
+func (r *Reporter) report(ctx context.Context, c client.Client, namespace string) {
+	r.mu.Lock()
+	workspaces := make(map[string]workspace, len(r.workspaces))
+	for id, w := range r.workspaces {
+		workspaces[id] = *w
+	}
+	r.mu.Unlock()
+
+	for id, w := range workspaces {
+		size, err := dirSize(w.Upperdir)
+		if errors.Is(err, fs.ErrNotExist) {
+			// the workspace container is gone
+			continue
+		}
+		if err != nil {
+			log.WithFields(w.OWI).WithError(err).Warn("cannot measure disk usage of workspace")
+			continue
+		}
+		if !w.Limit.IsZero() {
+			r.usageHist.Observe(float64(size) / float64(w.Limit.Value()))
+		}
+		if w.reported != nil && abs(size-w.reported.Value()) < reportThreshold {
+			continue
+		}
+
+		used := resource.NewQuantity(size, resource.BinarySI)
+		err = r.reportStatus(ctx, c, types.NamespacedName{Namespace: namespace, Name: id}, &workspacev1.EphemeralStorageStatus{
+			Used:  *used,
+			Limit: w.Limit,
+		})
+		if err != nil {
+			log.WithFields(w.OWI).WithError(err).Warn("cannot report disk usage")
+			continue
+		}
+
+		r.mu.Lock()
+		if w, ok := r.workspaces[id]; ok {
+			w.reported = used
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *Reporter) reportStatus(ctx context.Context, c client.Client, name types.NamespacedName, status *workspacev1.EphemeralStorageStatus) error {
+	var ws workspacev1.Workspace
+	err := c.Get(ctx, name, &ws)
+	if err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(ws.DeepCopy())
+	ws.Status.EphemeralStorage = status
+	return c.Status().Patch(ctx, &ws, patch)
+}
+
+// dirSize returns the size of all files in the directory. Files which disappear while we walk the directory are ignored.
+func dirSize(dir string) (size int64, err error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, err
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
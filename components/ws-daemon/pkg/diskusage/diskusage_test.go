@@ -0,0 +1,47 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diskusage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"a":         100,
+		"sub/b":     2000,
+		"sub/sub/c": 30,
+	}
+	for name, size := range files {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "sub/b"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := dirSize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 2130 {
+		t.Errorf("expected size 2130, got %d", size)
+	}
+
+	_, err = dirSize(filepath.Join(dir, "does-not-exist"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diskusage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+const (
+	// maxWorkingAreaSubscribers is the number of concurrent disk usage subscriptions we accept per workspace.
+	// Subscriptions are opened from within the workspace, hence we must not let them grow unbounded.
+	maxWorkingAreaSubscribers = 4
+)
+
+// ErrTooManySubscribers is returned when a workspace has too many open disk usage subscriptions
+var ErrTooManySubscribers = errors.New("too many disk usage subscribers")
+
+// WorkingAreaConfig configures the disk usage measurement of the workspace content in the working area
+type WorkingAreaConfig struct {
+	Enabled bool `json:"enabled"`
+	// Interval is how often the disk usage of the workspace content is measured
+	Interval util.Duration `json:"interval,omitempty"`
+	// SoftLimits are the fractions of the storage quota at which supervisor is notified, e.g. 0.8 and 0.95
+	SoftLimits []float64 `json:"softLimits,omitempty"`
+}
+
+// WorkingAreaMonitor measures how much of the working area the content of each workspace uses,
+// exports it as metric and tells subscribers when the usage crosses one of the soft limits.
+type WorkingAreaMonitor struct {
+	Config WorkingAreaConfig
+
+	usedBytes          *prometheus.GaugeVec
+	softLimitsCrossed  *prometheus.CounterVec
+	measurementsFailed prometheus.Counter
+}
+
+// NewWorkingAreaMonitor creates a new working area monitor
+func NewWorkingAreaMonitor(cfg WorkingAreaConfig, prom prometheus.Registerer) *WorkingAreaMonitor {
+	softLimits := append([]float64(nil), cfg.SoftLimits...)
+	sort.Float64s(softLimits)
+	cfg.SoftLimits = softLimits
+
+	m := &WorkingAreaMonitor{
+		Config: cfg,
+		usedBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "diskusage_working_area_used_bytes",
+			Help: "Disk space used by the content of workspaces in the working area",
+		}, []string{"node", "workspace"}),
+		softLimitsCrossed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "diskusage_working_area_soft_limits_crossed_total",
+			Help: "Number of times workspaces crossed a soft limit of their storage quota",
+		}, []string{"soft_limit"}),
+		measurementsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "diskusage_working_area_measurements_failed_total",
+			Help: "Number of times the disk usage of workspace content could not be measured",
+		}),
+	}
+
+	if cfg.Enabled {
+		prom.MustRegister(
+			m.usedBytes,
+			m.softLimitsCrossed,
+			m.measurementsFailed,
+		)
+	}
+
+	return m
+}
+
+// WorkspaceLifecycleHooks returns the hooks which start measuring the disk usage of a workspace once its content
+// is initializing or ready, and stop once it is disposed.
+func (m *WorkingAreaMonitor) WorkspaceLifecycleHooks() map[session.WorkspaceState][]session.WorkspaceLivecycleHook {
+	return map[session.WorkspaceState][]session.WorkspaceLivecycleHook{
+		session.WorkspaceInitializing: {m.startMeasuring},
+		session.WorkspaceReady:        {m.startMeasuring},
+		session.WorkspaceDisposed:     {m.stopMeasuring},
+	}
+}
+
+// startMeasuring starts measuring the disk usage of a workspace. This hook is idempotent, such that it can be
+// called on initialization and ready, the latter to support ws-daemon restarts.
+func (m *WorkingAreaMonitor) startMeasuring(ctx context.Context, ws *session.Workspace) (err error) {
+	//nolint:ineffassign
+	span, _ := opentracing.StartSpanFromContext(ctx, "diskusage.startMeasuring")
+	defer tracing.FinishSpan(span, &err)
+
+	if _, running := ws.NonPersistentAttrs[session.AttrDiskUsage]; running {
+		span.SetTag("alreadyRunning", true)
+		return nil
+	}
+
+	interval := time.Duration(m.Config.Interval)
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	usage := newWorkspaceDiskUsage(int64(ws.StorageQuota), m.Config.SoftLimits)
+	measureCtx, cancel := context.WithCancel(context.Background())
+	usage.stop = cancel
+	ws.NonPersistentAttrs[session.AttrDiskUsage] = usage
+
+	go m.measure(measureCtx, ws, usage, interval)
+	return nil
+}
+
+// stopMeasuring stops measuring the disk usage of a workspace and closes all subscriptions
+func (m *WorkingAreaMonitor) stopMeasuring(ctx context.Context, ws *session.Workspace) (err error) {
+	//nolint:ineffassign
+	span, _ := opentracing.StartSpanFromContext(ctx, "diskusage.stopMeasuring")
+	defer tracing.FinishSpan(span, &err)
+
+	usage, ok := ws.NonPersistentAttrs[session.AttrDiskUsage].(*WorkspaceDiskUsage)
+	if !ok {
+		return nil
+	}
+	delete(ws.NonPersistentAttrs, session.AttrDiskUsage)
+
+	usage.close()
+	return nil
+}
+
+func (m *WorkingAreaMonitor) measure(ctx context.Context, ws *session.Workspace, usage *WorkspaceDiskUsage, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	nodeName := os.Getenv("NODENAME")
+	defer m.usedBytes.DeleteLabelValues(nodeName, ws.InstanceID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		size, err := dirSize(ws.Location)
+		if errors.Is(err, fs.ErrNotExist) {
+			// the workspace content is gone
+			continue
+		}
+		if err != nil {
+			m.measurementsFailed.Inc()
+			log.WithFields(ws.OWI()).WithError(err).Warn("cannot measure disk usage of workspace content")
+			continue
+		}
+
+		m.usedBytes.WithLabelValues(nodeName, ws.InstanceID).Set(float64(size))
+		if crossed, ok := usage.update(size); ok {
+			m.softLimitsCrossed.WithLabelValues(formatSoftLimit(crossed)).Inc()
+			log.WithFields(ws.OWI()).WithField("used", size).WithField("limit", usage.limit).WithField("softLimit", crossed).Info("workspace content crossed soft limit of its storage quota")
+		}
+	}
+}
+
+// WorkspaceDiskUsage is the disk usage of the content of a single workspace
+type WorkspaceDiskUsage struct {
+	limit      int64
+	softLimits []float64
+
+	mu      sync.Mutex
+	latest  *api.DiskUsage
+	subs    map[chan *api.DiskUsage]struct{}
+	stop    context.CancelFunc
+	stopped bool
+}
+
+func newWorkspaceDiskUsage(limit int64, softLimits []float64) *WorkspaceDiskUsage {
+	return &WorkspaceDiskUsage{
+		limit:      limit,
+		softLimits: softLimits,
+		subs:       make(map[chan *api.DiskUsage]struct{}),
+	}
+}
+
+// update records a new measurement and notifies subscribers if the usage crossed a different soft limit
+// than before. It returns the soft limit if a higher one was crossed.
+func (u *WorkspaceDiskUsage) update(used int64) (crossed float64, ok bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	softLimit := crossedSoftLimit(used, u.limit, u.softLimits)
+	var previous float64
+	if u.latest != nil {
+		previous = u.latest.SoftLimit
+	}
+
+	u.latest = &api.DiskUsage{
+		Used:      used,
+		Limit:     u.limit,
+		SoftLimit: softLimit,
+	}
+	if softLimit == previous {
+		return 0, false
+	}
+
+	for sub := range u.subs {
+		// subscribers only care about the latest soft limit, hence we replace updates they haven't received yet
+		select {
+		case <-sub:
+		default:
+		}
+		sub <- u.latest
+	}
+	return softLimit, softLimit > previous
+}
+
+// Subscribe listens for the soft limits the workspace content crosses. The latest measurement is sent first.
+// The updates channel is closed once the workspace is disposed. Callers must call the returned function once
+// they're no longer interested in updates.
+func (u *WorkspaceDiskUsage) Subscribe() (updates <-chan *api.DiskUsage, cancel func(), err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.stopped {
+		return nil, nil, xerrors.Errorf("workspace content was disposed")
+	}
+	if len(u.subs) >= maxWorkingAreaSubscribers {
+		return nil, nil, ErrTooManySubscribers
+	}
+
+	sub := make(chan *api.DiskUsage, 1)
+	if u.latest != nil {
+		sub <- u.latest
+	}
+	u.subs[sub] = struct{}{}
+
+	return sub, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		if _, ok := u.subs[sub]; ok {
+			delete(u.subs, sub)
+			close(sub)
+		}
+	}, nil
+}
+
+func (u *WorkspaceDiskUsage) close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.stopped {
+		return
+	}
+	u.stopped = true
+	if u.stop != nil {
+		u.stop()
+	}
+	for sub := range u.subs {
+		delete(u.subs, sub)
+		close(sub)
+	}
+}
+
+// crossedSoftLimit returns the highest soft limit the usage reached, or zero if it reached none.
+// softLimits must be sorted in ascending order.
+func crossedSoftLimit(used, limit int64, softLimits []float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+
+	ratio := float64(used) / float64(limit)
+	var crossed float64
+	for _, l := range softLimits {
+		if ratio < l {
+			break
+		}
+		crossed = l
+	}
+	return crossed
+}
+
+func formatSoftLimit(l float64) string {
+	return strconv.FormatFloat(l, 'f', -1, 64)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diskusage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCrossedSoftLimit(t *testing.T) {
+	softLimits := []float64{0.8, 0.95}
+	tests := []struct {
+		Name        string
+		Used, Limit int64
+		Expectation float64
+	}{
+		{Name: "no quota", Used: 100, Limit: 0, Expectation: 0},
+		{Name: "below all soft limits", Used: 50, Limit: 100, Expectation: 0},
+		{Name: "at first soft limit", Used: 80, Limit: 100, Expectation: 0.8},
+		{Name: "between soft limits", Used: 90, Limit: 100, Expectation: 0.8},
+		{Name: "above all soft limits", Used: 99, Limit: 100, Expectation: 0.95},
+		{Name: "beyond quota", Used: 150, Limit: 100, Expectation: 0.95},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := crossedSoftLimit(test.Used, test.Limit, softLimits)
+			if act != test.Expectation {
+				t.Errorf("expected soft limit %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestWorkspaceDiskUsage(t *testing.T) {
+	usage := newWorkspaceDiskUsage(100, []float64{0.8, 0.95})
+
+	if _, ok := usage.update(50); ok {
+		t.Error("expected no soft limit to be crossed")
+	}
+
+	updates, cancel, err := usage.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if update := <-updates; update.Used != 50 || update.SoftLimit != 0 {
+		t.Errorf("expected the latest measurement first, got %v", update)
+	}
+
+	if crossed, ok := usage.update(85); !ok || crossed != 0.8 {
+		t.Errorf("expected soft limit 0.8 to be crossed, got %v", crossed)
+	}
+	if crossed, ok := usage.update(99); !ok || crossed != 0.95 {
+		t.Errorf("expected soft limit 0.95 to be crossed, got %v", crossed)
+	}
+	if update := <-updates; update.Used != 99 || update.SoftLimit != 0.95 {
+		t.Errorf("expected only the latest soft limit, got %v", update)
+	}
+
+	if _, ok := usage.update(10); ok {
+		t.Error("expected dropping below the soft limits not to count as crossing one")
+	}
+	if update := <-updates; update.SoftLimit != 0 {
+		t.Errorf("expected subscribers to learn about the lower usage, got %v", update)
+	}
+
+	for i := 1; i < maxWorkingAreaSubscribers; i++ {
+		_, c, err := usage.Subscribe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c()
+	}
+	if _, _, err := usage.Subscribe(); !errors.Is(err, ErrTooManySubscribers) {
+		t.Errorf("expected ErrTooManySubscribers, got %v", err)
+	}
+
+	usage.close()
+	if _, ok := <-updates; ok {
+		t.Error("expected updates to be closed once the workspace is disposed")
+	}
+}
@@ -36,6 +36,10 @@ const (
 	// AttrWaitForContent is the name of the wait-for-content probe cancel func.
 	// Expect this to be an instance of context.CancelFunc
 	AttrWaitForContent = "wait-for-content"
+
+	// AttrDiskUsage is the name of the disk usage measurement of the workspace content.
+	// Expect this to be an instance of *diskusage.WorkspaceDiskUsage
+	AttrDiskUsage = "disk-usage"
 )
 
 const (
@@ -59,6 +63,7 @@ type Workspace struct {
 	CreatedAt       time.Time        `json:"createdAt"`
 	DoBackup        bool             `json:"doBackup"`
 	Owner           string           `json:"owner"`
+	Organization    string           `json:"organization,omitempty"`
 	WorkspaceID     string           `json:"metaID"`
 	InstanceID      string           `json:"workspaceID"`
 	LastGitStatus   *csapi.GitStatus `json:"lastGitStatus"`
@@ -72,6 +77,9 @@ type Workspace struct {
 
 	XFSProjectID int `json:"xfsProjectID"`
 
+	// FinalBackup is true once the content was backed up after the workspace stopped
+	FinalBackup bool `json:"finalBackup,omitempty"`
+
 	NonPersistentAttrs map[string]interface{} `json:"-"`
 }
 
@@ -35,9 +35,9 @@ import (
 	v2 "github.com/gitpod-io/gitpod/common-go/cgroups/v2"
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
-	wsinit "github.com/gitpod-io/gitpod/content-service/pkg/initializer"
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/diskusage"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
 	nsi "github.com/gitpod-io/gitpod/ws-daemon/pkg/nsinsider"
 )
@@ -242,7 +242,8 @@ func (wbs *InWorkspaceServiceServer) PrepareForUserNS(ctx context.Context, req *
 	//   - https://lists.linuxcontainers.org/pipermail/lxc-devel/2014-July/009797.html
 	//   - https://lists.linuxcontainers.org/pipermail/lxc-users/2014-October/007948.html
 	err = nsi.Nsinsider(wbs.Session.InstanceID, int(containerPID), func(c *exec.Cmd) {
-		c.Args = append(c.Args, "prepare-dev", "--uid", strconv.Itoa(wsinit.GitpodUID), "--gid", strconv.Itoa(wsinit.GitpodGID))
+		rootID := int(wbs.Uidmapper.Config.Mapping.HostID(0))
+		c.Args = append(c.Args, "prepare-dev", "--uid", strconv.Itoa(rootID), "--gid", strconv.Itoa(rootID))
 	})
 	if err != nil {
 		log.WithError(err).WithFields(wbs.Session.OWI()).Error("PrepareForUserNS: cannot prepare /dev")
@@ -854,7 +855,9 @@ func (wbs *InWorkspaceServiceServer) EvacuateCGroup(ctx context.Context, req *ap
 		return nil, status.Errorf(codes.FailedPrecondition, "cannot produce user cgroup")
 	}
 
-	out, err := exec.CommandContext(ctx, "chown", "-R", fmt.Sprintf("%d:%d", wsinit.GitpodUID, wsinit.GitpodGID), filepath.Join(wbs.CGroupMountPoint, workspaceCGroup)).CombinedOutput()
+	// the workspace's root user manages the cgroup
+	rootID := wbs.Uidmapper.Config.Mapping.HostID(0)
+	out, err := exec.CommandContext(ctx, "chown", "-R", fmt.Sprintf("%d:%d", rootID, rootID), filepath.Join(wbs.CGroupMountPoint, workspaceCGroup)).CombinedOutput()
 	if err != nil {
 		log.WithError(err).WithFields(wbs.Session.OWI()).WithField("path", workspaceCGroup).WithField("out", string(out)).Error("EvacuateCGroup: cannot chown workspace cgroup")
 		return nil, status.Errorf(codes.FailedPrecondition, "cannot chown workspace cgroup")
@@ -935,6 +938,38 @@ func (wbs *InWorkspaceServiceServer) WorkspaceInfo(ctx context.Context, req *api
 	}, nil
 }
 
+// WatchDiskUsage streams the disk usage of the workspace content whenever it crosses one of the configured soft limits
+func (wbs *InWorkspaceServiceServer) WatchDiskUsage(req *api.WatchDiskUsageRequest, srv api.InWorkspaceService_WatchDiskUsageServer) error {
+	usage, ok := wbs.Session.NonPersistentAttrs[session.AttrDiskUsage].(*diskusage.WorkspaceDiskUsage)
+	if !ok {
+		return status.Errorf(codes.Unavailable, "disk usage is not measured")
+	}
+
+	updates, cancel, err := usage.Subscribe()
+	if errors.Is(err, diskusage.ErrTooManySubscribers) {
+		return status.Errorf(codes.ResourceExhausted, "too many disk usage subscribers")
+	}
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "disk usage is not measured")
+	}
+	defer cancel()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			err := srv.Send(update)
+			if err != nil {
+				return err
+			}
+		case <-srv.Context().Done():
+			return nil
+		}
+	}
+}
+
 func getWorkspaceResourceInfo(mountPoint, cgroupPath string) (*api.Resources, error) {
 	cpu, err := getCpuResourceInfoV2(mountPoint, cgroupPath)
 	if err != nil {
@@ -8,6 +8,8 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -19,6 +21,7 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
+	wsinit "github.com/gitpod-io/gitpod/content-service/pkg/initializer"
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
 )
@@ -38,6 +41,157 @@ type UidmapperConfig struct {
 	RootRange UIDRange `json:"rootUIDRange"`
 	// UserRange is the range to which any other user can be mapped to
 	UserRange []UIDRange `json:"userUIDRange"`
+	// Mapping is the UID/GID mapping of the user namespace of each workspace
+	Mapping IDMappingConfig `json:"mapping,omitempty"`
+	// HostSubIDs validates the mapping against the subordinate IDs the node delegates. If nil, it isn't validated.
+	HostSubIDs *SubIDConfig `json:"hostSubIDs,omitempty"`
+}
+
+// IDMappingConfig configures to which IDs on the node the users and groups of a workspace are mapped
+type IDMappingConfig struct {
+	// RootHostID is the ID the root user/group of a workspace is mapped to. Defaults to 33333.
+	RootHostID uint32 `json:"rootHostID,omitempty"`
+	// UserHostID is the ID the first non-root user/group of a workspace is mapped to. Defaults to 100000.
+	UserHostID uint32 `json:"userHostID,omitempty"`
+	// Size is the number of non-root users/groups mapped per workspace. It must include the gitpod user,
+	// hence be at least 33333. Defaults to 65534.
+	Size uint32 `json:"size,omitempty"`
+}
+
+// SubIDConfig points to the node's subordinate ID files (see subuid(5))
+type SubIDConfig struct {
+	// Owner is the name or ID of the user whose subordinate IDs workspaces are mapped to
+	Owner string `json:"owner"`
+	// SubUIDFile is the location of the node's /etc/subuid
+	SubUIDFile string `json:"subuidFile"`
+	// SubGIDFile is the location of the node's /etc/subgid
+	SubGIDFile string `json:"subgidFile"`
+}
+
+const (
+	defaultRootHostID = 33333
+	defaultUserHostID = 100000
+	defaultMappedIDs  = 65534
+)
+
+// WithDefaults returns the mapping with all unset fields set to their defaults
+func (c IDMappingConfig) WithDefaults() IDMappingConfig {
+	if c.RootHostID == 0 {
+		c.RootHostID = defaultRootHostID
+	}
+	if c.UserHostID == 0 {
+		c.UserHostID = defaultUserHostID
+	}
+	if c.Size == 0 {
+		c.Size = defaultMappedIDs
+	}
+	return c
+}
+
+// Mapping returns the mapping as written to uid_map and gid_map
+func (c IDMappingConfig) Mapping() []*api.WriteIDMappingRequest_Mapping {
+	c = c.WithDefaults()
+	return []*api.WriteIDMappingRequest_Mapping{
+		{ContainerId: 0, HostId: c.RootHostID, Size: 1},
+		{ContainerId: 1, HostId: c.UserHostID, Size: c.Size},
+	}
+}
+
+// HostID returns the ID on the node a user or group of the workspace is mapped to
+func (c IDMappingConfig) HostID(containerID uint32) uint32 {
+	c = c.WithDefaults()
+	if containerID == 0 {
+		return c.RootHostID
+	}
+	return c.UserHostID + containerID - 1
+}
+
+// Validate ensures the mapping of workspaces is within the configured ranges and, if configured,
+// within the subordinate IDs the node delegates.
+func (c UidmapperConfig) Validate() error {
+	mapping := c.Mapping.WithDefaults()
+	if mapping.Size < wsinit.GitpodUID || mapping.Size < wsinit.GitpodGID {
+		return xerrors.Errorf("ID mapping must include the gitpod user and group: size must be at least %d", wsinit.GitpodUID)
+	}
+	if uint64(mapping.UserHostID)+uint64(mapping.Size) > math.MaxUint32 {
+		return xerrors.Errorf("ID mapping exceeds the 32-bit ID space")
+	}
+
+	err := (&Uidmapper{Config: c}).validateMapping(mapping.Mapping())
+	if err != nil {
+		return xerrors.Errorf("ID mapping is out of the configured ranges: %s", status.Convert(err).Message())
+	}
+
+	if c.HostSubIDs == nil {
+		return nil
+	}
+	for _, fn := range []string{c.HostSubIDs.SubUIDFile, c.HostSubIDs.SubGIDFile} {
+		ranges, err := readSubIDs(fn, c.HostSubIDs.Owner)
+		if err != nil {
+			return err
+		}
+
+		var found bool
+		for _, r := range ranges {
+			if r.Contains(mapping.UserHostID, mapping.Size) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return xerrors.Errorf("ID mapping %d-%d is not delegated to %s in %s", mapping.UserHostID, mapping.UserHostID+mapping.Size-1, c.HostSubIDs.Owner, fn)
+		}
+	}
+	return nil
+}
+
+// readSubIDs returns the subordinate ID ranges delegated to owner in a subuid or subgid file
+func readSubIDs(fn, owner string) ([]UIDRange, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read subordinate IDs: %w", err)
+	}
+	defer f.Close()
+
+	ranges, err := parseSubIDs(f, owner)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse %s: %w", fn, err)
+	}
+	return ranges, nil
+}
+
+// parseSubIDs parses lines of the form "owner:start:count"
+func parseSubIDs(r io.Reader, owner string) ([]UIDRange, error) {
+	var res []UIDRange
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != 3 {
+			return nil, xerrors.Errorf("invalid line %q", line)
+		}
+		if fields[0] != owner {
+			continue
+		}
+
+		start, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid start in line %q: %w", line, err)
+		}
+		size, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid count in line %q: %w", line, err)
+		}
+		res = append(res, UIDRange{Start: uint32(start), Size: uint32(size)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // UIDRange represents a range of UID/GID's
@@ -51,7 +205,7 @@ func (r UIDRange) Contains(start, size uint32) bool {
 	if start < r.Start {
 		return false
 	}
-	if size > r.Size {
+	if uint64(start)+uint64(size) > uint64(r.Start)+uint64(r.Size) {
 		return false
 	}
 	return true
@@ -73,7 +227,11 @@ func (m *Uidmapper) HandleUIDMappingRequest(ctx context.Context, req *api.WriteI
 
 	log.Debug("received UID mapping request")
 
-	err = m.validateMapping(req.Mapping)
+	mapping := req.Mapping
+	if len(mapping) == 0 {
+		mapping = m.Config.Mapping.Mapping()
+	}
+	err = m.validateMapping(mapping)
 	if err != nil {
 		return err
 	}
@@ -94,7 +252,7 @@ func (m *Uidmapper) HandleUIDMappingRequest(ctx context.Context, req *api.WriteI
 
 	log = log.WithField("hostPID", hostPID)
 
-	err = WriteMapping(hostPID, req.Gid, req.Mapping)
+	err = WriteMapping(hostPID, req.Gid, mapping)
 	if err != nil {
 		log.WithError(err).Error("handleUIDMappingRequest: cannot write mapping")
 		return status.Error(codes.FailedPrecondition, "cannot write mapping")
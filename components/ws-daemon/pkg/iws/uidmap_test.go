@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package iws
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUIDRangeContains(t *testing.T) {
+	r := UIDRange{Start: 100000, Size: 70000}
+	tests := []struct {
+		Name        string
+		Start, Size uint32
+		Expectation bool
+	}{
+		{Name: "default mapping", Start: 100000, Size: 65534, Expectation: true},
+		{Name: "whole range", Start: 100000, Size: 70000, Expectation: true},
+		{Name: "before range", Start: 99999, Size: 10},
+		{Name: "exceeds range", Start: 110000, Size: 65534},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := r.Contains(test.Start, test.Size)
+			if act != test.Expectation {
+				t.Errorf("unexpected Contains: expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestParseSubIDs(t *testing.T) {
+	const subids = `# delegated by SSSD
+ubuntu:100000:65536
+root:200000:65536
+root:1000000:131072
+`
+
+	act, err := parseSubIDs(strings.NewReader(subids), "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []UIDRange{{Start: 200000, Size: 65536}, {Start: 1000000, Size: 131072}}
+	if diff := cmp.Diff(exp, act); diff != "" {
+		t.Errorf("unexpected subordinate IDs (-want +got):\n%s", diff)
+	}
+
+	_, err = parseSubIDs(strings.NewReader("root:200000"), "root")
+	if err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestUidmapperConfigValidate(t *testing.T) {
+	subids := filepath.Join(t.TempDir(), "subids")
+	err := os.WriteFile(subids, []byte("root:1000000:131072\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ranges := UidmapperConfig{
+		RootRange: UIDRange{Start: 33333, Size: 1},
+		UserRange: []UIDRange{{Start: 100000, Size: 70000}, {Start: 1000000, Size: 131072}},
+	}
+	tests := []struct {
+		Name        string
+		Mapping     IDMappingConfig
+		HostSubIDs  *SubIDConfig
+		Expectation string
+	}{
+		{
+			Name: "default mapping",
+		},
+		{
+			Name:        "too few IDs",
+			Mapping:     IDMappingConfig{Size: 1000},
+			Expectation: "ID mapping must include the gitpod user and group: size must be at least 33333",
+		},
+		{
+			Name:        "out of range",
+			Mapping:     IDMappingConfig{UserHostID: 500000},
+			Expectation: "ID mapping is out of the configured ranges: mapping for UID 1 is out of range",
+		},
+		{
+			Name:       "delegated subordinate IDs",
+			Mapping:    IDMappingConfig{UserHostID: 1000000, Size: 131072},
+			HostSubIDs: &SubIDConfig{Owner: "root", SubUIDFile: subids, SubGIDFile: subids},
+		},
+		{
+			Name:        "not delegated",
+			HostSubIDs:  &SubIDConfig{Owner: "root", SubUIDFile: subids, SubGIDFile: subids},
+			Expectation: "ID mapping 100000-165533 is not delegated to root in " + subids,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := ranges
+			cfg.Mapping = test.Mapping
+			cfg.HostSubIDs = test.HostSubIDs
+
+			var act string
+			if err := cfg.Validate(); err != nil {
+				act = err.Error()
+			}
+			if act != test.Expectation {
+				t.Errorf("unexpected Validate: expected %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
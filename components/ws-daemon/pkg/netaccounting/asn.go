@@ -0,0 +1,109 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package netaccounting
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// asnRange is a range of IPv4 addresses announced by an autonomous system
+type asnRange struct {
+	First netip.Addr
+	Last  netip.Addr
+	ASN   uint32
+}
+
+// ASNDatabase maps IPv4 addresses to the autonomous system which announces them
+type ASNDatabase struct {
+	ranges []asnRange
+}
+
+// LoadASNDatabase loads an IPv4-to-ASN database in the tab-separated format of iptoasn.com
+func LoadASNDatabase(fn string) (*ASNDatabase, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot open ASN database: %w", err)
+	}
+	defer f.Close()
+
+	return parseASNDatabase(f)
+}
+
+// parseASNDatabase parses lines of the form "range_start range_end AS_number country_code AS_description".
+// Ranges which aren't routed (AS_number 0) and IPv6 ranges are skipped.
+func parseASNDatabase(r io.Reader) (*ASNDatabase, error) {
+	var (
+		db   ASNDatabase
+		line int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) < 3 {
+			return nil, xerrors.Errorf("invalid ASN database line %d: expected at least three fields", line)
+		}
+		first, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, xerrors.Errorf("invalid ASN database line %d: %w", line, err)
+		}
+		last, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, xerrors.Errorf("invalid ASN database line %d: %w", line, err)
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid ASN database line %d: %w", line, err)
+		}
+		if asn == 0 || !first.Is4() || !last.Is4() {
+			continue
+		}
+
+		db.ranges = append(db.ranges, asnRange{First: first, Last: last, ASN: uint32(asn)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("cannot read ASN database: %w", err)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].First.Less(db.ranges[j].First)
+	})
+	return &db, nil
+}
+
+// Lookup returns the autonomous system which announces the address
+func (db *ASNDatabase) Lookup(addr netip.Addr) (asn uint32, ok bool) {
+	if db == nil {
+		return 0, false
+	}
+
+	// find the last range starting at or before addr
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].First)
+	}) - 1
+	if i < 0 {
+		return 0, false
+	}
+
+	r := db.ranges[i]
+	if r.Last.Less(addr) {
+		return 0, false
+	}
+	return r.ASN, true
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package netaccounting
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
+)
+
+const (
+	// defaultInterval is how often we read the counters of workspaces if no interval is configured
+	defaultInterval = 30 * time.Second
+
+	// defaultMaxDestinations is the number of destination addresses we count per workspace if not configured.
+	// Once a workspace talked to more destinations, the least recently used ones are evicted.
+	defaultMaxDestinations = 4096
+
+	// defaultPrefixLength is the length of the network prefix destinations are aggregated by
+	// if they aren't in the ASN database
+	defaultPrefixLength = 24
+)
+
+// Config configures the network accounting of workspaces
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Interval is how often the counters of each workspace are read
+	Interval util.Duration `json:"interval,omitempty"`
+	// MaxDestinations is the number of destination addresses counted per workspace
+	MaxDestinations int `json:"maxDestinations,omitempty"`
+	// PrefixLength is the length of the IPv4 network prefix by which destinations which aren't in the ASN database
+	// are aggregated
+	PrefixLength int `json:"prefixLength,omitempty"`
+	// ASNDatabase is the path to an IPv4-to-ASN database in the tab-separated format of iptoasn.com.
+	// If empty, all destinations are aggregated by network prefix.
+	ASNDatabase string `json:"asnDatabase,omitempty"`
+}
+
+// Accountant counts the connections workspaces open and the traffic they send per destination
+// using eBPF programs attached to their cgroup. It never captures packets, hence the accounting is
+// cheap enough to run for all workspaces and helps spotting abuse such as cryptomining.
+type Accountant struct {
+	Config         Config
+	CGroupBasePath string
+
+	asns *ASNDatabase
+
+	mu         sync.RWMutex
+	workspaces map[string]*accountedWorkspace
+
+	connectionsDesc        *prometheus.Desc
+	egressDestinationsDesc *prometheus.Desc
+	attachFailuresTotal    prometheus.Counter
+}
+
+// accountedWorkspace is the network accounting of a workspace as of the last time its counters were read
+type accountedWorkspace struct {
+	Type string
+	Res  *api.GetNetworkAccountingResponse
+}
+
+var (
+	connectionsBuckets        = prometheus.ExponentialBuckets(1, 4, 8)
+	egressDestinationsBuckets = prometheus.ExponentialBuckets(1, 2, 12)
+)
+
+// NewAccountant creates a new network accountant
+func NewAccountant(cfg Config, cgroupBasePath string, prom prometheus.Registerer) (*Accountant, error) {
+	if cfg.Interval == 0 {
+		cfg.Interval = util.Duration(defaultInterval)
+	}
+	if cfg.MaxDestinations == 0 {
+		cfg.MaxDestinations = defaultMaxDestinations
+	}
+	if cfg.PrefixLength == 0 {
+		cfg.PrefixLength = defaultPrefixLength
+	}
+	if cfg.PrefixLength < 0 || cfg.PrefixLength > 32 {
+		return nil, xerrors.Errorf("invalid prefix length %d: must be between 0 and 32", cfg.PrefixLength)
+	}
+
+	a := &Accountant{
+		Config:         cfg,
+		CGroupBasePath: cgroupBasePath,
+		workspaces:     make(map[string]*accountedWorkspace),
+
+		connectionsDesc: prometheus.NewDesc(
+			"netaccounting_workspace_connections",
+			"Distribution of the number of TCP connections workspaces opened",
+			[]string{"node", "type"}, nil,
+		),
+		egressDestinationsDesc: prometheus.NewDesc(
+			"netaccounting_workspace_egress_destinations",
+			"Distribution of the number of distinct destinations (autonomous systems or networks) workspaces sent traffic to",
+			[]string{"node", "type"}, nil,
+		),
+		attachFailuresTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "netaccounting_attach_failures_total",
+			Help: "Number of workspaces whose network traffic could not be accounted",
+		}),
+	}
+
+	if !cfg.Enabled {
+		return a, nil
+	}
+
+	// kernels before 5.11 account eBPF maps against the memlock limit
+	err := rlimit.RemoveMemlock()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot remove memlock limit: %w", err)
+	}
+
+	if cfg.ASNDatabase != "" {
+		a.asns, err = LoadASNDatabase(cfg.ASNDatabase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prom.MustRegister(a)
+
+	return a, nil
+}
+
+// WorkspaceAdded attaches the accounting probe to the cgroup of a new workspace
+func (a *Accountant) WorkspaceAdded(ctx context.Context, ws *dispatch.Workspace) error {
+	disp := dispatch.GetFromContext(ctx)
+	if disp == nil {
+		return fmt.Errorf("no dispatch available")
+	}
+
+	cgroupPath, err := disp.Runtime.ContainerCGroupPath(context.Background(), ws.ContainerID)
+	if err != nil {
+		return xerrors.Errorf("cannot get cgroup path for container %s: %w", ws.ContainerID, err)
+	}
+
+	p, err := attachProbe(filepath.Join(a.CGroupBasePath, cgroupPath), a.Config.MaxDestinations)
+	if err != nil {
+		a.attachFailuresTotal.Inc()
+		log.WithError(err).WithFields(ws.OWI()).Error("cannot account network traffic")
+		return err
+	}
+
+	wsType := ws.Pod.Labels[wsk8s.TypeLabel]
+	a.mu.Lock()
+	a.workspaces[ws.InstanceID] = &accountedWorkspace{Type: wsType, Res: &api.GetNetworkAccountingResponse{}}
+	a.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(a.Config.Interval))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				egress, connections, err := p.read()
+				if err != nil {
+					log.WithFields(ws.OWI()).WithError(err).Warn("could not read network accounting counters")
+					continue
+				}
+
+				res := aggregate(egress, connections, a.destinationOf)
+				a.mu.Lock()
+				a.workspaces[ws.InstanceID] = &accountedWorkspace{Type: wsType, Res: res}
+				a.mu.Unlock()
+
+			case <-ctx.Done():
+				p.Close()
+
+				a.mu.Lock()
+				delete(a.workspaces, ws.InstanceID)
+				a.mu.Unlock()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Get returns the network accounting of a workspace as of the last time its counters were read
+func (a *Accountant) Get(instanceID string) (res *api.GetNetworkAccountingResponse, ok bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	ws, ok := a.workspaces[instanceID]
+	if !ok {
+		return nil, false
+	}
+	return proto.Clone(ws.Res).(*api.GetNetworkAccountingResponse), true
+}
+
+var _ prometheus.Collector = &Accountant{}
+
+// Describe implements prometheus.Collector
+func (a *Accountant) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.connectionsDesc
+	ch <- a.egressDestinationsDesc
+	a.attachFailuresTotal.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. Instead of one series per workspace, we export the distribution
+// of the accounted workspaces by workspace type.
+func (a *Accountant) Collect(ch chan<- prometheus.Metric) {
+	a.mu.RLock()
+	var (
+		connections        = make(map[string]*histogram)
+		egressDestinations = make(map[string]*histogram)
+	)
+	for _, ws := range a.workspaces {
+		if _, ok := connections[ws.Type]; !ok {
+			connections[ws.Type] = newHistogram(connectionsBuckets)
+			egressDestinations[ws.Type] = newHistogram(egressDestinationsBuckets)
+		}
+		connections[ws.Type].Observe(float64(ws.Res.Connections))
+		egressDestinations[ws.Type].Observe(float64(len(ws.Res.Destinations)))
+	}
+	a.mu.RUnlock()
+
+	nodeName := os.Getenv("NODENAME")
+	for tpe, h := range connections {
+		ch <- prometheus.MustNewConstHistogram(a.connectionsDesc, h.Count, h.Sum, h.Buckets, nodeName, tpe)
+	}
+	for tpe, h := range egressDestinations {
+		ch <- prometheus.MustNewConstHistogram(a.egressDestinationsDesc, h.Count, h.Sum, h.Buckets, nodeName, tpe)
+	}
+	a.attachFailuresTotal.Collect(ch)
+}
+
+// histogram accumulates observations into cumulative buckets as expected by prometheus.MustNewConstHistogram
+type histogram struct {
+	Count   uint64
+	Sum     float64
+	Buckets map[float64]uint64
+}
+
+func newHistogram(upperBounds []float64) *histogram {
+	buckets := make(map[float64]uint64, len(upperBounds))
+	for _, b := range upperBounds {
+		buckets[b] = 0
+	}
+	return &histogram{Buckets: buckets}
+}
+
+func (h *histogram) Observe(v float64) {
+	h.Count++
+	h.Sum += v
+	for b := range h.Buckets {
+		if v <= b {
+			h.Buckets[b]++
+		}
+	}
+}
+
+// destinationOf returns the autonomous system an address belongs to, or its network if we don't know the AS
+func (a *Accountant) destinationOf(addr netip.Addr) string {
+	if asn, ok := a.asns.Lookup(addr); ok {
+		return fmt.Sprintf("AS%d", asn)
+	}
+
+	prefix, err := addr.Prefix(a.Config.PrefixLength)
+	if err != nil {
+		return addr.String()
+	}
+	return prefix.String()
+}
+
+// aggregate sums up the per-address counters by destination. Destinations are sorted by the bytes sent to them.
+func aggregate(egress map[netip.Addr]egressCounters, connections map[netip.Addr]uint64, destinationOf func(netip.Addr) string) *api.GetNetworkAccountingResponse {
+	var (
+		res          api.GetNetworkAccountingResponse
+		destinations = make(map[string]*api.EgressDestination)
+	)
+	destination := func(addr netip.Addr) *api.EgressDestination {
+		name := destinationOf(addr)
+		dst, ok := destinations[name]
+		if !ok {
+			dst = &api.EgressDestination{Destination: name}
+			destinations[name] = dst
+		}
+		return dst
+	}
+
+	for addr, counters := range egress {
+		dst := destination(addr)
+		dst.Bytes += int64(counters.Bytes)
+		dst.Packets += int64(counters.Packets)
+	}
+	for addr, count := range connections {
+		dst := destination(addr)
+		dst.Connections += int64(count)
+		res.Connections += int64(count)
+	}
+
+	res.Destinations = make([]*api.EgressDestination, 0, len(destinations))
+	for _, dst := range destinations {
+		res.Destinations = append(res.Destinations, dst)
+	}
+	sort.Slice(res.Destinations, func(i, j int) bool {
+		a, b := res.Destinations[i], res.Destinations[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Destination < b.Destination
+	})
+
+	return &res
+}
+
+// Service serves the network accounting of the workspaces on this node
+type Service struct {
+	Accountant *Accountant
+
+	api.UnimplementedNetworkAccountingServiceServer
+}
+
+// NewService creates a new network accounting service
+func NewService(accountant *Accountant) *Service {
+	return &Service{Accountant: accountant}
+}
+
+// GetNetworkAccounting returns the connections a workspace opened and the destinations it sent traffic to
+func (s *Service) GetNetworkAccounting(ctx context.Context, req *api.GetNetworkAccountingRequest) (*api.GetNetworkAccountingResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "ID is required")
+	}
+	if s.Accountant == nil || !s.Accountant.Config.Enabled {
+		return nil, status.Error(codes.FailedPrecondition, "network accounting is disabled")
+	}
+
+	res, ok := s.Accountant.Get(req.Id)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "workspace is not accounted")
+	}
+	return res, nil
+}
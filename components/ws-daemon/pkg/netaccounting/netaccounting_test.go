@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package netaccounting
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+func TestAggregate(t *testing.T) {
+	asns, err := parseASNDatabase(strings.NewReader("1.1.1.0\t1.1.1.255\t13335\tUS\tCLOUDFLARENET\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct := &Accountant{Config: Config{PrefixLength: 24}, asns: asns}
+
+	egress := map[netip.Addr]egressCounters{
+		netip.MustParseAddr("1.1.1.1"):  {Packets: 10, Bytes: 1000},
+		netip.MustParseAddr("1.1.1.2"):  {Packets: 5, Bytes: 500},
+		netip.MustParseAddr("10.0.0.1"): {Packets: 1, Bytes: 100},
+		netip.MustParseAddr("10.0.0.2"): {Packets: 1, Bytes: 100},
+		netip.MustParseAddr("10.0.1.1"): {Packets: 2, Bytes: 200},
+	}
+	connections := map[netip.Addr]uint64{
+		netip.MustParseAddr("1.1.1.1"):  2,
+		netip.MustParseAddr("10.0.0.1"): 1,
+		netip.MustParseAddr("10.0.2.1"): 3,
+	}
+
+	act := aggregate(egress, connections, acct.destinationOf)
+	exp := &api.GetNetworkAccountingResponse{
+		Connections: 6,
+		Destinations: []*api.EgressDestination{
+			{Destination: "AS13335", Connections: 2, Bytes: 1500, Packets: 15},
+			{Destination: "10.0.0.0/24", Connections: 1, Bytes: 200, Packets: 2},
+			{Destination: "10.0.1.0/24", Bytes: 200, Packets: 2},
+			{Destination: "10.0.2.0/24", Connections: 3},
+		},
+	}
+	if diff := cmp.Diff(exp, act, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected aggregate (-want +got):\n%s", diff)
+	}
+}
+
+func TestASNDatabase(t *testing.T) {
+	const db = `1.0.0.0	1.0.0.255	13335	US	CLOUDFLARENET
+1.0.4.0	1.0.7.255	38803	AU	WPL-AS-AP Wirefreebroadband Pty Ltd
+1.0.1.0	1.0.3.255	0	None	Not routed
+::	::ffff	0	None	Not routed
+`
+
+	asns, err := parseASNDatabase(strings.NewReader(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Addr string
+		ASN  uint32
+		OK   bool
+	}{
+		{Addr: "0.255.255.255"},
+		{Addr: "1.0.0.0", ASN: 13335, OK: true},
+		{Addr: "1.0.0.255", ASN: 13335, OK: true},
+		{Addr: "1.0.2.1"},
+		{Addr: "1.0.5.1", ASN: 38803, OK: true},
+		{Addr: "1.0.8.0"},
+	}
+	for _, test := range tests {
+		t.Run(test.Addr, func(t *testing.T) {
+			asn, ok := asns.Lookup(netip.MustParseAddr(test.Addr))
+			if asn != test.ASN || ok != test.OK {
+				t.Errorf("unexpected lookup result: expected %d/%v, got %d/%v", test.ASN, test.OK, asn, ok)
+			}
+		})
+	}
+
+	_, err = parseASNDatabase(strings.NewReader("1.0.0.0\t1.0.0.255\n"))
+	if err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestCollectByType(t *testing.T) {
+	acct, err := NewAccountant(Config{}, "", prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct.workspaces = map[string]*accountedWorkspace{
+		"ws1": {Type: "regular", Res: &api.GetNetworkAccountingResponse{Connections: 2}},
+		"ws2": {Type: "regular", Res: &api.GetNetworkAccountingResponse{Connections: 100, Destinations: []*api.EgressDestination{{Destination: "AS13335"}}}},
+		"ws3": {Type: "prebuild", Res: &api.GetNetworkAccountingResponse{Connections: 1}},
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(acct)
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var series int
+	for _, mf := range mfs {
+		if mf.GetName() != "netaccounting_workspace_connections" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			series++
+			for _, l := range m.Label {
+				if l.GetName() == "type" && l.GetValue() == "regular" {
+					if act := m.Histogram.GetSampleCount(); act != 2 {
+						t.Errorf("expected 2 regular workspaces, got %d", act)
+					}
+					if act := m.Histogram.GetSampleSum(); act != 102 {
+						t.Errorf("expected 102 connections of regular workspaces, got %v", act)
+					}
+				}
+			}
+		}
+	}
+	if series != 2 {
+		t.Errorf("expected one series per workspace type, got %d", series)
+	}
+}
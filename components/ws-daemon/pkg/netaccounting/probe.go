@@ -0,0 +1,250 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package netaccounting
+
+import (
+	"net/netip"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/link"
+	"golang.org/x/xerrors"
+)
+
+// egressCounters is the value of the egress map. Its layout must match what egressInstructions writes.
+type egressCounters struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// probe counts the TCP connections and the IPv4 egress traffic of the processes in a cgroup per destination address.
+// The counting happens in eBPF programs attached to the cgroup, such that we never look at the packets' payload.
+type probe struct {
+	egress      *ebpf.Map
+	connections *ebpf.Map
+	links       []link.Link
+}
+
+// attachProbe attaches a new probe to the cgroup
+func attachProbe(cgroupPath string, maxDestinations int) (p *probe, err error) {
+	p = &probe{}
+	defer func() {
+		if err != nil {
+			p.Close()
+		}
+	}()
+
+	p.egress, err = ebpf.NewMap(&ebpf.MapSpec{
+		Name:       "egress",
+		Type:       ebpf.LRUHash,
+		KeySize:    4,
+		ValueSize:  16,
+		MaxEntries: uint32(maxDestinations),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create egress map: %w", err)
+	}
+	p.connections, err = ebpf.NewMap(&ebpf.MapSpec{
+		Name:       "connections",
+		Type:       ebpf.LRUHash,
+		KeySize:    4,
+		ValueSize:  8,
+		MaxEntries: uint32(maxDestinations),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create connections map: %w", err)
+	}
+
+	err = p.attach(cgroupPath, &ebpf.ProgramSpec{
+		Name:         "count_egress",
+		Type:         ebpf.CGroupSKB,
+		Instructions: egressInstructions(p.egress.FD()),
+		License:      "GPL",
+	}, ebpf.AttachCGroupInetEgress)
+	if err != nil {
+		return nil, err
+	}
+	err = p.attach(cgroupPath, &ebpf.ProgramSpec{
+		Name:         "count_connect",
+		Type:         ebpf.CGroupSockAddr,
+		AttachType:   ebpf.AttachCGroupInet4Connect,
+		Instructions: connectInstructions(p.connections.FD()),
+		License:      "GPL",
+	}, ebpf.AttachCGroupInet4Connect)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *probe) attach(cgroupPath string, spec *ebpf.ProgramSpec, attachType ebpf.AttachType) error {
+	prog, err := ebpf.NewProgram(spec)
+	if err != nil {
+		return xerrors.Errorf("cannot load %s program: %w", spec.Name, err)
+	}
+	// the link holds its own reference to the program
+	defer prog.Close()
+
+	// AttachCgroup allows multiple programs per cgroup, hence programs attached from within the workspace
+	// cannot replace ours.
+	l, err := link.AttachCgroup(link.CgroupOptions{
+		Path:    cgroupPath,
+		Attach:  attachType,
+		Program: prog,
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot attach %s program: %w", spec.Name, err)
+	}
+	p.links = append(p.links, l)
+	return nil
+}
+
+// read returns the counters of all destinations the probe has seen
+func (p *probe) read() (egress map[netip.Addr]egressCounters, connections map[netip.Addr]uint64, err error) {
+	var (
+		key      [4]byte
+		counters egressCounters
+		count    uint64
+	)
+
+	egress = make(map[netip.Addr]egressCounters)
+	it := p.egress.Iterate()
+	for it.Next(&key, &counters) {
+		egress[netip.AddrFrom4(key)] = counters
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, xerrors.Errorf("cannot read egress map: %w", err)
+	}
+
+	connections = make(map[netip.Addr]uint64)
+	it = p.connections.Iterate()
+	for it.Next(&key, &count) {
+		connections[netip.AddrFrom4(key)] = count
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, xerrors.Errorf("cannot read connections map: %w", err)
+	}
+
+	return egress, connections, nil
+}
+
+// Close detaches the probe and releases its maps
+func (p *probe) Close() {
+	for _, l := range p.links {
+		_ = l.Close()
+	}
+	if p.egress != nil {
+		_ = p.egress.Close()
+	}
+	if p.connections != nil {
+		_ = p.connections.Close()
+	}
+}
+
+// egressInstructions counts the packets and bytes of every outgoing IPv4 packet by destination address.
+// The program never drops packets.
+//
+// Stack layout: fp-4 holds the destination address (the map key), fp-8 the first byte of the IP header
+// and fp-24 the counters of a destination we haven't seen before.
+func egressInstructions(egressMapFD int) asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+
+		// the packet data of cgroup skb programs starts with the IP header, whose first nibble is the version
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.Mov.Imm(asm.R2, 0),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, -8),
+		asm.Mov.Imm(asm.R4, 1),
+		asm.FnSkbLoadBytes.Call(),
+		asm.JNE.Imm(asm.R0, 0, "allow"),
+		asm.LoadMem(asm.R0, asm.RFP, -8, asm.Byte),
+		asm.RSh.Imm(asm.R0, 4),
+		asm.JNE.Imm(asm.R0, 4, "allow"),
+
+		// the destination address is at offset 16 of the IPv4 header
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.Mov.Imm(asm.R2, 16),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, -4),
+		asm.Mov.Imm(asm.R4, 4),
+		asm.FnSkbLoadBytes.Call(),
+		asm.JNE.Imm(asm.R0, 0, "allow"),
+
+		// skb->len
+		asm.LoadMem(asm.R7, asm.R6, 0, asm.Word),
+
+		asm.LoadMapPtr(asm.R1, egressMapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "insert"),
+		asm.Mov.Imm(asm.R1, 1),
+		asm.StoreXAdd(asm.R0, asm.R1, asm.DWord),
+		asm.Add.Imm(asm.R0, 8),
+		asm.StoreXAdd(asm.R0, asm.R7, asm.DWord),
+		asm.Ja.Label("allow"),
+
+		// Concurrent inserts of the same destination may lose a packet, which is fine for accounting.
+		asm.StoreImm(asm.RFP, -24, 1, asm.DWord).WithSymbol("insert"),
+		asm.StoreMem(asm.RFP, -16, asm.R7, asm.DWord),
+		asm.LoadMapPtr(asm.R1, egressMapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, -24),
+		asm.Mov.Imm(asm.R4, 0),
+		asm.FnMapUpdateElem.Call(),
+
+		asm.Mov.Imm(asm.R0, 1).WithSymbol("allow"),
+		asm.Return(),
+	}
+}
+
+// connectInstructions counts the TCP connections by destination address. The program never rejects connections.
+//
+// Stack layout: fp-4 holds the destination address (the map key) and fp-16 the count of a destination
+// we haven't seen before.
+func connectInstructions(connectionsMapFD int) asm.Instructions {
+	const (
+		// offsets in struct bpf_sock_addr
+		userIP4Offset = 4
+		typeOffset    = 32
+
+		sockStream = 1
+	)
+
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+
+		asm.LoadMem(asm.R0, asm.R6, typeOffset, asm.Word),
+		asm.JNE.Imm(asm.R0, sockStream, "allow"),
+
+		asm.LoadMem(asm.R0, asm.R6, userIP4Offset, asm.Word),
+		asm.StoreMem(asm.RFP, -4, asm.R0, asm.Word),
+
+		asm.LoadMapPtr(asm.R1, connectionsMapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "insert"),
+		asm.Mov.Imm(asm.R1, 1),
+		asm.StoreXAdd(asm.R0, asm.R1, asm.DWord),
+		asm.Ja.Label("allow"),
+
+		asm.StoreImm(asm.RFP, -16, 1, asm.DWord).WithSymbol("insert"),
+		asm.LoadMapPtr(asm.R1, connectionsMapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, -16),
+		asm.Mov.Imm(asm.R4, 0),
+		asm.FnMapUpdateElem.Call(),
+
+		asm.Mov.Imm(asm.R0, 1).WithSymbol("allow"),
+		asm.Return(),
+	}
+}
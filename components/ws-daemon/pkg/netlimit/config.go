@@ -4,9 +4,26 @@
 
 package netlimit
 
+import (
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
 type Config struct {
 	Enabled              bool  `json:"enabled"`
 	Enforce              bool  `json:"enforce"`
 	ConnectionsPerMinute int64 `json:"connectionsPerMinute"`
 	BucketSize           int64 `json:"bucketSize"`
 }
+
+// EgressConfig configures the egress bandwidth shaping of workspaces
+type EgressConfig struct {
+	Enabled bool `json:"enabled"`
+	// BandwidthPerSecond is the egress bandwidth of workspaces whose class configures none. If zero,
+	// the egress of those workspaces is counted but not shaped.
+	BandwidthPerSecond resource.Quantity `json:"bandwidthPerSecond"`
+	// Burst is the amount of bytes workspaces can send at once. If zero, a tenth of the bandwidth applies.
+	Burst resource.Quantity `json:"burst"`
+	// Interval is how often the egress of workspaces is counted
+	Interval util.Duration `json:"interval,omitempty"`
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package netlimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/nsinsider"
+)
+
+const (
+	// defaultEgressInterval is how often we count the egress of workspaces if no interval is configured
+	defaultEgressInterval = 30 * time.Second
+
+	// egressInterface is the network device of the workspace pod all egress traffic leaves through
+	egressInterface = "eth0"
+)
+
+// EgressLimiter shapes the egress bandwidth of workspaces using a token bucket filter on the network device
+// of their pod, and counts the bytes they send such that abusive workspaces can be detected.
+type EgressLimiter struct {
+	mu     sync.Mutex
+	config EgressConfig
+
+	sentBytes        *prometheus.GaugeVec
+	workspacesShaped *prometheus.CounterVec
+}
+
+// NewEgressLimiter creates a new egress limiter
+func NewEgressLimiter(config EgressConfig, prom prometheus.Registerer) *EgressLimiter {
+	l := &EgressLimiter{
+		config: config,
+
+		sentBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "netlimit_egress_sent_bytes",
+			Help: "Number of bytes sent by workspaces",
+		}, []string{"node", "workspace"}),
+		workspacesShaped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "netlimit_egress_workspaces_shaped_total",
+			Help: "Number of workspaces whose egress bandwidth was shaped",
+		}, []string{"source"}),
+	}
+
+	if config.Enabled {
+		prom.MustRegister(
+			l.sentBytes,
+			l.workspacesShaped,
+		)
+	}
+
+	return l
+}
+
+// WorkspaceAdded shapes the egress of a new workspace and starts counting the bytes it sends
+func (l *EgressLimiter) WorkspaceAdded(ctx context.Context, ws *dispatch.Workspace) error {
+	disp := dispatch.GetFromContext(ctx)
+	if disp == nil {
+		return fmt.Errorf("no dispatch available")
+	}
+
+	pid, err := disp.Runtime.ContainerPID(context.Background(), ws.ContainerID)
+	if err != nil {
+		return fmt.Errorf("could not get pid for container %s of workspace %s", ws.ContainerID, ws.WorkspaceID)
+	}
+
+	l.mu.Lock()
+	cfg := l.config
+	l.mu.Unlock()
+
+	rate, source := egressBandwidth(cfg, ws.Pod.Annotations)
+	if rate > 0 {
+		err = nsinsider.Nsinsider(ws.InstanceID, int(pid), func(cmd *exec.Cmd) {
+			cmd.Args = append(cmd.Args, "setup-egress-limit", "--rate", strconv.FormatInt(rate, 10), "--interface", egressInterface)
+			if burst := cfg.Burst.Value(); burst > 0 {
+				cmd.Args = append(cmd.Args, "--burst", strconv.FormatInt(burst, 10))
+			}
+		}, nsinsider.EnterMountNS(false), nsinsider.EnterNetNS(true))
+		if err != nil {
+			log.WithError(err).WithFields(ws.OWI()).Error("cannot enable egress limiting")
+			return err
+		}
+		l.workspacesShaped.WithLabelValues(source).Inc()
+	}
+
+	interval := time.Duration(cfg.Interval)
+	if interval == 0 {
+		interval = defaultEgressInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		nodeName := os.Getenv("NODENAME")
+		for {
+			select {
+			case <-ticker.C:
+				sent, err := readSentBytes(fmt.Sprintf("/proc/%d/net/dev", pid), egressInterface)
+				if err != nil {
+					log.WithFields(ws.OWI()).WithError(err).Warn("could not count egress bytes")
+					continue
+				}
+				l.sentBytes.WithLabelValues(nodeName, ws.Pod.Name).Set(float64(sent))
+			case <-ctx.Done():
+				l.sentBytes.DeleteLabelValues(nodeName, ws.Pod.Name)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Update changes the egress configuration. It applies to workspaces added afterwards.
+func (l *EgressLimiter) Update(config EgressConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.config = config
+	log.WithField("config", config).Info("updating network egress limits")
+}
+
+// egressBandwidth returns the egress bandwidth of a workspace in bytes per second. The bandwidth of its
+// workspace class, passed on by ws-manager through an annotation, takes precedence over the node-wide one.
+func egressBandwidth(cfg EgressConfig, annotations map[string]string) (rate int64, source string) {
+	if value, ok := annotations[kubernetes.WorkspaceEgressBandwidthLimitAnnotation]; ok {
+		q, err := resource.ParseQuantity(value)
+		if err == nil && q.Value() > 0 {
+			return q.Value(), "class"
+		}
+		log.WithError(err).WithField("value", value).Warn("invalid egress bandwidth annotation")
+	}
+	return cfg.BandwidthPerSecond.Value(), "node"
+}
+
+// readSentBytes reads the bytes sent through a network device from a /proc/<pid>/net/dev file
+func readSentBytes(fn, device string) (uint64, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return parseSentBytes(f, device)
+}
+
+func parseSentBytes(r io.Reader, device string) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, stats, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(name) != device {
+			continue
+		}
+
+		// the first eight fields are receive statistics, the ninth is the number of bytes transmitted
+		fields := strings.Fields(stats)
+		if len(fields) < 9 {
+			return 0, fmt.Errorf("invalid statistics of device %s: %s", device, stats)
+		}
+		return strconv.ParseUint(fields[8], 10, 64)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("device %s not found", device)
+}
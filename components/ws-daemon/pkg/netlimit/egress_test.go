@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package netlimit
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gitpod-io/gitpod/common-go/kubernetes"
+)
+
+func TestParseSentBytes(t *testing.T) {
+	const netdev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    1234      12    0    0    0     0          0         0     1234      12    0    0    0     0       0          0
+  eth0: 9876543    4321    0    0    0     0          0         0  5678901    3210    0    2    0     0       0          0
+`
+
+	sent, err := parseSentBytes(strings.NewReader(netdev), "eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 5678901 {
+		t.Errorf("unexpected sent bytes: expected 5678901, got %d", sent)
+	}
+
+	_, err = parseSentBytes(strings.NewReader(netdev), "eth1")
+	if err == nil {
+		t.Error("expected an error for a missing device")
+	}
+}
+
+func TestEgressBandwidth(t *testing.T) {
+	cfg := EgressConfig{BandwidthPerSecond: resource.MustParse("10Mi")}
+
+	tests := []struct {
+		Name         string
+		Annotations  map[string]string
+		ExpectRate   int64
+		ExpectSource string
+	}{
+		{Name: "node bandwidth", ExpectRate: 10 * 1024 * 1024, ExpectSource: "node"},
+		{Name: "class bandwidth", Annotations: map[string]string{kubernetes.WorkspaceEgressBandwidthLimitAnnotation: "50Mi"}, ExpectRate: 50 * 1024 * 1024, ExpectSource: "class"},
+		{Name: "invalid class bandwidth", Annotations: map[string]string{kubernetes.WorkspaceEgressBandwidthLimitAnnotation: "lots"}, ExpectRate: 10 * 1024 * 1024, ExpectSource: "node"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			rate, source := egressBandwidth(cfg, test.Annotations)
+			if rate != test.ExpectRate || source != test.ExpectSource {
+				t.Errorf("unexpected bandwidth: expected %d from %s, got %d from %s", test.ExpectRate, test.ExpectSource, rate, source)
+			}
+		})
+	}
+}
@@ -5,13 +5,35 @@
 package quota
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 )
 
+var (
+	// ErrExceeded is returned when workspace content does not fit into its storage quota
+	ErrExceeded = errors.New("storage quota exceeded")
+
+	// ErrNotEnforced is returned when the storage quota of a workspace cannot be installed
+	ErrNotEnforced = errors.New("storage quota cannot be enforced")
+)
+
+// IsExceeded returns true if err was caused by hitting a filesystem quota. Content initializers run in
+// a separate process and only report their error message, hence we look for the EDQUOT message as well.
+func IsExceeded(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrExceeded) || errors.Is(err, syscall.EDQUOT) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), syscall.EDQUOT.Error())
+}
+
 type xfsQuotaExec func(dir, command string) (output string, err error)
 
 func defaultXfsQuotaExec(dir, command string) (output string, err error) {
@@ -6,8 +6,10 @@ package quota
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -191,3 +193,26 @@ func TestSetQuota(t *testing.T) {
 		})
 	}
 }
+
+func TestIsExceeded(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Err      error
+		Expected bool
+	}{
+		{Name: "nil"},
+		{Name: "unrelated error", Err: fmt.Errorf("no space left on device")},
+		{Name: "quota exceeded", Err: fmt.Errorf("cannot restore: %w", ErrExceeded), Expected: true},
+		{Name: "EDQUOT", Err: &os.PathError{Op: "write", Path: "/foo", Err: syscall.EDQUOT}, Expected: true},
+		{Name: "initializer message", Err: fmt.Errorf("tar: ./node_modules/foo: Cannot write: Disk quota exceeded"), Expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := IsExceeded(test.Err)
+			if act != test.Expected {
+				t.Errorf("unexpected IsExceeded: expected %v, got %v", test.Expected, act)
+			}
+		})
+	}
+}
@@ -6,8 +6,11 @@ package config
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"html/template"
 	iofs "io/fs"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -17,7 +20,9 @@ import (
 	"github.com/go-ozzo/ozzo-validation/is"
 	"golang.org/x/xerrors"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
@@ -29,6 +34,13 @@ import (
 // DefaultWorkspaceClass is the name of the default workspace class
 const DefaultWorkspaceClass = "g1-standard"
 
+const (
+	// DefaultKubernetesClientQPS is the QPS of the Kubernetes client unless configured otherwise
+	DefaultKubernetesClientQPS = 100
+	// DefaultKubernetesClientBurst is the burst of the Kubernetes client unless configured otherwise
+	DefaultKubernetesClientBurst = 150
+)
+
 type osFS struct{}
 
 func (*osFS) Open(name string) (iofs.File, error) {
@@ -66,12 +78,46 @@ type ServiceConfiguration struct {
 	PProf struct {
 		Addr string `json:"addr"`
 	} `json:"pprof"`
+	// Diagnostics serves pprof profiles, the state of the work queues and reconcile traces of workspaces
+	// once enabled at runtime. Requests must carry the bearer token stored in TokenFile.
+	Diagnostics struct {
+		Addr      string `json:"addr"`
+		TokenFile string `json:"tokenFile"`
+	} `json:"diagnostics"`
+	// Audit writes every decision affecting a workspace as a line of JSON to File, or to stdout if File is empty.
+	Audit struct {
+		Enabled bool   `json:"enabled"`
+		File    string `json:"file,omitempty"`
+	} `json:"audit"`
+	// Prometheus serves our own metrics as well as the controller-runtime ones, e.g. the depth of each
+	// controller's work queue as workqueue_depth{name="workspace"}.
 	Prometheus struct {
 		Addr string `json:"addr"`
 	} `json:"prometheus"`
 	Health struct {
 		Addr string `json:"addr"`
 	} `json:"health"`
+	// KubernetesClient configures the rate limits of the client we talk to the Kubernetes API with.
+	// Unset values fall back to DefaultKubernetesClientQPS and DefaultKubernetesClientBurst.
+	KubernetesClient struct {
+		QPS   float32 `json:"qps,omitempty"`
+		Burst int     `json:"burst,omitempty"`
+	} `json:"kubernetesClient"`
+	// LeaderElection configures how fast a replica takes over once the leader is gone. Unset values fall back
+	// to defaults which let a replica take over within a few seconds.
+	LeaderElection struct {
+		LeaseDuration util.Duration `json:"leaseDuration,omitempty"`
+		RenewDeadline util.Duration `json:"renewDeadline,omitempty"`
+		RetryPeriod   util.Duration `json:"retryPeriod,omitempty"`
+	} `json:"leaderElection"`
+	// GracefulShutdownTimeout is how long we wait for in-flight reconciliations to finish once we're asked to stop.
+	GracefulShutdownTimeout util.Duration `json:"gracefulShutdownTimeout,omitempty"`
+	// Webhook configures the admission webhooks for workspace resources
+	Webhook struct {
+		Enabled bool `json:"enabled"`
+		// CertDir contains the serving certificate of the webhook server as tls.crt and tls.key
+		CertDir string `json:"certDir,omitempty"`
+	} `json:"webhook"`
 }
 
 // Configuration is the configuration of the ws-manager
@@ -121,6 +167,9 @@ type Configuration struct {
 	RegistryFacadeHost string `json:"registryFacadeHost"`
 	// Cluster host under which workspaces are served, e.g. ws-eu11.gitpod.io
 	WorkspaceClusterHost string `json:"workspaceClusterHost"`
+	// WorkspaceProxies are the addresses or networks of the proxies which forward requests to workspaces, e.g. ws-proxy.
+	// Supervisor trusts their X-Forwarded-For header to tell the address of clients.
+	WorkspaceProxies []string `json:"workspaceProxies,omitempty"`
 	// WorkspaceClasses provide different resource classes for workspaces
 	WorkspaceClasses map[string]*WorkspaceClass `json:"workspaceClass"`
 	// PreferredWorkspaceClass is the name of the workspace class that should be used by default
@@ -133,10 +182,33 @@ type Configuration struct {
 	// TimeoutMaxConcurrentReconciles configures the max amount of concurrent workspace reconciliations on
 	// the timeout controller.
 	TimeoutMaxConcurrentReconciles int `json:"timeoutMaxConcurrentReconciles,omitempty"`
+	// MaxConcurrentStartsPerNode caps the number of workspaces which start concurrently on a node. Further
+	// workspaces wait until a node finished starting some. If zero, starts are not limited.
+	MaxConcurrentStartsPerNode int `json:"maxConcurrentStartsPerNode,omitempty"`
+	// Capacity caps the number of workspaces running per node and in the cluster.
+	Capacity CapacityConfiguration `json:"capacity,omitempty"`
+	// SubscriberBufferSize is the number of status updates buffered per subscriber. Subscribers which fall
+	// further behind are dropped and have to resubscribe. If zero, a default applies.
+	SubscriberBufferSize int `json:"subscriberBufferSize,omitempty"`
 	// EnableCustomSSLCertificate controls if we need to support custom SSL certificates for git operations
 	EnableCustomSSLCertificate bool `json:"enableCustomSSLCertificate"`
 	// WorkspacekitImage points to the default workspacekit image
 	WorkspacekitImage string `json:"workspacekitImage,omitempty"`
+	// DiskPressureEvictionTimeout is the time a workspace pod tolerates disk pressure on its node before it is evicted.
+	// If zero, workspace pods tolerate disk pressure indefinitely.
+	DiskPressureEvictionTimeout util.Duration `json:"diskPressureEvictionTimeout,omitempty"`
+	// SnapshotRetention configures when snapshots are garbage collected. Their content is garbage collected by content-service.
+	SnapshotRetention SnapshotRetention `json:"snapshotRetention,omitempty"`
+	// NetworkPolicy configures the NetworkPolicy created for every workspace pod.
+	NetworkPolicy WorkspaceNetworkPolicy `json:"networkPolicy,omitempty"`
+	// OrphanSweep configures the periodic clean up of workspace resources whose workspace doesn't exist anymore.
+	OrphanSweep OrphanSweepConfiguration `json:"orphanSweep,omitempty"`
+	// ImagePullSecret refers to the registry credentials workspace pods pull their images with.
+	ImagePullSecret *ImagePullSecretConfiguration `json:"imagePullSecret,omitempty"`
+	// StartRetry configures how workspace starts which failed for infrastructure reasons are retried.
+	StartRetry StartRetryConfiguration `json:"startRetry,omitempty"`
+	// EphemeralStorageQuota configures how workspaces are warned and stopped as their ephemeral storage fills up.
+	EphemeralStorageQuota EphemeralStorageQuotaConfiguration `json:"ephemeralStorageQuota,omitempty"`
 
 	SSHGatewayCAPublicKeyFile string `json:"sshGatewayCAPublicKeyFile,omitempty"`
 
@@ -152,6 +224,194 @@ type WorkspaceClass struct {
 
 	// CreditsPerMinute is the cost per minute for this workspace class in credits
 	CreditsPerMinute float32 `json:"creditsPerMinute"`
+
+	// Timeouts override the manager's timeouts for workspaces of this class
+	Timeouts *WorkspaceClassTimeoutConfiguration `json:"timeouts,omitempty"`
+
+	// PVC makes workspaces of this class keep their content on a persistent volume claim which is
+	// backed up using a volume snapshot, instead of the node's disk and a remote storage backup.
+	PVC *PVCConfiguration `json:"pvc,omitempty"`
+
+	// NodeAffinity restricts workspaces of this class to nodes matching all requirements, e.g. to a dedicated
+	// node pool. The requirements add to the ones every workspace pod has.
+	NodeAffinity []corev1.NodeSelectorRequirement `json:"nodeAffinity,omitempty"`
+
+	// Tolerations let workspaces of this class run on tainted nodes, e.g. on GPU nodes which are tainted
+	// such that no other workspaces land on them.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PodPatch is a strategic merge patch applied to the pods of workspaces of this class once they've been
+	// created from the templates, e.g. to add sidecars, environment variables or volumes.
+	PodPatch json.RawMessage `json:"podPatch,omitempty"`
+
+	// Deprecation marks the class as deprecated. Existing workspaces of the class keep running,
+	// but no new workspaces can be started with it.
+	Deprecation *WorkspaceClassDeprecation `json:"deprecation,omitempty"`
+}
+
+// WorkspaceClassDeprecation configures how a deprecated workspace class is phased out
+type WorkspaceClassDeprecation struct {
+	// ReplacedBy is the name of the workspace class users should use instead
+	ReplacedBy string `json:"replacedBy,omitempty"`
+	// Message is returned to users who try to start a workspace with the deprecated class
+	Message string `json:"message,omitempty"`
+}
+
+// Deprecated returns true if the class is deprecated, i.e. no new workspaces can be started with it
+func (c *WorkspaceClass) Deprecated() bool {
+	return c != nil && c.Deprecation != nil
+}
+
+// DeprecationMessage returns the message explaining why a workspace cannot be started with the class
+func (c *WorkspaceClass) DeprecationMessage(name string) string {
+	if !c.Deprecated() {
+		return ""
+	}
+
+	msg := fmt.Sprintf("workspace class \"%s\" is deprecated", name)
+	if c.Deprecation.ReplacedBy != "" {
+		msg += fmt.Sprintf(", please use \"%s\" instead", c.Deprecation.ReplacedBy)
+	}
+	if c.Deprecation.Message != "" {
+		msg += ": " + c.Deprecation.Message
+	}
+	return msg
+}
+
+// SnapshotRetention configures the garbage collection of snapshots. Snapshots are kept forever if neither
+// the TTL nor the maximum per workspace is set.
+type SnapshotRetention struct {
+	// TTL is how long a snapshot is kept after it was taken. If zero, snapshots don't expire.
+	TTL util.Duration `json:"ttl,omitempty"`
+	// MaxPerWorkspace is the number of snapshots kept per workspace, older ones are deleted. If zero, the number is not limited.
+	MaxPerWorkspace int `json:"maxPerWorkspace,omitempty"`
+}
+
+// Enabled returns whether snapshots are garbage collected at all.
+func (r SnapshotRetention) Enabled() bool {
+	return r.TTL > 0 || r.MaxPerWorkspace > 0
+}
+
+// WorkspaceNetworkPolicy configures the NetworkPolicy which isolates a workspace pod. Workspaces can reach the
+// internet over IPv4, but not the cluster, except for the peers allowed by the egress rules. Because NetworkPolicies are
+// additive, workspace pods which get their own NetworkPolicy aren't selected by the namespace-wide one anymore.
+type WorkspaceNetworkPolicy struct {
+	// Enabled makes the manager create a NetworkPolicy for every workspace pod.
+	Enabled bool `json:"enabled"`
+	// ClusterCIDRs are the pod and service IP ranges of the cluster, which workspaces can't reach through the internet egress.
+	ClusterCIDRs []string `json:"clusterCIDRs,omitempty"`
+	// BlockedCIDRs are further IP ranges workspaces can't reach, e.g. the VM metadata service at 169.254.169.254/32.
+	BlockedCIDRs []string `json:"blockedCIDRs,omitempty"`
+	// Ingress are the rules which allow cluster services to reach workspaces, e.g. proxy and ws-proxy.
+	Ingress []networkingv1.NetworkPolicyIngressRule `json:"ingress,omitempty"`
+	// Egress are the rules which allow workspaces to reach cluster services, e.g. proxy and DNS.
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+// OrphanSweepConfiguration configures the sweeper which deletes the pods, secrets and services of workspaces
+// which don't exist anymore, e.g. after an etcd restore or because a finalizer got stuck.
+type OrphanSweepConfiguration struct {
+	// Interval is how often the sweeper runs. If zero, orphaned resources are not swept.
+	Interval util.Duration `json:"interval,omitempty"`
+	// DryRun only reports orphaned resources as metrics, without deleting them.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// StartRetryConfiguration configures the recreation of workspace pods whose start failed for reasons outside of
+// the workspace, e.g. their node became NotReady or their network couldn't be set up. Such starts are retried
+// before the workspace content is initialized, and are transparent to the user.
+type StartRetryConfiguration struct {
+	// MaxAttempts is how often the pod of a workspace is recreated. If zero, such starts fail the workspace.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Backoff is the time we wait before recreating the pod.
+	Backoff util.Duration `json:"backoff,omitempty"`
+	// SandboxTimeout is the time a scheduled pod may take until its containers can be started, i.e. its
+	// sandbox and network are set up. If zero, we wait indefinitely.
+	SandboxTimeout util.Duration `json:"sandboxTimeout,omitempty"`
+}
+
+// EphemeralStorageQuotaConfiguration configures the limits on the ephemeral storage workspaces use, relative to the
+// ephemeral storage limit of their container. It relies on ws-daemon reporting the disk usage of workspaces.
+// Workspaces are stopped at the hard limit, such that their content is backed up before the kubelet evicts them.
+type EphemeralStorageQuotaConfiguration struct {
+	// SoftLimit is the fraction of the ephemeral storage limit at which the user is warned. If zero, users are not warned.
+	SoftLimit float64 `json:"softLimit,omitempty"`
+	// HardLimit is the fraction of the ephemeral storage limit at which the workspace is stopped. If zero, workspaces
+	// are not stopped before the kubelet evicts them.
+	HardLimit float64 `json:"hardLimit,omitempty"`
+}
+
+// CapacityConfiguration caps the number of workspaces which run at the same time. Workspaces beyond the
+// cluster capacity stay pending until others stop. Nodes at capacity are avoided when scheduling, such that
+// the cluster autoscaler adds nodes.
+type CapacityConfiguration struct {
+	// MaxWorkspaces is the number of workspaces which may run in the cluster. If zero, it's not limited.
+	MaxWorkspaces int `json:"maxWorkspaces,omitempty"`
+	// MaxWorkspacesPerNode is the number of workspaces which may run on a node. If zero, it's not limited.
+	MaxWorkspacesPerNode int `json:"maxWorkspacesPerNode,omitempty"`
+}
+
+// ImagePullSecretConfiguration refers to a Secret of type kubernetes.io/dockerconfigjson. Every workspace pod gets
+// a copy of it as image pull secret. The secret is read whenever a workspace pod is created, and the copies of
+// existing workspaces are updated when it changes, such that credentials can be rotated, e.g. short-lived ECR tokens.
+type ImagePullSecretConfiguration struct {
+	// Name is the name of the secret.
+	Name string `json:"name"`
+	// Namespace is the namespace of the secret. Defaults to the namespace of the workspaces.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// PVCConfiguration configures the persistent volume claim of workspaces
+type PVCConfiguration struct {
+	// Size is the storage size of the workspace volume, e.g. 30Gi
+	Size string `json:"size"`
+	// StorageClass is the storage class of the workspace volume. Uses the cluster's default storage class if empty.
+	StorageClass string `json:"storageClass,omitempty"`
+	// SnapshotClass is the volume snapshot class used to back up the workspace volume. Uses the cluster's default snapshot class if empty.
+	SnapshotClass string `json:"snapshotClass,omitempty"`
+}
+
+// WorkspaceClassTimeoutConfiguration configures the timeouts of a workspace class. Unset values
+// fall back to the manager's timeout configuration.
+type WorkspaceClassTimeoutConfiguration struct {
+	// RegularWorkspace is the time a regular workspace can be without activity before it's shutdown
+	RegularWorkspace *util.Duration `json:"regularWorkspace,omitempty"`
+	// MaxLifetime is the maximum lifetime of a regular workspace
+	MaxLifetime *util.Duration `json:"maxLifetime,omitempty"`
+	// AfterClose is the time a workspace lives after it has been marked closed
+	AfterClose *util.Duration `json:"afterClose,omitempty"`
+}
+
+// KubernetesClientRateLimits returns the QPS and burst of the Kubernetes client, using the defaults for unset values
+func (c *ServiceConfiguration) KubernetesClientRateLimits() (qps float32, burst int) {
+	qps, burst = DefaultKubernetesClientQPS, DefaultKubernetesClientBurst
+	if c.KubernetesClient.QPS > 0 {
+		qps = c.KubernetesClient.QPS
+	}
+	if c.KubernetesClient.Burst > 0 {
+		burst = c.KubernetesClient.Burst
+	}
+	return qps, burst
+}
+
+// ClassTimeouts returns the timeouts for workspaces of the given class
+func (c *Configuration) ClassTimeouts(class string) WorkspaceTimeoutConfiguration {
+	timeouts := c.Timeouts
+
+	cls, ok := c.WorkspaceClasses[class]
+	if !ok || cls.Timeouts == nil {
+		return timeouts
+	}
+	if cls.Timeouts.RegularWorkspace != nil {
+		timeouts.RegularWorkspace = *cls.Timeouts.RegularWorkspace
+	}
+	if cls.Timeouts.MaxLifetime != nil {
+		timeouts.MaxLifetime = *cls.Timeouts.MaxLifetime
+	}
+	if cls.Timeouts.AfterClose != nil {
+		timeouts.AfterClose = *cls.Timeouts.AfterClose
+	}
+	return timeouts
 }
 
 // WorkspaceTimeoutConfiguration configures the timeout behaviour of workspaces
@@ -175,6 +435,9 @@ type WorkspaceTimeoutConfiguration struct {
 	Stopping util.Duration `json:"stopping"`
 	// Interrupted is the time a workspace may be interrupted (since it last saw activity or since it was created if it never saw any)
 	Interrupted util.Duration `json:"interrupted"`
+	// StoppingSoonWarning is how long before a workspace is stopped due to inactivity we warn its user about it.
+	// If zero, users are not warned.
+	StoppingSoonWarning util.Duration `json:"stoppingSoonWarning,omitempty"`
 }
 
 // InitProbeConfiguration configures the behaviour of the workspace ready probe
@@ -236,6 +499,62 @@ func (c *Configuration) Validate() error {
 	if c.Timeouts.Stopping < c.Timeouts.ContentFinalization {
 		return xerrors.Errorf("stopping timeout must be greater than content finalization timeout")
 	}
+	if c.DiskPressureEvictionTimeout != 0 && time.Duration(c.DiskPressureEvictionTimeout) < time.Second {
+		return xerrors.Errorf("disk pressure eviction timeout must be at least 1s, got %s", time.Duration(c.DiskPressureEvictionTimeout))
+	}
+	if c.MaxConcurrentStartsPerNode < 0 {
+		return xerrors.Errorf("max concurrent starts per node must not be negative, got %d", c.MaxConcurrentStartsPerNode)
+	}
+	if c.Capacity.MaxWorkspaces < 0 {
+		return xerrors.Errorf("max workspaces must not be negative, got %d", c.Capacity.MaxWorkspaces)
+	}
+	if c.Capacity.MaxWorkspacesPerNode < 0 {
+		return xerrors.Errorf("max workspaces per node must not be negative, got %d", c.Capacity.MaxWorkspacesPerNode)
+	}
+	if c.Timeouts.StoppingSoonWarning < 0 {
+		return xerrors.Errorf("stopping soon warning must not be negative, got %s", time.Duration(c.Timeouts.StoppingSoonWarning))
+	}
+	if c.SubscriberBufferSize < 0 {
+		return xerrors.Errorf("subscriber buffer size must not be negative, got %d", c.SubscriberBufferSize)
+	}
+	if c.SnapshotRetention.TTL < 0 {
+		return xerrors.Errorf("snapshot retention TTL must not be negative, got %s", time.Duration(c.SnapshotRetention.TTL))
+	}
+	if c.SnapshotRetention.MaxPerWorkspace < 0 {
+		return xerrors.Errorf("snapshot retention max per workspace must not be negative, got %d", c.SnapshotRetention.MaxPerWorkspace)
+	}
+	if c.ImagePullSecret != nil && c.ImagePullSecret.Name == "" {
+		return xerrors.Errorf("image pull secret: name is required")
+	}
+	if c.OrphanSweep.Interval < 0 {
+		return xerrors.Errorf("orphan sweep interval must not be negative, got %s", time.Duration(c.OrphanSweep.Interval))
+	}
+	if c.StartRetry.MaxAttempts < 0 {
+		return xerrors.Errorf("start retry max attempts must not be negative, got %d", c.StartRetry.MaxAttempts)
+	}
+	if c.StartRetry.Backoff < 0 {
+		return xerrors.Errorf("start retry backoff must not be negative, got %s", time.Duration(c.StartRetry.Backoff))
+	}
+	if c.StartRetry.SandboxTimeout < 0 {
+		return xerrors.Errorf("start retry sandbox timeout must not be negative, got %s", time.Duration(c.StartRetry.SandboxTimeout))
+	}
+	if q := c.EphemeralStorageQuota; q.SoftLimit < 0 || q.SoftLimit >= 1 || q.HardLimit < 0 || q.HardLimit >= 1 {
+		return xerrors.Errorf("ephemeral storage quota limits must be between 0 and 1, got %v and %v", q.SoftLimit, q.HardLimit)
+	}
+	if q := c.EphemeralStorageQuota; q.SoftLimit > 0 && q.HardLimit > 0 && q.SoftLimit >= q.HardLimit {
+		return xerrors.Errorf("ephemeral storage quota soft limit must be lower than the hard limit")
+	}
+	for _, cidrs := range [][]string{c.NetworkPolicy.ClusterCIDRs, c.NetworkPolicy.BlockedCIDRs} {
+		for _, cidr := range cidrs {
+			ip, _, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return xerrors.Errorf("network policy: %w", err)
+			}
+			if ip.To4() == nil {
+				return xerrors.Errorf("network policy: %s is not an IPv4 CIDR", cidr)
+			}
+		}
+	}
 
 	err = ozzo.ValidateStruct(c,
 		ozzo.Field(&c.WorkspaceURLTemplate, ozzo.Required, validWorkspaceURLTemplate),
@@ -258,6 +577,44 @@ func (c *Configuration) Validate() error {
 		if err := class.Container.Validate(); err != nil {
 			return xerrors.Errorf("workspace class %s: %w", name, err)
 		}
+		if t := class.Timeouts; t != nil {
+			for field, d := range map[string]*util.Duration{"regularWorkspace": t.RegularWorkspace, "maxLifetime": t.MaxLifetime, "afterClose": t.AfterClose} {
+				if d != nil && *d <= 0 {
+					return xerrors.Errorf("workspace class %s: timeout %s must be positive", name, field)
+				}
+			}
+		}
+
+		if pvc := class.PVC; pvc != nil {
+			if _, err := resource.ParseQuantity(pvc.Size); err != nil {
+				return xerrors.Errorf("workspace class %s: cannot parse PVC size: %w", name, err)
+			}
+		}
+
+		if d := class.Deprecation; d != nil {
+			if name == DefaultWorkspaceClass || name == c.PreferredWorkspaceClass {
+				return xerrors.Errorf("workspace class %s: the default and preferred workspace class cannot be deprecated", name)
+			}
+			if d.ReplacedBy != "" {
+				replacement, ok := c.WorkspaceClasses[d.ReplacedBy]
+				if !ok {
+					return xerrors.Errorf("workspace class %s: replacement class %s does not exist", name, d.ReplacedBy)
+				}
+				if replacement.Deprecated() {
+					return xerrors.Errorf("workspace class %s: replacement class %s is deprecated itself", name, d.ReplacedBy)
+				}
+			}
+		}
+
+		if len(class.PodPatch) > 0 {
+			pod, err := ApplyPodPatch(&corev1.Pod{}, class.PodPatch)
+			if err != nil {
+				return xerrors.Errorf("workspace class %s: invalid pod patch: %w", name, err)
+			}
+			if pod.Name != "" || pod.Namespace != "" {
+				return xerrors.Errorf("workspace class %s: pod patch must not change the name or namespace of the pod", name)
+			}
+		}
 
 		err = ozzo.ValidateStruct(&class.Templates,
 			ozzo.Field(&class.Templates.DefaultPath, validPodTemplate),
@@ -309,10 +666,29 @@ type ContainerConfiguration struct {
 
 // Validate validates a container configuration
 func (c *ContainerConfiguration) Validate() error {
-	return ozzo.ValidateStruct(c,
+	err := ozzo.ValidateStruct(c,
 		ozzo.Field(&c.Requests, validResourceRequestConfig),
 		ozzo.Field(&c.Limits, validResourceLimitConfig),
 	)
+	if err != nil {
+		return err
+	}
+
+	if c.Requests == nil || c.Limits == nil || c.Requests.EphemeralStorage == "" || c.Limits.EphemeralStorage == "" {
+		return nil
+	}
+	req, err := resource.ParseQuantity(c.Requests.EphemeralStorage)
+	if err != nil {
+		return xerrors.Errorf("cannot parse EphemeralStorage quantity: %w", err)
+	}
+	lim, err := resource.ParseQuantity(c.Limits.EphemeralStorage)
+	if err != nil {
+		return xerrors.Errorf("cannot parse EphemeralStorage quantity: %w", err)
+	}
+	if lim.Cmp(req) < 0 {
+		return xerrors.Errorf("ephemeral-storage limit (%s) must not be lower than request (%s)", c.Limits.EphemeralStorage, c.Requests.EphemeralStorage)
+	}
+	return nil
 }
 
 var validResourceRequestConfig = ozzo.By(func(o interface{}) error {
@@ -370,6 +746,15 @@ var validResourceLimitConfig = ozzo.By(func(o interface{}) error {
 			return xerrors.Errorf("cannot parse burst limit CPU quantity: %w", err)
 		}
 	}
+	if rc.CPU.BurstBudget != "" {
+		budget, err := time.ParseDuration(rc.CPU.BurstBudget)
+		if err != nil {
+			return xerrors.Errorf("cannot parse CPU burst budget: %w", err)
+		}
+		if budget < 0 {
+			return xerrors.Errorf("CPU burst budget must not be negative")
+		}
+	}
 	if rc.Memory != "" {
 		_, err := resource.ParseQuantity(rc.Memory)
 		if err != nil {
@@ -388,6 +773,46 @@ var validResourceLimitConfig = ozzo.By(func(o interface{}) error {
 			return xerrors.Errorf("cannot parse Storage quantity: %w", err)
 		}
 	}
+	if rc.IO != nil {
+		if rc.IO.ReadBandwidthPerSecond != "" {
+			_, err := resource.ParseQuantity(rc.IO.ReadBandwidthPerSecond)
+			if err != nil {
+				return xerrors.Errorf("cannot parse IO read bandwidth quantity: %w", err)
+			}
+		}
+		if rc.IO.WriteBandwidthPerSecond != "" {
+			_, err := resource.ParseQuantity(rc.IO.WriteBandwidthPerSecond)
+			if err != nil {
+				return xerrors.Errorf("cannot parse IO write bandwidth quantity: %w", err)
+			}
+		}
+		if rc.IO.ReadIOPS < 0 || rc.IO.WriteIOPS < 0 {
+			return xerrors.Errorf("IO IOPS limits must not be negative")
+		}
+	}
+	if rc.Network != nil && rc.Network.EgressBandwidthPerSecond != "" {
+		_, err := resource.ParseQuantity(rc.Network.EgressBandwidthPerSecond)
+		if err != nil {
+			return xerrors.Errorf("cannot parse network egress bandwidth quantity: %w", err)
+		}
+	}
+	if rc.MemoryPressure != nil {
+		if rc.MemoryPressure.High != "" {
+			high, err := resource.ParseQuantity(rc.MemoryPressure.High)
+			if err != nil {
+				return xerrors.Errorf("cannot parse memory high quantity: %w", err)
+			}
+			if rc.Memory != "" && high.Cmp(resource.MustParse(rc.Memory)) >= 0 {
+				return xerrors.Errorf("memory high must be below the memory limit")
+			}
+		}
+		if rc.MemoryPressure.SwapMax != "" {
+			_, err := resource.ParseQuantity(rc.MemoryPressure.SwapMax)
+			if err != nil {
+				return xerrors.Errorf("cannot parse swap max quantity: %w", err)
+			}
+		}
+	}
 	return nil
 })
 
@@ -429,6 +854,29 @@ func (r *ResourceRequestConfiguration) ResourceList() (corev1.ResourceList, erro
 	return l, nil
 }
 
+// ApplyPodPatch applies a strategic merge patch to a workspace pod. Returns the pod unchanged if the patch is empty.
+func ApplyPodPatch(pod *corev1.Pod, patch []byte) (*corev1.Pod, error) {
+	if len(patch) == 0 {
+		return pod, nil
+	}
+
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot marshal pod: %w", err)
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, corev1.Pod{})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot apply pod patch: %w", err)
+	}
+
+	var res corev1.Pod
+	err = json.Unmarshal(patched, &res)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal patched pod: %w", err)
+	}
+	return &res, nil
+}
+
 // GetWorkspacePodTemplate parses a pod template YAML file. Returns nil if path is empty.
 func GetWorkspacePodTemplate(filename string) (*corev1.Pod, error) {
 	if filename == "" {
@@ -516,10 +964,13 @@ type ResourceRequestConfiguration struct {
 }
 
 type ResourceLimitConfiguration struct {
-	CPU              *CpuResourceLimit `json:"cpu"`
-	Memory           string            `json:"memory"`
-	EphemeralStorage string            `json:"ephemeral-storage"`
-	Storage          string            `json:"storage,omitempty"`
+	CPU              *CpuResourceLimit     `json:"cpu"`
+	Memory           string                `json:"memory"`
+	EphemeralStorage string                `json:"ephemeral-storage"`
+	Storage          string                `json:"storage,omitempty"`
+	IO               *IOResourceLimit      `json:"io,omitempty"`
+	Network          *NetworkResourceLimit `json:"network,omitempty"`
+	MemoryPressure   *MemoryPressureLimit  `json:"memoryPressure,omitempty"`
 }
 
 func (r *ResourceLimitConfiguration) ResourceList() (corev1.ResourceList, error) {
@@ -565,8 +1016,74 @@ func (r *ResourceLimitConfiguration) StorageQuantity() (resource.Quantity, error
 type CpuResourceLimit struct {
 	MinLimit   string `json:"min"`
 	BurstLimit string `json:"burst"`
+	// BurstBudget is how long workspaces may run at their burst limit before ws-daemon throttles them to their
+	// min limit, e.g. 5m. The budget refills while they use less than their min limit. Unset means no budget.
+	BurstBudget string `json:"burstBudget,omitempty"`
+}
+
+// IOResourceLimit configures the IO limits ws-daemon enforces on workspaces of a class. Unset values
+// fall back to the node-wide limits of ws-daemon.
+type IOResourceLimit struct {
+	ReadBandwidthPerSecond  string `json:"readBandwidthPerSecond,omitempty"`
+	WriteBandwidthPerSecond string `json:"writeBandwidthPerSecond,omitempty"`
+	ReadIOPS                int64  `json:"readIOPS,omitempty"`
+	WriteIOPS               int64  `json:"writeIOPS,omitempty"`
+}
+
+// NetworkResourceLimit configures the network limits ws-daemon enforces on workspaces of a class
+type NetworkResourceLimit struct {
+	EgressBandwidthPerSecond string `json:"egressBandwidthPerSecond,omitempty"`
+}
+
+// MemoryPressureLimit configures how ws-daemon lets workspaces of a class degrade before they hit their memory limit
+type MemoryPressureLimit struct {
+	// High is the cgroup memory.high limit above which the workspace is throttled and reclaimed, instead of OOM-killed
+	High string `json:"high,omitempty"`
+	// SwapMax is the cgroup memory.swap.max limit, i.e. how much of the workspace memory may be swapped out
+	SwapMax string `json:"swapMax,omitempty"`
 }
 
 type MaintenanceConfig struct {
+	// EnabledUntil enables maintenance mode immediately until the given time.
 	EnabledUntil *time.Time `json:"enabledUntil"`
+	// Windows schedules maintenance mode for upcoming periods of time.
+	Windows []MaintenanceWindow `json:"windows,omitempty"`
+	// Message is returned to users whose workspace start is refused during maintenance.
+	Message string `json:"message,omitempty"`
+}
+
+// MaintenanceWindow is a scheduled period of maintenance mode.
+type MaintenanceWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// DrainAt, if set, is the time within the window after which running workspaces are stopped.
+	DrainAt *time.Time `json:"drainAt,omitempty"`
+}
+
+// IsActive returns true if maintenance mode is enabled at the given time.
+func (c *MaintenanceConfig) IsActive(now time.Time) bool {
+	if c.EnabledUntil != nil && now.Before(*c.EnabledUntil) {
+		return true
+	}
+	for _, w := range c.Windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDraining returns true if a maintenance window is active at the given time
+// and its drain deadline has passed.
+func (c *MaintenanceConfig) IsDraining(now time.Time) bool {
+	for _, w := range c.Windows {
+		if w.contains(now) && w.DrainAt != nil && !now.Before(*w.DrainAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
 }
@@ -65,6 +65,260 @@ func TestValidate(t *testing.T) {
 			}),
 			Expectation: `workspace class name "not/a/valid/name" is invalid: [a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')]`,
 		},
+		{
+			Name: "ephemeral-storage limit lower than request",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Requests: &ResourceRequestConfiguration{EphemeralStorage: "10Gi"},
+						Limits:   &ResourceLimitConfiguration{CPU: &CpuResourceLimit{}, EphemeralStorage: "5Gi"},
+					},
+				}
+			}),
+			Expectation: `workspace class g1-standard: ephemeral-storage limit (5Gi) must not be lower than request (10Gi)`,
+		},
+		{
+			Name: "class IO limits",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Limits: &ResourceLimitConfiguration{CPU: &CpuResourceLimit{}, IO: &IOResourceLimit{ReadBandwidthPerSecond: "200Mi", WriteIOPS: 1000}},
+					},
+				}
+			}),
+		},
+		{
+			Name: "invalid class IO bandwidth",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Limits: &ResourceLimitConfiguration{CPU: &CpuResourceLimit{}, IO: &IOResourceLimit{WriteBandwidthPerSecond: "fast"}},
+					},
+				}
+			}),
+			Expectation: `workspace class g1-standard: limits: cannot parse IO write bandwidth quantity: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'.`,
+		},
+		{
+			Name: "invalid class egress bandwidth",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Limits: &ResourceLimitConfiguration{CPU: &CpuResourceLimit{}, Network: &NetworkResourceLimit{EgressBandwidthPerSecond: "fast"}},
+					},
+				}
+			}),
+			Expectation: `workspace class g1-standard: limits: cannot parse network egress bandwidth quantity: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'.`,
+		},
+		{
+			Name: "invalid class CPU burst budget",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Limits: &ResourceLimitConfiguration{CPU: &CpuResourceLimit{BurstLimit: "6", BurstBudget: "soon"}},
+					},
+				}
+			}),
+			Expectation: `workspace class g1-standard: limits: cannot parse CPU burst budget: time: invalid duration "soon".`,
+		},
+		{
+			Name: "valid class memory pressure limits",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Limits: &ResourceLimitConfiguration{CPU: &CpuResourceLimit{}, Memory: "8Gi", MemoryPressure: &MemoryPressureLimit{High: "7Gi", SwapMax: "4Gi"}},
+					},
+				}
+			}),
+		},
+		{
+			Name: "class memory high above memory limit",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Container: ContainerConfiguration{
+						Limits: &ResourceLimitConfiguration{CPU: &CpuResourceLimit{}, Memory: "8Gi", MemoryPressure: &MemoryPressureLimit{High: "8Gi"}},
+					},
+				}
+			}),
+			Expectation: `workspace class g1-standard: limits: memory high must be below the memory limit.`,
+		},
+		{
+			Name: "non-positive class timeout",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				zero := util.Duration(0)
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					Timeouts: &WorkspaceClassTimeoutConfiguration{MaxLifetime: &zero},
+				}
+			}),
+			Expectation: `workspace class g1-standard: timeout maxLifetime must be positive`,
+		},
+		{
+			Name: "invalid PVC size",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					PVC: &PVCConfiguration{Size: "lots"},
+				}
+			}),
+			Expectation: `workspace class g1-standard: cannot parse PVC size: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+		{
+			Name: "pod patch",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					PodPatch: []byte(`{"spec":{"containers":[{"name":"workspace","env":[{"name":"FOO","value":"bar"}]}]}}`),
+				}
+			}),
+		},
+		{
+			Name: "pod patch renaming the pod",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass] = &WorkspaceClass{
+					PodPatch: []byte(`{"metadata":{"name":"foobar"}}`),
+				}
+			}),
+			Expectation: `workspace class g1-standard: pod patch must not change the name or namespace of the pod`,
+		},
+		{
+			Name: "deprecated class",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses["g1-large"] = &WorkspaceClass{
+					Deprecation: &WorkspaceClassDeprecation{ReplacedBy: DefaultWorkspaceClass},
+				}
+			}),
+		},
+		{
+			Name: "deprecated default class",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses[DefaultWorkspaceClass].Deprecation = &WorkspaceClassDeprecation{}
+			}),
+			Expectation: `workspace class g1-standard: the default and preferred workspace class cannot be deprecated`,
+		},
+		{
+			Name: "deprecated class with unknown replacement",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.WorkspaceClasses["g1-large"] = &WorkspaceClass{
+					Deprecation: &WorkspaceClassDeprecation{ReplacedBy: "g1-huge"},
+				}
+			}),
+			Expectation: `workspace class g1-large: replacement class g1-huge does not exist`,
+		},
+		{
+			Name: "sub-second disk pressure eviction timeout",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.DiskPressureEvictionTimeout = util.Duration(500 * time.Millisecond)
+			}),
+			Expectation: `disk pressure eviction timeout must be at least 1s, got 500ms`,
+		},
+		{
+			Name: "negative disk pressure eviction timeout",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.DiskPressureEvictionTimeout = util.Duration(-time.Minute)
+			}),
+			Expectation: `disk pressure eviction timeout must be at least 1s, got -1m0s`,
+		},
+		{
+			Name: "negative max concurrent starts per node",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.MaxConcurrentStartsPerNode = -1
+			}),
+			Expectation: `max concurrent starts per node must not be negative, got -1`,
+		},
+		{
+			Name: "negative max workspaces",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.Capacity.MaxWorkspaces = -1
+			}),
+			Expectation: `max workspaces must not be negative, got -1`,
+		},
+		{
+			Name: "negative max workspaces per node",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.Capacity.MaxWorkspacesPerNode = -1
+			}),
+			Expectation: `max workspaces per node must not be negative, got -1`,
+		},
+		{
+			Name: "negative subscriber buffer size",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.SubscriberBufferSize = -1
+			}),
+			Expectation: `subscriber buffer size must not be negative, got -1`,
+		},
+		{
+			Name: "negative stopping soon warning",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.Timeouts.StoppingSoonWarning = util.Duration(-time.Minute)
+			}),
+			Expectation: `stopping soon warning must not be negative, got -1m0s`,
+		},
+		{
+			Name: "negative snapshot retention TTL",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.SnapshotRetention.TTL = util.Duration(-time.Hour)
+			}),
+			Expectation: `snapshot retention TTL must not be negative, got -1h0m0s`,
+		},
+		{
+			Name: "negative snapshot retention max per workspace",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.SnapshotRetention.MaxPerWorkspace = -1
+			}),
+			Expectation: `snapshot retention max per workspace must not be negative, got -1`,
+		},
+		{
+			Name: "invalid network policy CIDR",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.NetworkPolicy.ClusterCIDRs = []string{"10.0.0.0/8", "10.0.0.0"}
+			}),
+			Expectation: `network policy: invalid CIDR address: 10.0.0.0`,
+		},
+		{
+			Name: "IPv6 network policy CIDR",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.NetworkPolicy.BlockedCIDRs = []string{"fd00::/8"}
+			}),
+			Expectation: `network policy: fd00::/8 is not an IPv4 CIDR`,
+		},
+		{
+			Name: "negative orphan sweep interval",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.OrphanSweep.Interval = util.Duration(-time.Minute)
+			}),
+			Expectation: `orphan sweep interval must not be negative, got -1m0s`,
+		},
+		{
+			Name: "negative start retry attempts",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.StartRetry.MaxAttempts = -1
+			}),
+			Expectation: `start retry max attempts must not be negative, got -1`,
+		},
+		{
+			Name: "ephemeral storage quota",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.EphemeralStorageQuota = EphemeralStorageQuotaConfiguration{SoftLimit: 0.8, HardLimit: 0.95}
+			}),
+		},
+		{
+			Name: "ephemeral storage quota hard limit beyond eviction",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.EphemeralStorageQuota = EphemeralStorageQuotaConfiguration{HardLimit: 1}
+			}),
+			Expectation: `ephemeral storage quota limits must be between 0 and 1, got 0 and 1`,
+		},
+		{
+			Name: "ephemeral storage quota soft limit above hard limit",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.EphemeralStorageQuota = EphemeralStorageQuotaConfiguration{SoftLimit: 0.9, HardLimit: 0.8}
+			}),
+			Expectation: `ephemeral storage quota soft limit must be lower than the hard limit`,
+		},
+		{
+			Name: "image pull secret without name",
+			Cfg: fromValidConfig(func(c *Configuration) {
+				c.ImagePullSecret = &ImagePullSecretConfiguration{Namespace: "default"}
+			}),
+			Expectation: `image pull secret: name is required`,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
@@ -81,3 +335,127 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestKubernetesClientRateLimits(t *testing.T) {
+	tests := []struct {
+		Name     string
+		QPS      float32
+		Burst    int
+		ExpQPS   float32
+		ExpBurst int
+	}{
+		{Name: "defaults", ExpQPS: DefaultKubernetesClientQPS, ExpBurst: DefaultKubernetesClientBurst},
+		{Name: "qps only", QPS: 20, ExpQPS: 20, ExpBurst: DefaultKubernetesClientBurst},
+		{Name: "burst only", Burst: 30, ExpQPS: DefaultKubernetesClientQPS, ExpBurst: 30},
+		{Name: "both", QPS: 20, Burst: 30, ExpQPS: 20, ExpBurst: 30},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var cfg ServiceConfiguration
+			cfg.KubernetesClient.QPS = test.QPS
+			cfg.KubernetesClient.Burst = test.Burst
+
+			qps, burst := cfg.KubernetesClientRateLimits()
+			if qps != test.ExpQPS || burst != test.ExpBurst {
+				t.Errorf("unexpected rate limits: qps %v, burst %d, expected qps %v, burst %d", qps, burst, test.ExpQPS, test.ExpBurst)
+			}
+		})
+	}
+}
+
+func TestClassTimeouts(t *testing.T) {
+	maxLifetime := util.Duration(8 * time.Hour)
+	cfg := &Configuration{
+		Timeouts: WorkspaceTimeoutConfiguration{
+			RegularWorkspace: util.Duration(30 * time.Minute),
+			MaxLifetime:      util.Duration(36 * time.Hour),
+			AfterClose:       util.Duration(2 * time.Minute),
+		},
+		WorkspaceClasses: map[string]*WorkspaceClass{
+			DefaultWorkspaceClass: {},
+			"large": {
+				Timeouts: &WorkspaceClassTimeoutConfiguration{MaxLifetime: &maxLifetime},
+			},
+		},
+	}
+
+	if got := cfg.ClassTimeouts(DefaultWorkspaceClass); got != cfg.Timeouts {
+		t.Errorf("default class should use the manager timeouts, got %+v", got)
+	}
+	if got := cfg.ClassTimeouts("unknown"); got != cfg.Timeouts {
+		t.Errorf("unknown class should use the manager timeouts, got %+v", got)
+	}
+
+	got := cfg.ClassTimeouts("large")
+	if got.MaxLifetime != maxLifetime {
+		t.Errorf("expected class max lifetime %s, got %s", maxLifetime, got.MaxLifetime)
+	}
+	if got.RegularWorkspace != cfg.Timeouts.RegularWorkspace || got.AfterClose != cfg.Timeouts.AfterClose {
+		t.Errorf("unset class timeouts should fall back to the manager timeouts, got %+v", got)
+	}
+}
+
+func TestMaintenanceConfig(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		Name     string
+		Cfg      MaintenanceConfig
+		Active   bool
+		Draining bool
+	}{
+		{
+			Name: "empty",
+		},
+		{
+			Name:   "enabled until future",
+			Cfg:    MaintenanceConfig{EnabledUntil: at(time.Hour)},
+			Active: true,
+		},
+		{
+			Name: "enabled until past",
+			Cfg:  MaintenanceConfig{EnabledUntil: at(-time.Hour)},
+		},
+		{
+			Name: "upcoming window",
+			Cfg: MaintenanceConfig{Windows: []MaintenanceWindow{
+				{Start: *at(time.Hour), End: *at(2 * time.Hour), DrainAt: at(time.Hour)},
+			}},
+		},
+		{
+			Name: "active window before drain",
+			Cfg: MaintenanceConfig{Windows: []MaintenanceWindow{
+				{Start: *at(-time.Hour), End: *at(time.Hour), DrainAt: at(30 * time.Minute)},
+			}},
+			Active: true,
+		},
+		{
+			Name: "active window after drain",
+			Cfg: MaintenanceConfig{Windows: []MaintenanceWindow{
+				{Start: *at(-time.Hour), End: *at(time.Hour), DrainAt: at(-30 * time.Minute)},
+			}},
+			Active:   true,
+			Draining: true,
+		},
+		{
+			Name: "past window",
+			Cfg: MaintenanceConfig{Windows: []MaintenanceWindow{
+				{Start: *at(-2 * time.Hour), End: *at(-time.Hour), DrainAt: at(-2 * time.Hour)},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if act := test.Cfg.IsActive(now); act != test.Active {
+				t.Errorf("expected active %v, got %v", test.Active, act)
+			}
+			if act := test.Cfg.IsDraining(now); act != test.Draining {
+				t.Errorf("expected draining %v, got %v", test.Draining, act)
+			}
+		})
+	}
+}
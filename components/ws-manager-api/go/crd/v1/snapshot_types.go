@@ -6,6 +6,8 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
 )
 
 // SnapshotSpec defines the desired state of the snapshot
@@ -19,10 +21,19 @@ type SnapshotSpec struct {
 
 // SnapshotStatus defines the observed state of the snapshot
 type SnapshotStatus struct {
-	// // +kubebuilder:validation:Optional
-	// Conditions []metav1.Condition `json:"conditions"`
+	// ObservedGeneration is the generation of the snapshot the status was last computed for.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the state of the snapshot operation. The Ready condition is true once the snapshot
+	// was taken, and false with the reason of the failure if it could not be taken.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
-	// Erorr is the error observed during snapshot creation if any
+	// Erorr is the error observed during snapshot creation if any.
+	// Deprecated: kept for existing consumers, use the Ready condition instead.
 	// +kubebuilder:validation:Optional
 	Error string `json:"error,omitempty"`
 
@@ -30,9 +41,116 @@ type SnapshotStatus struct {
 	// +kubebuilder:validation:Optional
 	URL string `json:"url,omitempty"`
 
-	// Completed indicates if the snapshot operation has completed either by taking the snapshot or due to failure
+	// Completed indicates if the snapshot operation has completed either by taking the snapshot or due to failure.
+	// Deprecated: kept for existing consumers, use the Ready condition instead.
 	// +kubebuilder:validation:Required
 	Completed bool `json:"completed"`
+
+	// Phase is the current phase of the snapshot operation
+	// +kubebuilder:validation:Optional
+	Phase SnapshotPhase `json:"phase,omitempty"`
+
+	// Progress is the estimated completion of the snapshot operation in percent
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Progress int32 `json:"progress,omitempty"`
+
+	// Size is the size of the snapshot archive in bytes, once it is known
+	// +kubebuilder:validation:Optional
+	Size int64 `json:"size,omitempty"`
+
+	// ErrorDetail describes why the snapshot operation failed, if it did
+	// +kubebuilder:validation:Optional
+	ErrorDetail *SnapshotError `json:"errorDetail,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Pending;Archiving;Uploading;Completed;Failed
+type SnapshotPhase string
+
+const (
+	SnapshotPhasePending   SnapshotPhase = "Pending"
+	SnapshotPhaseArchiving SnapshotPhase = "Archiving"
+	SnapshotPhaseUploading SnapshotPhase = "Uploading"
+	SnapshotPhaseCompleted SnapshotPhase = "Completed"
+	SnapshotPhaseFailed    SnapshotPhase = "Failed"
+)
+
+// +kubebuilder:validation:Enum=WorkspaceUnavailable;ArchiveFailed;UploadFailed
+type SnapshotErrorReason string
+
+const (
+	// SnapshotErrorWorkspaceUnavailable means the workspace content could not be accessed on the node
+	SnapshotErrorWorkspaceUnavailable SnapshotErrorReason = "WorkspaceUnavailable"
+	// SnapshotErrorArchiveFailed means the workspace content could not be archived
+	SnapshotErrorArchiveFailed SnapshotErrorReason = "ArchiveFailed"
+	// SnapshotErrorUploadFailed means the snapshot archive could not be uploaded to remote storage
+	SnapshotErrorUploadFailed SnapshotErrorReason = "UploadFailed"
+)
+
+// SnapshotConditionReady is true once the snapshot was taken. While the snapshot is being taken its reason is
+// the phase of the operation, if the snapshot failed its reason is the SnapshotErrorReason.
+const SnapshotConditionReady = "Ready"
+
+// SetCondition adds or replaces the condition of the same type. The condition is stamped with the observed
+// generation of the status, and keeps its last transition time unless its status changed.
+func (s *SnapshotStatus) SetCondition(cond metav1.Condition) {
+	s.Conditions = setCondition(s.Conditions, cond, s.ObservedGeneration)
+}
+
+// UpdateReadyCondition derives the Ready condition from the phase and error of the snapshot.
+func (s *SnapshotStatus) UpdateReadyCondition() {
+	cond := metav1.Condition{
+		Type:   SnapshotConditionReady,
+		Status: metav1.ConditionFalse,
+		Reason: string(s.Phase),
+	}
+	switch {
+	case s.Phase == SnapshotPhaseCompleted:
+		cond.Status = metav1.ConditionTrue
+	case s.ErrorDetail != nil:
+		cond.Reason = string(s.ErrorDetail.Reason)
+		cond.Message = s.ErrorDetail.Message
+	case s.Error != "":
+		cond.Message = s.Error
+	}
+	s.SetCondition(cond)
+}
+
+// IsAvailable returns true if the snapshot was taken and can be restored
+func (s *Snapshot) IsAvailable() bool {
+	if s.DeletionTimestamp != nil || s.Status.URL == "" {
+		return false
+	}
+	for _, c := range s.Status.Conditions {
+		if c.Type == SnapshotConditionReady {
+			return c.Status == metav1.ConditionTrue
+		}
+	}
+	// snapshots taken before they had conditions
+	return s.Status.Completed && s.Status.Error == ""
+}
+
+// IsRestorableBy returns true if a workspace with the given ownership may restore the snapshot, i.e. if the snapshot
+// was taken by the same user, or of a workspace of the same organization. The ownership of a snapshot is recorded in
+// its owner and team labels.
+func (s *Snapshot) IsRestorableBy(o Ownership) bool {
+	if owner := s.Labels[wsk8s.OwnerLabel]; owner != "" && owner == o.Owner {
+		return true
+	}
+	if team := s.Labels[wsk8s.TeamLabel]; team != "" && team == o.Team {
+		return true
+	}
+	return false
+}
+
+// SnapshotError is a structured description of a failed snapshot operation
+type SnapshotError struct {
+	// +kubebuilder:validation:Required
+	Reason SnapshotErrorReason `json:"reason"`
+
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -44,6 +162,9 @@ type SnapshotStatus struct {
 //+kubebuilder:printcolumn:name="Workspace",type="string",JSONPath=".spec.workspaceID"
 //+kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url",priority=10
 //+kubebuilder:printcolumn:name="Completed",type="boolean",JSONPath=".status.completed"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",priority=10
+//+kubebuilder:printcolumn:name="Progress",type="integer",JSONPath=".status.progress",priority=10
 
 // Snapshot is the Schema for the snapshot API
 type Snapshot struct {
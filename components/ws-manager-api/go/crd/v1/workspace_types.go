@@ -9,6 +9,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -22,6 +23,63 @@ const (
 	// ReasonInitializationFailure is a Reason for the WorkspaceConditionContentReady condition,
 	// indicating that content init failed. The condition's message will contain the failure details.
 	ReasonInitializationFailure = "InitializationFailure"
+
+	// ReasonNodeDisappeared is a Reason for the WorkspaceConditionInterrupted condition,
+	// indicating that the workspace's node disappeared.
+	ReasonNodeDisappeared = "NodeDisappeared"
+	// ReasonEvicted is a Reason for the WorkspaceConditionInterrupted condition,
+	// indicating that the workspace's pod was evicted from its node.
+	ReasonEvicted = "Evicted"
+
+	// ReasonOOMKilled is a Reason for the WorkspaceConditionResourceExhausted condition, indicating
+	// that the workspace container was killed because it ran out of memory.
+	ReasonOOMKilled = "OOMKilled"
+	// ReasonEphemeralStorageExceeded is a Reason for the WorkspaceConditionResourceExhausted condition,
+	// indicating that the workspace pod was evicted because it used more ephemeral storage than its limit.
+	ReasonEphemeralStorageExceeded = "EphemeralStorageExceeded"
+
+	// ReasonEphemeralStorageSoftLimit is a Reason for the WorkspaceConditionEphemeralStorageWarning condition,
+	// indicating that the workspace uses more ephemeral storage than the soft limit.
+	ReasonEphemeralStorageSoftLimit = "EphemeralStorageSoftLimit"
+
+	// ReasonNodeNotReady is a Reason for the WorkspaceConditionInfrastructureFailure condition, indicating
+	// that the node of the workspace pod became NotReady before the workspace started.
+	ReasonNodeNotReady = "NodeNotReady"
+	// ReasonPodRejected is a Reason for the WorkspaceConditionInfrastructureFailure condition, indicating
+	// that the kubelet refused to admit the workspace pod, e.g. because the node ran out of resources.
+	ReasonPodRejected = "PodRejected"
+	// ReasonSandboxTimeout is a Reason for the WorkspaceConditionInfrastructureFailure condition, indicating
+	// that the sandbox of the workspace pod wasn't set up in time, e.g. because the CNI failed.
+	ReasonSandboxTimeout = "SandboxTimeout"
+	// ReasonImagePullTimeout is a Reason for the WorkspaceConditionInfrastructureFailure condition, indicating
+	// that pulling the workspace image from registry-facade timed out.
+	ReasonImagePullTimeout = "ImagePullTimeout"
+
+	// ReasonNodeStartLimit is a Reason for the WorkspaceConditionPending condition, indicating that the
+	// workspace waits for nodes to finish starting other workspaces.
+	ReasonNodeStartLimit = "NodeStartLimit"
+	// ReasonClusterAtCapacity is a Reason for the WorkspaceConditionPending condition, indicating that the
+	// workspace waits for others to stop because the cluster runs as many workspaces as it may.
+	ReasonClusterAtCapacity = "ClusterAtCapacity"
+
+	// ReasonInsufficientResources is a Reason for the WorkspaceConditionUnschedulable condition, indicating
+	// that no node has enough CPU, memory or storage left for the workspace.
+	ReasonInsufficientResources = "InsufficientResources"
+	// ReasonUntoleratedTaint is a Reason for the WorkspaceConditionUnschedulable condition, indicating
+	// that the nodes are tainted, e.g. reserved for other workloads or cordoned.
+	ReasonUntoleratedTaint = "UntoleratedTaint"
+	// ReasonNodeAffinityMismatch is a Reason for the WorkspaceConditionUnschedulable condition, indicating
+	// that no node matches the node affinity of the workspace, e.g. the node pool of its class.
+	ReasonNodeAffinityMismatch = "NodeAffinityMismatch"
+	// ReasonVolumeNodeAffinityConflict is a Reason for the WorkspaceConditionUnschedulable condition, indicating
+	// that the workspace volume can't be attached to any of the nodes, e.g. because it lives in another zone.
+	ReasonVolumeNodeAffinityConflict = "VolumeNodeAffinityConflict"
+	// ReasonUnschedulable is a Reason for the WorkspaceConditionUnschedulable condition, used if we can't tell
+	// why the workspace can't be scheduled.
+	ReasonUnschedulable = "Unschedulable"
+	// ReasonScheduled is a Reason for the WorkspaceConditionUnschedulable condition, indicating that
+	// the workspace pod was scheduled eventually.
+	ReasonScheduled = "Scheduled"
 )
 
 // WorkspaceSpec defines the desired state of Workspace
@@ -64,6 +122,16 @@ type WorkspaceSpec struct {
 	StorageQuota int `json:"storageQuota,omitempty"`
 
 	SSHGatewayCAPublicKey string `json:"sshGatewayCAPublicKey,omitempty"`
+
+	// Debug marks a debug workspace, e.g. one started to debug the image or configuration of another workspace.
+	// Debug workspaces are not stopped for inactivity.
+	// +kubebuilder:validation:Optional
+	Debug bool `json:"debug,omitempty"`
+
+	// RestoreSnapshot is the name of the Snapshot in the namespace of the workspace its content is restored from.
+	// The workspace pod is only created while the snapshot is available.
+	// +kubebuilder:validation:Optional
+	RestoreSnapshot string `json:"restoreSnapshot,omitempty"`
 }
 
 type Ownership struct {
@@ -152,6 +220,16 @@ type PortSpec struct {
 	Protocol PortProtocol `json:"protocol"`
 }
 
+// PortStatus describes a port the workspace exposes
+type PortStatus struct {
+	Port       uint32         `json:"port"`
+	Visibility AdmissionLevel `json:"visibility"`
+	Protocol   PortProtocol   `json:"protocol"`
+
+	// URL is the public URL the port is reachable at
+	URL string `json:"url"`
+}
+
 func (ps PortSpec) Equal(other PortSpec) bool {
 	if ps.Port != other.Port {
 		return false
@@ -177,7 +255,13 @@ type WorkspaceStatus struct {
 	// +kubebuilder:default=Unknown
 	Phase WorkspacePhase `json:"phase,omitempty"`
 
+	// ObservedGeneration is the generation of the workspace the status was last computed for.
 	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions"`
 
 	// Snapshot contains a snapshot URL if a snapshot was produced prior to shutting the workspace down. This condition is only used for headless workspaces.
@@ -192,11 +276,60 @@ type WorkspaceStatus struct {
 
 	Storage StorageStatus `json:"storage,omitempty"`
 
+	// Headless contains the outcome and artifacts of headless workspaces.
+	// +kubebuilder:validation:Optional
+	Headless *HeadlessStatus `json:"headless,omitempty"`
+
+	// Ports are the ports the workspace exposes, as requested in its spec, together with the URL they are reachable at.
+	// +kubebuilder:validation:Optional
+	Ports []PortStatus `json:"ports,omitempty"`
+
+	// BackupAttempts records the attempts to back up the workspace content once it stopped. A failed
+	// backup is retried until the retry budget is exhausted, only then the BackupFailure condition is set.
+	// +kubebuilder:validation:Optional
+	BackupAttempts []BackupAttempt `json:"backupAttempts,omitempty"`
+
+	// StartAttempts records the workspace pods which were recreated because their start failed for
+	// infrastructure reasons.
+	// +kubebuilder:validation:Optional
+	StartAttempts []StartAttempt `json:"startAttempts,omitempty"`
+
+	// PVC is set if the workspace content lives on a persistent volume claim instead of the node's disk.
+	// +kubebuilder:validation:Optional
+	PVC *PVCStatus `json:"pvc,omitempty"`
+
 	LastActivity *metav1.Time `json:"lastActivity,omitempty"`
+
+	// Timeouts are the timeouts in effect for this workspace, resolved from its spec, its class and the manager configuration.
+	// +kubebuilder:validation:Optional
+	Timeouts *TimeoutSpec `json:"timeouts,omitempty"`
+
+	// CPU is the CPU limit ws-daemon currently enforces on the workspace. It is only set if CPU limiting is enabled.
+	// +kubebuilder:validation:Optional
+	CPU *CPUStatus `json:"cpu,omitempty"`
+
+	// EphemeralStorage is the disk usage of the workspace container as reported by ws-daemon. It is only set if
+	// ws-daemon reports disk usage.
+	// +kubebuilder:validation:Optional
+	EphemeralStorage *EphemeralStorageStatus `json:"ephemeralStorage,omitempty"`
 }
 
+// SetCondition adds or replaces the condition of the same type. The condition is stamped with the observed
+// generation of the status, and keeps its last transition time unless its status changed.
 func (s *WorkspaceStatus) SetCondition(cond metav1.Condition) {
-	s.Conditions = wsk8s.AddUniqueCondition(s.Conditions, cond)
+	s.Conditions = setCondition(s.Conditions, cond, s.ObservedGeneration)
+}
+
+// setCondition adds or replaces a condition following the semantics of metav1.Condition.
+func setCondition(conds []metav1.Condition, cond metav1.Condition, observedGeneration int64) []metav1.Condition {
+	cond.ObservedGeneration = observedGeneration
+	if old := wsk8s.GetCondition(conds, cond.Type); old != nil && old.Status == cond.Status && !old.LastTransitionTime.IsZero() {
+		cond.LastTransitionTime = old.LastTransitionTime
+	}
+	if cond.LastTransitionTime.IsZero() {
+		cond.LastTransitionTime = metav1.Now()
+	}
+	return wsk8s.AddUniqueCondition(conds, cond)
 }
 
 type StorageStatus struct {
@@ -205,7 +338,83 @@ type StorageStatus struct {
 	MountPath      string `json:"mountPath"`
 }
 
-// +kubebuilder:validation:Enum=Deployed;Failed;Timeout;FirstUserActivity;Closed;HeadlessTaskFailed;StoppedByRequest;Aborted;ContentReady;EverReady;BackupComplete;BackupFailure;Refresh;NodeDisappeared;ThroughputAdjusted
+// HeadlessStatus describes the outcome of a headless workspace. The snapshot a prebuild produced is
+// published in the workspace's snapshot status.
+type HeadlessStatus struct {
+	// ExitCode is the exit code of the workspace container once the headless task completed
+	// +kubebuilder:validation:Optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// Logs maps the IDs of the prebuild tasks to the storage objects their logs were uploaded to
+	// +kubebuilder:validation:Optional
+	Logs map[string]string `json:"logs,omitempty"`
+}
+
+// BackupAttempt describes a single attempt to back up the workspace content
+type BackupAttempt struct {
+	// Time is when the attempt finished
+	Time metav1.Time `json:"time"`
+
+	// Error is the reason the attempt failed, empty if it succeeded
+	// +kubebuilder:validation:Optional
+	Error string `json:"error,omitempty"`
+}
+
+// StartAttempt describes a workspace pod which failed to start for infrastructure reasons and was recreated
+type StartAttempt struct {
+	// Time is when the pod was given up on
+	Time metav1.Time `json:"time"`
+
+	// Reason is the reason of the InfrastructureFailure condition of the attempt
+	Reason string `json:"reason"`
+
+	// Message explains why the attempt failed
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+
+	// NodeName is the node the pod was scheduled to, if any
+	// +kubebuilder:validation:Optional
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// CPUStatus describes the CPU the workspace requested in comparison to what ws-daemon allows it to use
+type CPUStatus struct {
+	// Requested is the CPU limit of the workspace class which the workspace is guaranteed to get
+	Requested resource.Quantity `json:"requested"`
+
+	// Allowed is the CPU limit currently enforced on the workspace. It exceeds the requested limit while the workspace bursts.
+	Allowed resource.Quantity `json:"allowed"`
+
+	// Throttled is true if the workspace ran into its CPU limit during the last control period
+	// +kubebuilder:validation:Optional
+	Throttled bool `json:"throttled,omitempty"`
+}
+
+// EphemeralStorageStatus describes how much of its ephemeral storage the workspace container uses
+type EphemeralStorageStatus struct {
+	// Used is the size of the files the workspace wrote to its container
+	Used resource.Quantity `json:"used"`
+
+	// Limit is the ephemeral storage limit of the workspace container, beyond which the kubelet evicts the workspace pod
+	// +kubebuilder:validation:Optional
+	Limit resource.Quantity `json:"limit,omitempty"`
+}
+
+// PVCStatus describes the persistent volume claim of a workspace and its volume snapshots
+type PVCStatus struct {
+	// ClaimName is the name of the workspace's persistent volume claim
+	ClaimName string `json:"claimName"`
+
+	// RestoredFrom is the name of the volume snapshot the volume was restored from, if any
+	// +kubebuilder:validation:Optional
+	RestoredFrom string `json:"restoredFrom,omitempty"`
+
+	// VolumeSnapshot is the name of the volume snapshot taken of the volume when the workspace stopped
+	// +kubebuilder:validation:Optional
+	VolumeSnapshot string `json:"volumeSnapshot,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Deployed;Failed;Timeout;FirstUserActivity;Closed;HeadlessTaskFailed;HeadlessTaskSucceeded;StoppedByRequest;Aborted;ContentReady;EverReady;BackupComplete;BackupFailure;Refresh;NodeDisappeared;Interrupted;Pending;Unschedulable;StoppingSoon;ThroughputAdjusted;Ready
 type WorkspaceCondition string
 
 const (
@@ -228,6 +437,9 @@ const (
 	// HeadlessTaskFailed indicates that a headless workspace task failed
 	WorkspaceConditionsHeadlessTaskFailed WorkspaceCondition = "HeadlessTaskFailed"
 
+	// HeadlessTaskSucceeded indicates that the task of a headless workspace completed successfully
+	WorkspaceConditionHeadlessTaskSucceeded WorkspaceCondition = "HeadlessTaskSucceeded"
+
 	// StoppedByRequest is true if the workspace was stopped using a StopWorkspace call.
 	// The condition message will contain the requested grace period.
 	WorkspaceConditionStoppedByRequest WorkspaceCondition = "StoppedByRequest"
@@ -238,6 +450,10 @@ const (
 	// ContentReady is true once the content initialisation is complete
 	WorkspaceConditionContentReady WorkspaceCondition = "ContentReady"
 
+	// ContentCorrupted is true if the content the workspace was restored from does not match the digest
+	// it was backed up with, e.g. because remote storage returned a truncated archive. Content init fails then.
+	WorkspaceConditionContentCorrupted WorkspaceCondition = "ContentCorrupted"
+
 	// EverReady is true if the workspace has ever been ready (content init
 	// succeeded and container is ready)
 	WorkspaceConditionEverReady WorkspaceCondition = "EverReady"
@@ -254,6 +470,38 @@ const (
 	// NodeDisappeared is true if the workspace's node disappeared before the workspace was stopped
 	WorkspaceConditionNodeDisappeared WorkspaceCondition = "NodeDisappeared"
 
+	// Interrupted is true if the workspace was stopped by its node disappearing or its pod being evicted.
+	// The condition message tells the user whether the workspace content could be saved.
+	WorkspaceConditionInterrupted WorkspaceCondition = "Interrupted"
+
+	// ResourceExhausted is true if the workspace ran out of memory or ephemeral storage. The condition reason
+	// tells which resource, the message suggests how the user can avoid it.
+	WorkspaceConditionResourceExhausted WorkspaceCondition = "ResourceExhausted"
+
+	// EphemeralStorageWarning is true while the workspace uses more ephemeral storage than the soft limit. The
+	// condition message tells the user how much they use. The workspace is stopped once it reaches the hard limit.
+	WorkspaceConditionEphemeralStorageWarning WorkspaceCondition = "EphemeralStorageWarning"
+
+	// InfrastructureFailure is true if the start of the workspace pod failed for reasons outside of the workspace.
+	// Such pods are recreated as long as start attempts are left, the condition is reset then.
+	WorkspaceConditionInfrastructureFailure WorkspaceCondition = "InfrastructureFailure"
+
+	// Pending is true while the start of the workspace is held back, e.g. because the nodes are starting
+	// too many workspaces already. The condition message explains what the workspace waits for.
+	WorkspaceConditionPending WorkspaceCondition = "Pending"
+
+	// StoppingSoon is true if the workspace is about to be stopped due to inactivity. The condition message
+	// tells the user when, any activity cancels the stop.
+	WorkspaceConditionStoppingSoon WorkspaceCondition = "StoppingSoon"
+
+	// Unschedulable is true while the workspace pod can't be scheduled onto any node. The condition reason
+	// tells why, the message explains it to the user.
+	WorkspaceConditionUnschedulable WorkspaceCondition = "Unschedulable"
+
+	// Ready is true while the workspace is running. Its reason is the phase of the workspace. This condition
+	// exists for standard tooling, e.g. `kubectl wait --for=condition=Ready`, and mirrors the workspace phase.
+	WorkspaceConditionReady WorkspaceCondition = "Ready"
+
 	VolumeAttachRequest WorkspaceCondition = "VolumeAttachRequest"
 	// VolumeAttached is true if the workspace's volume has been attached to the node
 	VolumeAttached WorkspaceCondition = "VolumeAttached"
@@ -270,6 +518,7 @@ func NewWorkspaceConditionDeployed() metav1.Condition {
 		Type:               string(WorkspaceConditionDeployed),
 		LastTransitionTime: metav1.Now(),
 		Status:             metav1.ConditionTrue,
+		Reason:             "PodCreated",
 	}
 }
 
@@ -278,15 +527,26 @@ func NewWorkspaceConditionHeadlessTaskFailed(message string) metav1.Condition {
 		Type:               string(WorkspaceConditionsHeadlessTaskFailed),
 		LastTransitionTime: metav1.Now(),
 		Status:             metav1.ConditionTrue,
+		Reason:             "TaskFailed",
 		Message:            message,
 	}
 }
 
+func NewWorkspaceConditionHeadlessTaskSucceeded() metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionHeadlessTaskSucceeded),
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionTrue,
+		Reason:             "TaskSucceeded",
+	}
+}
+
 func NewWorkspaceConditionFailed(message string) metav1.Condition {
 	return metav1.Condition{
 		Type:               string(WorkspaceConditionFailed),
 		LastTransitionTime: metav1.Now(),
 		Status:             metav1.ConditionTrue,
+		Reason:             "Failed",
 		Message:            message,
 	}
 }
@@ -348,11 +608,22 @@ func NewWorkspaceConditionContentReady(status metav1.ConditionStatus, reason, me
 	}
 }
 
+func NewWorkspaceConditionContentCorrupted(message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionContentCorrupted),
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionTrue,
+		Reason:             "DigestMismatch",
+		Message:            message,
+	}
+}
+
 func NewWorkspaceConditionEverReady() metav1.Condition {
 	return metav1.Condition{
 		Type:               string(WorkspaceConditionEverReady),
 		LastTransitionTime: metav1.Now(),
 		Status:             metav1.ConditionTrue,
+		Reason:             "BecameReady",
 	}
 }
 
@@ -380,6 +651,7 @@ func NewWorkspaceConditionRefresh() metav1.Condition {
 		Type:               string(WorkspaceConditionRefresh),
 		LastTransitionTime: metav1.Now(),
 		Status:             metav1.ConditionTrue,
+		Reason:             "RefreshRequested",
 	}
 }
 
@@ -388,14 +660,103 @@ func NewWorkspaceConditionNodeDisappeared() metav1.Condition {
 		Type:               string(WorkspaceConditionNodeDisappeared),
 		LastTransitionTime: metav1.Now(),
 		Status:             metav1.ConditionTrue,
+		Reason:             "NodeNotFound",
+	}
+}
+
+func NewWorkspaceConditionInterrupted(reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionInterrupted),
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionResourceExhausted(reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionResourceExhausted),
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionEphemeralStorageWarning(status metav1.ConditionStatus, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionEphemeralStorageWarning),
+		LastTransitionTime: metav1.Now(),
+		Status:             status,
+		Reason:             ReasonEphemeralStorageSoftLimit,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionInfrastructureFailure(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionInfrastructureFailure),
+		LastTransitionTime: metav1.Now(),
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionPending(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionPending),
+		LastTransitionTime: metav1.Now(),
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionStoppingSoon(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionStoppingSoon),
+		LastTransitionTime: metav1.Now(),
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionUnschedulable(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionUnschedulable),
+		LastTransitionTime: metav1.Now(),
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func NewWorkspaceConditionReady(phase WorkspacePhase) metav1.Condition {
+	status := metav1.ConditionFalse
+	if phase == WorkspacePhaseRunning {
+		status = metav1.ConditionTrue
+	}
+	return metav1.Condition{
+		Type:               string(WorkspaceConditionReady),
+		LastTransitionTime: metav1.Now(),
+		Status:             status,
+		Reason:             string(phase),
 	}
 }
 
 func NewWorkspaceConditionContainerRunning(status metav1.ConditionStatus) metav1.Condition {
+	reason := "ContainerRunning"
+	if status != metav1.ConditionTrue {
+		reason = "ContainerNotRunning"
+	}
 	return metav1.Condition{
 		Type:               string(WorkspaceConditionContainerRunning),
 		LastTransitionTime: metav1.Now(),
 		Status:             status,
+		Reason:             reason,
 	}
 }
 
@@ -452,6 +813,7 @@ type WorkspaceRuntimeStatus struct {
 //+kubebuilder:printcolumn:name="Owner",type="string",JSONPath=".spec.ownership.owner"
 //+kubebuilder:printcolumn:name="Team",type="string",JSONPath=".spec.ownership.team"
 //+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",priority=10
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Workspace is the Schema for the workspaces API
@@ -481,6 +843,11 @@ func (w *Workspace) IsHeadless() bool {
 	return w.Spec.Type != WorkspaceTypeRegular
 }
 
+// UsesPVC returns whether the workspace content lives on a persistent volume claim.
+func (w *Workspace) UsesPVC() bool {
+	return w.Status.PVC != nil
+}
+
 func (w *Workspace) IsConditionTrue(condition WorkspaceCondition) bool {
 	return wsk8s.ConditionPresentAndTrue(w.Status.Conditions, string(condition))
 }
@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package v1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkspaceConditionsHaveReason(t *testing.T) {
+	// The API server rejects conditions without a reason, hence every condition we set needs one.
+	conditions := []metav1.Condition{
+		NewWorkspaceConditionDeployed(),
+		NewWorkspaceConditionHeadlessTaskFailed("failed"),
+		NewWorkspaceConditionHeadlessTaskSucceeded(),
+		NewWorkspaceConditionFailed("failed"),
+		NewWorkspaceConditionTimeout("timed out"),
+		NewWorkspaceConditionStoppedByRequest("stopped"),
+		NewWorkspaceConditionContentCorrupted("corrupted"),
+		NewWorkspaceConditionEverReady(),
+		NewWorkspaceConditionBackupComplete(),
+		NewWorkspaceConditionBackupFailure("failed"),
+		NewWorkspaceConditionRefresh(),
+		NewWorkspaceConditionNodeDisappeared(),
+		NewWorkspaceConditionEphemeralStorageWarning(metav1.ConditionTrue, "filling up"),
+		NewWorkspaceConditionReady(WorkspacePhaseRunning),
+		NewWorkspaceConditionContainerRunning(metav1.ConditionTrue),
+		NewWorkspaceConditionContainerRunning(metav1.ConditionFalse),
+	}
+	for _, c := range conditions {
+		if c.Reason == "" {
+			t.Errorf("condition %s has no reason", c.Type)
+		}
+	}
+}
@@ -30,6 +30,56 @@ func (in *AdmissionSpec) DeepCopy() *AdmissionSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupAttempt) DeepCopyInto(out *BackupAttempt) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupAttempt.
+func (in *BackupAttempt) DeepCopy() *BackupAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CPUStatus) DeepCopyInto(out *CPUStatus) {
+	*out = *in
+	out.Requested = in.Requested.DeepCopy()
+	out.Allowed = in.Allowed.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUStatus.
+func (in *CPUStatus) DeepCopy() *CPUStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CPUStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EphemeralStorageStatus) DeepCopyInto(out *EphemeralStorageStatus) {
+	*out = *in
+	out.Used = in.Used.DeepCopy()
+	out.Limit = in.Limit.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EphemeralStorageStatus.
+func (in *EphemeralStorageStatus) DeepCopy() *EphemeralStorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EphemeralStorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitSpec) DeepCopyInto(out *GitSpec) {
 	*out = *in
@@ -75,6 +125,33 @@ func (in *GitStatus) DeepCopy() *GitStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeadlessStatus) DeepCopyInto(out *HeadlessStatus) {
+	*out = *in
+	if in.ExitCode != nil {
+		in, out := &in.ExitCode, &out.ExitCode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Logs != nil {
+		in, out := &in.Logs, &out.Logs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeadlessStatus.
+func (in *HeadlessStatus) DeepCopy() *HeadlessStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HeadlessStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IDEImages) DeepCopyInto(out *IDEImages) {
 	*out = *in
@@ -110,6 +187,21 @@ func (in *Ownership) DeepCopy() *Ownership {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCStatus) DeepCopyInto(out *PVCStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCStatus.
+func (in *PVCStatus) DeepCopy() *PVCStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PortSpec) DeepCopyInto(out *PortSpec) {
 	*out = *in
@@ -125,13 +217,28 @@ func (in *PortSpec) DeepCopy() *PortSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortStatus) DeepCopyInto(out *PortStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortStatus.
+func (in *PortStatus) DeepCopy() *PortStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PortStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Snapshot) DeepCopyInto(out *Snapshot) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Snapshot.
@@ -152,6 +259,21 @@ func (in *Snapshot) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotError) DeepCopyInto(out *SnapshotError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotError.
+func (in *SnapshotError) DeepCopy() *SnapshotError {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotList) DeepCopyInto(out *SnapshotList) {
 	*out = *in
@@ -202,6 +324,18 @@ func (in *SnapshotSpec) DeepCopy() *SnapshotSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotStatus) DeepCopyInto(out *SnapshotStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ErrorDetail != nil {
+		in, out := &in.ErrorDetail, &out.ErrorDetail
+		*out = new(SnapshotError)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotStatus.
@@ -214,6 +348,22 @@ func (in *SnapshotStatus) DeepCopy() *SnapshotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StartAttempt) DeepCopyInto(out *StartAttempt) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StartAttempt.
+func (in *StartAttempt) DeepCopy() *StartAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(StartAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageStatus) DeepCopyInto(out *StorageStatus) {
 	*out = *in
@@ -444,10 +594,54 @@ func (in *WorkspaceStatus) DeepCopyInto(out *WorkspaceStatus) {
 		**out = **in
 	}
 	out.Storage = in.Storage
+	if in.Headless != nil {
+		in, out := &in.Headless, &out.Headless
+		*out = new(HeadlessStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]PortStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupAttempts != nil {
+		in, out := &in.BackupAttempts, &out.BackupAttempts
+		*out = make([]BackupAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartAttempts != nil {
+		in, out := &in.StartAttempts, &out.StartAttempts
+		*out = make([]StartAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PVC != nil {
+		in, out := &in.PVC, &out.PVC
+		*out = new(PVCStatus)
+		**out = **in
+	}
 	if in.LastActivity != nil {
 		in, out := &in.LastActivity, &out.LastActivity
 		*out = (*in).DeepCopy()
 	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(TimeoutSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(CPUStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		*out = new(EphemeralStorageStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceStatus.
@@ -0,0 +1,388 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+// loadtest runs the workspace controllers against an envtest (or existing) cluster and drives thousands
+// of workspaces through their lifecycle, with the kubelet and ws-daemon replaced by stubs. It reports the
+// start/stop latency of the workspaces, the reconcile time of the controllers and the API requests they made,
+// such that controller changes can be benchmarked before they're released.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/controllers"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const secretsNamespace = "workspace-secrets"
+
+var (
+	workspaces     = flag.Int("workspaces", 1000, "number of workspaces to start")
+	concurrency    = flag.Int("concurrency", 100, "number of workspaces to run at the same time")
+	runtimeFlag    = flag.Duration("runtime", 10*time.Second, "how long each workspace keeps running before it's stopped")
+	initDuration   = flag.Duration("init-duration", 2*time.Second, "how long the fake ws-daemon takes to initialize the content of a workspace")
+	backupDuration = flag.Duration("backup-duration", 2*time.Second, "how long the fake ws-daemon takes to back up the content of a workspace")
+	namespace      = flag.String("namespace", "default", "namespace to create the workspaces in")
+	timeout        = flag.Duration("timeout", 5*time.Minute, "how long a single workspace may take to start or stop")
+	crdDir         = flag.String("crd-dir", filepath.Join("config", "crd", "bases"), "directory containing the workspace CRD, used when starting envtest")
+	useCluster     = flag.Bool("use-existing-cluster", false, "run against the cluster from the current kubeconfig instead of envtest. The workspace CRD must be installed, ws-manager-mk2 must not be running, and workspace pods must not be scheduled (e.g. kind with cordoned nodes).")
+	verbose        = flag.Bool("v", false, "print the controller logs")
+)
+
+func main() {
+	flag.Parse()
+	if *verbose {
+		ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		log.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		log.Fatal(err)
+	}
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{*crdDir},
+		ErrorIfCRDPathMissing: true,
+		UseExistingCluster:    useCluster,
+	}
+	restConfig, err := env.Start()
+	if err != nil {
+		log.Fatalf("cannot start test environment: %v", err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			log.Printf("cannot stop test environment: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Only the requests of the controllers are counted, the harness and the stubs use their own client.
+	requests := newRequestCounter()
+	controllerConfig := rest.CopyConfig(restConfig)
+	controllerConfig.QPS = 100
+	controllerConfig.Burst = 150
+	controllerConfig.Wrap(requests.wrap)
+
+	mgr, err := ctrl.NewManager(controllerConfig, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		log.Fatalf("cannot create manager: %v", err)
+	}
+	err = setupControllers(mgr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	harnessConfig := rest.CopyConfig(restConfig)
+	harnessConfig.QPS = 500
+	harnessConfig.Burst = 1000
+	harness, err := cluster.New(harnessConfig, func(o *cluster.Options) { o.Scheme = scheme })
+	if err != nil {
+		log.Fatalf("cannot create harness client: %v", err)
+	}
+
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			log.Fatalf("cannot start manager: %v", err)
+		}
+	}()
+	go func() {
+		if err := harness.Start(ctx); err != nil {
+			log.Fatalf("cannot start harness client: %v", err)
+		}
+	}()
+	if !harness.GetCache().WaitForCacheSync(ctx) {
+		log.Fatal("cannot sync harness cache")
+	}
+
+	err = harness.GetClient().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: secretsNamespace}})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Fatalf("cannot create secrets namespace: %v", err)
+	}
+
+	go runFakeKubelet(ctx, harness.GetClient(), *namespace)
+	go runFakeWorkspaceDaemon(ctx, harness.GetClient(), *namespace, *initDuration, *backupDuration)
+
+	res := run(ctx, harness.GetClient())
+	report(os.Stdout, res, requests)
+	if res.failures > 0 {
+		os.Exit(1)
+	}
+}
+
+func setupControllers(mgr ctrl.Manager) error {
+	err := controllers.SetupIndexer(mgr)
+	if err != nil {
+		return fmt.Errorf("cannot set up indexer: %w", err)
+	}
+
+	cfg := newConfig()
+	maintenance := &noMaintenance{}
+	wsReconciler, err := controllers.NewWorkspaceReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetEventRecorderFor("workspace"), &cfg, metrics.Registry, maintenance)
+	if err != nil {
+		return fmt.Errorf("cannot create workspace reconciler: %w", err)
+	}
+	if err := wsReconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("cannot set up workspace reconciler: %w", err)
+	}
+
+	timeoutReconciler, err := controllers.NewTimeoutReconciler(mgr.GetClient(), mgr.GetEventRecorderFor("workspace"), cfg, maintenance)
+	if err != nil {
+		return fmt.Errorf("cannot create timeout reconciler: %w", err)
+	}
+	if err := timeoutReconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("cannot set up timeout reconciler: %w", err)
+	}
+	return nil
+}
+
+func newConfig() config.Configuration {
+	return config.Configuration{
+		GitpodHostURL:     "gitpod.io",
+		HeartbeatInterval: util.Duration(30 * time.Second),
+		Namespace:         *namespace,
+		SecretsNamespace:  secretsNamespace,
+		SeccompProfile:    "default.json",
+		Timeouts: config.WorkspaceTimeoutConfiguration{
+			AfterClose:          util.Duration(1 * time.Minute),
+			Initialization:      util.Duration(30 * time.Minute),
+			TotalStartup:        util.Duration(45 * time.Minute),
+			RegularWorkspace:    util.Duration(60 * time.Minute),
+			MaxLifetime:         util.Duration(36 * time.Hour),
+			HeadlessWorkspace:   util.Duration(90 * time.Minute),
+			Stopping:            util.Duration(60 * time.Minute),
+			ContentFinalization: util.Duration(55 * time.Minute),
+			Interrupted:         util.Duration(5 * time.Minute),
+		},
+		WorkspaceClasses: map[string]*config.WorkspaceClass{
+			"default": {
+				Name: "default",
+			},
+		},
+		WorkspaceURLTemplate:     "{{ .ID }}-{{ .Prefix }}-{{ .Host }}",
+		WorkspacePortURLTemplate: "{{ .WorkspacePort }}-{{ .ID }}-{{ .Prefix }}-{{ .Host }}",
+	}
+}
+
+type noMaintenance struct{}
+
+func (noMaintenance) IsEnabled(context.Context) bool  { return false }
+func (noMaintenance) IsDraining(context.Context) bool { return false }
+func (noMaintenance) Message(context.Context) string  { return "" }
+
+type result struct {
+	mu       sync.Mutex
+	start    []time.Duration
+	stop     []time.Duration
+	failures int
+	duration time.Duration
+}
+
+// run starts all workspaces, keeping at most -concurrency of them alive at any time.
+func run(ctx context.Context, c client.Client) *result {
+	initializer, err := proto.Marshal(&csapi.WorkspaceInitializer{
+		Spec: &csapi.WorkspaceInitializer_Empty{Empty: &csapi.EmptyInitializer{}},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		res  result
+		wg   sync.WaitGroup
+		idx  = make(chan int)
+		done int
+	)
+	t0 := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				start, stop, err := runWorkspace(ctx, c, initializer)
+
+				res.mu.Lock()
+				if err != nil {
+					log.Printf("workspace %d failed: %v", i, err)
+					res.failures++
+				} else {
+					res.start = append(res.start, start)
+					res.stop = append(res.stop, stop)
+				}
+				done++
+				if done%100 == 0 {
+					log.Printf("%d/%d workspaces done", done, *workspaces)
+				}
+				res.mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < *workspaces; i++ {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+	res.duration = time.Since(t0)
+	return &res
+}
+
+// runWorkspace drives a single workspace through its lifecycle and returns the time it took to start and stop.
+func runWorkspace(ctx context.Context, c client.Client, initializer []byte) (start, stop time.Duration, err error) {
+	ws := newWorkspace(uuid.NewString(), *namespace, initializer)
+
+	t0 := time.Now()
+	if err := c.Create(ctx, ws); err != nil {
+		return 0, 0, fmt.Errorf("cannot create workspace: %w", err)
+	}
+	err = waitFor(ctx, c, ws, func(ws *workspacev1.Workspace) bool {
+		return ws.Status.Phase == workspacev1.WorkspacePhaseRunning
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("workspace did not start: %w", err)
+	}
+	start = time.Since(t0)
+
+	time.Sleep(*runtimeFlag)
+
+	t0 = time.Now()
+	err = retryOnConflict(ctx, c, ws, func(ws *workspacev1.Workspace) {
+		ws.Status.SetCondition(workspacev1.NewWorkspaceConditionStoppedByRequest("load test"))
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot stop workspace: %w", err)
+	}
+	err = waitFor(ctx, c, ws, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("workspace did not stop: %w", err)
+	}
+	stop = time.Since(t0)
+
+	return start, stop, nil
+}
+
+func newWorkspace(name, namespace string, initializer []byte) *workspacev1.Workspace {
+	return &workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Finalizers: []string{workspacev1.GitpodFinalizerName},
+			Labels: map[string]string{
+				wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
+			},
+		},
+		Spec: workspacev1.WorkspaceSpec{
+			Ownership: workspacev1.Ownership{
+				Owner:       "loadtest",
+				WorkspaceID: name,
+			},
+			Type:  workspacev1.WorkspaceTypeRegular,
+			Class: "default",
+			Image: workspacev1.WorkspaceImages{
+				Workspace: workspacev1.WorkspaceImage{
+					Ref: pointer.String("alpine:latest"),
+				},
+				IDE: workspacev1.IDEImages{
+					Refs: []string{},
+				},
+			},
+			Ports:       []workspacev1.PortSpec{},
+			Initializer: initializer,
+			Admission: workspacev1.AdmissionSpec{
+				Level: workspacev1.AdmissionLevelEveryone,
+			},
+		},
+	}
+}
+
+// waitFor polls the workspace until cond is true. A nil cond waits for the workspace to be deleted.
+func waitFor(ctx context.Context, c client.Client, ws *workspacev1.Workspace, cond func(ws *workspacev1.Workspace) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var act workspacev1.Workspace
+		err := c.Get(ctx, client.ObjectKeyFromObject(ws), &act)
+		switch {
+		case apierrors.IsNotFound(err) && cond == nil:
+			return nil
+		case err != nil && !apierrors.IsNotFound(err):
+			return err
+		case err == nil && cond != nil && cond(&act):
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (phase %s)", ctx.Err(), act.Status.Phase)
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryOnConflict fetches the workspace, applies mod to it and updates its status until there's no conflict.
+func retryOnConflict(ctx context.Context, c client.Client, ws *workspacev1.Workspace, mod func(ws *workspacev1.Workspace)) error {
+	for {
+		var act workspacev1.Workspace
+		if err := c.Get(ctx, client.ObjectKeyFromObject(ws), &act); err != nil {
+			return err
+		}
+		mod(&act)
+		err := c.Status().Update(ctx, &act)
+		if apierrors.IsConflict(err) {
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+}
+
+func percentiles(ds []time.Duration) (p50, p90, p99, max time.Duration) {
+	if len(ds) == 0 {
+		return
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	at := func(p float64) time.Duration {
+		return ds[int(float64(len(ds)-1)*p)]
+	}
+	return at(0.5), at(0.9), at(0.99), ds[len(ds)-1]
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// requestCounter counts the requests made to the API server by verb and resource.
+type requestCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newRequestCounter() *requestCounter {
+	return &requestCounter{counts: make(map[string]int)}
+}
+
+func (rc *requestCounter) wrap(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		key := verb(req) + " " + resource(req.URL.Path)
+		rc.mu.Lock()
+		rc.counts[key]++
+		rc.mu.Unlock()
+		return rt.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func verb(req *http.Request) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			return "watch"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return strings.ToLower(req.Method)
+	}
+}
+
+// resource extracts the resource, and subresource if any, from an API path such as
+// /apis/workspace.gitpod.io/v1/namespaces/default/workspaces/foo/status.
+func resource(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segs) > 2 && segs[0] == "api":
+		segs = segs[2:]
+	case len(segs) > 3 && segs[0] == "apis":
+		segs = segs[3:]
+	default:
+		return path
+	}
+	if len(segs) > 2 && segs[0] == "namespaces" {
+		segs = segs[2:]
+	}
+	if len(segs) > 2 {
+		return segs[0] + "/" + segs[2]
+	}
+	return segs[0]
+}
+
+func report(out io.Writer, res *result, requests *requestCounter) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "workspaces\t%d (%d failed) in %s\n", *workspaces, res.failures, res.duration.Round(time.Millisecond))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "latency\tp50\tp90\tp99\tmax")
+	for _, l := range []struct {
+		name string
+		ds   []time.Duration
+	}{
+		{"start", res.start},
+		{"stop", res.stop},
+	} {
+		p50, p90, p99, max := percentiles(l.ds)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", l.name, p50.Round(time.Millisecond), p90.Round(time.Millisecond), p99.Round(time.Millisecond), max.Round(time.Millisecond))
+	}
+	fmt.Fprintln(w)
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		fmt.Fprintf(w, "cannot gather controller metrics: %v\n", err)
+	}
+	fmt.Fprintln(w, "controller\treconciles\terrors\tmean\tp99")
+	for _, mf := range families {
+		if mf.GetName() != "controller_runtime_reconcile_time_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			ctrl := label(m, "controller")
+			h := m.GetHistogram()
+			var mean time.Duration
+			if h.GetSampleCount() > 0 {
+				mean = time.Duration(h.GetSampleSum() / float64(h.GetSampleCount()) * float64(time.Second))
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t<%s\n", ctrl, h.GetSampleCount(), reconcileErrors(families, ctrl), mean.Round(time.Microsecond), histogramQuantile(h, 0.99))
+		}
+	}
+	fmt.Fprintln(w)
+
+	requests.mu.Lock()
+	defer requests.mu.Unlock()
+	keys := make([]string, 0, len(requests.counts))
+	var total int
+	for k, c := range requests.counts {
+		keys = append(keys, k)
+		total += c
+	}
+	sort.Slice(keys, func(i, j int) bool { return requests.counts[keys[i]] > requests.counts[keys[j]] })
+	fmt.Fprintf(w, "api requests\t%d (%.1f per workspace)\n", total, float64(total)/float64(*workspaces))
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s\t%d\n", k, requests.counts[k])
+	}
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+func reconcileErrors(families []*dto.MetricFamily, ctrl string) int {
+	for _, mf := range families {
+		if mf.GetName() != "controller_runtime_reconcile_errors_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if label(m, "controller") == ctrl {
+				return int(m.GetCounter().GetValue())
+			}
+		}
+	}
+	return 0
+}
+
+// histogramQuantile returns the upper bound of the bucket containing the q-quantile.
+func histogramQuantile(h *dto.Histogram, q float64) time.Duration {
+	rank := uint64(q * float64(h.GetSampleCount()))
+	for _, b := range h.GetBucket() {
+		if b.GetCumulativeCount() >= rank {
+			return time.Duration(b.GetUpperBound() * float64(time.Second))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const stubInterval = 200 * time.Millisecond
+
+// runFakeKubelet marks all pending workspace pods as running, with a ready workspace container.
+func runFakeKubelet(ctx context.Context, c client.Client, namespace string) {
+	tick(ctx, func() {
+		var pods corev1.PodList
+		if err := c.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+			log.Printf("fake kubelet: cannot list pods: %v", err)
+			return
+		}
+
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.DeletionTimestamp != nil || (pod.Status.Phase != "" && pod.Status.Phase != corev1.PodPending) {
+				continue
+			}
+
+			now := metav1.Now()
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.StartTime = &now
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "workspace",
+				Ready: true,
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: now},
+				},
+			}}
+			err := c.Status().Update(ctx, pod)
+			if err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				log.Printf("fake kubelet: cannot update pod %s: %v", pod.Name, err)
+			}
+		}
+	})
+}
+
+// runFakeWorkspaceDaemon initializes the content of starting workspaces and backs up stopping ones, taking
+// initDuration and backupDuration respectively.
+func runFakeWorkspaceDaemon(ctx context.Context, c client.Client, namespace string, initDuration, backupDuration time.Duration) {
+	// seen tracks when the daemon first saw a workspace which needs content initialization or backup.
+	seen := make(map[types.UID]time.Time)
+	tick(ctx, func() {
+		var workspaces workspacev1.WorkspaceList
+		if err := c.List(ctx, &workspaces, client.InNamespace(namespace)); err != nil {
+			log.Printf("fake ws-daemon: cannot list workspaces: %v", err)
+			return
+		}
+
+		active := make(map[types.UID]time.Time, len(seen))
+		for i := range workspaces.Items {
+			ws := &workspaces.Items[i]
+
+			var (
+				cond     metav1.Condition
+				duration time.Duration
+			)
+			switch {
+			case (ws.Status.Phase == workspacev1.WorkspacePhaseCreating || ws.Status.Phase == workspacev1.WorkspacePhaseInitializing) &&
+				!ws.IsConditionTrue(workspacev1.WorkspaceConditionContentReady):
+				cond = workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionTrue, workspacev1.ReasonInitializationSuccess, "")
+				duration = initDuration
+			case ws.Status.Phase == workspacev1.WorkspacePhaseStopping &&
+				ws.IsConditionTrue(workspacev1.WorkspaceConditionContentReady) &&
+				!ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupComplete):
+				cond = workspacev1.NewWorkspaceConditionBackupComplete()
+				duration = backupDuration
+			default:
+				continue
+			}
+
+			t, ok := seen[ws.UID]
+			if !ok {
+				t = time.Now()
+			}
+			if time.Since(t) < duration {
+				active[ws.UID] = t
+				continue
+			}
+
+			ws.Status.SetCondition(cond)
+			err := c.Status().Update(ctx, ws)
+			if apierrors.IsConflict(err) {
+				// try again with the next tick
+				active[ws.UID] = t
+				continue
+			}
+			if err != nil && !apierrors.IsNotFound(err) {
+				log.Printf("fake ws-daemon: cannot update workspace %s: %v", ws.Name, err)
+			}
+		}
+		seen = active
+	})
+}
+
+func tick(ctx context.Context, f func()) {
+	ticker := time.NewTicker(stubInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f()
+		}
+	}
+}
@@ -38,9 +38,15 @@ const (
 	// workspaceDir is the path within all containers where workspaceVolume is mounted to
 	workspaceDir = "/workspace"
 
+	// gitpodUID is the user and group ID workspace containers run as
+	gitpodUID = 33333
+
 	// headlessLabel marks a workspace as headless
 	headlessLabel = "gitpod.io/headless"
 
+	// debugWorkspaceLabel marks the pods of debug workspaces
+	debugWorkspaceLabel = "gitpod.io/debugWorkspace"
+
 	// instanceIDLabel is added for the container dispatch mechanism in ws-daemon to work
 	// TODO(furisto): remove this label once we have moved ws-daemon to a controller setup
 	instanceIDLabel = "gitpod.io/instanceID"
@@ -62,6 +68,10 @@ type startWorkspaceContext struct {
 	IDEPort        int32             `json:"idePort"`
 	SupervisorPort int32             `json:"supervisorPort"`
 	Headless       bool              `json:"headless"`
+	// AvoidNodes are the nodes the workspace must not be scheduled to, as they start too many workspaces already
+	AvoidNodes []string
+	// ImagePullSecret is the name of the workspace's copy of the configured image pull secret, if there is one
+	ImagePullSecret string
 }
 
 // createWorkspacePod creates the actual workspace pod based on the definite workspace pod and appropriate
@@ -104,6 +114,11 @@ func (r *WorkspaceReconciler) createWorkspacePod(sctx *startWorkspaceContext) (*
 	if err != nil {
 		return nil, xerrors.Errorf("cannot create workspace pod: %w", err)
 	}
+
+	pod, err = config.ApplyPodPatch(pod, class.PodPatch)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot apply pod patch of workspace class %s - this is a configuration problem: %w", class.Name, err)
+	}
 	return pod, nil
 }
 
@@ -207,6 +222,7 @@ func mergeNodeAffinityMatchExpressions(dst, src reflect.Value) (err error) {
 	} else {
 		for _, term := range srcs {
 			dsts[0].MatchExpressions = append(dsts[0].MatchExpressions, term.MatchExpressions...)
+			dsts[0].MatchFields = append(dsts[0].MatchFields, term.MatchFields...)
 		}
 	}
 	dst.Set(reflect.ValueOf(dsts))
@@ -259,7 +275,11 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 	}
 
 	labels := make(map[string]string)
-	labels["gitpod.io/networkpolicy"] = "default"
+	if !sctx.Config.NetworkPolicy.Enabled {
+		// workspaces which get their own NetworkPolicy must not be selected by the namespace-wide one,
+		// as it would allow them to reach other workspaces
+		labels[defaultNetworkPolicyLabel] = "default"
+	}
 	for k, v := range sctx.Labels {
 		labels[k] = v
 	}
@@ -279,14 +299,21 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 		"prometheus.io/path":   "/metrics",
 		"prometheus.io/port":   strconv.Itoa(int(sctx.IDEPort)),
 		"container.apparmor.security.beta.kubernetes.io/workspace": "unconfined",
-		// prevent cluster-autoscaler from removing a node
-		// https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/FAQ.md#what-types-of-pods-can-prevent-ca-from-removing-a-node
-		"cluster-autoscaler.kubernetes.io/safe-to-evict": "false",
+		wsk8s.WorkspaceSSHPublicKeys:                               sshPublicKeysAnnotation(sctx.Workspace),
+	}
+	// the workspace controller removes these once the workspace is stopping
+	for k, v := range scaleDownProtectionAnnotations {
+		annotations[k] = v
 	}
 
 	for k, v := range sctx.Workspace.Annotations {
 		annotations[k] = v
 	}
+	if sctx.Workspace.Spec.Debug {
+		annotations[wsk8s.DebugWorkspaceAnnotation] = "true"
+	} else {
+		delete(annotations, wsk8s.DebugWorkspaceAnnotation)
+	}
 
 	// By default we embue our workspace pods with some tolerance towards pressure taints,
 	// see https://kubernetes.io/docs/concepts/configuration/taint-and-toleration/#taint-based-evictions
@@ -295,6 +322,13 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 	// we'd rather wait things out or gracefully fail the workspace ourselves.
 	var perssureToleranceSeconds int64 = 30
 
+	// Unless configured otherwise, disk pressure is tolerated indefinitely. Operators can bound this
+	// so that workspaces which fill up the node's disk get evicted eventually.
+	var diskPressureToleranceSeconds *int64
+	if timeout := time.Duration(sctx.Config.DiskPressureEvictionTimeout); timeout > 0 {
+		diskPressureToleranceSeconds = pointer.Int64(int64(timeout.Seconds()))
+	}
+
 	// Mounting /dev/net/tun should be fine security-wise, because:
 	//   - the TAP driver documentation says so (see https://www.kernel.org/doc/Documentation/networking/tuntap.txt)
 	//   - systemd's nspawn does the same thing (if it's good enough for them, it's good enough for us)
@@ -315,6 +349,33 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 		},
 	}
 
+	var (
+		initContainers []corev1.Container
+		fsGroup        *int64
+	)
+	if sctx.Workspace.UsesPVC() {
+		volumes = append(volumes, corev1.Volume{
+			Name: pvcVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: sctx.Workspace.Status.PVC.ClaimName,
+				},
+			},
+		})
+		contentContainer, err := createPVCContentContainer(workspaceContainer.Image)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot create workspace content container: %w", err)
+		}
+		initContainers = append(initContainers, *contentContainer)
+		// make the volume writable for the workspace user
+		fsGroup = pointer.Int64(gitpodUID)
+	}
+
+	if !sctx.Headless {
+		sshPublicKeysVolume, _ := createSSHPublicKeysVolume()
+		volumes = append(volumes, sshPublicKeysVolume)
+	}
+
 	if sctx.Config.EnableCustomSSLCertificate {
 		volumes = append(volumes, corev1.Volume{
 			Name: "gitpod-ca-crt",
@@ -346,12 +407,28 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 		},
 	}
 
+	class, ok := sctx.Config.WorkspaceClasses[sctx.Workspace.Spec.Class]
+	if !ok {
+		return nil, xerrors.Errorf("unknown workspace class: %s", sctx.Workspace.Spec.Class)
+	}
+	matchExpressions = append(matchExpressions, class.NodeAffinity...)
+
+	var matchFields []corev1.NodeSelectorRequirement
+	if len(sctx.AvoidNodes) > 0 {
+		matchFields = append(matchFields, corev1.NodeSelectorRequirement{
+			Key:      "metadata.name",
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   sctx.AvoidNodes,
+		})
+	}
+
 	affinity := &corev1.Affinity{
 		NodeAffinity: &corev1.NodeAffinity{
 			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
 				NodeSelectorTerms: []corev1.NodeSelectorTerm{
 					{
 						MatchExpressions: matchExpressions,
+						MatchFields:      matchFields,
 					},
 				},
 			},
@@ -380,7 +457,9 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 					Type:             corev1.SeccompProfileTypeLocalhost,
 					LocalhostProfile: pointer.String(sctx.Config.SeccompProfile),
 				},
+				FSGroup: fsGroup,
 			},
+			InitContainers: initContainers,
 			Containers: []corev1.Container{
 				*workspaceContainer,
 			},
@@ -389,10 +468,10 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 			TerminationGracePeriodSeconds: &graceSec,
 			Tolerations: []corev1.Toleration{
 				{
-					Key:      "node.kubernetes.io/disk-pressure",
-					Operator: "Exists",
-					Effect:   "NoExecute",
-					// Tolarate Indefinitely
+					Key:               "node.kubernetes.io/disk-pressure",
+					Operator:          "Exists",
+					Effect:            "NoExecute",
+					TolerationSeconds: diskPressureToleranceSeconds,
 				},
 				{
 					Key:      "node.kubernetes.io/memory-pressure",
@@ -409,6 +488,10 @@ func createDefiniteWorkspacePod(sctx *startWorkspaceContext) (*corev1.Pod, error
 			},
 		},
 	}
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, class.Tolerations...)
+	if sctx.ImagePullSecret != "" {
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: sctx.ImagePullSecret})
+	}
 
 	return &pod, nil
 }
@@ -460,13 +543,21 @@ func createWorkspaceContainer(sctx *startWorkspaceContext) (*corev1.Container, e
 
 	image := fmt.Sprintf("%s/%s/%s", sctx.Config.RegistryFacadeHost, regapi.ProviderPrefixRemote, sctx.Workspace.Name)
 
+	workspaceMount := corev1.VolumeMount{
+		Name:             workspaceVolumeName,
+		MountPath:        workspaceDir,
+		ReadOnly:         false,
+		MountPropagation: &mountPropagation,
+	}
+	if sctx.Workspace.UsesPVC() {
+		workspaceMount = corev1.VolumeMount{
+			Name:      pvcVolumeName,
+			MountPath: workspaceDir,
+		}
+	}
+
 	volumeMounts := []corev1.VolumeMount{
-		{
-			Name:             workspaceVolumeName,
-			MountPath:        workspaceDir,
-			ReadOnly:         false,
-			MountPropagation: &mountPropagation,
-		},
+		workspaceMount,
 		{
 			MountPath:        "/.workspace",
 			Name:             "daemon-mount",
@@ -474,6 +565,11 @@ func createWorkspaceContainer(sctx *startWorkspaceContext) (*corev1.Container, e
 		},
 	}
 
+	if !sctx.Headless {
+		_, sshPublicKeysMount := createSSHPublicKeysVolume()
+		volumeMounts = append(volumeMounts, sshPublicKeysMount)
+	}
+
 	if sctx.Config.EnableCustomSSLCertificate {
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "gitpod-ca-crt",
@@ -503,6 +599,41 @@ func createWorkspaceContainer(sctx *startWorkspaceContext) (*corev1.Container, e
 	}, nil
 }
 
+// createPVCContentContainer produces the init container which populates the persistent volume claim of a
+// workspace. ws-daemon initializes the workspace content on the node as for any other workspace, which we
+// copy onto the volume unless it was restored from a volume snapshot and hence already holds the content.
+func createPVCContentContainer(image string) (*corev1.Container, error) {
+	const contentDir = "/.workspace-content"
+
+	sec, err := createDefaultSecurityContext()
+	if err != nil {
+		return nil, err
+	}
+
+	script := fmt.Sprintf(`until [ -e %[1]s/.gitpod/ready ]; do sleep 1; done
+if [ -z "$(ls -A %[2]s)" ]; then cp -R %[1]s/. %[2]s/; fi`, contentDir, workspaceDir)
+
+	return &corev1.Container{
+		Name:            "workspace-content",
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		SecurityContext: sec,
+		Command:         []string{"/bin/sh", "-c", script},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      workspaceVolumeName,
+				MountPath: contentDir,
+				ReadOnly:  true,
+			},
+			{
+				Name:      pvcVolumeName,
+				MountPath: workspaceDir,
+			},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}, nil
+}
+
 func createWorkspaceEnvironment(sctx *startWorkspaceContext) ([]corev1.EnvVar, error) {
 	class, ok := sctx.Config.WorkspaceClasses[sctx.Workspace.Spec.Class]
 	if !ok {
@@ -556,6 +687,9 @@ func createWorkspaceEnvironment(sctx *startWorkspaceContext) ([]corev1.EnvVar, e
 	result = append(result, corev1.EnvVar{Name: "THEIA_MINI_BROWSER_HOST_PATTERN", Value: "browser-{{hostname}}"})
 
 	result = append(result, corev1.EnvVar{Name: "GITPOD_SSH_CA_PUBLIC_KEY", Value: sctx.Workspace.Spec.SSHGatewayCAPublicKey})
+	if len(sctx.Config.WorkspaceProxies) > 0 {
+		result = append(result, corev1.EnvVar{Name: "SUPERVISOR_TRUSTED_PROXIES", Value: strings.Join(sctx.Config.WorkspaceProxies, ",")})
+	}
 
 	// We don't require that Git be configured for workspaces
 	if sctx.Workspace.Spec.Git != nil {
@@ -652,7 +786,7 @@ func createWorkspaceVolumes(sctx *startWorkspaceContext) (workspace corev1.Volum
 }
 
 func createDefaultSecurityContext() (*corev1.SecurityContext, error) {
-	gitpodGUID := int64(33333)
+	gitpodGUID := int64(gitpodUID)
 
 	res := &corev1.SecurityContext{
 		AllowPrivilegeEscalation: pointer.Bool(false),
@@ -702,6 +836,7 @@ func newStartWorkspaceContext(ctx context.Context, cfg *config.Configuration, ws
 			wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
 			instanceIDLabel:               ws.Name,
 			headlessLabel:                 strconv.FormatBool(ws.IsHeadless()),
+			debugWorkspaceLabel:           strconv.FormatBool(ws.Spec.Debug),
 		},
 		Config:         cfg,
 		Workspace:      ws,
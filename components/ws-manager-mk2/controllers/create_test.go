@@ -6,11 +6,14 @@ package controllers
 
 import (
 	"testing"
+	"time"
 
+	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/ws-manager/api/config"
 	v1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
 )
 
 func TestCreateWorkspaceEnvironment(t *testing.T) {
@@ -103,3 +106,230 @@ func TestCreateWorkspaceEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateDefiniteWorkspacePodDiskPressureToleration(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Timeout     util.Duration
+		Expectation *int64
+	}{
+		{
+			Name:        "tolerate indefinitely by default",
+			Expectation: nil,
+		},
+		{
+			Name:        "configured eviction timeout",
+			Timeout:     util.Duration(10 * time.Minute),
+			Expectation: pointer.Int64(600),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sctx := &startWorkspaceContext{
+				Config: &config.Configuration{
+					WorkspaceClasses: map[string]*config.WorkspaceClass{
+						"default": {Name: "default"},
+					},
+					DiskPressureEvictionTimeout: test.Timeout,
+				},
+				Workspace: &v1.Workspace{
+					Spec: v1.WorkspaceSpec{
+						Class: "default",
+					},
+				},
+			}
+
+			pod, err := createDefiniteWorkspacePod(sctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var toleration *corev1.Toleration
+			for i, tol := range pod.Spec.Tolerations {
+				if tol.Key == "node.kubernetes.io/disk-pressure" {
+					toleration = &pod.Spec.Tolerations[i]
+					break
+				}
+			}
+			if toleration == nil {
+				t.Fatal("workspace pod does not tolerate disk pressure")
+			}
+			if diff := cmp.Diff(test.Expectation, toleration.TolerationSeconds); diff != "" {
+				t.Errorf("disk pressure toleration seconds mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreateDefiniteWorkspacePodClassScheduling(t *testing.T) {
+	gpuAffinity := corev1.NodeSelectorRequirement{Key: "gitpod.io/pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}}
+	gpuToleration := corev1.Toleration{Key: "gitpod.io/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+
+	sctx := &startWorkspaceContext{
+		Config: &config.Configuration{
+			WorkspaceClasses: map[string]*config.WorkspaceClass{
+				"default": {Name: "default"},
+				"gpu": {
+					Name:         "gpu",
+					NodeAffinity: []corev1.NodeSelectorRequirement{gpuAffinity},
+					Tolerations:  []corev1.Toleration{gpuToleration},
+				},
+			},
+		},
+		Workspace: &v1.Workspace{
+			Spec: v1.WorkspaceSpec{
+				Class: "gpu",
+			},
+		},
+	}
+
+	pod, err := createDefiniteWorkspacePod(sctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 {
+		t.Fatalf("expected a single node selector term, got %d", len(terms))
+	}
+	exprs := terms[0].MatchExpressions
+	if diff := cmp.Diff(gpuAffinity, exprs[len(exprs)-1]); diff != "" {
+		t.Errorf("class node affinity mismatch (-want +got):\n%s", diff)
+	}
+	tols := pod.Spec.Tolerations
+	if diff := cmp.Diff(gpuToleration, tols[len(tols)-1]); diff != "" {
+		t.Errorf("class toleration mismatch (-want +got):\n%s", diff)
+	}
+
+	sctx.Workspace.Spec.Class = "unknown"
+	if _, err := createDefiniteWorkspacePod(sctx); err == nil {
+		t.Errorf("expected an error for an unknown workspace class")
+	}
+}
+
+func TestCreateDefiniteWorkspacePodPVC(t *testing.T) {
+	tests := []struct {
+		Name           string
+		PVC            *v1.PVCStatus
+		WorkspaceMount string
+		InitContainers []string
+	}{
+		{
+			Name:           "without PVC",
+			WorkspaceMount: workspaceVolumeName,
+		},
+		{
+			Name:           "with PVC",
+			PVC:            &v1.PVCStatus{ClaimName: "foobar"},
+			WorkspaceMount: pvcVolumeName,
+			InitContainers: []string{"workspace-content"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sctx := &startWorkspaceContext{
+				Config: &config.Configuration{
+					WorkspaceClasses: map[string]*config.WorkspaceClass{
+						"default": {Name: "default"},
+					},
+				},
+				Workspace: &v1.Workspace{
+					Spec: v1.WorkspaceSpec{
+						Class: "default",
+					},
+					Status: v1.WorkspaceStatus{
+						PVC: test.PVC,
+					},
+				},
+			}
+
+			pod, err := createDefiniteWorkspacePod(sctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var workspaceMount string
+			for _, m := range pod.Spec.Containers[0].VolumeMounts {
+				if m.MountPath == workspaceDir {
+					workspaceMount = m.Name
+				}
+			}
+			if workspaceMount != test.WorkspaceMount {
+				t.Errorf("unexpected workspace mount: expected %s, got %s", test.WorkspaceMount, workspaceMount)
+			}
+
+			var initContainers []string
+			for _, c := range pod.Spec.InitContainers {
+				initContainers = append(initContainers, c.Name)
+			}
+			if diff := cmp.Diff(test.InitContainers, initContainers); diff != "" {
+				t.Errorf("init containers mismatch (-want +got):\n%s", diff)
+			}
+
+			if test.PVC != nil {
+				var claimName string
+				for _, v := range pod.Spec.Volumes {
+					if v.PersistentVolumeClaim != nil {
+						claimName = v.PersistentVolumeClaim.ClaimName
+					}
+				}
+				if claimName != test.PVC.ClaimName {
+					t.Errorf("unexpected claim name: expected %s, got %s", test.PVC.ClaimName, claimName)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateWorkspacePodClassPatch(t *testing.T) {
+	sctx := &startWorkspaceContext{
+		Config: &config.Configuration{
+			WorkspaceClasses: map[string]*config.WorkspaceClass{
+				"default": {
+					Name: "default",
+					PodPatch: []byte(`{"spec":{"containers":[
+						{"name":"workspace","env":[{"name":"FOO","value":"bar"}]},
+						{"name":"sidecar","image":"sidecar:latest"}
+					]}}`),
+				},
+			},
+		},
+		Workspace: &v1.Workspace{
+			Spec: v1.WorkspaceSpec{
+				Class: "default",
+			},
+		},
+	}
+
+	r := &WorkspaceReconciler{}
+	pod, err := r.createWorkspacePod(sctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	containers := make(map[string]corev1.Container)
+	for _, c := range pod.Spec.Containers {
+		containers[c.Name] = c
+	}
+	if _, ok := containers["sidecar"]; !ok {
+		t.Errorf("expected the sidecar to be added")
+	}
+	ws, ok := containers["workspace"]
+	if !ok {
+		t.Fatalf("expected the workspace container to remain")
+	}
+	var hasFoo bool
+	for _, e := range ws.Env {
+		if e.Name == "FOO" && e.Value == "bar" {
+			hasFoo = true
+		}
+	}
+	if len(ws.Env) < 2 || !hasFoo {
+		t.Errorf("expected FOO to be added to the existing environment, got %v", ws.Env)
+	}
+	if ws.Image == "" {
+		t.Errorf("expected the workspace container to keep its image")
+	}
+}
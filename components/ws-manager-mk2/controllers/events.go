@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// Reasons of the events recorded on workspaces for the steps of their lifecycle.
+const (
+	EventReasonPending                     = "Pending"
+	EventReasonInitializing                = "Initializing"
+	EventReasonRunning                     = "Running"
+	EventReasonStopping                    = "Stopping"
+	EventReasonStopped                     = "Stopped"
+	EventReasonUnschedulable               = "Unschedulable"
+	EventReasonContentInitialized          = "ContentInitialized"
+	EventReasonContentInitializationFailed = "ContentInitializationFailed"
+	EventReasonStoppingSoon                = "StoppingSoon"
+	EventReasonStoppedByRequest            = "StoppedByRequest"
+	EventReasonInterrupted                 = "Interrupted"
+	EventReasonAborted                     = "Aborted"
+	EventReasonHeadlessTaskSucceeded       = "HeadlessTaskSucceeded"
+	EventReasonHeadlessTaskFailed          = "HeadlessTaskFailed"
+	EventReasonBackupStarted               = "BackupStarted"
+	EventReasonBackupSucceeded             = "BackupSucceeded"
+	EventReasonBackupFailed                = "BackupFailed"
+)
+
+// Actors of the decisions recorded in the audit log
+const (
+	auditActorWorkspaceController = "workspace-controller"
+	auditActorTimeoutController   = "timeout-controller"
+)
+
+// auditedLifecycleEvents are the lifecycle steps which are recorded in the audit log, too
+var auditedLifecycleEvents = map[string]struct{}{
+	EventReasonContentInitializationFailed: {},
+	EventReasonStoppedByRequest:            {},
+	EventReasonInterrupted:                 {},
+	EventReasonAborted:                     {},
+	EventReasonBackupSucceeded:             {},
+	EventReasonBackupFailed:                {},
+	EventReasonStopped:                     {},
+}
+
+// lifecycleEvent is a step in the lifecycle of a workspace which we record an event for.
+type lifecycleEvent struct {
+	Reason    string
+	EventType string
+	// Reached returns true and the message of the event if the workspace went through this step.
+	Reached func(ws *workspacev1.Workspace) (message string, ok bool)
+}
+
+func phaseEvent(phase workspacev1.WorkspacePhase, reason string) lifecycleEvent {
+	return lifecycleEvent{
+		Reason:    reason,
+		EventType: corev1.EventTypeNormal,
+		Reached: func(ws *workspacev1.Workspace) (string, bool) {
+			return "", ws.Status.Phase == phase
+		},
+	}
+}
+
+func conditionEvent(tpe workspacev1.WorkspaceCondition, status metav1.ConditionStatus, conditionReason string, reason, eventType string) lifecycleEvent {
+	return lifecycleEvent{
+		Reason:    reason,
+		EventType: eventType,
+		Reached: func(ws *workspacev1.Workspace) (string, bool) {
+			c := wsk8s.GetCondition(ws.Status.Conditions, string(tpe))
+			if c == nil || c.Status != status || (conditionReason != "" && c.Reason != conditionReason) {
+				return "", false
+			}
+			return c.Message, true
+		},
+	}
+}
+
+// lifecycleEvents are in the order a workspace usually goes through them. Failures and timeouts are recorded
+// where we detect them.
+var lifecycleEvents = []lifecycleEvent{
+	phaseEvent(workspacev1.WorkspacePhasePending, EventReasonPending),
+	conditionEvent(workspacev1.WorkspaceConditionUnschedulable, metav1.ConditionTrue, "", EventReasonUnschedulable, corev1.EventTypeWarning),
+	phaseEvent(workspacev1.WorkspacePhaseInitializing, EventReasonInitializing),
+	conditionEvent(workspacev1.WorkspaceConditionContentReady, metav1.ConditionTrue, "", EventReasonContentInitialized, corev1.EventTypeNormal),
+	conditionEvent(workspacev1.WorkspaceConditionContentReady, metav1.ConditionFalse, workspacev1.ReasonInitializationFailure, EventReasonContentInitializationFailed, corev1.EventTypeWarning),
+	phaseEvent(workspacev1.WorkspacePhaseRunning, EventReasonRunning),
+	conditionEvent(workspacev1.WorkspaceConditionStoppingSoon, metav1.ConditionTrue, "", EventReasonStoppingSoon, corev1.EventTypeNormal),
+	conditionEvent(workspacev1.WorkspaceConditionStoppedByRequest, metav1.ConditionTrue, "", EventReasonStoppedByRequest, corev1.EventTypeNormal),
+	conditionEvent(workspacev1.WorkspaceConditionInterrupted, metav1.ConditionTrue, "", EventReasonInterrupted, corev1.EventTypeWarning),
+	conditionEvent(workspacev1.WorkspaceConditionAborted, metav1.ConditionTrue, "", EventReasonAborted, corev1.EventTypeWarning),
+	conditionEvent(workspacev1.WorkspaceConditionHeadlessTaskSucceeded, metav1.ConditionTrue, "", EventReasonHeadlessTaskSucceeded, corev1.EventTypeNormal),
+	conditionEvent(workspacev1.WorkspaceConditionsHeadlessTaskFailed, metav1.ConditionTrue, "", EventReasonHeadlessTaskFailed, corev1.EventTypeWarning),
+	phaseEvent(workspacev1.WorkspacePhaseStopping, EventReasonStopping),
+	{
+		Reason:    EventReasonBackupStarted,
+		EventType: corev1.EventTypeNormal,
+		Reached: func(ws *workspacev1.Workspace) (string, bool) {
+			return "", ws.Status.Phase == workspacev1.WorkspacePhaseStopping && !isDisposalFinished(ws)
+		},
+	},
+	conditionEvent(workspacev1.WorkspaceConditionBackupComplete, metav1.ConditionTrue, "", EventReasonBackupSucceeded, corev1.EventTypeNormal),
+	conditionEvent(workspacev1.WorkspaceConditionBackupFailure, metav1.ConditionTrue, "", EventReasonBackupFailed, corev1.EventTypeWarning),
+	phaseEvent(workspacev1.WorkspacePhaseStopped, EventReasonStopped),
+}
+
+// lifecycleEventRecorder records an event for every step of the lifecycle a workspace goes through, once.
+// Many steps are reported by other components, e.g. ws-daemon sets the ContentReady condition, which is why
+// we remember which steps we've seen rather than comparing the status before and after a reconcile.
+type lifecycleEventRecorder struct {
+	recorder record.EventRecorder
+	// seen maps the name of a workspace to the set of reasons we recorded events for
+	seen *lru.Cache
+}
+
+func newLifecycleEventRecorder(recorder record.EventRecorder) (*lifecycleEventRecorder, error) {
+	cache, err := lru.New(6000)
+	if err != nil {
+		return nil, err
+	}
+	return &lifecycleEventRecorder{recorder: recorder, seen: cache}, nil
+}
+
+// Record records events for the lifecycle steps the workspace went through since we last saw it. If we haven't
+// seen the workspace before, e.g. after a controller restart, the steps of its previous status are assumed to
+// be recorded already. Audited steps are recorded in the audit log, too.
+func (r *lifecycleEventRecorder) Record(ws *workspacev1.Workspace, old *workspacev1.WorkspaceStatus, auditLog *audit.Log) {
+	var seen map[string]struct{}
+	if s, ok := r.seen.Get(ws.Name); ok {
+		seen = s.(map[string]struct{})
+	} else {
+		seen = make(map[string]struct{})
+		prev := ws.DeepCopy()
+		prev.Status = *old
+		for _, e := range lifecycleEvents {
+			if _, ok := e.Reached(prev); ok {
+				seen[e.Reason] = struct{}{}
+			}
+		}
+	}
+
+	for _, e := range lifecycleEvents {
+		if _, ok := seen[e.Reason]; ok {
+			continue
+		}
+		msg, ok := e.Reached(ws)
+		if !ok {
+			continue
+		}
+		r.recorder.Event(ws, e.EventType, e.Reason, msg)
+		seen[e.Reason] = struct{}{}
+
+		if _, ok := auditedLifecycleEvents[e.Reason]; ok {
+			ev := audit.WorkspaceEvent(ws, audit.ActionLifecycle, auditActorWorkspaceController, e.Reason)
+			ev.Details = make(map[string]string)
+			if msg != "" {
+				ev.Details["message"] = msg
+			}
+			if e.Reason == EventReasonStopped {
+				ev.Details["stopReason"] = stopReason(ws)
+			}
+			auditLog.Record(ev)
+		}
+	}
+
+	if ws.Status.Phase == workspacev1.WorkspacePhaseStopped {
+		// no more events after this
+		r.seen.Remove(ws.Name)
+		return
+	}
+	r.seen.Add(ws.Name, seen)
+}
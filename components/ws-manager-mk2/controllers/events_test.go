@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestLifecycleEventRecorder(t *testing.T) {
+	type step struct {
+		Name   string
+		Update func(ws *workspacev1.Workspace)
+		Events []string
+		// Audited are the reasons of the lifecycle steps recorded in the audit log
+		Audited []string
+	}
+	tests := []struct {
+		Name    string
+		Initial func(ws *workspacev1.Workspace)
+		Steps   []step
+	}{
+		{
+			Name: "regular lifecycle",
+			Steps: []step{
+				{
+					Name:   "pending",
+					Update: func(ws *workspacev1.Workspace) { ws.Status.Phase = workspacev1.WorkspacePhasePending },
+					Events: []string{"Normal Pending "},
+				},
+				{
+					Name: "content ready while initializing",
+					Update: func(ws *workspacev1.Workspace) {
+						ws.Status.Phase = workspacev1.WorkspacePhaseInitializing
+						ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionTrue, workspacev1.ReasonInitializationSuccess, "restored from backup"))
+					},
+					Events: []string{"Normal Initializing ", "Normal ContentInitialized restored from backup"},
+				},
+				{
+					Name:   "no change",
+					Update: func(ws *workspacev1.Workspace) {},
+				},
+				{
+					Name:   "running",
+					Update: func(ws *workspacev1.Workspace) { ws.Status.Phase = workspacev1.WorkspacePhaseRunning },
+					Events: []string{"Normal Running "},
+				},
+				{
+					Name:   "stopping",
+					Update: func(ws *workspacev1.Workspace) { ws.Status.Phase = workspacev1.WorkspacePhaseStopping },
+					Events: []string{"Normal Stopping ", "Normal BackupStarted "},
+				},
+				{
+					Name: "backup failed",
+					Update: func(ws *workspacev1.Workspace) {
+						ws.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupFailure("out of space"))
+					},
+					Events:  []string{"Warning BackupFailed out of space"},
+					Audited: []string{"BackupFailed"},
+				},
+				{
+					Name:    "stopped",
+					Update:  func(ws *workspacev1.Workspace) { ws.Status.Phase = workspacev1.WorkspacePhaseStopped },
+					Events:  []string{"Normal Stopped "},
+					Audited: []string{"Stopped"},
+				},
+				{
+					Name:   "stopped again",
+					Update: func(ws *workspacev1.Workspace) {},
+				},
+			},
+		},
+		{
+			Name: "controller restart",
+			Initial: func(ws *workspacev1.Workspace) {
+				ws.Status.Phase = workspacev1.WorkspacePhaseRunning
+				ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionTrue, workspacev1.ReasonInitializationSuccess, ""))
+			},
+			Steps: []step{
+				{
+					Name:   "still running",
+					Update: func(ws *workspacev1.Workspace) {},
+				},
+				{
+					Name: "backup complete",
+					Update: func(ws *workspacev1.Workspace) {
+						ws.Status.Phase = workspacev1.WorkspacePhaseStopping
+						ws.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupComplete())
+					},
+					Events:  []string{"Normal Stopping ", "Normal BackupSucceeded "},
+					Audited: []string{"BackupSucceeded"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			fakeRecorder := record.NewFakeRecorder(100)
+			r, err := newLifecycleEventRecorder(fakeRecorder)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ws := &workspacev1.Workspace{}
+			ws.Name = "foobar"
+			if test.Initial != nil {
+				test.Initial(ws)
+			}
+
+			var auditBuf bytes.Buffer
+			auditLog := audit.NewLog(&auditBuf)
+
+			for _, s := range test.Steps {
+				old := ws.Status.DeepCopy()
+				s.Update(ws)
+				auditBuf.Reset()
+				r.Record(ws, old, auditLog)
+
+				var events []string
+				for len(fakeRecorder.Events) > 0 {
+					events = append(events, <-fakeRecorder.Events)
+				}
+				if diff := cmp.Diff(s.Events, events); diff != "" {
+					t.Errorf("%s: unexpected events (-want +got):\n%s", s.Name, diff)
+				}
+
+				var audited []string
+				dec := json.NewDecoder(&auditBuf)
+				for dec.More() {
+					var ev audit.Event
+					if err := dec.Decode(&ev); err != nil {
+						t.Fatalf("%s: cannot decode audit log: %v", s.Name, err)
+					}
+					audited = append(audited, ev.Reason)
+				}
+				if diff := cmp.Diff(s.Audited, audited); diff != "" {
+					t.Errorf("%s: unexpected audit log (-want +got):\n%s", s.Name, diff)
+				}
+			}
+		})
+	}
+}
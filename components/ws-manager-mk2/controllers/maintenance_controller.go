@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"time"
 
@@ -36,8 +37,8 @@ var (
 
 func NewMaintenanceReconciler(c client.Client, reg prometheus.Registerer) (*MaintenanceReconciler, error) {
 	r := &MaintenanceReconciler{
-		Client:       c,
-		enabledUntil: nil,
+		Client: c,
+		cfg:    nil,
 	}
 
 	gauge := newMaintenanceEnabledGauge(r)
@@ -49,10 +50,29 @@ func NewMaintenanceReconciler(c client.Client, reg prometheus.Registerer) (*Main
 type MaintenanceReconciler struct {
 	client.Client
 
-	enabledUntil *time.Time
+	mu  sync.RWMutex
+	cfg *config.MaintenanceConfig
 }
 
 func (r *MaintenanceReconciler) IsEnabled(ctx context.Context) bool {
+	cfg := r.config(ctx)
+	return cfg != nil && cfg.IsActive(time.Now())
+}
+
+func (r *MaintenanceReconciler) IsDraining(ctx context.Context) bool {
+	cfg := r.config(ctx)
+	return cfg != nil && cfg.IsDraining(time.Now())
+}
+
+func (r *MaintenanceReconciler) Message(ctx context.Context) string {
+	cfg := r.config(ctx)
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Message
+}
+
+func (r *MaintenanceReconciler) config(ctx context.Context) *config.MaintenanceConfig {
 	// On the first call, we load the maintenance mode state from the ConfigMap,
 	// as it's possible we haven't reconciled it yet.
 	lookupOnce.Do(func() {
@@ -61,7 +81,9 @@ func (r *MaintenanceReconciler) IsEnabled(ctx context.Context) bool {
 		}
 	})
 
-	return r.enabledUntil != nil && time.Now().Before(*r.enabledUntil)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cfg
 }
 
 //+kubebuilder:rbac:groups=core,resources=configmap,verbs=get;list;watch
@@ -82,7 +104,7 @@ func (r *MaintenanceReconciler) loadFromCM(ctx context.Context, key types.Namesp
 	if err := r.Get(ctx, key, &cm); err != nil {
 		if errors.IsNotFound(err) {
 			// ConfigMap does not exist, disable maintenance mode.
-			r.setEnabledUntil(ctx, nil)
+			r.setConfig(ctx, nil)
 			return nil
 		}
 
@@ -93,29 +115,36 @@ func (r *MaintenanceReconciler) loadFromCM(ctx context.Context, key types.Namesp
 	configJson, ok := cm.Data["config.json"]
 	if !ok {
 		log.Info("missing config.json, setting maintenance mode as disabled")
-		r.setEnabledUntil(ctx, nil)
+		r.setConfig(ctx, nil)
 		return nil
 	}
 
 	var cfg config.MaintenanceConfig
 	if err := json.Unmarshal([]byte(configJson), &cfg); err != nil {
 		log.Error(err, "failed to unmarshal maintenance config, setting maintenance mode as disabled")
-		r.setEnabledUntil(ctx, nil)
+		r.setConfig(ctx, nil)
 		return nil
 	}
 
-	r.setEnabledUntil(ctx, cfg.EnabledUntil)
+	r.setConfig(ctx, &cfg)
 	return nil
 }
 
-func (r *MaintenanceReconciler) setEnabledUntil(ctx context.Context, enabledUntil *time.Time) {
-	if enabledUntil == r.enabledUntil {
+func (r *MaintenanceReconciler) setConfig(ctx context.Context, cfg *config.MaintenanceConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reflect.DeepEqual(cfg, r.cfg) {
 		// Nothing to do.
 		return
 	}
 
-	r.enabledUntil = enabledUntil
-	log.FromContext(ctx).Info("maintenance mode state change", "enabledUntil", enabledUntil)
+	r.cfg = cfg
+	if cfg == nil {
+		log.FromContext(ctx).Info("maintenance mode state change", "enabledUntil", nil)
+		return
+	}
+	log.FromContext(ctx).Info("maintenance mode state change", "enabledUntil", cfg.EnabledUntil, "windows", cfg.Windows)
 }
 
 func (r *MaintenanceReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
@@ -18,6 +18,7 @@ import (
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -29,6 +30,9 @@ const (
 	workspaceCreatingSeconds      string = "workspace_creating_seconds"
 	workspaceStartFailuresTotal   string = "workspace_starts_failure_total"
 	workspaceFailuresTotal        string = "workspace_failure_total"
+	workspaceFailuresReasonTotal  string = "workspace_failure_reason_total"
+	workspaceResourceExhaustion   string = "workspace_resource_exhaustion_total"
+	workspacePhaseSeconds         string = "workspace_phase_duration_seconds"
 	workspaceStopsTotal           string = "workspace_stops_total"
 	workspaceBackupsTotal         string = "workspace_backups_total"
 	workspaceBackupFailuresTotal  string = "workspace_backups_failure_total"
@@ -36,8 +40,17 @@ const (
 	workspaceRestoresFailureTotal string = "workspace_restores_failure_total"
 	workspaceNodeUtilization      string = "workspace_node_utilization"
 	workspaceActivityTotal        string = "workspace_activity_total"
+	workspaceCPUThrottled         string = "workspace_cpu_throttled"
+	workspaceCPUBursting          string = "workspace_cpu_bursting"
+	workspaceDeprecatedClass      string = "workspace_deprecated_class_total"
+	workspacePendingAdmission     string = "workspace_pending_admission_total"
+	headlessCompletionsTotal      string = "workspace_headless_completions_total"
+	headlessRuntimeSeconds        string = "workspace_headless_runtime_seconds"
 )
 
+// metricsTypeDebug is the type label of debug workspaces
+const metricsTypeDebug = "Debug"
+
 type StopReason string
 
 const (
@@ -50,19 +63,59 @@ const (
 	StopReasonRegular      = "regular-stop"
 )
 
+// FailureReason is the cause of a workspace's failed condition.
+type FailureReason string
+
+const (
+	FailureReasonImagePull   FailureReason = "image-pull"
+	FailureReasonContentInit FailureReason = "content-init"
+	FailureReasonBackup      FailureReason = "backup"
+	FailureReasonInterrupted FailureReason = "interrupted"
+	FailureReasonExhausted   FailureReason = "resource-exhausted"
+	FailureReasonOther       FailureReason = "other"
+)
+
+// phaseDurationPhases are the phases whose duration we record. Stopped is final, and
+// Unknown tells nothing about the workspace.
+var phaseDurationPhases = map[workspacev1.WorkspacePhase]struct{}{
+	workspacev1.WorkspacePhasePending:      {},
+	workspacev1.WorkspacePhaseCreating:     {},
+	workspacev1.WorkspacePhaseInitializing: {},
+	workspacev1.WorkspacePhaseRunning:      {},
+	workspacev1.WorkspacePhaseStopping:     {},
+}
+
+// HeadlessOutcome is how the task of a headless workspace ended.
+type HeadlessOutcome string
+
+const (
+	HeadlessOutcomeSucceeded  HeadlessOutcome = "succeeded"
+	HeadlessOutcomeTaskFailed HeadlessOutcome = "task-failed"
+	HeadlessOutcomeFailed     HeadlessOutcome = "failed"
+	HeadlessOutcomeAborted    HeadlessOutcome = "aborted"
+	HeadlessOutcomeTimeout    HeadlessOutcome = "timeout"
+	HeadlessOutcomeStopped    HeadlessOutcome = "stopped"
+)
+
 type controllerMetrics struct {
 	startupTimeHistVec           *prometheus.HistogramVec
 	pendingTimeHistVec           *prometheus.HistogramVec
 	creatingTimeHistVec          *prometheus.HistogramVec
 	totalStartsFailureCounterVec *prometheus.CounterVec
 	totalFailuresCounterVec      *prometheus.CounterVec
+	failureReasonsCounterVec     *prometheus.CounterVec
+	resourceExhaustionCounterVec *prometheus.CounterVec
 	totalStopsCounterVec         *prometheus.CounterVec
+	phaseTimeHistVec             *prometheus.HistogramVec
 
 	totalBackupCounterVec         *prometheus.CounterVec
 	totalBackupFailureCounterVec  *prometheus.CounterVec
 	totalRestoreCounterVec        *prometheus.CounterVec
 	totalRestoreFailureCounterVec *prometheus.CounterVec
 
+	headlessCompletionsCounterVec *prometheus.CounterVec
+	headlessRuntimeHistVec        *prometheus.HistogramVec
+
 	workspacePhases *phaseTotalVec
 	timeoutSettings *timeoutSettingsVec
 
@@ -70,6 +123,12 @@ type controllerMetrics struct {
 
 	workspaceActivityTotal *workspaceActivityVec
 
+	workspaceCPU *cpuLimitVec
+
+	workspaceDeprecatedClasses *deprecatedClassVec
+
+	workspacePendingAdmission *pendingAdmissionVec
+
 	// used to prevent recording metrics multiple times
 	cache *lru.Cache
 }
@@ -114,6 +173,25 @@ func newControllerMetrics(r *WorkspaceReconciler) (*controllerMetrics, error) {
 			Name:      workspaceFailuresTotal,
 			Help:      "total number of workspaces that had a failed condition",
 		}, []string{"type", "class"}),
+		failureReasonsCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      workspaceFailuresReasonTotal,
+			Help:      "total number of workspaces that had a failed condition, by cause of the failure",
+		}, []string{"type", "class", "reason"}),
+		resourceExhaustionCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      workspaceResourceExhaustion,
+			Help:      "total number of workspaces that ran out of memory or ephemeral storage",
+		}, []string{"type", "class", "reason"}),
+		phaseTimeHistVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      workspacePhaseSeconds,
+			Help:      "time the workspace spent in a phase",
+			Buckets:   prometheus.ExponentialBuckets(2, 2, 16),
+		}, []string{"type", "class", "phase"}),
 		totalStopsCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: metricsNamespace,
 			Subsystem: metricsWorkspaceSubsystem,
@@ -146,17 +224,34 @@ func newControllerMetrics(r *WorkspaceReconciler) (*controllerMetrics, error) {
 			Help:      "total number of workspace restore failures",
 		}, []string{"type", "class"}),
 
-		workspacePhases:          newPhaseTotalVec(r),
-		timeoutSettings:          newTimeoutSettingsVec(r),
-		workspaceNodeUtilization: newNodeUtilizationVec(r),
-		workspaceActivityTotal:   newWorkspaceActivityVec(r),
-		cache:                    cache,
+		headlessCompletionsCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      headlessCompletionsTotal,
+			Help:      "total number of headless workspaces that completed, by outcome of their task",
+		}, []string{"type", "class", "outcome"}),
+		headlessRuntimeHistVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      headlessRuntimeSeconds,
+			Help:      "time from creation until a headless workspace stopped, by outcome of its task",
+			Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+		}, []string{"type", "class", "outcome"}),
+
+		workspacePhases:            newPhaseTotalVec(r),
+		timeoutSettings:            newTimeoutSettingsVec(r),
+		workspaceNodeUtilization:   newNodeUtilizationVec(r),
+		workspaceActivityTotal:     newWorkspaceActivityVec(r),
+		workspaceCPU:               newCPULimitVec(r),
+		workspaceDeprecatedClasses: newDeprecatedClassVec(r),
+		workspacePendingAdmission:  newPendingAdmissionVec(r),
+		cache:                      cache,
 	}, nil
 }
 
 func (m *controllerMetrics) recordWorkspaceStartupTime(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	hist, err := m.startupTimeHistVec.GetMetricWithLabelValues(tpe, class)
 	if err != nil {
@@ -169,7 +264,7 @@ func (m *controllerMetrics) recordWorkspaceStartupTime(log *logr.Logger, ws *wor
 
 func (m *controllerMetrics) recordWorkspacePendingTime(log *logr.Logger, ws *workspacev1.Workspace, pendingTs time.Time) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	hist, err := m.pendingTimeHistVec.GetMetricWithLabelValues(tpe, class)
 	if err != nil {
@@ -181,7 +276,7 @@ func (m *controllerMetrics) recordWorkspacePendingTime(log *logr.Logger, ws *wor
 
 func (m *controllerMetrics) recordWorkspaceCreatingTime(log *logr.Logger, ws *workspacev1.Workspace, creatingTs time.Time) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	hist, err := m.creatingTimeHistVec.GetMetricWithLabelValues(tpe, class)
 	if err != nil {
@@ -191,30 +286,98 @@ func (m *controllerMetrics) recordWorkspaceCreatingTime(log *logr.Logger, ws *wo
 	hist.Observe(time.Since(creatingTs).Seconds())
 }
 
+func (m *controllerMetrics) recordWorkspacePhaseTime(log *logr.Logger, ws *workspacev1.Workspace, phase workspacev1.WorkspacePhase, phaseTs time.Time) {
+	if _, ok := phaseDurationPhases[phase]; !ok {
+		return
+	}
+
+	class := ws.Spec.Class
+	tpe := metricsWorkspaceType(ws)
+
+	hist, err := m.phaseTimeHistVec.GetMetricWithLabelValues(tpe, class, string(phase))
+	if err != nil {
+		log.Error(err, "could not record workspace phase time", "type", tpe, "class", class, "phase", phase)
+		return
+	}
+
+	hist.Observe(time.Since(phaseTs).Seconds())
+}
+
 func (m *controllerMetrics) countWorkspaceStartFailures(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	m.totalStartsFailureCounterVec.WithLabelValues(tpe, class).Inc()
 }
 
 func (m *controllerMetrics) countWorkspaceFailure(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	m.totalFailuresCounterVec.WithLabelValues(tpe, class).Inc()
+	m.failureReasonsCounterVec.WithLabelValues(tpe, class, string(failureReason(ws))).Inc()
+}
+
+// metricsWorkspaceType is the type label of workspace metrics. Debug workspaces are reported separately,
+// such that they don't skew the metrics of the workspaces they debug.
+func metricsWorkspaceType(ws *workspacev1.Workspace) string {
+	if ws.Spec.Debug {
+		return metricsTypeDebug
+	}
+	return string(ws.Spec.Type)
+}
+
+// failureReason determines the cause of a workspace's failed condition, checking the causes in the
+// same order as extractFailure does.
+func failureReason(ws *workspacev1.Workspace) FailureReason {
+	switch {
+	case wsk8s.ConditionWithStatusAndReason(ws.Status.Conditions, string(workspacev1.WorkspaceConditionContentReady), false, workspacev1.ReasonInitializationFailure):
+		return FailureReasonContentInit
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionResourceExhausted):
+		return FailureReasonExhausted
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionInterrupted):
+		return FailureReasonInterrupted
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupFailure):
+		return FailureReasonBackup
+	}
+
+	if c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionFailed)); c != nil && strings.HasPrefix(c.Message, "cannot pull image") {
+		return FailureReasonImagePull
+	}
+	return FailureReasonOther
+}
+
+func (m *controllerMetrics) countResourceExhaustion(log *logr.Logger, ws *workspacev1.Workspace) {
+	c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionResourceExhausted))
+	if c == nil {
+		return
+	}
+
+	m.resourceExhaustionCounterVec.WithLabelValues(metricsWorkspaceType(ws), ws.Spec.Class, c.Reason).Inc()
 }
 
 func (m *controllerMetrics) countWorkspaceStop(log *logr.Logger, ws *workspacev1.Workspace) {
+	class := ws.Spec.Class
+	tpe := metricsWorkspaceType(ws)
+
+	m.totalStopsCounterVec.WithLabelValues(stopReason(ws), tpe, class).Inc()
+}
+
+// stopReason returns why the workspace stopped
+func stopReason(ws *workspacev1.Workspace) string {
 	var reason string
 	if c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionFailed)); c != nil {
 		reason = StopReasonFailed
 		if !ws.IsConditionTrue(workspacev1.WorkspaceConditionEverReady) {
 			// Don't record 'failed' if there was a start failure.
 			reason = StopReasonStartFailure
-		} else if strings.Contains(c.Message, "Pod ephemeral local storage usage exceeds the total limit of containers") {
+		} else if strings.Contains(c.Message, "Pod ephemeral local storage usage exceeds the total limit of containers") ||
+			wsk8s.ConditionWithStatusAndReason(ws.Status.Conditions, string(workspacev1.WorkspaceConditionResourceExhausted), true, workspacev1.ReasonEphemeralStorageExceeded) {
 			reason = StopReasonOutOfSpace
 		}
+	} else if wsk8s.ConditionWithStatusAndReason(ws.Status.Conditions, string(workspacev1.WorkspaceConditionResourceExhausted), true, workspacev1.ReasonEphemeralStorageExceeded) {
+		// stopped by us at the hard limit of its ephemeral storage
+		reason = StopReasonOutOfSpace
 	} else if ws.IsConditionTrue(workspacev1.WorkspaceConditionAborted) {
 		reason = StopReasonAborted
 	} else if ws.IsConditionTrue(workspacev1.WorkspaceConditionTimeout) {
@@ -224,37 +387,65 @@ func (m *controllerMetrics) countWorkspaceStop(log *logr.Logger, ws *workspacev1
 	} else {
 		reason = StopReasonRegular
 	}
+	return reason
+}
 
+func (m *controllerMetrics) countHeadlessCompletion(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
+	outcome := string(headlessOutcome(ws))
 
-	m.totalStopsCounterVec.WithLabelValues(reason, tpe, class).Inc()
+	m.headlessCompletionsCounterVec.WithLabelValues(tpe, class, outcome).Inc()
+
+	hist, err := m.headlessRuntimeHistVec.GetMetricWithLabelValues(tpe, class, outcome)
+	if err != nil {
+		log.Error(err, "could not record headless workspace runtime", "type", tpe, "class", class, "outcome", outcome)
+		return
+	}
+	hist.Observe(time.Since(ws.CreationTimestamp.Time).Seconds())
+}
+
+func headlessOutcome(ws *workspacev1.Workspace) HeadlessOutcome {
+	switch {
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionFailed):
+		return HeadlessOutcomeFailed
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionAborted):
+		return HeadlessOutcomeAborted
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionTimeout):
+		return HeadlessOutcomeTimeout
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionsHeadlessTaskFailed):
+		return HeadlessOutcomeTaskFailed
+	case ws.IsConditionTrue(workspacev1.WorkspaceConditionHeadlessTaskSucceeded):
+		return HeadlessOutcomeSucceeded
+	default:
+		return HeadlessOutcomeStopped
+	}
 }
 
 func (m *controllerMetrics) countTotalBackups(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	m.totalBackupCounterVec.WithLabelValues(tpe, class).Inc()
 }
 
 func (m *controllerMetrics) countTotalBackupFailures(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	m.totalBackupFailureCounterVec.WithLabelValues(tpe, class).Inc()
 }
 
 func (m *controllerMetrics) countTotalRestores(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	m.totalRestoreCounterVec.WithLabelValues(tpe, class).Inc()
 }
 
 func (m *controllerMetrics) countTotalRestoreFailures(log *logr.Logger, ws *workspacev1.Workspace) {
 	class := ws.Spec.Class
-	tpe := string(ws.Spec.Type)
+	tpe := metricsWorkspaceType(ws)
 
 	m.totalRestoreFailureCounterVec.WithLabelValues(tpe, class).Inc()
 }
@@ -280,6 +471,7 @@ func (m *controllerMetrics) forgetWorkspace(ws *workspacev1.Workspace) {
 // metricState is used to track which metrics have been recorded for a workspace.
 type metricState struct {
 	phase                   workspacev1.WorkspacePhase
+	phaseStartTime          time.Time
 	pendingStartTime        time.Time
 	creatingStartTime       time.Time
 	recordedStartTime       bool
@@ -289,11 +481,22 @@ type metricState struct {
 	recordedContentReady    bool
 	recordedBackupFailed    bool
 	recordedBackupCompleted bool
+	recordedExhaustion      bool
 }
 
 func newMetricState(ws *workspacev1.Workspace) metricState {
+	// A workspace without a phase is a new one, which is pending since it was created. For any other
+	// phase we can't know when it began, e.g. after a controller restart, and don't record its duration.
+	phase := ws.Status.Phase
+	var phaseStartTime time.Time
+	if phase == "" || phase == workspacev1.WorkspacePhasePending {
+		phase = workspacev1.WorkspacePhasePending
+		phaseStartTime = ws.CreationTimestamp.Time
+	}
+
 	return metricState{
-		phase: ws.Status.Phase,
+		phase:          phase,
+		phaseStartTime: phaseStartTime,
 		// Here we assume that we've recorded metrics for the following states already if their conditions already exist.
 		// This is to prevent these from being re-recorded after the controller restarts and clears the metric state for
 		// each workspace.
@@ -304,6 +507,7 @@ func newMetricState(ws *workspacev1.Workspace) metricState {
 		recordedContentReady:    ws.IsConditionTrue(workspacev1.WorkspaceConditionContentReady),
 		recordedBackupFailed:    ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupFailure),
 		recordedBackupCompleted: ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupComplete),
+		recordedExhaustion:      ws.IsConditionTrue(workspacev1.WorkspaceConditionResourceExhausted),
 	}
 }
 
@@ -322,19 +526,28 @@ func (m *controllerMetrics) Describe(ch chan<- *prometheus.Desc) {
 	m.startupTimeHistVec.Describe(ch)
 	m.pendingTimeHistVec.Describe(ch)
 	m.creatingTimeHistVec.Describe(ch)
+	m.phaseTimeHistVec.Describe(ch)
 	m.totalStopsCounterVec.Describe(ch)
 	m.totalStartsFailureCounterVec.Describe(ch)
 	m.totalFailuresCounterVec.Describe(ch)
+	m.failureReasonsCounterVec.Describe(ch)
+	m.resourceExhaustionCounterVec.Describe(ch)
 
 	m.totalBackupCounterVec.Describe(ch)
 	m.totalBackupFailureCounterVec.Describe(ch)
 	m.totalRestoreCounterVec.Describe(ch)
 	m.totalRestoreFailureCounterVec.Describe(ch)
 
+	m.headlessCompletionsCounterVec.Describe(ch)
+	m.headlessRuntimeHistVec.Describe(ch)
+
 	m.workspacePhases.Describe(ch)
 	m.timeoutSettings.Describe(ch)
 	m.workspaceNodeUtilization.Describe(ch)
 	m.workspaceActivityTotal.Describe(ch)
+	m.workspaceCPU.Describe(ch)
+	m.workspaceDeprecatedClasses.Describe(ch)
+	m.workspacePendingAdmission.Describe(ch)
 }
 
 // Collect implements Collector.
@@ -342,19 +555,28 @@ func (m *controllerMetrics) Collect(ch chan<- prometheus.Metric) {
 	m.startupTimeHistVec.Collect(ch)
 	m.pendingTimeHistVec.Collect(ch)
 	m.creatingTimeHistVec.Collect(ch)
+	m.phaseTimeHistVec.Collect(ch)
 	m.totalStopsCounterVec.Collect(ch)
 	m.totalStartsFailureCounterVec.Collect(ch)
 	m.totalFailuresCounterVec.Collect(ch)
+	m.failureReasonsCounterVec.Collect(ch)
+	m.resourceExhaustionCounterVec.Collect(ch)
 
 	m.totalBackupCounterVec.Collect(ch)
 	m.totalBackupFailureCounterVec.Collect(ch)
 	m.totalRestoreCounterVec.Collect(ch)
 	m.totalRestoreFailureCounterVec.Collect(ch)
 
+	m.headlessCompletionsCounterVec.Collect(ch)
+	m.headlessRuntimeHistVec.Collect(ch)
+
 	m.workspacePhases.Collect(ch)
 	m.timeoutSettings.Collect(ch)
 	m.workspaceNodeUtilization.Collect(ch)
 	m.workspaceActivityTotal.Collect(ch)
+	m.workspaceCPU.Collect(ch)
+	m.workspaceDeprecatedClasses.Collect(ch)
+	m.workspacePendingAdmission.Collect(ch)
 }
 
 // phaseTotalVec returns a gauge vector counting the workspaces per phase
@@ -391,7 +613,7 @@ func (ptv *phaseTotalVec) Collect(ch chan<- prometheus.Metric) {
 
 	counts := make(map[string]int)
 	for _, ws := range workspaces.Items {
-		counts[string(ws.Spec.Type)+"::"+string(ws.Status.Phase)+"::"+ws.Spec.Class]++
+		counts[metricsWorkspaceType(&ws)+"::"+string(ws.Status.Phase)+"::"+ws.Spec.Class]++
 	}
 
 	for key, count := range counts {
@@ -679,7 +901,7 @@ func (wav *workspaceActivityVec) getWorkspaceActivityCounts() (active, notActive
 	}
 
 	for _, ws := range workspaces.Items {
-		if ws.Spec.Type != workspacev1.WorkspaceTypeRegular {
+		if ws.Spec.Type != workspacev1.WorkspaceTypeRegular || ws.Spec.Debug {
 			continue
 		}
 
@@ -693,3 +915,206 @@ func (wav *workspaceActivityVec) getWorkspaceActivityCounts() (active, notActive
 
 	return
 }
+
+// cpuLimitVec counts the workspaces per class whose CPU ws-daemon throttled, or which currently burst beyond
+// their requested CPU limit, as reported in their CPU status.
+type cpuLimitVec struct {
+	throttledDesc *prometheus.Desc
+	burstingDesc  *prometheus.Desc
+	reconciler    *WorkspaceReconciler
+}
+
+func newCPULimitVec(r *WorkspaceReconciler) *cpuLimitVec {
+	return &cpuLimitVec{
+		throttledDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsWorkspaceSubsystem, workspaceCPUThrottled),
+			"number of workspaces which ran into their CPU limit during the last control period",
+			[]string{"class"},
+			prometheus.Labels(map[string]string{}),
+		),
+		burstingDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsWorkspaceSubsystem, workspaceCPUBursting),
+			"number of workspaces which are allowed more CPU than they requested",
+			[]string{"class"},
+			prometheus.Labels(map[string]string{}),
+		),
+		reconciler: r,
+	}
+}
+
+// Describe implements Collector. It will send exactly two Descs to the provided channel.
+func (clv *cpuLimitVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clv.throttledDesc
+	ch <- clv.burstingDesc
+}
+
+// Collect implements Collector.
+func (clv *cpuLimitVec) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesOperationTimeout)
+	defer cancel()
+
+	var workspaces workspacev1.WorkspaceList
+	err := clv.reconciler.List(ctx, &workspaces, client.InNamespace(clv.reconciler.Config.Namespace))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "cannot list workspaces for CPU limit metrics")
+		return
+	}
+
+	throttled := make(map[string]int)
+	bursting := make(map[string]int)
+	for _, ws := range workspaces.Items {
+		cpu := ws.Status.CPU
+		if cpu == nil || ws.Status.Phase != workspacev1.WorkspacePhaseRunning {
+			continue
+		}
+
+		// make sure every class with CPU limits reports a value, even if zero
+		throttled[ws.Spec.Class] += 0
+		bursting[ws.Spec.Class] += 0
+		if cpu.Throttled {
+			throttled[ws.Spec.Class]++
+		}
+		if cpu.Allowed.Cmp(cpu.Requested) > 0 {
+			bursting[ws.Spec.Class]++
+		}
+	}
+
+	for class, count := range throttled {
+		metric, err := prometheus.NewConstMetric(clv.throttledDesc, prometheus.GaugeValue, float64(count), class)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "cannot create workspace CPU throttled metric", "class", class)
+			continue
+		}
+		ch <- metric
+	}
+	for class, count := range bursting {
+		metric, err := prometheus.NewConstMetric(clv.burstingDesc, prometheus.GaugeValue, float64(count), class)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "cannot create workspace CPU bursting metric", "class", class)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// deprecatedClassVec counts the workspaces which still use a deprecated workspace class, such that we know
+// when a class migration is done.
+type deprecatedClassVec struct {
+	desc       *prometheus.Desc
+	reconciler *WorkspaceReconciler
+}
+
+func newDeprecatedClassVec(r *WorkspaceReconciler) *deprecatedClassVec {
+	return &deprecatedClassVec{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsWorkspaceSubsystem, workspaceDeprecatedClass),
+			"number of workspaces using a deprecated workspace class",
+			[]string{"class", "replaced_by"},
+			prometheus.Labels(map[string]string{}),
+		),
+		reconciler: r,
+	}
+}
+
+// Describe implements Collector. It will send exactly one Desc to the provided channel.
+func (dcv *deprecatedClassVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dcv.desc
+}
+
+// Collect implements Collector.
+func (dcv *deprecatedClassVec) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesOperationTimeout)
+	defer cancel()
+
+	// make sure every deprecated class reports a value, even if no workspace uses it anymore
+	counts := make(map[string]int)
+	for name, class := range dcv.reconciler.Config.WorkspaceClasses {
+		if class.Deprecated() {
+			counts[name] = 0
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	var workspaces workspacev1.WorkspaceList
+	err := dcv.reconciler.List(ctx, &workspaces, client.InNamespace(dcv.reconciler.Config.Namespace))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "cannot list workspaces for deprecated class metrics")
+		return
+	}
+	for _, ws := range workspaces.Items {
+		if _, ok := counts[ws.Spec.Class]; ok {
+			counts[ws.Spec.Class]++
+		}
+	}
+
+	for class, count := range counts {
+		replacedBy := dcv.reconciler.Config.WorkspaceClasses[class].Deprecation.ReplacedBy
+		metric, err := prometheus.NewConstMetric(dcv.desc, prometheus.GaugeValue, float64(count), class, replacedBy)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "cannot create deprecated workspace class metric", "class", class)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// pendingAdmissionVec counts the workspaces which are held back before they start, by reason. Workspaces
+// pending because the cluster is at capacity are a signal to add capacity.
+type pendingAdmissionVec struct {
+	desc       *prometheus.Desc
+	reconciler *WorkspaceReconciler
+}
+
+func newPendingAdmissionVec(r *WorkspaceReconciler) *pendingAdmissionVec {
+	return &pendingAdmissionVec{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsWorkspaceSubsystem, workspacePendingAdmission),
+			"number of workspaces which wait to be admitted to start",
+			[]string{"reason"},
+			prometheus.Labels(map[string]string{}),
+		),
+		reconciler: r,
+	}
+}
+
+// Describe implements Collector. It will send exactly one Desc to the provided channel.
+func (pav *pendingAdmissionVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pav.desc
+}
+
+// Collect implements Collector.
+func (pav *pendingAdmissionVec) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesOperationTimeout)
+	defer cancel()
+
+	var workspaces workspacev1.WorkspaceList
+	err := pav.reconciler.List(ctx, &workspaces, client.InNamespace(pav.reconciler.Config.Namespace))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "cannot list workspaces for pending admission metrics")
+		return
+	}
+
+	// make sure every reason reports a value, such that alerts resolve once no workspace is pending anymore
+	counts := map[string]int{
+		workspacev1.ReasonNodeStartLimit:    0,
+		workspacev1.ReasonClusterAtCapacity: 0,
+	}
+	for _, ws := range workspaces.Items {
+		c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionPending))
+		if c == nil || c.Status != metav1.ConditionTrue || ws.Status.PodStarts > 0 {
+			continue
+		}
+		counts[c.Reason]++
+	}
+
+	for reason, count := range counts {
+		metric, err := prometheus.NewConstMetric(pav.desc, prometheus.GaugeValue, float64(count), reason)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "cannot create pending admission metric", "reason", reason)
+			continue
+		}
+		ch <- metric
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// defaultNetworkPolicyLabel selects workspace pods for the namespace-wide NetworkPolicy
+const defaultNetworkPolicyLabel = "gitpod.io/networkpolicy"
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;delete
+
+// ensureWorkspaceNetworkPolicy creates the NetworkPolicy of the workspace pod, if network policies are enabled.
+// The policy is owned by the workspace, such that it's garbage collected even if we don't get to delete it.
+func (r *WorkspaceReconciler) ensureWorkspaceNetworkPolicy(ctx context.Context, ws *workspacev1.Workspace) (err error) {
+	span, ctx := tracing.FromContext(ctx, "ensureWorkspaceNetworkPolicy")
+	defer tracing.FinishSpan(span, &err)
+
+	if !r.Config.NetworkPolicy.Enabled {
+		return nil
+	}
+
+	policy := newWorkspaceNetworkPolicy(ws, &r.Config.NetworkPolicy)
+	if err := ctrl.SetControllerReference(ws, policy, r.Scheme); err != nil {
+		return err
+	}
+	err = r.Create(ctx, policy)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("cannot create network policy: %w", err)
+	}
+	return nil
+}
+
+// deleteWorkspaceNetworkPolicy deletes the NetworkPolicy of a stopped workspace.
+func (r *WorkspaceReconciler) deleteWorkspaceNetworkPolicy(ctx context.Context, ws *workspacev1.Workspace) error {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ws.Name,
+			Namespace: ws.Namespace,
+		},
+	}
+	err := r.Delete(ctx, policy)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete network policy: %w", err)
+	}
+	return nil
+}
+
+func newWorkspaceNetworkPolicy(ws *workspacev1.Workspace, cfg *config.WorkspaceNetworkPolicy) *networkingv1.NetworkPolicy {
+	var except []string
+	except = append(except, cfg.ClusterCIDRs...)
+	except = append(except, cfg.BlockedCIDRs...)
+
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					IPBlock: &networkingv1.IPBlock{
+						CIDR:   "0.0.0.0/0",
+						Except: except,
+					},
+				},
+			},
+		},
+	}
+	for _, rule := range cfg.Egress {
+		egress = append(egress, *rule.DeepCopy())
+	}
+
+	var ingress []networkingv1.NetworkPolicyIngressRule
+	for _, rule := range cfg.Ingress {
+		ingress = append(ingress, *rule.DeepCopy())
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ws.Name,
+			Namespace: ws.Namespace,
+			Labels: map[string]string{
+				wsk8s.MetaIDLabel:      ws.Spec.Ownership.WorkspaceID,
+				wsk8s.WorkspaceIDLabel: ws.Name,
+				wsk8s.OwnerLabel:       ws.Spec.Ownership.Owner,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{wsk8s.WorkspaceIDLabel: ws.Name},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	v1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestNewWorkspaceNetworkPolicy(t *testing.T) {
+	ws := &v1.Workspace{}
+	ws.Name = "foobar"
+	ws.Namespace = "default"
+
+	dns := networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+	}
+	proxy := networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"component": "proxy"}}}},
+	}
+	policy := newWorkspaceNetworkPolicy(ws, &config.WorkspaceNetworkPolicy{
+		Enabled:      true,
+		ClusterCIDRs: []string{"10.0.0.0/14", "10.4.0.0/20"},
+		BlockedCIDRs: []string{"169.254.169.254/32"},
+		Ingress:      []networkingv1.NetworkPolicyIngressRule{proxy},
+		Egress:       []networkingv1.NetworkPolicyEgressRule{dns},
+	})
+
+	if policy.Name != ws.Name || policy.Namespace != ws.Namespace {
+		t.Errorf("unexpected name: %s/%s", policy.Namespace, policy.Name)
+	}
+	if diff := cmp.Diff(map[string]string{wsk8s.WorkspaceIDLabel: ws.Name}, policy.Spec.PodSelector.MatchLabels); diff != "" {
+		t.Errorf("unexpected pod selector (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]networkingv1.NetworkPolicyIngressRule{proxy}, policy.Spec.Ingress); diff != "" {
+		t.Errorf("unexpected ingress (-want +got):\n%s", diff)
+	}
+	expectedEgress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{
+				CIDR:   "0.0.0.0/0",
+				Except: []string{"10.0.0.0/14", "10.4.0.0/20", "169.254.169.254/32"},
+			}}},
+		},
+		dns,
+	}
+	if diff := cmp.Diff(expectedEgress, policy.Spec.Egress); diff != "" {
+		t.Errorf("unexpected egress (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	orphanedResources             string = "orphaned_resources"
+	orphanedResourcesDeletedTotal string = "orphaned_resources_deleted_total"
+
+	// orphanMinAge keeps the sweeper away from resources which are created before their workspace,
+	// e.g. the secrets of a workspace which is just being started.
+	orphanMinAge = 10 * time.Minute
+)
+
+func NewOrphanSweeper(c client.Client, reader client.Reader, cfg *config.Configuration, reg prometheus.Registerer) (*OrphanSweeper, error) {
+	if cfg.OrphanSweep.Interval <= 0 {
+		return nil, fmt.Errorf("orphan sweep interval must be positive")
+	}
+
+	s := &OrphanSweeper{
+		Client: c,
+		Reader: reader,
+		Config: cfg,
+		orphansGaugeVec: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      orphanedResources,
+			Help:      "number of resources found by the last sweep whose workspace doesn't exist anymore",
+		}, []string{"kind"}),
+		deletedCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      orphanedResourcesDeletedTotal,
+			Help:      "total number of orphaned resources deleted by the sweeper",
+		}, []string{"kind"}),
+	}
+	reg.MustRegister(s.orphansGaugeVec, s.deletedCounterVec)
+
+	return s, nil
+}
+
+// OrphanSweeper periodically deletes the pods, secrets and services of workspaces whose Workspace object
+// doesn't exist anymore, e.g. after an etcd restore or because a finalizer bug got the workspace deleted
+// before its resources. The TLS material of workspaces lives in their secrets. In dry-run mode orphaned
+// resources are only counted.
+type OrphanSweeper struct {
+	Client client.Client
+	// Reader reads from the API server rather than the cache, such that we don't mistake a workspace
+	// the cache hasn't seen yet for a missing one.
+	Reader client.Reader
+	Config *config.Configuration
+
+	orphansGaugeVec   *prometheus.GaugeVec
+	deletedCounterVec *prometheus.CounterVec
+}
+
+//+kubebuilder:rbac:groups=core,resources=secrets;services,verbs=get;list;delete
+
+// Start sweeps orphaned resources until the context is cancelled. The manager only starts the sweeper
+// on the leader.
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("orphan-sweeper")
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	ticker := time.NewTicker(time.Duration(s.Config.OrphanSweep.Interval))
+	defer ticker.Stop()
+	for {
+		if err := s.Sweep(ctx); err != nil {
+			log.Error(err, "cannot sweep orphaned resources")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes, or in dry-run mode only counts, the resources whose workspace doesn't exist anymore.
+func (s *OrphanSweeper) Sweep(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	var workspaces workspacev1.WorkspaceList
+	if err := s.Reader.List(ctx, &workspaces, client.InNamespace(s.Config.Namespace)); err != nil {
+		return fmt.Errorf("cannot list workspaces: %w", err)
+	}
+	exists := make(map[string]struct{}, len(workspaces.Items))
+	for _, ws := range workspaces.Items {
+		exists[ws.Name] = struct{}{}
+	}
+
+	candidates := []orphanCandidates{
+		{Kind: "pod", Namespace: s.Config.Namespace, List: &corev1.PodList{}},
+		{Kind: "service", Namespace: s.Config.Namespace, List: &corev1.ServiceList{}},
+		{Kind: "secret", Namespace: s.Config.Namespace, List: &corev1.SecretList{}},
+	}
+	if s.Config.SecretsNamespace != s.Config.Namespace {
+		candidates = append(candidates, orphanCandidates{Kind: "secret", Namespace: s.Config.SecretsNamespace, List: &corev1.SecretList{}})
+	}
+
+	var (
+		now     = time.Now()
+		orphans = make(map[string]int)
+		errs    []error
+	)
+	for _, c := range candidates {
+		err := s.Reader.List(ctx, c.List,
+			client.InNamespace(c.Namespace),
+			client.MatchingLabels{wsk8s.WorkspaceManagedByLabel: constants.ManagedBy},
+			client.HasLabels{wsk8s.WorkspaceIDLabel},
+		)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot list %ss in %s: %w", c.Kind, c.Namespace, err))
+			continue
+		}
+		items, err := meta.ExtractList(c.List)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok || !isOrphan(obj, exists, now) {
+				continue
+			}
+			orphans[c.Kind]++
+
+			if s.Config.OrphanSweep.DryRun {
+				log.Info("found orphaned resource", "kind", c.Kind, "namespace", obj.GetNamespace(), "name", obj.GetName())
+				continue
+			}
+			if err := s.deleteOrphan(ctx, obj); err != nil {
+				errs = append(errs, fmt.Errorf("cannot delete %s %s/%s: %w", c.Kind, obj.GetNamespace(), obj.GetName(), err))
+				continue
+			}
+			log.Info("deleted orphaned resource", "kind", c.Kind, "namespace", obj.GetNamespace(), "name", obj.GetName())
+			s.deletedCounterVec.WithLabelValues(c.Kind).Inc()
+		}
+	}
+
+	for _, c := range candidates {
+		s.orphansGaugeVec.WithLabelValues(c.Kind).Set(float64(orphans[c.Kind]))
+	}
+
+	return errors.Join(errs...)
+}
+
+// orphanCandidates are the resources of a kind in a namespace which might have outlived their workspace.
+type orphanCandidates struct {
+	Kind      string
+	Namespace string
+	List      client.ObjectList
+}
+
+// isOrphan returns true if the resource belongs to a workspace which doesn't exist, and is old enough
+// that its workspace can't be just about to be created.
+func isOrphan(obj client.Object, exists map[string]struct{}, now time.Time) bool {
+	if now.Sub(obj.GetCreationTimestamp().Time) < orphanMinAge {
+		return false
+	}
+	_, ok := exists[obj.GetLabels()[wsk8s.WorkspaceIDLabel]]
+	return !ok
+}
+
+// deleteOrphan deletes the resource, removing our finalizer first as there's no workspace left to clean up.
+func (s *OrphanSweeper) deleteOrphan(ctx context.Context, obj client.Object) error {
+	if controllerutil.ContainsFinalizer(obj, workspacev1.GitpodFinalizerName) {
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		controllerutil.RemoveFinalizer(obj, workspacev1.GitpodFinalizerName)
+		if err := s.Client.Patch(ctx, obj, patch); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+
+	err := s.Client.Delete(ctx, obj)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestOrphanSweeper(t *testing.T) {
+	now := time.Now()
+	meta := func(name, namespace, workspaceID string, age time.Duration) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			Labels: map[string]string{
+				wsk8s.WorkspaceIDLabel:        workspaceID,
+				wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
+			},
+		}
+	}
+	objects := func() []client.Object {
+		ws := &workspacev1.Workspace{}
+		ws.Name = "running"
+		ws.Namespace = "default"
+
+		orphanedPod := &corev1.Pod{ObjectMeta: meta("ws-gone", "default", "gone", time.Hour)}
+		orphanedPod.Finalizers = []string{workspacev1.GitpodFinalizerName}
+		foreignService := &corev1.Service{ObjectMeta: meta("foreign", "default", "gone", time.Hour)}
+		foreignService.Labels[wsk8s.WorkspaceManagedByLabel] = "ws-manager"
+
+		return []client.Object{
+			ws,
+			orphanedPod,
+			&corev1.Pod{ObjectMeta: meta("ws-running", "default", "running", time.Hour)},
+			&corev1.Service{ObjectMeta: meta("gone", "default", "gone", time.Hour)},
+			foreignService,
+			&corev1.Secret{ObjectMeta: meta("gone-env", "default", "gone", time.Hour)},
+			&corev1.Secret{ObjectMeta: meta("gone-tokens", "secrets", "gone", time.Hour)},
+			&corev1.Secret{ObjectMeta: meta("starting-tokens", "secrets", "starting", time.Minute)},
+		}
+	}
+
+	tests := []struct {
+		Name      string
+		DryRun    bool
+		Remaining []string
+		Deleted   float64
+	}{
+		{
+			Name:      "sweep",
+			Remaining: []string{"default/foreign", "default/ws-running", "secrets/starting-tokens"},
+			Deleted:   4,
+		},
+		{
+			Name:      "dry run",
+			DryRun:    true,
+			Remaining: []string{"default/foreign", "default/gone", "default/gone-env", "default/ws-gone", "default/ws-running", "secrets/gone-tokens", "secrets/starting-tokens"},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects()...).Build()
+			s, err := NewOrphanSweeper(c, c, &config.Configuration{
+				Namespace:        "default",
+				SecretsNamespace: "secrets",
+				OrphanSweep: config.OrphanSweepConfiguration{
+					Interval: util.Duration(time.Minute),
+					DryRun:   test.DryRun,
+				},
+			}, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := s.Sweep(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var remaining []string
+			for _, list := range []client.ObjectList{&corev1.PodList{}, &corev1.ServiceList{}, &corev1.SecretList{}} {
+				if err := c.List(context.Background(), list); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				switch l := list.(type) {
+				case *corev1.PodList:
+					for _, o := range l.Items {
+						remaining = append(remaining, o.Namespace+"/"+o.Name)
+					}
+				case *corev1.ServiceList:
+					for _, o := range l.Items {
+						remaining = append(remaining, o.Namespace+"/"+o.Name)
+					}
+				case *corev1.SecretList:
+					for _, o := range l.Items {
+						remaining = append(remaining, o.Namespace+"/"+o.Name)
+					}
+				}
+			}
+			sort.Strings(remaining)
+			if diff := cmp.Diff(test.Remaining, remaining); diff != "" {
+				t.Errorf("unexpected remaining resources (-want +got):\n%s", diff)
+			}
+
+			if orphans := testutil.ToFloat64(s.orphansGaugeVec.WithLabelValues("secret")); orphans != 2 {
+				t.Errorf("expected 2 orphaned secrets, got %v", orphans)
+			}
+			var deleted float64
+			for _, kind := range []string{"pod", "service", "secret"} {
+				deleted += testutil.ToFloat64(s.deletedCounterVec.WithLabelValues(kind))
+			}
+			if deleted != test.Deleted {
+				t.Errorf("expected %v deleted resources, got %v", test.Deleted, deleted)
+			}
+		})
+	}
+}
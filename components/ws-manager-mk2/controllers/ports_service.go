@@ -0,0 +1,132 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;delete
+
+// reconcileWorkspacePortsService makes the Service of the workspace's exposed ports match its spec. The Service
+// exists as long as the workspace runs and exposes ports. It's owned by the workspace, such that we get to
+// repair it if it's changed or deleted, and it's garbage collected with the workspace.
+func (r *WorkspaceReconciler) reconcileWorkspacePortsService(ctx context.Context, ws *workspacev1.Workspace) (err error) {
+	span, ctx := tracing.FromContext(ctx, "reconcileWorkspacePortsService")
+	defer tracing.FinishSpan(span, &err)
+
+	var existing corev1.Service
+	err = r.Get(ctx, types.NamespacedName{Namespace: ws.Namespace, Name: portsServiceName(ws)}, &existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot get ports service: %w", err)
+	}
+	exists := err == nil
+
+	if len(ws.Spec.Ports) == 0 || isWorkspaceBeingDeleted(ws) ||
+		ws.Status.Phase == workspacev1.WorkspacePhaseStopping || ws.Status.Phase == workspacev1.WorkspacePhaseStopped {
+		if !exists {
+			return nil
+		}
+		err = r.Delete(ctx, &existing)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("cannot delete ports service: %w", err)
+		}
+		return nil
+	}
+
+	desired := newWorkspacePortsService(ws)
+	if !exists {
+		if err := ctrl.SetControllerReference(ws, desired, r.Scheme); err != nil {
+			return err
+		}
+		err = r.Create(ctx, desired)
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("cannot create ports service: %w", err)
+		}
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) &&
+		equality.Semantic.DeepEqual(existing.Spec.Ports, desired.Spec.Ports) &&
+		existing.Labels[wsk8s.WorkspaceIDLabel] == ws.Name && metav1.IsControlledBy(&existing, ws) {
+		return nil
+	}
+
+	for k, v := range desired.Labels {
+		if existing.Labels == nil {
+			existing.Labels = make(map[string]string)
+		}
+		existing.Labels[k] = v
+	}
+	existing.Spec.Selector = desired.Spec.Selector
+	existing.Spec.Ports = desired.Spec.Ports
+	if err := ctrl.SetControllerReference(ws, &existing, r.Scheme); err != nil {
+		return err
+	}
+	err = r.Update(ctx, &existing)
+	if err != nil {
+		return fmt.Errorf("cannot update ports service: %w", err)
+	}
+	return nil
+}
+
+func portsServiceName(ws *workspacev1.Workspace) string {
+	return fmt.Sprintf("%s-ports", ws.Name)
+}
+
+func newWorkspacePortsService(ws *workspacev1.Workspace) *corev1.Service {
+	ports := make([]corev1.ServicePort, 0, len(ws.Spec.Ports))
+	for _, p := range ws.Spec.Ports {
+		visibility := "private"
+		if p.Visibility == workspacev1.AdmissionLevelEveryone {
+			visibility = "public"
+		}
+		protocol := strings.ToLower(string(p.Protocol))
+		if protocol == "" {
+			protocol = "http"
+		}
+
+		ports = append(ports, corev1.ServicePort{
+			Name:        fmt.Sprintf("p%d-%s", p.Port, visibility),
+			Protocol:    corev1.ProtocolTCP,
+			AppProtocol: &protocol,
+			Port:        int32(p.Port),
+			TargetPort:  intstr.FromInt(int(p.Port)),
+		})
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      portsServiceName(ws),
+			Namespace: ws.Namespace,
+			Labels: map[string]string{
+				wsk8s.MetaIDLabel:             ws.Spec.Ownership.WorkspaceID,
+				wsk8s.WorkspaceIDLabel:        ws.Name,
+				wsk8s.OwnerLabel:              ws.Spec.Ownership.Owner,
+				wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{wsk8s.WorkspaceIDLabel: ws.Name},
+			Ports:    ports,
+		},
+	}
+}
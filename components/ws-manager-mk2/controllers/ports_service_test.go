@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestReconcileWorkspacePortsService(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := &workspacev1.Workspace{}
+	ws.Name = "ws"
+	ws.Namespace = "default"
+	ws.UID = "ws-uid"
+	ws.Spec.Ownership = workspacev1.Ownership{Owner: "owner", WorkspaceID: "foobar"}
+	ws.Spec.Ports = []workspacev1.PortSpec{{Port: 3000, Visibility: workspacev1.AdmissionLevelOwner, Protocol: workspacev1.PortProtocolHttp}}
+	ws.Status.Phase = workspacev1.WorkspacePhaseRunning
+
+	var (
+		ctx = context.Background()
+		c   = fake.NewClientBuilder().WithScheme(scheme).WithObjects(ws).Build()
+		r   = &WorkspaceReconciler{Client: c, Scheme: scheme}
+		key = types.NamespacedName{Namespace: ws.Namespace, Name: portsServiceName(ws)}
+	)
+	reconcile := func() {
+		t.Helper()
+		if err := r.reconcileWorkspacePortsService(ctx, ws); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectPorts := func(expectation []corev1.ServicePort) {
+		t.Helper()
+		var svc corev1.Service
+		err := c.Get(ctx, key, &svc)
+		if expectation == nil {
+			if !apierrors.IsNotFound(err) {
+				t.Fatalf("expected no ports service, got %v", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("cannot get ports service: %v", err)
+		}
+		if !metav1.IsControlledBy(&svc, ws) {
+			t.Errorf("ports service is not controlled by the workspace")
+		}
+		if diff := cmp.Diff(map[string]string{wsk8s.WorkspaceIDLabel: ws.Name}, svc.Spec.Selector); diff != "" {
+			t.Errorf("unexpected selector (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(expectation, svc.Spec.Ports); diff != "" {
+			t.Errorf("unexpected ports (-want +got):\n%s", diff)
+		}
+	}
+
+	reconcile()
+	private := newWorkspacePortsService(ws).Spec.Ports
+	if len(private) != 1 || private[0].Name != "p3000-private" {
+		t.Fatalf("unexpected ports: %v", private)
+	}
+	expectPorts(private)
+
+	t.Run("repairs drift", func(t *testing.T) {
+		var svc corev1.Service
+		if err := c.Get(ctx, key, &svc); err != nil {
+			t.Fatal(err)
+		}
+		svc.Spec.Ports = nil
+		svc.Spec.Selector = map[string]string{"foo": "bar"}
+		if err := c.Update(ctx, &svc); err != nil {
+			t.Fatal(err)
+		}
+
+		reconcile()
+		expectPorts(private)
+	})
+
+	t.Run("recreates deleted service", func(t *testing.T) {
+		if err := c.Delete(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}); err != nil {
+			t.Fatal(err)
+		}
+
+		reconcile()
+		expectPorts(private)
+	})
+
+	t.Run("follows spec changes", func(t *testing.T) {
+		ws.Spec.Ports[0].Visibility = workspacev1.AdmissionLevelEveryone
+		reconcile()
+		public := newWorkspacePortsService(ws).Spec.Ports
+		if public[0].Name != "p3000-public" {
+			t.Fatalf("unexpected ports: %v", public)
+		}
+		expectPorts(public)
+	})
+
+	t.Run("removes service without ports", func(t *testing.T) {
+		ws.Spec.Ports = nil
+		reconcile()
+		expectPorts(nil)
+	})
+}
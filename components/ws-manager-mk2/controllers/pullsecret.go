@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// imagePullSecretLabel marks the per-workspace copies of the configured image pull secret
+const imagePullSecretLabel = "gitpod.io/image-pull-secret"
+
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+
+// imagePullSecretKey returns the name and namespace of the configured image pull secret.
+func imagePullSecretKey(cfg *config.Configuration) types.NamespacedName {
+	key := types.NamespacedName{Name: cfg.ImagePullSecret.Name, Namespace: cfg.ImagePullSecret.Namespace}
+	if key.Namespace == "" {
+		key.Namespace = cfg.Namespace
+	}
+	return key
+}
+
+func workspacePullSecretName(ws *workspacev1.Workspace) string {
+	return fmt.Sprintf("%s-%s", ws.Name, "pull")
+}
+
+// ensureWorkspacePullSecret copies the configured image pull secret for the workspace pod, reading it anew such that
+// pods start with the current credentials. Returns the name of the copy, or an empty string if no image pull secret
+// is configured.
+func (r *WorkspaceReconciler) ensureWorkspacePullSecret(ctx context.Context, ws *workspacev1.Workspace) (name string, err error) {
+	span, ctx := tracing.FromContext(ctx, "ensureWorkspacePullSecret")
+	defer tracing.FinishSpan(span, &err)
+
+	if r.Config.ImagePullSecret == nil {
+		return "", nil
+	}
+
+	var source corev1.Secret
+	if err := r.Get(ctx, imagePullSecretKey(r.Config), &source); err != nil {
+		return "", fmt.Errorf("cannot get image pull secret: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workspacePullSecretName(ws),
+			Namespace: r.Config.Namespace,
+			Labels: map[string]string{
+				wsk8s.WorkspaceIDLabel:        ws.Name,
+				wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
+				imagePullSecretLabel:          "true",
+			},
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+	if err := ctrl.SetControllerReference(ws, secret, r.Scheme); err != nil {
+		return "", err
+	}
+
+	err = r.Create(ctx, secret)
+	if apierrors.IsAlreadyExists(err) {
+		err = refreshPullSecret(ctx, r.Client, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, &source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot create workspace pull secret: %w", err)
+	}
+	return secret.Name, nil
+}
+
+// refreshPullSecret updates the copy of the image pull secret if its credentials changed.
+func refreshPullSecret(ctx context.Context, c client.Client, key types.NamespacedName, source *corev1.Secret) error {
+	var secret corev1.Secret
+	if err := c.Get(ctx, key, &secret); err != nil {
+		return err
+	}
+	if secret.Type == source.Type && equalSecretData(secret.Data, source.Data) {
+		return nil
+	}
+
+	secret.Type = source.Type
+	secret.Data = source.Data
+	return c.Update(ctx, &secret)
+}
+
+func equalSecretData(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !bytes.Equal(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewPullSecretReconciler(c client.Client, cfg *config.Configuration) (*PullSecretReconciler, error) {
+	if cfg.ImagePullSecret == nil {
+		return nil, fmt.Errorf("image pull secret is not configured")
+	}
+	return &PullSecretReconciler{Client: c, Config: cfg}, nil
+}
+
+// PullSecretReconciler updates the pull secrets of all workspaces when the configured image pull secret changes,
+// such that pods which haven't pulled their images yet, or have to pull them again, use the current credentials.
+type PullSecretReconciler struct {
+	client.Client
+
+	Config *config.Configuration
+}
+
+func (r *PullSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	var source corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &source); err != nil {
+		// if the image pull secret is gone we keep the last known credentials
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var secrets corev1.SecretList
+	err := r.List(ctx, &secrets,
+		client.InNamespace(r.Config.Namespace),
+		client.MatchingLabels{
+			wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
+			imagePullSecretLabel:          "true",
+		},
+	)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("cannot list workspace pull secrets: %w", err)
+	}
+
+	var refreshErr error
+	for _, s := range secrets.Items {
+		err := refreshPullSecret(ctx, r.Client, types.NamespacedName{Name: s.Name, Namespace: s.Namespace}, &source)
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "cannot refresh workspace pull secret", "secret", s.Name)
+			refreshErr = err
+		}
+	}
+	return ctrl.Result{}, refreshErr
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PullSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	key := imagePullSecretKey(r.Config)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pull-secret").
+		For(&corev1.Secret{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return object.GetName() == key.Name && object.GetNamespace() == key.Namespace
+		}))).
+		Complete(r)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestWorkspacePullSecretRotation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &corev1.Secret{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry":{"auth":"old"}}}`)},
+	}
+	source.Name = "registry-credentials"
+	source.Namespace = "registry"
+
+	ws := &workspacev1.Workspace{}
+	ws.Name = "foobar"
+	ws.Namespace = "default"
+
+	cfg := &config.Configuration{
+		Namespace:       "default",
+		ImagePullSecret: &config.ImagePullSecretConfiguration{Name: source.Name, Namespace: source.Namespace},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source, ws).Build()
+	ctx := context.Background()
+
+	r := &WorkspaceReconciler{Client: c, Scheme: scheme, Config: cfg}
+	name, err := r.ensureWorkspacePullSecret(ctx, ws)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "foobar-pull" {
+		t.Errorf("unexpected pull secret name: %s", name)
+	}
+
+	expectPullSecret := func(data map[string][]byte) {
+		t.Helper()
+		var secret corev1.Secret
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &secret); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			t.Errorf("unexpected secret type: %s", secret.Type)
+		}
+		if diff := cmp.Diff(data, secret.Data); diff != "" {
+			t.Errorf("unexpected pull secret data (-want +got):\n%s", diff)
+		}
+	}
+	expectPullSecret(source.Data)
+
+	rotated := map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry":{"auth":"new"}}}`)}
+	source.Data = rotated
+	if err := c.Update(ctx, source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	psr, err := NewPullSecretReconciler(c, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = psr.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: source.Namespace, Name: source.Name}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectPullSecret(rotated)
+}
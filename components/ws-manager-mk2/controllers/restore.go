@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+//+kubebuilder:rbac:groups=workspace.gitpod.io,resources=snapshots,verbs=get;list;watch
+
+// restoreSnapshotFailure returns why the workspace cannot start if the snapshot it is restored from is not
+// available (anymore), e.g. because it was deleted after the workspace was requested.
+func (r *WorkspaceReconciler) restoreSnapshotFailure(ctx context.Context, ws *workspacev1.Workspace) (string, error) {
+	name := ws.Spec.RestoreSnapshot
+	if name == "" {
+		return "", nil
+	}
+
+	var snapshot workspacev1.Snapshot
+	err := r.Get(ctx, types.NamespacedName{Namespace: ws.Namespace, Name: name}, &snapshot)
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("snapshot %s does not exist", name), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get snapshot %s: %w", name, err)
+	}
+	if !snapshot.IsRestorableBy(ws.Spec.Ownership) {
+		return fmt.Sprintf("snapshot %s does not belong to the owner or organization of the workspace", name), nil
+	}
+	if !snapshot.IsAvailable() {
+		return fmt.Sprintf("snapshot %s is not available", name), nil
+	}
+	return "", nil
+}
+
+// failStart fails a workspace before its pod was created. There's nothing to back up or dispose of, hence the
+// workspace is stopped right away.
+func (r *WorkspaceReconciler) failStart(ctx context.Context, ws *workspacev1.Workspace, message string) (ctrl.Result, error) {
+	patch := client.MergeFrom(ws.DeepCopy())
+	ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionFalse, workspacev1.ReasonInitializationFailure, message))
+	ws.Status.SetCondition(workspacev1.NewWorkspaceConditionFailed(message))
+	ws.Status.Phase = workspacev1.WorkspacePhaseStopped
+	if err := r.Status().Patch(ctx, ws, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Event(ws, corev1.EventTypeWarning, "StartFailed", message)
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// restoringSnapshots returns the names of the snapshots which workspaces are about to restore. They must not
+// be garbage collected until the content of the workspaces is ready.
+func restoringSnapshots(workspaces []workspacev1.Workspace) map[string]struct{} {
+	res := make(map[string]struct{})
+	for _, ws := range workspaces {
+		if ws.Spec.RestoreSnapshot == "" || ws.Status.Phase == workspacev1.WorkspacePhaseStopped || ws.IsConditionTrue(workspacev1.WorkspaceConditionContentReady) {
+			continue
+		}
+		res[ws.Spec.RestoreSnapshot] = struct{}{}
+	}
+	return res
+}
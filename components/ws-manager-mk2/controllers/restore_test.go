@@ -0,0 +1,160 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestRestoreSnapshotFailure(t *testing.T) {
+	snapshot := func(mod func(s *workspacev1.Snapshot)) *workspacev1.Snapshot {
+		s := &workspacev1.Snapshot{}
+		s.Name = "snap"
+		s.Namespace = "default"
+		s.Status.Completed = true
+		s.Status.Phase = workspacev1.SnapshotPhaseCompleted
+		s.Status.URL = "owner/workspaces/ws1/snap.tar@gitpod-owner"
+		s.Labels = map[string]string{wsk8s.OwnerLabel: "owner", wsk8s.TeamLabel: "org"}
+		s.Status.UpdateReadyCondition()
+		if mod != nil {
+			mod(s)
+		}
+		return s
+	}
+
+	tests := []struct {
+		Name        string
+		Restore     string
+		Ownership   workspacev1.Ownership
+		Objects     []client.Object
+		Expectation string
+	}{
+		{
+			Name: "no snapshot",
+		},
+		{
+			Name:    "available",
+			Restore: "snap",
+			Objects: []client.Object{snapshot(nil)},
+		},
+		{
+			Name:    "legacy snapshot without conditions",
+			Restore: "snap",
+			Objects: []client.Object{snapshot(func(s *workspacev1.Snapshot) { s.Status.Conditions = nil })},
+		},
+		{
+			Name:      "same organization",
+			Restore:   "snap",
+			Ownership: workspacev1.Ownership{Owner: "colleague", Team: "org"},
+			Objects:   []client.Object{snapshot(nil)},
+		},
+		{
+			Name:        "someone else's snapshot",
+			Restore:     "snap",
+			Ownership:   workspacev1.Ownership{Owner: "someone-else", Team: "other-org"},
+			Objects:     []client.Object{snapshot(nil)},
+			Expectation: "snapshot snap does not belong to the owner or organization of the workspace",
+		},
+		{
+			Name:        "snapshot without organization",
+			Restore:     "snap",
+			Ownership:   workspacev1.Ownership{Owner: "someone-else"},
+			Objects:     []client.Object{snapshot(func(s *workspacev1.Snapshot) { delete(s.Labels, wsk8s.TeamLabel) })},
+			Expectation: "snapshot snap does not belong to the owner or organization of the workspace",
+		},
+		{
+			Name:        "missing",
+			Restore:     "snap",
+			Expectation: "snapshot snap does not exist",
+		},
+		{
+			Name:    "still uploading",
+			Restore: "snap",
+			Objects: []client.Object{snapshot(func(s *workspacev1.Snapshot) {
+				s.Status.Completed = false
+				s.Status.Phase = workspacev1.SnapshotPhaseUploading
+				s.Status.UpdateReadyCondition()
+			})},
+			Expectation: "snapshot snap is not available",
+		},
+		{
+			Name:    "failed",
+			Restore: "snap",
+			Objects: []client.Object{snapshot(func(s *workspacev1.Snapshot) {
+				s.Status.Phase = workspacev1.SnapshotPhaseFailed
+				s.Status.ErrorDetail = &workspacev1.SnapshotError{Reason: workspacev1.SnapshotErrorUploadFailed}
+				s.Status.UpdateReadyCondition()
+			})},
+			Expectation: "snapshot snap is not available",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.Objects...).Build()
+			r := &WorkspaceReconciler{Client: c}
+
+			ws := &workspacev1.Workspace{}
+			ws.Name = "ws"
+			ws.Namespace = "default"
+			ws.Spec.RestoreSnapshot = test.Restore
+			ws.Spec.Ownership = test.Ownership
+			if ws.Spec.Ownership.Owner == "" {
+				ws.Spec.Ownership.Owner = "owner"
+			}
+
+			act, err := r.restoreSnapshotFailure(context.Background(), ws)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected failure (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRestoringSnapshots(t *testing.T) {
+	workspace := func(snapshot string, phase workspacev1.WorkspacePhase, contentReady bool) workspacev1.Workspace {
+		var ws workspacev1.Workspace
+		ws.Spec.RestoreSnapshot = snapshot
+		ws.Status.Phase = phase
+		if contentReady {
+			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionTrue, workspacev1.ReasonInitializationSuccess, ""))
+		}
+		return ws
+	}
+
+	act := restoringSnapshots([]workspacev1.Workspace{
+		workspace("", workspacev1.WorkspacePhasePending, false),
+		workspace("pending", workspacev1.WorkspacePhasePending, false),
+		workspace("initializing", workspacev1.WorkspacePhaseInitializing, false),
+		workspace("running", workspacev1.WorkspacePhaseRunning, true),
+		workspace("failed", workspacev1.WorkspacePhaseStopped, false),
+	})
+	expectation := map[string]struct{}{"pending": {}, "initializing": {}}
+	if diff := cmp.Diff(expectation, act); diff != "" {
+		t.Errorf("unexpected restoring snapshots (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// prevent cluster-autoscaler from removing a node
+	// https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/FAQ.md#what-types-of-pods-can-prevent-ca-from-removing-a-node
+	annotationSafeToEvict = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+	// prevent Karpenter from disrupting a node
+	// https://karpenter.sh/docs/concepts/disruption/#pod-level-controls
+	annotationDoNotDisrupt = "karpenter.sh/do-not-disrupt"
+)
+
+// scaleDownProtectionAnnotations are the annotations which keep autoscalers from removing the node of a workspace pod.
+var scaleDownProtectionAnnotations = map[string]string{
+	annotationSafeToEvict:  "false",
+	annotationDoNotDisrupt: "true",
+}
+
+// needsScaleDownProtection returns true if the node of a workspace in this phase must not be scaled down.
+// Once a workspace is stopping there's nothing left to protect, and nodes which only run stopping
+// workspaces should be free to go.
+func needsScaleDownProtection(phase workspacev1.WorkspacePhase) bool {
+	switch phase {
+	case workspacev1.WorkspacePhaseStopping, workspacev1.WorkspacePhaseStopped:
+		return false
+	default:
+		return true
+	}
+}
+
+// updateScaleDownProtection adds or removes the scale-down protection annotations of the workspace pod
+// depending on the phase of the workspace. Returns true if the pod was changed.
+func updateScaleDownProtection(pod *corev1.Pod, phase workspacev1.WorkspacePhase) (changed bool) {
+	protect := needsScaleDownProtection(phase)
+	for k, v := range scaleDownProtectionAnnotations {
+		current, exists := pod.Annotations[k]
+		switch {
+		case protect && (!exists || current != v):
+			if pod.Annotations == nil {
+				pod.Annotations = make(map[string]string)
+			}
+			pod.Annotations[k] = v
+			changed = true
+		case !protect && exists:
+			delete(pod.Annotations, k)
+			changed = true
+		}
+	}
+	return changed
+}
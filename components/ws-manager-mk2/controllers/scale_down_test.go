@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestUpdateScaleDownProtection(t *testing.T) {
+	protected := map[string]string{
+		"foo":                  "bar",
+		annotationSafeToEvict:  "false",
+		annotationDoNotDisrupt: "true",
+	}
+
+	tests := []struct {
+		Name        string
+		Phase       workspacev1.WorkspacePhase
+		Annotations map[string]string
+		Expectation map[string]string
+		Changed     bool
+	}{
+		{
+			Name:        "running and protected",
+			Phase:       workspacev1.WorkspacePhaseRunning,
+			Annotations: protected,
+			Expectation: protected,
+		},
+		{
+			Name:        "running without protection",
+			Phase:       workspacev1.WorkspacePhaseRunning,
+			Annotations: map[string]string{"foo": "bar", annotationSafeToEvict: "true"},
+			Expectation: protected,
+			Changed:     true,
+		},
+		{
+			Name:        "pod without annotations",
+			Phase:       workspacev1.WorkspacePhaseCreating,
+			Expectation: map[string]string{annotationSafeToEvict: "false", annotationDoNotDisrupt: "true"},
+			Changed:     true,
+		},
+		{
+			Name:        "stopping",
+			Phase:       workspacev1.WorkspacePhaseStopping,
+			Annotations: protected,
+			Expectation: map[string]string{"foo": "bar"},
+			Changed:     true,
+		},
+		{
+			Name:        "stopped without protection",
+			Phase:       workspacev1.WorkspacePhaseStopped,
+			Annotations: map[string]string{"foo": "bar"},
+			Expectation: map[string]string{"foo": "bar"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			pod := &corev1.Pod{}
+			if test.Annotations != nil {
+				pod.Annotations = make(map[string]string)
+				for k, v := range test.Annotations {
+					pod.Annotations[k] = v
+				}
+			}
+
+			changed := updateScaleDownProtection(pod, test.Phase)
+			if changed != test.Changed {
+				t.Errorf("unexpected change: got %v, want %v", changed, test.Changed)
+			}
+			if diff := cmp.Diff(test.Expectation, pod.Annotations); diff != "" {
+				t.Errorf("unexpected annotations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// updateSchedulingStatus reports why the workspace pod can't be scheduled, if it can't.
+//
+// The scheduler publishes its verdict in the PodScheduled condition of the pod, which carries the same message
+// as the FailedScheduling event, e.g. "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had untolerated taint {foo: bar}."
+func updateSchedulingStatus(ws *workspacev1.Workspace, pod *corev1.Pod) {
+	var scheduled *corev1.PodCondition
+	for i, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled {
+			scheduled = &pod.Status.Conditions[i]
+			break
+		}
+	}
+	if scheduled == nil {
+		return
+	}
+
+	if scheduled.Status == corev1.ConditionTrue {
+		if ws.IsConditionTrue(workspacev1.WorkspaceConditionUnschedulable) {
+			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionUnschedulable(metav1.ConditionFalse, workspacev1.ReasonScheduled, ""))
+		}
+		return
+	}
+	if scheduled.Reason != corev1.PodReasonUnschedulable {
+		return
+	}
+
+	reason, message := diagnoseScheduling(scheduled.Message)
+	if c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionUnschedulable)); c != nil && c.Status == metav1.ConditionTrue && c.Reason == reason && c.Message == message {
+		return
+	}
+	ws.Status.SetCondition(workspacev1.NewWorkspaceConditionUnschedulable(metav1.ConditionTrue, reason, message))
+}
+
+// diagnoseScheduling turns the message of the scheduler into a reason and a message users can make sense of.
+// If several causes keep nodes from running the workspace, the one which applies to most nodes wins.
+func diagnoseScheduling(msg string) (reason string, message string) {
+	summary := msg
+	if i := strings.Index(summary, " preemption:"); i >= 0 {
+		summary = summary[:i]
+	}
+	summary = strings.TrimSpace(summary)
+
+	_, causes, ok := strings.Cut(summary, "nodes are available:")
+	if !ok {
+		return workspacev1.ReasonUnschedulable, fmt.Sprintf("Waiting for a node to run the workspace: %s", summary)
+	}
+
+	var (
+		nodes     = make(map[string]int)
+		resources []string
+	)
+	for _, cause := range strings.Split(strings.TrimSuffix(strings.TrimSpace(causes), "."), ", ") {
+		cnt, text, ok := strings.Cut(strings.TrimSpace(cause), " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(cnt)
+		if err != nil {
+			continue
+		}
+
+		var r string
+		switch {
+		case strings.HasPrefix(text, "Insufficient "):
+			r = workspacev1.ReasonInsufficientResources
+			resources = append(resources, strings.TrimPrefix(text, "Insufficient "))
+		case strings.Contains(text, "untolerated taint"), strings.Contains(text, "were unschedulable"):
+			r = workspacev1.ReasonUntoleratedTaint
+		case strings.Contains(text, "volume node affinity conflict"):
+			r = workspacev1.ReasonVolumeNodeAffinityConflict
+		case strings.Contains(text, "didn't match Pod's node affinity"):
+			r = workspacev1.ReasonNodeAffinityMismatch
+		default:
+			continue
+		}
+		nodes[r] += n
+	}
+
+	// the order breaks ties between causes which apply to the same number of nodes
+	reason = workspacev1.ReasonUnschedulable
+	var most int
+	for _, r := range []string{
+		workspacev1.ReasonInsufficientResources,
+		workspacev1.ReasonVolumeNodeAffinityConflict,
+		workspacev1.ReasonNodeAffinityMismatch,
+		workspacev1.ReasonUntoleratedTaint,
+	} {
+		if nodes[r] > most {
+			reason, most = r, nodes[r]
+		}
+	}
+
+	switch reason {
+	case workspacev1.ReasonInsufficientResources:
+		sort.Strings(resources)
+		message = fmt.Sprintf("No node has enough %s left for the workspace. The cluster might be scaling up, which can take a few minutes.", strings.Join(resources, ", "))
+	case workspacev1.ReasonVolumeNodeAffinityConflict:
+		message = "The workspace volume can't be attached to any of the available nodes, e.g. because they are in another zone."
+	case workspacev1.ReasonNodeAffinityMismatch:
+		message = "No node for the workspace class is available. The cluster might be scaling up, which can take a few minutes."
+	case workspacev1.ReasonUntoleratedTaint:
+		message = "All nodes are reserved for other workloads or are being drained. The cluster might be scaling up, which can take a few minutes."
+	default:
+		message = "Waiting for a node to run the workspace."
+	}
+	return reason, fmt.Sprintf("%s (%s)", message, summary)
+}
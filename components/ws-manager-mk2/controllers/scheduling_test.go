@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestDiagnoseScheduling(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Message         string
+		Reason          string
+		MessageContains string
+	}{
+		{
+			Name:            "insufficient resources",
+			Message:         "0/3 nodes are available: 1 Insufficient memory, 2 Insufficient cpu. preemption: 0/3 nodes are available: 3 No preemption victims found for incoming pod.",
+			Reason:          workspacev1.ReasonInsufficientResources,
+			MessageContains: "No node has enough cpu, memory left",
+		},
+		{
+			Name:            "untolerated taint",
+			Message:         "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had untolerated taint {gitpod.io/gpu: }. preemption: 0/3 nodes are available: 3 Preemption is not helpful for scheduling.",
+			Reason:          workspacev1.ReasonUntoleratedTaint,
+			MessageContains: "reserved for other workloads",
+		},
+		{
+			Name:            "node affinity",
+			Message:         "0/2 nodes are available: 2 node(s) didn't match Pod's node affinity/selector.",
+			Reason:          workspacev1.ReasonNodeAffinityMismatch,
+			MessageContains: "No node for the workspace class",
+		},
+		{
+			Name:            "volume node affinity conflict",
+			Message:         "0/2 nodes are available: 2 node(s) had volume node affinity conflict.",
+			Reason:          workspacev1.ReasonVolumeNodeAffinityConflict,
+			MessageContains: "workspace volume can't be attached",
+		},
+		{
+			Name:            "ties prefer resources",
+			Message:         "0/2 nodes are available: 1 Insufficient cpu, 1 node(s) had untolerated taint {foo: bar}.",
+			Reason:          workspacev1.ReasonInsufficientResources,
+			MessageContains: "No node has enough cpu left",
+		},
+		{
+			Name:            "unknown cause",
+			Message:         "0/1 nodes are available: 1 node(s) had something else going on.",
+			Reason:          workspacev1.ReasonUnschedulable,
+			MessageContains: "Waiting for a node",
+		},
+		{
+			Name:            "unknown format",
+			Message:         "no nodes available to schedule pods",
+			Reason:          workspacev1.ReasonUnschedulable,
+			MessageContains: "no nodes available to schedule pods",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			reason, message := diagnoseScheduling(test.Message)
+			if reason != test.Reason {
+				t.Errorf("expected reason %s, got %s", test.Reason, reason)
+			}
+			if !strings.Contains(message, test.MessageContains) {
+				t.Errorf("expected message to contain %q, got %q", test.MessageContains, message)
+			}
+			if strings.Contains(message, "preemption") {
+				t.Errorf("expected message without the preemption details, got %q", message)
+			}
+		})
+	}
+}
+
+func TestUpdateSchedulingStatus(t *testing.T) {
+	ws := &workspacev1.Workspace{}
+	pod := &corev1.Pod{}
+	pod.Status.Conditions = []corev1.PodCondition{{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "0/1 nodes are available: 1 Insufficient cpu.",
+	}}
+
+	updateSchedulingStatus(ws, pod)
+	c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionUnschedulable))
+	if c == nil || c.Status != metav1.ConditionTrue || c.Reason != workspacev1.ReasonInsufficientResources {
+		t.Fatalf("expected workspace to be unschedulable due to insufficient resources, got %v", c)
+	}
+
+	pod.Status.Conditions[0].Status = corev1.ConditionTrue
+	pod.Status.Conditions[0].Reason = ""
+	updateSchedulingStatus(ws, pod)
+	c = wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionUnschedulable))
+	if c == nil || c.Status != metav1.ConditionFalse || c.Reason != workspacev1.ReasonScheduled {
+		t.Fatalf("expected workspace to be scheduled, got %v", c)
+	}
+}
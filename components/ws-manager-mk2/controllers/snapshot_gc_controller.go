@@ -0,0 +1,175 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	snapshotsDeletedTotal string = "snapshots_deleted_total"
+
+	// SnapshotDeletionReasonTTL means the snapshot was deleted because it outlived the TTL
+	SnapshotDeletionReasonTTL = "ttl"
+	// SnapshotDeletionReasonCount means the snapshot was deleted because its workspace has newer snapshots
+	// in excess of the maximum per workspace
+	SnapshotDeletionReasonCount = "count"
+
+	// snapshotRestoreRequeue is how often we check if a workspace restored a snapshot which is due for deletion
+	snapshotRestoreRequeue = time.Minute
+)
+
+func NewSnapshotGCReconciler(c client.Client, cfg config.SnapshotRetention, reg prometheus.Registerer) (*SnapshotGCReconciler, error) {
+	if !cfg.Enabled() {
+		return nil, fmt.Errorf("snapshot retention is not configured")
+	}
+
+	r := &SnapshotGCReconciler{
+		Client: c,
+		Config: cfg,
+		deletedCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      snapshotsDeletedTotal,
+			Help:      "total number of snapshots deleted by the snapshot garbage collection",
+		}, []string{"reason"}),
+	}
+	reg.MustRegister(r.deletedCounterVec)
+
+	return r, nil
+}
+
+// SnapshotGCReconciler deletes snapshots once they outlived the TTL, or once their workspace has more
+// snapshots than we keep. Snapshots which are still being taken are never deleted, and don't count towards
+// the maximum per workspace.
+//
+// The content of a snapshot is left in remote storage, because the server may still use it, e.g. for a
+// shared snapshot or a prebuild. The retention of content-service deletes it once nothing references it.
+type SnapshotGCReconciler struct {
+	client.Client
+
+	Config config.SnapshotRetention
+
+	deletedCounterVec *prometheus.CounterVec
+}
+
+//+kubebuilder:rbac:groups=workspace.gitpod.io,resources=snapshots,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=workspace.gitpod.io,resources=workspaces,verbs=get;list;watch
+
+// Reconcile garbage collects the snapshots of the workspace the snapshot belongs to. If a snapshot
+// is kept, the request is requeued for when it expires.
+func (r *SnapshotGCReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("snapshot", req.NamespacedName)
+
+	var snapshot workspacev1.Snapshot
+	if err := r.Get(ctx, req.NamespacedName, &snapshot); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var list workspacev1.SnapshotList
+	if err := r.List(ctx, &list, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var workspaces workspacev1.WorkspaceList
+	if err := r.List(ctx, &workspaces, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	restoring := restoringSnapshots(workspaces.Items)
+
+	var snapshots []workspacev1.Snapshot
+	for _, s := range list.Items {
+		if s.Status.Completed && s.DeletionTimestamp == nil && snapshotWorkspace(&s) == snapshotWorkspace(&snapshot) {
+			snapshots = append(snapshots, s)
+		}
+	}
+	// newest first, such that we keep the most recent snapshots of a workspace
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[j].CreationTimestamp.Before(&snapshots[i].CreationTimestamp)
+	})
+
+	var (
+		now         = time.Now()
+		nextExpiry  time.Duration
+		deletionErr error
+	)
+	for i := range snapshots {
+		s := &snapshots[i]
+
+		reason := ""
+		if r.Config.MaxPerWorkspace > 0 && i >= r.Config.MaxPerWorkspace {
+			reason = SnapshotDeletionReasonCount
+		} else if r.Config.TTL > 0 {
+			expiresIn := s.CreationTimestamp.Add(time.Duration(r.Config.TTL)).Sub(now)
+			if expiresIn <= 0 {
+				reason = SnapshotDeletionReasonTTL
+			} else if nextExpiry == 0 || expiresIn < nextExpiry {
+				nextExpiry = expiresIn
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		if _, ok := restoring[s.Name]; ok {
+			// a workspace is about to restore the snapshot, try again once it did
+			if nextExpiry == 0 || snapshotRestoreRequeue < nextExpiry {
+				nextExpiry = snapshotRestoreRequeue
+			}
+			continue
+		}
+
+		err := r.deleteSnapshot(ctx, s)
+		if err != nil {
+			log.Error(err, "cannot delete snapshot", "name", s.Name, "reason", reason)
+			deletionErr = err
+			continue
+		}
+		log.Info("deleted snapshot", "name", s.Name, "reason", reason)
+		r.deletedCounterVec.WithLabelValues(reason).Inc()
+	}
+	if deletionErr != nil {
+		return ctrl.Result{}, deletionErr
+	}
+
+	return ctrl.Result{RequeueAfter: nextExpiry}, nil
+}
+
+// deleteSnapshot deletes the snapshot, but not its content.
+func (r *SnapshotGCReconciler) deleteSnapshot(ctx context.Context, s *workspacev1.Snapshot) error {
+	err := r.Delete(ctx, s)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// snapshotWorkspace returns the workspace whose snapshots are garbage collected together.
+func snapshotWorkspace(s *workspacev1.Snapshot) string {
+	if id := s.Labels[wsk8s.MetaIDLabel]; id != "" {
+		return id
+	}
+	return s.Spec.WorkspaceID
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SnapshotGCReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("snapshot-gc").
+		For(&workspacev1.Snapshot{}).
+		Complete(r)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestSnapshotGC(t *testing.T) {
+	now := time.Now()
+	snapshot := func(name, workspaceID string, age time.Duration, completed bool) *workspacev1.Snapshot {
+		s := &workspacev1.Snapshot{}
+		s.Name = name
+		s.Namespace = "default"
+		s.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		s.Labels = map[string]string{
+			wsk8s.OwnerLabel:  "owner",
+			wsk8s.MetaIDLabel: workspaceID,
+		}
+		s.Spec.WorkspaceID = workspaceID + "-instance"
+		s.Status.Completed = completed
+		if completed {
+			s.Status.Phase = workspacev1.SnapshotPhaseCompleted
+			s.Status.URL = "owner/workspaces/" + workspaceID + "/" + name + ".tar@gitpod-owner"
+		}
+		return s
+	}
+
+	tests := []struct {
+		Name            string
+		Retention       config.SnapshotRetention
+		Objects         []client.Object
+		Remaining       []string
+		RequeueUpToHour bool
+	}{
+		{
+			Name:      "expired snapshot",
+			Retention: config.SnapshotRetention{TTL: util.Duration(time.Hour)},
+			Objects: []client.Object{
+				snapshot("new", "ws1", 10*time.Minute, true),
+				snapshot("old", "ws1", 2*time.Hour, true),
+			},
+			Remaining:       []string{"new"},
+			RequeueUpToHour: true,
+		},
+		{
+			Name:      "snapshot in progress",
+			Retention: config.SnapshotRetention{TTL: util.Duration(time.Hour)},
+			Objects: []client.Object{
+				snapshot("new", "ws1", 10*time.Minute, true),
+				snapshot("old", "ws1", 2*time.Hour, false),
+			},
+			Remaining:       []string{"new", "old"},
+			RequeueUpToHour: true,
+		},
+		{
+			Name:      "too many snapshots",
+			Retention: config.SnapshotRetention{MaxPerWorkspace: 2},
+			Objects: []client.Object{
+				snapshot("new", "ws1", 10*time.Minute, true),
+				snapshot("older", "ws1", 20*time.Minute, true),
+				snapshot("oldest", "ws1", 30*time.Minute, true),
+				snapshot("other", "ws2", 40*time.Minute, true),
+				snapshot("in-progress", "ws1", time.Minute, false),
+			},
+			Remaining: []string{"in-progress", "new", "older", "other"},
+		},
+		{
+			Name:      "snapshot being restored",
+			Retention: config.SnapshotRetention{TTL: util.Duration(time.Hour)},
+			Objects: []client.Object{
+				snapshot("new", "ws1", 10*time.Minute, true),
+				snapshot("old", "ws1", 2*time.Hour, true),
+				func() client.Object {
+					ws := &workspacev1.Workspace{}
+					ws.Name = "restoring"
+					ws.Namespace = "default"
+					ws.Spec.RestoreSnapshot = "old"
+					ws.Status.Phase = workspacev1.WorkspacePhaseInitializing
+					return ws
+				}(),
+			},
+			Remaining:       []string{"new", "old"},
+			RequeueUpToHour: true,
+		},
+		{
+			Name:      "failed snapshot without content",
+			Retention: config.SnapshotRetention{TTL: util.Duration(time.Hour)},
+			Objects: []client.Object{
+				func() client.Object {
+					s := snapshot("new", "ws1", 2*time.Hour, true)
+					s.Status.Phase = workspacev1.SnapshotPhaseFailed
+					return s
+				}(),
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.Objects...).Build()
+			r, err := NewSnapshotGCReconciler(c, test.Retention, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "new"}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requeues := res.RequeueAfter > 0 && res.RequeueAfter <= time.Hour; requeues != test.RequeueUpToHour {
+				t.Errorf("unexpected requeue after %s", res.RequeueAfter)
+			}
+
+			var list workspacev1.SnapshotList
+			if err := c.List(context.Background(), &list); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var remaining []string
+			for _, s := range list.Items {
+				remaining = append(remaining, s.Name)
+			}
+			sort.Strings(remaining)
+			if diff := cmp.Diff(test.Remaining, remaining); diff != "" {
+				t.Errorf("unexpected remaining snapshots (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
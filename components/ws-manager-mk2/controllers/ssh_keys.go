@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	sshPublicKeysVolumeName = "ssh-public-keys"
+	// sshPublicKeysDir is where the authorized SSH public keys of the workspace are mounted in the workspace
+	// container. Supervisor watches the authorized_keys file in there, which follows the pod annotation.
+	sshPublicKeysDir  = "/.workspace-ssh"
+	sshPublicKeysFile = "authorized_keys"
+)
+
+// sshPublicKeysAnnotation renders the SSH public keys of the workspace in the authorized_keys format.
+func sshPublicKeysAnnotation(ws *workspacev1.Workspace) string {
+	var keys []string
+	for _, k := range ws.Spec.SshPublicKeys {
+		// a key must not span several lines, otherwise it could smuggle in options for other keys
+		k = strings.TrimSpace(k)
+		if k == "" || strings.ContainsAny(k, "\r\n") {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return strings.Join(keys, "\n") + "\n"
+}
+
+// updateSSHPublicKeys updates the SSH public keys annotation of the workspace pod to match the workspace spec.
+// Returns true if the pod was changed.
+func updateSSHPublicKeys(pod *corev1.Pod, ws *workspacev1.Workspace) (changed bool) {
+	keys := sshPublicKeysAnnotation(ws)
+	if current, exists := pod.Annotations[wsk8s.WorkspaceSSHPublicKeys]; exists && current == keys {
+		return false
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[wsk8s.WorkspaceSSHPublicKeys] = keys
+	return true
+}
+
+// createSSHPublicKeysVolume produces the downward API volume which exposes the SSH public keys annotation to the
+// workspace. The kubelet updates its content while the workspace is running.
+func createSSHPublicKeysVolume() (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: sshPublicKeysVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{
+					{
+						Path: sshPublicKeysFile,
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.annotations['" + wsk8s.WorkspaceSSHPublicKeys + "']",
+						},
+					},
+				},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      sshPublicKeysVolumeName,
+		MountPath: sshPublicKeysDir,
+		ReadOnly:  true,
+	}
+	return volume, mount
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestUpdateSSHPublicKeys(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Keys        []string
+		Annotations map[string]string
+		Expectation string
+		Changed     bool
+	}{
+		{
+			Name:        "no keys",
+			Annotations: map[string]string{wsk8s.WorkspaceSSHPublicKeys: ""},
+		},
+		{
+			Name:        "missing annotation",
+			Keys:        []string{"ssh-ed25519 AAAA foo"},
+			Expectation: "ssh-ed25519 AAAA foo\n",
+			Changed:     true,
+		},
+		{
+			Name:        "key added",
+			Keys:        []string{"ssh-ed25519 AAAA foo", "ssh-rsa BBBB bar "},
+			Annotations: map[string]string{wsk8s.WorkspaceSSHPublicKeys: "ssh-ed25519 AAAA foo\n"},
+			Expectation: "ssh-ed25519 AAAA foo\nssh-rsa BBBB bar\n",
+			Changed:     true,
+		},
+		{
+			Name:        "up to date",
+			Keys:        []string{"ssh-ed25519 AAAA foo"},
+			Annotations: map[string]string{wsk8s.WorkspaceSSHPublicKeys: "ssh-ed25519 AAAA foo\n"},
+			Expectation: "ssh-ed25519 AAAA foo\n",
+		},
+		{
+			Name:        "multi-line key",
+			Keys:        []string{"ssh-ed25519 AAAA foo\ncommand=\"evil\" ssh-rsa BBBB"},
+			Annotations: map[string]string{wsk8s.WorkspaceSSHPublicKeys: "ssh-ed25519 AAAA foo\n"},
+			Changed:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &workspacev1.Workspace{}
+			ws.Spec.SshPublicKeys = test.Keys
+			pod := &corev1.Pod{}
+			pod.Annotations = test.Annotations
+
+			changed := updateSSHPublicKeys(pod, ws)
+			if changed != test.Changed {
+				t.Errorf("expected changed to be %v, got %v", test.Changed, changed)
+			}
+			if diff := cmp.Diff(test.Expectation, pod.Annotations[wsk8s.WorkspaceSSHPublicKeys]); diff != "" {
+				t.Errorf("unexpected annotation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
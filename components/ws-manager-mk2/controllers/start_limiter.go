@@ -0,0 +1,275 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// startLimitRequeue is the interval in which workspaces held back by the start limiter try to start again
+	startLimitRequeue = 5 * time.Second
+
+	// startAdmissionTTL is how long we remember admitting a workspace whose pod we might not see in the cache yet
+	startAdmissionTTL = 30 * time.Second
+)
+
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+// startLimiter caps the number of workspaces which start concurrently on a node. Starting many workspaces
+// at once on the same node, e.g. a freshly added one, makes their image pulls and content restores compete.
+// It also enforces the capacity of the cluster, i.e. how many workspaces may run per node and in total.
+//
+// We don't schedule the workspace pods ourselves, hence we can't tell on which node a workspace will start.
+// Instead, a workspace may only start while the nodes have room for more starts than there are workspace pods
+// waiting to be scheduled, and its pod avoids the nodes which start too many workspaces already.
+type startLimiter struct {
+	client     client.Client
+	maxPerNode int
+	capacity   config.CapacityConfiguration
+	namespace  string
+	classes    map[string]*config.WorkspaceClass
+
+	// mu serialises admissions, such that concurrent reconciliations don't admit more workspaces than there's room for
+	mu sync.Mutex
+	// admitted are the workspaces we admitted recently, by name, whose pod may not be in the cache yet
+	admitted map[string]time.Time
+}
+
+func newStartLimiter(c client.Client, maxPerNode int, capacity config.CapacityConfiguration, namespace string, classes map[string]*config.WorkspaceClass) *startLimiter {
+	return &startLimiter{
+		client:     c,
+		maxPerNode: maxPerNode,
+		capacity:   capacity,
+		namespace:  namespace,
+		classes:    classes,
+		admitted:   make(map[string]time.Time),
+	}
+}
+
+// startAdmission is the decision of the start limiter about a workspace
+type startAdmission struct {
+	// Admitted is true if the workspace may start now
+	Admitted bool
+	// Reason explains why the workspace was not admitted
+	Reason string
+	// ConditionReason is the reason of the Pending condition of a workspace which was not admitted
+	ConditionReason string
+	// AvoidNodes are the nodes the workspace must not start on, as they start too many workspaces already
+	AvoidNodes []string
+}
+
+// Admit decides if a workspace may start now. Once admitted, a workspace counts against the start limit until
+// it is running or stopping, and against the capacity until it is stopping.
+func (l *startLimiter) Admit(ctx context.Context, ws *workspacev1.Workspace) (*startAdmission, error) {
+	if l == nil || (l.maxPerNode <= 0 && l.capacity.MaxWorkspaces <= 0 && l.capacity.MaxWorkspacesPerNode <= 0) {
+		return &startAdmission{Admitted: true}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var workspaces workspacev1.WorkspaceList
+	if err := l.client.List(ctx, &workspaces, client.InNamespace(l.namespace)); err != nil {
+		return nil, fmt.Errorf("cannot list workspaces: %w", err)
+	}
+
+	var (
+		startingPerNode = make(map[string]int)
+		activePerNode   = make(map[string]int)
+		unscheduled     int
+		active          int
+		known           = make(map[string]struct{}, len(workspaces.Items))
+	)
+	for _, other := range workspaces.Items {
+		if other.Name == ws.Name || other.Status.PodStarts == 0 {
+			// Without a pod the workspace is not starting on any node yet, unless we just admitted it.
+			continue
+		}
+		known[other.Name] = struct{}{}
+
+		var nodeName string
+		if other.Status.Runtime != nil {
+			nodeName = other.Status.Runtime.NodeName
+		}
+		if isActive(&other) {
+			active++
+			if nodeName != "" {
+				activePerNode[nodeName]++
+			}
+		}
+		if !isStarting(&other) {
+			continue
+		}
+
+		if nodeName != "" {
+			startingPerNode[nodeName]++
+		} else {
+			unscheduled++
+		}
+	}
+	for name, admittedAt := range l.admitted {
+		if _, ok := known[name]; ok || name == ws.Name || time.Since(admittedAt) > startAdmissionTTL {
+			// The cache caught up with the workspace, or it's not starting anymore.
+			delete(l.admitted, name)
+			continue
+		}
+		unscheduled++
+		active++
+	}
+
+	if l.capacity.MaxWorkspaces > 0 && active >= l.capacity.MaxWorkspaces {
+		return &startAdmission{
+			Reason:          fmt.Sprintf("cluster at capacity (%d workspaces running, at most %d allowed)", active, l.capacity.MaxWorkspaces),
+			ConditionReason: workspacev1.ReasonClusterAtCapacity,
+		}, nil
+	}
+
+	var nodes corev1.NodeList
+	if err := l.client.List(ctx, &nodes); err != nil {
+		return nil, fmt.Errorf("cannot list nodes: %w", err)
+	}
+
+	var (
+		room       int
+		candidates int
+		avoidNodes []string
+	)
+	for _, node := range nodes.Items {
+		if !isWorkspaceNode(&node, ws, l.namespace, l.classes[ws.Spec.Class]) {
+			continue
+		}
+		candidates++
+		if l.capacity.MaxWorkspacesPerNode > 0 && activePerNode[node.Name] >= l.capacity.MaxWorkspacesPerNode {
+			// The node is full, the workspace has to go to another node, or a new one if the cluster scales up.
+			avoidNodes = append(avoidNodes, node.Name)
+			continue
+		}
+		if l.maxPerNode <= 0 {
+			continue
+		}
+		free := l.maxPerNode - startingPerNode[node.Name]
+		if free <= 0 {
+			avoidNodes = append(avoidNodes, node.Name)
+			continue
+		}
+		room += free
+	}
+	if l.maxPerNode > 0 {
+		if candidates == 0 {
+			// There's no node for the workspace yet, the cluster has to scale up. We expect a fresh node,
+			// which all workspaces waiting for scheduling would end up on.
+			room = l.maxPerNode
+		}
+
+		if unscheduled >= room {
+			return &startAdmission{
+				Reason:          fmt.Sprintf("waiting for nodes to finish starting other workspaces (%d workspaces waiting to be scheduled, at most %d concurrent starts per node)", unscheduled, l.maxPerNode),
+				ConditionReason: workspacev1.ReasonNodeStartLimit,
+			}, nil
+		}
+	}
+
+	l.admitted[ws.Name] = time.Now()
+	sort.Strings(avoidNodes)
+	return &startAdmission{Admitted: true, AvoidNodes: avoidNodes}, nil
+}
+
+// isStarting returns true if the workspace is on its way to running
+func isStarting(ws *workspacev1.Workspace) bool {
+	switch ws.Status.Phase {
+	case "", workspacev1.WorkspacePhasePending, workspacev1.WorkspacePhaseCreating, workspacev1.WorkspacePhaseInitializing:
+		return true
+	default:
+		return false
+	}
+}
+
+// isActive returns true if the workspace counts against the capacity of the cluster, i.e. is neither stopping nor stopped
+func isActive(ws *workspacev1.Workspace) bool {
+	return ws.Status.Phase != workspacev1.WorkspacePhaseStopping && ws.Status.Phase != workspacev1.WorkspacePhaseStopped
+}
+
+// isWorkspaceNode returns true if the node can run the workspace, i.e. matches the node affinity of its pod
+// and the pod tolerates the node's taints
+func isWorkspaceNode(node *corev1.Node, ws *workspacev1.Workspace, namespace string, class *config.WorkspaceClass) bool {
+	workloadType := "regular"
+	if ws.IsHeadless() {
+		workloadType = "headless"
+	}
+
+	for _, label := range []string{
+		"gitpod.io/workload_workspace_" + workloadType,
+		"gitpod.io/ws-daemon_ready_ns_" + namespace,
+		"gitpod.io/registry-facade_ready_ns_" + namespace,
+	} {
+		if _, ok := node.Labels[label]; !ok {
+			return false
+		}
+	}
+
+	var tolerations []corev1.Toleration
+	if class != nil {
+		for _, req := range class.NodeAffinity {
+			if !matchesNodeSelectorRequirement(node, req) {
+				return false
+			}
+		}
+		tolerations = class.Tolerations
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule {
+			// The pods tolerate the NoExecute taints which matter for workspaces by default
+			continue
+		}
+
+		var tolerated bool
+		for _, tol := range tolerations {
+			if tol.ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// matchesNodeSelectorRequirement returns true if the labels of the node satisfy the requirement
+func matchesNodeSelectorRequirement(node *corev1.Node, req corev1.NodeSelectorRequirement) bool {
+	op, ok := nodeSelectorOperators[req.Operator]
+	if !ok {
+		return false
+	}
+	r, err := labels.NewRequirement(req.Key, op, req.Values)
+	if err != nil {
+		return false
+	}
+	return r.Matches(labels.Set(node.Labels))
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestStartLimiterAdmit(t *testing.T) {
+	node := func(name string) *corev1.Node {
+		n := &corev1.Node{}
+		n.Name = name
+		n.Labels = map[string]string{
+			"gitpod.io/workload_workspace_regular":       "true",
+			"gitpod.io/ws-daemon_ready_ns_default":       "true",
+			"gitpod.io/registry-facade_ready_ns_default": "true",
+		}
+		return n
+	}
+	gpuNode := func(name string) *corev1.Node {
+		n := node(name)
+		n.Labels["gitpod.io/pool"] = "gpu"
+		n.Spec.Taints = []corev1.Taint{{Key: "gitpod.io/gpu", Effect: corev1.TaintEffectNoSchedule}}
+		return n
+	}
+	gpuClass := &config.WorkspaceClass{
+		NodeAffinity: []corev1.NodeSelectorRequirement{{Key: "gitpod.io/pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}}},
+		Tolerations:  []corev1.Toleration{{Key: "gitpod.io/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+	}
+	starting := func(name, nodeName string, phase workspacev1.WorkspacePhase) *workspacev1.Workspace {
+		ws := &workspacev1.Workspace{}
+		ws.Name = name
+		ws.Namespace = "default"
+		ws.Spec.Type = workspacev1.WorkspaceTypeRegular
+		ws.Status.Phase = phase
+		ws.Status.PodStarts = 1
+		if nodeName != "" {
+			ws.Status.Runtime = &workspacev1.WorkspaceRuntimeStatus{NodeName: nodeName}
+		}
+		return ws
+	}
+
+	tests := []struct {
+		Name       string
+		MaxPerNode int
+		Capacity   config.CapacityConfiguration
+		Class      *config.WorkspaceClass
+		Objects    []client.Object
+		Admitted   bool
+		Reason     string
+		AvoidNodes []string
+	}{
+		{
+			Name:       "unlimited",
+			MaxPerNode: 0,
+			Objects:    []client.Object{node("a"), starting("ws1", "a", workspacev1.WorkspacePhaseCreating)},
+			Admitted:   true,
+		},
+		{
+			Name:       "room on node",
+			MaxPerNode: 2,
+			Objects:    []client.Object{node("a"), starting("ws1", "a", workspacev1.WorkspacePhaseCreating)},
+			Admitted:   true,
+		},
+		{
+			Name:       "avoids saturated node",
+			MaxPerNode: 1,
+			Objects: []client.Object{
+				node("a"), node("b"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseInitializing),
+			},
+			Admitted:   true,
+			AvoidNodes: []string{"a"},
+		},
+		{
+			Name:       "all nodes saturated",
+			MaxPerNode: 1,
+			Objects: []client.Object{
+				node("a"), node("b"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseCreating),
+				starting("ws2", "b", workspacev1.WorkspacePhasePending),
+			},
+			Admitted: false,
+			Reason:   workspacev1.ReasonNodeStartLimit,
+		},
+		{
+			Name:       "running workspaces don't count",
+			MaxPerNode: 1,
+			Objects: []client.Object{
+				node("a"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseRunning),
+			},
+			Admitted: true,
+		},
+		{
+			Name:       "unscheduled workspaces take the room",
+			MaxPerNode: 2,
+			Objects: []client.Object{
+				node("a"),
+				starting("ws1", "", workspacev1.WorkspacePhasePending),
+				starting("ws2", "", workspacev1.WorkspacePhasePending),
+			},
+			Admitted: false,
+			Reason:   workspacev1.ReasonNodeStartLimit,
+		},
+		{
+			Name:       "limit applies per node",
+			MaxPerNode: 1,
+			Objects: []client.Object{
+				node("a"), node("b"), node("c"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseCreating),
+				starting("ws2", "", workspacev1.WorkspacePhasePending),
+			},
+			Admitted:   true,
+			AvoidNodes: []string{"a"},
+		},
+		{
+			Name:       "tainted nodes are no candidates",
+			MaxPerNode: 1,
+			Objects: []client.Object{
+				node("a"), gpuNode("b"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseCreating),
+			},
+			Admitted: false,
+			Reason:   workspacev1.ReasonNodeStartLimit,
+		},
+		{
+			Name:       "class restricted to a node pool",
+			MaxPerNode: 1,
+			Class:      gpuClass,
+			Objects: []client.Object{
+				node("a"), gpuNode("b"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseCreating),
+			},
+			Admitted: true,
+		},
+		{
+			Name:       "no nodes yet",
+			MaxPerNode: 1,
+			Admitted:   true,
+		},
+		{
+			Name:     "cluster at capacity",
+			Capacity: config.CapacityConfiguration{MaxWorkspaces: 2},
+			Objects: []client.Object{
+				node("a"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseRunning),
+				starting("ws2", "a", workspacev1.WorkspacePhaseCreating),
+			},
+			Admitted: false,
+			Reason:   workspacev1.ReasonClusterAtCapacity,
+		},
+		{
+			Name:     "stopping workspaces free capacity",
+			Capacity: config.CapacityConfiguration{MaxWorkspaces: 2},
+			Objects: []client.Object{
+				node("a"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseRunning),
+				starting("ws2", "a", workspacev1.WorkspacePhaseStopping),
+			},
+			Admitted: true,
+		},
+		{
+			Name:     "avoids full node",
+			Capacity: config.CapacityConfiguration{MaxWorkspacesPerNode: 1},
+			Objects: []client.Object{
+				node("a"), node("b"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseRunning),
+			},
+			Admitted:   true,
+			AvoidNodes: []string{"a"},
+		},
+		{
+			Name:     "all nodes full",
+			Capacity: config.CapacityConfiguration{MaxWorkspacesPerNode: 1},
+			Objects: []client.Object{
+				node("a"),
+				starting("ws1", "a", workspacev1.WorkspacePhaseRunning),
+			},
+			Admitted:   true,
+			AvoidNodes: []string{"a"},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.Objects...).Build()
+			limiter := newStartLimiter(c, test.MaxPerNode, test.Capacity, "default", map[string]*config.WorkspaceClass{"default": test.Class})
+
+			ws := &workspacev1.Workspace{}
+			ws.Name = "new"
+			ws.Spec.Type = workspacev1.WorkspaceTypeRegular
+			ws.Spec.Class = "default"
+
+			act, err := limiter.Admit(context.Background(), ws)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act.Admitted != test.Admitted {
+				t.Errorf("expected admitted %v, got %v (%s)", test.Admitted, act.Admitted, act.Reason)
+			}
+			if act.ConditionReason != test.Reason {
+				t.Errorf("expected condition reason %q, got %q", test.Reason, act.ConditionReason)
+			}
+			if diff := cmp.Diff(test.AvoidNodes, act.AvoidNodes); diff != "" {
+				t.Errorf("unexpected avoided nodes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStartLimiterCountsAdmittedWorkspaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	limiter := newStartLimiter(c, 2, config.CapacityConfiguration{}, "default", nil)
+
+	// The pods of admitted workspaces are not visible to the limiter yet, they must still count against the limit.
+	for i := 0; i < 3; i++ {
+		ws := &workspacev1.Workspace{}
+		ws.Name = fmt.Sprintf("ws%d", i)
+
+		act, err := limiter.Admit(context.Background(), ws)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := i < 2; act.Admitted != expected {
+			t.Errorf("workspace %d: expected admitted %v, got %v", i, expected, act.Admitted)
+		}
+	}
+}
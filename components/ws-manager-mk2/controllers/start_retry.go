@@ -0,0 +1,202 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// podReadyToStartContainers is the pod condition the kubelet sets once the pod sandbox and its network are set up
+const podReadyToStartContainers corev1.PodConditionType = "PodReadyToStartContainers"
+
+// isStartRetryable returns true if the workspace hasn't gotten far enough in its start to have any state
+// which would be lost if we recreated its pod.
+func isStartRetryable(ws *workspacev1.Workspace) bool {
+	return wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionContentReady)) == nil &&
+		!ws.IsConditionTrue(workspacev1.WorkspaceConditionEverReady) &&
+		!ws.IsConditionTrue(workspacev1.WorkspaceConditionFailed) &&
+		!ws.IsConditionTrue(workspacev1.WorkspaceConditionStoppedByRequest) &&
+		!ws.IsConditionTrue(workspacev1.WorkspaceConditionAborted) &&
+		!isWorkspaceBeingDeleted(ws)
+}
+
+// retriesStart returns true if the start of the workspace failed for infrastructure reasons, and we have
+// start attempts left to recreate its pod.
+func retriesStart(ws *workspacev1.Workspace, cfg *config.Configuration) bool {
+	return ws.IsConditionTrue(workspacev1.WorkspaceConditionInfrastructureFailure) &&
+		len(ws.Status.StartAttempts) < cfg.StartRetry.MaxAttempts
+}
+
+// checkInfrastructureFailure marks the workspace if its pod failed to start for reasons outside of the workspace.
+func (r *WorkspaceReconciler) checkInfrastructureFailure(ctx context.Context, workspace *workspacev1.Workspace, pod *corev1.Pod, cfg *config.Configuration) (err error) {
+	span, ctx := tracing.FromContext(ctx, "checkInfrastructureFailure")
+	defer tracing.FinishSpan(span, &err)
+
+	if cfg.StartRetry.MaxAttempts == 0 || workspace.IsConditionTrue(workspacev1.WorkspaceConditionInfrastructureFailure) {
+		return nil
+	}
+	if !isStartRetryable(workspace) || isPodBeingDeleted(pod) {
+		return nil
+	}
+
+	reason, message, err := r.detectInfrastructureFailure(ctx, pod, cfg)
+	if err != nil || reason == "" {
+		return err
+	}
+
+	workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionInfrastructureFailure(metav1.ConditionTrue, reason, message))
+	return nil
+}
+
+func (r *WorkspaceReconciler) detectInfrastructureFailure(ctx context.Context, pod *corev1.Pod, cfg *config.Configuration) (reason, message string, err error) {
+	if pod.Spec.NodeName == "" {
+		// Scheduling issues are reported by the Unschedulable condition, there's nothing to retry.
+		return "", "", nil
+	}
+
+	if pod.Status.Phase == corev1.PodFailed && isPodRejected(pod.Status.Reason) {
+		return workspacev1.ReasonPodRejected, fmt.Sprintf("node %s rejected the workspace pod: %s", pod.Spec.NodeName, pod.Status.Message), nil
+	}
+	if pod.Status.Phase != corev1.PodPending {
+		return "", "", nil
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		w := cs.State.Waiting
+		if w == nil || (w.Reason != "ErrImagePull" && w.Reason != "ImagePullBackOff") {
+			continue
+		}
+		if strings.HasPrefix(cs.Image, cfg.RegistryFacadeHost+"/") && isTimeoutMessage(w.Message) {
+			return workspacev1.ReasonImagePullTimeout, fmt.Sprintf("pulling image of container %s from registry-facade timed out: %s", cs.Name, w.Message), nil
+		}
+	}
+
+	var node corev1.Node
+	err = r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node)
+	if apierrors.IsNotFound(err) {
+		return workspacev1.ReasonNodeNotReady, fmt.Sprintf("node %s disappeared", pod.Spec.NodeName), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if !isNodeReady(&node) {
+		return workspacev1.ReasonNodeNotReady, fmt.Sprintf("node %s is not ready", pod.Spec.NodeName), nil
+	}
+
+	timeout := time.Duration(cfg.StartRetry.SandboxTimeout)
+	if timeout == 0 {
+		return "", "", nil
+	}
+	var scheduled, sandboxReady *corev1.PodCondition
+	for i, c := range pod.Status.Conditions {
+		switch c.Type {
+		case corev1.PodScheduled:
+			scheduled = &pod.Status.Conditions[i]
+		case podReadyToStartContainers:
+			sandboxReady = &pod.Status.Conditions[i]
+		}
+	}
+	if scheduled == nil || scheduled.Status != corev1.ConditionTrue || (sandboxReady != nil && sandboxReady.Status == corev1.ConditionTrue) {
+		return "", "", nil
+	}
+	if time.Since(scheduled.LastTransitionTime.Time) > timeout {
+		return workspacev1.ReasonSandboxTimeout, fmt.Sprintf("the sandbox of the workspace pod wasn't set up on node %s within %s", pod.Spec.NodeName, timeout), nil
+	}
+	return "", "", nil
+}
+
+// isPodRejected returns true if the pod status reason is one the kubelet uses when it refuses to admit a pod,
+// e.g. OutOfcpu if the node doesn't have enough CPU left after all.
+func isPodRejected(reason string) bool {
+	return strings.HasPrefix(reason, "OutOf") || reason == "NodeAffinity" || reason == "UnexpectedAdmissionError"
+}
+
+func isTimeoutMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded")
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// deleteFailedStartPod deletes the pod of a workspace whose start failed for infrastructure reasons. Nothing
+// needs to be backed up for such a pod, hence we remove its finalizer right away.
+func (r *WorkspaceReconciler) deleteFailedStartPod(ctx context.Context, ws *workspacev1.Workspace, pod *corev1.Pod) (result ctrl.Result, err error) {
+	span, ctx := tracing.FromContext(ctx, "deleteFailedStartPod")
+	defer tracing.FinishSpan(span, &err)
+
+	if !isPodBeingDeleted(pod) {
+		if err := r.Client.Delete(ctx, pod); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		r.Audit.Record(audit.WorkspaceEvent(ws, audit.ActionPodDeleted, auditActorWorkspaceController, "start failed, retrying"))
+	}
+
+	if controllerutil.ContainsFinalizer(pod, workspacev1.GitpodFinalizerName) {
+		patch := client.MergeFrom(pod.DeepCopy())
+		controllerutil.RemoveFinalizer(pod, workspacev1.GitpodFinalizerName)
+		if err := r.Client.Patch(ctx, pod, patch); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// retryStart records the failed start attempt and resets the status of the workspace, such that its pod is
+// created anew.
+func (r *WorkspaceReconciler) retryStart(ctx context.Context, workspace *workspacev1.Workspace) (result ctrl.Result, err error) {
+	span, ctx := tracing.FromContext(ctx, "retryStart")
+	defer tracing.FinishSpan(span, &err)
+
+	c := wsk8s.GetCondition(workspace.Status.Conditions, string(workspacev1.WorkspaceConditionInfrastructureFailure))
+	if wait := time.Until(c.LastTransitionTime.Add(time.Duration(r.Config.StartRetry.Backoff))); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	attempt := workspacev1.StartAttempt{
+		Time:    metav1.Now(),
+		Reason:  c.Reason,
+		Message: c.Message,
+	}
+	if workspace.Status.Runtime != nil {
+		attempt.NodeName = workspace.Status.Runtime.NodeName
+	}
+
+	patch := client.MergeFrom(workspace.DeepCopy())
+	workspace.Status.StartAttempts = append(workspace.Status.StartAttempts, attempt)
+	workspace.Status.PodStarts = 0
+	workspace.Status.Phase = workspacev1.WorkspacePhasePending
+	workspace.Status.Runtime = nil
+	workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionInfrastructureFailure(metav1.ConditionFalse, c.Reason, ""))
+	if err := r.Status().Patch(ctx, workspace, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Event(workspace, corev1.EventTypeWarning, "StartRetry", fmt.Sprintf("recreating workspace pod (attempt %d): %s", len(workspace.Status.StartAttempts), attempt.Message))
+	return ctrl.Result{Requeue: true}, nil
+}
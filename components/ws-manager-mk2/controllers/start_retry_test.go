@@ -0,0 +1,211 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestDetectInfrastructureFailure(t *testing.T) {
+	readyNode := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		node := &corev1.Node{}
+		node.Name = name
+		node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+		return node
+	}
+	pendingPod := func(mod func(pod *corev1.Pod)) *corev1.Pod {
+		pod := &corev1.Pod{}
+		pod.Spec.NodeName = "node"
+		pod.Status.Phase = corev1.PodPending
+		if mod != nil {
+			mod(pod)
+		}
+		return pod
+	}
+
+	tests := []struct {
+		Name   string
+		Node   *corev1.Node
+		Pod    *corev1.Pod
+		Reason string
+	}{
+		{
+			Name: "healthy start",
+			Node: readyNode("node", corev1.ConditionTrue),
+			Pod:  pendingPod(nil),
+		},
+		{
+			Name: "not scheduled",
+			Pod:  pendingPod(func(pod *corev1.Pod) { pod.Spec.NodeName = "" }),
+		},
+		{
+			Name: "rejected by kubelet",
+			Node: readyNode("node", corev1.ConditionTrue),
+			Pod: pendingPod(func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodFailed
+				pod.Status.Reason = "OutOfcpu"
+			}),
+			Reason: workspacev1.ReasonPodRejected,
+		},
+		{
+			Name:   "node not ready",
+			Node:   readyNode("node", corev1.ConditionUnknown),
+			Pod:    pendingPod(nil),
+			Reason: workspacev1.ReasonNodeNotReady,
+		},
+		{
+			Name:   "node gone",
+			Pod:    pendingPod(nil),
+			Reason: workspacev1.ReasonNodeNotReady,
+		},
+		{
+			Name: "registry-facade pull timeout",
+			Node: readyNode("node", corev1.ConditionTrue),
+			Pod: pendingPod(func(pod *corev1.Pod) {
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "workspace",
+					Image: "reg.gitpod.io:20000/remote/foobar",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+						Reason:  "ErrImagePull",
+						Message: "rpc error: code = DeadlineExceeded desc = context deadline exceeded",
+					}},
+				}}
+			}),
+			Reason: workspacev1.ReasonImagePullTimeout,
+		},
+		{
+			Name: "registry-facade pull error",
+			Node: readyNode("node", corev1.ConditionTrue),
+			Pod: pendingPod(func(pod *corev1.Pod) {
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "workspace",
+					Image: "reg.gitpod.io:20000/remote/foobar",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+						Reason:  "ErrImagePull",
+						Message: "manifest unknown",
+					}},
+				}}
+			}),
+		},
+		{
+			Name: "sandbox timeout",
+			Node: readyNode("node", corev1.ConditionTrue),
+			Pod: pendingPod(func(pod *corev1.Pod) {
+				pod.Status.Conditions = []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Now().Add(-10 * time.Minute))},
+					{Type: podReadyToStartContainers, Status: corev1.ConditionFalse},
+				}
+			}),
+			Reason: workspacev1.ReasonSandboxTimeout,
+		},
+		{
+			Name: "sandbox still within timeout",
+			Node: readyNode("node", corev1.ConditionTrue),
+			Pod: pendingPod(func(pod *corev1.Pod) {
+				pod.Status.Conditions = []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute))},
+				}
+			}),
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var objs []client.Object
+			if test.Node != nil {
+				objs = append(objs, test.Node)
+			}
+			cfg := &config.Configuration{
+				RegistryFacadeHost: "reg.gitpod.io:20000",
+				StartRetry: config.StartRetryConfiguration{
+					MaxAttempts:    2,
+					SandboxTimeout: util.Duration(5 * time.Minute),
+				},
+			}
+			r := &WorkspaceReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(), Config: cfg}
+
+			reason, _, err := r.detectInfrastructureFailure(context.Background(), test.Pod, cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Reason, reason); diff != "" {
+				t.Errorf("unexpected reason (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRetryStart(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := workspacev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := &workspacev1.Workspace{}
+	ws.Name = "foobar"
+	ws.Namespace = "default"
+	ws.Status.PodStarts = 1
+	ws.Status.Phase = workspacev1.WorkspacePhasePending
+	ws.Status.Runtime = &workspacev1.WorkspaceRuntimeStatus{NodeName: "node"}
+	ws.Status.SetCondition(workspacev1.NewWorkspaceConditionInfrastructureFailure(metav1.ConditionTrue, workspacev1.ReasonNodeNotReady, "node node is not ready"))
+
+	cfg := &config.Configuration{StartRetry: config.StartRetryConfiguration{MaxAttempts: 1}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ws).WithStatusSubresource(ws).Build()
+	r := &WorkspaceReconciler{Client: c, Config: cfg, Recorder: record.NewFakeRecorder(10)}
+	if !retriesStart(ws, cfg) {
+		t.Fatal("expected start to be retried")
+	}
+
+	_, err := r.retryStart(context.Background(), ws)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var act workspacev1.Workspace
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: ws.Namespace, Name: ws.Name}, &act); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act.Status.PodStarts != 0 || act.Status.Runtime != nil {
+		t.Errorf("expected the workspace to be reset, got pod starts %d and runtime %v", act.Status.PodStarts, act.Status.Runtime)
+	}
+	if len(act.Status.StartAttempts) != 1 {
+		t.Fatalf("expected one start attempt, got %d", len(act.Status.StartAttempts))
+	}
+	attempt := act.Status.StartAttempts[0]
+	if attempt.Reason != workspacev1.ReasonNodeNotReady || attempt.NodeName != "node" {
+		t.Errorf("unexpected start attempt: %+v", attempt)
+	}
+	if wsk8s.ConditionPresentAndTrue(act.Status.Conditions, string(workspacev1.WorkspaceConditionInfrastructureFailure)) {
+		t.Error("expected the infrastructure failure to be reset")
+	}
+
+	// the workspace is out of start attempts if its start fails again
+	act.Status.SetCondition(workspacev1.NewWorkspaceConditionInfrastructureFailure(metav1.ConditionTrue, workspacev1.ReasonPodRejected, ""))
+	if retriesStart(&act, cfg) {
+		t.Error("expected start not to be retried again")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -45,13 +46,19 @@ func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspa
 	log := log.FromContext(ctx).WithValues("owi", workspace.OWI())
 	ctx = logr.NewContext(ctx, log)
 
+	// Conditions set while computing the status are stamped with the generation they are based on.
+	workspace.Status.ObservedGeneration = workspace.Generation
+
 	oldPhase := workspace.Status.Phase
 	defer func() {
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionReady(workspace.Status.Phase))
 		if oldPhase != workspace.Status.Phase {
 			log.Info("workspace phase updated", "oldPhase", oldPhase, "phase", workspace.Status.Phase)
 		}
 	}()
 
+	workspace.Status.Timeouts = effectiveTimeouts(workspace, cfg)
+
 	switch len(pods.Items) {
 	case 0:
 		if workspace.Status.Phase == "" {
@@ -95,12 +102,25 @@ func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspa
 		workspace.Status.Runtime.PodName = pod.Name
 	}
 
+	if err := r.checkInfrastructureFailure(ctx, workspace, pod, cfg); err != nil {
+		return err
+	}
+	if retriesStart(workspace, cfg) {
+		// The pod gets recreated once it's gone, until then the workspace is still pending.
+		workspace.Status.Phase = workspacev1.WorkspacePhasePending
+		return nil
+	}
+
 	// Check if the node has disappeared. If so, ws-daemon has also disappeared and we need to
 	// mark the workspace backup as failed if it didn't complete disposal yet.
 	// Otherwise, the workspace will be stuck in the Stopping phase forever.
 	if err := r.checkNodeDisappeared(ctx, workspace, pod); err != nil {
 		return err
 	}
+	checkPodEvicted(workspace, pod)
+	checkResourceExhausted(workspace, pod)
+	checkEphemeralStorage(workspace, cfg)
+	updateSchedulingStatus(workspace, pod)
 
 	if workspace.Status.URL == "" {
 		url, err := config.RenderWorkspaceURL(cfg.WorkspaceURLTemplate, workspace.Name, workspace.Spec.Ownership.WorkspaceID, cfg.GitpodHostURL)
@@ -110,6 +130,12 @@ func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspa
 		workspace.Status.URL = url
 	}
 
+	ports, err := exposedPorts(workspace, cfg)
+	if err != nil {
+		return err
+	}
+	workspace.Status.Ports = ports
+
 	if workspace.Status.OwnerToken == "" {
 		ownerToken, err := getRandomString(32)
 		if err != nil {
@@ -130,16 +156,12 @@ func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspa
 		r.Recorder.Event(workspace, corev1.EventTypeWarning, "Failed", failure)
 	}
 
-	if workspace.IsHeadless() && !workspace.IsConditionTrue(workspacev1.WorkspaceConditionsHeadlessTaskFailed) {
-		for _, cs := range pod.Status.ContainerStatuses {
-			if cs.State.Terminated != nil && cs.State.Terminated.Message != "" {
-				workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionHeadlessTaskFailed(cs.State.Terminated.Message))
-				break
-			}
-		}
+	if workspace.IsHeadless() {
+		updateHeadlessTaskStatus(workspace, pod)
 	}
 
-	if isWorkspaceContainerRunning(pod.Status.ContainerStatuses) {
+	// The container statuses of a pod whose node disappeared are stale, its containers are gone with the node.
+	if isWorkspaceContainerRunning(pod.Status.ContainerStatuses) && !workspace.IsConditionTrue(workspacev1.WorkspaceConditionNodeDisappeared) {
 		workspace.UpsertConditionOnStatusChange(workspacev1.NewWorkspaceConditionContainerRunning(metav1.ConditionTrue))
 	} else {
 		workspace.UpsertConditionOnStatusChange(workspacev1.NewWorkspaceConditionContainerRunning(metav1.ConditionFalse))
@@ -169,6 +191,14 @@ func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspa
 				}
 			}
 		}
+		// PVC workspaces wait in an init container for ws-daemon to initialize their content,
+		// which ws-daemon only does while the workspace is creating.
+		for _, cs := range pod.Status.InitContainerStatuses {
+			if cs.State.Running != nil {
+				creating = true
+				break
+			}
+		}
 		if creating {
 			workspace.Status.Phase = workspacev1.WorkspacePhaseCreating
 		} else {
@@ -218,8 +248,9 @@ func (r *WorkspaceReconciler) updateWorkspaceStatus(ctx context.Context, workspa
 			workspace.Status.Phase = workspacev1.WorkspacePhaseStopped
 		} else if workspace.Status.Phase != workspacev1.WorkspacePhaseStopped {
 			// Should be in Stopping phase, but isn't yet.
-			// Move to Stopping to start disposal, but only if maintenance mode is disabled.
-			if !r.maintenance.IsEnabled(ctx) {
+			// Move to Stopping to start disposal, but only if maintenance mode is disabled
+			// or the maintenance window is draining workspaces.
+			if !r.maintenance.IsEnabled(ctx) || r.maintenance.IsDraining(ctx) {
 				workspace.Status.Phase = workspacev1.WorkspacePhaseStopping
 			}
 		}
@@ -256,10 +287,20 @@ func (r *WorkspaceReconciler) checkNodeDisappeared(ctx context.Context, workspac
 		return nil
 	}
 
-	if !isDisposalFinished(workspace) {
+	switch {
+	case isDisposalFinished(workspace):
+	case workspace.UsesPVC():
+		// The persistent volume outlives the node, hence we back up the workspace content by taking a volume
+		// snapshot once the workspace is stopping. Unlike a regular backup that does not need ws-daemon.
+		log.FromContext(ctx).Info("workspace node disappeared, backing up its volume", "node", pod.Spec.NodeName)
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionInterrupted(workspacev1.ReasonNodeDisappeared,
+			"The workspace was interrupted because its node disappeared. Its content is being backed up, restart the workspace to continue."))
+	default:
 		// Node disappeared before a backup could be taken, mark it with a backup failure.
 		log.FromContext(ctx).Error(nil, "workspace node disappeared while disposal has not finished yet", "node", pod.Spec.NodeName)
 		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupFailure("workspace node disappeared before backup was taken"))
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionInterrupted(workspacev1.ReasonNodeDisappeared,
+			"The workspace was interrupted because its node disappeared. Restart the workspace to continue from its last backup, changes made since then could not be saved."))
 	}
 
 	// Must set this after checking isDisposalFinished, as that method also checks for the NodeDisappeared condition.
@@ -267,14 +308,128 @@ func (r *WorkspaceReconciler) checkNodeDisappeared(ctx context.Context, workspac
 	return nil
 }
 
+// updateHeadlessTaskStatus detects the completion of a headless workspace's task from the exit code
+// of its workspace container, and records whether the task succeeded or failed.
+func updateHeadlessTaskStatus(workspace *workspacev1.Workspace, pod *corev1.Pod) {
+	if workspace.IsConditionTrue(workspacev1.WorkspaceConditionsHeadlessTaskFailed) || workspace.IsConditionTrue(workspacev1.WorkspaceConditionHeadlessTaskSucceeded) {
+		return
+	}
+
+	var terminated *corev1.ContainerStateTerminated
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated != nil {
+			terminated = cs.State.Terminated
+			break
+		}
+	}
+	if terminated == nil {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			// The pod can succeed without us observing the terminated container, its exit code was zero then.
+			workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionHeadlessTaskSucceeded())
+		}
+		return
+	}
+
+	if workspace.Status.Headless == nil {
+		workspace.Status.Headless = &workspacev1.HeadlessStatus{}
+	}
+	workspace.Status.Headless.ExitCode = pointer.Int32(terminated.ExitCode)
+
+	switch {
+	case terminated.Message != "":
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionHeadlessTaskFailed(terminated.Message))
+	case isPodBeingDeleted(pod):
+		// The container was stopped before the task completed, its exit code tells nothing about the task.
+	case terminated.ExitCode != 0:
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionHeadlessTaskFailed(fmt.Sprintf("headless task exited with code %d", terminated.ExitCode)))
+	default:
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionHeadlessTaskSucceeded())
+	}
+}
+
+// checkPodEvicted marks the workspace as interrupted if its pod was evicted. In contrast to a disappeared node,
+// ws-daemon is still running on the node and backs up the workspace content during disposal as usual.
+func checkPodEvicted(workspace *workspacev1.Workspace, pod *corev1.Pod) {
+	if workspace.IsConditionTrue(workspacev1.WorkspaceConditionInterrupted) {
+		return
+	}
+	if pod.Status.Phase != corev1.PodFailed || pod.Status.Reason != workspacev1.ReasonEvicted {
+		return
+	}
+
+	msg := "The workspace was interrupted because it was evicted from its node. Its content is being backed up, restart the workspace to continue."
+	if pod.Status.Message != "" {
+		msg = fmt.Sprintf("The workspace was interrupted because it was evicted from its node (%s). Its content is being backed up, restart the workspace to continue.", pod.Status.Message)
+	}
+	workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionInterrupted(workspacev1.ReasonEvicted, msg))
+}
+
+// checkResourceExhausted marks the workspace if one of its containers ran out of memory, or its pod was evicted
+// for exceeding its ephemeral storage limit. Both are caused by what the user runs in the workspace, so we point
+// them to a larger workspace class.
+func checkResourceExhausted(workspace *workspacev1.Workspace, pod *corev1.Pod) {
+	if workspace.IsConditionTrue(workspacev1.WorkspaceConditionResourceExhausted) {
+		return
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		for _, terminated := range []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+			if terminated == nil || terminated.Reason != workspacev1.ReasonOOMKilled {
+				continue
+			}
+
+			workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionResourceExhausted(workspacev1.ReasonOOMKilled,
+				fmt.Sprintf("Container %s ran out of memory and was killed. Choose a larger workspace class if your workload needs more memory.", cs.Name)))
+			return
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == workspacev1.ReasonEvicted && isEphemeralStorageEviction(pod.Status.Message) {
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionResourceExhausted(workspacev1.ReasonEphemeralStorageExceeded,
+			"The workspace used more disk space than its workspace class allows and was evicted. Choose a larger workspace class, or clean up files you don't need."))
+	}
+}
+
+// checkEphemeralStorage warns the user once the workspace exceeds the soft limit of its ephemeral storage, and
+// marks the workspace as exhausted at the hard limit. The workspace is then stopped by us and backed up, rather than
+// evicted by the kubelet.
+func checkEphemeralStorage(workspace *workspacev1.Workspace, cfg *config.Configuration) {
+	quota := cfg.EphemeralStorageQuota
+	usage := workspace.Status.EphemeralStorage
+	if usage == nil || usage.Limit.IsZero() || workspace.IsConditionTrue(workspacev1.WorkspaceConditionResourceExhausted) {
+		return
+	}
+
+	used := float64(usage.Used.Value()) / float64(usage.Limit.Value())
+	if quota.HardLimit > 0 && used >= quota.HardLimit {
+		workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionResourceExhausted(workspacev1.ReasonEphemeralStorageExceeded,
+			fmt.Sprintf("The workspace used %s of its %s disk space and was stopped. Its content is being backed up, choose a larger workspace class or clean up files you don't need.", usage.Used.String(), usage.Limit.String())))
+		return
+	}
+
+	if quota.SoftLimit > 0 && used >= quota.SoftLimit {
+		workspace.UpsertConditionOnStatusChange(workspacev1.NewWorkspaceConditionEphemeralStorageWarning(metav1.ConditionTrue,
+			fmt.Sprintf("The workspace uses %s of its %s disk space. It will be stopped once the disk is full, clean up files you don't need.", usage.Used.String(), usage.Limit.String())))
+	} else if workspace.IsConditionTrue(workspacev1.WorkspaceConditionEphemeralStorageWarning) {
+		workspace.UpsertConditionOnStatusChange(workspacev1.NewWorkspaceConditionEphemeralStorageWarning(metav1.ConditionFalse, ""))
+	}
+}
+
+// isEphemeralStorageEviction returns true if the kubelet evicted a pod for exceeding its own ephemeral storage
+// limits, as opposed to the node running low on disk.
+func isEphemeralStorageEviction(message string) bool {
+	return strings.Contains(message, "ephemeral local storage usage exceeds the total limit") ||
+		strings.Contains(message, "exceeded its local ephemeral storage limit")
+}
+
 func isDisposalFinished(ws *workspacev1.Workspace) bool {
 	return ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupComplete) ||
 		ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupFailure) ||
 		ws.IsConditionTrue(workspacev1.WorkspaceConditionAborted) ||
 		// Nothing to dispose if content wasn't ready.
 		!ws.IsConditionTrue(workspacev1.WorkspaceConditionContentReady) ||
-		// Can't dispose if node disappeared.
-		ws.IsConditionTrue(workspacev1.WorkspaceConditionNodeDisappeared) ||
+		// Can't dispose if node disappeared, unless the content lives on a volume which we can snapshot without it.
+		(ws.IsConditionTrue(workspacev1.WorkspaceConditionNodeDisappeared) && !ws.UsesPVC()) ||
 		// Image builds have nothing to dispose.
 		ws.Spec.Type == workspacev1.WorkspaceTypeImageBuild ||
 		// headless workspaces that failed do not need to be backed up
@@ -298,6 +453,17 @@ func (r *WorkspaceReconciler) extractFailure(ctx context.Context, ws *workspacev
 		}
 	}
 
+	// Check for interruptions, these take precedence over the resulting backup failures
+	// as they tell the user why the workspace stopped and how to continue.
+	if c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionInterrupted)); c != nil && c.Status == metav1.ConditionTrue {
+		return c.Message, nil
+	}
+
+	// Check for infrastructure failures we're out of start attempts for.
+	if c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionInfrastructureFailure)); c != nil && c.Status == metav1.ConditionTrue {
+		return fmt.Sprintf("Workspace start failed: %s", c.Message), nil
+	}
+
 	// Check for backup failure.
 	if c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionBackupFailure)); c != nil {
 		msg := c.Message
@@ -456,3 +622,32 @@ func isPodBeingDeleted(pod *corev1.Pod) bool {
 func isWorkspaceBeingDeleted(ws *workspacev1.Workspace) bool {
 	return ws.ObjectMeta.DeletionTimestamp != nil
 }
+
+// exposedPorts returns the status of the ports the workspace exposes according to its spec
+func exposedPorts(ws *workspacev1.Workspace, cfg *config.Configuration) ([]workspacev1.PortStatus, error) {
+	if len(ws.Spec.Ports) == 0 {
+		return nil, nil
+	}
+
+	res := make([]workspacev1.PortStatus, 0, len(ws.Spec.Ports))
+	for _, p := range ws.Spec.Ports {
+		url, err := config.RenderWorkspacePortURL(cfg.WorkspacePortURLTemplate, config.PortURLContext{
+			Host:          cfg.GitpodHostURL,
+			ID:            ws.Name,
+			IngressPort:   fmt.Sprint(p.Port),
+			Prefix:        ws.Spec.Ownership.WorkspaceID,
+			WorkspacePort: fmt.Sprint(p.Port),
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("cannot get URL of port %d: %w", p.Port, err)
+		}
+
+		res = append(res, workspacev1.PortStatus{
+			Port:       p.Port,
+			Visibility: p.Visibility,
+			Protocol:   p.Protocol,
+			URL:        url,
+		})
+	}
+	return res, nil
+}
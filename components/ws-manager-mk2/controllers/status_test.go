@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestCheckEphemeralStorage(t *testing.T) {
+	type Expectation struct {
+		Warning   bool
+		Exhausted bool
+	}
+	tests := []struct {
+		Name        string
+		Used        string
+		Quota       config.EphemeralStorageQuotaConfiguration
+		Warned      bool
+		Expectation Expectation
+	}{
+		{
+			Name:  "below soft limit",
+			Used:  "5Gi",
+			Quota: config.EphemeralStorageQuotaConfiguration{SoftLimit: 0.8, HardLimit: 0.95},
+		},
+		{
+			Name:        "above soft limit",
+			Used:        "8500Mi",
+			Quota:       config.EphemeralStorageQuotaConfiguration{SoftLimit: 0.8, HardLimit: 0.95},
+			Expectation: Expectation{Warning: true},
+		},
+		{
+			Name:   "back below soft limit",
+			Used:   "5Gi",
+			Quota:  config.EphemeralStorageQuotaConfiguration{SoftLimit: 0.8, HardLimit: 0.95},
+			Warned: true,
+		},
+		{
+			Name:        "above hard limit",
+			Used:        "9800Mi",
+			Quota:       config.EphemeralStorageQuotaConfiguration{SoftLimit: 0.8, HardLimit: 0.95},
+			Warned:      true,
+			Expectation: Expectation{Warning: true, Exhausted: true},
+		},
+		{
+			Name: "no quota",
+			Used: "9800Mi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &workspacev1.Workspace{}
+			ws.Status.EphemeralStorage = &workspacev1.EphemeralStorageStatus{
+				Used:  resource.MustParse(test.Used),
+				Limit: resource.MustParse("10Gi"),
+			}
+			if test.Warned {
+				ws.Status.SetCondition(workspacev1.NewWorkspaceConditionEphemeralStorageWarning(metav1.ConditionTrue, "disk is filling up"))
+			}
+
+			checkEphemeralStorage(ws, &config.Configuration{EphemeralStorageQuota: test.Quota})
+
+			act := Expectation{
+				Warning:   ws.IsConditionTrue(workspacev1.WorkspaceConditionEphemeralStorageWarning),
+				Exhausted: wsk8s.ConditionWithStatusAndReason(ws.Status.Conditions, string(workspacev1.WorkspaceConditionResourceExhausted), true, workspacev1.ReasonEphemeralStorageExceeded),
+			}
+			if act != test.Expectation {
+				t.Errorf("expected %+v, got %+v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestIsDisposalFinishedNodeDisappeared(t *testing.T) {
+	tests := []struct {
+		Name        string
+		PVC         bool
+		Expectation bool
+	}{
+		{Name: "ephemeral storage", Expectation: true},
+		{Name: "persistent volume", PVC: true, Expectation: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &workspacev1.Workspace{}
+			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionContentReady(metav1.ConditionTrue, workspacev1.ReasonInitializationSuccess, ""))
+			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionNodeDisappeared())
+			if test.PVC {
+				ws.Status.PVC = &workspacev1.PVCStatus{ClaimName: "foobar"}
+			}
+
+			if act := isDisposalFinished(ws); act != test.Expectation {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
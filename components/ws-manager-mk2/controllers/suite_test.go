@@ -148,19 +148,42 @@ func newTestConfig() config.Configuration {
 			"default": {
 				Name: "default",
 			},
+			"short-lived": {
+				Name: "short-lived",
+				Timeouts: &config.WorkspaceClassTimeoutConfiguration{
+					MaxLifetime: &shortLivedMaxLifetime,
+				},
+			},
+			"deprecated": {
+				Name:        "deprecated",
+				Deprecation: &config.WorkspaceClassDeprecation{ReplacedBy: "default"},
+			},
 		},
-		WorkspaceURLTemplate: "{{ .ID }}-{{ .Prefix }}-{{ .Host }}",
+		WorkspaceURLTemplate:     "{{ .ID }}-{{ .Prefix }}-{{ .Host }}",
+		WorkspacePortURLTemplate: "{{ .WorkspacePort }}-{{ .ID }}-{{ .Prefix }}-{{ .Host }}",
 	}
 }
 
+var shortLivedMaxLifetime = util.Duration(8 * time.Hour)
+
 type fakeMaintenance struct {
-	enabled bool
+	enabled  bool
+	draining bool
+	message  string
 }
 
 func (f *fakeMaintenance) IsEnabled(context.Context) bool {
 	return f.enabled
 }
 
+func (f *fakeMaintenance) IsDraining(context.Context) bool {
+	return f.enabled && f.draining
+}
+
+func (f *fakeMaintenance) Message(context.Context) string {
+	return f.message
+}
+
 func createNamespace(name string) *corev1.Namespace {
 	GinkgoHelper()
 
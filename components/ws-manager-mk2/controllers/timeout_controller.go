@@ -11,6 +11,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -23,6 +24,7 @@ import (
 	k8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
 	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/activity"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/maintenance"
 	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
@@ -59,6 +61,9 @@ type TimeoutReconciler struct {
 	reconcileInterval time.Duration
 	recorder          record.EventRecorder
 	maintenance       maintenance.Maintenance
+
+	// Audit records the decisions affecting workspaces. May be nil.
+	Audit *audit.Log
 }
 
 //+kubebuilder:rbac:groups=workspace.gitpod.io,resources=workspaces,verbs=get;list;watch;create;update;patch;delete
@@ -90,23 +95,36 @@ func (r *TimeoutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 		return ctrl.Result{}, nil
 	}
 
+	var timedout string
 	if r.maintenance.IsEnabled(ctx) {
-		// Don't reconcile timeouts in maintenance mode, to prevent workspace deletion.
-		// Requeue after some time to ensure we do still reconcile this workspace when
-		// maintenance mode ends.
-		return ctrl.Result{RequeueAfter: maintenanceRequeue}, nil
-	}
-
-	// The workspace hasn't timed out yet. After this point, we always
-	// want to requeue a reconciliation after the configured interval.
-	defer func() {
-		result.RequeueAfter = r.reconcileInterval
-	}()
+		if !r.maintenance.IsDraining(ctx) || isStoppingOrStopped(&workspace) {
+			// Don't reconcile timeouts in maintenance mode, to prevent workspace deletion.
+			// Requeue after some time to ensure we do still reconcile this workspace when
+			// maintenance mode ends, or when the maintenance window starts draining.
+			return ctrl.Result{RequeueAfter: maintenanceRequeue}, nil
+		}
 
-	timedout := r.isWorkspaceTimedOut(&workspace)
-	if timedout == "" {
-		// Hasn't timed out.
-		return ctrl.Result{}, nil
+		// The drain deadline of the maintenance window has passed, stop the workspace.
+		timedout = fmt.Sprintf("workspace timed out after %s", activityMaintenance)
+	} else {
+		// The workspace hasn't timed out yet. After this point, we always
+		// want to requeue a reconciliation after the configured interval.
+		defer func() {
+			result.RequeueAfter = r.reconcileInterval
+		}()
+
+		timedout = r.isWorkspaceTimedOut(&workspace)
+		if timedout == "" {
+			// Hasn't timed out, but might soon.
+			if err := r.updateStoppingSoon(ctx, &workspace); err != nil {
+				log.Error(err, "Failed to update workspace status with StoppingSoon condition")
+			}
+			return ctrl.Result{}, nil
+		}
+		if c := k8s.GetCondition(workspace.Status.Conditions, string(workspacev1.WorkspaceConditionUnschedulable)); c != nil && c.Status == metav1.ConditionTrue {
+			// Tell the user why the workspace never started instead of just that it took too long.
+			timedout = fmt.Sprintf("%s: %s", timedout, c.Message)
+		}
 	}
 
 	// Workspace timed out, set Timeout condition.
@@ -125,9 +143,60 @@ func (r *TimeoutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 	}
 
 	r.recorder.Event(&workspace, corev1.EventTypeNormal, "TimedOut", timedout)
+	r.Audit.Record(audit.WorkspaceEvent(&workspace, audit.ActionTimedOut, auditActorTimeoutController, timedout))
 	return ctrl.Result{}, nil
 }
 
+// updateStoppingSoon warns the user ahead of a workspace being stopped due to inactivity, and withdraws
+// the warning once the user is active again.
+func (r *TimeoutReconciler) updateStoppingSoon(ctx context.Context, ws *workspacev1.Workspace) error {
+	var cond *metav1.Condition
+	if stopAt := r.inactivityStopTime(ws); stopAt != nil && time.Until(*stopAt) <= time.Duration(r.Config.Timeouts.StoppingSoonWarning) {
+		c := workspacev1.NewWorkspaceConditionStoppingSoon(metav1.ConditionTrue, "Inactivity",
+			fmt.Sprintf("workspace will be stopped at %s due to inactivity", stopAt.UTC().Format(time.RFC3339)))
+		cond = &c
+	} else if ws.IsConditionTrue(workspacev1.WorkspaceConditionStoppingSoon) {
+		c := workspacev1.NewWorkspaceConditionStoppingSoon(metav1.ConditionFalse, "Activity", "")
+		cond = &c
+	}
+	if cond == nil {
+		return nil
+	}
+	if c := k8s.GetCondition(ws.Status.Conditions, cond.Type); c != nil && c.Status == cond.Status && c.Message == cond.Message {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		err := r.Get(ctx, types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}, ws)
+		if err != nil {
+			return err
+		}
+
+		ws.Status.SetCondition(*cond)
+		return r.Status().Update(ctx, ws)
+	})
+}
+
+// inactivityStopTime returns when a running workspace will be stopped due to inactivity, or nil if it
+// isn't subject to the inactivity timeout or the warning is disabled.
+func (r *TimeoutReconciler) inactivityStopTime(ws *workspacev1.Workspace) *time.Time {
+	if r.Config.Timeouts.StoppingSoonWarning == 0 || ws.Status.Phase != workspacev1.WorkspacePhaseRunning || ws.IsHeadless() {
+		return nil
+	}
+
+	lastActivity := activity.Last(ws)
+	if lastActivity == nil {
+		return nil
+	}
+	timeout := effectiveTimeouts(ws, &r.Config).Time.Duration
+	if timeout == 0 {
+		return nil
+	}
+
+	stopAt := lastActivity.Add(timeout)
+	return &stopAt
+}
+
 type timeoutActivity string
 
 const (
@@ -142,12 +211,18 @@ const (
 	activityInterrupted        timeoutActivity = "workspace interruption"
 	activityStopping           timeoutActivity = "stopping"
 	activityBackup             timeoutActivity = "backup"
+	activityMaintenance        timeoutActivity = "cluster maintenance"
 )
 
+func isStoppingOrStopped(ws *workspacev1.Workspace) bool {
+	return ws.Status.Phase == workspacev1.WorkspacePhaseStopping || ws.Status.Phase == workspacev1.WorkspacePhaseStopped
+}
+
 // isWorkspaceTimedOut determines if a workspace is timed out based on the manager configuration and state the pod is in.
 // This function does NOT use the Timeout condition, but rather is used to set that condition in the first place.
 func (r *TimeoutReconciler) isWorkspaceTimedOut(ws *workspacev1.Workspace) (reason string) {
-	timeouts := r.Config.Timeouts
+	timeouts := r.Config.ClassTimeouts(ws.Spec.Class)
+	effective := effectiveTimeouts(ws, &r.Config)
 	phase := ws.Status.Phase
 
 	decide := func(start time.Time, timeout util.Duration, activity timeoutActivity) string {
@@ -180,32 +255,38 @@ func (r *TimeoutReconciler) isWorkspaceTimedOut(ws *workspacev1.Workspace) (reas
 		return decide(start, timeouts.TotalStartup, activity)
 
 	case workspacev1.WorkspacePhaseRunning:
+		if ws.IsHeadless() {
+			// Headless workspaces stop once their task completes. They have no user activity and are
+			// not subject to the max lifetime, only to an upper bound on how long their task may run.
+			if timeouts.HeadlessWorkspace == 0 {
+				return ""
+			}
+			return decide(start, timeouts.HeadlessWorkspace, activityRunningHeadless)
+		}
+
 		// First check is always for the max lifetime
-		maxLifetime := r.getMaxLifetime(ws)
+		maxLifetime := util.Duration(effective.MaximumLifetime.Duration)
 		if msg := decide(start, maxLifetime, activityMaxLifetime); msg != "" {
 			return msg
 		}
 
-		timeout := timeouts.RegularWorkspace
-		if customTimeout := ws.Spec.Timeout.Time; customTimeout != nil {
-			timeout = util.Duration(customTimeout.Duration)
+		if ws.Spec.Debug {
+			// Debug workspaces are not subject to any inactivity timeout, such that debugging a workspace
+			// doesn't fight the idle reaper.
+			return ""
 		}
+
+		timeout := util.Duration(effective.Time.Duration)
 		activity := activityNone
-		if ws.IsHeadless() {
-			timeout = timeouts.HeadlessWorkspace
-			lastActivity = &start
-			activity = activityRunningHeadless
-		} else if lastActivity == nil {
+		if lastActivity == nil {
 			// The workspace is up and running, but the user has never produced any activity
 			return decide(start, timeouts.TotalStartup, activityNone)
 		} else if isClosed {
 			reason := func() string {
-				afterClosed := timeouts.AfterClose
-				if customClosedTimeout := ws.Spec.Timeout.ClosedTimeout; customClosedTimeout != nil {
-					afterClosed = util.Duration(customClosedTimeout.Duration)
-					if afterClosed == 0 {
-						return ""
-					}
+				afterClosed := util.Duration(effective.ClosedTimeout.Duration)
+				if afterClosed == 0 {
+					// A closed timeout of zero disables timing out closed workspaces
+					return ""
 				}
 				return decide(*lastActivity, afterClosed, activityClosed)
 			}()
@@ -233,12 +314,26 @@ func (r *TimeoutReconciler) isWorkspaceTimedOut(ws *workspacev1.Workspace) (reas
 	}
 }
 
-func (r *TimeoutReconciler) getMaxLifetime(ws *workspacev1.Workspace) util.Duration {
+// effectiveTimeouts resolves the timeouts that apply to a workspace. Timeouts set on the workspace itself
+// (e.g. from user or organization settings) take precedence over those of its class, which in turn
+// override the manager configuration.
+func effectiveTimeouts(ws *workspacev1.Workspace, cfg *config.Configuration) *workspacev1.TimeoutSpec {
+	timeouts := cfg.ClassTimeouts(ws.Spec.Class)
+	res := &workspacev1.TimeoutSpec{
+		Time:            &metav1.Duration{Duration: time.Duration(timeouts.RegularWorkspace)},
+		ClosedTimeout:   &metav1.Duration{Duration: time.Duration(timeouts.AfterClose)},
+		MaximumLifetime: &metav1.Duration{Duration: time.Duration(timeouts.MaxLifetime)},
+	}
+	if ws.Spec.Timeout.Time != nil {
+		res.Time = ws.Spec.Timeout.Time.DeepCopy()
+	}
+	if ws.Spec.Timeout.ClosedTimeout != nil {
+		res.ClosedTimeout = ws.Spec.Timeout.ClosedTimeout.DeepCopy()
+	}
 	if ws.Spec.Timeout.MaximumLifetime != nil {
-		return util.Duration(ws.Spec.Timeout.MaximumLifetime.Duration)
+		res.MaximumLifetime = ws.Spec.Timeout.MaximumLifetime.DeepCopy()
 	}
-
-	return r.Config.Timeouts.MaxLifetime
+	return res
 }
 
 func formatDuration(d time.Duration) string {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/util"
 	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo/v2"
@@ -47,6 +48,7 @@ var _ = Describe("TimeoutController", func() {
 			customMaxLifetime *time.Duration
 			update            func(ws *workspacev1.Workspace)
 			updateStatus      func(ws *workspacev1.Workspace)
+			maintenance       *fakeMaintenance
 			expectTimeout     bool
 		}
 		DescribeTable("workspace timeouts",
@@ -80,6 +82,10 @@ var _ = Describe("TimeoutController", func() {
 					}
 				})
 
+				if tc.maintenance != nil {
+					r.maintenance = tc.maintenance
+				}
+
 				// Run the timeout controller for this workspace.
 				By("running the TimeoutController reconcile()")
 				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}})
@@ -108,6 +114,26 @@ var _ = Describe("TimeoutController", func() {
 				age:             10 * time.Hour,
 				expectTimeout:   true,
 			}),
+			Entry("shouldn't timeout inactive workspace in maintenance mode", testCase{
+				phase:           workspacev1.WorkspacePhaseRunning,
+				lastActivityAgo: pointer.Duration(2 * time.Hour),
+				age:             10 * time.Hour,
+				maintenance:     &fakeMaintenance{enabled: true},
+				expectTimeout:   false,
+			}),
+			Entry("should stop active workspace when maintenance is draining", testCase{
+				phase:           workspacev1.WorkspacePhaseRunning,
+				lastActivityAgo: pointer.Duration(1 * time.Minute),
+				age:             10 * time.Minute,
+				maintenance:     &fakeMaintenance{enabled: true, draining: true},
+				expectTimeout:   true,
+			}),
+			Entry("shouldn't stop stopping workspace when maintenance is draining", testCase{
+				phase:         workspacev1.WorkspacePhaseStopping,
+				age:           10 * time.Minute,
+				maintenance:   &fakeMaintenance{enabled: true, draining: true},
+				expectTimeout: false,
+			}),
 			Entry("should timeout inactive workspace with custom timeout", testCase{
 				phase: workspacev1.WorkspacePhaseRunning,
 				// Use a lastActivity that would not trigger the default timeout, but does trigger the custom timeout.
@@ -138,6 +164,17 @@ var _ = Describe("TimeoutController", func() {
 				lastActivityAgo: nil,
 				expectTimeout:   true,
 			}),
+			Entry("shouldn't timeout headless workspace on max lifetime or inactivity", testCase{
+				phase: workspacev1.WorkspacePhaseRunning,
+				update: func(ws *workspacev1.Workspace) {
+					ws.Spec.Type = workspacev1.WorkspaceTypePrebuild
+				},
+				age:               1 * time.Hour,
+				customMaxLifetime: pointer.Duration(30 * time.Minute),
+				customTimeout:     pointer.Duration(10 * time.Minute),
+				lastActivityAgo:   nil,
+				expectTimeout:     false,
+			}),
 			Entry("should timeout workspace with no custom lifetime", testCase{
 				phase:           workspacev1.WorkspacePhaseRunning,
 				age:             50 * time.Hour,
@@ -151,6 +188,43 @@ var _ = Describe("TimeoutController", func() {
 				lastActivityAgo:   pointer.Duration(1 * time.Minute),
 				expectTimeout:     true,
 			}),
+			Entry("should timeout workspace with class lifetime", testCase{
+				phase: workspacev1.WorkspacePhaseRunning,
+				update: func(ws *workspacev1.Workspace) {
+					ws.Spec.Class = "short-lived"
+				},
+				age:             12 * time.Hour,
+				lastActivityAgo: pointer.Duration(1 * time.Minute),
+				expectTimeout:   true,
+			}),
+			Entry("should prefer custom lifetime over class lifetime", testCase{
+				phase: workspacev1.WorkspacePhaseRunning,
+				update: func(ws *workspacev1.Workspace) {
+					ws.Spec.Class = "short-lived"
+				},
+				age:               12 * time.Hour,
+				customMaxLifetime: pointer.Duration(24 * time.Hour),
+				lastActivityAgo:   pointer.Duration(1 * time.Minute),
+				expectTimeout:     false,
+			}),
+			Entry("shouldn't timeout inactive debug workspace", testCase{
+				phase: workspacev1.WorkspacePhaseRunning,
+				update: func(ws *workspacev1.Workspace) {
+					ws.Spec.Debug = true
+				},
+				age:             10 * time.Hour,
+				lastActivityAgo: pointer.Duration(2 * time.Hour),
+				expectTimeout:   false,
+			}),
+			Entry("should timeout debug workspace on max lifetime", testCase{
+				phase: workspacev1.WorkspacePhaseRunning,
+				update: func(ws *workspacev1.Workspace) {
+					ws.Spec.Debug = true
+				},
+				age:             50 * time.Hour,
+				lastActivityAgo: pointer.Duration(1 * time.Minute),
+				expectTimeout:   true,
+			}),
 			Entry("should timeout after controller restart if no FirstUserActivity", testCase{
 				phase:           workspacev1.WorkspacePhaseRunning,
 				age:             5 * time.Hour,
@@ -173,6 +247,47 @@ var _ = Describe("TimeoutController", func() {
 		)
 	})
 
+	Context("stopping soon", func() {
+		It("should warn before stopping an inactive workspace and withdraw the warning on activity", func() {
+			conf := newTestConfig()
+			conf.Timeouts.StoppingSoonWarning = util.Duration(10 * time.Minute)
+			fakeClient := fake.NewClientBuilder().WithStatusSubresource(&workspacev1.Workspace{}).WithScheme(k8sClient.Scheme()).Build()
+			r, err := NewTimeoutReconciler(fakeClient, record.NewFakeRecorder(100), conf, &fakeMaintenance{enabled: false})
+			Expect(err).ToNot(HaveOccurred())
+
+			ws := newWorkspace(uuid.NewString(), "default")
+			ws.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			Expect(fakeClient.Create(ctx, ws)).To(Succeed())
+
+			reconcileAndExpect := func(status metav1.ConditionStatus) {
+				GinkgoHelper()
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeClient.Get(ctx, types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}, ws)).To(Succeed())
+				c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionStoppingSoon))
+				Expect(c).ToNot(BeNil())
+				Expect(c.Status).To(Equal(status))
+				Expect(ws.IsConditionTrue(workspacev1.WorkspaceConditionTimeout)).To(BeFalse())
+			}
+
+			By("being inactive for almost the inactivity timeout")
+			updateObjWithRetries(fakeClient, ws, true, func(ws *workspacev1.Workspace) {
+				ws.Status.Phase = workspacev1.WorkspacePhaseRunning
+				lastActivity := metav1.NewTime(time.Now().Add(-time.Duration(conf.Timeouts.RegularWorkspace) + 5*time.Minute))
+				ws.Status.LastActivity = &lastActivity
+			})
+			reconcileAndExpect(metav1.ConditionTrue)
+
+			By("becoming active again")
+			updateObjWithRetries(fakeClient, ws, true, func(ws *workspacev1.Workspace) {
+				lastActivity := metav1.Now()
+				ws.Status.LastActivity = &lastActivity
+			})
+			reconcileAndExpect(metav1.ConditionFalse)
+		})
+	})
+
 	Context("reconciliation", func() {
 		var r *TimeoutReconciler
 		BeforeEach(func() {
@@ -206,6 +321,20 @@ var _ = Describe("TimeoutController", func() {
 	})
 })
 
+var _ = Describe("effectiveTimeouts", func() {
+	It("should resolve timeouts from workspace, class and config", func() {
+		conf := newTestConfig()
+		ws := newWorkspace(uuid.NewString(), "default")
+		ws.Spec.Class = "short-lived"
+		ws.Spec.Timeout.Time = &metav1.Duration{Duration: 5 * time.Minute}
+
+		timeouts := effectiveTimeouts(ws, &conf)
+		Expect(timeouts.Time.Duration).To(Equal(5 * time.Minute))
+		Expect(timeouts.MaximumLifetime.Duration).To(Equal(time.Duration(shortLivedMaxLifetime)))
+		Expect(timeouts.ClosedTimeout.Duration).To(Equal(time.Duration(conf.Timeouts.AfterClose)))
+	})
+})
+
 func expectNoTimeout(c client.Client, ws *workspacev1.Workspace) {
 	GinkgoHelper()
 	By("expecting controller to not timeout workspace")
@@ -0,0 +1,295 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// pvcVolumeName is the name of the workspace pod volume which refers to the workspace's persistent volume claim
+	pvcVolumeName = "vol-this-workspace-pvc"
+
+	// volumeSnapshotRequeue is the interval in which we check if a volume snapshot is ready to use
+	volumeSnapshotRequeue = 5 * time.Second
+)
+
+// volumeSnapshotGVK is the kind of the CSI volume snapshots. We use unstructured objects for them
+// such that ws-manager-mk2 does not depend on the external-snapshotter client.
+var volumeSnapshotGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshot",
+}
+
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+
+// ensureWorkspacePVC creates the persistent volume claim of a workspace whose class is configured to use one,
+// restoring it from the volume snapshot the workspace is initialized from, or else the latest volume snapshot
+// of the same workspace if there is one.
+func (r *WorkspaceReconciler) ensureWorkspacePVC(ctx context.Context, ws *workspacev1.Workspace) (err error) {
+	span, ctx := tracing.FromContext(ctx, "ensureWorkspacePVC")
+	defer tracing.FinishSpan(span, &err)
+
+	class, ok := r.Config.WorkspaceClasses[ws.Spec.Class]
+	if !ok || class.PVC == nil || ws.UsesPVC() || ws.Spec.Type == workspacev1.WorkspaceTypeImageBuild {
+		return nil
+	}
+
+	restoreFrom, err := r.volumeSnapshotToRestore(ctx, ws)
+	if err != nil {
+		return fmt.Errorf("cannot find volume snapshot to restore from: %w", err)
+	}
+
+	pvc, err := newWorkspacePVC(ws, class.PVC, restoreFrom)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.SetControllerReference(ws, pvc, r.Scheme); err != nil {
+		return err
+	}
+	err = r.Create(ctx, pvc)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("cannot create persistent volume claim: %w", err)
+	}
+
+	patch := client.MergeFrom(ws.DeepCopy())
+	ws.Status.PVC = &workspacev1.PVCStatus{
+		ClaimName:    pvc.Name,
+		RestoredFrom: restoreFrom,
+	}
+	return r.Status().Patch(ctx, ws, patch)
+}
+
+func newWorkspacePVC(ws *workspacev1.Workspace, cfg *config.PVCConfiguration, restoreFrom string) (*corev1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(cfg.Size)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse PVC size: %w", err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ws.Name,
+			Namespace: ws.Namespace,
+			Labels: map[string]string{
+				wsk8s.MetaIDLabel:      ws.Spec.Ownership.WorkspaceID,
+				wsk8s.WorkspaceIDLabel: ws.Name,
+				wsk8s.OwnerLabel:       ws.Spec.Ownership.Owner,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if cfg.StorageClass != "" {
+		pvc.Spec.StorageClassName = &cfg.StorageClass
+	}
+	if restoreFrom != "" {
+		pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+			APIGroup: &volumeSnapshotGVK.Group,
+			Kind:     volumeSnapshotGVK.Kind,
+			Name:     restoreFrom,
+		}
+	}
+
+	return pvc, nil
+}
+
+// volumeSnapshotToRestore returns the name of the volume snapshot to restore the workspace's persistent volume claim
+// from. Workspaces initialized from a volume snapshot only restore that one. If it's missing or not ready to use, the
+// claim starts out empty and ws-daemon falls back to the initializer's object storage content.
+func (r *WorkspaceReconciler) volumeSnapshotToRestore(ctx context.Context, ws *workspacev1.Workspace) (string, error) {
+	name := initializerVolumeSnapshot(ws)
+	if name == "" {
+		return r.latestVolumeSnapshot(ctx, ws)
+	}
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	err := r.Get(ctx, client.ObjectKey{Namespace: ws.Namespace, Name: name}, snapshot)
+	if apierrors.IsNotFound(err) {
+		log.FromContext(ctx).Info("volume snapshot to restore does not exist, falling back to object storage", "volumeSnapshot", name)
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if ready, _ := volumeSnapshotState(snapshot); !ready {
+		log.FromContext(ctx).Info("volume snapshot to restore is not ready to use, falling back to object storage", "volumeSnapshot", name)
+		return "", nil
+	}
+	return name, nil
+}
+
+// initializerVolumeSnapshot returns the name of the volume snapshot the workspace's content is initialized from, if any.
+func initializerVolumeSnapshot(ws *workspacev1.Workspace) string {
+	var init csapi.WorkspaceInitializer
+	err := proto.Unmarshal(ws.Spec.Initializer, &init)
+	if err != nil {
+		return ""
+	}
+	return init.GetVolumeSnapshot().GetVolumeSnapshotName()
+}
+
+// latestVolumeSnapshot returns the name of the newest ready to use volume snapshot of the workspace, or an
+// empty string if there is none.
+func (r *WorkspaceReconciler) latestVolumeSnapshot(ctx context.Context, ws *workspacev1.Workspace) (string, error) {
+	snapshots, err := r.listVolumeSnapshots(ctx, ws)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		latest    string
+		latestAge time.Time
+	)
+	for _, s := range snapshots {
+		if ready, _ := volumeSnapshotState(&s); !ready {
+			continue
+		}
+		if created := s.GetCreationTimestamp().Time; latest == "" || created.After(latestAge) {
+			latest, latestAge = s.GetName(), created
+		}
+	}
+	return latest, nil
+}
+
+func (r *WorkspaceReconciler) listVolumeSnapshots(ctx context.Context, ws *workspacev1.Workspace) ([]unstructured.Unstructured, error) {
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind(volumeSnapshotGVK.Kind + "List"))
+	err := r.List(ctx, &list, client.InNamespace(ws.Namespace), client.MatchingLabels{wsk8s.MetaIDLabel: ws.Spec.Ownership.WorkspaceID})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// snapshotWorkspaceVolume backs up the content of a stopping workspace by taking a volume snapshot of its
+// persistent volume claim. Once the snapshot is ready to use, older snapshots of the workspace are removed.
+func (r *WorkspaceReconciler) snapshotWorkspaceVolume(ctx context.Context, ws *workspacev1.Workspace) (result ctrl.Result, err error) {
+	span, ctx := tracing.FromContext(ctx, "snapshotWorkspaceVolume")
+	defer tracing.FinishSpan(span, &err)
+	log := log.FromContext(ctx)
+
+	if isDisposalFinished(ws) {
+		return ctrl.Result{}, nil
+	}
+	if ws.IsConditionTrue(workspacev1.WorkspaceConditionContainerRunning) {
+		// The workspace content may still change, wait for the container to stop.
+		return ctrl.Result{RequeueAfter: 500 * time.Millisecond}, nil
+	}
+
+	snapshot := newVolumeSnapshot(ws, r.Config.WorkspaceClasses[ws.Spec.Class])
+	err = r.Create(ctx, snapshot)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, fmt.Errorf("cannot create volume snapshot: %w", err)
+	}
+
+	if ws.Status.PVC.VolumeSnapshot == "" {
+		patch := client.MergeFrom(ws.DeepCopy())
+		ws.Status.PVC.VolumeSnapshot = snapshot.GetName()
+		if err := r.Status().Patch(ctx, ws, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	err = r.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("cannot get volume snapshot: %w", err)
+	}
+	ready, failure := volumeSnapshotState(snapshot)
+	switch {
+	case failure != "":
+		log.Info("volume snapshot failed", "volumeSnapshot", snapshot.GetName(), "error", failure)
+		ws.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupFailure(fmt.Sprintf("volume snapshot failed: %s", failure)))
+	case ready:
+		ws.Status.SetCondition(workspacev1.NewWorkspaceConditionBackupComplete())
+	default:
+		return ctrl.Result{RequeueAfter: volumeSnapshotRequeue}, nil
+	}
+	if err := r.Status().Update(ctx, ws); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if ready {
+		r.deleteOldVolumeSnapshots(ctx, ws, snapshot.GetName())
+	}
+	return ctrl.Result{}, nil
+}
+
+// deleteOldVolumeSnapshots removes all volume snapshots of the workspace but the one named keep.
+// Failing to do so is not fatal, as only the latest snapshot is ever restored.
+func (r *WorkspaceReconciler) deleteOldVolumeSnapshots(ctx context.Context, ws *workspacev1.Workspace, keep string) {
+	log := log.FromContext(ctx)
+
+	snapshots, err := r.listVolumeSnapshots(ctx, ws)
+	if err != nil {
+		log.Error(err, "cannot list volume snapshots")
+		return
+	}
+	for i := range snapshots {
+		if snapshots[i].GetName() == keep {
+			continue
+		}
+		err := r.Delete(ctx, &snapshots[i])
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "cannot delete old volume snapshot", "volumeSnapshot", snapshots[i].GetName())
+		}
+	}
+}
+
+// newVolumeSnapshot produces the volume snapshot of a workspace's persistent volume claim. The snapshot deliberately
+// has no owner reference to the workspace, as it has to outlive it to be restored when the workspace is started again.
+func newVolumeSnapshot(ws *workspacev1.Workspace, class *config.WorkspaceClass) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": ws.Status.PVC.ClaimName,
+		},
+	}
+	if class != nil && class.PVC != nil && class.PVC.SnapshotClass != "" {
+		spec["volumeSnapshotClassName"] = class.PVC.SnapshotClass
+	}
+
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(ws.Name)
+	snapshot.SetNamespace(ws.Namespace)
+	snapshot.SetLabels(map[string]string{
+		wsk8s.MetaIDLabel:      ws.Spec.Ownership.WorkspaceID,
+		wsk8s.WorkspaceIDLabel: ws.Name,
+		wsk8s.OwnerLabel:       ws.Spec.Ownership.Owner,
+	})
+	return snapshot
+}
+
+// volumeSnapshotState returns whether the volume snapshot is ready to use, or the error message if it failed.
+func volumeSnapshotState(snapshot *unstructured.Unstructured) (ready bool, failure string) {
+	ready, _, _ = unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	failure, _, _ = unstructured.NestedString(snapshot.Object, "status", "error", "message")
+	return ready, failure
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	v1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestNewWorkspacePVC(t *testing.T) {
+	ws := &v1.Workspace{}
+	ws.Name = "foobar"
+	ws.Spec.Ownership.WorkspaceID = "gitpodio-gitpod-1234"
+
+	pvc, err := newWorkspacePVC(ws, &config.PVCConfiguration{Size: "30Gi", StorageClass: "ssd"}, "snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(resource.MustParse("30Gi")) != 0 {
+		t.Errorf("unexpected size: %s", size.String())
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "ssd" {
+		t.Errorf("unexpected storage class: %v", pvc.Spec.StorageClassName)
+	}
+	if pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Kind != "VolumeSnapshot" || pvc.Spec.DataSource.Name != "snapshot" {
+		t.Errorf("unexpected data source: %v", pvc.Spec.DataSource)
+	}
+
+	pvc, err = newWorkspacePVC(ws, &config.PVCConfiguration{Size: "30Gi"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pvc.Spec.StorageClassName != nil || pvc.Spec.DataSource != nil {
+		t.Errorf("expected default storage class and no data source, got %v and %v", pvc.Spec.StorageClassName, pvc.Spec.DataSource)
+	}
+}
+
+func TestVolumeSnapshotState(t *testing.T) {
+	type Expectation struct {
+		Ready   bool
+		Failure string
+	}
+	tests := []struct {
+		Name        string
+		Status      map[string]interface{}
+		Expectation Expectation
+	}{
+		{
+			Name: "no status",
+		},
+		{
+			Name:        "ready",
+			Status:      map[string]interface{}{"readyToUse": true},
+			Expectation: Expectation{Ready: true},
+		},
+		{
+			Name:        "failed",
+			Status:      map[string]interface{}{"readyToUse": false, "error": map[string]interface{}{"message": "quota exceeded"}},
+			Expectation: Expectation{Failure: "quota exceeded"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &v1.Workspace{}
+			ws.Name = "foobar"
+			ws.Status.PVC = &v1.PVCStatus{ClaimName: "foobar"}
+			snapshot := newVolumeSnapshot(ws, &config.WorkspaceClass{PVC: &config.PVCConfiguration{SnapshotClass: "csi"}})
+			if test.Status != nil {
+				snapshot.Object["status"] = test.Status
+			}
+
+			if class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName"); class != "csi" {
+				t.Errorf("unexpected volume snapshot class: %s", class)
+			}
+
+			var act Expectation
+			act.Ready, act.Failure = volumeSnapshotState(snapshot)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("volumeSnapshotState() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInitializerVolumeSnapshot(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Initializer *csapi.WorkspaceInitializer
+		Expectation string
+	}{
+		{
+			Name:        "volume snapshot initializer",
+			Initializer: &csapi.WorkspaceInitializer{Spec: &csapi.WorkspaceInitializer_VolumeSnapshot{VolumeSnapshot: &csapi.VolumeSnapshotInitializer{VolumeSnapshotName: "foobar"}}},
+			Expectation: "foobar",
+		},
+		{
+			Name:        "backup initializer",
+			Initializer: &csapi.WorkspaceInitializer{Spec: &csapi.WorkspaceInitializer_Backup{Backup: &csapi.FromBackupInitializer{}}},
+		},
+		{
+			Name: "no initializer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &v1.Workspace{}
+			if test.Initializer != nil {
+				init, err := proto.Marshal(test.Initializer)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ws.Spec.Initializer = init
+			}
+
+			if act := initializerVolumeSnapshot(ws); act != test.Expectation {
+				t.Errorf("unexpected volume snapshot: %q, expected %q", act, test.Expectation)
+			}
+		})
+	}
+}
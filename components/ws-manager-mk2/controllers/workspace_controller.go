@@ -31,7 +31,9 @@ import (
 
 	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/diagnostics"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/maintenance"
 	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
 	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
@@ -55,6 +57,7 @@ func NewWorkspaceReconciler(c client.Client, scheme *runtime.Scheme, recorder re
 		maintenance: maintenance,
 		Recorder:    recorder,
 	}
+	reconciler.startLimiter = newStartLimiter(c, cfg.MaxConcurrentStartsPerNode, cfg.Capacity, cfg.Namespace, cfg.WorkspaceClasses)
 
 	metrics, err := newControllerMetrics(reconciler)
 	if err != nil {
@@ -63,6 +66,12 @@ func NewWorkspaceReconciler(c client.Client, scheme *runtime.Scheme, recorder re
 	reg.MustRegister(metrics)
 	reconciler.metrics = metrics
 
+	events, err := newLifecycleEventRecorder(recorder)
+	if err != nil {
+		return nil, err
+	}
+	reconciler.events = events
+
 	return reconciler, nil
 }
 
@@ -75,6 +84,14 @@ type WorkspaceReconciler struct {
 	metrics     *controllerMetrics
 	maintenance maintenance.Maintenance
 	Recorder    record.EventRecorder
+	events      *lifecycleEventRecorder
+
+	startLimiter *startLimiter
+
+	// Traces records the reconciles of workspaces while diagnostics are enabled. May be nil.
+	Traces *diagnostics.Traces
+	// Audit records the decisions affecting workspaces. May be nil.
+	Audit *audit.Log
 }
 
 //+kubebuilder:rbac:groups=workspace.gitpod.io,resources=workspaces,verbs=get;list;watch;create;update;patch;delete
@@ -98,6 +115,11 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	log := log.FromContext(ctx)
 
 	var workspace workspacev1.Workspace
+	trace := r.Traces.Begin(req.Name)
+	defer func() {
+		trace.End(workspace.Status.Phase, result, err)
+	}()
+
 	err = r.Get(ctx, req.NamespacedName, &workspace)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
@@ -130,7 +152,7 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	r.updateMetrics(ctx, &workspace)
-	r.emitPhaseEvents(ctx, &workspace, oldStatus)
+	r.events.Record(&workspace, oldStatus, r.Audit)
 
 	var podStatus *corev1.PodStatus
 	if len(workspacePods.Items) > 0 {
@@ -146,6 +168,11 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return errorResultLogConflict(log, fmt.Errorf("failed to update workspace status: %w", err))
 	}
 
+	err = r.reconcileWorkspacePortsService(ctx, &workspace)
+	if err != nil {
+		return errorResultLogConflict(log, fmt.Errorf("failed to reconcile ports service: %w", err))
+	}
+
 	result, err = r.actOnStatus(ctx, &workspace, workspacePods)
 	if err != nil {
 		return errorResultLogConflict(log, fmt.Errorf("failed to act on status: %w", err))
@@ -180,12 +207,59 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 	if len(workspacePods.Items) == 0 {
 		// if there isn't a workspace pod and we're not currently deleting this workspace,// create one.
 		switch {
-		case workspace.Status.PodStarts == 0:
+		case workspace.Status.PodStarts == 0 && workspace.Status.Phase != workspacev1.WorkspacePhaseStopped:
+			failure, err := r.restoreSnapshotFailure(ctx, workspace)
+			if err != nil {
+				log.Error(err, "unable to check the snapshot the workspace is restored from")
+				return ctrl.Result{}, err
+			}
+			if failure != "" {
+				log.Info("cannot start workspace", "reason", failure)
+				return r.failStart(ctx, workspace, failure)
+			}
+
+			admission, err := r.startLimiter.Admit(ctx, workspace)
+			if err != nil {
+				log.Error(err, "unable to decide if workspace may start")
+				return ctrl.Result{Requeue: true}, err
+			}
+			if !admission.Admitted {
+				// Hold back the start until the nodes have finished starting other workspaces, or the cluster has capacity again.
+				c := wsk8s.GetCondition(workspace.Status.Conditions, string(workspacev1.WorkspaceConditionPending))
+				if c == nil || c.Status != metav1.ConditionTrue || c.Reason != admission.ConditionReason || c.Message != admission.Reason {
+					log.V(1).Info("holding back workspace start", "reason", admission.Reason)
+					patch := client.MergeFrom(workspace.DeepCopy())
+					workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionPending(metav1.ConditionTrue, admission.ConditionReason, admission.Reason))
+					if err := r.Status().Patch(ctx, workspace, patch); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
+				return ctrl.Result{RequeueAfter: startLimitRequeue}, nil
+			}
+
+			if err := r.ensureWorkspacePVC(ctx, workspace); err != nil {
+				log.Error(err, "unable to provide workspace PVC")
+				return ctrl.Result{Requeue: true}, err
+			}
+
+			if err := r.ensureWorkspaceNetworkPolicy(ctx, workspace); err != nil {
+				log.Error(err, "unable to provide workspace network policy")
+				return ctrl.Result{Requeue: true}, err
+			}
+
+			pullSecret, err := r.ensureWorkspacePullSecret(ctx, workspace)
+			if err != nil {
+				log.Error(err, "unable to provide workspace pull secret")
+				return ctrl.Result{Requeue: true}, err
+			}
+
 			sctx, err := newStartWorkspaceContext(ctx, r.Config, workspace)
 			if err != nil {
 				log.Error(err, "unable to create startWorkspace context")
 				return ctrl.Result{Requeue: true}, err
 			}
+			sctx.AvoidNodes = admission.AvoidNodes
+			sctx.ImagePullSecret = pullSecret
 
 			pod, err := r.createWorkspacePod(sctx)
 			if err != nil {
@@ -213,6 +287,9 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 				// Use a Patch instead of an Update, to prevent conflicts.
 				patch := client.MergeFrom(workspace.DeepCopy())
 				workspace.Status.PodStarts++
+				if c := wsk8s.GetCondition(workspace.Status.Conditions, string(workspacev1.WorkspaceConditionPending)); c != nil && c.Status == metav1.ConditionTrue {
+					workspace.Status.SetCondition(workspacev1.NewWorkspaceConditionPending(metav1.ConditionFalse, c.Reason, ""))
+				}
 				if err := r.Status().Patch(ctx, workspace, patch); err != nil {
 					log.Error(err, "Failed to patch PodStarts in workspace status")
 					return ctrl.Result{}, err
@@ -221,10 +298,16 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 				r.Recorder.Event(workspace, corev1.EventTypeNormal, "Creating", "")
 			}
 
+		case retriesStart(workspace, r.Config):
+			return r.retryStart(ctx, workspace)
+
 		case workspace.Status.Phase == workspacev1.WorkspacePhaseStopped:
 			if err := r.deleteWorkspaceSecrets(ctx, workspace); err != nil {
 				return ctrl.Result{}, err
 			}
+			if err := r.deleteWorkspaceNetworkPolicy(ctx, workspace); err != nil {
+				return ctrl.Result{}, err
+			}
 
 			// Done stopping workspace - remove finalizer.
 			if controllerutil.ContainsFinalizer(workspace, workspacev1.GitpodFinalizerName) {
@@ -242,6 +325,9 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 			// but not guaranteed, so try deleting anyway.
 			r.Recorder.Event(workspace, corev1.EventTypeNormal, "Deleting", "")
 			err := r.Client.Delete(ctx, workspace)
+			if err == nil && !isWorkspaceBeingDeleted(workspace) {
+				r.Audit.Record(audit.WorkspaceEvent(workspace, audit.ActionWorkspaceDeleted, auditActorWorkspaceController, "workspace stopped"))
+			}
 			return ctrl.Result{}, client.IgnoreNotFound(err)
 		}
 
@@ -254,10 +340,24 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 	}
 	pod := &workspacePods.Items[0]
 
+	podPatch := client.MergeFrom(pod.DeepCopy())
+	protectionChanged := updateScaleDownProtection(pod, workspace.Status.Phase)
+	// keys added while the workspace is running reach supervisor through the pod annotation
+	keysChanged := !isPodBeingDeleted(pod) && updateSSHPublicKeys(pod, workspace)
+	if protectionChanged || keysChanged {
+		if err := r.Client.Patch(ctx, pod, podPatch); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to update annotations of pod: %w", err)
+		}
+	}
+
 	switch {
+	// if the start failed for infrastructure reasons, delete the pod such that it gets recreated
+	case retriesStart(workspace, r.Config):
+		return r.deleteFailedStartPod(ctx, workspace, pod)
+
 	// if there is a pod, and it's failed, delete it
 	case workspace.IsConditionTrue(workspacev1.WorkspaceConditionFailed) && !isPodBeingDeleted(pod):
-		return r.deleteWorkspacePod(ctx, pod, "workspace failed")
+		return r.deleteWorkspacePod(ctx, workspace, pod, "workspace failed")
 
 	// if the pod was stopped by request, delete it
 	case workspace.IsConditionTrue(workspacev1.WorkspaceConditionStoppedByRequest) && !isPodBeingDeleted(pod):
@@ -271,6 +371,9 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 		err := r.Client.Delete(ctx, pod, &client.DeleteOptions{
 			GracePeriodSeconds: gracePeriodSeconds,
 		})
+		if err == nil {
+			r.Audit.Record(audit.WorkspaceEvent(workspace, audit.ActionPodDeleted, auditActorWorkspaceController, "stopped by request"))
+		}
 		if apierrors.IsNotFound(err) {
 			// pod is gone - nothing to do here
 		} else {
@@ -279,29 +382,40 @@ func (r *WorkspaceReconciler) actOnStatus(ctx context.Context, workspace *worksp
 
 	// if the node disappeared, delete the pod.
 	case workspace.IsConditionTrue(workspacev1.WorkspaceConditionNodeDisappeared) && !isPodBeingDeleted(pod):
-		return r.deleteWorkspacePod(ctx, pod, "node disappeared")
+		return r.deleteWorkspacePod(ctx, workspace, pod, "node disappeared")
 
 	// if the workspace timed out, delete it
 	case workspace.IsConditionTrue(workspacev1.WorkspaceConditionTimeout) && !isPodBeingDeleted(pod):
-		return r.deleteWorkspacePod(ctx, pod, "timed out")
+		return r.deleteWorkspacePod(ctx, workspace, pod, "timed out")
+
+	// if the workspace ran out of ephemeral storage, delete it before the kubelet evicts it without backup
+	case wsk8s.ConditionWithStatusAndReason(workspace.Status.Conditions, string(workspacev1.WorkspaceConditionResourceExhausted), true, workspacev1.ReasonEphemeralStorageExceeded) && !isPodBeingDeleted(pod):
+		return r.deleteWorkspacePod(ctx, workspace, pod, "ephemeral storage exhausted")
 
 	// if the content initialization failed, delete the pod
 	case wsk8s.ConditionWithStatusAndReason(workspace.Status.Conditions, string(workspacev1.WorkspaceConditionContentReady), false, workspacev1.ReasonInitializationFailure) && !isPodBeingDeleted(pod):
-		return r.deleteWorkspacePod(ctx, pod, "init failed")
+		return r.deleteWorkspacePod(ctx, workspace, pod, "init failed")
 
 	case isWorkspaceBeingDeleted(workspace) && !isPodBeingDeleted(pod):
-		return r.deleteWorkspacePod(ctx, pod, "workspace deleted")
+		return r.deleteWorkspacePod(ctx, workspace, pod, "workspace deleted")
 
 	case workspace.IsHeadless() && workspace.Status.Phase == workspacev1.WorkspacePhaseStopped && !isPodBeingDeleted(pod):
 		// Workspace was requested to be deleted, propagate by deleting the Pod.
 		// The Pod deletion will then trigger workspace disposal steps.
 		err := r.Client.Delete(ctx, pod)
+		if err == nil {
+			r.Audit.Record(audit.WorkspaceEvent(workspace, audit.ActionPodDeleted, auditActorWorkspaceController, "headless workspace stopped"))
+		}
 		if apierrors.IsNotFound(err) {
 			// pod is gone - nothing to do here
 		} else {
 			return ctrl.Result{Requeue: true}, err
 		}
 
+	// the content of PVC workspaces is backed up by taking a volume snapshot instead of by ws-daemon
+	case workspace.UsesPVC() && workspace.Status.Phase == workspacev1.WorkspacePhaseStopping:
+		return r.snapshotWorkspaceVolume(ctx, workspace)
+
 	case workspace.Status.Phase == workspacev1.WorkspacePhaseRunning:
 		err := r.deleteWorkspaceSecrets(ctx, workspace)
 		if err != nil {
@@ -343,6 +457,19 @@ func (r *WorkspaceReconciler) updateMetrics(ctx context.Context, workspace *work
 		lastState.recordedFailure = true
 	}
 
+	if !lastState.recordedExhaustion && workspace.IsConditionTrue(workspacev1.WorkspaceConditionResourceExhausted) {
+		r.metrics.countResourceExhaustion(&log, workspace)
+		lastState.recordedExhaustion = true
+	}
+
+	if workspace.Status.Phase != "" && workspace.Status.Phase != lastState.phase {
+		if !lastState.phaseStartTime.IsZero() {
+			r.metrics.recordWorkspacePhaseTime(&log, workspace, lastState.phase, lastState.phaseStartTime)
+		}
+		lastState.phase = workspace.Status.Phase
+		lastState.phaseStartTime = time.Now()
+	}
+
 	if lastState.pendingStartTime.IsZero() && workspace.Status.Phase == workspacev1.WorkspacePhasePending {
 		lastState.pendingStartTime = time.Now()
 	} else if !lastState.pendingStartTime.IsZero() && workspace.Status.Phase != workspacev1.WorkspacePhasePending {
@@ -380,6 +507,9 @@ func (r *WorkspaceReconciler) updateMetrics(ctx context.Context, workspace *work
 
 	if workspace.Status.Phase == workspacev1.WorkspacePhaseStopped {
 		r.metrics.countWorkspaceStop(&log, workspace)
+		if workspace.IsHeadless() {
+			r.metrics.countHeadlessCompletion(&log, workspace)
+		}
 
 		if !lastState.recordedStartFailure && isStartFailure(workspace) {
 			// Workspace never became ready, count as a startup failure.
@@ -406,27 +536,16 @@ func isStartFailure(ws *workspacev1.Workspace) bool {
 	return !everReady && !isAborted && !isStoppedByRequest
 }
 
-func (r *WorkspaceReconciler) emitPhaseEvents(ctx context.Context, ws *workspacev1.Workspace, old *workspacev1.WorkspaceStatus) {
-	if ws.Status.Phase == workspacev1.WorkspacePhaseInitializing && old.Phase != workspacev1.WorkspacePhaseInitializing {
-		r.Recorder.Event(ws, corev1.EventTypeNormal, "Initializing", "")
-	}
-
-	if ws.Status.Phase == workspacev1.WorkspacePhaseRunning && old.Phase != workspacev1.WorkspacePhaseRunning {
-		r.Recorder.Event(ws, corev1.EventTypeNormal, "Running", "")
-	}
-
-	if ws.Status.Phase == workspacev1.WorkspacePhaseStopping && old.Phase != workspacev1.WorkspacePhaseStopping {
-		r.Recorder.Event(ws, corev1.EventTypeNormal, "Stopping", "")
-	}
-}
-
-func (r *WorkspaceReconciler) deleteWorkspacePod(ctx context.Context, pod *corev1.Pod, reason string) (result ctrl.Result, err error) {
+func (r *WorkspaceReconciler) deleteWorkspacePod(ctx context.Context, ws *workspacev1.Workspace, pod *corev1.Pod, reason string) (result ctrl.Result, err error) {
 	span, ctx := tracing.FromContext(ctx, "deleteWorkspacePod")
 	defer tracing.FinishSpan(span, &err)
 
 	// Workspace was requested to be deleted, propagate by deleting the Pod.
 	// The Pod deletion will then trigger workspace disposal steps.
 	err = r.Client.Delete(ctx, pod)
+	if err == nil {
+		r.Audit.Record(audit.WorkspaceEvent(ws, audit.ActionPodDeleted, auditActorWorkspaceController, reason))
+	}
 	if apierrors.IsNotFound(err) {
 		// pod is gone - nothing to do here
 	} else {
@@ -456,6 +575,14 @@ func (r *WorkspaceReconciler) deleteWorkspaceSecrets(ctx context.Context, ws *wo
 		log.Error(err, "could not delete token secret", "workspace", ws.Name)
 	}
 
+	if r.Config.ImagePullSecret != nil {
+		err = r.deleteSecret(ctx, workspacePullSecretName(ws), r.Config.Namespace)
+		if err != nil {
+			errs = append(errs, err.Error())
+			log.Error(err, "could not delete pull secret", "workspace", ws.Name)
+		}
+	}
+
 	if len(errs) != 0 {
 		return fmt.Errorf(strings.Join(errs, ":"))
 	}
@@ -541,6 +668,7 @@ func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return true
 		})).
 		Owns(&corev1.Pod{}).
+		Owns(&corev1.Service{}).
 		// Add a watch for Nodes, so that they're cached in memory and don't require calling the k8s API
 		// when reconciling workspaces.
 		Watches(&corev1.Node{}, &handler.Funcs{
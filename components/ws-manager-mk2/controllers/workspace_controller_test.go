@@ -99,6 +99,11 @@ var _ = Describe("WorkspaceController", func() {
 			expectSecretCleanup(envSecret)
 			expectSecretCleanup(tokenSecret)
 
+			// Ready condition and observed generation should be set for standard tooling.
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionReady), metav1.ConditionTrue, string(workspacev1.WorkspacePhaseRunning))
+			Expect(ws.Status.ObservedGeneration).To(Equal(ws.Generation))
+			Expect(wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionReady)).ObservedGeneration).To(Equal(ws.Generation))
+
 			markReady(ws)
 
 			requestStop(ws)
@@ -118,9 +123,55 @@ var _ = Describe("WorkspaceController", func() {
 				restores:       1,
 				stops:          map[StopReason]int{StopReasonRegular: 1},
 				backups:        1,
+				phases: map[workspacev1.WorkspacePhase]int{
+					workspacev1.WorkspacePhaseCreating: 1,
+					workspacev1.WorkspacePhaseRunning:  1,
+					workspacev1.WorkspacePhaseStopping: 1,
+				},
 			})
 		})
 
+		It("should expose ports", func() {
+			ws := newWorkspace(uuid.NewString(), "default")
+			ws.Spec.Ports = []workspacev1.PortSpec{{Port: 3000, Visibility: workspacev1.AdmissionLevelOwner, Protocol: workspacev1.PortProtocolHttp}}
+			pod := createWorkspaceExpectPod(ws)
+
+			expectPorts := func(ports ...workspacev1.PortStatus) {
+				GinkgoHelper()
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}, ws)).To(Succeed())
+					g.Expect(ws.Status.Ports).To(Equal(ports))
+				}, timeout, interval).Should(Succeed())
+			}
+
+			expectPorts(workspacev1.PortStatus{
+				Port:       3000,
+				Visibility: workspacev1.AdmissionLevelOwner,
+				Protocol:   workspacev1.PortProtocolHttp,
+				URL:        fmt.Sprintf("3000-%s-%s-gitpod.io", ws.Name, ws.Spec.Ownership.WorkspaceID),
+			})
+
+			By("changing the port visibility")
+			updateObjWithRetries(k8sClient, ws, false, func(ws *workspacev1.Workspace) {
+				ws.Spec.Ports[0].Visibility = workspacev1.AdmissionLevelEveryone
+			})
+			expectPorts(workspacev1.PortStatus{
+				Port:       3000,
+				Visibility: workspacev1.AdmissionLevelEveryone,
+				Protocol:   workspacev1.PortProtocolHttp,
+				URL:        fmt.Sprintf("3000-%s-%s-gitpod.io", ws.Name, ws.Spec.Ownership.WorkspaceID),
+			})
+
+			By("removing the port")
+			updateObjWithRetries(k8sClient, ws, false, func(ws *workspacev1.Workspace) {
+				ws.Spec.Ports = []workspacev1.PortSpec{}
+			})
+			expectPorts()
+
+			requestStop(ws)
+			expectWorkspaceCleanup(ws, pod)
+		})
+
 		It("should handle content init failure", func() {
 			ws := newWorkspace(uuid.NewString(), "default")
 			m := collectMetricCounts(wsMetrics, ws)
@@ -139,6 +190,7 @@ var _ = Describe("WorkspaceController", func() {
 				failures:        1,
 				restoreFailures: 1,
 				stops:           map[StopReason]int{StopReasonStartFailure: 1},
+				failureReasons:  map[FailureReason]int{FailureReasonContentInit: 1},
 			})
 		})
 
@@ -180,6 +232,7 @@ var _ = Describe("WorkspaceController", func() {
 				backupFailures: 1,
 				failures:       1,
 				stops:          map[StopReason]int{StopReasonFailed: 1},
+				failureReasons: map[FailureReason]int{FailureReasonBackup: 1},
 			})
 		})
 
@@ -210,11 +263,12 @@ var _ = Describe("WorkspaceController", func() {
 			expectWorkspaceCleanup(ws, pod)
 
 			expectMetricsDelta(m, collectMetricCounts(wsMetrics, ws), metricCounts{
-				restores:      1,
-				startFailures: 0,
-				failures:      1,
-				stops:         map[StopReason]int{StopReasonFailed: 1},
-				backups:       1,
+				restores:       1,
+				startFailures:  0,
+				failures:       1,
+				stops:          map[StopReason]int{StopReasonFailed: 1},
+				backups:        1,
+				failureReasons: map[FailureReason]int{FailureReasonOther: 1},
 			})
 		})
 
@@ -293,6 +347,77 @@ var _ = Describe("WorkspaceController", func() {
 			})
 		})
 
+		It("should handle workspace eviction", func() {
+			ws := newWorkspace(uuid.NewString(), "default")
+			m := collectMetricCounts(wsMetrics, ws)
+			pod := createWorkspaceExpectPod(ws)
+
+			markReady(ws)
+
+			// Update Pod as if it got evicted by the kubelet.
+			updateObjWithRetries(k8sClient, pod, true, func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodFailed
+				pod.Status.Reason = "Evicted"
+				pod.Status.Message = "The node was low on resource: memory."
+			})
+
+			// Controller should mark the workspace as interrupted, and still take a backup.
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionInterrupted), metav1.ConditionTrue, workspacev1.ReasonEvicted)
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionFailed), metav1.ConditionTrue, "")
+			Expect(wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionFailed)).Message).To(ContainSubstring("evicted"))
+
+			expectFinalizerAndMarkBackupCompleted(ws, pod)
+
+			expectWorkspaceCleanup(ws, pod)
+
+			expectMetricsDelta(m, collectMetricCounts(wsMetrics, ws), metricCounts{
+				restores:       1,
+				startFailures:  0,
+				failures:       1,
+				stops:          map[StopReason]int{StopReasonFailed: 1},
+				backups:        1,
+				failureReasons: map[FailureReason]int{FailureReasonInterrupted: 1},
+			})
+		})
+
+		It("should handle workspace running out of memory", func() {
+			ws := newWorkspace(uuid.NewString(), "default")
+			m := collectMetricCounts(wsMetrics, ws)
+			pod := createWorkspaceExpectPod(ws)
+
+			markReady(ws)
+
+			// Update Pod as if the kernel killed the workspace container.
+			updateObjWithRetries(k8sClient, pod, true, func(pod *corev1.Pod) {
+				pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+					Name: "workspace",
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: containerKilledExitCode,
+							Reason:   workspacev1.ReasonOOMKilled,
+						},
+					},
+				})
+			})
+
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionResourceExhausted), metav1.ConditionTrue, workspacev1.ReasonOOMKilled)
+			Expect(wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionResourceExhausted)).Message).To(ContainSubstring("larger workspace class"))
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionFailed), metav1.ConditionTrue, "")
+
+			expectFinalizerAndMarkBackupCompleted(ws, pod)
+
+			expectWorkspaceCleanup(ws, pod)
+
+			expectMetricsDelta(m, collectMetricCounts(wsMetrics, ws), metricCounts{
+				restores:       1,
+				failures:       1,
+				stops:          map[StopReason]int{StopReasonFailed: 1},
+				backups:        1,
+				failureReasons: map[FailureReason]int{FailureReasonExhausted: 1},
+				exhaustions:    map[string]int{workspacev1.ReasonOOMKilled: 1},
+			})
+		})
+
 		It("node disappearing should fail with backup failure", func() {
 			ws := newWorkspace(uuid.NewString(), "default")
 			m := collectMetricCounts(wsMetrics, ws)
@@ -380,12 +505,20 @@ var _ = Describe("WorkspaceController", func() {
 				return nil
 			}, timeout, interval).Should(Succeed(), "pod/workspace not cleaned up")
 
+			// The workspace should be marked as interrupted, and fail with the interruption message
+			// instead of a generic backup failure.
+			expectConditionEventually(ws, string(workspacev1.WorkspaceConditionInterrupted), metav1.ConditionTrue, workspacev1.ReasonNodeDisappeared)
+			c := wsk8s.GetCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionFailed))
+			Expect(c).ToNot(BeNil())
+			Expect(c.Message).To(ContainSubstring("node disappeared"))
+
 			expectMetricsDelta(m, collectMetricCounts(wsMetrics, ws), metricCounts{
 				restores:       1,
 				backups:        1,
 				backupFailures: 1,
 				failures:       1,
 				stops:          map[StopReason]int{StopReasonFailed: 1},
+				failureReasons: map[FailureReason]int{FailureReasonInterrupted: 1},
 			})
 		})
 
@@ -410,6 +543,13 @@ var _ = Describe("WorkspaceController", func() {
 				}
 			})
 
+			By("reporting the headless task's exit code")
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}, ws)).To(Succeed())
+				g.Expect(ws.Status.Headless).ToNot(BeNil())
+				g.Expect(ws.Status.Headless.ExitCode).To(Equal(ptr.Int32(5)))
+			}, timeout, interval).Should(Succeed())
+
 			expectWorkspaceCleanup(ws, pod)
 			expectMetricsDelta(m, collectMetricCounts(wsMetrics, ws), metricCounts{
 				restores:       1,
@@ -417,6 +557,7 @@ var _ = Describe("WorkspaceController", func() {
 				backupFailures: 0,
 				failures:       0,
 				stops:          map[StopReason]int{StopReasonRegular: 1},
+				headless:       map[HeadlessOutcome]int{HeadlessOutcomeTaskFailed: 1},
 			})
 		})
 
@@ -434,6 +575,7 @@ var _ = Describe("WorkspaceController", func() {
 				backupFailures: 0,
 				failures:       0,
 				stops:          map[StopReason]int{StopReasonRegular: 1},
+				headless:       map[HeadlessOutcome]int{HeadlessOutcomeSucceeded: 1},
 			})
 		})
 
@@ -462,6 +604,8 @@ var _ = Describe("WorkspaceController", func() {
 				backupFailures: 0,
 				failures:       1,
 				stops:          map[StopReason]int{StopReasonFailed: 1},
+				headless:       map[HeadlessOutcome]int{HeadlessOutcomeFailed: 1},
+				failureReasons: map[FailureReason]int{FailureReasonOther: 1},
 			})
 		})
 
@@ -482,6 +626,7 @@ var _ = Describe("WorkspaceController", func() {
 				backupFailures: 0,
 				failures:       0,
 				stops:          map[StopReason]int{StopReasonAborted: 1},
+				headless:       map[HeadlessOutcome]int{HeadlessOutcomeAborted: 1},
 			})
 		})
 
@@ -499,6 +644,7 @@ var _ = Describe("WorkspaceController", func() {
 				backupFailures: 0,
 				failures:       0,
 				stops:          map[StopReason]int{StopReasonRegular: 1},
+				headless:       map[HeadlessOutcome]int{HeadlessOutcomeSucceeded: 1},
 			})
 		})
 	})
@@ -832,6 +978,12 @@ type metricCounts struct {
 	backupFailures  int
 	restores        int
 	restoreFailures int
+	headless        map[HeadlessOutcome]int
+	failureReasons  map[FailureReason]int
+	exhaustions     map[string]int
+	// phases counts the recorded phase durations. Which phases a workspace is observed in depends on
+	// timing, hence only the phases given in the expected delta are checked.
+	phases map[workspacev1.WorkspacePhase]int
 }
 
 // collectHistCount is a hack to get the value of the histogram's sample count.
@@ -845,6 +997,12 @@ func collectHistCount(h prometheus.Histogram) uint64 {
 
 var stopReasons = []StopReason{StopReasonFailed, StopReasonStartFailure, StopReasonAborted, StopReasonOutOfSpace, StopReasonTimeout, StopReasonTabClosed, StopReasonRegular}
 
+var failureReasons = []FailureReason{FailureReasonImagePull, FailureReasonContentInit, FailureReasonBackup, FailureReasonInterrupted, FailureReasonExhausted, FailureReasonOther}
+
+var exhaustionReasons = []string{workspacev1.ReasonOOMKilled, workspacev1.ReasonEphemeralStorageExceeded}
+
+var headlessOutcomes = []HeadlessOutcome{HeadlessOutcomeSucceeded, HeadlessOutcomeTaskFailed, HeadlessOutcomeFailed, HeadlessOutcomeAborted, HeadlessOutcomeTimeout, HeadlessOutcomeStopped}
+
 func collectMetricCounts(wsMetrics *controllerMetrics, ws *workspacev1.Workspace) metricCounts {
 	tpe := string(ws.Spec.Type)
 	cls := ws.Spec.Class
@@ -854,6 +1012,22 @@ func collectMetricCounts(wsMetrics *controllerMetrics, ws *workspacev1.Workspace
 	for _, reason := range stopReasons {
 		stopCounts[reason] = int(testutil.ToFloat64(wsMetrics.totalStopsCounterVec.WithLabelValues(string(reason), tpe, cls)))
 	}
+	failureReasonCounts := make(map[FailureReason]int)
+	for _, reason := range failureReasons {
+		failureReasonCounts[reason] = int(testutil.ToFloat64(wsMetrics.failureReasonsCounterVec.WithLabelValues(tpe, cls, string(reason))))
+	}
+	exhaustionCounts := make(map[string]int)
+	for _, reason := range exhaustionReasons {
+		exhaustionCounts[reason] = int(testutil.ToFloat64(wsMetrics.resourceExhaustionCounterVec.WithLabelValues(tpe, cls, reason)))
+	}
+	phaseCounts := make(map[workspacev1.WorkspacePhase]int)
+	for phase := range phaseDurationPhases {
+		phaseCounts[phase] = int(collectHistCount(wsMetrics.phaseTimeHistVec.WithLabelValues(tpe, cls, string(phase)).(prometheus.Histogram)))
+	}
+	headlessCounts := make(map[HeadlessOutcome]int)
+	for _, outcome := range headlessOutcomes {
+		headlessCounts[outcome] = int(testutil.ToFloat64(wsMetrics.headlessCompletionsCounterVec.WithLabelValues(tpe, cls, string(outcome))))
+	}
 	return metricCounts{
 		starts:          int(collectHistCount(startHist)),
 		creatingCounts:  int(collectHistCount(creatingHist)),
@@ -864,6 +1038,10 @@ func collectMetricCounts(wsMetrics *controllerMetrics, ws *workspacev1.Workspace
 		backupFailures:  int(testutil.ToFloat64(wsMetrics.totalBackupFailureCounterVec.WithLabelValues(tpe, cls))),
 		restores:        int(testutil.ToFloat64(wsMetrics.totalRestoreCounterVec.WithLabelValues(tpe, cls))),
 		restoreFailures: int(testutil.ToFloat64(wsMetrics.totalRestoreFailureCounterVec.WithLabelValues(tpe, cls))),
+		headless:        headlessCounts,
+		failureReasons:  failureReasonCounts,
+		exhaustions:     exhaustionCounts,
+		phases:          phaseCounts,
 	}
 }
 
@@ -881,4 +1059,16 @@ func expectMetricsDelta(initial metricCounts, cur metricCounts, expectedDelta me
 	Expect(cur.backupFailures-initial.backupFailures).To(Equal(expectedDelta.backupFailures), "expected metric count delta for backupFailures")
 	Expect(cur.restores-initial.restores).To(Equal(expectedDelta.restores), "expected metric count delta for restores")
 	Expect(cur.restoreFailures-initial.restoreFailures).To(Equal(expectedDelta.restoreFailures), "expected metric count delta for restoreFailures")
+	for _, outcome := range headlessOutcomes {
+		Expect(cur.headless[outcome]-initial.headless[outcome]).To(Equal(expectedDelta.headless[outcome]), "expected metric count delta for headless completions with outcome %s", outcome)
+	}
+	for _, reason := range failureReasons {
+		Expect(cur.failureReasons[reason]-initial.failureReasons[reason]).To(Equal(expectedDelta.failureReasons[reason]), "expected metric count delta for failures with reason %s", reason)
+	}
+	for _, reason := range exhaustionReasons {
+		Expect(cur.exhaustions[reason]-initial.exhaustions[reason]).To(Equal(expectedDelta.exhaustions[reason]), "expected metric count delta for resource exhaustions with reason %s", reason)
+	}
+	for phase, delta := range expectedDelta.phases {
+		Expect(cur.phases[phase]-initial.phases[phase]).To(Equal(delta), "expected metric count delta for phase %s durations", phase)
+	}
 }
@@ -0,0 +1,259 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	config "github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// maxEnvVarSize is the maximum size of a single environment variable (name=value). The kernel refuses
+	// to exec a process with a larger variable (MAX_ARG_STRLEN), which would leave the workspace pod stuck.
+	maxEnvVarSize = 128 * 1024
+	// maxTotalEnvVarSize is the maximum combined size of all environment variables of a workspace.
+	maxTotalEnvVarSize = 1024 * 1024
+)
+
+// imageRefRegexp matches image references of the form [domain[:port]/]path[:tag][@digest],
+// following the grammar of github.com/distribution/reference.
+var imageRefRegexp = regexp.MustCompile(`^` +
+	// optional domain with port
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	// path components
+	`[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*` +
+	// optional tag
+	`(?::[\w][\w.-]{0,127})?` +
+	// optional digest
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?` +
+	`$`)
+
+func NewWorkspaceValidator(cfg *config.Configuration) *WorkspaceValidator {
+	return &WorkspaceValidator{Config: cfg}
+}
+
+// WorkspaceValidator rejects invalid Workspace objects on admission, such that they fail
+// when they're created instead of producing workspace pods that never start.
+type WorkspaceValidator struct {
+	Config *config.Configuration
+}
+
+//+kubebuilder:webhook:path=/validate-workspace-gitpod-io-v1-workspace,mutating=false,failurePolicy=fail,sideEffects=None,groups=workspace.gitpod.io,resources=workspaces,verbs=create;update,versions=v1,name=vworkspace.kb.io,admissionReviewVersions=v1
+
+var _ admission.CustomValidator = &WorkspaceValidator{}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *WorkspaceValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ws, ok := obj.(*workspacev1.Workspace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Workspace but got a %T", obj)
+	}
+
+	return nil, toInvalidError(ws, v.validateSpec(ws))
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *WorkspaceValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldWs, ok := oldObj.(*workspacev1.Workspace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Workspace but got a %T", oldObj)
+	}
+	ws, ok := newObj.(*workspacev1.Workspace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Workspace but got a %T", newObj)
+	}
+
+	// The fields checked on creation are immutable, hence we don't validate them again. This also
+	// ensures existing workspaces can still be updated after e.g. their workspace class was removed.
+	return nil, toInvalidError(ws, validateImmutableFields(oldWs, ws))
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *WorkspaceValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *WorkspaceValidator) validateSpec(ws *workspacev1.Workspace) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+
+	if class, ok := v.Config.WorkspaceClasses[ws.Spec.Class]; !ok {
+		errs = append(errs, field.NotSupported(spec.Child("class"), ws.Spec.Class, workspaceClassNames(v.Config)))
+	} else if class.Deprecated() {
+		errs = append(errs, field.Forbidden(spec.Child("class"), class.DeprecationMessage(ws.Spec.Class)))
+	}
+
+	image := spec.Child("image")
+	if ref := ws.Spec.Image.Workspace.Ref; ref != nil {
+		errs = append(errs, validateImageRef(image.Child("workspace", "ref"), *ref)...)
+	}
+	errs = append(errs, validateImageRef(image.Child("ide", "web"), ws.Spec.Image.IDE.Web)...)
+	errs = append(errs, validateImageRef(image.Child("ide", "supervisor"), ws.Spec.Image.IDE.Supervisor)...)
+	for i, ref := range ws.Spec.Image.IDE.Refs {
+		errs = append(errs, validateImageRef(image.Child("ide", "refs").Index(i), ref)...)
+	}
+
+	var total int
+	for _, env := range []struct {
+		path *field.Path
+		vars []corev1.EnvVar
+	}{
+		{spec.Child("userEnvVars"), ws.Spec.UserEnvVars},
+		{spec.Child("sysEnvVars"), ws.Spec.SysEnvVars},
+	} {
+		for i, e := range env.vars {
+			size := len(e.Name) + len(e.Value) + 1
+			if size > maxEnvVarSize {
+				errs = append(errs, field.TooLong(env.path.Index(i).Child("value"), "", maxEnvVarSize))
+			}
+			total += size
+		}
+	}
+	if total > maxTotalEnvVarSize {
+		errs = append(errs, field.Forbidden(spec, fmt.Sprintf("environment variables must not exceed %d bytes in total", maxTotalEnvVarSize)))
+	}
+
+	return errs
+}
+
+func validateImageRef(path *field.Path, ref string) field.ErrorList {
+	if ref == "" {
+		// Not all workspace types use all images, whether an image is required is up to the workspace's creator.
+		return nil
+	}
+	if !imageRefRegexp.MatchString(ref) {
+		return field.ErrorList{field.Invalid(path, ref, "must be a valid image reference")}
+	}
+	return nil
+}
+
+// validateImmutableFields ensures that only the fields of the workspace spec which ws-manager
+// modifies during the workspace lifetime are changed on update.
+func validateImmutableFields(oldWs, ws *workspacev1.Workspace) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+
+	immutable := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"ownership", oldWs.Spec.Ownership, ws.Spec.Ownership},
+		{"type", oldWs.Spec.Type, ws.Spec.Type},
+		{"class", oldWs.Spec.Class, ws.Spec.Class},
+		{"image", oldWs.Spec.Image, ws.Spec.Image},
+		{"initializer", oldWs.Spec.Initializer, ws.Spec.Initializer},
+		{"userEnvVars", oldWs.Spec.UserEnvVars, ws.Spec.UserEnvVars},
+		{"sysEnvVars", oldWs.Spec.SysEnvVars, ws.Spec.SysEnvVars},
+		{"workspaceLocation", oldWs.Spec.WorkspaceLocation, ws.Spec.WorkspaceLocation},
+		{"git", oldWs.Spec.Git, ws.Spec.Git},
+		{"storageQuota", oldWs.Spec.StorageQuota, ws.Spec.StorageQuota},
+		{"sshGatewayCAPublicKey", oldWs.Spec.SSHGatewayCAPublicKey, ws.Spec.SSHGatewayCAPublicKey},
+		{"debug", oldWs.Spec.Debug, ws.Spec.Debug},
+		{"restoreSnapshot", oldWs.Spec.RestoreSnapshot, ws.Spec.RestoreSnapshot},
+	}
+	for _, f := range immutable {
+		if !equality.Semantic.DeepEqual(f.old, f.new) {
+			errs = append(errs, field.Forbidden(spec.Child(f.name), "field is immutable"))
+		}
+	}
+
+	return errs
+}
+
+func toInvalidError(ws *workspacev1.Workspace, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(workspacev1.GroupVersion.WithKind("Workspace").GroupKind(), ws.Name, errs)
+}
+
+func workspaceClassNames(cfg *config.Configuration) []string {
+	res := make([]string, 0, len(cfg.WorkspaceClasses))
+	for name := range cfg.WorkspaceClasses {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func NewWorkspaceDefaulter(cfg *config.Configuration) *WorkspaceDefaulter {
+	return &WorkspaceDefaulter{Config: cfg}
+}
+
+// WorkspaceDefaulter fills in the fields of a Workspace which were omitted on creation from the
+// controller configuration, such that e.g. workspaces created using kubectl can start.
+type WorkspaceDefaulter struct {
+	Config *config.Configuration
+}
+
+//+kubebuilder:webhook:path=/mutate-workspace-gitpod-io-v1-workspace,mutating=true,failurePolicy=fail,sideEffects=None,groups=workspace.gitpod.io,resources=workspaces,verbs=create;update,versions=v1,name=mworkspace.kb.io,admissionReviewVersions=v1
+
+var _ admission.CustomDefaulter = &WorkspaceDefaulter{}
+
+// Default implements admission.CustomDefaulter.
+func (d *WorkspaceDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	ws, ok := obj.(*workspacev1.Workspace)
+	if !ok {
+		return fmt.Errorf("expected a Workspace but got a %T", obj)
+	}
+
+	// Only default on creation, as most of the defaulted fields are immutable afterwards.
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Operation != admissionv1.Create {
+		return nil
+	}
+
+	if ws.Spec.Class == "" {
+		ws.Spec.Class = d.defaultClass()
+	}
+
+	// The timeouts of the workspace class are deliberately not filled in, but resolved whenever the workspace
+	// is reconciled (see effectiveTimeouts). That way changes to the class apply to existing workspaces.
+
+	if class, ok := d.Config.WorkspaceClasses[ws.Spec.Class]; ok && class.Container.Limits != nil && ws.Spec.StorageQuota == 0 {
+		storage, err := class.Container.Limits.StorageQuantity()
+		if err != nil {
+			return fmt.Errorf("workspace class %s has invalid storage quantity: %w", ws.Spec.Class, err)
+		}
+		ws.Spec.StorageQuota = int(storage.Value())
+	}
+
+	if ws.Spec.SSHGatewayCAPublicKey == "" {
+		ws.Spec.SSHGatewayCAPublicKey = d.Config.SSHGatewayCAPublicKey
+	}
+
+	return nil
+}
+
+// defaultClass returns the workspace class of workspaces created without one, i.e. the class the
+// installation prefers, or else the default class every installation is required to have.
+func (d *WorkspaceDefaulter) defaultClass() string {
+	if _, ok := d.Config.WorkspaceClasses[d.Config.PreferredWorkspaceClass]; ok {
+		return d.Config.PreferredWorkspaceClass
+	}
+	return config.DefaultWorkspaceClass
+}
+
+// SetupWorkspaceWebhookWithManager registers the Workspace admission webhooks with the manager's webhook server.
+func SetupWorkspaceWebhookWithManager(mgr ctrl.Manager, cfg *config.Configuration) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&workspacev1.Workspace{}).
+		WithDefaulter(NewWorkspaceDefaulter(cfg)).
+		WithValidator(NewWorkspaceValidator(cfg)).
+		Complete()
+}
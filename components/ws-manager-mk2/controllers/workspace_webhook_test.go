@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go/ptr"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+var _ = Describe("WorkspaceValidator", func() {
+	var (
+		conf = newTestConfig()
+		v    = NewWorkspaceValidator(&conf)
+	)
+
+	DescribeTable("create",
+		func(mod func(ws *workspacev1.Workspace), expectedErr string) {
+			ws := newWorkspace(uuid.NewString(), "default")
+			ws.Spec.Image.IDE.Web = "eu.gcr.io/gitpod-core-dev/build/ide/code:commit-4ad44a1c"
+			ws.Spec.Image.IDE.Supervisor = "eu.gcr.io/gitpod-core-dev/build/supervisor@sha256:4d6dc4a0a0d9b0b3d4a7c21f4d1a4e1b4f0bb7c3a1e8b1b9c0d8e7f6a5b4c3d2"
+			if mod != nil {
+				mod(ws)
+			}
+
+			_, err := v.ValidateCreate(ctx, ws)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+		},
+		Entry("valid workspace", nil, ""),
+		Entry("registry-facade image ref", func(ws *workspacev1.Workspace) {
+			ws.Spec.Image.Workspace.Ref = ptr.String("reg.gitpod.io:20000/remote/" + uuid.NewString())
+		}, ""),
+		Entry("invalid workspace image ref", func(ws *workspacev1.Workspace) {
+			ws.Spec.Image.Workspace.Ref = ptr.String("Not A Valid/Image")
+		}, "spec.image.workspace.ref: Invalid value"),
+		Entry("invalid IDE image ref", func(ws *workspacev1.Workspace) {
+			ws.Spec.Image.IDE.Refs = []string{"alpine:latest", "alpine:::"}
+		}, "spec.image.ide.refs[1]: Invalid value"),
+		Entry("unknown workspace class", func(ws *workspacev1.Workspace) {
+			ws.Spec.Class = "does-not-exist"
+		}, `spec.class: Unsupported value: "does-not-exist"`),
+		Entry("deprecated workspace class", func(ws *workspacev1.Workspace) {
+			ws.Spec.Class = "deprecated"
+		}, `spec.class: Forbidden: workspace class "deprecated" is deprecated, please use "default" instead`),
+		Entry("oversize env var", func(ws *workspacev1.Workspace) {
+			ws.Spec.UserEnvVars = []corev1.EnvVar{{Name: "FOO", Value: strings.Repeat("a", maxEnvVarSize)}}
+		}, "spec.userEnvVars[0].value: Too long"),
+		Entry("oversize total env vars", func(ws *workspacev1.Workspace) {
+			for i := 0; i < 20; i++ {
+				ws.Spec.SysEnvVars = append(ws.Spec.SysEnvVars, corev1.EnvVar{Name: "FOO", Value: strings.Repeat("a", maxEnvVarSize/2)})
+			}
+		}, "spec: Forbidden: environment variables must not exceed"),
+	)
+
+	DescribeTable("update",
+		func(mod func(ws *workspacev1.Workspace), expectedErr string) {
+			oldWs := newWorkspace(uuid.NewString(), "default")
+			ws := oldWs.DeepCopy()
+			mod(ws)
+
+			_, err := v.ValidateUpdate(ctx, oldWs, ws)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+		},
+		Entry("mutable fields", func(ws *workspacev1.Workspace) {
+			ws.Spec.Timeout.Time = &metav1.Duration{Duration: 1}
+			ws.Spec.Admission.Level = workspacev1.AdmissionLevelOwner
+			ws.Spec.Ports = append(ws.Spec.Ports, workspacev1.PortSpec{Port: 3000})
+			ws.Spec.SshPublicKeys = []string{"ssh-ed25519 AAAA"}
+			ws.Finalizers = nil
+		}, ""),
+		Entry("class change", func(ws *workspacev1.Workspace) {
+			ws.Spec.Class = "short-lived"
+		}, "spec.class: Forbidden: field is immutable"),
+		Entry("image change", func(ws *workspacev1.Workspace) {
+			ws.Spec.Image.Workspace.Ref = ptr.String("alpine:edge")
+		}, "spec.image: Forbidden: field is immutable"),
+		Entry("owner change", func(ws *workspacev1.Workspace) {
+			ws.Spec.Ownership.Owner = "someone-else"
+		}, "spec.ownership: Forbidden: field is immutable"),
+	)
+})
+
+var _ = Describe("WorkspaceDefaulter", func() {
+	var (
+		conf = newTestConfig()
+		d    = NewWorkspaceDefaulter(&conf)
+	)
+
+	It("should fill in omitted fields from the configuration", func() {
+		conf.SSHGatewayCAPublicKey = "ssh-ed25519 CA"
+		ws := newWorkspace(uuid.NewString(), "default")
+		ws.Spec.Class = ""
+
+		Expect(d.Default(ctx, ws)).To(Succeed())
+		Expect(ws.Spec.Class).To(Equal(config.DefaultWorkspaceClass))
+		Expect(ws.Spec.SSHGatewayCAPublicKey).To(Equal("ssh-ed25519 CA"))
+	})
+
+	It("should default to the preferred workspace class", func() {
+		conf.PreferredWorkspaceClass = "short-lived"
+		defer func() { conf.PreferredWorkspaceClass = "" }()
+		ws := newWorkspace(uuid.NewString(), "default")
+		ws.Spec.Class = ""
+
+		Expect(d.Default(ctx, ws)).To(Succeed())
+		Expect(ws.Spec.Class).To(Equal("short-lived"))
+	})
+
+	It("should not freeze the class timeouts into the workspace", func() {
+		ws := newWorkspace(uuid.NewString(), "default")
+		ws.Spec.Class = "short-lived"
+
+		Expect(d.Default(ctx, ws)).To(Succeed())
+		Expect(ws.Spec.Timeout.Time).To(BeNil())
+		Expect(ws.Spec.Timeout.MaximumLifetime).To(BeNil())
+	})
+
+	It("should keep fields which were set", func() {
+		ws := newWorkspace(uuid.NewString(), "default")
+		ws.Spec.Class = "short-lived"
+		ws.Spec.Timeout.Time = &metav1.Duration{Duration: 5 * time.Minute}
+		ws.Spec.SSHGatewayCAPublicKey = "ssh-ed25519 other"
+
+		Expect(d.Default(ctx, ws)).To(Succeed())
+		Expect(ws.Spec.Class).To(Equal("short-lived"))
+		Expect(ws.Spec.Timeout.Time.Duration).To(Equal(5 * time.Minute))
+		Expect(ws.Spec.SSHGatewayCAPublicKey).To(Equal("ssh-ed25519 other"))
+	})
+})
@@ -6,11 +6,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -40,10 +42,13 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/pprof"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/components/scrubber"
 	imgbldr "github.com/gitpod-io/gitpod/image-builder/api"
 	regapi "github.com/gitpod-io/gitpod/registry-facade/api"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/controllers"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/diagnostics"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/maintenance"
 	imgproxy "github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/proxy"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/service"
@@ -63,6 +68,22 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+const (
+	// The leader releases its lease when it stops, such that another replica takes over within a retry
+	// period. The lease duration only matters if the leader disappears without releasing the lease.
+	defaultLeaseDuration = 8 * time.Second
+	defaultRenewDeadline = 5 * time.Second
+	defaultRetryPeriod   = 1 * time.Second
+
+	// defaultGracefulShutdownTimeout must stay below the termination grace period of the pod,
+	// otherwise we're killed before we could release the lease.
+	defaultGracefulShutdownTimeout = 25 * time.Second
+
+	// grpcShutdownTimeout is how long we wait for in-flight gRPC calls before we close all connections.
+	// Subscriptions never finish on their own, clients reconnect to another replica.
+	grpcShutdownTimeout = 5 * time.Second
+)
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
@@ -125,25 +146,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	cacheNamespaces := map[string]cache.Config{
+		cfg.Manager.Namespace:        {},
+		cfg.Manager.SecretsNamespace: {},
+	}
+	if ips := cfg.Manager.ImagePullSecret; ips != nil && ips.Namespace != "" {
+		// we watch the image pull secret to refresh the pull secrets of workspaces when it changes
+		cacheNamespaces[ips.Namespace] = cache.Config{}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:  scheme,
 		Metrics: metricsserver.Options{BindAddress: cfg.Prometheus.Addr},
 		Cache: cache.Options{
-			DefaultNamespaces: map[string]cache.Config{
-				cfg.Manager.Namespace:        {},
-				cfg.Manager.SecretsNamespace: {},
-			},
+			DefaultNamespaces: cacheNamespaces,
 		},
 		WebhookServer: webhook.NewServer(webhook.Options{
-			Port: 9443,
+			Port:    9443,
+			CertDir: cfg.Webhook.CertDir,
 		}),
 		HealthProbeBindAddress:        cfg.Health.Addr,
 		LeaderElection:                true,
 		LeaderElectionID:              "ws-manager-mk2-leader.gitpod.io",
 		LeaderElectionReleaseOnCancel: true,
+		LeaseDuration:                 durationOrDefault(cfg.LeaderElection.LeaseDuration, defaultLeaseDuration),
+		RenewDeadline:                 durationOrDefault(cfg.LeaderElection.RenewDeadline, defaultRenewDeadline),
+		RetryPeriod:                   durationOrDefault(cfg.LeaderElection.RetryPeriod, defaultRetryPeriod),
+		GracefulShutdownTimeout:       durationOrDefault(cfg.GracefulShutdownTimeout, defaultGracefulShutdownTimeout),
 		NewClient: func(config *rest.Config, options client.Options) (client.Client, error) {
-			config.QPS = 100
-			config.Burst = 150
+			config.QPS, config.Burst = cfg.KubernetesClientRateLimits()
 
 			c, err := client.New(config, options)
 			if err != nil {
@@ -160,6 +191,15 @@ func main() {
 
 	mgrCtx := ctrl.SetupSignalHandler()
 
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		auditLog, err = newAuditLog(cfg.Audit.File)
+		if err != nil {
+			setupLog.Error(err, "unable to open audit log")
+			os.Exit(1)
+		}
+	}
+
 	maintenanceReconciler, err := controllers.NewMaintenanceReconciler(mgr.GetClient(), metrics.Registry)
 	if err != nil {
 		setupLog.Error(err, "unable to create maintenance controller", "controller", "Maintenance")
@@ -171,8 +211,9 @@ func main() {
 		setupLog.Error(err, "unable to create timeout controller", "controller", "Timeout")
 		os.Exit(1)
 	}
+	timeoutReconciler.Audit = auditLog
 
-	wsmanService, err := setupGRPCService(cfg, mgr.GetClient(), maintenanceReconciler)
+	wsmanService, err := setupGRPCService(mgrCtx, cfg, mgr.GetClient(), maintenanceReconciler, auditLog)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager service")
 		os.Exit(1)
@@ -197,6 +238,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	var traces *diagnostics.Traces
+	if cfg.Diagnostics.Addr != "" {
+		diagnosticsServer, err := diagnostics.NewServer(cfg.Diagnostics.Addr, cfg.Diagnostics.TokenFile, metrics.Registry)
+		if err != nil {
+			setupLog.Error(err, "unable to create diagnostics server")
+			os.Exit(1)
+		}
+
+		if err = mgr.Add(diagnosticsServer); err != nil {
+			setupLog.Error(err, "unable to add diagnostics server to manager")
+			os.Exit(1)
+		}
+		traces = diagnosticsServer.Traces
+	}
+
 	go func() {
 		<-mgr.Elected()
 
@@ -206,6 +262,8 @@ func main() {
 			setupLog.Error(err, "unable to create controller", "controller", "Workspace")
 			os.Exit(1)
 		}
+		workspaceReconciler.Traces = traces
+		workspaceReconciler.Audit = auditLog
 
 		if err = workspaceReconciler.SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to setup workspace controller with manager", "controller", "Workspace")
@@ -223,10 +281,51 @@ func main() {
 		os.Exit(1)
 	}
 
-	// if err = (&workspacev1.Workspace{}).SetupWebhookWithManager(mgr); err != nil {
-	// 	setupLog.Error(err, "unable to create webhook", "webhook", "Workspace")
-	// 	os.Exit(1)
-	// }
+	if cfg.Manager.SnapshotRetention.Enabled() {
+		snapshotGCReconciler, err := controllers.NewSnapshotGCReconciler(mgr.GetClient(), cfg.Manager.SnapshotRetention, metrics.Registry)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SnapshotGC")
+			os.Exit(1)
+		}
+
+		if err = snapshotGCReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to setup snapshot gc controller with manager", "controller", "SnapshotGC")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Manager.ImagePullSecret != nil {
+		pullSecretReconciler, err := controllers.NewPullSecretReconciler(mgr.GetClient(), &cfg.Manager)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PullSecret")
+			os.Exit(1)
+		}
+
+		if err = pullSecretReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to setup pull secret controller with manager", "controller", "PullSecret")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Manager.OrphanSweep.Interval > 0 {
+		orphanSweeper, err := controllers.NewOrphanSweeper(mgr.GetClient(), mgr.GetAPIReader(), &cfg.Manager, metrics.Registry)
+		if err != nil {
+			setupLog.Error(err, "unable to create orphan sweeper")
+			os.Exit(1)
+		}
+
+		if err = mgr.Add(orphanSweeper); err != nil {
+			setupLog.Error(err, "unable to add orphan sweeper to manager")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Webhook.Enabled {
+		if err = controllers.SetupWorkspaceWebhookWithManager(mgr, &cfg.Manager); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Workspace")
+			os.Exit(1)
+		}
+	}
 
 	//+kubebuilder:scaffold:builder
 
@@ -246,7 +345,7 @@ func main() {
 	}
 }
 
-func setupGRPCService(cfg *config.ServiceConfiguration, k8s client.Client, maintenance maintenance.Maintenance) (*service.WorkspaceManagerServer, error) {
+func setupGRPCService(ctx context.Context, cfg *config.ServiceConfiguration, k8s client.Client, maintenance maintenance.Maintenance, auditLog *audit.Log) (*service.WorkspaceManagerServer, error) {
 	// TODO(cw): remove use of common-go/log
 
 	if len(cfg.RPCServer.RateLimits) > 0 {
@@ -303,6 +402,7 @@ func setupGRPCService(cfg *config.ServiceConfiguration, k8s client.Client, maint
 	}
 
 	srv := service.NewWorkspaceManagerServer(k8s, &cfg.Manager, metrics.Registry, maintenance)
+	srv.Audit = auditLog
 
 	grpc_prometheus.Register(grpcServer)
 	wsmanapi.RegisterWorkspaceManagerServer(grpcServer, srv)
@@ -323,9 +423,47 @@ func setupGRPCService(cfg *config.ServiceConfiguration, k8s client.Client, maint
 	}()
 	log.WithField("addr", cfg.RPCServer.Addr).Info("started gRPC server")
 
+	go func() {
+		// Stop accepting new calls once we're asked to stop, such that clients move on to another replica.
+		<-ctx.Done()
+		log.Info("stopping gRPC server")
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(grpcShutdownTimeout):
+			grpcServer.Stop()
+		}
+	}()
+
 	return srv, nil
 }
 
+// newAuditLog creates an audit log which appends to the file, or writes to stdout if no file is given
+func newAuditLog(fn string) (*audit.Log, error) {
+	if fn == "" {
+		return audit.NewLog(os.Stdout), nil
+	}
+
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open audit log %s: %w", fn, err)
+	}
+	return audit.NewLog(f), nil
+}
+
+func durationOrDefault(d util.Duration, def time.Duration) *time.Duration {
+	res := def
+	if d > 0 {
+		res = time.Duration(d)
+	}
+	return &res
+}
+
 func getConfig(fn string) (*config.ServiceConfiguration, error) {
 	ctnt, err := os.ReadFile(fn)
 	if err != nil {
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+// Action is a decision affecting a workspace
+type Action string
+
+const (
+	// ActionStartRequested is a request to start a workspace via the API
+	ActionStartRequested Action = "StartRequested"
+	// ActionStopRequested is a request to stop a workspace via the API
+	ActionStopRequested Action = "StopRequested"
+	// ActionTimeoutChanged is a request to change the timeout of a workspace via the API
+	ActionTimeoutChanged Action = "TimeoutChanged"
+	// ActionTimedOut is the timeout of a workspace being applied
+	ActionTimedOut Action = "TimedOut"
+	// ActionPodDeleted is the workspace pod being deleted, which stops the workspace
+	ActionPodDeleted Action = "PodDeleted"
+	// ActionWorkspaceDeleted is the workspace resource being deleted after the workspace stopped
+	ActionWorkspaceDeleted Action = "WorkspaceDeleted"
+	// ActionLifecycle is a step in the lifecycle of the workspace, e.g. the outcome of its backup
+	ActionLifecycle Action = "Lifecycle"
+)
+
+// Event is a single entry of the audit log
+type Event struct {
+	Time        time.Time `json:"time"`
+	Action      Action    `json:"action"`
+	InstanceID  string    `json:"instanceId"`
+	WorkspaceID string    `json:"workspaceId,omitempty"`
+	OwnerID     string    `json:"ownerId,omitempty"`
+	// Actor is who made the decision, i.e. a controller or the client which called the API
+	Actor   string            `json:"actor"`
+	Reason  string            `json:"reason,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// WorkspaceEvent produces an event about the workspace
+func WorkspaceEvent(ws *workspacev1.Workspace, action Action, actor, reason string) Event {
+	return Event{
+		Action:      action,
+		InstanceID:  ws.Name,
+		WorkspaceID: ws.Spec.Ownership.WorkspaceID,
+		OwnerID:     ws.Spec.Ownership.Owner,
+		Actor:       actor,
+		Reason:      reason,
+	}
+}
+
+// Log is an append-only log of the decisions affecting workspaces. Every event is written as a single
+// line of JSON, such that compliance reviews can follow the history of a workspace without having to
+// reconstruct it from the debug logs. A nil Log discards all events.
+type Log struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewLog creates a new audit log writing to w
+func NewLog(w io.Writer) *Log {
+	return &Log{enc: json.NewEncoder(w)}
+}
+
+// entry marks audit log lines, such that they can be told apart when written to stdout among other logs
+type entry struct {
+	Audit bool `json:"audit"`
+	Event
+}
+
+// Record appends the event to the audit log. Events without a time happened now.
+func (l *Log) Record(ev Event) {
+	if l == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(entry{Audit: true, Event: ev}); err != nil {
+		log.Log.Error(err, "cannot write audit log", "action", ev.Action, "instanceId", ev.InstanceID)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package audit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestLogRecord(t *testing.T) {
+	ws := &workspacev1.Workspace{}
+	ws.Name = "instance"
+	ws.Spec.Ownership.Owner = "owner"
+	ws.Spec.Ownership.WorkspaceID = "workspace"
+
+	var buf bytes.Buffer
+	l := NewLog(&buf)
+	ev := WorkspaceEvent(ws, ActionTimedOut, "timeout-controller", "workspace timed out after 30m")
+	ev.Time = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.Record(ev)
+	l.Record(Event{Time: ev.Time, Action: ActionStopRequested, InstanceID: "other", Actor: "10.0.0.1:1234", Details: map[string]string{"policy": "NORMALLY"}})
+
+	expectation := `{"audit":true,"time":"2023-05-01T12:00:00Z","action":"TimedOut","instanceId":"instance","workspaceId":"workspace","ownerId":"owner","actor":"timeout-controller","reason":"workspace timed out after 30m"}
+{"audit":true,"time":"2023-05-01T12:00:00Z","action":"StopRequested","instanceId":"other","actor":"10.0.0.1:1234","details":{"policy":"NORMALLY"}}
+`
+	if diff := cmp.Diff(expectation, buf.String()); diff != "" {
+		t.Errorf("unexpected audit log (-want +got):\n%s", diff)
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	// must not panic
+	l.Record(Event{Action: ActionPodDeleted})
+}
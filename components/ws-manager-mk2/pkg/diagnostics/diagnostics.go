@@ -0,0 +1,268 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diagnostics
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gitpod-io/gitpod/common-go/pprof"
+)
+
+const (
+	// DefaultEnableDuration is how long diagnostics stay enabled if no duration is given.
+	DefaultEnableDuration = 1 * time.Hour
+	// MaxEnableDuration is the longest diagnostics can be enabled for at once.
+	MaxEnableDuration = 24 * time.Hour
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server serves pprof profiles, the state of the controllers' work queues and reconcile traces of workspaces,
+// such that reconcile stalls can be debugged on a running ws-manager-mk2.
+//
+// Every request must carry the bearer token from the token file. Apart from enabling them, all diagnostics are
+// disabled until they're enabled at runtime using POST /debug/enable?for=<duration>, and disable themselves again
+// after that duration.
+type Server struct {
+	Addr      string
+	TokenFile string
+	Gatherer  prometheus.Gatherer
+	Traces    *Traces
+
+	// enabledUntil is the unix time in nanoseconds until which diagnostics are enabled
+	enabledUntil atomic.Int64
+}
+
+// NewServer creates a new, disabled diagnostics server.
+func NewServer(addr, tokenFile string, gatherer prometheus.Gatherer) (*Server, error) {
+	if tokenFile == "" {
+		return nil, fmt.Errorf("diagnostics require a token file")
+	}
+
+	s := &Server{
+		Addr:      addr,
+		TokenFile: tokenFile,
+		Gatherer:  gatherer,
+	}
+	traces, err := newTraces(s.Enabled)
+	if err != nil {
+		return nil, err
+	}
+	s.Traces = traces
+	return s, nil
+}
+
+// Enabled returns true if diagnostics are currently enabled.
+func (s *Server) Enabled() bool {
+	return time.Now().UnixNano() < s.enabledUntil.Load()
+}
+
+// Enable enables diagnostics for the given duration. A non-positive duration disables them.
+func (s *Server) Enable(d time.Duration) {
+	if d <= 0 {
+		s.enabledUntil.Store(0)
+		return
+	}
+	s.enabledUntil.Store(time.Now().Add(d).UnixNano())
+}
+
+// Start implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("diagnostics")
+
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("serving diagnostics", "addr", s.Addr)
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica serves diagnostics,
+// such that stalls of non-leaders can be debugged, too.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the HTTP handler serving all diagnostics endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/enable", s.handleEnable)
+	mux.HandleFunc("/debug/disable", s.handleDisable)
+	mux.HandleFunc("/debug/status", s.handleStatus)
+	mux.Handle("/debug/workqueue", s.requireEnabled(http.HandlerFunc(s.handleWorkqueue)))
+	mux.Handle("/debug/workspaces/", s.requireEnabled(http.HandlerFunc(s.handleWorkspaceTrace)))
+	mux.Handle(pprof.Path, s.requireEnabled(pprof.Handler()))
+	return s.authenticate(mux)
+}
+
+// authenticate only lets requests through which carry the bearer token from the token file. The file is read
+// for every request, such that the token can be rotated without a restart.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := os.ReadFile(s.TokenFile)
+		if err != nil {
+			log.FromContext(r.Context()).Error(err, "cannot read diagnostics token", "file", s.TokenFile)
+			http.Error(w, "diagnostics are unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		expected := strings.TrimSpace(string(token))
+		actual, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if expected == "" || !ok || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) requireEnabled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.Enabled() {
+			http.Error(w, "diagnostics are disabled, enable them using POST /debug/enable", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleEnable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	d := DefaultEnableDuration
+	if v := r.URL.Query().Get("for"); v != "" {
+		var err error
+		d, err = time.ParseDuration(v)
+		if err != nil || d <= 0 || d > MaxEnableDuration {
+			http.Error(w, fmt.Sprintf("duration must be positive and at most %s", MaxEnableDuration), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.Enable(d)
+	log.FromContext(r.Context()).Info("diagnostics enabled", "duration", d.String())
+	s.handleStatus(w, r)
+}
+
+func (s *Server) handleDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.Enable(0)
+	s.Traces.Reset()
+	log.FromContext(r.Context()).Info("diagnostics disabled")
+	s.handleStatus(w, r)
+}
+
+type status struct {
+	Enabled      bool       `json:"enabled"`
+	EnabledUntil *time.Time `json:"enabledUntil,omitempty"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	res := status{Enabled: s.Enabled()}
+	if res.Enabled {
+		until := time.Unix(0, s.enabledUntil.Load())
+		res.EnabledUntil = &until
+	}
+	writeJSON(w, res)
+}
+
+// handleWorkqueue dumps the state of the controllers' work queues and workers, as reported by controller-runtime.
+func (s *Server) handleWorkqueue(w http.ResponseWriter, r *http.Request) {
+	families, err := s.Gatherer.Gather()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot gather metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	res := make(map[string]map[string]float64)
+	for _, mf := range families {
+		name := mf.GetName()
+		if !strings.HasPrefix(name, "workqueue_") && !strings.HasPrefix(name, "controller_runtime_") {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			var queue string
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" || l.GetName() == "controller" {
+					queue = l.GetValue()
+				}
+			}
+			if queue == "" {
+				continue
+			}
+
+			var value float64
+			switch {
+			case m.GetGauge() != nil:
+				value = m.GetGauge().GetValue()
+			case m.GetCounter() != nil:
+				value = m.GetCounter().GetValue()
+			case m.GetHistogram() != nil:
+				// the number of observations, e.g. the number of reconciles
+				value = float64(m.GetHistogram().GetSampleCount())
+			default:
+				continue
+			}
+
+			if res[queue] == nil {
+				res[queue] = make(map[string]float64)
+			}
+			// counters like reconcile_total carry more labels, e.g. the result
+			res[queue][name] += value
+		}
+	}
+	writeJSON(w, res)
+}
+
+// handleWorkspaceTrace returns the recorded reconciles of a workspace, served at /debug/workspaces/<name>.
+func (s *Server) handleWorkspaceTrace(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/debug/workspaces/"), "/")
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, s.Traces.Get(name))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diagnostics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestServer(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer("", tokenFile, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := srv.Handler()
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	steps := []struct {
+		Name   string
+		Method string
+		Path   string
+		Token  string
+		Code   int
+	}{
+		{Name: "no token", Method: http.MethodGet, Path: "/debug/status", Code: http.StatusUnauthorized},
+		{Name: "wrong token", Method: http.MethodGet, Path: "/debug/status", Token: "guess", Code: http.StatusUnauthorized},
+		{Name: "status", Method: http.MethodGet, Path: "/debug/status", Token: "secret", Code: http.StatusOK},
+		{Name: "disabled", Method: http.MethodGet, Path: "/debug/workqueue", Token: "secret", Code: http.StatusForbidden},
+		{Name: "enable too long", Method: http.MethodPost, Path: "/debug/enable?for=48h", Token: "secret", Code: http.StatusBadRequest},
+		{Name: "enable via GET", Method: http.MethodGet, Path: "/debug/enable", Token: "secret", Code: http.StatusMethodNotAllowed},
+		{Name: "enable", Method: http.MethodPost, Path: "/debug/enable?for=10m", Token: "secret", Code: http.StatusOK},
+		{Name: "enabled", Method: http.MethodGet, Path: "/debug/workqueue", Token: "secret", Code: http.StatusOK},
+		{Name: "enabled but wrong token", Method: http.MethodGet, Path: "/debug/workqueue", Token: "guess", Code: http.StatusUnauthorized},
+		{Name: "workspace trace", Method: http.MethodGet, Path: "/debug/workspaces/foobar", Token: "secret", Code: http.StatusOK},
+		{Name: "disable", Method: http.MethodPost, Path: "/debug/disable", Token: "secret", Code: http.StatusOK},
+		{Name: "disabled again", Method: http.MethodGet, Path: "/debug/workspaces/foobar", Token: "secret", Code: http.StatusForbidden},
+	}
+	for _, step := range steps {
+		rec := do(step.Method, step.Path, step.Token)
+		if rec.Code != step.Code {
+			t.Errorf("%s: expected status %d, got %d: %s", step.Name, step.Code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestTraces(t *testing.T) {
+	var enabled bool
+	traces, err := newTraces(func() bool { return enabled })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traces.Begin("foobar").End(workspacev1.WorkspacePhasePending, ctrl.Result{}, nil)
+	if act := traces.Get("foobar"); len(act) != 0 {
+		t.Errorf("expected no traces while disabled, got %v", act)
+	}
+
+	enabled = true
+	for i := 0; i < maxTracesPerWorkspace+10; i++ {
+		traces.Begin("foobar").End(workspacev1.WorkspacePhaseRunning, ctrl.Result{Requeue: true}, errors.New("conflict"))
+	}
+	act := traces.Get("foobar")
+	if len(act) != maxTracesPerWorkspace {
+		t.Fatalf("expected %d traces, got %d", maxTracesPerWorkspace, len(act))
+	}
+	if act[0].Phase != workspacev1.WorkspacePhaseRunning || !act[0].Requeue || act[0].Error != "conflict" {
+		t.Errorf("unexpected trace: %+v", act[0])
+	}
+
+	// a nil tracer is valid and records nothing
+	var nilTraces *Traces
+	nilTraces.Begin("foobar").End(workspacev1.WorkspacePhaseRunning, ctrl.Result{}, nil)
+}
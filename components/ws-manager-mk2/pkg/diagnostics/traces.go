@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package diagnostics
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// maxTracedWorkspaces is the number of workspaces whose reconciles we keep
+	maxTracedWorkspaces = 2000
+	// maxTracesPerWorkspace is the number of reconciles we keep per workspace
+	maxTracesPerWorkspace = 50
+)
+
+// ReconcileTrace describes a single reconcile of a workspace.
+type ReconcileTrace struct {
+	Start        time.Time                  `json:"start"`
+	Duration     time.Duration              `json:"duration"`
+	Phase        workspacev1.WorkspacePhase `json:"phase,omitempty"`
+	Requeue      bool                       `json:"requeue,omitempty"`
+	RequeueAfter time.Duration              `json:"requeueAfter,omitempty"`
+	Error        string                     `json:"error,omitempty"`
+}
+
+// Traces keeps the most recent reconciles of workspaces while diagnostics are enabled.
+type Traces struct {
+	enabled func() bool
+
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+func newTraces(enabled func() bool) (*Traces, error) {
+	cache, err := lru.New(maxTracedWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	return &Traces{enabled: enabled, cache: cache}, nil
+}
+
+// Begin starts tracing a reconcile of the workspace. Returns nil if diagnostics are disabled.
+// Traces is nil-safe, such that reconcilers don't need to care whether diagnostics are served at all.
+func (t *Traces) Begin(workspace string) *Trace {
+	if t == nil || !t.enabled() {
+		return nil
+	}
+	return &Trace{traces: t, workspace: workspace, start: time.Now()}
+}
+
+// Get returns the recorded reconciles of the workspace, oldest first.
+func (t *Traces) Get(workspace string) []ReconcileTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	v, ok := t.cache.Get(workspace)
+	if !ok {
+		return []ReconcileTrace{}
+	}
+	return append([]ReconcileTrace(nil), v.([]ReconcileTrace)...)
+}
+
+// Reset forgets all recorded reconciles.
+func (t *Traces) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cache.Purge()
+}
+
+func (t *Traces) record(workspace string, trace ReconcileTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var traces []ReconcileTrace
+	if v, ok := t.cache.Get(workspace); ok {
+		traces = v.([]ReconcileTrace)
+	}
+	traces = append(traces, trace)
+	if len(traces) > maxTracesPerWorkspace {
+		traces = traces[len(traces)-maxTracesPerWorkspace:]
+	}
+	t.cache.Add(workspace, traces)
+}
+
+// Trace is a reconcile in progress.
+type Trace struct {
+	traces    *Traces
+	workspace string
+	start     time.Time
+}
+
+// End records the outcome of the reconcile. Calling End on a nil Trace does nothing.
+func (t *Trace) End(phase workspacev1.WorkspacePhase, result ctrl.Result, err error) {
+	if t == nil {
+		return
+	}
+
+	trace := ReconcileTrace{
+		Start:        t.start,
+		Duration:     time.Since(t.start),
+		Phase:        phase,
+		Requeue:      result.Requeue,
+		RequeueAfter: result.RequeueAfter,
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	t.traces.record(t.workspace, trace)
+}
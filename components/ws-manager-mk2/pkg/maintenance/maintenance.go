@@ -11,4 +11,9 @@ import "context"
 // the cluster can be updated in-place.
 type Maintenance interface {
 	IsEnabled(ctx context.Context) bool
+	// IsDraining returns true if running workspaces should be stopped
+	// because the drain deadline of an active maintenance window has passed.
+	IsDraining(ctx context.Context) bool
+	// Message returns the user-facing maintenance message, if one is configured.
+	Message(ctx context.Context) string
 }
@@ -45,10 +45,24 @@ func (p ImageBuilder) Logs(req *api.LogsRequest, srv api.ImageBuilder_LogsServer
 	return forwardStream(srv.Context(), c.Recv, srv.Send)
 }
 
+func (p ImageBuilder) BuildLogs(req *api.BuildLogsRequest, srv api.ImageBuilder_BuildLogsServer) error {
+	c, err := p.D.BuildLogs(srv.Context(), req)
+	if err != nil {
+		return err
+	}
+	defer c.CloseSend()
+
+	return forwardStream(srv.Context(), c.Recv, srv.Send)
+}
+
 func (p ImageBuilder) ListBuilds(ctx context.Context, req *api.ListBuildsRequest) (*api.ListBuildsResponse, error) {
 	return p.D.ListBuilds(ctx, req)
 }
 
+func (p ImageBuilder) CancelBuild(ctx context.Context, req *api.CancelBuildRequest) (*api.CancelBuildResponse, error) {
+	return p.D.CancelBuild(ctx, req)
+}
+
 type ProtoMessage interface {
 	proto.Message
 	comparable
@@ -20,6 +20,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
@@ -43,6 +44,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/util"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/activity"
+	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/audit"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/constants"
 	"github.com/gitpod-io/gitpod/ws-manager-mk2/pkg/maintenance"
 	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
@@ -72,6 +74,11 @@ func NewWorkspaceManagerServer(clnt client.Client, cfg *config.Configuration, re
 	metrics := newWorkspaceMetrics(cfg.Namespace, clnt)
 	reg.MustRegister(metrics)
 
+	bufferSize := cfg.SubscriberBufferSize
+	if bufferSize == 0 {
+		bufferSize = defaultSubscriberBufferSize
+	}
+
 	return &WorkspaceManagerServer{
 		Client:      clnt,
 		Config:      cfg,
@@ -79,6 +86,7 @@ func NewWorkspaceManagerServer(clnt client.Client, cfg *config.Configuration, re
 		maintenance: maintenance,
 		subs: subscriptions{
 			subscribers: make(map[string]chan *wsmanapi.SubscribeResponse),
+			bufferSize:  bufferSize,
 		},
 	}
 }
@@ -89,6 +97,9 @@ type WorkspaceManagerServer struct {
 	metrics     *workspaceMetrics
 	maintenance maintenance.Maintenance
 
+	// Audit records the decisions affecting workspaces. May be nil.
+	Audit *audit.Log
+
 	subs subscriptions
 	wsmanapi.UnimplementedWorkspaceManagerServer
 }
@@ -109,7 +120,11 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 	defer tracing.FinishSpan(span, &err)
 
 	if wsm.maintenance.IsEnabled(ctx) {
-		return &wsmanapi.StartWorkspaceResponse{}, status.Error(codes.FailedPrecondition, "under maintenance")
+		msg := "under maintenance"
+		if m := wsm.maintenance.Message(ctx); m != "" {
+			msg = m
+		}
+		return &wsmanapi.StartWorkspaceResponse{}, status.Error(codes.FailedPrecondition, msg)
 	}
 
 	if err := validateStartWorkspaceRequest(req); err != nil {
@@ -190,6 +205,9 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 	if !ok {
 		return nil, status.Errorf(codes.InvalidArgument, "workspace class \"%s\" is unknown", req.Spec.Class)
 	}
+	if class.Deprecated() {
+		return nil, status.Error(codes.FailedPrecondition, class.DeprecationMessage(classID))
+	}
 
 	storage, err := class.Container.Limits.StorageQuantity()
 	if err != nil {
@@ -211,6 +229,65 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 		if limits.CPU.BurstLimit != "" {
 			annotations[wsk8s.WorkspaceCpuBurstLimitAnnotation] = limits.CPU.BurstLimit
 		}
+
+		if limits.CPU.BurstBudget != "" {
+			annotations[wsk8s.WorkspaceCpuBurstBudgetAnnotation] = limits.CPU.BurstBudget
+		}
+
+		annotations[wsk8s.WorkspaceCpuBurstClassAnnotation] = classID
+	}
+	if limits != nil && limits.IO != nil {
+		if limits.IO.ReadBandwidthPerSecond != "" {
+			annotations[wsk8s.WorkspaceIOReadBandwidthLimitAnnotation] = limits.IO.ReadBandwidthPerSecond
+		}
+		if limits.IO.WriteBandwidthPerSecond != "" {
+			annotations[wsk8s.WorkspaceIOWriteBandwidthLimitAnnotation] = limits.IO.WriteBandwidthPerSecond
+		}
+		if limits.IO.ReadIOPS > 0 {
+			annotations[wsk8s.WorkspaceIOReadIOPSLimitAnnotation] = strconv.FormatInt(limits.IO.ReadIOPS, 10)
+		}
+		if limits.IO.WriteIOPS > 0 {
+			annotations[wsk8s.WorkspaceIOWriteIOPSLimitAnnotation] = strconv.FormatInt(limits.IO.WriteIOPS, 10)
+		}
+	}
+	if limits != nil && limits.Network != nil && limits.Network.EgressBandwidthPerSecond != "" {
+		annotations[wsk8s.WorkspaceEgressBandwidthLimitAnnotation] = limits.Network.EgressBandwidthPerSecond
+	}
+	if limits != nil && limits.MemoryPressure != nil {
+		if limits.MemoryPressure.High != "" {
+			annotations[wsk8s.WorkspaceMemoryHighAnnotation] = limits.MemoryPressure.High
+		}
+		if limits.MemoryPressure.SwapMax != "" {
+			annotations[wsk8s.WorkspaceSwapMaxAnnotation] = limits.MemoryPressure.SwapMax
+		}
+	}
+
+	// Debug workspaces are requested by the gp CLI rebuild flow through an annotation, as the start request
+	// has no field for them.
+	debug := workspaceType == workspacev1.WorkspaceTypeRegular && annotations[wsk8s.DebugWorkspaceAnnotation] == util.BooleanTrueString
+	if !debug {
+		delete(annotations, wsk8s.DebugWorkspaceAnnotation)
+	}
+
+	// Snapshots are restored into new workspaces through an annotation, too. The content initializer of the
+	// request is replaced by one restoring the snapshot.
+	restoreSnapshot := annotations[wsk8s.RestoreSnapshotAnnotation]
+	delete(annotations, wsk8s.RestoreSnapshotAnnotation)
+	ownership := workspacev1.Ownership{
+		Owner:       req.Metadata.Owner,
+		WorkspaceID: req.Metadata.MetaId,
+		Team:        req.Metadata.GetTeam(),
+	}
+	if restoreSnapshot != "" {
+		snapshot, err := wsm.restorableSnapshot(ctx, restoreSnapshot, ownership)
+		if err != nil {
+			return nil, err
+		}
+		req.Spec.Initializer = &csapi.WorkspaceInitializer{
+			Spec: &csapi.WorkspaceInitializer_Snapshot{
+				Snapshot: &csapi.SnapshotInitializer{Snapshot: snapshot.Status.URL},
+			},
+		}
 	}
 
 	var sshGatewayCAPublicKey string
@@ -251,12 +328,9 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 			},
 		},
 		Spec: workspacev1.WorkspaceSpec{
-			Ownership: workspacev1.Ownership{
-				Owner:       req.Metadata.Owner,
-				WorkspaceID: req.Metadata.MetaId,
-			},
-			Type:  workspaceType,
-			Class: classID,
+			Ownership: ownership,
+			Type:      workspaceType,
+			Class:     classID,
 			Image: workspacev1.WorkspaceImages{
 				Workspace: workspacev1.WorkspaceImage{
 					Ref: pointer.String(req.Spec.WorkspaceImage),
@@ -284,6 +358,8 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 			SshPublicKeys:         req.Spec.SshPublicKeys,
 			StorageQuota:          int(storage.Value()),
 			SSHGatewayCAPublicKey: sshGatewayCAPublicKey,
+			Debug:                 debug,
+			RestoreSnapshot:       restoreSnapshot,
 		},
 	}
 	controllerutil.AddFinalizer(&ws, workspacev1.GitpodFinalizerName)
@@ -313,6 +389,12 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 		log.WithError(err).WithFields(owi).Error("error creating workspace")
 		return nil, status.Errorf(codes.FailedPrecondition, "cannot create workspace")
 	}
+	ev := audit.WorkspaceEvent(&ws, audit.ActionStartRequested, auditActor(ctx), "")
+	ev.Details = map[string]string{"type": string(ws.Spec.Type), "class": ws.Spec.Class}
+	if restoreSnapshot != "" {
+		ev.Details["restoreSnapshot"] = restoreSnapshot
+	}
+	wsm.Audit.Record(ev)
 
 	var wsr workspacev1.Workspace
 	err = wait.PollWithContext(ctx, 100*time.Millisecond, 30*time.Second, func(c context.Context) (done bool, err error) {
@@ -337,6 +419,27 @@ func (wsm *WorkspaceManagerServer) StartWorkspace(ctx context.Context, req *wsma
 	}, nil
 }
 
+// restorableSnapshot returns the snapshot a new workspace is restored from, if it is available and belongs to the
+// owner or organization of the workspace.
+func (wsm *WorkspaceManagerServer) restorableSnapshot(ctx context.Context, name string, ownership workspacev1.Ownership) (*workspacev1.Snapshot, error) {
+	var snapshot workspacev1.Snapshot
+	err := wsm.Client.Get(ctx, types.NamespacedName{Namespace: wsm.Config.Namespace, Name: name}, &snapshot)
+	if errors.IsNotFound(err) {
+		return nil, status.Errorf(codes.NotFound, "snapshot %s does not exist", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get snapshot %s: %w", name, err)
+	}
+	if !snapshot.IsRestorableBy(ownership) {
+		// we don't tell snapshots of others apart from snapshots which don't exist
+		return nil, status.Errorf(codes.NotFound, "snapshot %s does not exist", name)
+	}
+	if !snapshot.IsAvailable() {
+		return nil, status.Errorf(codes.FailedPrecondition, "snapshot %s is not available", name)
+	}
+	return &snapshot, nil
+}
+
 func (wsm *WorkspaceManagerServer) workspaceExists(ctx context.Context, id string) (bool, error) {
 	var workspaces workspacev1.WorkspaceList
 	err := wsm.Client.List(ctx, &workspaces, client.MatchingLabels{wsk8s.WorkspaceIDLabel: id})
@@ -384,6 +487,11 @@ func (wsm *WorkspaceManagerServer) createWorkspaceSecret(ctx context.Context, ow
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
+			// the labels let the orphan sweeper find secrets whose workspace is gone
+			Labels: map[string]string{
+				wsk8s.WorkspaceIDLabel:        owner.GetName(),
+				wsk8s.WorkspaceManagedByLabel: constants.ManagedBy,
+			},
 		},
 		StringData: data,
 	}
@@ -421,25 +529,42 @@ func (wsm *WorkspaceManagerServer) StopWorkspace(ctx context.Context, req *wsman
 			log.WithError(err).WithFields(owi).Error("failed to add Aborted condition to workspace")
 		}
 	}
+	var ev audit.Event
 	err = wsm.modifyWorkspace(ctx, req.Id, true, func(ws *workspacev1.Workspace) error {
 		ws.Status.SetCondition(workspacev1.NewWorkspaceConditionStoppedByRequest(gracePeriod.String()))
+		ev = audit.WorkspaceEvent(ws, audit.ActionStopRequested, auditActor(ctx), "")
 		return nil
 	})
 	// Ignore NotFound errors, workspace has already been stopped.
 	if err != nil && status.Code(err) != codes.NotFound {
 		return nil, err
 	}
+	if err == nil {
+		ev.Details = map[string]string{"policy": req.Policy.String(), "gracePeriod": gracePeriod.String()}
+		wsm.Audit.Record(ev)
+	}
 	return &wsmanapi.StopWorkspaceResponse{}, nil
 }
 
 func (wsm *WorkspaceManagerServer) GetWorkspaces(ctx context.Context, req *wsmanapi.GetWorkspacesRequest) (*wsmanapi.GetWorkspacesResponse, error) {
-	labelSelector, err := metadataFilterToLabelSelector(req.MustMatch)
+	res, err := wsm.listWorkspaceStatus(ctx, req.MustMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsmanapi.GetWorkspacesResponse{Status: res}, nil
+}
+
+// listWorkspaceStatus returns the status of all workspaces matching the filter
+func (wsm *WorkspaceManagerServer) listWorkspaceStatus(ctx context.Context, filter *wsmanapi.MetadataFilter) ([]*wsmanapi.WorkspaceStatus, error) {
+	labelSelector, err := metadataFilterToLabelSelector(filter)
 	if err != nil {
 		return nil, status.Errorf(codes.FailedPrecondition, "cannot convert metadata filter: %v", err)
 	}
 
 	var workspaces workspacev1.WorkspaceList
 	err = wsm.Client.List(ctx, &workspaces, &client.ListOptions{
+		Namespace:     wsm.Config.Namespace,
 		LabelSelector: labelSelector,
 	})
 	if err != nil {
@@ -448,14 +573,13 @@ func (wsm *WorkspaceManagerServer) GetWorkspaces(ctx context.Context, req *wsman
 
 	res := make([]*wsmanapi.WorkspaceStatus, 0, len(workspaces.Items))
 	for _, ws := range workspaces.Items {
-		if !matchesMetadataAnnotations(&ws, req.MustMatch) {
+		if !matchesMetadataAnnotations(&ws, filter) {
 			continue
 		}
 
 		res = append(res, wsm.extractWorkspaceStatus(&ws))
 	}
-
-	return &wsmanapi.GetWorkspacesResponse{Status: res}, nil
+	return res, nil
 }
 
 func (wsm *WorkspaceManagerServer) DescribeWorkspace(ctx context.Context, req *wsmanapi.DescribeWorkspaceRequest) (*wsmanapi.DescribeWorkspaceResponse, error) {
@@ -479,14 +603,17 @@ func (wsm *WorkspaceManagerServer) DescribeWorkspace(ctx context.Context, req *w
 	return result, nil
 }
 
-// Subscribe streams all status updates to a client
+// Subscribe streams all status updates to a client. It starts with the current status of all
+// matching workspaces, such that clients which reconnect don't miss updates.
 func (m *WorkspaceManagerServer) Subscribe(req *wsmanapi.SubscribeRequest, srv wsmanapi.WorkspaceManager_SubscribeServer) (err error) {
 	var sub subscriber = srv
 	if req.MustMatch != nil {
 		sub = &filteringSubscriber{srv, req.MustMatch}
 	}
 
-	return m.subs.Subscribe(srv.Context(), sub)
+	return m.subs.Subscribe(srv.Context(), sub, func(ctx context.Context) ([]*wsmanapi.WorkspaceStatus, error) {
+		return m.listWorkspaceStatus(ctx, req.MustMatch)
+	})
 }
 
 // MarkActive records a workspace as being active which prevents it from timing out
@@ -522,6 +649,10 @@ func (wsm *WorkspaceManagerServer) MarkActive(ctx context.Context, req *wsmanapi
 
 	err = wsm.modifyWorkspace(ctx, req.Id, true, func(ws *workspacev1.Workspace) error {
 		ws.Status.LastActivity = &lastActivityStatus
+		if ws.IsConditionTrue(workspacev1.WorkspaceConditionStoppingSoon) {
+			// The user is back, withdraw the warning right away instead of waiting for the timeout controller.
+			ws.Status.SetCondition(workspacev1.NewWorkspaceConditionStoppingSoon(metav1.ConditionFalse, "MarkActiveRequest", ""))
+		}
 		return nil
 	})
 	if err != nil {
@@ -565,31 +696,86 @@ func (wsm *WorkspaceManagerServer) MarkActive(ctx context.Context, req *wsmanapi
 	return &wsmanapi.MarkActiveResponse{}, nil
 }
 
-func (wsm *WorkspaceManagerServer) SetTimeout(ctx context.Context, req *wsmanapi.SetTimeoutRequest) (*wsmanapi.SetTimeoutResponse, error) {
+func (wsm *WorkspaceManagerServer) SetTimeout(ctx context.Context, req *wsmanapi.SetTimeoutRequest) (res *wsmanapi.SetTimeoutResponse, err error) {
+	span, ctx := tracing.FromContext(ctx, "SetTimeout")
+	tracing.ApplyOWI(span, log.OWI("", "", req.Id))
+	defer tracing.FinishSpan(span, &err)
+
 	duration, err := time.ParseDuration(req.Duration)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid duration: %v", err)
 	}
+	if duration <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "duration must be positive")
+	}
+	if req.Type != wsmanapi.TimeoutType_WORKSPACE_TIMEOUT && req.Type != wsmanapi.TimeoutType_CLOSED_TIMEOUT {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported timeout type %v", req.Type)
+	}
+
+	// The timeout is validated against the workspace as it is being modified, such that a concurrent change
+	// of the maximum lifetime cannot be bypassed.
+	var ev audit.Event
+	err = wsm.modifyWorkspace(ctx, req.Id, false, func(ws *workspacev1.Workspace) error {
+		if err := validateTimeout(ws, wsm.Config, duration); err != nil {
+			return err
+		}
 
-	if req.Type == wsmanapi.TimeoutType_WORKSPACE_TIMEOUT {
-		err = wsm.modifyWorkspace(ctx, req.Id, false, func(ws *workspacev1.Workspace) error {
+		switch req.Type {
+		case wsmanapi.TimeoutType_WORKSPACE_TIMEOUT:
 			ws.Spec.Timeout.Time = &metav1.Duration{Duration: duration}
 			ws.Spec.Timeout.ClosedTimeout = &metav1.Duration{Duration: time.Duration(0)}
-			return nil
-		})
-	} else if req.Type == wsmanapi.TimeoutType_CLOSED_TIMEOUT {
-		err = wsm.modifyWorkspace(ctx, req.Id, false, func(ws *workspacev1.Workspace) error {
+		case wsmanapi.TimeoutType_CLOSED_TIMEOUT:
 			ws.Spec.Timeout.ClosedTimeout = &metav1.Duration{Duration: duration}
-			return nil
-		})
-	}
+		}
+		ev = audit.WorkspaceEvent(ws, audit.ActionTimeoutChanged, auditActor(ctx), "")
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	ev.Details = map[string]string{"type": req.Type.String(), "duration": duration.String()}
+	wsm.Audit.Record(ev)
 
 	return &wsmanapi.SetTimeoutResponse{}, nil
 }
 
+// auditActor identifies the client which called the API for the audit log, by the common name of its
+// client certificate if it has one, otherwise by its address.
+func auditActor(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		for _, chain := range tlsInfo.State.VerifiedChains {
+			if len(chain) > 0 && chain[0].Subject.CommonName != "" {
+				return chain[0].Subject.CommonName
+			}
+		}
+	}
+	if p.Addr == nil {
+		return "unknown"
+	}
+	return p.Addr.String()
+}
+
+// validateTimeout ensures a workspace timeout can be set to the given duration. The timeout must not exceed the
+// maximum lifetime of the workspace, which is either set on the workspace (e.g. by the organization) or by its class.
+func validateTimeout(ws *workspacev1.Workspace, cfg *config.Configuration, duration time.Duration) error {
+	if ws.Status.Phase == workspacev1.WorkspacePhaseStopping || ws.Status.Phase == workspacev1.WorkspacePhaseStopped || !ws.DeletionTimestamp.IsZero() {
+		return status.Errorf(codes.FailedPrecondition, "workspace %s is stopping", ws.Name)
+	}
+
+	maxLifetime := time.Duration(cfg.ClassTimeouts(ws.Spec.Class).MaxLifetime)
+	if ws.Spec.Timeout.MaximumLifetime != nil {
+		maxLifetime = ws.Spec.Timeout.MaximumLifetime.Duration
+	}
+	if maxLifetime > 0 && duration > maxLifetime {
+		return status.Errorf(codes.FailedPrecondition, "timeout %s exceeds the maximum lifetime of %s", duration, maxLifetime)
+	}
+	return nil
+}
+
 func (wsm *WorkspaceManagerServer) ControlPort(ctx context.Context, req *wsmanapi.ControlPortRequest) (res *wsmanapi.ControlPortResponse, err error) {
 	span, ctx := tracing.FromContext(ctx, "ControlPort")
 	tracing.ApplyOWI(span, log.OWI("", "", req.Id))
@@ -664,6 +850,12 @@ func (wsm *WorkspaceManagerServer) TakeSnapshot(ctx context.Context, req *wsmana
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%d", req.Id, time.Now().UnixNano()),
 			Namespace: wsm.Config.Namespace,
+			// The ownership groups the snapshots of a workspace once its instances are gone, e.g. to garbage collect them.
+			Labels: map[string]string{
+				wsk8s.OwnerLabel:  ws.Spec.Ownership.Owner,
+				wsk8s.MetaIDLabel: ws.Spec.Ownership.WorkspaceID,
+				wsk8s.TeamLabel:   ws.Spec.Ownership.Team,
+			},
 		},
 		Spec: workspacev1.SnapshotSpec{
 			NodeName:    ws.Status.Runtime.NodeName,
@@ -705,7 +897,7 @@ func (wsm *WorkspaceManagerServer) TakeSnapshot(ctx context.Context, req *wsmana
 
 	if !req.ReturnImmediately {
 		err = wait.PollWithContext(ctx, 100*time.Millisecond, 0, func(c context.Context) (done bool, err error) {
-			err = wsm.Client.Get(ctx, types.NamespacedName{Namespace: wsm.Config.Namespace, Name: ws.Name}, &sso)
+			err = wsm.Client.Get(ctx, types.NamespacedName{Namespace: wsm.Config.Namespace, Name: snapshot.Name}, &sso)
 			if err != nil {
 				return false, nil
 			}
@@ -721,6 +913,9 @@ func (wsm *WorkspaceManagerServer) TakeSnapshot(ctx context.Context, req *wsmana
 			return nil, status.Errorf(codes.Internal, "cannot wait for snapshot: %q", err)
 		}
 
+		if sso.Status.ErrorDetail != nil {
+			return nil, status.Errorf(codes.Internal, "cannot take snapshot (%s): %q", sso.Status.ErrorDetail.Reason, sso.Status.ErrorDetail.Message)
+		}
 		if sso.Status.Error != "" {
 			return nil, status.Errorf(codes.Internal, "cannot take snapshot: %q", sso.Status.Error)
 		}
@@ -773,6 +968,11 @@ func (wsm *WorkspaceManagerServer) DescribeCluster(ctx context.Context, req *wsm
 
 	classes := make([]*wsmanapi.WorkspaceClass, 0, len(wsm.Config.WorkspaceClasses))
 	for id, class := range wsm.Config.WorkspaceClasses {
+		if class.Deprecated() {
+			// deprecated classes cannot be used to start workspaces, hence we don't offer them
+			continue
+		}
+
 		var cpu, ram, disk resource.Quantity
 		desc := class.Description
 		if desc == "" {
@@ -1021,14 +1221,24 @@ func (wsm *WorkspaceManagerServer) extractWorkspaceStatus(ws *workspacev1.Worksp
 		tpe = wsmanapi.WorkspaceType_REGULAR
 	}
 
-	timeout := wsm.Config.Timeouts.RegularWorkspace.String()
-	if ws.Spec.Timeout.Time != nil {
-		timeout = ws.Spec.Timeout.Time.Duration.String()
-	}
-
-	closedTimeout := wsm.Config.Timeouts.AfterClose.String()
-	if ws.Spec.Timeout.ClosedTimeout != nil {
-		closedTimeout = ws.Spec.Timeout.ClosedTimeout.Duration.String()
+	timeouts := wsm.Config.ClassTimeouts(ws.Spec.Class)
+	timeout := timeouts.RegularWorkspace.String()
+	closedTimeout := timeouts.AfterClose.String()
+	if ws.Status.Timeouts != nil {
+		// the controller has already resolved the timeouts in effect
+		if ws.Status.Timeouts.Time != nil {
+			timeout = ws.Status.Timeouts.Time.Duration.String()
+		}
+		if ws.Status.Timeouts.ClosedTimeout != nil {
+			closedTimeout = ws.Status.Timeouts.ClosedTimeout.Duration.String()
+		}
+	} else {
+		if ws.Spec.Timeout.Time != nil {
+			timeout = ws.Spec.Timeout.Time.Duration.String()
+		}
+		if ws.Spec.Timeout.ClosedTimeout != nil {
+			closedTimeout = ws.Spec.Timeout.ClosedTimeout.Duration.String()
+		}
 	}
 
 	var phase wsmanapi.WorkspacePhase
@@ -1076,6 +1286,11 @@ func (wsm *WorkspaceManagerServer) extractWorkspaceStatus(ws *workspacev1.Worksp
 		admissionLevel = wsmanapi.AdmissionLevel_ADMIT_OWNER_ONLY
 	}
 
+	portURLs := make(map[uint32]string, len(ws.Status.Ports))
+	for _, p := range ws.Status.Ports {
+		portURLs[p.Port] = p.URL
+	}
+
 	ports := make([]*wsmanapi.PortSpec, 0, len(ws.Spec.Ports))
 	for _, p := range ws.Spec.Ports {
 		v := wsmanapi.PortVisibility_PORT_VISIBILITY_PRIVATE
@@ -1086,16 +1301,21 @@ func (wsm *WorkspaceManagerServer) extractWorkspaceStatus(ws *workspacev1.Worksp
 		if p.Protocol == workspacev1.PortProtocolHttps {
 			protocol = wsmanapi.PortProtocol_PORT_PROTOCOL_HTTPS
 		}
-		url, err := config.RenderWorkspacePortURL(wsm.Config.WorkspacePortURLTemplate, config.PortURLContext{
-			Host:          wsm.Config.GitpodHostURL,
-			ID:            ws.Name,
-			IngressPort:   fmt.Sprint(p.Port),
-			Prefix:        ws.Spec.Ownership.WorkspaceID,
-			WorkspacePort: fmt.Sprint(p.Port),
-		})
-		if err != nil {
-			log.WithError(err).WithField("port", p.Port).Error("cannot render public URL for port, excluding the port from the workspace status")
-			continue
+		url, ok := portURLs[p.Port]
+		if !ok {
+			// The controller has not caught up with the port yet.
+			var err error
+			url, err = config.RenderWorkspacePortURL(wsm.Config.WorkspacePortURLTemplate, config.PortURLContext{
+				Host:          wsm.Config.GitpodHostURL,
+				ID:            ws.Name,
+				IngressPort:   fmt.Sprint(p.Port),
+				Prefix:        ws.Spec.Ownership.WorkspaceID,
+				WorkspacePort: fmt.Sprint(p.Port),
+			})
+			if err != nil {
+				log.WithError(err).WithField("port", p.Port).Error("cannot render public URL for port, excluding the port from the workspace status")
+				continue
+			}
 		}
 		ports = append(ports, &wsmanapi.PortSpec{
 			Port:       p.Port,
@@ -1129,7 +1349,8 @@ func (wsm *WorkspaceManagerServer) extractWorkspaceStatus(ws *workspacev1.Worksp
 			Timeout:        timeout,
 			ClosedTimeout:  closedTimeout,
 		},
-		Phase: phase,
+		Phase:   phase,
+		Message: phaseMessage(ws),
 		Conditions: &wsmanapi.WorkspaceConditions{
 			Failed:              getConditionMessageIfTrue(ws.Status.Conditions, string(workspacev1.WorkspaceConditionFailed)),
 			Timeout:             getConditionMessageIfTrue(ws.Status.Conditions, string(workspacev1.WorkspaceConditionTimeout)),
@@ -1140,6 +1361,7 @@ func (wsm *WorkspaceManagerServer) extractWorkspaceStatus(ws *workspacev1.Worksp
 			StoppedByRequest:    convertCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionStoppedByRequest)),
 			FinalBackupComplete: convertCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionBackupComplete)),
 			Aborted:             convertCondition(ws.Status.Conditions, string(workspacev1.WorkspaceConditionAborted)),
+			VolumeSnapshot:      convertVolumeSnapshot(ws),
 		},
 		Runtime: runtime,
 		Auth: &wsmanapi.WorkspaceAuthentication{
@@ -1152,6 +1374,32 @@ func (wsm *WorkspaceManagerServer) extractWorkspaceStatus(ws *workspacev1.Worksp
 	return res
 }
 
+// convertVolumeSnapshot returns the volume snapshot of a PVC workspace once it is ready to be restored from.
+func convertVolumeSnapshot(ws *workspacev1.Workspace) *wsmanapi.VolumeSnapshotInfo {
+	if !ws.UsesPVC() || ws.Status.PVC.VolumeSnapshot == "" || !ws.IsConditionTrue(workspacev1.WorkspaceConditionBackupComplete) {
+		return nil
+	}
+	return &wsmanapi.VolumeSnapshotInfo{
+		VolumeSnapshotName: ws.Status.PVC.VolumeSnapshot,
+	}
+}
+
+// phaseMessage explains the phase of a workspace to the user, e.g. what a starting workspace waits for,
+// that a running workspace is about to be stopped, or that it ran out of resources
+func phaseMessage(ws *workspacev1.Workspace) string {
+	for _, c := range []workspacev1.WorkspaceCondition{
+		workspacev1.WorkspaceConditionResourceExhausted,
+		workspacev1.WorkspaceConditionUnschedulable,
+		workspacev1.WorkspaceConditionPending,
+		workspacev1.WorkspaceConditionStoppingSoon,
+	} {
+		if m := getConditionMessageIfTrue(ws.Status.Conditions, string(c)); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
 func getConditionMessageIfTrue(conds []metav1.Condition, tpe string) string {
 	for _, c := range conds {
 		if c.Type == tpe && c.Status == metav1.ConditionTrue {
@@ -1285,13 +1533,23 @@ type subscriber interface {
 	Send(*wsmanapi.SubscribeResponse) error
 }
 
+// defaultSubscriberBufferSize is the number of status updates buffered per subscriber if none is configured
+const defaultSubscriberBufferSize = 250
+
 type subscriptions struct {
 	mu          sync.RWMutex
 	subscribers map[string]chan *wsmanapi.SubscribeResponse
+	bufferSize  int
 }
 
-func (subs *subscriptions) Subscribe(ctx context.Context, recv subscriber) (err error) {
-	incoming := make(chan *wsmanapi.SubscribeResponse, 250)
+// Subscribe forwards status updates to recv until the context is canceled or recv falls too far behind.
+// If resync is not nil, the status it returns is sent before any update.
+func (subs *subscriptions) Subscribe(ctx context.Context, recv subscriber, resync func(ctx context.Context) ([]*wsmanapi.WorkspaceStatus, error)) (err error) {
+	bufferSize := subs.bufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultSubscriberBufferSize
+	}
+	incoming := make(chan *wsmanapi.SubscribeResponse, bufferSize)
 
 	var key string
 	peer, ok := peer.FromContext(ctx)
@@ -1315,6 +1573,23 @@ func (subs *subscriptions) Subscribe(ctx context.Context, recv subscriber) (err
 		subs.mu.Unlock()
 	}()
 
+	if resync != nil {
+		// We subscribed before listing the workspaces, hence updates which happen in between are
+		// buffered and sent afterwards. Clients might see a status twice, but never miss one.
+		current, err := resync(ctx)
+		if err != nil {
+			log.WithField("subscriberKey", key).WithError(err).Error("cannot resync subscriber")
+			return err
+		}
+		for _, s := range current {
+			err = recv.Send(&wsmanapi.SubscribeResponse{Status: s})
+			if err != nil {
+				log.WithField("subscriberKey", key).WithError(err).Error("cannot send update - dropping subscriber")
+				return err
+			}
+		}
+	}
+
 	for {
 		var inc *wsmanapi.SubscribeResponse
 		select {
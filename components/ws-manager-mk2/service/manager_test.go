@@ -6,12 +6,17 @@ package service
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/ws-manager/api"
 	"github.com/gitpod-io/gitpod/ws-manager/api/config"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestDescribeCluster(t *testing.T) {
@@ -72,6 +77,22 @@ func TestDescribeCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "deprecated class",
+			Config: config.Configuration{
+				WorkspaceClasses: map[string]*config.WorkspaceClass{
+					"large": {},
+					"small": {Deprecation: &config.WorkspaceClassDeprecation{ReplacedBy: "large"}},
+				},
+			},
+			Expectation: Expectation{
+				Response: &api.DescribeClusterResponse{
+					WorkspaceClasses: []*api.WorkspaceClass{
+						{Id: "large", Description: "0 vCPU, 0GB memory, 0GB disk"},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -93,3 +114,219 @@ func TestDescribeCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTimeout(t *testing.T) {
+	classLifetime := util.Duration(36 * time.Hour)
+	cfg := &config.Configuration{
+		Timeouts: config.WorkspaceTimeoutConfiguration{MaxLifetime: util.Duration(8 * time.Hour)},
+		WorkspaceClasses: map[string]*config.WorkspaceClass{
+			"default": {},
+			"long":    {Timeouts: &config.WorkspaceClassTimeoutConfiguration{MaxLifetime: &classLifetime}},
+		},
+	}
+
+	tests := []struct {
+		Name        string
+		Class       string
+		Phase       workspacev1.WorkspacePhase
+		MaxLifetime *metav1.Duration
+		Duration    time.Duration
+		Expectation string
+	}{
+		{
+			Name:     "within default maximum",
+			Class:    "default",
+			Duration: 3 * time.Hour,
+		},
+		{
+			Name:        "exceeds default maximum",
+			Class:       "default",
+			Duration:    24 * time.Hour,
+			Expectation: "rpc error: code = FailedPrecondition desc = timeout 24h0m0s exceeds the maximum lifetime of 8h0m0s",
+		},
+		{
+			Name:     "within class maximum",
+			Class:    "long",
+			Duration: 24 * time.Hour,
+		},
+		{
+			Name:        "exceeds workspace maximum",
+			Class:       "long",
+			MaxLifetime: &metav1.Duration{Duration: 2 * time.Hour},
+			Duration:    3 * time.Hour,
+			Expectation: "rpc error: code = FailedPrecondition desc = timeout 3h0m0s exceeds the maximum lifetime of 2h0m0s",
+		},
+		{
+			Name:        "stopping workspace",
+			Class:       "default",
+			Phase:       workspacev1.WorkspacePhaseStopping,
+			Duration:    time.Hour,
+			Expectation: "rpc error: code = FailedPrecondition desc = workspace foobar is stopping",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &workspacev1.Workspace{}
+			ws.Name = "foobar"
+			ws.Spec.Class = test.Class
+			ws.Spec.Timeout.MaximumLifetime = test.MaxLifetime
+			ws.Status.Phase = test.Phase
+			if ws.Status.Phase == "" {
+				ws.Status.Phase = workspacev1.WorkspacePhaseRunning
+			}
+
+			var act string
+			if err := validateTimeout(ws, cfg, test.Duration); err != nil {
+				act = err.Error()
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("validateTimeout() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type recordingSubscriber struct {
+	mu       sync.Mutex
+	received []string
+}
+
+func (r *recordingSubscriber) Send(resp *api.SubscribeResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, resp.Status.Id)
+	return nil
+}
+
+func (r *recordingSubscriber) Received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.received...)
+}
+
+func TestSubscribe(t *testing.T) {
+	status := func(id, owner string) *api.WorkspaceStatus {
+		return &api.WorkspaceStatus{Id: id, Metadata: &api.WorkspaceMetadata{Owner: owner, MetaId: "meta-" + id}}
+	}
+
+	tests := []struct {
+		Name     string
+		Filter   *api.MetadataFilter
+		Resync   []*api.WorkspaceStatus
+		Updates  []*api.WorkspaceStatus
+		Expected []string
+	}{
+		{
+			Name:     "resync before updates",
+			Resync:   []*api.WorkspaceStatus{status("a", "foo"), status("b", "bar")},
+			Updates:  []*api.WorkspaceStatus{status("c", "foo")},
+			Expected: []string{"a", "b", "c"},
+		},
+		{
+			Name:     "filter by owner",
+			Filter:   &api.MetadataFilter{Owner: "foo"},
+			Updates:  []*api.WorkspaceStatus{status("a", "foo"), status("b", "bar")},
+			Expected: []string{"a"},
+		},
+		{
+			Name:     "filter by workspace ID",
+			Filter:   &api.MetadataFilter{MetaId: "meta-b"},
+			Updates:  []*api.WorkspaceStatus{status("a", "foo"), status("b", "bar")},
+			Expected: []string{"b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			subs := subscriptions{subscribers: make(map[string]chan *api.SubscribeResponse)}
+			rec := &recordingSubscriber{}
+			var sub subscriber = rec
+			if test.Filter != nil {
+				sub = &filteringSubscriber{rec, test.Filter}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resynced := make(chan struct{})
+			done := make(chan error, 1)
+			go func() {
+				done <- subs.Subscribe(ctx, sub, func(ctx context.Context) ([]*api.WorkspaceStatus, error) {
+					defer close(resynced)
+					return test.Resync, nil
+				})
+			}()
+			<-resynced
+
+			for _, u := range test.Updates {
+				subs.PublishToSubscribers(ctx, &api.SubscribeResponse{Status: u})
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(rec.Received()) < len(test.Expected) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			// give unexpected updates a chance to arrive
+			time.Sleep(50 * time.Millisecond)
+
+			if diff := cmp.Diff(test.Expected, rec.Received()); diff != "" {
+				t.Errorf("unexpected updates (-want +got):\n%s", diff)
+			}
+
+			cancel()
+			<-done
+		})
+	}
+}
+
+func TestSubscribeDropsSlowSubscribers(t *testing.T) {
+	subs := subscriptions{
+		subscribers: make(map[string]chan *api.SubscribeResponse),
+		bufferSize:  1,
+	}
+
+	// The subscriber blocks on the first update it receives, such that the buffer fills up.
+	blocked := make(chan struct{})
+	sub := subscriberFunc(func(resp *api.SubscribeResponse) error {
+		<-blocked
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- subs.Subscribe(ctx, sub, nil)
+	}()
+	for {
+		subs.mu.RLock()
+		n := len(subs.subscribers)
+		subs.mu.RUnlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		subs.PublishToSubscribers(ctx, &api.SubscribeResponse{Status: &api.WorkspaceStatus{}})
+	}
+	close(blocked)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected the subscription to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("slow subscriber was not dropped")
+	}
+}
+
+type subscriberFunc func(*api.SubscribeResponse) error
+
+func (f subscriberFunc) Send(resp *api.SubscribeResponse) error {
+	return f(resp)
+}
@@ -66,4 +66,7 @@ type WorkspaceInfo struct {
 
 	IsEnabledSSHCA bool
 	IsManagedByMk2 bool
+
+	// IsDebugWorkspace is true for debug workspaces, e.g. those started by the gp CLI rebuild flow
+	IsDebugWorkspace bool
 }
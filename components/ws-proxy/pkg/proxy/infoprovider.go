@@ -159,6 +159,8 @@ func (r *CRDWorkspaceInfoProvider) Reconcile(ctx context.Context, req ctrl.Reque
 		IsRunning:       ws.Status.Phase == workspacev1.WorkspacePhaseRunning,
 		IsEnabledSSHCA:  ws.Spec.SSHGatewayCAPublicKey != "",
 		IsManagedByMk2:  managedByMk2,
+
+		IsDebugWorkspace: ws.Annotations[wsk8s.DebugWorkspaceAnnotation] == "true",
 	}
 
 	r.store.Update(req.Name, wsinfo)
@@ -780,6 +780,9 @@ func sensitiveCookieHandler(domain string) func(h http.Handler) http.Handler {
 	}
 }
 
+// debugWorkspaceHeader is set on responses for debug workspaces
+const debugWorkspaceHeader = "X-Gitpod-Debug-Workspace"
+
 // workspaceMustExistHandler redirects if we don't know about a workspace yet.
 func workspaceMustExistHandler(config *Config, infoProvider common.WorkspaceInfoProvider) mux.MiddlewareFunc {
 	return func(h http.Handler) http.Handler {
@@ -791,6 +794,10 @@ func workspaceMustExistHandler(config *Config, infoProvider common.WorkspaceInfo
 				http.Redirect(resp, req, redirectURL, http.StatusFound)
 				return
 			}
+			if info.IsDebugWorkspace {
+				// mark the session, such that the IDE and its users can tell they're in a debug workspace
+				resp.Header().Set(debugWorkspaceHeader, "true")
+			}
 
 			h.ServeHTTP(resp, req.WithContext(context.WithValue(req.Context(), infoContextValueKey, info)))
 		})
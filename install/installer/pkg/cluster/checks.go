@@ -24,6 +24,10 @@ const (
 	// additional information, which get interpreted as pre-release (eg, 1.2.3-rc4)
 	kernelVersionConstraint     = ">= 5.4.0-0"
 	kubernetesVersionConstraint = ">= 1.21.0-0"
+
+	// cgroupV2KernelVersionConstraint is the kernel version from which on the major node distributions
+	// boot with the unified cgroup (v2) hierarchy by default
+	cgroupV2KernelVersionConstraint = ">= 5.8.0-0"
 )
 
 // checkCertManagerInstalled checks that cert-manager is installed as a cluster dependency
@@ -264,6 +268,45 @@ func checkKernelVersion(ctx context.Context, config *rest.Config, namespace stri
 	return res, nil
 }
 
+// checkCGroupV2 checks that workspace nodes likely run the unified cgroup (v2) hierarchy which ws-daemon requires.
+// Kubernetes does not report the cgroup version of a node, hence we infer it from the kernel version.
+func checkCGroupV2(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+	constraint, err := semver.NewConstraint(cgroupV2KernelVersionConstraint)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := ListNodesFromContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []ValidationError
+	for _, node := range nodes {
+		if _, ok := node.Labels[AffinityLabelWorkspacesRegular]; !ok {
+			if _, ok := node.Labels[AffinityLabelWorkspacesHeadless]; !ok {
+				continue
+			}
+		}
+
+		kernelVersion := strings.TrimSuffix(node.Status.NodeInfo.KernelVersion, "+")
+		version, err := semver.NewVersion(kernelVersion)
+		if err != nil {
+			// checkKernelVersion already reports unparseable kernel versions
+			continue
+		}
+
+		if !constraint.Check(version) {
+			res = append(res, ValidationError{
+				Message: fmt.Sprintf("workspace node %s runs kernel %s which likely boots with cgroup v1 - ws-daemon requires cgroup v2 (e.g. boot with systemd.unified_cgroup_hierarchy=1)", node.Name, kernelVersion),
+				Type:    ValidationStatusWarning,
+			})
+		}
+	}
+
+	return res, nil
+}
+
 func checkNamespaceExists(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
 	client, err := clientsetFromContext(ctx, config)
 	if err != nil {
@@ -56,6 +56,11 @@ var ClusterChecks = ValidationChecks{
 		Description: "all cluster nodes run Linux " + kernelVersionConstraint,
 		Check:       checkKernelVersion,
 	},
+	{
+		Name:        "cgroup v2",
+		Description: "all workspace nodes run the unified cgroup (v2) hierarchy",
+		Check:       checkCGroupV2,
+	},
 	{
 		Name:        "containerd enabled",
 		Check:       checkContainerDRuntime,
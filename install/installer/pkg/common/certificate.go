@@ -5,9 +5,17 @@
 package common
 
 import (
+	"strings"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// AnnotationReloadOnSecretChange is understood by https://github.com/stakater/Reloader and restarts
+// the annotated workload whenever one of the listed secrets changes.
+const AnnotationReloadOnSecretChange = "secret.reloader.stakater.com/reload"
+
 func CAVolume() corev1.Volume {
 	return corev1.Volume{
 		Name: "ca-certificates",
@@ -27,3 +35,37 @@ func CAVolumeMount() corev1.VolumeMount {
 		ReadOnly:  true,
 	}
 }
+
+// InternalCertRotation returns the duration and renewBefore of certificates issued by the internal CA.
+// If no rotation policy is configured, certificates live for InternalCertDuration and cert-manager
+// picks its default renewal time. The policy itself is validated as part of the config validation.
+func InternalCertRotation(ctx *RenderContext) (duration *metav1.Duration, renewBefore *metav1.Duration) {
+	duration = InternalCertDuration
+
+	cfg := ctx.Config.InternalCertificates
+	if cfg == nil {
+		return duration, nil
+	}
+
+	if cfg.Duration != nil {
+		duration = &metav1.Duration{Duration: time.Duration(*cfg.Duration)}
+	}
+	if cfg.RenewBefore != nil {
+		renewBefore = &metav1.Duration{Duration: time.Duration(*cfg.RenewBefore)}
+	}
+
+	return duration, renewBefore
+}
+
+// InternalCertRestartAnnotations returns the annotations which restart a workload once one of
+// the given internal certificate secrets was rotated. Returns nil if restarts are not enabled.
+func InternalCertRestartAnnotations(ctx *RenderContext, secrets ...string) map[string]string {
+	cfg := ctx.Config.InternalCertificates
+	if cfg == nil || !cfg.RestartOnRotation || len(secrets) == 0 {
+		return nil
+	}
+
+	return map[string]string{
+		AnnotationReloadOnSecretChange: strings.Join(secrets, ","),
+	}
+}
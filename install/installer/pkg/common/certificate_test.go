@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalCertRotation(t *testing.T) {
+	duration := func(d time.Duration) *util.Duration {
+		res := util.Duration(d)
+		return &res
+	}
+
+	tests := []struct {
+		Name                string
+		Config              *config.InternalCertificates
+		ExpectedDuration    time.Duration
+		ExpectedRenewBefore time.Duration
+	}{
+		{
+			Name:             "no rotation policy",
+			ExpectedDuration: common.InternalCertDuration.Duration,
+		},
+		{
+			Name: "custom duration and renewBefore",
+			Config: &config.InternalCertificates{
+				Duration:    duration(30 * 24 * time.Hour),
+				RenewBefore: duration(10 * 24 * time.Hour),
+			},
+			ExpectedDuration:    30 * 24 * time.Hour,
+			ExpectedRenewBefore: 10 * 24 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx, err := common.NewRenderContext(config.Config{InternalCertificates: test.Config}, versions.Manifest{}, "test_namespace")
+			require.NoError(t, err)
+
+			d, rb := common.InternalCertRotation(ctx)
+			require.Equal(t, test.ExpectedDuration, d.Duration)
+			if test.ExpectedRenewBefore == 0 {
+				require.Nil(t, rb)
+			} else {
+				require.Equal(t, test.ExpectedRenewBefore, rb.Duration)
+			}
+		})
+	}
+}
+
+func TestInternalCertRestartAnnotations(t *testing.T) {
+	ctx, err := common.NewRenderContext(config.Config{}, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+	require.Nil(t, common.InternalCertRestartAnnotations(ctx, "foo"))
+
+	ctx.Config.InternalCertificates = &config.InternalCertificates{RestartOnRotation: true}
+	require.Equal(t, map[string]string{
+		common.AnnotationReloadOnSecretChange: "foo,bar",
+	}, common.InternalCertRestartAnnotations(ctx, "foo", "bar"))
+}
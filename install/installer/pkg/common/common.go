@@ -609,6 +609,56 @@ func IsDatabaseMigrationDisabled(ctx *RenderContext) bool {
 	return disableMigration
 }
 
+// IsComponentDisabled returns true if an optional component has been switched off in the config
+func IsComponentDisabled(ctx *RenderContext, component string) bool {
+	if component == DockerRegistryName {
+		return !pointer.BoolDeref(ctx.Config.ContainerRegistry.InCluster, false)
+	}
+
+	if ctx.Config.Components == nil || ctx.Config.Components.Disabled == nil {
+		return false
+	}
+
+	disabled := ctx.Config.Components.Disabled
+	switch component {
+	case PublicApiComponent:
+		return disabled.PublicAPIServer
+	case UsageComponent:
+		return disabled.Usage
+	case IDEMetricsComponent:
+		return disabled.IDEMetrics
+	case OpenVSXProxyComponent:
+		return disabled.OpenVSXProxy
+	}
+	return false
+}
+
+// WithComponentEnabled only renders f if the optional component has not been disabled
+func WithComponentEnabled(component string, f RenderFunc) RenderFunc {
+	return func(ctx *RenderContext) ([]runtime.Object, error) {
+		if IsComponentDisabled(ctx, component) {
+			return nil, nil
+		}
+		return f(ctx)
+	}
+}
+
+// SSHGatewayExposure returns how the SSH gateway is reachable from outside the cluster
+func SSHGatewayExposure(ctx *RenderContext) config.SSHGatewayExposure {
+	if ctx.Config.SSHGateway == nil || ctx.Config.SSHGateway.Exposure == "" {
+		return config.SSHGatewayExposureProxy
+	}
+	return ctx.Config.SSHGateway.Exposure
+}
+
+// SSHGatewayPort returns the port SSH clients connect to
+func SSHGatewayPort(ctx *RenderContext) int32 {
+	if ctx.Config.SSHGateway == nil || ctx.Config.SSHGateway.Port == 0 {
+		return SSHGatewayDefaultPort
+	}
+	return ctx.Config.SSHGateway.Port
+}
+
 func Replicas(ctx *RenderContext, component string) *int32 {
 	replicas := int32(1)
 
@@ -754,6 +804,14 @@ var (
 		APIVersion: "trust.cert-manager.io/v1alpha1",
 		Kind:       "Bundle",
 	}
+	TypeMetaValidatingWebhookConfiguration = metav1.TypeMeta{
+		APIVersion: "admissionregistration.k8s.io/v1",
+		Kind:       "ValidatingWebhookConfiguration",
+	}
+	TypeMetaMutatingWebhookConfiguration = metav1.TypeMeta{
+		APIVersion: "admissionregistration.k8s.io/v1",
+		Kind:       "MutatingWebhookConfiguration",
+	}
 	TypePodDisruptionBudget = metav1.TypeMeta{
 		APIVersion: "policy/v1",
 		Kind:       "PodDisruptionBudget",
@@ -14,6 +14,7 @@ import (
 	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestKubeRBACProxyContainer_DefaultPorts(t *testing.T) {
@@ -75,3 +76,29 @@ func TestServerComponentWaiterContainer(t *testing.T) {
 	ideProxyHost := common.ClusterURL("http", common.IDEProxyComponent, ctx.Namespace, common.IDEProxyPort)
 	require.Equal(t, []string{"-v", "component", "--gitpod-host", ctx.Config.Domain, "--ide-metrics-host", ideProxyHost, "--namespace", "test_namespace", "--component", common.ServerComponent, "--labels", labels, "--image", ctx.Config.Repository + "/server:" + "happy_path_server_image"}, container.Args)
 }
+
+func TestIsComponentDisabled(t *testing.T) {
+	ctx, err := common.NewRenderContext(config.Config{}, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+
+	require.False(t, common.IsComponentDisabled(ctx, common.PublicApiComponent))
+	require.True(t, common.IsComponentDisabled(ctx, common.DockerRegistryName), "builtin registry should follow containerRegistry.inCluster")
+
+	ctx.Config.Components = &config.Components{
+		Disabled: &config.DisabledComponents{
+			PublicAPIServer: true,
+			OpenVSXProxy:    true,
+		},
+	}
+	require.True(t, common.IsComponentDisabled(ctx, common.PublicApiComponent))
+	require.True(t, common.IsComponentDisabled(ctx, common.OpenVSXProxyComponent))
+	require.False(t, common.IsComponentDisabled(ctx, common.UsageComponent))
+	require.False(t, common.IsComponentDisabled(ctx, common.IDEMetricsComponent))
+	require.False(t, common.IsComponentDisabled(ctx, common.ServerComponent))
+
+	objs, err := common.WithComponentEnabled(common.PublicApiComponent, func(ctx *common.RenderContext) ([]runtime.Object, error) {
+		return []runtime.Object{&corev1.ConfigMap{}}, nil
+	})(ctx)
+	require.NoError(t, err)
+	require.Empty(t, objs)
+}
@@ -5,8 +5,6 @@
 package common
 
 import (
-	"time"
-
 	"github.com/gitpod-io/gitpod/installer/pkg/config"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -71,8 +69,9 @@ const (
 	IDEMetricsPort              = 3000
 	IDEProxyComponent           = "ide-proxy"
 	IDEProxyPort                = 80
+	SSHGatewayDefaultPort       = 22
 )
 
 var (
-	InternalCertDuration = &metav1.Duration{Duration: time.Hour * 24 * 90}
+	InternalCertDuration = &metav1.Duration{Duration: config.InternalCertDuration}
 )
@@ -54,6 +54,8 @@ var sortOrder = []string{
 	"CronJob",
 	"Ingress",
 	"APIService",
+	"ValidatingWebhookConfiguration",
+	"MutatingWebhookConfiguration",
 }
 
 type RuntimeObject struct {
@@ -52,3 +52,17 @@ func AllowKubeDnsEgressRule() v1.NetworkPolicyEgressRule {
 
 	return dnsEgressRule
 }
+
+// PodSelectorInNamespace selects pods with the given labels in namespace. If namespace differs
+// from the namespace being rendered, the peer is restricted to that namespace explicitly.
+func PodSelectorInNamespace(ctx *RenderContext, namespace string, labels map[string]string) v1.NetworkPolicyPeer {
+	peer := v1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: labels},
+	}
+	if namespace != ctx.Namespace {
+		peer.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{
+			corev1.LabelMetadataName: namespace,
+		}}
+	}
+	return peer
+}
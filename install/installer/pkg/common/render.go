@@ -46,6 +46,27 @@ func CompositeRenderFunc(f ...RenderFunc) RenderFunc {
 	}
 }
 
+// InWorkspaceNamespace renders f into the workspace namespace
+func InWorkspaceNamespace(f RenderFunc) RenderFunc {
+	return func(ctx *RenderContext) ([]runtime.Object, error) {
+		wsCtx := *ctx
+		wsCtx.metaNamespace = ctx.MetaNamespace()
+		wsCtx.Namespace = ctx.WorkspaceNamespace()
+		return f(&wsCtx)
+	}
+}
+
+// WithSplitNamespaces only renders f into the workspace namespace if it differs from the meta namespace.
+// This is used for objects which every namespace hosting Gitpod components needs a copy of.
+func WithSplitNamespaces(f RenderFunc) RenderFunc {
+	return InWorkspaceNamespace(func(ctx *RenderContext) ([]runtime.Object, error) {
+		if !ctx.HasSplitNamespaces() {
+			return nil, nil
+		}
+		return f(ctx)
+	})
+}
+
 func CompositeHelmFunc(f ...HelmFunc) HelmFunc {
 	return func(ctx *RenderContext) ([]string, error) {
 		var res []string
@@ -75,6 +96,28 @@ type RenderContext struct {
 	Values          GeneratedValues
 
 	experimentalConfig *experimental.Config
+	metaNamespace      string
+}
+
+// MetaNamespace returns the namespace the meta components are installed into
+func (r *RenderContext) MetaNamespace() string {
+	if r.metaNamespace != "" {
+		return r.metaNamespace
+	}
+	return r.Namespace
+}
+
+// WorkspaceNamespace returns the namespace the workspace components are installed into
+func (r *RenderContext) WorkspaceNamespace() string {
+	if r.Config.WorkspaceNamespace != "" {
+		return r.Config.WorkspaceNamespace
+	}
+	return r.MetaNamespace()
+}
+
+// HasSplitNamespaces returns true if meta and workspace components are installed into different namespaces
+func (r *RenderContext) HasSplitNamespaces() bool {
+	return r.MetaNamespace() != r.WorkspaceNamespace()
 }
 
 // WithExperimental provides access to the unsupported config. This will only do something
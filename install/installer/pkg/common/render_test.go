@@ -36,6 +36,32 @@ func TestCompositeRenderFunc_NilObjectsNilError(t *testing.T) {
 	require.Len(t, objects, 0)
 }
 
+func TestInWorkspaceNamespace(t *testing.T) {
+	var namespaces []string
+	f := func(ctx *common.RenderContext) ([]runtime.Object, error) {
+		namespaces = append(namespaces, ctx.Namespace, ctx.MetaNamespace(), ctx.WorkspaceNamespace())
+		return nil, nil
+	}
+
+	ctx, err := common.NewRenderContext(config.Config{}, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+
+	_, err = common.InWorkspaceNamespace(f)(ctx)
+	require.NoError(t, err)
+	_, err = common.WithSplitNamespaces(f)(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"test_namespace", "test_namespace", "test_namespace"}, namespaces)
+
+	namespaces = nil
+	ctx.Config.WorkspaceNamespace = "workspaces"
+	_, err = common.InWorkspaceNamespace(f)(ctx)
+	require.NoError(t, err)
+	_, err = common.WithSplitNamespaces(f)(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"workspaces", "test_namespace", "workspaces", "workspaces", "test_namespace", "workspaces"}, namespaces)
+	require.Equal(t, "test_namespace", ctx.Namespace, "must not modify the original context")
+}
+
 func TestReplicas(t *testing.T) {
 	testCases := []struct {
 		Component        string
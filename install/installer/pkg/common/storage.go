@@ -52,6 +52,17 @@ func StorageConfig(context *RenderContext) storageconfig.StorageConfig {
 		}
 	}
 
+	if context.Config.ObjectStorage.Azure != nil {
+		res = &storageconfig.StorageConfig{
+			Kind: storageconfig.AzureStorage,
+			AzureConfig: storageconfig.AzureConfig{
+				AccountName:    context.Config.ObjectStorage.Azure.AccountName,
+				AccountKeyFile: filepath.Join(StorageMount, "accountKey"),
+				Endpoint:       context.Config.ObjectStorage.Azure.Endpoint,
+			},
+		}
+	}
+
 	if useMinio(context) {
 		res = &storageconfig.StorageConfig{
 			Kind: storageconfig.MinIOStorage,
@@ -79,6 +90,17 @@ func StorageConfig(context *RenderContext) storageconfig.StorageConfig {
 	_ = context.WithExperimental(func(ucfg *experimental.Config) error {
 		if ucfg.Workspace != nil {
 			res.Stage = storageconfig.Stage(ucfg.Workspace.Stage)
+
+			cs := ucfg.Workspace.ContentService
+			if cs.DownloadURLTTL != nil {
+				res.SignedURLs.DownloadTTL = *cs.DownloadURLTTL
+			}
+			if cs.UploadURLTTL != nil {
+				res.SignedURLs.UploadTTL = *cs.UploadURLTTL
+			}
+			if cs.MaxURLTTL != nil {
+				res.SignedURLs.MaxTTL = *cs.MaxURLTTL
+			}
 		}
 		return nil
 	})
@@ -146,6 +168,12 @@ func AddStorageMounts(ctx *RenderContext, pod *corev1.PodSpec, container ...stri
 		return nil
 	}
 
+	if ctx.Config.ObjectStorage.Azure != nil {
+		MountStorage(pod, ctx.Config.ObjectStorage.Azure.Credentials.Name, container...)
+
+		return nil
+	}
+
 	if useMinio(ctx) {
 		// builtin storage needs no extra mounts
 		return nil
@@ -0,0 +1,44 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	storageconfig "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+)
+
+func TestStorageConfigSignedURLs(t *testing.T) {
+	cfg := config.Config{
+		ObjectStorage: config.ObjectStorage{
+			InCluster: pointer.Bool(true),
+		},
+	}
+	ctx, err := common.NewRenderContext(cfg, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+	require.Equal(t, storageconfig.SignedURLConfig{}, common.StorageConfig(ctx).SignedURLs, "defaults are left to content-service")
+
+	download, max := util.Duration(4*time.Hour), util.Duration(24*time.Hour)
+	cfg.Experimental = &experimental.Config{
+		Workspace: &experimental.WorkspaceConfig{},
+	}
+	cfg.Experimental.Workspace.ContentService.DownloadURLTTL = &download
+	cfg.Experimental.Workspace.ContentService.MaxURLTTL = &max
+	ctx, err = common.NewRenderContext(cfg, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+	require.Equal(t, storageconfig.SignedURLConfig{
+		DownloadTTL: download,
+		MaxTTL:      max,
+	}, common.StorageConfig(ctx).SignedURLs)
+}
@@ -36,6 +36,8 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 				return map[string]string{
 					common.AnnotationConfigChecksum: configHash,
 				}
+			}, func() map[string]string {
+				return common.InternalCertRestartAnnotations(ctx, wsmanagermk2.TLSSecretNameClient)
 			}),
 		},
 		Spec: appsv1.DaemonSetSpec{
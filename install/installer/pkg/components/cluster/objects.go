@@ -16,3 +16,12 @@ var Objects = common.CompositeRenderFunc(
 	rolebinding,
 	common.DefaultServiceAccount(NobodyComponent),
 )
+
+// NamespaceObjects are the objects each additional namespace hosting Gitpod components needs
+var NamespaceObjects = common.CompositeRenderFunc(
+	namespace,
+	clusterrole,
+	resourcequota,
+	rolebinding,
+	common.DefaultServiceAccount(NobodyComponent),
+)
@@ -28,7 +28,7 @@ var WebAppObjects = common.CompositeRenderFunc(
 )
 
 var WorkspaceObjects = common.CompositeRenderFunc(
-	componentsworkspace.Objects,
+	common.InWorkspaceNamespace(componentsworkspace.Objects),
 )
 
 var FullObjects = common.CompositeRenderFunc(
@@ -62,6 +62,10 @@ var CommonObjects = common.CompositeRenderFunc(
 	dockerregistry.Objects,
 	cluster.Objects,
 	gitpod.Objects,
+	common.WithSplitNamespaces(common.CompositeRenderFunc(
+		cluster.NamespaceObjects,
+		dockerregistry.NamespaceObjects,
+	)),
 )
 
 var CommonHelmDependencies = common.CompositeHelmFunc(
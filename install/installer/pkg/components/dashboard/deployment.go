@@ -20,6 +20,12 @@ import (
 func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 	labels := common.CustomizeLabel(ctx, Component, common.TypeMetaDeployment)
 
+	var initContainers []corev1.Container
+	if !common.IsComponentDisabled(ctx, common.PublicApiComponent) {
+		initContainers = append(initContainers, *common.PublicApiServerComponentWaiterContainer(ctx))
+	}
+	initContainers = append(initContainers, *common.ServerComponentWaiterContainer(ctx))
+
 	return []runtime.Object{
 		&appsv1.Deployment{
 			TypeMeta: common.TypeMetaDeployment,
@@ -48,10 +54,7 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 						DNSPolicy:                     corev1.DNSClusterFirst,
 						RestartPolicy:                 corev1.RestartPolicyAlways,
 						TerminationGracePeriodSeconds: pointer.Int64(30),
-						InitContainers: []corev1.Container{
-							*common.PublicApiServerComponentWaiterContainer(ctx),
-							*common.ServerComponentWaiterContainer(ctx),
-						},
+						InitContainers:                initContainers,
 						Containers: []corev1.Container{{
 							Name:            Component,
 							Image:           ctx.ImageName(ctx.Config.Repository, Component, ctx.VersionManifest.Components.Dashboard.Version),
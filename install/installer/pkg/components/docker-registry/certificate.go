@@ -20,6 +20,8 @@ func certificate(ctx *common.RenderContext) ([]runtime.Object, error) {
 		return nil, nil
 	}
 
+	duration, renewBefore := common.InternalCertRotation(ctx)
+
 	return []runtime.Object{&certmanagerv1.Certificate{
 		TypeMeta: common.TypeMetaCertificate,
 		ObjectMeta: metav1.ObjectMeta{
@@ -28,8 +30,9 @@ func certificate(ctx *common.RenderContext) ([]runtime.Object, error) {
 			Labels:    common.DefaultLabels(Component),
 		},
 		Spec: certmanagerv1.CertificateSpec{
-			Duration:   common.InternalCertDuration,
-			SecretName: BuiltInRegistryCerts,
+			Duration:    duration,
+			RenewBefore: renewBefore,
+			SecretName:  BuiltInRegistryCerts,
 			IssuerRef: cmmeta.ObjectReference{
 				Name:  common.CertManagerCAIssuer,
 				Kind:  certmanagerv1.ClusterIssuerKind,
@@ -34,6 +34,12 @@ var Helm = common.CompositeHelmFunc(
 			helm.KeyValue("docker-registry.serviceAccount.name", Component),
 		}
 
+		// Restart the registry once its internal certificate was rotated. Note that helm splits --set values on commas,
+		// which is fine as long as we list a single secret only.
+		for k, v := range common.InternalCertRestartAnnotations(cfg, BuiltInRegistryCerts) {
+			registryValues = append(registryValues, helm.KeyValue(fmt.Sprintf("docker-registry.podAnnotations.%s", strings.Replace(k, ".", "\\.", -1)), v))
+		}
+
 		if len(cfg.Config.ImagePullSecrets) > 0 {
 			// This chart doesn't add in the "name/value" pair format
 			for k, v := range cfg.Config.ImagePullSecrets {
@@ -22,3 +22,8 @@ var Objects = common.CompositeRenderFunc(
 		return common.DefaultServiceAccount(Component)(ctx)
 	},
 )
+
+// NamespaceObjects are the objects workspace components need when installed into their own namespace
+var NamespaceObjects = common.CompositeRenderFunc(
+	secret,
+)
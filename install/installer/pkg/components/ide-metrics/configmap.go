@@ -352,6 +352,22 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 				},
 			},
 		},
+		{
+			Name:   "supervisor_ssh_sessions_total",
+			Help:   "Total number of SSH sessions started in the workspace",
+			Labels: []config.LabelAllowList{},
+		},
+		{
+			Name: "supervisor_task_state_total",
+			Help: "Total number of tasks which reached a state",
+			Labels: []config.LabelAllowList{
+				{
+					Name:         "state",
+					AllowValues:  []string{"opening", "running", "closed"},
+					DefaultValue: "unknown",
+				},
+			},
+		},
 		{
 			Name: "service_waiter_skip_components_result_total",
 			Help: "Total number of wait result of service_waiter/component service_waiter_skip_components flag",
@@ -432,6 +448,17 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 				},
 			},
 		},
+		{
+			Name:    "supervisor_port_exposure_duration_seconds",
+			Help:    "the time it takes to auto-expose a served port",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+			Labels: []config.LabelAllowList{
+				{
+					Name:        "result",
+					AllowValues: []string{"success", "failure"},
+				},
+			},
+		},
 		{
 			Name:    "supervisor_initializer_bytes_second",
 			Help:    "initializer speed in bytes per second",
@@ -6,11 +6,11 @@ package ide_metrics
 
 import "github.com/gitpod-io/gitpod/installer/pkg/common"
 
-var Objects = common.CompositeRenderFunc(
+var Objects = common.WithComponentEnabled(Component, common.CompositeRenderFunc(
 	configmap,
 	deployment,
 	rolebinding,
 	service,
 	networkpolicy,
 	common.DefaultServiceAccount(Component),
-)
+))
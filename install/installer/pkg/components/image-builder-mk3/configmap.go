@@ -14,6 +14,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/image-builder/api/config"
 	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	contentservice "github.com/gitpod-io/gitpod/installer/pkg/components/content-service"
 	dockerregistry "github.com/gitpod-io/gitpod/installer/pkg/components/docker-registry"
 	"github.com/gitpod-io/gitpod/installer/pkg/components/workspace"
 	wsmanagermk2 "github.com/gitpod-io/gitpod/installer/pkg/components/ws-manager-mk2"
@@ -43,6 +44,14 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 
 	baseImageRepoName := "base-images"
 	workspaceImageRepoName := "workspace-images"
+	var (
+		buildCache                   config.BuildCacheConfig
+		queue                        config.QueueConfig
+		platforms                    []string
+		timeout                      util.Duration
+		credentialHelpers            []string
+		credentialHelperRepositories []string
+	)
 
 	_ = ctx.WithExperimental(func(cfg *experimental.Config) error {
 		if cfg.Workspace != nil {
@@ -52,10 +61,28 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 			if cfg.Workspace.ImageBuilderMk3.WorkspaceImageRepositoryName != "" {
 				workspaceImageRepoName = cfg.Workspace.ImageBuilderMk3.WorkspaceImageRepositoryName
 			}
+			buildCache.Enabled = cfg.Workspace.ImageBuilderMk3.EnableBuildCache
+			if cfg.Workspace.ImageBuilderMk3.BuildCacheRepositoryName != "" {
+				buildCache.Repository = fmt.Sprintf("%s/%s", registryName, cfg.Workspace.ImageBuilderMk3.BuildCacheRepositoryName)
+			}
+			queue.MaxConcurrentBuilds = cfg.Workspace.ImageBuilderMk3.MaxConcurrentBuilds
+			queue.MaxConcurrentBuildsPerOrganization = cfg.Workspace.ImageBuilderMk3.MaxConcurrentBuildsPerOrganization
+			platforms = cfg.Workspace.ImageBuilderMk3.Platforms
+			if cfg.Workspace.ImageBuilderMk3.BuildTimeout != nil {
+				timeout = *cfg.Workspace.ImageBuilderMk3.BuildTimeout
+			}
+			credentialHelpers = cfg.Workspace.ImageBuilderMk3.CredentialHelpers
+			credentialHelperRepositories = cfg.Workspace.ImageBuilderMk3.CredentialHelperRepositories
 		}
 		return nil
 	})
 
+	var buildLogs config.BuildLogsConfig
+	if ctx.Config.Kind != configv1.InstallationWorkspace {
+		// content-service only runs next to image-builder-mk3 if the installation contains the webapp
+		buildLogs.ContentServiceAddress = common.ClusterAddress(contentservice.Component, ctx.MetaNamespace(), contentservice.RPCPort)
+	}
+
 	workspaceManagerAddress := fmt.Sprintf("%s:%d", common.WSManagerMk2Component, wsmanagermk2.RPCPort)
 	orchestrator := config.Configuration{
 		WorkspaceManager: config.WorkspaceManagerConfig{
@@ -66,12 +93,19 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 				PrivateKey:  "/wsman-certs/tls.key",
 			},
 		},
-		PullSecret:               secretName,
-		PullSecretFile:           "/config/pull-secret/pull-secret.json",
-		BaseImageRepository:      fmt.Sprintf("%s/%s", registryName, baseImageRepoName),
-		WorkspaceImageRepository: fmt.Sprintf("%s/%s", registryName, workspaceImageRepoName),
-		BuilderImage:             ctx.ImageName(ctx.Config.Repository, BuilderImage, ctx.VersionManifest.Components.ImageBuilderMk3.BuilderImage.Version),
-		EnableAdditionalECRAuth:  ctx.Config.ContainerRegistry.EnableAdditionalECRAuth,
+		PullSecret:                   secretName,
+		PullSecretFile:               "/config/pull-secret/pull-secret.json",
+		BaseImageRepository:          fmt.Sprintf("%s/%s", registryName, baseImageRepoName),
+		WorkspaceImageRepository:     fmt.Sprintf("%s/%s", registryName, workspaceImageRepoName),
+		BuilderImage:                 ctx.ImageName(ctx.Config.Repository, BuilderImage, ctx.VersionManifest.Components.ImageBuilderMk3.BuilderImage.Version),
+		EnableAdditionalECRAuth:      ctx.Config.ContainerRegistry.EnableAdditionalECRAuth,
+		BuildCache:                   buildCache,
+		Queue:                        queue,
+		BuildLogs:                    buildLogs,
+		Platforms:                    platforms,
+		BuildTimeout:                 timeout,
+		CredentialHelpers:            credentialHelpers,
+		CredentialHelperRepositories: credentialHelperRepositories,
 	}
 
 	workspaceImage := ctx.Config.Workspace.WorkspaceImage
@@ -128,10 +128,12 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 	return []runtime.Object{&appsv1.Deployment{
 		TypeMeta: common.TypeMetaDeployment,
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        Component,
-			Namespace:   ctx.Namespace,
-			Labels:      labels,
-			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment),
+			Name:      Component,
+			Namespace: ctx.Namespace,
+			Labels:    labels,
+			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment, func() map[string]string {
+				return common.InternalCertRestartAnnotations(ctx, TLSSecretName, wsmanagermk2.TLSSecretNameClient)
+			}),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(Component)},
@@ -21,13 +21,9 @@ func networkpolicy(ctx *common.RenderContext) ([]runtime.Object, error) {
 	// until https://github.com/gitpod-io/ops/issues/6905 is fixed.
 	if ctx.Config.Kind != config.InstallationWorkspace {
 		ingressRules = []networkingv1.NetworkPolicyPeer{
-			{
-				PodSelector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{
-						"component": server.Component,
-					},
-				},
-			},
+			common.PodSelectorInNamespace(ctx, ctx.MetaNamespace(), map[string]string{
+				"component": server.Component,
+			}),
 			{
 				PodSelector: &metav1.LabelSelector{
 					MatchLabels: map[string]string{
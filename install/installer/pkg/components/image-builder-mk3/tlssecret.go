@@ -23,6 +23,8 @@ func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
 		fmt.Sprintf("%s-dev", Component),
 	}
 
+	duration, renewBefore := common.InternalCertRotation(ctx)
+
 	return []runtime.Object{
 		&certmanagerv1.Certificate{
 			TypeMeta: common.TypeMetaCertificate,
@@ -32,9 +34,10 @@ func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
 				Labels:    common.DefaultLabels(Component),
 			},
 			Spec: certmanagerv1.CertificateSpec{
-				Duration:   common.InternalCertDuration,
-				SecretName: TLSSecretName,
-				DNSNames:   serverAltNames,
+				Duration:    duration,
+				RenewBefore: renewBefore,
+				SecretName:  TLSSecretName,
+				DNSNames:    serverAltNames,
 				IssuerRef: cmmeta.ObjectReference{
 					Name:  common.CertManagerCAIssuer,
 					Kind:  certmanagerv1.ClusterIssuerKind,
@@ -8,7 +8,7 @@ import "github.com/gitpod-io/gitpod/installer/pkg/common"
 
 // todo(sje): conditionally deploy this component
 
-var Objects = common.CompositeRenderFunc(
+var Objects = common.WithComponentEnabled(Component, common.CompositeRenderFunc(
 	configmap,
 	networkpolicy,
 	rolebinding,
@@ -16,4 +16,4 @@ var Objects = common.CompositeRenderFunc(
 	pdb,
 	service,
 	common.DefaultServiceAccount(Component),
-)
+))
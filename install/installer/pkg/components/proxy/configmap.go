@@ -80,14 +80,6 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		return nil, err
 	}
 
-	openVSX, err := renderTemplate(vhostOpenVSXTmpl, openVSXTpl{
-		Domain:  ctx.Config.Domain,
-		RepoURL: fmt.Sprintf("openvsx-proxy.%s.%s:%d", ctx.Namespace, kubeDomain, openvsxproxy.ServicePort),
-	})
-	if err != nil {
-		return nil, err
-	}
-
 	ideProxy, err := renderTemplate(ideProxyTmpl, commonTpl{
 		Domain:       ctx.Config.Domain,
 		ReverseProxy: fmt.Sprintf("ide-proxy.%s.%s:%d", ctx.Namespace, kubeDomain, ideProxyComponent.ServicePort),
@@ -98,10 +90,21 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 
 	data := map[string]string{
 		"vhost.empty":     *empty,
-		"vhost.open-vsx":  *openVSX,
 		"vhost.ide-proxy": *ideProxy,
 	}
 
+	if !common.IsComponentDisabled(ctx, common.OpenVSXProxyComponent) {
+		// Without the proxy, clients talk to the upstream registry directly
+		openVSX, err := renderTemplate(vhostOpenVSXTmpl, openVSXTpl{
+			Domain:  ctx.Config.Domain,
+			RepoURL: fmt.Sprintf("openvsx-proxy.%s.%s:%d", ctx.Namespace, kubeDomain, openvsxproxy.ServicePort),
+		})
+		if err != nil {
+			return nil, err
+		}
+		data["vhost.open-vsx"] = *openVSX
+	}
+
 	if ctx.Config.ObjectStorage.CloudStorage == nil {
 		// Don't expose Minio if using cloud storage
 		minio, err := renderTemplate(vhostMinioTmpl, commonTpl{
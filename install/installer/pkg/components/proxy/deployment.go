@@ -273,6 +273,9 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 								}, {
 									Name:  "WORKSPACE_HANDLER_FILE",
 									Value: strings.ToLower(string(ctx.Config.Kind)),
+								}, {
+									Name:  "WORKSPACE_NAMESPACE",
+									Value: ctx.WorkspaceNamespace(),
 								}, {
 									Name:  "ANALYTICS_PLUGIN_TRUSTED_SEGMENT_KEY",
 									Value: trustedSegmentKey,
@@ -66,11 +66,9 @@ func networkpolicy(ctx *common.RenderContext) ([]runtime.Object, error) {
 					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
 						"component": common.WSManagerBridgeComponent,
 					}},
-				}, {
-					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
-						"component": common.WSProxyComponent,
-					}},
-				}},
+				}, common.PodSelectorInNamespace(ctx, ctx.WorkspaceNamespace(), map[string]string{
+					"component": common.WSProxyComponent,
+				})},
 			}, {
 				Ports: []networkingv1.NetworkPolicyPort{{
 					Protocol: common.TCPProtocol,
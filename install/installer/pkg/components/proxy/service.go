@@ -81,11 +81,11 @@ func service(ctx *common.RenderContext) ([]runtime.Object, error) {
 			ServicePort:   ContainerConfigcatPort,
 		},
 	}
-	if ctx.Config.SSHGatewayHostKey != nil {
+	if ctx.Config.SSHGatewayHostKeySecret() != nil && common.SSHGatewayExposure(ctx) == configv1.SSHGatewayExposureProxy {
 		ports = append(ports, common.ServicePort{
 			Name:          ContainerSSHName,
 			ContainerPort: ContainerSSHPort,
-			ServicePort:   ContainerSSHPort,
+			ServicePort:   common.SSHGatewayPort(ctx),
 		})
 	}
 
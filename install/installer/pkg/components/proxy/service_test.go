@@ -113,6 +113,46 @@ func TestServiceAnnotations(t *testing.T) {
 	}
 }
 
+func TestServiceSSHGateway(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		SSHGateway *config.SSHGateway
+		ExpectPort int32
+	}{
+		{
+			Name: "disabled",
+		},
+		{
+			Name:       "exposed through the proxy",
+			SSHGateway: &config.SSHGateway{Enabled: true, HostKey: &config.ObjectRef{Name: "host-key"}, Port: 2222},
+			ExpectPort: 2222,
+		},
+		{
+			Name:       "exposed through a dedicated load balancer",
+			SSHGateway: &config.SSHGateway{Enabled: true, HostKey: &config.ObjectRef{Name: "host-key"}, Exposure: config.SSHGatewayExposureLoadBalancer},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			ctx := renderContextWithProxyConfig(t, nil, nil)
+			ctx.Config.SSHGateway = testCase.SSHGateway
+
+			objects, err := service(ctx)
+			require.NoError(t, err)
+
+			svc := objects[0].(*corev1.Service)
+			var port int32
+			for _, p := range svc.Spec.Ports {
+				if p.Name == ContainerSSHName {
+					port = p.Port
+				}
+			}
+			require.Equal(t, testCase.ExpectPort, port)
+		})
+	}
+}
+
 func loadBalancerAnnotations(ctx *common.RenderContext, annotations map[string]string) map[string]string {
 	annotations["external-dns.alpha.kubernetes.io/hostname"] = fmt.Sprintf("%s,*.%s,*.ws.%s", ctx.Config.Domain, ctx.Config.Domain, ctx.Config.Domain)
 	annotations["cloud.google.com/neg"] = `{"exposed_ports": {"80":{},"443": {}}}`
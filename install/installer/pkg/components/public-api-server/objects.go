@@ -10,6 +10,10 @@ import (
 )
 
 func Objects(ctx *common.RenderContext) ([]runtime.Object, error) {
+	if common.IsComponentDisabled(ctx, Component) {
+		return nil, nil
+	}
+
 	return common.CompositeRenderFunc(
 		configmap,
 		deployment,
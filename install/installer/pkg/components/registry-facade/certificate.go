@@ -16,6 +16,8 @@ import (
 )
 
 func certificate(ctx *common.RenderContext) ([]runtime.Object, error) {
+	duration, renewBefore := common.InternalCertRotation(ctx)
+
 	return []runtime.Object{&certmanagerv1.Certificate{
 		TypeMeta: common.TypeMetaCertificate,
 		ObjectMeta: metav1.ObjectMeta{
@@ -24,8 +26,9 @@ func certificate(ctx *common.RenderContext) ([]runtime.Object, error) {
 			Labels:    common.DefaultLabels(Component),
 		},
 		Spec: certmanagerv1.CertificateSpec{
-			Duration:   common.InternalCertDuration,
-			SecretName: common.RegistryFacadeTLSCertSecret,
+			Duration:    duration,
+			RenewBefore: renewBefore,
+			SecretName:  common.RegistryFacadeTLSCertSecret,
 			IssuerRef: cmmeta.ObjectReference{
 				Name:  common.CertManagerCAIssuer,
 				Kind:  certmanagerv1.ClusterIssuerKind,
@@ -40,7 +40,7 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 				Name: "config-certificates",
 				VolumeSource: corev1.VolumeSource{
 					Secret: &corev1.SecretVolumeSource{
-						SecretName: "builtin-registry-facade-cert",
+						SecretName: common.RegistryFacadeTLSCertSecret,
 					},
 				},
 			},
@@ -163,10 +163,12 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 	return []runtime.Object{&appsv1.DaemonSet{
 		TypeMeta: common.TypeMetaDaemonset,
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        Component,
-			Namespace:   ctx.Namespace,
-			Labels:      labels,
-			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDaemonset),
+			Name:      Component,
+			Namespace: ctx.Namespace,
+			Labels:    labels,
+			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDaemonset, func() map[string]string {
+				return common.InternalCertRestartAnnotations(ctx, common.RegistryFacadeTLSCertSecret, wsmanagermk2.TLSSecretNameClient)
+			}),
 		},
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(Component)},
@@ -87,6 +87,11 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		return nil
 	})
 
+	vsxRegistryUrl := fmt.Sprintf("https://open-vsx.%s", ctx.Config.Domain)
+	if common.IsComponentDisabled(ctx, common.OpenVSXProxyComponent) {
+		vsxRegistryUrl = ctx.Config.OpenVSX.URL
+	}
+
 	stripeConfig := ""
 	_ = ctx.WithExperimental(func(cfg *experimental.Config) error {
 		if cfg.WebApp != nil && cfg.WebApp.Server != nil {
@@ -278,7 +283,7 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		IDEServiceAddr:      common.ClusterAddress(ideservice.Component, ctx.Namespace, ideservice.GRPCServicePort),
 		MaximumEventLoopLag: 0.35,
 		CodeSync:            CodeSync{},
-		VSXRegistryUrl:      fmt.Sprintf("https://open-vsx.%s", ctx.Config.Domain), // todo(sje): or "https://{{ .Values.vsxRegistry.host | default "open-vsx.org" }}" if not using OpenVSX proxy
+		VSXRegistryUrl:      vsxRegistryUrl,
 		EnablePayment:       stripeSecret != "" || stripeConfig != "",
 		StripeSecretsFile:   fmt.Sprintf("%s/apikeys", stripeSecretMountPath),
 		LinkedInSecretsFile: fmt.Sprintf("%s/linkedin", linkedInSecretMountPath),
@@ -107,16 +107,20 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 	)
 
 	if ctx.Config.HTTPProxy != nil {
+		noGRPCProxy := []string{
+			fmt.Sprintf("%s.%s.svc.cluster.local", contentservice.Component, ctx.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", common.ImageBuilderComponent, ctx.WorkspaceNamespace()),
+		}
+		if !common.IsComponentDisabled(ctx, usage.Component) {
+			noGRPCProxy = append(noGRPCProxy, fmt.Sprintf("%s.%s.svc.cluster.local", usage.Component, ctx.Namespace))
+		}
+		noGRPCProxy = append(noGRPCProxy, "$(NO_PROXY)")
+
 		env = append(env, corev1.EnvVar{
 			Name: "no_grpc_proxy",
 			// @grpc/grpc-js does not support wildcards in NO_PROXY
 			// @link https://github.com/grpc/grpc-node/issues/1293
-			Value: strings.Join([]string{
-				fmt.Sprintf("%s.%s.svc.cluster.local", contentservice.Component, ctx.Namespace),
-				fmt.Sprintf("%s.%s.svc.cluster.local", common.ImageBuilderComponent, ctx.Namespace),
-				fmt.Sprintf("%s.%s.svc.cluster.local", usage.Component, ctx.Namespace),
-				"$(NO_PROXY)",
-			}, ","),
+			Value: strings.Join(noGRPCProxy, ","),
 		})
 	}
 
@@ -292,10 +296,12 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 		&appsv1.Deployment{
 			TypeMeta: common.TypeMetaDeployment,
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        Component,
-				Namespace:   ctx.Namespace,
-				Labels:      labels,
-				Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment),
+				Name:      Component,
+				Namespace: ctx.Namespace,
+				Labels:    labels,
+				Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment, func() map[string]string {
+					return common.InternalCertRestartAnnotations(ctx, wsmanagermk2.TLSSecretNameClient)
+				}),
 			},
 			Spec: appsv1.DeploymentSpec{
 				Selector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(Component)},
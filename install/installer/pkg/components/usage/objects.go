@@ -13,7 +13,7 @@ import (
 
 func Objects(ctx *common.RenderContext) ([]runtime.Object, error) {
 	cfg := getExperimentalUsageConfig(ctx)
-	if cfg == nil {
+	if cfg == nil || common.IsComponentDisabled(ctx, Component) {
 		return nil, nil
 	}
 
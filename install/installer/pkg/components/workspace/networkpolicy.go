@@ -39,9 +39,7 @@ func networkpolicy(ctx *common.RenderContext) ([]runtime.Object, error) {
 			Ingress: []networkingv1.NetworkPolicyIngressRule{
 				{
 					From: []networkingv1.NetworkPolicyPeer{
-						{
-							PodSelector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(proxy.Component)},
-						},
+						common.PodSelectorInNamespace(ctx, ctx.MetaNamespace(), common.DefaultLabels(proxy.Component)),
 					},
 				},
 				{
@@ -96,9 +94,7 @@ func networkpolicy(ctx *common.RenderContext) ([]runtime.Object, error) {
 				},
 				{
 					To: []networkingv1.NetworkPolicyPeer{
-						{
-							PodSelector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(proxy.Component)},
-						},
+						common.PodSelectorInNamespace(ctx, ctx.MetaNamespace(), common.DefaultLabels(proxy.Component)),
 					},
 				},
 				common.AllowKubeDnsEgressRule(),
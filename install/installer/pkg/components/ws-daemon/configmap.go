@@ -5,6 +5,7 @@
 package wsdaemon
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"time"
 
@@ -25,11 +26,63 @@ import (
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/netlimit"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
+	wsdcfg, err := daemonConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := common.ToJSONString(wsdcfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ws-daemon config: %w", err)
+	}
+
+	return []runtime.Object{&corev1.ConfigMap{
+		TypeMeta: common.TypeMetaConfigmap,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        Component,
+			Namespace:   ctx.Namespace,
+			Labels:      common.CustomizeLabel(ctx, Component, common.TypeMetaConfigmap),
+			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaConfigmap),
+		},
+		Data: map[string]string{
+			"config.json": string(fc),
+		},
+	}}, nil
+}
+
+// restartConfigHash hashes the ws-daemon config without the settings ws-daemon reloads at runtime. Changing
+// only those, e.g. tuning the workspace limits, must not restart ws-daemon on every node.
+func restartConfigHash(ctx *common.RenderContext) (string, error) {
+	wsdcfg, err := daemonConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	wsdcfg.LogLevel = ""
+	wsdcfg.Daemon.CPULimit.Limit = resource.Quantity{}
+	wsdcfg.Daemon.CPULimit.BurstLimit = resource.Quantity{}
+	wsdcfg.Daemon.CPULimit.TotalBandwidth = resource.Quantity{}
+	wsdcfg.Daemon.IOLimit = daemon.IOLimitConfig{}
+	wsdcfg.Daemon.ProcLimit = 0
+	wsdcfg.Daemon.NetLimit.Enforce = false
+	wsdcfg.Daemon.NetLimit.ConnectionsPerMinute = 0
+	wsdcfg.Daemon.NetLimit.BucketSize = 0
+	wsdcfg.Daemon.EgressLimit.BandwidthPerSecond = resource.Quantity{}
+	wsdcfg.Daemon.EgressLimit.Burst = resource.Quantity{}
+
+	fc, err := common.ToJSONString(wsdcfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ws-daemon config: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(fc)), nil
+}
+
+func daemonConfig(ctx *common.RenderContext) (*wsdconfig.Config, error) {
 	var fsshift wsdapi.FSShiftMethod
 	switch ctx.Config.Workspace.Runtime.FSShiftMethod {
 	case config.FSShiftShiftFS:
@@ -38,6 +91,12 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		return nil, fmt.Errorf("unknown fs shift method: %s", ctx.Config.Workspace.Runtime.FSShiftMethod)
 	}
 
+	// ws-daemon's CPU, IO and process limiting is implemented for cgroup v2 only - config validation
+	// rejects any other version, hence there is no cgroup v1 configuration to render.
+	if v := ctx.Config.Workspace.Runtime.CGroupVersion; v != "" && v != config.CGroupV2 {
+		return nil, fmt.Errorf("unsupported cgroup version: %s", v)
+	}
+
 	cpuLimitConfig := cpulimit.Config{
 		Enabled:        false,
 		CGroupBasePath: "/mnt/node-cgroups",
@@ -136,7 +195,8 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 				UserNamespaces: content.UserNamespacesConfig{
 					FSShift: content.FSShiftMethod(fsshift),
 				},
-				Storage: common.StorageConfig(ctx),
+				Storage:    common.StorageConfig(ctx),
+				Encryption: encryptionConfig(ctx),
 				Backup: content.BackupConfig{
 					Timeout:  util.Duration(time.Minute * 5),
 					Attempts: 3,
@@ -144,6 +204,7 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 				Initializer: content.InitializerConfig{
 					Command: "/app/content-initializer",
 				},
+				PrebuildCache: prebuildCacheConfig(ctx),
 			},
 			Uidmapper: iws.UidmapperConfig{
 				ProcLocation: "/proc",
@@ -180,21 +241,22 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 			},
 		},
 	}
-	fc, err := common.ToJSONString(wsdcfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal ws-daemon config: %w", err)
-	}
+	return &wsdcfg, nil
+}
 
-	return []runtime.Object{&corev1.ConfigMap{
-		TypeMeta: common.TypeMetaConfigmap,
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        Component,
-			Namespace:   ctx.Namespace,
-			Labels:      common.CustomizeLabel(ctx, Component, common.TypeMetaConfigmap),
-			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaConfigmap),
-		},
-		Data: map[string]string{
-			"config.json": string(fc),
-		},
-	}}, nil
+// prebuildCacheConfig configures the node-local prebuild cache, which is kept in a hostPath such that it outlives ws-daemon
+func prebuildCacheConfig(ctx *common.RenderContext) content.PrebuildCacheConfig {
+	var res content.PrebuildCacheConfig
+	_ = ctx.WithExperimental(func(ucfg *experimental.Config) error {
+		if ucfg.Workspace == nil || !ucfg.Workspace.WSDaemon.PrebuildCache.Enabled {
+			return nil
+		}
+
+		res.Location = ContainerPrebuildCache
+		if maxSize := ucfg.Workspace.WSDaemon.PrebuildCache.MaxSize; maxSize != nil {
+			res.MaxSize = *maxSize
+		}
+		return nil
+	})
+	return res
 }
@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package wsdaemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/content"
+)
+
+func TestPrebuildCacheConfig(t *testing.T) {
+	maxSize := resource.MustParse("50Gi")
+	tests := []struct {
+		Name        string
+		Enabled     bool
+		MaxSize     *resource.Quantity
+		Expectation content.PrebuildCacheConfig
+	}{
+		{
+			Name: "disabled",
+		},
+		{
+			Name:        "enabled",
+			Enabled:     true,
+			Expectation: content.PrebuildCacheConfig{Location: ContainerPrebuildCache},
+		},
+		{
+			Name:        "max size",
+			Enabled:     true,
+			MaxSize:     &maxSize,
+			Expectation: content.PrebuildCacheConfig{Location: ContainerPrebuildCache, MaxSize: maxSize},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ws := &experimental.WorkspaceConfig{}
+			ws.WSDaemon.PrebuildCache.Enabled = test.Enabled
+			ws.WSDaemon.PrebuildCache.MaxSize = test.MaxSize
+
+			cfg, err := daemonConfig(renderContextWithWorkspaceConfig(t, ws))
+			require.NoError(t, err)
+			require.Equal(t, test.Expectation, cfg.Daemon.Content.PrebuildCache)
+		})
+	}
+}
@@ -12,6 +12,8 @@ const (
 	HostWorkingAreaMk2      = "/var/gitpod/workspaces-mk2"
 	ContainerWorkingAreaMk2 = "/mnt/workingarea-mk2"
 	HostBackupPath          = "/var/gitpod/tmp/backup"
+	HostPrebuildCachePath   = "/var/gitpod/prebuild-cache"
+	ContainerPrebuildCache  = "/mnt/prebuild-cache"
 	TLSSecretName           = "ws-daemon-tls"
 	VolumeTLSCerts          = "ws-daemon-tls-certs"
 	ReadinessPort           = baseserver.BuiltinHealthPort
@@ -24,7 +24,7 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 	labels := common.CustomizeLabel(ctx, Component, common.TypeMetaDaemonset)
 
 	//nolint:typecheck
-	configHash, err := common.ObjectHash(configmap(ctx))
+	configHash, err := restartConfigHash(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +188,24 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 		common.CAVolumeMount(),
 	}
 
+	if prebuildCacheConfig(ctx).Location != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "prebuild-cache",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{
+				Path: HostPrebuildCachePath,
+				Type: func() *corev1.HostPathType { r := corev1.HostPathDirectoryOrCreate; return &r }(),
+			}},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "prebuild-cache",
+			MountPath: ContainerPrebuildCache,
+		})
+	}
+
+	encVolumes, encMounts := encryptionVolumes(ctx)
+	volumes = append(volumes, encVolumes...)
+	volumeMounts = append(volumeMounts, encMounts...)
+
 	tolerations := []corev1.Toleration{
 		{
 			Key:      "node.kubernetes.io/disk-pressure",
@@ -284,10 +302,12 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 	return []runtime.Object{&appsv1.DaemonSet{
 		TypeMeta: common.TypeMetaDaemonset,
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        Component,
-			Namespace:   ctx.Namespace,
-			Labels:      labels,
-			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDaemonset),
+			Name:      Component,
+			Namespace: ctx.Namespace,
+			Labels:    labels,
+			Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDaemonset, func() map[string]string {
+				return common.InternalCertRestartAnnotations(ctx, TLSSecretName)
+			}),
 		},
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(Component)},
@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package wsdaemon
+
+import (
+	"fmt"
+	"path/filepath"
+
+	cntntcfg "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	encryptionKeysDir       = "/mnt/encryption-keys"
+	encryptionKMSCredsDir   = "/mnt/encryption-kms"
+	encryptionKMSCredsEntry = "credentials.json"
+)
+
+func contentEncryption(ctx *common.RenderContext) *experimental.ContentEncryption {
+	var res *experimental.ContentEncryption
+	_ = ctx.WithExperimental(func(ucfg *experimental.Config) error {
+		if ucfg.Workspace != nil {
+			res = ucfg.Workspace.ContentEncryption
+		}
+		return nil
+	})
+	return res
+}
+
+// encryptionConfig produces the config of the key provider backups and snapshots are encrypted with
+func encryptionConfig(ctx *common.RenderContext) cntntcfg.EncryptionConfig {
+	enc := contentEncryption(ctx)
+	switch {
+	case enc == nil:
+		return cntntcfg.EncryptionConfig{}
+	case enc.GCPKMS != nil:
+		res := cntntcfg.EncryptionConfig{
+			Provider: cntntcfg.EncryptionProviderGCPKMS,
+			GCPKMS:   &cntntcfg.GCPKMSConfig{Keys: enc.GCPKMS.Keys},
+		}
+		if enc.GCPKMS.CredentialsSecret != "" {
+			res.GCPKMS.CredentialsFile = filepath.Join(encryptionKMSCredsDir, encryptionKMSCredsEntry)
+		}
+		return res
+	case len(enc.Local) > 0:
+		return cntntcfg.EncryptionConfig{
+			Provider: cntntcfg.EncryptionProviderLocal,
+			Local:    &cntntcfg.LocalKeysConfig{KeysDir: encryptionKeysDir},
+		}
+	default:
+		return cntntcfg.EncryptionConfig{}
+	}
+}
+
+// encryptionVolumes mounts the keys of organizations, or the credentials for KMS, into ws-daemon
+func encryptionVolumes(ctx *common.RenderContext) (volumes []corev1.Volume, mounts []corev1.VolumeMount) {
+	enc := contentEncryption(ctx)
+	if enc == nil {
+		return nil, nil
+	}
+
+	if enc.GCPKMS != nil {
+		if enc.GCPKMS.CredentialsSecret == "" {
+			return nil, nil
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: "encryption-kms",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: enc.GCPKMS.CredentialsSecret,
+				Items:      []corev1.KeyToPath{{Key: encryptionKMSCredsEntry, Path: encryptionKMSCredsEntry}},
+			}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      "encryption-kms",
+			MountPath: encryptionKMSCredsDir,
+			ReadOnly:  true,
+		})
+		return volumes, mounts
+	}
+
+	for i, keys := range enc.Local {
+		// organization IDs don't make for valid volume names, hence we number the volumes
+		name := fmt.Sprintf("encryption-keys-%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: keys.Secret}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: filepath.Join(encryptionKeysDir, keys.Organization),
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
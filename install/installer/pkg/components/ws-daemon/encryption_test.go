@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package wsdaemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+
+	cntntcfg "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+)
+
+func renderContextWithWorkspaceConfig(t *testing.T, ws *experimental.WorkspaceConfig) *common.RenderContext {
+	ctx, err := common.NewRenderContext(config.Config{
+		Domain: "test.domain.everything.awesome.is",
+		ObjectStorage: config.ObjectStorage{
+			InCluster: pointer.Bool(true),
+		},
+		Workspace: config.Workspace{
+			Runtime: config.WorkspaceRuntime{
+				FSShiftMethod: config.FSShiftShiftFS,
+			},
+		},
+		Experimental: &experimental.Config{
+			Workspace: ws,
+		},
+	}, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestContentEncryption(t *testing.T) {
+	type Expectation struct {
+		Config cntntcfg.EncryptionConfig
+		Mounts map[string]string
+	}
+	tests := []struct {
+		Name        string
+		Encryption  *experimental.ContentEncryption
+		Expectation Expectation
+	}{
+		{
+			Name: "disabled",
+		},
+		{
+			Name: "local keys",
+			Encryption: &experimental.ContentEncryption{
+				Local: []experimental.OrganizationKeys{
+					{Organization: "org-a", Secret: "keys-a"},
+					{Organization: "org-b", Secret: "keys-b"},
+				},
+			},
+			Expectation: Expectation{
+				Config: cntntcfg.EncryptionConfig{
+					Provider: cntntcfg.EncryptionProviderLocal,
+					Local:    &cntntcfg.LocalKeysConfig{KeysDir: "/mnt/encryption-keys"},
+				},
+				Mounts: map[string]string{
+					"keys-a": "/mnt/encryption-keys/org-a",
+					"keys-b": "/mnt/encryption-keys/org-b",
+				},
+			},
+		},
+		{
+			Name: "gcp kms",
+			Encryption: &experimental.ContentEncryption{
+				GCPKMS: &experimental.ContentEncryptionGCPKMS{
+					CredentialsSecret: "kms-creds",
+					Keys:              map[string]string{"org-a": "projects/p/locations/l/keyRings/r/cryptoKeys/k"},
+				},
+			},
+			Expectation: Expectation{
+				Config: cntntcfg.EncryptionConfig{
+					Provider: cntntcfg.EncryptionProviderGCPKMS,
+					GCPKMS: &cntntcfg.GCPKMSConfig{
+						CredentialsFile: "/mnt/encryption-kms/credentials.json",
+						Keys:            map[string]string{"org-a": "projects/p/locations/l/keyRings/r/cryptoKeys/k"},
+					},
+				},
+				Mounts: map[string]string{
+					"kms-creds": "/mnt/encryption-kms",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := renderContextWithWorkspaceConfig(t, &experimental.WorkspaceConfig{ContentEncryption: test.Encryption})
+
+			cfg, err := daemonConfig(ctx)
+			require.NoError(t, err)
+			require.Equal(t, test.Expectation.Config, cfg.Daemon.Content.Encryption)
+
+			volumes, volumeMounts := encryptionVolumes(ctx)
+			secrets := make(map[string]string)
+			for _, v := range volumes {
+				secrets[v.Name] = v.Secret.SecretName
+			}
+			mounts := make(map[string]string)
+			for _, m := range volumeMounts {
+				// keys are read whenever they're used, hence they must not be mounted using a subPath which
+				// would prevent kubelet from updating them when a key is added to the secret
+				require.Empty(t, m.SubPath)
+				require.True(t, m.ReadOnly, "keys must be mounted read-only")
+				mounts[secrets[m.Name]] = m.MountPath
+			}
+			if test.Expectation.Mounts == nil {
+				test.Expectation.Mounts = map[string]string{}
+			}
+			require.Equal(t, test.Expectation.Mounts, mounts)
+		})
+	}
+}
@@ -15,6 +15,8 @@ import (
 )
 
 func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
+	duration, renewBefore := common.InternalCertRotation(ctx)
+
 	return []runtime.Object{
 		&certmanagerv1.Certificate{
 			TypeMeta: common.TypeMetaCertificate,
@@ -24,8 +26,9 @@ func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
 				Labels:    common.DefaultLabels(Component),
 			},
 			Spec: certmanagerv1.CertificateSpec{
-				Duration:   common.InternalCertDuration,
-				SecretName: TLSSecretName,
+				Duration:    duration,
+				RenewBefore: renewBefore,
+				SecretName:  TLSSecretName,
 				DNSNames: []string{
 					fmt.Sprintf("gitpod.%s", ctx.Namespace),
 					fmt.Sprintf("%s.%s.svc", Component, ctx.Namespace),
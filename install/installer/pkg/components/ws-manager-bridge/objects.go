@@ -22,6 +22,9 @@ var Objects = common.CompositeRenderFunc(
 func WSManagerList(ctx *common.RenderContext) []WorkspaceCluster {
 	skipSelf := false
 	wsmanagerAddr := fmt.Sprintf("dns:///%s:%d", wsmanagermk2.Component, wsmanagermk2.RPCPort)
+	if ctx.HasSplitNamespaces() {
+		wsmanagerAddr = fmt.Sprintf("dns:///%s.%s.svc:%d", wsmanagermk2.Component, ctx.WorkspaceNamespace(), wsmanagermk2.RPCPort)
+	}
 	_ = ctx.WithExperimental(func(cfg *experimental.Config) error {
 		if cfg.WebApp != nil && cfg.WebApp.WorkspaceManagerBridge != nil {
 			skipSelf = cfg.WebApp.WorkspaceManagerBridge.SkipSelf
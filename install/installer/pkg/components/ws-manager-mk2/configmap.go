@@ -85,6 +85,7 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 	hostWorkingArea := wsdaemon.HostWorkingAreaMk2
 
 	rateLimits := map[string]grpc.RateLimit{}
+	var workspaceProxies []string
 
 	err = ctx.WithExperimental(func(ucfg *experimental.Config) error {
 		if ucfg.Workspace == nil {
@@ -111,8 +112,9 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 					},
 					Limits: &config.ResourceLimitConfiguration{
 						CPU: &config.CpuResourceLimit{
-							MinLimit:   c.Resources.Limits.Cpu.MinLimit,
-							BurstLimit: c.Resources.Limits.Cpu.BurstLimit,
+							MinLimit:    c.Resources.Limits.Cpu.MinLimit,
+							BurstLimit:  c.Resources.Limits.Cpu.BurstLimit,
+							BurstBudget: c.Resources.Limits.Cpu.BurstBudget,
 						},
 						Memory:           c.Resources.Limits.Memory,
 						EphemeralStorage: c.Resources.Limits.EphemeralStorage,
@@ -151,6 +153,7 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 			workspacePortURLTemplate = ucfg.Workspace.WorkspacePortURLTemplate
 		}
 		rateLimits = ucfg.Workspace.WSManagerRateLimits
+		workspaceProxies = ucfg.Workspace.WorkspaceProxies
 
 		return nil
 	})
@@ -201,6 +204,7 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 			HeartbeatInterval:       util.Duration(30 * time.Second),
 			GitpodHostURL:           gitpodHostURL,
 			WorkspaceClusterHost:    workspaceClusterHost,
+			WorkspaceProxies:        workspaceProxies,
 			InitProbe: config.InitProbeConfiguration{
 				Timeout: (1 * time.Second).String(),
 			},
@@ -274,6 +278,15 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		wsmcfg.Manager.EnableCustomSSLCertificate = true
 	}
 
+	if admissionWebhooksEnabled(ctx) {
+		wsmcfg.Webhook.Enabled = true
+		wsmcfg.Webhook.CertDir = webhookCertDir
+	}
+
+	if ctx.Config.Workspace.DiskPressureEvictionTimeout != nil {
+		wsmcfg.Manager.DiskPressureEvictionTimeout = *ctx.Config.Workspace.DiskPressureEvictionTimeout
+	}
+
 	if ctx.Config.SSHGatewayCAKey != nil {
 		wsmcfg.Manager.SSHGatewayCAPublicKeyFile = "/mnt/ca-key/ca.pem"
 	}
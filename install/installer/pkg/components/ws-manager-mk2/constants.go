@@ -11,6 +11,9 @@ const (
 	RPCPort                    = 8080
 	RPCPortName                = "rpc"
 	HealthPort                 = 9090
+	WebhookPort                = 9443
+	WebhookPortName            = "webhook"
+	WebhookServicePort         = 443
 	TLSSecretNameSecret        = "ws-manager-mk2-tls"
 	TLSSecretNameClient        = "ws-manager-mk2-client-tls"
 	VolumeConfig               = "config"
@@ -180,6 +180,13 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 		}, volumes...),
 	}
 
+	if admissionWebhooksEnabled(ctx) {
+		podSpec.Containers[0].Ports = append(podSpec.Containers[0].Ports, corev1.ContainerPort{
+			Name:          WebhookPortName,
+			ContainerPort: WebhookPort,
+		})
+	}
+
 	err = common.AddStorageMounts(ctx, &podSpec, Component)
 	if err != nil {
 		return nil, err
@@ -192,6 +199,9 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 				Name:      Component,
 				Namespace: ctx.Namespace,
 				Labels:    labels,
+				Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment, func() map[string]string {
+					return common.InternalCertRestartAnnotations(ctx, TLSSecretNameSecret, wsdaemon.TLSSecretName, common.ImageBuilderTLSSecret)
+				}),
 			},
 			Spec: appsv1.DeploymentSpec{
 				Selector: &metav1.LabelSelector{MatchLabels: labels},
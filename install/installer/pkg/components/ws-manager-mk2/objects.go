@@ -26,8 +26,14 @@ var Objects common.RenderFunc = func(cfg *common.RenderContext) ([]runtime.Objec
 				ContainerPort: RPCPort,
 				ServicePort:   RPCPort,
 			},
+			{
+				Name:          WebhookPortName,
+				ContainerPort: WebhookPort,
+				ServicePort:   WebhookServicePort,
+			},
 		}),
 		tlssecret,
 		unprivilegedRolebinding,
+		webhooks,
 	)(cfg)
 }
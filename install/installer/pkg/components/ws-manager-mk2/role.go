@@ -84,6 +84,19 @@ var controllerRules = []rbacv1.PolicyRule{
 			"delete",
 			"get",
 			"list",
+			"update",
+			"watch",
+		},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"services"},
+		Verbs: []string{
+			"create",
+			"delete",
+			"get",
+			"list",
+			"update",
 			"watch",
 		},
 	},
@@ -100,6 +113,39 @@ var controllerRules = []rbacv1.PolicyRule{
 			"watch",
 		},
 	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"persistentvolumeclaims"},
+		Verbs: []string{
+			"create",
+			"delete",
+			"get",
+			"list",
+			"watch",
+		},
+	},
+	{
+		APIGroups: []string{"networking.k8s.io"},
+		Resources: []string{"networkpolicies"},
+		Verbs: []string{
+			"create",
+			"delete",
+			"get",
+			"list",
+			"watch",
+		},
+	},
+	{
+		APIGroups: []string{"snapshot.storage.k8s.io"},
+		Resources: []string{"volumesnapshots"},
+		Verbs: []string{
+			"create",
+			"delete",
+			"get",
+			"list",
+			"watch",
+		},
+	},
 }
 
 var controllerClusterRules = []rbacv1.PolicyRule{
@@ -16,6 +16,8 @@ import (
 )
 
 func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
+	duration, renewBefore := common.InternalCertRotation(ctx)
+
 	serverAltNames := []string{
 		fmt.Sprintf("gitpod.%s", ctx.Namespace),
 		fmt.Sprintf("%s.%s.svc", Component, ctx.Namespace),
@@ -36,36 +38,41 @@ func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
 
 	issuer := common.CertManagerCAIssuer
 
-	return []runtime.Object{
-		&certmanagerv1.Certificate{
+	clientCertificate := func(namespace string) *certmanagerv1.Certificate {
+		return &certmanagerv1.Certificate{
 			TypeMeta: common.TypeMetaCertificate,
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      TLSSecretNameSecret,
-				Namespace: ctx.Namespace,
+				Name:      Component,
+				Namespace: namespace,
 				Labels:    common.DefaultLabels(Component),
 			},
 			Spec: certmanagerv1.CertificateSpec{
-				Duration:   common.InternalCertDuration,
-				SecretName: TLSSecretNameSecret,
-				DNSNames:   serverAltNames,
+				Duration:    duration,
+				RenewBefore: renewBefore,
+				SecretName:  TLSSecretNameClient,
+				DNSNames:    clientAltNames,
 				IssuerRef: cmmeta.ObjectReference{
 					Name:  issuer,
 					Kind:  certmanagerv1.ClusterIssuerKind,
 					Group: "cert-manager.io",
 				},
 			},
-		},
+		}
+	}
+
+	objs := []runtime.Object{
 		&certmanagerv1.Certificate{
 			TypeMeta: common.TypeMetaCertificate,
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      Component,
+				Name:      TLSSecretNameSecret,
 				Namespace: ctx.Namespace,
 				Labels:    common.DefaultLabels(Component),
 			},
 			Spec: certmanagerv1.CertificateSpec{
-				Duration:   common.InternalCertDuration,
-				SecretName: TLSSecretNameClient,
-				DNSNames:   clientAltNames,
+				Duration:    duration,
+				RenewBefore: renewBefore,
+				SecretName:  TLSSecretNameSecret,
+				DNSNames:    serverAltNames,
 				IssuerRef: cmmeta.ObjectReference{
 					Name:  issuer,
 					Kind:  certmanagerv1.ClusterIssuerKind,
@@ -73,5 +80,13 @@ func tlssecret(ctx *common.RenderContext) ([]runtime.Object, error) {
 				},
 			},
 		},
-	}, nil
+		clientCertificate(ctx.Namespace),
+	}
+
+	if ctx.HasSplitNamespaces() {
+		// server and ws-manager-bridge live in the meta namespace and need their own copy of the client certificate
+		objs = append(objs, clientCertificate(ctx.MetaNamespace()))
+	}
+
+	return objs, nil
 }
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package wsmanagermk2
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	certmanagerv1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	wsdaemon "github.com/gitpod-io/gitpod/installer/pkg/components/ws-daemon"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+)
+
+func TestInternalCertificateRotation(t *testing.T) {
+	duration := util.Duration(30 * 24 * time.Hour)
+	renewBefore := util.Duration(10 * 24 * time.Hour)
+
+	ctx, err := common.NewRenderContext(config.Config{
+		Domain: "example.com",
+		ObjectStorage: config.ObjectStorage{
+			InCluster: pointer.Bool(true),
+		},
+		InternalCertificates: &config.InternalCertificates{
+			Duration:          &duration,
+			RenewBefore:       &renewBefore,
+			RestartOnRotation: true,
+		},
+	}, versions.Manifest{
+		Components: versions.Components{
+			WSManagerMk2: versions.Versioned{
+				Version: "commit-test-latest",
+			},
+		},
+	}, "test_namespace")
+	require.NoError(t, err)
+
+	certs, err := tlssecret(ctx)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	for _, obj := range certs {
+		cert, ok := obj.(*certmanagerv1.Certificate)
+		require.Truef(t, ok, "tlssecret function did not return a certificate")
+		require.NotNil(t, cert.Spec.Duration)
+		require.Equal(t, time.Duration(duration), cert.Spec.Duration.Duration)
+		require.NotNil(t, cert.Spec.RenewBefore)
+		require.Equal(t, time.Duration(renewBefore), cert.Spec.RenewBefore.Duration)
+	}
+
+	objs, err := deployment(ctx)
+	require.NoError(t, err)
+	dpl, ok := objs[0].(*appsv1.Deployment)
+	require.Truef(t, ok, "deployment function did not return a deployment")
+	require.Equal(t,
+		strings.Join([]string{TLSSecretNameSecret, wsdaemon.TLSSecretName, common.ImageBuilderTLSSecret}, ","),
+		dpl.Annotations[common.AnnotationReloadOnSecretChange],
+	)
+}
+
+func TestClientCertificateInMetaNamespace(t *testing.T) {
+	ctx, err := common.NewRenderContext(config.Config{
+		Domain:             "example.com",
+		WorkspaceNamespace: "workspaces",
+	}, versions.Manifest{}, "test_namespace")
+	require.NoError(t, err)
+
+	certs, err := common.InWorkspaceNamespace(tlssecret)(ctx)
+	require.NoError(t, err)
+
+	namespaces := map[string][]string{}
+	for _, obj := range certs {
+		cert, ok := obj.(*certmanagerv1.Certificate)
+		require.Truef(t, ok, "tlssecret function did not return a certificate")
+		namespaces[cert.Spec.SecretName] = append(namespaces[cert.Spec.SecretName], cert.Namespace)
+	}
+	require.Equal(t, map[string][]string{
+		TLSSecretNameSecret: {"workspaces"},
+		TLSSecretNameClient: {"workspaces", "test_namespace"},
+	}, namespaces)
+}
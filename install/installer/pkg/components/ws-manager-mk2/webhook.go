@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package wsmanagermk2
+
+import (
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+)
+
+const (
+	// webhookCertDir is where the webhook server finds its serving certificate. The webhooks are served
+	// with the certificate of the RPC server, which is valid for the ws-manager-mk2 service.
+	webhookCertDir = "/certs"
+
+	validatingWebhookPath = "/validate-workspace-gitpod-io-v1-workspace"
+	mutatingWebhookPath   = "/mutate-workspace-gitpod-io-v1-workspace"
+)
+
+// admissionWebhooksEnabled returns true if ws-manager-mk2 serves the admission webhooks for workspaces
+func admissionWebhooksEnabled(ctx *common.RenderContext) bool {
+	var enabled bool
+	_ = ctx.WithExperimental(func(ucfg *experimental.Config) error {
+		enabled = ucfg.Workspace != nil && ucfg.Workspace.EnableAdmissionWebhooks
+		return nil
+	})
+	return enabled
+}
+
+func webhooks(ctx *common.RenderContext) ([]runtime.Object, error) {
+	if !admissionWebhooksEnabled(ctx) {
+		return nil, nil
+	}
+
+	return []runtime.Object{
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			TypeMeta: common.TypeMetaValidatingWebhookConfiguration,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-%s", Component, ctx.Namespace),
+				Labels:      common.DefaultLabels(Component),
+				Annotations: webhookAnnotations(ctx),
+			},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+				Name:                    "vworkspace.kb.io",
+				ClientConfig:            webhookClientConfig(ctx, validatingWebhookPath),
+				Rules:                   webhookRules(),
+				NamespaceSelector:       webhookNamespaceSelector(ctx),
+				FailurePolicy:           failurePolicy(admissionregistrationv1.Fail),
+				SideEffects:             sideEffects(admissionregistrationv1.SideEffectClassNone),
+				AdmissionReviewVersions: []string{"v1"},
+			}},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			TypeMeta: common.TypeMetaMutatingWebhookConfiguration,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-%s", Component, ctx.Namespace),
+				Labels:      common.DefaultLabels(Component),
+				Annotations: webhookAnnotations(ctx),
+			},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name:                    "mworkspace.kb.io",
+				ClientConfig:            webhookClientConfig(ctx, mutatingWebhookPath),
+				Rules:                   webhookRules(),
+				NamespaceSelector:       webhookNamespaceSelector(ctx),
+				FailurePolicy:           failurePolicy(admissionregistrationv1.Fail),
+				SideEffects:             sideEffects(admissionregistrationv1.SideEffectClassNone),
+				AdmissionReviewVersions: []string{"v1"},
+			}},
+		},
+	}, nil
+}
+
+// webhookAnnotations make cert-manager inject the CA of the webhook's serving certificate
+func webhookAnnotations(ctx *common.RenderContext) map[string]string {
+	return map[string]string{
+		"cert-manager.io/inject-ca-from": fmt.Sprintf("%s/%s", ctx.Namespace, TLSSecretNameSecret),
+	}
+}
+
+func webhookClientConfig(ctx *common.RenderContext, path string) admissionregistrationv1.WebhookClientConfig {
+	return admissionregistrationv1.WebhookClientConfig{
+		Service: &admissionregistrationv1.ServiceReference{
+			Namespace: ctx.Namespace,
+			Name:      Component,
+			Path:      pointer.String(path),
+			Port:      pointer.Int32(WebhookServicePort),
+		},
+	}
+}
+
+func webhookRules() []admissionregistrationv1.RuleWithOperations {
+	return []admissionregistrationv1.RuleWithOperations{{
+		Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+		Rule: admissionregistrationv1.Rule{
+			APIGroups:   []string{"workspace.gitpod.io"},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"workspaces"},
+		},
+	}}
+}
+
+// webhookNamespaceSelector restricts the webhooks to the workspaces this installation manages
+func webhookNamespaceSelector(ctx *common.RenderContext) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{"kubernetes.io/metadata.name": ctx.Namespace},
+	}
+}
+
+func failurePolicy(p admissionregistrationv1.FailurePolicyType) *admissionregistrationv1.FailurePolicyType {
+	return &p
+}
+
+func sideEffects(s admissionregistrationv1.SideEffectClass) *admissionregistrationv1.SideEffectClass {
+	return &s
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package wsmanagermk2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+	wsmancfg "github.com/gitpod-io/gitpod/ws-manager/api/config"
+)
+
+func TestAdmissionWebhooks(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		ctx, err := common.NewRenderContext(config.Config{
+			Domain: "example.com",
+			ObjectStorage: config.ObjectStorage{
+				InCluster: pointer.Bool(true),
+			},
+			Experimental: &experimental.Config{
+				Workspace: &experimental.WorkspaceConfig{
+					EnableAdmissionWebhooks: enabled,
+				},
+			},
+		}, versions.Manifest{
+			Components: versions.Components{
+				WSManagerMk2: versions.Versioned{
+					Version: "commit-test-latest",
+				},
+			},
+		}, "test_namespace")
+		require.NoError(t, err)
+
+		objs, err := webhooks(ctx)
+		require.NoError(t, err)
+		if !enabled {
+			require.Empty(t, objs)
+		} else {
+			require.NotEmpty(t, objs)
+			vwc, ok := objs[0].(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			require.Truef(t, ok, "webhooks function did not return a validating webhook configuration")
+			require.Equal(t, "test_namespace/"+TLSSecretNameSecret, vwc.Annotations["cert-manager.io/inject-ca-from"])
+			require.Equal(t, Component, vwc.Webhooks[0].ClientConfig.Service.Name)
+			mwc, ok := objs[1].(*admissionregistrationv1.MutatingWebhookConfiguration)
+			require.Truef(t, ok, "webhooks function did not return a mutating webhook configuration")
+			require.Equal(t, mutatingWebhookPath, *mwc.Webhooks[0].ClientConfig.Service.Path)
+		}
+
+		objs, err = configmap(ctx)
+		require.NoError(t, err)
+		var serviceConfig wsmancfg.ServiceConfiguration
+		require.NoError(t, json.Unmarshal([]byte(objs[0].(*corev1.ConfigMap).Data["config.json"]), &serviceConfig))
+		require.Equal(t, enabled, serviceConfig.Webhook.Enabled)
+
+		objs, err = deployment(ctx)
+		require.NoError(t, err)
+		var hasPort bool
+		for _, p := range objs[0].(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Ports {
+			hasPort = hasPort || p.ContainerPort == WebhookPort
+		}
+		require.Equal(t, enabled, hasPort)
+	}
+}
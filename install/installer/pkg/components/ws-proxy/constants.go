@@ -20,5 +20,6 @@ const (
 	SSHServicePort       = 22
 	SSHTargetPort        = 2200
 	SSHPortName          = "ssh"
+	SSHServiceName       = Component + "-ssh"
 	ReadinessPort        = 8086
 )
@@ -10,6 +10,7 @@ import (
 	"github.com/gitpod-io/gitpod/installer/pkg/common"
 
 	wsmanagermk2 "github.com/gitpod-io/gitpod/installer/pkg/components/ws-manager-mk2"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -46,12 +47,20 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 			MountPath: "/mnt/certificates"},
 	}
 
-	if ctx.Config.SSHGatewayHostKey != nil {
+	sshPort := corev1.ContainerPort{
+		Name:          SSHPortName,
+		ContainerPort: SSHTargetPort,
+	}
+	if hostKey := ctx.Config.SSHGatewayHostKeySecret(); hostKey != nil {
+		if common.SSHGatewayExposure(ctx) == config.SSHGatewayExposureHostPort {
+			sshPort.HostPort = common.SSHGatewayPort(ctx)
+		}
+
 		volumes = append(volumes, corev1.Volume{
 			Name: "host-key",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: ctx.Config.SSHGatewayHostKey.Name,
+					SecretName: hostKey.Name,
 				},
 			},
 		})
@@ -130,10 +139,7 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 			}, {
 				Name:          baseserver.BuiltinMetricsPortName,
 				ContainerPort: baseserver.BuiltinMetricsPort,
-			}, {
-				Name:          SSHPortName,
-				ContainerPort: SSHServicePort,
-			}},
+			}, sshPort},
 			SecurityContext: &corev1.SecurityContext{
 				Privileged:               pointer.Bool(false),
 				AllowPrivilegeEscalation: pointer.Bool(false),
@@ -189,10 +195,12 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 		&appsv1.Deployment{
 			TypeMeta: common.TypeMetaDeployment,
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        Component,
-				Namespace:   ctx.Namespace,
-				Labels:      labels,
-				Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment),
+				Name:      Component,
+				Namespace: ctx.Namespace,
+				Labels:    labels,
+				Annotations: common.CustomizeAnnotation(ctx, Component, common.TypeMetaDeployment, func() map[string]string {
+					return common.InternalCertRestartAnnotations(ctx, wsmanagermk2.TLSSecretNameClient)
+				}),
 			},
 			Spec: appsv1.DeploymentSpec{
 				Selector: &metav1.LabelSelector{MatchLabels: common.DefaultLabels(Component)},
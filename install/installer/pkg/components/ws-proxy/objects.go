@@ -42,5 +42,6 @@ var Objects = common.CompositeRenderFunc(
 		}
 		return common.GenerateService(Component, ports)(cfg)
 	},
+	sshService,
 	common.DefaultServiceAccount(Component),
 )
@@ -0,0 +1,33 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package wsproxy
+
+import (
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// sshService exposes the SSH gateway through a dedicated LoadBalancer, bypassing the proxy
+func sshService(ctx *common.RenderContext) ([]runtime.Object, error) {
+	if ctx.Config.SSHGatewayHostKeySecret() == nil || common.SSHGatewayExposure(ctx) != config.SSHGatewayExposureLoadBalancer {
+		return nil, nil
+	}
+
+	ports := []common.ServicePort{
+		{
+			Name:          SSHPortName,
+			ContainerPort: SSHTargetPort,
+			ServicePort:   common.SSHGatewayPort(ctx),
+		},
+	}
+
+	return common.GenerateService(Component, ports, func(service *corev1.Service) {
+		service.Name = SSHServiceName
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	})(ctx)
+}
@@ -74,6 +74,7 @@ func (v version) Defaults(in interface{}) error {
 		corev1.ResourceMemory: resource.MustParse("2Gi"),
 	}
 	cfg.Workspace.Runtime.FSShiftMethod = FSShiftShiftFS
+	cfg.Workspace.Runtime.CGroupVersion = CGroupV2
 	cfg.Workspace.Runtime.ContainerDSocketDir = containerd.ContainerdSocketLocationDefault.String()
 	cfg.Workspace.Runtime.ContainerDRuntimeDir = containerd.ContainerdLocationDefault.String()
 	cfg.Workspace.MaxLifetime = util.Duration(36 * time.Hour)
@@ -114,6 +115,9 @@ func (v version) CheckDeprecated(rawCfg interface{}) (map[string]interface{}, []
 type Config struct {
 	// Installation type to run - for most users, this will be Full
 	Kind InstallationKind `json:"kind" validate:"required,installation_kind"`
+	// Namespace to install the workspace components (ws-manager, ws-daemon, registry-facade, workspaces...) into.
+	// Defaults to the installation namespace. Secrets referenced by workspace components must exist in this namespace.
+	WorkspaceNamespace string `json:"workspaceNamespace,omitempty" validate:"omitempty,namespace_name"`
 	// The domain to deploy to
 	Domain     string   `json:"domain" validate:"required,fqdn"`
 	Metadata   Metadata `json:"metadata"`
@@ -141,7 +145,10 @@ type Config struct {
 	AuthProviders []ObjectRef   `json:"authProviders" validate:"dive"`
 	BlockNewUsers BlockNewUsers `json:"blockNewUsers"`
 
-	SSHGatewayHostKey *ObjectRef `json:"sshGatewayHostKey,omitempty"`
+	SSHGatewayHostKey *ObjectRef `json:"sshGatewayHostKey,omitempty" validate:"excluded_with=SSHGateway"`
+
+	// SSHGateway configures SSH access to workspaces through ws-proxy. It supersedes sshGatewayHostKey.
+	SSHGateway *SSHGateway `json:"sshGateway,omitempty"`
 
 	SSHGatewayCAKey *ObjectRef `json:"sshGatewayCAKey,omitempty"`
 
@@ -149,6 +156,8 @@ type Config struct {
 
 	CustomCACert *ObjectRef `json:"customCACert,omitempty"`
 
+	InternalCertificates *InternalCertificates `json:"internalCertificates,omitempty"`
+
 	DropImageRepo *bool `json:"dropImageRepo,omitempty"`
 
 	Customization *[]Customization `json:"customization,omitempty"`
@@ -212,6 +221,7 @@ type ObjectStorage struct {
 	InCluster    *bool                      `json:"inCluster,omitempty"`
 	S3           *ObjectStorageS3           `json:"s3,omitempty"`
 	CloudStorage *ObjectStorageCloudStorage `json:"cloudStorage,omitempty"`
+	Azure        *ObjectStorageAzure        `json:"azure,omitempty"`
 	// DEPRECATED
 	MaximumBackupCount *int       `json:"maximumBackupCount,omitempty"`
 	BlobQuota          *int64     `json:"blobQuota,omitempty"`
@@ -232,6 +242,13 @@ type ObjectStorageCloudStorage struct {
 	Project        string    `json:"project" validate:"required"`
 }
 
+type ObjectStorageAzure struct {
+	AccountName string    `json:"accountName" validate:"required"`
+	Credentials ObjectRef `json:"credentials" validate:"required"`
+	// Endpoint is the blob service endpoint of the storage account, which only needs to be set for national clouds
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
 type InstallationKind string
 
 const (
@@ -277,6 +294,23 @@ type S3Storage struct {
 	Certificate *ObjectRef `json:"certificate,omitempty"`
 }
 
+// InternalCertificates configures the rotation of certificates issued by the internal CA,
+// e.g. those used for gRPC between the workspace components
+type InternalCertificates struct {
+	// Duration is the lifetime of an internal certificate. Defaults to 90 days, must be at least 1h.
+	Duration *util.Duration `json:"duration,omitempty" validate:"omitempty,duration_min=1h"`
+	// RenewBefore is the time before expiry at which cert-manager renews a certificate.
+	// Must be positive and shorter than Duration.
+	RenewBefore *util.Duration `json:"renewBefore,omitempty"`
+	// RestartOnRotation annotates the consumers of internal certificates so that they're restarted
+	// once a certificate was renewed.
+	//
+	// Requires Reloader (https://github.com/stakater/Reloader) to be installed in the cluster.
+	// The installer neither installs Reloader nor checks for it - without it nothing gets restarted,
+	// and consumers keep serving the old certificate until they're restarted by other means.
+	RestartOnRotation bool `json:"restartOnRotation,omitempty"`
+}
+
 type ServiceAnnotations map[string]string
 
 type LogLevel string
@@ -305,6 +339,8 @@ type WorkspaceRuntime struct {
 	ContainerDRuntimeDir string `json:"containerdRuntimeDir" validate:"required,startswith=/"`
 	// The location of containerd socket on the host machine
 	ContainerDSocketDir string `json:"containerdSocketDir" validate:"required,startswith=/"`
+	// The cgroup hierarchy the workspace nodes run. Only cgroup v2 is supported by ws-daemon.
+	CGroupVersion CGroupVersion `json:"cgroupVersion,omitempty" validate:"omitempty,cgroup_version"`
 }
 
 type WorkspaceResources struct {
@@ -349,6 +385,11 @@ type Workspace struct {
 	// TimeoutAfterClose is the time a workspace timed out after it has been closed (“closed” means that it does not get a heartbeat from an IDE anymore)
 	TimeoutAfterClose *util.Duration `json:"timeoutAfterClose,omitempty"`
 
+	// DiskPressureEvictionTimeout is the time a workspace tolerates disk pressure on its node before it is evicted.
+	// Together with ephemeral-storage requests/limits this bounds workspaces which fill up the node's disk.
+	// If unset, workspaces tolerate disk pressure indefinitely. Must be at least 1s.
+	DiskPressureEvictionTimeout *util.Duration `json:"diskPressureEvictionTimeout,omitempty" validate:"omitempty,duration_min=1s"`
+
 	WorkspaceImage string `json:"workspaceImage,omitempty"`
 }
 
@@ -366,6 +407,45 @@ type Proxy struct {
 	ServiceAnnotations ServiceAnnotations `json:"serviceAnnotations"`
 }
 
+type SSHGatewayExposure string
+
+const (
+	// SSHGatewayExposureProxy routes SSH through the proxy service alongside HTTPS
+	SSHGatewayExposureProxy SSHGatewayExposure = "Proxy"
+	// SSHGatewayExposureLoadBalancer gives ws-proxy a dedicated LoadBalancer service for SSH
+	SSHGatewayExposureLoadBalancer SSHGatewayExposure = "LoadBalancer"
+	// SSHGatewayExposureHostPort binds the SSH gateway to a port on the nodes running ws-proxy
+	SSHGatewayExposureHostPort SSHGatewayExposure = "HostPort"
+)
+
+type SSHGateway struct {
+	Enabled bool `json:"enabled"`
+	// Secret containing the host keys of the gateway
+	HostKey *ObjectRef `json:"hostKey,omitempty" validate:"required_if=Enabled true"`
+	// Port clients connect to. Defaults to 22.
+	Port int32 `json:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+	// How the gateway is reachable from outside the cluster. Defaults to Proxy.
+	Exposure SSHGatewayExposure `json:"exposure,omitempty" validate:"omitempty,ssh_gateway_exposure"`
+}
+
+// SSHGatewayHostKeySecret returns the secret holding the SSH gateway host keys, or nil if SSH access is disabled
+func (cfg *Config) SSHGatewayHostKeySecret() *ObjectRef {
+	if cfg.SSHGateway != nil {
+		if !cfg.SSHGateway.Enabled {
+			return nil
+		}
+		return cfg.SSHGateway.HostKey
+	}
+	return cfg.SSHGatewayHostKey
+}
+
+type CGroupVersion string
+
+const (
+	CGroupV1 CGroupVersion = "v1"
+	CGroupV2 CGroupVersion = "v2"
+)
+
 type FSShiftMethod string
 
 const (
@@ -409,6 +489,19 @@ type Components struct {
 	IDE        *IDEComponents        `json:"ide"`
 	PodConfig  map[string]*PodConfig `json:"podConfig,omitempty"`
 	Proxy      *ProxyComponent       `json:"proxy,omitempty"`
+	// Disabled allows optional components to be left out of the installation
+	Disabled *DisabledComponents `json:"disabled,omitempty"`
+}
+
+// DisabledComponents lists the optional components that may be switched off to reduce
+// the footprint of an installation. Components depending on them fall back to running
+// without them. The builtin container registry is controlled by containerRegistry.inCluster.
+type DisabledComponents struct {
+	PublicAPIServer bool `json:"publicApiServer,omitempty"`
+	Usage           bool `json:"usage,omitempty"`
+	IDEMetrics      bool `json:"ideMetrics,omitempty"`
+	// OpenVSXProxy makes clients talk to openVSX.url directly instead of through the caching proxy
+	OpenVSXProxy bool `json:"openVSXProxy,omitempty"`
 }
 
 type IDEComponents struct {
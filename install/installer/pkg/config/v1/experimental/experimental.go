@@ -15,6 +15,7 @@ import (
 
 	agentSmith "github.com/gitpod-io/gitpod/agent-smith/pkg/config"
 	"github.com/gitpod-io/gitpod/common-go/grpc"
+	"github.com/gitpod-io/gitpod/common-go/util"
 	db "github.com/gitpod-io/gitpod/components/gitpod-db/go"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cpulimit"
 	corev1 "k8s.io/api/core/v1"
@@ -86,6 +87,13 @@ type WorkspaceConfig struct {
 
 	WSManagerRateLimits map[string]grpc.RateLimit `json:"wsManagerRateLimits,omitempty"`
 
+	// EnableAdmissionWebhooks makes ws-manager-mk2 validate and default workspaces when they're created or updated
+	EnableAdmissionWebhooks bool `json:"enableAdmissionWebhooks,omitempty"`
+
+	// WorkspaceProxies are the addresses or networks of the proxies in front of workspaces, e.g. the pod network of
+	// ws-proxy. Supervisor trusts their X-Forwarded-For header to tell the address of clients.
+	WorkspaceProxies []string `json:"workspaceProxies,omitempty"`
+
 	RegistryFacade struct {
 		IPFSCache struct {
 			Enabled  bool   `json:"enabled"`
@@ -105,6 +113,14 @@ type WorkspaceConfig struct {
 		Runtime struct {
 			NodeToContainerMapping []NodeToContainerMappingValues `json:"nodeToContainerMapping"`
 		} `json:"runtime"`
+
+		// PrebuildCache keeps prebuild archives on the node, such that workspaces which start from the same
+		// prebuild on a node download it only once
+		PrebuildCache struct {
+			Enabled bool `json:"enabled"`
+			// MaxSize is the disk space of the node the cache uses at most, defaults to 20Gi
+			MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+		} `json:"prebuildCache"`
 	} `json:"wsDaemon"`
 
 	WorkspaceClasses        map[string]WorkspaceClass `json:"classes,omitempty"`
@@ -121,16 +137,68 @@ type WorkspaceConfig struct {
 	ContentService struct {
 		// Deprecated
 		UsageReportBucketName string `json:"usageReportBucketName"`
+		// DownloadURLTTL is how long presigned download URLs of workspace content remain valid. Defaults to 1h.
+		DownloadURLTTL *util.Duration `json:"downloadURLTTL,omitempty"`
+		// UploadURLTTL is how long presigned upload URLs remain valid. Defaults to 30m.
+		UploadURLTTL *util.Duration `json:"uploadURLTTL,omitempty"`
+		// MaxURLTTL bounds the lifetime of refreshed URLs. Defaults to 7 days.
+		MaxURLTTL *util.Duration `json:"maxURLTTL,omitempty"`
 	} `json:"contentService"`
 
+	// ContentEncryption encrypts the backups and snapshots of workspaces with the key of their organization
+	ContentEncryption *ContentEncryption `json:"contentEncryption,omitempty"`
+
 	EnableProtectedSecrets *bool `json:"enableProtectedSecrets"`
 
 	ImageBuilderMk3 struct {
 		BaseImageRepositoryName      string `json:"baseImageRepositoryName"`
 		WorkspaceImageRepositoryName string `json:"workspaceImageRepositoryName"`
+		// EnableBuildCache makes image builds of a project reuse the layers of its previous builds
+		EnableBuildCache bool `json:"enableBuildCache,omitempty"`
+		// BuildCacheRepositoryName is the repository the build cache is pushed to. Defaults to the base image repository.
+		BuildCacheRepositoryName string `json:"buildCacheRepositoryName,omitempty"`
+		// MaxConcurrentBuilds limits the image builds which run at the same time, zero means no limit
+		MaxConcurrentBuilds int `json:"maxConcurrentBuilds,omitempty"`
+		// MaxConcurrentBuildsPerOrganization limits the image builds of one organization which run at the same time, zero means no limit
+		MaxConcurrentBuildsPerOrganization int `json:"maxConcurrentBuildsPerOrganization,omitempty"`
+		// Platforms are the platforms workspace images are built for, e.g. linux/amd64 and linux/arm64. Defaults to linux/amd64.
+		Platforms []string `json:"platforms,omitempty"`
+		// BuildTimeout limits how long an image build may run. Defaults to 1h.
+		BuildTimeout *util.Duration `json:"buildTimeout,omitempty"`
+		// CredentialHelpers provide short-lived credentials for the registries of cloud providers, i.e. ecr, gcr or acr
+		CredentialHelpers []string `json:"credentialHelpers,omitempty"`
+		// CredentialHelperRepositories are the registries or repositories, besides the ones of the installation, users may use
+		// images of with the credentials of the credential helpers, e.g. eu.gcr.io/my-project
+		CredentialHelperRepositories []string `json:"credentialHelperRepositories,omitempty"`
 	} `json:"imageBuilderMk3"`
 }
 
+// ContentEncryption configures where the keys of organizations come from. Organizations without a key keep
+// their content unencrypted.
+type ContentEncryption struct {
+	// Local reads the keys of organizations from secrets
+	Local []OrganizationKeys `json:"local,omitempty" validate:"excluded_with=GCPKMS,dive"`
+	// GCPKMS uses keys held in Google Cloud KMS
+	GCPKMS *ContentEncryptionGCPKMS `json:"gcpKms,omitempty"`
+}
+
+// OrganizationKeys references the secret with the keys of an organization. Every <keyID>.key entry of the secret
+// holds a base64-encoded 256 bit key. New content is encrypted with the key whose ID sorts last, hence keys are
+// rotated by adding an entry. Keys must be kept for as long as content encrypted with them exists.
+type OrganizationKeys struct {
+	Organization string `json:"organization" validate:"required"`
+	Secret       string `json:"secret" validate:"required"`
+}
+
+type ContentEncryptionGCPKMS struct {
+	// CredentialsSecret holds the credentials.json of a service account which may use the keys.
+	// Defaults to the credentials of ws-daemon's environment.
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Keys maps organization IDs to the resource name of their key,
+	// i.e. projects/<project>/locations/<location>/keyRings/<keyRing>/cryptoKeys/<key>
+	Keys map[string]string `json:"keys" validate:"required"`
+}
+
 type WorkspaceClass struct {
 	Name        string             `json:"name" validate:"required"`
 	Description string             `json:"description"`
@@ -154,6 +222,8 @@ type WorkspaceCpuLimits struct {
 	Buckets    []cpulimit.Bucket `json:"buckets"`
 	MinLimit   string            `json:"min"`
 	BurstLimit string            `json:"burst"`
+	// BurstBudget is how long workspaces may run at their burst limit before they're throttled to their min limit
+	BurstBudget string `json:"burstBudget,omitempty"`
 }
 
 type WorkspaceTemplates struct {
@@ -50,6 +50,15 @@ func ClusterValidation(cfg *Config) cluster.ValidationChecks {
 		if cfg.Workspace.WorkspaceCIDR != "" {
 			res = append(res, cluster.CheckWorkspaceCIDR(cfg.Workspace.WorkspaceCIDR))
 		}
+
+		if enc := cfg.Workspace.ContentEncryption; enc != nil {
+			for _, keys := range enc.Local {
+				res = append(res, cluster.CheckSecret(keys.Secret))
+			}
+			if enc.GCPKMS != nil && enc.GCPKMS.CredentialsSecret != "" {
+				res = append(res, cluster.CheckSecret(enc.GCPKMS.CredentialsSecret, cluster.CheckSecretRequiredData("credentials.json")))
+			}
+		}
 	}
 
 	return res
@@ -8,14 +8,17 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/gitpod-io/gitpod/installer/pkg/cluster"
+	"github.com/gitpod-io/gitpod/installer/pkg/config"
 	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
 	"golang.org/x/crypto/ssh"
 	"sigs.k8s.io/yaml"
 
 	"github.com/go-playground/validator/v10"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/rest"
 )
 
@@ -45,6 +48,18 @@ var FSShiftMethodList = map[FSShiftMethod]struct{}{
 	FSShiftShiftFS: {},
 }
 
+// CGroupVersionList contains the cgroup versions ws-daemon supports. cgroup v1 is
+// deliberately absent so that installations on such nodes fail during validation.
+var CGroupVersionList = map[CGroupVersion]struct{}{
+	CGroupV2: {},
+}
+
+var SSHGatewayExposureList = map[SSHGatewayExposure]struct{}{
+	SSHGatewayExposureProxy:        {},
+	SSHGatewayExposureLoadBalancer: {},
+	SSHGatewayExposureHostPort:     {},
+}
+
 // LoadValidationFuncs load custom validation functions for this version of the config API
 func (v version) LoadValidationFuncs(validate *validator.Validate) error {
 	funcs := map[string]validator.Func{
@@ -56,6 +71,14 @@ func (v version) LoadValidationFuncs(validate *validator.Validate) error {
 			_, ok := FSShiftMethodList[FSShiftMethod(fl.Field().String())]
 			return ok
 		},
+		"cgroup_version": func(fl validator.FieldLevel) bool {
+			_, ok := CGroupVersionList[CGroupVersion(fl.Field().String())]
+			return ok
+		},
+		"ssh_gateway_exposure": func(fl validator.FieldLevel) bool {
+			_, ok := SSHGatewayExposureList[SSHGatewayExposure(fl.Field().String())]
+			return ok
+		},
 		"installation_kind": func(fl validator.FieldLevel) bool {
 			_, ok := InstallationKindList[InstallationKind(fl.Field().String())]
 			return ok
@@ -64,6 +87,16 @@ func (v version) LoadValidationFuncs(validate *validator.Validate) error {
 			_, ok := LogLevelList[LogLevel(fl.Field().String())]
 			return ok
 		},
+		"namespace_name": func(fl validator.FieldLevel) bool {
+			return len(validation.IsDNS1123Label(fl.Field().String())) == 0
+		},
+		"duration_min": func(fl validator.FieldLevel) bool {
+			min, err := time.ParseDuration(fl.Param())
+			if err != nil {
+				return false
+			}
+			return time.Duration(fl.Field().Int()) >= min
+		},
 		"block_new_users_passlist": func(fl validator.FieldLevel) bool {
 			if !fl.Parent().FieldByName("Enabled").Bool() {
 				// Not enabled - it's valid
@@ -109,9 +142,27 @@ func (v version) LoadValidationFuncs(validate *validator.Validate) error {
 		}
 	}
 
+	validate.RegisterStructValidation(validateInternalCertificates, InternalCertificates{})
+
 	return nil
 }
 
+// validateInternalCertificates ensures that certificates are renewed before they expire
+func validateInternalCertificates(sl validator.StructLevel) {
+	cfg := sl.Current().Interface().(InternalCertificates)
+	if cfg.RenewBefore == nil {
+		return
+	}
+
+	duration := config.InternalCertDuration
+	if cfg.Duration != nil {
+		duration = time.Duration(*cfg.Duration)
+	}
+	if renewBefore := time.Duration(*cfg.RenewBefore); renewBefore <= 0 || renewBefore >= duration {
+		sl.ReportError(cfg.RenewBefore, "RenewBefore", "RenewBefore", "internal_cert_renew_before", duration.String())
+	}
+}
+
 // ClusterValidation introduces configuration specific cluster validation checks
 func (v version) ClusterValidation(rcfg interface{}) cluster.ValidationChecks {
 	cfg := rcfg.(*Config)
@@ -135,6 +186,11 @@ func (v version) ClusterValidation(rcfg interface{}) cluster.ValidationChecks {
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData("accessKeyId", "secretAccessKey")))
 	}
 
+	if cfg.ObjectStorage.Azure != nil {
+		secretName := cfg.ObjectStorage.Azure.Credentials.Name
+		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData("accountKey")))
+	}
+
 	if cfg.ContainerRegistry.External != nil {
 		secretName := cfg.ContainerRegistry.External.Certificate.Name
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData(".dockerconfigjson")))
@@ -204,8 +260,8 @@ func (v version) ClusterValidation(rcfg interface{}) cluster.ValidationChecks {
 		}
 	}
 
-	if cfg.SSHGatewayHostKey != nil {
-		secretName := cfg.SSHGatewayHostKey.Name
+	if hostKey := cfg.SSHGatewayHostKeySecret(); hostKey != nil {
+		secretName := hostKey.Name
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRule(func(s *corev1.Secret) ([]cluster.ValidationError, error) {
 			var signers []ssh.Signer
 			errors := make([]cluster.ValidationError, 0)
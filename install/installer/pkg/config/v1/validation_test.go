@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License.AGPL.txt in the project root for license information.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/installer/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateInternalCertificates(t *testing.T) {
+	duration := func(d time.Duration) *util.Duration {
+		res := util.Duration(d)
+		return &res
+	}
+
+	tests := []struct {
+		Name        string
+		Config      *InternalCertificates
+		Expectation string
+	}{
+		{
+			Name:   "no rotation policy",
+			Config: nil,
+		},
+		{
+			Name: "valid rotation policy",
+			Config: &InternalCertificates{
+				Duration:    duration(30 * 24 * time.Hour),
+				RenewBefore: duration(10 * 24 * time.Hour),
+			},
+		},
+		{
+			Name: "renewBefore without duration",
+			Config: &InternalCertificates{
+				RenewBefore: duration(10 * 24 * time.Hour),
+			},
+		},
+		{
+			Name: "duration too short",
+			Config: &InternalCertificates{
+				Duration: duration(time.Minute),
+			},
+			Expectation: "Field 'Config.InternalCertificates.Duration' must be at least '1h'",
+		},
+		{
+			Name: "renewBefore exceeds duration",
+			Config: &InternalCertificates{
+				Duration:    duration(24 * time.Hour),
+				RenewBefore: duration(48 * time.Hour),
+			},
+			Expectation: "Field 'Config.InternalCertificates.RenewBefore' must be positive and shorter than the certificate duration '24h0m0s'",
+		},
+		{
+			Name: "renewBefore exceeds default duration",
+			Config: &InternalCertificates{
+				RenewBefore: duration(100 * 24 * time.Hour),
+			},
+			Expectation: "Field 'Config.InternalCertificates.RenewBefore' must be positive and shorter than the certificate duration '2160h0m0s'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := version{}.Factory().(*Config)
+			require.NoError(t, version{}.Defaults(cfg))
+			cfg.Domain = "gitpod.example.com"
+			cfg.InternalCertificates = test.Config
+
+			res, err := config.Validate(version{}, cfg)
+			require.NoError(t, err)
+
+			if test.Expectation == "" {
+				for _, f := range res.Fatal {
+					require.NotContains(t, f, "InternalCertificates")
+				}
+				return
+			}
+			require.Contains(t, res.Fatal, test.Expectation)
+		})
+	}
+}
+
+func TestValidateCGroupVersion(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Version     CGroupVersion
+		Expectation string
+	}{
+		{Name: "unset", Version: ""},
+		{Name: "cgroup v2", Version: CGroupV2},
+		{
+			Name:        "cgroup v1",
+			Version:     CGroupV1,
+			Expectation: "Field 'Config.Workspace.Runtime.CGroupVersion' must be 'v2' - ws-daemon does not support nodes running cgroup v1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := version{}.Factory().(*Config)
+			require.NoError(t, version{}.Defaults(cfg))
+			cfg.Domain = "gitpod.example.com"
+			cfg.Workspace.Runtime.CGroupVersion = test.Version
+
+			res, err := config.Validate(version{}, cfg)
+			require.NoError(t, err)
+
+			if test.Expectation == "" {
+				for _, f := range res.Fatal {
+					require.NotContains(t, f, "CGroupVersion")
+				}
+				return
+			}
+			require.Contains(t, res.Fatal, test.Expectation)
+		})
+	}
+}
+
+func TestValidateWorkspaceNamespace(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Namespace string
+		Valid     bool
+	}{
+		{Name: "unset", Namespace: "", Valid: true},
+		{Name: "valid", Namespace: "gitpod-workspaces", Valid: true},
+		{Name: "uppercase", Namespace: "Workspaces"},
+		{Name: "dots", Namespace: "gitpod.workspaces"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := version{}.Factory().(*Config)
+			require.NoError(t, version{}.Defaults(cfg))
+			cfg.Domain = "gitpod.example.com"
+			cfg.WorkspaceNamespace = test.Namespace
+
+			res, err := config.Validate(version{}, cfg)
+			require.NoError(t, err)
+
+			if test.Valid {
+				for _, f := range res.Fatal {
+					require.NotContains(t, f, "WorkspaceNamespace")
+				}
+				return
+			}
+			require.Contains(t, res.Fatal, "Field 'Config.WorkspaceNamespace' failed namespace_name validation")
+		})
+	}
+}
@@ -55,6 +55,14 @@ func Validate(version ConfigVersion, cfg interface{}) (r *ValidationResult, err
 					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' is %s '%s'", v.Namespace(), tag, v.Param()))
 				case "startswith":
 					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' must start with '%s'", v.Namespace(), v.Param()))
+				case "cgroup_version":
+					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' must be 'v2' - ws-daemon does not support nodes running cgroup v1", v.Namespace()))
+				case "excluded_with":
+					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' cannot be used together with '%s'", v.Namespace(), v.Param()))
+				case "duration_min":
+					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' must be at least '%s'", v.Namespace(), v.Param()))
+				case "internal_cert_renew_before":
+					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' must be positive and shorter than the certificate duration '%s'", v.Namespace(), v.Param()))
 				case "block_new_users_passlist":
 					res.Fatal = append(res.Fatal, fmt.Sprintf("Field '%s' failed. If 'Enabled = true', there must be at least one fully-qualified domain name in the passlist", v.Namespace()))
 				default:
@@ -4,6 +4,13 @@
 
 package config
 
+import "time"
+
 var (
 	GitpodContainerRegistry = "eu.gcr.io/gitpod-dev-artifact/build"
 )
+
+const (
+	// InternalCertDuration is the default lifetime of certificates issued by the internal CA
+	InternalCertDuration = 90 * 24 * time.Hour
+)